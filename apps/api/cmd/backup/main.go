@@ -0,0 +1,76 @@
+// Command backup backs up or restores a user's boards, shapes, chats and assets without going through the API
+//
+//	go run ./cmd/backup -user <uuid>                    create a backup
+//	go run ./cmd/backup -user <uuid> -list              list backups, newest first
+//	go run ./cmd/backup -user <uuid> -restore <backup>  restore a backup
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log"
+
+	"melina-studio-backend/internal/config"
+	"melina-studio-backend/internal/libraries"
+	"melina-studio-backend/internal/repo"
+	"melina-studio-backend/internal/service"
+
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	userFlag := flag.String("user", "", "UUID of the user to back up or restore")
+	list := flag.Bool("list", false, "list the user's backups")
+	restore := flag.String("restore", "", "ID of the backup to restore")
+	flag.Parse()
+
+	userID, err := uuid.Parse(*userFlag)
+	if err != nil {
+		log.Fatal("-user must be a valid UUID")
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("Warning: .env file not found")
+	}
+	if _, err := config.LoadSettings(); err != nil {
+		log.Fatal(err)
+	}
+	if err := config.ConnectDB(); err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	defer config.CloseDB()
+
+	ctx := context.Background()
+	gcsClient, err := libraries.NewClients(ctx)
+	if err != nil {
+		log.Fatal("Failed to init gcp clients:", err)
+	}
+	defer gcsClient.Close()
+
+	backupService := service.NewBackupService(config.LoadBackupConfig(), repo.NewBackupRepository(config.DB), gcsClient)
+
+	switch {
+	case *list:
+		ids, err := backupService.List(ctx, userID)
+		if err != nil {
+			log.Fatal("Failed to list backups:", err)
+		}
+		for _, id := range ids {
+			fmt.Println(id)
+		}
+	case *restore != "":
+		info, err := backupService.Restore(ctx, userID, *restore)
+		if err != nil {
+			log.Fatal("Failed to restore backup:", err)
+		}
+		fmt.Printf("Restored %s: %d boards, %d shapes, %d chats, %d assets\n", info.ID, info.Boards, info.Shapes, info.Chats, info.Assets)
+	default:
+		info, err := backupService.Backup(ctx, userID)
+		if err != nil {
+			log.Fatal("Failed to create backup:", err)
+		}
+		fmt.Printf("Stored backup %s at %s: %d boards, %d shapes, %d chats, %d assets\n", info.ID, info.Location, info.Boards, info.Shapes, info.Chats, info.Assets)
+	}
+}
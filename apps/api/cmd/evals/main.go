@@ -0,0 +1,127 @@
+// Command evals replays the golden-board suites against the agent and reports the cases that fail,
+// and the regressions against a previous report. Run it before changing MASTER_PROMPT or the tool schemas
+//
+//	go run ./cmd/evals -user <uuid>                                  run every suite with the default model
+//	go run ./cmd/evals -user <uuid> -each-provider -out report.json  run with one model per provider and save the report
+//	go run ./cmd/evals -user <uuid> -baseline report.json            fail on cases that passed in the saved report
+//
+// The eval boards are created for the given user and deleted after each case
+package main
+
+import (
+	"context"
+	"flag"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"melina-studio-backend/internal/config"
+	llmHandlers "melina-studio-backend/internal/llm_handlers"
+	"melina-studio-backend/internal/melina/evals"
+	"melina-studio-backend/internal/melina/tools"
+	"melina-studio-backend/internal/repo"
+
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	userFlag := flag.String("user", "", "UUID of the user that owns the eval boards")
+	suitesDir := flag.String("suites", "evals", "directory of the suite files")
+	modelsFlag := flag.String("models", "", "comma-separated models to run (default: DEFAULT_MODEL)")
+	eachProvider := flag.Bool("each-provider", false, "run with one model of each provider")
+	caseFilter := flag.String("case", "", "only run cases whose suite/name contains this")
+	baseline := flag.String("baseline", "", "report to compare against; cases that passed there and fail now are regressions")
+	out := flag.String("out", "", "file to save the report to")
+	timeout := flag.Duration("timeout", 2*time.Minute, "time limit of each case")
+	flag.Parse()
+
+	userID, err := uuid.Parse(*userFlag)
+	if err != nil {
+		log.Fatal("-user must be a valid UUID")
+	}
+
+	suites, err := evals.LoadSuites(*suitesDir)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if *caseFilter != "" {
+		suites = filterCases(suites, *caseFilter)
+	}
+
+	var modelNames []string
+	switch {
+	case *eachProvider:
+		modelNames = evals.ProviderModels()
+	case *modelsFlag != "":
+		for _, name := range strings.Split(*modelsFlag, ",") {
+			if name = strings.TrimSpace(name); name != "" {
+				modelNames = append(modelNames, name)
+			}
+		}
+	}
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("Warning: .env file not found")
+	}
+	if _, err := config.LoadSettings(); err != nil {
+		log.Fatal(err)
+	}
+	if len(modelNames) == 0 {
+		modelNames = []string{llmHandlers.DefaultModel()}
+	}
+	if err := config.ConnectDB(); err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	defer config.CloseDB()
+	tools.RegisterTools(tools.NewToolDeps(config.DB))
+
+	runner := evals.NewRunner(userID, repo.NewBoardRepository(config.DB), repo.NewBoardDataRepository(config.DB), *timeout)
+	report := runner.Run(context.Background(), suites, modelNames)
+	report.Print(os.Stdout)
+
+	if *out != "" {
+		if err := report.Save(*out); err != nil {
+			log.Fatal("Failed to save report:", err)
+		}
+	}
+
+	// with a baseline only regressions fail the run, cases that already failed there are known issues
+	failed := len(report.Failed()) > 0
+	if *baseline != "" {
+		previous, err := evals.LoadReport(*baseline)
+		if err != nil {
+			log.Fatal("Failed to load baseline:", err)
+		}
+		regressions := evals.Regressions(previous, report)
+		for _, r := range regressions {
+			log.Printf("REGRESSION %s/%s [%s]", r.Suite, r.Case, r.Model)
+		}
+		failed = len(regressions) > 0
+	}
+	if failed {
+		config.CloseDB()
+		os.Exit(1)
+	}
+}
+
+// filterCases keeps the cases whose "suite/name" contains filter
+func filterCases(suites []evals.Suite, filter string) []evals.Suite {
+	var kept []evals.Suite
+	for _, suite := range suites {
+		var cases []evals.Case
+		for _, c := range suite.Cases {
+			if strings.Contains(suite.Name+"/"+c.Name, filter) {
+				cases = append(cases, c)
+			}
+		}
+		if len(cases) > 0 {
+			kept = append(kept, evals.Suite{Name: suite.Name, Cases: cases})
+		}
+	}
+	if len(kept) == 0 {
+		log.Fatalf("No cases match %q", filter)
+	}
+	return kept
+}
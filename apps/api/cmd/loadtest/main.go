@@ -0,0 +1,153 @@
+// Command loadtest simulates concurrent board sessions against a running API and reports the latency
+// percentiles of pings, chat turns and shape saves, and the messages the WebSocket hub delivered.
+// Run it against staging with LLM_FAKE_MODEL=true, so the chat turns stream canned replies instead of
+// reaching a provider
+//
+//	go run ./cmd/loadtest -url https://staging.example.com -users <uuid>,<uuid> -clients 200 -duration 10m
+//	go run ./cmd/loadtest -url http://localhost:8000 -tokens tokens.txt -chat-every 5s -out report.json
+//
+// With -users the access tokens are signed with the JWT_SECRET of .env, which must match the target's,
+// and are renewed when the server asks; tokens from -tokens are used as they are, one per line
+package main
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"log"
+	"os"
+	"os/signal"
+	"strings"
+	"time"
+
+	"melina-studio-backend/internal/auth"
+	"melina-studio-backend/internal/loadtest"
+
+	"github.com/google/uuid"
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	baseURL := flag.String("url", "http://localhost:8000", "address of the API to load")
+	clients := flag.Int("clients", 50, "number of concurrent board sessions")
+	duration := flag.Duration("duration", 5*time.Minute, "how long each session sends traffic")
+	rampUp := flag.Duration("ramp", 30*time.Second, "spread the sessions' start over this long")
+	chatEvery := flag.Duration("chat-every", 20*time.Second, "time between a session's chat turns (0 to turn off)")
+	saveEvery := flag.Duration("save-every", 5*time.Second, "time between a session's shape saves (0 to turn off)")
+	pingEvery := flag.Duration("ping-every", time.Second, "time between a session's pings (0 to turn off)")
+	model := flag.String("model", "fake", "model the chat turns ask for")
+	shapes := flag.Int("shapes", 20, "shapes sent by each save")
+	usersFlag := flag.String("users", "", "comma-separated user UUIDs, or a file of them, to sign tokens for")
+	tenant := flag.String("tenant", "", "tenant of the users")
+	tokensFile := flag.String("tokens", "", "file of access tokens, one per line")
+	keepBoards := flag.Bool("keep-boards", false, "leave the sessions' sandbox boards in place")
+	out := flag.String("out", "", "file to save the report to")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("Warning: .env file not found")
+	}
+
+	tokenSource, err := newTokenSource(*usersFlag, *tenant, *tokensFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	log.Printf("Running %d sessions against %s for %s", *clients, *baseURL, *duration)
+	report, err := loadtest.Run(ctx, loadtest.Options{
+		BaseURL:    *baseURL,
+		Clients:    *clients,
+		Duration:   *duration,
+		RampUp:     *rampUp,
+		ChatEvery:  *chatEvery,
+		SaveEvery:  *saveEvery,
+		PingEvery:  *pingEvery,
+		Model:      *model,
+		Shapes:     *shapes,
+		KeepBoards: *keepBoards,
+		Token:      tokenSource,
+	})
+	if err != nil {
+		log.Fatal(err)
+	}
+	report.Print(os.Stdout)
+
+	if *out != "" {
+		data, err := json.MarshalIndent(report, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := os.WriteFile(*out, data, 0o644); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	for _, metric := range report.Metrics {
+		if metric.Errors > 0 {
+			os.Exit(1)
+		}
+	}
+}
+
+// newTokenSource returns the access token of each session: tokens signed for the users in turn, or the
+// listed tokens in turn
+func newTokenSource(usersFlag string, tenantID string, tokensFile string) (func(i int) (string, error), error) {
+	switch {
+	case usersFlag != "" && tokensFile != "":
+		return nil, errors.New("use one of -users and -tokens")
+	case tokensFile != "":
+		tokens, err := readLines(tokensFile)
+		if err != nil {
+			return nil, err
+		}
+		if len(tokens) == 0 {
+			return nil, errors.New("the -tokens file is empty")
+		}
+		return func(i int) (string, error) {
+			return tokens[i%len(tokens)], nil
+		}, nil
+	case usersFlag != "":
+		var userIDs []string
+		if _, err := os.Stat(usersFlag); err == nil {
+			if userIDs, err = readLines(usersFlag); err != nil {
+				return nil, err
+			}
+		} else {
+			userIDs = strings.Split(usersFlag, ",")
+		}
+		for i, id := range userIDs {
+			parsed, err := uuid.Parse(strings.TrimSpace(id))
+			if err != nil {
+				return nil, errors.New("-users must list valid UUIDs")
+			}
+			userIDs[i] = parsed.String()
+		}
+		return func(i int) (string, error) {
+			return auth.GenerateAccessToken(userIDs[i%len(userIDs)], tenantID)
+		}, nil
+	}
+	return nil, errors.New("-users or -tokens is required")
+}
+
+// readLines returns the non-empty lines of a file
+func readLines(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var lines []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines, scanner.Err()
+}
@@ -8,8 +8,11 @@ import (
 
 	"melina-studio-backend/internal/api"
 	"melina-studio-backend/internal/api/routes"
+	"melina-studio-backend/internal/auth"
 	"melina-studio-backend/internal/config"
 	"melina-studio-backend/internal/libraries"
+	"melina-studio-backend/internal/melina/tools"
+	"melina-studio-backend/internal/plugins"
 	"melina-studio-backend/internal/repo"
 	"melina-studio-backend/internal/service"
 
@@ -22,6 +25,12 @@ func main() {
 		log.Println("Warning: .env file not found")
 	}
 
+	// Load and validate settings, reporting every missing key at once
+	if _, err := config.LoadSettings(); err != nil {
+		log.Fatal(err)
+	}
+	stopSecretRotation := config.StartSecretRotation()
+
 	// Connect to database
 	if err := config.ConnectDB(); err != nil {
 		log.Fatal("Failed to connect to database:", err)
@@ -32,18 +41,60 @@ func main() {
 		log.Fatal("Failed to migrate database:", err)
 	}
 
+	// Tool handlers run against the database the server connected to
+	tools.RegisterTools(tools.NewToolDeps(config.DB))
+
+	// Start the plugins and give the agent their tools (their routes are registered with the others)
+	pluginHost := plugins.InitHost(config.LoadPluginConfig(), repo.NewBoardDataRepository(config.DB))
+	if err := pluginHost.Load(); err != nil {
+		log.Printf("Failed to load plugins: %v", err)
+	}
+
+	// Load the config-defined HTTP tools and keep them in sync with the database (the admin routes edit them)
+	customToolService := service.InitCustomToolService(config.LoadCustomToolConfig(), repo.NewCustomToolRepository(config.DB))
+	customToolService.Start()
+
+	// Share revoked access tokens between instances
+	if err := auth.InitTokenDenylist(config.GetSettings().Redis.URL); err != nil {
+		log.Fatal("Failed to set up the token denylist:", err)
+	}
+
 	// Create and configure Fiber app (also initializes GCS clients)
 	app := api.NewServer()
 
-	// Register routes
-	routes.Register(app)
+	// Decide the privacy mode of LLM requests and audit what each provider receives
+	service.InitPrivacyService(repo.NewLLMDataAuditRepository(config.DB), repo.NewTenantRepository(config.DB), repo.NewAuthRepository(config.DB), config.GetSettings().LLM.PrivacyMode)
+
+	// Initialize and start the background job queue (must exist before routes are registered)
+	jobQueue := service.InitJobQueue(config.LoadJobQueueConfig())
+	jobQueue.Start()
 
-	// Initialize and start cleanup service
+	// Initialize and start cleanup service (must exist before routes are registered, the admin routes configure it)
 	cleanupConfig := config.LoadCleanupConfig()
 	tempUploadRepo := repo.NewTempUploadRepository(config.DB)
-	cleanupService := service.NewCleanupService(cleanupConfig, tempUploadRepo, libraries.GetClients())
+	retentionService := service.NewRetentionService(repo.NewRetentionRepository(config.DB))
+	contentStore := service.NewContentStore(repo.NewStoredObjectRepository(config.DB), libraries.GetClients())
+	storageQuotaService := service.NewStorageQuotaService(config.LoadStorageQuotaConfig(), repo.NewStorageUsageRepository(config.DB), repo.NewAuthRepository(config.DB))
+	cleanupService := service.InitCleanupService(cleanupConfig, repo.NewCleanupRepository(config.DB), tempUploadRepo, repo.NewBoardRepository(config.DB), libraries.GetClients(), contentStore, storageQuotaService, retentionService)
 	cleanupService.Start()
 
+	// Initialize and start scheduled board exports (must exist before routes are registered, the export routes manage it)
+	exportService := service.NewBoardExportService(config.LoadExportConfig(), repo.NewAuthRepository(config.DB))
+	exportScheduleService := service.InitExportScheduleService(config.LoadExportScheduleConfig(), repo.NewExportScheduleRepository(config.DB),
+		repo.NewBoardRepository(config.DB), repo.NewBoardDataRepository(config.DB), exportService, jobQueue)
+	exportScheduleService.Start()
+
+	// Initialize and start periodic board snapshots (must exist before routes are registered, the version routes use it)
+	boardVersionService := service.InitBoardVersionService(config.LoadBoardVersionConfig(), repo.NewBoardVersionRepository(config.DB), repo.NewBoardDataRepository(config.DB))
+	boardVersionService.Start()
+
+	// Register routes
+	routes.Register(app)
+
+	// Initialize and start scheduled tenant backups
+	backupService := service.NewBackupService(config.LoadBackupConfig(), repo.NewBackupRepository(config.DB), libraries.GetClients())
+	backupService.Start()
+
 	// Setup graceful shutdown
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
@@ -55,6 +106,27 @@ func main() {
 		// Stop cleanup service
 		cleanupService.Stop()
 
+		// Stop backup service
+		backupService.Stop()
+
+		// Stop queueing scheduled exports
+		exportScheduleService.Stop()
+
+		// Stop taking periodic board snapshots
+		boardVersionService.Stop()
+
+		// Stop reloading custom tools
+		customToolService.Stop()
+
+		// Stop the plugins
+		pluginHost.Stop()
+
+		// Stop secret rotation
+		stopSecretRotation()
+
+		// Stop job queue
+		jobQueue.Stop()
+
 		// Shutdown Fiber app
 		if err := app.Shutdown(); err != nil {
 			log.Printf("Error shutting down server: %v", err)
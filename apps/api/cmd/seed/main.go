@@ -0,0 +1,81 @@
+// Command seed fills a development database with demo users, their boards, shapes and chat history, and
+// the onboarding sample board, so a fresh environment is usable at once and the e2e tests have fixtures
+//
+//	go run ./cmd/seed                        seed the demo users that don't exist yet
+//	go run ./cmd/seed -migrate               create the tables first, on an empty database
+//	go run ./cmd/seed -out e2e/fixtures.json also save the users and board IDs for the e2e tests
+//
+// Every demo user signs in with the password melina-demo. It refuses to run when GO_ENV is production
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"melina-studio-backend/internal/config"
+	"melina-studio-backend/internal/repo"
+	"melina-studio-backend/internal/service"
+
+	"github.com/joho/godotenv"
+)
+
+func main() {
+	migrate := flag.Bool("migrate", false, "create or update the tables before seeding")
+	out := flag.String("out", "", "file to save the seeded users and boards to")
+	flag.Parse()
+
+	if err := godotenv.Load(); err != nil {
+		log.Println("Warning: .env file not found")
+	}
+	settings, err := config.LoadSettings()
+	if err != nil {
+		log.Fatal(err)
+	}
+	if settings.IsProduction() {
+		log.Fatal("Refusing to seed demo users into a production database")
+	}
+	if err := config.ConnectDB(); err != nil {
+		log.Fatal("Failed to connect to database:", err)
+	}
+	defer config.CloseDB()
+	if err := config.MigrateAllModels(*migrate); err != nil {
+		log.Fatal("Failed to migrate database:", err)
+	}
+
+	boardRepo := repo.NewBoardRepository(config.DB)
+	boardDataRepo := repo.NewBoardDataRepository(config.DB)
+	chatRepo := repo.NewChatRepository(config.DB)
+	// no job queue: the sample board is provisioned before Seed returns
+	onboarding := service.NewOnboardingService(repo.NewOnboardingRepository(config.DB), boardRepo, boardDataRepo, chatRepo, nil)
+	seedService := service.NewSeedService(repo.NewAuthRepository(config.DB), boardRepo, boardDataRepo, chatRepo, onboarding)
+
+	users, err := seedService.Seed(context.Background())
+	for _, user := range users {
+		if user.Existing {
+			fmt.Printf("%-22s %s  already exists, left as it is\n", user.Email, user.UserID)
+			continue
+		}
+		fmt.Printf("%-22s %s  %s plan, %d boards\n", user.Email, user.UserID, user.Subscription, len(user.Boards))
+		for _, board := range user.Boards {
+			fmt.Printf("    %s  %s (%d shapes, %d messages)\n", board.BoardID, board.Title, board.Shapes, board.Messages)
+		}
+	}
+	if err != nil {
+		log.Fatal("Failed to seed:", err)
+	}
+	fmt.Printf("\nSign in with any of them using the password %s\n", service.SeedPassword)
+
+	if *out != "" {
+		data, err := json.MarshalIndent(users, "", "  ")
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := os.WriteFile(*out, data, 0o644); err != nil {
+			log.Fatal(err)
+		}
+	}
+}
@@ -0,0 +1,58 @@
+// Command wordcount is an example plugin: it gives the agent a countWords tool that reads the board's text
+// and serves POST /api/v1/plugins/wordcount/count. Build it next to its manifest and point PLUGINS_DIR
+// at examples/plugins:
+//
+//	go build -o examples/plugins/wordcount/wordcount ./examples/plugins/wordcount
+package main
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"net/http"
+	"strings"
+
+	"melina-studio-backend/pkg/pluginsdk"
+)
+
+type wordCount struct{}
+
+func (wordCount) Describe() pluginsdk.Description {
+	return pluginsdk.Description{
+		Name:    "wordcount",
+		Version: "0.1.0",
+		Tools: []pluginsdk.ToolSpec{{
+			Name:        "countWords",
+			Description: "Count the words in the text shapes of the board, in total and per shape.",
+			Parameters:  map[string]interface{}{"type": "object", "properties": map[string]interface{}{}},
+		}},
+		Routes: []pluginsdk.RouteSpec{{Method: "POST", Path: "/count", Description: "Count the words of the request body"}},
+	}
+}
+
+func (wordCount) CallTool(ctx context.Context, call pluginsdk.ToolCall) (interface{}, error) {
+	if call.Tool != "countWords" {
+		return nil, fmt.Errorf("unknown tool %s", call.Tool)
+	}
+	total := 0
+	perShape := map[string]int{}
+	for _, shape := range call.Shapes {
+		text, _ := shape["text"].(string)
+		if n := len(strings.Fields(text)); n > 0 {
+			id, _ := shape["id"].(string)
+			perShape[id] = n
+			total += n
+		}
+	}
+	return map[string]interface{}{"total": total, "shapes": perShape}, nil
+}
+
+func (wordCount) HandleRoute(ctx context.Context, req pluginsdk.RouteRequest) (*pluginsdk.RouteResponse, error) {
+	return pluginsdk.JSONResponse(http.StatusOK, map[string]int{"words": len(strings.Fields(string(req.Body)))})
+}
+
+func main() {
+	if err := pluginsdk.Serve(wordCount{}); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,58 @@
+package api
+
+import (
+	"bytes"
+	"compress/gzip"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// gzipLargeResponses gzips response bodies of at least threshold bytes for clients that accept it
+// Shape lists and exports are large and compress well; small responses aren't worth the CPU
+func gzipLargeResponses(threshold int) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if err := c.Next(); err != nil {
+			return err
+		}
+		if threshold <= 0 || !strings.Contains(c.Get(fiber.HeaderAcceptEncoding), "gzip") {
+			return nil
+		}
+
+		resp := c.Response()
+		// streamed bodies (SSE) and bodies that are already encoded are left alone
+		if resp.IsBodyStream() || len(resp.Header.Peek(fiber.HeaderContentEncoding)) > 0 {
+			return nil
+		}
+		body := resp.Body()
+		if len(body) < threshold || !compressibleContentType(string(resp.Header.ContentType())) {
+			return nil
+		}
+
+		var buf bytes.Buffer
+		gz, err := gzip.NewWriterLevel(&buf, gzip.BestSpeed)
+		if err != nil {
+			return nil
+		}
+		if _, err := gz.Write(body); err != nil {
+			return nil
+		}
+		if err := gz.Close(); err != nil {
+			return nil
+		}
+
+		resp.SetBodyRaw(buf.Bytes())
+		resp.Header.Set(fiber.HeaderContentEncoding, "gzip")
+		resp.Header.Add(fiber.HeaderVary, fiber.HeaderAcceptEncoding)
+		return nil
+	}
+}
+
+// compressibleContentType reports whether a content type is text-like; images and archives are compressed already
+func compressibleContentType(contentType string) bool {
+	contentType = strings.ToLower(contentType)
+	return strings.HasPrefix(contentType, "text/") ||
+		strings.Contains(contentType, "json") ||
+		strings.Contains(contentType, "javascript") ||
+		strings.Contains(contentType, "xml")
+}
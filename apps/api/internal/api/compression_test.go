@@ -0,0 +1,64 @@
+package api
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestGzipLargeResponses(t *testing.T) {
+	app := fiber.New()
+	app.Use(gzipLargeResponses(100))
+	large := strings.Repeat(`{"type":"rect","x":10,"y":20},`, 20)
+	app.Get("/large", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, fiber.MIMEApplicationJSON)
+		return c.SendString(large)
+	})
+	app.Get("/small", func(c *fiber.Ctx) error {
+		return c.JSON(fiber.Map{"ok": true})
+	})
+	app.Get("/image", func(c *fiber.Ctx) error {
+		c.Set(fiber.HeaderContentType, "image/png")
+		return c.SendString(large)
+	})
+
+	get := func(path string, acceptGzip bool) (string, string) {
+		req := httptest.NewRequest("GET", path, nil)
+		if acceptGzip {
+			req.Header.Set(fiber.HeaderAcceptEncoding, "gzip, deflate")
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatalf("%s: %v", path, err)
+		}
+		defer resp.Body.Close()
+		encoding := resp.Header.Get(fiber.HeaderContentEncoding)
+		var body io.Reader = resp.Body
+		if encoding == "gzip" {
+			gz, err := gzip.NewReader(resp.Body)
+			if err != nil {
+				t.Fatalf("%s: %v", path, err)
+			}
+			body = gz
+		}
+		data, _ := io.ReadAll(body)
+		return encoding, string(data)
+	}
+
+	if encoding, body := get("/large", true); encoding != "gzip" || body != large {
+		t.Fatalf("large JSON should be gzipped, got encoding %q", encoding)
+	}
+	if encoding, _ := get("/large", false); encoding != "" {
+		t.Fatalf("clients that don't accept gzip get plain bodies, got %q", encoding)
+	}
+	if encoding, _ := get("/small", true); encoding != "" {
+		t.Fatalf("small responses stay plain, got %q", encoding)
+	}
+	if encoding, _ := get("/image", true); encoding != "" {
+		t.Fatalf("images stay plain, got %q", encoding)
+	}
+}
@@ -0,0 +1,14 @@
+package v1
+
+import (
+	"melina-studio-backend/internal/handlers"
+	"melina-studio-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func registerActionCatalog(r fiber.Router) {
+	actionCatalogHandler := handlers.NewActionCatalogHandler(service.NewActionCatalogService())
+
+	r.Get("/actions", actionCatalogHandler.ListActions)
+}
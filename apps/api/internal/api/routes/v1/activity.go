@@ -0,0 +1,20 @@
+package v1
+
+import (
+	"melina-studio-backend/internal/config"
+	"melina-studio-backend/internal/handlers"
+	"melina-studio-backend/internal/repo"
+	"melina-studio-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func registerActivity(r fiber.Router) {
+	boardRepo := repo.NewBoardRepository(config.DB)
+	activityRepo := repo.NewBoardActivityRepository(config.DB)
+	heatmapService := service.NewActivityHeatmapService(activityRepo, repo.NewBoardDataRepository(config.DB))
+	activityHandler := handlers.NewActivityHandler(boardRepo, activityRepo, heatmapService)
+
+	r.Get("/boards/:boardId/activity", activityHandler.GetActivity)
+	r.Get("/boards/:boardId/activity/heatmap", activityHandler.GetHeatmap)
+}
@@ -0,0 +1,80 @@
+package v1
+
+import (
+	"melina-studio-backend/internal/auth"
+	"melina-studio-backend/internal/config"
+	"melina-studio-backend/internal/handlers"
+	"melina-studio-backend/internal/libraries"
+	"melina-studio-backend/internal/plugins"
+	"melina-studio-backend/internal/repo"
+	"melina-studio-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// registerAdmin registers ops-only routes, authenticated with the admin token instead of a user session
+func registerAdmin(r fiber.Router, tenantService *service.TenantService, flagService *service.FeatureFlagService, securityPolicyService *service.SecurityPolicyService) {
+	backupConfig := config.LoadBackupConfig()
+	backupService := service.NewBackupService(backupConfig, repo.NewBackupRepository(config.DB), libraries.GetClients())
+	backupHandler := handlers.NewBackupHandler(backupService)
+	tenantHandler := handlers.NewTenantHandler(tenantService)
+	flagHandler := handlers.NewFeatureFlagHandler(flagService)
+	budgetHandler := newBudgetHandler()
+	securityPolicyHandler := handlers.NewSecurityPolicyHandler(securityPolicyService)
+	announcementHandler := newAnnouncementHandler()
+	feedbackHandler := newFeedbackHandler()
+	replayHandler := handlers.NewTurnReplayHandler(service.NewTurnReplayService(
+		repo.NewTurnMetadataRepository(config.DB),
+		repo.NewChatRepository(config.DB),
+		repo.NewBoardRepository(config.DB),
+		repo.NewBoardDataRepository(config.DB),
+		config.LoadCleanupConfig().SandboxTTL,
+	))
+
+	cleanupHandler := handlers.NewCleanupHandler(service.GetCleanupService())
+	privacyHandler := handlers.NewPrivacyHandler(service.GetPrivacyService())
+	customToolHandler := handlers.NewCustomToolHandler(service.GetCustomToolService())
+	pluginHandler := handlers.NewPluginHandler(plugins.GetHost())
+
+	admin := r.Group("/admin", auth.AdminMiddleware(backupConfig.AdminToken))
+	admin.Post("/backups/:userId", backupHandler.CreateBackup)
+	admin.Get("/backups/:userId", backupHandler.ListBackups)
+	admin.Post("/backups/:userId/:backupId/restore", backupHandler.RestoreBackup)
+
+	admin.Get("/tenants", tenantHandler.ListTenants)
+	admin.Post("/tenants", tenantHandler.CreateTenant)
+	admin.Put("/tenants/:tenantId", tenantHandler.UpdateTenant)
+	admin.Get("/tenants/:tenantId/budget", budgetHandler.GetTenantBudget)
+	admin.Put("/tenants/:tenantId/budget", budgetHandler.SetTenantBudget)
+	admin.Delete("/tenants/:tenantId/budget", budgetHandler.DeleteTenantBudget)
+	admin.Get("/tenants/:tenantId/security-policy", securityPolicyHandler.GetTenantSecurityPolicy)
+	admin.Put("/tenants/:tenantId/security-policy", securityPolicyHandler.SetTenantSecurityPolicy)
+	admin.Delete("/tenants/:tenantId/security-policy", securityPolicyHandler.DeleteTenantSecurityPolicy)
+
+	admin.Get("/flags", flagHandler.ListFlags)
+	admin.Put("/flags/:key", flagHandler.UpsertFlag)
+	admin.Delete("/flags/:key", flagHandler.DeleteFlag)
+
+	admin.Get("/custom-tools", customToolHandler.ListCustomTools)
+	admin.Put("/custom-tools/:name", customToolHandler.SetCustomTool)
+	admin.Delete("/custom-tools/:name", customToolHandler.DeleteCustomTool)
+
+	admin.Get("/plugins", pluginHandler.ListPlugins)
+
+	admin.Get("/announcements", announcementHandler.ListAnnouncements)
+	admin.Post("/announcements", announcementHandler.CreateAnnouncement)
+	admin.Put("/announcements/:announcementId", announcementHandler.UpdateAnnouncement)
+	admin.Delete("/announcements/:announcementId", announcementHandler.DeleteAnnouncement)
+
+	admin.Get("/feedback", feedbackHandler.ListFeedback)
+	admin.Get("/feedback/summary", feedbackHandler.GetFeedbackSummary)
+
+	admin.Post("/turns/:messageId/reproduce", replayHandler.ReproduceTurn)
+
+	admin.Get("/privacy/report", privacyHandler.GetPrivacyReport)
+
+	admin.Get("/cleanup", cleanupHandler.GetCleanup)
+	admin.Put("/cleanup", cleanupHandler.UpdateCleanupSettings)
+	admin.Post("/cleanup/run", cleanupHandler.RunCleanup)
+	admin.Get("/cleanup/runs", cleanupHandler.ListCleanupRuns)
+}
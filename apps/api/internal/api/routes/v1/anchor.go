@@ -0,0 +1,20 @@
+package v1
+
+import (
+	"melina-studio-backend/internal/config"
+	"melina-studio-backend/internal/handlers"
+	"melina-studio-backend/internal/repo"
+	"melina-studio-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func registerAnchor(r fiber.Router) {
+	boardRepo := repo.NewBoardRepository(config.DB)
+	boardDataRepo := repo.NewBoardDataRepository(config.DB)
+	chatRepo := repo.NewChatRepository(config.DB)
+	anchorService := service.NewAnchorService(boardDataRepo, chatRepo)
+	anchorHandler := handlers.NewAnchorHandler(boardRepo, anchorService)
+
+	r.Post("/boards/:boardId/anchors", anchorHandler.CreateAnchor)
+}
@@ -0,0 +1,22 @@
+package v1
+
+import (
+	"melina-studio-backend/internal/config"
+	"melina-studio-backend/internal/handlers"
+	"melina-studio-backend/internal/repo"
+	"melina-studio-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func newAnnouncementHandler() *handlers.AnnouncementHandler {
+	announcementService := service.NewAnnouncementService(repo.NewAnnouncementRepository(config.DB), hub)
+	return handlers.NewAnnouncementHandler(announcementService)
+}
+
+func registerAnnouncements(r fiber.Router) {
+	announcementHandler := newAnnouncementHandler()
+
+	r.Get("/announcements", announcementHandler.GetMyAnnouncements)
+	r.Post("/announcements/read", announcementHandler.MarkRead)
+}
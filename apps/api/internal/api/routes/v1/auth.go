@@ -17,7 +17,7 @@ func registerAuthPublic(r fiber.Router) {
 	authService := service.NewAuthService(refreshTokenRepo)
 	geoService := service.NewGeolocationService()
 	customRulesRepo := repo.NewCustomRulesRepository(config.DB)
-	authHandler := handlers.NewAuthHandler(authRepo, authService, subscriptionPlanRepo, geoService, customRulesRepo)
+	authHandler := handlers.NewAuthHandler(authRepo, authService, subscriptionPlanRepo, geoService, customRulesRepo, nil, nil, newOnboardingService(), hub)
 
 	// Auth rate limiter for sensitive endpoints (10 requests per minute)
 	authLimiter := api.AuthRateLimiter()
@@ -43,11 +43,16 @@ func registerAuthProtected(r fiber.Router) {
 	authService := service.NewAuthService(refreshTokenRepo)
 	geoService := service.NewGeolocationService()
 	customRulesRepo := repo.NewCustomRulesRepository(config.DB)
-	authHandler := handlers.NewAuthHandler(authRepo, authService, subscriptionPlanRepo, geoService, customRulesRepo)
+	storageQuotaService := service.NewStorageQuotaService(config.LoadStorageQuotaConfig(), repo.NewStorageUsageRepository(config.DB), authRepo)
+	uploadValidator := service.NewUploadValidator(config.LoadUploadConfig(), authRepo)
+	authHandler := handlers.NewAuthHandler(authRepo, authService, subscriptionPlanRepo, geoService, customRulesRepo, storageQuotaService, uploadValidator, nil, hub)
+	storageHandler := handlers.NewStorageHandler(storageQuotaService)
 
 	// Protected auth routes (requires auth)
 	r.Get("/me", authHandler.GetMe)
-	r.Patch("/me/update", authHandler.UpdateMe)
+	r.Patch("/me", authHandler.UpdateMe)
+	r.Patch("/me/update", authHandler.UpdateMe) // kept for clients that still use the old path
+	r.Get("/me/storage", storageHandler.GetMyStorage)
 	r.Post("/logout-all", authHandler.LogoutAll)
 	r.Get("/sessions", authHandler.GetActiveSessions)
 	r.Delete("/sessions/:sessionId", authHandler.RevokeSession)
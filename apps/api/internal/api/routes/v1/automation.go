@@ -0,0 +1,20 @@
+package v1
+
+import (
+	"melina-studio-backend/internal/config"
+	"melina-studio-backend/internal/handlers"
+	"melina-studio-backend/internal/repo"
+	"melina-studio-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func registerAutomation(r fiber.Router, automationService *service.AutomationService) {
+	automationHandler := handlers.NewAutomationHandler(repo.NewBoardRepository(config.DB), automationService)
+
+	r.Get("/boards/:boardId/automations", automationHandler.ListAutomations)
+	r.Post("/boards/:boardId/automations", automationHandler.CreateAutomation)
+	r.Put("/boards/:boardId/automations/:ruleId", automationHandler.UpdateAutomation)
+	r.Delete("/boards/:boardId/automations/:ruleId", automationHandler.DeleteAutomation)
+	r.Get("/boards/:boardId/automations/:ruleId/runs", automationHandler.ListAutomationRuns)
+}
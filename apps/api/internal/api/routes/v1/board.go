@@ -4,27 +4,47 @@ import (
 	"melina-studio-backend/internal/config"
 	"melina-studio-backend/internal/handlers"
 	"melina-studio-backend/internal/repo"
+	"melina-studio-backend/internal/service"
 
 	"github.com/gofiber/fiber/v2"
 )
 
-func registerBoard(r fiber.Router) {
+func registerBoard(r fiber.Router, automationService *service.AutomationService) {
 	// Initialize handler
 	boardRepo := repo.NewBoardRepository(config.DB)
 	boardDataRepo := repo.NewBoardDataRepository(config.DB)
-	boardHandler := handlers.NewBoardHandler(boardRepo, boardDataRepo)
+	chatRepo := repo.NewChatRepository(config.DB)
+	anchorService := service.NewAnchorService(boardDataRepo, chatRepo)
+	activityService := service.NewActivityService(repo.NewBoardActivityRepository(config.DB), hub)
+	storageQuotaService := service.NewStorageQuotaService(config.LoadStorageQuotaConfig(), repo.NewStorageUsageRepository(config.DB), repo.NewAuthRepository(config.DB))
+	uploadValidator := service.NewUploadValidator(config.LoadUploadConfig(), repo.NewAuthRepository(config.DB))
+	boardHandler := handlers.NewBoardHandler(boardRepo, boardDataRepo, anchorService, activityService, storageQuotaService, uploadValidator, config.LoadCleanupConfig().SandboxTTL, automationService, hub)
 
 	// Register routes
 	r.Get("/boards", boardHandler.GetAllBoards)
 	r.Post("/boards", boardHandler.CreateBoard)
+	r.Post("/boards/sandbox", boardHandler.CreateSandboxBoard)
 	r.Get("/boards/:boardId", boardHandler.GetBoardByID)
+	r.Get("/boards/:boardId/action-items", boardHandler.GetActionItems)
+	r.Get("/boards/:boardId/placement", boardHandler.FindPlacement)
+	r.Get("/boards/:boardId/shapes/:shapeId", boardHandler.GetShape)
+	r.Post("/boards/:boardId/shapes/:shapeId/lock", boardHandler.LockShape)
+	r.Post("/boards/:boardId/shapes/:shapeId/unlock", boardHandler.UnlockShape)
+	r.Get("/boards/:boardId/presentation", boardHandler.GetPresentation)
+	r.Put("/boards/:boardId/presentation", boardHandler.SetPresentation)
 
 	r.Post("/boards/:boardId/save", boardHandler.SaveData)
+	r.Patch("/boards/:boardId/shapes/batch", boardHandler.BatchSaveShapes)
+	r.Post("/boards/:boardId/replace-text", boardHandler.ReplaceText)
 	r.Delete("/boards/:boardId/clear", boardHandler.ClearBoard)
 
 	r.Delete("/boards/:boardId/delete", boardHandler.DeleteBoardByID)
 	r.Put("/boards/:boardId/update", boardHandler.UpdateBoardByID)
 	r.Post("/boards/:boardId/duplicate", boardHandler.DuplicateBoard)
+	r.Post("/boards/:boardId/archive", boardHandler.ArchiveBoard)
+	r.Post("/boards/:boardId/unarchive", boardHandler.UnarchiveBoard)
+	r.Put("/boards/:boardId/cover", boardHandler.SetBoardCover)
+	r.Delete("/boards/:boardId/cover", boardHandler.DeleteBoardCover)
 
 	r.Post("/boards/:boardId/upload-selection-image", boardHandler.UploadSelectionImage)
 }
@@ -0,0 +1,25 @@
+package v1
+
+import (
+	"melina-studio-backend/internal/config"
+	"melina-studio-backend/internal/handlers"
+	"melina-studio-backend/internal/repo"
+	"melina-studio-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func registerBoardExport(r fiber.Router) {
+	exportService := service.NewBoardExportService(config.LoadExportConfig(), repo.NewAuthRepository(config.DB))
+	exportHandler := handlers.NewBoardExportHandler(exportService, repo.NewBoardRepository(config.DB))
+
+	r.Get("/boards/:boardId/export/png", exportHandler.ExportPNG)
+
+	scheduleHandler := handlers.NewExportScheduleHandler(repo.NewBoardRepository(config.DB), service.GetExportScheduleService())
+	r.Get("/boards/:boardId/export-schedules", scheduleHandler.ListExportSchedules)
+	r.Post("/boards/:boardId/export-schedules", scheduleHandler.CreateExportSchedule)
+	r.Put("/boards/:boardId/export-schedules/:scheduleId", scheduleHandler.UpdateExportSchedule)
+	r.Delete("/boards/:boardId/export-schedules/:scheduleId", scheduleHandler.DeleteExportSchedule)
+	r.Post("/boards/:boardId/export-schedules/:scheduleId/run", scheduleHandler.RunExportSchedule)
+	r.Get("/boards/:boardId/export-schedules/:scheduleId/runs", scheduleHandler.ListExportRuns)
+}
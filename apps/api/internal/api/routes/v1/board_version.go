@@ -0,0 +1,18 @@
+package v1
+
+import (
+	"melina-studio-backend/internal/config"
+	"melina-studio-backend/internal/handlers"
+	"melina-studio-backend/internal/repo"
+	"melina-studio-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func registerBoardVersions(r fiber.Router) {
+	versionHandler := handlers.NewBoardVersionHandler(repo.NewBoardRepository(config.DB), service.GetBoardVersionService(), hub)
+
+	r.Get("/boards/:boardId/versions", versionHandler.ListVersions)
+	r.Post("/boards/:boardId/versions", versionHandler.CreateVersion)
+	r.Post("/boards/:boardId/versions/:versionId/restore", versionHandler.RestoreVersion)
+}
@@ -0,0 +1,23 @@
+package v1
+
+import (
+	"melina-studio-backend/internal/config"
+	"melina-studio-backend/internal/handlers"
+	"melina-studio-backend/internal/repo"
+	"melina-studio-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func newBudgetHandler() *handlers.BudgetHandler {
+	budgetService := service.NewBudgetService(repo.NewBudgetRepository(config.DB), repo.NewAuthRepository(config.DB))
+	return handlers.NewBudgetHandler(budgetService, repo.NewBoardRepository(config.DB))
+}
+
+func registerBudget(r fiber.Router) {
+	budgetHandler := newBudgetHandler()
+
+	r.Get("/boards/:boardId/budget", budgetHandler.GetBoardBudget)
+	r.Put("/boards/:boardId/budget", budgetHandler.SetBoardBudget)
+	r.Delete("/boards/:boardId/budget", budgetHandler.DeleteBoardBudget)
+}
@@ -3,7 +3,10 @@ package v1
 import (
 	"melina-studio-backend/internal/config"
 	"melina-studio-backend/internal/handlers"
+	"melina-studio-backend/internal/libraries"
+	"melina-studio-backend/internal/melina/workflow"
 	"melina-studio-backend/internal/repo"
+	"melina-studio-backend/internal/service"
 
 	"github.com/gofiber/fiber/v2"
 )
@@ -11,8 +14,29 @@ import (
 func registerChat(app fiber.Router) {
 	chatRepo := repo.NewChatRepository(config.DB)
 	tempUploadRepo := repo.NewTempUploadRepository(config.DB)
-	chatHandler := handlers.NewChatHandler(chatRepo, tempUploadRepo)
+	contentStore := service.NewContentStore(repo.NewStoredObjectRepository(config.DB), libraries.GetClients())
+	storageQuotaService := service.NewStorageQuotaService(config.LoadStorageQuotaConfig(), repo.NewStorageUsageRepository(config.DB), repo.NewAuthRepository(config.DB))
+	uploadValidator := service.NewUploadValidator(config.LoadUploadConfig(), repo.NewAuthRepository(config.DB))
+	uploadCommitService := service.NewUploadCommitService(tempUploadRepo, chatRepo, repo.NewBoardDataRepository(config.DB))
+	attachmentService := service.NewChatAttachmentService(chatRepo, tempUploadRepo, libraries.GetClients(), contentStore, storageQuotaService)
+	chatHandler := handlers.NewChatHandler(chatRepo, tempUploadRepo, contentStore, storageQuotaService, uploadValidator, uploadCommitService, attachmentService)
 
 	app.Get("/chat/:boardId", chatHandler.GetChatsByBoardId)
 	app.Post("/chat/:boardId/upload-image", chatHandler.UploadImage)
+	app.Post("/uploads/:uploadId/commit", chatHandler.CommitUpload)
+	app.Get("/chat/messages/:id/thinking", chatHandler.GetMessageThinking)
+	app.Get("/chat/messages/:id/attachments", chatHandler.ListMessageAttachments)
+	app.Delete("/chat/messages/:id/attachments/:attachmentId", chatHandler.DeleteMessageAttachment)
+}
+
+// registerChatStream is the SSE fallback for clients whose network blocks websockets
+// Registered before registerChat so /chat/stream isn't taken for a board id
+func registerChatStream(app fiber.Router, tenantService *service.TenantService) {
+	chatRepo := repo.NewChatRepository(config.DB)
+	boardDataRepo := repo.NewBoardDataRepository(config.DB)
+	boardRepo := repo.NewBoardRepository(config.DB)
+	wf := workflow.NewWorkflow(chatRepo, boardDataRepo, boardRepo, tenantService)
+
+	app.Get("/chat/stream", libraries.SSEHandler(hub))
+	app.Post("/chat/stream", libraries.SSEMessageHandler(hub, wf))
 }
@@ -0,0 +1,22 @@
+package v1
+
+import (
+	"melina-studio-backend/internal/config"
+	"melina-studio-backend/internal/handlers"
+	"melina-studio-backend/internal/repo"
+	"melina-studio-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func registerCodeExport(r fiber.Router) {
+	boardRepo := repo.NewBoardRepository(config.DB)
+	boardDataRepo := repo.NewBoardDataRepository(config.DB)
+	artifactRepo := repo.NewCodeArtifactRepository(config.DB)
+	codeExportService := service.NewCodeExportService(artifactRepo, boardDataRepo, hub)
+	codeExportHandler := handlers.NewCodeExportHandler(boardRepo, artifactRepo, codeExportService)
+
+	r.Post("/boards/:boardId/frames/:frameId/export-code", codeExportHandler.ExportCode)
+	r.Get("/boards/:boardId/artifacts", codeExportHandler.ListArtifacts)
+	r.Get("/boards/:boardId/artifacts/:artifactId", codeExportHandler.GetArtifact)
+}
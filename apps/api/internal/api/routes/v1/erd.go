@@ -0,0 +1,18 @@
+package v1
+
+import (
+	"melina-studio-backend/internal/config"
+	"melina-studio-backend/internal/handlers"
+	"melina-studio-backend/internal/repo"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func registerERD(r fiber.Router) {
+	boardRepo := repo.NewBoardRepository(config.DB)
+	boardDataRepo := repo.NewBoardDataRepository(config.DB)
+	erdHandler := handlers.NewERDHandler(boardRepo, boardDataRepo)
+
+	r.Post("/boards/:boardId/import/sql", erdHandler.ImportSQL)
+	r.Get("/boards/:boardId/export/sql", erdHandler.ExportSQL)
+}
@@ -0,0 +1,22 @@
+package v1
+
+import (
+	"melina-studio-backend/internal/config"
+	"melina-studio-backend/internal/handlers"
+	"melina-studio-backend/internal/repo"
+	"melina-studio-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func registerFacilitation(r fiber.Router) {
+	boardRepo := repo.NewBoardRepository(config.DB)
+	facilitationRepo := repo.NewFacilitationRepository(config.DB)
+	facilitationService := service.NewFacilitationService(facilitationRepo)
+	facilitationHandler := handlers.NewFacilitationHandler(boardRepo, facilitationService)
+
+	r.Post("/boards/:boardId/facilitation", facilitationHandler.StartSession)
+	r.Get("/boards/:boardId/facilitation", facilitationHandler.GetSession)
+	r.Post("/boards/:boardId/facilitation/advance", facilitationHandler.AdvanceSession)
+	r.Delete("/boards/:boardId/facilitation", facilitationHandler.EndSession)
+}
@@ -0,0 +1,14 @@
+package v1
+
+import (
+	"melina-studio-backend/internal/handlers"
+	"melina-studio-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func registerFeatureFlags(r fiber.Router, flagService *service.FeatureFlagService) {
+	flagHandler := handlers.NewFeatureFlagHandler(flagService)
+
+	r.Get("/flags", flagHandler.GetFlags)
+}
@@ -0,0 +1,21 @@
+package v1
+
+import (
+	"melina-studio-backend/internal/config"
+	"melina-studio-backend/internal/handlers"
+	"melina-studio-backend/internal/repo"
+	"melina-studio-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func newFeedbackHandler() *handlers.FeedbackHandler {
+	feedbackService := service.NewFeedbackService(repo.NewMessageFeedbackRepository(config.DB), repo.NewChatRepository(config.DB))
+	return handlers.NewFeedbackHandler(feedbackService)
+}
+
+func registerFeedback(r fiber.Router) {
+	feedbackHandler := newFeedbackHandler()
+
+	r.Post("/feedback", feedbackHandler.SubmitFeedback)
+}
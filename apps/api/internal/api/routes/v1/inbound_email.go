@@ -0,0 +1,27 @@
+package v1
+
+import (
+	"melina-studio-backend/internal/config"
+	"melina-studio-backend/internal/handlers"
+	"melina-studio-backend/internal/repo"
+	"melina-studio-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func registerInboundEmail(r fiber.Router, inboundEmailService *service.InboundEmailService) {
+	inboundEmailHandler := handlers.NewInboundEmailHandler(repo.NewBoardRepository(config.DB), inboundEmailService)
+
+	r.Get("/boards/:boardId/inbox", inboundEmailHandler.GetInbox)
+	r.Put("/boards/:boardId/inbox", inboundEmailHandler.UpdateInbox)
+	r.Post("/boards/:boardId/inbox/rotate", inboundEmailHandler.RotateInbox)
+	r.Delete("/boards/:boardId/inbox", inboundEmailHandler.DeleteInbox)
+}
+
+func registerInboundEmailPublic(r fiber.Router, inboundEmailService *service.InboundEmailService) {
+	inboundEmailHandler := handlers.NewInboundEmailHandler(repo.NewBoardRepository(config.DB), inboundEmailService)
+
+	// Public routes, authenticated by the INBOUND_EMAIL_WEBHOOK_SECRET token query parameter
+	r.Post("/webhooks/inbound-email/sendgrid", inboundEmailHandler.SendGridWebhook)
+	r.Post("/webhooks/inbound-email/ses", inboundEmailHandler.SESWebhook)
+}
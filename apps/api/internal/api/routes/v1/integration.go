@@ -0,0 +1,34 @@
+package v1
+
+import (
+	"melina-studio-backend/internal/auth"
+	"melina-studio-backend/internal/config"
+	"melina-studio-backend/internal/handlers"
+	"melina-studio-backend/internal/repo"
+	"melina-studio-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func registerAPIKeys(r fiber.Router, apiKeyService *service.APIKeyService) {
+	apiKeyHandler := handlers.NewAPIKeyHandler(apiKeyService)
+
+	r.Get("/api-keys", apiKeyHandler.ListAPIKeys)
+	r.Post("/api-keys", apiKeyHandler.CreateAPIKey)
+	r.Delete("/api-keys/:keyId", apiKeyHandler.DeleteAPIKey)
+}
+
+// registerIntegrations adds the Zapier and Make routes, authenticated by API key rather than session
+func registerIntegrations(r fiber.Router, apiKeyService *service.APIKeyService) {
+	integrationService := service.NewIntegrationService(repo.NewIntegrationRepository(config.DB), repo.NewAuthRepository(config.DB),
+		repo.NewBoardRepository(config.DB), repo.NewBoardDataRepository(config.DB), hub)
+	integrationHandler := handlers.NewIntegrationHandler(integrationService)
+
+	integrations := r.Group("/integrations", auth.APIKeyMiddleware(apiKeyService.Lookup))
+	integrations.Get("/me", integrationHandler.Me)
+	integrations.Get("/triggers/new-board", integrationHandler.NewBoardTrigger)
+	integrations.Get("/triggers/new-shape", integrationHandler.NewShapeTrigger)
+	integrations.Get("/triggers/chat-completed", integrationHandler.ChatCompletedTrigger)
+	integrations.Post("/actions/create-board", integrationHandler.CreateBoardAction)
+	integrations.Post("/actions/add-note", integrationHandler.AddNoteAction)
+}
@@ -0,0 +1,14 @@
+package v1
+
+import (
+	"melina-studio-backend/internal/handlers"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func registerModels(r fiber.Router) {
+	modelHandler := handlers.NewModelHandler()
+
+	r.Get("/models/health", modelHandler.GetHealth)
+	r.Get("/agent-profiles", modelHandler.GetAgentProfiles)
+}
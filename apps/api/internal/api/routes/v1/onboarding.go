@@ -0,0 +1,29 @@
+package v1
+
+import (
+	"melina-studio-backend/internal/config"
+	"melina-studio-backend/internal/handlers"
+	"melina-studio-backend/internal/repo"
+	"melina-studio-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// newOnboardingService is shared by signup, which provisions the sample board, and the onboarding routes
+func newOnboardingService() *service.OnboardingService {
+	return service.NewOnboardingService(
+		repo.NewOnboardingRepository(config.DB),
+		repo.NewBoardRepository(config.DB),
+		repo.NewBoardDataRepository(config.DB),
+		repo.NewChatRepository(config.DB),
+		service.GetJobQueue(),
+	)
+}
+
+func registerOnboarding(r fiber.Router) {
+	onboardingHandler := handlers.NewOnboardingHandler(newOnboardingService())
+
+	r.Get("/onboarding", onboardingHandler.GetProgress)
+	r.Post("/onboarding/steps/:step", onboardingHandler.CompleteStep)
+	r.Post("/onboarding/dismiss", onboardingHandler.Dismiss)
+}
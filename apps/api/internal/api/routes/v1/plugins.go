@@ -0,0 +1,21 @@
+package v1
+
+import (
+	"melina-studio-backend/internal/handlers"
+	"melina-studio-backend/internal/plugins"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// registerPlugins registers the routes the loaded plugins serve, each under /plugins/<name>
+func registerPlugins(r fiber.Router) {
+	host := plugins.GetHost()
+	if host == nil {
+		return
+	}
+	pluginHandler := handlers.NewPluginHandler(host)
+
+	for _, route := range host.Routes() {
+		r.Add(route.Method, "/plugins/"+route.Plugin+route.Path, pluginHandler.ProxyRoute(route))
+	}
+}
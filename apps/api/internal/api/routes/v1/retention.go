@@ -0,0 +1,20 @@
+package v1
+
+import (
+	"melina-studio-backend/internal/config"
+	"melina-studio-backend/internal/handlers"
+	"melina-studio-backend/internal/repo"
+	"melina-studio-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func registerRetention(r fiber.Router) {
+	retentionRepo := repo.NewRetentionRepository(config.DB)
+	retentionService := service.NewRetentionService(retentionRepo)
+	retentionHandler := handlers.NewRetentionHandler(retentionService)
+
+	r.Get("/settings/retention", retentionHandler.GetPolicy)
+	r.Put("/settings/retention", retentionHandler.UpdatePolicy)
+	r.Get("/settings/retention/dry-run", retentionHandler.DryRun)
+}
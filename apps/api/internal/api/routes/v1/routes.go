@@ -6,8 +6,10 @@ import (
 	"melina-studio-backend/internal/libraries"
 	"melina-studio-backend/internal/melina/workflow"
 	"melina-studio-backend/internal/repo"
+	"melina-studio-backend/internal/service"
 
 	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
 )
 
 var hub *libraries.Hub
@@ -20,26 +22,94 @@ func init() {
 }
 
 func RegisterRoutes(r fiber.Router) {
+	// Resolve the tenant before anything else; a no-op unless multi-tenant mode is enabled
+	tenantService := service.NewTenantService(repo.NewTenantRepository(config.DB), repo.NewAuthRepository(config.DB))
+	r.Use(auth.TenantMiddleware(config.LoadTenantConfig(), tenantLookup(tenantService)))
+
+	// Shared so flag changes made through the admin routes are seen by the evaluation middleware right away
+	flagService := service.NewFeatureFlagService(repo.NewFeatureFlagRepository(config.DB))
+
+	// Shared for the same reason: the authenticators enforce policies the admin routes change
+	securityPolicyService := service.NewSecurityPolicyService(repo.NewTenantSecurityPolicyRepository(config.DB))
+	auth.SetSecurityPolicyLookup(securityPolicyService.Lookup)
+
+	// Shared so the rules' cooldowns see every save, whichever route made it
+	automationService := service.NewAutomationService(config.LoadAutomationConfig(), config.DB, repo.NewAutomationRepository(config.DB),
+		repo.NewBoardRepository(config.DB), repo.NewBoardDataRepository(config.DB), repo.NewCustomRulesRepository(config.DB),
+		repo.NewTokenConsumptionRepository(config.DB), service.GetJobQueue(), hub)
+
+	// Shared so an address's hourly limit counts the emails of both webhooks
+	inboundEmailService := service.NewInboundEmailService(config.LoadInboundEmailConfig(), repo.NewBoardInboxRepository(config.DB),
+		repo.NewBoardRepository(config.DB), repo.NewBoardDataRepository(config.DB), automationService, service.GetJobQueue(), hub)
+
+	// Shared between the key management routes and the middleware of the integration routes
+	apiKeyService := service.NewAPIKeyService(repo.NewAPIKeyRepository(config.DB), repo.NewAuthRepository(config.DB))
+
 	// Public routes (no auth required)
 	registerAuthPublic(r.Group("/auth"))
-	registerWebSocket(r)
+	registerWebSocket(r, tenantService)
 	registerPaymentPublic(r)
+	registerInboundEmailPublic(r, inboundEmailService)
+	registerIntegrations(r, apiKeyService)
+	registerTenant(r, tenantService)
+	registerAdmin(r, tenantService, flagService, securityPolicyService)
 
 	// Protected routes (requires auth)
-	protected := r.Group("", auth.AuthMiddleware())
-	registerBoard(protected)
+	protected := r.Group("", auth.AuthMiddleware(), auth.FeatureFlagMiddleware(flagService.Evaluate))
+	registerBoard(protected, automationService)
+	registerChatStream(protected, tenantService)
 	registerChat(protected)
 	registerTokens(protected)
 	registerAuthProtected(protected.Group("/auth"))
 	registerPayment(protected)
+	registerFacilitation(protected)
+	registerSummary(protected)
+	registerCodeExport(protected)
+	registerERD(protected)
+	registerBoardExport(protected)
+	registerSearch(protected)
+	registerAnchor(protected)
+	registerViewport(protected)
+	registerActivity(protected)
+	registerRetention(protected)
+	registerBudget(protected)
+	registerModels(protected)
+	registerFeatureFlags(protected, flagService)
+	registerOnboarding(protected)
+	registerAnnouncements(protected)
+	registerFeedback(protected)
+	registerPlugins(protected)
+	registerAutomation(protected, automationService)
+	registerInboundEmail(protected, inboundEmailService)
+	registerBoardVersions(protected)
+	registerAPIKeys(protected, apiKeyService)
+	registerActionCatalog(protected)
 }
 
-func registerWebSocket(r fiber.Router) {
+func registerWebSocket(r fiber.Router, tenantService *service.TenantService) {
 	chatRepo := repo.NewChatRepository(config.DB)
 	boardDataRepo := repo.NewBoardDataRepository(config.DB)
 	boardRepo := repo.NewBoardRepository(config.DB)
-	wf := workflow.NewWorkflow(chatRepo, boardDataRepo, boardRepo)
+	wf := workflow.NewWorkflow(chatRepo, boardDataRepo, boardRepo, tenantService)
+
+	// Connections join a board's room, for its changes and presentation, once the board is found to be the user's.
+	// Boards aren't shared, so a room holds the owner's connections; saves and clears check ownership the same way
+	hub.SetBoardAccess(boardAccess(boardRepo))
 
 	// WebSocket route - auth handled in websocket handler
 	r.Get("/ws", libraries.WebSocketHandler(hub, wf))
 }
+
+func boardAccess(boardRepo repo.BoardRepoInterface) libraries.BoardAccessFunc {
+	return func(userID string, boardId string) bool {
+		userUUID, err := uuid.Parse(userID)
+		if err != nil {
+			return false
+		}
+		boardUUID, err := uuid.Parse(boardId)
+		if err != nil {
+			return false
+		}
+		return boardRepo.ValidateBoardOwnership(userUUID, boardUUID) == nil
+	}
+}
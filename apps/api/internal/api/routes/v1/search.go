@@ -0,0 +1,18 @@
+package v1
+
+import (
+	"melina-studio-backend/internal/config"
+	"melina-studio-backend/internal/handlers"
+	"melina-studio-backend/internal/repo"
+	"melina-studio-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func registerSearch(r fiber.Router) {
+	searchRepo := repo.NewSearchRepository(config.DB)
+	searchService := service.NewSearchService(searchRepo)
+	searchHandler := handlers.NewSearchHandler(searchService)
+
+	r.Get("/search", searchHandler.Search)
+}
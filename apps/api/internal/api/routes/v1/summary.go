@@ -0,0 +1,24 @@
+package v1
+
+import (
+	"melina-studio-backend/internal/config"
+	"melina-studio-backend/internal/handlers"
+	"melina-studio-backend/internal/repo"
+	"melina-studio-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func registerSummary(r fiber.Router) {
+	boardRepo := repo.NewBoardRepository(config.DB)
+	boardDataRepo := repo.NewBoardDataRepository(config.DB)
+	chatRepo := repo.NewChatRepository(config.DB)
+	summaryRepo := repo.NewBoardSummaryRepository(config.DB)
+	summaryService := service.NewBoardSummaryService(summaryRepo, boardDataRepo, chatRepo, service.GetJobQueue())
+	summaryHandler := handlers.NewBoardSummaryHandler(boardRepo, summaryRepo, summaryService)
+
+	r.Post("/boards/:boardId/summarize", summaryHandler.Summarize)
+	r.Get("/boards/:boardId/summaries", summaryHandler.ListSummaries)
+	r.Get("/boards/:boardId/summaries/:summaryId", summaryHandler.GetSummary)
+	r.Get("/boards/:boardId/summaries/:summaryId/download", summaryHandler.DownloadSummary)
+}
@@ -0,0 +1,28 @@
+package v1
+
+import (
+	"errors"
+	"melina-studio-backend/internal/auth"
+	"melina-studio-backend/internal/handlers"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func registerTenant(r fiber.Router, tenantService *service.TenantService) {
+	tenantHandler := handlers.NewTenantHandler(tenantService)
+
+	r.Get("/tenant", tenantHandler.GetCurrentTenant)
+}
+
+// tenantLookup adapts the tenant service to the lookup the tenant middleware expects
+func tenantLookup(tenantService *service.TenantService) auth.TenantLookup {
+	return func(slug string) (*models.Tenant, error) {
+		tenant, err := tenantService.Resolve(slug)
+		if errors.Is(err, service.ErrTenantNotFound) {
+			return nil, auth.ErrUnknownTenant
+		}
+		return tenant, err
+	}
+}
@@ -16,5 +16,6 @@ func registerTokens(app fiber.Router) {
 	app.Get("/tokens/usage", tokenHandler.GetTokenConsumption)
 	app.Get("/tokens/subscription-status", tokenHandler.GetSubscriptionStatus)
 	app.Get("/tokens/analytics", tokenHandler.GetTokenAnalytics)
+	app.Get("/tokens/boards/:boardId/cost", tokenHandler.GetBoardCost)
 	app.Get("/subscription-plans", tokenHandler.GetAllSubscriptionPlans)
 }
@@ -0,0 +1,20 @@
+package v1
+
+import (
+	"melina-studio-backend/internal/config"
+	"melina-studio-backend/internal/handlers"
+	"melina-studio-backend/internal/repo"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func registerViewport(r fiber.Router) {
+	boardRepo := repo.NewBoardRepository(config.DB)
+	boardDataRepo := repo.NewBoardDataRepository(config.DB)
+	viewportRepo := repo.NewBoardViewportRepository(config.DB)
+	viewportHandler := handlers.NewViewportHandler(boardRepo, boardDataRepo, viewportRepo)
+
+	r.Get("/boards/:boardId/viewport", viewportHandler.GetViewport)
+	r.Put("/boards/:boardId/viewport", viewportHandler.SaveViewport)
+	r.Get("/boards/:boardId/focus", viewportHandler.FocusShapes)
+}
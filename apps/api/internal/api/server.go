@@ -1,8 +1,10 @@
 package api
 
 import (
+	"fmt"
 	"log"
-	"os"
+	"melina-studio-backend/internal/auth"
+	"melina-studio-backend/internal/config"
 	"time"
 
 	"context"
@@ -21,7 +23,11 @@ func NewServer() *fiber.App {
 		ErrorHandler: customErrorHandler,
 		AppName:      "Melina Studio",
 		// Enable proxy header to get real client IP when behind reverse proxy (nginx, cloudflare, etc.)
-		ProxyHeader: fiber.HeaderXForwardedFor,
+		// The header is only read from the trusted proxies; auth.ClientIP picks the client out of it for allowlists
+		ProxyHeader:             fiber.HeaderXForwardedFor,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          config.GetSettings().Server.TrustedProxyList(),
+		EnableIPValidation:      true,
 	})
 
 	// Global middleware
@@ -31,7 +37,8 @@ func NewServer() *fiber.App {
 	app.Use(cors.New(cors.Config{
 		AllowOrigins:     "http://localhost:3000, https://melina.studio , https://www.melina.studio",
 		AllowMethods:     "GET,POST,PUT,PATCH,DELETE,OPTIONS",
-		AllowHeaders:     "Origin, Content-Type, Accept, Authorization",
+		AllowHeaders:     "Origin, Content-Type, Accept, Authorization, X-Client-Id",
+		ExposeHeaders:    "Content-Disposition, X-Watermarked",
 		AllowCredentials: true,
 	}))
 	// Note: CSRF protection not needed - using JWT auth with SameSite cookies + CORS
@@ -45,9 +52,13 @@ func NewServer() *fiber.App {
 		return c.Next()
 	})
 
+	// gzip large REST responses such as board data and exports
+	app.Use(gzipLargeResponses(config.LoadCompressionConfig().GzipThreshold))
+
 	// Middleware to allow WebSocket upgrade
 	app.Use("/ws", func(c *fiber.Ctx) error {
 		if websocket.IsWebSocketUpgrade(c) {
+			auth.KeepClientIP(c)
 			return c.Next()
 		}
 		return fiber.ErrUpgradeRequired
@@ -77,13 +88,10 @@ func customErrorHandler(c *fiber.Ctx, err error) error {
 }
 
 func StartServer(app *fiber.App) error {
-	port := os.Getenv("PORT")
-	if port == "" {
-		port = "3000"
-	}
+	port := config.GetSettings().Server.Port
 
-	log.Printf("🚀 Server starting on port %s\n", port)
-	return app.Listen(":" + port)
+	log.Printf("🚀 Server starting on port %d\n", port)
+	return app.Listen(fmt.Sprintf(":%d", port))
 }
 
 // AuthRateLimiter returns a stricter rate limiter for auth routes (10 requests per minute)
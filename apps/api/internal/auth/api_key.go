@@ -0,0 +1,66 @@
+package auth
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	// APIKeyHeader carries the API keys integrations such as Zapier and Make authenticate with
+	APIKeyHeader = "X-API-Key"
+	// APIKeyPrefix starts every API key, so a leaked one is easy to recognize
+	APIKeyPrefix = "mk_"
+)
+
+// ErrInvalidAPIKey is returned by API key lookups for keys that don't exist or were revoked
+var ErrInvalidAPIKey = errors.New("invalid API key")
+
+// APIKeyOwner is the user an API key acts as
+type APIKeyOwner struct {
+	UserID   string
+	TenantID string
+}
+
+// APIKeyLookup resolves the owner of an API key
+type APIKeyLookup func(key string) (*APIKeyOwner, error)
+
+// APIKeyMiddleware authenticates the integration routes with the API key in the X-API-Key header. The key acts
+// as its user on the key's tenant, within the network allowlist of the user's organization
+func APIKeyMiddleware(lookup APIKeyLookup) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		key := strings.TrimSpace(c.Get(APIKeyHeader))
+		if !strings.HasPrefix(key, APIKeyPrefix) {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Missing or invalid API key",
+			})
+		}
+
+		owner, err := lookup(key)
+		if err != nil {
+			if errors.Is(err, ErrInvalidAPIKey) {
+				return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+					"error": "Missing or invalid API key",
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to check API key",
+			})
+		}
+
+		// a key is only valid on its user's tenant
+		if tenantID, enforced := TenantIDFromCtx(c); enforced && owner.TenantID != tenantID {
+			return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+				"error": "Missing or invalid API key",
+			})
+		}
+
+		if err := CheckSignInIP(owner.TenantID, ClientIP(c)); err != nil {
+			return SecurityPolicyError(c, err)
+		}
+
+		c.Locals("userID", owner.UserID)
+		return c.Next()
+	}
+}
@@ -0,0 +1,42 @@
+package auth
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestAPIKeyMiddleware(t *testing.T) {
+	lookup := func(key string) (*APIKeyOwner, error) {
+		if key != "mk_valid" {
+			return nil, ErrInvalidAPIKey
+		}
+		return &APIKeyOwner{UserID: "user-1"}, nil
+	}
+	app := fiber.New()
+	app.Get("/me", APIKeyMiddleware(lookup), func(c *fiber.Ctx) error {
+		return c.SendString(c.Locals("userID").(string))
+	})
+
+	cases := map[string]int{
+		"":          fiber.StatusUnauthorized,
+		"valid":     fiber.StatusUnauthorized,
+		"mk_wrong":  fiber.StatusUnauthorized,
+		"mk_valid":  fiber.StatusOK,
+		" mk_valid": fiber.StatusOK,
+	}
+	for key, want := range cases {
+		req := httptest.NewRequest("GET", "/me", nil)
+		if key != "" {
+			req.Header.Set(APIKeyHeader, key)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != want {
+			t.Errorf("%q: got %d, want %d", key, resp.StatusCode, want)
+		}
+	}
+}
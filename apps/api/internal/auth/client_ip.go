@@ -0,0 +1,67 @@
+package auth
+
+import (
+	"net"
+	"strings"
+
+	"github.com/gofiber/contrib/websocket"
+	"github.com/gofiber/fiber/v2"
+)
+
+// clientIPLocalsKey keeps the client's address for the WebSocket connection a request is upgraded to
+const clientIPLocalsKey = "clientIP"
+
+// ClientIP returns the address a request came from, as the network allowlists see it. The proxy header is only
+// believed when the connection comes from one of the app's trusted proxies, and then read from the right: the
+// first hop that isn't a trusted proxy is the client, as everything left of it is whatever the client sent
+func ClientIP(c *fiber.Ctx) string {
+	remote := c.Context().RemoteIP().String()
+	cfg := c.App().Config()
+	if cfg.ProxyHeader == "" || !cfg.EnableTrustedProxyCheck || !c.IsProxyTrusted() {
+		return remote
+	}
+
+	hops := strings.Split(c.Get(cfg.ProxyHeader), ",")
+	for i := len(hops) - 1; i >= 0; i-- {
+		hop := strings.TrimSpace(hops[i])
+		if hop == "" {
+			continue
+		}
+		ip := net.ParseIP(hop)
+		if ip == nil {
+			// unparsable, so no allowlist lets it through
+			return hop
+		}
+		if !isTrustedProxy(ip, cfg.TrustedProxies) {
+			return ip.String()
+		}
+	}
+	return remote
+}
+
+// KeepClientIP stores the request's client address for the WebSocket connection it is upgraded to
+func KeepClientIP(c *fiber.Ctx) {
+	c.Locals(clientIPLocalsKey, ClientIP(c))
+}
+
+// websocketClientIP returns the address KeepClientIP stored when the connection was upgraded
+func websocketClientIP(conn *websocket.Conn) string {
+	if ip, ok := conn.Locals(clientIPLocalsKey).(string); ok {
+		return ip
+	}
+	return conn.IP()
+}
+
+// isTrustedProxy reports whether the address is one of the proxies, given as addresses or CIDR ranges
+func isTrustedProxy(ip net.IP, proxies []string) bool {
+	for _, proxy := range proxies {
+		if strings.Contains(proxy, "/") {
+			if _, ipNet, err := net.ParseCIDR(proxy); err == nil && ipNet.Contains(ip) {
+				return true
+			}
+		} else if trusted := net.ParseIP(proxy); trusted != nil && trusted.Equal(ip) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+package auth
+
+import (
+	"net"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestClientIPReadsForwardedForFromTheRight(t *testing.T) {
+	// app.Test connections come from 0.0.0.0, the proxy here
+	behindProxy := fiber.New(fiber.Config{
+		ProxyHeader:             fiber.HeaderXForwardedFor,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          []string{"0.0.0.0", "10.0.0.0/8"},
+	})
+	direct := fiber.New(fiber.Config{
+		ProxyHeader:             fiber.HeaderXForwardedFor,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          []string{"10.0.0.0/8"},
+	})
+	for _, app := range []*fiber.App{behindProxy, direct} {
+		app.Get("/ip", func(c *fiber.Ctx) error {
+			return c.SendString(ClientIP(c))
+		})
+	}
+
+	cases := []struct {
+		name         string
+		app          *fiber.App
+		forwardedFor string
+		want         string
+	}{
+		{"no header", behindProxy, "", "0.0.0.0"},
+		{"one hop", behindProxy, "203.0.113.7", "203.0.113.7"},
+		{"forged hop on the left", behindProxy, "203.0.113.7, 198.51.100.9", "198.51.100.9"},
+		{"trusted hops on the right", behindProxy, "203.0.113.7, 198.51.100.9, 10.1.2.3", "198.51.100.9"},
+		{"unparsable hop", behindProxy, "203.0.113.7, not-an-ip, 10.1.2.3", "not-an-ip"},
+		{"untrusted connection", direct, "203.0.113.7", "0.0.0.0"},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest("GET", "/ip", nil)
+		if tc.forwardedFor != "" {
+			req.Header.Set(fiber.HeaderXForwardedFor, tc.forwardedFor)
+		}
+		resp, err := tc.app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		body := make([]byte, 64)
+		n, _ := resp.Body.Read(body)
+		if got := string(body[:n]); got != tc.want {
+			t.Errorf("%s: got %q, want %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestAllowlistIgnoresForgedForwardedFor(t *testing.T) {
+	_, office, _ := net.ParseCIDR("203.0.113.0/24")
+	SetSecurityPolicyLookup(func(string) (*SecurityPolicy, error) {
+		return &SecurityPolicy{AllowedIPRanges: []*net.IPNet{office}}, nil
+	})
+	t.Cleanup(func() { SetSecurityPolicyLookup(nil) })
+	lookup := func(key string) (*APIKeyOwner, error) {
+		return &APIKeyOwner{UserID: "user-1", TenantID: "tenant-1"}, nil
+	}
+	app := fiber.New(fiber.Config{
+		ProxyHeader:             fiber.HeaderXForwardedFor,
+		EnableTrustedProxyCheck: true,
+		TrustedProxies:          []string{"0.0.0.0"},
+	})
+	app.Get("/me", APIKeyMiddleware(lookup), func(c *fiber.Ctx) error {
+		return c.SendString(c.Locals("userID").(string))
+	})
+
+	cases := map[string]int{
+		"203.0.113.7":               fiber.StatusOK,
+		"198.51.100.9":              fiber.StatusForbidden,
+		"203.0.113.7, 198.51.100.9": fiber.StatusForbidden,
+		"203.0.113.7,198.51.100.9":  fiber.StatusForbidden,
+		"198.51.100.9, 203.0.113.7": fiber.StatusOK,
+	}
+	for forwardedFor, want := range cases {
+		req := httptest.NewRequest("GET", "/me", nil)
+		req.Header.Set(APIKeyHeader, "mk_valid")
+		req.Header.Set(fiber.HeaderXForwardedFor, forwardedFor)
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != want {
+			t.Errorf("X-Forwarded-For %q: got %d, want %d", forwardedFor, resp.StatusCode, want)
+		}
+	}
+}
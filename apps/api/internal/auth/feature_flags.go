@@ -0,0 +1,53 @@
+package auth
+
+import (
+	"log"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const featureFlagsLocalsKey = "featureFlags"
+
+// FlagEvaluator returns every feature flag's state for a user
+type FlagEvaluator func(userID string) (map[string]bool, error)
+
+// FeatureFlagMiddleware evaluates the feature flags for the authenticated user and stores them on the request
+// It must run after AuthMiddleware. Evaluation failures leave every flag off rather than failing the request
+func FeatureFlagMiddleware(evaluate FlagEvaluator) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, _ := c.Locals("userID").(string)
+		flags, err := evaluate(userID)
+		if err != nil {
+			log.Println(err, "Error evaluating feature flags")
+			flags = map[string]bool{}
+		}
+		c.Locals(featureFlagsLocalsKey, flags)
+		return c.Next()
+	}
+}
+
+// FeatureFlagsFromCtx returns the flags evaluated for the request
+func FeatureFlagsFromCtx(c *fiber.Ctx) map[string]bool {
+	flags, _ := c.Locals(featureFlagsLocalsKey).(map[string]bool)
+	if flags == nil {
+		return map[string]bool{}
+	}
+	return flags
+}
+
+// FlagEnabled reports whether the flag is on for the request's user
+func FlagEnabled(c *fiber.Ctx, key string) bool {
+	return FeatureFlagsFromCtx(c)[key]
+}
+
+// RequireFeature hides a route behind a feature flag, answering 404 while the flag is off for the user
+func RequireFeature(key string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !FlagEnabled(c, key) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Not found",
+			})
+		}
+		return c.Next()
+	}
+}
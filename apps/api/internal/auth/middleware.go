@@ -1,8 +1,10 @@
 package auth
 
 import (
+	"crypto/subtle"
 	"errors"
 	"strings"
+	"time"
 
 	"github.com/gofiber/contrib/websocket"
 	"github.com/gofiber/fiber/v2"
@@ -10,24 +12,27 @@ import (
 
 const AccessTokenCookie = "access_token"
 
-func AuthMiddleware() fiber.Handler {
-	return func(c *fiber.Ctx) error {
-		var tokenStr string
+const (
+	accessClaimsLocalsKey     = "accessClaims"
+	sessionExpiresAtLocalsKey = "sessionExpiresAt"
+)
 
-		// First try to get token from cookie
-		tokenStr = c.Cookies(AccessTokenCookie)
+// AccessClaimsFromCtx returns the claims of the access token the request was authenticated with
+func AccessClaimsFromCtx(c *fiber.Ctx) *JWTClaims {
+	claims, _ := c.Locals(accessClaimsLocalsKey).(*JWTClaims)
+	return claims
+}
 
-		// Fallback to Authorization header (for backwards compatibility / API clients)
-		if tokenStr == "" {
-			authHeader := c.Get("Authorization")
-			if authHeader != "" {
-				tokenStr = strings.TrimPrefix(authHeader, "Bearer ")
-				if tokenStr == authHeader {
-					tokenStr = "" // Not a Bearer token
-				}
-			}
-		}
+// SessionExpiresAtFromCtx returns when the request's access token expires or the organization's policy ends it,
+// whichever comes first; zero when neither does
+func SessionExpiresAtFromCtx(c *fiber.Ctx) time.Time {
+	expiresAt, _ := c.Locals(sessionExpiresAtLocalsKey).(time.Time)
+	return expiresAt
+}
 
+func AuthMiddleware() fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		tokenStr := AccessTokenFromRequest(c)
 		if tokenStr == "" {
 			return fiber.ErrUnauthorized
 		}
@@ -37,14 +42,56 @@ func AuthMiddleware() fiber.Handler {
 			return fiber.ErrUnauthorized
 		}
 
+		// a token is only valid on the tenant it was issued for
+		if tenantID, enforced := TenantIDFromCtx(c); enforced && claims.TenantID != tenantID {
+			return fiber.ErrUnauthorized
+		}
+
+		// the organization may restrict where its users connect from and how long their sessions last
+		deadline, err := CheckSecurityPolicy(claims, ClientIP(c))
+		if err != nil {
+			return SecurityPolicyError(c, err)
+		}
+
 		c.Locals("userID", claims.UserID)
+		c.Locals(accessClaimsLocalsKey, claims)
+		c.Locals(sessionExpiresAtLocalsKey, sessionExpiresAt(claims, deadline))
+		return c.Next()
+	}
+}
+
+// AccessTokenFromRequest returns the access token from the cookie, or else the Authorization header
+// (for backwards compatibility / API clients)
+func AccessTokenFromRequest(c *fiber.Ctx) string {
+	if tokenStr := c.Cookies(AccessTokenCookie); tokenStr != "" {
+		return tokenStr
+	}
+	authHeader := c.Get("Authorization")
+	tokenStr := strings.TrimPrefix(authHeader, "Bearer ")
+	if tokenStr == authHeader {
+		return "" // Not a Bearer token
+	}
+	return tokenStr
+}
+
+// AdminMiddleware guards ops-only routes with a static token sent in the X-Admin-Token header
+// When no token is configured the admin routes are disabled entirely
+func AdminMiddleware(adminToken string) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if adminToken == "" {
+			return fiber.ErrNotFound
+		}
+		token := c.Get("X-Admin-Token")
+		if subtle.ConstantTimeCompare([]byte(token), []byte(adminToken)) != 1 {
+			return fiber.ErrUnauthorized
+		}
 		return c.Next()
 	}
 }
 
 // AuthenticateWebSocket validates token from WebSocket connection
-// Supports: query parameter (?token=xxx) and cookies (access_token)
-func AuthenticateWebSocket(conn *websocket.Conn) (string, error) {
+// The claims and expiry are returned so the connection can be closed once the token expires
+func AuthenticateWebSocket(conn *websocket.Conn) (*JWTClaims, time.Time, error) {
 	var tokenStr string
 
 	// Try query parameter first (for browser WebSocket connections)
@@ -56,13 +103,40 @@ func AuthenticateWebSocket(conn *websocket.Conn) (string, error) {
 	}
 
 	if tokenStr == "" {
-		return "", errors.New("no authentication token provided")
+		return nil, time.Time{}, errors.New("no authentication token provided")
 	}
 
+	return ValidateWebSocketToken(conn, tokenStr)
+}
+
+// ValidateWebSocketToken validates an access token for an open WebSocket connection,
+// at connect time or when the client sends a refreshed token
+// The expiry is when the token expires or the organization's policy ends it, whichever comes first
+func ValidateWebSocketToken(conn *websocket.Conn, tokenStr string) (*JWTClaims, time.Time, error) {
 	claims, err := ValidateAccessToken(tokenStr)
 	if err != nil {
-		return "", err
+		return nil, time.Time{}, err
+	}
+
+	if tenantID, enforced := conn.Locals(tenantIDLocalsKey).(string); enforced && claims.TenantID != tenantID {
+		return nil, time.Time{}, errors.New("token was issued for another tenant")
 	}
 
-	return claims.UserID, nil
+	deadline, err := CheckSecurityPolicy(claims, websocketClientIP(conn))
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return claims, sessionExpiresAt(claims, deadline), nil
+}
+
+// sessionExpiresAt is when the token expires or the policy's deadline passes, whichever comes first
+func sessionExpiresAt(claims *JWTClaims, deadline time.Time) time.Time {
+	var expiresAt time.Time
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+	if !deadline.IsZero() && (expiresAt.IsZero() || deadline.Before(expiresAt)) {
+		expiresAt = deadline
+	}
+	return expiresAt
 }
@@ -1,33 +1,25 @@
 package oauth
 
 import (
-	"os"
-	"sync"
+	"melina-studio-backend/internal/config"
 
 	"golang.org/x/oauth2"
 	"golang.org/x/oauth2/google"
 )
 
-var (
-	googleOAuthConfig *oauth2.Config
-	googleOnce        sync.Once
-)
-
-// GetGoogleOAuthConfig returns the Google OAuth config, initializing it lazily
-// This ensures env vars are loaded before the config is created
+// GetGoogleOAuthConfig returns the Google OAuth config
+// It is built on every call so a rotated client secret is picked up without a restart
 func GetGoogleOAuthConfig() *oauth2.Config {
-	googleOnce.Do(func() {
-		googleOAuthConfig = &oauth2.Config{
-			ClientID:     os.Getenv("GOOGLE_CLIENT_ID"),
-			ClientSecret: os.Getenv("GOOGLE_CLIENT_SECRET"),
-			RedirectURL:  os.Getenv("GOOGLE_REDIRECT_URI"),
-			Scopes: []string{
-				"openid",
-				"profile",
-				"email",
-			},
-			Endpoint: google.Endpoint,
-		}
-	})
-	return googleOAuthConfig
+	settings := config.GetSettings().Auth
+	return &oauth2.Config{
+		ClientID:     settings.GoogleClientID,
+		ClientSecret: settings.GoogleClientSecret,
+		RedirectURL:  settings.GoogleRedirectURI,
+		Scopes: []string{
+			"openid",
+			"profile",
+			"email",
+		},
+		Endpoint: google.Endpoint,
+	}
 }
@@ -0,0 +1,103 @@
+package oauth
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"melina-studio-backend/internal/config"
+
+	"golang.org/x/oauth2"
+)
+
+// StateTTL is how long a user has to finish the provider's consent screen
+const StateTTL = 10 * time.Minute
+
+var ErrInvalidState = errors.New("invalid oauth state")
+
+// FlowState is what a login needs to remember until the provider calls back: the state that
+// ties the callback to this browser and the PKCE verifier the code is exchanged with
+type FlowState struct {
+	Provider  string `json:"p"`
+	State     string `json:"s"`
+	Verifier  string `json:"v"`
+	ExpiresAt int64  `json:"e"`
+}
+
+// NewFlowState starts a login with the given provider
+func NewFlowState(provider string) *FlowState {
+	return &FlowState{
+		Provider:  provider,
+		State:     oauth2.GenerateVerifier(),
+		Verifier:  oauth2.GenerateVerifier(),
+		ExpiresAt: time.Now().Add(StateTTL).Unix(),
+	}
+}
+
+// AuthCodeURL returns the provider's consent URL with the state and the PKCE challenge
+func (s *FlowState) AuthCodeURL(cfg *oauth2.Config) string {
+	return cfg.AuthCodeURL(s.State, oauth2.AccessTypeOffline, oauth2.S256ChallengeOption(s.Verifier))
+}
+
+// Exchange trades the callback's code for a token, proving it is the client that started the login
+func (s *FlowState) Exchange(ctx context.Context, cfg *oauth2.Config, code string) (*oauth2.Token, error) {
+	return cfg.Exchange(ctx, code, oauth2.VerifierOption(s.Verifier))
+}
+
+// Encode signs the flow state so it can be kept in a cookie without the server storing it
+func (s *FlowState) Encode() (string, error) {
+	return s.encode(stateSecret())
+}
+
+func (s *FlowState) encode(secret []byte) (string, error) {
+	payload, err := json.Marshal(s)
+	if err != nil {
+		return "", err
+	}
+	encoded := base64.RawURLEncoding.EncodeToString(payload)
+	return encoded + "." + signState(encoded, secret), nil
+}
+
+// VerifyCallback checks the signed cookie value against the provider that called back and the state it returned
+func VerifyCallback(cookieValue, provider, state string) (*FlowState, error) {
+	return verifyCallback(cookieValue, provider, state, stateSecret(), time.Now())
+}
+
+func verifyCallback(cookieValue, provider, state string, secret []byte, now time.Time) (*FlowState, error) {
+	encoded, signature, ok := strings.Cut(cookieValue, ".")
+	if !ok || !hmac.Equal([]byte(signature), []byte(signState(encoded, secret))) {
+		return nil, ErrInvalidState
+	}
+	payload, err := base64.RawURLEncoding.DecodeString(encoded)
+	if err != nil {
+		return nil, ErrInvalidState
+	}
+	var s FlowState
+	if err := json.Unmarshal(payload, &s); err != nil {
+		return nil, ErrInvalidState
+	}
+	if s.Provider != provider || now.Unix() > s.ExpiresAt {
+		return nil, ErrInvalidState
+	}
+	if state == "" || subtle.ConstantTimeCompare([]byte(s.State), []byte(state)) != 1 {
+		return nil, ErrInvalidState
+	}
+	return &s, nil
+}
+
+func signState(encoded string, secret []byte) string {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte("oauth-state:" + encoded))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// stateSecret reuses the access token secret, the state is signed with a prefix of its own
+func stateSecret() []byte {
+	return []byte(config.GetSettings().Auth.JWTSecret)
+}
@@ -0,0 +1,43 @@
+package oauth
+
+import (
+	"testing"
+	"time"
+)
+
+func TestVerifyCallback(t *testing.T) {
+	secret := []byte("test-secret")
+	now := time.Now()
+	flow := &FlowState{Provider: "google", State: "state", Verifier: "verifier", ExpiresAt: now.Add(StateTTL).Unix()}
+	cookie, err := flow.encode(secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	got, err := verifyCallback(cookie, "google", "state", secret, now)
+	if err != nil {
+		t.Fatalf("expected a valid callback, got %v", err)
+	}
+	if got.Verifier != "verifier" {
+		t.Fatalf("got verifier %q, want %q", got.Verifier, "verifier")
+	}
+
+	cases := map[string]struct {
+		cookie, provider, state string
+		secret                  []byte
+		now                     time.Time
+	}{
+		"no cookie":        {"", "google", "state", secret, now},
+		"state mismatch":   {cookie, "google", "other", secret, now},
+		"missing state":    {cookie, "google", "", secret, now},
+		"other provider":   {cookie, "github", "state", secret, now},
+		"expired":          {cookie, "google", "state", secret, now.Add(StateTTL + time.Minute)},
+		"wrong signature":  {cookie, "google", "state", []byte("other-secret"), now},
+		"tampered payload": {"x" + cookie, "google", "state", secret, now},
+	}
+	for name, tc := range cases {
+		if _, err := verifyCallback(tc.cookie, tc.provider, tc.state, tc.secret, tc.now); err != ErrInvalidState {
+			t.Errorf("%s: got %v, want ErrInvalidState", name, err)
+		}
+	}
+}
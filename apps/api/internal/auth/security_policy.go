@@ -0,0 +1,162 @@
+package auth
+
+import (
+	"errors"
+	"log"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+var (
+	ErrIPNotAllowed   = errors.New("sign-ins from this network are not allowed by your organization")
+	ErrSessionExpired = errors.New("your organization requires you to sign in again")
+	// ErrReauthRequired asks the client to refresh its access token, which the organization wants renewed more often
+	ErrReauthRequired = errors.New("access token must be refreshed")
+)
+
+// SecurityPolicy is an organization's sign-in policy as the authenticators enforce it
+type SecurityPolicy struct {
+	// AllowedIPRanges lists the networks the organization's users may connect from; empty allows any
+	AllowedIPRanges []*net.IPNet
+	// MaxSession ends a session this long after sign-in, however often it is refreshed; 0 leaves it to the refresh token
+	MaxSession time.Duration
+	// ReauthInterval is the oldest access token accepted; clients refresh at least this often. 0 accepts tokens until they expire
+	ReauthInterval time.Duration
+}
+
+// SecurityPolicyLookup returns the policy of a tenant, nil when it has none
+type SecurityPolicyLookup func(tenantID string) (*SecurityPolicy, error)
+
+var (
+	securityPolicyMu sync.RWMutex
+	securityPolicy   SecurityPolicyLookup
+)
+
+// SetSecurityPolicyLookup sets where the authenticators find the organizations' policies; nil turns enforcement off
+func SetSecurityPolicyLookup(lookup SecurityPolicyLookup) {
+	securityPolicyMu.Lock()
+	defer securityPolicyMu.Unlock()
+	securityPolicy = lookup
+}
+
+// tenantSecurityPolicy returns the policy of the tenant; users of the default tenant have none
+func tenantSecurityPolicy(tenantID string) (*SecurityPolicy, error) {
+	securityPolicyMu.RLock()
+	lookup := securityPolicy
+	securityPolicyMu.RUnlock()
+	if lookup == nil || tenantID == "" {
+		return nil, nil
+	}
+	return lookup(tenantID)
+}
+
+// AllowsIP reports whether the policy lets a client connect from the address
+func (p *SecurityPolicy) AllowsIP(ip string) bool {
+	if p == nil || len(p.AllowedIPRanges) == 0 {
+		return true
+	}
+	addr := net.ParseIP(ip)
+	if addr == nil {
+		return false
+	}
+	for _, ipRange := range p.AllowedIPRanges {
+		if ipRange.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
+
+// Deadline returns when the policy stops accepting the token, the zero time when it sets no limit
+func (p *SecurityPolicy) Deadline(claims *JWTClaims) time.Time {
+	var deadline time.Time
+	if p == nil {
+		return deadline
+	}
+	if p.MaxSession > 0 {
+		if start := claims.SessionStart(); !start.IsZero() {
+			deadline = start.Add(p.MaxSession)
+		}
+	}
+	if p.ReauthInterval > 0 && claims.IssuedAt != nil {
+		if reauth := claims.IssuedAt.Add(p.ReauthInterval); deadline.IsZero() || reauth.Before(deadline) {
+			deadline = reauth
+		}
+	}
+	return deadline
+}
+
+// check applies the policy to a token used from ip; refresh tokens are only held to the session length
+func (p *SecurityPolicy) check(claims *JWTClaims, ip string, now time.Time, refresh bool) error {
+	if p == nil {
+		return nil
+	}
+	if !p.AllowsIP(ip) {
+		return ErrIPNotAllowed
+	}
+	if p.MaxSession > 0 && now.Sub(claims.SessionStart()) > p.MaxSession {
+		return ErrSessionExpired
+	}
+	if !refresh && p.ReauthInterval > 0 && (claims.IssuedAt == nil || now.Sub(claims.IssuedAt.Time) > p.ReauthInterval) {
+		return ErrReauthRequired
+	}
+	return nil
+}
+
+// CheckSecurityPolicy applies the policy of the token's organization to an access token used from ip,
+// returning when the policy ends the token (zero when it doesn't)
+func CheckSecurityPolicy(claims *JWTClaims, ip string) (time.Time, error) {
+	policy, err := tenantSecurityPolicy(claims.TenantID)
+	if err != nil {
+		return time.Time{}, err
+	}
+	if err := policy.check(claims, ip, time.Now(), false); err != nil {
+		return time.Time{}, err
+	}
+	return policy.Deadline(claims), nil
+}
+
+// CheckRefreshPolicy applies the policy of the token's organization to a refresh token used from ip
+func CheckRefreshPolicy(claims *JWTClaims, ip string) error {
+	policy, err := tenantSecurityPolicy(claims.TenantID)
+	if err != nil {
+		return err
+	}
+	return policy.check(claims, ip, time.Now(), true)
+}
+
+// CheckSignInIP applies the network allowlist of the user's organization to a sign-in from ip
+func CheckSignInIP(tenantID string, ip string) error {
+	policy, err := tenantSecurityPolicy(tenantID)
+	if err != nil {
+		return err
+	}
+	if !policy.AllowsIP(ip) {
+		return ErrIPNotAllowed
+	}
+	return nil
+}
+
+// SecurityPolicyError answers a request the organization's policy refuses. A refresh is all an old access token
+// needs, so it gets 401 like an expired one; a lookup failure gets 503 rather than signing everyone out
+func SecurityPolicyError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, ErrReauthRequired):
+		return fiber.ErrUnauthorized
+	case errors.Is(err, ErrSessionExpired):
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, ErrIPNotAllowed):
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	log.Printf("Security policy lookup failed: %v", err)
+	return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+		"error": "Failed to check your organization's security policy",
+	})
+}
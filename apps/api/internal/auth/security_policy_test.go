@@ -0,0 +1,108 @@
+package auth
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestSecurityPolicyAllowsIP(t *testing.T) {
+	_, office, _ := net.ParseCIDR("203.0.113.0/24")
+	policy := &SecurityPolicy{AllowedIPRanges: []*net.IPNet{office}}
+
+	if !policy.AllowsIP("203.0.113.7") {
+		t.Error("address inside the allowlist was refused")
+	}
+	if policy.AllowsIP("198.51.100.7") {
+		t.Error("address outside the allowlist was allowed")
+	}
+	if policy.AllowsIP("not-an-ip") {
+		t.Error("unparsable address was allowed")
+	}
+	if !(&SecurityPolicy{}).AllowsIP("198.51.100.7") {
+		t.Error("an empty allowlist should allow any address")
+	}
+}
+
+func TestSecurityPolicySessionLimits(t *testing.T) {
+	now := time.Now()
+	signedIn := now.Add(-3 * time.Hour)
+	refreshed := now.Add(-10 * time.Minute)
+	claims := &JWTClaims{
+		AuthTime:         jwt.NewNumericDate(signedIn),
+		RegisteredClaims: jwt.RegisteredClaims{IssuedAt: jwt.NewNumericDate(refreshed)},
+	}
+
+	long := &SecurityPolicy{MaxSession: 8 * time.Hour}
+	if err := long.check(claims, "", now, false); err != nil {
+		t.Errorf("session within the limit refused: %v", err)
+	}
+	short := &SecurityPolicy{MaxSession: 2 * time.Hour}
+	if err := short.check(claims, "", now, true); !errors.Is(err, ErrSessionExpired) {
+		t.Errorf("refreshing past the session limit: got %v, want ErrSessionExpired", err)
+	}
+
+	reauth := &SecurityPolicy{ReauthInterval: 5 * time.Minute}
+	if err := reauth.check(claims, "", now, false); !errors.Is(err, ErrReauthRequired) {
+		t.Errorf("stale access token: got %v, want ErrReauthRequired", err)
+	}
+	if err := reauth.check(claims, "", now, true); err != nil {
+		t.Errorf("the refresh that renews a stale access token was refused: %v", err)
+	}
+
+	both := &SecurityPolicy{MaxSession: 8 * time.Hour, ReauthInterval: 5 * time.Minute}
+	if got, want := both.Deadline(claims), refreshed.Add(5*time.Minute); !got.Equal(want.Truncate(time.Second)) {
+		t.Errorf("deadline = %v, want the earlier reauth deadline %v", got, want)
+	}
+}
+
+func TestSessionStartFallsBackToIssuedAt(t *testing.T) {
+	issued := time.Now().Add(-time.Hour).Truncate(time.Second)
+	claims := &JWTClaims{RegisteredClaims: jwt.RegisteredClaims{IssuedAt: jwt.NewNumericDate(issued)}}
+	if got := claims.SessionStart(); !got.Equal(issued) {
+		t.Errorf("tokens issued before auth_time existed start their session at iat: got %v, want %v", got, issued)
+	}
+}
+
+func TestRenewedTokensKeepTheSessionStart(t *testing.T) {
+	signedIn := time.Now().Add(-2 * time.Hour)
+	refresh, _, err := generateRefreshToken("user-1", "tenant-1", signedIn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := ValidateRefreshToken(refresh)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	access, err := RenewAccessToken(claims)
+	if err != nil {
+		t.Fatal(err)
+	}
+	renewed, err := ValidateAccessToken(access)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !renewed.SessionStart().Equal(claims.SessionStart()) {
+		t.Errorf("renewed token starts its session at %v, want %v", renewed.SessionStart(), claims.SessionStart())
+	}
+	if !renewed.IssuedAt.After(signedIn) {
+		t.Error("a renewed token must be issued now, not at sign-in")
+	}
+}
+
+func TestNoSecurityPolicyWithoutLookupOrTenant(t *testing.T) {
+	SetSecurityPolicyLookup(nil)
+	if policy, err := tenantSecurityPolicy("tenant-1"); policy != nil || err != nil {
+		t.Errorf("without a lookup: got %v, %v", policy, err)
+	}
+
+	SetSecurityPolicyLookup(func(string) (*SecurityPolicy, error) { return &SecurityPolicy{}, nil })
+	t.Cleanup(func() { SetSecurityPolicyLookup(nil) })
+	if policy, err := tenantSecurityPolicy(""); policy != nil || err != nil {
+		t.Errorf("default tenant: got %v, %v", policy, err)
+	}
+}
@@ -0,0 +1,94 @@
+package auth
+
+import (
+	"errors"
+	"net"
+	"strings"
+
+	"melina-studio-backend/internal/config"
+	"melina-studio-backend/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	tenantLocalsKey   = "tenant"
+	tenantIDLocalsKey = "tenantID"
+)
+
+// ErrUnknownTenant is returned by tenant lookups for slugs that don't exist
+var ErrUnknownTenant = errors.New("unknown tenant")
+
+// TenantLookup resolves a tenant by slug
+type TenantLookup func(slug string) (*models.Tenant, error)
+
+// TenantMiddleware resolves the request's tenant from the tenant header or the subdomain
+// Requests without a tenant belong to the default tenant. When multi-tenant mode is off this is a no-op
+func TenantMiddleware(cfg config.TenantConfig, lookup TenantLookup) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		if !cfg.Enabled {
+			return c.Next()
+		}
+
+		slug := ResolveTenantSlug(c.Get(cfg.Header), c.Hostname(), cfg.BaseDomain)
+		if slug == "" {
+			c.Locals(tenantIDLocalsKey, "")
+			return c.Next()
+		}
+
+		tenant, err := lookup(slug)
+		if err != nil {
+			if errors.Is(err, ErrUnknownTenant) {
+				return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+					"error": "Unknown tenant",
+				})
+			}
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to resolve tenant",
+			})
+		}
+		if !tenant.IsActive {
+			return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+				"error": "Tenant is disabled",
+			})
+		}
+
+		c.Locals(tenantLocalsKey, tenant)
+		c.Locals(tenantIDLocalsKey, tenant.UUID.String())
+		return c.Next()
+	}
+}
+
+// ResolveTenantSlug picks the tenant slug from the header, or else from the first label of a subdomain of baseDomain
+func ResolveTenantSlug(header string, host string, baseDomain string) string {
+	if slug := strings.ToLower(strings.TrimSpace(header)); slug != "" {
+		return slug
+	}
+	if baseDomain == "" {
+		return ""
+	}
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if !strings.HasSuffix(host, "."+baseDomain) {
+		return ""
+	}
+	sub := strings.TrimSuffix(host, "."+baseDomain)
+	if sub == "" || sub == "www" || strings.Contains(sub, ".") {
+		return ""
+	}
+	return sub
+}
+
+// TenantFromCtx returns the tenant resolved for the request, nil for the default tenant or when multi-tenant mode is off
+func TenantFromCtx(c *fiber.Ctx) *models.Tenant {
+	tenant, _ := c.Locals(tenantLocalsKey).(*models.Tenant)
+	return tenant
+}
+
+// TenantIDFromCtx returns the resolved tenant ID ("" for the default tenant) and whether tenant isolation is enforced
+func TenantIDFromCtx(c *fiber.Ctx) (string, bool) {
+	tenantID, ok := c.Locals(tenantIDLocalsKey).(string)
+	return tenantID, ok
+}
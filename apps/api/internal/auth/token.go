@@ -4,30 +4,63 @@ import (
 	"errors"
 	"time"
 
+	"melina-studio-backend/internal/config"
+
 	"github.com/golang-jwt/jwt/v5"
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
 )
 
-var (
-	AccessSecret  = []byte("ACCESS_SECRET")
-	RefreshSecret = []byte("REFRESH_SECRET")
-)
-
 const (
 	AccessTokenExpiry  = 15 * time.Minute
 	RefreshTokenExpiry = 7 * 24 * time.Hour // 7 days
 )
 
+func accessSecret() []byte {
+	return []byte(config.GetSettings().Auth.JWTSecret)
+}
+
+func refreshSecret() []byte {
+	return []byte(config.GetSettings().Auth.JWTRefreshSecret)
+}
+
 type JWTClaims struct {
 	UserID string `json:"user_id"`
+	// TenantID is empty for users of the default tenant
+	TenantID string `json:"tenant_id,omitempty"`
+	// AuthTime is when the user signed in; refreshed tokens keep it, so it marks the start of the session
+	AuthTime *jwt.NumericDate `json:"auth_time,omitempty"`
 	jwt.RegisteredClaims
 }
 
-func GenerateAccessToken(userID string) (string, error) {
+// SessionStart returns when the token's session began; tokens issued before auth_time existed count from their issue time
+func (c *JWTClaims) SessionStart() time.Time {
+	if c.AuthTime != nil {
+		return c.AuthTime.Time
+	}
+	if c.IssuedAt != nil {
+		return c.IssuedAt.Time
+	}
+	return time.Time{}
+}
+
+// GenerateAccessToken creates the access token of a user who just signed in
+func GenerateAccessToken(userID string, tenantID string) (string, error) {
+	return generateAccessToken(userID, tenantID, time.Now())
+}
+
+// RenewAccessToken creates an access token for the session of a refresh token, keeping its sign-in time
+func RenewAccessToken(refreshClaims *JWTClaims) (string, error) {
+	return generateAccessToken(refreshClaims.UserID, refreshClaims.TenantID, refreshClaims.SessionStart())
+}
+
+func generateAccessToken(userID string, tenantID string, authTime time.Time) (string, error) {
 	claims := &JWTClaims{
-		UserID: userID,
+		UserID:   userID,
+		TenantID: tenantID,
+		AuthTime: jwt.NewNumericDate(authTime),
 		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        uuid.NewString(), // JTI - lets a single token be revoked on logout
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(AccessTokenExpiry)),
 			IssuedAt:  jwt.NewNumericDate(time.Now()),
 			Issuer:    "melina-studio-backend",
@@ -35,15 +68,26 @@ func GenerateAccessToken(userID string) (string, error) {
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(AccessSecret)
+	return token.SignedString(accessSecret())
 }
 
 // GenerateRefreshToken creates a JWT refresh token with a unique ID for DB tracking
-func GenerateRefreshToken(userID string) (string, string, error) {
+func GenerateRefreshToken(userID string, tenantID string) (string, string, error) {
+	return generateRefreshToken(userID, tenantID, time.Now())
+}
+
+// RenewRefreshToken creates the refresh token that replaces one on rotation, keeping its sign-in time
+func RenewRefreshToken(refreshClaims *JWTClaims) (string, string, error) {
+	return generateRefreshToken(refreshClaims.UserID, refreshClaims.TenantID, refreshClaims.SessionStart())
+}
+
+func generateRefreshToken(userID string, tenantID string, authTime time.Time) (string, string, error) {
 	tokenID := uuid.NewString() // unique ID for DB storage and revocation
 
 	claims := &JWTClaims{
-		UserID: userID,
+		UserID:   userID,
+		TenantID: tenantID,
+		AuthTime: jwt.NewNumericDate(authTime),
 		RegisteredClaims: jwt.RegisteredClaims{
 			ID:        tokenID, // JTI - used to track in DB
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(RefreshTokenExpiry)),
@@ -53,7 +97,7 @@ func GenerateRefreshToken(userID string) (string, string, error) {
 	}
 
 	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	signedToken, err := token.SignedString(RefreshSecret)
+	signedToken, err := token.SignedString(refreshSecret())
 	if err != nil {
 		return "", "", err
 	}
@@ -64,7 +108,7 @@ func GenerateRefreshToken(userID string) (string, string, error) {
 // ValidateRefreshToken validates a refresh token and returns the claims
 func ValidateRefreshToken(tokenString string) (*JWTClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (any, error) {
-		return RefreshSecret, nil
+		return refreshSecret(), nil
 	})
 
 	if err != nil {
@@ -83,20 +127,24 @@ func GetRefreshTokenExpiry() time.Time {
 	return time.Now().Add(RefreshTokenExpiry)
 }
 
+// ValidateAccessToken validates an access token and returns the claims, rejecting tokens revoked by a logout
 func ValidateAccessToken(tokenString string) (*JWTClaims, error) {
 	token, err := jwt.ParseWithClaims(tokenString, &JWTClaims{}, func(token *jwt.Token) (interface{}, error) {
-		return AccessSecret, nil
+		return accessSecret(), nil
 	})
 
 	if err != nil {
 		return nil, err
 	}
 
-	if claims, ok := token.Claims.(*JWTClaims); ok && token.Valid {
-		return claims, nil
+	claims, ok := token.Claims.(*JWTClaims)
+	if !ok || !token.Valid {
+		return nil, errors.New("invalid token")
 	}
-
-	return nil, errors.New("invalid token")
+	if isAccessTokenRevoked(claims) {
+		return nil, errors.New("token has been revoked")
+	}
+	return claims, nil
 }
 
 func HashPassword(password string) (string, error) {
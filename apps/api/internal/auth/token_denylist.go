@@ -0,0 +1,197 @@
+package auth
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// TokenDenylist rejects access tokens before they expire: one token by its JTI on logout, or every token of a
+// user issued before a cutoff on logout-all. Entries only need to outlive the tokens they reject, so they
+// expire after AccessTokenExpiry
+type TokenDenylist interface {
+	RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error
+	RevokeUserTokens(ctx context.Context, userID string, issuedBefore time.Time) error
+	IsRevoked(ctx context.Context, claims *JWTClaims) (bool, error)
+}
+
+// denylistTimeout bounds the lookup each authenticated request makes
+const denylistTimeout = 500 * time.Millisecond
+
+var (
+	denylistMu sync.RWMutex
+	denylist   TokenDenylist = newMemoryDenylist()
+)
+
+// InitTokenDenylist shares the denylist between instances through Redis; without a URL it stays in memory,
+// which only protects the instance that revoked the tokens
+func InitTokenDenylist(redisURL string) error {
+	if redisURL == "" {
+		return nil
+	}
+	opts, err := redis.ParseURL(redisURL)
+	if err != nil {
+		return fmt.Errorf("invalid REDIS_URL: %w", err)
+	}
+	client := redis.NewClient(opts)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := client.Ping(ctx).Err(); err != nil {
+		return fmt.Errorf("failed to connect to redis: %w", err)
+	}
+	SetTokenDenylist(&redisDenylist{client: client})
+	return nil
+}
+
+// SetTokenDenylist replaces the denylist, e.g. in tests
+func SetTokenDenylist(d TokenDenylist) {
+	denylistMu.Lock()
+	defer denylistMu.Unlock()
+	denylist = d
+}
+
+func getTokenDenylist() TokenDenylist {
+	denylistMu.RLock()
+	defer denylistMu.RUnlock()
+	return denylist
+}
+
+// RevokeAccessToken rejects one access token until it expires
+func RevokeAccessToken(ctx context.Context, claims *JWTClaims) error {
+	if claims == nil || claims.ID == "" {
+		return nil
+	}
+	expiresAt := time.Now().Add(AccessTokenExpiry)
+	if claims.ExpiresAt != nil {
+		expiresAt = claims.ExpiresAt.Time
+	}
+	return getTokenDenylist().RevokeToken(ctx, claims.ID, expiresAt)
+}
+
+// RevokeUserAccessTokens rejects every access token of the user issued up to now
+func RevokeUserAccessTokens(ctx context.Context, userID string) error {
+	return getTokenDenylist().RevokeUserTokens(ctx, userID, time.Now())
+}
+
+// isAccessTokenRevoked checks a validated token against the denylist. When the denylist can't be reached
+// the token is accepted: an outage of Redis shouldn't sign everyone out
+func isAccessTokenRevoked(claims *JWTClaims) bool {
+	ctx, cancel := context.WithTimeout(context.Background(), denylistTimeout)
+	defer cancel()
+	revoked, err := getTokenDenylist().IsRevoked(ctx, claims)
+	if err != nil {
+		log.Printf("Token denylist lookup failed, accepting the token: %v", err)
+		return false
+	}
+	return revoked
+}
+
+// issuedBeforeCutoff reports whether a token predates a logout-all. IssuedAt has second precision, so a token
+// issued in the same second as the cutoff, such as the one from signing in again right away, stays valid
+func issuedBeforeCutoff(claims *JWTClaims, cutoffUnix int64) bool {
+	if claims.IssuedAt == nil {
+		return true
+	}
+	return claims.IssuedAt.Unix() < cutoffUnix
+}
+
+// redisDenylist keeps the denylist in Redis so every instance rejects revoked tokens
+type redisDenylist struct {
+	client *redis.Client
+}
+
+func redisTokenKey(jti string) string   { return "auth:denylist:jti:" + jti }
+func redisUserKey(userID string) string { return "auth:denylist:user:" + userID }
+
+func (d *redisDenylist) RevokeToken(ctx context.Context, jti string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	return d.client.Set(ctx, redisTokenKey(jti), 1, ttl).Err()
+}
+
+func (d *redisDenylist) RevokeUserTokens(ctx context.Context, userID string, issuedBefore time.Time) error {
+	return d.client.Set(ctx, redisUserKey(userID), issuedBefore.Unix(), AccessTokenExpiry).Err()
+}
+
+func (d *redisDenylist) IsRevoked(ctx context.Context, claims *JWTClaims) (bool, error) {
+	keys := []string{redisUserKey(claims.UserID)}
+	if claims.ID != "" {
+		keys = append(keys, redisTokenKey(claims.ID))
+	}
+	values, err := d.client.MGet(ctx, keys...).Result()
+	if err != nil {
+		return false, err
+	}
+	if cutoff, ok := values[0].(string); ok {
+		if cutoffUnix, err := strconv.ParseInt(cutoff, 10, 64); err == nil && issuedBeforeCutoff(claims, cutoffUnix) {
+			return true, nil
+		}
+	}
+	return len(values) > 1 && values[1] != nil, nil
+}
+
+// memoryDenylist is the single-instance denylist used without Redis
+type memoryDenylist struct {
+	mu      sync.Mutex
+	tokens  map[string]time.Time // jti -> token expiry
+	cutoffs map[string]memoryCutoff
+}
+
+type memoryCutoff struct {
+	issuedBefore int64
+	expiresAt    time.Time
+}
+
+func newMemoryDenylist() *memoryDenylist {
+	return &memoryDenylist{tokens: make(map[string]time.Time), cutoffs: make(map[string]memoryCutoff)}
+}
+
+func (d *memoryDenylist) RevokeToken(_ context.Context, jti string, expiresAt time.Time) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.prune(time.Now())
+	d.tokens[jti] = expiresAt
+	return nil
+}
+
+func (d *memoryDenylist) RevokeUserTokens(_ context.Context, userID string, issuedBefore time.Time) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	d.prune(time.Now())
+	d.cutoffs[userID] = memoryCutoff{issuedBefore: issuedBefore.Unix(), expiresAt: time.Now().Add(AccessTokenExpiry)}
+	return nil
+}
+
+func (d *memoryDenylist) IsRevoked(_ context.Context, claims *JWTClaims) (bool, error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	now := time.Now()
+	if cutoff, ok := d.cutoffs[claims.UserID]; ok && now.Before(cutoff.expiresAt) && issuedBeforeCutoff(claims, cutoff.issuedBefore) {
+		return true, nil
+	}
+	if expiresAt, ok := d.tokens[claims.ID]; ok && claims.ID != "" && now.Before(expiresAt) {
+		return true, nil
+	}
+	return false, nil
+}
+
+// prune drops the entries whose tokens have expired anyway
+func (d *memoryDenylist) prune(now time.Time) {
+	for jti, expiresAt := range d.tokens {
+		if !now.Before(expiresAt) {
+			delete(d.tokens, jti)
+		}
+	}
+	for userID, cutoff := range d.cutoffs {
+		if !now.Before(cutoff.expiresAt) {
+			delete(d.cutoffs, userID)
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestLogoutRevokesAccessToken(t *testing.T) {
+	SetTokenDenylist(newMemoryDenylist())
+	t.Cleanup(func() { SetTokenDenylist(newMemoryDenylist()) })
+
+	token, err := GenerateAccessToken("user-1", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	claims, err := ValidateAccessToken(token)
+	if err != nil {
+		t.Fatalf("fresh token rejected: %v", err)
+	}
+	if claims.ID == "" {
+		t.Fatal("access tokens must carry a JTI")
+	}
+
+	other, _ := GenerateAccessToken("user-1", "")
+
+	if err := RevokeAccessToken(context.Background(), claims); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := ValidateAccessToken(token); err == nil {
+		t.Error("revoked token is still accepted")
+	}
+	if _, err := ValidateAccessToken(other); err != nil {
+		t.Errorf("revoking one token rejected another: %v", err)
+	}
+}
+
+func TestLogoutAllRevokesEarlierTokens(t *testing.T) {
+	d := newMemoryDenylist()
+	ctx := context.Background()
+	now := time.Now()
+	claimsAt := func(userID string, issuedAt time.Time) *JWTClaims {
+		return &JWTClaims{UserID: userID, RegisteredClaims: jwt.RegisteredClaims{ID: "jti", IssuedAt: jwt.NewNumericDate(issuedAt)}}
+	}
+
+	if err := d.RevokeUserTokens(ctx, "user-1", now); err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name   string
+		claims *JWTClaims
+		want   bool
+	}{
+		{"issued before logout-all", claimsAt("user-1", now.Add(-time.Minute)), true},
+		{"issued after logout-all", claimsAt("user-1", now.Add(2*time.Second)), false},
+		{"another user", claimsAt("user-2", now.Add(-time.Minute)), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := d.IsRevoked(ctx, tt.claims)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got != tt.want {
+				t.Errorf("IsRevoked = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMemoryDenylistForgetsExpiredTokens(t *testing.T) {
+	d := newMemoryDenylist()
+	ctx := context.Background()
+	d.RevokeToken(ctx, "expired", time.Now().Add(-time.Second))
+	d.RevokeToken(ctx, "live", time.Now().Add(time.Minute))
+
+	if len(d.tokens) != 1 {
+		t.Errorf("expired entries should be pruned, have %d", len(d.tokens))
+	}
+	if revoked, _ := d.IsRevoked(ctx, &JWTClaims{RegisteredClaims: jwt.RegisteredClaims{ID: "live"}}); !revoked {
+		t.Error("live entry should be revoked")
+	}
+}
@@ -0,0 +1,41 @@
+package config
+
+import "time"
+
+// AutomationConfig holds the limits of the per-board automation rules
+type AutomationConfig struct {
+	// Enabled evaluates the rules when users save shapes; they can be managed either way
+	Enabled bool
+	// MaxRulesPerBoard caps the rules of one board
+	MaxRulesPerBoard int
+	// MaxRunsPerSave caps the runs one save can start, so pasting a hundred tagged notes doesn't start a hundred agents
+	MaxRunsPerSave int
+	// Cooldown is how long a rule waits before it fires again for the same shape, as every drag of a shape saves it
+	Cooldown time.Duration
+	// AgentTimeout bounds an agent_prompt run
+	AgentTimeout time.Duration
+	// Webhooks are the limits of the webhook action's calls: only the hosts listed in AUTOMATION_WEBHOOK_ALLOWED_HOSTS,
+	// https unless AUTOMATION_WEBHOOK_ALLOW_HTTP, and no private networks unless AUTOMATION_WEBHOOK_ALLOW_PRIVATE_NETWORKS
+	Webhooks CustomToolConfig
+}
+
+// LoadAutomationConfig loads the automation limits from environment variables
+func LoadAutomationConfig() AutomationConfig {
+	timeout := time.Duration(envNonNegativeInt("AUTOMATION_WEBHOOK_TIMEOUT_SECONDS", 10)) * time.Second
+	return AutomationConfig{
+		Enabled:          envBool("AUTOMATIONS_ENABLED", true),
+		MaxRulesPerBoard: envNonNegativeInt("AUTOMATIONS_MAX_RULES_PER_BOARD", 20),
+		MaxRunsPerSave:   envNonNegativeInt("AUTOMATIONS_MAX_RUNS_PER_SAVE", 10),
+		Cooldown:         time.Duration(envNonNegativeInt("AUTOMATIONS_COOLDOWN_SECONDS", 60)) * time.Second,
+		AgentTimeout:     time.Duration(envNonNegativeInt("AUTOMATIONS_AGENT_TIMEOUT_SECONDS", 180)) * time.Second,
+		Webhooks: CustomToolConfig{
+			Enabled:              true,
+			AllowedHosts:         envHostList("AUTOMATION_WEBHOOK_ALLOWED_HOSTS"),
+			AllowHTTP:            envBool("AUTOMATION_WEBHOOK_ALLOW_HTTP", false),
+			AllowPrivateNetworks: envBool("AUTOMATION_WEBHOOK_ALLOW_PRIVATE_NETWORKS", false),
+			DefaultTimeout:       timeout,
+			MaxTimeout:           timeout,
+			MaxResponseBytes:     64 * 1024,
+		},
+	}
+}
@@ -0,0 +1,62 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// BackupConfig holds configuration for tenant backups
+type BackupConfig struct {
+	// Enabled turns on the scheduled backups; on-demand backups through the admin API work regardless
+	Enabled  bool
+	Interval time.Duration
+	// Keep is how many backups are kept per user, older ones are pruned after each scheduled run
+	Keep   int
+	Bucket string
+	Prefix string
+	// AdminToken guards the admin API (X-Admin-Token header); when empty the admin API is disabled
+	AdminToken string
+}
+
+// LoadBackupConfig loads backup configuration from environment variables
+func LoadBackupConfig() BackupConfig {
+	enabled := false
+	if val := os.Getenv("BACKUP_ENABLED"); val != "" {
+		enabled, _ = strconv.ParseBool(val)
+	}
+
+	intervalHours := 24
+	if val := os.Getenv("BACKUP_INTERVAL_HOURS"); val != "" {
+		if parsed, err := strconv.Atoi(val); err == nil && parsed > 0 {
+			intervalHours = parsed
+		}
+	}
+
+	keep := 7
+	if val := os.Getenv("BACKUP_KEEP"); val != "" {
+		if parsed, err := strconv.Atoi(val); err == nil && parsed > 0 {
+			keep = parsed
+		}
+	}
+
+	bucket := os.Getenv("BACKUP_BUCKET")
+	if bucket == "" {
+		bucket = GetSettings().GCP.StorageBucket
+	}
+
+	prefix := strings.Trim(os.Getenv("BACKUP_PREFIX"), "/")
+	if prefix == "" {
+		prefix = "backups"
+	}
+
+	return BackupConfig{
+		Enabled:    enabled,
+		Interval:   time.Duration(intervalHours) * time.Hour,
+		Keep:       keep,
+		Bucket:     bucket,
+		Prefix:     prefix,
+		AdminToken: os.Getenv("BACKUP_ADMIN_TOKEN"),
+	}
+}
@@ -0,0 +1,22 @@
+package config
+
+import "time"
+
+// BoardVersionConfig holds the settings of the board snapshots
+type BoardVersionConfig struct {
+	// Enabled takes the periodic snapshots; manual ones and restores work either way
+	Enabled bool
+	// Interval is how often each instance snapshots the boards whose shapes changed since its last pass
+	Interval time.Duration
+	// MaxPerBoard caps the snapshots kept per board; the oldest are deleted first
+	MaxPerBoard int
+}
+
+// LoadBoardVersionConfig loads the board snapshot settings from environment variables
+func LoadBoardVersionConfig() BoardVersionConfig {
+	return BoardVersionConfig{
+		Enabled:     envBool("BOARD_VERSIONS_ENABLED", true),
+		Interval:    time.Duration(max(envNonNegativeInt("BOARD_VERSIONS_INTERVAL_MINUTES", 30), 1)) * time.Minute,
+		MaxPerBoard: max(envNonNegativeInt("BOARD_VERSIONS_MAX_PER_BOARD", 50), 1),
+	}
+}
@@ -11,6 +11,8 @@ type CleanupConfig struct {
 	Enabled  bool
 	Interval time.Duration
 	MaxAge   time.Duration
+	// SandboxTTL is how long a sandbox board lives before the cleanup service deletes it
+	SandboxTTL time.Duration
 }
 
 // LoadCleanupConfig loads cleanup configuration from environment variables
@@ -34,9 +36,17 @@ func LoadCleanupConfig() CleanupConfig {
 		}
 	}
 
+	sandboxHours := 24
+	if val := os.Getenv("CLEANUP_SANDBOX_HOURS"); val != "" {
+		if parsed, err := strconv.Atoi(val); err == nil && parsed > 0 {
+			sandboxHours = parsed
+		}
+	}
+
 	return CleanupConfig{
-		Enabled:  enabled,
-		Interval: time.Duration(intervalMinutes) * time.Minute,
-		MaxAge:   time.Duration(maxAgeMinutes) * time.Minute,
+		Enabled:    enabled,
+		Interval:   time.Duration(intervalMinutes) * time.Minute,
+		MaxAge:     time.Duration(maxAgeMinutes) * time.Minute,
+		SandboxTTL: time.Duration(sandboxHours) * time.Hour,
 	}
 }
@@ -0,0 +1,23 @@
+package config
+
+import (
+	"testing"
+	"time"
+)
+
+func TestLoadCleanupConfigSandboxTTL(t *testing.T) {
+	t.Setenv("CLEANUP_SANDBOX_HOURS", "")
+	if got := LoadCleanupConfig().SandboxTTL; got != 24*time.Hour {
+		t.Errorf("default sandbox TTL should be 24h, got %v", got)
+	}
+
+	t.Setenv("CLEANUP_SANDBOX_HOURS", "6")
+	if got := LoadCleanupConfig().SandboxTTL; got != 6*time.Hour {
+		t.Errorf("expected 6h, got %v", got)
+	}
+
+	t.Setenv("CLEANUP_SANDBOX_HOURS", "0")
+	if got := LoadCleanupConfig().SandboxTTL; got != 24*time.Hour {
+		t.Errorf("a non-positive value should keep the default, got %v", got)
+	}
+}
@@ -0,0 +1,29 @@
+package config
+
+import "compress/flate"
+
+// CompressionConfig controls the compression of websocket messages and REST responses
+type CompressionConfig struct {
+	// WebSocket negotiates permessage-deflate with clients that support it
+	WebSocket bool
+	// WebSocketThreshold is the smallest message in bytes that is compressed; small deltas aren't worth the CPU
+	WebSocketThreshold int
+	// WebSocketLevel is the flate level of compressed messages
+	WebSocketLevel int
+	// GzipThreshold is the smallest REST response body in bytes that is gzipped; 0 disables gzip
+	GzipThreshold int
+}
+
+// LoadCompressionConfig loads compression configuration from environment variables
+func LoadCompressionConfig() CompressionConfig {
+	level := envNonNegativeInt("WS_COMPRESSION_LEVEL", flate.BestSpeed)
+	if level < flate.BestSpeed || level > flate.BestCompression {
+		level = flate.BestSpeed
+	}
+	return CompressionConfig{
+		WebSocket:          envBool("WS_COMPRESSION", true),
+		WebSocketThreshold: envNonNegativeInt("WS_COMPRESSION_THRESHOLD", 1024),
+		WebSocketLevel:     level,
+		GzipThreshold:      envNonNegativeInt("HTTP_GZIP_THRESHOLD", 4096),
+	}
+}
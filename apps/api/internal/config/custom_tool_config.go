@@ -0,0 +1,85 @@
+package config
+
+import (
+	"net"
+	"os"
+	"strings"
+	"time"
+)
+
+// CustomToolConfig holds the limits of the declarative HTTP tools admins add without a deploy
+type CustomToolConfig struct {
+	// Enabled offers the custom tools to the agent; they can be managed either way
+	Enabled bool
+	// AllowedHosts are the hosts the tools may call: exact names, or "*.example.com" for its subdomains.
+	// Empty allows none, so no tool can run until the deployment lists its integrations
+	AllowedHosts []string
+	// AllowHTTP lets tools call plain http URLs; by default only https is allowed
+	AllowHTTP bool
+	// AllowPrivateNetworks lets tools reach loopback, private and link-local addresses, e.g. a sidecar in development
+	AllowPrivateNetworks bool
+	// DefaultTimeout applies to tools without their own; MaxTimeout caps the ones they set
+	DefaultTimeout time.Duration
+	MaxTimeout     time.Duration
+	// MaxResponseBytes is the largest response body a tool call reads; larger ones fail the call
+	MaxResponseBytes int64
+	// ReloadInterval is how often each instance reloads the tools, so changes reach every instance
+	ReloadInterval time.Duration
+}
+
+// LoadCustomToolConfig loads the custom tool limits from environment variables
+func LoadCustomToolConfig() CustomToolConfig {
+	return CustomToolConfig{
+		Enabled:              envBool("CUSTOM_TOOLS_ENABLED", false),
+		AllowedHosts:         envHostList("CUSTOM_TOOLS_ALLOWED_HOSTS"),
+		AllowHTTP:            envBool("CUSTOM_TOOLS_ALLOW_HTTP", false),
+		AllowPrivateNetworks: envBool("CUSTOM_TOOLS_ALLOW_PRIVATE_NETWORKS", false),
+		DefaultTimeout:       time.Duration(envNonNegativeInt("CUSTOM_TOOLS_TIMEOUT_SECONDS", 10)) * time.Second,
+		MaxTimeout:           time.Duration(envNonNegativeInt("CUSTOM_TOOLS_MAX_TIMEOUT_SECONDS", 30)) * time.Second,
+		MaxResponseBytes:     int64(envPositiveInt("CUSTOM_TOOLS_MAX_RESPONSE_KB", 256)) * 1024,
+		ReloadInterval:       time.Duration(envNonNegativeInt("CUSTOM_TOOLS_RELOAD_SECONDS", 30)) * time.Second,
+	}
+}
+
+// envPositiveInt reads a count where 0 makes no sense, such as a size cap that would fail every call; 0 and
+// below mean the fallback
+func envPositiveInt(name string, fallback int) int {
+	if parsed := envNonNegativeInt(name, fallback); parsed > 0 {
+		return parsed
+	}
+	return fallback
+}
+
+// envHostList reads a comma-separated list of host names, lower-cased
+func envHostList(name string) []string {
+	var hosts []string
+	for _, host := range strings.Split(os.Getenv(name), ",") {
+		if host = strings.ToLower(strings.TrimSpace(host)); host != "" {
+			hosts = append(hosts, host)
+		}
+	}
+	return hosts
+}
+
+// AllowsHost reports whether the tools may call the host, given with or without a port
+func (c CustomToolConfig) AllowsHost(host string) bool {
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "" {
+		return false
+	}
+	for _, allowed := range c.AllowedHosts {
+		if suffix, ok := strings.CutPrefix(allowed, "*."); ok {
+			if strings.HasSuffix(host, "."+suffix) {
+				return true
+			}
+			continue
+		}
+		if host == allowed {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,36 @@
+package config
+
+import "testing"
+
+func TestCustomToolConfigAllowsHost(t *testing.T) {
+	cfg := CustomToolConfig{AllowedHosts: []string{"api.atlassian.com", "*.example.com"}}
+	cases := map[string]bool{
+		"api.atlassian.com":      true,
+		"API.Atlassian.com:443":  true,
+		"atlassian.com":          false,
+		"jira.example.com":       true,
+		"a.b.example.com":        true,
+		"example.com":            false,
+		"evilexample.com":        false,
+		"api.atlassian.com.evil": false,
+		"":                       false,
+	}
+	for host, want := range cases {
+		if got := cfg.AllowsHost(host); got != want {
+			t.Errorf("AllowsHost(%q) = %v, want %v", host, got, want)
+		}
+	}
+	if (CustomToolConfig{}).AllowsHost("api.atlassian.com") {
+		t.Error("an empty allowlist allowed a host")
+	}
+}
+
+func TestCustomToolConfigMaxResponse(t *testing.T) {
+	cases := map[string]int64{"": 256 * 1024, "0": 256 * 1024, "-5": 256 * 1024, "64": 64 * 1024}
+	for value, want := range cases {
+		t.Setenv("CUSTOM_TOOLS_MAX_RESPONSE_KB", value)
+		if got := LoadCustomToolConfig().MaxResponseBytes; got != want {
+			t.Errorf("CUSTOM_TOOLS_MAX_RESPONSE_KB=%q: got %d, want %d", value, got, want)
+		}
+	}
+}
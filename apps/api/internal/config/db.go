@@ -4,7 +4,6 @@ import (
 	"fmt"
 	"log"
 	"melina-studio-backend/internal/models"
-	"os"
 	"time"
 
 	"gorm.io/driver/postgres"
@@ -15,7 +14,7 @@ import (
 var DB *gorm.DB
 
 func ConnectDB() error {
-	dsn := os.Getenv("DB_URL")
+	dsn := GetSettings().Database.URL
 
 	var err error
 	DB, err = gorm.Open(postgres.New(postgres.Config{
@@ -54,16 +53,46 @@ func MigrateAllModels(run bool) error {
 			&models.Chat{},
 			&models.RefreshToken{},
 			&models.TempUpload{},
+			&models.StoredObject{},
+			&models.StorageUsage{},
 			&models.TokenConsumption{},
 			&models.SubscriptionTier{},
 			&models.Order{},
 			&models.CustomRules{},
+			&models.FacilitationSession{},
+			&models.BoardSummary{},
+			&models.CodeArtifact{},
+			&models.BoardActivity{},
+			&models.RetentionPolicy{},
+			&models.Tenant{},
+			&models.FeatureFlag{},
+			&models.Budget{},
+			&models.OnboardingProgress{},
+			&models.Announcement{},
+			&models.AnnouncementRead{},
+			&models.MessageFeedback{},
+			&models.TurnMetadata{},
+			&models.BoardViewport{},
+			&models.CleanupSettings{},
+			&models.CleanupRun{},
+			&models.LLMDataAudit{},
+			&models.TenantSecurityPolicy{},
+			&models.CustomTool{},
+			&models.AutomationRule{},
+			&models.AutomationRun{},
+			&models.ExportSchedule{},
+			&models.ExportRun{},
+			&models.BoardInbox{},
+			&models.BoardVersion{},
+			&models.APIKey{},
 		)
 		if err != nil {
 			return fmt.Errorf("failed to migrate database: %w", err)
 		}
 		log.Println("✅ Database migration completed")
 
+		createSearchIndexes()
+
 		// // Seed subscription plans
 		// err = SeedSubscriptionPlans(DB)
 		// if err != nil {
@@ -77,6 +106,23 @@ func MigrateAllModels(run bool) error {
 	}
 }
 
+// searchIndexes are the full-text indexes behind GET /search
+// The expressions must match the ones used in repo/search.go for Postgres to use them
+var searchIndexes = []string{
+	`CREATE INDEX IF NOT EXISTS idx_boards_title_fts ON boards USING GIN (to_tsvector('english', title))`,
+	`CREATE INDEX IF NOT EXISTS idx_board_data_text_fts ON board_data USING GIN (to_tsvector('english', coalesce(data->>'text', '') || ' ' || coalesce(data->>'name', '')))`,
+	`CREATE INDEX IF NOT EXISTS idx_chats_content_fts ON chats USING GIN (to_tsvector('english', content))`,
+}
+
+// createSearchIndexes creates the full-text indexes, which AutoMigrate can't express
+func createSearchIndexes() {
+	for _, stmt := range searchIndexes {
+		if err := DB.Exec(stmt).Error; err != nil {
+			log.Printf("⚠️ Warning: Failed to create search index: %v", err)
+		}
+	}
+}
+
 func CloseDB() error {
 	sqlDB, err := DB.DB()
 	if err != nil {
@@ -0,0 +1,45 @@
+package config
+
+import (
+	"strings"
+
+	"melina-studio-backend/internal/models"
+)
+
+// ExportConfig holds the watermark composited on the board images users export and share
+type ExportConfig struct {
+	// Watermark maps a plan to whether its exports carry the watermark
+	Watermark map[models.Subscription]bool
+	// WatermarkText is drawn when no logo is configured
+	WatermarkText string
+	// WatermarkImage is the path of a PNG logo drawn instead of the text
+	WatermarkImage string
+	// WatermarkPosition is top-left, top-right, bottom-left, bottom-right or center
+	WatermarkPosition string
+	// WatermarkOpacity is between 0 and 1
+	WatermarkOpacity float64
+}
+
+// LoadExportConfig loads the export watermark from environment variables; by default only free exports carry it
+func LoadExportConfig() ExportConfig {
+	return ExportConfig{
+		Watermark: map[models.Subscription]bool{
+			models.SubscriptionFree:     envBool("EXPORT_WATERMARK_FREE", true),
+			models.SubscriptionPro:      envBool("EXPORT_WATERMARK_PRO", false),
+			models.SubscriptionPremium:  envBool("EXPORT_WATERMARK_PREMIUM", false),
+			models.SubscriptionOnDemand: envBool("EXPORT_WATERMARK_ON_DEMAND", false),
+		},
+		WatermarkText:     envString("EXPORT_WATERMARK_TEXT", "Made with Melina Studio"),
+		WatermarkImage:    envString("EXPORT_WATERMARK_IMAGE", ""),
+		WatermarkPosition: strings.ToLower(envString("EXPORT_WATERMARK_POSITION", "bottom-right")),
+		WatermarkOpacity:  float64(min(envNonNegativeInt("EXPORT_WATERMARK_OPACITY_PERCENT", 70), 100)) / 100,
+	}
+}
+
+// Watermarked reports whether a plan's exports carry the watermark; unknown plans are treated as free
+func (c ExportConfig) Watermarked(plan models.Subscription) bool {
+	if watermarked, ok := c.Watermark[plan]; ok {
+		return watermarked
+	}
+	return c.Watermark[models.SubscriptionFree]
+}
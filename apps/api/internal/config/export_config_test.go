@@ -0,0 +1,26 @@
+package config
+
+import (
+	"testing"
+
+	"melina-studio-backend/internal/models"
+)
+
+func TestExportWatermarkPlans(t *testing.T) {
+	t.Setenv("EXPORT_WATERMARK_PRO", "")
+	cfg := LoadExportConfig()
+	if !cfg.Watermarked(models.SubscriptionFree) {
+		t.Error("free exports should carry the watermark by default")
+	}
+	if cfg.Watermarked(models.SubscriptionPro) || cfg.Watermarked(models.SubscriptionPremium) {
+		t.Error("paid exports should be free of the watermark by default")
+	}
+	if !cfg.Watermarked("legacy") {
+		t.Error("unknown plans should be treated as free")
+	}
+
+	t.Setenv("EXPORT_WATERMARK_PRO", "true")
+	if !LoadExportConfig().Watermarked(models.SubscriptionPro) {
+		t.Error("EXPORT_WATERMARK_PRO should add the watermark to pro exports")
+	}
+}
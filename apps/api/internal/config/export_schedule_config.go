@@ -0,0 +1,46 @@
+package config
+
+import "time"
+
+// ExportScheduleConfig holds the limits of the scheduled board exports
+type ExportScheduleConfig struct {
+	// Enabled runs the due schedules; they can be managed either way
+	Enabled bool
+	// PollInterval is how often each instance looks for due schedules
+	PollInterval time.Duration
+	// MaxPerBoard caps the schedules of one board
+	MaxPerBoard int
+	// DefaultHour is the UTC hour schedules run at when they don't pick one
+	DefaultHour int
+	// UploadTimeout bounds rendering and pushing one export
+	UploadTimeout time.Duration
+	// Destinations are the limits of the webhook and S3 uploads: only the hosts listed in EXPORT_DESTINATION_ALLOWED_HOSTS
+	// (AWS S3 by default), https unless EXPORT_DESTINATION_ALLOW_HTTP, and no private networks unless
+	// EXPORT_DESTINATION_ALLOW_PRIVATE_NETWORKS
+	Destinations CustomToolConfig
+}
+
+// LoadExportScheduleConfig loads the scheduled export limits from environment variables
+func LoadExportScheduleConfig() ExportScheduleConfig {
+	timeout := time.Duration(envNonNegativeInt("EXPORT_SCHEDULES_UPLOAD_TIMEOUT_SECONDS", 60)) * time.Second
+	hosts := envHostList("EXPORT_DESTINATION_ALLOWED_HOSTS")
+	if len(hosts) == 0 {
+		hosts = []string{"*.amazonaws.com"}
+	}
+	return ExportScheduleConfig{
+		Enabled:       envBool("EXPORT_SCHEDULES_ENABLED", true),
+		PollInterval:  time.Duration(max(envNonNegativeInt("EXPORT_SCHEDULES_POLL_MINUTES", 5), 1)) * time.Minute,
+		MaxPerBoard:   envNonNegativeInt("EXPORT_SCHEDULES_MAX_PER_BOARD", 5),
+		DefaultHour:   min(envNonNegativeInt("EXPORT_SCHEDULES_DEFAULT_HOUR_UTC", 2), 23),
+		UploadTimeout: timeout,
+		Destinations: CustomToolConfig{
+			Enabled:              true,
+			AllowedHosts:         hosts,
+			AllowHTTP:            envBool("EXPORT_DESTINATION_ALLOW_HTTP", false),
+			AllowPrivateNetworks: envBool("EXPORT_DESTINATION_ALLOW_PRIVATE_NETWORKS", false),
+			DefaultTimeout:       timeout,
+			MaxTimeout:           timeout,
+			MaxResponseBytes:     64 * 1024,
+		},
+	}
+}
@@ -0,0 +1,48 @@
+package config
+
+import (
+	"os"
+	"strings"
+)
+
+// InboundEmailConfig holds the settings of the email-to-board gateway: each board can get an address at Domain,
+// and the mail provider's inbound webhook (SendGrid Inbound Parse or SES through SNS) posts what it receives
+type InboundEmailConfig struct {
+	// Enabled accepts inbound emails and lets owners create board addresses
+	Enabled bool
+	// Domain is the domain the provider receives mail for, e.g. inbox.melina.studio
+	Domain string
+	// WebhookSecret must be sent by the provider as the token query parameter of the webhook URL;
+	// without it the webhooks are refused
+	WebhookSecret string
+	// SNSTopicARNs are the SNS topics the SES webhook takes messages from; empty takes any topic. Messages are
+	// only trusted once their SNS signature checks out, as the token can leak through logs
+	SNSTopicARNs []string
+	// MaxNoteChars caps the body kept on a note; the rest is cut
+	MaxNoteChars int
+	// MaxPerHour caps the emails one board address accepts an hour
+	MaxPerHour int
+}
+
+// LoadInboundEmailConfig loads the email-to-board gateway settings from environment variables
+func LoadInboundEmailConfig() InboundEmailConfig {
+	return InboundEmailConfig{
+		Enabled:       envBool("INBOUND_EMAIL_ENABLED", false),
+		Domain:        strings.ToLower(strings.TrimSpace(os.Getenv("INBOUND_EMAIL_DOMAIN"))),
+		WebhookSecret: os.Getenv("INBOUND_EMAIL_WEBHOOK_SECRET"),
+		SNSTopicARNs:  envTopicList("INBOUND_EMAIL_SNS_TOPIC_ARNS"),
+		MaxNoteChars:  max(envNonNegativeInt("INBOUND_EMAIL_MAX_NOTE_CHARS", 1000), 100),
+		MaxPerHour:    envNonNegativeInt("INBOUND_EMAIL_MAX_PER_HOUR", 30),
+	}
+}
+
+// envTopicList reads a comma-separated list of SNS topic ARNs, which are case-sensitive
+func envTopicList(name string) []string {
+	var topics []string
+	for _, topic := range strings.Split(os.Getenv(name), ",") {
+		if topic = strings.TrimSpace(topic); topic != "" {
+			topics = append(topics, topic)
+		}
+	}
+	return topics
+}
@@ -0,0 +1,34 @@
+package config
+
+import (
+	"os"
+	"strconv"
+)
+
+// JobQueueConfig holds configuration for the background job queue
+type JobQueueConfig struct {
+	Workers  int
+	Capacity int
+}
+
+// LoadJobQueueConfig loads job queue configuration from environment variables
+func LoadJobQueueConfig() JobQueueConfig {
+	workers := 2
+	if val := os.Getenv("JOB_QUEUE_WORKERS"); val != "" {
+		if parsed, err := strconv.Atoi(val); err == nil && parsed > 0 {
+			workers = parsed
+		}
+	}
+
+	capacity := 100
+	if val := os.Getenv("JOB_QUEUE_CAPACITY"); val != "" {
+		if parsed, err := strconv.Atoi(val); err == nil && parsed > 0 {
+			capacity = parsed
+		}
+	}
+
+	return JobQueueConfig{
+		Workers:  workers,
+		Capacity: capacity,
+	}
+}
@@ -0,0 +1,47 @@
+package config
+
+import (
+	"strings"
+	"time"
+)
+
+// PluginConfig controls the out-of-process plugins loaded at startup
+type PluginConfig struct {
+	// Enabled loads the plugins in Dir; without it none are started
+	Enabled bool
+	// Dir holds one directory per plugin, each with a plugin.yaml manifest and its executable
+	Dir string
+	// GrantedScopes are the scopes plugins may ask for; a plugin asking for any other is not started
+	GrantedScopes []string
+	// StartTimeout is how long a plugin has to start answering after it is launched
+	StartTimeout time.Duration
+	// CallTimeout caps each tool call and route request
+	CallTimeout time.Duration
+}
+
+// LoadPluginConfig loads the plugin settings from environment variables
+func LoadPluginConfig() PluginConfig {
+	var scopes []string
+	for _, scope := range strings.Split(envString("PLUGINS_GRANTED_SCOPES", "tools"), ",") {
+		if scope = strings.TrimSpace(scope); scope != "" {
+			scopes = append(scopes, scope)
+		}
+	}
+	return PluginConfig{
+		Enabled:       envBool("PLUGINS_ENABLED", false),
+		Dir:           envString("PLUGINS_DIR", "plugins"),
+		GrantedScopes: scopes,
+		StartTimeout:  time.Duration(envNonNegativeInt("PLUGINS_START_TIMEOUT_SECONDS", 10)) * time.Second,
+		CallTimeout:   time.Duration(envNonNegativeInt("PLUGINS_TIMEOUT_SECONDS", 15)) * time.Second,
+	}
+}
+
+// Grants reports whether plugins may ask for the scope
+func (c PluginConfig) Grants(scope string) bool {
+	for _, granted := range c.GrantedScopes {
+		if granted == scope {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,295 @@
+package config
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"golang.org/x/oauth2/google"
+)
+
+// errSecretNotFound is returned by secret sources for secrets that don't exist; the setting keeps its value
+var errSecretNotFound = errors.New("secret not found")
+
+// SecretSource fetches secrets by name from a secrets manager
+type SecretSource interface {
+	Fetch(ctx context.Context, name string) (string, error)
+}
+
+// secretSource is the source selected at startup, nil when secrets come from plain settings
+var secretSource SecretSource
+
+// initSecrets selects the secret source from the settings and loads every secret setting from it
+func initSecrets(s *Settings) error {
+	src, err := newSecretSource(context.Background(), s)
+	if err != nil {
+		return err
+	}
+	if src == nil {
+		return nil
+	}
+	secretSource = src
+
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	loaded, err := applySecrets(ctx, s, src, os.LookupEnv)
+	if err != nil {
+		return err
+	}
+	log.Printf("🔐 Loaded %d secrets from %s", loaded, s.Secrets.Provider)
+	return nil
+}
+
+// StartSecretRotation re-reads the secrets every SECRETS_REFRESH_MINUTES and swaps in the new settings
+// Values read through GetSettings on each use pick up rotated secrets; clients built once at startup
+// (GCS, Vertex) keep the credentials they were created with until the next restart
+func StartSecretRotation() (stop func()) {
+	if secretSource == nil {
+		return func() {}
+	}
+	interval := GetSettings().Secrets.RefreshInterval
+	ticker := time.NewTicker(interval)
+	done := make(chan struct{})
+
+	go func() {
+		for {
+			select {
+			case <-ticker.C:
+				rotateSecrets()
+			case <-done:
+				ticker.Stop()
+				return
+			}
+		}
+	}()
+
+	log.Printf("🔐 Secret rotation started (every %s)", interval)
+	return func() { close(done) }
+}
+
+func rotateSecrets() {
+	next := *GetSettings()
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if _, err := applySecrets(ctx, &next, secretSource, os.LookupEnv); err != nil {
+		// keep serving the current values; the cache already falls back to the last good ones per secret
+		log.Printf("⚠️ Warning: secret rotation failed: %v", err)
+		return
+	}
+	current.Store(&next)
+}
+
+// applySecrets overwrites the secret settings with the values from the source
+// An env var that is set explicitly still wins, so a single secret can be overridden locally.
+// Every failing secret is reported in one error
+func applySecrets(ctx context.Context, s *Settings, src SecretSource, lookupEnv func(string) (string, bool)) (int, error) {
+	loaded := 0
+	var failed []string
+	for _, f := range s.fields() {
+		if !f.secret {
+			continue
+		}
+		if val, ok := lookupEnv(f.env); ok && val != "" {
+			continue
+		}
+		val, err := src.Fetch(ctx, s.Secrets.Prefix+f.env)
+		if errors.Is(err, errSecretNotFound) {
+			continue
+		}
+		if err != nil {
+			failed = append(failed, fmt.Sprintf("%s (%v)", f.env, err))
+			continue
+		}
+		f.value.SetString(val)
+		loaded++
+	}
+	if len(failed) > 0 {
+		return loaded, fmt.Errorf("failed to load secrets: %s", strings.Join(failed, ", "))
+	}
+	return loaded, nil
+}
+
+func newSecretSource(ctx context.Context, s *Settings) (SecretSource, error) {
+	cfg := s.Secrets
+	ttl := cfg.RefreshInterval
+
+	switch cfg.Provider {
+	case "", "env":
+		return nil, nil
+	case "gcp":
+		project := cfg.GCPProject
+		if project == "" {
+			project = s.GCP.ProjectID
+		}
+		if project == "" {
+			return nil, fmt.Errorf("invalid configuration: SECRETS_PROVIDER=gcp needs SECRETS_GCP_PROJECT or GOOGLE_CLOUD_PROJECT_ID")
+		}
+		client, err := google.DefaultClient(ctx, "https://www.googleapis.com/auth/cloud-platform")
+		if err != nil {
+			return nil, fmt.Errorf("failed to create secret manager client: %w", err)
+		}
+		return newCachedSecretSource(&gcpSecretSource{project: project, client: client}, ttl), nil
+	case "vault":
+		if cfg.VaultAddr == "" || cfg.VaultToken == "" {
+			return nil, fmt.Errorf("invalid configuration: SECRETS_PROVIDER=vault needs VAULT_ADDR and VAULT_TOKEN")
+		}
+		return newCachedSecretSource(&vaultSecretSource{
+			addr:   strings.TrimSuffix(cfg.VaultAddr, "/"),
+			token:  cfg.VaultToken,
+			mount:  strings.Trim(cfg.VaultMount, "/"),
+			path:   strings.Trim(cfg.VaultPath, "/"),
+			client: &http.Client{Timeout: 10 * time.Second},
+		}, ttl), nil
+	default:
+		return nil, fmt.Errorf("invalid configuration: unknown SECRETS_PROVIDER %q (expected env, gcp or vault)", cfg.Provider)
+	}
+}
+
+type cachedSecret struct {
+	value     string
+	fetchedAt time.Time
+}
+
+// cachedSecretSource keeps fetched secrets for ttl and serves the last good value when a refresh fails,
+// so a secrets manager outage doesn't take down a running server
+type cachedSecretSource struct {
+	inner   SecretSource
+	ttl     time.Duration
+	mu      sync.Mutex
+	entries map[string]cachedSecret
+	now     func() time.Time
+}
+
+func newCachedSecretSource(inner SecretSource, ttl time.Duration) *cachedSecretSource {
+	return &cachedSecretSource{
+		inner:   inner,
+		ttl:     ttl,
+		entries: make(map[string]cachedSecret),
+		now:     time.Now,
+	}
+}
+
+func (c *cachedSecretSource) Fetch(ctx context.Context, name string) (string, error) {
+	c.mu.Lock()
+	entry, ok := c.entries[name]
+	c.mu.Unlock()
+	if ok && c.now().Sub(entry.fetchedAt) < c.ttl {
+		return entry.value, nil
+	}
+
+	value, err := c.inner.Fetch(ctx, name)
+	if err != nil {
+		if ok && !errors.Is(err, errSecretNotFound) {
+			log.Printf("⚠️ Warning: refreshing secret %s failed, keeping the cached value: %v", name, err)
+			return entry.value, nil
+		}
+		return "", err
+	}
+
+	c.mu.Lock()
+	c.entries[name] = cachedSecret{value: value, fetchedAt: c.now()}
+	c.mu.Unlock()
+	return value, nil
+}
+
+// gcpSecretSource reads the latest version of a secret from GCP Secret Manager over its REST API
+type gcpSecretSource struct {
+	project string
+	client  *http.Client
+}
+
+func (g *gcpSecretSource) Fetch(ctx context.Context, name string) (string, error) {
+	endpoint := fmt.Sprintf("https://secretmanager.googleapis.com/v1/projects/%s/secrets/%s/versions/latest:access",
+		url.PathEscape(g.project), url.PathEscape(name))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	resp, err := g.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", errSecretNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("secret manager returned %s", resp.Status)
+	}
+
+	var body struct {
+		Payload struct {
+			Data string `json:"data"`
+		} `json:"payload"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("decode secret manager response: %w", err)
+	}
+	data, err := base64.StdEncoding.DecodeString(body.Payload.Data)
+	if err != nil {
+		return "", fmt.Errorf("decode secret payload: %w", err)
+	}
+	return string(data), nil
+}
+
+// vaultSecretSource reads secrets from the keys of a single Vault KV v2 secret
+type vaultSecretSource struct {
+	addr   string
+	token  string
+	mount  string
+	path   string
+	client *http.Client
+}
+
+func (v *vaultSecretSource) Fetch(ctx context.Context, name string) (string, error) {
+	endpoint := fmt.Sprintf("%s/v1/%s/data/%s", v.addr, v.mount, v.path)
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, endpoint, nil)
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("X-Vault-Token", v.token)
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return "", errSecretNotFound
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("vault returned %s", resp.Status)
+	}
+
+	var body struct {
+		Data struct {
+			Data map[string]any `json:"data"`
+		} `json:"data"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return "", fmt.Errorf("decode vault response: %w", err)
+	}
+	value, ok := body.Data.Data[name]
+	if !ok {
+		return "", errSecretNotFound
+	}
+	if str, ok := value.(string); ok {
+		return str, nil
+	}
+	// structured values, e.g. a service account stored as a JSON object
+	encoded, err := json.Marshal(value)
+	if err != nil {
+		return "", err
+	}
+	return string(encoded), nil
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type fakeSecretSource struct {
+	values map[string]string
+	err    error
+	calls  int
+}
+
+func (f *fakeSecretSource) Fetch(ctx context.Context, name string) (string, error) {
+	f.calls++
+	if f.err != nil {
+		return "", f.err
+	}
+	val, ok := f.values[name]
+	if !ok {
+		return "", errSecretNotFound
+	}
+	return val, nil
+}
+
+func TestApplySecrets(t *testing.T) {
+	s, _ := readSettings("", envFrom(nil))
+	s.Secrets.Prefix = "melina-"
+	src := &fakeSecretSource{values: map[string]string{
+		"melina-OPENAI_API_KEY":   "from-manager",
+		"melina-GROQ_API_KEY":     "from-manager",
+		"melina-DB_URL":           "not-a-secret-setting",
+		"melina-JWT_SECRET":       "rotated-jwt",
+		"melina-GEMINI_MODEL_ID":  "not-a-secret-setting",
+		"melina-GOOGLE_CLIENT_ID": "not-a-secret-setting",
+	}}
+
+	loaded, err := applySecrets(context.Background(), s, src, envFrom(map[string]string{"GROQ_API_KEY": "from-env"}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if loaded != 2 {
+		t.Errorf("expected 2 secrets loaded, got %d", loaded)
+	}
+	if s.LLM.OpenAIAPIKey != "from-manager" || s.Auth.JWTSecret != "rotated-jwt" {
+		t.Errorf("secret settings were not loaded: %+v %+v", s.LLM, s.Auth)
+	}
+	if s.LLM.GroqAPIKey != "" {
+		t.Errorf("an explicit env var should win over the secrets manager, got %q", s.LLM.GroqAPIKey)
+	}
+	if s.Database.URL != "" || s.LLM.GeminiModelID != "" || s.Auth.GoogleClientID != "" {
+		t.Error("settings not tagged secret must not be read from the secrets manager")
+	}
+	if s.Auth.JWTRefreshSecret != "REFRESH_SECRET" {
+		t.Errorf("missing secrets should keep their value, got %q", s.Auth.JWTRefreshSecret)
+	}
+}
+
+func TestCachedSecretSourceServesStaleValueOnError(t *testing.T) {
+	inner := &fakeSecretSource{values: map[string]string{"KEY": "v1"}}
+	now := time.Now()
+	cache := newCachedSecretSource(inner, time.Minute)
+	cache.now = func() time.Time { return now }
+
+	if val, _ := cache.Fetch(context.Background(), "KEY"); val != "v1" {
+		t.Fatalf("expected v1, got %q", val)
+	}
+	cache.Fetch(context.Background(), "KEY")
+	if inner.calls != 1 {
+		t.Errorf("expected the second fetch to be cached, got %d calls", inner.calls)
+	}
+
+	now = now.Add(2 * time.Minute)
+	inner.err = errors.New("unavailable")
+	if val, err := cache.Fetch(context.Background(), "KEY"); err != nil || val != "v1" {
+		t.Errorf("expected the stale value on error, got %q, %v", val, err)
+	}
+
+	inner.err = nil
+	inner.values["KEY"] = "v2"
+	if val, _ := cache.Fetch(context.Background(), "KEY"); val != "v2" {
+		t.Errorf("expected the rotated value, got %q", val)
+	}
+}
+
+func TestVaultSecretSource(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/v1/secret/data/melina-studio" || r.Header.Get("X-Vault-Token") != "token" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write([]byte(`{"data":{"data":{"OPENAI_API_KEY":"sk-test","GCP_SERVICE_ACCOUNT_CREDENTIALS":{"type":"service_account"}}}}`))
+	}))
+	defer server.Close()
+
+	src := &vaultSecretSource{addr: server.URL, token: "token", mount: "secret", path: "melina-studio", client: server.Client()}
+	if val, err := src.Fetch(context.Background(), "OPENAI_API_KEY"); err != nil || val != "sk-test" {
+		t.Errorf("expected sk-test, got %q, %v", val, err)
+	}
+	if val, err := src.Fetch(context.Background(), "GCP_SERVICE_ACCOUNT_CREDENTIALS"); err != nil || val != `{"type":"service_account"}` {
+		t.Errorf("expected the JSON object, got %q, %v", val, err)
+	}
+	if _, err := src.Fetch(context.Background(), "MISSING"); !errors.Is(err, errSecretNotFound) {
+		t.Errorf("expected errSecretNotFound, got %v", err)
+	}
+}
@@ -0,0 +1,546 @@
+package config
+
+import (
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"log"
+	"net"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Settings is the typed application configuration, loaded and validated once at startup by LoadSettings
+// Every value comes from its default, then the optional YAML file at CONFIG_FILE, then its env var,
+// so the environment always wins over the file. Leaf fields are strings, bools, ints or durations, tagged with:
+//   - env:      the environment variable that overrides the value
+//   - default:  the value used when neither the file nor the environment set one
+//   - required: "true" to always require a value, "production" to require it when GO_ENV=production
+//   - secret:   the value may come from the secrets manager selected by SECRETS_PROVIDER (see secrets.go)
+//   - unit:     what a plain number means for a duration, e.g. "minute"; durations such as "90s" work too
+//   - min, max: the range of an int or duration, in its unit
+type Settings struct {
+	Server   ServerSettings   `yaml:"server"`
+	Database DatabaseSettings `yaml:"database"`
+	Auth     AuthSettings     `yaml:"auth"`
+	LLM      LLMSettings      `yaml:"llm"`
+	GCP      GCPSettings      `yaml:"gcp"`
+	AWS      AWSSettings      `yaml:"aws"`
+	Payments PaymentSettings  `yaml:"payments"`
+	Email    EmailSettings    `yaml:"email"`
+	Redis    RedisSettings    `yaml:"redis"`
+	Secrets  SecretsSettings  `yaml:"secrets"`
+
+	// invalid lists the values of the file or the environment that don't fit their field, reported by validate
+	invalid []string
+}
+
+type ServerSettings struct {
+	Port        int    `yaml:"port" env:"PORT" default:"3000" min:"1" max:"65535"`
+	Env         string `yaml:"env" env:"GO_ENV" default:"development"`
+	FrontendURL string `yaml:"frontend_url" env:"FRONTEND_URL" default:"http://localhost:3000" required:"production"`
+	// TrustedProxies lists the reverse proxies, as addresses or CIDR ranges, whose X-Forwarded-For is believed;
+	// requests from anywhere else are taken to come from the connection's address
+	TrustedProxies string `yaml:"trusted_proxies" env:"TRUSTED_PROXIES" default:"127.0.0.1,::1"`
+}
+
+type DatabaseSettings struct {
+	URL string `yaml:"url" env:"DB_URL" required:"true"`
+}
+
+type AuthSettings struct {
+	// The development defaults keep local sessions working; production must set real secrets
+	JWTSecret          string `yaml:"jwt_secret" env:"JWT_SECRET" default:"ACCESS_SECRET" required:"production" secret:"true"`
+	JWTRefreshSecret   string `yaml:"jwt_refresh_secret" env:"JWT_REFRESH_SECRET" default:"REFRESH_SECRET" required:"production" secret:"true"`
+	GoogleClientID     string `yaml:"google_client_id" env:"GOOGLE_CLIENT_ID"`
+	GoogleClientSecret string `yaml:"google_client_secret" env:"GOOGLE_CLIENT_SECRET" secret:"true"`
+	GoogleRedirectURI  string `yaml:"google_redirect_uri" env:"GOOGLE_REDIRECT_URI"`
+	GitHubClientID     string `yaml:"github_client_id" env:"GITHUB_CLIENT_ID"`
+	GitHubClientSecret string `yaml:"github_client_secret" env:"GITHUB_CLIENT_SECRET" secret:"true"`
+	GitHubRedirectURI  string `yaml:"github_redirect_uri" env:"GITHUB_REDIRECT_URI"`
+	// CredentialsKey encrypts the credentials users store for integrations, e.g. export destinations: 32 bytes,
+	// base64 encoded. Changing it makes the stored credentials unreadable
+	CredentialsKey string `yaml:"credentials_key" env:"CREDENTIALS_ENCRYPTION_KEY" secret:"true"`
+}
+
+// LLMSettings holds the provider credentials; a provider without a key only fails when one of its models is used
+type LLMSettings struct {
+	OpenAIAPIKey      string `yaml:"openai_api_key" env:"OPENAI_API_KEY" secret:"true"`
+	GroqAPIKey        string `yaml:"groq_api_key" env:"GROQ_API_KEY" secret:"true"`
+	GroqBaseURL       string `yaml:"groq_base_url" env:"GROQ_BASE_URL" default:"https://api.groq.com/openai/v1"`
+	GeminiAPIKey      string `yaml:"gemini_api_key" env:"GEMINI_API_KEY" secret:"true"`
+	GeminiModelID     string `yaml:"gemini_model_id" env:"GEMINI_MODEL_ID"`
+	OpenRouterAPIKey  string `yaml:"openrouter_api_key" env:"OPENROUTER_API_KEY" secret:"true"`
+	DeepSeekAPIKey    string `yaml:"deepseek_api_key" env:"DEEPSEEK_API_KEY" secret:"true"`
+	DeepSeekBaseURL   string `yaml:"deepseek_base_url" env:"DEEPSEEK_BASE_URL" default:"https://api.deepseek.com"`
+	MistralAPIKey     string `yaml:"mistral_api_key" env:"MISTRAL_API_KEY" secret:"true"`
+	MistralBaseURL    string `yaml:"mistral_base_url" env:"MISTRAL_BASE_URL" default:"https://api.mistral.ai/v1"`
+	ClaudeVertexModel string `yaml:"claude_vertex_model" env:"CLAUDE_VERTEX_MODEL" default:"claude-sonnet-4-5@20250929"`
+	// AnthropicAPIKey enables the Message Batches API for background jobs on Claude models, at half the price
+	AnthropicAPIKey string `yaml:"anthropic_api_key" env:"ANTHROPIC_API_KEY" secret:"true"`
+	// BatchMaxWait is how long a job waits for its batch before running interactively instead
+	BatchMaxWait time.Duration `yaml:"batch_max_wait_minutes" env:"LLM_BATCH_MAX_WAIT_MINUTES" default:"60" unit:"minute" min:"1"`
+	// OllamaModels lists the local models to offer, comma-separated; they are picked as "ollama/<model>"
+	OllamaModels  string `yaml:"ollama_models" env:"OLLAMA_MODELS"`
+	OllamaBaseURL string `yaml:"ollama_base_url" env:"OLLAMA_BASE_URL" default:"http://localhost:11434"`
+	// OllamaContextTokens is the context length the local models are served with (Ollama's num_ctx)
+	OllamaContextTokens int `yaml:"ollama_context_tokens" env:"OLLAMA_CONTEXT_TOKENS" default:"32768" min:"1"`
+	// OllamaDataRegion is the data region (eu or us) the Ollama hosts run in, which lets region-pinned tenants use them
+	OllamaDataRegion string `yaml:"ollama_data_region" env:"OLLAMA_DATA_REGION"`
+	// DefaultModel is used by background jobs that don't pick a model; empty means gemini-2.5-flash
+	DefaultModel string `yaml:"default_model" env:"DEFAULT_MODEL"`
+	// VerificationModel is the cheap vision model that checks the agent's drawings for users who turn it on
+	VerificationModel string `yaml:"verification_model" env:"LLM_VERIFICATION_MODEL" default:"gemini-2.5-flash"`
+	// FallbackModels is the comma-separated chain of models tried, in order, while a model's provider is unhealthy
+	FallbackModels string `yaml:"fallback_models" env:"LLM_FALLBACK_MODELS"`
+	// ResponseCacheTTL keeps identical temperature-0 completions (summaries) for this long; 0 disables the cache
+	ResponseCacheTTL time.Duration `yaml:"response_cache_minutes" env:"LLM_RESPONSE_CACHE_MINUTES" default:"0" unit:"minute" min:"0"`
+	// StreamMaxEventMB caps one streamed provider event (a large tool input arrives as a single data line)
+	StreamMaxEventMB int `yaml:"stream_max_event_mb" env:"LLM_STREAM_MAX_EVENT_MB" default:"16" min:"1"`
+	// PrivacyMode sends the providers' data-retention opt-outs for users outside a tenant; tenants set their own
+	PrivacyMode bool `yaml:"privacy_mode" env:"LLM_PRIVACY_MODE" default:"false"`
+	// FakeModel offers the "fake" model, which streams a canned reply without calling a provider, so load tests
+	// against staging don't spend tokens; refused in production
+	FakeModel bool `yaml:"fake_model" env:"LLM_FAKE_MODEL" default:"false"`
+	// FakeModelChunkDelay is the delay between the words the fake model streams
+	FakeModelChunkDelay time.Duration `yaml:"fake_model_chunk_ms" env:"LLM_FAKE_MODEL_CHUNK_MS" default:"20" unit:"millisecond" min:"0"`
+	// MockModel offers the "mock" model, which echoes messages or plays MockScenario's scripted tool calls, so the
+	// chat pipeline runs locally without API keys; refused in production
+	MockModel    bool   `yaml:"mock_model" env:"LLM_MOCK_MODEL" default:"false"`
+	MockScenario string `yaml:"mock_scenario" env:"LLM_MOCK_SCENARIO" default:"config/mock_scenario.yaml"`
+	// EmbeddingsProvider backs semantic search, note clustering and summary ranking: openai, vertex or ollama
+	EmbeddingsProvider       string `yaml:"embeddings_provider" env:"EMBEDDINGS_PROVIDER" default:"openai"`
+	EmbeddingsModel          string `yaml:"embeddings_model" env:"EMBEDDINGS_MODEL"`
+	EmbeddingsVertexLocation string `yaml:"embeddings_vertex_location" env:"EMBEDDINGS_VERTEX_LOCATION" default:"us-central1"`
+}
+
+type GCPSettings struct {
+	ProjectID    string `yaml:"project_id" env:"GOOGLE_CLOUD_PROJECT_ID"`
+	VertexRegion string `yaml:"vertex_region" env:"GOOGLE_CLOUD_VERTEXAI_LOCATION"`
+	// VertexRegionEU and VertexRegionUS serve tenants pinned to a data region; empty means Claude on Vertex isn't offered there
+	VertexRegionEU string `yaml:"vertex_region_eu" env:"GOOGLE_CLOUD_VERTEXAI_LOCATION_EU" default:"europe-west1"`
+	VertexRegionUS string `yaml:"vertex_region_us" env:"GOOGLE_CLOUD_VERTEXAI_LOCATION_US" default:"us-east5"`
+	// ServiceAccountCredentials is the service account JSON, base64 encoded in env vars or raw from a secrets manager
+	ServiceAccountCredentials string `yaml:"service_account_credentials" env:"GCP_SERVICE_ACCOUNT_CREDENTIALS" required:"true" secret:"true"`
+	StorageBucket             string `yaml:"storage_bucket" env:"GCP_STORAGE_BUCKET" required:"true"`
+	GCSBaseURL                string `yaml:"gcs_base_url" env:"GCS_BASE_URL" default:"https://storage.googleapis.com"`
+}
+
+// AWSSettings holds the Bedrock credentials; either an access key pair or a Bedrock API key
+type AWSSettings struct {
+	Region          string `yaml:"region" env:"AWS_REGION" default:"us-east-1"`
+	AccessKeyID     string `yaml:"access_key_id" env:"AWS_ACCESS_KEY_ID"`
+	SecretAccessKey string `yaml:"secret_access_key" env:"AWS_SECRET_ACCESS_KEY" secret:"true"`
+	SessionToken    string `yaml:"session_token" env:"AWS_SESSION_TOKEN" secret:"true"`
+	BedrockAPIKey   string `yaml:"bedrock_api_key" env:"AWS_BEARER_TOKEN_BEDROCK" secret:"true"`
+	// BedrockProfilePrefix selects the cross-region inference profile ("us.", "eu.", "apac."); empty calls the model directly
+	BedrockProfilePrefix string `yaml:"bedrock_profile_prefix" env:"BEDROCK_INFERENCE_PROFILE_PREFIX" default:"us."`
+	// BedrockRegionEU and BedrockRegionUS serve tenants pinned to a data region; empty means Bedrock isn't offered there
+	BedrockRegionEU string `yaml:"bedrock_region_eu" env:"AWS_BEDROCK_REGION_EU" default:"eu-central-1"`
+	BedrockRegionUS string `yaml:"bedrock_region_us" env:"AWS_BEDROCK_REGION_US" default:"us-east-1"`
+}
+
+type PaymentSettings struct {
+	RazorpayKeyID         string `yaml:"razorpay_key_id" env:"RAZORPAY_CLIENT_API_KEY"`
+	RazorpayKeySecret     string `yaml:"razorpay_key_secret" env:"RAZORPAY_CLIENT_SECRET_KEY" secret:"true"`
+	RazorpayWebhookSecret string `yaml:"razorpay_webhook_secret" env:"RAZORPAY_WEBHOOK_SECRET" secret:"true"`
+}
+
+// EmailSettings configures the SMTP server used for notification emails; without a host no email is sent
+type EmailSettings struct {
+	SMTPHost     string `yaml:"smtp_host" env:"SMTP_HOST"`
+	SMTPPort     int    `yaml:"smtp_port" env:"SMTP_PORT" default:"587" min:"1" max:"65535"`
+	SMTPUsername string `yaml:"smtp_username" env:"SMTP_USERNAME"`
+	SMTPPassword string `yaml:"smtp_password" env:"SMTP_PASSWORD" secret:"true"`
+	From         string `yaml:"from" env:"EMAIL_FROM" default:"Melina Studio <no-reply@melina.studio>"`
+}
+
+// RedisSettings configures the Redis shared by every instance, e.g. for revoked access tokens
+type RedisSettings struct {
+	// URL is a redis:// or rediss:// URL; without it state that should be shared stays in each instance's memory
+	URL string `yaml:"url" env:"REDIS_URL" secret:"true"`
+}
+
+// SecretsSettings selects where the fields tagged secret are loaded from
+type SecretsSettings struct {
+	// Provider is "env" (plain settings only), "gcp" (Secret Manager) or "vault" (KV v2)
+	Provider string `yaml:"provider" env:"SECRETS_PROVIDER" default:"env"`
+	// Prefix is prepended to the env var name to form the secret name, e.g. "melina-" for "melina-OPENAI_API_KEY"
+	Prefix          string        `yaml:"prefix" env:"SECRETS_PREFIX"`
+	RefreshInterval time.Duration `yaml:"refresh_minutes" env:"SECRETS_REFRESH_MINUTES" default:"15" unit:"minute" min:"1"`
+	// GCPProject defaults to GOOGLE_CLOUD_PROJECT_ID; Secret Manager is reached with application default credentials
+	GCPProject string `yaml:"gcp_project" env:"SECRETS_GCP_PROJECT"`
+	VaultAddr  string `yaml:"vault_addr" env:"VAULT_ADDR"`
+	VaultToken string `yaml:"vault_token" env:"VAULT_TOKEN"`
+	VaultMount string `yaml:"vault_mount" env:"VAULT_MOUNT" default:"secret"`
+	VaultPath  string `yaml:"vault_path" env:"VAULT_SECRET_PATH" default:"melina-studio"`
+}
+
+// TrustedProxyList splits TRUSTED_PROXIES
+func (s ServerSettings) TrustedProxyList() []string {
+	var proxies []string
+	for _, proxy := range strings.Split(s.TrustedProxies, ",") {
+		if proxy = strings.TrimSpace(proxy); proxy != "" {
+			proxies = append(proxies, proxy)
+		}
+	}
+	return proxies
+}
+
+// ServiceAccountJSON returns the service account JSON, accepting it raw or base64 encoded
+func (g GCPSettings) ServiceAccountJSON() ([]byte, error) {
+	raw := strings.TrimSpace(g.ServiceAccountCredentials)
+	if raw == "" {
+		return nil, fmt.Errorf("GCP_SERVICE_ACCOUNT_CREDENTIALS not set")
+	}
+	if strings.HasPrefix(raw, "{") {
+		return []byte(raw), nil
+	}
+	decoded, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, fmt.Errorf("decode service account json: %w", err)
+	}
+	return decoded, nil
+}
+
+// CredentialsKeyBytes decodes CREDENTIALS_ENCRYPTION_KEY into an AES-256 key
+func (a AuthSettings) CredentialsKeyBytes() ([]byte, error) {
+	raw := strings.TrimSpace(a.CredentialsKey)
+	if raw == "" {
+		return nil, fmt.Errorf("CREDENTIALS_ENCRYPTION_KEY not set")
+	}
+	key, err := base64.StdEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, fmt.Errorf("decode CREDENTIALS_ENCRYPTION_KEY: %w", err)
+	}
+	if len(key) != 32 {
+		return nil, fmt.Errorf("CREDENTIALS_ENCRYPTION_KEY must be 32 bytes, got %d", len(key))
+	}
+	return key, nil
+}
+
+// IsProduction reports whether the server runs with GO_ENV=production
+func (s *Settings) IsProduction() bool {
+	return s.Server.Env == "production"
+}
+
+var (
+	// current is swapped as a whole when secrets rotate, so readers never see a half-updated value
+	current      atomic.Pointer[Settings]
+	settingsOnce sync.Once
+)
+
+// LoadSettings loads the settings from CONFIG_FILE, the environment and the secrets manager and validates them
+// It reports every missing or invalid key in a single error so a deployment can be fixed in one go
+func LoadSettings() (*Settings, error) {
+	s, err := readSettings(os.Getenv("CONFIG_FILE"), os.LookupEnv)
+	if err != nil {
+		return nil, err
+	}
+	if err := initSecrets(s); err != nil {
+		return nil, err
+	}
+	if err := s.validate(); err != nil {
+		return nil, err
+	}
+	for _, warning := range s.warnings() {
+		log.Printf("⚠️ Warning: %s", warning)
+	}
+
+	current.Store(s)
+	return s, nil
+}
+
+// GetSettings returns the current settings
+// Callers should not hold on to the result, so rotated secrets are picked up on the next call.
+// Tools that never called LoadSettings get the settings read from the environment, without validation
+func GetSettings() *Settings {
+	if s := current.Load(); s != nil {
+		return s
+	}
+	settingsOnce.Do(func() {
+		s, err := readSettings(os.Getenv("CONFIG_FILE"), os.LookupEnv)
+		if err != nil {
+			log.Printf("⚠️ Warning: %v", err)
+			s, _ = readSettings("", os.LookupEnv)
+		}
+		current.CompareAndSwap(nil, s)
+	})
+	return current.Load()
+}
+
+// readSettings applies the defaults, then the YAML file (if any), then the environment. File and env values
+// are parsed alike; the ones that don't fit their field are kept for validate to report
+func readSettings(path string, lookupEnv func(string) (string, bool)) (*Settings, error) {
+	s := &Settings{}
+	fields := s.fields()
+	for _, f := range fields {
+		if err := f.set(f.defaultValue); err != nil {
+			return nil, fmt.Errorf("default of %s: %w", f.env, err)
+		}
+	}
+
+	if path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config file %s: %w", path, err)
+		}
+		var file map[string]interface{}
+		if err := yaml.Unmarshal(data, &file); err != nil {
+			return nil, fmt.Errorf("failed to parse config file %s: %w", path, err)
+		}
+		for _, f := range fields {
+			if val, ok := fileValue(file, f.key); ok {
+				if err := f.set(val); err != nil {
+					s.invalid = append(s.invalid, fmt.Sprintf("%s %v, got %q", strings.Join(f.key, "."), err, val))
+				}
+			}
+		}
+	}
+
+	for _, f := range fields {
+		if val, ok := lookupEnv(f.env); ok && val != "" {
+			if err := f.set(val); err != nil {
+				s.invalid = append(s.invalid, fmt.Sprintf("%s %v, got %q", f.env, err, val))
+			}
+		}
+	}
+	return s, nil
+}
+
+// fileValue returns the value at the key path of the YAML file, as text; null values count as unset
+func fileValue(file map[string]interface{}, key []string) (string, bool) {
+	var node interface{} = file
+	for _, part := range key {
+		section, ok := node.(map[string]interface{})
+		if !ok {
+			return "", false
+		}
+		if node, ok = section[part]; !ok || node == nil {
+			return "", false
+		}
+	}
+	switch node.(type) {
+	case map[string]interface{}, []interface{}:
+		return "", false
+	}
+	return fmt.Sprint(node), true
+}
+
+func (s *Settings) validate() error {
+	var problems []string
+	var missing []string
+	for _, f := range s.fields() {
+		required := f.required == "true" || (f.required == "production" && s.IsProduction())
+		if required && f.empty() {
+			missing = append(missing, f.env)
+		}
+	}
+	if len(missing) > 0 {
+		problems = append(problems, "missing "+strings.Join(missing, ", "))
+	}
+	problems = append(problems, s.invalid...)
+
+	for _, proxy := range s.Server.TrustedProxyList() {
+		if net.ParseIP(proxy) == nil {
+			if _, _, err := net.ParseCIDR(proxy); err != nil {
+				problems = append(problems, fmt.Sprintf("TRUSTED_PROXIES must list addresses or CIDR ranges, got %q", proxy))
+			}
+		}
+	}
+	if s.Auth.CredentialsKey != "" {
+		if _, err := s.Auth.CredentialsKeyBytes(); err != nil {
+			problems = append(problems, err.Error())
+		}
+	}
+	switch s.LLM.EmbeddingsProvider {
+	case "openai", "vertex", "ollama":
+	default:
+		problems = append(problems, fmt.Sprintf("EMBEDDINGS_PROVIDER must be openai, vertex or ollama, got %q", s.LLM.EmbeddingsProvider))
+	}
+	if s.IsProduction() {
+		if s.Auth.JWTSecret == "ACCESS_SECRET" || s.Auth.JWTRefreshSecret == "REFRESH_SECRET" {
+			problems = append(problems, "JWT_SECRET and JWT_REFRESH_SECRET must not use the development defaults in production")
+		}
+		if s.LLM.FakeModel {
+			problems = append(problems, "LLM_FAKE_MODEL must not be enabled in production")
+		}
+		if s.LLM.MockModel {
+			problems = append(problems, "LLM_MOCK_MODEL must not be enabled in production")
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid configuration: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// warnings lists optional settings whose absence disables a feature
+func (s *Settings) warnings() []string {
+	var warnings []string
+	if s.LLM.OpenAIAPIKey == "" && s.LLM.GroqAPIKey == "" && s.LLM.GeminiAPIKey == "" && s.LLM.OpenRouterAPIKey == "" && s.GCP.ProjectID == "" && s.LLM.OllamaModels == "" &&
+		s.AWS.AccessKeyID == "" && s.AWS.BedrockAPIKey == "" && s.LLM.DeepSeekAPIKey == "" && s.LLM.MistralAPIKey == "" {
+		warnings = append(warnings, "no LLM provider is configured, every model will fail")
+	}
+	if s.LLM.GeminiAPIKey != "" && s.LLM.GeminiModelID == "" {
+		warnings = append(warnings, "GEMINI_API_KEY is set without GEMINI_MODEL_ID")
+	}
+	if s.Auth.GoogleClientID == "" {
+		warnings = append(warnings, "GOOGLE_CLIENT_ID is not set, Google sign-in is disabled")
+	}
+	if s.Auth.GitHubClientID == "" {
+		warnings = append(warnings, "GITHUB_CLIENT_ID is not set, GitHub sign-in is disabled")
+	}
+	if s.Auth.CredentialsKey == "" {
+		warnings = append(warnings, "CREDENTIALS_ENCRYPTION_KEY is not set, export destinations needing credentials can't be saved")
+	}
+	if s.Redis.URL == "" && s.IsProduction() {
+		warnings = append(warnings, "REDIS_URL is not set, revoked access tokens are only rejected by the instance that revoked them")
+	}
+	return warnings
+}
+
+type settingField struct {
+	// key is the field's path in the YAML file, e.g. llm.ollama_context_tokens
+	key          []string
+	env          string
+	defaultValue string
+	required     string
+	secret       bool
+	unit         time.Duration
+	min, max     string
+	value        reflect.Value
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+// durationUnits are the units a duration setting's plain numbers can be in
+var durationUnits = map[string]time.Duration{
+	"millisecond": time.Millisecond,
+	"second":      time.Second,
+	"minute":      time.Minute,
+	"hour":        time.Hour,
+}
+
+// set parses the raw value, from a default, the file or the environment, into the field's type and range
+func (f settingField) set(raw string) error {
+	if f.value.Kind() == reflect.String {
+		f.value.SetString(raw)
+		return nil
+	}
+	raw = strings.TrimSpace(raw)
+	switch {
+	case f.value.Type() == durationType:
+		d, err := f.parseDuration(raw)
+		if err != nil {
+			return err
+		}
+		if err := f.checkRange(int64(d), func(bound int64) string { return time.Duration(bound).String() }); err != nil {
+			return err
+		}
+		f.value.SetInt(int64(d))
+	case f.value.Kind() == reflect.Bool:
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return errors.New("must be true or false")
+		}
+		f.value.SetBool(b)
+	case f.value.Kind() == reflect.Int:
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return errors.New("must be a whole number")
+		}
+		if err := f.checkRange(int64(n), func(bound int64) string { return strconv.FormatInt(bound, 10) }); err != nil {
+			return err
+		}
+		f.value.SetInt(int64(n))
+	}
+	return nil
+}
+
+// parseDuration reads a plain number in the field's unit, or a duration such as "90s"
+func (f settingField) parseDuration(raw string) (time.Duration, error) {
+	if f.unit != 0 {
+		if n, err := strconv.ParseInt(raw, 10, 64); err == nil {
+			return time.Duration(n) * f.unit, nil
+		}
+	}
+	d, err := time.ParseDuration(raw)
+	if err != nil {
+		return 0, errors.New("must be a whole number or a duration such as 90s")
+	}
+	return d, nil
+}
+
+// checkRange applies the field's min and max, given in its unit for durations
+func (f settingField) checkRange(n int64, format func(int64) string) error {
+	scale := int64(1)
+	if f.unit != 0 {
+		scale = int64(f.unit)
+	}
+	if f.min != "" {
+		if bound, _ := strconv.ParseInt(f.min, 10, 64); n < bound*scale {
+			return fmt.Errorf("must be at least %s", format(bound*scale))
+		}
+	}
+	if f.max != "" {
+		if bound, _ := strconv.ParseInt(f.max, 10, 64); n > bound*scale {
+			return fmt.Errorf("must be at most %s", format(bound*scale))
+		}
+	}
+	return nil
+}
+
+// empty reports whether a required setting is missing; only text settings can be
+func (f settingField) empty() bool {
+	return f.value.Kind() == reflect.String && strings.TrimSpace(f.value.String()) == ""
+}
+
+// fields returns every tagged leaf setting, in declaration order
+func (s *Settings) fields() []settingField {
+	var fields []settingField
+	var walk func(v reflect.Value, key []string)
+	walk = func(v reflect.Value, key []string) {
+		t := v.Type()
+		for i := 0; i < t.NumField(); i++ {
+			field := v.Field(i)
+			tag := t.Field(i).Tag
+			name, _, _ := strings.Cut(tag.Get("yaml"), ",")
+			fieldKey := append(append([]string{}, key...), name)
+			if field.Kind() == reflect.Struct {
+				walk(field, fieldKey)
+				continue
+			}
+			env := tag.Get("env")
+			if env == "" {
+				continue
+			}
+			switch kind := field.Kind(); {
+			case kind == reflect.String, kind == reflect.Bool, kind == reflect.Int, field.Type() == durationType:
+			default:
+				panic(fmt.Sprintf("setting %s has unsupported type %s", env, field.Type()))
+			}
+			fields = append(fields, settingField{
+				key:          fieldKey,
+				env:          env,
+				defaultValue: tag.Get("default"),
+				required:     tag.Get("required"),
+				secret:       tag.Get("secret") == "true",
+				unit:         durationUnits[tag.Get("unit")],
+				min:          tag.Get("min"),
+				max:          tag.Get("max"),
+				value:        field,
+			})
+		}
+	}
+	walk(reflect.ValueOf(s).Elem(), nil)
+	return fields
+}
@@ -0,0 +1,191 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+func envFrom(values map[string]string) func(string) (string, bool) {
+	return func(key string) (string, bool) {
+		val, ok := values[key]
+		return val, ok
+	}
+}
+
+func TestReadSettingsPrecedence(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.yaml")
+	file := "server:\n  port: \"4000\"\n  frontend_url: https://file.example\nllm:\n  groq_api_key: from-file\n"
+	if err := os.WriteFile(path, []byte(file), 0o600); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := readSettings(path, envFrom(map[string]string{
+		"PORT":         "5000",
+		"GROQ_API_KEY": "",
+	}))
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if s.Server.Port != 5000 {
+		t.Errorf("env should win over the file, got port %d", s.Server.Port)
+	}
+	if s.Server.FrontendURL != "https://file.example" {
+		t.Errorf("file should win over the default, got %q", s.Server.FrontendURL)
+	}
+	if s.LLM.GroqAPIKey != "from-file" {
+		t.Errorf("empty env var should not clear the file value, got %q", s.LLM.GroqAPIKey)
+	}
+	if s.LLM.GroqBaseURL != "https://api.groq.com/openai/v1" {
+		t.Errorf("expected default groq base url, got %q", s.LLM.GroqBaseURL)
+	}
+}
+
+func TestValidateReportsEveryMissingKey(t *testing.T) {
+	s, err := readSettings("", envFrom(map[string]string{"GO_ENV": "production"}))
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.Server.FrontendURL = ""
+
+	err = s.validate()
+	if err == nil {
+		t.Fatal("expected validation error")
+	}
+	for _, key := range []string{"DB_URL", "GCP_SERVICE_ACCOUNT_CREDENTIALS", "GCP_STORAGE_BUCKET", "FRONTEND_URL", "JWT_SECRET"} {
+		if !strings.Contains(err.Error(), key) {
+			t.Errorf("expected %s in %q", key, err.Error())
+		}
+	}
+
+	dev, _ := readSettings("", envFrom(map[string]string{
+		"DB_URL":                          "postgres://localhost/melina",
+		"GCP_SERVICE_ACCOUNT_CREDENTIALS": "e30=",
+		"GCP_STORAGE_BUCKET":              "bucket",
+	}))
+	if err := dev.validate(); err != nil {
+		t.Errorf("development settings with the required keys should be valid, got %v", err)
+	}
+}
+
+func TestValidateTrustedProxies(t *testing.T) {
+	for value, valid := range map[string]bool{"": true, "127.0.0.1, ::1": true, "10.0.0.0/8,172.16.0.0/12": true, "nginx": false, "10.0.0.0/33": false} {
+		s, _ := readSettings("", envFrom(map[string]string{
+			"DB_URL":                          "postgres://localhost/melina",
+			"GCP_SERVICE_ACCOUNT_CREDENTIALS": "e30=",
+			"GCP_STORAGE_BUCKET":              "bucket",
+		}))
+		s.Server.TrustedProxies = value
+		if err := s.validate(); (err == nil) != valid {
+			t.Errorf("TRUSTED_PROXIES=%q: got %v, want valid %v", value, err, valid)
+		}
+	}
+}
+
+func TestReadSettingsParsesBooleans(t *testing.T) {
+	required := map[string]string{
+		"DB_URL":                          "postgres://localhost/melina",
+		"GCP_SERVICE_ACCOUNT_CREDENTIALS": "e30=",
+		"GCP_STORAGE_BUCKET":              "bucket",
+	}
+	for value, want := range map[string]bool{"": false, "true": true, "1": true, "FALSE": false} {
+		env := map[string]string{"LLM_PRIVACY_MODE": value}
+		for k, v := range required {
+			env[k] = v
+		}
+		s, err := readSettings("", envFrom(env))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := s.validate(); err != nil || s.LLM.PrivacyMode != want {
+			t.Errorf("LLM_PRIVACY_MODE=%q: got %v, %v, want %v", value, s.LLM.PrivacyMode, err, want)
+		}
+	}
+
+	env := map[string]string{"LLM_PRIVACY_MODE": "yes please"}
+	for k, v := range required {
+		env[k] = v
+	}
+	s, err := readSettings("", envFrom(env))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.validate(); err == nil || !strings.Contains(err.Error(), "LLM_PRIVACY_MODE must be true or false") {
+		t.Errorf("expected an invalid LLM_PRIVACY_MODE to fail validation, got %v", err)
+	}
+}
+
+func TestReadSettingsParsesNumbersAndDurations(t *testing.T) {
+	required := map[string]string{
+		"DB_URL":                          "postgres://localhost/melina",
+		"GCP_SERVICE_ACCOUNT_CREDENTIALS": "e30=",
+		"GCP_STORAGE_BUCKET":              "bucket",
+	}
+	cases := []struct {
+		env, value string
+		check      func(*Settings) bool
+		invalid    string
+	}{
+		{env: "OLLAMA_CONTEXT_TOKENS", value: "4096", check: func(s *Settings) bool { return s.LLM.OllamaContextTokens == 4096 }},
+		{env: "OLLAMA_CONTEXT_TOKENS", value: "", check: func(s *Settings) bool { return s.LLM.OllamaContextTokens == 32768 }},
+		{env: "OLLAMA_CONTEXT_TOKENS", value: "nope", invalid: "OLLAMA_CONTEXT_TOKENS must be a whole number"},
+		{env: "OLLAMA_CONTEXT_TOKENS", value: "-1", invalid: "OLLAMA_CONTEXT_TOKENS must be at least 1"},
+		{env: "PORT", value: "70000", invalid: "PORT must be at most 65535"},
+		{env: "LLM_BATCH_MAX_WAIT_MINUTES", value: "90", check: func(s *Settings) bool { return s.LLM.BatchMaxWait == 90*time.Minute }},
+		{env: "LLM_BATCH_MAX_WAIT_MINUTES", value: "2h", check: func(s *Settings) bool { return s.LLM.BatchMaxWait == 2*time.Hour }},
+		{env: "LLM_BATCH_MAX_WAIT_MINUTES", value: "0", invalid: "LLM_BATCH_MAX_WAIT_MINUTES must be at least 1m0s"},
+		{env: "LLM_FAKE_MODEL_CHUNK_MS", value: "5", check: func(s *Settings) bool { return s.LLM.FakeModelChunkDelay == 5*time.Millisecond }},
+		{env: "LLM_RESPONSE_CACHE_MINUTES", value: "soon", invalid: "LLM_RESPONSE_CACHE_MINUTES must be a whole number or a duration"},
+		{env: "LLM_FAKE_MODEL", value: "maybe", invalid: "LLM_FAKE_MODEL must be true or false"},
+	}
+	for _, tc := range cases {
+		env := map[string]string{tc.env: tc.value}
+		for k, v := range required {
+			env[k] = v
+		}
+		s, err := readSettings("", envFrom(env))
+		if err != nil {
+			t.Fatal(err)
+		}
+		err = s.validate()
+		if tc.invalid != "" {
+			if err == nil || !strings.Contains(err.Error(), tc.invalid) {
+				t.Errorf("%s=%q: expected %q, got %v", tc.env, tc.value, tc.invalid, err)
+			}
+			continue
+		}
+		if err != nil || !tc.check(s) {
+			t.Errorf("%s=%q: unexpected settings or error %v", tc.env, tc.value, err)
+		}
+	}
+}
+
+func TestReadSettingsTypedFileValues(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "settings.yaml")
+	file := "server:\n  port: 4000\nllm:\n  fake_model: true\n  response_cache_minutes: 10\n  stream_max_event_mb: many\n"
+	if err := os.WriteFile(path, []byte(file), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	s, err := readSettings(path, envFrom(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := s.validate(); err == nil || !strings.Contains(err.Error(), "llm.stream_max_event_mb must be a whole number") {
+		t.Fatalf("expected the bad file value to be refused, got %v", err)
+	}
+
+	file = strings.Replace(file, "many", "32", 1)
+	if err := os.WriteFile(path, []byte(file), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	s, err = readSettings(path, envFrom(nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Server.Port != 4000 || !s.LLM.FakeModel || s.LLM.ResponseCacheTTL != 10*time.Minute || s.LLM.StreamMaxEventMB != 32 {
+		t.Errorf("unexpected settings from the file: port %d, fake %v, cache %v, event %d",
+			s.Server.Port, s.LLM.FakeModel, s.LLM.ResponseCacheTTL, s.LLM.StreamMaxEventMB)
+	}
+}
@@ -0,0 +1,36 @@
+package config
+
+import "melina-studio-backend/internal/models"
+
+// StorageQuotaConfig holds the bytes each plan may keep in storage
+type StorageQuotaConfig struct {
+	Enabled bool
+	// Limits maps a plan to its quota in bytes; 0 means unlimited
+	Limits map[models.Subscription]int64
+}
+
+const megabyte = 1 << 20
+
+// LoadStorageQuotaConfig loads the storage quotas from environment variables, given in MB
+func LoadStorageQuotaConfig() StorageQuotaConfig {
+	return StorageQuotaConfig{
+		Enabled: envBool("STORAGE_QUOTA_ENABLED", true),
+		Limits: map[models.Subscription]int64{
+			models.SubscriptionFree:     int64(envNonNegativeInt("STORAGE_QUOTA_FREE_MB", 100)) * megabyte,
+			models.SubscriptionPro:      int64(envNonNegativeInt("STORAGE_QUOTA_PRO_MB", 2048)) * megabyte,
+			models.SubscriptionPremium:  int64(envNonNegativeInt("STORAGE_QUOTA_PREMIUM_MB", 10240)) * megabyte,
+			models.SubscriptionOnDemand: int64(envNonNegativeInt("STORAGE_QUOTA_ON_DEMAND_MB", 0)) * megabyte,
+		},
+	}
+}
+
+// Limit returns the quota of a plan in bytes, 0 when it is unlimited or quotas are off
+func (c StorageQuotaConfig) Limit(plan models.Subscription) int64 {
+	if !c.Enabled {
+		return 0
+	}
+	if limit, ok := c.Limits[plan]; ok {
+		return limit
+	}
+	return c.Limits[models.SubscriptionFree]
+}
@@ -0,0 +1,61 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"time"
+)
+
+// StreamPacing controls how the streamed deltas of one websocket message type reach the client
+type StreamPacing struct {
+	// Rate is the sustained number of messages per second; 0 sends chunks as soon as they are ready
+	Rate int
+	// MinChars coalesces deltas until a chunk has at least this many characters and ends on a word boundary
+	MinChars int
+	// MaxDelay flushes a partial chunk that has waited this long
+	MaxDelay time.Duration
+	// Markdown only splits outside code fences, links and table rows
+	Markdown bool
+}
+
+// StreamPacingConfig holds the pacing of the streamed message types
+type StreamPacingConfig struct {
+	Chat     StreamPacing
+	Thinking StreamPacing
+}
+
+// LoadStreamPacingConfig loads stream pacing configuration from environment variables
+func LoadStreamPacingConfig() StreamPacingConfig {
+	return StreamPacingConfig{
+		Chat: StreamPacing{
+			Rate:     envNonNegativeInt("STREAM_CHAT_RATE", 30),
+			MinChars: envNonNegativeInt("STREAM_CHAT_MIN_CHARS", 12),
+			MaxDelay: time.Duration(envNonNegativeInt("STREAM_CHAT_MAX_DELAY_MS", 80)) * time.Millisecond,
+			Markdown: envBool("STREAM_CHAT_MARKDOWN", false),
+		},
+		Thinking: StreamPacing{
+			Rate:     envNonNegativeInt("STREAM_THINKING_RATE", 20),
+			MinChars: envNonNegativeInt("STREAM_THINKING_MIN_CHARS", 24),
+			MaxDelay: time.Duration(envNonNegativeInt("STREAM_THINKING_MAX_DELAY_MS", 120)) * time.Millisecond,
+			Markdown: envBool("STREAM_THINKING_MARKDOWN", false),
+		},
+	}
+}
+
+func envNonNegativeInt(name string, fallback int) int {
+	if val := os.Getenv(name); val != "" {
+		if parsed, err := strconv.Atoi(val); err == nil && parsed >= 0 {
+			return parsed
+		}
+	}
+	return fallback
+}
+
+func envBool(name string, fallback bool) bool {
+	if val := os.Getenv(name); val != "" {
+		if parsed, err := strconv.ParseBool(val); err == nil {
+			return parsed
+		}
+	}
+	return fallback
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"os"
+	"strconv"
+	"strings"
+)
+
+// TenantConfig holds configuration for the multi-tenant deployment mode
+type TenantConfig struct {
+	Enabled bool
+	// BaseDomain is the apex the tenant subdomains live under, e.g. "melina.studio" for "acme.melina.studio"
+	BaseDomain string
+	// Header carries the tenant slug for clients that can't use subdomains; it wins over the subdomain
+	Header string
+}
+
+// LoadTenantConfig loads multi-tenant configuration from environment variables
+func LoadTenantConfig() TenantConfig {
+	enabled := false
+	if val := os.Getenv("MULTI_TENANT_ENABLED"); val != "" {
+		enabled, _ = strconv.ParseBool(val)
+	}
+
+	header := os.Getenv("TENANT_HEADER")
+	if header == "" {
+		header = "X-Tenant"
+	}
+
+	return TenantConfig{
+		Enabled:    enabled,
+		BaseDomain: strings.ToLower(strings.TrimPrefix(os.Getenv("TENANT_BASE_DOMAIN"), ".")),
+		Header:     header,
+	}
+}
@@ -0,0 +1,76 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"time"
+
+	"melina-studio-backend/internal/models"
+)
+
+// Upload scanners
+const (
+	UploadScannerNone   = ""
+	UploadScannerClamAV = "clamav"
+	UploadScannerICAP   = "icap"
+)
+
+// UploadConfig holds the checks uploads pass before they are stored
+type UploadConfig struct {
+	// AllowedTypes are the MIME types accepted, matched against the sniffed content rather than the file name
+	AllowedTypes []string
+	// MaxBytes maps a plan to the largest single upload in bytes; 0 means no cap
+	MaxBytes map[models.Subscription]int64
+	// Scanner is "clamav" (clamd INSTREAM), "icap" (REQMOD) or empty for none
+	Scanner string
+	// ClamAVAddr is the host:port of clamd
+	ClamAVAddr string
+	// ICAPURL is the icap:// URL of the scanning service
+	ICAPURL     string
+	ScanTimeout time.Duration
+	// ScanFailOpen accepts uploads when the scanner can't be reached; by default they are refused
+	ScanFailOpen bool
+}
+
+// LoadUploadConfig loads upload validation configuration from environment variables
+func LoadUploadConfig() UploadConfig {
+	allowed := []string{"image/png", "image/jpeg", "image/gif", "image/webp"}
+	if val := os.Getenv("UPLOAD_ALLOWED_TYPES"); val != "" {
+		allowed = nil
+		for _, t := range strings.Split(val, ",") {
+			if t = strings.ToLower(strings.TrimSpace(t)); t != "" {
+				allowed = append(allowed, t)
+			}
+		}
+	}
+
+	return UploadConfig{
+		AllowedTypes: allowed,
+		MaxBytes: map[models.Subscription]int64{
+			models.SubscriptionFree:     int64(envNonNegativeInt("UPLOAD_MAX_FREE_MB", 5)) * megabyte,
+			models.SubscriptionPro:      int64(envNonNegativeInt("UPLOAD_MAX_PRO_MB", 20)) * megabyte,
+			models.SubscriptionPremium:  int64(envNonNegativeInt("UPLOAD_MAX_PREMIUM_MB", 50)) * megabyte,
+			models.SubscriptionOnDemand: int64(envNonNegativeInt("UPLOAD_MAX_ON_DEMAND_MB", 100)) * megabyte,
+		},
+		Scanner:      strings.ToLower(os.Getenv("UPLOAD_SCANNER")),
+		ClamAVAddr:   envString("CLAMAV_ADDR", "localhost:3310"),
+		ICAPURL:      os.Getenv("ICAP_URL"),
+		ScanTimeout:  time.Duration(envNonNegativeInt("UPLOAD_SCAN_TIMEOUT_SECONDS", 30)) * time.Second,
+		ScanFailOpen: envBool("UPLOAD_SCAN_FAIL_OPEN", false),
+	}
+}
+
+// MaxUploadBytes returns the largest upload a plan allows, 0 when uncapped
+func (c UploadConfig) MaxUploadBytes(plan models.Subscription) int64 {
+	if limit, ok := c.MaxBytes[plan]; ok {
+		return limit
+	}
+	return c.MaxBytes[models.SubscriptionFree]
+}
+
+func envString(name string, fallback string) string {
+	if val := os.Getenv(name); val != "" {
+		return val
+	}
+	return fallback
+}
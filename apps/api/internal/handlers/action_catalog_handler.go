@@ -0,0 +1,55 @@
+package handlers
+
+import (
+	"melina-studio-backend/internal/service"
+	"reflect"
+	"runtime"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ActionCatalogHandler struct {
+	actionCatalogService *service.ActionCatalogService
+}
+
+func NewActionCatalogHandler(actionCatalogService *service.ActionCatalogService) *ActionCatalogHandler {
+	return &ActionCatalogHandler{
+		actionCatalogService: actionCatalogService,
+	}
+}
+
+// function to list the tools, endpoints and templates the backend offers, filtered by the q, kind and category query parameters
+func (h *ActionCatalogHandler) ListActions(c *fiber.Ctx) error {
+	kind := service.ActionKind(c.Query("kind"))
+	switch kind {
+	case "", service.ActionKindTool, service.ActionKindEndpoint, service.ActionKindTemplate:
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Kind must be 'tool', 'endpoint' or 'template'",
+		})
+	}
+
+	actions := h.actionCatalogService.List(routeInfos(c.App()), service.ActionFilter{
+		Query:    c.Query("q"),
+		Kind:     kind,
+		Category: c.Query("category"),
+	})
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"actions": actions,
+		"count":   len(actions),
+	})
+}
+
+// routeInfos lists the app's routes, middleware included, with the function names of their handlers
+func routeInfos(app *fiber.App) []service.RouteInfo {
+	routes := app.GetRoutes()
+	infos := make([]service.RouteInfo, 0, len(routes))
+	for _, route := range routes {
+		names := make([]string, 0, len(route.Handlers))
+		for _, handler := range route.Handlers {
+			names = append(names, runtime.FuncForPC(reflect.ValueOf(handler).Pointer()).Name())
+		}
+		infos = append(infos, service.RouteInfo{Method: route.Method, Path: route.Path, Handlers: names})
+	}
+	return infos
+}
@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+	"melina-studio-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type ActivityHandler struct {
+	boardRepo      repo.BoardRepoInterface
+	activityRepo   repo.BoardActivityRepoInterface
+	heatmapService *service.ActivityHeatmapService
+}
+
+func NewActivityHandler(boardRepo repo.BoardRepoInterface, activityRepo repo.BoardActivityRepoInterface, heatmapService *service.ActivityHeatmapService) *ActivityHandler {
+	return &ActivityHandler{
+		boardRepo:      boardRepo,
+		activityRepo:   activityRepo,
+		heatmapService: heatmapService,
+	}
+}
+
+// function to get the paginated activity feed of a board, newest first
+func (h *ActivityHandler) GetActivity(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	boardId, err := uuid.Parse(c.Params("boardId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid board ID",
+		})
+	}
+
+	if err := h.boardRepo.ValidateBoardOwnership(userID, boardId); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Board not found",
+		})
+	}
+
+	page := c.QueryInt("page", 1)
+	pageSize := c.QueryInt("pageSize", 20)
+
+	activities, total, err := h.activityRepo.ListByBoard(boardId, page, pageSize)
+	if err != nil {
+		log.Println(err, "Error getting board activity")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get board activity",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"activity": activities,
+		"total":    total,
+		"page":     page,
+		"pageSize": pageSize,
+		"hasMore":  int64(page*pageSize) < total,
+	})
+}
+
+// function to get where the shape changes of a board concentrate over the last days, as cells to overlay on the canvas
+func (h *ActivityHandler) GetHeatmap(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	boardId, err := uuid.Parse(c.Params("boardId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid board ID",
+		})
+	}
+
+	if err := h.boardRepo.ValidateBoardOwnership(userID, boardId); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Board not found",
+		})
+	}
+
+	heatmap, err := h.heatmapService.Heatmap(boardId, service.HeatmapOptions{
+		Days:     c.QueryInt("days", 0),
+		CellSize: c.QueryFloat("cell", 0),
+		Actor:    models.ActivityActor(c.Query("actor")),
+	})
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidHeatmap) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		log.Println(err, "Error building board activity heatmap")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to build board activity heatmap",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"heatmap": heatmap,
+	})
+}
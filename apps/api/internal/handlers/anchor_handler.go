@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+	"melina-studio-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AnchorHandler struct {
+	boardRepo     repo.BoardRepoInterface
+	anchorService *service.AnchorService
+}
+
+func NewAnchorHandler(boardRepo repo.BoardRepoInterface, anchorService *service.AnchorService) *AnchorHandler {
+	return &AnchorHandler{
+		boardRepo:     boardRepo,
+		anchorService: anchorService,
+	}
+}
+
+// function to create a shareable deep link to a shape or chat message
+func (h *AnchorHandler) CreateAnchor(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	boardId, err := uuid.Parse(c.Params("boardId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid board ID",
+		})
+	}
+
+	if err := h.boardRepo.ValidateBoardOwnership(userID, boardId); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Board not found",
+		})
+	}
+
+	var dto struct {
+		ShapeID string `json:"shape_id"`
+		ChatID  string `json:"chat_id"`
+	}
+	if err := c.BodyParser(&dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if (dto.ShapeID == "") == (dto.ChatID == "") {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Exactly one of shape_id or chat_id is required",
+		})
+	}
+
+	target, rawID := models.AnchorTargetShape, dto.ShapeID
+	if dto.ChatID != "" {
+		target, rawID = models.AnchorTargetChat, dto.ChatID
+	}
+	targetID, err := uuid.Parse(rawID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid " + string(target) + " ID",
+		})
+	}
+
+	anchor, err := h.anchorService.CreateAnchor(boardId, target, targetID)
+	if err != nil {
+		if errors.Is(err, service.ErrAnchorNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Anchor target not found",
+			})
+		}
+		log.Println(err, "Error creating anchor")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create anchor",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(anchor)
+}
@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"melina-studio-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AnnouncementHandler struct {
+	announcementService *service.AnnouncementService
+}
+
+func NewAnnouncementHandler(announcementService *service.AnnouncementService) *AnnouncementHandler {
+	return &AnnouncementHandler{
+		announcementService: announcementService,
+	}
+}
+
+// function to get the latest announcements with the current user's read state
+func (h *AnnouncementHandler) GetMyAnnouncements(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	announcements, err := h.announcementService.ListForUser(userID, c.QueryInt("limit", 0))
+	if err != nil {
+		log.Println(err, "Error getting announcements")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get announcements",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(announcements)
+}
+
+// function to mark announcements as read; without ids every announcement is marked
+func (h *AnnouncementHandler) MarkRead(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	var dto struct {
+		IDs []uuid.UUID `json:"ids"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&dto); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+	}
+
+	if err := h.announcementService.MarkRead(userID, dto.IDs); err != nil {
+		log.Println(err, "Error marking announcements read")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to mark announcements read",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Announcements marked read",
+	})
+}
+
+// function to list every announcement, drafts included
+func (h *AnnouncementHandler) ListAnnouncements(c *fiber.Ctx) error {
+	announcements, err := h.announcementService.List()
+	if err != nil {
+		log.Println(err, "Error listing announcements")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to list announcements",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"announcements": announcements,
+	})
+}
+
+// function to create an announcement, published right away when publish is set
+func (h *AnnouncementHandler) CreateAnnouncement(c *fiber.Ctx) error {
+	var dto service.AnnouncementUpdate
+	if err := c.BodyParser(&dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	announcement, err := h.announcementService.Create(dto)
+	if err != nil {
+		return announcementError(c, err)
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"announcement": announcement,
+	})
+}
+
+// function to edit or publish an announcement
+func (h *AnnouncementHandler) UpdateAnnouncement(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("announcementId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid announcement ID",
+		})
+	}
+
+	var dto service.AnnouncementUpdate
+	if err := c.BodyParser(&dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	announcement, err := h.announcementService.Update(id, dto)
+	if err != nil {
+		return announcementError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"announcement": announcement,
+	})
+}
+
+// function to delete an announcement
+func (h *AnnouncementHandler) DeleteAnnouncement(c *fiber.Ctx) error {
+	id, err := uuid.Parse(c.Params("announcementId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid announcement ID",
+		})
+	}
+
+	if err := h.announcementService.Delete(id); err != nil {
+		return announcementError(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+func announcementError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidAnnouncement):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, service.ErrAnnouncementNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Announcement not found",
+		})
+	}
+	log.Println(err, "Error saving announcement")
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "Failed to save announcement",
+	})
+}
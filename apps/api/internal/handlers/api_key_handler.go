@@ -0,0 +1,94 @@
+package handlers
+
+import (
+	"melina-studio-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type APIKeyHandler struct {
+	apiKeyService *service.APIKeyService
+}
+
+func NewAPIKeyHandler(apiKeyService *service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{
+		apiKeyService: apiKeyService,
+	}
+}
+
+// apiKeyErrors are the responses to the API key service's errors
+var apiKeyErrors = []errorStatus{
+	{service.ErrInvalidAPIKeyInput, fiber.StatusBadRequest, ""},
+	{service.ErrTooManyAPIKeys, fiber.StatusBadRequest, ""},
+	{service.ErrAPIKeyNotFound, fiber.StatusNotFound, "API key not found"},
+}
+
+// function to list the API keys of the user, without the keys themselves
+func (h *APIKeyHandler) ListAPIKeys(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	keys, err := h.apiKeyService.List(userID)
+	if err != nil {
+		return serviceError(c, err, apiKeyErrors, "list API key")
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"api_keys": keys,
+	})
+}
+
+// function to issue an API key for integrations; the key is only returned here
+func (h *APIKeyHandler) CreateAPIKey(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	var dto struct {
+		Name string `json:"name"`
+	}
+	if err := c.BodyParser(&dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	key, record, err := h.apiKeyService.Create(userID, dto.Name)
+	if err != nil {
+		return serviceError(c, err, apiKeyErrors, "create API key")
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"key":     key,
+		"api_key": record,
+	})
+}
+
+// function to revoke an API key of the user
+func (h *APIKeyHandler) DeleteAPIKey(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+	keyId, err := uuid.Parse(c.Params("keyId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid API key ID",
+		})
+	}
+
+	if err := h.apiKeyService.Delete(userID, keyId); err != nil {
+		return serviceError(c, err, apiKeyErrors, "delete API key")
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "API key revoked",
+	})
+}
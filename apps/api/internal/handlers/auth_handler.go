@@ -1,18 +1,20 @@
 package handlers
 
 import (
-	"context"
+	"bytes"
 	"encoding/json"
 	"errors"
+	"io"
 	"log"
 	"melina-studio-backend/internal/auth"
 	"melina-studio-backend/internal/auth/oauth"
+	"melina-studio-backend/internal/config"
 	"melina-studio-backend/internal/libraries"
 	"melina-studio-backend/internal/models"
 	"melina-studio-backend/internal/repo"
 	"melina-studio-backend/internal/service"
 	"net/http"
-	"os"
+	"strconv"
 	"strings"
 	"time"
 
@@ -28,11 +30,12 @@ const (
 	RefreshTokenCookie = "refresh_token"
 	AccessTokenMaxAge  = 15 * 60          // 15 minutes in seconds
 	RefreshTokenMaxAge = 7 * 24 * 60 * 60 // 7 days in seconds
+	OAuthStateCookie   = "oauth_state"
 )
 
 // Helper function to set auth cookies
 func setAuthCookies(c *fiber.Ctx, accessToken, refreshToken string) {
-	isProduction := os.Getenv("GO_ENV") == "production"
+	isProduction := config.GetSettings().IsProduction()
 
 	// Set access token cookie
 	c.Cookie(&fiber.Cookie{
@@ -75,21 +78,97 @@ func clearAuthCookies(c *fiber.Ctx) {
 	})
 }
 
+// startOAuthFlow keeps the login's state and PKCE verifier in a signed cookie and redirects to the provider
+func startOAuthFlow(c *fiber.Ctx, provider string, cfg *oauth2.Config) error {
+	flow := oauth.NewFlowState(provider)
+	value, err := flow.Encode()
+	if err != nil {
+		log.Println(err, "Error encoding oauth state")
+		return c.Redirect(config.GetSettings().Server.FrontendURL + "/auth?error=oauth_failed")
+	}
+	// Lax so the cookie comes back with the provider's top-level redirect
+	c.Cookie(&fiber.Cookie{
+		Name:     OAuthStateCookie,
+		Value:    value,
+		Expires:  time.Now().Add(oauth.StateTTL),
+		HTTPOnly: true,
+		Secure:   config.GetSettings().IsProduction(),
+		SameSite: "Lax",
+		Path:     "/",
+	})
+	return c.Redirect(flow.AuthCodeURL(cfg))
+}
+
+// finishOAuthFlow checks the callback belongs to a login this browser started and exchanges its code
+// The state cookie is single use, it is cleared whatever the outcome
+func finishOAuthFlow(c *fiber.Ctx, provider string, cfg *oauth2.Config) (*oauth2.Token, string) {
+	cookieValue := c.Cookies(OAuthStateCookie)
+	c.Cookie(&fiber.Cookie{
+		Name:     OAuthStateCookie,
+		Value:    "",
+		Expires:  time.Now().Add(-1 * time.Hour),
+		HTTPOnly: true,
+		Path:     "/",
+	})
+
+	code := c.Query("code")
+	if code == "" {
+		return nil, "missing_code"
+	}
+
+	flow, err := oauth.VerifyCallback(cookieValue, provider, c.Query("state"))
+	if err != nil {
+		log.Println(err, "Error verifying "+provider+" oauth state")
+		return nil, "invalid_oauth_state"
+	}
+
+	token, err := flow.Exchange(c.Context(), cfg, code)
+	if err != nil {
+		log.Println(err, "Error exchanging "+provider+" oauth code")
+		return nil, "oauth_exchange_failed"
+	}
+	return token, ""
+}
+
+// signInPolicyRedirectError is the error code an OAuth callback redirects with when the organization's policy refuses the sign-in
+func signInPolicyRedirectError(err error) string {
+	if errors.Is(err, auth.ErrIPNotAllowed) {
+		return "ip_not_allowed"
+	}
+	log.Println(err, "Error checking the security policy")
+	return "security_policy_unavailable"
+}
+
 type AuthHandler struct {
 	authRepo             repo.AuthRepoInterface
 	authService          *service.AuthService
 	subscriptionPlanRepo repo.SubscriptionPlanRepoInterface
 	geoService           *service.GeolocationService
 	customRulesRepo      repo.CustomRulesRepoInterface
+	storageQuota         *service.StorageQuotaService
+	uploads              *service.UploadValidator
+	onboarding           *service.OnboardingService
+	hub                  *libraries.Hub
 }
 
-func NewAuthHandler(authRepo repo.AuthRepoInterface, authService *service.AuthService, subscriptionPlanRepo repo.SubscriptionPlanRepoInterface, geoService *service.GeolocationService, customRulesRepo repo.CustomRulesRepoInterface) *AuthHandler {
+func NewAuthHandler(authRepo repo.AuthRepoInterface, authService *service.AuthService, subscriptionPlanRepo repo.SubscriptionPlanRepoInterface, geoService *service.GeolocationService, customRulesRepo repo.CustomRulesRepoInterface, storageQuota *service.StorageQuotaService, uploads *service.UploadValidator, onboarding *service.OnboardingService, hub *libraries.Hub) *AuthHandler {
 	return &AuthHandler{
 		authRepo:             authRepo,
 		authService:          authService,
 		subscriptionPlanRepo: subscriptionPlanRepo,
 		geoService:           geoService,
 		customRulesRepo:      customRulesRepo,
+		storageQuota:         storageQuota,
+		uploads:              uploads,
+		onboarding:           onboarding,
+		hub:                  hub,
+	}
+}
+
+// startOnboarding provisions the sample board of a user who just signed up
+func (h *AuthHandler) startOnboarding(userID uuid.UUID, tenantID *uuid.UUID) {
+	if h.onboarding != nil {
+		h.onboarding.Start(userID, tenantID)
 	}
 }
 
@@ -106,7 +185,7 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 	}
 
 	user, err := h.authRepo.GetUserByEmail(dto.Email)
-	if err != nil {
+	if err != nil || !belongsToRequestTenant(c, user) {
 		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
 			"error": "Invalid credentials",
 		})
@@ -133,8 +212,13 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 		})
 	}
 
+	// the user's organization may only allow sign-ins from its networks
+	if err := auth.CheckSignInIP(user.TenantKey(), auth.ClientIP(c)); err != nil {
+		return auth.SecurityPolicyError(c, err)
+	}
+
 	// generate access token
-	accessToken, err := auth.GenerateAccessToken(user.UUID.String())
+	accessToken, err := auth.GenerateAccessToken(user.UUID.String(), user.TenantKey())
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to generate access token",
@@ -142,7 +226,7 @@ func (h *AuthHandler) Login(c *fiber.Ctx) error {
 	}
 
 	// generate and store refresh token
-	refreshToken, err := h.authService.CreateAndStoreRefreshToken(user.UUID, c.Get("User-Agent"), c.IP())
+	refreshToken, err := h.authService.CreateAndStoreRefreshToken(user.UUID, user.TenantKey(), c.Get("User-Agent"), c.IP())
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to generate refresh token",
@@ -185,6 +269,14 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 		})
 	}
 
+	// the organization may only allow sign-ups from its networks
+	tenantID := requestTenantID(c)
+	if tenantID != nil {
+		if err := auth.CheckSignInIP(tenantID.String(), auth.ClientIP(c)); err != nil {
+			return auth.SecurityPolicyError(c, err)
+		}
+	}
+
 	// hash the password
 	hashedPassword, err := auth.HashPassword(dto.Password)
 	if err != nil {
@@ -202,22 +294,25 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 	}
 
 	// create a new user
-	newUserUUID, err := h.authRepo.CreateUser(&models.User{
+	newUser := &models.User{
 		Email:       dto.Email,
 		Password:    &hashedPassword,
 		FirstName:   dto.FirstName,
 		LastName:    dto.LastName,
 		LoginMethod: models.LoginMethodEmail,
 		Country:     country,
-	})
+		TenantID:    tenantID,
+	}
+	newUserUUID, err := h.authRepo.CreateUser(newUser)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to create user",
 		})
 	}
+	h.startOnboarding(newUserUUID, newUser.TenantID)
 
 	// generate access token
-	accessToken, err := auth.GenerateAccessToken(newUserUUID.String())
+	accessToken, err := auth.GenerateAccessToken(newUserUUID.String(), newUser.TenantKey())
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to generate access token",
@@ -225,7 +320,7 @@ func (h *AuthHandler) Register(c *fiber.Ctx) error {
 	}
 
 	// generate and store refresh token
-	refreshToken, err := h.authService.CreateAndStoreRefreshToken(newUserUUID, c.Get("User-Agent"), c.IP())
+	refreshToken, err := h.authService.CreateAndStoreRefreshToken(newUserUUID, newUser.TenantKey(), c.Get("User-Agent"), c.IP())
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to generate refresh token",
@@ -261,6 +356,24 @@ func (h *AuthHandler) RefreshToken(c *fiber.Ctx) error {
 		})
 	}
 
+	// A refresh token only works on the tenant it was issued for
+	if tenantID, enforced := auth.TenantIDFromCtx(c); enforced && claims.TenantID != tenantID {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid or expired refresh token",
+		})
+	}
+
+	// the organization may limit where sessions are renewed from and how long they last
+	if err := auth.CheckRefreshPolicy(claims, auth.ClientIP(c)); err != nil {
+		if errors.Is(err, auth.ErrSessionExpired) {
+			if err := h.authService.RevokeToken(storedToken.ID); err != nil {
+				log.Println(err, "Error revoking expired session")
+			}
+			clearAuthCookies(c)
+		}
+		return auth.SecurityPolicyError(c, err)
+	}
+
 	// Revoke the old token (rotation - one-time use)
 	if err := h.authService.RevokeToken(storedToken.ID); err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -268,8 +381,8 @@ func (h *AuthHandler) RefreshToken(c *fiber.Ctx) error {
 		})
 	}
 
-	// Generate new access token
-	accessToken, err := auth.GenerateAccessToken(claims.UserID)
+	// Generate new access token, in the same session
+	accessToken, err := auth.RenewAccessToken(claims)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to generate access token",
@@ -277,8 +390,7 @@ func (h *AuthHandler) RefreshToken(c *fiber.Ctx) error {
 	}
 
 	// Generate and store new refresh token
-	userUUID, _ := uuid.Parse(claims.UserID)
-	newRefreshToken, err := h.authService.CreateAndStoreRefreshToken(userUUID, c.Get("User-Agent"), c.IP())
+	newRefreshToken, err := h.authService.RotateRefreshToken(claims, c.Get("User-Agent"), c.IP())
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to generate refresh token",
@@ -303,6 +415,13 @@ func (h *AuthHandler) Logout(c *fiber.Ctx) error {
 		h.authService.RevokeTokenByJTI(refreshTokenValue)
 	}
 
+	// Reject the access token right away rather than when it expires, so a copy of it can't be replayed
+	if claims, err := auth.ValidateAccessToken(auth.AccessTokenFromRequest(c)); err == nil {
+		if err := auth.RevokeAccessToken(c.Context(), claims); err != nil {
+			log.Println(err, "Error revoking access token")
+		}
+	}
+
 	// Clear cookies
 	clearAuthCookies(c)
 
@@ -327,6 +446,19 @@ func (h *AuthHandler) LogoutAll(c *fiber.Ctx) error {
 		})
 	}
 
+	// Access tokens already handed out would otherwise keep working until they expire
+	if err := auth.RevokeUserAccessTokens(c.Context(), userID); err != nil {
+		log.Println(err, "Error revoking access tokens")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to logout from all devices",
+		})
+	}
+
+	// open websockets and SSE streams keep the token they were opened with, close them too
+	if h.hub != nil {
+		h.hub.CloseUserClients(userID)
+	}
+
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "Logged out from all devices",
 	})
@@ -418,7 +550,7 @@ func (h *AuthHandler) GetMe(c *fiber.Ctx) error {
 	})
 }
 
-// UpdateMe updates the current authenticated user
+// UpdateMe updates the current user's profile: names, avatar, timezone, locale and preferences
 func (h *AuthHandler) UpdateMe(c *fiber.Ctx) error {
 	userID := c.Locals("userID").(string)
 	userUUID, err := uuid.Parse(userID)
@@ -436,14 +568,36 @@ func (h *AuthHandler) UpdateMe(c *fiber.Ctx) error {
 		})
 	}
 
-	payload := &models.User{}
-
-	if v, ok := form.Value["first_name"]; ok && len(v) > 0 {
-		payload.FirstName = v[0]
+	profile := &service.ProfileUpdate{}
+	for field, target := range map[string]**string{
+		"first_name": &profile.FirstName,
+		"last_name":  &profile.LastName,
+		"timezone":   &profile.Timezone,
+		"locale":     &profile.Locale,
+	} {
+		if v, ok := form.Value[field]; ok && len(v) > 0 {
+			value := v[0]
+			*target = &value
+		}
+	}
+	if err := profile.Normalize(); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
 	}
 
-	if v, ok := form.Value["last_name"]; ok && len(v) > 0 {
-		payload.LastName = v[0]
+	payload := &models.User{}
+	if profile.FirstName != nil {
+		payload.FirstName = *profile.FirstName
+	}
+	if profile.LastName != nil {
+		payload.LastName = *profile.LastName
+	}
+	if profile.Timezone != nil {
+		payload.Timezone = *profile.Timezone
+	}
+	if profile.Locale != nil {
+		payload.Locale = *profile.Locale
 	}
 
 	if files, ok := form.File["avatar"]; ok && len(files) > 0 {
@@ -458,15 +612,29 @@ func (h *AuthHandler) UpdateMe(c *fiber.Ctx) error {
 			})
 		}
 		defer file.Close()
-		log.Println("file", file)
+		data, err := io.ReadAll(file)
+		if err != nil {
+			log.Println("error", err)
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to read avatar file",
+			})
+		}
 		key := "users/" + userUUID.String() + "/avatar.png"
-		url, err := libraries.GetClients().Upload(context.Background(), key, file, fileHeader.Header.Get("Content-Type"))
+		contentType, ok, err := validateUpload(c, h.uploads, userUUID, data)
+		if !ok {
+			return err
+		}
+		if ok, err := checkStorageQuota(c, h.storageQuota, userUUID, key, int64(len(data))); !ok {
+			return err
+		}
+		url, err := libraries.GetClients().Upload(storageContext(c), key, bytes.NewReader(data), contentType)
 		if err != nil {
 			log.Println("error", err)
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": "Failed to upload avatar to gcp",
 			})
 		}
+		h.storageQuota.Record(userUUID, models.StorageKindAvatar, key, int64(len(data)))
 		log.Println(url, "url")
 		payload.Avatar = url
 	} else {
@@ -479,6 +647,65 @@ func (h *AuthHandler) UpdateMe(c *fiber.Ctx) error {
 			"error": "Failed to update user",
 		})
 	}
+
+	// a bool can't go through UpdateUserByID, which skips zero values
+	if v, ok := form.Value["store_thinking"]; ok && len(v) > 0 {
+		storeThinking, err := strconv.ParseBool(v[0])
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "store_thinking must be true or false",
+			})
+		}
+		if err := h.authRepo.SetStoreThinking(userUUID, storeThinking); err != nil {
+			log.Println(err, "Error updating store_thinking")
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to update user",
+			})
+		}
+	}
+	if v, ok := form.Value["verify_drawings"]; ok && len(v) > 0 {
+		verifyDrawings, err := strconv.ParseBool(v[0])
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "verify_drawings must be true or false",
+			})
+		}
+		if err := h.authRepo.SetVerifyDrawings(userUUID, verifyDrawings); err != nil {
+			log.Println(err, "Error updating verify_drawings")
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to update user",
+			})
+		}
+	}
+	if v, ok := form.Value["confirm_destructive_tools"]; ok && len(v) > 0 {
+		confirmDestructiveTools, err := strconv.ParseBool(v[0])
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "confirm_destructive_tools must be true or false",
+			})
+		}
+		if err := h.authRepo.SetConfirmDestructiveTools(userUUID, confirmDestructiveTools); err != nil {
+			log.Println(err, "Error updating confirm_destructive_tools")
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to update user",
+			})
+		}
+	}
+	if v, ok := form.Value["redact_pii"]; ok && len(v) > 0 {
+		redactPII, err := strconv.ParseBool(v[0])
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "redact_pii must be true or false",
+			})
+		}
+		if err := h.authRepo.SetRedactPII(userUUID, redactPII); err != nil {
+			log.Println(err, "Error updating redact_pii")
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to update user",
+			})
+		}
+	}
+
 	user, err := h.authRepo.GetUserByID(userUUID)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -493,26 +720,16 @@ func (h *AuthHandler) UpdateMe(c *fiber.Ctx) error {
 
 // GoogleLogin redirects the user to the Google OAuth login page
 func (h *AuthHandler) GoogleLogin(c *fiber.Ctx) error {
-	randomHash := uuid.NewString()
-	url := oauth.GetGoogleOAuthConfig().AuthCodeURL(
-		randomHash,
-		oauth2.AccessTypeOffline,
-	)
-	return c.Redirect(url)
+	return startOAuthFlow(c, "google", oauth.GetGoogleOAuthConfig())
 }
 
 // GoogleCallback handles the callback from the Google OAuth login page
 func (h *AuthHandler) GoogleCallback(c *fiber.Ctx) error {
-	frontendURL := os.Getenv("FRONTEND_URL")
-
-	code := c.Query("code")
-	if code == "" {
-		return c.Redirect(frontendURL + "/auth?error=missing_code")
-	}
+	frontendURL := config.GetSettings().Server.FrontendURL
 
-	token, err := oauth.GetGoogleOAuthConfig().Exchange(c.Context(), code)
-	if err != nil {
-		return c.Redirect(frontendURL + "/auth?error=oauth_exchange_failed")
+	token, failure := finishOAuthFlow(c, "google", oauth.GetGoogleOAuthConfig())
+	if token == nil {
+		return c.Redirect(frontendURL + "/auth?error=" + failure)
 	}
 
 	client := oauth.GetGoogleOAuthConfig().Client(c.Context(), token)
@@ -573,10 +790,12 @@ func (h *AuthHandler) GoogleCallback(c *fiber.Ctx) error {
 			Subscription: models.SubscriptionFree,
 			Avatar:       userInfo.Picture,
 			Country:      country,
+			TenantID:     requestTenantID(c),
 		})
 		if err != nil {
 			return c.Redirect(frontendURL + "/auth?error=failed_to_create_user")
 		}
+		h.startOnboarding(newUserUUID, requestTenantID(c))
 
 		// Fetch the newly created user to get all fields
 		user, err = h.authRepo.GetUserByID(newUserUUID)
@@ -588,16 +807,22 @@ func (h *AuthHandler) GoogleCallback(c *fiber.Ctx) error {
 		if user.LoginMethod != models.LoginMethodGoogle {
 			return c.Redirect(frontendURL + "/auth?error=email_exists_different_provider&provider=" + string(user.LoginMethod))
 		}
+		if !belongsToRequestTenant(c, user) {
+			return c.Redirect(frontendURL + "/auth?error=tenant_mismatch")
+		}
+	}
+	if err := auth.CheckSignInIP(user.TenantKey(), auth.ClientIP(c)); err != nil {
+		return c.Redirect(frontendURL + "/auth?error=" + signInPolicyRedirectError(err))
 	}
 
 	// 3. Issue JWTs using the database user UUID (not Google's Sub)
-	accessToken, err := auth.GenerateAccessToken(user.UUID.String())
+	accessToken, err := auth.GenerateAccessToken(user.UUID.String(), user.TenantKey())
 	if err != nil {
 		return c.Redirect(frontendURL + "/auth?error=failed_to_generate_token")
 	}
 
 	// Generate and store refresh token (using authService like regular login)
-	refreshToken, err := h.authService.CreateAndStoreRefreshToken(user.UUID, c.Get("User-Agent"), c.IP())
+	refreshToken, err := h.authService.CreateAndStoreRefreshToken(user.UUID, user.TenantKey(), c.Get("User-Agent"), c.IP())
 	if err != nil {
 		return c.Redirect(frontendURL + "/auth?error=failed_to_generate_refresh_token")
 	}
@@ -611,26 +836,16 @@ func (h *AuthHandler) GoogleCallback(c *fiber.Ctx) error {
 
 // GithubLogin redirects the user to the Github OAuth login page
 func (h *AuthHandler) GithubLogin(c *fiber.Ctx) error {
-	randomHash := uuid.NewString()
-	url := oauth.GetGitHubOAuthConfig().AuthCodeURL(
-		randomHash,
-		oauth2.AccessTypeOffline,
-	)
-	return c.Redirect(url)
+	return startOAuthFlow(c, "github", oauth.GetGitHubOAuthConfig())
 }
 
 // GithubCallback handles the callback from the Github OAuth login page
 func (h *AuthHandler) GithubCallback(c *fiber.Ctx) error {
-	frontendURL := os.Getenv("FRONTEND_URL")
+	frontendURL := config.GetSettings().Server.FrontendURL
 
-	code := c.Query("code")
-	if code == "" {
-		return c.Redirect(frontendURL + "/auth?error=missing_code")
-	}
-
-	token, err := oauth.GetGitHubOAuthConfig().Exchange(c.Context(), code)
-	if err != nil {
-		return c.Redirect(frontendURL + "/auth?error=oauth_exchange_failed")
+	token, failure := finishOAuthFlow(c, "github", oauth.GetGitHubOAuthConfig())
+	if token == nil {
+		return c.Redirect(frontendURL + "/auth?error=" + failure)
 	}
 
 	client := oauth.GetGitHubOAuthConfig().Client(c.Context(), token)
@@ -743,10 +958,12 @@ func (h *AuthHandler) GithubCallback(c *fiber.Ctx) error {
 			LoginMethod:  models.LoginMethodGithub,
 			Subscription: models.SubscriptionFree,
 			Country:      country,
+			TenantID:     requestTenantID(c),
 		})
 		if err != nil {
 			return c.Redirect(frontendURL + "/auth?error=failed_to_create_user")
 		}
+		h.startOnboarding(newUserUUID, requestTenantID(c))
 
 		// Fetch the newly created user to get all fields
 		user, err = h.authRepo.GetUserByID(newUserUUID)
@@ -758,16 +975,22 @@ func (h *AuthHandler) GithubCallback(c *fiber.Ctx) error {
 		if user.LoginMethod != models.LoginMethodGithub {
 			return c.Redirect(frontendURL + "/auth?error=email_exists_different_provider&provider=" + string(user.LoginMethod))
 		}
+		if !belongsToRequestTenant(c, user) {
+			return c.Redirect(frontendURL + "/auth?error=tenant_mismatch")
+		}
+	}
+	if err := auth.CheckSignInIP(user.TenantKey(), auth.ClientIP(c)); err != nil {
+		return c.Redirect(frontendURL + "/auth?error=" + signInPolicyRedirectError(err))
 	}
 
 	// 3. Issue JWTs using the database user UUID (not Github's ID)
-	accessToken, err := auth.GenerateAccessToken(user.UUID.String())
+	accessToken, err := auth.GenerateAccessToken(user.UUID.String(), user.TenantKey())
 	if err != nil {
 		return c.Redirect(frontendURL + "/auth?error=failed_to_generate_token")
 	}
 
 	// Generate and store refresh token (using authService like regular login)
-	refreshToken, err := h.authService.CreateAndStoreRefreshToken(user.UUID, c.Get("User-Agent"), c.IP())
+	refreshToken, err := h.authService.CreateAndStoreRefreshToken(user.UUID, user.TenantKey(), c.Get("User-Agent"), c.IP())
 	if err != nil {
 		return c.Redirect(frontendURL + "/auth?error=failed_to_generate_refresh_token")
 	}
@@ -0,0 +1,149 @@
+package handlers
+
+import (
+	"melina-studio-backend/internal/repo"
+	"melina-studio-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type AutomationHandler struct {
+	boardRepo         repo.BoardRepoInterface
+	automationService *service.AutomationService
+}
+
+func NewAutomationHandler(boardRepo repo.BoardRepoInterface, automationService *service.AutomationService) *AutomationHandler {
+	return &AutomationHandler{
+		boardRepo:         boardRepo,
+		automationService: automationService,
+	}
+}
+
+// automationErrors are the responses to the automation service's errors
+var automationErrors = []errorStatus{
+	{service.ErrInvalidAutomation, fiber.StatusBadRequest, ""},
+	{service.ErrTooManyAutomations, fiber.StatusBadRequest, ""},
+	{service.ErrAutomationNotFound, fiber.StatusNotFound, "Automation rule not found"},
+}
+
+// function to list the automation rules of a board
+func (h *AutomationHandler) ListAutomations(c *fiber.Ctx) error {
+	_, boardId, status, msg := ownedBoard(c, h.boardRepo)
+	if status != fiber.StatusOK {
+		return c.Status(status).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	rules, err := h.automationService.List(boardId)
+	if err != nil {
+		return serviceError(c, err, automationErrors, "list automation rule")
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"rules": rules,
+	})
+}
+
+// function to add an automation rule to a board
+func (h *AutomationHandler) CreateAutomation(c *fiber.Ctx) error {
+	userID, boardId, status, msg := ownedBoard(c, h.boardRepo)
+	if status != fiber.StatusOK {
+		return c.Status(status).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	var dto service.AutomationRuleInput
+	if err := c.BodyParser(&dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	rule, err := h.automationService.Create(userID, boardId, dto)
+	if err != nil {
+		return serviceError(c, err, automationErrors, "create automation rule")
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"rule": rule,
+	})
+}
+
+// function to replace an automation rule of a board
+func (h *AutomationHandler) UpdateAutomation(c *fiber.Ctx) error {
+	_, boardId, status, msg := ownedBoard(c, h.boardRepo)
+	if status != fiber.StatusOK {
+		return c.Status(status).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+	ruleId, err := uuid.Parse(c.Params("ruleId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid rule ID",
+		})
+	}
+
+	var dto service.AutomationRuleInput
+	if err := c.BodyParser(&dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	rule, err := h.automationService.Update(boardId, ruleId, dto)
+	if err != nil {
+		return serviceError(c, err, automationErrors, "update automation rule")
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"rule": rule,
+	})
+}
+
+// function to delete an automation rule of a board with its execution log
+func (h *AutomationHandler) DeleteAutomation(c *fiber.Ctx) error {
+	_, boardId, status, msg := ownedBoard(c, h.boardRepo)
+	if status != fiber.StatusOK {
+		return c.Status(status).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+	ruleId, err := uuid.Parse(c.Params("ruleId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid rule ID",
+		})
+	}
+
+	if err := h.automationService.Delete(boardId, ruleId); err != nil {
+		return serviceError(c, err, automationErrors, "delete automation rule")
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Automation rule deleted",
+	})
+}
+
+// function to get the execution log of an automation rule, newest run first
+func (h *AutomationHandler) ListAutomationRuns(c *fiber.Ctx) error {
+	_, boardId, status, msg := ownedBoard(c, h.boardRepo)
+	if status != fiber.StatusOK {
+		return c.Status(status).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+	ruleId, err := uuid.Parse(c.Params("ruleId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid rule ID",
+		})
+	}
+
+	runs, err := h.automationService.Runs(boardId, ruleId)
+	if err != nil {
+		return serviceError(c, err, automationErrors, "get the runs of automation rule")
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"runs": runs,
+	})
+}
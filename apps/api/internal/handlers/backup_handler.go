@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"melina-studio-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type BackupHandler struct {
+	backupService *service.BackupService
+}
+
+func NewBackupHandler(backupService *service.BackupService) *BackupHandler {
+	return &BackupHandler{
+		backupService: backupService,
+	}
+}
+
+// function to back up a user's boards, shapes, chats and assets right now
+func (h *BackupHandler) CreateBackup(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("userId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	info, err := h.backupService.Backup(c.Context(), userID)
+	if err != nil {
+		return backupError(c, err, "Failed to create backup")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"backup": info,
+	})
+}
+
+// function to list a user's backups, newest first
+func (h *BackupHandler) ListBackups(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("userId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	backups, err := h.backupService.List(c.Context(), userID)
+	if err != nil {
+		return backupError(c, err, "Failed to list backups")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"backups": backups,
+	})
+}
+
+// function to restore a user's data from one of their backups
+func (h *BackupHandler) RestoreBackup(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Params("userId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	info, err := h.backupService.Restore(c.Context(), userID, c.Params("backupId"))
+	if err != nil {
+		return backupError(c, err, "Failed to restore backup")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Backup restored successfully",
+		"backup":  info,
+	})
+}
+
+func backupError(c *fiber.Ctx, err error, message string) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidBackupID), errors.Is(err, service.ErrInvalidBackup):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, service.ErrBackupStorageUnavailable):
+		return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	log.Println(err, message)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": message,
+	})
+}
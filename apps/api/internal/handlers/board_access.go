@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"melina-studio-backend/internal/repo"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// requestUser parses the user the request was authenticated as, returning an HTTP status and message on failure
+func requestUser(c *fiber.Ctx) (uuid.UUID, int, string) {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return uuid.Nil, fiber.StatusBadRequest, "Invalid user ID"
+	}
+	return userID, fiber.StatusOK, ""
+}
+
+// ownedBoard parses the user and board of the request and checks the user owns the board, returning an
+// HTTP status and message on failure
+func ownedBoard(c *fiber.Ctx, boardRepo repo.BoardRepoInterface) (uuid.UUID, uuid.UUID, int, string) {
+	userID, status, msg := requestUser(c)
+	if status != fiber.StatusOK {
+		return uuid.Nil, uuid.Nil, status, msg
+	}
+	boardId, err := uuid.Parse(c.Params("boardId"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, fiber.StatusBadRequest, "Invalid board ID"
+	}
+	if err := boardRepo.ValidateBoardOwnership(userID, boardId); err != nil {
+		return uuid.Nil, uuid.Nil, fiber.StatusNotFound, "Board not found"
+	}
+	return userID, boardId, fiber.StatusOK, ""
+}
+
+// errorStatus is the response to a service's sentinel error; an empty message answers with the error itself
+type errorStatus struct {
+	err     error
+	status  int
+	message string
+}
+
+// serviceError answers with the first of statuses the error wraps. Any other error is logged and answered
+// with a 500 saying what failed
+func serviceError(c *fiber.Ctx, err error, statuses []errorStatus, failed string) error {
+	for _, s := range statuses {
+		if errors.Is(err, s.err) {
+			message := s.message
+			if message == "" {
+				message = err.Error()
+			}
+			return c.Status(s.status).JSON(fiber.Map{
+				"error": message,
+			})
+		}
+	}
+	log.Println(err, "Error trying to "+failed)
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "Failed to " + failed,
+	})
+}
@@ -0,0 +1,153 @@
+package handlers
+
+import (
+	"bytes"
+	"io"
+	"log"
+	"melina-studio-backend/internal/libraries"
+	"melina-studio-backend/internal/melina/tools"
+	"melina-studio-backend/internal/models"
+	"os"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// function to set a board's cover image, either an uploaded image file or one cropped from the board preview
+func (h *BoardHandler) SetBoardCover(c *fiber.Ctx) error {
+	userId, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	boardId, err := uuid.Parse(c.Params("boardId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid board ID",
+		})
+	}
+	board, err := h.repo.GetBoardById(userId, boardId)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Board not found",
+		})
+	}
+
+	var image []byte
+	if fileHeader, err := c.FormFile("image"); err == nil {
+		file, err := fileHeader.Open()
+		if err != nil {
+			log.Println(err, "Error opening cover file")
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to open cover file",
+			})
+		}
+		defer file.Close()
+		image, err = io.ReadAll(file)
+		if err != nil {
+			log.Println(err, "Error reading cover file")
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to read cover file",
+			})
+		}
+	} else {
+		// without a file the cover is cropped from the preview the client saved with the board
+		preview, err := os.ReadFile("temp/images/" + boardId.String() + ".png")
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Upload an image or save the board first to generate a cover from its preview",
+			})
+		}
+		image, err = tools.RenderBoardCover(preview)
+		if err != nil {
+			log.Println(err, "Error rendering board cover")
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to generate cover",
+			})
+		}
+	}
+
+	key := tools.BoardCoverKey(boardId)
+	contentType, ok, err := validateUpload(c, h.uploads, userId, image)
+	if !ok {
+		return err
+	}
+	// a sandbox's images are deleted with it and don't count against the user's quota
+	if !board.IsSandbox {
+		if ok, err := checkStorageQuota(c, h.storageQuota, userId, key, int64(len(image))); !ok {
+			return err
+		}
+	}
+	url, err := libraries.GetClients().Upload(storageContext(c), key, bytes.NewReader(image), contentType)
+	if err != nil {
+		log.Println(err, "Error uploading cover to gcs")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to upload cover",
+		})
+	}
+	if !board.IsSandbox {
+		h.storageQuota.Record(userId, models.StorageKindPreview, key, int64(len(image)))
+	}
+
+	url = tools.VersionedURL(url)
+	if err := h.repo.SetBoardCover(userId, boardId, models.BoardCover{CoverImage: &url}); err != nil {
+		log.Println(err, "Error saving board cover")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to save cover",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"cover_image": url,
+		"message":     "Cover updated successfully",
+	})
+}
+
+// function to remove a board's cover image
+func (h *BoardHandler) DeleteBoardCover(c *fiber.Ctx) error {
+	userId, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	boardId, err := uuid.Parse(c.Params("boardId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid board ID",
+		})
+	}
+	board, err := h.repo.GetBoardById(userId, boardId)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Board not found",
+		})
+	}
+	if board.CoverImage == "" {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"message": "Board has no cover",
+		})
+	}
+
+	empty := ""
+	if err := h.repo.SetBoardCover(userId, boardId, models.BoardCover{CoverImage: &empty}); err != nil {
+		log.Println(err, "Error removing board cover")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to remove cover",
+		})
+	}
+
+	key := tools.BoardCoverKey(boardId)
+	if err := libraries.GetClients().Remove(storageContext(c), key); err != nil {
+		log.Println(err, "Error deleting cover from gcs")
+	} else if err := h.storageQuota.Release(userId, key); err != nil {
+		log.Println(err, "Error releasing cover storage")
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Cover removed successfully",
+	})
+}
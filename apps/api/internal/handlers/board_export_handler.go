@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"melina-studio-backend/internal/repo"
+	"melina-studio-backend/internal/service"
+	"strconv"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type BoardExportHandler struct {
+	exportService *service.BoardExportService
+	boardRepo     repo.BoardRepoInterface
+}
+
+func NewBoardExportHandler(exportService *service.BoardExportService, boardRepo repo.BoardRepoInterface) *BoardExportHandler {
+	return &BoardExportHandler{
+		exportService: exportService,
+		boardRepo:     boardRepo,
+	}
+}
+
+// function to export a board as a PNG, watermarked on the plans whose exports carry it
+func (h *BoardExportHandler) ExportPNG(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	boardId, err := uuid.Parse(c.Params("boardId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid board ID",
+		})
+	}
+
+	if err := h.boardRepo.ValidateBoardOwnership(userID, boardId); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Board not found",
+		})
+	}
+
+	image, watermarked, err := h.exportService.ExportPNG(userID, boardId)
+	if err != nil {
+		if errors.Is(err, service.ErrBoardPreviewMissing) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Save the board first to export it",
+			})
+		}
+		log.Println(err, "Error exporting board")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to export board",
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, "image/png")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="board.png"`)
+	c.Set("X-Watermarked", strconv.FormatBool(watermarked))
+	return c.Status(fiber.StatusOK).Send(image)
+}
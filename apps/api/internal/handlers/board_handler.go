@@ -2,32 +2,52 @@ package handlers
 
 import (
 	"bytes"
-	"context"
 	"encoding/base64"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"melina-studio-backend/internal/libraries"
+	llmHandlers "melina-studio-backend/internal/llm_handlers"
+	"melina-studio-backend/internal/melina/tools"
 	"melina-studio-backend/internal/models"
 	"melina-studio-backend/internal/repo"
+	"melina-studio-backend/internal/service"
 	"os"
 	"path/filepath"
+	"slices"
+	"time"
 
 	"github.com/google/uuid"
 
 	"github.com/gofiber/fiber/v2"
+	"gorm.io/datatypes"
 )
 
 // for simple crud operations service layer is not required
 type BoardHandler struct {
-	repo          repo.BoardRepoInterface
-	boardDataRepo repo.BoardDataRepoInterface
+	repo            repo.BoardRepoInterface
+	boardDataRepo   repo.BoardDataRepoInterface
+	anchorService   *service.AnchorService
+	activityService *service.ActivityService
+	storageQuota    *service.StorageQuotaService
+	uploads         *service.UploadValidator
+	sandboxTTL      time.Duration
+	automations     *service.AutomationService
+	hub             *libraries.Hub
 }
 
-func NewBoardHandler(repo repo.BoardRepoInterface, boardDataRepo repo.BoardDataRepoInterface) *BoardHandler {
+func NewBoardHandler(repo repo.BoardRepoInterface, boardDataRepo repo.BoardDataRepoInterface, anchorService *service.AnchorService, activityService *service.ActivityService, storageQuota *service.StorageQuotaService, uploads *service.UploadValidator, sandboxTTL time.Duration, automations *service.AutomationService, hub *libraries.Hub) *BoardHandler {
 	return &BoardHandler{
-		repo:          repo,
-		boardDataRepo: boardDataRepo,
+		repo:            repo,
+		boardDataRepo:   boardDataRepo,
+		anchorService:   anchorService,
+		activityService: activityService,
+		storageQuota:    storageQuota,
+		uploads:         uploads,
+		sandboxTTL:      sandboxTTL,
+		automations:     automations,
+		hub:             hub,
 	}
 }
 
@@ -51,8 +71,9 @@ func (h *BoardHandler) CreateBoard(c *fiber.Ctx) error {
 
 	// create a new board
 	uuid, err := h.repo.CreateBoard(&models.Board{
-		Title:  dto.Title,
-		UserID: userID,
+		Title:    dto.Title,
+		UserID:   userID,
+		TenantID: requestTenantID(c),
 	})
 	if err != nil {
 		log.Println(err, "Error creating board")
@@ -67,6 +88,77 @@ func (h *BoardHandler) CreateBoard(c *fiber.Ctx) error {
 	})
 }
 
+// function to create a sandbox board for trying prompts safely; it is left out of board lists, search and
+// storage quotas and is deleted once it expires. With source_board_id the sandbox starts as a copy of that board
+func (h *BoardHandler) CreateSandboxBoard(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	var dto struct {
+		Title         string `json:"title"`
+		SourceBoardID string `json:"source_board_id"`
+	}
+	if err := c.BodyParser(&dto); err != nil && len(c.Body()) > 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	board := &models.Board{
+		Title:    dto.Title,
+		UserID:   userID,
+		TenantID: requestTenantID(c),
+	}
+	var sourceBoardID uuid.UUID
+	if dto.SourceBoardID != "" {
+		sourceBoardID, err = uuid.Parse(dto.SourceBoardID)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid source board ID",
+			})
+		}
+		source, err := h.repo.GetBoardById(userID, sourceBoardID)
+		if err != nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Source board not found",
+			})
+		}
+		board.AgentProfile = source.AgentProfile
+		if board.Title == "" {
+			board.Title = "Sandbox: " + source.Title
+		}
+	}
+	if board.Title == "" {
+		board.Title = "Sandbox"
+	}
+
+	boardID, err := h.repo.CreateSandboxBoard(board, h.sandboxTTL)
+	if err != nil {
+		log.Println(err, "Error creating sandbox board")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create sandbox board",
+		})
+	}
+	if sourceBoardID != uuid.Nil {
+		if err := h.copyBoardData(sourceBoardID, boardID); err != nil {
+			log.Println(err, "Error copying board data to sandbox")
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to copy board data",
+			})
+		}
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"uuid":       boardID.String(),
+		"expires_at": board.ExpiresAt,
+		"message":    "Sandbox board created successfully",
+	})
+}
+
 // function to get all boards
 func (h *BoardHandler) GetAllBoards(c *fiber.Ctx) error {
 	userID, err := uuid.Parse(c.Locals("userID").(string))
@@ -76,21 +168,105 @@ func (h *BoardHandler) GetAllBoards(c *fiber.Ctx) error {
 		})
 	}
 
-	boards, error := h.repo.GetAllBoards(userID)
+	// ?fields= trims each board to the listed fields, e.g. the dashboard only needs uuid, title, thumbnail and dates
+	fields, err := service.ParseFieldList(c.Query("fields"), service.BoardFields)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	includes, err := service.ParseFieldList(c.Query("include"), service.BoardListIncludes)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	withShapeCount := slices.Contains(includes, "shape_count")
+	// archived boards are only listed with ?archived=true, and then only they are
+	archived := c.QueryBool("archived")
+
+	// answer polling clients from the list's version without loading the boards
+	boardCount, boardsUpdated, err := h.repo.GetBoardsVersion(userID)
+	if err != nil {
+		log.Println(err, "Error getting boards version")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get boards",
+		})
+	}
+	etagParts := []interface{}{userID, boardCount, boardsUpdated.UnixNano(), string(c.Request().URI().QueryString())}
+	if withShapeCount {
+		shapeCount, shapesUpdated, err := h.boardDataRepo.GetUserBoardDataVersion(userID)
+		if err != nil {
+			log.Println(err, "Error getting shapes version")
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to get boards",
+			})
+		}
+		etagParts = append(etagParts, shapeCount, shapesUpdated.UnixNano())
+	}
+	if libraries.NotModified(c, libraries.VersionETag(etagParts...)) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	// the shape counts are matched to the boards by uuid, so it is loaded even when not selected
+	columns := fields
+	if withShapeCount && columns != nil && !slices.Contains(columns, "uuid") {
+		columns = append(slices.Clone(columns), "uuid")
+	}
+	boards, error := h.repo.ListBoards(userID, archived, columns)
 	if error != nil {
 		log.Println(error, "Error getting boards")
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to get boards",
 		})
 	}
+
+	if !withShapeCount {
+		shaped, err := service.SelectFields(boards, fields)
+		if err != nil {
+			log.Println(err, "Error selecting board fields")
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to get boards",
+			})
+		}
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"boards": shaped,
+		})
+	}
+
+	boardIds := make([]uuid.UUID, len(boards))
+	for i, board := range boards {
+		boardIds[i] = board.UUID
+	}
+	counts, err := h.boardDataRepo.CountShapesByBoard(boardIds)
+	if err != nil {
+		log.Println(err, "Error counting shapes")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get boards",
+		})
+	}
+	if fields == nil {
+		fields = service.BoardFields
+	}
+	shaped, err := service.SelectFields(boards, fields)
+	if err != nil {
+		log.Println(err, "Error selecting board fields")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get boards",
+		})
+	}
+	list := shaped.([]map[string]interface{})
+	for i := range list {
+		list[i]["shape_count"] = counts[boards[i].UUID]
+	}
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"boards": boards,
+		"boards": list,
 	})
 }
 
 // function to save data to board
 func (h *BoardHandler) SaveData(c *fiber.Ctx) error {
-	_, err := uuid.Parse(c.Locals("userID").(string))
+	userID, err := uuid.Parse(c.Locals("userID").(string))
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "Invalid user ID",
@@ -106,6 +282,13 @@ func (h *BoardHandler) SaveData(c *fiber.Ctx) error {
 		})
 	}
 
+	board, err := h.repo.GetBoardById(userID, boardId)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Board not found",
+		})
+	}
+
 	// Parse multipart form data
 	form, err := c.MultipartForm()
 	if err != nil {
@@ -131,8 +314,29 @@ func (h *BoardHandler) SaveData(c *fiber.Ctx) error {
 		})
 	}
 
-	// Collect UUIDs of shapes being saved
-	var shapeUUIDs []uuid.UUID
+	// Snapshot the stored shapes so the save can be described in the activity feed
+	existingShapes, err := h.boardDataRepo.GetBoardData(boardId)
+	if err != nil {
+		log.Println(err, "Error getting board data")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get board data",
+		})
+	}
+
+	// with snapping on, saved coordinates are rounded to the board's grid, and timeline items
+	// always take the x and width of their dates
+	grid := tools.BoardSnapGrid(board)
+	scale, hasTimeline := tools.BoardTimeScale(board)
+	var snappedIDs []string
+
+	// Locked shapes keep their stored version: the save can't change or delete them
+	locked := service.SplitLockedShapes(existingShapes)
+	unlockedShapes := locked.Unlocked
+
+	// Collect UUIDs of shapes being saved, always keeping the locked ones
+	shapeUUIDs := make([]uuid.UUID, 0, len(shapes)+len(locked.IDs))
+	shapeUUIDs = append(shapeUUIDs, locked.IDs...)
+	var savedShapes []models.Shape
 
 	// Save each shape (create or update)
 	for _, data := range shapes {
@@ -143,7 +347,18 @@ func (h *BoardHandler) SaveData(c *fiber.Ctx) error {
 				"error": "Invalid shape ID",
 			})
 		}
+		if locked.Has(shapeUUID) {
+			continue
+		}
+		snapped := tools.SnapShape(&data, grid)
+		if hasTimeline && tools.LayoutTimelineShape(&data, scale) {
+			snapped = true
+		}
+		if snapped {
+			snappedIDs = append(snappedIDs, data.ID)
+		}
 		shapeUUIDs = append(shapeUUIDs, shapeUUID)
+		savedShapes = append(savedShapes, data)
 
 		err = h.boardDataRepo.SaveShapeData(boardId, &data)
 		if err != nil {
@@ -163,6 +378,21 @@ func (h *BoardHandler) SaveData(c *fiber.Ctx) error {
 		})
 	}
 
+	h.activityService.Record(service.DiffShapeActivities(userID, boardId, unlockedShapes, savedShapes)...)
+
+	// cards dropped into a kanban column take the column's status
+	statusIDs, err := h.syncCardStatuses(userID, boardId)
+	if err != nil {
+		log.Println(err, "Error syncing kanban card statuses")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to save shape data",
+		})
+	}
+
+	// the board's automation rules fire for the shapes the user created or changed
+	h.automations.ShapesSaved(userID, boardId, unlockedShapes, savedShapes)
+	h.broadcastShapeChanges(c, boardId, unlockedShapes, nil)
+
 	// Handle image file if provided
 	files := form.File["image"]
 	if len(files) > 0 {
@@ -191,9 +421,223 @@ func (h *BoardHandler) SaveData(c *fiber.Ctx) error {
 		log.Printf("Image saved successfully: %s", filepath)
 	}
 
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+	response := fiber.Map{
 		"message": "Data saved successfully",
-	})
+	}
+	if len(locked.IDs) > 0 {
+		// the client should show these as stored, whatever it sent for them
+		response["locked_shapes"] = locked.Strings()
+	}
+	if len(snappedIDs) > 0 {
+		// the client should move these to their stored coordinates, snapped or laid out from their dates
+		response["snapped_shapes"] = snappedIDs
+	}
+	if len(statusIDs) > 0 {
+		// the client should reload these cards, they took the status of the column they were moved to
+		response["status_changed_shapes"] = statusIDs
+	}
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// function to apply a batch of shape creates, updates and deletes in one transaction, so the client
+// only sends the shapes that changed instead of the whole board
+func (h *BoardHandler) BatchSaveShapes(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	boardId, err := uuid.Parse(c.Params("boardId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid board ID",
+		})
+	}
+
+	var dto struct {
+		Operations []service.ShapeOperationInput `json:"operations"`
+	}
+	if err := c.BodyParser(&dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	ops, err := service.ParseShapeOperations(dto.Operations)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	board, err := h.repo.GetBoardById(userID, boardId)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Board not found",
+		})
+	}
+
+	// Snapshot the touched shapes, to skip the locked ones and describe the change in the activity feed
+	shapeIDs := make([]uuid.UUID, 0, len(ops))
+	for _, op := range ops {
+		shapeIDs = append(shapeIDs, op.ID)
+	}
+	storedShapes, err := h.boardDataRepo.GetShapesByUUIDs(shapeIDs)
+	if err != nil {
+		log.Println(err, "Error getting board data")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get board data",
+		})
+	}
+	batch, err := service.PlanShapeBatch(boardId, ops, storedShapes)
+	if err != nil {
+		// a shape to update is gone or belongs to another board; the client should reload the board
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	lockedIDs, unlockedShapes := batch.LockedIDs, batch.Unlocked
+
+	// saved coordinates are snapped and timeline items laid out as in the full save
+	grid := tools.BoardSnapGrid(board)
+	scale, hasTimeline := tools.BoardTimeScale(board)
+	var snappedIDs []string
+	var savedShapes []models.Shape
+	applied := batch.Applied
+	for _, op := range applied {
+		if op.Shape != nil {
+			snapped := tools.SnapShape(op.Shape, grid)
+			if hasTimeline && tools.LayoutTimelineShape(op.Shape, scale) {
+				snapped = true
+			}
+			if snapped {
+				snappedIDs = append(snappedIDs, op.Shape.ID)
+			}
+			savedShapes = append(savedShapes, *op.Shape)
+		}
+	}
+
+	if err := h.boardDataRepo.ApplyShapeOperations(boardId, applied); err != nil {
+		if errors.Is(err, repo.ErrShapeNotFound) {
+			// the shape was deleted since the client queued the update; it should reload the board
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		log.Println(err, "Error applying shape operations")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to save shape data",
+		})
+	}
+
+	h.activityService.Record(service.DiffShapeActivities(userID, boardId, unlockedShapes, savedShapes)...)
+
+	statusIDs, err := h.syncCardStatuses(userID, boardId)
+	if err != nil {
+		log.Println(err, "Error syncing kanban card statuses")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to save shape data",
+		})
+	}
+
+	h.automations.ShapesSaved(userID, boardId, unlockedShapes, savedShapes)
+
+	appliedIDs := make([]uuid.UUID, 0, len(applied))
+	for _, op := range applied {
+		appliedIDs = append(appliedIDs, op.ID)
+	}
+	h.broadcastShapeChanges(c, boardId, unlockedShapes, appliedIDs)
+
+	response := fiber.Map{
+		"message": "Data saved successfully",
+		"applied": len(applied),
+	}
+	if len(lockedIDs) > 0 {
+		response["locked_shapes"] = lockedIDs
+	}
+	if len(snappedIDs) > 0 {
+		response["snapped_shapes"] = snappedIDs
+	}
+	if len(statusIDs) > 0 {
+		response["status_changed_shapes"] = statusIDs
+	}
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// syncCardStatuses stores the column status of the board's kanban cards that changed columns, records the moves
+// and tells the user's connections; it returns the ids of the cards that changed
+func (h *BoardHandler) syncCardStatuses(userID uuid.UUID, boardId uuid.UUID) ([]string, error) {
+	shapes, err := h.boardDataRepo.GetBoardData(boardId)
+	if err != nil {
+		return nil, err
+	}
+	changes := tools.SyncCardStatuses(shapes)
+	if len(changes) == 0 {
+		return nil, nil
+	}
+	updates := make(map[uuid.UUID]datatypes.JSON, len(changes))
+	for _, change := range changes {
+		updates[change.ShapeID] = change.Data
+	}
+	if err := h.boardDataRepo.UpdateShapesData(boardId, updates); err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(changes))
+	activities := make([]*models.BoardActivity, 0, len(changes))
+	for _, change := range changes {
+		ids = append(ids, change.ShapeID.String())
+		activities = append(activities, tools.CardMovedActivity(userID, boardId, models.ActivityActorUser, change))
+		if h.hub != nil {
+			libraries.SendCardStatusMessage(h.hub, userID.String(), &libraries.CardStatusPayload{
+				BoardId:        boardId.String(),
+				ShapeId:        change.ShapeID.String(),
+				ColumnId:       change.ColumnID.String(),
+				Status:         change.Status,
+				PreviousStatus: change.PreviousStatus,
+			})
+		}
+	}
+	h.activityService.Record(activities...)
+	return ids, nil
+}
+
+// broadcastShapeChanges sends what a save did to the other connections viewing the board. before holds the
+// stored versions of the shapes the save could touch, touched their ids and those of the shapes it created,
+// nil meaning the whole board. Failures are logged, they never fail the save
+func (h *BoardHandler) broadcastShapeChanges(c *fiber.Ctx, boardId uuid.UUID, before []models.BoardData, touched []uuid.UUID) {
+	if h.hub == nil || !h.hub.HasBoardRoom(boardId.String()) {
+		return
+	}
+
+	var stored []models.BoardData
+	var err error
+	if touched == nil {
+		stored, err = h.boardDataRepo.GetBoardData(boardId)
+	} else {
+		stored, err = h.boardDataRepo.GetShapesByUUIDs(touched)
+	}
+	if err != nil {
+		log.Println(err, "Error getting saved shapes to broadcast")
+		return
+	}
+	// locked shapes are left as they were by every save
+	unlocked := func(shapes []models.BoardData) []models.BoardData {
+		kept := make([]models.BoardData, 0, len(shapes))
+		for _, shape := range shapes {
+			if shape.BoardId == boardId && !shape.Locked {
+				kept = append(kept, shape)
+			}
+		}
+		return kept
+	}
+
+	changes := service.DiffShapeChanges(unlocked(before), unlocked(stored))
+	if changes.Empty() {
+		return
+	}
+	libraries.SendBoardShapeChanges(h.hub, boardId.String(), c.Get(libraries.ClientIDHeader), changes.Created, changes.Updated, changes.Deleted)
 }
 
 // function to get board by ID
@@ -213,14 +657,24 @@ func (h *BoardHandler) GetBoardByID(c *fiber.Ctx) error {
 		})
 	}
 
-	board, err := h.boardDataRepo.GetBoardData(boardId)
+	// ?fields= trims boardInfo; ?include=shapes or ?include=info leaves out the other part
+	fields, err := service.ParseFieldList(c.Query("fields"), service.BoardFields)
 	if err != nil {
-		log.Println(err, "Error getting board")
-		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
-			"error": "Failed to get board",
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
 		})
 	}
+	includes, err := service.ParseFieldList(c.Query("include"), service.BoardIncludes)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	include := func(part string) bool {
+		return includes == nil || slices.Contains(includes, part)
+	}
 
+	// the board info is loaded even when left out, it is what checks the board belongs to the user
 	boardInfo, err := h.repo.GetBoardById(userID, boardId)
 	if err != nil {
 		log.Println(err, "Error getting board info")
@@ -229,23 +683,161 @@ func (h *BoardHandler) GetBoardByID(c *fiber.Ctx) error {
 		})
 	}
 
+	// the shapes are only loaded when the client doesn't have this version yet
+	shapeCount, shapesUpdated, err := h.boardDataRepo.GetBoardDataVersion(boardId)
+	if err != nil {
+		log.Println(err, "Error getting board version")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get board",
+		})
+	}
+	etag := libraries.VersionETag(boardId, boardInfo.UpdatedAt.UnixNano(), shapeCount, shapesUpdated.UnixNano(), string(c.Request().URI().QueryString()))
+	if libraries.NotModified(c, etag) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
+
+	response := fiber.Map{}
+	if include("shapes") {
+		board, err := h.boardDataRepo.GetBoardData(boardId)
+		if err != nil {
+			log.Println(err, "Error getting board")
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to get board",
+			})
+		}
+		response["board"] = board
+	}
+	if include("info") {
+		shaped, err := service.SelectFields(boardInfo, fields)
+		if err != nil {
+			log.Println(err, "Error selecting board fields")
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to get board info",
+			})
+		}
+		response["boardInfo"] = shaped
+	}
+
+	// resolve a deep-link anchor (?anchor=) so the frontend can focus the target on load
+	if token := c.Query("anchor"); token != "" {
+		anchor, err := h.anchorService.ResolveAnchor(boardId, token)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid anchor",
+			})
+		}
+		response["anchor"] = anchor
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// function to get a single shape of a board
+func (h *BoardHandler) GetShape(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	boardId, err := uuid.Parse(c.Params("boardId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid board ID",
+		})
+	}
+	shapeId, err := uuid.Parse(c.Params("shapeId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid shape ID",
+		})
+	}
+
+	if _, err := h.repo.GetBoardById(userID, boardId); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Board not found",
+		})
+	}
+
+	shape, err := h.boardDataRepo.GetShapeByUUID(shapeId)
+	if err != nil || shape.BoardId != boardId {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Shape not found",
+		})
+	}
+
+	if libraries.NotModified(c, libraries.VersionETag(shape.UUID, shape.UpdatedAt.UnixNano())) {
+		return c.SendStatus(fiber.StatusNotModified)
+	}
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"board":     board,
-		"boardInfo": boardInfo,
+		"shape": shape,
 	})
 }
 
-// function to clear board
-func (h *BoardHandler) ClearBoard(c *fiber.Ctx) error {
-	boardIdStr := c.Params("boardId")
-	boardId, err := uuid.Parse(boardIdStr)
+// function to get the action item cards of a board, for syncing to external trackers
+func (h *BoardHandler) GetActionItems(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	boardId, err := uuid.Parse(c.Params("boardId"))
 	if err != nil {
 		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
 			"error": "Invalid board ID",
 		})
 	}
 
-	err = h.boardDataRepo.ClearBoardData(boardId)
+	if _, err := h.repo.GetBoardById(userID, boardId); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Board not found",
+		})
+	}
+
+	shapes, err := h.boardDataRepo.GetShapesByType(boardId, models.ActionItemCard)
+	if err != nil {
+		log.Println(err, "Error getting action items")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get action items",
+		})
+	}
+
+	actionItems := make([]models.ActionItem, 0, len(shapes))
+	for _, shape := range shapes {
+		item, err := models.NewActionItem(shape)
+		if err != nil {
+			log.Println(err, "Error parsing action item", shape.UUID)
+			continue
+		}
+		actionItems = append(actionItems, item)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"action_items": actionItems,
+	})
+}
+
+// function to clear board
+func (h *BoardHandler) ClearBoard(c *fiber.Ctx) error {
+	userID, boardId, status, msg := ownedBoard(c, h.repo)
+	if status != fiber.StatusOK {
+		return c.Status(status).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	// clearing keeps the locked shapes
+	shapes, err := h.boardDataRepo.GetBoardData(boardId)
+	if err != nil {
+		log.Println(err, "Error getting board data")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get board data",
+		})
+	}
+	err = h.boardDataRepo.DeleteShapesNotInList(boardId, service.SplitLockedShapes(shapes).IDs)
 	if err != nil {
 		log.Println(err, "Error clearing board")
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
@@ -253,6 +845,15 @@ func (h *BoardHandler) ClearBoard(c *fiber.Ctx) error {
 		})
 	}
 
+	h.activityService.Record(&models.BoardActivity{
+		BoardID: boardId,
+		UserID:  userID,
+		Type:    models.ActivityBoardCleared,
+		Actor:   models.ActivityActorUser,
+		Summary: "Cleared the board",
+	})
+	h.broadcastShapeChanges(c, boardId, shapes, nil)
+
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "Board cleared successfully",
 	})
@@ -304,6 +905,74 @@ func (h *BoardHandler) DeleteBoardByID(c *fiber.Ctx) error {
 	})
 }
 
+// function to archive a board, hiding it from the board list without deleting anything
+func (h *BoardHandler) ArchiveBoard(c *fiber.Ctx) error {
+	return h.setArchived(c, true)
+}
+
+// function to bring an archived board back to the board list
+func (h *BoardHandler) UnarchiveBoard(c *fiber.Ctx) error {
+	return h.setArchived(c, false)
+}
+
+func (h *BoardHandler) setArchived(c *fiber.Ctx, archived bool) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	boardId, err := uuid.Parse(c.Params("boardId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid board ID",
+		})
+	}
+
+	board, err := h.repo.GetBoardById(userID, boardId)
+	if err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Board not found",
+		})
+	}
+	if board.IsSandbox {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Sandbox boards can't be archived",
+		})
+	}
+
+	if board.IsArchived != archived {
+		if err := h.repo.SetArchived(userID, boardId, archived); err != nil {
+			log.Println(err, "Error archiving board")
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to update board",
+			})
+		}
+		activity := &models.BoardActivity{
+			BoardID: boardId,
+			UserID:  userID,
+			Type:    models.ActivityBoardArchived,
+			Actor:   models.ActivityActorUser,
+			Summary: "Archived the board",
+		}
+		if !archived {
+			activity.Type = models.ActivityBoardUnarchived
+			activity.Summary = "Unarchived the board"
+		}
+		h.activityService.Record(activity)
+	}
+
+	message := "Board archived successfully"
+	if !archived {
+		message = "Board unarchived successfully"
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"is_archived": archived,
+		"message":     message,
+	})
+}
+
 // function to update board by ID
 func (h *BoardHandler) UpdateBoardByID(c *fiber.Ctx) error {
 	userId, err := uuid.Parse(c.Locals("userID").(string))
@@ -326,6 +995,15 @@ func (h *BoardHandler) UpdateBoardByID(c *fiber.Ctx) error {
 		Thumbnail     *string `json:"thumbnail"`
 		Starred       *bool   `json:"starred"`
 		SaveThumbnail *bool   `json:"saveThumbnail"`
+		AgentProfile  *string `json:"agent_profile"`
+		Icon          *string `json:"icon"`
+		Description   *string `json:"description"`
+		GridSize      *int    `json:"grid_size"`
+		SnapToGrid    *bool   `json:"snap_to_grid"`
+		// TimelineOrigin is the date at TimelineOriginX; an empty string clears the time scale
+		TimelineOrigin   *string  `json:"timeline_origin"`
+		TimelineOriginX  *float64 `json:"timeline_origin_x"`
+		TimelineDayWidth *float64 `json:"timeline_day_width"`
 	}
 
 	if err := c.BodyParser(&dto); err != nil {
@@ -334,6 +1012,51 @@ func (h *BoardHandler) UpdateBoardByID(c *fiber.Ctx) error {
 		})
 	}
 
+	// icon and description can be cleared, so they are saved apart from the other fields
+	details := models.BoardCover{Icon: dto.Icon, Description: dto.Description}
+	if details.Icon != nil {
+		if err := tools.ValidateBoardIcon(*details.Icon); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+	}
+	if details.Description != nil {
+		if err := tools.ValidateBoardDescription(*details.Description); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+	}
+
+	grid := models.BoardGrid{GridSize: dto.GridSize, SnapToGrid: dto.SnapToGrid}
+	if grid.GridSize != nil {
+		if err := tools.ValidateGridSize(*grid.GridSize); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+	}
+
+	timeline := models.BoardTimeline{Origin: dto.TimelineOrigin, OriginX: dto.TimelineOriginX, DayWidth: dto.TimelineDayWidth}
+	if timeline.Origin != nil && *timeline.Origin != "" {
+		origin, err := tools.ParseTimelineDate(*timeline.Origin)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		formatted := origin.Format(tools.TimelineDateLayout)
+		timeline.Origin = &formatted
+	}
+	if timeline.DayWidth != nil {
+		if err := tools.ValidateTimelineDayWidth(*timeline.DayWidth); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+	}
+
 	payload := &models.Board{}
 	if dto.Title != nil {
 		payload.Title = *dto.Title
@@ -344,8 +1067,17 @@ func (h *BoardHandler) UpdateBoardByID(c *fiber.Ctx) error {
 	if dto.Starred != nil {
 		payload.Starred = *dto.Starred
 	}
+	if dto.AgentProfile != nil {
+		if _, err := llmHandlers.GetAgentProfile(*dto.AgentProfile); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid agent profile",
+			})
+		}
+		payload.AgentProfile = *dto.AgentProfile
+	}
 
-	if dto.SaveThumbnail != nil && *dto.SaveThumbnail {
+	// sandboxes are never listed, so they get no thumbnail
+	if dto.SaveThumbnail != nil && *dto.SaveThumbnail && !h.isSandbox(userId, boardId) {
 		// get the image from the temp/images directory
 		imagePath := "temp/images/" + boardId.String() + ".png"
 		image, err := os.ReadFile(imagePath)
@@ -356,13 +1088,22 @@ func (h *BoardHandler) UpdateBoardByID(c *fiber.Ctx) error {
 			})
 		}
 		// upload the image to gcs
-		url, err := libraries.GetClients().Upload(context.Background(), boardId.String()+".png", bytes.NewReader(image), "image/png")
+		key := boardId.String() + ".png"
+		contentType, ok, err := validateUpload(c, h.uploads, userId, image)
+		if !ok {
+			return err
+		}
+		if ok, err := checkStorageQuota(c, h.storageQuota, userId, key, int64(len(image))); !ok {
+			return err
+		}
+		url, err := libraries.GetClients().Upload(storageContext(c), key, bytes.NewReader(image), contentType)
 		if err != nil {
 			log.Println(err, "Error uploading image to gcs")
 			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 				"error": "Failed to upload image to gcs",
 			})
 		}
+		h.storageQuota.Record(userId, models.StorageKindPreview, key, int64(len(image)))
 
 		// save the url to board thumbnail
 		payload.Thumbnail = url
@@ -375,10 +1116,85 @@ func (h *BoardHandler) UpdateBoardByID(c *fiber.Ctx) error {
 			"error": "Failed to update board",
 		})
 	}
+	if details.Icon != nil || details.Description != nil {
+		if err := h.repo.SetBoardCover(userId, boardId, details); err != nil {
+			log.Println(err, "Error updating board details")
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to update board",
+			})
+		}
+	}
+	if grid.GridSize != nil || grid.SnapToGrid != nil {
+		if err := h.repo.SetBoardGrid(userId, boardId, grid); err != nil {
+			log.Println(err, "Error updating board grid")
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to update board",
+			})
+		}
+	}
+	var timelineIDs []string
+	if timeline.Origin != nil || timeline.OriginX != nil || timeline.DayWidth != nil {
+		if err := h.repo.SetBoardTimeline(userId, boardId, timeline); err != nil {
+			log.Println(err, "Error updating board timeline")
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to update board",
+			})
+		}
+		// the timeline items move with the new time scale
+		if timelineIDs, err = h.relayoutTimeline(userId, boardId); err != nil {
+			log.Println(err, "Error laying out timeline shapes")
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to update board",
+			})
+		}
+	}
+
+	if dto.Title != nil {
+		h.activityService.Record(&models.BoardActivity{
+			BoardID: boardId,
+			UserID:  userId,
+			Type:    models.ActivityBoardRenamed,
+			Actor:   models.ActivityActorUser,
+			Summary: fmt.Sprintf("Renamed the board to %q", *dto.Title),
+		})
+	}
 
-	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+	response := fiber.Map{
 		"message": "Board updated successfully",
-	})
+	}
+	if len(timelineIDs) > 0 {
+		// the client should reload these, they moved to their dates on the new time scale
+		response["timeline_shapes"] = timelineIDs
+	}
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// relayoutTimeline moves the board's timeline items to their dates on its time scale and returns the ids of those that moved
+func (h *BoardHandler) relayoutTimeline(userID uuid.UUID, boardId uuid.UUID) ([]string, error) {
+	board, err := h.repo.GetBoardById(userID, boardId)
+	if err != nil {
+		return nil, err
+	}
+	scale, ok := tools.BoardTimeScale(board)
+	if !ok {
+		return nil, nil
+	}
+	shapes, err := h.boardDataRepo.GetBoardData(boardId)
+	if err != nil {
+		return nil, err
+	}
+	updates := tools.RelayoutTimeline(shapes, scale)
+	if len(updates) == 0 {
+		return nil, nil
+	}
+	if err := h.boardDataRepo.UpdateShapesData(boardId, updates); err != nil {
+		return nil, err
+	}
+	ids := make([]string, 0, len(updates))
+	for id := range updates {
+		ids = append(ids, id.String())
+	}
+	return ids, nil
 }
 
 // function to duplicate a board along with all its data
@@ -409,8 +1225,16 @@ func (h *BoardHandler) DuplicateBoard(c *fiber.Ctx) error {
 
 	// Create a new board with copied title
 	newBoard := &models.Board{
-		Title:  sourceBoard.Title + " (Copy)",
-		UserID: userID,
+		Title:            sourceBoard.Title + " (Copy)",
+		UserID:           userID,
+		AgentProfile:     sourceBoard.AgentProfile,
+		Icon:             sourceBoard.Icon,
+		Description:      sourceBoard.Description,
+		GridSize:         sourceBoard.GridSize,
+		SnapToGrid:       sourceBoard.SnapToGrid,
+		TimelineOrigin:   sourceBoard.TimelineOrigin,
+		TimelineOriginX:  sourceBoard.TimelineOriginX,
+		TimelineDayWidth: sourceBoard.TimelineDayWidth,
 	}
 
 	newBoardId, err := h.repo.CreateBoard(newBoard)
@@ -421,36 +1245,48 @@ func (h *BoardHandler) DuplicateBoard(c *fiber.Ctx) error {
 		})
 	}
 
-	// Get all shapes from the source board
-	sourceShapes, err := h.boardDataRepo.GetBoardData(sourceBoardId)
-	if err != nil {
+	// Copy all shapes from the source board
+	if err := h.copyBoardData(sourceBoardId, newBoardId); err != nil {
 		log.Println(err, "Error getting source board data")
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to get source board data",
 		})
 	}
 
-	// Copy all shapes to the new board with new UUIDs
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"uuid":    newBoardId.String(),
+		"message": "Board duplicated successfully",
+	})
+}
+
+// isSandbox reports whether the user's board is a sandbox board
+func (h *BoardHandler) isSandbox(userID uuid.UUID, boardId uuid.UUID) bool {
+	board, err := h.repo.GetBoardById(userID, boardId)
+	return err == nil && board.IsSandbox
+}
+
+// copyBoardData copies all shapes of the source board to the target board with new UUIDs
+func (h *BoardHandler) copyBoardData(sourceBoardId uuid.UUID, targetBoardId uuid.UUID) error {
+	sourceShapes, err := h.boardDataRepo.GetBoardData(sourceBoardId)
+	if err != nil {
+		return err
+	}
 	for _, shape := range sourceShapes {
-		newShapeUUID := uuid.New()
 		newShape := models.BoardData{
-			UUID:             newShapeUUID,
-			BoardId:          newBoardId,
+			UUID:             uuid.New(),
+			BoardId:          targetBoardId,
 			Type:             shape.Type,
 			Data:             shape.Data,
 			ImageUrl:         shape.ImageUrl,
 			AnnotationNumber: shape.AnnotationNumber,
+			SlideOrder:       shape.SlideOrder,
 		}
 		if err := h.boardDataRepo.CreateBoardData(&newShape); err != nil {
 			log.Println(err, "Error copying shape data")
 			// Continue with other shapes even if one fails
 		}
 	}
-
-	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
-		"uuid":    newBoardId.String(),
-		"message": "Board duplicated successfully",
-	})
+	return nil
 }
 
 // function to upload selection image to gcp and storing the url of those shapes to the shape ids of that board
@@ -483,14 +1319,35 @@ func (h *BoardHandler) UploadSelectionImage(c *fiber.Ctx) error {
 		})
 	}
 
+	userId, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
 	// Upload the image to gcp
 	key := fmt.Sprintf("%s/%s.png", boardId.String(), body.SelectionShapeId)
-	url, err := libraries.GetClients().Upload(context.Background(), key, bytes.NewReader(decodedImage), "image/png")
+	contentType, ok, err := validateUpload(c, h.uploads, userId, decodedImage)
+	if !ok {
+		return err
+	}
+	// a sandbox's images are deleted with it and don't count against the user's quota
+	sandbox := h.isSandbox(userId, boardId)
+	if !sandbox {
+		if ok, err := checkStorageQuota(c, h.storageQuota, userId, key, int64(len(decodedImage))); !ok {
+			return err
+		}
+	}
+	url, err := libraries.GetClients().Upload(storageContext(c), key, bytes.NewReader(decodedImage), contentType)
 	if err != nil {
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "failed to upload image to gcp",
 		})
 	}
+	if !sandbox {
+		h.storageQuota.Record(userId, models.StorageKindPreview, key, int64(len(decodedImage)))
+	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
 		"message": "Selection image uploaded successfully",
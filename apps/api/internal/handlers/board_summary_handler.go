@@ -0,0 +1,220 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+	"melina-studio-backend/internal/service"
+	"regexp"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type BoardSummaryHandler struct {
+	boardRepo      repo.BoardRepoInterface
+	summaryRepo    repo.BoardSummaryRepoInterface
+	summaryService *service.BoardSummaryService
+}
+
+func NewBoardSummaryHandler(boardRepo repo.BoardRepoInterface, summaryRepo repo.BoardSummaryRepoInterface, summaryService *service.BoardSummaryService) *BoardSummaryHandler {
+	return &BoardSummaryHandler{
+		boardRepo:      boardRepo,
+		summaryRepo:    summaryRepo,
+		summaryService: summaryService,
+	}
+}
+
+// function to generate a structured summary of a board
+func (h *BoardSummaryHandler) Summarize(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	boardId, err := uuid.Parse(c.Params("boardId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid board ID",
+		})
+	}
+
+	if err := h.boardRepo.ValidateBoardOwnership(userID, boardId); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Board not found",
+		})
+	}
+
+	var dto struct {
+		ModelName string `json:"model_name"`
+	}
+	// body is optional
+	_ = c.BodyParser(&dto)
+
+	if err := checkTenantModel(c, dto.ModelName); err != nil {
+		return err
+	}
+
+	summary, queued, err := h.summaryService.CreateSummary(c.Context(), userID, boardId, dto.ModelName)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidSummary) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		log.Println(err, "Error generating board summary")
+		if summary == nil {
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to start board summary",
+			})
+		}
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error":   "Failed to generate board summary",
+			"summary": summary,
+		})
+	}
+
+	if queued {
+		return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+			"message": "Board summary queued",
+			"summary": summary,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Board summary generated",
+		"summary": summary,
+	})
+}
+
+// function to list the summaries of a board
+func (h *BoardSummaryHandler) ListSummaries(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	boardId, err := uuid.Parse(c.Params("boardId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid board ID",
+		})
+	}
+
+	summaries, err := h.summaryRepo.ListByBoard(userID, boardId)
+	if err != nil {
+		log.Println(err, "Error listing board summaries")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get board summaries",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"summaries": summaries,
+	})
+}
+
+// function to get a single summary (used to poll queued summaries)
+func (h *BoardSummaryHandler) GetSummary(c *fiber.Ctx) error {
+	summary, status, msg := h.loadSummary(c)
+	if summary == nil {
+		return c.Status(status).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"summary": summary,
+	})
+}
+
+// function to download a completed summary as a markdown file, or a Word document with format=docx
+func (h *BoardSummaryHandler) DownloadSummary(c *fiber.Ctx) error {
+	format := c.Query("format", "markdown")
+	if format != "markdown" && format != "docx" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "format must be 'markdown' or 'docx'",
+		})
+	}
+
+	summary, status, msg := h.loadSummary(c)
+	if summary == nil {
+		return c.Status(status).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	if summary.Status != models.SummaryStatusCompleted {
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error":  "Summary is not ready yet",
+			"status": summary.Status,
+		})
+	}
+
+	if format == "docx" {
+		doc, err := service.SummaryDocx(summary.Markdown)
+		if err != nil {
+			log.Println(err, "Error building board summary document")
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to build summary document",
+			})
+		}
+		c.Set(fiber.HeaderContentType, "application/vnd.openxmlformats-officedocument.wordprocessingml.document")
+		c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+summaryFileName(summary, ".docx")+`"`)
+		return c.Status(fiber.StatusOK).Send(doc)
+	}
+
+	c.Set(fiber.HeaderContentType, "text/markdown; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="`+summaryFileName(summary, ".md")+`"`)
+	return c.Status(fiber.StatusOK).SendString(summary.Markdown)
+}
+
+// loadSummary parses the route params and loads the summary, returning an HTTP status and message on failure
+func (h *BoardSummaryHandler) loadSummary(c *fiber.Ctx) (*models.BoardSummary, int, string) {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return nil, fiber.StatusBadRequest, "Invalid user ID"
+	}
+
+	boardId, err := uuid.Parse(c.Params("boardId"))
+	if err != nil {
+		return nil, fiber.StatusBadRequest, "Invalid board ID"
+	}
+
+	summaryId, err := uuid.Parse(c.Params("summaryId"))
+	if err != nil {
+		return nil, fiber.StatusBadRequest, "Invalid summary ID"
+	}
+
+	summary, err := h.summaryRepo.GetByID(userID, boardId, summaryId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fiber.StatusNotFound, "Summary not found"
+		}
+		log.Println(err, "Error getting board summary")
+		return nil, fiber.StatusInternalServerError, "Failed to get summary"
+	}
+	return summary, fiber.StatusOK, ""
+}
+
+var summaryFileNameSanitizer = regexp.MustCompile(`[^a-z0-9]+`)
+
+// summaryFileName builds a download file name with the extension from the summary title line
+func summaryFileName(summary *models.BoardSummary, extension string) string {
+	title := "board-summary"
+	firstLine := strings.SplitN(summary.Markdown, "\n", 2)[0]
+	if strings.HasPrefix(firstLine, "# ") {
+		slug := strings.Trim(summaryFileNameSanitizer.ReplaceAllString(strings.ToLower(firstLine[2:]), "-"), "-")
+		if slug != "" {
+			title = slug
+		}
+	}
+	return title + extension
+}
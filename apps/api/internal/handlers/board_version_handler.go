@@ -0,0 +1,109 @@
+package handlers
+
+import (
+	"melina-studio-backend/internal/libraries"
+	"melina-studio-backend/internal/repo"
+	"melina-studio-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type BoardVersionHandler struct {
+	boardRepo           repo.BoardRepoInterface
+	boardVersionService *service.BoardVersionService
+	hub                 *libraries.Hub
+}
+
+func NewBoardVersionHandler(boardRepo repo.BoardRepoInterface, boardVersionService *service.BoardVersionService, hub *libraries.Hub) *BoardVersionHandler {
+	return &BoardVersionHandler{
+		boardRepo:           boardRepo,
+		boardVersionService: boardVersionService,
+		hub:                 hub,
+	}
+}
+
+// boardVersionErrors are the responses to the board version service's errors
+var boardVersionErrors = []errorStatus{
+	{service.ErrInvalidBoardVersion, fiber.StatusBadRequest, ""},
+	{service.ErrBoardVersionNotFound, fiber.StatusNotFound, "Board version not found"},
+}
+
+// function to list the snapshots of a board, newest first
+func (h *BoardVersionHandler) ListVersions(c *fiber.Ctx) error {
+	_, boardId, status, msg := ownedBoard(c, h.boardRepo)
+	if status != fiber.StatusOK {
+		return c.Status(status).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	versions, err := h.boardVersionService.List(boardId)
+	if err != nil {
+		return serviceError(c, err, boardVersionErrors, "list board version")
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"versions": versions,
+	})
+}
+
+// function to take a snapshot of a board now, with an optional label
+func (h *BoardVersionHandler) CreateVersion(c *fiber.Ctx) error {
+	userID, boardId, status, msg := ownedBoard(c, h.boardRepo)
+	if status != fiber.StatusOK {
+		return c.Status(status).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	var dto struct {
+		Label string `json:"label"`
+	}
+	if len(c.Body()) > 0 {
+		if err := c.BodyParser(&dto); err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid request body",
+			})
+		}
+	}
+
+	version, err := h.boardVersionService.Create(userID, boardId, dto.Label)
+	if err != nil {
+		return serviceError(c, err, boardVersionErrors, "create board version")
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"version": version,
+	})
+}
+
+// function to roll a board back to one of its snapshots and have the owner's connections reload it
+func (h *BoardVersionHandler) RestoreVersion(c *fiber.Ctx) error {
+	userID, boardId, status, msg := ownedBoard(c, h.boardRepo)
+	if status != fiber.StatusOK {
+		return c.Status(status).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+	versionId, err := uuid.Parse(c.Params("versionId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid version ID",
+		})
+	}
+
+	version, backup, err := h.boardVersionService.Restore(userID, boardId, versionId)
+	if err != nil {
+		return serviceError(c, err, boardVersionErrors, "restore board version")
+	}
+	if h.hub != nil {
+		libraries.SendBoardRestoredMessage(h.hub, userID.String(), &libraries.BoardRestoredPayload{
+			BoardId:    boardId.String(),
+			VersionId:  version.UUID.String(),
+			ShapeCount: version.ShapeCount,
+		})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"version": version,
+		"backup":  backup,
+	})
+}
@@ -0,0 +1,158 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+	"melina-studio-backend/internal/service"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type BudgetHandler struct {
+	budgetService *service.BudgetService
+	boardRepo     repo.BoardRepoInterface
+}
+
+func NewBudgetHandler(budgetService *service.BudgetService, boardRepo repo.BoardRepoInterface) *BudgetHandler {
+	return &BudgetHandler{
+		budgetService: budgetService,
+		boardRepo:     boardRepo,
+	}
+}
+
+// function to get the monthly budget of a board the user owns
+func (h *BudgetHandler) GetBoardBudget(c *fiber.Ctx) error {
+	return h.withOwnedBoard(c, func(boardID uuid.UUID) error {
+		return h.getBudget(c, models.BudgetScopeBoard, boardID)
+	})
+}
+
+// function to set the monthly budget of a board the user owns
+func (h *BudgetHandler) SetBoardBudget(c *fiber.Ctx) error {
+	return h.withOwnedBoard(c, func(boardID uuid.UUID) error {
+		return h.setBudget(c, models.BudgetScopeBoard, boardID)
+	})
+}
+
+// function to remove the budget of a board the user owns
+func (h *BudgetHandler) DeleteBoardBudget(c *fiber.Ctx) error {
+	return h.withOwnedBoard(c, func(boardID uuid.UUID) error {
+		return h.deleteBudget(c, models.BudgetScopeBoard, boardID)
+	})
+}
+
+// function to get the monthly budget of a tenant
+func (h *BudgetHandler) GetTenantBudget(c *fiber.Ctx) error {
+	tenantID, err := uuid.Parse(c.Params("tenantId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid tenant ID",
+		})
+	}
+	return h.getBudget(c, models.BudgetScopeTenant, tenantID)
+}
+
+// function to set the monthly budget of a tenant, shared by all of its users
+func (h *BudgetHandler) SetTenantBudget(c *fiber.Ctx) error {
+	tenantID, err := uuid.Parse(c.Params("tenantId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid tenant ID",
+		})
+	}
+	return h.setBudget(c, models.BudgetScopeTenant, tenantID)
+}
+
+// function to remove the budget of a tenant
+func (h *BudgetHandler) DeleteTenantBudget(c *fiber.Ctx) error {
+	tenantID, err := uuid.Parse(c.Params("tenantId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid tenant ID",
+		})
+	}
+	return h.deleteBudget(c, models.BudgetScopeTenant, tenantID)
+}
+
+// withOwnedBoard runs next with the board of the request after checking the user owns it
+func (h *BudgetHandler) withOwnedBoard(c *fiber.Ctx, next func(boardID uuid.UUID) error) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+	boardID, err := uuid.Parse(c.Params("boardId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid board ID",
+		})
+	}
+	if err := h.boardRepo.ValidateBoardOwnership(userID, boardID); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Board not found",
+		})
+	}
+	return next(boardID)
+}
+
+func (h *BudgetHandler) getBudget(c *fiber.Ctx, scope models.BudgetScope, scopeID uuid.UUID) error {
+	status, err := h.budgetService.GetBudget(scope, scopeID, time.Now())
+	if err != nil {
+		if errors.Is(err, service.ErrBudgetNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "No budget set",
+			})
+		}
+		log.Println(err, "Error getting budget")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get budget",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(status)
+}
+
+func (h *BudgetHandler) setBudget(c *fiber.Ctx, scope models.BudgetScope, scopeID uuid.UUID) error {
+	var dto struct {
+		MonthlyLimitUSD float64 `json:"monthly_limit_usd"`
+		HardStop        bool    `json:"hard_stop"`
+		AlertEmail      string  `json:"alert_email"`
+	}
+	if err := c.BodyParser(&dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if _, err := h.budgetService.SetBudget(scope, scopeID, dto.MonthlyLimitUSD, dto.HardStop, dto.AlertEmail); err != nil {
+		if errors.Is(err, service.ErrInvalidBudget) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		log.Println(err, "Error saving budget")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to save budget",
+		})
+	}
+
+	return h.getBudget(c, scope, scopeID)
+}
+
+func (h *BudgetHandler) deleteBudget(c *fiber.Ctx, scope models.BudgetScope, scopeID uuid.UUID) error {
+	if err := h.budgetService.DeleteBudget(scope, scopeID); err != nil {
+		log.Println(err, "Error deleting budget")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete budget",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Budget removed",
+	})
+}
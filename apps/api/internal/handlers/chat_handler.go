@@ -1,23 +1,30 @@
 package handlers
 
 import (
-	"context"
+	"errors"
+	"io"
 	"log"
-	"melina-studio-backend/internal/libraries"
 	"melina-studio-backend/internal/models"
 	"melina-studio-backend/internal/repo"
+	"melina-studio-backend/internal/service"
 
 	"github.com/gofiber/fiber/v2"
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 )
 
 type ChatHandler struct {
 	chatRepo       repo.ChatRepoInterface
 	tempUploadRepo repo.TempUploadRepoInterface
+	contentStore   *service.ContentStore
+	storageQuota   *service.StorageQuotaService
+	uploads        *service.UploadValidator
+	uploadCommits  *service.UploadCommitService
+	attachments    *service.ChatAttachmentService
 }
 
-func NewChatHandler(chatRepo repo.ChatRepoInterface, tempUploadRepo repo.TempUploadRepoInterface) *ChatHandler {
-	return &ChatHandler{chatRepo: chatRepo, tempUploadRepo: tempUploadRepo}
+func NewChatHandler(chatRepo repo.ChatRepoInterface, tempUploadRepo repo.TempUploadRepoInterface, contentStore *service.ContentStore, storageQuota *service.StorageQuotaService, uploads *service.UploadValidator, uploadCommits *service.UploadCommitService, attachments *service.ChatAttachmentService) *ChatHandler {
+	return &ChatHandler{chatRepo: chatRepo, tempUploadRepo: tempUploadRepo, contentStore: contentStore, storageQuota: storageQuota, uploads: uploads, uploadCommits: uploadCommits, attachments: attachments}
 }
 
 // get chats by board id with pagination
@@ -54,6 +61,47 @@ func (h *ChatHandler) GetChatsByBoardId(c *fiber.Ctx) error {
 	})
 }
 
+// function to get the reasoning behind an assistant message, if it was stored
+func (h *ChatHandler) GetMessageThinking(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	messageID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid message ID",
+		})
+	}
+
+	chat, err := h.chatRepo.GetUserChatByID(userID, messageID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Message not found",
+			})
+		}
+		log.Println(err, "Error getting message")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get message",
+		})
+	}
+
+	if chat.Role != models.RoleAssistant || chat.Thought == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "No thinking stored for this message",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message_id": chat.UUID,
+		"thinking":   *chat.Thought,
+	})
+}
+
 // just upload image to gcp and return the url
 func (h *ChatHandler) UploadImage(c *fiber.Ctx) error {
 	boardId := c.Params("boardId")
@@ -80,29 +128,181 @@ func (h *ChatHandler) UploadImage(c *fiber.Ctx) error {
 	}
 	defer file.Close()
 
-	// Generate a unique object key: boards/{boardId}/images/{uuid}_{filename}
-	objectKey := "boards/" + boardId + "/images/" + uuid.NewString() + "_" + fileHeader.Filename
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to read image file",
+		})
+	}
+
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+	// the type is sniffed from the content; the declared Content-Type is the client's word for it
+	contentType, ok, err := validateUpload(c, h.uploads, userID, data)
+	if !ok {
+		return err
+	}
+	objectKey := service.ContentObjectKey(service.ContentHash(data), contentType, fileHeader.Filename)
+	if ok, err := checkStorageQuota(c, h.storageQuota, userID, objectKey, int64(len(data))); !ok {
+		return err
+	}
 
-	imageUrl, err := libraries.GetClients().Upload(context.Background(), objectKey, file, fileHeader.Header.Get("Content-Type"))
+	// Identical images share one object, stored at content/{sha256}{ext}
+	ctx := storageContext(c)
+	object, err := h.contentStore.Store(ctx, data, contentType, fileHeader.Filename)
 	if err != nil {
+		log.Println(err, "Error uploading image")
 		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
 			"error": "Failed to upload image",
 		})
 	}
 
-	// Track temp upload for cleanup
+	h.storageQuota.Record(userID, models.StorageKindUpload, object.ObjectKey, int64(len(data)))
+
+	// Track temp upload for cleanup, which releases its reference to the stored object and its quota usage
 	boardUUID, _ := uuid.Parse(boardId)
 	tempUpload := &models.TempUpload{
-		BoardID:   boardUUID,
-		ObjectKey: objectKey,
-		URL:       imageUrl,
+		BoardID:     boardUUID,
+		UserID:      userID,
+		ObjectKey:   object.ObjectKey,
+		URL:         object.URL,
+		ContentHash: object.ContentHash,
+		Bucket:      object.Bucket,
+	}
+	response := fiber.Map{
+		"message": "Image uploaded successfully",
+		"url":     object.URL,
 	}
+	// upload_id is what POST /uploads/:uploadId/commit takes to keep the image past cleanup
 	if err := h.tempUploadRepo.Create(tempUpload); err != nil {
 		log.Printf("Failed to track temp upload: %v", err)
+	} else {
+		response["upload_id"] = tempUpload.UUID
+	}
+
+	return c.Status(fiber.StatusOK).JSON(response)
+}
+
+// function to commit a temp upload to a chat message or shape, so cleanup keeps it
+func (h *ChatHandler) CommitUpload(c *fiber.Ctx) error {
+	uploadID, err := uuid.Parse(c.Params("uploadId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid upload ID",
+		})
+	}
+
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	var dto service.UploadCommitInput
+	if err := c.BodyParser(&dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	upload, err := h.uploadCommits.Commit(userID, uploadID, dto)
+	switch {
+	case errors.Is(err, service.ErrInvalidUploadCommit):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, service.ErrUploadNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Upload not found",
+		})
+	case errors.Is(err, service.ErrUploadCommitted):
+		return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+			"error": "Upload is already committed to another message or shape",
+		})
+	case err != nil:
+		log.Println(err, "Error committing upload")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to commit upload",
+		})
 	}
 
 	return c.Status(fiber.StatusOK).JSON(fiber.Map{
-		"message": "Image uploaded successfully",
-		"url":     imageUrl,
+		"upload": upload,
+	})
+}
+
+// function to list the images attached to a chat message
+func (h *ChatHandler) ListMessageAttachments(c *fiber.Ctx) error {
+	userID, messageID, ok, err := attachmentMessageParams(c)
+	if !ok {
+		return err
+	}
+
+	attachments, err := h.attachments.List(userID, messageID)
+	if err != nil {
+		return attachmentError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"attachments": attachments,
+	})
+}
+
+// function to delete an image attached to a chat message, with its stored object
+func (h *ChatHandler) DeleteMessageAttachment(c *fiber.Ctx) error {
+	userID, messageID, ok, err := attachmentMessageParams(c)
+	if !ok {
+		return err
+	}
+	attachmentID, err := uuid.Parse(c.Params("attachmentId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid attachment ID",
+		})
+	}
+
+	if err := h.attachments.Delete(storageContext(c), userID, messageID, attachmentID); err != nil {
+		return attachmentError(c, err)
+	}
+
+	return c.SendStatus(fiber.StatusNoContent)
+}
+
+// attachmentMessageParams parses the user and message of an attachment request; ok is false once a response was sent
+func attachmentMessageParams(c *fiber.Ctx) (uuid.UUID, uuid.UUID, bool, error) {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+	messageID, err := uuid.Parse(c.Params("id"))
+	if err != nil {
+		return uuid.Nil, uuid.Nil, false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid message ID",
+		})
+	}
+	return userID, messageID, true, nil
+}
+
+func attachmentError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrAttachmentMessageNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Message not found",
+		})
+	case errors.Is(err, service.ErrAttachmentNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Attachment not found",
+		})
+	}
+	log.Println(err, "Error handling message attachments")
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "Failed to handle message attachments",
 	})
 }
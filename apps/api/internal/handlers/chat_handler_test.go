@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type fakeChatRepo struct {
+	repo.ChatRepoInterface
+	chats map[uuid.UUID]*models.Chat
+	owner uuid.UUID
+}
+
+func (r *fakeChatRepo) GetUserChatByID(userID uuid.UUID, chatID uuid.UUID) (*models.Chat, error) {
+	chat, ok := r.chats[chatID]
+	if !ok || userID != r.owner {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return chat, nil
+}
+
+func TestGetMessageThinking(t *testing.T) {
+	owner := uuid.New()
+	thought := "The user wants a flowchart"
+	withThinking := &models.Chat{UUID: uuid.New(), Role: models.RoleAssistant, Thought: &thought}
+	withoutThinking := &models.Chat{UUID: uuid.New(), Role: models.RoleAssistant}
+	human := &models.Chat{UUID: uuid.New(), Role: models.RoleUser, Thought: &thought}
+	chatRepo := &fakeChatRepo{owner: owner, chats: map[uuid.UUID]*models.Chat{
+		withThinking.UUID:    withThinking,
+		withoutThinking.UUID: withoutThinking,
+		human.UUID:           human,
+	}}
+	handler := NewChatHandler(chatRepo, nil, nil, nil, nil, nil, nil)
+
+	cases := []struct {
+		name      string
+		userID    uuid.UUID
+		messageID string
+		status    int
+	}{
+		{"stored thinking", owner, withThinking.UUID.String(), fiber.StatusOK},
+		{"no thinking stored", owner, withoutThinking.UUID.String(), fiber.StatusNotFound},
+		{"user message", owner, human.UUID.String(), fiber.StatusNotFound},
+		{"another user's message", uuid.New(), withThinking.UUID.String(), fiber.StatusNotFound},
+		{"invalid id", owner, "nope", fiber.StatusBadRequest},
+	}
+	for _, tc := range cases {
+		app := fiber.New()
+		app.Get("/chat/messages/:id/thinking", func(c *fiber.Ctx) error {
+			c.Locals("userID", tc.userID.String())
+			return c.Next()
+		}, handler.GetMessageThinking)
+
+		resp, err := app.Test(httptest.NewRequest("GET", "/chat/messages/"+tc.messageID+"/thinking", nil))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != tc.status {
+			t.Errorf("%s: got %d, want %d", tc.name, resp.StatusCode, tc.status)
+			continue
+		}
+		if tc.status != fiber.StatusOK {
+			continue
+		}
+		var body struct {
+			MessageID uuid.UUID `json:"message_id"`
+			Thinking  string    `json:"thinking"`
+		}
+		if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+			t.Fatal(err)
+		}
+		if body.MessageID != withThinking.UUID || body.Thinking != thought {
+			t.Errorf("%s: unexpected body %+v", tc.name, body)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"melina-studio-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+const (
+	defaultCleanupRuns = 20
+	maxCleanupRuns     = 100
+)
+
+type CleanupHandler struct {
+	cleanupService *service.CleanupService
+}
+
+func NewCleanupHandler(cleanupService *service.CleanupService) *CleanupHandler {
+	return &CleanupHandler{
+		cleanupService: cleanupService,
+	}
+}
+
+// unavailable answers when the process runs without the cleanup service, e.g. in tools that only serve routes
+func (h *CleanupHandler) unavailable(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+		"error": "Cleanup service is not running",
+	})
+}
+
+// function to get the cleanup job's settings, next run and last run stats
+func (h *CleanupHandler) GetCleanup(c *fiber.Ctx) error {
+	if h.cleanupService == nil {
+		return h.unavailable(c)
+	}
+	status, err := h.cleanupService.Status()
+	if err != nil {
+		log.Println(err, "Error getting cleanup status")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get cleanup status",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(status)
+}
+
+// function to change the cleanup job's schedule and upload retention
+func (h *CleanupHandler) UpdateCleanupSettings(c *fiber.Ctx) error {
+	if h.cleanupService == nil {
+		return h.unavailable(c)
+	}
+	var dto service.CleanupSettingsUpdate
+	if err := c.BodyParser(&dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	settings, err := h.cleanupService.UpdateSettings(dto)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCleanupSettings) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		log.Println(err, "Error saving cleanup settings")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to save cleanup settings",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"settings": settings,
+	})
+}
+
+// function to run the cleanup job now and return its stats
+func (h *CleanupHandler) RunCleanup(c *fiber.Ctx) error {
+	if h.cleanupService == nil {
+		return h.unavailable(c)
+	}
+	run, err := h.cleanupService.RunNow()
+	if err != nil {
+		if errors.Is(err, service.ErrCleanupRunning) {
+			return c.Status(fiber.StatusConflict).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		log.Println(err, "Error running cleanup")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to run cleanup",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"run": run,
+	})
+}
+
+// function to list the stats of the latest cleanup runs
+func (h *CleanupHandler) ListCleanupRuns(c *fiber.Ctx) error {
+	if h.cleanupService == nil {
+		return h.unavailable(c)
+	}
+	limit := c.QueryInt("limit", defaultCleanupRuns)
+	if limit < 1 || limit > maxCleanupRuns {
+		limit = defaultCleanupRuns
+	}
+	runs, err := h.cleanupService.ListRuns(limit)
+	if err != nil {
+		log.Println(err, "Error listing cleanup runs")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to list cleanup runs",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"runs": runs,
+	})
+}
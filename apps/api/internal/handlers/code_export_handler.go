@@ -0,0 +1,165 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+	"melina-studio-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CodeExportHandler struct {
+	boardRepo         repo.BoardRepoInterface
+	artifactRepo      repo.CodeArtifactRepoInterface
+	codeExportService *service.CodeExportService
+}
+
+func NewCodeExportHandler(boardRepo repo.BoardRepoInterface, artifactRepo repo.CodeArtifactRepoInterface, codeExportService *service.CodeExportService) *CodeExportHandler {
+	return &CodeExportHandler{
+		boardRepo:         boardRepo,
+		artifactRepo:      artifactRepo,
+		codeExportService: codeExportService,
+	}
+}
+
+// function to generate HTML/CSS or React code from a wireframe frame
+func (h *CodeExportHandler) ExportCode(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	boardId, err := uuid.Parse(c.Params("boardId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid board ID",
+		})
+	}
+
+	frameId, err := uuid.Parse(c.Params("frameId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid frame ID",
+		})
+	}
+
+	if err := h.boardRepo.ValidateBoardOwnership(userID, boardId); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Board not found",
+		})
+	}
+
+	var dto struct {
+		Framework string `json:"framework"`
+		ModelName string `json:"model_name"`
+	}
+	// body is optional
+	_ = c.BodyParser(&dto)
+
+	if err := checkTenantModel(c, dto.ModelName); err != nil {
+		return err
+	}
+
+	artifact, err := h.codeExportService.ExportFrame(c.Context(), userID, boardId, frameId, models.CodeFramework(dto.Framework), dto.ModelName)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrInvalidCodeFramework):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Framework must be 'html' or 'react'",
+			})
+		case errors.Is(err, service.ErrFrameNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Frame not found",
+			})
+		case errors.Is(err, service.ErrEmptyFrame):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Frame has no shapes to export",
+			})
+		}
+		log.Println(err, "Error exporting frame code")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to generate code",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message":  "Code generated",
+		"artifact": artifact,
+	})
+}
+
+// function to list the code artifacts of a board
+func (h *CodeExportHandler) ListArtifacts(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	boardId, err := uuid.Parse(c.Params("boardId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid board ID",
+		})
+	}
+
+	artifacts, err := h.artifactRepo.ListByBoard(userID, boardId)
+	if err != nil {
+		log.Println(err, "Error listing code artifacts")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get code artifacts",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"artifacts": artifacts,
+	})
+}
+
+// function to get a single code artifact with its code
+func (h *CodeExportHandler) GetArtifact(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	boardId, err := uuid.Parse(c.Params("boardId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid board ID",
+		})
+	}
+
+	artifactId, err := uuid.Parse(c.Params("artifactId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid artifact ID",
+		})
+	}
+
+	artifact, err := h.artifactRepo.GetByID(userID, boardId, artifactId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Artifact not found",
+			})
+		}
+		log.Println(err, "Error getting code artifact")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get artifact",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"artifact": artifact,
+	})
+}
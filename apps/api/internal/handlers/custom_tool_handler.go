@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"melina-studio-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type CustomToolHandler struct {
+	toolService *service.CustomToolService
+}
+
+func NewCustomToolHandler(toolService *service.CustomToolService) *CustomToolHandler {
+	return &CustomToolHandler{
+		toolService: toolService,
+	}
+}
+
+// function to list every custom tool, including the disabled ones
+func (h *CustomToolHandler) ListCustomTools(c *fiber.Ctx) error {
+	customTools, err := h.toolService.List()
+	if err != nil {
+		log.Println(err, "Error listing custom tools")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to list custom tools",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"tools": customTools,
+	})
+}
+
+// function to create or replace a custom tool and give it to the agent
+func (h *CustomToolHandler) SetCustomTool(c *fiber.Ctx) error {
+	var dto service.CustomToolInput
+	if err := c.BodyParser(&dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	tool, err := h.toolService.Set(c.Params("name"), dto)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidCustomTool) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		log.Println(err, "Error saving custom tool")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to save custom tool",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"tool": tool,
+	})
+}
+
+// function to delete a custom tool and take it away from the agent
+func (h *CustomToolHandler) DeleteCustomTool(c *fiber.Ctx) error {
+	if err := h.toolService.Delete(c.Params("name")); err != nil {
+		if errors.Is(err, service.ErrCustomToolNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Custom tool not found",
+			})
+		}
+		log.Println(err, "Error deleting custom tool")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete custom tool",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Custom tool deleted",
+	})
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"log"
+	"melina-studio-backend/internal/melina/tools"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// maxSQLImportSize caps the DDL accepted by the import endpoint
+const maxSQLImportSize = 256 * 1024
+
+type ERDHandler struct {
+	boardRepo     repo.BoardRepoInterface
+	boardDataRepo repo.BoardDataRepoInterface
+}
+
+func NewERDHandler(boardRepo repo.BoardRepoInterface, boardDataRepo repo.BoardDataRepoInterface) *ERDHandler {
+	return &ERDHandler{
+		boardRepo:     boardRepo,
+		boardDataRepo: boardDataRepo,
+	}
+}
+
+// function to import SQL DDL as entity shapes and relation connectors
+func (h *ERDHandler) ImportSQL(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	boardId, err := uuid.Parse(c.Params("boardId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid board ID",
+		})
+	}
+
+	if err := h.boardRepo.ValidateBoardOwnership(userID, boardId); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Board not found",
+		})
+	}
+
+	var dto struct {
+		SQL string   `json:"sql"`
+		X   *float64 `json:"x"`
+		Y   *float64 `json:"y"`
+	}
+	if err := c.BodyParser(&dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	if strings.TrimSpace(dto.SQL) == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "sql is required",
+		})
+	}
+	if len(dto.SQL) > maxSQLImportSize {
+		return c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"error": "SQL is too large",
+		})
+	}
+
+	tables, err := tools.ParseSQLDDL(dto.SQL)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	shapes, err := h.boardDataRepo.GetBoardData(boardId)
+	if err != nil {
+		log.Println(err, "Error getting board data")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get board",
+		})
+	}
+
+	// place the diagram below existing content unless a position is given
+	originX, originY := 0.0, 0.0
+	if state := tools.GenerateCanvasState(shapes, 0, 0); state != nil {
+		originX = state.OverallBounds.MinX
+		originY = state.OverallBounds.MaxY + 80
+	}
+	if dto.X != nil {
+		originX = *dto.X
+	}
+	if dto.Y != nil {
+		originY = *dto.Y
+	}
+
+	entities, relations := tools.LayoutERD(tables, originX, originY)
+	created := append(entities, relations...)
+	for _, shape := range created {
+		if err := h.boardDataRepo.SaveShapeData(boardId, shape); err != nil {
+			log.Println(err, "Error saving ERD shape")
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to save diagram",
+			})
+		}
+	}
+
+	if err := tools.InvalidateAnnotatedImageCache(h.boardRepo, userID, boardId); err != nil {
+		log.Println(err, "Error invalidating annotated image cache")
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message":   "SQL imported successfully",
+		"tables":    len(entities),
+		"relations": len(relations),
+		"shapes":    created,
+	})
+}
+
+// function to export the entity shapes of a board as SQL DDL
+func (h *ERDHandler) ExportSQL(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	boardId, err := uuid.Parse(c.Params("boardId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid board ID",
+		})
+	}
+
+	if err := h.boardRepo.ValidateBoardOwnership(userID, boardId); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Board not found",
+		})
+	}
+
+	shapes, err := h.boardDataRepo.GetShapesByType(boardId, models.Entity)
+	if err != nil {
+		log.Println(err, "Error getting entity shapes")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get entities",
+		})
+	}
+
+	tables := tools.ERDTablesFromShapes(shapes, nil)
+	if len(tables) == 0 {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Board has no entities",
+		})
+	}
+
+	c.Set(fiber.HeaderContentType, "application/sql; charset=utf-8")
+	c.Set(fiber.HeaderContentDisposition, `attachment; filename="schema.sql"`)
+	return c.Status(fiber.StatusOK).SendString(tools.GenerateDDL(tables))
+}
@@ -0,0 +1,179 @@
+package handlers
+
+import (
+	"melina-studio-backend/internal/repo"
+	"melina-studio-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type ExportScheduleHandler struct {
+	boardRepo             repo.BoardRepoInterface
+	exportScheduleService *service.ExportScheduleService
+}
+
+func NewExportScheduleHandler(boardRepo repo.BoardRepoInterface, exportScheduleService *service.ExportScheduleService) *ExportScheduleHandler {
+	return &ExportScheduleHandler{
+		boardRepo:             boardRepo,
+		exportScheduleService: exportScheduleService,
+	}
+}
+
+// exportScheduleErrors are the responses to the export schedule service's errors
+var exportScheduleErrors = []errorStatus{
+	{service.ErrInvalidExportSchedule, fiber.StatusBadRequest, ""},
+	{service.ErrTooManyExportSchedules, fiber.StatusBadRequest, ""},
+	{service.ErrExportScheduleNotFound, fiber.StatusNotFound, "Export schedule not found"},
+	{service.ErrJobQueueFull, fiber.StatusServiceUnavailable, "Too many exports are running, try again shortly"},
+}
+
+// scheduleParam parses the schedule id of the request
+func scheduleParam(c *fiber.Ctx) (uuid.UUID, bool) {
+	scheduleId, err := uuid.Parse(c.Params("scheduleId"))
+	return scheduleId, err == nil
+}
+
+// function to list the export schedules of a board; credentials are never returned
+func (h *ExportScheduleHandler) ListExportSchedules(c *fiber.Ctx) error {
+	_, boardId, status, msg := ownedBoard(c, h.boardRepo)
+	if status != fiber.StatusOK {
+		return c.Status(status).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	schedules, err := h.exportScheduleService.List(boardId)
+	if err != nil {
+		return serviceError(c, err, exportScheduleErrors, "list export schedule")
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"schedules": schedules,
+	})
+}
+
+// function to add an export schedule to a board
+func (h *ExportScheduleHandler) CreateExportSchedule(c *fiber.Ctx) error {
+	userID, boardId, status, msg := ownedBoard(c, h.boardRepo)
+	if status != fiber.StatusOK {
+		return c.Status(status).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	var dto service.ExportScheduleInput
+	if err := c.BodyParser(&dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	schedule, err := h.exportScheduleService.Create(userID, boardId, dto)
+	if err != nil {
+		return serviceError(c, err, exportScheduleErrors, "create export schedule")
+	}
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"schedule": schedule,
+	})
+}
+
+// function to replace an export schedule of a board
+func (h *ExportScheduleHandler) UpdateExportSchedule(c *fiber.Ctx) error {
+	_, boardId, status, msg := ownedBoard(c, h.boardRepo)
+	if status != fiber.StatusOK {
+		return c.Status(status).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+	scheduleId, ok := scheduleParam(c)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid schedule ID",
+		})
+	}
+
+	var dto service.ExportScheduleInput
+	if err := c.BodyParser(&dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	schedule, err := h.exportScheduleService.Update(boardId, scheduleId, dto)
+	if err != nil {
+		return serviceError(c, err, exportScheduleErrors, "update export schedule")
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"schedule": schedule,
+	})
+}
+
+// function to delete an export schedule of a board with its delivery log
+func (h *ExportScheduleHandler) DeleteExportSchedule(c *fiber.Ctx) error {
+	_, boardId, status, msg := ownedBoard(c, h.boardRepo)
+	if status != fiber.StatusOK {
+		return c.Status(status).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+	scheduleId, ok := scheduleParam(c)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid schedule ID",
+		})
+	}
+
+	if err := h.exportScheduleService.Delete(boardId, scheduleId); err != nil {
+		return serviceError(c, err, exportScheduleErrors, "delete export schedule")
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Export schedule deleted",
+	})
+}
+
+// function to queue an export of a schedule right away, e.g. to check its destination
+func (h *ExportScheduleHandler) RunExportSchedule(c *fiber.Ctx) error {
+	_, boardId, status, msg := ownedBoard(c, h.boardRepo)
+	if status != fiber.StatusOK {
+		return c.Status(status).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+	scheduleId, ok := scheduleParam(c)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid schedule ID",
+		})
+	}
+
+	if err := h.exportScheduleService.RunNow(boardId, scheduleId); err != nil {
+		return serviceError(c, err, exportScheduleErrors, "run export schedule")
+	}
+	return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+		"message": "Export queued",
+	})
+}
+
+// function to get the delivery log of an export schedule, newest run first
+func (h *ExportScheduleHandler) ListExportRuns(c *fiber.Ctx) error {
+	_, boardId, status, msg := ownedBoard(c, h.boardRepo)
+	if status != fiber.StatusOK {
+		return c.Status(status).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+	scheduleId, ok := scheduleParam(c)
+	if !ok {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid schedule ID",
+		})
+	}
+
+	runs, err := h.exportScheduleService.Runs(boardId, scheduleId)
+	if err != nil {
+		return serviceError(c, err, exportScheduleErrors, "get the runs of export schedule")
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"runs": runs,
+	})
+}
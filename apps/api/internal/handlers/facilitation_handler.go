@@ -0,0 +1,176 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+	"melina-studio-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type FacilitationHandler struct {
+	boardRepo           repo.BoardRepoInterface
+	facilitationService *service.FacilitationService
+}
+
+func NewFacilitationHandler(boardRepo repo.BoardRepoInterface, facilitationService *service.FacilitationService) *FacilitationHandler {
+	return &FacilitationHandler{
+		boardRepo:           boardRepo,
+		facilitationService: facilitationService,
+	}
+}
+
+// function to start a facilitation session on a board
+func (h *FacilitationHandler) StartSession(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	boardId, err := uuid.Parse(c.Params("boardId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid board ID",
+		})
+	}
+
+	if err := h.boardRepo.ValidateBoardOwnership(userID, boardId); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Board not found",
+		})
+	}
+
+	var dto struct {
+		Kind  string `json:"kind"`
+		Topic string `json:"topic"`
+	}
+	if err := c.BodyParser(&dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	session, err := h.facilitationService.StartSession(userID, boardId, models.FacilitationKind(dto.Kind), dto.Topic)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidFacilitationKind) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Kind must be 'retro' or 'brainstorm'",
+			})
+		}
+		log.Println(err, "Error starting facilitation session")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to start facilitation session",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"message": "Facilitation session started",
+		"session": session,
+		"kickoff": service.GetFacilitationKickoffMessage(session.Step),
+	})
+}
+
+// function to get the active facilitation session of a board
+func (h *FacilitationHandler) GetSession(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	boardId, err := uuid.Parse(c.Params("boardId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid board ID",
+		})
+	}
+
+	session, err := h.facilitationService.GetActiveSession(userID, boardId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "No active facilitation session",
+			})
+		}
+		log.Println(err, "Error getting facilitation session")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get facilitation session",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"session": session,
+		"steps":   models.FacilitationSteps,
+	})
+}
+
+// function to move the facilitation session to its next step
+func (h *FacilitationHandler) AdvanceSession(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	boardId, err := uuid.Parse(c.Params("boardId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid board ID",
+		})
+	}
+
+	session, err := h.facilitationService.AdvanceSession(userID, boardId)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "No active facilitation session",
+			})
+		}
+		log.Println(err, "Error advancing facilitation session")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to advance facilitation session",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Facilitation session advanced",
+		"session": session,
+		"kickoff": service.GetFacilitationKickoffMessage(session.Step),
+	})
+}
+
+// function to end the facilitation session of a board
+func (h *FacilitationHandler) EndSession(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	boardId, err := uuid.Parse(c.Params("boardId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid board ID",
+		})
+	}
+
+	if err := h.facilitationService.EndSession(userID, boardId); err != nil {
+		log.Println(err, "Error ending facilitation session")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to end facilitation session",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Facilitation session ended",
+	})
+}
@@ -0,0 +1,88 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"melina-studio-backend/internal/auth"
+	"melina-studio-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type FeatureFlagHandler struct {
+	flagService *service.FeatureFlagService
+}
+
+func NewFeatureFlagHandler(flagService *service.FeatureFlagService) *FeatureFlagHandler {
+	return &FeatureFlagHandler{
+		flagService: flagService,
+	}
+}
+
+// function to get the feature flags evaluated for the current user
+func (h *FeatureFlagHandler) GetFlags(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"flags": auth.FeatureFlagsFromCtx(c),
+	})
+}
+
+// function to list every feature flag with its rollout settings
+func (h *FeatureFlagHandler) ListFlags(c *fiber.Ctx) error {
+	flags, err := h.flagService.List()
+	if err != nil {
+		log.Println(err, "Error listing feature flags")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to list feature flags",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"flags": flags,
+	})
+}
+
+// function to create or update a feature flag
+func (h *FeatureFlagHandler) UpsertFlag(c *fiber.Ctx) error {
+	var dto service.FeatureFlagUpdate
+	if err := c.BodyParser(&dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	flag, err := h.flagService.Upsert(c.Params("key"), dto)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidFeatureFlag) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		log.Println(err, "Error saving feature flag")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to save feature flag",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"flag": flag,
+	})
+}
+
+// function to delete a feature flag
+func (h *FeatureFlagHandler) DeleteFlag(c *fiber.Ctx) error {
+	if err := h.flagService.Delete(c.Params("key")); err != nil {
+		if errors.Is(err, service.ErrFeatureFlagNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Feature flag not found",
+			})
+		}
+		log.Println(err, "Error deleting feature flag")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete feature flag",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Feature flag deleted",
+	})
+}
@@ -0,0 +1,115 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"melina-studio-backend/internal/service"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type FeedbackHandler struct {
+	feedbackService *service.FeedbackService
+}
+
+func NewFeedbackHandler(feedbackService *service.FeedbackService) *FeedbackHandler {
+	return &FeedbackHandler{
+		feedbackService: feedbackService,
+	}
+}
+
+// function to rate an AI message with a thumbs up or down
+func (h *FeedbackHandler) SubmitFeedback(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	var dto service.FeedbackInput
+	if err := c.BodyParser(&dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	feedback, err := h.feedbackService.Submit(userID, dto)
+	if err != nil {
+		return feedbackError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"feedback": feedback,
+	})
+}
+
+// function to list the latest feedback, optionally only thumbs up or down
+func (h *FeedbackHandler) ListFeedback(c *fiber.Ctx) error {
+	since, ok, err := feedbackSinceQuery(c)
+	if !ok {
+		return err
+	}
+
+	feedback, err := h.feedbackService.List(c.Query("rating"), since, c.QueryInt("limit", 0))
+	if err != nil {
+		return feedbackError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"feedback": feedback,
+	})
+}
+
+// function to aggregate the feedback per model or prompt version
+func (h *FeedbackHandler) GetFeedbackSummary(c *fiber.Ctx) error {
+	since, ok, err := feedbackSinceQuery(c)
+	if !ok {
+		return err
+	}
+
+	groupBy := c.Query("group_by", "prompt_version")
+	rows, err := h.feedbackService.Aggregate(groupBy, since)
+	if err != nil {
+		return feedbackError(c, err)
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"group_by": groupBy,
+		"summary":  rows,
+	})
+}
+
+// feedbackSinceQuery reads the optional RFC 3339 "since" query parameter
+func feedbackSinceQuery(c *fiber.Ctx) (time.Time, bool, error) {
+	raw := c.Query("since")
+	if raw == "" {
+		return time.Time{}, true, nil
+	}
+	since, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}, false, c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "since must be an RFC 3339 time",
+		})
+	}
+	return since, true, nil
+}
+
+func feedbackError(c *fiber.Ctx, err error) error {
+	switch {
+	case errors.Is(err, service.ErrInvalidFeedback):
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	case errors.Is(err, service.ErrFeedbackMessageNotFound):
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Message not found",
+		})
+	}
+	log.Println(err, "Error handling feedback")
+	return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+		"error": "Failed to handle feedback",
+	})
+}
@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"melina-studio-backend/internal/repo"
+	"melina-studio-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type InboundEmailHandler struct {
+	boardRepo           repo.BoardRepoInterface
+	inboundEmailService *service.InboundEmailService
+}
+
+func NewInboundEmailHandler(boardRepo repo.BoardRepoInterface, inboundEmailService *service.InboundEmailService) *InboundEmailHandler {
+	return &InboundEmailHandler{
+		boardRepo:           boardRepo,
+		inboundEmailService: inboundEmailService,
+	}
+}
+
+// inboxErrors are the responses to the inbound email service's errors
+var inboxErrors = []errorStatus{
+	{service.ErrInboundEmailDisabled, fiber.StatusServiceUnavailable, ""},
+	{service.ErrInvalidInbox, fiber.StatusBadRequest, ""},
+	{service.ErrInboxNotFound, fiber.StatusNotFound, ""},
+}
+
+// function to get the email address of a board
+func (h *InboundEmailHandler) GetInbox(c *fiber.Ctx) error {
+	_, boardId, status, msg := ownedBoard(c, h.boardRepo)
+	if status != fiber.StatusOK {
+		return c.Status(status).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	inbox, err := h.inboundEmailService.Get(boardId)
+	if err != nil {
+		return serviceError(c, err, inboxErrors, "get board email address")
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"inbox": inbox,
+	})
+}
+
+// function to give a board an email address or change its settings
+func (h *InboundEmailHandler) UpdateInbox(c *fiber.Ctx) error {
+	userID, boardId, status, msg := ownedBoard(c, h.boardRepo)
+	if status != fiber.StatusOK {
+		return c.Status(status).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	var dto service.InboxInput
+	if err := c.BodyParser(&dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	inbox, err := h.inboundEmailService.Save(userID, boardId, dto)
+	if err != nil {
+		return serviceError(c, err, inboxErrors, "save board email address")
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"inbox": inbox,
+	})
+}
+
+// function to replace the email address of a board, dropping emails sent to the old one
+func (h *InboundEmailHandler) RotateInbox(c *fiber.Ctx) error {
+	_, boardId, status, msg := ownedBoard(c, h.boardRepo)
+	if status != fiber.StatusOK {
+		return c.Status(status).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	inbox, err := h.inboundEmailService.Rotate(boardId)
+	if err != nil {
+		return serviceError(c, err, inboxErrors, "rotate board email address")
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"inbox": inbox,
+	})
+}
+
+// function to remove the email address of a board
+func (h *InboundEmailHandler) DeleteInbox(c *fiber.Ctx) error {
+	_, boardId, status, msg := ownedBoard(c, h.boardRepo)
+	if status != fiber.StatusOK {
+		return c.Status(status).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	if err := h.inboundEmailService.Delete(boardId); err != nil {
+		return serviceError(c, err, inboxErrors, "delete board email address")
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Board email address removed",
+	})
+}
+
+// SendGridWebhook receives the emails SendGrid Inbound Parse posts
+func (h *InboundEmailHandler) SendGridWebhook(c *fiber.Ctx) error {
+	if !h.inboundEmailService.WebhookAllowed(c.Query("token")) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid token",
+		})
+	}
+
+	form, err := c.MultipartForm()
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid payload",
+		})
+	}
+	fields := make(map[string]string, len(form.Value))
+	for name, values := range form.Value {
+		if len(values) > 0 {
+			fields[name] = values[0]
+		}
+	}
+
+	email, err := service.SendGridEmail(fields)
+	if err != nil {
+		// Acknowledge anyway, a retry won't make the email readable
+		log.Println(err, "Error trying to read SendGrid email")
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"delivered": 0,
+		})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"delivered": h.inboundEmailService.Receive(email),
+	})
+}
+
+// SESWebhook receives the emails an SES receipt rule publishes to an SNS topic, and confirms the subscription.
+// Messages are only acted on once their SNS signature checks out
+func (h *InboundEmailHandler) SESWebhook(c *fiber.Ctx) error {
+	if !h.inboundEmailService.WebhookAllowed(c.Query("token")) {
+		return c.Status(fiber.StatusUnauthorized).JSON(fiber.Map{
+			"error": "Invalid token",
+		})
+	}
+
+	// SNS posts JSON as text/plain, so the body is decoded by hand
+	var msg service.SNSMessage
+	if err := json.Unmarshal(c.Body(), &msg); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid payload",
+		})
+	}
+	// the token alone isn't trusted, it can leak through access logs and proxies
+	if err := h.inboundEmailService.VerifySNSMessage(c.UserContext(), msg); err != nil {
+		log.Println(err, "Refusing SNS message from", msg.TopicArn)
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": "Invalid signature",
+		})
+	}
+
+	switch msg.Type {
+	case "SubscriptionConfirmation":
+		if err := service.ConfirmSNSSubscription(c.UserContext(), msg.SubscribeURL); err != nil {
+			log.Println(err, "Error trying to confirm SNS subscription to", msg.TopicArn)
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Failed to confirm subscription",
+			})
+		}
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"status": "confirmed",
+		})
+	case "Notification":
+		email, err := service.ParseSESNotification(msg.Message)
+		if err != nil {
+			if !errors.Is(err, service.ErrNotAnEmail) {
+				log.Println(err, "Error trying to read SES email")
+			}
+			return c.Status(fiber.StatusOK).JSON(fiber.Map{
+				"delivered": 0,
+			})
+		}
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"delivered": h.inboundEmailService.Receive(email),
+		})
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"status": "ignored",
+	})
+}
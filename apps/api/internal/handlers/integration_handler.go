@@ -0,0 +1,170 @@
+package handlers
+
+import (
+	"melina-studio-backend/internal/service"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// IntegrationHandler serves the routes of the no-code integrations (Zapier, Make). Triggers answer with a bare
+// array and actions with a bare object, the flat shapes those tools expect
+type IntegrationHandler struct {
+	integrationService *service.IntegrationService
+}
+
+func NewIntegrationHandler(integrationService *service.IntegrationService) *IntegrationHandler {
+	return &IntegrationHandler{
+		integrationService: integrationService,
+	}
+}
+
+// integrationErrors are the responses to the integration service's errors
+var integrationErrors = []errorStatus{
+	{service.ErrInvalidIntegrationInput, fiber.StatusBadRequest, ""},
+	{service.ErrIntegrationBoardMissing, fiber.StatusNotFound, "Board not found"},
+}
+
+// triggerParams parses the user and the since, limit and board_id query parameters of a trigger, returning an
+// HTTP status and message on failure
+func triggerParams(c *fiber.Ctx) (uuid.UUID, time.Time, int, *uuid.UUID, int, string) {
+	userID, status, msg := requestUser(c)
+	if status != fiber.StatusOK {
+		return uuid.Nil, time.Time{}, 0, nil, status, msg
+	}
+	since, err := service.ParseIntegrationCursor(c.Query("since"))
+	if err != nil {
+		return uuid.Nil, time.Time{}, 0, nil, fiber.StatusBadRequest, err.Error()
+	}
+	var boardID *uuid.UUID
+	if raw := c.Query("board_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return uuid.Nil, time.Time{}, 0, nil, fiber.StatusBadRequest, "Invalid board ID"
+		}
+		boardID = &id
+	}
+	return userID, since, c.QueryInt("limit", 0), boardID, fiber.StatusOK, ""
+}
+
+// function to return the account an API key acts as, which integrations call to test the connection
+func (h *IntegrationHandler) Me(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	user, err := h.integrationService.Me(userID)
+	if err != nil {
+		return serviceError(c, err, integrationErrors, "get the account")
+	}
+	return c.Status(fiber.StatusOK).JSON(user)
+}
+
+// function to poll the boards created since a cursor, newest first
+func (h *IntegrationHandler) NewBoardTrigger(c *fiber.Ctx) error {
+	userID, since, limit, _, status, msg := triggerParams(c)
+	if status != fiber.StatusOK {
+		return c.Status(status).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	boards, err := h.integrationService.NewBoards(userID, since, limit)
+	if err != nil {
+		return serviceError(c, err, integrationErrors, "list new boards")
+	}
+	return c.Status(fiber.StatusOK).JSON(boards)
+}
+
+// function to poll the shapes created since a cursor, on every board or on board_id, newest first
+func (h *IntegrationHandler) NewShapeTrigger(c *fiber.Ctx) error {
+	userID, since, limit, boardID, status, msg := triggerParams(c)
+	if status != fiber.StatusOK {
+		return c.Status(status).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	shapes, err := h.integrationService.NewShapes(userID, boardID, since, limit)
+	if err != nil {
+		return serviceError(c, err, integrationErrors, "list new shapes")
+	}
+	return c.Status(fiber.StatusOK).JSON(shapes)
+}
+
+// function to poll the agent replies completed since a cursor, on every board or on board_id, newest first
+func (h *IntegrationHandler) ChatCompletedTrigger(c *fiber.Ctx) error {
+	userID, since, limit, boardID, status, msg := triggerParams(c)
+	if status != fiber.StatusOK {
+		return c.Status(status).JSON(fiber.Map{
+			"error": msg,
+		})
+	}
+
+	chats, err := h.integrationService.CompletedChats(userID, boardID, since, limit)
+	if err != nil {
+		return serviceError(c, err, integrationErrors, "list completed chats")
+	}
+	return c.Status(fiber.StatusOK).JSON(chats)
+}
+
+// function to create a board from an integration
+func (h *IntegrationHandler) CreateBoardAction(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	var dto struct {
+		Title string `json:"title"`
+	}
+	if err := c.BodyParser(&dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	board, err := h.integrationService.CreateBoard(userID, requestTenantID(c), dto.Title)
+	if err != nil {
+		return serviceError(c, err, integrationErrors, "create the board")
+	}
+	return c.Status(fiber.StatusCreated).JSON(board)
+}
+
+// function to add a sticky note to a board from an integration
+func (h *IntegrationHandler) AddNoteAction(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	var dto struct {
+		BoardID string `json:"board_id"`
+		Text    string `json:"text"`
+	}
+	if err := c.BodyParser(&dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	boardId, err := uuid.Parse(dto.BoardID)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid board ID",
+		})
+	}
+
+	note, err := h.integrationService.AddNote(userID, boardId, dto.Text)
+	if err != nil {
+		return serviceError(c, err, integrationErrors, "add the note")
+	}
+	return c.Status(fiber.StatusCreated).JSON(note)
+}
@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	llmHandlers "melina-studio-backend/internal/llm_handlers"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type ModelHandler struct{}
+
+func NewModelHandler() *ModelHandler {
+	return &ModelHandler{}
+}
+
+// function to get the circuit breaker state, error rate and latency of every LLM provider used since startup
+func (h *ModelHandler) GetHealth(c *fiber.Ctx) error {
+	providers := llmHandlers.ProviderHealth()
+	healthy := true
+	for _, p := range providers {
+		if p.State != llmHandlers.CircuitClosed {
+			healthy = false
+			break
+		}
+	}
+	return c.JSON(fiber.Map{
+		"healthy":   healthy,
+		"providers": providers,
+	})
+}
+
+// function to list the agent profiles a board chat can use and the tools each one gets
+func (h *ModelHandler) GetAgentProfiles(c *fiber.Ctx) error {
+	return c.JSON(fiber.Map{
+		"default":  llmHandlers.DefaultAgentProfile,
+		"profiles": llmHandlers.ListAgentProfiles(),
+	})
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type OnboardingHandler struct {
+	onboardingService *service.OnboardingService
+}
+
+func NewOnboardingHandler(onboardingService *service.OnboardingService) *OnboardingHandler {
+	return &OnboardingHandler{
+		onboardingService: onboardingService,
+	}
+}
+
+// function to get the current user's onboarding progress and sample board
+func (h *OnboardingHandler) GetProgress(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	progress, err := h.onboardingService.GetProgress(userID)
+	return h.respond(c, progress, err)
+}
+
+// function to mark a step of the onboarding tour as done
+func (h *OnboardingHandler) CompleteStep(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	progress, err := h.onboardingService.CompleteStep(userID, models.OnboardingStep(c.Params("step")))
+	if errors.Is(err, service.ErrUnknownOnboardingStep) {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return h.respond(c, progress, err)
+}
+
+// function to hide the onboarding tour
+func (h *OnboardingHandler) Dismiss(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	progress, err := h.onboardingService.Dismiss(userID)
+	return h.respond(c, progress, err)
+}
+
+func (h *OnboardingHandler) respond(c *fiber.Ctx, progress *models.OnboardingView, err error) error {
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// users who signed up before onboarding existed
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "No onboarding for this user",
+		})
+	}
+	if err != nil {
+		log.Println(err, "Error handling onboarding progress")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update onboarding progress",
+		})
+	}
+	return c.Status(fiber.StatusOK).JSON(progress)
+}
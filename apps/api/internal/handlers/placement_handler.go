@@ -0,0 +1,65 @@
+package handlers
+
+import (
+	"log"
+	"melina-studio-backend/internal/melina/tools"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// function to find a free spot on a board for a new shape of the given size, next to related shapes
+func (h *BoardHandler) FindPlacement(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	boardId, err := uuid.Parse(c.Params("boardId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid board ID",
+		})
+	}
+
+	width, height := c.QueryFloat("width", 200), c.QueryFloat("height", 100)
+	if width <= 0 || height <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "width and height must be positive",
+		})
+	}
+
+	if _, err := h.repo.GetBoardById(userID, boardId); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Board not found",
+		})
+	}
+
+	shapes, err := h.boardDataRepo.GetBoardData(boardId)
+	if err != nil {
+		log.Println(err, "Error getting board data")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get board data",
+		})
+	}
+
+	var anchor *tools.BoundingBox
+	if near := c.Query("near"); near != "" {
+		if anchor = tools.AnchorBounds(shapes, strings.Split(near, ",")); anchor == nil {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "None of the near shapes was found on the board",
+			})
+		}
+	}
+
+	spot := tools.FindFreeSpot(tools.OccupiedBounds(shapes), width, height, anchor)
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"x":      spot.X,
+		"y":      spot.Y,
+		"width":  width,
+		"height": height,
+	})
+}
@@ -0,0 +1,64 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"melina-studio-backend/internal/plugins"
+	"melina-studio-backend/pkg/pluginsdk"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+type PluginHandler struct {
+	host *plugins.Host
+}
+
+func NewPluginHandler(host *plugins.Host) *PluginHandler {
+	return &PluginHandler{
+		host: host,
+	}
+}
+
+// function to list the loaded plugins with their state, tools and routes
+func (h *PluginHandler) ListPlugins(c *fiber.Ctx) error {
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"plugins": h.host.List(),
+	})
+}
+
+// function to pass a request to the plugin route it matched and send back the plugin's reply
+func (h *PluginHandler) ProxyRoute(route plugins.Route) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, _ := c.Locals("userID").(string)
+		req := pluginsdk.RouteRequest{
+			Method:      c.Method(),
+			Route:       route.Path,
+			Params:      c.AllParams(),
+			Query:       c.Queries(),
+			ContentType: string(c.Request().Header.ContentType()),
+			Body:        c.Body(),
+		}
+
+		resp, err := h.host.HandleRoute(c.UserContext(), route.Plugin, req, userID)
+		if err != nil {
+			if errors.Is(err, plugins.ErrPluginUnavailable) || errors.Is(err, plugins.ErrPluginNotFound) {
+				return c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+					"error": "Plugin is not available",
+				})
+			}
+			log.Println(err, "Error calling plugin route")
+			return c.Status(fiber.StatusBadGateway).JSON(fiber.Map{
+				"error": "Plugin failed to handle the request",
+			})
+		}
+
+		status := resp.Status
+		if status == 0 {
+			status = fiber.StatusOK
+		}
+		if resp.ContentType != "" {
+			c.Set(fiber.HeaderContentType, resp.ContentType)
+		}
+		return c.Status(status).Send(resp.Body)
+	}
+}
@@ -0,0 +1,126 @@
+package handlers
+
+import (
+	"log"
+	"melina-studio-backend/internal/libraries"
+	"melina-studio-backend/internal/melina/tools"
+	"melina-studio-backend/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// function to get a board's presentation: its slides in order, with the viewport that shows each one
+func (h *BoardHandler) GetPresentation(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	boardId, err := uuid.Parse(c.Params("boardId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid board ID",
+		})
+	}
+
+	if _, err := h.repo.GetBoardById(userID, boardId); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Board not found",
+		})
+	}
+
+	frames, err := h.boardDataRepo.GetShapesByType(boardId, models.Frame)
+	if err != nil {
+		log.Println(err, "Error getting board frames")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get presentation",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(tools.BuildPresentation(boardId, frames))
+}
+
+// function to set the frames of a board's presentation and their order; an empty list removes every slide
+func (h *BoardHandler) SetPresentation(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	boardId, err := uuid.Parse(c.Params("boardId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid board ID",
+		})
+	}
+
+	var dto struct {
+		FrameIDs []uuid.UUID `json:"frame_ids"`
+	}
+	if err := c.BodyParser(&dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if dto.FrameIDs == nil {
+		dto.FrameIDs = []uuid.UUID{}
+	}
+
+	if _, err := h.repo.GetBoardById(userID, boardId); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Board not found",
+		})
+	}
+
+	frames, err := h.boardDataRepo.GetShapesByType(boardId, models.Frame)
+	if err != nil {
+		log.Println(err, "Error getting board frames")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get board frames",
+		})
+	}
+	position := make(map[uuid.UUID]int, len(frames))
+	for i := range frames {
+		position[frames[i].UUID] = -1
+	}
+	for i, frameID := range dto.FrameIDs {
+		p, ok := position[frameID]
+		if !ok {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Not a frame of this board: " + frameID.String(),
+			})
+		}
+		if p >= 0 {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Frame listed twice: " + frameID.String(),
+			})
+		}
+		position[frameID] = i
+	}
+
+	if err := h.boardDataRepo.SetSlideOrder(boardId, dto.FrameIDs); err != nil {
+		log.Println(err, "Error saving presentation")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to save presentation",
+		})
+	}
+	for i := range frames {
+		frames[i].SlideOrder = nil
+		if p := position[frames[i].UUID]; p >= 0 {
+			frames[i].SlideOrder = &p
+		}
+	}
+
+	libraries.SendPresentationUpdatedMessage(h.hub, userID.String(), &libraries.PresentationUpdatedPayload{
+		BoardId:  boardId.String(),
+		FrameIds: dto.FrameIDs,
+	})
+
+	return c.Status(fiber.StatusOK).JSON(tools.BuildPresentation(boardId, frames))
+}
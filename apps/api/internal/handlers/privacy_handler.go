@@ -0,0 +1,50 @@
+package handlers
+
+import (
+	"log"
+	"melina-studio-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type PrivacyHandler struct {
+	privacyService *service.PrivacyService
+}
+
+func NewPrivacyHandler(privacyService *service.PrivacyService) *PrivacyHandler {
+	return &PrivacyHandler{
+		privacyService: privacyService,
+	}
+}
+
+// function to report which model providers received what classes of data, and under which opt-outs
+func (h *PrivacyHandler) GetPrivacyReport(c *fiber.Ctx) error {
+	since, ok, err := feedbackSinceQuery(c)
+	if !ok {
+		return err
+	}
+
+	var tenantID *uuid.UUID
+	if raw := c.Query("tenant_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid tenant ID",
+			})
+		}
+		tenantID = &id
+	}
+
+	report, err := h.privacyService.Report(since, tenantID)
+	if err != nil {
+		log.Println(err, "Error building privacy report")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to build privacy report",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"report": report,
+	})
+}
@@ -0,0 +1,84 @@
+package handlers
+
+import (
+	"log"
+	"melina-studio-backend/internal/melina/tools"
+	"melina-studio-backend/internal/models"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// function to find and replace text across every shape of a board
+func (h *BoardHandler) ReplaceText(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	boardId, err := uuid.Parse(c.Params("boardId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid board ID",
+		})
+	}
+
+	var dto struct {
+		tools.TextReplaceOptions
+		DryRun bool `json:"dry_run"`
+	}
+	if err := c.BodyParser(&dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	if _, err := h.repo.GetBoardById(userID, boardId); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Board not found",
+		})
+	}
+
+	shapes, err := h.boardDataRepo.GetBoardData(boardId)
+	if err != nil {
+		log.Println(err, "Error getting board data")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get board data",
+		})
+	}
+	updated, report, err := tools.ReplaceBoardText(shapes, dto.TextReplaceOptions)
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+
+	// a dry run returns the report without changing the board
+	if !dto.DryRun && len(updated) > 0 {
+		if err := h.boardDataRepo.UpdateShapesData(boardId, tools.ShapeDataUpdates(updated)); err != nil {
+			log.Println(err, "Error replacing board text")
+			return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+				"error": "Failed to replace text",
+			})
+		}
+		if err := tools.InvalidateAnnotatedImageCache(h.repo, userID, boardId); err != nil {
+			log.Println(err, "Error invalidating annotated image cache")
+		}
+		h.activityService.Record(&models.BoardActivity{
+			BoardID: boardId,
+			UserID:  userID,
+			Type:    models.ActivityShapeRenamed,
+			Actor:   models.ActivityActorUser,
+			Count:   report.Shapes,
+			Summary: tools.TextReplacedSummary("Replaced", dto.TextReplaceOptions, report),
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"dry_run": dto.DryRun,
+		"report":  report,
+		"shapes":  updated,
+	})
+}
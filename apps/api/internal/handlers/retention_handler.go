@@ -0,0 +1,112 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"melina-studio-backend/internal/service"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type RetentionHandler struct {
+	retentionService *service.RetentionService
+}
+
+func NewRetentionHandler(retentionService *service.RetentionService) *RetentionHandler {
+	return &RetentionHandler{
+		retentionService: retentionService,
+	}
+}
+
+// function to get the workspace retention policy
+func (h *RetentionHandler) GetPolicy(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	policy, err := h.retentionService.GetPolicy(userID)
+	if err != nil {
+		log.Println(err, "Error getting retention policy")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get retention policy",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"policy": policy,
+	})
+}
+
+// function to set the workspace retention policy, null fields keep that data forever
+func (h *RetentionHandler) UpdatePolicy(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	var dto struct {
+		ChatRetentionDays         *int `json:"chat_retention_days"`
+		ArchivedChatRetentionDays *int `json:"archived_chat_retention_days"`
+		SnapshotsToKeep           *int `json:"snapshots_to_keep"`
+	}
+	if err := c.BodyParser(&dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	policy, err := h.retentionService.UpdatePolicy(userID, dto.ChatRetentionDays, dto.ArchivedChatRetentionDays, dto.SnapshotsToKeep)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidRetentionPolicy) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		log.Println(err, "Error saving retention policy")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to save retention policy",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"policy": policy,
+	})
+}
+
+// function to report what the retention policy would purge right now, without deleting anything
+func (h *RetentionHandler) DryRun(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	policy, err := h.retentionService.GetPolicy(userID)
+	if err != nil {
+		log.Println(err, "Error getting retention policy")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get retention policy",
+		})
+	}
+
+	report, err := h.retentionService.Apply(policy, time.Now(), true)
+	if err != nil {
+		log.Println(err, "Error running retention dry run")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to run retention dry run",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"policy": policy,
+		"report": report,
+	})
+}
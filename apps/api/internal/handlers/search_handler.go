@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type SearchHandler struct {
+	searchService *service.SearchService
+}
+
+func NewSearchHandler(searchService *service.SearchService) *SearchHandler {
+	return &SearchHandler{
+		searchService: searchService,
+	}
+}
+
+// function to search board titles, shape text and chat messages across all of the user's boards
+// mode=semantic ranks by meaning using embeddings instead of keyword matches
+func (h *SearchHandler) Search(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	query := c.Query("q")
+	mode := c.Query("mode", "keyword")
+	var hits []models.SearchHit
+	switch mode {
+	case "keyword":
+		hits, err = h.searchService.Search(userID, query, c.Query("type"), c.QueryInt("limit", 0))
+	case "semantic":
+		hits, err = h.searchService.SemanticSearch(c.UserContext(), userID, query, c.Query("type"), c.QueryInt("limit", 0))
+	default:
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "mode must be keyword or semantic",
+		})
+	}
+	if err != nil {
+		if errors.Is(err, service.ErrSearchQueryTooShort) || errors.Is(err, service.ErrInvalidSearchType) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		log.Println(err, "Error searching")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to search",
+		})
+	}
+
+	return c.JSON(fiber.Map{
+		"query": query,
+		"mode":  mode,
+		"hits":  hits,
+	})
+}
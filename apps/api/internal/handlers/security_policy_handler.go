@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"melina-studio-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type SecurityPolicyHandler struct {
+	policyService *service.SecurityPolicyService
+}
+
+func NewSecurityPolicyHandler(policyService *service.SecurityPolicyService) *SecurityPolicyHandler {
+	return &SecurityPolicyHandler{
+		policyService: policyService,
+	}
+}
+
+// function to get the IP allowlist and session policy of a tenant
+func (h *SecurityPolicyHandler) GetTenantSecurityPolicy(c *fiber.Ctx) error {
+	tenantID, err := uuid.Parse(c.Params("tenantId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid tenant ID",
+		})
+	}
+
+	policy, err := h.policyService.Get(tenantID)
+	if err != nil {
+		if errors.Is(err, service.ErrSecurityPolicyNotFound) {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "No security policy set",
+			})
+		}
+		log.Println(err, "Error getting security policy")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get security policy",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(policy)
+}
+
+// function to set the IP allowlist and session policy of a tenant, enforced on its users' existing sessions too
+func (h *SecurityPolicyHandler) SetTenantSecurityPolicy(c *fiber.Ctx) error {
+	tenantID, err := uuid.Parse(c.Params("tenantId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid tenant ID",
+		})
+	}
+
+	var dto service.SecurityPolicyInput
+	if err := c.BodyParser(&dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	policy, err := h.policyService.Set(tenantID, dto)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidSecurityPolicy) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		log.Println(err, "Error saving security policy")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to save security policy",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(policy)
+}
+
+// function to remove the security policy of a tenant
+func (h *SecurityPolicyHandler) DeleteTenantSecurityPolicy(c *fiber.Ctx) error {
+	tenantID, err := uuid.Parse(c.Params("tenantId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid tenant ID",
+		})
+	}
+
+	if err := h.policyService.Delete(tenantID); err != nil {
+		log.Println(err, "Error deleting security policy")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to delete security policy",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"message": "Security policy removed",
+	})
+}
@@ -0,0 +1,78 @@
+package handlers
+
+import (
+	"log"
+	"melina-studio-backend/internal/libraries"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// function to lock a shape so neither the user nor the agent can change or delete it
+func (h *BoardHandler) LockShape(c *fiber.Ctx) error {
+	return h.setShapeLocked(c, true)
+}
+
+// function to unlock a shape
+func (h *BoardHandler) UnlockShape(c *fiber.Ctx) error {
+	return h.setShapeLocked(c, false)
+}
+
+func (h *BoardHandler) setShapeLocked(c *fiber.Ctx, locked bool) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	boardId, err := uuid.Parse(c.Params("boardId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid board ID",
+		})
+	}
+	shapeId, err := uuid.Parse(c.Params("shapeId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid shape ID",
+		})
+	}
+
+	if _, err := h.repo.GetBoardById(userID, boardId); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Board not found",
+		})
+	}
+
+	shape, err := h.boardDataRepo.GetShapeByUUID(shapeId)
+	if err != nil || shape.BoardId != boardId {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Shape not found",
+		})
+	}
+
+	if err := h.boardDataRepo.SetShapeLocked(boardId, shapeId, locked); err != nil {
+		log.Println(err, "Error updating shape lock")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update shape lock",
+		})
+	}
+
+	// other tabs of the user show the lock right away
+	libraries.SendShapeLockMessage(h.hub, userID.String(), &libraries.ShapeLockPayload{
+		BoardId: boardId.String(),
+		ShapeId: shapeId.String(),
+		Locked:  locked,
+	})
+
+	message := "Shape unlocked"
+	if locked {
+		message = "Shape locked"
+	}
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"shape_id": shapeId,
+		"locked":   locked,
+		"message":  message,
+	})
+}
@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"melina-studio-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type StorageHandler struct {
+	storageQuotaService *service.StorageQuotaService
+}
+
+func NewStorageHandler(storageQuotaService *service.StorageQuotaService) *StorageHandler {
+	return &StorageHandler{storageQuotaService: storageQuotaService}
+}
+
+// function to get the bytes the current user stores, per kind, against their plan's quota
+func (h *StorageHandler) GetMyStorage(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	report, err := h.storageQuotaService.Report(userID)
+	if err != nil {
+		log.Println(err, "Error getting storage usage")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get storage usage",
+		})
+	}
+	return c.Status(fiber.StatusOK).JSON(report)
+}
+
+// checkStorageQuota reports whether the user may store size bytes at objectKey; when they may not, the 413
+// response is already written and its error should be returned. Failing to read the usage doesn't block uploads
+func checkStorageQuota(c *fiber.Ctx, quota *service.StorageQuotaService, userID uuid.UUID, objectKey string, size int64) (bool, error) {
+	report, err := quota.Check(userID, objectKey, size)
+	if errors.Is(err, service.ErrStorageQuotaExceeded) {
+		return false, c.Status(fiber.StatusRequestEntityTooLarge).JSON(fiber.Map{
+			"error": fmt.Sprintf("Storage quota exceeded: %s of %s used, this upload needs %s. Delete some images or upgrade your plan",
+				service.FormatBytes(report.UsedBytes), service.FormatBytes(report.LimitBytes), service.FormatBytes(size)),
+			"used_bytes":      report.UsedBytes,
+			"limit_bytes":     report.LimitBytes,
+			"requested_bytes": size,
+		})
+	}
+	if err != nil {
+		log.Println(err, "Error checking storage quota")
+	}
+	return true, nil
+}
@@ -0,0 +1,163 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"log"
+	"melina-studio-backend/internal/auth"
+	"melina-studio-backend/internal/libraries"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type TenantHandler struct {
+	tenantService *service.TenantService
+}
+
+func NewTenantHandler(tenantService *service.TenantService) *TenantHandler {
+	return &TenantHandler{
+		tenantService: tenantService,
+	}
+}
+
+// function to get the public settings of the tenant the request resolved to
+func (h *TenantHandler) GetCurrentTenant(c *fiber.Ctx) error {
+	tenant := auth.TenantFromCtx(c)
+	if tenant == nil {
+		return c.Status(fiber.StatusOK).JSON(fiber.Map{
+			"tenant": nil,
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"tenant": fiber.Map{
+			"slug":           tenant.Slug,
+			"name":           tenant.Name,
+			"allowed_models": tenant.AllowedModelNames(),
+			"data_region":    tenant.DataRegion,
+		},
+	})
+}
+
+// function to list every tenant
+func (h *TenantHandler) ListTenants(c *fiber.Ctx) error {
+	tenants, err := h.tenantService.List()
+	if err != nil {
+		log.Println(err, "Error listing tenants")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to list tenants",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"tenants": tenants,
+	})
+}
+
+// function to create a tenant
+func (h *TenantHandler) CreateTenant(c *fiber.Ctx) error {
+	var dto struct {
+		Slug          string   `json:"slug"`
+		Name          string   `json:"name"`
+		AllowedModels []string `json:"allowed_models"`
+		StorageBucket string   `json:"storage_bucket"`
+	}
+	if err := c.BodyParser(&dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	tenant, err := h.tenantService.Create(dto.Slug, dto.Name, dto.AllowedModels, dto.StorageBucket)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidTenant) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		log.Println(err, "Error creating tenant")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to create tenant",
+		})
+	}
+
+	return c.Status(fiber.StatusCreated).JSON(fiber.Map{
+		"tenant": tenant,
+	})
+}
+
+// function to update a tenant's name, allowed models, storage bucket, privacy mode, data region or active flag
+func (h *TenantHandler) UpdateTenant(c *fiber.Ctx) error {
+	tenantId, err := uuid.Parse(c.Params("tenantId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid tenant ID",
+		})
+	}
+
+	var dto service.TenantUpdate
+	if err := c.BodyParser(&dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+
+	tenant, err := h.tenantService.Update(tenantId, dto)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrTenantNotFound):
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Tenant not found",
+			})
+		case errors.Is(err, service.ErrInvalidTenant):
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": err.Error(),
+			})
+		}
+		log.Println(err, "Error updating tenant")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to update tenant",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"tenant": tenant,
+	})
+}
+
+// requestTenantID returns the tenant new records of this request belong to, nil for the default tenant
+func requestTenantID(c *fiber.Ctx) *uuid.UUID {
+	if tenant := auth.TenantFromCtx(c); tenant != nil {
+		id := tenant.UUID
+		return &id
+	}
+	return nil
+}
+
+// belongsToRequestTenant reports whether the user may sign in on the tenant the request resolved to
+func belongsToRequestTenant(c *fiber.Ctx, user models.User) bool {
+	tenantID, enforced := auth.TenantIDFromCtx(c)
+	return !enforced || user.TenantKey() == tenantID
+}
+
+// checkTenantModel rejects models the request's tenant is not allowed to use
+func checkTenantModel(c *fiber.Ctx, modelName string) error {
+	if err := service.CheckTenantModel(auth.TenantFromCtx(c), modelName); err != nil {
+		return c.Status(fiber.StatusForbidden).JSON(fiber.Map{
+			"error": err.Error(),
+		})
+	}
+	return nil
+}
+
+// storageContext carries the tenant's storage bucket to uploads made while handling the request
+func storageContext(c *fiber.Ctx) context.Context {
+	ctx := context.Background()
+	if tenant := auth.TenantFromCtx(c); tenant != nil && tenant.StorageBucket != "" {
+		ctx = libraries.WithStorageBucket(ctx, tenant.StorageBucket)
+	}
+	return ctx
+}
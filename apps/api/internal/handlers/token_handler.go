@@ -2,6 +2,7 @@ package handlers
 
 import (
 	"melina-studio-backend/internal/config"
+	llmHandlers "melina-studio-backend/internal/llm_handlers"
 	"melina-studio-backend/internal/repo"
 	"melina-studio-backend/internal/service"
 	"time"
@@ -106,37 +107,36 @@ func (h *TokenHandler) GetAllSubscriptionPlans(c *fiber.Ctx) error {
 	return c.Status(fiber.StatusOK).JSON(plans)
 }
 
-var modelPricing = map[string]struct {
-	Input  float64
-	Output float64
-}{
-	"claude-4.5-sonnet": {Input: 4.00, Output: 19.00},
-	"gpt-5.1":           {Input: 1.60, Output: 12.50},
-	"gemini-2.5-flash":  {Input: 0.20, Output: 0.75},
-	"meta-llama/llama-4-scout-17b-16e-instruct": {Input: 0.15, Output: 0.45},
-	"llama-3.3-70b-versatile":                   {Input: 0.75, Output: 1.00},
-	"moonshotai/kimi-k2.5":                      {Input: 2.00, Output: 5.00},
-	"moonshotai/kimi-k2-thinking":               {Input: 2.00, Output: 5.00},
-	"deepseek/deepseek-r1":                      {Input: 0.90, Output: 3.00},
-	"deepseek/deepseek-r1-0528":                 {Input: 0.90, Output: 3.00},
-}
+// GetBoardCost returns what the user's requests on a board cost, this month by default or over the last ?days
+func (h *TokenHandler) GetBoardCost(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	boardID, err := uuid.Parse(c.Params("boardId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid board ID",
+		})
+	}
 
-// calculateCost calculates the cost for token usage based on model pricing
-func calculateCost(model string, inputTokens, outputTokens int) float64 {
-	pricing, exists := modelPricing[model]
-	if !exists {
-		// Default pricing if model not found
-		pricing = struct {
-			Input  float64
-			Output float64
-		}{Input: 1.0, Output: 2.0}
+	now := time.Now().UTC()
+	since := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	if days := c.QueryInt("days", 0); days > 0 {
+		since = now.AddDate(0, 0, -min(days, 365))
 	}
 
-	// Cost per token = price per 1M tokens / 1,000,000
-	inputCost := (float64(inputTokens) / 1_000_000) * pricing.Input
-	outputCost := (float64(outputTokens) / 1_000_000) * pricing.Output
+	cost, err := h.tokenRepo.GetBoardCost(userID, boardID, since)
+	if err != nil {
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get board cost",
+		})
+	}
 
-	return inputCost + outputCost
+	return c.Status(fiber.StatusOK).JSON(cost)
 }
 
 // GetTokenAnalytics returns detailed token consumption analytics
@@ -191,7 +191,7 @@ func (h *TokenHandler) GetTokenAnalytics(c *fiber.Ctx) error {
 	usageByModelWithCost := make([]ModelUsageWithCost, len(usageByModel))
 	var totalCost float64
 	for i, u := range usageByModel {
-		cost := calculateCost(u.Model, int(u.InputTokens), int(u.OutputTokens))
+		cost := llmHandlers.ModelCost(u.Model, int(u.InputTokens), int(u.OutputTokens))
 		totalCost += cost
 		usageByModelWithCost[i] = ModelUsageWithCost{
 			Model:        u.Model,
@@ -235,6 +235,11 @@ func (h *TokenHandler) GetTokenAnalytics(c *fiber.Ctx) error {
 
 	historyWithCost := make([]HistoryWithCost, len(history))
 	for i, h := range history {
+		// records from before costs were stored are priced now
+		cost := h.CostUSD
+		if cost == 0 {
+			cost = llmHandlers.ModelCost(h.Model, h.InputTokens, h.OutputTokens)
+		}
 		historyWithCost[i] = HistoryWithCost{
 			UUID:         h.UUID,
 			Provider:     h.Provider,
@@ -242,7 +247,7 @@ func (h *TokenHandler) GetTokenAnalytics(c *fiber.Ctx) error {
 			TotalTokens:  h.TotalTokens,
 			InputTokens:  h.InputTokens,
 			OutputTokens: h.OutputTokens,
-			Cost:         calculateCost(h.Model, h.InputTokens, h.OutputTokens),
+			Cost:         cost,
 			CreatedAt:    h.CreatedAt,
 			BoardUUID:    h.BoardUUID,
 		}
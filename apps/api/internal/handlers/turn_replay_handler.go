@@ -0,0 +1,48 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"melina-studio-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type TurnReplayHandler struct {
+	replayService *service.TurnReplayService
+}
+
+func NewTurnReplayHandler(replayService *service.TurnReplayService) *TurnReplayHandler {
+	return &TurnReplayHandler{
+		replayService: replayService,
+	}
+}
+
+// function to replay the turn of an AI message on a sandbox board, for debugging
+// ?keep=true keeps the sandbox board so its shapes can be inspected
+func (h *TurnReplayHandler) ReproduceTurn(c *fiber.Ctx) error {
+	messageID, err := uuid.Parse(c.Params("messageId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid message ID",
+		})
+	}
+
+	replay, err := h.replayService.Replay(c.UserContext(), messageID, c.QueryBool("keep"))
+	if errors.Is(err, service.ErrTurnNotFound) {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "No recorded turn for this message",
+		})
+	}
+	if err != nil {
+		log.Println(err, "Error replaying turn")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to replay turn",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"replay": replay,
+	})
+}
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"errors"
+	"log"
+	"melina-studio-backend/internal/libraries"
+	"melina-studio-backend/internal/service"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// validateUpload checks an upload before it is stored and returns the content type to store it as.
+// When the upload is refused the response is already written and its error should be returned
+func validateUpload(c *fiber.Ctx, validator *service.UploadValidator, userID uuid.UUID, data []byte) (string, bool, error) {
+	contentType, err := validator.Validate(c.Context(), userID, data)
+	if err == nil {
+		return contentType, true, nil
+	}
+
+	status := fiber.StatusInternalServerError
+	switch {
+	case errors.Is(err, service.ErrUploadTypeNotAllowed):
+		status = fiber.StatusUnsupportedMediaType
+	case errors.Is(err, service.ErrUploadTooLarge):
+		status = fiber.StatusRequestEntityTooLarge
+	case errors.Is(err, libraries.ErrUploadInfected):
+		log.Println(err, "Refused infected upload from user", userID)
+		return "", false, c.Status(fiber.StatusUnprocessableEntity).JSON(fiber.Map{
+			"error": "The file was flagged by the malware scanner and wasn't uploaded",
+		})
+	case errors.Is(err, service.ErrUploadScanFailed):
+		log.Println(err, "Error scanning upload")
+		return "", false, c.Status(fiber.StatusServiceUnavailable).JSON(fiber.Map{
+			"error": "Uploads can't be scanned right now, please try again later",
+		})
+	}
+	return "", false, c.Status(status).JSON(fiber.Map{
+		"error": err.Error(),
+	})
+}
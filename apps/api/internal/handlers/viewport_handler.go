@@ -0,0 +1,172 @@
+package handlers
+
+import (
+	"log"
+	"math"
+	"melina-studio-backend/internal/melina/tools"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+type ViewportHandler struct {
+	boardRepo     repo.BoardRepoInterface
+	boardDataRepo repo.BoardDataRepoInterface
+	viewportRepo  repo.BoardViewportRepoInterface
+}
+
+func NewViewportHandler(boardRepo repo.BoardRepoInterface, boardDataRepo repo.BoardDataRepoInterface, viewportRepo repo.BoardViewportRepoInterface) *ViewportHandler {
+	return &ViewportHandler{
+		boardRepo:     boardRepo,
+		boardDataRepo: boardDataRepo,
+		viewportRepo:  viewportRepo,
+	}
+}
+
+// function to get the user's last viewport on a board; viewport is null until they move the camera there
+func (h *ViewportHandler) GetViewport(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	boardId, err := uuid.Parse(c.Params("boardId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid board ID",
+		})
+	}
+
+	if err := h.boardRepo.ValidateBoardOwnership(userID, boardId); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Board not found",
+		})
+	}
+
+	viewport, err := h.viewportRepo.GetViewport(userID, boardId)
+	if err != nil {
+		log.Println(err, "Error getting viewport")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get viewport",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"viewport": viewport,
+	})
+}
+
+// function to store where the user left the camera on a board
+func (h *ViewportHandler) SaveViewport(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	boardId, err := uuid.Parse(c.Params("boardId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid board ID",
+		})
+	}
+
+	var dto models.Viewport
+	if err := c.BodyParser(&dto); err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid request body",
+		})
+	}
+	for _, v := range []float64{dto.X, dto.Y, dto.Zoom} {
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "x, y and zoom must be numbers",
+			})
+		}
+	}
+	if dto.Zoom <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "zoom must be positive",
+		})
+	}
+
+	if err := h.boardRepo.ValidateBoardOwnership(userID, boardId); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Board not found",
+		})
+	}
+
+	viewport := &models.BoardViewport{UserID: userID, BoardID: boardId, X: dto.X, Y: dto.Y, Zoom: dto.Zoom}
+	if err := h.viewportRepo.SaveViewport(viewport); err != nil {
+		log.Println(err, "Error saving viewport")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to save viewport",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"viewport": viewport,
+	})
+}
+
+// function to get the viewport that shows the given shapes, e.g. a frame, fitted to the client's canvas
+func (h *ViewportHandler) FocusShapes(c *fiber.Ctx) error {
+	userID, err := uuid.Parse(c.Locals("userID").(string))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid user ID",
+		})
+	}
+
+	boardId, err := uuid.Parse(c.Params("boardId"))
+	if err != nil {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "Invalid board ID",
+		})
+	}
+
+	shapes := c.Query("shapes")
+	if shapes == "" {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "shapes is required",
+		})
+	}
+	width := c.QueryFloat("width", tools.FocusCanvasWidth)
+	height := c.QueryFloat("height", tools.FocusCanvasHeight)
+	if width <= 0 || height <= 0 {
+		return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+			"error": "width and height must be positive",
+		})
+	}
+
+	if err := h.boardRepo.ValidateBoardOwnership(userID, boardId); err != nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "Board not found",
+		})
+	}
+
+	boardData, err := h.boardDataRepo.GetBoardData(boardId)
+	if err != nil {
+		log.Println(err, "Error getting board data")
+		return c.Status(fiber.StatusInternalServerError).JSON(fiber.Map{
+			"error": "Failed to get board data",
+		})
+	}
+	bounds := tools.AnchorBounds(boardData, strings.Split(shapes, ","))
+	if bounds == nil {
+		return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+			"error": "None of the shapes was found on the board",
+		})
+	}
+
+	return c.Status(fiber.StatusOK).JSON(fiber.Map{
+		"bounds":   tools.BoundsRect(*bounds),
+		"viewport": tools.FitViewport(*bounds, width, height),
+	})
+}
@@ -0,0 +1,172 @@
+package libraries
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// WebSocketMessageTypeAnnotation is a laser pointer or highlight, sent by a client or the agent and
+	// relayed to the user's other connections; annotations are never stored
+	WebSocketMessageTypeAnnotation WebSocketMessageType = "annotation"
+	// WebSocketMessageTypeAnnotationExpired tells the connections to remove an annotation once its TTL is over
+	WebSocketMessageTypeAnnotationExpired WebSocketMessageType = "annotation_expired"
+)
+
+type AnnotationKind string
+
+const (
+	AnnotationPointer   AnnotationKind = "pointer"
+	AnnotationHighlight AnnotationKind = "highlight"
+)
+
+const (
+	AnnotationSourceUser  = "user"
+	AnnotationSourceAgent = "agent"
+)
+
+// annotations live between annotationMinTTL and annotationMaxTTL; a pointer that keeps moving is
+// resent with the same id, which restarts its TTL
+const (
+	annotationMinTTL       = 250 * time.Millisecond
+	annotationMaxTTL       = 30 * time.Second
+	annotationPointerTTL   = 3 * time.Second
+	annotationHighlightTTL = 5 * time.Second
+	annotationMaxLabel     = 200
+)
+
+// AnnotationPayload is an ephemeral pointer at a point or highlight of shapes on a board
+type AnnotationPayload struct {
+	Id      string         `json:"id"`
+	BoardId string         `json:"board_id"`
+	Kind    AnnotationKind `json:"kind"`
+	Source  string         `json:"source"` // set by the server, "user" or "agent"
+	// X and Y are where a pointer points, in canvas coordinates
+	X *float64 `json:"x,omitempty"`
+	Y *float64 `json:"y,omitempty"`
+	// ShapeIds and Bounds are what a highlight covers; Bounds lets the client draw shapes it hasn't received yet
+	ShapeIds  []string         `json:"shape_ids,omitempty"`
+	Bounds    *SelectionBounds `json:"bounds,omitempty"`
+	Label     string           `json:"label,omitempty"`
+	Color     string           `json:"color,omitempty"`
+	TTLMs     int              `json:"ttl_ms"`
+	ExpiresAt time.Time        `json:"expires_at"`
+}
+
+// AnnotationExpiredPayload names the annotation to remove
+type AnnotationExpiredPayload struct {
+	Id      string `json:"id"`
+	BoardId string `json:"board_id"`
+}
+
+var (
+	annotationTimersMu sync.Mutex
+	// annotationTimers are the pending expiries, by user and annotation id
+	annotationTimers = make(map[string]*time.Timer)
+)
+
+// normalizeAnnotation checks an annotation and fills in its id, TTL and expiry
+func normalizeAnnotation(payload *AnnotationPayload, source string, now time.Time) error {
+	if payload.BoardId == "" {
+		return errors.New("board_id is required")
+	}
+	switch payload.Kind {
+	case AnnotationPointer:
+		if payload.X == nil || payload.Y == nil {
+			return errors.New("a pointer needs x and y")
+		}
+	case AnnotationHighlight:
+		if len(payload.ShapeIds) == 0 && payload.Bounds == nil {
+			return errors.New("a highlight needs shape_ids or bounds")
+		}
+	default:
+		return errors.New("kind must be pointer or highlight")
+	}
+
+	if payload.Id == "" {
+		payload.Id = uuid.NewString()
+	}
+	if label := []rune(payload.Label); len(label) > annotationMaxLabel {
+		payload.Label = string(label[:annotationMaxLabel])
+	}
+	payload.Source = source
+
+	ttl := time.Duration(payload.TTLMs) * time.Millisecond
+	if ttl <= 0 {
+		ttl = annotationPointerTTL
+		if payload.Kind == AnnotationHighlight {
+			ttl = annotationHighlightTTL
+		}
+	}
+	ttl = min(max(ttl, annotationMinTTL), annotationMaxTTL)
+	payload.TTLMs = int(ttl / time.Millisecond)
+	payload.ExpiresAt = now.Add(ttl)
+	return nil
+}
+
+// RelayAnnotation passes an annotation a user drew to their other connections, e.g. the screen they present on
+func RelayAnnotation(hub *Hub, client *Client, payload *AnnotationPayload) error {
+	if err := normalizeAnnotation(payload, AnnotationSourceUser, time.Now()); err != nil {
+		return err
+	}
+	publishAnnotation(hub, client.UserID, client.ID, payload)
+	return nil
+}
+
+// SendAgentAnnotation shows an annotation from the agent on every connection of the user
+func SendAgentAnnotation(hub *Hub, userID string, payload *AnnotationPayload) error {
+	if err := normalizeAnnotation(payload, AnnotationSourceAgent, time.Now()); err != nil {
+		return err
+	}
+	publishAnnotation(hub, userID, "", payload)
+	return nil
+}
+
+// publishAnnotation sends the annotation and (re)starts the timer that expires it
+func publishAnnotation(hub *Hub, userID string, exceptClientID string, payload *AnnotationPayload) {
+	msg, err := json.Marshal(WebSocketMessage{
+		Type: WebSocketMessageTypeAnnotation,
+		Data: payload,
+	})
+	if err != nil {
+		log.Println("failed to marshal annotation:", err)
+		return
+	}
+	hub.Direct <- DirectMessage{UserID: userID, Message: msg, ExceptClientID: exceptClientID}
+
+	key := userID + ":" + payload.Id
+	expired := AnnotationExpiredPayload{Id: payload.Id, BoardId: payload.BoardId}
+	annotationTimersMu.Lock()
+	defer annotationTimersMu.Unlock()
+	if timer, ok := annotationTimers[key]; ok {
+		timer.Stop()
+	}
+	var timer *time.Timer
+	timer = time.AfterFunc(time.Until(payload.ExpiresAt), func() {
+		annotationTimersMu.Lock()
+		// a resend replaced this timer, the annotation lives on
+		if annotationTimers[key] != timer {
+			annotationTimersMu.Unlock()
+			return
+		}
+		delete(annotationTimers, key)
+		annotationTimersMu.Unlock()
+
+		msg, err := json.Marshal(WebSocketMessage{
+			Type: WebSocketMessageTypeAnnotationExpired,
+			Data: &expired,
+		})
+		if err != nil {
+			log.Println("failed to marshal annotation expiry:", err)
+			return
+		}
+		// the sender removes it too, its own copy expires at the same time
+		hub.SendToUser(userID, msg)
+	})
+	annotationTimers[key] = timer
+}
@@ -0,0 +1,82 @@
+package libraries
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestNormalizeAnnotation(t *testing.T) {
+	now := time.Now()
+	x, y := 10.0, 20.0
+	pointer := &AnnotationPayload{BoardId: "board", Kind: AnnotationPointer, X: &x, Y: &y, TTLMs: 120000}
+	if err := normalizeAnnotation(pointer, AnnotationSourceUser, now); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if pointer.Id == "" || pointer.Source != AnnotationSourceUser {
+		t.Errorf("expected an id and the source to be set, got %+v", pointer)
+	}
+	if pointer.TTLMs != int(annotationMaxTTL/time.Millisecond) || !pointer.ExpiresAt.Equal(now.Add(annotationMaxTTL)) {
+		t.Errorf("expected the TTL to be capped, got %d", pointer.TTLMs)
+	}
+
+	highlight := &AnnotationPayload{BoardId: "board", Kind: AnnotationHighlight, ShapeIds: []string{"shape"}}
+	if err := normalizeAnnotation(highlight, AnnotationSourceAgent, now); err != nil || highlight.TTLMs != int(annotationHighlightTTL/time.Millisecond) {
+		t.Errorf("expected the default highlight TTL, got %d (%v)", highlight.TTLMs, err)
+	}
+
+	for _, invalid := range []*AnnotationPayload{
+		{Kind: AnnotationPointer, X: &x, Y: &y},
+		{BoardId: "board", Kind: AnnotationPointer, X: &x},
+		{BoardId: "board", Kind: AnnotationHighlight},
+		{BoardId: "board", Kind: "laser", X: &x, Y: &y},
+	} {
+		if err := normalizeAnnotation(invalid, AnnotationSourceUser, now); err == nil {
+			t.Errorf("expected %+v to be rejected", invalid)
+		}
+	}
+}
+
+func TestRelayAnnotationSkipsTheSenderAndExpires(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+	presenter := &Client{ID: "presenter", UserID: "user", Send: make(chan []byte, 8)}
+	screen := &Client{ID: "screen", UserID: "user", Send: make(chan []byte, 8)}
+	other := &Client{ID: "other", UserID: "someone-else", Send: make(chan []byte, 8)}
+	for _, client := range []*Client{presenter, screen, other} {
+		hub.Register <- client
+	}
+
+	x, y := 1.0, 2.0
+	if err := RelayAnnotation(hub, presenter, &AnnotationPayload{BoardId: "board", Kind: AnnotationPointer, X: &x, Y: &y, TTLMs: 1}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	messageType := func(client *Client) WebSocketMessageType {
+		select {
+		case msg := <-client.Send:
+			var decoded WebSocketMessage
+			if err := json.Unmarshal(msg, &decoded); err != nil {
+				t.Fatalf("invalid message: %v", err)
+			}
+			return decoded.Type
+		case <-time.After(2 * time.Second):
+			return ""
+		}
+	}
+	if got := messageType(screen); got != WebSocketMessageTypeAnnotation {
+		t.Fatalf("expected the other connection to get the annotation, got %q", got)
+	}
+	// the presenter only hears about the expiry, after the minimum TTL
+	if got := messageType(presenter); got != WebSocketMessageTypeAnnotationExpired {
+		t.Errorf("expected the presenter to get the expiry only, got %q", got)
+	}
+	if got := messageType(screen); got != WebSocketMessageTypeAnnotationExpired {
+		t.Errorf("expected the annotation to expire, got %q", got)
+	}
+	select {
+	case msg := <-other.Send:
+		t.Errorf("another user must not see the annotation: %s", msg)
+	default:
+	}
+}
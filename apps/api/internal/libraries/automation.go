@@ -0,0 +1,35 @@
+package libraries
+
+import (
+	"encoding/json"
+	"log"
+)
+
+// WebSocketMessageTypeAutomationRun tells the user's connections an automation rule of one of their boards ran
+const WebSocketMessageTypeAutomationRun WebSocketMessageType = "automation_run"
+
+// AutomationRunPayload is the outcome of a rule's run. The client should reload the ChangedShapes the run
+// created or moved, or the whole board with ReloadBoard, before its next save
+type AutomationRunPayload struct {
+	BoardId       string   `json:"board_id"`
+	RuleId        string   `json:"rule_id"`
+	RunId         string   `json:"run_id"`
+	ShapeId       string   `json:"shape_id,omitempty"`
+	Status        string   `json:"status"`
+	Error         string   `json:"error,omitempty"`
+	ChangedShapes []string `json:"changed_shapes,omitempty"`
+	ReloadBoard   bool     `json:"reload_board,omitempty"`
+}
+
+// SendAutomationRunMessage tells every connection of the board's owner that a rule ran
+func SendAutomationRunMessage(hub *Hub, userID string, payload *AutomationRunPayload) {
+	msg, err := json.Marshal(WebSocketMessage{
+		Type: WebSocketMessageTypeAutomationRun,
+		Data: payload,
+	})
+	if err != nil {
+		log.Println("failed to marshal automation run message:", err)
+		return
+	}
+	hub.SendToUser(userID, msg)
+}
@@ -0,0 +1,159 @@
+package libraries
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+)
+
+// OverflowPolicy decides what happens to a client's messages while its Send channel is full
+type OverflowPolicy struct {
+	// MaxBufferedDeltas is how many messages may wait beyond the Send channel before streamed
+	// deltas are dropped for the rest of the reply and a stream_truncated notice is sent instead
+	MaxBufferedDeltas int
+	// MaxBuffered caps the overflow for every other message, which is only dropped past it
+	MaxBuffered int
+}
+
+// DefaultOverflowPolicy grows a client's buffer from the 256 slots of Send to about 1.3k messages
+var DefaultOverflowPolicy = OverflowPolicy{MaxBufferedDeltas: 1024, MaxBuffered: 4096}
+
+type outboundKind int
+
+const (
+	outboundMessage outboundKind = iota
+	// outboundDelta is a streamed chunk of text; it is the only kind dropped while a reply is truncated
+	outboundDelta
+	// outboundStreamEnd closes the reply (chat_completed) so the next reply streams again
+	outboundStreamEnd
+)
+
+// StreamTruncatedPayload tells the client its streamed reply is incomplete; the full reply still arrives
+// with chat_completed, or can be fetched from FallbackURL if that is dropped too
+type StreamTruncatedPayload struct {
+	BoardId     string `json:"board_id"`
+	Reason      string `json:"reason"`
+	FallbackURL string `json:"fallback_url"`
+}
+
+// sendOverflow holds the messages waiting for room on a client's Send channel, in order
+type sendOverflow struct {
+	queue     [][]byte
+	draining  bool
+	truncated bool
+}
+
+// deliver puts a message on the client's send channel; when the channel is full it is buffered
+// according to the client's overflow policy and handed over in order as the writer catches up
+func deliver(client *Client, message []byte, kind outboundKind, boardId string) {
+	client.outMu.Lock()
+	defer client.outMu.Unlock()
+	o := &client.overflow
+
+	if o.truncated {
+		switch kind {
+		case outboundDelta:
+			return
+		case outboundStreamEnd:
+			o.truncated = false
+		}
+	}
+
+	if len(o.queue) == 0 && trySend(client, message) {
+		return
+	}
+
+	policy := client.overflowPolicy()
+	switch {
+	case kind == outboundDelta && len(o.queue) >= policy.MaxBufferedDeltas:
+		log.Printf("[websocket] Client %s is too slow, truncating the streamed reply", client.ID)
+		o.truncated = true
+		// the notice may exceed the limit, the client must learn that its reply is incomplete
+		if notice, err := streamTruncatedMessage(boardId); err == nil {
+			o.queue = append(o.queue, notice)
+		}
+	case len(o.queue) >= policy.MaxBuffered:
+		log.Printf("[websocket] SendMessage: overflow full, dropping message for client %s", client.ID)
+		return
+	default:
+		o.queue = append(o.queue, message)
+	}
+
+	if !o.draining {
+		o.draining = true
+		go drainOverflow(client)
+	}
+}
+
+// drainOverflow moves buffered messages to the Send channel, blocking until the writer takes each one
+func drainOverflow(client *Client) {
+	for {
+		client.outMu.Lock()
+		if len(client.overflow.queue) == 0 {
+			client.overflow.draining = false
+			client.outMu.Unlock()
+			return
+		}
+		message := client.overflow.queue[0]
+		client.outMu.Unlock()
+
+		if !blockingSend(client, message) {
+			client.outMu.Lock()
+			client.overflow = sendOverflow{}
+			client.outMu.Unlock()
+			return
+		}
+
+		client.outMu.Lock()
+		client.overflow.queue = client.overflow.queue[1:]
+		client.outMu.Unlock()
+	}
+}
+
+// trySend sends without blocking; false means the channel is full
+func trySend(client *Client, message []byte) (sent bool) {
+	// Use defer/recover to safely handle closed channel panic
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("[websocket] SendMessage recovered from panic (client likely disconnected): %v", r)
+			// nothing to buffer for a disconnected client
+			sent = true
+		}
+	}()
+
+	select {
+	case client.Send <- message:
+		return true
+	default:
+		return false
+	}
+}
+
+// blockingSend waits for room on the channel; false means the client disconnected
+func blockingSend(client *Client, message []byte) (sent bool) {
+	defer func() {
+		if recover() != nil {
+			sent = false
+		}
+	}()
+	client.Send <- message
+	return true
+}
+
+func streamTruncatedMessage(boardId string) ([]byte, error) {
+	return json.Marshal(WebSocketMessage{
+		Type: WebSocketMessageTypeStreamTruncated,
+		Data: &StreamTruncatedPayload{
+			BoardId:     boardId,
+			Reason:      "connection too slow for streaming",
+			FallbackURL: fmt.Sprintf("/api/v1/chat/%s", boardId),
+		},
+	})
+}
+
+func (c *Client) overflowPolicy() OverflowPolicy {
+	if c.Overflow.MaxBuffered > 0 {
+		return c.Overflow
+	}
+	return DefaultOverflowPolicy
+}
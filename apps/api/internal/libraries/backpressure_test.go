@@ -0,0 +1,66 @@
+package libraries
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestDeliverTruncatesSlowStream(t *testing.T) {
+	client := &Client{ID: "slow", Send: make(chan []byte, 1), Overflow: OverflowPolicy{MaxBufferedDeltas: 2, MaxBuffered: 10}}
+
+	deliver(client, []byte("shape"), outboundMessage, "")
+	deliver(client, []byte("d1"), outboundDelta, "b1")
+	deliver(client, []byte("d2"), outboundDelta, "b1")
+	deliver(client, []byte("d3"), outboundDelta, "b1")
+	deliver(client, []byte("d4"), outboundDelta, "b1")
+	deliver(client, []byte("completed"), outboundStreamEnd, "")
+
+	var got []string
+	read := func(n int) {
+		for len(got) < n {
+			select {
+			case msg := <-client.Send:
+				got = append(got, string(msg))
+			case <-time.After(time.Second):
+				t.Fatalf("timed out after %v", got)
+			}
+		}
+	}
+	read(5)
+	// once the client caught up the next reply streams normally
+	deliver(client, []byte("next"), outboundDelta, "b1")
+	read(6)
+
+	want := []string{"shape", "d1", "d2", "", "completed", "next"}
+	for i := range want {
+		if i == 3 {
+			var notice struct {
+				Type WebSocketMessageType   `json:"type"`
+				Data StreamTruncatedPayload `json:"data"`
+			}
+			if err := json.Unmarshal([]byte(got[i]), &notice); err != nil || notice.Type != WebSocketMessageTypeStreamTruncated || notice.Data.FallbackURL != "/api/v1/chat/b1" {
+				t.Errorf("message 3 = %s, want a stream_truncated notice for b1", got[i])
+			}
+			continue
+		}
+		if got[i] != want[i] {
+			t.Errorf("message %d = %q, want %q (all: %v)", i, got[i], want[i], got)
+		}
+	}
+}
+
+func TestDeliverDropsPastMaxBuffered(t *testing.T) {
+	client := &Client{ID: "stuck", Send: make(chan []byte, 1), Overflow: OverflowPolicy{MaxBufferedDeltas: 5, MaxBuffered: 2}}
+	for _, msg := range []string{"a", "b", "c", "d"} {
+		deliver(client, []byte(msg), outboundMessage, "")
+	}
+
+	client.outMu.Lock()
+	buffered := len(client.overflow.queue)
+	client.outMu.Unlock()
+	if buffered > 2 {
+		t.Errorf("buffered %d messages, want at most 2", buffered)
+	}
+	close(client.Send)
+}
@@ -0,0 +1,112 @@
+package libraries
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Binary frames carry images next to a chat message without base64 encoding them into the JSON:
+//
+//	[4-byte big-endian header length][JSON header][attachment bytes...]
+//
+// The header is a regular websocket message plus an "attachments" list giving the media type and size of
+// each attachment, in the order their bytes follow. The message refers to attachment N by the URL "attachment:N"
+// in shape_image_urls or uploaded_image_urls
+const (
+	// AttachmentURLPrefix marks an image URL that refers to an attachment of the same binary frame
+	AttachmentURLPrefix = "attachment:"
+
+	maxFrameHeaderBytes = 1 << 20
+	maxFrameAttachments = 16
+)
+
+// FrameAttachment is an image sent inline in a binary frame
+type FrameAttachment struct {
+	MediaType string
+	Data      []byte
+}
+
+type frameAttachmentHeader struct {
+	MediaType string `json:"media_type"`
+	Size      int    `json:"size"`
+}
+
+// decodeBinaryFrame splits a binary frame into its JSON header and attachments
+func decodeBinaryFrame(frame []byte) ([]byte, []FrameAttachment, error) {
+	if len(frame) < 4 {
+		return nil, nil, fmt.Errorf("binary frame too short")
+	}
+	headerLen := int(binary.BigEndian.Uint32(frame[:4]))
+	if headerLen > maxFrameHeaderBytes || headerLen > len(frame)-4 {
+		return nil, nil, fmt.Errorf("invalid binary frame header length %d", headerLen)
+	}
+	header := frame[4 : 4+headerLen]
+	body := frame[4+headerLen:]
+
+	var envelope struct {
+		Attachments []frameAttachmentHeader `json:"attachments"`
+	}
+	if err := json.Unmarshal(header, &envelope); err != nil {
+		return nil, nil, fmt.Errorf("invalid binary frame header: %w", err)
+	}
+	if len(envelope.Attachments) > maxFrameAttachments {
+		return nil, nil, fmt.Errorf("binary frame has %d attachments, at most %d are allowed", len(envelope.Attachments), maxFrameAttachments)
+	}
+
+	attachments := make([]FrameAttachment, 0, len(envelope.Attachments))
+	offset := 0
+	for i, a := range envelope.Attachments {
+		if !strings.HasPrefix(a.MediaType, "image/") {
+			return nil, nil, fmt.Errorf("attachment %d is %q, only images are supported", i, a.MediaType)
+		}
+		if a.Size <= 0 || a.Size > len(body)-offset {
+			return nil, nil, fmt.Errorf("attachment %d has an invalid size %d", i, a.Size)
+		}
+		attachments = append(attachments, FrameAttachment{MediaType: a.MediaType, Data: body[offset : offset+a.Size]})
+		offset += a.Size
+	}
+	if offset != len(body) {
+		return nil, nil, fmt.Errorf("binary frame has %d bytes not described by its header", len(body)-offset)
+	}
+	return header, attachments, nil
+}
+
+// parseBinaryWebSocketMessage parses a binary frame; only chat messages may carry attachments
+func parseBinaryWebSocketMessage(frame []byte) (*WebSocketMessage, error) {
+	header, attachments, err := decodeBinaryFrame(frame)
+	if err != nil {
+		return nil, err
+	}
+	message, err := parseWebSocketMessage(header)
+	if err != nil {
+		return nil, err
+	}
+	if len(attachments) == 0 {
+		return message, nil
+	}
+
+	chatPayload, ok := message.Data.(*ChatMessagePayload)
+	if !ok {
+		return nil, fmt.Errorf("attachments are only supported on chat messages")
+	}
+	if chatPayload.Metadata == nil {
+		chatPayload.Metadata = &ChatMessageMetadata{}
+	}
+	chatPayload.Metadata.Attachments = attachments
+	return message, nil
+}
+
+// ResolveAttachment returns the attachment an "attachment:N" URL refers to; isAttachment is false for regular URLs
+func ResolveAttachment(url string, attachments []FrameAttachment) (attachment *FrameAttachment, isAttachment bool, err error) {
+	if !strings.HasPrefix(url, AttachmentURLPrefix) {
+		return nil, false, nil
+	}
+	index, err := strconv.Atoi(strings.TrimPrefix(url, AttachmentURLPrefix))
+	if err != nil || index < 0 || index >= len(attachments) {
+		return nil, true, fmt.Errorf("no attachment %q in this message", url)
+	}
+	return &attachments[index], true, nil
+}
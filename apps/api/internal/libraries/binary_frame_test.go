@@ -0,0 +1,64 @@
+package libraries
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func encodeTestFrame(header string, attachments ...[]byte) []byte {
+	frame := make([]byte, 4, 4+len(header))
+	binary.BigEndian.PutUint32(frame, uint32(len(header)))
+	frame = append(frame, header...)
+	for _, a := range attachments {
+		frame = append(frame, a...)
+	}
+	return frame
+}
+
+func TestParseBinaryWebSocketMessageAttachesImagesToTheChatMessage(t *testing.T) {
+	png := []byte{0x89, 'P', 'N', 'G'}
+	jpeg := []byte{0xff, 0xd8, 0xff}
+	frame := encodeTestFrame(`{"type":"chat_message","data":{"board_id":"b","message":"look","metadata":{"uploaded_image_urls":["attachment:1"]}},`+
+		`"attachments":[{"media_type":"image/png","size":4},{"media_type":"image/jpeg","size":3}]}`, png, jpeg)
+
+	message, err := parseBinaryWebSocketMessage(frame)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	payload, ok := message.Data.(*ChatMessagePayload)
+	if !ok || payload.Metadata == nil || len(payload.Metadata.Attachments) != 2 {
+		t.Fatalf("expected two attachments on the chat message, got %#v", message.Data)
+	}
+
+	attachment, isAttachment, err := ResolveAttachment(payload.Metadata.UploadedImageUrls[0], payload.Metadata.Attachments)
+	if err != nil || !isAttachment || attachment.MediaType != "image/jpeg" || !bytes.Equal(attachment.Data, jpeg) {
+		t.Fatalf("attachment:1 should resolve to the jpeg, got %#v, %v", attachment, err)
+	}
+	if _, isAttachment, _ := ResolveAttachment("https://example.com/a.png", payload.Metadata.Attachments); isAttachment {
+		t.Fatal("regular URLs are not attachments")
+	}
+	if _, _, err := ResolveAttachment("attachment:2", payload.Metadata.Attachments); err == nil {
+		t.Fatal("expected an error for a missing attachment")
+	}
+}
+
+func TestDecodeBinaryFrameRejectsMalformedFrames(t *testing.T) {
+	cases := map[string][]byte{
+		"too short":            {0, 0},
+		"header too long":      {0, 0, 1, 0, '{', '}'},
+		"size past the end":    encodeTestFrame(`{"attachments":[{"media_type":"image/png","size":10}]}`, []byte{1, 2}),
+		"undescribed bytes":    encodeTestFrame(`{"attachments":[{"media_type":"image/png","size":1}]}`, []byte{1, 2}),
+		"not an image":         encodeTestFrame(`{"attachments":[{"media_type":"text/html","size":1}]}`, []byte{1}),
+		"header not an object": encodeTestFrame(`[]`),
+	}
+	for name, frame := range cases {
+		if _, _, err := decodeBinaryFrame(frame); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+
+	if _, err := parseBinaryWebSocketMessage(encodeTestFrame(`{"type":"ping","attachments":[{"media_type":"image/png","size":1}]}`, []byte{1})); err == nil {
+		t.Error("attachments on a non-chat message should be rejected")
+	}
+}
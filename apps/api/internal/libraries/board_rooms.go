@@ -0,0 +1,246 @@
+package libraries
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// WebSocketMessageTypeBoardJoin makes a connection receive the shape changes anyone makes to a board
+	WebSocketMessageTypeBoardJoin WebSocketMessageType = "board_join"
+	// WebSocketMessageTypeBoardLeave stops them
+	WebSocketMessageTypeBoardLeave WebSocketMessageType = "board_leave"
+	// WebSocketMessageTypeBoardJoined confirms a join with the connection's id and the board's viewers
+	WebSocketMessageTypeBoardJoined WebSocketMessageType = "board_joined"
+	// WebSocketMessageTypeBoardLeft confirms a leave
+	WebSocketMessageTypeBoardLeft WebSocketMessageType = "board_left"
+	// WebSocketMessageTypeBoardMembers tells a board's viewers who is viewing it whenever someone joins or leaves
+	WebSocketMessageTypeBoardMembers WebSocketMessageType = "board_members"
+)
+
+// ClientIDHeader names the connection an HTTP save comes from, so the save isn't echoed back to it.
+// Connections learn their id from board_joined
+const ClientIDHeader = "X-Client-Id"
+
+// maxBoardRoomsPerClient caps the boards a connection can view at once
+const maxBoardRoomsPerClient = 20
+
+// BoardAccessFunc reports whether a user may view a board, checked before a connection joins its room
+type BoardAccessFunc func(userID string, boardId string) bool
+
+// BoardRoomPayload names the board of a join or leave
+type BoardRoomPayload struct {
+	BoardId string `json:"board_id"`
+}
+
+// BoardMember is a connection viewing a board
+type BoardMember struct {
+	ClientId string `json:"client_id"`
+	UserId   string `json:"user_id"`
+}
+
+// boardRoom is the connections viewing a board. The ones that joined its presentation follow the slide the
+// presenter is on (see presentation.go)
+type boardRoom struct {
+	members   map[string]*Client
+	followers map[string]bool
+	slide     *PresentationSlidePayload
+}
+
+func newBoardRoom() *boardRoom {
+	return &boardRoom{members: make(map[string]*Client), followers: make(map[string]bool)}
+}
+
+// BoardMembersPayload lists a board's viewers; ClientId is set on board_joined only, it is the joining connection
+type BoardMembersPayload struct {
+	BoardId  string        `json:"board_id"`
+	ClientId string        `json:"client_id,omitempty"`
+	Members  []BoardMember `json:"members"`
+}
+
+// SetBoardAccess sets the check joins go through; until it is set, joins are refused
+func (h *Hub) SetBoardAccess(access BoardAccessFunc) {
+	h.roomsMu.Lock()
+	defer h.roomsMu.Unlock()
+	h.boardAccess = access
+}
+
+// JoinBoard adds the connection to the board's room once the user is allowed to view the board, then confirms
+// the join to it and tells the room's other members
+func (h *Hub) JoinBoard(client *Client, boardId string) error {
+	if _, err := uuid.Parse(boardId); err != nil {
+		return errors.New("a valid board_id is required")
+	}
+	h.roomsMu.RLock()
+	access := h.boardAccess
+	h.roomsMu.RUnlock()
+	if access == nil {
+		return errors.New("boards can't be joined on this server")
+	}
+	// checked outside the lock, it may query the database
+	if !access(client.UserID, boardId) {
+		return errors.New("board not found")
+	}
+
+	h.roomsMu.Lock()
+	room, ok := h.rooms[boardId]
+	if !ok || room.members[client.ID] == nil {
+		if h.clientRoomCount(client) >= maxBoardRoomsPerClient {
+			h.roomsMu.Unlock()
+			return fmt.Errorf("a connection can view at most %d boards", maxBoardRoomsPerClient)
+		}
+		if h.rooms == nil {
+			h.rooms = make(map[string]*boardRoom)
+		}
+		if !ok {
+			room = newBoardRoom()
+			h.rooms[boardId] = room
+		}
+		room.members[client.ID] = client
+	}
+	members, others := roomMembers(room, client.ID)
+	h.roomsMu.Unlock()
+
+	h.sendRoomMessage([]*Client{client}, WebSocketMessageTypeBoardJoined, &BoardMembersPayload{BoardId: boardId, ClientId: client.ID, Members: members})
+	h.sendRoomMessage(others, WebSocketMessageTypeBoardMembers, &BoardMembersPayload{BoardId: boardId, Members: members})
+	return nil
+}
+
+// LeaveBoard removes the connection from the board's room, confirms it and tells the remaining members
+func (h *Hub) LeaveBoard(client *Client, boardId string) {
+	members, others := h.removeFromRoom(client, boardId)
+	h.sendRoomMessage([]*Client{client}, WebSocketMessageTypeBoardLeft, &BoardRoomPayload{BoardId: boardId})
+	h.sendRoomMessage(others, WebSocketMessageTypeBoardMembers, &BoardMembersPayload{BoardId: boardId, Members: members})
+}
+
+// leaveBoardRooms removes a closed connection from every room it was in. The remaining members are told
+// from another goroutine, as this runs on the hub's
+func (h *Hub) leaveBoardRooms(client *Client) {
+	h.roomsMu.RLock()
+	var boardIds []string
+	for boardId, room := range h.rooms {
+		if room.members[client.ID] != nil {
+			boardIds = append(boardIds, boardId)
+		}
+	}
+	h.roomsMu.RUnlock()
+	if len(boardIds) == 0 {
+		return
+	}
+
+	go func() {
+		for _, boardId := range boardIds {
+			members, others := h.removeFromRoom(client, boardId)
+			h.sendRoomMessage(others, WebSocketMessageTypeBoardMembers, &BoardMembersPayload{BoardId: boardId, Members: members})
+		}
+	}()
+}
+
+// removeFromRoom takes the connection out of the board's room and its presentation, dropping the room once
+// empty, and returns the members left
+func (h *Hub) removeFromRoom(client *Client, boardId string) ([]BoardMember, []*Client) {
+	h.roomsMu.Lock()
+	defer h.roomsMu.Unlock()
+	room, ok := h.rooms[boardId]
+	if !ok || room.members[client.ID] == nil {
+		return nil, nil
+	}
+	delete(room.members, client.ID)
+	delete(room.followers, client.ID)
+	if len(room.members) == 0 {
+		delete(h.rooms, boardId)
+		return nil, nil
+	}
+	return roomMembers(room, "")
+}
+
+// HasBoardRoom reports whether any connection is viewing the board, so senders can skip building messages
+func (h *Hub) HasBoardRoom(boardId string) bool {
+	h.roomsMu.RLock()
+	defer h.roomsMu.RUnlock()
+	room, ok := h.rooms[boardId]
+	return ok && len(room.members) > 0
+}
+
+// SendToBoard sends a message to every connection viewing the board but exceptClientID, the one the change came from
+func (h *Hub) SendToBoard(boardId string, message []byte, exceptClientID string) {
+	h.roomsMu.RLock()
+	room, ok := h.rooms[boardId]
+	var others []*Client
+	if ok {
+		_, others = roomMembers(room, exceptClientID)
+	}
+	h.roomsMu.RUnlock()
+	for _, client := range others {
+		h.send(client, message, outboundMessage)
+	}
+}
+
+// clientRoomCount counts the rooms the connection is in; call with roomsMu held
+func (h *Hub) clientRoomCount(client *Client) int {
+	count := 0
+	for _, room := range h.rooms {
+		if room.members[client.ID] != nil {
+			count++
+		}
+	}
+	return count
+}
+
+// roomMembers lists a room's members, sorted by connection id, and its connections but exceptClientID; call
+// with roomsMu held
+func roomMembers(room *boardRoom, exceptClientID string) ([]BoardMember, []*Client) {
+	members := make([]BoardMember, 0, len(room.members))
+	others := make([]*Client, 0, len(room.members))
+	for id, client := range room.members {
+		members = append(members, BoardMember{ClientId: id, UserId: client.UserID})
+		if id != exceptClientID {
+			others = append(others, client)
+		}
+	}
+	sort.Slice(members, func(i, j int) bool {
+		return members[i].ClientId < members[j].ClientId
+	})
+	return members, others
+}
+
+func (h *Hub) sendRoomMessage(clients []*Client, messageType WebSocketMessageType, data interface{}) {
+	if len(clients) == 0 {
+		return
+	}
+	msg, err := json.Marshal(WebSocketMessage{Type: messageType, Data: data})
+	if err != nil {
+		log.Println("failed to marshal board room message:", err)
+		return
+	}
+	for _, client := range clients {
+		h.SendMessage(client, msg)
+	}
+}
+
+// SendBoardShapeChanges sends the shapes an HTTP save created, updated or deleted to the connections viewing
+// the board, except the one the save came from
+func SendBoardShapeChanges(hub *Hub, boardId string, exceptClientID string, created []map[string]interface{}, updated []map[string]interface{}, deleted []string) {
+	send := func(messageType WebSocketMessageType, data interface{}) {
+		msg, err := json.Marshal(WebSocketMessage{Type: messageType, Data: data})
+		if err != nil {
+			log.Println("failed to marshal board shape change:", err)
+			return
+		}
+		hub.SendToBoard(boardId, msg, exceptClientID)
+	}
+	for _, shape := range created {
+		send(WebSocketMessageTypeShapeCreated, &ShapeCreatedPayload{BoardId: boardId, Shape: shape})
+	}
+	for _, shape := range updated {
+		send(WebSocketMessageTypeShapeUpdated, &ShapeUpdatedPayload{BoardId: boardId, Shape: shape})
+	}
+	for _, shapeId := range deleted {
+		send(WebSocketMessageTypeShapeDeleted, &ShapeDeletedPayload{BoardId: boardId, ShapeId: shapeId})
+	}
+}
@@ -0,0 +1,95 @@
+package libraries
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestBoardRoomsShareShapeChanges(t *testing.T) {
+	const board = "0b6f4f3e-3c1e-4f7a-9d0e-5b8a7c6d5e4f"
+	hub := NewHub()
+	owner := &Client{ID: "owner", UserID: "alice", Send: make(chan []byte, 16)}
+	viewer := &Client{ID: "viewer", UserID: "bob", Send: make(chan []byte, 16)}
+	stranger := &Client{ID: "stranger", UserID: "eve", Send: make(chan []byte, 16)}
+	next := func(client *Client) WebSocketMessage {
+		t.Helper()
+		select {
+		case msg := <-client.Send:
+			var decoded WebSocketMessage
+			if err := json.Unmarshal(msg, &decoded); err != nil {
+				t.Fatalf("invalid message: %v", err)
+			}
+			return decoded
+		case <-time.After(200 * time.Millisecond):
+			return WebSocketMessage{}
+		}
+	}
+
+	if err := hub.JoinBoard(owner, board); err == nil {
+		t.Fatal("expected joins to be refused until the access check is set")
+	}
+	hub.SetBoardAccess(func(userID string, boardId string) bool {
+		return userID != "eve"
+	})
+	if err := hub.JoinBoard(stranger, board); err == nil {
+		t.Fatal("expected a user without access to be refused")
+	}
+	if err := hub.JoinBoard(owner, "not-a-board"); err == nil {
+		t.Fatal("expected an invalid board id to be refused")
+	}
+
+	if err := hub.JoinBoard(owner, board); err != nil {
+		t.Fatal(err)
+	}
+	if got := next(owner); got.Type != WebSocketMessageTypeBoardJoined {
+		t.Fatalf("expected a join confirmation, got %q", got.Type)
+	}
+	if err := hub.JoinBoard(viewer, board); err != nil {
+		t.Fatal(err)
+	}
+	joined := next(viewer)
+	if data, _ := joined.Data.(map[string]interface{}); joined.Type != WebSocketMessageTypeBoardJoined || data["client_id"] != "viewer" {
+		t.Fatalf("expected the confirmation to carry the connection's id, got %+v", joined)
+	}
+	if got := next(owner); got.Type != WebSocketMessageTypeBoardMembers {
+		t.Fatalf("expected the owner to be told of the new viewer, got %q", got.Type)
+	}
+
+	// the agent's changes reach the connection of the turn once and every other viewer
+	SendShapeCreatedMessage(hub, owner, board, map[string]interface{}{"id": "shape"})
+	if got := next(owner); got.Type != WebSocketMessageTypeShapeCreated {
+		t.Errorf("expected the turn's connection to get the shape, got %q", got.Type)
+	}
+	if got := next(owner); got.Type != "" {
+		t.Errorf("expected no echo to the turn's connection, got %q", got.Type)
+	}
+	if got := next(viewer); got.Type != WebSocketMessageTypeShapeCreated {
+		t.Errorf("expected the viewer to get the shape, got %q", got.Type)
+	}
+
+	// an HTTP save skips the connection it came from
+	SendBoardShapeChanges(hub, board, "viewer", nil, nil, []string{"shape"})
+	if got := next(owner); got.Type != WebSocketMessageTypeShapeDeleted {
+		t.Errorf("expected the owner to get the deletion, got %q", got.Type)
+	}
+	if got := next(viewer); got.Type != "" {
+		t.Errorf("expected the saving connection to be skipped, got %q", got.Type)
+	}
+
+	hub.LeaveBoard(viewer, board)
+	if got := next(viewer); got.Type != WebSocketMessageTypeBoardLeft {
+		t.Errorf("expected a leave confirmation, got %q", got.Type)
+	}
+	next(owner)
+	SendShapeDeletedMessage(hub, owner, board, "shape")
+	next(owner)
+	if got := next(viewer); got.Type != "" {
+		t.Errorf("expected no changes after leaving, got %q", got.Type)
+	}
+
+	hub.LeaveBoard(owner, board)
+	if hub.HasBoardRoom(board) {
+		t.Error("expected the room to be dropped once empty")
+	}
+}
@@ -0,0 +1,30 @@
+package libraries
+
+import (
+	"encoding/json"
+	"log"
+)
+
+// WebSocketMessageTypeBoardRestored tells the user's connections a board was rolled back to a snapshot
+const WebSocketMessageTypeBoardRestored WebSocketMessageType = "board_restored"
+
+// BoardRestoredPayload is the snapshot a board was restored to. Every shape may have changed, so the client
+// should reload the whole board and drop its unsaved changes
+type BoardRestoredPayload struct {
+	BoardId    string `json:"board_id"`
+	VersionId  string `json:"version_id"`
+	ShapeCount int    `json:"shape_count"`
+}
+
+// SendBoardRestoredMessage tells every connection of the board's owner to reload the board
+func SendBoardRestoredMessage(hub *Hub, userID string, payload *BoardRestoredPayload) {
+	msg, err := json.Marshal(WebSocketMessage{
+		Type: WebSocketMessageTypeBoardRestored,
+		Data: payload,
+	})
+	if err != nil {
+		log.Println("failed to marshal board restored message:", err)
+		return
+	}
+	hub.SendToUser(userID, msg)
+}
@@ -0,0 +1,52 @@
+package libraries
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"fmt"
+)
+
+// ErrCredentialsUnreadable is returned when stored credentials can't be decrypted, e.g. after the key changed
+var ErrCredentialsUnreadable = errors.New("stored credentials can't be decrypted")
+
+// SealCredentials encrypts credentials with AES-256-GCM and returns the nonce and ciphertext base64 encoded
+func SealCredentials(key []byte, plaintext []byte) (string, error) {
+	gcm, err := credentialsCipher(key)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", fmt.Errorf("generate nonce: %w", err)
+	}
+	sealed := gcm.Seal(nonce, nonce, plaintext, nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+// OpenCredentials decrypts credentials sealed by SealCredentials
+func OpenCredentials(key []byte, sealed string) ([]byte, error) {
+	gcm, err := credentialsCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	data, err := base64.StdEncoding.DecodeString(sealed)
+	if err != nil || len(data) < gcm.NonceSize() {
+		return nil, ErrCredentialsUnreadable
+	}
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return nil, ErrCredentialsUnreadable
+	}
+	return plaintext, nil
+}
+
+func credentialsCipher(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, fmt.Errorf("credentials key: %w", err)
+	}
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,40 @@
+package libraries
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestSealCredentialsRoundTrip(t *testing.T) {
+	key := bytes.Repeat([]byte{7}, 32)
+	secret := []byte(`{"access_key_id":"AKIA","secret_access_key":"shh"}`)
+
+	sealed, err := SealCredentials(key, secret)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if bytes.Contains([]byte(sealed), []byte("shh")) {
+		t.Fatalf("expected the credentials to be encrypted, got %s", sealed)
+	}
+	again, _ := SealCredentials(key, secret)
+	if again == sealed {
+		t.Error("expected a fresh nonce for every seal")
+	}
+
+	opened, err := OpenCredentials(key, sealed)
+	if err != nil || !bytes.Equal(opened, secret) {
+		t.Fatalf("expected the credentials back, got %q (%v)", opened, err)
+	}
+
+	otherKey := bytes.Repeat([]byte{8}, 32)
+	if _, err := OpenCredentials(otherKey, sealed); !errors.Is(err, ErrCredentialsUnreadable) {
+		t.Errorf("expected another key to fail, got %v", err)
+	}
+	if _, err := OpenCredentials(key, sealed[:len(sealed)-4]+"AAAA"); !errors.Is(err, ErrCredentialsUnreadable) {
+		t.Errorf("expected tampered credentials to fail, got %v", err)
+	}
+	if _, err := SealCredentials([]byte("short"), secret); err == nil {
+		t.Error("expected a short key to be rejected")
+	}
+}
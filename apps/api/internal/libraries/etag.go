@@ -0,0 +1,41 @@
+package libraries
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strings"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+// VersionETag builds a weak ETag from the values that identify a version of a response, such as row counts
+// and update times, so unchanged data doesn't have to be loaded to answer a conditional request
+func VersionETag(parts ...interface{}) string {
+	h := sha256.New()
+	for _, part := range parts {
+		fmt.Fprintf(h, "%v|", part)
+	}
+	return `W/"` + hex.EncodeToString(h.Sum(nil)[:12]) + `"`
+}
+
+// NotModified sets the ETag of the response and reports whether the client's If-None-Match already has it,
+// in which case the handler answers 304 without a body
+func NotModified(c *fiber.Ctx, etag string) bool {
+	c.Set(fiber.HeaderETag, etag)
+	// no-store would keep browsers from keeping the body to revalidate; no-cache still asks every time
+	c.Set(fiber.HeaderCacheControl, "private, no-cache")
+
+	ifNoneMatch := c.Get(fiber.HeaderIfNoneMatch)
+	if ifNoneMatch == "" {
+		return false
+	}
+	for _, candidate := range strings.Split(ifNoneMatch, ",") {
+		candidate = strings.TrimSpace(candidate)
+		// If-None-Match uses the weak comparison
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == strings.TrimPrefix(etag, "W/") {
+			return true
+		}
+	}
+	return false
+}
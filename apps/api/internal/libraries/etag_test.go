@@ -0,0 +1,69 @@
+package libraries
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+)
+
+func TestVersionETag(t *testing.T) {
+	updated := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC).UnixNano()
+	etag := VersionETag("board", updated, 12)
+	if !strings.HasPrefix(etag, `W/"`) || !strings.HasSuffix(etag, `"`) || len(etag) != len(`W/""`)+24 {
+		t.Fatalf("unexpected ETag %q", etag)
+	}
+	if VersionETag("board", updated, 12) != etag {
+		t.Error("expected the same version to give the same ETag")
+	}
+	for _, other := range []string{VersionETag("board", updated, 13), VersionETag("board", updated+1, 12), VersionETag("board", updated)} {
+		if other == etag {
+			t.Errorf("expected a changed version to change the ETag, got %q twice", etag)
+		}
+	}
+	// the separator keeps parts from running into each other
+	if VersionETag("ab", "c") == VersionETag("a", "bc") {
+		t.Error("expected differently split parts to give different ETags")
+	}
+}
+
+func TestNotModified(t *testing.T) {
+	etag := VersionETag("board", 1)
+	app := fiber.New()
+	app.Get("/", func(c *fiber.Ctx) error {
+		if NotModified(c, etag) {
+			return c.SendStatus(fiber.StatusNotModified)
+		}
+		return c.SendString("board")
+	})
+
+	cases := []struct {
+		ifNoneMatch string
+		want        int
+	}{
+		{"", fiber.StatusOK},
+		{etag, fiber.StatusNotModified},
+		{strings.TrimPrefix(etag, "W/"), fiber.StatusNotModified},
+		{`W/"stale", ` + etag, fiber.StatusNotModified},
+		{"*", fiber.StatusNotModified},
+		{`W/"stale"`, fiber.StatusOK},
+	}
+	for _, tc := range cases {
+		req := httptest.NewRequest("GET", "/", nil)
+		if tc.ifNoneMatch != "" {
+			req.Header.Set(fiber.HeaderIfNoneMatch, tc.ifNoneMatch)
+		}
+		resp, err := app.Test(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if resp.StatusCode != tc.want {
+			t.Errorf("If-None-Match %q: got %d, want %d", tc.ifNoneMatch, resp.StatusCode, tc.want)
+		}
+		if resp.Header.Get(fiber.HeaderETag) != etag || resp.Header.Get(fiber.HeaderCacheControl) != "private, no-cache" {
+			t.Errorf("If-None-Match %q: expected the ETag and cache headers, got %v", tc.ifNoneMatch, resp.Header)
+		}
+	}
+}
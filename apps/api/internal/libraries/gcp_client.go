@@ -2,9 +2,8 @@ package libraries
 
 import (
 	"context"
-	"encoding/base64"
 	"fmt"
-	"os"
+	"melina-studio-backend/internal/config"
 
 	aiplatform "cloud.google.com/go/aiplatform/apiv1"
 	"cloud.google.com/go/storage"
@@ -25,16 +24,11 @@ func GetClients() *Clients {
 }
 
 func NewClients(ctx context.Context) (*Clients, error) {
-	// read base64 encoded JSON
-	encoded := os.Getenv("GCP_SERVICE_ACCOUNT_CREDENTIALS")
-	if encoded == "" {
-		return nil, fmt.Errorf("GCP_SERVICE_ACCOUNT_CREDENTIALS not set")
-	}
-
-	// decode JSON
-	decoded, err := base64.StdEncoding.DecodeString(encoded)
+	// read the service account JSON (raw or base64 encoded)
+	settings := config.GetSettings().GCP
+	decoded, err := settings.ServiceAccountJSON()
 	if err != nil {
-		return nil, fmt.Errorf("failed to decode service account json: %w", err)
+		return nil, err
 	}
 
 	// parse JSON
@@ -55,8 +49,8 @@ func NewClients(ctx context.Context) (*Clients, error) {
 	clients = &Clients{
 		GCS:          gcsClient,
 		Vertex:       vertexClient,
-		ProjectID:    os.Getenv("GOOGLE_CLOUD_PROJECT_ID"),
-		VertexRegion: os.Getenv("GOOGLE_CLOUD_VERTEXAI_LOCATION"),
+		ProjectID:    settings.ProjectID,
+		VertexRegion: settings.VertexRegion,
 	}
 
 	return clients, nil
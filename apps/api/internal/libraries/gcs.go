@@ -2,13 +2,31 @@ package libraries
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
 	"io"
-	"os"
+	"melina-studio-backend/internal/config"
 
 	"cloud.google.com/go/storage"
+	"google.golang.org/api/iterator"
+	"google.golang.org/api/option"
 )
 
+type storageBucketKey struct{}
+
+// WithStorageBucket makes Upload and Remove use another bucket than GCP_STORAGE_BUCKET, e.g. a tenant's own
+func WithStorageBucket(ctx context.Context, bucket string) context.Context {
+	return context.WithValue(ctx, storageBucketKey{}, bucket)
+}
+
+// StorageBucket returns the bucket set on the context, or else GCP_STORAGE_BUCKET
+func StorageBucket(ctx context.Context) string {
+	if bucket, ok := ctx.Value(storageBucketKey{}).(string); ok && bucket != "" {
+		return bucket
+	}
+	return config.GetSettings().GCP.StorageBucket
+}
+
 // Upload uploads a file to GCS at bucket/key
 func (c *Clients) Upload(
 	ctx context.Context,
@@ -16,7 +34,7 @@ func (c *Clients) Upload(
 	reader io.Reader,
 	contentType string,
 ) (string, error) {
-	bucket := os.Getenv("GCP_STORAGE_BUCKET")
+	bucket := StorageBucket(ctx)
 	if bucket == "" {
 		return "", fmt.Errorf("GCP_STORAGE_BUCKET environment variable is not set")
 	}
@@ -40,7 +58,7 @@ func (c *Clients) Upload(
 	}
 
 	// Public, non-expiring URL
-	base_url := os.Getenv("GCS_BASE_URL")
+	base_url := config.GetSettings().GCP.GCSBaseURL
 	publicURL := fmt.Sprintf("%s/%s/%s", base_url, bucket, objectKey)
 
 	return publicURL, nil
@@ -67,7 +85,7 @@ func (c *Clients) Remove(
 	ctx context.Context,
 	objectKey string,
 ) error {
-	bucket := os.Getenv("GCP_STORAGE_BUCKET")
+	bucket := StorageBucket(ctx)
 	obj := c.GCS.Bucket(bucket).Object(objectKey)
 
 	if err := obj.Delete(ctx); err != nil {
@@ -76,3 +94,138 @@ func (c *Clients) Remove(
 
 	return nil
 }
+
+// WriteObject writes a private (non-public) object to the given bucket
+func (c *Clients) WriteObject(
+	ctx context.Context,
+	bucket string,
+	objectKey string,
+	reader io.Reader,
+	contentType string,
+) error {
+	writer := c.GCS.Bucket(bucket).Object(objectKey).NewWriter(ctx)
+	writer.ContentType = contentType
+
+	if _, err := io.Copy(writer, reader); err != nil {
+		_ = writer.Close()
+		return fmt.Errorf("gcs write failed: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("gcs write close failed: %w", err)
+	}
+	return nil
+}
+
+// ReadObject reads a whole object from the given bucket
+func (c *Clients) ReadObject(
+	ctx context.Context,
+	bucket string,
+	objectKey string,
+) ([]byte, error) {
+	reader, err := c.GCS.Bucket(bucket).Object(objectKey).NewReader(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("gcs read failed: %w", err)
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, fmt.Errorf("gcs read failed: %w", err)
+	}
+	return data, nil
+}
+
+// CopyObject copies an object server-side, possibly across buckets
+func (c *Clients) CopyObject(
+	ctx context.Context,
+	srcBucket string,
+	srcKey string,
+	dstBucket string,
+	dstKey string,
+) error {
+	src := c.GCS.Bucket(srcBucket).Object(srcKey)
+	dst := c.GCS.Bucket(dstBucket).Object(dstKey)
+	if _, err := dst.CopierFrom(src).Run(ctx); err != nil {
+		return fmt.Errorf("gcs copy failed: %w", err)
+	}
+	return nil
+}
+
+// ListPrefixes returns the "directories" directly under prefix in the given bucket
+func (c *Clients) ListPrefixes(
+	ctx context.Context,
+	bucket string,
+	prefix string,
+) ([]string, error) {
+	it := c.GCS.Bucket(bucket).Objects(ctx, &storage.Query{Prefix: prefix, Delimiter: "/"})
+	var prefixes []string
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("gcs list failed: %w", err)
+		}
+		if attrs.Prefix != "" {
+			prefixes = append(prefixes, attrs.Prefix)
+		}
+	}
+	return prefixes, nil
+}
+
+// RemovePrefix deletes every object under prefix in the given bucket
+func (c *Clients) RemovePrefix(
+	ctx context.Context,
+	bucket string,
+	prefix string,
+) error {
+	it := c.GCS.Bucket(bucket).Objects(ctx, &storage.Query{Prefix: prefix})
+	for {
+		attrs, err := it.Next()
+		if err == iterator.Done {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("gcs list failed: %w", err)
+		}
+		if err := c.GCS.Bucket(bucket).Object(attrs.Name).Delete(ctx); err != nil {
+			return fmt.Errorf("gcs delete failed: %w", err)
+		}
+	}
+}
+
+// WriteObjectAs writes a private object to a bucket with another service account than the server's, e.g. one a
+// user gave for their own bucket. Only service account keys are accepted: other credential types can make the
+// client read local files or call arbitrary URLs
+func WriteObjectAs(
+	ctx context.Context,
+	serviceAccountJSON []byte,
+	bucket string,
+	objectKey string,
+	reader io.Reader,
+	contentType string,
+) error {
+	var key struct {
+		Type string `json:"type"`
+	}
+	if err := json.Unmarshal(serviceAccountJSON, &key); err != nil || key.Type != "service_account" {
+		return fmt.Errorf("credentials must be a service account key")
+	}
+	client, err := storage.NewClient(ctx, option.WithCredentialsJSON(serviceAccountJSON))
+	if err != nil {
+		return fmt.Errorf("storage.NewClient: %w", err)
+	}
+	defer client.Close()
+
+	writer := client.Bucket(bucket).Object(objectKey).NewWriter(ctx)
+	writer.ContentType = contentType
+	if _, err := io.Copy(writer, reader); err != nil {
+		_ = writer.Close()
+		return fmt.Errorf("gcs write failed: %w", err)
+	}
+	if err := writer.Close(); err != nil {
+		return fmt.Errorf("gcs write close failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,34 @@
+package libraries
+
+import (
+	"encoding/json"
+	"log"
+)
+
+// WebSocketMessageTypeInboundEmail tells the user's connections an email arrived on one of their boards
+const WebSocketMessageTypeInboundEmail WebSocketMessageType = "inbound_email"
+
+// InboundEmailPayload is an email turned into a note, or the summary the agent added next to it (Summary).
+// The client should reload the ChangedShapes, or the whole board with ReloadBoard, before its next save
+type InboundEmailPayload struct {
+	BoardId       string   `json:"board_id"`
+	From          string   `json:"from"`
+	Subject       string   `json:"subject"`
+	Summary       bool     `json:"summary,omitempty"`
+	Error         string   `json:"error,omitempty"`
+	ChangedShapes []string `json:"changed_shapes,omitempty"`
+	ReloadBoard   bool     `json:"reload_board,omitempty"`
+}
+
+// SendInboundEmailMessage tells every connection of the board's owner that an email was added
+func SendInboundEmailMessage(hub *Hub, userID string, payload *InboundEmailPayload) {
+	msg, err := json.Marshal(WebSocketMessage{
+		Type: WebSocketMessageTypeInboundEmail,
+		Data: payload,
+	})
+	if err != nil {
+		log.Println("failed to marshal inbound email message:", err)
+		return
+	}
+	hub.SendToUser(userID, msg)
+}
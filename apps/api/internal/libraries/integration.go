@@ -0,0 +1,30 @@
+package libraries
+
+import (
+	"encoding/json"
+	"log"
+)
+
+// WebSocketMessageTypeIntegrationAction tells the user's connections an integration (Zapier, Make) changed a board
+const WebSocketMessageTypeIntegrationAction WebSocketMessageType = "integration_action"
+
+// IntegrationActionPayload is what an integration action did. The client should reload the ChangedShapes
+// before its next save, which would otherwise drop them
+type IntegrationActionPayload struct {
+	BoardId       string   `json:"board_id"`
+	Action        string   `json:"action"`
+	ChangedShapes []string `json:"changed_shapes,omitempty"`
+}
+
+// SendIntegrationActionMessage tells every connection of the board's owner that an integration changed the board
+func SendIntegrationActionMessage(hub *Hub, userID string, payload *IntegrationActionPayload) {
+	msg, err := json.Marshal(WebSocketMessage{
+		Type: WebSocketMessageTypeIntegrationAction,
+		Data: payload,
+	})
+	if err != nil {
+		log.Println("failed to marshal integration action message:", err)
+		return
+	}
+	hub.SendToUser(userID, msg)
+}
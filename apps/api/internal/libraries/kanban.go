@@ -0,0 +1,31 @@
+package libraries
+
+import (
+	"encoding/json"
+	"log"
+)
+
+// WebSocketMessageTypeCardStatus tells the user's connections a kanban card moved to another column and took its status
+const WebSocketMessageTypeCardStatus WebSocketMessageType = "card_status_changed"
+
+// CardStatusPayload is a card's new status and the column frame that gave it
+type CardStatusPayload struct {
+	BoardId        string `json:"board_id"`
+	ShapeId        string `json:"shape_id"`
+	ColumnId       string `json:"column_id"`
+	Status         string `json:"status"`
+	PreviousStatus string `json:"previous_status"`
+}
+
+// SendCardStatusMessage tells every connection of the board's owner that a card's status changed
+func SendCardStatusMessage(hub *Hub, userID string, payload *CardStatusPayload) {
+	msg, err := json.Marshal(WebSocketMessage{
+		Type: WebSocketMessageTypeCardStatus,
+		Data: payload,
+	})
+	if err != nil {
+		log.Println("failed to marshal card status message:", err)
+		return
+	}
+	hub.SendToUser(userID, msg)
+}
@@ -0,0 +1,58 @@
+package libraries
+
+import (
+	"errors"
+	"fmt"
+	"melina-studio-backend/internal/config"
+	"net"
+	"net/mail"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrEmailDisabled is returned when no SMTP server is configured
+var ErrEmailDisabled = errors.New("email is not configured")
+
+// EmailEnabled reports whether an SMTP server is configured
+func EmailEnabled() bool {
+	return config.GetSettings().Email.SMTPHost != ""
+}
+
+// SendEmail sends a plain text email through the configured SMTP server
+func SendEmail(to []string, subject string, body string) error {
+	settings := config.GetSettings().Email
+	if settings.SMTPHost == "" {
+		return ErrEmailDisabled
+	}
+	if len(to) == 0 {
+		return fmt.Errorf("no recipients")
+	}
+
+	from, err := mail.ParseAddress(settings.From)
+	if err != nil {
+		return fmt.Errorf("invalid EMAIL_FROM: %w", err)
+	}
+
+	var auth smtp.Auth
+	if settings.SMTPUsername != "" {
+		auth = smtp.PlainAuth("", settings.SMTPUsername, settings.SMTPPassword, settings.SMTPHost)
+	}
+
+	addr := net.JoinHostPort(settings.SMTPHost, strconv.Itoa(settings.SMTPPort))
+	return smtp.SendMail(addr, auth, from.Address, to, buildEmail(from.String(), to, subject, body, time.Now()))
+}
+
+func buildEmail(from string, to []string, subject string, body string, now time.Time) []byte {
+	var sb strings.Builder
+	sb.WriteString("From: " + from + "\r\n")
+	sb.WriteString("To: " + strings.Join(to, ", ") + "\r\n")
+	sb.WriteString("Subject: " + strings.NewReplacer("\r", "", "\n", " ").Replace(subject) + "\r\n")
+	sb.WriteString("Date: " + now.Format(time.RFC1123Z) + "\r\n")
+	sb.WriteString("MIME-Version: 1.0\r\n")
+	sb.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	sb.WriteString("\r\n")
+	sb.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return []byte(sb.String())
+}
@@ -0,0 +1,108 @@
+package libraries
+
+import (
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+const (
+	// a construct that stays open longer than this is flushed anyway, so a long code block still streams
+	markdownMaxHold  = 2 * time.Second
+	markdownMaxChars = 4000
+)
+
+// markdownChunker finds split points in streamed markdown that don't cut through code fences,
+// links, inline code or table rows, so the client never renders half of a construct
+type markdownChunker struct {
+	inFence bool
+	// partial is the emitted part of the line that is still streaming
+	partial string
+}
+
+// split returns how many bytes of text can be emitted without breaking markdown; 0 means hold it all
+func (m *markdownChunker) split(text string) int {
+	full := m.partial + text
+	inFence := m.inFence
+	safe := 0
+	lineStart := 0
+	for lineStart < len(full) {
+		nl := strings.IndexByte(full[lineStart:], '\n')
+		if nl < 0 {
+			// the last line is still streaming
+			if !inFence {
+				if s := safeInLine(full[lineStart:]); s > 0 {
+					safe = lineStart + s
+				}
+			}
+			break
+		}
+		lineEnd := lineStart + nl + 1
+		if isFenceLine(full[lineStart : lineStart+nl]) {
+			inFence = !inFence
+		}
+		if !inFence {
+			safe = lineEnd
+		}
+		lineStart = lineEnd
+	}
+	if safe <= len(m.partial) {
+		return 0
+	}
+	return safe - len(m.partial)
+}
+
+// advance records emitted text so the fence state carries over to the next chunk
+func (m *markdownChunker) advance(emitted string) {
+	full := m.partial + emitted
+	for {
+		nl := strings.IndexByte(full, '\n')
+		if nl < 0 {
+			break
+		}
+		if isFenceLine(full[:nl]) {
+			m.inFence = !m.inFence
+		}
+		full = full[nl+1:]
+	}
+	m.partial = full
+}
+
+func isFenceLine(line string) bool {
+	trimmed := strings.TrimLeft(line, " \t")
+	return strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~")
+}
+
+// safeInLine returns the offset after the last whitespace of an unfinished line that is outside
+// links and inline code; table rows and possible fence openers are only split at their newline
+func safeInLine(line string) int {
+	trimmed := strings.TrimLeft(line, " \t")
+	if strings.HasPrefix(trimmed, "|") || strings.HasPrefix(trimmed, "`") || strings.HasPrefix(trimmed, "~") {
+		return 0
+	}
+
+	safe := 0
+	brackets := 0
+	inURL, inCode := false, false
+	for i, r := range line {
+		switch {
+		case r == '`':
+			inCode = !inCode
+		case inCode:
+		case r == '[':
+			brackets++
+		case r == ']' && brackets > 0:
+			brackets--
+			if i+1 < len(line) && line[i+1] == '(' {
+				inURL = true
+			}
+		case r == ')' && inURL:
+			inURL = false
+		}
+		if unicode.IsSpace(r) && brackets == 0 && !inURL && !inCode {
+			safe = i + utf8.RuneLen(r)
+		}
+	}
+	return safe
+}
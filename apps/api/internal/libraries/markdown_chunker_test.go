@@ -0,0 +1,42 @@
+package libraries
+
+import "testing"
+
+func TestMarkdownChunkerSplit(t *testing.T) {
+	cases := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"plain words", "Here is the plan for", "Here is the plan "},
+		{"open link", "See the [design doc](https://exa", "See the "},
+		{"closed link", "See the [design doc](https://example.com) for", "See the [design doc](https://example.com) "},
+		{"inline code", "Run `go test ./...", "Run "},
+		{"table row", "| a | b |\n| 1 | 2", "| a | b |\n"},
+		{"open fence", "Code:\n```go\nfunc main() {\n", "Code:\n"},
+		{"closed fence", "```go\nx := 1\n```\nDone and", "```go\nx := 1\n```\nDone "},
+		{"fence opener in progress", "Code:\n``", "Code:\n"},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			m := &markdownChunker{}
+			if got := c.text[:m.split(c.text)]; got != c.want {
+				t.Errorf("split(%q) emits %q, want %q", c.text, got, c.want)
+			}
+		})
+	}
+}
+
+func TestMarkdownChunkerCarriesFenceState(t *testing.T) {
+	m := &markdownChunker{}
+	// a forced flush in the middle of a code block
+	m.advance("```py\nprint(")
+	if n := m.split("1)\nprint(2)\n"); n != 0 {
+		t.Errorf("split inside an open fence = %d, want 0", n)
+	}
+	m.advance("1)\n")
+	rest := "print(2)\n```\nAfter the"
+	if got := rest[:m.split(rest)]; got != "print(2)\n```\nAfter " {
+		t.Errorf("after the fence closes emits %q", got)
+	}
+}
@@ -0,0 +1,174 @@
+package libraries
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"melina-studio-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// WebSocketMessageTypePresentationJoin makes a connection follow the presentation of a board
+	WebSocketMessageTypePresentationJoin WebSocketMessageType = "presentation_join"
+	// WebSocketMessageTypePresentationLeave stops following it
+	WebSocketMessageTypePresentationLeave WebSocketMessageType = "presentation_leave"
+	// WebSocketMessageTypePresentationNavigate is the presenter moving to a slide
+	WebSocketMessageTypePresentationNavigate WebSocketMessageType = "presentation_navigate"
+	// WebSocketMessageTypePresentationEnd is the presenter stopping the presentation
+	WebSocketMessageTypePresentationEnd WebSocketMessageType = "presentation_end"
+	// WebSocketMessageTypePresentationSlide tells followers which slide to show
+	WebSocketMessageTypePresentationSlide WebSocketMessageType = "presentation_slide"
+	// WebSocketMessageTypePresentationEnded tells followers the presentation is over
+	WebSocketMessageTypePresentationEnded WebSocketMessageType = "presentation_ended"
+	// WebSocketMessageTypePresentationUpdated tells the user's connections the slide order changed
+	WebSocketMessageTypePresentationUpdated WebSocketMessageType = "presentation_updated"
+)
+
+// PresentationPayload names the board of a join, leave or end message
+type PresentationPayload struct {
+	BoardId string `json:"board_id"`
+}
+
+// PresentationSlidePayload is the slide the presenter shows; the server fills in PresenterId
+type PresentationSlidePayload struct {
+	BoardId     string `json:"board_id"`
+	Slide       int    `json:"slide"`
+	FrameId     string `json:"frame_id"`
+	PresenterId string `json:"presenter_id"`
+	// Viewport is the presenter's camera, so followers see exactly what they see
+	Viewport *models.Viewport `json:"viewport,omitempty"`
+}
+
+// PresentationUpdatedPayload is the new slide order of a board
+type PresentationUpdatedPayload struct {
+	BoardId  string      `json:"board_id"`
+	FrameIds []uuid.UUID `json:"frame_ids"`
+}
+
+// JoinPresentation makes the connection follow the board's presentation, joining the board's room first if it
+// hasn't, and sends it the current slide
+func (h *Hub) JoinPresentation(client *Client, boardId string) error {
+	if err := h.joinBoardRoom(client, boardId); err != nil {
+		return err
+	}
+	h.roomsMu.Lock()
+	room, ok := h.rooms[boardId]
+	var current *PresentationSlidePayload
+	if ok && room.members[client.ID] != nil {
+		room.followers[client.ID] = true
+		current = room.slide
+	}
+	h.roomsMu.Unlock()
+	if !ok {
+		return errors.New("board not found")
+	}
+
+	if current != nil {
+		sendPresentationMessage(h, []*Client{client}, WebSocketMessageTypePresentationSlide, current)
+	}
+	return nil
+}
+
+// LeavePresentation stops the connection following the board's presentation; it stays in the board's room
+func (h *Hub) LeavePresentation(client *Client, boardId string) {
+	h.roomsMu.Lock()
+	defer h.roomsMu.Unlock()
+	if room, ok := h.rooms[boardId]; ok {
+		delete(room.followers, client.ID)
+	}
+}
+
+// NavigatePresentation moves the board's followers to the presenter's slide; the presenter joins the board's
+// room and follows the presentation if it hasn't
+func (h *Hub) NavigatePresentation(presenter *Client, slide *PresentationSlidePayload) error {
+	if slide.BoardId == "" {
+		return errors.New("board_id is required")
+	}
+	if slide.Slide < 0 {
+		return errors.New("slide must not be negative")
+	}
+	slide.PresenterId = presenter.ID
+	if err := h.joinBoardRoom(presenter, slide.BoardId); err != nil {
+		return err
+	}
+
+	h.roomsMu.Lock()
+	room, ok := h.rooms[slide.BoardId]
+	var followers []*Client
+	if ok && room.members[presenter.ID] != nil {
+		room.followers[presenter.ID] = true
+		room.slide = slide
+		followers = room.presentationFollowers(presenter)
+	}
+	h.roomsMu.Unlock()
+
+	sendPresentationMessage(h, followers, WebSocketMessageTypePresentationSlide, slide)
+	return nil
+}
+
+// EndPresentation tells the board's followers the presentation is over; they keep following for the next one.
+// Only a connection in the board's room can end it
+func (h *Hub) EndPresentation(presenter *Client, boardId string) {
+	h.roomsMu.Lock()
+	room, ok := h.rooms[boardId]
+	var followers []*Client
+	if ok && room.members[presenter.ID] != nil {
+		room.slide = nil
+		followers = room.presentationFollowers(presenter)
+	}
+	h.roomsMu.Unlock()
+
+	sendPresentationMessage(h, followers, WebSocketMessageTypePresentationEnded, &PresentationPayload{BoardId: boardId})
+}
+
+// joinBoardRoom joins the board's room through JoinBoard's access check unless the connection is already in it
+func (h *Hub) joinBoardRoom(client *Client, boardId string) error {
+	h.roomsMu.RLock()
+	room, ok := h.rooms[boardId]
+	member := ok && room.members[client.ID] != nil
+	h.roomsMu.RUnlock()
+	if member {
+		return nil
+	}
+	return h.JoinBoard(client, boardId)
+}
+
+// presentationFollowers are the room's followers other than the presenter; call with roomsMu held
+func (r *boardRoom) presentationFollowers(presenter *Client) []*Client {
+	followers := make([]*Client, 0, len(r.followers))
+	for id := range r.followers {
+		if member := r.members[id]; member != nil && id != presenter.ID {
+			followers = append(followers, member)
+		}
+	}
+	return followers
+}
+
+func sendPresentationMessage(hub *Hub, clients []*Client, messageType WebSocketMessageType, data interface{}) {
+	if len(clients) == 0 {
+		return
+	}
+	msg, err := json.Marshal(WebSocketMessage{Type: messageType, Data: data})
+	if err != nil {
+		log.Println("failed to marshal presentation message:", err)
+		return
+	}
+	for _, client := range clients {
+		hub.SendMessage(client, msg)
+	}
+}
+
+// SendPresentationUpdatedMessage tells every connection of the board's owner that the slide order changed
+func SendPresentationUpdatedMessage(hub *Hub, userID string, payload *PresentationUpdatedPayload) {
+	msg, err := json.Marshal(WebSocketMessage{
+		Type: WebSocketMessageTypePresentationUpdated,
+		Data: payload,
+	})
+	if err != nil {
+		log.Println("failed to marshal presentation update:", err)
+		return
+	}
+	hub.SendToUser(userID, msg)
+}
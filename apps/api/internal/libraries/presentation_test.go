@@ -0,0 +1,86 @@
+package libraries
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestPresentationNavigationReachesFollowers(t *testing.T) {
+	const board = "6a1c2d3e-4f5a-4b6c-8d7e-9f0a1b2c3d4e"
+	hub := NewHub()
+	hub.SetBoardAccess(func(userID string, boardId string) bool {
+		return userID == "owner"
+	})
+	presenter := &Client{ID: "presenter", UserID: "owner", Send: make(chan []byte, 8)}
+	projector := &Client{ID: "projector", UserID: "owner", Send: make(chan []byte, 8)}
+	viewer := &Client{ID: "viewer", UserID: "owner", Send: make(chan []byte, 8)}
+	stranger := &Client{ID: "stranger", UserID: "someone-else", Send: make(chan []byte, 8)}
+
+	if err := hub.JoinPresentation(projector, board); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := hub.JoinPresentation(stranger, board); err == nil {
+		t.Fatal("expected a user without access to the board to be refused")
+	}
+	// viewing the board isn't following its presentation
+	if err := hub.JoinBoard(viewer, board); err != nil {
+		t.Fatal(err)
+	}
+	if err := hub.NavigatePresentation(presenter, &PresentationSlidePayload{BoardId: board, Slide: 2, FrameId: "frame"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	// slideOf skips the room's join and member messages
+	slideOf := func(client *Client) *PresentationSlidePayload {
+		for {
+			select {
+			case msg := <-client.Send:
+				var decoded struct {
+					Type WebSocketMessageType     `json:"type"`
+					Data PresentationSlidePayload `json:"data"`
+				}
+				if err := json.Unmarshal(msg, &decoded); err != nil {
+					t.Fatalf("unexpected message %s", msg)
+				}
+				if decoded.Type == WebSocketMessageTypeBoardJoined || decoded.Type == WebSocketMessageTypeBoardMembers {
+					continue
+				}
+				if decoded.Type != WebSocketMessageTypePresentationSlide {
+					t.Fatalf("unexpected message %s", msg)
+				}
+				return &decoded.Data
+			default:
+				return nil
+			}
+		}
+	}
+	if slide := slideOf(projector); slide == nil || slide.Slide != 2 || slide.PresenterId != "presenter" {
+		t.Errorf("expected the projector to follow to slide 2, got %+v", slide)
+	}
+	if slide := slideOf(presenter); slide != nil {
+		t.Errorf("the presenter must not get its own navigation, got %+v", slide)
+	}
+	if slide := slideOf(viewer); slide != nil {
+		t.Errorf("a connection only viewing the board must not follow, got %+v", slide)
+	}
+	if slide := slideOf(stranger); slide != nil {
+		t.Errorf("another user must not follow the presentation, got %+v", slide)
+	}
+
+	// a connection joining late starts at the current slide
+	late := &Client{ID: "late", UserID: "owner", Send: make(chan []byte, 8)}
+	if err := hub.JoinPresentation(late, board); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if slide := slideOf(late); slide == nil || slide.FrameId != "frame" {
+		t.Errorf("expected the late follower to get the current slide, got %+v", slide)
+	}
+
+	// leaving the board's room stops following; the presentation goes with the room
+	for _, client := range []*Client{presenter, projector, viewer, late} {
+		hub.LeaveBoard(client, board)
+	}
+	if hub.HasBoardRoom(board) {
+		t.Error("expected the room to be dropped once empty")
+	}
+}
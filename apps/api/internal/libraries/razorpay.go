@@ -5,7 +5,7 @@ import (
 	"crypto/sha256"
 	"encoding/hex"
 	"fmt"
-	"os"
+	"melina-studio-backend/internal/config"
 
 	"github.com/razorpay/razorpay-go"
 )
@@ -15,8 +15,9 @@ type RazorpayClient struct {
 }
 
 func NewRazorpayClient() *RazorpayClient {
+	settings := config.GetSettings().Payments
 	return &RazorpayClient{
-		client: razorpay.NewClient(os.Getenv("RAZORPAY_CLIENT_API_KEY"), os.Getenv("RAZORPAY_CLIENT_SECRET_KEY")),
+		client: razorpay.NewClient(settings.RazorpayKeyID, settings.RazorpayKeySecret),
 	}
 }
 
@@ -47,7 +48,7 @@ func (rc *RazorpayClient) CreateOrder(amount int, currency, receipt string, note
 // VerifyPaymentSignature verifies the payment signature from Razorpay
 // This is used to verify webhook signatures and payment completion
 func (rc *RazorpayClient) VerifyPaymentSignature(orderID, paymentID, signature string) bool {
-	secret := os.Getenv("RAZORPAY_CLIENT_SECRET_KEY")
+	secret := config.GetSettings().Payments.RazorpayKeySecret
 
 	// Create the message to verify: order_id|payment_id
 	message := orderID + "|" + paymentID
@@ -63,7 +64,7 @@ func (rc *RazorpayClient) VerifyPaymentSignature(orderID, paymentID, signature s
 
 // VerifyWebhookSignature verifies the webhook signature from Razorpay
 func (rc *RazorpayClient) VerifyWebhookSignature(body []byte, signature string) bool {
-	secret := os.Getenv("RAZORPAY_WEBHOOK_SECRET")
+	secret := config.GetSettings().Payments.RazorpayWebhookSecret
 
 	// Create HMAC SHA256 hash
 	h := hmac.New(sha256.New, []byte(secret))
@@ -94,5 +95,5 @@ func (rc *RazorpayClient) FetchOrder(orderID string) (map[string]interface{}, er
 
 // GetRazorpayKey returns the Razorpay API key (for frontend use)
 func (rc *RazorpayClient) GetRazorpayKey() string {
-	return os.Getenv("RAZORPAY_CLIENT_API_KEY")
+	return config.GetSettings().Payments.RazorpayKeyID
 }
@@ -0,0 +1,23 @@
+package libraries
+
+import (
+	"fmt"
+	"strings"
+)
+
+// S3ObjectURL returns the URL of an object: virtual-hosted on AWS, path-style on a custom endpoint (R2, MinIO, ...)
+// and for bucket names with dots, which don't match the wildcard certificate. The key is escaped for SignAWSRequest
+func S3ObjectURL(endpoint string, bucket string, region string, key string) string {
+	segments := strings.Split(key, "/")
+	for i, segment := range segments {
+		segments[i] = AWSURIEscape(segment)
+	}
+	escaped := "/" + strings.Join(segments, "/")
+	if endpoint != "" {
+		return strings.TrimSuffix(endpoint, "/") + "/" + bucket + escaped
+	}
+	if strings.Contains(bucket, ".") {
+		return fmt.Sprintf("https://s3.%s.amazonaws.com/%s%s", region, bucket, escaped)
+	}
+	return fmt.Sprintf("https://%s.s3.%s.amazonaws.com%s", bucket, region, escaped)
+}
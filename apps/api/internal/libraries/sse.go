@@ -0,0 +1,191 @@
+package libraries
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log"
+	"melina-studio-backend/internal/auth"
+	"sync"
+	"time"
+
+	"github.com/gofiber/fiber/v2"
+	"github.com/google/uuid"
+)
+
+// WebSocketMessageTypeStreamConnected is the first event of an SSE stream and carries the client id to post messages with
+const WebSocketMessageTypeStreamConnected WebSocketMessageType = "stream_connected"
+
+// sseHeartbeatInterval keeps proxies from closing an idle stream and notices clients that went away
+const sseHeartbeatInterval = 25 * time.Second
+
+// StreamConnectedPayload tells an SSE client which id to send its messages with
+type StreamConnectedPayload struct {
+	ClientId string `json:"client_id"`
+}
+
+// sseClients are the open SSE streams by client id, so POSTed messages reach the stream they belong to
+var (
+	sseClientsMu sync.RWMutex
+	sseClients   = make(map[string]*Client)
+)
+
+// SSEHandler streams the same events as the websocket as Server-Sent Events, for networks that block websockets.
+// Messages are sent with SSEMessageHandler, using the client id of the stream_connected event
+func SSEHandler(hub *Hub) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, ok := c.Locals("userID").(string)
+		if !ok || userID == "" {
+			return fiber.ErrUnauthorized
+		}
+
+		client := &Client{
+			ID:        uuid.NewString(),
+			UserID:    userID,
+			Send:      make(chan []byte, 256),
+			streamEnd: make(chan struct{}),
+		}
+
+		sseClientsMu.Lock()
+		sseClients[client.ID] = client
+		sseClientsMu.Unlock()
+		hub.Register <- client
+		// the stream keeps the token it was opened with, so it ends when that token does, like a websocket
+		if expiresAt := auth.SessionExpiresAtFromCtx(c); !expiresAt.IsZero() {
+			client.watchAuthExpiry(hub, expiresAt)
+		}
+
+		c.Set("Content-Type", "text/event-stream")
+		c.Set("Cache-Control", "no-cache")
+		c.Set("Connection", "keep-alive")
+		// nginx buffers responses by default, which would hold the stream back
+		c.Set("X-Accel-Buffering", "no")
+
+		c.Context().SetBodyStreamWriter(func(w *bufio.Writer) {
+			defer func() {
+				client.stopAuthWatch()
+				sseClientsMu.Lock()
+				delete(sseClients, client.ID)
+				sseClientsMu.Unlock()
+				hub.Unregister <- client
+			}()
+
+			connected, err := json.Marshal(WebSocketMessage{
+				Type: WebSocketMessageTypeStreamConnected,
+				Data: &StreamConnectedPayload{ClientId: client.ID},
+			})
+			if err != nil {
+				log.Println("failed to marshal stream_connected event:", err)
+				return
+			}
+			w.Write(formatSSEEvent(connected))
+			if err := w.Flush(); err != nil {
+				return
+			}
+
+			heartbeat := time.NewTicker(sseHeartbeatInterval)
+			defer heartbeat.Stop()
+
+			for {
+				select {
+				case msg, ok := <-client.Send:
+					if !ok {
+						return
+					}
+					w.Write(formatSSEEvent(msg))
+				case <-heartbeat.C:
+					w.WriteString(": ping\n\n")
+				case <-client.streamEnd:
+					log.Printf("closing sse stream of user %s: %s", client.UserID, client.streamEndReason)
+					if ended, err := json.Marshal(WebSocketMessage{
+						Type: WebSocketMessageTypeError,
+						Data: map[string]string{"error": client.streamEndReason},
+					}); err == nil {
+						w.Write(formatSSEEvent(ended))
+						w.Flush()
+					}
+					return
+				}
+				// a failed flush means the client went away
+				if err := w.Flush(); err != nil {
+					log.Println("sse write error:", err)
+					return
+				}
+			}
+		})
+		return nil
+	}
+}
+
+// SSEMessageHandler accepts the messages a websocket client would send, for the SSE stream named by client_id.
+// Replies and errors arrive on the stream, like they would on the websocket
+func SSEMessageHandler(hub *Hub, processor ChatMessageProcessor) fiber.Handler {
+	return func(c *fiber.Ctx) error {
+		userID, ok := c.Locals("userID").(string)
+		if !ok || userID == "" {
+			return fiber.ErrUnauthorized
+		}
+
+		var target struct {
+			ClientId string `json:"client_id"`
+		}
+		if err := json.Unmarshal(c.Body(), &target); err != nil || target.ClientId == "" {
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "client_id is required",
+			})
+		}
+
+		sseClientsMu.RLock()
+		client, exists := sseClients[target.ClientId]
+		sseClientsMu.RUnlock()
+		// another user's stream is reported as missing too
+		if !exists || client.UserID != userID {
+			return c.Status(fiber.StatusNotFound).JSON(fiber.Map{
+				"error": "Stream not found",
+			})
+		}
+
+		message, err := parseWebSocketMessage(c.Body())
+		if err != nil {
+			log.Println(err, "Error parsing SSE message")
+			return c.Status(fiber.StatusBadRequest).JSON(fiber.Map{
+				"error": "Invalid JSON format",
+			})
+		}
+
+		// the stream can't be handed a new token; this request was authenticated with the current one
+		if message.Type == WebSocketMessageTypeAuthRefresh {
+			refreshStreamAuth(hub, client, auth.SessionExpiresAtFromCtx(c))
+		} else {
+			handleClientMessage(hub, client, processor, message)
+		}
+		return c.Status(fiber.StatusAccepted).JSON(fiber.Map{
+			"message": "Message accepted",
+		})
+	}
+}
+
+// endStream ends the client's SSE stream, telling it why
+func (c *Client) endStream(reason string) {
+	if c.streamEnd == nil {
+		return
+	}
+	c.streamEndOnce.Do(func() {
+		c.streamEndReason = reason
+		close(c.streamEnd)
+	})
+}
+
+// formatSSEEvent wraps a websocket message as an SSE event; the data is the same JSON the websocket sends,
+// type included, so clients dispatch both transports the same way
+func formatSSEEvent(msg []byte) []byte {
+	var buf bytes.Buffer
+	// data lines can't contain newlines; marshalled JSON doesn't, but be safe with anything else
+	for _, line := range bytes.Split(msg, []byte("\n")) {
+		buf.WriteString("data: ")
+		buf.Write(line)
+		buf.WriteByte('\n')
+	}
+	buf.WriteByte('\n')
+	return buf.Bytes()
+}
@@ -0,0 +1,17 @@
+package libraries
+
+import "testing"
+
+func TestFormatSSEEvent(t *testing.T) {
+	got := string(formatSSEEvent([]byte(`{"type":"chat_response","data":{"message":"hi"}}`)))
+	want := "data: {\"type\":\"chat_response\",\"data\":{\"message\":\"hi\"}}\n\n"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+
+	got = string(formatSSEEvent([]byte("line one\nline two")))
+	want = "data: line one\ndata: line two\n\n"
+	if got != want {
+		t.Fatalf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,273 @@
+package libraries
+
+import (
+	"encoding/json"
+	"log"
+	"melina-studio-backend/internal/config"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// pacerBurst is how many chunks may go out back to back before the rate applies
+const pacerBurst = 4
+
+var streamPacing = sync.OnceValue(config.LoadStreamPacingConfig)
+
+// pacingFor returns the pacing of a message type; only streamed text deltas are paced
+func pacingFor(t WebSocketMessageType) (config.StreamPacing, bool) {
+	switch t {
+	case WebSocketMessageTypeChatResponse:
+		return streamPacing().Chat, true
+	case WebSocketMessageTypeThinkingResponse:
+		return streamPacing().Thinking, true
+	}
+	return config.StreamPacing{}, false
+}
+
+// pacedItem is either a text delta to coalesce or an already encoded message to pass through in order
+type pacedItem struct {
+	msgType WebSocketMessageType
+	delta   *ChatMessageResponsePayload
+	raw     []byte
+	kind    outboundKind
+}
+
+// streamPacer owns a client's outbound queue once the client starts streaming: tiny deltas are
+// coalesced into word-sized chunks and released through a token bucket per message type,
+// while every other message is passed through in order, flushing the pending text first
+type streamPacer struct {
+	client *Client
+	in     chan pacedItem
+	done   chan struct{}
+	mu     sync.Mutex
+	closed bool
+}
+
+func newStreamPacer(client *Client) *streamPacer {
+	p := &streamPacer{
+		client: client,
+		in:     make(chan pacedItem, 256),
+		done:   make(chan struct{}),
+	}
+	go p.run()
+	return p
+}
+
+// enqueue queues an item, blocking while the queue is full; it returns false once the pacer is stopped
+func (p *streamPacer) enqueue(item pacedItem) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.closed {
+		return false
+	}
+	p.in <- item
+	return true
+}
+
+// stop flushes the pending text and waits for the queue to drain
+func (p *streamPacer) stop() {
+	p.mu.Lock()
+	if !p.closed {
+		p.closed = true
+		close(p.in)
+	}
+	p.mu.Unlock()
+	<-p.done
+}
+
+func (p *streamPacer) run() {
+	defer close(p.done)
+
+	var pending *ChatMessageResponsePayload
+	var pendingType WebSocketMessageType
+	var pendingSince time.Time
+	var deadline <-chan time.Time
+	var md *markdownChunker
+	buckets := map[WebSocketMessageType]*tokenBucket{}
+
+	// emit sends the first n bytes of the pending text and keeps the rest pending
+	emit := func(n int) {
+		if pending == nil || n <= 0 {
+			return
+		}
+		pacing, _ := pacingFor(pendingType)
+		if pacing.Rate > 0 {
+			bucket, ok := buckets[pendingType]
+			if !ok {
+				bucket = newTokenBucket(float64(pacing.Rate), pacerBurst, time.Now())
+				buckets[pendingType] = bucket
+			}
+			time.Sleep(bucket.take(time.Now()))
+		}
+		chunk := *pending
+		chunk.Message = pending.Message[:n]
+		msg, err := json.Marshal(WebSocketMessage{Type: pendingType, Data: &chunk})
+		if err != nil {
+			log.Println("failed to marshal chat message response response:", err)
+		} else {
+			deliver(p.client, msg, outboundDelta, pending.BoardId)
+		}
+		if md != nil {
+			md.advance(chunk.Message)
+		}
+
+		if n == len(pending.Message) {
+			pending = nil
+			deadline = nil
+			return
+		}
+		pending.Message = pending.Message[n:]
+		pendingSince = time.Now()
+		if pacing.MaxDelay > 0 {
+			deadline = time.After(pacing.MaxDelay)
+		}
+	}
+	flush := func() {
+		if pending != nil {
+			emit(len(pending.Message))
+		}
+	}
+
+	for {
+		select {
+		case item, ok := <-p.in:
+			if !ok {
+				flush()
+				return
+			}
+			if item.delta == nil {
+				flush()
+				deliver(p.client, item.raw, item.kind, "")
+				if item.kind == outboundStreamEnd {
+					md = nil
+				}
+				continue
+			}
+			pacing, _ := pacingFor(item.msgType)
+			if pending != nil && !sameStream(pendingType, pending, item) {
+				flush()
+				md = nil
+			}
+			if pacing.Markdown && md == nil {
+				md = &markdownChunker{}
+			}
+			if pending == nil {
+				chunk := *item.delta
+				pending = &chunk
+				pendingType = item.msgType
+				pendingSince = time.Now()
+				if pacing.MaxDelay > 0 {
+					deadline = time.After(pacing.MaxDelay)
+				}
+			} else {
+				pending.Message += item.delta.Message
+			}
+			emit(readyLength(pending.Message, pacing, md))
+		case <-deadline:
+			if md == nil {
+				flush()
+				continue
+			}
+			// hold an open construct a little longer, but never indefinitely
+			if n := md.split(pending.Message); n > 0 {
+				emit(n)
+			} else if time.Since(pendingSince) >= markdownMaxHold || len(pending.Message) >= markdownMaxChars {
+				flush()
+			} else {
+				pacing, _ := pacingFor(pendingType)
+				deadline = time.After(pacing.MaxDelay)
+			}
+		}
+	}
+}
+
+// readyLength returns how much of the pending text to send now, 0 to keep waiting for more
+func readyLength(text string, pacing config.StreamPacing, md *markdownChunker) int {
+	if md == nil {
+		if chunkReady(text, pacing.MinChars) {
+			return len(text)
+		}
+		return 0
+	}
+	if utf8.RuneCountInString(text) < pacing.MinChars {
+		return 0
+	}
+	n := md.split(text)
+	if utf8.RuneCountInString(text[:n]) < pacing.MinChars {
+		return 0
+	}
+	return n
+}
+
+// sameStream reports whether a delta continues the pending chunk
+func sameStream(pendingType WebSocketMessageType, pending *ChatMessageResponsePayload, item pacedItem) bool {
+	d := item.delta
+	return item.msgType == pendingType &&
+		d.BoardId == pending.BoardId &&
+		d.HumanMessageId == pending.HumanMessageId &&
+		d.AiMessageId == pending.AiMessageId
+}
+
+// chunkReady reports whether the text is long enough and ends on a word boundary, so words are
+// never split across chunks; runs without a boundary are flushed at four times the minimum.
+// A minimum of 0 disables coalescing
+func chunkReady(text string, minChars int) bool {
+	n := utf8.RuneCountInString(text)
+	if n == 0 || n < minChars {
+		return false
+	}
+	if minChars <= 0 || n >= 4*minChars {
+		return true
+	}
+	last, _ := utf8.DecodeLastRuneInString(text)
+	return unicode.IsSpace(last) || unicode.IsPunct(last)
+}
+
+// tokenBucket allows rate tokens per second with bursts of up to burst tokens
+type tokenBucket struct {
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+func newTokenBucket(rate float64, burst int, now time.Time) *tokenBucket {
+	return &tokenBucket{rate: rate, burst: float64(burst), tokens: float64(burst), last: now}
+}
+
+// take consumes a token and returns how long to wait before it may be used
+func (b *tokenBucket) take(now time.Time) time.Duration {
+	b.tokens = min(b.burst, b.tokens+now.Sub(b.last).Seconds()*b.rate)
+	b.last = now
+	b.tokens--
+	if b.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-b.tokens / b.rate * float64(time.Second))
+}
+
+// startPacer returns the client's pacer, starting it on the first streamed delta
+func (c *Client) startPacer() *streamPacer {
+	c.pacerMu.Lock()
+	defer c.pacerMu.Unlock()
+	if c.pacer == nil {
+		c.pacer = newStreamPacer(c)
+	}
+	return c.pacer
+}
+
+// activePacer returns the client's pacer if it has started streaming
+func (c *Client) activePacer() *streamPacer {
+	c.pacerMu.Lock()
+	defer c.pacerMu.Unlock()
+	return c.pacer
+}
+
+// stopPacer flushes and stops the pacer; messages sent afterwards bypass it
+func (c *Client) stopPacer() {
+	if p := c.activePacer(); p != nil {
+		p.stop()
+	}
+}
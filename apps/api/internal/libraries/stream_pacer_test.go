@@ -0,0 +1,128 @@
+package libraries
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestChunkReady(t *testing.T) {
+	cases := []struct {
+		text     string
+		minChars int
+		want     bool
+	}{
+		{"Hel", 12, false},
+		{"Hello world ", 12, true},
+		{"Hello world, this", 12, false},
+		{"Hello world.", 12, true},
+		{"aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", 12, true},
+		{"x", 0, true},
+		{"", 0, false},
+	}
+	for _, c := range cases {
+		if got := chunkReady(c.text, c.minChars); got != c.want {
+			t.Errorf("chunkReady(%q, %d) = %v, want %v", c.text, c.minChars, got, c.want)
+		}
+	}
+}
+
+func TestTokenBucket(t *testing.T) {
+	start := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	bucket := newTokenBucket(10, 2, start)
+
+	if bucket.take(start) != 0 || bucket.take(start) != 0 {
+		t.Fatal("burst tokens should be available immediately")
+	}
+	if wait := bucket.take(start); wait != 100*time.Millisecond {
+		t.Errorf("wait after the burst = %v, want 100ms", wait)
+	}
+	if wait := bucket.take(start.Add(time.Second)); wait != 0 {
+		t.Errorf("wait after refilling = %v, want 0", wait)
+	}
+}
+
+func TestPacerCoalescesDeltasInOrder(t *testing.T) {
+	client := &Client{ID: "c1", Send: make(chan []byte, 16)}
+	var hub *Hub
+
+	for _, delta := range []string{"Hello", " wor", "ld, this is", " a test. "} {
+		SendChatMessageResponse(hub, client, WebSocketMessageTypeChatResponse, &ChatMessageResponsePayload{BoardId: "b1", Message: delta})
+	}
+	SendChatMessageResponse(hub, client, WebSocketMessageTypeChatCompleted, &ChatMessageResponsePayload{BoardId: "b1"})
+	client.stopPacer()
+	close(client.Send)
+
+	var text string
+	var types []WebSocketMessageType
+	for msg := range client.Send {
+		var resp struct {
+			Type WebSocketMessageType       `json:"type"`
+			Data ChatMessageResponsePayload `json:"data"`
+		}
+		if err := json.Unmarshal(msg, &resp); err != nil {
+			t.Fatal(err)
+		}
+		types = append(types, resp.Type)
+		if resp.Type == WebSocketMessageTypeChatResponse {
+			text += resp.Data.Message
+		}
+	}
+
+	if text != "Hello world, this is a test. " {
+		t.Errorf("streamed text = %q", text)
+	}
+	if len(types) > 3 || types[len(types)-1] != WebSocketMessageTypeChatCompleted {
+		t.Errorf("message types = %v, want a few coalesced chunks followed by chat_completed", types)
+	}
+}
+
+func TestChatCompletedCarriesTheCanonicalReply(t *testing.T) {
+	client := &Client{ID: "c1", Send: make(chan []byte, 16)}
+	var hub *Hub
+
+	SendChatMessageResponse(hub, client, WebSocketMessageTypeChatResponse, &ChatMessageResponsePayload{BoardId: "b1", Message: "Hello wor"})
+	SendChatMessageResponse(hub, client, WebSocketMessageTypeChatCompleted, &ChatMessageResponsePayload{
+		BoardId:    "b1",
+		Message:    "Hello world.",
+		Final:      true,
+		Thinking:   "The user greeted me",
+		ModelName:  "gemini-2.5-flash",
+		TokenUsage: &ChatCompletedUsage{InputTokens: 10, OutputTokens: 3, TotalTokens: 13, CountingMethod: "provider_api"},
+	})
+	client.stopPacer()
+	close(client.Send)
+
+	type sent struct {
+		Type WebSocketMessageType   `json:"type"`
+		Data map[string]interface{} `json:"data"`
+	}
+	var messages []sent
+	for msg := range client.Send {
+		var resp sent
+		if err := json.Unmarshal(msg, &resp); err != nil {
+			t.Fatal(err)
+		}
+		messages = append(messages, resp)
+	}
+	if len(messages) != 2 {
+		t.Fatalf("expected a chunk and chat_completed, got %v", messages)
+	}
+
+	// chunks stay lean: the completion fields are left out of them
+	for _, key := range []string{"final", "thinking", "model_name", "token_usage"} {
+		if _, ok := messages[0].Data[key]; ok {
+			t.Errorf("expected the chunk not to carry %q, got %v", key, messages[0].Data)
+		}
+	}
+	completed := messages[1].Data
+	if messages[1].Type != WebSocketMessageTypeChatCompleted || completed["message"] != "Hello world." || completed["final"] != true {
+		t.Errorf("expected the final reply, got %v", messages[1])
+	}
+	if completed["thinking"] != "The user greeted me" || completed["model_name"] != "gemini-2.5-flash" {
+		t.Errorf("expected the thinking and model, got %v", completed)
+	}
+	if usage, _ := completed["token_usage"].(map[string]interface{}); usage["total_tokens"] != 13.0 || usage["counting_method"] != "provider_api" {
+		t.Errorf("expected the token usage, got %v", completed["token_usage"])
+	}
+}
@@ -0,0 +1,90 @@
+package libraries
+
+import (
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ToolApprovalRequestPayload asks the user to confirm a destructive tool call before it runs
+type ToolApprovalRequestPayload struct {
+	ApprovalId string                 `json:"approval_id"`
+	BoardId    string                 `json:"board_id"`
+	ToolName   string                 `json:"tool_name"`
+	Input      map[string]interface{} `json:"input"`
+	ExpiresAt  time.Time              `json:"expires_at"`
+}
+
+// ToolApprovalResponsePayload is the user's answer to a tool_approval_request
+type ToolApprovalResponsePayload struct {
+	ApprovalId string `json:"approval_id"`
+	Approved   bool   `json:"approved"`
+}
+
+// pendingApproval is a tool approval waiting for its answer; only connections of the same user may answer it
+type pendingApproval struct {
+	userID   string
+	decision chan bool
+}
+
+var (
+	pendingApprovalsMu sync.Mutex
+	pendingApprovals   = make(map[string]*pendingApproval)
+)
+
+// RequestToolApproval sends a tool_approval_request to the client and returns the channel its answer
+// arrives on. Call the returned cancel once done waiting so an answer arriving late is dropped
+func RequestToolApproval(hub *Hub, client *Client, boardId string, toolName string, input map[string]interface{}, timeout time.Duration) (<-chan bool, func()) {
+	approvalId := uuid.NewString()
+	pending := &pendingApproval{userID: client.UserID, decision: make(chan bool, 1)}
+
+	pendingApprovalsMu.Lock()
+	pendingApprovals[approvalId] = pending
+	pendingApprovalsMu.Unlock()
+
+	cancel := func() {
+		pendingApprovalsMu.Lock()
+		delete(pendingApprovals, approvalId)
+		pendingApprovalsMu.Unlock()
+	}
+
+	msg, err := json.Marshal(WebSocketMessage{
+		Type: WebSocketMessageTypeToolApprovalReq,
+		Data: &ToolApprovalRequestPayload{
+			ApprovalId: approvalId,
+			BoardId:    boardId,
+			ToolName:   toolName,
+			Input:      input,
+			ExpiresAt:  time.Now().Add(timeout),
+		},
+	})
+	if err != nil {
+		log.Println("failed to marshal tool approval request:", err)
+		cancel()
+		pending.decision <- false
+		return pending.decision, func() {}
+	}
+	hub.SendMessage(client, msg)
+
+	return pending.decision, cancel
+}
+
+// ResolveToolApproval delivers the user's answer to a pending approval; it reports false when
+// there is no such approval for this user, e.g. because it already expired
+func ResolveToolApproval(client *Client, response *ToolApprovalResponsePayload) bool {
+	pendingApprovalsMu.Lock()
+	pending, ok := pendingApprovals[response.ApprovalId]
+	if ok && pending.userID == client.UserID {
+		delete(pendingApprovals, response.ApprovalId)
+	}
+	pendingApprovalsMu.Unlock()
+
+	if !ok || pending.userID != client.UserID {
+		return false
+	}
+	pending.decision <- response.Approved
+	return true
+}
@@ -0,0 +1,23 @@
+package libraries
+
+import "testing"
+
+func TestResolveToolApprovalOnlyAcceptsTheRequestingUser(t *testing.T) {
+	pending := &pendingApproval{userID: "owner", decision: make(chan bool, 1)}
+	pendingApprovalsMu.Lock()
+	pendingApprovals["approval"] = pending
+	pendingApprovalsMu.Unlock()
+
+	if ResolveToolApproval(&Client{UserID: "someone-else"}, &ToolApprovalResponsePayload{ApprovalId: "approval", Approved: true}) {
+		t.Fatal("another user must not answer the approval")
+	}
+	if !ResolveToolApproval(&Client{UserID: "owner"}, &ToolApprovalResponsePayload{ApprovalId: "approval", Approved: true}) {
+		t.Fatal("the owner should answer the approval")
+	}
+	if approved := <-pending.decision; !approved {
+		t.Fatal("expected the approval to be delivered")
+	}
+	if ResolveToolApproval(&Client{UserID: "owner"}, &ToolApprovalResponsePayload{ApprovalId: "approval", Approved: false}) {
+		t.Fatal("an answered approval must not be answered twice")
+	}
+}
@@ -0,0 +1,193 @@
+package libraries
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"melina-studio-backend/internal/config"
+	"net"
+	"net/textproto"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// ErrUploadInfected is returned when the scanner finds malware in an upload
+var ErrUploadInfected = errors.New("upload is infected")
+
+// UploadScanner checks an upload for malware before it is stored.
+// Scan returns ErrUploadInfected for infected content and other errors when the scan couldn't run
+type UploadScanner interface {
+	Scan(ctx context.Context, data []byte) error
+}
+
+// NewUploadScanner returns the scanner configured by UPLOAD_SCANNER, nil when scanning is off
+func NewUploadScanner(cfg config.UploadConfig) (UploadScanner, error) {
+	switch cfg.Scanner {
+	case config.UploadScannerNone:
+		return nil, nil
+	case config.UploadScannerClamAV:
+		return &clamAVScanner{addr: cfg.ClamAVAddr, timeout: cfg.ScanTimeout}, nil
+	case config.UploadScannerICAP:
+		u, err := parseICAPURL(cfg.ICAPURL)
+		if err != nil {
+			return nil, err
+		}
+		return &icapScanner{url: u, timeout: cfg.ScanTimeout}, nil
+	default:
+		return nil, fmt.Errorf("unknown UPLOAD_SCANNER %q", cfg.Scanner)
+	}
+}
+
+// dialScanner connects to a scanning daemon with the deadline of the context or the timeout, whichever is first
+func dialScanner(ctx context.Context, addr string, timeout time.Duration) (net.Conn, error) {
+	if timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, timeout)
+		defer cancel()
+	}
+	var dialer net.Dialer
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, err
+	}
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+	return conn, nil
+}
+
+// clamAVScanner streams uploads to clamd with the INSTREAM command
+type clamAVScanner struct {
+	addr    string
+	timeout time.Duration
+}
+
+// clamAVChunkSize stays well under clamd's default StreamMaxLength chunks
+const clamAVChunkSize = 64 * 1024
+
+func (s *clamAVScanner) Scan(ctx context.Context, data []byte) error {
+	conn, err := dialScanner(ctx, s.addr, s.timeout)
+	if err != nil {
+		return fmt.Errorf("clamd connect failed: %w", err)
+	}
+	defer conn.Close()
+
+	w := bufio.NewWriter(conn)
+	w.WriteString("zINSTREAM\x00")
+	var size [4]byte
+	for len(data) > 0 {
+		n := min(len(data), clamAVChunkSize)
+		binary.BigEndian.PutUint32(size[:], uint32(n))
+		w.Write(size[:])
+		w.Write(data[:n])
+		data = data[n:]
+	}
+	binary.BigEndian.PutUint32(size[:], 0)
+	w.Write(size[:])
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("clamd write failed: %w", err)
+	}
+
+	reply, err := bufio.NewReader(conn).ReadString(0)
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("clamd read failed: %w", err)
+	}
+	return parseClamAVReply(reply)
+}
+
+// parseClamAVReply reads "stream: OK", "stream: <signature> FOUND" or "<message> ERROR"
+func parseClamAVReply(reply string) error {
+	reply = strings.TrimSpace(strings.TrimRight(reply, "\x00"))
+	result := strings.TrimSpace(strings.TrimPrefix(reply, "stream:"))
+	switch {
+	case result == "OK":
+		return nil
+	case strings.HasSuffix(result, " FOUND"):
+		return fmt.Errorf("%w: %s", ErrUploadInfected, strings.TrimSuffix(result, " FOUND"))
+	default:
+		return fmt.Errorf("clamd scan failed: %s", reply)
+	}
+}
+
+// icapScanner sends uploads to an ICAP server as the body of a REQMOD request. The server answers
+// 204 for clean content and 200 with a replacement (a block page) for infected content
+type icapScanner struct {
+	url     *url.URL
+	timeout time.Duration
+}
+
+// parseICAPURL checks an icap://host[:port]/service URL, defaulting to the ICAP port 1344
+func parseICAPURL(raw string) (*url.URL, error) {
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme != "icap" || u.Host == "" {
+		return nil, fmt.Errorf("ICAP_URL must be an icap://host[:port]/service URL, got %q", raw)
+	}
+	if u.Port() == "" {
+		u.Host = net.JoinHostPort(u.Hostname(), "1344")
+	}
+	return u, nil
+}
+
+func (s *icapScanner) Scan(ctx context.Context, data []byte) error {
+	conn, err := dialScanner(ctx, s.url.Host, s.timeout)
+	if err != nil {
+		return fmt.Errorf("icap connect failed: %w", err)
+	}
+	defer conn.Close()
+
+	httpHeader := fmt.Sprintf("POST /upload HTTP/1.1\r\nHost: melina-studio\r\nContent-Length: %d\r\n\r\n", len(data))
+	var req bytes.Buffer
+	fmt.Fprintf(&req, "REQMOD %s ICAP/1.0\r\n", s.url.String())
+	fmt.Fprintf(&req, "Host: %s\r\n", s.url.Host)
+	req.WriteString("Allow: 204\r\n")
+	fmt.Fprintf(&req, "Encapsulated: req-hdr=0, req-body=%d\r\n\r\n", len(httpHeader))
+	req.WriteString(httpHeader)
+	if len(data) > 0 {
+		fmt.Fprintf(&req, "%x\r\n", len(data))
+		req.Write(data)
+		req.WriteString("\r\n")
+	}
+	req.WriteString("0\r\n\r\n")
+	if _, err := conn.Write(req.Bytes()); err != nil {
+		return fmt.Errorf("icap write failed: %w", err)
+	}
+
+	reader := textproto.NewReader(bufio.NewReader(conn))
+	statusLine, err := reader.ReadLine()
+	if err != nil {
+		return fmt.Errorf("icap read failed: %w", err)
+	}
+	header, err := reader.ReadMIMEHeader()
+	if err != nil && err != io.EOF {
+		return fmt.Errorf("icap read failed: %w", err)
+	}
+	return parseICAPResponse(statusLine, header)
+}
+
+// parseICAPResponse maps the ICAP status to a verdict, naming the threat from the headers servers commonly set
+func parseICAPResponse(statusLine string, header textproto.MIMEHeader) error {
+	parts := strings.SplitN(statusLine, " ", 3)
+	if len(parts) < 2 || !strings.HasPrefix(parts[0], "ICAP/") {
+		return fmt.Errorf("icap scan failed: malformed status %q", statusLine)
+	}
+	switch parts[1] {
+	case "204":
+		return nil
+	case "200":
+		threat := "blocked by the ICAP server"
+		for _, name := range []string{"X-Infection-Found", "X-Virus-ID", "X-Violations-Found"} {
+			if v := header.Get(name); v != "" {
+				threat = v
+				break
+			}
+		}
+		return fmt.Errorf("%w: %s", ErrUploadInfected, threat)
+	default:
+		return fmt.Errorf("icap scan failed: %s", statusLine)
+	}
+}
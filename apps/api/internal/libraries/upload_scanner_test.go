@@ -0,0 +1,149 @@
+package libraries
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// serveOnce accepts one connection and hands it to handle
+func serveOnce(t *testing.T, handle func(conn net.Conn)) string {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		handle(conn)
+	}()
+	return ln.Addr().String()
+}
+
+// fakeClamd reads an INSTREAM request and answers FOUND when the content contains "EICAR"
+func fakeClamd(t *testing.T) string {
+	return serveOnce(t, func(conn net.Conn) {
+		r := bufio.NewReader(conn)
+		cmd, err := r.ReadString(0)
+		if err != nil || cmd != "zINSTREAM\x00" {
+			conn.Write([]byte("UNKNOWN COMMAND\x00"))
+			return
+		}
+		var content []byte
+		for {
+			var size uint32
+			if err := binary.Read(r, binary.BigEndian, &size); err != nil {
+				return
+			}
+			if size == 0 {
+				break
+			}
+			chunk := make([]byte, size)
+			if _, err := io.ReadFull(r, chunk); err != nil {
+				return
+			}
+			content = append(content, chunk...)
+		}
+		if strings.Contains(string(content), "EICAR") {
+			conn.Write([]byte("stream: Eicar-Test-Signature FOUND\x00"))
+			return
+		}
+		conn.Write([]byte("stream: OK\x00"))
+	})
+}
+
+func TestClamAVScanner(t *testing.T) {
+	clean := &clamAVScanner{addr: fakeClamd(t), timeout: time.Second}
+	// larger than one chunk, so the stream is split
+	if err := clean.Scan(context.Background(), make([]byte, 3*clamAVChunkSize+10)); err != nil {
+		t.Errorf("clean content: %v", err)
+	}
+
+	infected := &clamAVScanner{addr: fakeClamd(t), timeout: time.Second}
+	err := infected.Scan(context.Background(), []byte("X5O!P%@AP[4\\PZX54(P^)7CC)7}$EICAR-STANDARD-ANTIVIRUS-TEST-FILE!$H+H*"))
+	if !errors.Is(err, ErrUploadInfected) || !strings.Contains(err.Error(), "Eicar-Test-Signature") {
+		t.Errorf("infected content: got %v", err)
+	}
+}
+
+func TestClamAVScannerUnreachable(t *testing.T) {
+	ln, _ := net.Listen("tcp", "127.0.0.1:0")
+	addr := ln.Addr().String()
+	ln.Close()
+
+	err := (&clamAVScanner{addr: addr, timeout: time.Second}).Scan(context.Background(), []byte("x"))
+	if err == nil || errors.Is(err, ErrUploadInfected) {
+		t.Errorf("expected a scan failure, got %v", err)
+	}
+}
+
+func TestParseClamAVReply(t *testing.T) {
+	if err := parseClamAVReply("stream: OK\x00"); err != nil {
+		t.Errorf("OK reply: %v", err)
+	}
+	if err := parseClamAVReply("INSTREAM size limit exceeded. ERROR\x00"); err == nil || errors.Is(err, ErrUploadInfected) {
+		t.Errorf("ERROR reply should be a scan failure, got %v", err)
+	}
+}
+
+func TestICAPScanner(t *testing.T) {
+	for _, tt := range []struct {
+		name     string
+		response string
+		infected bool
+	}{
+		{"clean", "ICAP/1.0 204 No Content\r\nISTag: \"x\"\r\n\r\n", false},
+		{"infected", "ICAP/1.0 200 OK\r\nX-Infection-Found: Type=0; Resolution=2; Threat=EICAR;\r\nEncapsulated: res-hdr=0, null-body=20\r\n\r\n", true},
+	} {
+		t.Run(tt.name, func(t *testing.T) {
+			requests := make(chan string, 1)
+			addr := serveOnce(t, func(conn net.Conn) {
+				r := textproto.NewReader(bufio.NewReader(conn))
+				line, _ := r.ReadLine()
+				r.ReadMIMEHeader() // ICAP headers
+				r.ReadMIMEHeader() // encapsulated HTTP headers
+				requests <- line
+				conn.Write([]byte(tt.response))
+			})
+
+			scanner := &icapScanner{timeout: time.Second}
+			var err error
+			scanner.url, err = parseICAPURL("icap://" + addr + "/avscan")
+			if err != nil {
+				t.Fatal(err)
+			}
+			err = scanner.Scan(context.Background(), []byte("content"))
+			if got := <-requests; !strings.HasPrefix(got, "REQMOD icap://"+addr+"/avscan ICAP/1.0") {
+				t.Errorf("request line = %q", got)
+			}
+			if tt.infected {
+				if !errors.Is(err, ErrUploadInfected) || !strings.Contains(err.Error(), "EICAR") {
+					t.Errorf("got %v, want an infection naming EICAR", err)
+				}
+			} else if err != nil {
+				t.Errorf("clean content: %v", err)
+			}
+		})
+	}
+}
+
+func TestParseICAPResponse(t *testing.T) {
+	if err := parseICAPResponse("ICAP/1.0 500 Server Error", nil); err == nil || errors.Is(err, ErrUploadInfected) {
+		t.Errorf("500 should be a scan failure, got %v", err)
+	}
+	if err := parseICAPResponse("garbage", nil); err == nil {
+		t.Error("malformed status should fail")
+	}
+}
@@ -5,8 +5,8 @@ import (
 	"fmt"
 	"log"
 	"melina-studio-backend/internal/auth"
+	"melina-studio-backend/internal/config"
 	"sync"
-	"time"
 
 	"github.com/gofiber/contrib/websocket"
 	"github.com/gofiber/fiber/v2"
@@ -29,13 +29,27 @@ const (
 	WebSocketMessageTypeShapeUpdateStart  WebSocketMessageType = "shape_update_start"
 	WebSocketMessageTypeShapeUpdated      WebSocketMessageType = "shape_updated"
 	WebSocketMessageTypeShapeDeleted      WebSocketMessageType = "shape_deleted"
+	WebSocketMessageTypeShapeLock         WebSocketMessageType = "shape_lock_changed"
 	WebSocketMessageTypeBoardRenamed      WebSocketMessageType = "board_renamed"
+	WebSocketMessageTypeBoardCover        WebSocketMessageType = "board_cover_updated"
+	WebSocketMessageTypeViewportChange    WebSocketMessageType = "viewport_change"
 	WebSocketMessageTypeTokenWarning      WebSocketMessageType = "token_warning"
 	WebSocketMessageTypeTokenBlocked      WebSocketMessageType = "token_blocked"
 	WebSocketMessageTypeThinkingStart     WebSocketMessageType = "thinking_start"
 	WebSocketMessageTypeThinkingResponse  WebSocketMessageType = "thinking_response"
 	WebSocketMessageTypeThinkingCompleted WebSocketMessageType = "thinking_completed"
 	WebSocketMessageTypeLoaderUpdate      WebSocketMessageType = "loader_update"
+	WebSocketMessageTypeLoaderStatus      WebSocketMessageType = "loader_status"
+	WebSocketMessageTypeCodeExportChunk   WebSocketMessageType = "code_export_chunk"
+	WebSocketMessageTypeCodeExportDone    WebSocketMessageType = "code_export_completed"
+	WebSocketMessageTypeBoardActivity     WebSocketMessageType = "board_activity"
+	WebSocketMessageTypeStreamTruncated   WebSocketMessageType = "stream_truncated"
+	WebSocketMessageTypeBudgetAlert       WebSocketMessageType = "budget_alert"
+	WebSocketMessageTypeBudgetExceeded    WebSocketMessageType = "budget_exceeded"
+	WebSocketMessageTypeToolApprovalReq   WebSocketMessageType = "tool_approval_request"
+	WebSocketMessageTypeToolApprovalResp  WebSocketMessageType = "tool_approval_response"
+	WebSocketMessageTypeAnnouncement      WebSocketMessageType = "announcement"
+	WebSocketMessageTypeCapabilityWarning WebSocketMessageType = "capability_warning"
 )
 
 type Client struct {
@@ -44,6 +58,24 @@ type Client struct {
 	Conn   *websocket.Conn
 	Send   chan []byte
 	once   sync.Once
+	// Overflow is the backpressure policy once Send is full; the zero value uses DefaultOverflowPolicy
+	Overflow OverflowPolicy
+	// pacer orders and paces outbound messages once the client starts streaming (see stream_pacer.go)
+	pacerMu sync.Mutex
+	pacer   *streamPacer
+	// overflow buffers messages while the writer is behind (see backpressure.go)
+	outMu    sync.Mutex
+	overflow sendOverflow
+	// auth closes the connection once its access token expires without a refresh (see ws_auth.go)
+	authMu sync.Mutex
+	auth   *authWatch
+	// streamEnd ends an SSE stream, with the reason as its last event (see sse.go)
+	streamEnd       chan struct{}
+	streamEndOnce   sync.Once
+	streamEndReason string
+	// subscription is the event categories the client receives, all of them until it subscribes (see ws_subscriptions.go)
+	subMu        sync.RWMutex
+	subscription subscription
 }
 
 type Hub struct {
@@ -51,6 +83,21 @@ type Hub struct {
 	Register   chan *Client
 	Unregister chan *Client
 	Broadcast  chan []byte
+	Direct     chan DirectMessage
+	// signOut closes every connection of a user, see CloseUserClients
+	signOut chan string
+	// rooms are the connections viewing each board and following its presentation, by board (see board_rooms.go)
+	roomsMu     sync.RWMutex
+	rooms       map[string]*boardRoom
+	boardAccess BoardAccessFunc
+}
+
+// DirectMessage is a message for every connection of a single user
+type DirectMessage struct {
+	UserID  string
+	Message []byte
+	// ExceptClientID skips the connection the message came from, for messages relayed between connections
+	ExceptClientID string
 }
 
 type WebSocketMessage struct {
@@ -75,6 +122,8 @@ type ShapeImageUrl struct {
 type ChatMessageMetadata struct {
 	ShapeImageUrls    []ShapeImageUrl `json:"shape_image_urls"`
 	UploadedImageUrls []string        `json:"uploaded_image_urls"`
+	// Attachments are the images sent inline in a binary frame, referred to as "attachment:N"
+	Attachments []FrameAttachment `json:"-"`
 }
 
 type ChatMessagePayload struct {
@@ -86,6 +135,8 @@ type ChatMessagePayload struct {
 	ActiveTheme    string               `json:"active_theme"`
 	Metadata       *ChatMessageMetadata `json:"metadata,omitempty"`
 	EnableThinking bool                 `json:"enable_thinking"`
+	// ReferencedBoardIds are other boards of the user attached as read-only context
+	ReferencedBoardIds []string `json:"referenced_board_ids,omitempty"`
 }
 
 type ChatMessageResponsePayload struct {
@@ -94,6 +145,22 @@ type ChatMessageResponsePayload struct {
 	HumanMessageId string      `json:"human_message_id"`
 	AiMessageId    string      `json:"ai_message_id"`
 	Data           interface{} `json:"data,omitempty"`
+	// set on chat_completed only: Message is then the canonical full reply that replaces the streamed chunks
+	Final      bool                `json:"final,omitempty"`
+	Thinking   string              `json:"thinking,omitempty"`
+	ModelName  string              `json:"model_name,omitempty"`
+	TokenUsage *ChatCompletedUsage `json:"token_usage,omitempty"`
+	// PromptVersion is the system prompt's version, sent back with feedback on the reply
+	PromptVersion string `json:"prompt_version,omitempty"`
+}
+
+// ChatCompletedUsage is the token usage of a finished reply
+type ChatCompletedUsage struct {
+	InputTokens    int     `json:"input_tokens"`
+	OutputTokens   int     `json:"output_tokens"`
+	TotalTokens    int     `json:"total_tokens"`
+	CountingMethod string  `json:"counting_method"`
+	CostUSD        float64 `json:"cost_usd"`
 }
 
 // Add this new struct
@@ -121,6 +188,8 @@ type WorkflowConfig struct {
 	MaxTokens      *int
 	ActiveTheme    string
 	EnableThinking bool
+	// ReferencedBoardIds are other boards attached to this chat turn as read-only context
+	ReferencedBoardIds []string
 }
 
 type BoardRenamedPayload struct {
@@ -128,6 +197,23 @@ type BoardRenamedPayload struct {
 	NewName string `json:"new_name"`
 }
 
+// BoardCoverPayload carries the cover fields the agent changed; the others are left out
+type BoardCoverPayload struct {
+	BoardId     string  `json:"board_id"`
+	CoverImage  *string `json:"cover_image,omitempty"`
+	Icon        *string `json:"icon,omitempty"`
+	Description *string `json:"description,omitempty"`
+}
+
+// ViewportChangePayload moves the client's camera to show the given shapes
+type ViewportChangePayload struct {
+	BoardId  string   `json:"board_id"`
+	ShapeIds []string `json:"shape_ids"`
+	X        float64  `json:"x"`
+	Y        float64  `json:"y"`
+	Zoom     float64  `json:"zoom"`
+}
+
 type TokenUsagePayload struct {
 	ConsumedTokens int     `json:"consumed_tokens"`
 	TotalLimit     int     `json:"total_limit"`
@@ -135,17 +221,73 @@ type TokenUsagePayload struct {
 	ResetDate      string  `json:"reset_date"` // ISO 8601 format
 }
 
+// BudgetPayload reports a board or tenant budget crossing an alert threshold, or refusing a turn
+type BudgetPayload struct {
+	Scope     string  `json:"scope"` // "board" or "tenant"
+	ScopeID   string  `json:"scope_id"`
+	Threshold int     `json:"threshold"` // percentage of the limit crossed: 50, 80 or 100
+	SpentUSD  float64 `json:"spent_usd"`
+	LimitUSD  float64 `json:"limit_usd"`
+	HardStop  bool    `json:"hard_stop"`
+	ResetDate string  `json:"reset_date"` // ISO 8601 format
+}
+
+// CapabilityWarningPayload tells the client how a turn was adjusted to what the chosen model supports
+type CapabilityWarningPayload struct {
+	BoardId string `json:"board_id"`
+	Code    string `json:"code"` // e.g. "thinking_disabled", "images_described", "history_trimmed"
+	Message string `json:"message"`
+	Model   string `json:"model"`
+}
+
 type LoaderUpdatePayload struct {
 	BoardId string `json:"board_id"`
 	Message string `json:"message"`
 }
 
+// LoaderStatus values of LoaderStatusPayload.State
+const (
+	LoaderStatusStart = "start"
+	LoaderStatusStop  = "stop"
+)
+
+// LoaderStatusPayload describes what the agent is doing; a "start" stays shown until the "stop" with the same StatusId
+type LoaderStatusPayload struct {
+	BoardId   string `json:"board_id"`
+	StatusId  string `json:"status_id"`
+	State     string `json:"state"`
+	Label     string `json:"label,omitempty"`
+	Tool      string `json:"tool,omitempty"`
+	Iteration int    `json:"iteration"`
+}
+
+type CodeExportPayload struct {
+	BoardId    string `json:"board_id"`
+	FrameId    string `json:"frame_id"`
+	ArtifactId string `json:"artifact_id,omitempty"`
+	Chunk      string `json:"chunk,omitempty"`
+}
+
+type ShapeLockPayload struct {
+	BoardId string `json:"board_id"`
+	ShapeId string `json:"shape_id"`
+	Locked  bool   `json:"locked"`
+}
+
+type BoardActivityPayload struct {
+	BoardId  string      `json:"board_id"`
+	Activity interface{} `json:"activity"`
+}
+
 func NewHub() *Hub {
 	return &Hub{
 		Clients:    make(map[string]*Client),
 		Register:   make(chan *Client),
 		Unregister: make(chan *Client),
 		Broadcast:  make(chan []byte),
+		Direct:     make(chan DirectMessage),
+		signOut:    make(chan string),
+		rooms:      make(map[string]*boardRoom),
 	}
 }
 
@@ -157,18 +299,37 @@ func (h *Hub) Run() {
 		case client := <-h.Unregister:
 			if _, exists := h.Clients[client.ID]; exists {
 				delete(h.Clients, client.ID)
-				client.once.Do(func() {
-					close(client.Send)
-				})
+				h.leaveBoardRooms(client)
+				// the pacer may still be waiting on its rate limit, don't hold up the hub for it
+				go func(client *Client) {
+					client.stopPacer()
+					client.once.Do(func() {
+						close(client.Send)
+					})
+				}(client)
 			}
 		case message := <-h.Broadcast:
+			category := messageCategory(message)
+			for _, client := range h.Clients {
+				if client.receives(category) {
+					deliver(client, message, outboundMessage, "")
+				}
+			}
+		case userID := <-h.signOut:
 			for _, client := range h.Clients {
-				select {
-				case client.Send <- message:
-				default:
-					// Channel full or closed, skip
+				if client.UserID == userID {
+					// writing the close frame may block, don't hold up the hub for it
+					go client.closeSession("signed out")
 				}
 			}
+		case direct := <-h.Direct:
+			category := messageCategory(direct.Message)
+			for _, client := range h.Clients {
+				if client.UserID != direct.UserID || client.ID == direct.ExceptClientID || !client.receives(category) {
+					continue
+				}
+				deliver(client, direct.Message, outboundMessage, "")
+			}
 		}
 	}
 }
@@ -177,22 +338,68 @@ func (h *Hub) BroadcastMessage(message []byte) {
 	h.Broadcast <- message
 }
 
-func (h *Hub) SendMessage(client *Client, message []byte) {
-	// Use defer/recover to safely handle closed channel panic
-	defer func() {
-		if r := recover(); r != nil {
-			log.Printf("[websocket] SendMessage recovered from panic (client likely disconnected): %v", r)
+// CloseUserClients closes every open connection of a user, websockets and SSE streams alike, e.g. once they
+// signed out of every device
+func (h *Hub) CloseUserClients(userID string) {
+	h.signOut <- userID
+}
+
+// SendToUser sends a message to every open connection of a user
+// Used by HTTP handlers, which have no websocket client of their own
+func (h *Hub) SendToUser(userID string, message []byte) {
+	h.Direct <- DirectMessage{UserID: userID, Message: message}
+}
+
+// NewRelayClient creates a client that is not registered with the hub and passes the
+// chat_response chunks sent to it to onChunk, so streaming LLM clients can be used outside a chat turn
+// The returned stop function must be called once streaming is done; it waits for pending chunks
+func NewRelayClient(userID string, onChunk func(chunk string)) (*Client, func()) {
+	client := &Client{
+		ID:     uuid.NewString(),
+		UserID: userID,
+		Send:   make(chan []byte, 256),
+	}
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for msg := range client.Send {
+			var resp struct {
+				Type WebSocketMessageType        `json:"type"`
+				Data *ChatMessageResponsePayload `json:"data"`
+			}
+			if err := json.Unmarshal(msg, &resp); err != nil {
+				continue
+			}
+			if resp.Type == WebSocketMessageTypeChatResponse && resp.Data != nil && resp.Data.Message != "" {
+				onChunk(resp.Data.Message)
+			}
 		}
 	}()
 
-	// Non-blocking send with select to avoid blocking on closed channel
-	select {
-	case client.Send <- message:
-		// Message sent successfully
-	default:
-		// Channel is full or closed, skip this message
-		log.Printf("[websocket] SendMessage: channel full or closed, dropping message for client %s", client.ID)
+	stop := func() {
+		client.stopPacer()
+		client.once.Do(func() {
+			close(client.Send)
+		})
+		<-done
 	}
+	return client, stop
+}
+
+func (h *Hub) SendMessage(client *Client, message []byte) {
+	h.send(client, message, outboundMessage)
+}
+
+func (h *Hub) send(client *Client, message []byte, kind outboundKind) {
+	if !client.receives(messageCategory(message)) {
+		return
+	}
+	// a streaming client's messages go through its pacer so they stay in order with the buffered text
+	if p := client.activePacer(); p != nil && p.enqueue(pacedItem{raw: message, kind: kind}) {
+		return
+	}
+	deliver(client, message, kind, "")
 }
 
 // sendErrorMessage sends a standardized error message to a client
@@ -238,7 +445,22 @@ func SendEventType(hub *Hub, client *Client, eventType WebSocketMessageType) {
 }
 
 // sendChatMessageResponse sends a chat message response to a client
+// Text deltas are coalesced and paced per client; everything else is sent right away
 func SendChatMessageResponse(hub *Hub, client *Client, Type WebSocketMessageType, message *ChatMessageResponsePayload) {
+	if !client.receives(eventCategories[Type]) {
+		return
+	}
+	kind := outboundMessage
+	if Type == WebSocketMessageTypeChatCompleted {
+		kind = outboundStreamEnd
+	}
+	if _, paced := pacingFor(Type); paced && message.Data == nil {
+		if client.startPacer().enqueue(pacedItem{msgType: Type, delta: message}) {
+			return
+		}
+		kind = outboundDelta
+	}
+
 	chatMessageResponseResp := WebSocketMessage{
 		Type: Type,
 		Data: message,
@@ -249,12 +471,10 @@ func SendChatMessageResponse(hub *Hub, client *Client, Type WebSocketMessageType
 		log.Println("failed to marshal chat message response response:", err)
 		return
 	}
-	hub.SendMessage(client, chatMessageResponseBytes)
-	// add a delay mille seconds
-	time.Sleep(50 * time.Millisecond)
+	hub.send(client, chatMessageResponseBytes, kind)
 }
 
-// SendShapeCreatedMessage sends a shape created message to a client
+// SendShapeCreatedMessage sends a shape created message to a client and the other connections viewing the board
 func SendShapeCreatedMessage(hub *Hub, client *Client, boardId string, shape map[string]interface{}) {
 	shapeCreatedResp := WebSocketMessage{
 		Type: WebSocketMessageTypeShapeCreated,
@@ -269,9 +489,10 @@ func SendShapeCreatedMessage(hub *Hub, client *Client, boardId string, shape map
 		return
 	}
 	hub.SendMessage(client, shapeCreatedBytes)
+	hub.SendToBoard(boardId, shapeCreatedBytes, client.ID)
 }
 
-// SendShapeUpdatedMessage sends a shape updated message to a client
+// SendShapeUpdatedMessage sends a shape updated message to a client and the other connections viewing the board
 func SendShapeUpdatedMessage(hub *Hub, client *Client, boardId string, shape map[string]interface{}) {
 	shapeUpdatedResp := WebSocketMessage{
 		Type: WebSocketMessageTypeShapeUpdated,
@@ -286,9 +507,10 @@ func SendShapeUpdatedMessage(hub *Hub, client *Client, boardId string, shape map
 		return
 	}
 	hub.SendMessage(client, shapeUpdatedBytes)
+	hub.SendToBoard(boardId, shapeUpdatedBytes, client.ID)
 }
 
-// SendShapeDeletedMessage sends a shape deleted message to a client
+// SendShapeDeletedMessage sends a shape deleted message to a client and the other connections viewing the board
 func SendShapeDeletedMessage(hub *Hub, client *Client, boardId string, shapeId string) {
 	shapeDeletedResp := WebSocketMessage{
 		Type: WebSocketMessageTypeShapeDeleted,
@@ -303,6 +525,7 @@ func SendShapeDeletedMessage(hub *Hub, client *Client, boardId string, shapeId s
 		return
 	}
 	hub.SendMessage(client, shapeDeletedBytes)
+	hub.SendToBoard(boardId, shapeDeletedBytes, client.ID)
 }
 
 // SendBoardRenamedMessage sends a board renamed message to a client
@@ -322,6 +545,19 @@ func SendBoardRenamedMessage(hub *Hub, client *Client, boardId string, newName s
 	hub.SendMessage(client, boardRenamedBytes)
 }
 
+// SendBoardCoverMessage tells a client the board's cover, icon or description changed
+func SendBoardCoverMessage(hub *Hub, client *Client, payload *BoardCoverPayload) {
+	msg, err := json.Marshal(WebSocketMessage{
+		Type: WebSocketMessageTypeBoardCover,
+		Data: payload,
+	})
+	if err != nil {
+		log.Println("failed to marshal board cover message:", err)
+		return
+	}
+	hub.SendMessage(client, msg)
+}
+
 // SendTokenWarning sends a token warning message to a client (80% threshold reached)
 func SendTokenWarning(hub *Hub, client *Client, usage *TokenUsagePayload) {
 	tokenWarningResp := WebSocketMessage{
@@ -350,6 +586,35 @@ func SendTokenBlocked(hub *Hub, client *Client, usage *TokenUsagePayload) {
 	hub.SendMessage(client, tokenBlockedBytes)
 }
 
+// SendCapabilityWarning tells a client that its turn was adjusted before it started
+func SendCapabilityWarning(hub *Hub, client *Client, warning *CapabilityWarningPayload) {
+	warningBytes, err := json.Marshal(WebSocketMessage{Type: WebSocketMessageTypeCapabilityWarning, Data: warning})
+	if err != nil {
+		log.Println("failed to marshal capability warning:", err)
+		return
+	}
+	hub.SendMessage(client, warningBytes)
+}
+
+// SendBudgetAlert tells a client that a budget crossed an alert threshold
+func SendBudgetAlert(hub *Hub, client *Client, budget *BudgetPayload) {
+	sendBudgetMessage(hub, client, WebSocketMessageTypeBudgetAlert, budget)
+}
+
+// SendBudgetExceeded tells a client that its turn was refused because a hard-capped budget is spent
+func SendBudgetExceeded(hub *Hub, client *Client, budget *BudgetPayload) {
+	sendBudgetMessage(hub, client, WebSocketMessageTypeBudgetExceeded, budget)
+}
+
+func sendBudgetMessage(hub *Hub, client *Client, msgType WebSocketMessageType, budget *BudgetPayload) {
+	budgetBytes, err := json.Marshal(WebSocketMessage{Type: msgType, Data: budget})
+	if err != nil {
+		log.Println("failed to marshal budget message:", err)
+		return
+	}
+	hub.SendMessage(client, budgetBytes)
+}
+
 // SendLoaderUpdateMessage sends a dynamic loader text message to a client
 func SendLoaderUpdateMessage(hub *Hub, client *Client, boardId string, message string) {
 	log.Printf("[websocket] SendLoaderUpdateMessage: boardId=%s, message=%s", boardId, message)
@@ -369,6 +634,89 @@ func SendLoaderUpdateMessage(hub *Hub, client *Client, boardId string, message s
 	log.Printf("[websocket] SendLoaderUpdateMessage: sent successfully")
 }
 
+// SendLoaderStatusMessage sends the start or stop of a tool activity status to a client
+func SendLoaderStatusMessage(hub *Hub, client *Client, payload *LoaderStatusPayload) {
+	loaderStatusResp := WebSocketMessage{
+		Type: WebSocketMessageTypeLoaderStatus,
+		Data: payload,
+	}
+	loaderStatusBytes, err := json.Marshal(loaderStatusResp)
+	if err != nil {
+		log.Println("failed to marshal loader status response:", err)
+		return
+	}
+	hub.SendMessage(client, loaderStatusBytes)
+}
+
+// SendCodeExportMessage sends a code export chunk or completion event to every connection of a user
+func SendCodeExportMessage(hub *Hub, userID string, Type WebSocketMessageType, payload *CodeExportPayload) {
+	codeExportResp := WebSocketMessage{
+		Type: Type,
+		Data: payload,
+	}
+	codeExportBytes, err := json.Marshal(codeExportResp)
+	if err != nil {
+		log.Println("failed to marshal code export message:", err)
+		return
+	}
+	hub.SendToUser(userID, codeExportBytes)
+}
+
+// SendBoardActivityMessage sends a new activity feed entry to every connection of the board's owner
+func SendBoardActivityMessage(hub *Hub, userID string, payload *BoardActivityPayload) {
+	activityResp := WebSocketMessage{
+		Type: WebSocketMessageTypeBoardActivity,
+		Data: payload,
+	}
+	activityBytes, err := json.Marshal(activityResp)
+	if err != nil {
+		log.Println("failed to marshal board activity message:", err)
+		return
+	}
+	hub.SendToUser(userID, activityBytes)
+}
+
+// SendViewportChangeMessage asks a client to move its camera, so the user sees what the agent points at
+func SendViewportChangeMessage(hub *Hub, client *Client, payload *ViewportChangePayload) {
+	msg, err := json.Marshal(WebSocketMessage{
+		Type: WebSocketMessageTypeViewportChange,
+		Data: payload,
+	})
+	if err != nil {
+		log.Println("failed to marshal viewport change message:", err)
+		return
+	}
+	hub.SendMessage(client, msg)
+}
+
+// SendShapeLockMessage tells every connection of the board's owner that a shape was locked or unlocked
+func SendShapeLockMessage(hub *Hub, userID string, payload *ShapeLockPayload) {
+	lockResp := WebSocketMessage{
+		Type: WebSocketMessageTypeShapeLock,
+		Data: payload,
+	}
+	lockBytes, err := json.Marshal(lockResp)
+	if err != nil {
+		log.Println("failed to marshal shape lock message:", err)
+		return
+	}
+	hub.SendToUser(userID, lockBytes)
+}
+
+// SendAnnouncementMessage pushes a newly published announcement to every open connection
+func SendAnnouncementMessage(hub *Hub, announcement interface{}) {
+	announcementResp := WebSocketMessage{
+		Type: WebSocketMessageTypeAnnouncement,
+		Data: announcement,
+	}
+	announcementBytes, err := json.Marshal(announcementResp)
+	if err != nil {
+		log.Println("failed to marshal announcement message:", err)
+		return
+	}
+	hub.BroadcastMessage(announcementBytes)
+}
+
 // parseWebSocketMessage parses incoming websocket message and returns the message structure
 func parseWebSocketMessage(msg []byte) (*WebSocketMessage, error) {
 	var rawMessage struct {
@@ -404,6 +752,48 @@ func parseWebSocketMessage(msg []byte) (*WebSocketMessage, error) {
 				return nil, err
 			}
 			message.Data = &shapePayload
+		case WebSocketMessageTypeToolApprovalResp:
+			var approvalPayload ToolApprovalResponsePayload
+			if err := json.Unmarshal(rawMessage.Data, &approvalPayload); err != nil {
+				return nil, err
+			}
+			message.Data = &approvalPayload
+		case WebSocketMessageTypeAuthRefresh:
+			var refreshPayload AuthRefreshPayload
+			if err := json.Unmarshal(rawMessage.Data, &refreshPayload); err != nil {
+				return nil, err
+			}
+			message.Data = &refreshPayload
+		case WebSocketMessageTypePresentationJoin, WebSocketMessageTypePresentationLeave, WebSocketMessageTypePresentationEnd:
+			var presentationPayload PresentationPayload
+			if err := json.Unmarshal(rawMessage.Data, &presentationPayload); err != nil {
+				return nil, err
+			}
+			message.Data = &presentationPayload
+		case WebSocketMessageTypePresentationNavigate:
+			var slidePayload PresentationSlidePayload
+			if err := json.Unmarshal(rawMessage.Data, &slidePayload); err != nil {
+				return nil, err
+			}
+			message.Data = &slidePayload
+		case WebSocketMessageTypeSubscribe:
+			var subscribePayload SubscribePayload
+			if err := json.Unmarshal(rawMessage.Data, &subscribePayload); err != nil {
+				return nil, err
+			}
+			message.Data = &subscribePayload
+		case WebSocketMessageTypeBoardJoin, WebSocketMessageTypeBoardLeave:
+			var roomPayload BoardRoomPayload
+			if err := json.Unmarshal(rawMessage.Data, &roomPayload); err != nil {
+				return nil, err
+			}
+			message.Data = &roomPayload
+		case WebSocketMessageTypeAnnotation:
+			var annotationPayload AnnotationPayload
+			if err := json.Unmarshal(rawMessage.Data, &annotationPayload); err != nil {
+				return nil, err
+			}
+			message.Data = &annotationPayload
 		default:
 			// For other types, unmarshal as generic interface{}
 			var data interface{}
@@ -422,10 +812,14 @@ type ChatMessageProcessor interface {
 	ProcessChatMessage(hub *Hub, client *Client, cfg *WorkflowConfig)
 }
 
+// wsCompression is read once, like the stream pacing
+var wsCompression = sync.OnceValue(config.LoadCompressionConfig)
+
 func WebSocketHandler(hub *Hub, processor ChatMessageProcessor) fiber.Handler {
+	compression := wsCompression()
 	return websocket.New(func(conn *websocket.Conn) {
 		// Authenticate WebSocket connection
-		userID, err := auth.AuthenticateWebSocket(conn)
+		claims, expiresAt, err := auth.AuthenticateWebSocket(conn)
 		if err != nil {
 			log.Println("WebSocket auth failed:", err)
 			errorMsg := WebSocketMessage{
@@ -440,12 +834,21 @@ func WebSocketHandler(hub *Hub, processor ChatMessageProcessor) fiber.Handler {
 
 		client := &Client{
 			ID:     uuid.NewString(),
-			UserID: userID,
+			UserID: claims.UserID,
 			Conn:   conn,
 			Send:   make(chan []byte, 256),
 		}
 
 		hub.Register <- client
+		if !expiresAt.IsZero() {
+			client.watchAuthExpiry(hub, expiresAt)
+		}
+
+		if compression.WebSocket {
+			if err := conn.SetCompressionLevel(compression.WebSocketLevel); err != nil {
+				log.Println("failed to set websocket compression level:", err)
+			}
+		}
 
 		// Write loop
 		go func() {
@@ -454,6 +857,8 @@ func WebSocketHandler(hub *Hub, processor ChatMessageProcessor) fiber.Handler {
 				conn.Close()
 			}()
 			for msg := range client.Send {
+				// only worth it for large messages such as shape lists; a no-op unless the client negotiated compression
+				conn.EnableWriteCompression(compression.WebSocket && len(msg) >= compression.WebSocketThreshold)
 				if err := conn.WriteMessage(websocket.TextMessage, msg); err != nil {
 					log.Println("write error:", err)
 					return
@@ -463,68 +868,153 @@ func WebSocketHandler(hub *Hub, processor ChatMessageProcessor) fiber.Handler {
 
 		// Read loop
 		for {
-			_, msg, err := conn.ReadMessage()
+			frameType, msg, err := conn.ReadMessage()
 			if err != nil {
 				log.Println("read error:", err)
 				break
 			}
-			log.Println("received:", string(msg))
 
-			// Parse message using standard interface
-			message, err := parseWebSocketMessage(msg)
+			// Parse message using standard interface; binary frames carry images next to the JSON
+			var message *WebSocketMessage
+			if frameType == websocket.BinaryMessage {
+				log.Printf("received binary frame: %d bytes", len(msg))
+				message, err = parseBinaryWebSocketMessage(msg)
+			} else {
+				log.Println("received:", string(msg))
+				message, err = parseWebSocketMessage(msg)
+			}
 			if err != nil {
 				log.Println("failed to parse JSON:", err)
-				SendErrorMessage(hub, client, "Invalid JSON format")
-				continue
-			}
-
-			// Handle ping messages
-			if message.Type == WebSocketMessageTypePing {
-				sendPongMessage(hub, client)
-			} else if message.Type == WebSocketMessageTypeMessage {
-				if message.Data == nil {
-					SendErrorMessage(hub, client, "Chat message payload is required")
-					continue
-				}
-				// Type assert to ChatMessagePayload
-				chatPayload, ok := message.Data.(*ChatMessagePayload)
-				if !ok {
-					SendErrorMessage(hub, client, "Invalid chat message payload type")
-					continue
+				if frameType == websocket.BinaryMessage {
+					SendErrorMessage(hub, client, "Invalid binary frame: "+err.Error())
+				} else {
+					SendErrorMessage(hub, client, "Invalid JSON format")
 				}
-				// extract the board id from the message
-				boardId := chatPayload.BoardId
-				if boardId == "" {
-					SendErrorMessage(hub, client, "Board ID is required")
-					continue
-				}
-
-				fmt.Println("chatPayload", chatPayload)
-				fmt.Println("chatPayload.ModelName", chatPayload.ModelName)
-				fmt.Println("chatPayload.Temperature", chatPayload.Temperature)
-				fmt.Println("chatPayload.MaxTokens", chatPayload.MaxTokens)
-
-				payload := &WorkflowConfig{
-					BoardId:        boardId,
-					UserID:         client.UserID,
-					Message:        chatPayload,
-					ModelName:      chatPayload.ModelName,
-					Temperature:    chatPayload.Temperature,
-					MaxTokens:      chatPayload.MaxTokens,
-					ActiveTheme:    chatPayload.ActiveTheme,
-					EnableThinking: chatPayload.EnableThinking,
-				}
-
-				// send the chat message to the processor
-				go processor.ProcessChatMessage(hub, client, payload)
-			} else {
-				//  return error that type is invalid or not provided
-				SendErrorMessage(hub, client, "Type is invalid or not provided")
 				continue
 			}
+
+			handleClientMessage(hub, client, processor, message)
 		}
 
+		client.stopAuthWatch()
 		hub.Unregister <- client
 		conn.Close()
-	})
+	}, websocket.Config{EnableCompression: compression.WebSocket})
+}
+
+// handleClientMessage acts on a message a client sent, over the websocket or the SSE fallback's POST endpoint
+func handleClientMessage(hub *Hub, client *Client, processor ChatMessageProcessor, message *WebSocketMessage) {
+	// Handle ping messages
+	if message.Type == WebSocketMessageTypePing {
+		sendPongMessage(hub, client)
+	} else if message.Type == WebSocketMessageTypeMessage {
+		if message.Data == nil {
+			SendErrorMessage(hub, client, "Chat message payload is required")
+			return
+		}
+		// Type assert to ChatMessagePayload
+		chatPayload, ok := message.Data.(*ChatMessagePayload)
+		if !ok {
+			SendErrorMessage(hub, client, "Invalid chat message payload type")
+			return
+		}
+		// extract the board id from the message
+		boardId := chatPayload.BoardId
+		if boardId == "" {
+			SendErrorMessage(hub, client, "Board ID is required")
+			return
+		}
+
+		fmt.Println("chatPayload", chatPayload)
+		fmt.Println("chatPayload.ModelName", chatPayload.ModelName)
+		fmt.Println("chatPayload.Temperature", chatPayload.Temperature)
+		fmt.Println("chatPayload.MaxTokens", chatPayload.MaxTokens)
+
+		payload := &WorkflowConfig{
+			BoardId:        boardId,
+			UserID:         client.UserID,
+			Message:        chatPayload,
+			ModelName:      chatPayload.ModelName,
+			Temperature:    chatPayload.Temperature,
+			MaxTokens:      chatPayload.MaxTokens,
+			ActiveTheme:    chatPayload.ActiveTheme,
+			EnableThinking: chatPayload.EnableThinking,
+
+			ReferencedBoardIds: chatPayload.ReferencedBoardIds,
+		}
+
+		// send the chat message to the processor
+		go processor.ProcessChatMessage(hub, client, payload)
+	} else if message.Type == WebSocketMessageTypeToolApprovalResp {
+		approvalPayload, ok := message.Data.(*ToolApprovalResponsePayload)
+		if !ok {
+			SendErrorMessage(hub, client, "Invalid tool approval payload")
+			return
+		}
+		if !ResolveToolApproval(client, approvalPayload) {
+			SendErrorMessage(hub, client, "Tool approval request not found or expired")
+		}
+	} else if message.Type == WebSocketMessageTypeAuthRefresh {
+		refreshPayload, ok := message.Data.(*AuthRefreshPayload)
+		if !ok {
+			SendErrorMessage(hub, client, "Invalid auth refresh payload")
+			return
+		}
+		refreshClientAuth(hub, client, refreshPayload)
+	} else if message.Type == WebSocketMessageTypeSubscribe {
+		// a subscribe without data restores every category
+		subscribePayload, _ := message.Data.(*SubscribePayload)
+		if subscribePayload == nil {
+			subscribePayload = &SubscribePayload{}
+		}
+		subscribe(hub, client, subscribePayload)
+	} else if message.Type == WebSocketMessageTypeBoardJoin || message.Type == WebSocketMessageTypeBoardLeave {
+		roomPayload, ok := message.Data.(*BoardRoomPayload)
+		if !ok || roomPayload.BoardId == "" {
+			SendErrorMessage(hub, client, "Board ID is required")
+			return
+		}
+		if message.Type == WebSocketMessageTypeBoardLeave {
+			hub.LeaveBoard(client, roomPayload.BoardId)
+		} else if err := hub.JoinBoard(client, roomPayload.BoardId); err != nil {
+			SendErrorMessage(hub, client, "Failed to join board: "+err.Error())
+		}
+	} else if message.Type == WebSocketMessageTypeAnnotation {
+		annotationPayload, ok := message.Data.(*AnnotationPayload)
+		if !ok {
+			SendErrorMessage(hub, client, "Annotation payload is required")
+			return
+		}
+		if err := RelayAnnotation(hub, client, annotationPayload); err != nil {
+			SendErrorMessage(hub, client, "Invalid annotation: "+err.Error())
+		}
+	} else if message.Type == WebSocketMessageTypePresentationNavigate {
+		slidePayload, ok := message.Data.(*PresentationSlidePayload)
+		if !ok {
+			SendErrorMessage(hub, client, "Presentation slide payload is required")
+			return
+		}
+		if err := hub.NavigatePresentation(client, slidePayload); err != nil {
+			SendErrorMessage(hub, client, "Invalid presentation slide: "+err.Error())
+		}
+	} else if message.Type == WebSocketMessageTypePresentationJoin || message.Type == WebSocketMessageTypePresentationLeave || message.Type == WebSocketMessageTypePresentationEnd {
+		presentationPayload, ok := message.Data.(*PresentationPayload)
+		if !ok || presentationPayload.BoardId == "" {
+			SendErrorMessage(hub, client, "Board ID is required")
+			return
+		}
+		switch message.Type {
+		case WebSocketMessageTypePresentationJoin:
+			if err := hub.JoinPresentation(client, presentationPayload.BoardId); err != nil {
+				SendErrorMessage(hub, client, err.Error())
+			}
+		case WebSocketMessageTypePresentationLeave:
+			hub.LeavePresentation(client, presentationPayload.BoardId)
+		default:
+			hub.EndPresentation(client, presentationPayload.BoardId)
+		}
+	} else {
+		//  return error that type is invalid or not provided
+		SendErrorMessage(hub, client, "Type is invalid or not provided")
+	}
 }
@@ -0,0 +1,169 @@
+package libraries
+
+import (
+	"encoding/json"
+	"log"
+	"melina-studio-backend/internal/auth"
+	"time"
+
+	"github.com/gofiber/contrib/websocket"
+)
+
+const (
+	// WebSocketMessageTypeAuthRefresh carries a refreshed access token for an open connection
+	WebSocketMessageTypeAuthRefresh WebSocketMessageType = "auth_refresh"
+	// WebSocketMessageTypeAuthRefreshed confirms a refresh and tells the client when the new token expires
+	WebSocketMessageTypeAuthRefreshed WebSocketMessageType = "auth_refreshed"
+	// WebSocketMessageTypeAuthExpiring asks the client to refresh before the connection is closed
+	WebSocketMessageTypeAuthExpiring WebSocketMessageType = "auth_expiring"
+)
+
+// authExpiryWarning is how long before the token expires the client is sent auth_expiring
+const authExpiryWarning = time.Minute
+
+// authWriteTimeout bounds the close frame sent when the token expired
+const authWriteTimeout = 5 * time.Second
+
+// AuthRefreshPayload is the refreshed access token a client sends over an open connection
+type AuthRefreshPayload struct {
+	Token string `json:"token"`
+}
+
+// AuthStatusPayload tells the client when the connection's access token expires
+type AuthStatusPayload struct {
+	ExpiresAt time.Time `json:"expires_at"`
+}
+
+// authWatch holds the timers that warn a client and close its connection once its access token expires
+type authWatch struct {
+	expiresAt time.Time
+	warn      *time.Timer
+	expire    *time.Timer
+}
+
+// authTimerDelays returns how long until the client is warned and until its token expires
+func authTimerDelays(expiresAt, now time.Time) (warnIn, expireIn time.Duration) {
+	expireIn = expiresAt.Sub(now)
+	if expireIn < 0 {
+		expireIn = 0
+	}
+	warnIn = expireIn - authExpiryWarning
+	if warnIn < 0 {
+		warnIn = 0
+	}
+	return warnIn, expireIn
+}
+
+// watchAuthExpiry (re)arms the client's expiry timers for a token expiring at expiresAt
+func (c *Client) watchAuthExpiry(hub *Hub, expiresAt time.Time) {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+
+	c.stopAuthTimersLocked()
+	warnIn, expireIn := authTimerDelays(expiresAt, time.Now())
+	watch := &authWatch{expiresAt: expiresAt}
+	watch.warn = time.AfterFunc(warnIn, func() {
+		if c.currentAuthWatch() == watch {
+			sendAuthStatus(hub, c, WebSocketMessageTypeAuthExpiring, expiresAt)
+		}
+	})
+	watch.expire = time.AfterFunc(expireIn, func() {
+		if c.currentAuthWatch() == watch {
+			c.closeSession("access token expired")
+		}
+	})
+	c.auth = watch
+}
+
+// currentAuthWatch returns the timers of the token in use; a timer of a replaced token is stale
+func (c *Client) currentAuthWatch() *authWatch {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	return c.auth
+}
+
+// stopAuthWatch stops the expiry timers once the connection is gone
+func (c *Client) stopAuthWatch() {
+	c.authMu.Lock()
+	defer c.authMu.Unlock()
+	c.stopAuthTimersLocked()
+	c.auth = nil
+}
+
+func (c *Client) stopAuthTimersLocked() {
+	if c.auth == nil {
+		return
+	}
+	c.auth.warn.Stop()
+	c.auth.expire.Stop()
+}
+
+// closeSession closes a connection whose token expired without a refresh or whose user signed out everywhere.
+// A websocket gets a close frame and an SSE stream a last error event saying why, so the client reconnects
+// with a fresh token instead of giving up
+func (c *Client) closeSession(reason string) {
+	if c.Conn == nil {
+		c.endStream(reason)
+		return
+	}
+	log.Printf("closing websocket of user %s: %s", c.UserID, reason)
+	closeMsg := websocket.FormatCloseMessage(websocket.ClosePolicyViolation, reason)
+	if err := c.Conn.WriteControl(websocket.CloseMessage, closeMsg, time.Now().Add(authWriteTimeout)); err != nil {
+		log.Println("failed to send websocket close frame:", err)
+	}
+	c.Conn.Close()
+}
+
+// refreshClientAuth re-validates a connection with the token the client refreshed and moves its expiry
+func refreshClientAuth(hub *Hub, client *Client, payload *AuthRefreshPayload) {
+	// SSE streams are renewed by the POST's own authentication, see SSEMessageHandler
+	if client.Conn == nil {
+		SendErrorMessage(hub, client, "Token refresh is only supported on WebSocket connections")
+		return
+	}
+	if payload.Token == "" {
+		SendErrorMessage(hub, client, "Token is required")
+		return
+	}
+
+	claims, expiresAt, err := auth.ValidateWebSocketToken(client.Conn, payload.Token)
+	if err != nil {
+		log.Println("WebSocket token refresh failed:", err)
+		SendErrorMessage(hub, client, "Token refresh failed: "+err.Error())
+		return
+	}
+	// the connection stays bound to the user it was opened for
+	if claims.UserID != client.UserID {
+		SendErrorMessage(hub, client, "Token refresh failed: token belongs to another user")
+		return
+	}
+	if expiresAt.IsZero() {
+		client.stopAuthWatch()
+		return
+	}
+
+	client.watchAuthExpiry(hub, expiresAt)
+	sendAuthStatus(hub, client, WebSocketMessageTypeAuthRefreshed, expiresAt)
+}
+
+// refreshStreamAuth moves an SSE stream's expiry to that of the request that renewed it
+func refreshStreamAuth(hub *Hub, client *Client, expiresAt time.Time) {
+	if expiresAt.IsZero() {
+		client.stopAuthWatch()
+		return
+	}
+	client.watchAuthExpiry(hub, expiresAt)
+	sendAuthStatus(hub, client, WebSocketMessageTypeAuthRefreshed, expiresAt)
+}
+
+func sendAuthStatus(hub *Hub, client *Client, msgType WebSocketMessageType, expiresAt time.Time) {
+	msg, err := json.Marshal(WebSocketMessage{
+		Type: msgType,
+		Data: &AuthStatusPayload{ExpiresAt: expiresAt},
+	})
+	if err != nil {
+		log.Println("failed to marshal auth status message:", err)
+		return
+	}
+	hub.SendMessage(client, msg)
+}
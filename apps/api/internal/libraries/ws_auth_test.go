@@ -0,0 +1,92 @@
+package libraries
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestAuthTimerDelays(t *testing.T) {
+	now := time.Now()
+
+	warnIn, expireIn := authTimerDelays(now.Add(15*time.Minute), now)
+	if warnIn != 14*time.Minute || expireIn != 15*time.Minute {
+		t.Fatalf("got warn %v expire %v, want 14m and 15m", warnIn, expireIn)
+	}
+
+	warnIn, expireIn = authTimerDelays(now.Add(-time.Second), now)
+	if warnIn != 0 || expireIn != 0 {
+		t.Fatalf("an expired token should fire right away, got warn %v expire %v", warnIn, expireIn)
+	}
+}
+
+func TestWatchAuthExpiryWarnsOnlyForTheCurrentToken(t *testing.T) {
+	client := &Client{UserID: "user", Send: make(chan []byte, 4)}
+	hub := &Hub{}
+
+	// the first token is replaced before its warning fires
+	client.watchAuthExpiry(hub, time.Now().Add(authExpiryWarning+50*time.Millisecond))
+	client.watchAuthExpiry(hub, time.Now().Add(authExpiryWarning+time.Hour))
+	defer client.stopAuthWatch()
+
+	select {
+	case msg := <-client.Send:
+		t.Fatalf("unexpected message for a replaced token: %s", msg)
+	case <-time.After(150 * time.Millisecond):
+	}
+
+	client.watchAuthExpiry(hub, time.Now().Add(authExpiryWarning))
+	select {
+	case msg := <-client.Send:
+		var got WebSocketMessage
+		if err := json.Unmarshal(msg, &got); err != nil {
+			t.Fatal(err)
+		}
+		if got.Type != WebSocketMessageTypeAuthExpiring {
+			t.Fatalf("got %q, want %q", got.Type, WebSocketMessageTypeAuthExpiring)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected an auth_expiring warning")
+	}
+}
+
+func TestSSEStreamEndsWithItsToken(t *testing.T) {
+	client := &Client{UserID: "user", Send: make(chan []byte, 4), streamEnd: make(chan struct{})}
+	client.watchAuthExpiry(&Hub{}, time.Now())
+	defer client.stopAuthWatch()
+
+	select {
+	case <-client.streamEnd:
+		if client.streamEndReason != "access token expired" {
+			t.Errorf("unexpected reason %q", client.streamEndReason)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the stream to end once its token expired")
+	}
+	// ending twice is harmless
+	client.closeSession("signed out")
+}
+
+func TestCloseUserClientsEndsOnlyThatUsersStreams(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+	mine := &Client{ID: "mine", UserID: "user", Send: make(chan []byte, 4), streamEnd: make(chan struct{})}
+	theirs := &Client{ID: "theirs", UserID: "someone-else", Send: make(chan []byte, 4), streamEnd: make(chan struct{})}
+	hub.Register <- mine
+	hub.Register <- theirs
+
+	hub.CloseUserClients("user")
+	select {
+	case <-mine.streamEnd:
+		if mine.streamEndReason != "signed out" {
+			t.Errorf("unexpected reason %q", mine.streamEndReason)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("expected the user's stream to end")
+	}
+	select {
+	case <-theirs.streamEnd:
+		t.Error("another user's stream must stay open")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,171 @@
+package libraries
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"sort"
+)
+
+const (
+	// WebSocketMessageTypeSubscribe limits a connection to the event categories it lists; an empty list restores all
+	WebSocketMessageTypeSubscribe WebSocketMessageType = "subscribe"
+	// WebSocketMessageTypeSubscribed confirms a subscription with the categories the connection now receives
+	WebSocketMessageTypeSubscribed WebSocketMessageType = "subscribed"
+)
+
+// EventCategory groups the events a connection can subscribe to
+type EventCategory string
+
+const (
+	// EventCategoryChat is the agent's replies as they stream: text, thinking, loaders and code export
+	EventCategoryChat EventCategory = "chat"
+	// EventCategoryShapes is changes to the board: shapes, locks, card statuses, the name, cover and activity feed
+	EventCategoryShapes EventCategory = "shapes"
+	// EventCategoryPresence is who is around and what they point at: board viewers, annotations, presentations and camera moves
+	EventCategoryPresence EventCategory = "presence"
+	// EventCategoryToken is token usage and budget warnings
+	EventCategoryToken EventCategory = "token"
+)
+
+// eventCategories maps each subscribable event to its category. Events missing here (errors, pongs,
+// auth, tool approvals, announcements, subscription and board room confirmations) reach every connection,
+// since the client has to act on them whatever it renders
+var eventCategories = map[WebSocketMessageType]EventCategory{
+	WebSocketMessageTypeChatResponse:      EventCategoryChat,
+	WebSocketMessageTypeChatStarting:      EventCategoryChat,
+	WebSocketMessageTypeChatCompleted:     EventCategoryChat,
+	WebSocketMessageTypeThinkingStart:     EventCategoryChat,
+	WebSocketMessageTypeThinkingResponse:  EventCategoryChat,
+	WebSocketMessageTypeThinkingCompleted: EventCategoryChat,
+	WebSocketMessageTypeLoaderUpdate:      EventCategoryChat,
+	WebSocketMessageTypeLoaderStatus:      EventCategoryChat,
+	WebSocketMessageTypeStreamTruncated:   EventCategoryChat,
+	WebSocketMessageTypeCapabilityWarning: EventCategoryChat,
+	WebSocketMessageTypeCodeExportChunk:   EventCategoryChat,
+	WebSocketMessageTypeCodeExportDone:    EventCategoryChat,
+
+	WebSocketMessageTypeShapeStart:        EventCategoryShapes,
+	WebSocketMessageTypeShapeCreated:      EventCategoryShapes,
+	WebSocketMessageTypeShapeUpdateStart:  EventCategoryShapes,
+	WebSocketMessageTypeShapeUpdated:      EventCategoryShapes,
+	WebSocketMessageTypeShapeDeleted:      EventCategoryShapes,
+	WebSocketMessageTypeShapeLock:         EventCategoryShapes,
+	WebSocketMessageTypeCardStatus:        EventCategoryShapes,
+	WebSocketMessageTypeBoardRenamed:      EventCategoryShapes,
+	WebSocketMessageTypeBoardCover:        EventCategoryShapes,
+	WebSocketMessageTypeBoardActivity:     EventCategoryShapes,
+	WebSocketMessageTypeAutomationRun:     EventCategoryShapes,
+	WebSocketMessageTypeInboundEmail:      EventCategoryShapes,
+	WebSocketMessageTypeBoardRestored:     EventCategoryShapes,
+	WebSocketMessageTypeIntegrationAction: EventCategoryShapes,
+
+	WebSocketMessageTypeAnnotation:          EventCategoryPresence,
+	WebSocketMessageTypeAnnotationExpired:   EventCategoryPresence,
+	WebSocketMessageTypePresentationSlide:   EventCategoryPresence,
+	WebSocketMessageTypePresentationEnded:   EventCategoryPresence,
+	WebSocketMessageTypePresentationUpdated: EventCategoryPresence,
+	WebSocketMessageTypeViewportChange:      EventCategoryPresence,
+	WebSocketMessageTypeBoardMembers:        EventCategoryPresence,
+
+	WebSocketMessageTypeTokenWarning:   EventCategoryToken,
+	WebSocketMessageTypeTokenBlocked:   EventCategoryToken,
+	WebSocketMessageTypeBudgetAlert:    EventCategoryToken,
+	WebSocketMessageTypeBudgetExceeded: EventCategoryToken,
+}
+
+var validEventCategories = map[EventCategory]bool{
+	EventCategoryChat:     true,
+	EventCategoryShapes:   true,
+	EventCategoryPresence: true,
+	EventCategoryToken:    true,
+}
+
+// SubscribePayload lists the event categories a connection wants
+type SubscribePayload struct {
+	Events []EventCategory `json:"events"`
+}
+
+// subscription is the set of categories a connection receives; nil receives everything
+type subscription map[EventCategory]bool
+
+// receives reports whether a connection subscribed to s gets messages of the category; uncategorized
+// messages always go through
+func (s subscription) receives(category EventCategory) bool {
+	return s == nil || category == "" || s[category]
+}
+
+// newSubscription validates the requested categories; an empty list subscribes to everything
+func newSubscription(events []EventCategory) (subscription, error) {
+	if len(events) == 0 {
+		return nil, nil
+	}
+	sub := make(subscription, len(events))
+	for _, event := range events {
+		if !validEventCategories[event] {
+			return nil, fmt.Errorf("unknown event category %q (expected chat, shapes, presence or token)", event)
+		}
+		sub[event] = true
+	}
+	return sub, nil
+}
+
+// categories lists the subscription's categories in order, every category when it is unrestricted
+func (s subscription) categories() []EventCategory {
+	events := make([]EventCategory, 0, len(validEventCategories))
+	for event := range validEventCategories {
+		if s.receives(event) {
+			events = append(events, event)
+		}
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i] < events[j] })
+	return events
+}
+
+// messageCategory returns the category of an outbound message. Messages are marshalled from
+// WebSocketMessage, so the type is read from the start of the JSON without decoding the payload
+func messageCategory(message []byte) EventCategory {
+	const prefix = `{"type":"`
+	if rest, ok := bytes.CutPrefix(message, []byte(prefix)); ok {
+		if end := bytes.IndexByte(rest, '"'); end >= 0 {
+			return eventCategories[WebSocketMessageType(rest[:end])]
+		}
+	}
+	var envelope struct {
+		Type WebSocketMessageType `json:"type"`
+	}
+	if err := json.Unmarshal(message, &envelope); err != nil {
+		return ""
+	}
+	return eventCategories[envelope.Type]
+}
+
+// receives reports whether the client subscribed to messages of the category
+func (c *Client) receives(category EventCategory) bool {
+	c.subMu.RLock()
+	defer c.subMu.RUnlock()
+	return c.subscription.receives(category)
+}
+
+// subscribe replaces the client's subscription and confirms the categories it now receives
+func subscribe(hub *Hub, client *Client, payload *SubscribePayload) {
+	sub, err := newSubscription(payload.Events)
+	if err != nil {
+		SendErrorMessage(hub, client, err.Error())
+		return
+	}
+	client.subMu.Lock()
+	client.subscription = sub
+	client.subMu.Unlock()
+
+	msg, err := json.Marshal(WebSocketMessage{
+		Type: WebSocketMessageTypeSubscribed,
+		Data: &SubscribePayload{Events: sub.categories()},
+	})
+	if err != nil {
+		log.Println("failed to marshal subscribed message:", err)
+		return
+	}
+	hub.SendMessage(client, msg)
+}
@@ -0,0 +1,90 @@
+package libraries
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+)
+
+func TestMessageCategory(t *testing.T) {
+	shape, _ := json.Marshal(WebSocketMessage{Type: WebSocketMessageTypeShapeCreated, Data: &ShapeCreatedPayload{BoardId: "board"}})
+	if got := messageCategory(shape); got != EventCategoryShapes {
+		t.Errorf("shape_created: got %q", got)
+	}
+	if got := messageCategory([]byte(`{ "data": {}, "type": "token_warning" }`)); got != EventCategoryToken {
+		t.Errorf("hand-written JSON should still be categorized, got %q", got)
+	}
+	if got := messageCategory([]byte(`{"type":"error"}`)); got != "" {
+		t.Errorf("errors must not be filtered, got %q", got)
+	}
+	if got := messageCategory([]byte("not json")); got != "" {
+		t.Errorf("unparsable message: got %q", got)
+	}
+}
+
+func TestSubscribeFiltersFanOut(t *testing.T) {
+	hub := NewHub()
+	go hub.Run()
+	embed := &Client{ID: "embed", UserID: "user", Send: make(chan []byte, 8)}
+	editor := &Client{ID: "editor", UserID: "user", Send: make(chan []byte, 8)}
+	hub.Register <- embed
+	hub.Register <- editor
+
+	subscribe(hub, embed, &SubscribePayload{Events: []EventCategory{EventCategoryShapes}})
+	next := func(client *Client) WebSocketMessageType {
+		t.Helper()
+		select {
+		case msg := <-client.Send:
+			var decoded WebSocketMessage
+			if err := json.Unmarshal(msg, &decoded); err != nil {
+				t.Fatalf("invalid message: %v", err)
+			}
+			return decoded.Type
+		case <-time.After(200 * time.Millisecond):
+			return ""
+		}
+	}
+	if got := next(embed); got != WebSocketMessageTypeSubscribed {
+		t.Fatalf("expected a subscribed confirmation, got %q", got)
+	}
+
+	SendBoardActivityMessage(hub, "user", &BoardActivityPayload{BoardId: "board"})
+	SendTokenWarning(hub, embed, &TokenUsagePayload{})
+	SendChatMessageResponse(hub, embed, WebSocketMessageTypeChatResponse, &ChatMessageResponsePayload{Message: "hi"})
+	SendErrorMessage(hub, embed, "boom")
+
+	// the activity goes through the hub's fan-out and may arrive after the direct sends
+	received := map[WebSocketMessageType]bool{}
+	for msgType := next(embed); msgType != ""; msgType = next(embed) {
+		received[msgType] = true
+	}
+	if !received[WebSocketMessageTypeBoardActivity] || !received[WebSocketMessageTypeError] {
+		t.Errorf("subscribed categories and errors should be delivered, got %v", received)
+	}
+	if received[WebSocketMessageTypeTokenWarning] || received[WebSocketMessageTypeChatResponse] {
+		t.Errorf("unsubscribed categories should be filtered, got %v", received)
+	}
+	if got := next(editor); got != WebSocketMessageTypeBoardActivity {
+		t.Errorf("connections that never subscribed get everything, got %q", got)
+	}
+
+	subscribe(hub, embed, &SubscribePayload{})
+	next(embed)
+	SendTokenWarning(hub, embed, &TokenUsagePayload{})
+	if got := next(embed); got != WebSocketMessageTypeTokenWarning {
+		t.Errorf("an empty subscription should restore every category, got %q", got)
+	}
+}
+
+func TestSubscribeRejectsUnknownCategories(t *testing.T) {
+	if _, err := newSubscription([]EventCategory{EventCategoryChat, "cursors"}); err == nil {
+		t.Error("expected an unknown category to be rejected")
+	}
+	sub, err := newSubscription([]EventCategory{EventCategoryToken, EventCategoryChat})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := sub.categories(); len(got) != 2 || got[0] != EventCategoryChat || got[1] != EventCategoryToken {
+		t.Errorf("got %v", got)
+	}
+}
@@ -0,0 +1,118 @@
+package llmHandlers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+)
+
+// AgentProfile is a named agent with its own tool set, picked per board chat so risky tools can be left out
+type AgentProfile struct {
+	Name        string   `json:"name"`
+	DisplayName string   `json:"display_name"`
+	Description string   `json:"description"`
+	Tools       []string `json:"tools,omitempty"` // nil allows every registered tool
+	// Instructions are added to the system prompt so the model doesn't plan around tools it doesn't have
+	Instructions string `json:"-"`
+}
+
+// DefaultAgentProfile is used by boards that haven't picked a profile
+const DefaultAgentProfile = "default"
+
+// AgentProfiles maps profile names to their tool sets
+var AgentProfiles = map[string]AgentProfile{
+	DefaultAgentProfile: {
+		Name:        DefaultAgentProfile,
+		DisplayName: "Melina",
+		Description: "The full assistant with every board tool",
+	},
+	"diagrammer": {
+		Name:        "diagrammer",
+		DisplayName: "Diagrammer",
+		Description: "Draws and edits shapes only; can't rename the board, generate wireframes or read other boards",
+		Tools:       []string{"getBoardData", "getShapeDetails", "addShape", "updateShape", "deleteShape", "replaceText", "validateDiagram", "getBoardStats", "focusOn", "pointAt"},
+		Instructions: "You are running as the Diagrammer agent. You can only read the board and add, update or delete shapes; " +
+			"other tools are not available in this chat.",
+	},
+	"reviewer": {
+		Name:        "reviewer",
+		DisplayName: "Reviewer",
+		Description: "Reads and critiques the board without changing it",
+		Tools:       []string{"getBoardData", "getShapeDetails", "validateDiagram", "generateDDL", "getBoardStats", "focusOn", "pointAt"},
+		Instructions: "You are running as the Reviewer agent. You can read the board but not change it: " +
+			"describe the changes you recommend instead of making them.",
+	},
+}
+
+// GetAgentProfile returns the named profile; an empty name is the default profile
+func GetAgentProfile(name string) (*AgentProfile, error) {
+	if name == "" {
+		name = DefaultAgentProfile
+	}
+	profile, ok := AgentProfiles[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown agent profile: %s", name)
+	}
+	return &profile, nil
+}
+
+// ListAgentProfiles returns every profile, the default first and the rest by name
+func ListAgentProfiles() []AgentProfile {
+	profiles := make([]AgentProfile, 0, len(AgentProfiles))
+	for _, profile := range AgentProfiles {
+		profiles = append(profiles, profile)
+	}
+	sort.Slice(profiles, func(i, j int) bool {
+		if profiles[i].Name == DefaultAgentProfile || profiles[j].Name == DefaultAgentProfile {
+			return profiles[i].Name == DefaultAgentProfile
+		}
+		return profiles[i].Name < profiles[j].Name
+	})
+	return profiles
+}
+
+// AllowsTool reports whether the profile may call the tool
+func (p *AgentProfile) AllowsTool(name string) bool {
+	if p == nil || p.Tools == nil {
+		return true
+	}
+	for _, tool := range p.Tools {
+		if tool == name {
+			return true
+		}
+	}
+	return false
+}
+
+// FilterTools keeps the tool definitions the profile allows, in either the Anthropic ("name") or the
+// OpenAI ("function.name") format
+func (p *AgentProfile) FilterTools(tools []map[string]interface{}) []map[string]interface{} {
+	if p == nil || p.Tools == nil {
+		return tools
+	}
+	filtered := make([]map[string]interface{}, 0, len(tools))
+	for _, tool := range tools {
+		name, _ := tool["name"].(string)
+		if fn, ok := tool["function"].(map[string]interface{}); ok {
+			name, _ = fn["name"].(string)
+		}
+		if p.AllowsTool(name) {
+			filtered = append(filtered, tool)
+		}
+	}
+	return filtered
+}
+
+// agentProfileKey is the context key of the profile whose tools a turn may call
+type agentProfileKey struct{}
+
+// WithAgentProfile restricts the tools executed with the returned context to the profile's,
+// in case the model calls a tool it wasn't given
+func WithAgentProfile(ctx context.Context, profile *AgentProfile) context.Context {
+	return context.WithValue(ctx, agentProfileKey{}, profile)
+}
+
+func agentProfileFrom(ctx context.Context) *AgentProfile {
+	profile, _ := ctx.Value(agentProfileKey{}).(*AgentProfile)
+	return profile
+}
@@ -0,0 +1,56 @@
+package llmHandlers
+
+import (
+	"context"
+	"testing"
+)
+
+func TestAgentProfileFilterToolsHandlesBothFormats(t *testing.T) {
+	profile, err := GetAgentProfile("reviewer")
+	if err != nil {
+		t.Fatal(err)
+	}
+	tools := []map[string]interface{}{
+		{"name": "getBoardData"},
+		{"name": "addShape"},
+		{"type": "function", "function": map[string]interface{}{"name": "validateDiagram"}},
+		{"type": "function", "function": map[string]interface{}{"name": "deleteShape"}},
+	}
+
+	filtered := profile.FilterTools(tools)
+	if len(filtered) != 2 {
+		t.Fatalf("got %d tools, want 2: %v", len(filtered), filtered)
+	}
+}
+
+func TestDefaultAgentProfileAllowsEveryTool(t *testing.T) {
+	profile, err := GetAgentProfile("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if profile.Name != DefaultAgentProfile || !profile.AllowsTool("renameBoard") {
+		t.Fatalf("unexpected default profile: %+v", profile)
+	}
+	if _, err := GetAgentProfile("admin"); err == nil {
+		t.Fatal("expected an error for an unknown profile")
+	}
+	if profiles := ListAgentProfiles(); profiles[0].Name != DefaultAgentProfile {
+		t.Fatalf("default profile should be listed first, got %s", profiles[0].Name)
+	}
+}
+
+func TestExecuteToolsRefusesToolsOutsideTheProfile(t *testing.T) {
+	called := false
+	RegisterTool("profileTestTool", func(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+		called = true
+		return "ok", nil
+	})
+	defer UnregisterTool("profileTestTool")
+
+	profile, _ := GetAgentProfile("reviewer")
+	ctx := WithAgentProfile(context.Background(), profile)
+	results := ExecuteTools(ctx, []ToolCall{{Name: "profileTestTool", Input: map[string]interface{}{"a": "b"}}}, nil)
+	if called || results[0].Error == nil {
+		t.Fatal("tool outside the profile should not run")
+	}
+}
@@ -1,17 +1,15 @@
 package llmHandlers
 
 import (
-	"bufio"
 	"bytes"
 	"context"
-	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"io"
+	"melina-studio-backend/internal/config"
 	"melina-studio-backend/internal/constants"
-	"melina-studio-backend/internal/libraries"
 	"melina-studio-backend/internal/models"
 	"net/http"
-	"os"
 	"strings"
 
 	"golang.org/x/oauth2"
@@ -22,7 +20,8 @@ import (
 type ClaudeResponse struct {
 	StopReason      string
 	TextContent     []string
-	ThinkingContent string // Accumulated thinking/reasoning content
+	ThinkingContent string          // Accumulated thinking/reasoning content
+	ThinkingBlocks  []ThinkingBlock // Signed thinking blocks, replayed with the tool results
 	ToolUses        []ToolUse
 	RawResponse     interface{} // Can hold either HTTP response or gRPC response
 }
@@ -92,6 +91,7 @@ type streamDelta struct {
 	Delta       string `json:"delta"`        // for input_json_delta (partial JSON) - some APIs use this
 	PartialJSON string `json:"partial_json"` // for input_json_delta (partial JSON) - Vertex AI uses this
 	Thinking    string `json:"thinking"`     // for thinking blocks
+	Signature   string `json:"signature"`    // for signature_delta, which closes a thinking block
 }
 
 type streamContentBlockRef struct {
@@ -99,27 +99,22 @@ type streamContentBlockRef struct {
 	Index int    `json:"index"`
 	ID    string `json:"id,omitempty"`   // for tool_use blocks
 	Name  string `json:"name,omitempty"` // for tool_use blocks
+	Data  string `json:"data,omitempty"` // for redacted_thinking blocks
 }
 
 func callClaudeWithMessages(ctx context.Context, systemMessage string, messages []Message, tools []map[string]interface{}, temperature *float32, maxTokens *int, modelIDOverride string, enableThinking bool) (*ClaudeResponse, error) {
-	projectID := os.Getenv("GOOGLE_CLOUD_PROJECT_ID")
-	location := os.Getenv("GOOGLE_CLOUD_VERTEXAI_LOCATION") // "us-east5"
+	settings := config.GetSettings()
+	projectID := settings.GCP.ProjectID
+	location := regionalLocation(ctx, ProviderVertexAnthropic, settings.GCP.VertexRegion) // "us-east5"
 	modelID := modelIDOverride
 	if modelID == "" {
-		modelID = os.Getenv("CLAUDE_VERTEX_MODEL") // fallback to env var
-	}
-	if modelID == "" {
-		modelID = "claude-sonnet-4-5@20250929" // final fallback
+		modelID = settings.LLM.ClaudeVertexModel // fallback to the configured default
 	}
 
 	// -------- 1) Build authed HTTP client from SA JSON --------
-	enc := os.Getenv("GCP_SERVICE_ACCOUNT_CREDENTIALS")
-	if enc == "" {
-		return nil, fmt.Errorf("GCP_SERVICE_ACCOUNT_CREDENTIALS not set")
-	}
-	saJSON, err := base64.StdEncoding.DecodeString(enc)
+	saJSON, err := settings.GCP.ServiceAccountJSON()
 	if err != nil {
-		return nil, fmt.Errorf("decode sa json: %w", err)
+		return nil, err
 	}
 
 	creds, err := google.CredentialsFromJSON(ctx, saJSON, "https://www.googleapis.com/auth/cloud-platform")
@@ -202,6 +197,9 @@ func callClaudeWithMessages(ctx context.Context, systemMessage string, messages
 		return nil, fmt.Errorf("new request: %w", err)
 	}
 	req.Header.Set("Content-Type", "application/json")
+	if useInterleavedThinking(modelID, enableThinking, tools) {
+		req.Header.Set("anthropic-beta", interleavedThinkingBeta)
+	}
 
 	// -------- 4) Send request --------
 	resp, err := httpClient.Do(req)
@@ -245,10 +243,15 @@ func callClaudeWithMessages(ctx context.Context, systemMessage string, messages
 						Name:  name,
 						Input: input,
 					})
+				case "thinking", "redacted_thinking":
+					if tb, ok := parseThinkingBlock(block); ok {
+						cr.ThinkingBlocks = append(cr.ThinkingBlocks, tb)
+					}
 				}
 			}
 		}
 	}
+	cr.ThinkingContent = thinkingText(cr.ThinkingBlocks)
 
 	return cr, nil
 }
@@ -265,24 +268,18 @@ func StreamClaudeWithMessages(
 	modelIDOverride string,
 	enableThinking bool,
 ) (*ClaudeResponse, error) {
-	projectID := os.Getenv("GOOGLE_CLOUD_PROJECT_ID")
-	location := os.Getenv("GOOGLE_CLOUD_VERTEXAI_LOCATION") // e.g. "us-east5"
+	settings := config.GetSettings()
+	projectID := settings.GCP.ProjectID
+	location := regionalLocation(ctx, ProviderVertexAnthropic, settings.GCP.VertexRegion) // e.g. "us-east5"
 	modelID := modelIDOverride
 	if modelID == "" {
-		modelID = os.Getenv("CLAUDE_VERTEX_MODEL") // fallback to env var
-	}
-	if modelID == "" {
-		modelID = "claude-sonnet-4-5@20250929" // final fallback
+		modelID = settings.LLM.ClaudeVertexModel // fallback to the configured default
 	}
 
 	// ---------- 1) Auth HTTP client from SA JSON ----------
-	enc := os.Getenv("GCP_SERVICE_ACCOUNT_CREDENTIALS")
-	if enc == "" {
-		return nil, fmt.Errorf("GCP_SERVICE_ACCOUNT_CREDENTIALS not set")
-	}
-	saJSON, err := base64.StdEncoding.DecodeString(enc)
+	saJSON, err := settings.GCP.ServiceAccountJSON()
 	if err != nil {
-		return nil, fmt.Errorf("decode sa json: %w", err)
+		return nil, err
 	}
 
 	creds, err := google.CredentialsFromJSON(ctx, saJSON, "https://www.googleapis.com/auth/cloud-platform")
@@ -366,6 +363,9 @@ func StreamClaudeWithMessages(
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Accept", "text/event-stream")
+	if useInterleavedThinking(modelID, enableThinking, tools) {
+		req.Header.Set("anthropic-beta", interleavedThinkingBeta)
+	}
 
 	// ---------- 4) Do request & read SSE ----------
 	resp, err := httpClient.Do(req)
@@ -390,7 +390,7 @@ func StreamClaudeWithMessages(
 	// Track current text block being built
 	var currentTextBuilder strings.Builder
 	var accumulatedText strings.Builder
-	var currentThinkingBuilder strings.Builder
+	thinking := newStreamedThinking()
 
 	// Track current tool_use block being built (by index)
 	// Map of block index -> ToolUse being built
@@ -400,20 +400,27 @@ func StreamClaudeWithMessages(
 	// Track usage data
 	var usageData *streamUsage
 
-	scanner := bufio.NewScanner(resp.Body)
-	for scanner.Scan() {
-		line := scanner.Text()
-
-		// SSE lines look like: "data: { ... }"
-		if !strings.HasPrefix(line, "data: ") {
-			continue
+	// tool inputs can arrive as one very long data line, so events are framed by an SSE reader
+	// with a configurable limit instead of a line scanner capped at 64KB
+	reader := NewSSEReader(resp.Body, streamMaxEventSize())
+	dispatch := newStreamDispatcher(streamCtx, false)
+	for {
+		sse, err := reader.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("stream read error: %w", err)
 		}
-		data := strings.TrimPrefix(line, "data: ")
+		data := sse.Data
 
 		// Vertex typically uses [DONE] or similar sentinel when finished
-		if data == "[DONE]" || data == "" {
+		if data == "[DONE]" {
 			break
 		}
+		if data == "" {
+			continue
+		}
 
 		var ev streamEvent
 		if err := json.Unmarshal([]byte(data), &ev); err != nil {
@@ -434,17 +441,7 @@ func StreamClaudeWithMessages(
 					// Accumulate text delta
 					currentTextBuilder.WriteString(ev.Delta.Text)
 					accumulatedText.WriteString(ev.Delta.Text)
-
-					// Send streaming chunk to client
-					if streamCtx != nil && streamCtx.Client != nil {
-						payload := &libraries.ChatMessageResponsePayload{
-							Message: ev.Delta.Text,
-						}
-						if streamCtx.BoardId != "" {
-							payload.BoardId = streamCtx.BoardId
-						}
-						libraries.SendChatMessageResponse(streamCtx.Hub, streamCtx.Client, libraries.WebSocketMessageTypeChatResponse, payload)
-					}
+					dispatch.Emit(TextDelta(ev.Delta.Text))
 				} else if ev.Delta.Type == "input_json_delta" {
 					// Tool use input is being streamed (partial JSON)
 					// Vertex AI uses "partial_json" field, other APIs might use "delta"
@@ -481,17 +478,10 @@ func StreamClaudeWithMessages(
 						}
 					}
 				} else if ev.Delta.Type == "thinking_delta" && ev.Delta.Thinking != "" {
-					currentThinkingBuilder.WriteString(ev.Delta.Thinking)
-					// Stream thinking to client (reuse chat_response or create a new type)
-					if streamCtx != nil && streamCtx.Client != nil {
-						payload := &libraries.ChatMessageResponsePayload{
-							Message: ev.Delta.Thinking,
-						}
-						if streamCtx.BoardId != "" {
-							payload.BoardId = streamCtx.BoardId
-						}
-						libraries.SendChatMessageResponse(streamCtx.Hub, streamCtx.Client, libraries.WebSocketMessageTypeThinkingResponse, payload)
-					}
+					thinking.at(ev.Index).Thinking += ev.Delta.Thinking
+					dispatch.Emit(ThinkingDelta(ev.Delta.Thinking))
+				} else if ev.Delta.Type == "signature_delta" {
+					thinking.at(ev.Index).Signature = ev.Delta.Signature
 				}
 			}
 
@@ -546,15 +536,6 @@ func StreamClaudeWithMessages(
 				}
 			}
 
-			// Finalize thinking block if active - send thinking_completed event
-			// Note: Don't reset currentThinkingBuilder here - it's saved to cr.ThinkingContent at the end
-			if currentThinkingBuilder.Len() > 0 {
-				// Send thinking_completed event
-				if streamCtx != nil && streamCtx.Client != nil {
-					libraries.SendEventType(streamCtx.Hub, streamCtx.Client, libraries.WebSocketMessageTypeThinkingCompleted)
-				}
-			}
-
 		case "content_block_start":
 			// A new content block is starting
 			if ev.ContentBlock != nil {
@@ -568,16 +549,16 @@ func StreamClaudeWithMessages(
 					}
 					currentToolUseInputBuilders[idx] = &strings.Builder{}
 					fmt.Printf("[anthropic] Started tool_use block: index=%d, ID=%s, Name=%s\n", idx, ev.ContentBlock.ID, ev.ContentBlock.Name)
+					dispatch.Emit(ToolStarted(ev.ContentBlock.ID, ev.ContentBlock.Name))
 				} else if ev.ContentBlock.Type == "text" {
 					// Reset text builder for new text block
 					currentTextBuilder.Reset()
 				} else if ev.ContentBlock.Type == "thinking" {
-					// Reset thinking builder for new thinking block
-					currentThinkingBuilder.Reset()
-					// Send thinking_start event
-					if streamCtx != nil && streamCtx.Client != nil {
-						libraries.SendEventType(streamCtx.Hub, streamCtx.Client, libraries.WebSocketMessageTypeThinkingStart)
-					}
+					// A new thinking block; with interleaved thinking one can follow each tool call
+					// thinking_start goes out with the block's first delta
+					thinking.at(ev.Index)
+				} else if ev.ContentBlock.Type == "redacted_thinking" {
+					thinking.at(ev.Index).Redacted = ev.ContentBlock.Data
 				}
 			}
 
@@ -634,6 +615,12 @@ func StreamClaudeWithMessages(
 					usageData.OutputTokens = ev.Message.Usage.OutputTokens
 				}
 				fmt.Printf("[anthropic] message_start usage: input=%d, output=%d\n", usageData.InputTokens, usageData.OutputTokens)
+				dispatch.Emit(UsageReported(&TokenUsage{
+					InputTokens:    usageData.InputTokens,
+					OutputTokens:   usageData.OutputTokens,
+					TotalTokens:    usageData.InputTokens + usageData.OutputTokens,
+					CountingMethod: "provider_api",
+				}))
 			}
 
 		case "message_delta":
@@ -654,6 +641,12 @@ func StreamClaudeWithMessages(
 					usageData.OutputTokens = ev.Usage.OutputTokens
 				}
 				fmt.Printf("[anthropic] message_delta usage: input=%d, output=%d (merged)\n", usageData.InputTokens, usageData.OutputTokens)
+				dispatch.Emit(UsageReported(&TokenUsage{
+					InputTokens:    usageData.InputTokens,
+					OutputTokens:   usageData.OutputTokens,
+					TotalTokens:    usageData.InputTokens + usageData.OutputTokens,
+					CountingMethod: "provider_api",
+				}))
 			}
 
 		case "content_block":
@@ -663,17 +656,7 @@ func StreamClaudeWithMessages(
 					// Complete text block
 					cr.TextContent = append(cr.TextContent, block.Text)
 					accumulatedText.WriteString(block.Text)
-
-					// Send to client
-					if streamCtx != nil && streamCtx.Client != nil {
-						payload := &libraries.ChatMessageResponsePayload{
-							Message: block.Text,
-						}
-						if streamCtx.BoardId != "" {
-							payload.BoardId = streamCtx.BoardId
-						}
-						libraries.SendChatMessageResponse(streamCtx.Hub, streamCtx.Client, libraries.WebSocketMessageTypeChatResponse, payload)
-					}
+					dispatch.Emit(TextDelta(block.Text))
 				} else if block.Type == "tool_use" {
 					// Complete tool use block - this might contain the full input
 					toolUse := ToolUse{
@@ -719,10 +702,7 @@ func StreamClaudeWithMessages(
 			}
 		}
 	}
-
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("scanner error: %w", err)
-	}
+	dispatch.Emit(StreamDone())
 
 	// Finalize any remaining text
 	if currentTextBuilder.Len() > 0 {
@@ -753,19 +733,18 @@ func StreamClaudeWithMessages(
 		cr.TextContent = append(cr.TextContent, accumulatedText.String())
 	}
 
-	// Capture accumulated thinking content
-	if currentThinkingBuilder.Len() > 0 {
-		cr.ThinkingContent = currentThinkingBuilder.String()
-	}
+	// Capture the thinking blocks, signatures included, for the next request of the tool loop
+	cr.ThinkingBlocks = thinking.Blocks()
+	cr.ThinkingContent = thinkingText(cr.ThinkingBlocks)
 
 	// Store usage data in RawResponse for token extraction
-	if usageData != nil {
+	if usage := dispatch.Usage(); usage != nil {
 		if rawMap, ok := cr.RawResponse.(map[string]interface{}); ok {
 			rawMap["usage"] = map[string]interface{}{
-				"input_tokens":  usageData.InputTokens,
-				"output_tokens": usageData.OutputTokens,
+				"input_tokens":  usage.InputTokens,
+				"output_tokens": usage.OutputTokens,
 			}
-			fmt.Printf("[anthropic] Stored usage in RawResponse: input=%d, output=%d\n", usageData.InputTokens, usageData.OutputTokens)
+			fmt.Printf("[anthropic] Stored usage in RawResponse: input=%d, output=%d\n", usage.InputTokens, usage.OutputTokens)
 		}
 	}
 
@@ -775,9 +754,12 @@ func StreamClaudeWithMessages(
 // === Updated ExecuteToolFlow that uses dynamic dispatcher ===
 func ChatWithTools(ctx context.Context, systemMessage string, messages []Message, tools []map[string]interface{}, streamCtx *StreamingContext, temperature *float32, maxTokens *int, modelID string, enableThinking bool) (*ClaudeResponse, error) {
 	maxIterations := constants.GetMaxIterations(ctx)
+	// malformed tool calls get one corrected retry per tool, out of the same iteration budget
+	ctx = WithToolInputRetries(ctx)
 
 	workingMessages := make([]Message, 0, len(messages)+6)
 	workingMessages = append(workingMessages, messages...)
+	images := newToolLoopImages(len(workingMessages))
 
 	var lastResp *ClaudeResponse
 
@@ -870,25 +852,13 @@ func ChatWithTools(ctx context.Context, systemMessage string, messages []Message
 			toolResultsContent = append(toolResultsContent, FormatAnthropicToolResult(execResult))
 		}
 
-		// Append assistant message (what was returned earlier)
-		assistantContent := []map[string]interface{}{}
-		for _, t := range cr.TextContent {
-			assistantContent = append(assistantContent, map[string]interface{}{
-				"type": "text",
-				"text": t,
-			})
-		}
-		for _, tu := range cr.ToolUses {
-			assistantContent = append(assistantContent, map[string]interface{}{
-				"type":  "tool_use",
-				"id":    tu.ID,
-				"name":  tu.Name,
-				"input": tu.Input,
-			})
-		}
+		images.compact(workingMessages)
+
+		// Append assistant message (what was returned earlier), thinking blocks first so the
+		// signatures Claude checks when thinking is on come back unchanged
 		workingMessages = append(workingMessages, Message{
 			Role:    "assistant",
-			Content: assistantContent,
+			Content: anthropicAssistantContent(cr),
 		})
 
 		// Append user message with tool results
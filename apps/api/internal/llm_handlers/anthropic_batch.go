@@ -0,0 +1,320 @@
+package llmHandlers
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"melina-studio-backend/internal/config"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	anthropicAPIBaseURL = "https://api.anthropic.com/v1"
+	anthropicAPIVersion = "2023-06-01"
+	batchPollInterval   = 30 * time.Second
+	batchMaxTokens      = 8192
+	// anthropicBatchProvider names the direct Anthropic API in the privacy audit
+	anthropicBatchProvider Provider = "anthropic"
+)
+
+// anthropicBatchModels maps the registry's Claude models to Anthropic API model IDs; only these can be batched
+var anthropicBatchModels = map[string]string{
+	"claude-4.5-sonnet":         "claude-sonnet-4-5-20250929",
+	"claude-4-opus":             "claude-opus-4-20250514",
+	"bedrock/claude-4.5-sonnet": "claude-sonnet-4-5-20250929",
+	"bedrock/claude-4-opus":     "claude-opus-4-20250514",
+}
+
+// BatchRequest is one completion of a batch; CustomID identifies its result
+type BatchRequest struct {
+	CustomID      string
+	SystemMessage string
+	Messages      []Message
+	Temperature   *float32
+	MaxTokens     int
+}
+
+// BatchResult is the outcome of one request of a batch
+type BatchResult struct {
+	CustomID string
+	Text     string
+	Usage    *TokenUsage
+	Err      error
+}
+
+// BatchAvailable reports whether jobs for the model can go through the Anthropic Message Batches API,
+// which is billed at half the interactive price but may take minutes to hours
+func BatchAvailable(modelName string) bool {
+	_, ok := anthropicBatchModels[modelName]
+	return ok && config.GetSettings().LLM.AnthropicAPIKey != ""
+}
+
+// BatchMaxWait is how long a job waits for a batch before canceling it and running interactively
+func BatchMaxWait() time.Duration {
+	return config.GetSettings().LLM.BatchMaxWait
+}
+
+// CompleteBatch runs a single completion through the batch API
+func CompleteBatch(ctx context.Context, modelName string, systemMessage string, input string, temperature *float32) (string, *TokenUsage, error) {
+	results, err := RunBatch(ctx, modelName, []BatchRequest{{
+		CustomID:      "completion",
+		SystemMessage: systemMessage,
+		Messages:      []Message{{Role: "user", Content: input}},
+		Temperature:   temperature,
+	}})
+	if err != nil {
+		return "", nil, err
+	}
+	if len(results) != 1 {
+		return "", nil, fmt.Errorf("anthropic batch returned %d results", len(results))
+	}
+	return results[0].Text, results[0].Usage, results[0].Err
+}
+
+// RunBatch submits the requests as one batch and waits until it ends; when ctx is done the batch is canceled
+func RunBatch(ctx context.Context, modelName string, requests []BatchRequest) ([]BatchResult, error) {
+	modelID, ok := anthropicBatchModels[modelName]
+	if !ok {
+		return nil, fmt.Errorf("model %s can't be batched", modelName)
+	}
+	apiKey := config.GetSettings().LLM.AnthropicAPIKey
+	if apiKey == "" {
+		return nil, fmt.Errorf("ANTHROPIC_API_KEY must be set")
+	}
+	// the Anthropic API routes requests globally, so pinned requests run interactively on a regional provider
+	if region := dataRegionFrom(ctx); region != "" {
+		return nil, fmt.Errorf("%w: the batch API has no %s endpoint", ErrNoCompliantRegion, strings.ToUpper(string(region)))
+	}
+	for _, request := range requests {
+		recordPrivacyAudit(ctx, anthropicBatchProvider, request.Messages)
+	}
+	c := &anthropicBatchClient{
+		baseURL:      anthropicAPIBaseURL,
+		apiKey:       apiKey,
+		httpClient:   &http.Client{Timeout: time.Minute},
+		pollInterval: batchPollInterval,
+	}
+	return c.run(ctx, modelID, requests)
+}
+
+type anthropicBatchClient struct {
+	baseURL      string
+	apiKey       string
+	httpClient   *http.Client
+	pollInterval time.Duration
+}
+
+type anthropicBatch struct {
+	ID               string `json:"id"`
+	ProcessingStatus string `json:"processing_status"` // in_progress, canceling or ended
+	ResultsURL       string `json:"results_url"`
+}
+
+type anthropicBatchResultLine struct {
+	CustomID string `json:"custom_id"`
+	Result   struct {
+		Type    string `json:"type"` // succeeded, errored, canceled or expired
+		Message struct {
+			Content []struct {
+				Type string `json:"type"`
+				Text string `json:"text"`
+			} `json:"content"`
+			Usage streamUsage `json:"usage"`
+		} `json:"message"`
+		Error struct {
+			Error struct {
+				Type    string `json:"type"`
+				Message string `json:"message"`
+			} `json:"error"`
+		} `json:"error"`
+	} `json:"result"`
+}
+
+func (c *anthropicBatchClient) run(ctx context.Context, modelID string, requests []BatchRequest) ([]BatchResult, error) {
+	batch, err := c.create(ctx, modelID, requests)
+	if err != nil {
+		return nil, err
+	}
+	fmt.Printf("[anthropic_batch] Submitted batch %s with %d requests\n", batch.ID, len(requests))
+
+	ticker := time.NewTicker(c.pollInterval)
+	defer ticker.Stop()
+	for batch.ProcessingStatus != "ended" {
+		select {
+		case <-ctx.Done():
+			c.cancel(batch.ID)
+			return nil, ctx.Err()
+		case <-ticker.C:
+		}
+		if err := c.do(ctx, http.MethodGet, c.baseURL+"/messages/batches/"+batch.ID, nil, batch); err != nil {
+			// the job gives up on the batch either way
+			c.cancel(batch.ID)
+			if ctx.Err() != nil {
+				return nil, ctx.Err()
+			}
+			return nil, err
+		}
+	}
+
+	return c.results(ctx, batch.ResultsURL, requests)
+}
+
+// cancel stops a batch the job gave up on, so its remaining requests aren't billed
+func (c *anthropicBatchClient) cancel(batchID string) {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	if err := c.do(ctx, http.MethodPost, c.baseURL+"/messages/batches/"+batchID+"/cancel", nil, nil); err != nil {
+		fmt.Printf("[anthropic_batch] Failed to cancel batch %s: %v\n", batchID, err)
+	}
+}
+
+func (c *anthropicBatchClient) create(ctx context.Context, modelID string, requests []BatchRequest) (*anthropicBatch, error) {
+	items := make([]map[string]interface{}, len(requests))
+	for i, r := range requests {
+		maxTokens := r.MaxTokens
+		if maxTokens <= 0 {
+			maxTokens = batchMaxTokens
+		}
+		msgs := make([]map[string]interface{}, len(r.Messages))
+		for j, m := range r.Messages {
+			msgs[j] = map[string]interface{}{"role": m.Role, "content": m.Content}
+		}
+		params := map[string]interface{}{
+			"model":      modelID,
+			"max_tokens": maxTokens,
+			"messages":   msgs,
+		}
+		if r.SystemMessage != "" {
+			params["system"] = r.SystemMessage
+		}
+		if r.Temperature != nil {
+			params["temperature"] = *r.Temperature
+		}
+		items[i] = map[string]interface{}{"custom_id": r.CustomID, "params": params}
+	}
+
+	var batch anthropicBatch
+	if err := c.do(ctx, http.MethodPost, c.baseURL+"/messages/batches", map[string]interface{}{"requests": items}, &batch); err != nil {
+		return nil, err
+	}
+	return &batch, nil
+}
+
+// results downloads the JSONL results and returns them in the order of the requests
+func (c *anthropicBatchClient) results(ctx context.Context, resultsURL string, requests []BatchRequest) ([]BatchResult, error) {
+	if resultsURL == "" {
+		return nil, fmt.Errorf("anthropic batch ended without results")
+	}
+	resp, err := c.send(ctx, http.MethodGet, resultsURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	byID := make(map[string]BatchResult, len(requests))
+	scanner := bufio.NewScanner(resp.Body)
+	scanner.Buffer(make([]byte, 0, 64*1024), 16*1024*1024)
+	for scanner.Scan() {
+		line := bytes.TrimSpace(scanner.Bytes())
+		if len(line) == 0 {
+			continue
+		}
+		var item anthropicBatchResultLine
+		if err := json.Unmarshal(line, &item); err != nil {
+			return nil, fmt.Errorf("failed to decode batch result: %w", err)
+		}
+		byID[item.CustomID] = toBatchResult(item)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read batch results: %w", err)
+	}
+
+	results := make([]BatchResult, len(requests))
+	for i, r := range requests {
+		result, ok := byID[r.CustomID]
+		if !ok {
+			result = BatchResult{CustomID: r.CustomID, Err: errors.New("missing from the batch results")}
+		}
+		results[i] = result
+	}
+	return results, nil
+}
+
+func toBatchResult(item anthropicBatchResultLine) BatchResult {
+	result := BatchResult{CustomID: item.CustomID}
+	switch item.Result.Type {
+	case "succeeded":
+		var parts []string
+		for _, block := range item.Result.Message.Content {
+			if block.Type == "text" {
+				parts = append(parts, block.Text)
+			}
+		}
+		result.Text = strings.Join(parts, "\n\n")
+		usage := item.Result.Message.Usage
+		result.Usage = &TokenUsage{
+			InputTokens:    usage.InputTokens,
+			OutputTokens:   usage.OutputTokens,
+			TotalTokens:    usage.InputTokens + usage.OutputTokens,
+			CountingMethod: "provider_api",
+		}
+	case "errored":
+		result.Err = fmt.Errorf("batch request failed: %s: %s", item.Result.Error.Error.Type, item.Result.Error.Error.Message)
+	default:
+		result.Err = fmt.Errorf("batch request %s", item.Result.Type)
+	}
+	return result
+}
+
+// do sends a JSON request and decodes the JSON response into out, when given
+func (c *anthropicBatchClient) do(ctx context.Context, method string, url string, body interface{}, out interface{}) error {
+	resp, err := c.send(ctx, method, url, body)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("failed to decode anthropic response: %w", err)
+	}
+	return nil
+}
+
+func (c *anthropicBatchClient) send(ctx context.Context, method string, url string, body interface{}) (*http.Response, error) {
+	var reader io.Reader
+	if body != nil {
+		payload, err := json.Marshal(body)
+		if err != nil {
+			return nil, err
+		}
+		reader = bytes.NewReader(payload)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, reader)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("x-api-key", c.apiKey)
+	req.Header.Set("anthropic-version", anthropicAPIVersion)
+	if body != nil {
+		req.Header.Set("content-type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("anthropic batch request: %w", err)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		defer resp.Body.Close()
+		data, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return nil, fmt.Errorf("anthropic batch API error %d: %s", resp.StatusCode, strings.TrimSpace(string(data)))
+	}
+	return resp, nil
+}
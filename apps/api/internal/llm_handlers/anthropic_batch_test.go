@@ -0,0 +1,96 @@
+package llmHandlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestAnthropicBatchRun(t *testing.T) {
+	var polls atomic.Int32
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("x-api-key") != "test-key" || r.Header.Get("anthropic-version") == "" {
+			t.Errorf("missing auth headers on %s", r.URL.Path)
+		}
+		switch {
+		case r.Method == http.MethodPost && r.URL.Path == "/messages/batches":
+			var body struct {
+				Requests []struct {
+					CustomID string                 `json:"custom_id"`
+					Params   map[string]interface{} `json:"params"`
+				} `json:"requests"`
+			}
+			if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+				t.Fatalf("decode: %v", err)
+			}
+			if len(body.Requests) != 2 || body.Requests[0].Params["model"] != "claude-test" || body.Requests[0].Params["system"] != "be brief" {
+				t.Errorf("unexpected batch: %+v", body)
+			}
+			fmt.Fprint(w, `{"id":"msgbatch_1","processing_status":"in_progress"}`)
+		case r.Method == http.MethodGet && r.URL.Path == "/messages/batches/msgbatch_1":
+			if polls.Add(1) < 2 {
+				fmt.Fprint(w, `{"id":"msgbatch_1","processing_status":"in_progress"}`)
+				return
+			}
+			fmt.Fprintf(w, `{"id":"msgbatch_1","processing_status":"ended","results_url":"%s/results"}`, server.URL)
+		case r.URL.Path == "/results":
+			// results come back in any order
+			fmt.Fprintln(w, `{"custom_id":"b","result":{"type":"errored","error":{"type":"error","error":{"type":"overloaded_error","message":"busy"}}}}`)
+			fmt.Fprintln(w, `{"custom_id":"a","result":{"type":"succeeded","message":{"content":[{"type":"text","text":"hello"}],"usage":{"input_tokens":10,"output_tokens":3}}}}`)
+		default:
+			t.Errorf("unexpected request %s %s", r.Method, r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	c := &anthropicBatchClient{baseURL: server.URL, apiKey: "test-key", httpClient: server.Client(), pollInterval: time.Millisecond}
+	results, err := c.run(context.Background(), "claude-test", []BatchRequest{
+		{CustomID: "a", SystemMessage: "be brief", Messages: []Message{{Role: "user", Content: "hi"}}},
+		{CustomID: "b", SystemMessage: "be brief", Messages: []Message{{Role: "user", Content: "hi again"}}},
+	})
+	if err != nil {
+		t.Fatalf("run: %v", err)
+	}
+	if len(results) != 2 || results[0].CustomID != "a" || results[1].CustomID != "b" {
+		t.Fatalf("results not in request order: %+v", results)
+	}
+	if results[0].Text != "hello" || results[0].Err != nil || results[0].Usage.TotalTokens != 13 {
+		t.Errorf("unexpected first result: %+v", results[0])
+	}
+	if results[1].Err == nil {
+		t.Error("expected the errored request to carry an error")
+	}
+}
+
+func TestAnthropicBatchCancelsOnContextDone(t *testing.T) {
+	canceled := make(chan struct{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/messages/batches/msgbatch_1/cancel":
+			canceled <- struct{}{}
+			fmt.Fprint(w, `{"id":"msgbatch_1","processing_status":"canceling"}`)
+		default:
+			fmt.Fprint(w, `{"id":"msgbatch_1","processing_status":"in_progress"}`)
+		}
+	}))
+	defer server.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	c := &anthropicBatchClient{baseURL: server.URL, apiKey: "test-key", httpClient: server.Client(), pollInterval: time.Millisecond}
+	if _, err := c.run(ctx, "claude-test", []BatchRequest{{CustomID: "a"}}); err == nil {
+		t.Fatal("expected the deadline to end the wait")
+	}
+	select {
+	case <-canceled:
+	default:
+		t.Error("the batch was not canceled")
+	}
+}
@@ -0,0 +1,120 @@
+package llmHandlers
+
+import (
+	"strings"
+)
+
+// interleavedThinkingBeta lets Claude think between tool calls instead of only before the first one
+const interleavedThinkingBeta = "interleaved-thinking-2025-05-14"
+
+// ThinkingBlock is a thinking or redacted_thinking block Claude returned
+// In a tool-use loop it must go back unchanged, signature included, in the assistant turn it came from
+type ThinkingBlock struct {
+	Thinking  string
+	Signature string
+	Redacted  string // data of a redacted_thinking block; Thinking and Signature are empty then
+}
+
+// supportsInterleavedThinking reports whether the Claude model accepts the interleaved thinking beta (Claude 4 and later)
+func supportsInterleavedThinking(modelID string) bool {
+	return strings.Contains(modelID, "claude") && !strings.Contains(modelID, "claude-3")
+}
+
+// useInterleavedThinking reports whether a request should ask for interleaved thinking: it only
+// changes anything when Claude is thinking and can call tools
+func useInterleavedThinking(modelID string, enableThinking bool, tools []map[string]interface{}) bool {
+	return enableThinking && len(tools) > 0 && supportsInterleavedThinking(modelID)
+}
+
+// contentBlock returns the block as it is replayed in an assistant message; ok is false for a
+// thinking block without a signature, which Claude would reject
+func (b ThinkingBlock) contentBlock() (map[string]interface{}, bool) {
+	if b.Redacted != "" {
+		return map[string]interface{}{"type": "redacted_thinking", "data": b.Redacted}, true
+	}
+	if b.Signature == "" {
+		return nil, false
+	}
+	return map[string]interface{}{"type": "thinking", "thinking": b.Thinking, "signature": b.Signature}, true
+}
+
+// parseThinkingBlock reads a thinking or redacted_thinking block of a non-streamed response
+func parseThinkingBlock(block map[string]interface{}) (ThinkingBlock, bool) {
+	switch block["type"] {
+	case "thinking":
+		thinking, _ := block["thinking"].(string)
+		signature, _ := block["signature"].(string)
+		return ThinkingBlock{Thinking: thinking, Signature: signature}, true
+	case "redacted_thinking":
+		data, _ := block["data"].(string)
+		return ThinkingBlock{Redacted: data}, true
+	}
+	return ThinkingBlock{}, false
+}
+
+// thinkingText joins the readable thinking of a response; with interleaved thinking there can be several blocks
+func thinkingText(blocks []ThinkingBlock) string {
+	parts := make([]string, 0, len(blocks))
+	for _, b := range blocks {
+		if b.Thinking != "" {
+			parts = append(parts, b.Thinking)
+		}
+	}
+	return strings.Join(parts, "\n\n")
+}
+
+// streamedThinking collects the thinking blocks of a streamed response by content block index
+type streamedThinking struct {
+	order  []int
+	blocks map[int]*ThinkingBlock
+}
+
+func newStreamedThinking() *streamedThinking {
+	return &streamedThinking{blocks: map[int]*ThinkingBlock{}}
+}
+
+// at returns the block at the index, starting one if the stream had not announced it
+func (s *streamedThinking) at(index int) *ThinkingBlock {
+	b, ok := s.blocks[index]
+	if !ok {
+		b = &ThinkingBlock{}
+		s.blocks[index] = b
+		s.order = append(s.order, index)
+	}
+	return b
+}
+
+// Blocks returns the collected blocks in the order they started
+func (s *streamedThinking) Blocks() []ThinkingBlock {
+	blocks := make([]ThinkingBlock, 0, len(s.order))
+	for _, idx := range s.order {
+		blocks = append(blocks, *s.blocks[idx])
+	}
+	return blocks
+}
+
+// anthropicAssistantContent rebuilds an assistant turn for the next request of a tool-use loop
+// Thinking blocks lead, as Claude returns them, followed by the text and the tool calls
+func anthropicAssistantContent(cr *ClaudeResponse) []map[string]interface{} {
+	content := make([]map[string]interface{}, 0, len(cr.ThinkingBlocks)+len(cr.TextContent)+len(cr.ToolUses))
+	for _, tb := range cr.ThinkingBlocks {
+		if block, ok := tb.contentBlock(); ok {
+			content = append(content, block)
+		}
+	}
+	for _, t := range cr.TextContent {
+		content = append(content, map[string]interface{}{
+			"type": "text",
+			"text": t,
+		})
+	}
+	for _, tu := range cr.ToolUses {
+		content = append(content, map[string]interface{}{
+			"type":  "tool_use",
+			"id":    tu.ID,
+			"name":  tu.Name,
+			"input": tu.Input,
+		})
+	}
+	return content
+}
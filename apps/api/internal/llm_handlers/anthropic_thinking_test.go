@@ -0,0 +1,86 @@
+package llmHandlers
+
+import (
+	"testing"
+)
+
+func TestUseInterleavedThinking(t *testing.T) {
+	tools := []map[string]interface{}{{"name": "addShape"}}
+	tests := []struct {
+		model    string
+		thinking bool
+		tools    []map[string]interface{}
+		want     bool
+	}{
+		{"claude-sonnet-4-5@20250929", true, tools, true},
+		{"us.anthropic.claude-opus-4-1-20250805-v1:0", true, tools, true},
+		{"claude-3-7-sonnet@20250219", true, tools, false},
+		{"claude-sonnet-4-5@20250929", false, tools, false},
+		{"claude-sonnet-4-5@20250929", true, nil, false},
+	}
+	for _, tt := range tests {
+		if got := useInterleavedThinking(tt.model, tt.thinking, tt.tools); got != tt.want {
+			t.Errorf("useInterleavedThinking(%q, %v, %d tools) = %v", tt.model, tt.thinking, len(tt.tools), got)
+		}
+	}
+}
+
+func TestStreamedThinkingKeepsBlocksAndSignaturesInOrder(t *testing.T) {
+	thinking := newStreamedThinking()
+	thinking.at(0)
+	thinking.at(0).Thinking += "first "
+	thinking.at(0).Thinking += "step"
+	thinking.at(0).Signature = "sig-0"
+	thinking.at(2).Redacted = "opaque"
+	thinking.at(3).Thinking += "after the tool"
+	thinking.at(3).Signature = "sig-3"
+
+	blocks := thinking.Blocks()
+	if len(blocks) != 3 {
+		t.Fatalf("blocks = %+v", blocks)
+	}
+	if blocks[0] != (ThinkingBlock{Thinking: "first step", Signature: "sig-0"}) || blocks[1].Redacted != "opaque" || blocks[2].Signature != "sig-3" {
+		t.Fatalf("blocks = %+v", blocks)
+	}
+	if got := thinkingText(blocks); got != "first step\n\nafter the tool" {
+		t.Fatalf("thinking text = %q", got)
+	}
+}
+
+func TestAnthropicAssistantContentReplaysSignedThinkingFirst(t *testing.T) {
+	cr := &ClaudeResponse{
+		ThinkingBlocks: []ThinkingBlock{
+			{Thinking: "plan", Signature: "sig"},
+			{Thinking: "unsigned"},
+			{Redacted: "opaque"},
+		},
+		TextContent: []string{"Drawing"},
+		ToolUses:    []ToolUse{{ID: "t1", Name: "addShape", Input: map[string]interface{}{"shapeType": "rect"}}},
+	}
+
+	content := anthropicAssistantContent(cr)
+	var types []string
+	for _, block := range content {
+		types = append(types, block["type"].(string))
+	}
+	if len(types) != 4 || types[0] != "thinking" || types[1] != "redacted_thinking" || types[2] != "text" || types[3] != "tool_use" {
+		t.Fatalf("block types = %v", types)
+	}
+	if content[0]["signature"] != "sig" || content[0]["thinking"] != "plan" || content[1]["data"] != "opaque" {
+		t.Fatalf("thinking blocks = %v", content[:2])
+	}
+}
+
+func TestParseThinkingBlock(t *testing.T) {
+	tb, ok := parseThinkingBlock(map[string]interface{}{"type": "thinking", "thinking": "plan", "signature": "sig"})
+	if !ok || tb.Thinking != "plan" || tb.Signature != "sig" {
+		t.Fatalf("thinking = %+v, %v", tb, ok)
+	}
+	tb, ok = parseThinkingBlock(map[string]interface{}{"type": "redacted_thinking", "data": "opaque"})
+	if !ok || tb.Redacted != "opaque" {
+		t.Fatalf("redacted = %+v, %v", tb, ok)
+	}
+	if _, ok := parseThinkingBlock(map[string]interface{}{"type": "text", "text": "hi"}); ok {
+		t.Fatal("expected a text block to be ignored")
+	}
+}
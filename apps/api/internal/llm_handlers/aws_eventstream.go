@@ -0,0 +1,102 @@
+package llmHandlers
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"io"
+)
+
+// awsEventMessage is one decoded message of an AWS event stream (application/vnd.amazon.eventstream)
+type awsEventMessage struct {
+	Headers map[string]string
+	Payload []byte
+}
+
+// awsEventStreamMaxMessage guards against reading a corrupt length prefix as a huge allocation
+const awsEventStreamMaxMessage = 16 * 1024 * 1024
+
+// readAWSEventMessage reads the next message from the stream, returning io.EOF at a clean end
+// Layout: total length, headers length, prelude CRC, headers, payload, message CRC (all big endian)
+func readAWSEventMessage(r io.Reader) (*awsEventMessage, error) {
+	prelude := make([]byte, 12)
+	if _, err := io.ReadFull(r, prelude); err != nil {
+		return nil, err
+	}
+	totalLen := binary.BigEndian.Uint32(prelude[0:4])
+	headersLen := binary.BigEndian.Uint32(prelude[4:8])
+	if crc32.ChecksumIEEE(prelude[0:8]) != binary.BigEndian.Uint32(prelude[8:12]) {
+		return nil, fmt.Errorf("event stream: prelude checksum mismatch")
+	}
+	if totalLen < 16 || totalLen > awsEventStreamMaxMessage || headersLen > totalLen-16 {
+		return nil, fmt.Errorf("event stream: invalid message length %d", totalLen)
+	}
+
+	rest := make([]byte, totalLen-12)
+	if _, err := io.ReadFull(r, rest); err != nil {
+		return nil, fmt.Errorf("event stream: %w", err)
+	}
+	crcOffset := len(rest) - 4
+	digest := crc32.NewIEEE()
+	digest.Write(prelude)
+	digest.Write(rest[:crcOffset])
+	if digest.Sum32() != binary.BigEndian.Uint32(rest[crcOffset:]) {
+		return nil, fmt.Errorf("event stream: message checksum mismatch")
+	}
+
+	headers, err := parseAWSEventHeaders(rest[:headersLen])
+	if err != nil {
+		return nil, err
+	}
+	return &awsEventMessage{Headers: headers, Payload: rest[headersLen:crcOffset]}, nil
+}
+
+// parseAWSEventHeaders decodes the header block; only string values are kept, other types are skipped
+func parseAWSEventHeaders(b []byte) (map[string]string, error) {
+	headers := map[string]string{}
+	for len(b) > 0 {
+		nameLen := int(b[0])
+		if len(b) < 1+nameLen+1 {
+			return nil, fmt.Errorf("event stream: truncated header")
+		}
+		name := string(b[1 : 1+nameLen])
+		valueType := b[1+nameLen]
+		b = b[2+nameLen:]
+
+		var size int
+		switch valueType {
+		case 0, 1: // bool true / false
+			size = 0
+		case 2: // byte
+			size = 1
+		case 3: // short
+			size = 2
+		case 4: // int
+			size = 4
+		case 5, 8: // long, timestamp
+			size = 8
+		case 9: // uuid
+			size = 16
+		case 6, 7: // bytes, string
+			if len(b) < 2 {
+				return nil, fmt.Errorf("event stream: truncated header value")
+			}
+			valueLen := int(binary.BigEndian.Uint16(b[0:2]))
+			if len(b) < 2+valueLen {
+				return nil, fmt.Errorf("event stream: truncated header value")
+			}
+			if valueType == 7 {
+				headers[name] = string(b[2 : 2+valueLen])
+			}
+			b = b[2+valueLen:]
+			continue
+		default:
+			return nil, fmt.Errorf("event stream: unknown header type %d", valueType)
+		}
+		if len(b) < size {
+			return nil, fmt.Errorf("event stream: truncated header value")
+		}
+		b = b[size:]
+	}
+	return headers, nil
+}
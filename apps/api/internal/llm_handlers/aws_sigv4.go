@@ -0,0 +1,25 @@
+package llmHandlers
+
+import (
+	"net/http"
+	"time"
+
+	"melina-studio-backend/internal/libraries"
+)
+
+// awsCredentials are the static credentials used to sign Bedrock requests
+type awsCredentials = libraries.AWSCredentials
+
+// signAWSRequest signs the request in place with AWS Signature Version 4; the signer lives in libraries,
+// which the S3 export destination shares
+func signAWSRequest(req *http.Request, body []byte, creds awsCredentials, region string, service string, now time.Time) {
+	libraries.SignAWSRequest(req, body, creds, region, service, now)
+}
+
+func awsCanonicalURI(escapedPath string) string {
+	return libraries.AWSCanonicalURI(escapedPath)
+}
+
+func awsURIEscape(s string) string {
+	return libraries.AWSURIEscape(s)
+}
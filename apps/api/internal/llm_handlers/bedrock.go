@@ -0,0 +1,659 @@
+package llmHandlers
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"melina-studio-backend/internal/config"
+	"melina-studio-backend/internal/constants"
+	"melina-studio-backend/internal/libraries"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+)
+
+// BedrockClient implements llm.Client on top of the Bedrock Converse API
+// Messages keep the Anthropic content block format used by the rest of the app and are
+// converted to Converse blocks per request, so Claude and Llama share one code path
+type BedrockClient struct {
+	ModelID     string                   // Bedrock model ID, e.g. "anthropic.claude-sonnet-4-5-20250929-v1:0"
+	Tools       []map[string]interface{} // Anthropic tool definitions, converted to toolSpec
+	Temperature *float32                 // Optional: nil means use default
+	MaxTokens   *int                     // Optional: nil means use default
+	httpClient  *http.Client
+}
+
+func NewBedrockClient(modelID string, tools []map[string]interface{}, temperature *float32, maxTokens *int) (*BedrockClient, error) {
+	aws := config.GetSettings().AWS
+	if aws.BedrockAPIKey == "" && (aws.AccessKeyID == "" || aws.SecretAccessKey == "") {
+		return nil, fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY or AWS_BEARER_TOKEN_BEDROCK must be set")
+	}
+	if modelID == "" {
+		modelID = "anthropic.claude-sonnet-4-5-20250929-v1:0" // default
+	}
+	return &BedrockClient{
+		ModelID:     modelID,
+		Tools:       tools,
+		Temperature: temperature,
+		MaxTokens:   maxTokens,
+		// streaming responses stay open for the whole answer, so only bound the connection setup
+		httpClient: &http.Client{Transport: &http.Transport{
+			Proxy:                 http.ProxyFromEnvironment,
+			TLSHandshakeTimeout:   10 * time.Second,
+			ResponseHeaderTimeout: 2 * time.Minute,
+		}},
+	}, nil
+}
+
+func (c *BedrockClient) Chat(ctx context.Context, systemMessage string, messages []Message, enableThinking bool) (string, error) {
+	resp, err := c.chatWithTools(ctx, systemMessage, messages, nil, enableThinking)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(resp.TextContent, "\n\n"), nil
+}
+
+func (c *BedrockClient) ChatStream(ctx context.Context, hub *libraries.Hub, client *libraries.Client, boardId string, systemMessage string, messages []Message, enableThinking bool) (string, error) {
+	var streamCtx *StreamingContext
+	if client != nil {
+		streamCtx = &StreamingContext{
+			Hub:     hub,
+			Client:  client,
+			BoardId: boardId,
+			UserID:  client.UserID,
+		}
+	}
+	resp, err := c.chatWithTools(ctx, systemMessage, messages, streamCtx, enableThinking)
+	if err != nil {
+		return "", err
+	}
+	return strings.Join(resp.TextContent, "\n\n"), nil
+}
+
+func (c *BedrockClient) ChatStreamWithUsage(req ChatStreamRequest) (*ResponseWithUsage, error) {
+	if req.BoardID == "" {
+		return nil, fmt.Errorf("boardId is required")
+	}
+
+	// Capture the last user message as input for token counting
+	var inputText string
+	for _, m := range req.Messages {
+		if text, ok := m.Content.(string); ok && m.Role == "user" {
+			inputText = text
+		}
+	}
+
+	var streamCtx *StreamingContext
+	if req.Client != nil {
+		streamCtx = &StreamingContext{
+			Hub:       req.Hub,
+			Client:    req.Client,
+			BoardId:   req.BoardID,
+			UserID:    req.Client.UserID,
+			LoaderGen: req.LoaderGen,
+		}
+	}
+
+	resp, err := c.chatWithTools(req.Ctx, req.SystemMessage, req.Messages, streamCtx, req.EnableThinking)
+	if err != nil {
+		return nil, err
+	}
+
+	return &ResponseWithUsage{
+		Text:       strings.Join(resp.TextContent, "\n\n"),
+		Thinking:   resp.ThinkingContent,
+		TokenUsage: ExtractAnthropicUsage(resp, inputText),
+	}, nil
+}
+
+// chatWithTools runs the tool loop in Converse format, mirroring ChatWithTools for Vertex
+// The assistant turns are replayed exactly as Bedrock returned them, so reasoning signatures survive
+func (c *BedrockClient) chatWithTools(ctx context.Context, systemMessage string, messages []Message, streamCtx *StreamingContext, enableThinking bool) (*ClaudeResponse, error) {
+	maxIterations := constants.GetMaxIterations(ctx)
+	ctx = WithToolInputRetries(ctx)
+	workingMessages := toConverseMessages(messages)
+	images := newToolLoopImages(len(workingMessages))
+	// only Claude accepts the thinking request field
+	enableThinking = enableThinking && strings.Contains(c.ModelID, "anthropic.")
+
+	var lastResp *ClaudeResponse
+	var totalInputTokens, totalOutputTokens int
+
+	for iter := 0; iter < maxIterations; iter++ {
+		cr, assistantContent, err := c.converse(ctx, systemMessage, workingMessages, streamCtx, enableThinking)
+		if err != nil {
+			return nil, err
+		}
+		lastResp = cr
+		in, out := bedrockUsage(cr)
+		totalInputTokens += in
+		totalOutputTokens += out
+
+		if len(cr.ToolUses) == 0 {
+			setBedrockUsage(cr, totalInputTokens, totalOutputTokens)
+			return cr, nil
+		}
+
+		toolCalls := make([]ToolCall, 0, len(cr.ToolUses))
+		for _, toolUse := range cr.ToolUses {
+			toolCalls = append(toolCalls, ToolCall{
+				ID:       toolUse.ID,
+				Name:     toolUse.Name,
+				Input:    toolUse.Input,
+				Provider: "bedrock",
+			})
+		}
+		execResults := ExecuteTools(ctx, toolCalls, streamCtx)
+
+		toolResults := make([]interface{}, 0, len(execResults))
+		for _, execResult := range execResults {
+			if block, ok := toConverseBlock(FormatAnthropicToolResult(execResult)); ok {
+				toolResults = append(toolResults, block)
+			}
+		}
+
+		images.compactConverse(workingMessages)
+
+		workingMessages = append(workingMessages,
+			map[string]interface{}{"role": "assistant", "content": assistantContent},
+			map[string]interface{}{"role": "user", "content": toolResults},
+		)
+	}
+
+	// Max iterations reached - ask for a text summary instead of failing, like the Vertex loop.
+	// Converse rejects toolUse history without a toolConfig, so the tools stay declared and any
+	// further tool calls in the summary are ignored
+	fmt.Printf("[bedrock] Max iterations (%d) reached. Making final call for text response.\n", maxIterations)
+	workingMessages = append(workingMessages, map[string]interface{}{
+		"role": "user",
+		"content": []interface{}{map[string]interface{}{
+			"text": "You have reached the maximum number of tool iterations. Please provide a summary of what you have accomplished so far and what remains to be done (if anything). Do not attempt to call any more tools.",
+		}},
+	})
+	// the previous user turn holds tool results, so merge instead of sending two user turns in a row
+	workingMessages = mergeConverseTurns(workingMessages)
+
+	finalResp, _, err := c.converse(ctx, systemMessage, workingMessages, streamCtx, enableThinking)
+	if err != nil || len(finalResp.TextContent) == 0 {
+		fmt.Printf("[bedrock] Warning: final summary call failed or was empty: %v. Returning last response.\n", err)
+		setBedrockUsage(lastResp, totalInputTokens, totalOutputTokens)
+		return lastResp, nil
+	}
+	in, out := bedrockUsage(finalResp)
+	setBedrockUsage(finalResp, totalInputTokens+in, totalOutputTokens+out)
+	finalResp.ToolUses = nil
+	return finalResp, nil
+}
+
+// converse sends one Converse (or ConverseStream when streaming to a client) request
+// and returns the parsed response plus the assistant content blocks to replay
+func (c *BedrockClient) converse(ctx context.Context, systemMessage string, messages []map[string]interface{}, streamCtx *StreamingContext, enableThinking bool) (*ClaudeResponse, []interface{}, error) {
+	body := c.buildConverseBody(systemMessage, messages, enableThinking)
+	stream := streamCtx != nil && streamCtx.Client != nil
+
+	action := "converse"
+	if stream {
+		action = "converse-stream"
+	}
+	resp, err := c.post(ctx, action, body)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer resp.Body.Close()
+
+	if stream {
+		return parseConverseStream(resp.Body, streamCtx)
+	}
+
+	var raw struct {
+		Output struct {
+			Message struct {
+				Content []map[string]interface{} `json:"content"`
+			} `json:"message"`
+		} `json:"output"`
+		StopReason string        `json:"stopReason"`
+		Usage      converseUsage `json:"usage"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&raw); err != nil {
+		return nil, nil, fmt.Errorf("decode response: %w", err)
+	}
+
+	cr := &ClaudeResponse{StopReason: raw.StopReason, RawResponse: map[string]interface{}{}}
+	content := make([]interface{}, 0, len(raw.Output.Message.Content))
+	for _, block := range raw.Output.Message.Content {
+		content = append(content, block)
+		if text, ok := block["text"].(string); ok {
+			cr.TextContent = append(cr.TextContent, text)
+		}
+		if toolUse, ok := block["toolUse"].(map[string]interface{}); ok {
+			id, _ := toolUse["toolUseId"].(string)
+			name, _ := toolUse["name"].(string)
+			input, _ := toolUse["input"].(map[string]interface{})
+			cr.ToolUses = append(cr.ToolUses, ToolUse{ID: id, Name: name, Input: input})
+		}
+		if reasoning, ok := block["reasoningContent"].(map[string]interface{}); ok {
+			if reasoningText, ok := reasoning["reasoningText"].(map[string]interface{}); ok {
+				text, _ := reasoningText["text"].(string)
+				cr.ThinkingContent += text
+			}
+		}
+	}
+	setBedrockUsage(cr, raw.Usage.InputTokens, raw.Usage.OutputTokens)
+	return cr, content, nil
+}
+
+func (c *BedrockClient) buildConverseBody(systemMessage string, messages []map[string]interface{}, enableThinking bool) map[string]interface{} {
+	maxTokensValue := 1024 // default
+	if c.MaxTokens != nil {
+		maxTokensValue = *c.MaxTokens
+	}
+	inferenceConfig := map[string]interface{}{"maxTokens": maxTokensValue}
+	if c.Temperature != nil {
+		inferenceConfig["temperature"] = *c.Temperature
+	}
+
+	body := map[string]interface{}{
+		"messages":        messages,
+		"inferenceConfig": inferenceConfig,
+	}
+	if systemMessage != "" {
+		body["system"] = []map[string]interface{}{{"text": systemMessage}}
+	}
+
+	if enableThinking {
+		thinkingBudget := 1024
+		additional := map[string]interface{}{
+			"thinking": map[string]interface{}{
+				"type":          "enabled",
+				"budget_tokens": thinkingBudget,
+			},
+		}
+		// Bedrock takes Anthropic beta flags in the request body rather than a header
+		if useInterleavedThinking(c.ModelID, enableThinking, c.Tools) {
+			additional["anthropic_beta"] = []string{interleavedThinkingBeta}
+		}
+		body["additionalModelRequestFields"] = additional
+		// Claude only allows the default temperature with thinking, and maxTokens must exceed the budget
+		delete(inferenceConfig, "temperature")
+		if maxTokensValue <= thinkingBudget {
+			inferenceConfig["maxTokens"] = thinkingBudget + 1024
+		}
+	}
+
+	if len(c.Tools) > 0 {
+		specs := make([]map[string]interface{}, 0, len(c.Tools))
+		for _, tool := range c.Tools {
+			specs = append(specs, map[string]interface{}{
+				"toolSpec": map[string]interface{}{
+					"name":        tool["name"],
+					"description": tool["description"],
+					"inputSchema": map[string]interface{}{"json": tool["input_schema"]},
+				},
+			})
+		}
+		body["toolConfig"] = map[string]interface{}{"tools": specs}
+	}
+	return body
+}
+
+// post sends a Converse request, authenticating with the Bedrock API key when set and SigV4 otherwise
+func (c *BedrockClient) post(ctx context.Context, action string, body map[string]interface{}) (*http.Response, error) {
+	aws := config.GetSettings().AWS
+	region := regionalLocation(ctx, ProviderBedrock, aws.Region)
+	// a request pinned to a data region uses that region's inference profile, which keeps it in the region
+	profilePrefix := aws.BedrockProfilePrefix
+	if dataRegion := dataRegionFrom(ctx); dataRegion != "" && profilePrefix != "" {
+		profilePrefix = string(dataRegion) + "."
+	}
+	modelID := c.ModelID
+	if profilePrefix != "" && !strings.HasPrefix(modelID, profilePrefix) {
+		modelID = profilePrefix + modelID
+	}
+
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return nil, fmt.Errorf("marshal body: %w", err)
+	}
+
+	endpoint := fmt.Sprintf("https://bedrock-runtime.%s.amazonaws.com", region)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(payload))
+	if err != nil {
+		return nil, fmt.Errorf("new request: %w", err)
+	}
+	// model IDs contain ':' which Bedrock expects percent-encoded in the path
+	req.URL.Path = "/model/" + modelID + "/" + action
+	req.URL.RawPath = "/model/" + awsURIEscape(modelID) + "/" + action
+	req.Header.Set("Content-Type", "application/json")
+
+	if aws.BedrockAPIKey != "" {
+		req.Header.Set("Authorization", "Bearer "+aws.BedrockAPIKey)
+	} else {
+		signAWSRequest(req, payload, awsCredentials{
+			AccessKeyID:     aws.AccessKeyID,
+			SecretAccessKey: aws.SecretAccessKey,
+			SessionToken:    aws.SessionToken,
+		}, region, "bedrock", time.Now())
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("http do: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		defer resp.Body.Close()
+		buf := new(bytes.Buffer)
+		_, _ = buf.ReadFrom(resp.Body)
+		return nil, fmt.Errorf("bedrock error %d: %s", resp.StatusCode, buf.String())
+	}
+	return resp, nil
+}
+
+type converseUsage struct {
+	InputTokens  int `json:"inputTokens"`
+	OutputTokens int `json:"outputTokens"`
+}
+
+// converseStreamEvent covers the payloads of every ConverseStream event type
+type converseStreamEvent struct {
+	ContentBlockIndex int `json:"contentBlockIndex"`
+	Start             *struct {
+		ToolUse *struct {
+			ToolUseID string `json:"toolUseId"`
+			Name      string `json:"name"`
+		} `json:"toolUse"`
+	} `json:"start"`
+	Delta *struct {
+		Text    *string `json:"text"`
+		ToolUse *struct {
+			Input string `json:"input"`
+		} `json:"toolUse"`
+		ReasoningContent *struct {
+			Text      string `json:"text"`
+			Signature string `json:"signature"`
+		} `json:"reasoningContent"`
+	} `json:"delta"`
+	StopReason string         `json:"stopReason"`
+	Usage      *converseUsage `json:"usage"`
+	Message    string         `json:"message"`
+}
+
+// converseBlockBuilder accumulates one streamed content block
+type converseBlockBuilder struct {
+	text      strings.Builder
+	reasoning strings.Builder
+	signature string
+	toolUse   *ToolUse
+	toolInput strings.Builder
+}
+
+// parseConverseStream reads a ConverseStream event stream, forwarding text and reasoning to the client
+func parseConverseStream(body io.Reader, streamCtx *StreamingContext) (*ClaudeResponse, []interface{}, error) {
+	cr := &ClaudeResponse{RawResponse: map[string]interface{}{}}
+	blocks := map[int]*converseBlockBuilder{}
+	var thinking strings.Builder
+
+	blockAt := func(idx int) *converseBlockBuilder {
+		if blocks[idx] == nil {
+			blocks[idx] = &converseBlockBuilder{}
+		}
+		return blocks[idx]
+	}
+	dispatch := newStreamDispatcher(streamCtx, false)
+
+	for {
+		msg, err := readAWSEventMessage(body)
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, err
+		}
+
+		var ev converseStreamEvent
+		if err := json.Unmarshal(msg.Payload, &ev); err != nil {
+			continue
+		}
+		if msg.Headers[":message-type"] == "exception" {
+			return nil, nil, fmt.Errorf("bedrock stream error (%s): %s", msg.Headers[":exception-type"], ev.Message)
+		}
+
+		switch msg.Headers[":event-type"] {
+		case "contentBlockStart":
+			if ev.Start != nil && ev.Start.ToolUse != nil {
+				blockAt(ev.ContentBlockIndex).toolUse = &ToolUse{
+					ID:    ev.Start.ToolUse.ToolUseID,
+					Name:  ev.Start.ToolUse.Name,
+					Input: map[string]interface{}{},
+				}
+				dispatch.Emit(ToolStarted(ev.Start.ToolUse.ToolUseID, ev.Start.ToolUse.Name))
+			}
+
+		case "contentBlockDelta":
+			if ev.Delta == nil {
+				continue
+			}
+			b := blockAt(ev.ContentBlockIndex)
+			switch {
+			case ev.Delta.Text != nil:
+				b.text.WriteString(*ev.Delta.Text)
+				dispatch.Emit(TextDelta(*ev.Delta.Text))
+			case ev.Delta.ToolUse != nil:
+				b.toolInput.WriteString(ev.Delta.ToolUse.Input)
+			case ev.Delta.ReasoningContent != nil:
+				b.reasoning.WriteString(ev.Delta.ReasoningContent.Text)
+				thinking.WriteString(ev.Delta.ReasoningContent.Text)
+				dispatch.Emit(ThinkingDelta(ev.Delta.ReasoningContent.Text))
+				if ev.Delta.ReasoningContent.Signature != "" {
+					b.signature = ev.Delta.ReasoningContent.Signature
+				}
+			}
+
+		case "messageStop":
+			cr.StopReason = ev.StopReason
+
+		case "metadata":
+			if ev.Usage != nil {
+				setBedrockUsage(cr, ev.Usage.InputTokens, ev.Usage.OutputTokens)
+				dispatch.Emit(UsageReported(&TokenUsage{
+					InputTokens:    ev.Usage.InputTokens,
+					OutputTokens:   ev.Usage.OutputTokens,
+					TotalTokens:    ev.Usage.InputTokens + ev.Usage.OutputTokens,
+					CountingMethod: "provider_api",
+				}))
+			}
+		}
+	}
+	dispatch.Emit(StreamDone())
+
+	// rebuild the assistant turn in block order
+	indices := make([]int, 0, len(blocks))
+	for idx := range blocks {
+		indices = append(indices, idx)
+	}
+	sort.Ints(indices)
+
+	content := make([]interface{}, 0, len(indices))
+	for _, idx := range indices {
+		b := blocks[idx]
+		switch {
+		case b.toolUse != nil:
+			if b.toolInput.Len() > 0 {
+				var input map[string]interface{}
+				if err := json.Unmarshal([]byte(b.toolInput.String()), &input); err == nil {
+					b.toolUse.Input = input
+				} else {
+					fmt.Printf("[bedrock] Failed to parse toolUse input JSON for index %d: %v\n", idx, err)
+				}
+			}
+			cr.ToolUses = append(cr.ToolUses, *b.toolUse)
+			content = append(content, map[string]interface{}{
+				"toolUse": map[string]interface{}{
+					"toolUseId": b.toolUse.ID,
+					"name":      b.toolUse.Name,
+					"input":     b.toolUse.Input,
+				},
+			})
+		case b.reasoning.Len() > 0:
+			reasoningText := map[string]interface{}{"text": b.reasoning.String()}
+			if b.signature != "" {
+				reasoningText["signature"] = b.signature
+			}
+			content = append(content, map[string]interface{}{
+				"reasoningContent": map[string]interface{}{"reasoningText": reasoningText},
+			})
+		case b.text.Len() > 0:
+			cr.TextContent = append(cr.TextContent, b.text.String())
+			content = append(content, map[string]interface{}{"text": b.text.String()})
+		}
+	}
+	cr.ThinkingContent = thinking.String()
+	return cr, content, nil
+}
+
+// toConverseMessages converts Anthropic-style messages to Converse messages
+// Converse needs strictly alternating roles and no empty blocks, so consecutive turns of one role are merged
+func toConverseMessages(messages []Message) []map[string]interface{} {
+	out := make([]map[string]interface{}, 0, len(messages))
+	for _, m := range messages {
+		role := string(m.Role)
+		if role != "assistant" {
+			role = "user"
+		}
+
+		var content []interface{}
+		switch v := m.Content.(type) {
+		case string:
+			if strings.TrimSpace(v) != "" {
+				content = append(content, map[string]interface{}{"text": v})
+			}
+		case []map[string]interface{}:
+			for _, block := range v {
+				if converted, ok := toConverseBlock(block); ok {
+					content = append(content, converted)
+				}
+			}
+		case []interface{}:
+			for _, item := range v {
+				if block, ok := item.(map[string]interface{}); ok {
+					if converted, ok := toConverseBlock(block); ok {
+						content = append(content, converted)
+					}
+				}
+			}
+		}
+		if len(content) == 0 {
+			continue
+		}
+		out = append(out, map[string]interface{}{"role": role, "content": content})
+	}
+	return mergeConverseTurns(out)
+}
+
+// mergeConverseTurns joins consecutive messages that have the same role
+func mergeConverseTurns(messages []map[string]interface{}) []map[string]interface{} {
+	merged := make([]map[string]interface{}, 0, len(messages))
+	for _, m := range messages {
+		if n := len(merged); n > 0 && merged[n-1]["role"] == m["role"] {
+			prev, _ := merged[n-1]["content"].([]interface{})
+			next, _ := m["content"].([]interface{})
+			combined := make([]interface{}, 0, len(prev)+len(next))
+			combined = append(combined, prev...)
+			combined = append(combined, next...)
+			merged[n-1] = map[string]interface{}{"role": m["role"], "content": combined}
+			continue
+		}
+		merged = append(merged, m)
+	}
+	return merged
+}
+
+// toConverseBlock converts one Anthropic content block; thinking blocks from other providers are dropped
+func toConverseBlock(block map[string]interface{}) (map[string]interface{}, bool) {
+	switch block["type"] {
+	case "text":
+		text, _ := block["text"].(string)
+		if strings.TrimSpace(text) == "" {
+			return nil, false
+		}
+		return map[string]interface{}{"text": text}, true
+
+	case "image":
+		source, _ := block["source"].(map[string]interface{})
+		mediaType, _ := source["media_type"].(string)
+		data, _ := source["data"].(string)
+		if data == "" {
+			return nil, false
+		}
+		format := strings.TrimPrefix(mediaType, "image/")
+		if format == "jpg" {
+			format = "jpeg"
+		}
+		// blobs are base64 strings in the JSON protocol, so the data passes through unchanged
+		return map[string]interface{}{
+			"image": map[string]interface{}{
+				"format": format,
+				"source": map[string]interface{}{"bytes": data},
+			},
+		}, true
+
+	case "tool_use":
+		input := block["input"]
+		if input == nil {
+			input = map[string]interface{}{}
+		}
+		return map[string]interface{}{
+			"toolUse": map[string]interface{}{
+				"toolUseId": block["id"],
+				"name":      block["name"],
+				"input":     input,
+			},
+		}, true
+
+	case "tool_result":
+		var content []interface{}
+		switch v := block["content"].(type) {
+		case string:
+			content = append(content, map[string]interface{}{"text": v})
+		case []map[string]interface{}:
+			for _, inner := range v {
+				if converted, ok := toConverseBlock(inner); ok {
+					content = append(content, converted)
+				}
+			}
+		}
+		if len(content) == 0 {
+			content = append(content, map[string]interface{}{"text": "(no output)"})
+		}
+		result := map[string]interface{}{
+			"toolUseId": block["tool_use_id"],
+			"content":   content,
+		}
+		if isError, _ := block["is_error"].(bool); isError {
+			result["status"] = "error"
+		}
+		return map[string]interface{}{"toolResult": result}, true
+	}
+	return nil, false
+}
+
+// setBedrockUsage stores usage where ExtractAnthropicUsage looks for it
+func setBedrockUsage(cr *ClaudeResponse, inputTokens, outputTokens int) {
+	if rawMap, ok := cr.RawResponse.(map[string]interface{}); ok {
+		rawMap["usage"] = map[string]interface{}{
+			"input_tokens":  inputTokens,
+			"output_tokens": outputTokens,
+		}
+	}
+}
+
+func bedrockUsage(cr *ClaudeResponse) (int, int) {
+	raw, _ := cr.RawResponse.(map[string]interface{})
+	usage, _ := raw["usage"].(map[string]interface{})
+	in, _ := usage["input_tokens"].(int)
+	out, _ := usage["output_tokens"].(int)
+	return in, out
+}
@@ -0,0 +1,178 @@
+package llmHandlers
+
+import (
+	"bytes"
+	"encoding/binary"
+	"hash/crc32"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignAWSRequest(t *testing.T) {
+	// "get-vanilla" from the AWS SigV4 test suite
+	req, _ := http.NewRequest(http.MethodGet, "https://example.amazonaws.com/", nil)
+	signAWSRequest(req, nil, awsCredentials{
+		AccessKeyID:     "AKIDEXAMPLE",
+		SecretAccessKey: "wJalrXUtnFEMI/K7MDENG+bPxRfiCYEXAMPLEKEY",
+	}, "us-east-1", "service", time.Date(2015, 8, 30, 12, 36, 0, 0, time.UTC))
+
+	want := "AWS4-HMAC-SHA256 Credential=AKIDEXAMPLE/20150830/us-east-1/service/aws4_request, " +
+		"SignedHeaders=host;x-amz-date, Signature=5fa00fa31553b73ebf1942676e86291e8372ff2a2260956d9b8aae1d763fbf31"
+	if got := req.Header.Get("Authorization"); got != want {
+		t.Errorf("Authorization = %q, want %q", got, want)
+	}
+}
+
+func TestAWSCanonicalURI(t *testing.T) {
+	escaped := "/model/" + awsURIEscape("us.anthropic.claude-sonnet-4-5-20250929-v1:0") + "/converse"
+	if escaped != "/model/us.anthropic.claude-sonnet-4-5-20250929-v1%3A0/converse" {
+		t.Fatalf("escaped path = %q", escaped)
+	}
+	if got := awsCanonicalURI(escaped); got != "/model/us.anthropic.claude-sonnet-4-5-20250929-v1%253A0/converse" {
+		t.Errorf("canonical URI = %q", got)
+	}
+}
+
+// encodeAWSEvent builds an event stream message with string headers
+func encodeAWSEvent(headers map[string]string, payload []byte) []byte {
+	var hb bytes.Buffer
+	for name, value := range headers {
+		hb.WriteByte(byte(len(name)))
+		hb.WriteString(name)
+		hb.WriteByte(7)
+		binary.Write(&hb, binary.BigEndian, uint16(len(value)))
+		hb.WriteString(value)
+	}
+
+	var msg bytes.Buffer
+	binary.Write(&msg, binary.BigEndian, uint32(16+hb.Len()+len(payload)))
+	binary.Write(&msg, binary.BigEndian, uint32(hb.Len()))
+	binary.Write(&msg, binary.BigEndian, crc32.ChecksumIEEE(msg.Bytes()))
+	msg.Write(hb.Bytes())
+	msg.Write(payload)
+	binary.Write(&msg, binary.BigEndian, crc32.ChecksumIEEE(msg.Bytes()))
+	return msg.Bytes()
+}
+
+func TestReadAWSEventMessage(t *testing.T) {
+	var stream bytes.Buffer
+	stream.Write(encodeAWSEvent(map[string]string{":event-type": "contentBlockDelta", ":message-type": "event"}, []byte(`{"delta":{"text":"hi"}}`)))
+	stream.Write(encodeAWSEvent(map[string]string{":event-type": "messageStop"}, []byte(`{"stopReason":"end_turn"}`)))
+
+	msg, err := readAWSEventMessage(&stream)
+	if err != nil {
+		t.Fatalf("first message: %v", err)
+	}
+	if msg.Headers[":event-type"] != "contentBlockDelta" || msg.Headers[":message-type"] != "event" {
+		t.Errorf("headers = %v", msg.Headers)
+	}
+	if string(msg.Payload) != `{"delta":{"text":"hi"}}` {
+		t.Errorf("payload = %s", msg.Payload)
+	}
+
+	if msg, err = readAWSEventMessage(&stream); err != nil || msg.Headers[":event-type"] != "messageStop" {
+		t.Fatalf("second message: %v %v", msg, err)
+	}
+	if _, err := readAWSEventMessage(&stream); err != io.EOF {
+		t.Errorf("end of stream err = %v, want io.EOF", err)
+	}
+
+	corrupt := encodeAWSEvent(map[string]string{":event-type": "metadata"}, []byte(`{}`))
+	corrupt[len(corrupt)-6] ^= 0xff
+	if _, err := readAWSEventMessage(bytes.NewReader(corrupt)); err == nil || !strings.Contains(err.Error(), "checksum") {
+		t.Errorf("corrupt message err = %v, want checksum error", err)
+	}
+}
+
+func TestParseConverseStream(t *testing.T) {
+	events := []struct {
+		eventType string
+		payload   string
+	}{
+		{"messageStart", `{"role":"assistant"}`},
+		{"contentBlockDelta", `{"contentBlockIndex":0,"delta":{"reasoningContent":{"text":"plan"}}}`},
+		{"contentBlockDelta", `{"contentBlockIndex":0,"delta":{"reasoningContent":{"signature":"sig"}}}`},
+		{"contentBlockStop", `{"contentBlockIndex":0}`},
+		{"contentBlockDelta", `{"contentBlockIndex":1,"delta":{"text":"Drawing "}}`},
+		{"contentBlockDelta", `{"contentBlockIndex":1,"delta":{"text":"now"}}`},
+		{"contentBlockStart", `{"contentBlockIndex":2,"start":{"toolUse":{"toolUseId":"t1","name":"addShape"}}}`},
+		{"contentBlockDelta", `{"contentBlockIndex":2,"delta":{"toolUse":{"input":"{\"shapeType\":"}}}`},
+		{"contentBlockDelta", `{"contentBlockIndex":2,"delta":{"toolUse":{"input":"\"rect\"}"}}}`},
+		{"messageStop", `{"stopReason":"tool_use"}`},
+		{"metadata", `{"usage":{"inputTokens":12,"outputTokens":34}}`},
+	}
+	var stream bytes.Buffer
+	for _, ev := range events {
+		stream.Write(encodeAWSEvent(map[string]string{":event-type": ev.eventType, ":message-type": "event"}, []byte(ev.payload)))
+	}
+
+	cr, content, err := parseConverseStream(&stream, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cr.StopReason != "tool_use" || cr.ThinkingContent != "plan" {
+		t.Errorf("stop reason %q, thinking %q", cr.StopReason, cr.ThinkingContent)
+	}
+	if len(cr.TextContent) != 1 || cr.TextContent[0] != "Drawing now" {
+		t.Errorf("text = %v", cr.TextContent)
+	}
+	if len(cr.ToolUses) != 1 || cr.ToolUses[0].ID != "t1" || cr.ToolUses[0].Input["shapeType"] != "rect" {
+		t.Errorf("tool uses = %+v", cr.ToolUses)
+	}
+	if in, out := bedrockUsage(cr); in != 12 || out != 34 {
+		t.Errorf("usage = %d/%d", in, out)
+	}
+
+	if len(content) != 3 {
+		t.Fatalf("assistant content = %v", content)
+	}
+	reasoning := content[0].(map[string]interface{})["reasoningContent"].(map[string]interface{})["reasoningText"].(map[string]interface{})
+	if reasoning["signature"] != "sig" {
+		t.Errorf("reasoning block = %v", reasoning)
+	}
+}
+
+func TestToConverseMessages(t *testing.T) {
+	messages := []Message{
+		{Role: "user", Content: "first"},
+		{Role: "user", Content: []map[string]interface{}{
+			{"type": "text", "text": "look at this"},
+			{"type": "image", "source": map[string]interface{}{"type": "base64", "media_type": "image/jpg", "data": "AAAA"}},
+		}},
+		{Role: "assistant", Content: []map[string]interface{}{
+			{"type": "thinking", "thinking": "dropped"},
+			{"type": "tool_use", "id": "t1", "name": "getBoardData", "input": map[string]interface{}{"boardId": "b"}},
+		}},
+		{Role: "user", Content: []map[string]interface{}{
+			{"type": "tool_result", "tool_use_id": "t1", "content": "boom", "is_error": true},
+		}},
+		{Role: "assistant", Content: "  "},
+	}
+
+	got := toConverseMessages(messages)
+	if len(got) != 3 {
+		t.Fatalf("got %d messages, want 3 (user turns merged, empty dropped): %v", len(got), got)
+	}
+
+	first := got[0]["content"].([]interface{})
+	if len(first) != 3 {
+		t.Fatalf("merged user content = %v", first)
+	}
+	image := first[2].(map[string]interface{})["image"].(map[string]interface{})
+	if image["format"] != "jpeg" || image["source"].(map[string]interface{})["bytes"] != "AAAA" {
+		t.Errorf("image block = %v", image)
+	}
+
+	assistant := got[1]["content"].([]interface{})
+	if len(assistant) != 1 || assistant[0].(map[string]interface{})["toolUse"] == nil {
+		t.Errorf("assistant content = %v", assistant)
+	}
+
+	result := got[2]["content"].([]interface{})[0].(map[string]interface{})["toolResult"].(map[string]interface{})
+	if result["toolUseId"] != "t1" || result["status"] != "error" {
+		t.Errorf("tool result = %v", result)
+	}
+}
@@ -0,0 +1,21 @@
+package llmHandlers
+
+import (
+	"fmt"
+	"melina-studio-backend/internal/config"
+
+	openrouter "github.com/revrost/go-openrouter"
+)
+
+// NewDeepSeekClient talks to the DeepSeek API directly through the OpenAI-compatible client
+// deepseek-reasoner streams its thinking as reasoning_content, which is forwarded like OpenRouter reasoning
+// when thinking is enabled; tool results are fed back as plain text, so reasoning never has to be replayed
+func NewDeepSeekClient(modelID string, temperature *float32, maxTokens *int, tools []map[string]interface{}) (*OpenRouterClient, error) {
+	settings := config.GetSettings().LLM
+	if settings.DeepSeekAPIKey == "" {
+		return nil, fmt.Errorf("DEEPSEEK_API_KEY is not set")
+	}
+	cfg := openrouter.DefaultConfig(settings.DeepSeekAPIKey)
+	cfg.BaseURL = settings.DeepSeekBaseURL
+	return newOpenAICompatibleClient(ProviderDeepSeek, cfg, modelID, temperature, maxTokens, tools), nil
+}
@@ -0,0 +1,120 @@
+package llmHandlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"melina-studio-backend/internal/config"
+	"melina-studio-backend/internal/libraries"
+
+	"github.com/revrost/go-openrouter"
+)
+
+// fakeChatCompletions answers chat completions with the reply and reasoning, keeping the last request body
+func fakeChatCompletions(t *testing.T, reasoningField string) (*httptest.Server, *map[string]interface{}) {
+	t.Helper()
+	body := &map[string]interface{}{}
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/chat/completions" || r.Header.Get("Authorization") != "Bearer test-key" {
+			t.Errorf("unexpected request %s with %q", r.URL.Path, r.Header.Get("Authorization"))
+		}
+		*body = map[string]interface{}{}
+		if err := json.NewDecoder(r.Body).Decode(body); err != nil {
+			t.Error(err)
+		}
+		if (*body)["stream"] == true {
+			w.Header().Set("Content-Type", "text/event-stream")
+			fmt.Fprintf(w, "data: {\"choices\":[{\"index\":0,\"delta\":{%q:\"Thinking it over\"}}]}\n\n", reasoningField)
+			fmt.Fprint(w, "data: {\"choices\":[{\"index\":0,\"delta\":{\"content\":\"Hello\"},\"finish_reason\":\"stop\"}]}\n\n")
+			fmt.Fprint(w, "data: {\"choices\":[],\"usage\":{\"prompt_tokens\":12,\"completion_tokens\":5,\"total_tokens\":17}}\n\n")
+			fmt.Fprint(w, "data: [DONE]\n\n")
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		fmt.Fprintf(w, `{"choices":[{"index":0,"message":{"role":"assistant","content":"Hello",%q:"Thinking it over"},"finish_reason":"stop"}]}`, reasoningField)
+	}))
+	t.Cleanup(server.Close)
+	return server, body
+}
+
+func TestOpenAICompatibleProviders(t *testing.T) {
+	for _, name := range []string{"deepseek-chat", "deepseek-reasoner", "mistral-large-latest", "mistral-medium-latest"} {
+		info, err := ValidateModel(name)
+		if err != nil || (info.Provider != ProviderDeepSeek && info.Provider != ProviderMistral) {
+			t.Errorf("ValidateModel(%q) = %+v, %v", name, info, err)
+		}
+	}
+
+	withLLMSettings(t, func(s *config.LLMSettings) {
+		s.DeepSeekAPIKey = ""
+		s.MistralAPIKey = ""
+	})
+	for _, provider := range []Provider{ProviderDeepSeek, ProviderMistral} {
+		if _, err := New(Config{Provider: provider, Model: "any"}); err == nil {
+			t.Errorf("expected %s to need an API key", provider)
+		}
+	}
+}
+
+func TestDeepSeekRequestsAndReasoning(t *testing.T) {
+	server, body := fakeChatCompletions(t, "reasoning_content")
+	withLLMSettings(t, func(s *config.LLMSettings) {
+		s.DeepSeekAPIKey = "test-key"
+		s.DeepSeekBaseURL = server.URL
+	})
+	c, err := NewDeepSeekClient("deepseek-reasoner", nil, nil, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	messages := []Message{{Role: "user", Content: "Hi"}}
+
+	resp, err := c.callOpenRouterWithMessages(context.Background(), "", messages, nil, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.ReasoningContent != "Thinking it over" {
+		t.Errorf("expected reasoning_content to be read, got %q", resp.ReasoningContent)
+	}
+	if _, ok := (*body)["reasoning"]; ok {
+		t.Errorf("expected no OpenRouter reasoning object, got %v", *body)
+	}
+
+	streamCtx := &StreamingContext{Hub: &libraries.Hub{}, Client: &libraries.Client{}}
+	resp, err = c.callOpenRouterWithMessages(context.Background(), "", messages, streamCtx, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if options, _ := (*body)["stream_options"].(map[string]interface{}); options["include_usage"] != true {
+		t.Errorf("expected DeepSeek streams to ask for usage, got %v", (*body)["stream_options"])
+	}
+	if resp.ReasoningContent != "Thinking it over" {
+		t.Errorf("expected the streamed reasoning, got %q", resp.ReasoningContent)
+	}
+}
+
+func TestOnlyOpenRouterGetsTheReasoningObject(t *testing.T) {
+	server, body := fakeChatCompletions(t, "reasoning")
+	cfg := openrouter.DefaultConfig("test-key")
+	cfg.BaseURL = server.URL
+
+	for provider, want := range map[Provider]bool{ProviderOpenRouter: true, ProviderMistral: false} {
+		c := newOpenAICompatibleClient(provider, cfg, "some-model", nil, nil, nil)
+		resp, err := c.callOpenRouterWithMessages(context.Background(), "", []Message{{Role: "user", Content: "Hi"}}, nil, true)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if _, got := (*body)["reasoning"]; got != want {
+			t.Errorf("%s: reasoning object sent = %v, want %v", provider, got, want)
+		}
+		if _, ok := (*body)["stream_options"]; ok {
+			t.Errorf("%s: expected no stream options", provider)
+		}
+		if resp.ReasoningContent != "Thinking it over" {
+			t.Errorf("%s: expected the reasoning to be read, got %q", provider, resp.ReasoningContent)
+		}
+	}
+}
@@ -0,0 +1,346 @@
+package llmHandlers
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"melina-studio-backend/internal/config"
+	"net/http"
+	"strings"
+	"sync"
+
+	"github.com/openai/openai-go"
+	"github.com/openai/openai-go/option"
+	"golang.org/x/oauth2"
+	"golang.org/x/oauth2/google"
+)
+
+// DefaultEmbeddingModel is the model used to embed short board texts (sticky notes, labels)
+const DefaultEmbeddingModel = openai.EmbeddingModelTextEmbedding3Small
+
+const (
+	defaultVertexEmbeddingModel = "text-embedding-005"
+	defaultOllamaEmbeddingModel = "nomic-embed-text"
+	// embeddingCacheSize bounds the shared cache; ~2k vectors of 1536 floats is about 25MB
+	embeddingCacheSize = 2000
+)
+
+// Embeddings turns texts into vectors; vectors from different models are not comparable
+type Embeddings interface {
+	// Embed returns one vector per input text, in input order
+	Embed(ctx context.Context, texts []string) ([][]float64, error)
+	// Model identifies the provider and model, e.g. "openai/text-embedding-3-small"
+	Model() string
+}
+
+// EmbedTexts embeds texts with the provider selected by EMBEDDINGS_PROVIDER
+func EmbedTexts(ctx context.Context, texts []string) ([][]float64, error) {
+	embeddings, err := GetEmbeddings()
+	if err != nil {
+		return nil, err
+	}
+	return embeddings.Embed(ctx, texts)
+}
+
+// GetEmbeddings returns the configured provider wrapped with batching and the shared cache
+func GetEmbeddings() (Embeddings, error) {
+	llm := config.GetSettings().LLM
+	var inner Embeddings
+	batchSize := 0
+	switch llm.EmbeddingsProvider {
+	case "", "openai":
+		if llm.OpenAIAPIKey == "" {
+			return nil, fmt.Errorf("OPENAI_API_KEY must be set")
+		}
+		model := llm.EmbeddingsModel
+		if model == "" {
+			model = DefaultEmbeddingModel
+		}
+		inner = &openAIEmbeddings{apiKey: llm.OpenAIAPIKey, model: model}
+		batchSize = 512
+	case "vertex":
+		model := llm.EmbeddingsModel
+		if model == "" {
+			model = defaultVertexEmbeddingModel
+		}
+		inner = &vertexEmbeddings{model: model, location: llm.EmbeddingsVertexLocation}
+		// the predict endpoint takes at most 250 instances and 20k tokens per call
+		batchSize = 100
+	case "ollama":
+		model := llm.EmbeddingsModel
+		if model == "" {
+			model = defaultOllamaEmbeddingModel
+		}
+		inner = &ollamaEmbeddings{baseURL: strings.TrimSuffix(llm.OllamaBaseURL, "/"), model: model}
+		batchSize = 64
+	default:
+		return nil, fmt.Errorf("unknown EMBEDDINGS_PROVIDER %q (expected openai, vertex or ollama)", llm.EmbeddingsProvider)
+	}
+	return &cachedEmbeddings{inner: &batchedEmbeddings{inner: inner, batchSize: batchSize}, cache: sharedEmbeddingCache}, nil
+}
+
+// CosineSimilarity returns the cosine of the angle between a and b, 0 when either is empty
+func CosineSimilarity(a, b []float64) float64 {
+	var dot, normA, normB float64
+	for i := range a {
+		if i >= len(b) {
+			break
+		}
+		dot += a[i] * b[i]
+		normA += a[i] * a[i]
+		normB += b[i] * b[i]
+	}
+	if normA == 0 || normB == 0 {
+		return 0
+	}
+	return dot / (math.Sqrt(normA) * math.Sqrt(normB))
+}
+
+// batchedEmbeddings splits large inputs into provider-sized requests
+type batchedEmbeddings struct {
+	inner     Embeddings
+	batchSize int
+}
+
+func (b *batchedEmbeddings) Model() string { return b.inner.Model() }
+
+func (b *batchedEmbeddings) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	if b.batchSize <= 0 || len(texts) <= b.batchSize {
+		return b.inner.Embed(ctx, texts)
+	}
+	vectors := make([][]float64, 0, len(texts))
+	for start := 0; start < len(texts); start += b.batchSize {
+		end := min(start+b.batchSize, len(texts))
+		batch, err := b.inner.Embed(ctx, texts[start:end])
+		if err != nil {
+			return nil, err
+		}
+		vectors = append(vectors, batch...)
+	}
+	return vectors, nil
+}
+
+// embeddingCache keeps recent vectors by model and text hash, evicting the oldest entries first
+type embeddingCache struct {
+	mu      sync.Mutex
+	entries map[string][]float64
+	order   []string
+	max     int
+}
+
+var sharedEmbeddingCache = newEmbeddingCache(embeddingCacheSize)
+
+func newEmbeddingCache(max int) *embeddingCache {
+	return &embeddingCache{entries: make(map[string][]float64), max: max}
+}
+
+func (c *embeddingCache) get(key string) ([]float64, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	v, ok := c.entries[key]
+	return v, ok
+}
+
+func (c *embeddingCache) put(key string, v []float64) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; ok {
+		return
+	}
+	c.entries[key] = v
+	c.order = append(c.order, key)
+	for len(c.order) > c.max {
+		delete(c.entries, c.order[0])
+		c.order = c.order[1:]
+	}
+}
+
+// cachedEmbeddings only sends the texts it hasn't seen, so re-ranking the same boards stays cheap
+type cachedEmbeddings struct {
+	inner Embeddings
+	cache *embeddingCache
+}
+
+func (c *cachedEmbeddings) Model() string { return c.inner.Model() }
+
+func (c *cachedEmbeddings) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	if len(texts) == 0 {
+		return [][]float64{}, nil
+	}
+
+	vectors := make([][]float64, len(texts))
+	keys := make([]string, len(texts))
+	missingIdx := map[string][]int{}
+	var missing []string
+	for i, text := range texts {
+		sum := sha256.Sum256([]byte(text))
+		keys[i] = c.inner.Model() + ":" + hex.EncodeToString(sum[:])
+		if v, ok := c.cache.get(keys[i]); ok {
+			vectors[i] = v
+			continue
+		}
+		if _, seen := missingIdx[keys[i]]; !seen {
+			missing = append(missing, text)
+		}
+		missingIdx[keys[i]] = append(missingIdx[keys[i]], i)
+	}
+	if len(missing) == 0 {
+		return vectors, nil
+	}
+
+	// the provider is the model's prefix, e.g. "openai"; embedded texts are board content or search queries about it
+	provider, _, _ := strings.Cut(c.inner.Model(), "/")
+	recordPrivacyAudit(ctx, Provider(provider), nil, DataClassBoard)
+	fetched, err := c.inner.Embed(ctx, missing)
+	if err != nil {
+		return nil, err
+	}
+	if len(fetched) != len(missing) {
+		return nil, fmt.Errorf("embedding provider returned %d vectors for %d texts", len(fetched), len(missing))
+	}
+	for j, text := range missing {
+		sum := sha256.Sum256([]byte(text))
+		key := c.inner.Model() + ":" + hex.EncodeToString(sum[:])
+		c.cache.put(key, fetched[j])
+		for _, i := range missingIdx[key] {
+			vectors[i] = fetched[j]
+		}
+	}
+	return vectors, nil
+}
+
+type openAIEmbeddings struct {
+	apiKey string
+	model  string
+}
+
+func (e *openAIEmbeddings) Model() string { return "openai/" + e.model }
+
+func (e *openAIEmbeddings) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	client := openai.NewClient(option.WithAPIKey(e.apiKey))
+	resp, err := client.Embeddings.New(ctx, openai.EmbeddingNewParams{
+		Model: e.model,
+		Input: openai.EmbeddingNewParamsInputUnion{
+			OfArrayOfStrings: texts,
+		},
+	})
+	if err != nil {
+		return nil, fmt.Errorf("embedding request failed: %w", err)
+	}
+
+	vectors := make([][]float64, len(texts))
+	for _, item := range resp.Data {
+		if int(item.Index) < len(vectors) {
+			vectors[item.Index] = item.Embedding
+		}
+	}
+	for i, v := range vectors {
+		if v == nil {
+			return nil, fmt.Errorf("missing embedding for input %d", i)
+		}
+	}
+	return vectors, nil
+}
+
+// vertexEmbeddings calls the Vertex AI text embedding models with the GCP service account
+type vertexEmbeddings struct {
+	model    string
+	location string
+}
+
+func (e *vertexEmbeddings) Model() string { return "vertex/" + e.model }
+
+func (e *vertexEmbeddings) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	settings := config.GetSettings()
+	saJSON, err := settings.GCP.ServiceAccountJSON()
+	if err != nil {
+		return nil, err
+	}
+	creds, err := google.CredentialsFromJSON(ctx, saJSON, "https://www.googleapis.com/auth/cloud-platform")
+	if err != nil {
+		return nil, fmt.Errorf("CredentialsFromJSON: %w", err)
+	}
+	httpClient := oauth2.NewClient(ctx, creds.TokenSource)
+
+	url := fmt.Sprintf(
+		"https://%s-aiplatform.googleapis.com/v1/projects/%s/locations/%s/publishers/google/models/%s:predict",
+		e.location, settings.GCP.ProjectID, e.location, e.model,
+	)
+	instances := make([]map[string]interface{}, len(texts))
+	for i, text := range texts {
+		instances[i] = map[string]interface{}{"content": text, "task_type": "SEMANTIC_SIMILARITY"}
+	}
+
+	var out struct {
+		Predictions []struct {
+			Embeddings struct {
+				Values []float64 `json:"values"`
+			} `json:"embeddings"`
+		} `json:"predictions"`
+	}
+	if err := postEmbeddingJSON(ctx, httpClient, url, map[string]interface{}{"instances": instances}, &out); err != nil {
+		return nil, err
+	}
+	if len(out.Predictions) != len(texts) {
+		return nil, fmt.Errorf("vertex returned %d embeddings for %d texts", len(out.Predictions), len(texts))
+	}
+	vectors := make([][]float64, len(texts))
+	for i, p := range out.Predictions {
+		vectors[i] = p.Embeddings.Values
+	}
+	return vectors, nil
+}
+
+// ollamaEmbeddings uses a local embedding model through Ollama's /api/embed endpoint
+type ollamaEmbeddings struct {
+	baseURL string
+	model   string
+}
+
+func (e *ollamaEmbeddings) Model() string { return "ollama/" + e.model }
+
+func (e *ollamaEmbeddings) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	var out struct {
+		Embeddings [][]float64 `json:"embeddings"`
+	}
+	body := map[string]interface{}{"model": e.model, "input": texts}
+	if err := postEmbeddingJSON(ctx, http.DefaultClient, e.baseURL+"/api/embed", body, &out); err != nil {
+		return nil, err
+	}
+	if len(out.Embeddings) != len(texts) {
+		return nil, fmt.Errorf("ollama returned %d embeddings for %d texts", len(out.Embeddings), len(texts))
+	}
+	return out.Embeddings, nil
+}
+
+func postEmbeddingJSON(ctx context.Context, client *http.Client, url string, body interface{}, out interface{}) error {
+	payload, err := json.Marshal(body)
+	if err != nil {
+		return fmt.Errorf("marshal body: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(payload))
+	if err != nil {
+		return fmt.Errorf("new request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return fmt.Errorf("embedding request failed: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		buf := new(bytes.Buffer)
+		_, _ = buf.ReadFrom(resp.Body)
+		return fmt.Errorf("embedding error %d: %s", resp.StatusCode, buf.String())
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("decode response: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package llmHandlers
+
+import (
+	"context"
+	"math"
+	"testing"
+)
+
+// fakeEmbeddings returns [len(text)] for each text and records the batches it was called with
+type fakeEmbeddings struct {
+	batches [][]string
+}
+
+func (f *fakeEmbeddings) Model() string { return "fake/len" }
+
+func (f *fakeEmbeddings) Embed(ctx context.Context, texts []string) ([][]float64, error) {
+	f.batches = append(f.batches, texts)
+	vectors := make([][]float64, len(texts))
+	for i, text := range texts {
+		vectors[i] = []float64{float64(len(text))}
+	}
+	return vectors, nil
+}
+
+func TestBatchedEmbeddings(t *testing.T) {
+	fake := &fakeEmbeddings{}
+	b := &batchedEmbeddings{inner: fake, batchSize: 2}
+
+	vectors, err := b.Embed(context.Background(), []string{"a", "bb", "ccc", "dddd", "eeeee"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.batches) != 3 || len(fake.batches[2]) != 1 {
+		t.Errorf("batches = %v, want sizes 2,2,1", fake.batches)
+	}
+	for i, v := range vectors {
+		if v[0] != float64(i+1) {
+			t.Errorf("vector %d = %v, order not preserved", i, v)
+		}
+	}
+}
+
+func TestCachedEmbeddings(t *testing.T) {
+	fake := &fakeEmbeddings{}
+	c := &cachedEmbeddings{inner: fake, cache: newEmbeddingCache(2)}
+	ctx := context.Background()
+
+	if _, err := c.Embed(ctx, []string{"one", "two", "one"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.batches) != 1 || len(fake.batches[0]) != 2 {
+		t.Fatalf("first call should embed each distinct text once, got %v", fake.batches)
+	}
+
+	vectors, err := c.Embed(ctx, []string{"two", "three"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.batches) != 2 || len(fake.batches[1]) != 1 || fake.batches[1][0] != "three" {
+		t.Errorf("only uncached texts should be sent, got %v", fake.batches)
+	}
+	if vectors[0][0] != 3 || vectors[1][0] != 5 {
+		t.Errorf("vectors = %v", vectors)
+	}
+
+	// "one" was evicted when "three" pushed the cache past its size
+	if _, err := c.Embed(ctx, []string{"one"}); err != nil {
+		t.Fatal(err)
+	}
+	if len(fake.batches) != 3 {
+		t.Errorf("evicted text should be embedded again, got %v", fake.batches)
+	}
+}
+
+func TestCosineSimilarity(t *testing.T) {
+	if got := CosineSimilarity([]float64{1, 0}, []float64{2, 0}); math.Abs(got-1) > 1e-9 {
+		t.Errorf("parallel vectors: got %v", got)
+	}
+	if got := CosineSimilarity([]float64{1, 0}, []float64{0, 3}); math.Abs(got) > 1e-9 {
+		t.Errorf("orthogonal vectors: got %v", got)
+	}
+	if got := CosineSimilarity([]float64{0, 0}, []float64{1, 1}); got != 0 {
+		t.Errorf("zero vector: got %v", got)
+	}
+}
@@ -13,6 +13,12 @@ const (
 	ProviderVertexAnthropic Provider = "vertex_anthropic" // Your anthropic.go wrapper
 	ProviderGemini          Provider = "gemini"
 	ProviderOpenRouter      Provider = "openrouter" // OpenRouter (supports Kimi-K2.5, etc.)
+	ProviderOllama          Provider = "ollama"     // Self-hosted Ollama (OpenAI-compatible API, no cloud keys)
+	ProviderBedrock         Provider = "bedrock"    // AWS Bedrock Converse API (Claude, Llama)
+	ProviderDeepSeek        Provider = "deepseek"   // DeepSeek API (OpenAI-compatible, reasoning_content)
+	ProviderMistral         Provider = "mistral"    // Mistral La Plateforme (OpenAI-compatible)
+	ProviderFake            Provider = "fake"       // Canned streamed replies for load tests (LLM_FAKE_MODEL)
+	ProviderMock            Provider = "mock"       // Echoes or scripted tool calls for local development (LLM_MOCK_MODEL)
 )
 
 type Config struct {
@@ -31,7 +37,24 @@ type Config struct {
 	Tools []map[string]interface{}
 }
 
+// New builds the client for the configured provider, tracked by the provider's circuit breaker and the privacy audit.
+// Deterministic tool-less clients also reuse identical completions when LLM_RESPONSE_CACHE_MINUTES is set.
+// Requests pinned to a data region go to the provider's regional endpoint, or are refused when it has none
 func New(cfg Config) (Client, error) {
+	client, err := newProviderClient(cfg)
+	if err != nil {
+		return nil, err
+	}
+	client = withPrivacyAudit(cfg.Provider, client)
+	client = withHealthTracking(cfg.Provider, client)
+	if ttl := responseCacheTTL(); ttl > 0 && cacheableConfig(cfg) {
+		client = withResponseCache(cfg, client, ttl)
+	}
+	client = withRegionPinning(cfg.Provider, client)
+	return client, nil
+}
+
+func newProviderClient(cfg Config) (Client, error) {
 	switch cfg.Provider {
 
 	case ProviderOpenAI:
@@ -45,6 +68,7 @@ func New(cfg Config) (Client, error) {
 			Tools:       cfg.Tools,
 			Temperature: cfg.Temperature,
 			MaxTokens:   cfg.MaxTokens,
+			TextOnly:    !AcceptsImages(ProviderLangChainGroq, cfg.Model),
 		})
 
 	case ProviderVertexAnthropic:
@@ -62,6 +86,24 @@ func New(cfg Config) (Client, error) {
 	case ProviderOpenRouter:
 		return NewOpenRouterClient(cfg.Model, cfg.Temperature, cfg.MaxTokens, cfg.Tools)
 
+	case ProviderOllama:
+		return NewOllamaClient(cfg.Model, cfg.Tools, cfg.Temperature, cfg.MaxTokens)
+
+	case ProviderBedrock:
+		return NewBedrockClient(cfg.Model, cfg.Tools, cfg.Temperature, cfg.MaxTokens)
+
+	case ProviderDeepSeek:
+		return NewDeepSeekClient(cfg.Model, cfg.Temperature, cfg.MaxTokens, cfg.Tools)
+
+	case ProviderMistral:
+		return NewMistralClient(cfg.Model, cfg.Temperature, cfg.MaxTokens, cfg.Tools)
+
+	case ProviderFake:
+		return NewFakeClient(), nil
+
+	case ProviderMock:
+		return NewMockClient(cfg.Tools), nil
+
 	default:
 		return nil, fmt.Errorf("unknown LLM provider: %s", cfg.Provider)
 	}
@@ -0,0 +1,94 @@
+package llmHandlers
+
+import (
+	"context"
+	"melina-studio-backend/internal/config"
+	"melina-studio-backend/internal/libraries"
+	"strings"
+	"time"
+)
+
+// FakeModelName is the registry name of the load-test model offered when LLM_FAKE_MODEL is on
+const FakeModelName = "fake"
+
+// fakeReply is what the fake model answers every turn with, long enough to stream a few dozen chunks
+const fakeReply = "This is a simulated reply from the fake model. It streams word by word like a real provider, " +
+	"so load tests exercise the chat pipeline, the stream pacer and the hub without calling an LLM. " +
+	"Nothing is drawn on the board and no tokens are counted for this turn."
+
+// fakeModelEnabled reports whether the fake model is offered
+func fakeModelEnabled() bool {
+	return config.GetSettings().LLM.FakeModel
+}
+
+// fakeModelInfo returns the registry entry of the fake model while it is enabled
+func fakeModelInfo(modelName string) (*ModelInfo, bool) {
+	if modelName != FakeModelName || !fakeModelEnabled() {
+		return nil, false
+	}
+	return &ModelInfo{
+		Provider:      ProviderFake,
+		ModelID:       FakeModelName,
+		DisplayName:   "Fake (load testing)",
+		TextOnly:      true,
+		ContextWindow: defaultContextWindow,
+	}, true
+}
+
+// FakeClient streams fakeReply at a fixed pace instead of calling a provider
+type FakeClient struct {
+	chunkDelay time.Duration
+}
+
+func NewFakeClient() *FakeClient {
+	return &FakeClient{chunkDelay: config.GetSettings().LLM.FakeModelChunkDelay}
+}
+
+func (c *FakeClient) Chat(ctx context.Context, systemMessage string, messages []Message, enableThinking bool) (string, error) {
+	return c.stream(ctx, nil)
+}
+
+func (c *FakeClient) ChatStream(ctx context.Context, hub *libraries.Hub, client *libraries.Client, boardId string, systemMessage string, messages []Message, enableThinking bool) (string, error) {
+	var streamCtx *StreamingContext
+	if client != nil {
+		streamCtx = &StreamingContext{Hub: hub, Client: client, BoardId: boardId, UserID: client.UserID}
+	}
+	return c.stream(ctx, streamCtx)
+}
+
+func (c *FakeClient) ChatStreamWithUsage(req ChatStreamRequest) (*ResponseWithUsage, error) {
+	var streamCtx *StreamingContext
+	if req.Client != nil {
+		streamCtx = &StreamingContext{Hub: req.Hub, Client: req.Client, BoardId: req.BoardID, UserID: req.Client.UserID}
+	}
+	text, err := c.stream(req.Ctx, streamCtx)
+	if err != nil {
+		return nil, err
+	}
+	// load tests run for real users, whose quotas shouldn't pay for them
+	return &ResponseWithUsage{Text: text, TokenUsage: &TokenUsage{CountingMethod: "fake"}}, nil
+}
+
+// stream sends fakeReply a word at a time, stopping early when the request is cancelled
+func (c *FakeClient) stream(ctx context.Context, streamCtx *StreamingContext) (string, error) {
+	dispatch := newStreamDispatcher(streamCtx, false)
+	var sent strings.Builder
+	for i, word := range strings.Fields(fakeReply) {
+		if i > 0 {
+			word = " " + word
+		}
+		if c.chunkDelay > 0 {
+			timer := time.NewTimer(c.chunkDelay)
+			select {
+			case <-ctx.Done():
+				timer.Stop()
+				return sent.String(), ctx.Err()
+			case <-timer.C:
+			}
+		}
+		sent.WriteString(word)
+		dispatch.Emit(TextDelta(word))
+	}
+	dispatch.Emit(StreamDone())
+	return sent.String(), nil
+}
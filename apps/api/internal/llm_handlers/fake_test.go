@@ -0,0 +1,36 @@
+package llmHandlers
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFakeClientRepliesWithCannedText(t *testing.T) {
+	text, err := (&FakeClient{}).Chat(context.Background(), "", nil, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != fakeReply {
+		t.Errorf("got %q, want the canned reply", text)
+	}
+}
+
+func TestFakeClientStopsWhenCancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	text, err := (&FakeClient{chunkDelay: time.Second}).Chat(ctx, "", nil, false)
+	if !errors.Is(err, context.Canceled) {
+		t.Errorf("got error %v, want context.Canceled", err)
+	}
+	if text != "" {
+		t.Errorf("got %q before cancellation, want nothing", text)
+	}
+}
+
+func TestFakeModelIsOffOutsideLoadTests(t *testing.T) {
+	if _, err := ValidateModel(FakeModelName); err == nil {
+		t.Error("the fake model was accepted without LLM_FAKE_MODEL")
+	}
+}
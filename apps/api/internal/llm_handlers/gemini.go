@@ -5,10 +5,10 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"melina-studio-backend/internal/config"
 	"melina-studio-backend/internal/constants"
 	"melina-studio-backend/internal/libraries"
 	"melina-studio-backend/internal/models"
-	"os"
 	"strings"
 	"time"
 
@@ -40,8 +40,9 @@ type GenaiGeminiClient struct {
 }
 
 func NewGenaiGeminiClient(ctx context.Context, tools []map[string]interface{}, temperature *float32, maxTokens *int) (*GenaiGeminiClient, error) {
-	apiKey := os.Getenv("GEMINI_API_KEY")
-	modelID := os.Getenv("GEMINI_MODEL_ID")
+	settings := config.GetSettings().LLM
+	apiKey := settings.GeminiAPIKey
+	modelID := settings.GeminiModelID
 
 	if apiKey == "" || modelID == "" {
 		return nil, fmt.Errorf("GEMINI_API_KEY and GEMINI_MODEL_ID must be set")
@@ -255,6 +256,10 @@ func (v *GenaiGeminiClient) callGeminiWithMessages(ctx context.Context, systemMe
 		MaxOutputTokens: v.MaxTokens,
 		Tools:           genaiTools,
 	}
+	if seed, ok := seedFrom(ctx); ok {
+		genSeed := int32(seed)
+		genConfig.Seed = &genSeed
+	}
 
 	if enableThinking {
 		budget := int32(1024) // token budget for thinking
@@ -289,8 +294,7 @@ func (v *GenaiGeminiClient) callGeminiWithMessages(ctx context.Context, systemMe
 
 		var lastChunk *genai.GenerateContentResponse
 		var accumulatedText strings.Builder
-		var thinkingStarted bool
-		var thinkingCompleted bool
+		dispatch := newStreamDispatcher(streamCtx, false)
 
 		// Iterate over streaming chunks
 		for chunk, chunkErr := range iterator {
@@ -304,45 +308,31 @@ func (v *GenaiGeminiClient) callGeminiWithMessages(ctx context.Context, systemMe
 			// Process each candidate's parts to detect thinking vs text
 			if len(chunk.Candidates) > 0 && chunk.Candidates[0].Content != nil {
 				for _, part := range chunk.Candidates[0].Content.Parts {
-					if part.Text != "" {
-						if part.Thought {
-							// This is THINKING content
-							if !thinkingStarted {
-								thinkingStarted = true
-								libraries.SendEventType(streamCtx.Hub, streamCtx.Client, libraries.WebSocketMessageTypeThinkingStart)
-							}
-							accumulatedThinking.WriteString(part.Text)
-							payload := &libraries.ChatMessageResponsePayload{
-								Message: part.Text,
-							}
-							if streamCtx.BoardId != "" {
-								payload.BoardId = streamCtx.BoardId
-							}
-							libraries.SendChatMessageResponse(streamCtx.Hub, streamCtx.Client, libraries.WebSocketMessageTypeThinkingResponse, payload)
-						} else {
-							// This is REGULAR text - send thinking_completed first if needed
-							if thinkingStarted && !thinkingCompleted {
-								thinkingCompleted = true
-								libraries.SendEventType(streamCtx.Hub, streamCtx.Client, libraries.WebSocketMessageTypeThinkingCompleted)
-							}
-							accumulatedText.WriteString(part.Text)
-							payload := &libraries.ChatMessageResponsePayload{
-								Message: part.Text,
-							}
-							if streamCtx.BoardId != "" {
-								payload.BoardId = streamCtx.BoardId
-							}
-							libraries.SendChatMessageResponse(streamCtx.Hub, streamCtx.Client, libraries.WebSocketMessageTypeChatResponse, payload)
-						}
+					if part.FunctionCall != nil {
+						dispatch.Emit(ToolStarted(part.FunctionCall.ID, part.FunctionCall.Name))
+					}
+					if part.Text == "" {
+						continue
+					}
+					if part.Thought {
+						accumulatedThinking.WriteString(part.Text)
+						dispatch.Emit(ThinkingDelta(part.Text))
+					} else {
+						accumulatedText.WriteString(part.Text)
+						dispatch.Emit(TextDelta(part.Text))
 					}
 				}
 			}
+			if chunk.UsageMetadata != nil {
+				dispatch.Emit(UsageReported(&TokenUsage{
+					InputTokens:    int(chunk.UsageMetadata.PromptTokenCount),
+					OutputTokens:   int(chunk.UsageMetadata.CandidatesTokenCount),
+					TotalTokens:    int(chunk.UsageMetadata.TotalTokenCount),
+					CountingMethod: "provider_api",
+				}))
+			}
 		}
-
-		// If thinking started but no text came, send completed
-		if thinkingStarted && !thinkingCompleted {
-			libraries.SendEventType(streamCtx.Hub, streamCtx.Client, libraries.WebSocketMessageTypeThinkingCompleted)
-		}
+		dispatch.Emit(StreamDone())
 
 		// Use the last chunk as the base for the final response
 		resp = lastChunk
@@ -445,9 +435,11 @@ func (v *GenaiGeminiClient) callGeminiWithMessages(ctx context.Context, systemMe
 // ChatWithTools handles tool execution loop similar to Anthropic's implementation
 func (v *GenaiGeminiClient) ChatWithTools(ctx context.Context, systemMessage string, messages []Message, streamCtx *StreamingContext, enableThinking bool) (*GeminiResponse, error) {
 	maxIterations := constants.GetMaxIterations(ctx)
+	ctx = WithToolInputRetries(ctx)
 
 	workingMessages := make([]Message, 0, len(messages)+6)
 	workingMessages = append(workingMessages, messages...)
+	images := newToolLoopImages(len(workingMessages))
 
 	var lastResp *GeminiResponse
 
@@ -506,6 +498,8 @@ func (v *GenaiGeminiClient) ChatWithTools(ctx context.Context, systemMessage str
 			imageContentBlocks = append(imageContentBlocks, imgBlocks...)
 		}
 
+		images.compact(workingMessages)
+
 		// Append assistant message with function calls
 		assistantParts := []map[string]interface{}{}
 		for _, text := range gr.TextContent {
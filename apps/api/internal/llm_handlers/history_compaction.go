@@ -0,0 +1,105 @@
+package llmHandlers
+
+// staleImageNote replaces a board image once the tool loop has moved past the iteration that fetched it
+const staleImageNote = "[Board image from an earlier step removed to save tokens; call getBoardData again to see the board as it is now]"
+
+// toolLoopImages compacts the board images of a tool loop's messages as the loop goes. Messages before compacted
+// are the caller's or already compacted. The board images of an iteration are outdated once the next iteration's
+// results are in, so the loops compact right before appending those results
+type toolLoopImages struct {
+	compacted int
+}
+
+// newToolLoopImages starts tracking a loop whose first callerMessages messages are the caller's
+func newToolLoopImages(callerMessages int) *toolLoopImages {
+	return &toolLoopImages{compacted: callerMessages}
+}
+
+// compact compacts the messages added since the last call
+func (l *toolLoopImages) compact(messages []Message) {
+	compactToolImages(messages[l.compacted:])
+	l.compacted = len(messages)
+}
+
+// compactConverse is compact for Bedrock's Converse messages
+func (l *toolLoopImages) compactConverse(messages []map[string]interface{}) {
+	compactConverseImages(messages[l.compacted:])
+	l.compacted = len(messages)
+}
+
+// compactToolImages replaces the images in tool loop messages with staleImageNote
+// Every re-sent image costs its full tokens again on each later iteration, while the model only
+// needs the latest look at the board, so the loops compact an iteration's messages once the next
+// iteration's results are in. The user's own attachments are never passed here
+func compactToolImages(messages []Message) {
+	for i := range messages {
+		if blocks, ok := messages[i].Content.([]map[string]interface{}); ok {
+			messages[i].Content = compactImageBlocks(blocks)
+		}
+	}
+}
+
+// compactImageBlocks returns the blocks with image blocks, images nested in tool results and the
+// base64 image of raw function responses replaced; the blocks themselves are copied, not changed
+func compactImageBlocks(blocks []map[string]interface{}) []map[string]interface{} {
+	out := make([]map[string]interface{}, 0, len(blocks))
+	for _, block := range blocks {
+		switch block["type"] {
+		case "image":
+			block = map[string]interface{}{"type": "text", "text": staleImageNote}
+		case "tool_result":
+			if inner, ok := block["content"].([]map[string]interface{}); ok {
+				block = withValue(block, "content", compactImageBlocks(inner))
+			}
+		case "function_response":
+			// OpenAI replays the handler's raw result, base64 image included
+			fn, _ := block["function"].(map[string]interface{})
+			if response, ok := fn["response"].(map[string]interface{}); ok {
+				if hasImage, _ := response["_imageContent"].(bool); hasImage {
+					block = withValue(block, "function", withValue(fn, "response", withValue(response, "image", staleImageNote)))
+				}
+			}
+		}
+		out = append(out, block)
+	}
+	return out
+}
+
+// compactConverseImages is compactToolImages for Bedrock's Converse messages
+func compactConverseImages(messages []map[string]interface{}) {
+	for i, m := range messages {
+		if content, ok := m["content"].([]interface{}); ok {
+			messages[i] = withValue(m, "content", compactConverseBlocks(content))
+		}
+	}
+}
+
+func compactConverseBlocks(blocks []interface{}) []interface{} {
+	out := make([]interface{}, 0, len(blocks))
+	for _, item := range blocks {
+		block, ok := item.(map[string]interface{})
+		if !ok {
+			out = append(out, item)
+			continue
+		}
+		if _, isImage := block["image"]; isImage {
+			block = map[string]interface{}{"text": staleImageNote}
+		} else if result, ok := block["toolResult"].(map[string]interface{}); ok {
+			if inner, ok := result["content"].([]interface{}); ok {
+				block = withValue(block, "toolResult", withValue(result, "content", compactConverseBlocks(inner)))
+			}
+		}
+		out = append(out, block)
+	}
+	return out
+}
+
+// withValue returns a copy of the map with the key set
+func withValue(m map[string]interface{}, key string, value interface{}) map[string]interface{} {
+	copied := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		copied[k] = v
+	}
+	copied[key] = value
+	return copied
+}
@@ -0,0 +1,100 @@
+package llmHandlers
+
+import (
+	"testing"
+)
+
+func boardImageBlock() map[string]interface{} {
+	return map[string]interface{}{"type": "image", "source": map[string]interface{}{"type": "base64", "media_type": "image/png", "data": "iVBOR"}}
+}
+
+func TestCompactToolImagesReplacesNestedAndSeparateImages(t *testing.T) {
+	toolResult := map[string]interface{}{
+		"type":        "tool_result",
+		"tool_use_id": "t1",
+		"content":     []map[string]interface{}{{"type": "text", "text": "Board image"}, boardImageBlock()},
+	}
+	openAIResult := map[string]interface{}{
+		"type": "function_response",
+		"function": map[string]interface{}{
+			"name":     "getBoardData",
+			"response": map[string]interface{}{"_imageContent": true, "image": "iVBOR", "boardId": "b1"},
+		},
+	}
+	messages := []Message{
+		{Role: "user", Content: []map[string]interface{}{toolResult}},
+		{Role: "user", Content: []map[string]interface{}{boardImageBlock(), openAIResult}},
+		{Role: "assistant", Content: "plain text stays"},
+	}
+
+	compactToolImages(messages)
+
+	nested := messages[0].Content.([]map[string]interface{})[0]["content"].([]map[string]interface{})
+	if nested[0]["text"] != "Board image" || nested[1]["type"] != "text" || nested[1]["text"] != staleImageNote {
+		t.Fatalf("tool result content = %v", nested)
+	}
+	separate := messages[1].Content.([]map[string]interface{})
+	if separate[0]["text"] != staleImageNote {
+		t.Fatalf("image block = %v", separate[0])
+	}
+	response := separate[1]["function"].(map[string]interface{})["response"].(map[string]interface{})
+	if response["image"] != staleImageNote || response["boardId"] != "b1" {
+		t.Fatalf("function response = %v", response)
+	}
+	if messages[2].Content != "plain text stays" {
+		t.Fatalf("text message = %v", messages[2].Content)
+	}
+
+	// the original blocks are copied, not changed
+	if toolResult["content"].([]map[string]interface{})[1]["type"] != "image" {
+		t.Fatal("compaction changed the original tool result")
+	}
+	if openAIResult["function"].(map[string]interface{})["response"].(map[string]interface{})["image"] != "iVBOR" {
+		t.Fatal("compaction changed the original function response")
+	}
+}
+
+func TestCompactConverseImages(t *testing.T) {
+	toolResult, _ := toConverseBlock(map[string]interface{}{
+		"type":        "tool_result",
+		"tool_use_id": "t1",
+		"content":     []map[string]interface{}{{"type": "text", "text": "Board image"}, boardImageBlock()},
+	})
+	messages := []map[string]interface{}{{"role": "user", "content": []interface{}{toolResult}}}
+
+	compactConverseImages(messages)
+
+	block := messages[0]["content"].([]interface{})[0].(map[string]interface{})
+	inner := block["toolResult"].(map[string]interface{})["content"].([]interface{})
+	if len(inner) != 2 || inner[1].(map[string]interface{})["text"] != staleImageNote {
+		t.Fatalf("tool result content = %v", inner)
+	}
+	if block["toolResult"].(map[string]interface{})["toolUseId"] != "t1" {
+		t.Fatalf("tool result = %v", block)
+	}
+}
+
+func TestToolLoopImagesKeepsTheCallersAndLatestImages(t *testing.T) {
+	imageOf := func(m Message) interface{} {
+		return m.Content.([]map[string]interface{})[0]["type"]
+	}
+	messages := []Message{{Role: "user", Content: []map[string]interface{}{boardImageBlock()}}}
+	images := newToolLoopImages(len(messages))
+
+	// first iteration: nothing of the loop's own to compact yet
+	images.compact(messages)
+	messages = append(messages, Message{Role: "user", Content: []map[string]interface{}{boardImageBlock()}})
+	// second iteration: the first iteration's image is outdated
+	images.compact(messages)
+	messages = append(messages, Message{Role: "user", Content: []map[string]interface{}{boardImageBlock()}})
+
+	if imageOf(messages[0]) != "image" {
+		t.Error("expected the caller's attachment to be kept")
+	}
+	if imageOf(messages[1]) != "text" {
+		t.Error("expected the first iteration's image to be compacted")
+	}
+	if imageOf(messages[2]) != "image" {
+		t.Error("expected the latest iteration's image to be kept")
+	}
+}
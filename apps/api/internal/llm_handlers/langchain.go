@@ -21,6 +21,7 @@ type LangChainClient struct {
 	Tools       []map[string]interface{}
 	Temperature *float32 // Optional: nil means use default
 	MaxTokens   *int     // Optional: nil means use default
+	textOnly    bool
 }
 
 // StreamingContext holds the context needed for streaming responses
@@ -44,6 +45,7 @@ type LangChainConfig struct {
 	Tools       []map[string]interface{} // Tool definitions in OpenAI format
 	Temperature *float32                 // Optional: nil means use default
 	MaxTokens   *int                     // Optional: nil means use default
+	TextOnly    bool                     // model has no image input: image blocks are replaced by a note
 }
 
 // LangChainResponse contains the parsed response from LangChain
@@ -87,6 +89,7 @@ func NewLangChainClient(cfg LangChainConfig) (*LangChainClient, error) {
 		Tools:       cfg.Tools,
 		Temperature: cfg.Temperature,
 		MaxTokens:   cfg.MaxTokens,
+		textOnly:    cfg.TextOnly,
 	}, nil
 }
 
@@ -155,6 +158,10 @@ func (c *LangChainClient) convertMessagesToLangChainContent(messages []Message)
 					}
 
 				case "image":
+					if c.textOnly {
+						parts = append(parts, llms.TextPart(imageUnavailableNote))
+						continue
+					}
 					if source, ok := block["source"].(map[string]interface{}); ok {
 						mediaType, _ := source["media_type"].(string)
 						dataStr, _ := source["data"].(string)
@@ -213,26 +220,10 @@ func (c *LangChainClient) callLangChainWithMessages(ctx context.Context, systemM
 	// Convert tools to langchaingo format
 	langChainTools := convertToolsToLangChainTools(c.Tools)
 
+	// intermediate iterations buffer their text until they are known to have made no tool calls
+	dispatch := newStreamDispatcher(streamCtx, streamCtx != nil && !streamCtx.ShouldStream)
 	streamingFunc := func(ctx context.Context, chunk []byte) error {
-		// Only handle streaming if client is provided
-		if streamCtx != nil && streamCtx.Client != nil {
-			chunkStr := string(chunk)
-
-			if streamCtx.ShouldStream {
-				// Stream immediately (final iteration, no tool calls)
-				payload := &libraries.ChatMessageResponsePayload{
-					Message: chunkStr,
-				}
-				// Only include BoardId if it's not empty
-				if streamCtx.BoardId != "" {
-					payload.BoardId = streamCtx.BoardId
-				}
-				libraries.SendChatMessageResponse(streamCtx.Hub, streamCtx.Client, libraries.WebSocketMessageTypeChatResponse, payload)
-			} else {
-				// Buffer chunks (intermediate iteration, might have tool calls)
-				streamCtx.BufferedChunks = append(streamCtx.BufferedChunks, chunkStr)
-			}
-		}
+		dispatch.Emit(TextDelta(string(chunk)))
 		return nil
 	}
 
@@ -250,6 +241,10 @@ func (c *LangChainClient) callLangChainWithMessages(ctx context.Context, systemM
 		opts = append(opts, llms.WithMaxTokens(*c.MaxTokens))
 	}
 
+	if seed, ok := seedFrom(ctx); ok {
+		opts = append(opts, llms.WithSeed(int(seed)))
+	}
+
 	// Add thinking support - Meta/Llama models do NOT support thinking
 	if enableThinking {
 		if isLlamaModel(c.Model) {
@@ -290,6 +285,7 @@ func (c *LangChainClient) callLangChainWithMessages(ctx context.Context, systemM
 	if err != nil {
 		return nil, fmt.Errorf("langchain GenerateContent: %w", err)
 	}
+	dispatch.Emit(StreamDone())
 
 	if len(resp.Choices) == 0 {
 		return nil, fmt.Errorf("langchain returned no choices")
@@ -421,9 +417,11 @@ func (c *LangChainClient) callLangChainWithMessages(ctx context.Context, systemM
 // ChatWithTools handles tool execution loop similar to Anthropic's and Gemini's implementation
 func (c *LangChainClient) ChatWithTools(ctx context.Context, systemMessage string, messages []Message, streamCtx *StreamingContext, enableThinking bool) (*LangChainResponse, error) {
 	maxIterations := constants.GetMaxIterations(ctx)
+	ctx = WithToolInputRetries(ctx)
 
 	workingMessages := make([]Message, 0, len(messages)+6)
 	workingMessages = append(workingMessages, messages...)
+	images := newToolLoopImages(len(workingMessages))
 
 	var lastResp *LangChainResponse
 
@@ -483,17 +481,7 @@ func (c *LangChainClient) ChatWithTools(ctx context.Context, systemMessage strin
 			}
 
 			// Final iteration - send all buffered chunks to the client
-			if currentStreamCtx != nil && len(currentStreamCtx.BufferedChunks) > 0 {
-				for _, chunk := range currentStreamCtx.BufferedChunks {
-					payload := &libraries.ChatMessageResponsePayload{
-						Message: chunk,
-					}
-					if currentStreamCtx.BoardId != "" {
-						payload.BoardId = currentStreamCtx.BoardId
-					}
-					libraries.SendChatMessageResponse(currentStreamCtx.Hub, currentStreamCtx.Client, libraries.WebSocketMessageTypeChatResponse, payload)
-				}
-			}
+			flushBufferedChunks(currentStreamCtx)
 			return lr, nil
 		}
 
@@ -529,6 +517,8 @@ func (c *LangChainClient) ChatWithTools(ctx context.Context, systemMessage strin
 		// Don't add assistant message with function calls to history
 		// The model already knows it made the call, we just need to provide the result
 
+		images.compact(workingMessages)
+
 		// Append user message with function results as simple text
 		// Combine all tool results into a single clear message
 		var toolResultTexts []string
@@ -631,17 +621,7 @@ func (c *LangChainClient) ChatWithTools(ctx context.Context, systemMessage strin
 	}
 
 	// Send any buffered chunks from final response
-	if finalStreamCtx != nil && len(finalStreamCtx.BufferedChunks) > 0 {
-		for _, chunk := range finalStreamCtx.BufferedChunks {
-			payload := &libraries.ChatMessageResponsePayload{
-				Message: chunk,
-			}
-			if finalStreamCtx.BoardId != "" {
-				payload.BoardId = finalStreamCtx.BoardId
-			}
-			libraries.SendChatMessageResponse(finalStreamCtx.Hub, finalStreamCtx.Client, libraries.WebSocketMessageTypeChatResponse, payload)
-		}
-	}
+	flushBufferedChunks(finalStreamCtx)
 
 	// Fallback: if final response has no text content, return lastResp or default message
 	if len(finalResp.TextContent) == 0 || (len(finalResp.TextContent) == 1 && strings.TrimSpace(finalResp.TextContent[0]) == "") {
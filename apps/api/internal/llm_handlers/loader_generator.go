@@ -8,6 +8,7 @@ import (
 	"path/filepath"
 	"sync"
 
+	"github.com/google/uuid"
 	"gopkg.in/yaml.v3"
 )
 
@@ -21,6 +22,8 @@ const (
 // LoaderConfig holds the configuration loaded from YAML
 type LoaderConfig struct {
 	Messages map[string][]string `yaml:"messages"`
+	// Status maps tool names (see loader_messages.yaml for the key format) to loader_status labels
+	Status map[string]string `yaml:"status"`
 }
 
 // LoaderGenerator generates loader messages from configuration
@@ -29,6 +32,9 @@ type LoaderGenerator struct {
 	mu            sync.Mutex
 	toolCallCount map[string]int      // Track calls per tool type
 	usedMessages  map[string][]string // Track used messages per tool type
+	iteration     int                 // Tool rounds started in the current reply
+	statusID      string              // The loader_status currently shown, empty when none
+	statusLabel   string
 }
 
 // Global config - loaded once on startup
@@ -107,6 +113,9 @@ func (lg *LoaderGenerator) Reset() {
 	defer lg.mu.Unlock()
 	lg.toolCallCount = make(map[string]int)
 	lg.usedMessages = make(map[string][]string)
+	lg.iteration = 0
+	lg.statusID = ""
+	lg.statusLabel = ""
 }
 
 // GetMessage returns a random message for the given category
@@ -193,3 +202,89 @@ func (lg *LoaderGenerator) SendThinkingMessage(hub *libraries.Hub, client *libra
 		libraries.SendLoaderUpdateMessage(hub, client, boardId, msg)
 	}
 }
+
+// BeginToolRound marks the start of a batch of tool calls and returns its 0-based iteration
+func (lg *LoaderGenerator) BeginToolRound() int {
+	lg.mu.Lock()
+	defer lg.mu.Unlock()
+	lg.iteration++
+	return lg.iteration - 1
+}
+
+// StatusLabel returns the loader_status label for a tool call, from the most specific configured key
+func (lg *LoaderGenerator) StatusLabel(toolName string, variant string, iteration int) string {
+	if lg.config == nil {
+		return ""
+	}
+	var keys []string
+	if variant != "" {
+		if iteration > 0 {
+			keys = append(keys, toolName+"."+variant+"@followup")
+		}
+		keys = append(keys, toolName+"."+variant)
+	}
+	if iteration > 0 {
+		keys = append(keys, toolName+"@followup")
+	}
+	keys = append(keys, toolName, "default")
+	for _, key := range keys {
+		if label, ok := lg.config.Status[key]; ok && label != "" {
+			return label
+		}
+	}
+	return ""
+}
+
+// SendStatus shows the status of a tool call; consecutive calls with the same label share one status
+func (lg *LoaderGenerator) SendStatus(hub *libraries.Hub, client *libraries.Client, boardId string, toolName string, variant string, iteration int) {
+	if hub == nil || client == nil {
+		return
+	}
+	label := lg.StatusLabel(toolName, variant, iteration)
+	if label == "" {
+		return
+	}
+
+	lg.mu.Lock()
+	if label == lg.statusLabel {
+		lg.mu.Unlock()
+		return
+	}
+	previous := lg.statusID
+	lg.statusID = uuid.NewString()
+	lg.statusLabel = label
+	current := lg.statusID
+	lg.mu.Unlock()
+
+	if previous != "" {
+		libraries.SendLoaderStatusMessage(hub, client, &libraries.LoaderStatusPayload{
+			BoardId: boardId, StatusId: previous, State: libraries.LoaderStatusStop, Iteration: iteration,
+		})
+	}
+	libraries.SendLoaderStatusMessage(hub, client, &libraries.LoaderStatusPayload{
+		BoardId:   boardId,
+		StatusId:  current,
+		State:     libraries.LoaderStatusStart,
+		Label:     label,
+		Tool:      toolName,
+		Iteration: iteration,
+	})
+}
+
+// StopStatus ends the status shown, if any; called once a tool round finishes
+func (lg *LoaderGenerator) StopStatus(hub *libraries.Hub, client *libraries.Client, boardId string, iteration int) {
+	if hub == nil || client == nil {
+		return
+	}
+	lg.mu.Lock()
+	previous := lg.statusID
+	lg.statusID = ""
+	lg.statusLabel = ""
+	lg.mu.Unlock()
+
+	if previous != "" {
+		libraries.SendLoaderStatusMessage(hub, client, &libraries.LoaderStatusPayload{
+			BoardId: boardId, StatusId: previous, State: libraries.LoaderStatusStop, Iteration: iteration,
+		})
+	}
+}
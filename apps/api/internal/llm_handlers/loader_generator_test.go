@@ -0,0 +1,54 @@
+package llmHandlers
+
+import (
+	"os"
+	"testing"
+
+	"gopkg.in/yaml.v3"
+)
+
+func TestStatusLabel(t *testing.T) {
+	data, err := os.ReadFile("../../config/loader_messages.yaml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var cfg LoaderConfig
+	if err := yaml.Unmarshal(data, &cfg); err != nil {
+		t.Fatalf("loader_messages.yaml: %v", err)
+	}
+	lg := &LoaderGenerator{config: &cfg}
+
+	cases := []struct {
+		tool, variant string
+		iteration     int
+		want          string
+	}{
+		{"getBoardData", "", 0, "Reading your board…"},
+		{"getBoardData", "", 2, "Checking how it looks…"},
+		{"addShape", "flowchart", 0, "Drawing the flowchart…"},
+		{"addShape", "rect", 0, "Drawing on your board…"},
+		{"addShape", "rect", 1, "Adding more detail…"},
+		{"deleteShape", "", 3, "Tidying up…"},
+		{"someFutureTool", "", 0, "Working on your board…"},
+	}
+	for _, c := range cases {
+		if got := lg.StatusLabel(c.tool, c.variant, c.iteration); got != c.want {
+			t.Errorf("StatusLabel(%s, %q, %d) = %q, want %q", c.tool, c.variant, c.iteration, got, c.want)
+		}
+	}
+}
+
+func TestIsFlowchartRound(t *testing.T) {
+	shape := func(shapeType string) ToolCall {
+		return ToolCall{Name: "addShape", Input: map[string]interface{}{"shapeType": shapeType}}
+	}
+	if !isFlowchartRound([]ToolCall{shape("rect"), shape("arrow"), shape("rect")}) {
+		t.Error("boxes and arrows should be a flowchart")
+	}
+	if isFlowchartRound([]ToolCall{shape("rect"), shape("text")}) {
+		t.Error("boxes without connectors are not a flowchart")
+	}
+	if isFlowchartRound([]ToolCall{{Name: "updateShape", Input: map[string]interface{}{"shapeType": "arrow"}}, shape("rect")}) {
+		t.Error("only drawn shapes count")
+	}
+}
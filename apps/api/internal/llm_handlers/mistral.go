@@ -0,0 +1,21 @@
+package llmHandlers
+
+import (
+	"fmt"
+	"melina-studio-backend/internal/config"
+
+	openrouter "github.com/revrost/go-openrouter"
+)
+
+// NewMistralClient talks to La Plateforme directly through the OpenAI-compatible client
+// Only chat models are registered: Magistral streams its thinking as structured content chunks,
+// which the OpenAI-compatible stream decoder can't read
+func NewMistralClient(modelID string, temperature *float32, maxTokens *int, tools []map[string]interface{}) (*OpenRouterClient, error) {
+	settings := config.GetSettings().LLM
+	if settings.MistralAPIKey == "" {
+		return nil, fmt.Errorf("MISTRAL_API_KEY is not set")
+	}
+	cfg := openrouter.DefaultConfig(settings.MistralAPIKey)
+	cfg.BaseURL = settings.MistralBaseURL
+	return newOpenAICompatibleClient(ProviderMistral, cfg, modelID, temperature, maxTokens, tools), nil
+}
@@ -0,0 +1,257 @@
+package llmHandlers
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"melina-studio-backend/internal/config"
+	"melina-studio-backend/internal/libraries"
+	"melina-studio-backend/internal/models"
+	"os"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// MockModelName is the registry name of the local development model offered when LLM_MOCK_MODEL is on
+const MockModelName = "mock"
+
+// MockScenario scripts the mock model's replies: the first rule whose match occurs in the user's message
+// is played, and a message no rule matches is echoed back
+type MockScenario struct {
+	Rules []MockRule `yaml:"rules"`
+}
+
+// MockRule is one scripted reply
+type MockRule struct {
+	// Match is looked for in the user's message, ignoring case; empty matches every message
+	Match string     `yaml:"match"`
+	Steps []MockStep `yaml:"steps"`
+}
+
+// MockStep streams its text, then runs its tool calls the way a model's tool round would. Text and string
+// tool inputs may use {{message}} and {{board_id}}
+type MockStep struct {
+	Text      string         `yaml:"text"`
+	ToolCalls []MockToolCall `yaml:"tool_calls"`
+}
+
+// MockToolCall is a tool the step calls
+type MockToolCall struct {
+	Name  string                 `yaml:"name"`
+	Input map[string]interface{} `yaml:"input"`
+}
+
+// mockModelEnabled reports whether the mock model is offered
+func mockModelEnabled() bool {
+	return config.GetSettings().LLM.MockModel
+}
+
+// mockModelInfo returns the registry entry of the mock model while it is enabled
+func mockModelInfo(modelName string) (*ModelInfo, bool) {
+	if modelName != MockModelName || !mockModelEnabled() {
+		return nil, false
+	}
+	return &ModelInfo{
+		Provider:      ProviderMock,
+		ModelID:       MockModelName,
+		DisplayName:   "Mock (local development)",
+		TextOnly:      true,
+		ContextWindow: defaultContextWindow,
+	}, true
+}
+
+// LoadMockScenario reads a scenario file; an empty path is the empty scenario, which echoes every message.
+// Tool calls are checked against the tool definitions given, so a typo fails here rather than mid-chat
+func LoadMockScenario(path string, tools []map[string]interface{}) (*MockScenario, error) {
+	scenario := &MockScenario{}
+	if path == "" {
+		return scenario, nil
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read mock scenario: %w", err)
+	}
+	if err := yaml.Unmarshal(data, scenario); err != nil {
+		return nil, fmt.Errorf("parse mock scenario %s: %w", path, err)
+	}
+
+	known := make(map[string]bool, len(tools))
+	for _, tool := range tools {
+		if name := toolDefinitionName(tool); name != "" {
+			known[name] = true
+		}
+	}
+	for i, rule := range scenario.Rules {
+		for _, step := range rule.Steps {
+			for _, call := range step.ToolCalls {
+				if call.Name == "" {
+					return nil, fmt.Errorf("mock scenario %s: rule %d has a tool call without a name", path, i+1)
+				}
+				if len(known) > 0 && !known[call.Name] {
+					return nil, fmt.Errorf("mock scenario %s: rule %d calls %q, which is not one of the agent's tools", path, i+1, call.Name)
+				}
+			}
+		}
+	}
+	return scenario, nil
+}
+
+// toolDefinitionName returns the name of a tool definition in the OpenAI or Anthropic format
+func toolDefinitionName(tool map[string]interface{}) string {
+	if function, ok := tool["function"].(map[string]interface{}); ok {
+		name, _ := function["name"].(string)
+		return name
+	}
+	name, _ := tool["name"].(string)
+	return name
+}
+
+// match returns the steps scripted for a message, nil when no rule matches it
+func (s *MockScenario) match(message string) []MockStep {
+	lower := strings.ToLower(message)
+	for _, rule := range s.Rules {
+		if strings.Contains(lower, strings.ToLower(rule.Match)) {
+			return rule.Steps
+		}
+	}
+	return nil
+}
+
+// MockClient answers deterministically from a scenario, so the chat and tool pipeline runs without API keys
+type MockClient struct {
+	scenario *MockScenario
+	// err is why the scenario didn't load; it fails the chat turns rather than the server, which exits
+	// when a chat agent's client can't be built
+	err error
+}
+
+func NewMockClient(tools []map[string]interface{}) *MockClient {
+	scenario, err := LoadMockScenario(config.GetSettings().LLM.MockScenario, tools)
+	return &MockClient{scenario: scenario, err: err}
+}
+
+func (c *MockClient) Chat(ctx context.Context, systemMessage string, messages []Message, enableThinking bool) (string, error) {
+	return c.play(ctx, "", messages, nil)
+}
+
+func (c *MockClient) ChatStream(ctx context.Context, hub *libraries.Hub, client *libraries.Client, boardId string, systemMessage string, messages []Message, enableThinking bool) (string, error) {
+	var streamCtx *StreamingContext
+	if client != nil {
+		streamCtx = &StreamingContext{Hub: hub, Client: client, BoardId: boardId, UserID: client.UserID}
+	}
+	return c.play(ctx, boardId, messages, streamCtx)
+}
+
+func (c *MockClient) ChatStreamWithUsage(req ChatStreamRequest) (*ResponseWithUsage, error) {
+	var streamCtx *StreamingContext
+	if req.Client != nil {
+		streamCtx = &StreamingContext{Hub: req.Hub, Client: req.Client, BoardId: req.BoardID, UserID: req.Client.UserID, LoaderGen: req.LoaderGen}
+	}
+	text, err := c.play(req.Ctx, req.BoardID, req.Messages, streamCtx)
+	if err != nil {
+		return nil, err
+	}
+	// nothing was sent to a provider, so the turn costs no tokens
+	return &ResponseWithUsage{Text: text, TokenUsage: &TokenUsage{CountingMethod: "mock"}}, nil
+}
+
+// play streams the steps scripted for the last user message, or echoes it when none are
+func (c *MockClient) play(ctx context.Context, boardID string, messages []Message, streamCtx *StreamingContext) (string, error) {
+	if c.err != nil {
+		return "", c.err
+	}
+	message := lastUserText(messages)
+	steps := c.scenario.match(message)
+	if steps == nil {
+		steps = []MockStep{{Text: "Echo: {{message}}"}}
+	}
+	vars := strings.NewReplacer("{{message}}", message, "{{board_id}}", boardID)
+
+	ctx = WithToolInputRetries(ctx)
+	var reply strings.Builder
+	for i, step := range steps {
+		if err := ctx.Err(); err != nil {
+			return reply.String(), err
+		}
+		dispatch := newStreamDispatcher(streamCtx, false)
+		if text := vars.Replace(step.Text); text != "" {
+			if reply.Len() > 0 {
+				text = "\n" + text
+			}
+			reply.WriteString(text)
+			dispatch.Emit(TextDelta(text))
+		}
+		if len(step.ToolCalls) == 0 {
+			dispatch.Emit(StreamDone())
+			continue
+		}
+
+		toolCalls := make([]ToolCall, len(step.ToolCalls))
+		for j, call := range step.ToolCalls {
+			input, _ := substituteMockInput(call.Input, vars).(map[string]interface{})
+			toolCalls[j] = ToolCall{
+				ID:       fmt.Sprintf("mock_%d_%d", i+1, j+1),
+				Name:     call.Name,
+				Input:    input,
+				Provider: string(ProviderMock),
+			}
+			dispatch.Emit(ToolStarted(toolCalls[j].ID, call.Name))
+		}
+		dispatch.Emit(StreamDone())
+		for _, result := range ExecuteTools(ctx, toolCalls, streamCtx) {
+			// a scenario that breaks a tool should say so, or it looks like the pipeline lost the call
+			if result.Error != nil {
+				log.Printf("[mock] tool %s failed: %v", result.ToolName, result.Error)
+				fmt.Fprintf(&reply, "\n[%s failed: %v]", result.ToolName, result.Error)
+			}
+		}
+	}
+	return reply.String(), nil
+}
+
+// substituteMockInput fills the placeholders in the string values of a scripted tool input
+func substituteMockInput(value interface{}, vars *strings.Replacer) interface{} {
+	switch v := value.(type) {
+	case string:
+		return vars.Replace(v)
+	case int:
+		// the tools read numbers the way JSON decodes them
+		return float64(v)
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			out[key] = substituteMockInput(item, vars)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(v))
+		for i, item := range v {
+			out[i] = substituteMockInput(item, vars)
+		}
+		return out
+	}
+	return value
+}
+
+// lastUserText returns the text of the last user message
+func lastUserText(messages []Message) string {
+	for i := len(messages) - 1; i >= 0; i-- {
+		if messages[i].Role != models.RoleUser {
+			continue
+		}
+		switch content := messages[i].Content.(type) {
+		case string:
+			return content
+		case []map[string]interface{}:
+			var parts []string
+			for _, block := range content {
+				if text, ok := block["text"].(string); ok {
+					parts = append(parts, text)
+				}
+			}
+			return strings.Join(parts, "\n")
+		}
+	}
+	return ""
+}
@@ -0,0 +1,103 @@
+package llmHandlers
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"melina-studio-backend/internal/models"
+)
+
+func writeMockScenario(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "scenario.yaml")
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestMockScenarioExampleLoads(t *testing.T) {
+	tools := []map[string]interface{}{
+		{"type": "function", "function": map[string]interface{}{"name": "addShape"}},
+		{"type": "function", "function": map[string]interface{}{"name": "getBoardData"}},
+	}
+	scenario, err := LoadMockScenario("../../config/mock_scenario.yaml", tools)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(scenario.Rules) == 0 {
+		t.Error("the example scenario has no rules")
+	}
+}
+
+func TestMockScenarioRejectsUnknownTools(t *testing.T) {
+	path := writeMockScenario(t, `
+rules:
+  - match: box
+    steps:
+      - tool_calls:
+          - name: addShap
+`)
+	tools := []map[string]interface{}{{"name": "addShape"}}
+	if _, err := LoadMockScenario(path, tools); err == nil {
+		t.Error("a tool call of an unknown tool was accepted")
+	}
+}
+
+func TestMockClientEchoesUnmatchedMessages(t *testing.T) {
+	client := &MockClient{scenario: &MockScenario{}}
+	reply, err := client.Chat(context.Background(), "", []Message{{Role: models.RoleUser, Content: "hello there"}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply != "Echo: hello there" {
+		t.Errorf("got %q, want the message echoed", reply)
+	}
+}
+
+func TestMockClientPlaysScriptedToolCalls(t *testing.T) {
+	var got map[string]interface{}
+	RegisterTool("mockTestTool", func(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+		got = input
+		return "ok", nil
+	})
+	defer UnregisterTool("mockTestTool")
+
+	path := writeMockScenario(t, `
+rules:
+  - match: draw
+    steps:
+      - text: "Drawing {{message}}"
+        tool_calls:
+          - name: mockTestTool
+            input:
+              boardId: "{{board_id}}"
+              x: 100
+      - text: Done
+`)
+	scenario, err := LoadMockScenario(path, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	client := &MockClient{scenario: scenario}
+	reply, err := client.ChatStream(context.Background(), nil, nil, "board-1", "", []Message{{Role: models.RoleUser, Content: "Draw a box"}}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if reply != "Drawing Draw a box\nDone" {
+		t.Errorf("got reply %q", reply)
+	}
+	if got["boardId"] != "board-1" || got["x"] != float64(100) {
+		t.Errorf("tool got input %v, want the board ID filled in and x as a JSON number", got)
+	}
+}
+
+func TestMockClientReportsScenarioErrors(t *testing.T) {
+	client := &MockClient{err: os.ErrNotExist}
+	if _, err := client.Chat(context.Background(), "", nil, false); !errors.Is(err, os.ErrNotExist) {
+		t.Errorf("got %v, want the scenario's load error", err)
+	}
+}
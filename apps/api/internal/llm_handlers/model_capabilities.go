@@ -0,0 +1,148 @@
+package llmHandlers
+
+import (
+	"fmt"
+
+	"melina-studio-backend/internal/models"
+)
+
+// Codes of the warnings a chat turn gets when the model can't do everything the turn asked for
+const (
+	CapabilityImagesDescribed  = "images_described"
+	CapabilityThinkingDisabled = "thinking_disabled"
+	CapabilityToolsUnavailable = "tools_unavailable"
+	CapabilityHistoryTrimmed   = "history_trimmed"
+	CapabilityContextExceeded  = "context_exceeded"
+)
+
+const (
+	defaultContextWindow    = 128000
+	maxContextOutputReserve = 8192
+	imageTokenEstimate      = 1600 // roughly what one selection image costs across providers
+	charsPerTokenEstimate   = 4
+)
+
+// ModelCapabilities is what a model can take in a chat turn
+type ModelCapabilities struct {
+	Vision        bool
+	Tools         bool
+	Thinking      bool
+	ContextWindow int
+}
+
+// Capabilities returns what the model supports
+// Meta/Llama models have no thinking, and a local model loses tools once Ollama rejected them
+func (m *ModelInfo) Capabilities() ModelCapabilities {
+	caps := ModelCapabilities{
+		Vision:        !m.TextOnly,
+		Tools:         true,
+		Thinking:      !isLlamaModel(m.ModelID),
+		ContextWindow: m.ContextWindow,
+	}
+	if m.Provider == ProviderOllama {
+		_, noTools := ollamaNoToolModels.Load(m.ModelID)
+		caps.Tools = !noTools
+	}
+	if caps.ContextWindow <= 0 {
+		caps.ContextWindow = defaultContextWindow
+	}
+	return caps
+}
+
+// CapabilityWarning tells the user how a turn was adjusted to what the model supports
+type CapabilityWarning struct {
+	Code    string
+	Message string
+}
+
+// TurnRequest is what a chat turn asks of the model, measured before the turn starts
+type TurnRequest struct {
+	Images       int  // selection and uploaded images attached to the message
+	Thinking     bool // the user asked for thinking
+	Tools        bool // the agent profile gives the agent tools
+	PromptTokens int  // estimated tokens of the system prompt and the new message
+	History      []Message
+}
+
+// TurnPlan is how the turn runs on the model
+type TurnPlan struct {
+	Thinking bool
+	// HistoryStart is the index of the first history message sent; older ones don't fit the context window
+	HistoryStart int
+	// Blocked is set when the new message alone does not fit the context window
+	Blocked  bool
+	Warnings []CapabilityWarning
+}
+
+// PlanTurn checks a turn against the model's capabilities before it starts and adjusts what it can:
+// thinking is turned off, images are described as text and the oldest history is dropped to fit
+func PlanTurn(info *ModelInfo, req TurnRequest) TurnPlan {
+	caps := info.Capabilities()
+	plan := TurnPlan{Thinking: req.Thinking}
+	warn := func(code, format string, args ...interface{}) {
+		plan.Warnings = append(plan.Warnings, CapabilityWarning{Code: code, Message: fmt.Sprintf(format, args...)})
+	}
+
+	if req.Images > 0 && !caps.Vision {
+		warn(CapabilityImagesDescribed, "%s can't view images; the selected shapes are described as text and other attached images are left out", info.DisplayName)
+	}
+	if req.Thinking && !caps.Thinking {
+		plan.Thinking = false
+		warn(CapabilityThinkingDisabled, "%s doesn't support thinking; the reply comes without it", info.DisplayName)
+	}
+	if req.Tools && !caps.Tools {
+		warn(CapabilityToolsUnavailable, "%s can't use tools, so it can answer but not change the board", info.DisplayName)
+	}
+
+	// keep room for the reply: a quarter of small windows, at most maxContextOutputReserve
+	budget := caps.ContextWindow - min(caps.ContextWindow/4, maxContextOutputReserve) - req.PromptTokens
+	if budget < 0 {
+		plan.Blocked = true
+		plan.HistoryStart = len(req.History)
+		warn(CapabilityContextExceeded, "This message is too long for %s (about %d tokens, the model takes %d)", info.DisplayName, req.PromptTokens, caps.ContextWindow)
+		return plan
+	}
+
+	// drop the oldest messages until the rest fits, then start on a user message
+	used := 0
+	for _, m := range req.History {
+		used += EstimateMessageTokens(m)
+	}
+	for plan.HistoryStart < len(req.History) && used > budget {
+		used -= EstimateMessageTokens(req.History[plan.HistoryStart])
+		plan.HistoryStart++
+	}
+	if plan.HistoryStart > 0 {
+		for plan.HistoryStart < len(req.History) && req.History[plan.HistoryStart].Role != models.RoleUser {
+			plan.HistoryStart++
+		}
+		warn(CapabilityHistoryTrimmed, "The conversation is longer than %s can read; the %d oldest messages were left out", info.DisplayName, plan.HistoryStart)
+	}
+	return plan
+}
+
+// EstimateTokens is a quick estimate of the tokens of a text, for checks that run before every turn
+func EstimateTokens(text string) int {
+	return (len(text) + charsPerTokenEstimate - 1) / charsPerTokenEstimate
+}
+
+// EstimateMessageTokens estimates the tokens of a message, counting a fixed cost per image
+func EstimateMessageTokens(m Message) int {
+	switch content := m.Content.(type) {
+	case string:
+		return EstimateTokens(content)
+	case []map[string]interface{}:
+		tokens := 0
+		for _, block := range content {
+			if block["type"] == "image" {
+				tokens += imageTokenEstimate
+				continue
+			}
+			if text, ok := block["text"].(string); ok {
+				tokens += EstimateTokens(text)
+			}
+		}
+		return tokens
+	}
+	return 0
+}
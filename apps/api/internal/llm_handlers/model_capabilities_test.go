@@ -0,0 +1,69 @@
+package llmHandlers
+
+import (
+	"strings"
+	"testing"
+
+	"melina-studio-backend/internal/models"
+)
+
+func warningCodes(plan TurnPlan) string {
+	codes := make([]string, 0, len(plan.Warnings))
+	for _, w := range plan.Warnings {
+		codes = append(codes, w.Code)
+	}
+	return strings.Join(codes, ",")
+}
+
+func TestPlanTurnAdjustsToTextOnlyLlama(t *testing.T) {
+	info := ModelRegistry["llama-3.3-70b-versatile"]
+	plan := PlanTurn(&info, TurnRequest{Images: 2, Thinking: true, Tools: true, PromptTokens: 500})
+	if plan.Thinking || plan.Blocked || plan.HistoryStart != 0 {
+		t.Fatalf("plan = %+v", plan)
+	}
+	if got := warningCodes(plan); got != "images_described,thinking_disabled" {
+		t.Fatalf("warnings = %s", got)
+	}
+}
+
+func TestPlanTurnKeepsCapableModelsUntouched(t *testing.T) {
+	info := ModelRegistry["claude-4.5-sonnet"]
+	plan := PlanTurn(&info, TurnRequest{Images: 1, Thinking: true, Tools: true, PromptTokens: 500})
+	if !plan.Thinking || len(plan.Warnings) != 0 {
+		t.Fatalf("plan = %+v", plan)
+	}
+}
+
+func TestPlanTurnTrimsHistoryToTheContextWindow(t *testing.T) {
+	info := &ModelInfo{DisplayName: "Small", ContextWindow: 1000}
+	long := strings.Repeat("x", 1200) // 300 tokens
+	history := []Message{
+		{Role: models.RoleUser, Content: long},
+		{Role: models.RoleAssistant, Content: long},
+		{Role: models.RoleUser, Content: long},
+		{Role: models.RoleAssistant, Content: long},
+	}
+	// 750 tokens of budget after the reply reserve, 100 of them for the prompt
+	plan := PlanTurn(info, TurnRequest{PromptTokens: 100, History: history})
+	if plan.Blocked || plan.HistoryStart != 2 {
+		t.Fatalf("plan = %+v", plan)
+	}
+	if got := warningCodes(plan); got != "history_trimmed" {
+		t.Fatalf("warnings = %s", got)
+	}
+
+	plan = PlanTurn(info, TurnRequest{PromptTokens: 900, History: history})
+	if !plan.Blocked || plan.HistoryStart != len(history) || warningCodes(plan) != "context_exceeded" {
+		t.Fatalf("plan = %+v", plan)
+	}
+}
+
+func TestEstimateMessageTokensCountsImages(t *testing.T) {
+	m := Message{Role: models.RoleUser, Content: []map[string]interface{}{
+		{"type": "text", "text": "abcdefgh"},
+		{"type": "image", "source": map[string]interface{}{"data": "..."}},
+	}}
+	if got := EstimateMessageTokens(m); got != 2+imageTokenEstimate {
+		t.Fatalf("tokens = %d", got)
+	}
+}
@@ -0,0 +1,64 @@
+package llmHandlers
+
+import "strings"
+
+// ModelPrice is the price of a model in USD per million tokens
+type ModelPrice struct {
+	Input  float64
+	Output float64
+}
+
+// defaultModelPrice is charged for models missing from the price sheet
+var defaultModelPrice = ModelPrice{Input: 1.0, Output: 2.0}
+
+// ModelPricing is the price sheet of the models in ModelRegistry, keyed the same way
+var ModelPricing = map[string]ModelPrice{
+	"claude-4.5-sonnet":         {Input: 4.00, Output: 19.00},
+	"claude-4-opus":             {Input: 15.00, Output: 75.00},
+	"bedrock/claude-4.5-sonnet": {Input: 4.00, Output: 19.00},
+	"bedrock/claude-4-opus":     {Input: 15.00, Output: 75.00},
+	"bedrock/llama-3.3-70b":     {Input: 0.75, Output: 1.00},
+	"gpt-5.1":                   {Input: 1.60, Output: 12.50},
+	"gpt-5.2":                   {Input: 1.75, Output: 14.00},
+	"gpt-4.1":                   {Input: 2.00, Output: 8.00},
+	"gemini-2.5-flash":          {Input: 0.20, Output: 0.75},
+	"gemini-2.5-pro":            {Input: 1.25, Output: 10.00},
+	"meta-llama/llama-4-scout-17b-16e-instruct": {Input: 0.15, Output: 0.45},
+	"llama-3.3-70b-versatile":                   {Input: 0.75, Output: 1.00},
+	"moonshotai/kimi-k2.5":                      {Input: 2.00, Output: 5.00},
+	"moonshotai/kimi-k2-thinking":               {Input: 2.00, Output: 5.00},
+	"deepseek/deepseek-r1":                      {Input: 0.90, Output: 3.00},
+	"deepseek/deepseek-r1-0528":                 {Input: 0.90, Output: 3.00},
+	"anthropic/claude-3.5-sonnet":               {Input: 3.00, Output: 15.00},
+	"deepseek-chat":                             {Input: 0.35, Output: 0.55},
+	"deepseek-reasoner":                         {Input: 0.35, Output: 0.55},
+	"mistral-large-latest":                      {Input: 2.50, Output: 7.50},
+	"mistral-medium-latest":                     {Input: 0.50, Output: 2.50},
+}
+
+// ModelCost returns the USD cost of a request to a model
+func ModelCost(model string, inputTokens, outputTokens int) float64 {
+	// self-hosted models cost nothing per token
+	if strings.HasPrefix(model, OllamaModelPrefix) {
+		return 0
+	}
+
+	pricing, exists := ModelPricing[model]
+	if !exists {
+		pricing = defaultModelPrice
+	}
+
+	// Cost per token = price per 1M tokens / 1,000,000
+	inputCost := (float64(inputTokens) / 1_000_000) * pricing.Input
+	outputCost := (float64(outputTokens) / 1_000_000) * pricing.Output
+
+	return inputCost + outputCost
+}
+
+// UsageCost returns the USD cost of a request's token usage
+func UsageCost(model string, usage *TokenUsage) float64 {
+	if usage == nil {
+		return 0
+	}
+	return ModelCost(model, usage.InputTokens, usage.OutputTokens)
+}
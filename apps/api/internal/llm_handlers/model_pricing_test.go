@@ -0,0 +1,38 @@
+package llmHandlers
+
+import (
+	"math"
+	"testing"
+)
+
+func TestModelCost(t *testing.T) {
+	tests := []struct {
+		name   string
+		model  string
+		input  int
+		output int
+		want   float64
+	}{
+		{"priced model", "gpt-5.1", 1_000_000, 100_000, 1.60 + 1.25},
+		{"unknown model uses the default price", "some-new-model", 500_000, 500_000, 0.5 + 1.0},
+		{"self-hosted model is free", OllamaModelPrefix + "llama3.1", 1_000_000, 1_000_000, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ModelCost(tt.model, tt.input, tt.output); math.Abs(got-tt.want) > 1e-9 {
+				t.Fatalf("ModelCost = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestModelPricingCoversRegistry(t *testing.T) {
+	for name := range ModelRegistry {
+		if _, ok := ModelPricing[name]; !ok {
+			t.Errorf("model %s is missing from the price sheet", name)
+		}
+	}
+	if UsageCost("gpt-5.1", nil) != 0 {
+		t.Fatal("a request without usage should cost nothing")
+	}
+}
@@ -1,12 +1,31 @@
 package llmHandlers
 
-import "fmt"
+import (
+	"fmt"
+	"melina-studio-backend/internal/config"
+)
 
 // ModelInfo contains information about a supported model
 type ModelInfo struct {
 	Provider    Provider
 	ModelID     string // The actual model ID to send to the provider
 	DisplayName string
+	// TextOnly models take no image input; selections reach them as a text description of the shapes
+	TextOnly bool
+	// ContextWindow is the number of input tokens the model accepts
+	ContextWindow int
+}
+
+// DefaultModelName is used by background jobs when the caller does not pick a model and DEFAULT_MODEL is unset
+const DefaultModelName = "gemini-2.5-flash"
+
+// DefaultModel returns the model background jobs use when the caller does not pick one
+// Self-hosted deployments point DEFAULT_MODEL at a local model, e.g. "ollama/llama3.1"
+func DefaultModel() string {
+	if model := config.GetSettings().LLM.DefaultModel; model != "" {
+		return model
+	}
+	return DefaultModelName
 }
 
 // ModelRegistry maps model names to their configurations
@@ -14,89 +33,185 @@ type ModelInfo struct {
 var ModelRegistry = map[string]ModelInfo{
 	// Anthropic models (via Vertex) - use Vertex model IDs
 	"claude-4.5-sonnet": {
-		Provider:    ProviderVertexAnthropic,
-		ModelID:     "claude-sonnet-4-5@20250929", // Vertex model ID format
-		DisplayName: "Claude 4.5 Sonnet",
+		Provider:      ProviderVertexAnthropic,
+		ModelID:       "claude-sonnet-4-5@20250929", // Vertex model ID format
+		DisplayName:   "Claude 4.5 Sonnet",
+		ContextWindow: 200000,
 	},
 	"claude-4-opus": {
-		Provider:    ProviderVertexAnthropic,
-		ModelID:     "claude-opus-4@20250514", // Vertex model ID format
-		DisplayName: "Claude 4 Opus",
+		Provider:      ProviderVertexAnthropic,
+		ModelID:       "claude-opus-4@20250514", // Vertex model ID format
+		DisplayName:   "Claude 4 Opus",
+		ContextWindow: 200000,
+	},
+
+	// AWS Bedrock models (via Converse API) - Bedrock model IDs, the inference profile prefix is added per region
+	"bedrock/claude-4.5-sonnet": {
+		Provider:      ProviderBedrock,
+		ModelID:       "anthropic.claude-sonnet-4-5-20250929-v1:0",
+		DisplayName:   "Claude 4.5 Sonnet (Bedrock)",
+		ContextWindow: 200000,
+	},
+	"bedrock/claude-4-opus": {
+		Provider:      ProviderBedrock,
+		ModelID:       "anthropic.claude-opus-4-20250514-v1:0",
+		DisplayName:   "Claude 4 Opus (Bedrock)",
+		ContextWindow: 200000,
+	},
+	"bedrock/llama-3.3-70b": {
+		Provider:      ProviderBedrock,
+		ModelID:       "meta.llama3-3-70b-instruct-v1:0",
+		DisplayName:   "Llama 3.3 70B (Bedrock)",
+		ContextWindow: 128000,
+		TextOnly:      true,
 	},
 
 	// Groq models (via LangChain)
 	"meta-llama/llama-4-scout-17b-16e-instruct": {
-		Provider:    ProviderLangChainGroq,
-		ModelID:     "meta-llama/llama-4-scout-17b-16e-instruct",
-		DisplayName: "Llama 4 Scout 17B",
+		Provider:      ProviderLangChainGroq,
+		ModelID:       "meta-llama/llama-4-scout-17b-16e-instruct",
+		DisplayName:   "Llama 4 Scout 17B",
+		ContextWindow: 131072,
 	},
 	"llama-3.3-70b-versatile": {
-		Provider:    ProviderLangChainGroq,
-		ModelID:     "llama-3.3-70b-versatile",
-		DisplayName: "Llama 3.3 70B Versatile",
+		Provider:      ProviderLangChainGroq,
+		ModelID:       "llama-3.3-70b-versatile",
+		DisplayName:   "Llama 3.3 70B Versatile",
+		ContextWindow: 131072,
+		TextOnly:      true,
 	},
 
 	// OpenAI models (via direct SDK with thinking/reasoning support)
 	"gpt-5.1": {
-		Provider:    ProviderOpenAI,
-		ModelID:     "gpt-5.1",
-		DisplayName: "GPT 5.1",
+		Provider:      ProviderOpenAI,
+		ModelID:       "gpt-5.1",
+		DisplayName:   "GPT 5.1",
+		ContextWindow: 400000,
 	},
 	"gpt-5.2": {
-		Provider:    ProviderOpenAI,
-		ModelID:     "gpt-5.2",
-		DisplayName: "GPT 5.2",
+		Provider:      ProviderOpenAI,
+		ModelID:       "gpt-5.2",
+		DisplayName:   "GPT 5.2",
+		ContextWindow: 400000,
 	},
 	"gpt-4.1": {
-		Provider:    ProviderOpenAI,
-		ModelID:     "gpt-4.1",
-		DisplayName: "GPT 4.1",
+		Provider:      ProviderOpenAI,
+		ModelID:       "gpt-4.1",
+		DisplayName:   "GPT 4.1",
+		ContextWindow: 1047576,
 	},
 
 	// Gemini models
 	"gemini-2.5-flash": {
-		Provider:    ProviderGemini,
-		ModelID:     "gemini-2.5-flash",
-		DisplayName: "Gemini 2.5 Flash",
+		Provider:      ProviderGemini,
+		ModelID:       "gemini-2.5-flash",
+		DisplayName:   "Gemini 2.5 Flash",
+		ContextWindow: 1048576,
 	},
 	"gemini-2.5-pro": {
-		Provider:    ProviderGemini,
-		ModelID:     "gemini-2.5-pro",
-		DisplayName: "Gemini 2.5 Pro",
+		Provider:      ProviderGemini,
+		ModelID:       "gemini-2.5-pro",
+		DisplayName:   "Gemini 2.5 Pro",
+		ContextWindow: 1048576,
+	},
+
+	// DeepSeek models (direct API)
+	"deepseek-chat": {
+		Provider:      ProviderDeepSeek,
+		ModelID:       "deepseek-chat",
+		DisplayName:   "DeepSeek V3",
+		ContextWindow: 128000,
+		TextOnly:      true,
+	},
+	"deepseek-reasoner": {
+		Provider:      ProviderDeepSeek,
+		ModelID:       "deepseek-reasoner",
+		DisplayName:   "DeepSeek Reasoner",
+		ContextWindow: 128000,
+		TextOnly:      true,
+	},
+
+	// Mistral models (direct API)
+	"mistral-large-latest": {
+		Provider:      ProviderMistral,
+		ModelID:       "mistral-large-latest",
+		DisplayName:   "Mistral Large",
+		ContextWindow: 128000,
+		TextOnly:      true,
+	},
+	"mistral-medium-latest": {
+		Provider:      ProviderMistral,
+		ModelID:       "mistral-medium-latest",
+		DisplayName:   "Mistral Medium",
+		ContextWindow: 128000,
 	},
 
 	// OpenRouter models
 	"moonshotai/kimi-k2.5": {
-		Provider:    ProviderOpenRouter,
-		ModelID:     "moonshotai/kimi-k2.5",
-		DisplayName: "Kimi K2.5",
+		Provider:      ProviderOpenRouter,
+		ModelID:       "moonshotai/kimi-k2.5",
+		DisplayName:   "Kimi K2.5",
+		ContextWindow: 262144,
 	},
 	"moonshotai/kimi-k2-thinking": {
-		Provider:    ProviderOpenRouter,
-		ModelID:     "moonshotai/kimi-k2-thinking",
-		DisplayName: "Kimi K2 Thinking",
+		Provider:      ProviderOpenRouter,
+		ModelID:       "moonshotai/kimi-k2-thinking",
+		DisplayName:   "Kimi K2 Thinking",
+		ContextWindow: 262144,
+		TextOnly:      true,
 	},
 	"deepseek/deepseek-r1": {
-		Provider:    ProviderOpenRouter,
-		ModelID:     "deepseek/deepseek-r1",
-		DisplayName: "DeepSeek R1",
+		Provider:      ProviderOpenRouter,
+		ModelID:       "deepseek/deepseek-r1",
+		DisplayName:   "DeepSeek R1",
+		ContextWindow: 163840,
+		TextOnly:      true,
 	},
 	"deepseek/deepseek-r1-0528": {
-		Provider:    ProviderOpenRouter,
-		ModelID:     "deepseek/deepseek-r1-0528",
-		DisplayName: "DeepSeek R1 (0528)",
+		Provider:      ProviderOpenRouter,
+		ModelID:       "deepseek/deepseek-r1-0528",
+		DisplayName:   "DeepSeek R1 (0528)",
+		ContextWindow: 163840,
+		TextOnly:      true,
 	},
 	"anthropic/claude-3.5-sonnet": {
-		Provider:    ProviderOpenRouter,
-		ModelID:     "anthropic/claude-3.5-sonnet",
-		DisplayName: "Claude 3.5 Sonnet (OpenRouter)",
+		Provider:      ProviderOpenRouter,
+		ModelID:       "anthropic/claude-3.5-sonnet",
+		DisplayName:   "Claude 3.5 Sonnet (OpenRouter)",
+		ContextWindow: 200000,
 	},
 }
 
+// imageUnavailableNote stands in for an image sent to a text-only model
+const imageUnavailableNote = "[An image was attached here, but this model cannot view images]"
+
+// AcceptsImages reports whether a provider's model takes image input
+// Models outside the registry are assumed to, except local Ollama models without a vision model name
+func AcceptsImages(provider Provider, modelID string) bool {
+	for _, info := range ModelRegistry {
+		if info.Provider == provider && info.ModelID == modelID {
+			return !info.TextOnly
+		}
+	}
+	if provider == ProviderOllama {
+		return ollamaVisionModel(modelID)
+	}
+	return true
+}
+
 // ValidateModel checks if a model name is valid and returns its info
 func ValidateModel(modelName string) (*ModelInfo, error) {
 	info, exists := ModelRegistry[modelName]
 	if !exists {
+		if local, ok := ollamaModelInfo(modelName); ok {
+			return local, nil
+		}
+		if fake, ok := fakeModelInfo(modelName); ok {
+			return fake, nil
+		}
+		if mock, ok := mockModelInfo(modelName); ok {
+			return mock, nil
+		}
 		return nil, fmt.Errorf("unknown model: %s", modelName)
 	}
 	return &info, nil
@@ -108,6 +223,15 @@ func GetAllowedModels() []string {
 	for name := range ModelRegistry {
 		models = append(models, name)
 	}
+	for _, model := range ollamaModels() {
+		models = append(models, OllamaModelPrefix+model)
+	}
+	if fakeModelEnabled() {
+		models = append(models, FakeModelName)
+	}
+	if mockModelEnabled() {
+		models = append(models, MockModelName)
+	}
 	return models
 }
 
@@ -119,5 +243,16 @@ func GetModelsByProvider(provider Provider) []string {
 			models = append(models, name)
 		}
 	}
+	if provider == ProviderOllama {
+		for _, model := range ollamaModels() {
+			models = append(models, OllamaModelPrefix+model)
+		}
+	}
+	if provider == ProviderFake && fakeModelEnabled() {
+		models = append(models, FakeModelName)
+	}
+	if provider == ProviderMock && mockModelEnabled() {
+		models = append(models, MockModelName)
+	}
 	return models
 }
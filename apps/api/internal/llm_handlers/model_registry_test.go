@@ -0,0 +1,24 @@
+package llmHandlers
+
+import "testing"
+
+func TestAcceptsImages(t *testing.T) {
+	tests := []struct {
+		provider Provider
+		model    string
+		want     bool
+	}{
+		{ProviderVertexAnthropic, "claude-sonnet-4-5@20250929", true},
+		{ProviderLangChainGroq, "llama-3.3-70b-versatile", false},
+		{ProviderDeepSeek, "deepseek-chat", false},
+		{ProviderOpenRouter, "deepseek/deepseek-r1", false},
+		{ProviderOpenRouter, "some/unlisted-model", true},
+		{ProviderOllama, "llama3.1", false},
+		{ProviderOllama, "llama3.2-vision", true},
+	}
+	for _, tt := range tests {
+		if got := AcceptsImages(tt.provider, tt.model); got != tt.want {
+			t.Errorf("AcceptsImages(%s, %q) = %v", tt.provider, tt.model, got)
+		}
+	}
+}
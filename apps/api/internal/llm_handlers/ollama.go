@@ -0,0 +1,143 @@
+package llmHandlers
+
+import (
+	"context"
+	"fmt"
+	"melina-studio-backend/internal/config"
+	"melina-studio-backend/internal/libraries"
+	"strings"
+	"sync"
+)
+
+// OllamaModelPrefix marks registry names served by the local Ollama server, e.g. "ollama/llama3.1"
+const OllamaModelPrefix = "ollama/"
+
+// ollamaNoToolModels remembers models Ollama rejected tools for, so later calls skip the failing round trip
+var ollamaNoToolModels sync.Map
+
+// OllamaClient talks to a self-hosted Ollama server through its OpenAI-compatible API
+// It reuses the LangChain client for the tool loop and streaming; models that don't support tools
+// fall back to plain chat. Thinking is not requested since Ollama ignores the OpenAI reasoning options
+type OllamaClient struct {
+	*LangChainClient
+}
+
+func NewOllamaClient(model string, tools []map[string]interface{}, temperature *float32, maxTokens *int) (*OllamaClient, error) {
+	baseURL := strings.TrimSuffix(config.GetSettings().LLM.OllamaBaseURL, "/")
+	if baseURL == "" {
+		return nil, fmt.Errorf("OLLAMA_BASE_URL must be set")
+	}
+	if _, ok := ollamaNoToolModels.Load(model); ok {
+		tools = nil
+	}
+
+	client, err := NewLangChainClient(LangChainConfig{
+		Model:   model,
+		BaseURL: baseURL + "/v1",
+		// Ollama ignores the key, but the OpenAI client refuses to start without one
+		APIKey:      "ollama",
+		Tools:       tools,
+		Temperature: temperature,
+		MaxTokens:   maxTokens,
+		TextOnly:    !ollamaVisionModel(model),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &OllamaClient{LangChainClient: client}, nil
+}
+
+func (c *OllamaClient) Chat(ctx context.Context, systemMessage string, messages []Message, enableThinking bool) (string, error) {
+	var text string
+	err := c.withToolFallback(func() error {
+		var err error
+		text, err = c.LangChainClient.Chat(ctx, systemMessage, messages, false)
+		return err
+	})
+	return text, err
+}
+
+func (c *OllamaClient) ChatStream(ctx context.Context, hub *libraries.Hub, client *libraries.Client, boardId string, systemMessage string, messages []Message, enableThinking bool) (string, error) {
+	var text string
+	err := c.withToolFallback(func() error {
+		var err error
+		text, err = c.LangChainClient.ChatStream(ctx, hub, client, boardId, systemMessage, messages, false)
+		return err
+	})
+	return text, err
+}
+
+func (c *OllamaClient) ChatStreamWithUsage(req ChatStreamRequest) (*ResponseWithUsage, error) {
+	req.EnableThinking = false
+	var resp *ResponseWithUsage
+	err := c.withToolFallback(func() error {
+		var err error
+		resp, err = c.LangChainClient.ChatStreamWithUsage(req)
+		return err
+	})
+	return resp, err
+}
+
+// withToolFallback retries the call without tools when the model turns out not to support them
+func (c *OllamaClient) withToolFallback(call func() error) error {
+	err := call()
+	if err == nil || len(c.Tools) == 0 || !isOllamaToolsUnsupported(err) {
+		return err
+	}
+
+	fmt.Printf("[ollama] Model %s does not support tools, retrying without them\n", c.Model)
+	ollamaNoToolModels.Store(c.Model, true)
+	c.Tools = nil
+	return call()
+}
+
+func isOllamaToolsUnsupported(err error) bool {
+	return strings.Contains(strings.ToLower(err.Error()), "does not support tools")
+}
+
+// ollamaVisionModel guesses from the model name whether a local model takes images (llava, llama3.2-vision, qwen2.5vl...)
+func ollamaVisionModel(model string) bool {
+	name := strings.ToLower(model)
+	for _, marker := range []string{"llava", "vision", "vl", "moondream", "gemma3"} {
+		if strings.Contains(name, marker) {
+			return true
+		}
+	}
+	return false
+}
+
+// ollamaContextTokens is the context length local models are served with
+func ollamaContextTokens() int {
+	return config.GetSettings().LLM.OllamaContextTokens
+}
+
+// ollamaModelInfo returns the registry entry for an "ollama/<model>" name listed in OLLAMA_MODELS
+func ollamaModelInfo(modelName string) (*ModelInfo, bool) {
+	model, ok := strings.CutPrefix(modelName, OllamaModelPrefix)
+	if !ok || model == "" {
+		return nil, false
+	}
+	for _, configured := range ollamaModels() {
+		if configured == model {
+			return &ModelInfo{
+				Provider:      ProviderOllama,
+				ModelID:       model,
+				DisplayName:   model + " (local)",
+				TextOnly:      !ollamaVisionModel(model),
+				ContextWindow: ollamaContextTokens(),
+			}, true
+		}
+	}
+	return nil, false
+}
+
+// ollamaModels returns the local models enabled with OLLAMA_MODELS
+func ollamaModels() []string {
+	var models []string
+	for _, model := range strings.Split(config.GetSettings().LLM.OllamaModels, ",") {
+		if model = strings.TrimSpace(model); model != "" {
+			models = append(models, model)
+		}
+	}
+	return models
+}
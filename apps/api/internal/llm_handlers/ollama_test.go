@@ -0,0 +1,88 @@
+package llmHandlers
+
+import (
+	"errors"
+	"slices"
+	"testing"
+
+	"melina-studio-backend/internal/config"
+)
+
+// withLLMSettings changes the LLM settings for one test
+func withLLMSettings(t *testing.T, change func(*config.LLMSettings)) {
+	t.Helper()
+	settings := config.GetSettings()
+	saved := settings.LLM
+	change(&settings.LLM)
+	t.Cleanup(func() { settings.LLM = saved })
+}
+
+func TestOllamaModelsComeFromSettings(t *testing.T) {
+	withLLMSettings(t, func(s *config.LLMSettings) {
+		s.OllamaModels = " llama3.1, ,llava:13b "
+		s.OllamaContextTokens = 8192
+	})
+
+	info, err := ValidateModel("ollama/llava:13b")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Provider != ProviderOllama || info.ModelID != "llava:13b" || info.TextOnly || info.ContextWindow != 8192 {
+		t.Errorf("unexpected model info %+v", info)
+	}
+	if info, _ := ValidateModel("ollama/llama3.1"); info == nil || !info.TextOnly {
+		t.Errorf("expected llama3.1 to be text only, got %+v", info)
+	}
+	for _, name := range []string{"ollama/mistral", "ollama/", "llama3.1"} {
+		if _, err := ValidateModel(name); err == nil {
+			t.Errorf("expected %q to be refused", name)
+		}
+	}
+
+	if got := GetModelsByProvider(ProviderOllama); !slices.Equal(got, []string{"ollama/llama3.1", "ollama/llava:13b"}) {
+		t.Errorf("GetModelsByProvider(ollama) = %v", got)
+	}
+	if allowed := GetAllowedModels(); !slices.Contains(allowed, "ollama/llama3.1") || len(allowed) != len(ModelRegistry)+2 {
+		t.Errorf("expected the local models to be allowed, got %v", allowed)
+	}
+}
+
+func TestDefaultModel(t *testing.T) {
+	withLLMSettings(t, func(s *config.LLMSettings) { s.DefaultModel = "" })
+	if got := DefaultModel(); got != DefaultModelName {
+		t.Errorf("DefaultModel() = %q, want %q", got, DefaultModelName)
+	}
+	withLLMSettings(t, func(s *config.LLMSettings) { s.DefaultModel = "ollama/llama3.1" })
+	if got := DefaultModel(); got != "ollama/llama3.1" {
+		t.Errorf("DefaultModel() = %q", got)
+	}
+}
+
+func TestOllamaToolFallback(t *testing.T) {
+	model := "tool-less-" + t.Name()
+	t.Cleanup(func() { ollamaNoToolModels.Delete(model) })
+	c := &OllamaClient{LangChainClient: &LangChainClient{Model: model, Tools: []map[string]interface{}{{"name": "addShape"}}}}
+
+	var calls []int
+	err := c.withToolFallback(func() error {
+		calls = append(calls, len(c.Tools))
+		if len(c.Tools) > 0 {
+			return errors.New(`registry.ollama.ai/library/gemma: model "gemma" Does Not Support Tools`)
+		}
+		return nil
+	})
+	if err != nil || !slices.Equal(calls, []int{1, 0}) {
+		t.Fatalf("expected a retry without tools, got calls %v err %v", calls, err)
+	}
+	if _, ok := ollamaNoToolModels.Load(model); !ok {
+		t.Error("expected the model to be remembered as tool-less")
+	}
+
+	// other errors are returned as they are
+	c.Tools = []map[string]interface{}{{"name": "addShape"}}
+	calls = nil
+	failure := errors.New("connection refused")
+	if err := c.withToolFallback(func() error { calls = append(calls, len(c.Tools)); return failure }); err != failure || len(calls) != 1 {
+		t.Errorf("expected the error without a retry, got %v after %d calls", err, len(calls))
+	}
+}
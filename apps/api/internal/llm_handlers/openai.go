@@ -2,10 +2,11 @@ package llmHandlers
 
 import (
 	"context"
+	"encoding/json"
 	"fmt"
+	"melina-studio-backend/internal/config"
 	"melina-studio-backend/internal/constants"
 	"melina-studio-backend/internal/libraries"
-	"os"
 	"strings"
 	"time"
 
@@ -30,10 +31,12 @@ type OpenAIResponse struct {
 	ReasoningContent string
 	ToolCalls        []ToolCall
 	RawResponse      interface{}
+	// Usage is summed over every call of a tool loop; nil when the API reported none
+	Usage *responses.ResponseUsage
 }
 
 func NewOpenAIClient(model string, tools []map[string]interface{}, temperature *float32, maxTokens *int) (*OpenAIClient, error) {
-	apiKey := os.Getenv("OPENAI_API_KEY")
+	apiKey := config.GetSettings().LLM.OpenAIAPIKey
 
 	if apiKey == "" {
 		return nil, fmt.Errorf("OPENAI_API_KEY must be set")
@@ -64,11 +67,19 @@ func convertToolsToOpenAITools(tools []map[string]interface{}) []responses.ToolU
 				description, _ := fn["description"].(string)
 				parameters, _ := fn["parameters"].(map[string]interface{})
 
+				// strict mode makes OpenAI reject arguments that don't match the schema
+				strict := false
+				if strictParameters, ok := StrictSchema(parameters); ok {
+					parameters = strictParameters
+					strict = true
+				}
+
 				openAITools = append(openAITools, responses.ToolUnionParam{
 					OfFunction: &responses.FunctionToolParam{
 						Name:        name,
 						Description: openai.String(description),
 						Parameters:  parameters,
+						Strict:      openai.Bool(strict),
 					},
 				})
 			}
@@ -77,6 +88,27 @@ func convertToolsToOpenAITools(tools []map[string]interface{}) []responses.ToolU
 	return openAITools
 }
 
+// parseOpenAIArguments decodes a function call's JSON arguments into the tool input
+func parseOpenAIArguments(arguments string) map[string]interface{} {
+	input := map[string]interface{}{}
+	if err := json.Unmarshal([]byte(arguments), &input); err != nil {
+		fmt.Printf("[openai] could not parse function call arguments: %v\n", err)
+	}
+	return input
+}
+
+// jsonString returns strings as they are and encodes anything else as JSON
+func jsonString(v interface{}) string {
+	if s, ok := v.(string); ok {
+		return s
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(b)
+}
+
 // callOpenAIWithMessages calls OpenAI Responses API and returns parsed response
 func (c *OpenAIClient) callOpenAIWithMessages(ctx context.Context, systemMessage string, messages []Message, streamCtx *StreamingContext, enableThinking bool) (*OpenAIResponse, error) {
 	// Build input items for Responses API
@@ -131,13 +163,22 @@ func (c *OpenAIClient) callOpenAIWithMessages(ctx context.Context, systemMessage
 							msgRole,
 						))
 					}
+				case "function_call":
+					if fn, ok := block["function"].(map[string]interface{}); ok {
+						callID, _ := fn["call_id"].(string)
+						name, _ := fn["name"].(string)
+						inputItems = append(inputItems, responses.ResponseInputItemParamOfFunctionCall(
+							jsonString(fn["arguments"]),
+							callID,
+							name,
+						))
+					}
 				case "function_response":
 					if fn, ok := block["function"].(map[string]interface{}); ok {
 						callID, _ := fn["call_id"].(string)
-						responseStr, _ := fn["response"].(string)
 						inputItems = append(inputItems, responses.ResponseInputItemParamOfFunctionCallOutput(
 							callID,
-							responseStr,
+							jsonString(fn["response"]),
 						))
 					}
 				}
@@ -153,6 +194,11 @@ func (c *OpenAIClient) callOpenAIWithMessages(ctx context.Context, systemMessage
 		},
 	}
 
+	// privacy mode keeps OpenAI from retaining the response for later retrieval
+	if privacyModeFrom(ctx) {
+		params.Store = openai.Bool(false)
+	}
+
 	// Add tools if available
 	openAITools := convertToolsToOpenAITools(c.Tools)
 	if len(openAITools) > 0 {
@@ -188,7 +234,7 @@ func (c *OpenAIClient) callOpenAIWithMessages(ctx context.Context, systemMessage
 	if streamCtx != nil && streamCtx.Client != nil {
 		var accumulatedText strings.Builder
 		var accumulatedThinking strings.Builder
-		var thinkingStarted, thinkingCompleted bool
+		dispatch := newStreamDispatcher(streamCtx, false)
 
 		// Create streaming request
 		stream := c.client.Responses.NewStreaming(ctx, params)
@@ -198,52 +244,18 @@ func (c *OpenAIClient) callOpenAIWithMessages(ctx context.Context, systemMessage
 
 			switch event.Type {
 			case "response.reasoning_summary_text.delta":
-				// Thinking/reasoning content delta
-				if !thinkingStarted {
-					thinkingStarted = true
-					libraries.SendEventType(streamCtx.Hub, streamCtx.Client, libraries.WebSocketMessageTypeThinkingStart)
-				}
-
-				// Get text from the Delta.OfString field
-				textDelta := event.Delta.OfString
-				if textDelta != "" {
-					accumulatedThinking.WriteString(textDelta)
-
-					payload := &libraries.ChatMessageResponsePayload{
-						Message: textDelta,
-					}
-					if streamCtx.BoardId != "" {
-						payload.BoardId = streamCtx.BoardId
-					}
-					libraries.SendChatMessageResponse(streamCtx.Hub, streamCtx.Client, libraries.WebSocketMessageTypeThinkingResponse, payload)
-				}
-
-			case "response.reasoning_summary_text.done", "response.reasoning_summary_part.done":
-				// Thinking completed
-				if thinkingStarted && !thinkingCompleted {
-					thinkingCompleted = true
-					libraries.SendEventType(streamCtx.Hub, streamCtx.Client, libraries.WebSocketMessageTypeThinkingCompleted)
-				}
+				// Thinking/reasoning content delta, from the Delta.OfString field
+				accumulatedThinking.WriteString(event.Delta.OfString)
+				dispatch.Emit(ThinkingDelta(event.Delta.OfString))
 
 			case "response.output_text.delta":
 				// Regular text content delta
-				if thinkingStarted && !thinkingCompleted {
-					thinkingCompleted = true
-					libraries.SendEventType(streamCtx.Hub, streamCtx.Client, libraries.WebSocketMessageTypeThinkingCompleted)
-				}
-
-				// Get text from the Delta.OfString field
-				textDelta := event.Delta.OfString
-				if textDelta != "" {
-					accumulatedText.WriteString(textDelta)
+				accumulatedText.WriteString(event.Delta.OfString)
+				dispatch.Emit(TextDelta(event.Delta.OfString))
 
-					payload := &libraries.ChatMessageResponsePayload{
-						Message: textDelta,
-					}
-					if streamCtx.BoardId != "" {
-						payload.BoardId = streamCtx.BoardId
-					}
-					libraries.SendChatMessageResponse(streamCtx.Hub, streamCtx.Client, libraries.WebSocketMessageTypeChatResponse, payload)
+			case "response.output_item.added":
+				if event.Item.Type == "function_call" {
+					dispatch.Emit(ToolStarted(event.Item.CallID, event.Item.Name))
 				}
 
 			case "response.function_call_arguments.done":
@@ -252,7 +264,7 @@ func (c *OpenAIClient) callOpenAIWithMessages(ctx context.Context, systemMessage
 					or.ToolCalls = append(or.ToolCalls, ToolCall{
 						ID:       event.Item.CallID,
 						Name:     event.Item.Name,
-						Input:    map[string]interface{}{"arguments": event.Item.Arguments},
+						Input:    parseOpenAIArguments(event.Item.Arguments),
 						Provider: "openai",
 					})
 				}
@@ -260,17 +272,23 @@ func (c *OpenAIClient) callOpenAIWithMessages(ctx context.Context, systemMessage
 			case "response.completed":
 				// Response completed
 				or.RawResponse = event.Response
+				if event.Response.JSON.Usage.Valid() {
+					usage := event.Response.Usage
+					or.Usage = &usage
+					dispatch.Emit(UsageReported(&TokenUsage{
+						InputTokens:    int(usage.InputTokens),
+						OutputTokens:   int(usage.OutputTokens),
+						TotalTokens:    int(usage.TotalTokens),
+						CountingMethod: "provider_api",
+					}))
+				}
 			}
 		}
 
 		if err := stream.Err(); err != nil {
 			return nil, fmt.Errorf("openai stream error: %w", err)
 		}
-
-		// Handle edge case
-		if thinkingStarted && !thinkingCompleted {
-			libraries.SendEventType(streamCtx.Hub, streamCtx.Client, libraries.WebSocketMessageTypeThinkingCompleted)
-		}
+		dispatch.Emit(StreamDone())
 
 		or.TextContent = []string{accumulatedText.String()}
 		or.ReasoningContent = accumulatedThinking.String()
@@ -283,6 +301,10 @@ func (c *OpenAIClient) callOpenAIWithMessages(ctx context.Context, systemMessage
 		}
 
 		or.RawResponse = resp
+		if resp.JSON.Usage.Valid() {
+			usage := resp.Usage
+			or.Usage = &usage
+		}
 
 		// Extract content from response
 		for _, item := range resp.Output {
@@ -296,7 +318,7 @@ func (c *OpenAIClient) callOpenAIWithMessages(ctx context.Context, systemMessage
 				or.ToolCalls = append(or.ToolCalls, ToolCall{
 					ID:       item.CallID,
 					Name:     item.Name,
-					Input:    map[string]interface{}{"arguments": item.Arguments},
+					Input:    parseOpenAIArguments(item.Arguments),
 					Provider: "openai",
 				})
 			} else if item.Type == "reasoning" {
@@ -310,20 +332,43 @@ func (c *OpenAIClient) callOpenAIWithMessages(ctx context.Context, systemMessage
 	return or, nil
 }
 
+// addOpenAIUsage returns the sum of two usages, either of which may be nil
+func addOpenAIUsage(total, usage *responses.ResponseUsage) *responses.ResponseUsage {
+	if usage == nil {
+		return total
+	}
+	sum := responses.ResponseUsage{}
+	if total != nil {
+		sum = *total
+	}
+	sum.InputTokens += usage.InputTokens
+	sum.InputTokensDetails.CachedTokens += usage.InputTokensDetails.CachedTokens
+	sum.OutputTokens += usage.OutputTokens
+	sum.OutputTokensDetails.ReasoningTokens += usage.OutputTokensDetails.ReasoningTokens
+	sum.TotalTokens += usage.TotalTokens
+	return &sum
+}
+
 // ChatWithTools handles tool execution loop
 func (c *OpenAIClient) ChatWithTools(ctx context.Context, systemMessage string, messages []Message, streamCtx *StreamingContext, enableThinking bool) (*OpenAIResponse, error) {
 	maxIterations := constants.GetMaxIterations(ctx)
+	ctx = WithToolInputRetries(ctx)
 
 	workingMessages := make([]Message, 0, len(messages)+6)
 	workingMessages = append(workingMessages, messages...)
+	images := newToolLoopImages(len(workingMessages))
 
 	var lastResp *OpenAIResponse
+	// Accumulate token usage across all iterations
+	var totalUsage *responses.ResponseUsage
 
 	for iter := 0; iter < maxIterations; iter++ {
 		or, err := c.callOpenAIWithMessages(ctx, systemMessage, workingMessages, streamCtx, enableThinking)
 		if err != nil {
 			return nil, fmt.Errorf("callOpenAIWithMessages: %w", err)
 		}
+		totalUsage = addOpenAIUsage(totalUsage, or.Usage)
+		or.Usage = totalUsage
 		lastResp = or
 
 		// If no tool calls, we're done
@@ -337,16 +382,22 @@ func (c *OpenAIClient) ChatWithTools(ctx context.Context, systemMessage string,
 		// Format results for OpenAI
 		functionResults := []map[string]interface{}{}
 		for _, execResult := range execResults {
+			response := execResult.Result
+			if execResult.Error != nil {
+				response = toolErrorResponse(execResult.Error)
+			}
 			functionResults = append(functionResults, map[string]interface{}{
 				"type": "function_response",
 				"function": map[string]interface{}{
 					"call_id":  execResult.ToolCallID,
 					"name":     execResult.ToolName,
-					"response": execResult.Result,
+					"response": response,
 				},
 			})
 		}
 
+		images.compact(workingMessages)
+
 		// Append assistant message with function calls
 		assistantParts := []map[string]interface{}{}
 		for _, text := range or.TextContent {
@@ -363,7 +414,7 @@ func (c *OpenAIClient) ChatWithTools(ctx context.Context, systemMessage string,
 				"function": map[string]interface{}{
 					"call_id":   tc.ID,
 					"name":      tc.Name,
-					"arguments": tc.Input["arguments"],
+					"arguments": tc.Input,
 				},
 			})
 		}
@@ -400,6 +451,10 @@ func (c *OpenAIClient) ChatWithTools(ctx context.Context, systemMessage string,
 		fmt.Printf("[openai] Warning: final summary call failed: %v. Returning last response.\n", err)
 		return lastResp, nil
 	}
+	finalResp.Usage = addOpenAIUsage(totalUsage, finalResp.Usage)
+	if lastResp != nil {
+		lastResp.Usage = finalResp.Usage
+	}
 
 	if len(finalResp.TextContent) == 0 || (len(finalResp.TextContent) == 1 && strings.TrimSpace(finalResp.TextContent[0]) == "") {
 		if lastResp != nil && len(lastResp.TextContent) > 0 {
@@ -489,12 +544,17 @@ func (c *OpenAIClient) ChatStreamWithUsage(req ChatStreamRequest) (*ResponseWith
 		return nil, fmt.Errorf("openai returned no text content")
 	}
 
-	// Extract token usage - OpenAI includes it in the response
-	tokenUsage := &TokenUsage{
-		InputTokens:  0,
-		OutputTokens: 0,
+	// Get input text for fallback token counting
+	inputText := systemMessage
+	for _, m := range messages {
+		if text, ok := m.Content.(string); ok {
+			inputText += "\n" + text
+		}
 	}
 
+	// Extract token usage from response
+	tokenUsage := ExtractOpenAIUsage(resp, inputText)
+
 	return &ResponseWithUsage{
 		Text:       strings.Join(resp.TextContent, "\n\n"),
 		Thinking:   resp.ReasoningContent,
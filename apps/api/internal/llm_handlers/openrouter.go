@@ -5,10 +5,10 @@ import (
 	"encoding/json"
 	"fmt"
 	"io"
+	"melina-studio-backend/internal/config"
 	"melina-studio-backend/internal/constants"
 	"melina-studio-backend/internal/libraries"
 	"melina-studio-backend/internal/models"
-	"os"
 	"strings"
 	"time"
 
@@ -18,6 +18,10 @@ import (
 type OpenRouterClient struct {
 	client  *openrouter.Client
 	modelID string
+	// provider is ProviderOpenRouter, or a provider whose native API is OpenAI-compatible (DeepSeek, Mistral)
+	provider Provider
+	// imageInput is false for text-only models, which get a placeholder instead of image parts
+	imageInput bool
 
 	Temperature float32
 	MaxTokens   int
@@ -49,11 +53,17 @@ type OpenRouterFunctionCall struct {
 }
 
 func NewOpenRouterClient(modelID string, temperature *float32, maxTokens *int, tools []map[string]interface{}) (*OpenRouterClient, error) {
-	apiKey := os.Getenv("OPENROUTER_API_KEY")
+	apiKey := config.GetSettings().LLM.OpenRouterAPIKey
 	if apiKey == "" {
 		return nil, fmt.Errorf("OPENROUTER_API_KEY is not set")
 	}
-	client := openrouter.NewClient(apiKey)
+	return newOpenAICompatibleClient(ProviderOpenRouter, openrouter.DefaultConfig(apiKey), modelID, temperature, maxTokens, tools), nil
+}
+
+// newOpenAICompatibleClient builds the client for any provider speaking the OpenAI chat completions API
+// The tool loop and reasoning parsing are shared; provider only switches the OpenRouter-specific request fields
+func newOpenAICompatibleClient(provider Provider, cfg *openrouter.ClientConfig, modelID string, temperature *float32, maxTokens *int, tools []map[string]interface{}) *OpenRouterClient {
+	client := openrouter.NewClientWithConfig(*cfg)
 
 	// Set defaults if not provided
 	tempValue := float32(0.2)
@@ -69,10 +79,12 @@ func NewOpenRouterClient(modelID string, temperature *float32, maxTokens *int, t
 	return &OpenRouterClient{
 		client:      client,
 		modelID:     modelID,
+		provider:    provider,
+		imageInput:  AcceptsImages(provider, modelID),
 		Temperature: tempValue,
 		MaxTokens:   maxTokensValue,
 		Tools:       tools,
-	}, nil
+	}
 }
 
 // convertToolsToOpenRouterTools converts tool definitions to OpenRouter format
@@ -128,8 +140,9 @@ func (c *OpenRouterClient) convertMessagesToOpenRouterMessages(messages []Messag
 			}
 
 		case []map[string]interface{}:
-			// Multi-part content - extract text parts
+			// Multi-part content - text parts, plus image parts for models that take images
 			var textParts []string
+			var imageParts []openrouter.ChatMessagePart
 			for _, block := range content {
 				blockType, _ := block["type"].(string)
 				switch blockType {
@@ -138,16 +151,34 @@ func (c *OpenRouterClient) convertMessagesToOpenRouterMessages(messages []Messag
 						textParts = append(textParts, text)
 					}
 				case "image":
-					// OpenRouter supports image URLs via content array
-					if source, ok := block["source"].(map[string]interface{}); ok {
-						mediaType, _ := source["media_type"].(string)
-						dataStr, _ := source["data"].(string)
-						dataURI := fmt.Sprintf("data:%s;base64,%s", mediaType, dataStr)
-						// For now, add as text description - full image support would use ChatMessagePart
-						textParts = append(textParts, fmt.Sprintf("[Image: %s]", dataURI[:min(50, len(dataURI))]+"..."))
+					source, ok := block["source"].(map[string]interface{})
+					if !ok {
+						continue
+					}
+					if !c.imageInput {
+						// selections already reach text-only models as a description; this covers tool result images
+						textParts = append(textParts, imageUnavailableNote)
+						continue
 					}
+					mediaType, _ := source["media_type"].(string)
+					dataStr, _ := source["data"].(string)
+					imageParts = append(imageParts, openrouter.ChatMessagePart{
+						Type:     openrouter.ChatMessagePartTypeImageURL,
+						ImageURL: &openrouter.ChatMessageImageURL{URL: fmt.Sprintf("data:%s;base64,%s", mediaType, dataStr)},
+					})
 				}
 			}
+			if len(imageParts) > 0 && m.Role != "assistant" {
+				parts := make([]openrouter.ChatMessagePart, 0, len(textParts)+len(imageParts))
+				for _, text := range textParts {
+					parts = append(parts, openrouter.ChatMessagePart{Type: openrouter.ChatMessagePartTypeText, Text: text})
+				}
+				msgs = append(msgs, openrouter.ChatCompletionMessage{
+					Role:    openrouter.ChatMessageRoleUser,
+					Content: openrouter.Content{Multi: append(parts, imageParts...)},
+				})
+				continue
+			}
 			if len(textParts) > 0 {
 				combinedText := strings.Join(textParts, "\n")
 				switch m.Role {
@@ -184,8 +215,18 @@ func (c *OpenRouterClient) callOpenRouterWithMessages(ctx context.Context, syste
 		Temperature: c.Temperature,
 		MaxTokens:   c.MaxTokens,
 	}
+	if seed, ok := seedFrom(ctx); ok && c.provider == ProviderOpenRouter {
+		reqSeed := int(seed)
+		req.Seed = &reqSeed
+	}
+	// privacy mode only routes to providers that don't store or train on prompts
+	if privacyModeFrom(ctx) && c.provider == ProviderOpenRouter {
+		req.Provider = &openrouter.ChatProvider{DataCollection: openrouter.DataCollectionDeny}
+	}
 
-	if enableThinking {
+	// The reasoning object is OpenRouter-only; DeepSeek reasons based on the model picked
+	// (deepseek-reasoner) and Mistral rejects unknown request fields
+	if enableThinking && c.provider == ProviderOpenRouter {
 		// NOTE: The SDK has a bug where Effort is serialized as "prompt" instead of "effort"
 		// Workaround: Use MaxTokens instead which is correctly mapped
 		// Setting max_tokens for reasoning allocates budget for thinking
@@ -232,6 +273,10 @@ func (c *OpenRouterClient) callOpenRouterWithMessages(ctx context.Context, syste
 // enableThinking controls whether to parse <think> tags from content
 func (c *OpenRouterClient) callWithStreaming(ctx context.Context, req openrouter.ChatCompletionRequest, streamCtx *StreamingContext, enableThinking bool) (*OpenRouterResponse, error) {
 	req.Stream = true
+	if c.provider == ProviderDeepSeek {
+		// DeepSeek only reports usage on streams when asked to
+		req.StreamOptions = &openrouter.StreamOptions{IncludeUsage: true}
+	}
 
 	stream, err := c.client.CreateChatCompletionStream(ctx, req)
 	if err != nil {
@@ -241,7 +286,7 @@ func (c *OpenRouterClient) callWithStreaming(ctx context.Context, req openrouter
 
 	var fullContent strings.Builder
 	var accumulatedThinking strings.Builder
-	var thinkingStarted, thinkingCompleted bool
+	dispatch := newStreamDispatcher(streamCtx, !streamCtx.ShouldStream)
 	var insideThinkTag bool            // Track if we're currently inside <think> tags
 	var pendingContent strings.Builder // Buffer content to check for tags
 	var toolCalls []OpenRouterFunctionCall
@@ -268,6 +313,12 @@ func (c *OpenRouterClient) callWithStreaming(ctx context.Context, req openrouter
 				CompletionTokens: chunk.Usage.CompletionTokens,
 				TotalTokens:      chunk.Usage.TotalTokens,
 			}
+			dispatch.Emit(UsageReported(&TokenUsage{
+				InputTokens:    chunk.Usage.PromptTokens,
+				OutputTokens:   chunk.Usage.CompletionTokens,
+				TotalTokens:    chunk.Usage.TotalTokens,
+				CountingMethod: "provider_api",
+			}))
 		}
 
 		if len(chunk.Choices) == 0 {
@@ -297,34 +348,12 @@ func (c *OpenRouterClient) callWithStreaming(ctx context.Context, req openrouter
 				}
 			}
 
-			if reasoningText != "" {
-				if !thinkingStarted {
-					thinkingStarted = true
-					libraries.SendEventType(streamCtx.Hub, streamCtx.Client, libraries.WebSocketMessageTypeThinkingStart)
-				}
-
-				accumulatedThinking.WriteString(reasoningText)
-
-				if streamCtx.ShouldStream {
-					payload := &libraries.ChatMessageResponsePayload{
-						Message: reasoningText,
-					}
-					if streamCtx.BoardId != "" {
-						payload.BoardId = streamCtx.BoardId
-					}
-					libraries.SendChatMessageResponse(streamCtx.Hub, streamCtx.Client, libraries.WebSocketMessageTypeThinkingResponse, payload)
-				}
-			}
+			accumulatedThinking.WriteString(reasoningText)
+			dispatch.Emit(ThinkingDelta(reasoningText))
 		}
 
 		// Handle content chunks (delta.Content is a string in streaming)
 		if delta.Content != "" {
-			// If we were receiving structured reasoning and now getting content, thinking is complete
-			if thinkingStarted && !thinkingCompleted && reasoningText == "" {
-				thinkingCompleted = true
-				libraries.SendEventType(streamCtx.Hub, streamCtx.Client, libraries.WebSocketMessageTypeThinkingCompleted)
-			}
-
 			// Only parse <think> tags when enableThinking is true
 			// Otherwise, stream content directly (stripping think tags)
 			if enableThinking {
@@ -342,31 +371,17 @@ func (c *OpenRouterClient) callWithStreaming(ctx context.Context, req openrouter
 							thinkingChunk := content[:idx]
 							if thinkingChunk != "" {
 								accumulatedThinking.WriteString(thinkingChunk)
-								if streamCtx.ShouldStream {
-									payload := &libraries.ChatMessageResponsePayload{Message: thinkingChunk}
-									if streamCtx.BoardId != "" {
-										payload.BoardId = streamCtx.BoardId
-									}
-									libraries.SendChatMessageResponse(streamCtx.Hub, streamCtx.Client, libraries.WebSocketMessageTypeThinkingResponse, payload)
-								}
+								dispatch.Emit(ThinkingDelta(thinkingChunk))
 							}
-							// End thinking
+							// End thinking; the dispatcher closes it with the next text or tool call
 							insideThinkTag = false
-							thinkingCompleted = true
-							libraries.SendEventType(streamCtx.Hub, streamCtx.Client, libraries.WebSocketMessageTypeThinkingCompleted)
 							content = content[idx+8:] // Skip past </think>
 						} else {
 							// No closing tag yet, stream all as thinking (but keep last 8 chars in case tag is split)
 							if len(content) > 8 {
 								thinkingChunk := content[:len(content)-8]
 								accumulatedThinking.WriteString(thinkingChunk)
-								if streamCtx.ShouldStream {
-									payload := &libraries.ChatMessageResponsePayload{Message: thinkingChunk}
-									if streamCtx.BoardId != "" {
-										payload.BoardId = streamCtx.BoardId
-									}
-									libraries.SendChatMessageResponse(streamCtx.Hub, streamCtx.Client, libraries.WebSocketMessageTypeThinkingResponse, payload)
-								}
+								dispatch.Emit(ThinkingDelta(thinkingChunk))
 								content = content[len(content)-8:]
 							}
 							break // Wait for more content
@@ -378,33 +393,17 @@ func (c *OpenRouterClient) callWithStreaming(ctx context.Context, req openrouter
 							regularChunk := content[:idx]
 							if regularChunk != "" {
 								fullContent.WriteString(regularChunk)
-								if streamCtx.ShouldStream {
-									payload := &libraries.ChatMessageResponsePayload{Message: regularChunk}
-									if streamCtx.BoardId != "" {
-										payload.BoardId = streamCtx.BoardId
-									}
-									libraries.SendChatMessageResponse(streamCtx.Hub, streamCtx.Client, libraries.WebSocketMessageTypeChatResponse, payload)
-								}
+								dispatch.Emit(TextDelta(regularChunk))
 							}
 							// Start thinking
 							insideThinkTag = true
-							if !thinkingStarted {
-								thinkingStarted = true
-								libraries.SendEventType(streamCtx.Hub, streamCtx.Client, libraries.WebSocketMessageTypeThinkingStart)
-							}
 							content = content[idx+7:] // Skip past <think>
 						} else {
 							// No opening tag, stream as regular content (but keep last 7 chars in case tag is split)
 							if len(content) > 7 {
 								regularChunk := content[:len(content)-7]
 								fullContent.WriteString(regularChunk)
-								if streamCtx.ShouldStream {
-									payload := &libraries.ChatMessageResponsePayload{Message: regularChunk}
-									if streamCtx.BoardId != "" {
-										payload.BoardId = streamCtx.BoardId
-									}
-									libraries.SendChatMessageResponse(streamCtx.Hub, streamCtx.Client, libraries.WebSocketMessageTypeChatResponse, payload)
-								}
+								dispatch.Emit(TextDelta(regularChunk))
 								content = content[len(content)-7:]
 							}
 							break // Wait for more content
@@ -433,13 +432,7 @@ func (c *OpenRouterClient) callWithStreaming(ctx context.Context, req openrouter
 							// Stream content before <think>
 							regularChunk := content[:startIdx]
 							fullContent.WriteString(regularChunk)
-							if streamCtx.ShouldStream {
-								payload := &libraries.ChatMessageResponsePayload{Message: regularChunk}
-								if streamCtx.BoardId != "" {
-									payload.BoardId = streamCtx.BoardId
-								}
-								libraries.SendChatMessageResponse(streamCtx.Hub, streamCtx.Client, libraries.WebSocketMessageTypeChatResponse, payload)
-							}
+							dispatch.Emit(TextDelta(regularChunk))
 						}
 						content = content[startIdx:] // Keep <think>... for next iteration
 						break
@@ -448,13 +441,7 @@ func (c *OpenRouterClient) callWithStreaming(ctx context.Context, req openrouter
 					regularChunk := content[:startIdx]
 					if regularChunk != "" {
 						fullContent.WriteString(regularChunk)
-						if streamCtx.ShouldStream {
-							payload := &libraries.ChatMessageResponsePayload{Message: regularChunk}
-							if streamCtx.BoardId != "" {
-								payload.BoardId = streamCtx.BoardId
-							}
-							libraries.SendChatMessageResponse(streamCtx.Hub, streamCtx.Client, libraries.WebSocketMessageTypeChatResponse, payload)
-						}
+						dispatch.Emit(TextDelta(regularChunk))
 					}
 					content = content[endIdx+8:] // Skip past </think>
 				}
@@ -462,13 +449,7 @@ func (c *OpenRouterClient) callWithStreaming(ctx context.Context, req openrouter
 				// Stream any remaining content that's not inside tags
 				if !strings.Contains(strings.ToLower(content), "<think>") && content != "" {
 					fullContent.WriteString(content)
-					if streamCtx.ShouldStream {
-						payload := &libraries.ChatMessageResponsePayload{Message: content}
-						if streamCtx.BoardId != "" {
-							payload.BoardId = streamCtx.BoardId
-						}
-						libraries.SendChatMessageResponse(streamCtx.Hub, streamCtx.Client, libraries.WebSocketMessageTypeChatResponse, payload)
-					}
+					dispatch.Emit(TextDelta(content))
 					content = ""
 				}
 				pendingContent.Reset()
@@ -491,6 +472,7 @@ func (c *OpenRouterClient) callWithStreaming(ctx context.Context, req openrouter
 						Arguments: make(map[string]interface{}),
 					}
 					toolArgsBuffer[idx] = &strings.Builder{}
+					dispatch.Emit(ToolStarted(tc.ID, tc.Function.Name))
 				}
 
 				// Update ID and name if provided (they come in the first chunk)
@@ -518,30 +500,15 @@ func (c *OpenRouterClient) callWithStreaming(ctx context.Context, req openrouter
 		if insideThinkTag {
 			// Remaining content is thinking
 			accumulatedThinking.WriteString(remaining)
-			if streamCtx.ShouldStream {
-				payload := &libraries.ChatMessageResponsePayload{Message: remaining}
-				if streamCtx.BoardId != "" {
-					payload.BoardId = streamCtx.BoardId
-				}
-				libraries.SendChatMessageResponse(streamCtx.Hub, streamCtx.Client, libraries.WebSocketMessageTypeThinkingResponse, payload)
-			}
+			dispatch.Emit(ThinkingDelta(remaining))
 		} else {
 			// Remaining content is regular
 			fullContent.WriteString(remaining)
-			if streamCtx.ShouldStream {
-				payload := &libraries.ChatMessageResponsePayload{Message: remaining}
-				if streamCtx.BoardId != "" {
-					payload.BoardId = streamCtx.BoardId
-				}
-				libraries.SendChatMessageResponse(streamCtx.Hub, streamCtx.Client, libraries.WebSocketMessageTypeChatResponse, payload)
-			}
+			dispatch.Emit(TextDelta(remaining))
 		}
 	}
 
-	// Handle edge case: thinking started but never completed (no regular content followed)
-	if thinkingStarted && !thinkingCompleted {
-		libraries.SendEventType(streamCtx.Hub, streamCtx.Client, libraries.WebSocketMessageTypeThinkingCompleted)
-	}
+	dispatch.Emit(StreamDone())
 
 	// Parse accumulated arguments for each tool call
 	for idx, tc := range toolCallsMap {
@@ -584,6 +551,13 @@ func (c *OpenRouterClient) parseResponse(resp *openrouter.ChatCompletionResponse
 		result.TextContent = append(result.TextContent, choice.Message.Content.Text)
 	}
 
+	// Extract reasoning (DeepSeek uses reasoning_content, OpenRouter uses reasoning)
+	if choice.Message.ReasoningContent != nil {
+		result.ReasoningContent = *choice.Message.ReasoningContent
+	} else if choice.Message.Reasoning != nil {
+		result.ReasoningContent = *choice.Message.Reasoning
+	}
+
 	// Extract tool calls
 	if len(choice.Message.ToolCalls) > 0 {
 		for _, tc := range choice.Message.ToolCalls {
@@ -610,9 +584,11 @@ func (c *OpenRouterClient) parseResponse(resp *openrouter.ChatCompletionResponse
 // ChatWithTools handles tool execution loop
 func (c *OpenRouterClient) ChatWithTools(ctx context.Context, systemMessage string, messages []Message, streamCtx *StreamingContext, enableThinking bool) (*OpenRouterResponse, error) {
 	maxIterations := constants.GetMaxIterations(ctx)
+	ctx = WithToolInputRetries(ctx)
 
 	workingMessages := make([]Message, 0, len(messages)+6)
 	workingMessages = append(workingMessages, messages...)
+	images := newToolLoopImages(len(workingMessages))
 
 	var lastResp *OpenRouterResponse
 	var totalPromptTokens, totalCompletionTokens int
@@ -651,17 +627,7 @@ func (c *OpenRouterClient) ChatWithTools(ctx context.Context, systemMessage stri
 		// If no function calls, this is the final iteration
 		if len(lr.FunctionCalls) == 0 {
 			// Send buffered chunks
-			if currentStreamCtx != nil && len(currentStreamCtx.BufferedChunks) > 0 {
-				for _, chunk := range currentStreamCtx.BufferedChunks {
-					payload := &libraries.ChatMessageResponsePayload{
-						Message: chunk,
-					}
-					if currentStreamCtx.BoardId != "" {
-						payload.BoardId = currentStreamCtx.BoardId
-					}
-					libraries.SendChatMessageResponse(currentStreamCtx.Hub, currentStreamCtx.Client, libraries.WebSocketMessageTypeChatResponse, payload)
-				}
-			}
+			flushBufferedChunks(currentStreamCtx)
 			return lr, nil
 		}
 
@@ -672,7 +638,7 @@ func (c *OpenRouterClient) ChatWithTools(ctx context.Context, systemMessage stri
 				ID:       fc.ID,
 				Name:     fc.Name,
 				Input:    fc.Arguments,
-				Provider: "openrouter",
+				Provider: string(c.provider),
 			}
 		}
 
@@ -694,6 +660,8 @@ func (c *OpenRouterClient) ChatWithTools(ctx context.Context, systemMessage stri
 			}
 			assistantContent += strings.Join(toolCallSummary, "\n")
 		}
+		images.compact(workingMessages)
+
 		workingMessages = append(workingMessages, Message{
 			Role:    "assistant",
 			Content: assistantContent,
@@ -767,17 +735,7 @@ func (c *OpenRouterClient) ChatWithTools(ctx context.Context, systemMessage stri
 	}
 
 	// Send buffered chunks
-	if finalStreamCtx != nil && len(finalStreamCtx.BufferedChunks) > 0 {
-		for _, chunk := range finalStreamCtx.BufferedChunks {
-			payload := &libraries.ChatMessageResponsePayload{
-				Message: chunk,
-			}
-			if finalStreamCtx.BoardId != "" {
-				payload.BoardId = finalStreamCtx.BoardId
-			}
-			libraries.SendChatMessageResponse(finalStreamCtx.Hub, finalStreamCtx.Client, libraries.WebSocketMessageTypeChatResponse, payload)
-		}
-	}
+	flushBufferedChunks(finalStreamCtx)
 
 	if len(finalResp.TextContent) == 0 {
 		finalResp.TextContent = []string{"I completed several operations but reached the maximum iteration limit."}
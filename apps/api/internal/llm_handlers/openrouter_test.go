@@ -0,0 +1,37 @@
+package llmHandlers
+
+import (
+	"testing"
+
+	"github.com/revrost/go-openrouter"
+)
+
+func imageMessage() []Message {
+	return []Message{{Role: "user", Content: []map[string]interface{}{
+		{"type": "text", "text": "What is this?"},
+		{"type": "image", "source": map[string]interface{}{"type": "base64", "media_type": "image/png", "data": "iVBOR"}},
+	}}}
+}
+
+func TestOpenRouterSendsImagePartsToVisionModels(t *testing.T) {
+	c := &OpenRouterClient{imageInput: true}
+	msgs := c.convertMessagesToOpenRouterMessages(imageMessage())
+	if len(msgs) != 1 || len(msgs[0].Content.Multi) != 2 {
+		t.Fatalf("messages = %+v", msgs)
+	}
+	image := msgs[0].Content.Multi[1]
+	if image.Type != openrouter.ChatMessagePartTypeImageURL || image.ImageURL == nil || image.ImageURL.URL != "data:image/png;base64,iVBOR" {
+		t.Fatalf("image part = %+v", image)
+	}
+}
+
+func TestOpenRouterNotesImagesForTextOnlyModels(t *testing.T) {
+	c := &OpenRouterClient{}
+	msgs := c.convertMessagesToOpenRouterMessages(imageMessage())
+	if len(msgs) != 1 || msgs[0].Content.Multi != nil {
+		t.Fatalf("messages = %+v", msgs)
+	}
+	if want := "What is this?\n" + imageUnavailableNote; msgs[0].Content.Text != want {
+		t.Fatalf("text = %q", msgs[0].Content.Text)
+	}
+}
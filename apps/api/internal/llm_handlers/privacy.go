@@ -0,0 +1,177 @@
+package llmHandlers
+
+import (
+	"context"
+	"melina-studio-backend/internal/libraries"
+	"sort"
+	"sync"
+
+	"github.com/google/uuid"
+)
+
+// DataClass is a kind of user data sent to a model provider
+type DataClass string
+
+const (
+	DataClassChat   DataClass = "chat"   // the user's messages and the chat history
+	DataClassImages DataClass = "images" // uploaded images, selections and board thumbnails
+	DataClassBoard  DataClass = "board"  // shapes, text and structure of boards
+)
+
+// RequestPrivacy is who a request is made for and whether their organization turned on privacy mode
+type RequestPrivacy struct {
+	TenantID    uuid.UUID // uuid.Nil for the default tenant
+	PrivacyMode bool
+	DataClasses []DataClass // the data the caller includes besides what the messages show
+	DataRegion  DataRegion  // where the organization pinned its LLM traffic; empty means anywhere
+}
+
+type requestPrivacyKey struct{}
+
+// WithPrivacy makes the requests sent with the returned context carry the opt-outs of privacy mode,
+// on providers that take one, and attributes them to the tenant in the privacy audit
+func WithPrivacy(ctx context.Context, privacy RequestPrivacy) context.Context {
+	return context.WithValue(ctx, requestPrivacyKey{}, privacy)
+}
+
+// privacyFrom returns the request's privacy settings, if any were set
+func privacyFrom(ctx context.Context) (RequestPrivacy, bool) {
+	if ctx == nil {
+		return RequestPrivacy{}, false
+	}
+	privacy, ok := ctx.Value(requestPrivacyKey{}).(RequestPrivacy)
+	return privacy, ok
+}
+
+// privacyModeFrom reports whether the request's organization turned on privacy mode
+func privacyModeFrom(ctx context.Context) bool {
+	privacy, ok := privacyFrom(ctx)
+	return ok && privacy.PrivacyMode
+}
+
+// PrivacyControls lists the opt-outs sent to the provider in privacy mode
+// OpenAI keeps Responses API calls for 30 days unless store is off, and OpenRouter can route to
+// providers that train on prompts unless data collection is denied. The other hosted APIs have no
+// per-request switch: Anthropic, Bedrock and Vertex don't train on API traffic and Gemini, Groq,
+// DeepSeek and Mistral only offer account-level settings. Ollama runs on our own hosts
+func PrivacyControls(provider Provider) []string {
+	switch provider {
+	case ProviderOpenAI:
+		return []string{"store=false"}
+	case ProviderOpenRouter:
+		return []string{"provider.data_collection=deny"}
+	case ProviderOllama:
+		return []string{"self-hosted"}
+	}
+	return nil
+}
+
+// PrivacyAuditEntry is one request as the privacy audit records it
+type PrivacyAuditEntry struct {
+	TenantID    uuid.UUID
+	Provider    Provider
+	DataClasses []DataClass
+	PrivacyMode bool
+	Controls    []string // the opt-outs the request carried
+}
+
+// PrivacyAuditRecorder stores privacy audit entries; it must not block the request
+type PrivacyAuditRecorder interface {
+	RecordLLMRequest(entry PrivacyAuditEntry)
+}
+
+var (
+	privacyAuditMu sync.RWMutex
+	privacyAudit   PrivacyAuditRecorder
+)
+
+// SetPrivacyAuditRecorder sets where the privacy audit of outbound requests goes; nil turns it off
+func SetPrivacyAuditRecorder(r PrivacyAuditRecorder) {
+	privacyAuditMu.Lock()
+	defer privacyAuditMu.Unlock()
+	privacyAudit = r
+}
+
+// recordPrivacyAudit records a request to the provider, with the data classes its messages show
+// and the ones the caller declared
+func recordPrivacyAudit(ctx context.Context, provider Provider, messages []Message, extra ...DataClass) PrivacyAuditEntry {
+	privacy, _ := privacyFrom(ctx)
+	declared := append(append([]DataClass{}, privacy.DataClasses...), extra...)
+	entry := PrivacyAuditEntry{
+		TenantID:    privacy.TenantID,
+		Provider:    provider,
+		DataClasses: requestDataClasses(messages, declared),
+		PrivacyMode: privacy.PrivacyMode,
+	}
+	if privacy.PrivacyMode {
+		entry.Controls = PrivacyControls(provider)
+	}
+
+	privacyAuditMu.RLock()
+	recorder := privacyAudit
+	privacyAuditMu.RUnlock()
+	if recorder != nil {
+		recorder.RecordLLMRequest(entry)
+	}
+	return entry
+}
+
+// requestDataClasses merges the declared classes with the ones found in the messages, sorted and without duplicates
+func requestDataClasses(messages []Message, declared []DataClass) []DataClass {
+	seen := make(map[DataClass]bool)
+	for _, class := range declared {
+		seen[class] = true
+	}
+	for _, m := range messages {
+		switch content := m.Content.(type) {
+		case string:
+			if content != "" {
+				seen[DataClassChat] = true
+			}
+		case []map[string]interface{}:
+			for _, block := range content {
+				switch block["type"] {
+				case "text":
+					seen[DataClassChat] = true
+				case "image":
+					seen[DataClassImages] = true
+				case "tool_result":
+					// tool results describe the board the tools read or changed
+					seen[DataClassBoard] = true
+				}
+			}
+		}
+	}
+	classes := make([]DataClass, 0, len(seen))
+	for class := range seen {
+		classes = append(classes, class)
+	}
+	sort.Slice(classes, func(i, j int) bool { return classes[i] < classes[j] })
+	return classes
+}
+
+// auditedClient records every call in the privacy audit before it is sent; it wraps the provider client
+// directly, so calls the circuit breaker refuses or the response cache answers aren't counted
+type auditedClient struct {
+	inner    Client
+	provider Provider
+}
+
+func withPrivacyAudit(provider Provider, inner Client) Client {
+	return &auditedClient{inner: inner, provider: provider}
+}
+
+func (c *auditedClient) Chat(ctx context.Context, systemMessage string, messages []Message, enableThinking bool) (string, error) {
+	recordPrivacyAudit(ctx, c.provider, messages)
+	return c.inner.Chat(ctx, systemMessage, messages, enableThinking)
+}
+
+func (c *auditedClient) ChatStream(ctx context.Context, hub *libraries.Hub, client *libraries.Client, boardId string, systemMessage string, messages []Message, enableThinking bool) (string, error) {
+	recordPrivacyAudit(ctx, c.provider, messages)
+	return c.inner.ChatStream(ctx, hub, client, boardId, systemMessage, messages, enableThinking)
+}
+
+func (c *auditedClient) ChatStreamWithUsage(req ChatStreamRequest) (*ResponseWithUsage, error) {
+	recordPrivacyAudit(req.Ctx, c.provider, req.Messages)
+	return c.inner.ChatStreamWithUsage(req)
+}
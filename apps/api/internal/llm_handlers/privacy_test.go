@@ -0,0 +1,63 @@
+package llmHandlers
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+type recordedAudit struct {
+	entries []PrivacyAuditEntry
+}
+
+func (r *recordedAudit) RecordLLMRequest(entry PrivacyAuditEntry) {
+	r.entries = append(r.entries, entry)
+}
+
+func TestRequestDataClasses(t *testing.T) {
+	messages := []Message{
+		{Role: "user", Content: "draw a flowchart"},
+		{Role: "user", Content: []map[string]interface{}{
+			{"type": "image", "source": map[string]interface{}{}},
+			{"type": "tool_result", "tool_use_id": "t1", "content": "ok"},
+		}},
+	}
+	got := requestDataClasses(messages, []DataClass{DataClassChat})
+	want := []DataClass{DataClassBoard, DataClassChat, DataClassImages}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+}
+
+func TestRecordPrivacyAudit(t *testing.T) {
+	recorder := &recordedAudit{}
+	SetPrivacyAuditRecorder(recorder)
+	t.Cleanup(func() { SetPrivacyAuditRecorder(nil) })
+
+	tenantID := uuid.New()
+	ctx := WithPrivacy(context.Background(), RequestPrivacy{TenantID: tenantID, PrivacyMode: true, DataClasses: []DataClass{DataClassBoard}})
+	entry := recordPrivacyAudit(ctx, ProviderOpenRouter, []Message{{Role: "user", Content: "hi"}})
+	if entry.TenantID != tenantID || !entry.PrivacyMode {
+		t.Fatalf("entry lost the request's privacy: %+v", entry)
+	}
+	if !reflect.DeepEqual(entry.Controls, PrivacyControls(ProviderOpenRouter)) {
+		t.Fatalf("got controls %v, want OpenRouter's", entry.Controls)
+	}
+
+	// providers without a per-request opt-out are still audited, with no controls
+	entry = recordPrivacyAudit(ctx, ProviderGemini, nil)
+	if len(entry.Controls) != 0 || !reflect.DeepEqual(entry.DataClasses, []DataClass{DataClassBoard}) {
+		t.Fatalf("unexpected Gemini entry %+v", entry)
+	}
+
+	// outside privacy mode nothing is opted out
+	entry = recordPrivacyAudit(context.Background(), ProviderOpenAI, []Message{{Role: "user", Content: "hi"}})
+	if entry.PrivacyMode || len(entry.Controls) != 0 {
+		t.Fatalf("request without privacy mode carried controls: %+v", entry)
+	}
+	if len(recorder.entries) != 3 {
+		t.Fatalf("recorded %d requests, want 3", len(recorder.entries))
+	}
+}
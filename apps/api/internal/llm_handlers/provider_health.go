@@ -0,0 +1,341 @@
+package llmHandlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"melina-studio-backend/internal/config"
+	"melina-studio-backend/internal/libraries"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ErrProviderUnavailable is returned without calling the provider while its circuit breaker is open
+var ErrProviderUnavailable = errors.New("LLM provider is temporarily unavailable")
+
+const (
+	// outcomes older than healthWindow don't count towards the error rate
+	healthWindow      = 5 * time.Minute
+	healthMaxOutcomes = 200
+	// the breaker trips when at least breakerMinRequests calls in the window failed at breakerFailureRate or more
+	breakerMinRequests = 5
+	breakerFailureRate = 0.5
+	// an open breaker lets a single trial call through after breakerCooldown
+	breakerCooldown = 30 * time.Second
+)
+
+type CircuitState string
+
+const (
+	CircuitClosed   CircuitState = "closed"
+	CircuitOpen     CircuitState = "open"
+	CircuitHalfOpen CircuitState = "half_open"
+)
+
+// ProviderHealthStatus is the health of one provider as reported by GET /models/health
+type ProviderHealthStatus struct {
+	Provider     Provider     `json:"provider"`
+	State        CircuitState `json:"state"`
+	Requests     int          `json:"requests"`
+	Failures     int          `json:"failures"`
+	ErrorRate    float64      `json:"error_rate"`
+	AvgLatencyMs int64        `json:"avg_latency_ms"`
+	P95LatencyMs int64        `json:"p95_latency_ms"`
+	LastError    string       `json:"last_error,omitempty"`
+	LastErrorAt  *time.Time   `json:"last_error_at,omitempty"`
+	OpenedAt     *time.Time   `json:"opened_at,omitempty"`
+}
+
+type callOutcome struct {
+	at      time.Time
+	latency time.Duration
+	failed  bool
+}
+
+// providerBreaker tracks the recent calls of one provider and its circuit state
+type providerBreaker struct {
+	state         CircuitState
+	outcomes      []callOutcome
+	openedAt      time.Time
+	trialInFlight bool
+	lastError     string
+	lastErrorAt   time.Time
+}
+
+// healthTracker holds a breaker per provider; the zero value is not usable, use newHealthTracker
+type healthTracker struct {
+	mu       sync.Mutex
+	breakers map[Provider]*providerBreaker
+	now      func() time.Time
+}
+
+var providerHealth = newHealthTracker(time.Now)
+
+func newHealthTracker(now func() time.Time) *healthTracker {
+	return &healthTracker{breakers: make(map[Provider]*providerBreaker), now: now}
+}
+
+func (t *healthTracker) breaker(p Provider) *providerBreaker {
+	b, ok := t.breakers[p]
+	if !ok {
+		b = &providerBreaker{state: CircuitClosed}
+		t.breakers[p] = b
+	}
+	return b
+}
+
+// available reports whether a call to the provider would be let through, without reserving the trial
+func (t *healthTracker) available(p Provider) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	b := t.breaker(p)
+	switch b.state {
+	case CircuitOpen:
+		return t.now().Sub(b.openedAt) >= breakerCooldown
+	case CircuitHalfOpen:
+		return !b.trialInFlight
+	}
+	return true
+}
+
+// allow reserves a call; after the cooldown an open breaker lets exactly one trial through
+func (t *healthTracker) allow(p Provider) bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	b := t.breaker(p)
+	switch b.state {
+	case CircuitOpen:
+		if t.now().Sub(b.openedAt) < breakerCooldown {
+			return false
+		}
+		b.state = CircuitHalfOpen
+		b.trialInFlight = true
+		return true
+	case CircuitHalfOpen:
+		if b.trialInFlight {
+			return false
+		}
+		b.trialInFlight = true
+		return true
+	}
+	return true
+}
+
+// release frees a reserved half-open trial without recording an outcome
+func (t *healthTracker) release(p Provider) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	b := t.breaker(p)
+	if b.state == CircuitHalfOpen {
+		b.trialInFlight = false
+	}
+}
+
+// record stores the outcome of a call and moves the breaker
+func (t *healthTracker) record(p Provider, latency time.Duration, err error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+	b := t.breaker(p)
+
+	failed := err != nil
+	b.outcomes = append(b.outcomes, callOutcome{at: now, latency: latency, failed: failed})
+	b.prune(now)
+	if failed {
+		b.lastError = err.Error()
+		b.lastErrorAt = now
+	}
+
+	switch b.state {
+	case CircuitHalfOpen:
+		b.trialInFlight = false
+		if failed {
+			b.state = CircuitOpen
+			b.openedAt = now
+		} else {
+			// start over so the failures that tripped the breaker don't trip it again
+			b.state = CircuitClosed
+			b.outcomes = b.outcomes[len(b.outcomes)-1:]
+		}
+	case CircuitClosed:
+		requests, failures := b.counts()
+		if failed && requests >= breakerMinRequests && float64(failures)/float64(requests) >= breakerFailureRate {
+			b.state = CircuitOpen
+			b.openedAt = now
+			fmt.Printf("[health] Circuit opened for provider %s (%d/%d calls failed): %v\n", p, failures, requests, err)
+		}
+	}
+}
+
+func (b *providerBreaker) prune(now time.Time) {
+	keep := 0
+	for keep < len(b.outcomes) && now.Sub(b.outcomes[keep].at) > healthWindow {
+		keep++
+	}
+	b.outcomes = b.outcomes[keep:]
+	if len(b.outcomes) > healthMaxOutcomes {
+		b.outcomes = b.outcomes[len(b.outcomes)-healthMaxOutcomes:]
+	}
+}
+
+func (b *providerBreaker) counts() (requests int, failures int) {
+	for _, o := range b.outcomes {
+		requests++
+		if o.failed {
+			failures++
+		}
+	}
+	return requests, failures
+}
+
+func (t *healthTracker) snapshot() []ProviderHealthStatus {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	now := t.now()
+
+	statuses := make([]ProviderHealthStatus, 0, len(t.breakers))
+	for p, b := range t.breakers {
+		b.prune(now)
+		status := ProviderHealthStatus{Provider: p, State: b.state, LastError: b.lastError}
+		status.Requests, status.Failures = b.counts()
+		if status.Requests > 0 {
+			status.ErrorRate = float64(status.Failures) / float64(status.Requests)
+			latencies := make([]time.Duration, 0, len(b.outcomes))
+			var total time.Duration
+			for _, o := range b.outcomes {
+				latencies = append(latencies, o.latency)
+				total += o.latency
+			}
+			sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+			status.AvgLatencyMs = (total / time.Duration(len(latencies))).Milliseconds()
+			status.P95LatencyMs = latencies[(len(latencies)*95-1)/100].Milliseconds()
+		}
+		if !b.lastErrorAt.IsZero() {
+			at := b.lastErrorAt
+			status.LastErrorAt = &at
+		}
+		if b.state != CircuitClosed {
+			opened := b.openedAt
+			status.OpenedAt = &opened
+		}
+		statuses = append(statuses, status)
+	}
+	sort.Slice(statuses, func(i, j int) bool { return statuses[i].Provider < statuses[j].Provider })
+	return statuses
+}
+
+// ProviderHealth returns the health of every provider called since startup
+func ProviderHealth() []ProviderHealthStatus {
+	return providerHealth.snapshot()
+}
+
+// ProviderAvailable reports whether the provider's circuit breaker currently lets calls through
+func ProviderAvailable(p Provider) bool {
+	return providerHealth.available(p)
+}
+
+// ResolveHealthyModel returns the model to use for a request: the requested one while its provider is
+// healthy, otherwise the first model of LLM_FALLBACK_MODELS whose provider is healthy and that allowed accepts.
+// When nothing healthy is left the requested model is returned and its call fails fast
+func ResolveHealthyModel(modelName string, allowed func(modelName string) bool) (string, *ModelInfo, error) {
+	info, err := ValidateModel(modelName)
+	if err != nil {
+		return "", nil, err
+	}
+	name := pickHealthyModel(modelName, fallbackModels(), func(candidate string) bool {
+		candidateInfo, err := ValidateModel(candidate)
+		return err == nil && ProviderAvailable(candidateInfo.Provider)
+	}, allowed)
+	if name == modelName {
+		return modelName, info, nil
+	}
+	fmt.Printf("[health] Provider %s is unavailable, falling back from %s to %s\n", info.Provider, modelName, name)
+	fallbackInfo, err := ValidateModel(name)
+	if err != nil {
+		return "", nil, err
+	}
+	return name, fallbackInfo, nil
+}
+
+// pickHealthyModel walks the requested model and then the chain, returning the first healthy, allowed model
+func pickHealthyModel(requested string, chain []string, healthy func(string) bool, allowed func(string) bool) string {
+	if healthy(requested) {
+		return requested
+	}
+	for _, candidate := range chain {
+		if candidate == requested {
+			continue
+		}
+		if healthy(candidate) && (allowed == nil || allowed(candidate)) {
+			return candidate
+		}
+	}
+	return requested
+}
+
+func fallbackModels() []string {
+	var models []string
+	for _, name := range strings.Split(config.GetSettings().LLM.FallbackModels, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			models = append(models, name)
+		}
+	}
+	return models
+}
+
+// trackedClient records latency and failures of every call and short-circuits while the breaker is open
+type trackedClient struct {
+	inner    Client
+	provider Provider
+}
+
+func withHealthTracking(provider Provider, inner Client) Client {
+	return &trackedClient{inner: inner, provider: provider}
+}
+
+func (c *trackedClient) call(ctx context.Context, fn func() error) error {
+	if !providerHealth.allow(c.provider) {
+		return fmt.Errorf("%w: %s", ErrProviderUnavailable, c.provider)
+	}
+	start := time.Now()
+	err := fn()
+	// a caller that went away says nothing about the provider
+	if err != nil && ctx != nil && errors.Is(ctx.Err(), context.Canceled) {
+		providerHealth.release(c.provider)
+		return err
+	}
+	providerHealth.record(c.provider, time.Since(start), err)
+	return err
+}
+
+func (c *trackedClient) Chat(ctx context.Context, systemMessage string, messages []Message, enableThinking bool) (string, error) {
+	var text string
+	err := c.call(ctx, func() error {
+		var err error
+		text, err = c.inner.Chat(ctx, systemMessage, messages, enableThinking)
+		return err
+	})
+	return text, err
+}
+
+func (c *trackedClient) ChatStream(ctx context.Context, hub *libraries.Hub, client *libraries.Client, boardId string, systemMessage string, messages []Message, enableThinking bool) (string, error) {
+	var text string
+	err := c.call(ctx, func() error {
+		var err error
+		text, err = c.inner.ChatStream(ctx, hub, client, boardId, systemMessage, messages, enableThinking)
+		return err
+	})
+	return text, err
+}
+
+func (c *trackedClient) ChatStreamWithUsage(req ChatStreamRequest) (*ResponseWithUsage, error) {
+	var resp *ResponseWithUsage
+	err := c.call(req.Ctx, func() error {
+		var err error
+		resp, err = c.inner.ChatStreamWithUsage(req)
+		return err
+	})
+	return resp, err
+}
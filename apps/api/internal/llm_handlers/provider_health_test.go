@@ -0,0 +1,97 @@
+package llmHandlers
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestHealthTrackerTripsAndRecovers(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	tracker := newHealthTracker(func() time.Time { return now })
+	boom := errors.New("503 service unavailable")
+
+	// four failures stay below the minimum number of requests
+	for i := 0; i < 4; i++ {
+		tracker.record(ProviderLangChainGroq, time.Second, boom)
+	}
+	if !tracker.allow(ProviderLangChainGroq) {
+		t.Fatal("breaker opened before breakerMinRequests calls")
+	}
+	tracker.record(ProviderLangChainGroq, time.Second, boom)
+	if tracker.allow(ProviderLangChainGroq) || tracker.available(ProviderLangChainGroq) {
+		t.Fatal("breaker should be open after 5 failed calls")
+	}
+	if !tracker.available(ProviderOpenAI) {
+		t.Fatal("other providers must not be affected")
+	}
+
+	// after the cooldown exactly one trial goes through
+	now = now.Add(breakerCooldown)
+	if !tracker.available(ProviderLangChainGroq) || !tracker.allow(ProviderLangChainGroq) {
+		t.Fatal("breaker should let a trial through after the cooldown")
+	}
+	if tracker.allow(ProviderLangChainGroq) {
+		t.Fatal("only one trial may be in flight while half open")
+	}
+	tracker.record(ProviderLangChainGroq, time.Second, boom)
+	if tracker.allow(ProviderLangChainGroq) {
+		t.Fatal("a failed trial should reopen the breaker")
+	}
+
+	now = now.Add(breakerCooldown)
+	tracker.allow(ProviderLangChainGroq)
+	tracker.record(ProviderLangChainGroq, 200*time.Millisecond, nil)
+	status := tracker.snapshot()[0]
+	if status.State != CircuitClosed || status.Requests != 1 || status.Failures != 0 {
+		t.Errorf("after a successful trial status = %+v, want closed with a fresh window", status)
+	}
+}
+
+func TestHealthTrackerWindow(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	tracker := newHealthTracker(func() time.Time { return now })
+
+	for i := 0; i < 4; i++ {
+		tracker.record(ProviderOpenAI, time.Second, errors.New("timeout"))
+	}
+	now = now.Add(healthWindow + time.Second)
+	for i := 0; i < 3; i++ {
+		tracker.record(ProviderOpenAI, 100*time.Millisecond, nil)
+	}
+	tracker.record(ProviderOpenAI, 300*time.Millisecond, errors.New("timeout"))
+
+	status := tracker.snapshot()[0]
+	if status.State != CircuitClosed {
+		t.Fatalf("expired failures tripped the breaker: %+v", status)
+	}
+	if status.Requests != 4 || status.Failures != 1 || status.ErrorRate != 0.25 {
+		t.Errorf("status = %+v, want 1 of 4 calls failed", status)
+	}
+	if status.AvgLatencyMs != 150 || status.P95LatencyMs != 300 {
+		t.Errorf("latency avg %d p95 %d, want 150 and 300", status.AvgLatencyMs, status.P95LatencyMs)
+	}
+	if status.LastError != "timeout" || status.LastErrorAt == nil {
+		t.Errorf("last error = %q at %v", status.LastError, status.LastErrorAt)
+	}
+}
+
+func TestPickHealthyModel(t *testing.T) {
+	down := map[string]bool{"gpt-4.1": true, "gemini-2.5-flash": true}
+	healthy := func(name string) bool { return !down[name] }
+	chain := []string{"gemini-2.5-flash", "gpt-4.1", "claude-4.5-sonnet", "deepseek-chat"}
+
+	if got := pickHealthyModel("deepseek-chat", chain, healthy, nil); got != "deepseek-chat" {
+		t.Errorf("healthy model replaced by %q", got)
+	}
+	if got := pickHealthyModel("gpt-4.1", chain, healthy, nil); got != "claude-4.5-sonnet" {
+		t.Errorf("fallback = %q, want claude-4.5-sonnet", got)
+	}
+	onlyDeepSeek := func(name string) bool { return name == "deepseek-chat" }
+	if got := pickHealthyModel("gpt-4.1", chain, healthy, onlyDeepSeek); got != "deepseek-chat" {
+		t.Errorf("fallback = %q, want the first allowed model", got)
+	}
+	if got := pickHealthyModel("gpt-4.1", chain[:2], healthy, nil); got != "gpt-4.1" {
+		t.Errorf("with no healthy fallback got %q, want the requested model", got)
+	}
+}
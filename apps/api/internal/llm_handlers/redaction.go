@@ -0,0 +1,228 @@
+package llmHandlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+)
+
+// redactionPattern finds one kind of personal data; valid rejects look-alikes such as ids or coordinates
+type redactionPattern struct {
+	kind  string
+	re    *regexp.Regexp
+	valid func(match string) bool
+}
+
+// cards go before phones, so a card number is never half taken for a phone number
+var redactionPatterns = []redactionPattern{
+	{kind: "EMAIL", re: regexp.MustCompile(`[A-Za-z0-9._%+-]+@[A-Za-z0-9.-]+\.[A-Za-z]{2,}`)},
+	{kind: "CARD", re: regexp.MustCompile(`\b(?:\d[ -]?){12,18}\d\b`), valid: luhnValid},
+	{kind: "PHONE", re: regexp.MustCompile(`\+\d{1,3}[\s.-]?\(?\d{1,4}\)?(?:[\s.-]?\d{2,4}){2,4}\b|\(\d{3}\)\s?\d{3}[\s.-]\d{4}\b|\b\d{3}[.-]\d{3}[.-]\d{4}\b`), valid: phoneLength},
+}
+
+var redactionToken = regexp.MustCompile(`\[(?:EMAIL|CARD|PHONE)_\d+\]`)
+
+// Redactor swaps emails, phone numbers and card numbers for placeholders like [EMAIL_1] before a turn
+// reaches the provider, and swaps them back in what the model returns. One value keeps one placeholder
+// for the whole turn, so the model can still tell people apart and refer to them
+type Redactor struct {
+	mu     sync.Mutex
+	tokens map[string]string // value -> placeholder
+	values map[string]string // placeholder -> value
+	counts map[string]int
+}
+
+func NewRedactor() *Redactor {
+	return &Redactor{
+		tokens: make(map[string]string),
+		values: make(map[string]string),
+		counts: make(map[string]int),
+	}
+}
+
+// Redact replaces the personal data in text with placeholders
+func (r *Redactor) Redact(text string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, pattern := range redactionPatterns {
+		text = pattern.re.ReplaceAllStringFunc(text, func(match string) string {
+			if pattern.valid != nil && !pattern.valid(match) {
+				return match
+			}
+			if token, ok := r.tokens[match]; ok {
+				return token
+			}
+			r.counts[pattern.kind]++
+			token := fmt.Sprintf("[%s_%d]", pattern.kind, r.counts[pattern.kind])
+			r.tokens[match] = token
+			r.values[token] = match
+			return token
+		})
+	}
+	return text
+}
+
+// Restore puts the original values back in place of the placeholders this redactor handed out
+func (r *Redactor) Restore(text string) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if len(r.values) == 0 {
+		return text
+	}
+	return redactionToken.ReplaceAllStringFunc(text, func(token string) string {
+		if value, ok := r.values[token]; ok {
+			return value
+		}
+		return token
+	})
+}
+
+// Redacted reports whether anything was replaced so far
+func (r *Redactor) Redacted() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.values) > 0
+}
+
+// RedactMessages returns the messages with the text of every message and tool result redacted
+func (r *Redactor) RedactMessages(messages []Message) []Message {
+	redacted := make([]Message, len(messages))
+	for i, m := range messages {
+		redacted[i] = Message{Role: m.Role, Content: r.redactContent(m.Content)}
+	}
+	return redacted
+}
+
+func (r *Redactor) redactContent(content interface{}) interface{} {
+	switch content := content.(type) {
+	case string:
+		return r.Redact(content)
+	case []map[string]interface{}:
+		blocks := make([]map[string]interface{}, len(content))
+		for i, block := range content {
+			copied := make(map[string]interface{}, len(block))
+			for key, value := range block {
+				copied[key] = value
+			}
+			if text, ok := block["text"].(string); ok {
+				copied["text"] = r.Redact(text)
+			}
+			if block["type"] == "tool_result" {
+				copied["content"] = r.RedactValue(block["content"])
+			}
+			blocks[i] = copied
+		}
+		return blocks
+	}
+	return content
+}
+
+// RedactValue redacts every string in a tool result; images are left alone
+// Results of other types, e.g. structs, are redacted as the JSON the model would see
+func (r *Redactor) RedactValue(value interface{}) interface{} {
+	switch value.(type) {
+	case nil, string, map[string]interface{}, []map[string]interface{}, []interface{}, []string:
+	default:
+		if encoded, err := json.Marshal(value); err == nil {
+			var decoded interface{}
+			if err := json.Unmarshal(encoded, &decoded); err == nil {
+				value = decoded
+			}
+		}
+	}
+	return mapStrings(value, "image", r.Redact)
+}
+
+// RestoreValue restores every string in a tool input
+func (r *Redactor) RestoreValue(value interface{}) interface{} {
+	return mapStrings(value, "", r.Restore)
+}
+
+// mapStrings applies fn to the strings in nested maps and slices, skipping the values under skipKey
+func mapStrings(value interface{}, skipKey string, fn func(string) string) interface{} {
+	switch value := value.(type) {
+	case string:
+		return fn(value)
+	case map[string]interface{}:
+		mapped := make(map[string]interface{}, len(value))
+		for key, v := range value {
+			if skipKey != "" && key == skipKey {
+				mapped[key] = v
+				continue
+			}
+			mapped[key] = mapStrings(v, skipKey, fn)
+		}
+		return mapped
+	case []map[string]interface{}:
+		mapped := make([]map[string]interface{}, len(value))
+		for i, v := range value {
+			mapped[i], _ = mapStrings(v, skipKey, fn).(map[string]interface{})
+		}
+		return mapped
+	case []interface{}:
+		mapped := make([]interface{}, len(value))
+		for i, v := range value {
+			mapped[i] = mapStrings(v, skipKey, fn)
+		}
+		return mapped
+	case []string:
+		mapped := make([]string, len(value))
+		for i, v := range value {
+			mapped[i] = fn(v)
+		}
+		return mapped
+	}
+	return value
+}
+
+// luhnValid checks a card number's check digit, which ids and phone numbers rarely pass
+func luhnValid(match string) bool {
+	digits := strings.Map(func(c rune) rune {
+		if c >= '0' && c <= '9' {
+			return c
+		}
+		return -1
+	}, match)
+	if len(digits) < 13 || len(digits) > 19 {
+		return false
+	}
+	sum := 0
+	for i := 0; i < len(digits); i++ {
+		d := int(digits[len(digits)-1-i] - '0')
+		if i%2 == 1 {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+	}
+	return sum%10 == 0
+}
+
+// phoneLength keeps matches with as many digits as a phone number can have
+func phoneLength(match string) bool {
+	digits := 0
+	for _, c := range match {
+		if c >= '0' && c <= '9' {
+			digits++
+		}
+	}
+	return digits >= 7 && digits <= 15
+}
+
+type redactorKey struct{}
+
+// WithRedactor makes the tools of a turn restore placeholders in their input and redact their results
+func WithRedactor(ctx context.Context, r *Redactor) context.Context {
+	return context.WithValue(ctx, redactorKey{}, r)
+}
+
+// redactorFrom returns the turn's redactor, if redaction is on
+func redactorFrom(ctx context.Context) (*Redactor, bool) {
+	r, ok := ctx.Value(redactorKey{}).(*Redactor)
+	return r, ok && r != nil
+}
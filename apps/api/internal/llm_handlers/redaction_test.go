@@ -0,0 +1,78 @@
+package llmHandlers
+
+import (
+	"testing"
+)
+
+func TestRedactorRoundTrip(t *testing.T) {
+	r := NewRedactor()
+	text := "Mail ada@example.com or bob@example.org, call +1 415 555 0100, card 4111 1111 1111 1111. Again: ada@example.com"
+	redacted := r.Redact(text)
+	want := "Mail [EMAIL_1] or [EMAIL_2], call [PHONE_1], card [CARD_1]. Again: [EMAIL_1]"
+	if redacted != want {
+		t.Fatalf("got %q, want %q", redacted, want)
+	}
+	if !r.Redacted() {
+		t.Fatal("expected the redactor to report replacements")
+	}
+	if got := r.Restore(redacted); got != text {
+		t.Fatalf("restore got %q, want %q", got, text)
+	}
+	// placeholders the redactor didn't hand out stay as they are
+	if got := r.Restore("[EMAIL_9]"); got != "[EMAIL_9]" {
+		t.Fatalf("got %q", got)
+	}
+}
+
+func TestRedactorLeavesLookAlikes(t *testing.T) {
+	r := NewRedactor()
+	for _, text := range []string{
+		"order 4111 1111 1111 1112", // fails the Luhn check
+		"move the shape to x=1200 y=340",
+		"version 2024.10.17",
+		"id 12345",
+	} {
+		if got := r.Redact(text); got != text {
+			t.Errorf("Redact(%q) = %q, want it unchanged", text, got)
+		}
+	}
+	if r.Redacted() {
+		t.Fatal("expected nothing to be redacted")
+	}
+}
+
+func TestRedactorValues(t *testing.T) {
+	r := NewRedactor()
+	messages := r.RedactMessages([]Message{
+		{Role: "user", Content: "write to ada@example.com"},
+		{Role: "user", Content: []map[string]interface{}{
+			{"type": "tool_result", "tool_use_id": "t1", "content": map[string]interface{}{
+				"shapes": []interface{}{map[string]interface{}{"text": "ada@example.com"}},
+				"image":  "ada@example.com",
+			}},
+		}},
+	})
+	if messages[0].Content != "write to [EMAIL_1]" {
+		t.Fatalf("got %v", messages[0].Content)
+	}
+	result := messages[1].Content.([]map[string]interface{})[0]["content"].(map[string]interface{})
+	if text := result["shapes"].([]interface{})[0].(map[string]interface{})["text"]; text != "[EMAIL_1]" {
+		t.Fatalf("tool result text got %v", text)
+	}
+	if result["image"] != "ada@example.com" {
+		t.Fatalf("images must be left alone, got %v", result["image"])
+	}
+
+	input := r.RestoreValue(map[string]interface{}{"text": "Contact: [EMAIL_1]", "ids": []string{"[EMAIL_1]"}}).(map[string]interface{})
+	if input["text"] != "Contact: ada@example.com" || input["ids"].([]string)[0] != "ada@example.com" {
+		t.Fatalf("got %v", input)
+	}
+
+	type shape struct {
+		Text string `json:"text"`
+	}
+	structured := r.RedactValue(shape{Text: "ada@example.com"}).(map[string]interface{})
+	if structured["text"] != "[EMAIL_1]" {
+		t.Fatalf("struct results got %v", structured)
+	}
+}
@@ -0,0 +1,137 @@
+package llmHandlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"melina-studio-backend/internal/config"
+	"melina-studio-backend/internal/libraries"
+	"strings"
+)
+
+// DataRegion is where an organization requires its LLM traffic to stay; empty means anywhere
+type DataRegion string
+
+const (
+	DataRegionEU DataRegion = "eu"
+	DataRegionUS DataRegion = "us"
+)
+
+// ErrNoCompliantRegion is returned without calling the provider when it has no endpoint in the request's data region
+var ErrNoCompliantRegion = errors.New("model is not available in the required data region")
+
+// ParseDataRegion validates a data region; an empty string lifts the restriction
+func ParseDataRegion(value string) (DataRegion, error) {
+	switch region := DataRegion(strings.ToLower(strings.TrimSpace(value))); region {
+	case "", DataRegionEU, DataRegionUS:
+		return region, nil
+	}
+	return "", fmt.Errorf("unknown data region %q (expected eu or us)", value)
+}
+
+// RegionalEndpoint returns the location the provider serves the region's requests from, false when it
+// can't keep them in the region. Vertex and Bedrock run in the configured regional locations, Mistral's
+// API is hosted in the EU and Ollama runs wherever OLLAMA_DATA_REGION says our hosts are. The other
+// providers route requests globally or only offer residency at the account level
+func RegionalEndpoint(provider Provider, region DataRegion) (string, bool) {
+	settings := config.GetSettings()
+	var location string
+	switch provider {
+	case ProviderVertexAnthropic:
+		location = regionalSetting(region, settings.GCP.VertexRegionEU, settings.GCP.VertexRegionUS)
+	case ProviderBedrock:
+		location = regionalSetting(region, settings.AWS.BedrockRegionEU, settings.AWS.BedrockRegionUS)
+	case ProviderMistral:
+		if region == DataRegionEU {
+			location = settings.LLM.MistralBaseURL
+		}
+	case ProviderOllama:
+		if ollamaRegion, err := ParseDataRegion(settings.LLM.OllamaDataRegion); err == nil && ollamaRegion == region {
+			location = settings.LLM.OllamaBaseURL
+		}
+	}
+	return location, location != ""
+}
+
+func regionalSetting(region DataRegion, eu string, us string) string {
+	switch region {
+	case DataRegionEU:
+		return eu
+	case DataRegionUS:
+		return us
+	}
+	return ""
+}
+
+// ModelServesRegion reports whether a registry model can keep requests in the region; every model serves an empty region
+func ModelServesRegion(modelName string, region DataRegion) bool {
+	if region == "" {
+		return true
+	}
+	modelInfo, err := ValidateModel(modelName)
+	if err != nil {
+		return false
+	}
+	_, ok := RegionalEndpoint(modelInfo.Provider, region)
+	return ok
+}
+
+// dataRegionFrom returns the data region the request must stay in, empty when it isn't pinned
+func dataRegionFrom(ctx context.Context) DataRegion {
+	privacy, _ := privacyFrom(ctx)
+	return privacy.DataRegion
+}
+
+// regionalLocation picks the provider location of a request: the regional one when the request is
+// pinned to a data region, the deployment's default otherwise
+func regionalLocation(ctx context.Context, provider Provider, fallback string) string {
+	if region := dataRegionFrom(ctx); region != "" {
+		if location, ok := RegionalEndpoint(provider, region); ok {
+			return location
+		}
+	}
+	return fallback
+}
+
+// pinnedClient refuses requests pinned to a data region the provider can't serve, before they reach the
+// provider, the privacy audit or its circuit breaker
+type pinnedClient struct {
+	inner    Client
+	provider Provider
+}
+
+func withRegionPinning(provider Provider, inner Client) Client {
+	return &pinnedClient{inner: inner, provider: provider}
+}
+
+func (c *pinnedClient) check(ctx context.Context) error {
+	region := dataRegionFrom(ctx)
+	if region == "" {
+		return nil
+	}
+	if _, ok := RegionalEndpoint(c.provider, region); !ok {
+		return fmt.Errorf("%w: %s has no %s endpoint", ErrNoCompliantRegion, c.provider, strings.ToUpper(string(region)))
+	}
+	return nil
+}
+
+func (c *pinnedClient) Chat(ctx context.Context, systemMessage string, messages []Message, enableThinking bool) (string, error) {
+	if err := c.check(ctx); err != nil {
+		return "", err
+	}
+	return c.inner.Chat(ctx, systemMessage, messages, enableThinking)
+}
+
+func (c *pinnedClient) ChatStream(ctx context.Context, hub *libraries.Hub, client *libraries.Client, boardId string, systemMessage string, messages []Message, enableThinking bool) (string, error) {
+	if err := c.check(ctx); err != nil {
+		return "", err
+	}
+	return c.inner.ChatStream(ctx, hub, client, boardId, systemMessage, messages, enableThinking)
+}
+
+func (c *pinnedClient) ChatStreamWithUsage(req ChatStreamRequest) (*ResponseWithUsage, error) {
+	if err := c.check(req.Ctx); err != nil {
+		return nil, err
+	}
+	return c.inner.ChatStreamWithUsage(req)
+}
@@ -0,0 +1,59 @@
+package llmHandlers
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestParseDataRegion(t *testing.T) {
+	for input, want := range map[string]DataRegion{"": "", "EU": DataRegionEU, " us ": DataRegionUS} {
+		if got, err := ParseDataRegion(input); err != nil || got != want {
+			t.Errorf("ParseDataRegion(%q) = %q, %v, want %q", input, got, err, want)
+		}
+	}
+	if _, err := ParseDataRegion("mars"); err == nil {
+		t.Error("expected an unknown region to be rejected")
+	}
+}
+
+func TestRegionalEndpoint(t *testing.T) {
+	if location, ok := RegionalEndpoint(ProviderVertexAnthropic, DataRegionEU); !ok || location != "europe-west1" {
+		t.Errorf("vertex EU = %q, %v", location, ok)
+	}
+	if location, ok := RegionalEndpoint(ProviderBedrock, DataRegionUS); !ok || location != "us-east-1" {
+		t.Errorf("bedrock US = %q, %v", location, ok)
+	}
+	if _, ok := RegionalEndpoint(ProviderMistral, DataRegionUS); ok {
+		t.Error("mistral is only hosted in the EU")
+	}
+	for _, provider := range []Provider{ProviderOpenAI, ProviderGemini, ProviderDeepSeek, ProviderOpenRouter} {
+		if _, ok := RegionalEndpoint(provider, DataRegionEU); ok {
+			t.Errorf("%s has no regional endpoint", provider)
+		}
+	}
+	if !ModelServesRegion("gpt-4.1", "") {
+		t.Error("every model serves an unpinned request")
+	}
+}
+
+func TestPinnedClientRefusesProvidersOutsideTheRegion(t *testing.T) {
+	inner := &countingClient{}
+	ctx := WithPrivacy(context.Background(), RequestPrivacy{DataRegion: DataRegionEU})
+
+	if _, err := withRegionPinning(ProviderOpenAI, inner).Chat(ctx, "", nil, false); !errors.Is(err, ErrNoCompliantRegion) {
+		t.Fatalf("expected ErrNoCompliantRegion, got %v", err)
+	}
+	if inner.calls != 0 {
+		t.Fatal("a refused request must not reach the provider")
+	}
+	if _, err := withRegionPinning(ProviderVertexAnthropic, inner).Chat(ctx, "", nil, false); err != nil || inner.calls != 1 {
+		t.Fatalf("expected the EU request to reach vertex, got %v after %d calls", err, inner.calls)
+	}
+	if _, err := withRegionPinning(ProviderOpenAI, inner).Chat(context.Background(), "", nil, false); err != nil {
+		t.Fatalf("unpinned requests go anywhere, got %v", err)
+	}
+	if got := regionalLocation(ctx, ProviderVertexAnthropic, "us-east5"); got != "europe-west1" {
+		t.Errorf("regionalLocation = %q", got)
+	}
+}
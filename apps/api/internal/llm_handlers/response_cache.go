@@ -0,0 +1,139 @@
+package llmHandlers
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"melina-studio-backend/internal/config"
+	"melina-studio-backend/internal/libraries"
+	"sync"
+	"time"
+)
+
+// responseCacheSize bounds the number of cached completions; summaries are a few KB each
+const responseCacheSize = 500
+
+// responseCache keeps recent completions by request hash until they expire, evicting the oldest first
+type responseCache struct {
+	mu      sync.Mutex
+	entries map[string]cachedResponse
+	order   []string
+	max     int
+	now     func() time.Time
+}
+
+type cachedResponse struct {
+	text    string
+	expires time.Time
+}
+
+var sharedResponseCache = newResponseCache(responseCacheSize, time.Now)
+
+func newResponseCache(max int, now func() time.Time) *responseCache {
+	return &responseCache{entries: make(map[string]cachedResponse), max: max, now: now}
+}
+
+func (c *responseCache) get(key string) (string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	entry, ok := c.entries[key]
+	if !ok || c.now().After(entry.expires) {
+		return "", false
+	}
+	return entry.text, true
+}
+
+func (c *responseCache) put(key string, text string, ttl time.Duration) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if _, ok := c.entries[key]; !ok {
+		c.order = append(c.order, key)
+	}
+	c.entries[key] = cachedResponse{text: text, expires: c.now().Add(ttl)}
+	for len(c.order) > c.max {
+		delete(c.entries, c.order[0])
+		c.order = c.order[1:]
+	}
+}
+
+// responseCacheTTL returns LLM_RESPONSE_CACHE_MINUTES, 0 when the cache is disabled
+func responseCacheTTL() time.Duration {
+	return config.GetSettings().LLM.ResponseCacheTTL
+}
+
+// cacheableConfig reports whether a client's completions are deterministic enough to reuse:
+// temperature pinned to 0 and no tools, so the answer depends only on the prompt
+func cacheableConfig(cfg Config) bool {
+	return cfg.Temperature != nil && *cfg.Temperature == 0 && len(cfg.Tools) == 0
+}
+
+// cachedClient answers repeated identical non-streaming Chat calls from the shared cache.
+// Streaming calls always reach the provider since the user is watching the tokens arrive
+type cachedClient struct {
+	inner Client
+	// scope separates entries of different providers, models and output limits
+	scope string
+	cache *responseCache
+	ttl   time.Duration
+}
+
+func withResponseCache(cfg Config, inner Client, ttl time.Duration) Client {
+	maxTokens := 0
+	if cfg.MaxTokens != nil {
+		maxTokens = *cfg.MaxTokens
+	}
+	return &cachedClient{
+		inner: inner,
+		scope: fmt.Sprintf("%s|%s|t=0|max=%d", cfg.Provider, cfg.Model, maxTokens),
+		cache: sharedResponseCache,
+		ttl:   ttl,
+	}
+}
+
+// responseCacheKey hashes the scope, system prompt and messages into the cache key
+func responseCacheKey(scope string, systemMessage string, messages []Message) (string, error) {
+	payload, err := json.Marshal(messages)
+	if err != nil {
+		return "", err
+	}
+	h := sha256.New()
+	h.Write([]byte(scope))
+	h.Write([]byte{0})
+	h.Write([]byte(systemMessage))
+	h.Write([]byte{0})
+	h.Write(payload)
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func (c *cachedClient) Chat(ctx context.Context, systemMessage string, messages []Message, enableThinking bool) (string, error) {
+	if enableThinking {
+		return c.inner.Chat(ctx, systemMessage, messages, enableThinking)
+	}
+	key, err := responseCacheKey(c.scope, systemMessage, messages)
+	if err != nil {
+		return c.inner.Chat(ctx, systemMessage, messages, enableThinking)
+	}
+	if text, ok := c.cache.get(key); ok {
+		fmt.Printf("[response_cache] Cache hit for %s\n", c.scope)
+		return text, nil
+	}
+
+	text, err := c.inner.Chat(ctx, systemMessage, messages, enableThinking)
+	if err != nil {
+		return "", err
+	}
+	if text != "" {
+		c.cache.put(key, text, c.ttl)
+	}
+	return text, nil
+}
+
+func (c *cachedClient) ChatStream(ctx context.Context, hub *libraries.Hub, client *libraries.Client, boardId string, systemMessage string, messages []Message, enableThinking bool) (string, error) {
+	return c.inner.ChatStream(ctx, hub, client, boardId, systemMessage, messages, enableThinking)
+}
+
+func (c *cachedClient) ChatStreamWithUsage(req ChatStreamRequest) (*ResponseWithUsage, error) {
+	return c.inner.ChatStreamWithUsage(req)
+}
@@ -0,0 +1,76 @@
+package llmHandlers
+
+import (
+	"context"
+	"melina-studio-backend/internal/libraries"
+	"testing"
+	"time"
+)
+
+// countingClient answers every Chat call with the same text and counts the calls
+type countingClient struct {
+	calls int
+}
+
+func (c *countingClient) Chat(ctx context.Context, systemMessage string, messages []Message, enableThinking bool) (string, error) {
+	c.calls++
+	return "summary", nil
+}
+
+func (c *countingClient) ChatStream(ctx context.Context, hub *libraries.Hub, client *libraries.Client, boardId string, systemMessage string, messages []Message, enableThinking bool) (string, error) {
+	c.calls++
+	return "summary", nil
+}
+
+func (c *countingClient) ChatStreamWithUsage(req ChatStreamRequest) (*ResponseWithUsage, error) {
+	c.calls++
+	return &ResponseWithUsage{}, nil
+}
+
+func TestCachedClientReusesIdenticalRequests(t *testing.T) {
+	now := time.Date(2025, 1, 1, 12, 0, 0, 0, time.UTC)
+	inner := &countingClient{}
+	client := &cachedClient{inner: inner, scope: "openai|gpt-4.1|t=0|max=0", cache: newResponseCache(10, func() time.Time { return now }), ttl: time.Minute}
+	messages := []Message{{Role: "user", Content: "board outline"}}
+	ctx := context.Background()
+
+	for i := 0; i < 3; i++ {
+		if text, err := client.Chat(ctx, "summarize", messages, false); err != nil || text != "summary" {
+			t.Fatalf("Chat = %q, %v", text, err)
+		}
+	}
+	if inner.calls != 1 {
+		t.Errorf("provider called %d times for identical requests, want 1", inner.calls)
+	}
+
+	client.Chat(ctx, "summarize", []Message{{Role: "user", Content: "another board"}}, false)
+	client.Chat(ctx, "summarize", messages, true)
+	client.ChatStream(ctx, nil, nil, "", "summarize", messages, false)
+	if inner.calls != 4 {
+		t.Errorf("provider called %d times, want different messages, thinking and streaming to bypass the cache", inner.calls)
+	}
+
+	now = now.Add(2 * time.Minute)
+	client.Chat(ctx, "summarize", messages, false)
+	if inner.calls != 5 {
+		t.Errorf("expired entry was served from the cache")
+	}
+}
+
+func TestResponseCacheKeyScope(t *testing.T) {
+	messages := []Message{{Role: "user", Content: "hi"}}
+	a, _ := responseCacheKey("openai|gpt-4.1|t=0|max=0", "sys", messages)
+	b, _ := responseCacheKey("gemini||t=0|max=0", "sys", messages)
+	c, _ := responseCacheKey("openai|gpt-4.1|t=0|max=0", "sys2", messages)
+	if a == b || a == c {
+		t.Error("requests to different models or with different prompts share a cache key")
+	}
+
+	zero, hot := float32(0), float32(0.7)
+	if !cacheableConfig(Config{Temperature: &zero}) || cacheableConfig(Config{Temperature: &hot}) || cacheableConfig(Config{}) {
+		t.Error("only temperature-0 configs are cacheable")
+	}
+	if cacheableConfig(Config{Temperature: &zero, Tools: []map[string]interface{}{{"name": "addShape"}}}) {
+		t.Error("configs with tools are not cacheable")
+	}
+}
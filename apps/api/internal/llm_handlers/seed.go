@@ -0,0 +1,37 @@
+package llmHandlers
+
+import (
+	"context"
+	"math/rand/v2"
+)
+
+type turnSeedKey struct{}
+
+// NewTurnSeed picks the sampling seed of a chat turn; it is recorded so the turn can be replayed
+func NewTurnSeed() int64 {
+	// providers take 32-bit seeds
+	return int64(rand.Int32())
+}
+
+// WithSeed makes the requests sent with the returned context sample with the seed, on providers that
+// take one. Sampling is only repeatable at a fixed temperature and model version, and even then best effort
+func WithSeed(ctx context.Context, seed int64) context.Context {
+	return context.WithValue(ctx, turnSeedKey{}, seed)
+}
+
+// seedFrom returns the turn's seed, if one was set
+func seedFrom(ctx context.Context) (int64, bool) {
+	seed, ok := ctx.Value(turnSeedKey{}).(int64)
+	return seed, ok
+}
+
+// SeedSupported reports whether the provider's requests carry the turn's seed
+// Anthropic and Bedrock have no seed parameter, OpenAI's Responses API dropped it, and DeepSeek and
+// Mistral are left out because Mistral rejects the field under that name
+func SeedSupported(provider Provider) bool {
+	switch provider {
+	case ProviderGemini, ProviderOpenRouter, ProviderLangChainGroq, ProviderOllama:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,143 @@
+package llmHandlers
+
+import (
+	"bufio"
+	"errors"
+	"io"
+	"strings"
+
+	"melina-studio-backend/internal/config"
+)
+
+// defaultSSEMaxEventSize is the data size of one event accepted when no limit is configured
+const defaultSSEMaxEventSize = 16 * 1024 * 1024
+
+// sseReadBufferSize is the read buffer; longer lines are assembled across reads up to the event limit
+const sseReadBufferSize = 64 * 1024
+
+// ErrSSEEventTooLarge is returned when a line or an event's data is larger than the reader's limit
+var ErrSSEEventTooLarge = errors.New("sse: event exceeds size limit")
+
+// SSEEvent is one dispatched server-sent event; Data joins the event's data lines with "\n"
+type SSEEvent struct {
+	Event string
+	Data  string
+	ID    string
+}
+
+// SSEReader frames a text/event-stream into events
+// Lines may end in "\n", "\r\n" or a lone "\r"; lines starting with ":" are comments
+type SSEReader struct {
+	r            *bufio.Reader
+	maxEventSize int
+	pending      []string // lines already split off a read that contained lone "\r" endings
+}
+
+// NewSSEReader reads events from r, rejecting lines or events with more than maxEventSize bytes of data
+// A maxEventSize of zero or less uses the 16MB default
+func NewSSEReader(r io.Reader, maxEventSize int) *SSEReader {
+	if maxEventSize <= 0 {
+		maxEventSize = defaultSSEMaxEventSize
+	}
+	return &SSEReader{r: bufio.NewReaderSize(r, sseReadBufferSize), maxEventSize: maxEventSize}
+}
+
+// streamMaxEventSize is the configured limit for one streamed provider event, in bytes
+func streamMaxEventSize() int {
+	return config.GetSettings().LLM.StreamMaxEventMB * 1024 * 1024
+}
+
+// Next returns the next event with data, or io.EOF once the stream ends
+// An event still open when the stream ends is dispatched rather than dropped, since some proxies
+// close the connection without the final blank line
+func (s *SSEReader) Next() (*SSEEvent, error) {
+	var ev SSEEvent
+	var data strings.Builder
+	hasData := false
+
+	for {
+		line, err := s.readLine()
+		if err == io.EOF {
+			if hasData {
+				ev.Data = data.String()
+				return &ev, nil
+			}
+			return nil, io.EOF
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		if line == "" {
+			if hasData {
+				ev.Data = data.String()
+				return &ev, nil
+			}
+			// a blank line with no data ends an empty event, which is not dispatched
+			ev = SSEEvent{}
+			continue
+		}
+		if line[0] == ':' {
+			continue
+		}
+
+		field, value, found := strings.Cut(line, ":")
+		if found {
+			value = strings.TrimPrefix(value, " ")
+		}
+		switch field {
+		case "data":
+			if hasData {
+				data.WriteByte('\n')
+			}
+			data.WriteString(value)
+			hasData = true
+			if data.Len() > s.maxEventSize {
+				return nil, ErrSSEEventTooLarge
+			}
+		case "event":
+			ev.Event = value
+		case "id":
+			if !strings.ContainsRune(value, 0) {
+				ev.ID = value
+			}
+		}
+	}
+}
+
+// readLine returns the next line without its ending
+func (s *SSEReader) readLine() (string, error) {
+	if len(s.pending) > 0 {
+		line := s.pending[0]
+		s.pending = s.pending[1:]
+		return line, nil
+	}
+
+	var buf []byte
+	for {
+		chunk, err := s.r.ReadSlice('\n')
+		if len(buf)+len(chunk) > s.maxEventSize+len("data: \r\n") {
+			return "", ErrSSEEventTooLarge
+		}
+		buf = append(buf, chunk...)
+		if err == bufio.ErrBufferFull {
+			continue
+		}
+		if err != nil {
+			if err == io.EOF && len(buf) > 0 {
+				break
+			}
+			return "", err
+		}
+		break
+	}
+
+	line := strings.TrimSuffix(string(buf), "\n")
+	line = strings.TrimSuffix(line, "\r")
+	if strings.Contains(line, "\r") {
+		parts := strings.Split(line, "\r")
+		s.pending = parts[1:]
+		return parts[0], nil
+	}
+	return line, nil
+}
@@ -0,0 +1,161 @@
+package llmHandlers
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+func readAllSSE(t *testing.T, input string, maxEventSize int) ([]SSEEvent, error) {
+	t.Helper()
+	reader := NewSSEReader(strings.NewReader(input), maxEventSize)
+	var events []SSEEvent
+	for {
+		ev, err := reader.Next()
+		if err == io.EOF {
+			return events, nil
+		}
+		if err != nil {
+			return events, err
+		}
+		events = append(events, *ev)
+	}
+}
+
+func TestSSEReaderFramesEvents(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []SSEEvent
+	}{
+		{
+			name:  "anthropic framing",
+			input: "event: message_start\ndata: {\"type\":\"message_start\"}\n\nevent: ping\ndata: {\"type\": \"ping\"}\n\n",
+			want: []SSEEvent{
+				{Event: "message_start", Data: `{"type":"message_start"}`},
+				{Event: "ping", Data: `{"type": "ping"}`},
+			},
+		},
+		{
+			name:  "multi-line data is joined with newlines",
+			input: "data: first\ndata: second\ndata:\n\n",
+			want:  []SSEEvent{{Data: "first\nsecond\n"}},
+		},
+		{
+			name:  "crlf and lone cr endings",
+			input: "event: a\r\ndata: 1\r\n\r\ndata: 2\rdata: 3\r\r",
+			want:  []SSEEvent{{Event: "a", Data: "1"}, {Data: "2\n3"}},
+		},
+		{
+			name:  "comments, unknown fields and empty events are skipped",
+			input: ": keep-alive\nretry: 100\n\nevent: ignored\n\nfoo: bar\ndata: x\n\n",
+			want:  []SSEEvent{{Data: "x"}},
+		},
+		{
+			name:  "only one leading space is stripped",
+			input: "data:no space\n\ndata:  two spaces\n\nid: 7\ndata\n\n",
+			want:  []SSEEvent{{Data: "no space"}, {Data: " two spaces"}, {ID: "7", Data: ""}},
+		},
+		{
+			name:  "open event is dispatched at end of stream",
+			input: "data: [DONE]",
+			want:  []SSEEvent{{Data: "[DONE]"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			events, err := readAllSSE(t, tt.input, 0)
+			if err != nil {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if len(events) != len(tt.want) {
+				t.Fatalf("got %d events %+v, want %+v", len(events), events, tt.want)
+			}
+			for i := range events {
+				if events[i] != tt.want[i] {
+					t.Fatalf("event %d = %+v, want %+v", i, events[i], tt.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestSSEReaderHandlesLinesLongerThanItsBuffer(t *testing.T) {
+	// larger than both bufio.Scanner's 64KB token limit and the reader's own buffer
+	payload := `{"input":"` + strings.Repeat("x", 300*1024) + `"}`
+	events, err := readAllSSE(t, "data: "+payload+"\n\n", 0)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(events) != 1 || events[0].Data != payload {
+		t.Fatalf("long data line was not read back intact")
+	}
+}
+
+func TestSSEReaderRejectsOversizedEvents(t *testing.T) {
+	if _, err := readAllSSE(t, "data: "+strings.Repeat("x", 2048)+"\n\n", 1024); !errors.Is(err, ErrSSEEventTooLarge) {
+		t.Fatalf("long line: err = %v, want ErrSSEEventTooLarge", err)
+	}
+
+	lines := strings.Repeat("data: "+strings.Repeat("x", 200)+"\n", 10) + "\n"
+	if _, err := readAllSSE(t, lines, 1024); !errors.Is(err, ErrSSEEventTooLarge) {
+		t.Fatalf("many lines: err = %v, want ErrSSEEventTooLarge", err)
+	}
+}
+
+func FuzzSSEReader(f *testing.F) {
+	f.Add("event: message_start\ndata: {}\n\n")
+	f.Add("data: a\r\ndata: b\r\rdata:c\n\n: comment\n")
+	f.Add("data\n\nid: \x00\ndata: [DONE]")
+	f.Add(strings.Repeat("data: x\n", 40))
+
+	const maxEventSize = 256
+	f.Fuzz(func(t *testing.T, input string) {
+		reader := NewSSEReader(strings.NewReader(input), maxEventSize)
+		for i := 0; i <= len(input); i++ {
+			ev, err := reader.Next()
+			if err != nil {
+				if err != io.EOF && !errors.Is(err, ErrSSEEventTooLarge) {
+					t.Fatalf("unexpected error: %v", err)
+				}
+				return
+			}
+			if len(ev.Data) > maxEventSize {
+				t.Fatalf("event data of %d bytes passed the %d byte limit", len(ev.Data), maxEventSize)
+			}
+			if strings.ContainsAny(ev.Event+ev.ID, "\r\n") {
+				t.Fatalf("line ending leaked into a field: %+v", ev)
+			}
+		}
+		t.Fatalf("reader returned more events than there are input bytes")
+	})
+}
+
+func FuzzSSEReaderRoundTrip(f *testing.F) {
+	f.Add("plain")
+	f.Add("two\nlines")
+	f.Add(" leading space\n\ntrailing blank\n")
+	f.Add(`{"type":"content_block_delta","delta":{"partial_json":"{\"x\": 1}"}}`)
+
+	f.Fuzz(func(t *testing.T, data string) {
+		if strings.Contains(data, "\r") {
+			t.Skip("a carriage return inside data is a line ending on the wire")
+		}
+		var encoded strings.Builder
+		encoded.WriteString("event: delta\n")
+		for _, line := range strings.Split(data, "\n") {
+			encoded.WriteString("data: " + line + "\n")
+		}
+		encoded.WriteString("\n")
+
+		events, err := readAllSSE(t, encoded.String(), 0)
+		if err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+		if len(events) != 1 || events[0].Event != "delta" || events[0].Data != data {
+			t.Fatalf("round trip of %q gave %+v", data, events)
+		}
+	})
+}
@@ -0,0 +1,153 @@
+package llmHandlers
+
+import (
+	"melina-studio-backend/internal/libraries"
+)
+
+// StreamEventKind is what a provider saw in its response stream
+type StreamEventKind string
+
+const (
+	StreamEventTextDelta     StreamEventKind = "text_delta"
+	StreamEventThinkingDelta StreamEventKind = "thinking_delta"
+	StreamEventToolStarted   StreamEventKind = "tool_started"
+	StreamEventUsage         StreamEventKind = "usage"
+	StreamEventDone          StreamEventKind = "done"
+)
+
+// StreamEvent is one provider-neutral event of a streamed response
+// Providers produce these from their own wire format; a streamDispatcher turns them into WebSocket messages
+type StreamEvent struct {
+	Kind     StreamEventKind
+	Text     string      // TextDelta, ThinkingDelta
+	ToolID   string      // ToolStarted, when the provider assigns one
+	ToolName string      // ToolStarted
+	Usage    *TokenUsage // Usage: the usage reported so far for the response
+}
+
+// TextDelta is a chunk of the answer text
+func TextDelta(text string) StreamEvent {
+	return StreamEvent{Kind: StreamEventTextDelta, Text: text}
+}
+
+// ThinkingDelta is a chunk of the model's reasoning
+func ThinkingDelta(text string) StreamEvent {
+	return StreamEvent{Kind: StreamEventThinkingDelta, Text: text}
+}
+
+// ToolStarted is sent when the model begins a tool call, before its input has finished streaming
+func ToolStarted(id string, name string) StreamEvent {
+	return StreamEvent{Kind: StreamEventToolStarted, ToolID: id, ToolName: name}
+}
+
+// UsageReported carries the token usage the provider has reported so far
+func UsageReported(usage *TokenUsage) StreamEvent {
+	return StreamEvent{Kind: StreamEventUsage, Usage: usage}
+}
+
+// StreamDone ends the response
+func StreamDone() StreamEvent {
+	return StreamEvent{Kind: StreamEventDone}
+}
+
+// streamDispatcher converts a provider's stream events into WebSocket messages for one response
+// It owns the thinking phase, so every provider sends thinking_start before the first reasoning chunk
+// and exactly one thinking_completed once answer text, a tool call or the end of the response follows
+type streamDispatcher struct {
+	streamCtx *StreamingContext
+	// buffer holds answer text in streamCtx.BufferedChunks instead of sending it, for providers that
+	// only show an iteration's text once they know it made no tool calls; reasoning is then not streamed
+	buffer   bool
+	thinking bool
+	usage    *TokenUsage
+	// send delivers a message; an empty text sends just the event type. Nil when there is no client
+	send func(messageType libraries.WebSocketMessageType, text string)
+}
+
+// newStreamDispatcher sends to the streaming context's client, if it has one
+func newStreamDispatcher(streamCtx *StreamingContext, buffer bool) *streamDispatcher {
+	d := &streamDispatcher{streamCtx: streamCtx, buffer: buffer}
+	if streamCtx != nil && streamCtx.Client != nil {
+		d.send = func(messageType libraries.WebSocketMessageType, text string) {
+			if text == "" {
+				libraries.SendEventType(streamCtx.Hub, streamCtx.Client, messageType)
+				return
+			}
+			payload := &libraries.ChatMessageResponsePayload{Message: text}
+			if streamCtx.BoardId != "" {
+				payload.BoardId = streamCtx.BoardId
+			}
+			libraries.SendChatMessageResponse(streamCtx.Hub, streamCtx.Client, messageType, payload)
+		}
+	}
+	return d
+}
+
+// Emit handles one event from the provider
+func (d *streamDispatcher) Emit(ev StreamEvent) {
+	switch ev.Kind {
+	case StreamEventTextDelta:
+		if ev.Text == "" {
+			return
+		}
+		d.endThinking()
+		if d.buffer {
+			if d.streamCtx != nil {
+				d.streamCtx.BufferedChunks = append(d.streamCtx.BufferedChunks, ev.Text)
+			}
+			return
+		}
+		d.deliver(libraries.WebSocketMessageTypeChatResponse, ev.Text)
+	case StreamEventThinkingDelta:
+		if ev.Text == "" {
+			return
+		}
+		if !d.thinking {
+			d.thinking = true
+			d.deliver(libraries.WebSocketMessageTypeThinkingStart, "")
+		}
+		if !d.buffer {
+			d.deliver(libraries.WebSocketMessageTypeThinkingResponse, ev.Text)
+		}
+	case StreamEventToolStarted:
+		// the loader for the call is shown when it runs; the model has stopped reasoning either way
+		d.endThinking()
+	case StreamEventUsage:
+		if ev.Usage != nil {
+			d.usage = ev.Usage
+		}
+	case StreamEventDone:
+		d.endThinking()
+	}
+}
+
+// Usage returns the last usage the provider reported, or nil
+func (d *streamDispatcher) Usage() *TokenUsage {
+	return d.usage
+}
+
+func (d *streamDispatcher) endThinking() {
+	if !d.thinking {
+		return
+	}
+	d.thinking = false
+	d.deliver(libraries.WebSocketMessageTypeThinkingCompleted, "")
+}
+
+func (d *streamDispatcher) deliver(messageType libraries.WebSocketMessageType, text string) {
+	if d.send != nil {
+		d.send(messageType, text)
+	}
+}
+
+// flushBufferedChunks sends the answer text a buffering dispatcher held back for the iteration
+func flushBufferedChunks(streamCtx *StreamingContext) {
+	if streamCtx == nil || streamCtx.Client == nil {
+		return
+	}
+	d := newStreamDispatcher(streamCtx, false)
+	for _, chunk := range streamCtx.BufferedChunks {
+		d.Emit(TextDelta(chunk))
+	}
+	streamCtx.BufferedChunks = nil
+}
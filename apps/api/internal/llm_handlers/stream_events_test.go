@@ -0,0 +1,78 @@
+package llmHandlers
+
+import (
+	"strings"
+	"testing"
+
+	"melina-studio-backend/internal/libraries"
+)
+
+// recordingDispatcher returns a dispatcher whose messages are written as "type" or "type:text"
+func recordingDispatcher(streamCtx *StreamingContext, buffer bool) (*streamDispatcher, *[]string) {
+	var sent []string
+	d := &streamDispatcher{streamCtx: streamCtx, buffer: buffer}
+	d.send = func(messageType libraries.WebSocketMessageType, text string) {
+		if text == "" {
+			sent = append(sent, string(messageType))
+			return
+		}
+		sent = append(sent, string(messageType)+":"+text)
+	}
+	return d, &sent
+}
+
+func TestStreamDispatcherOpensAndClosesThinkingOnce(t *testing.T) {
+	d, sent := recordingDispatcher(nil, false)
+	d.Emit(ThinkingDelta("let me "))
+	d.Emit(ThinkingDelta("plan"))
+	d.Emit(TextDelta("Drawing"))
+	d.Emit(TextDelta(""))
+	d.Emit(ToolStarted("t1", "addShape"))
+	d.Emit(StreamDone())
+
+	want := "thinking_start,thinking_response:let me ,thinking_response:plan,thinking_completed,chat_response:Drawing"
+	if got := strings.Join(*sent, ","); got != want {
+		t.Fatalf("sent %s\nwant %s", got, want)
+	}
+}
+
+func TestStreamDispatcherClosesThinkingOnToolOrDone(t *testing.T) {
+	for _, end := range []StreamEvent{ToolStarted("t1", "addShape"), StreamDone()} {
+		d, sent := recordingDispatcher(nil, false)
+		d.Emit(ThinkingDelta("hmm"))
+		d.Emit(end)
+		d.Emit(StreamDone())
+		if got := strings.Join(*sent, ","); got != "thinking_start,thinking_response:hmm,thinking_completed" {
+			t.Fatalf("%s: sent %s", end.Kind, got)
+		}
+	}
+}
+
+func TestStreamDispatcherBuffersText(t *testing.T) {
+	streamCtx := &StreamingContext{}
+	d, sent := recordingDispatcher(streamCtx, true)
+	d.Emit(ThinkingDelta("hidden"))
+	d.Emit(TextDelta("a"))
+	d.Emit(TextDelta("b"))
+
+	if got := strings.Join(*sent, ","); got != "thinking_start,thinking_completed" {
+		t.Fatalf("sent %s", got)
+	}
+	if got := strings.Join(streamCtx.BufferedChunks, ""); got != "ab" {
+		t.Fatalf("buffered %q", got)
+	}
+}
+
+func TestStreamDispatcherKeepsLastUsage(t *testing.T) {
+	d := newStreamDispatcher(nil, false)
+	if d.Usage() != nil {
+		t.Fatal("expected no usage before any was reported")
+	}
+	d.Emit(UsageReported(&TokenUsage{InputTokens: 10}))
+	d.Emit(UsageReported(nil))
+	d.Emit(UsageReported(&TokenUsage{InputTokens: 10, OutputTokens: 5}))
+	d.Emit(TextDelta("no client, nothing sent"))
+	if usage := d.Usage(); usage == nil || usage.OutputTokens != 5 {
+		t.Fatalf("usage = %+v", usage)
+	}
+}
@@ -9,7 +9,21 @@ type TokenUsage struct {
 	InputTokens    int
 	OutputTokens   int
 	TotalTokens    int
-	CountingMethod string // "provider_api" or "tiktoken"
+	CountingMethod string // "provider_api", "tiktoken", "fake" or "mock"
+}
+
+// CompletedUsage is the usage and cost sent with chat_completed, nil when the provider reported none
+func CompletedUsage(model string, usage *TokenUsage) *libraries.ChatCompletedUsage {
+	if usage == nil {
+		return nil
+	}
+	return &libraries.ChatCompletedUsage{
+		InputTokens:    usage.InputTokens,
+		OutputTokens:   usage.OutputTokens,
+		TotalTokens:    usage.TotalTokens,
+		CountingMethod: usage.CountingMethod,
+		CostUSD:        UsageCost(model, usage),
+	}
 }
 
 func estimateWithTiktoken(input string, outputs []string, model string) *TokenUsage {
@@ -77,6 +91,26 @@ func ExtractAnthropicUsage(response *ClaudeResponse, inputText string) *TokenUsa
 	return estimateWithTiktoken(inputText, response.TextContent, "claude-4.5-sonnet")
 }
 
+// ExtractOpenAIUsage extracts token usage from an OpenAI Responses API response
+func ExtractOpenAIUsage(response *OpenAIResponse, inputText string) *TokenUsage {
+	if usage := response.Usage; usage != nil && (usage.InputTokens > 0 || usage.OutputTokens > 0) {
+		// output_tokens already includes the reasoning tokens, which are billed as output
+		fmt.Printf("[openai] Token usage: input=%d, output=%d (reasoning=%d), total=%d\n",
+			usage.InputTokens, usage.OutputTokens, usage.OutputTokensDetails.ReasoningTokens, usage.TotalTokens)
+		input := int(usage.InputTokens)
+		output := int(usage.OutputTokens)
+		return &TokenUsage{
+			InputTokens:    input,
+			OutputTokens:   output,
+			TotalTokens:    input + output,
+			CountingMethod: "provider_api",
+		}
+	}
+
+	fmt.Printf("[openai] No usage data found, falling back to tiktoken estimation\n")
+	return estimateWithTiktoken(inputText, response.TextContent, "openai")
+}
+
 // Extract from Gemini response
 func ExtractGeminiUsage(response *GeminiResponse, inputText string) *TokenUsage {
 	// Gemini includes usageMetadata in response
@@ -0,0 +1,54 @@
+package llmHandlers
+
+import (
+	"testing"
+
+	"github.com/openai/openai-go/responses"
+)
+
+func TestAddOpenAIUsageSumsIterations(t *testing.T) {
+	first := &responses.ResponseUsage{InputTokens: 100, OutputTokens: 40, TotalTokens: 140}
+	first.OutputTokensDetails.ReasoningTokens = 30
+	second := &responses.ResponseUsage{InputTokens: 180, OutputTokens: 20, TotalTokens: 200}
+
+	total := addOpenAIUsage(nil, first)
+	total = addOpenAIUsage(total, nil)
+	total = addOpenAIUsage(total, second)
+
+	if total.InputTokens != 280 || total.OutputTokens != 60 || total.TotalTokens != 340 {
+		t.Fatalf("unexpected sum: %+v", total)
+	}
+	if total.OutputTokensDetails.ReasoningTokens != 30 {
+		t.Fatalf("reasoning tokens = %d, want 30", total.OutputTokensDetails.ReasoningTokens)
+	}
+	if first.InputTokens != 100 {
+		t.Fatal("the first usage was modified")
+	}
+}
+
+func TestExtractOpenAIUsageCountsReasoningAsOutput(t *testing.T) {
+	usage := &responses.ResponseUsage{InputTokens: 50, OutputTokens: 70, TotalTokens: 120}
+	usage.OutputTokensDetails.ReasoningTokens = 60
+
+	got := ExtractOpenAIUsage(&OpenAIResponse{Usage: usage}, "")
+	if got.InputTokens != 50 || got.OutputTokens != 70 || got.TotalTokens != 120 {
+		t.Fatalf("unexpected usage: %+v", got)
+	}
+	if got.CountingMethod != "provider_api" {
+		t.Fatalf("counting method = %q", got.CountingMethod)
+	}
+}
+
+func TestCompletedUsage(t *testing.T) {
+	if CompletedUsage("gemini-2.5-flash", nil) != nil {
+		t.Fatal("expected no usage when the provider reported none")
+	}
+	usage := &TokenUsage{InputTokens: 1000, OutputTokens: 200, TotalTokens: 1200, CountingMethod: "provider_api"}
+	got := CompletedUsage("gemini-2.5-flash", usage)
+	if got.InputTokens != 1000 || got.OutputTokens != 200 || got.TotalTokens != 1200 || got.CountingMethod != "provider_api" {
+		t.Fatalf("unexpected usage: %+v", got)
+	}
+	if got.CostUSD != UsageCost("gemini-2.5-flash", usage) {
+		t.Fatalf("cost = %v, want %v", got.CostUSD, UsageCost("gemini-2.5-flash", usage))
+	}
+}
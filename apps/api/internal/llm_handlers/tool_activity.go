@@ -0,0 +1,69 @@
+package llmHandlers
+
+import (
+	"context"
+	"sync"
+)
+
+// toolActivityKey is the context key of the ToolActivity recorder for a turn
+type toolActivityKey struct{}
+
+// ToolActivity records the tool calls that succeeded during a turn and the shapes they created,
+// so callers can see what the agent changed before the client has saved it
+type ToolActivity struct {
+	mu            sync.Mutex
+	results       []ToolExecutionResult
+	createdShapes []map[string]interface{}
+}
+
+// WithToolActivity attaches a recorder for the tool calls made with the returned context
+// A context that already has one keeps it, so a caller that wraps the whole turn sees every call
+func WithToolActivity(ctx context.Context) (context.Context, *ToolActivity) {
+	if activity, ok := ctx.Value(toolActivityKey{}).(*ToolActivity); ok {
+		return ctx, activity
+	}
+	activity := &ToolActivity{}
+	return context.WithValue(ctx, toolActivityKey{}, activity), activity
+}
+
+// ToolActivityFrom returns the turn's recorder attached with WithToolActivity, if there is one
+func ToolActivityFrom(ctx context.Context) (*ToolActivity, bool) {
+	activity, ok := ctx.Value(toolActivityKey{}).(*ToolActivity)
+	return activity, ok
+}
+
+// recordToolActivity adds a successful tool call to the turn's recorder, if there is one
+func recordToolActivity(ctx context.Context, result ToolExecutionResult) {
+	activity, ok := ctx.Value(toolActivityKey{}).(*ToolActivity)
+	if !ok {
+		return
+	}
+	activity.mu.Lock()
+	defer activity.mu.Unlock()
+	activity.results = append(activity.results, result)
+}
+
+// Results returns the successful tool calls in the order they ran
+func (a *ToolActivity) Results() []ToolExecutionResult {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]ToolExecutionResult(nil), a.results...)
+}
+
+// RecordCreatedShape adds a shape a tool created to the turn's recorder, if there is one
+func RecordCreatedShape(ctx context.Context, shape map[string]interface{}) {
+	activity, ok := ctx.Value(toolActivityKey{}).(*ToolActivity)
+	if !ok {
+		return
+	}
+	activity.mu.Lock()
+	defer activity.mu.Unlock()
+	activity.createdShapes = append(activity.createdShapes, shape)
+}
+
+// CreatedShapes returns the shapes created by tools during the turn
+func (a *ToolActivity) CreatedShapes() []map[string]interface{} {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]map[string]interface{}(nil), a.createdShapes...)
+}
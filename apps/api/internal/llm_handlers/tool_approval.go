@@ -0,0 +1,89 @@
+package llmHandlers
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"melina-studio-backend/internal/libraries"
+)
+
+// ToolApprovalTimeout is how long a destructive tool call waits for the user's answer before it is declined
+const ToolApprovalTimeout = 60 * time.Second
+
+// approvalRequiredTools are the tools that can't be undone from the chat and need the user's confirmation
+var approvalRequiredTools = map[string]bool{
+	"deleteShape": true,
+	"renameBoard": true,
+}
+
+// configuredApprovalTools are the tools loaded at runtime that asked for confirmation, see SetToolApprovalRequired
+var (
+	configuredApprovalMu    sync.RWMutex
+	configuredApprovalTools = make(map[string]bool)
+)
+
+// SetToolApprovalRequired makes a tool registered at runtime ask for confirmation like the destructive built-ins
+func SetToolApprovalRequired(name string, required bool) {
+	configuredApprovalMu.Lock()
+	defer configuredApprovalMu.Unlock()
+	if required {
+		configuredApprovalTools[name] = true
+	} else {
+		delete(configuredApprovalTools, name)
+	}
+}
+
+// RequiresToolApproval reports whether a tool asks the user before running, for users who turned confirmations on
+func RequiresToolApproval(name string) bool {
+	if approvalRequiredTools[name] {
+		return true
+	}
+	configuredApprovalMu.RLock()
+	defer configuredApprovalMu.RUnlock()
+	return configuredApprovalTools[name]
+}
+
+// requestToolApproval sends the approval request to the client; tests swap it out
+var requestToolApproval = libraries.RequestToolApproval
+
+// toolApprovalsKey is the context key that turns on confirmations for a turn
+type toolApprovalsKey struct{}
+
+// WithToolApprovals makes ExecuteTools ask the user before running destructive tools in the returned context
+func WithToolApprovals(ctx context.Context) context.Context {
+	return context.WithValue(ctx, toolApprovalsKey{}, true)
+}
+
+func toolApprovalsEnabled(ctx context.Context) bool {
+	enabled, _ := ctx.Value(toolApprovalsKey{}).(bool)
+	return enabled
+}
+
+// awaitToolApproval asks the user to confirm a destructive tool call and blocks until they answer, the request
+// times out or the turn is cancelled. It returns nil when the call may run; without a client to ask,
+// the call is declined rather than run unconfirmed
+func awaitToolApproval(ctx context.Context, streamCtx *StreamingContext, name string, input map[string]interface{}, timeout time.Duration) error {
+	if streamCtx == nil || streamCtx.Hub == nil || streamCtx.Client == nil {
+		return fmt.Errorf("%s needs the user's confirmation, but there is no connected client to ask", name)
+	}
+
+	decision, cancel := requestToolApproval(streamCtx.Hub, streamCtx.Client, streamCtx.BoardId, name, input, timeout)
+	defer cancel()
+
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+
+	select {
+	case approved := <-decision:
+		if !approved {
+			return fmt.Errorf("the user declined %s; do not retry it unless they ask again", name)
+		}
+		return nil
+	case <-timer.C:
+		return fmt.Errorf("the user did not confirm %s within %s, so it was not run", name, timeout)
+	case <-ctx.Done():
+		return fmt.Errorf("%s was not run: %w", name, ctx.Err())
+	}
+}
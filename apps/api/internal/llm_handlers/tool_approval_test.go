@@ -0,0 +1,80 @@
+package llmHandlers
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"melina-studio-backend/internal/libraries"
+)
+
+// stubToolApproval answers approval requests with the given decision, or never when answer is nil
+func stubToolApproval(t *testing.T, answer *bool) *int {
+	t.Helper()
+	asked := 0
+	original := requestToolApproval
+	requestToolApproval = func(hub *libraries.Hub, client *libraries.Client, boardId string, toolName string, input map[string]interface{}, timeout time.Duration) (<-chan bool, func()) {
+		asked++
+		decision := make(chan bool, 1)
+		if answer != nil {
+			decision <- *answer
+		}
+		return decision, func() {}
+	}
+	t.Cleanup(func() { requestToolApproval = original })
+	return &asked
+}
+
+func TestExecuteToolsWaitsForApprovalOfDestructiveTools(t *testing.T) {
+	ran := 0
+	RegisterTool("deleteShape", func(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+		ran++
+		return "deleted", nil
+	})
+	defer UnregisterTool("deleteShape")
+
+	streamCtx := &StreamingContext{Hub: &libraries.Hub{}, Client: &libraries.Client{}, BoardId: "board"}
+	call := []ToolCall{{ID: "1", Name: "deleteShape", Input: map[string]interface{}{"shapeId": "s1"}}}
+
+	approve, decline := true, false
+	asked := stubToolApproval(t, &approve)
+	if result := ExecuteTools(WithToolApprovals(context.Background()), call, streamCtx)[0]; result.Error != nil || ran != 1 {
+		t.Fatalf("approved call should run, got error %v and %d runs", result.Error, ran)
+	}
+	if *asked != 1 {
+		t.Fatalf("expected one approval request, got %d", *asked)
+	}
+
+	stubToolApproval(t, &decline)
+	result := ExecuteTools(WithToolApprovals(context.Background()), call, streamCtx)[0]
+	if result.Error == nil || !strings.Contains(result.Error.Error(), "declined") || ran != 1 {
+		t.Fatalf("declined call should not run, got error %v and %d runs", result.Error, ran)
+	}
+
+	// without the setting nothing is asked
+	asked = stubToolApproval(t, &decline)
+	if result := ExecuteTools(context.Background(), call, streamCtx)[0]; result.Error != nil || *asked != 0 {
+		t.Fatalf("calls without approvals should run unasked, got error %v and %d requests", result.Error, *asked)
+	}
+}
+
+func TestAwaitToolApprovalDeclinesWithoutAnswer(t *testing.T) {
+	stubToolApproval(t, nil)
+	streamCtx := &StreamingContext{Hub: &libraries.Hub{}, Client: &libraries.Client{}}
+
+	err := awaitToolApproval(context.Background(), streamCtx, "renameBoard", nil, 10*time.Millisecond)
+	if err == nil || !strings.Contains(err.Error(), "did not confirm") {
+		t.Fatalf("expected a timeout, got %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := awaitToolApproval(ctx, streamCtx, "renameBoard", nil, time.Minute); err == nil {
+		t.Fatal("expected a cancelled turn to decline")
+	}
+
+	if err := awaitToolApproval(context.Background(), nil, "renameBoard", nil, time.Minute); err == nil {
+		t.Fatal("expected a call without a client to be declined")
+	}
+}
@@ -3,6 +3,7 @@ package llmHandlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"strings"
 	"sync"
@@ -68,19 +69,47 @@ type ImageContent struct {
 	Shapes      []map[string]interface{}
 }
 
+// streamingContextKey is the context key of the StreamingContext passed to tool handlers
+type streamingContextKey struct{}
+
+// WithStreamingContext attaches the turn's StreamingContext for the tool handlers
+func WithStreamingContext(ctx context.Context, streamCtx *StreamingContext) context.Context {
+	return context.WithValue(ctx, streamingContextKey{}, streamCtx)
+}
+
+// StreamingContextFrom returns the StreamingContext attached with WithStreamingContext
+func StreamingContextFrom(ctx context.Context) (*StreamingContext, bool) {
+	streamCtx, ok := ctx.Value(streamingContextKey{}).(*StreamingContext)
+	return streamCtx, ok && streamCtx != nil
+}
+
 // ExecuteTools executes a batch of tool calls and returns results
 func ExecuteTools(ctx context.Context, toolCalls []ToolCall, streamCtx *StreamingContext) []ToolExecutionResult {
 	results := make([]ToolExecutionResult, 0, len(toolCalls))
 
 	// Pass StreamingContext through context if available
 	if streamCtx != nil {
-		ctx = context.WithValue(ctx, "streamingContext", streamCtx)
+		ctx = WithStreamingContext(ctx, streamCtx)
+	}
+
+	// the loader status follows the tools of this round and is cleared once they finish
+	iteration := 0
+	drawsFlowchart := false
+	if streamCtx != nil && streamCtx.LoaderGen != nil {
+		iteration = streamCtx.LoaderGen.BeginToolRound()
+		drawsFlowchart = isFlowchartRound(toolCalls)
+		defer streamCtx.LoaderGen.StopStatus(streamCtx.Hub, streamCtx.Client, streamCtx.BoardId, iteration)
 	}
 
 	for _, tc := range toolCalls {
 		// Send dynamic loader update before executing tool
 		if streamCtx != nil && streamCtx.LoaderGen != nil {
 			streamCtx.LoaderGen.SendLoaderUpdate(streamCtx.Hub, streamCtx.Client, streamCtx.BoardId, tc.Name)
+			variant := statusVariant(tc)
+			if drawsFlowchart && tc.Name == "addShape" {
+				variant = "flowchart"
+			}
+			streamCtx.LoaderGen.SendStatus(streamCtx.Hub, streamCtx.Client, streamCtx.BoardId, tc.Name, variant, iteration)
 		}
 
 		result := ToolExecutionResult{
@@ -91,7 +120,8 @@ func ExecuteTools(ctx context.Context, toolCalls []ToolCall, streamCtx *Streamin
 		// Handle empty input (streaming artifact) - return error result instead of skipping
 		// This is important because Claude requires a tool_result for every tool_use
 		if len(tc.Input) == 0 {
-			result.Error = fmt.Errorf("tool input was empty (streaming artifact) - please retry with valid parameters")
+			result.Error = InvalidToolInput("tool input was empty (streaming artifact) - please retry with valid parameters")
+			asToolInputError(ctx, tc.Name, result.Error)
 			results = append(results, result)
 			fmt.Printf("[%s] EMPTY INPUT for tool %s (id=%s) - returning error result\n", tc.Provider, tc.Name, tc.ID)
 			continue
@@ -106,6 +136,14 @@ func ExecuteTools(ctx context.Context, toolCalls []ToolCall, streamCtx *Streamin
 			continue
 		}
 
+		// The board's agent profile may not include this tool
+		if profile := agentProfileFrom(ctx); !profile.AllowsTool(tc.Name) {
+			result.Error = fmt.Errorf("tool %s is not available to the %s agent", tc.Name, profile.DisplayName)
+			results = append(results, result)
+			fmt.Printf("[%s] TOOL %s NOT ALLOWED for profile %s\n", tc.Provider, tc.Name, profile.Name)
+			continue
+		}
+
 		// Ensure input is map[string]interface{}
 		input := make(map[string]interface{})
 		if tc.Input != nil {
@@ -113,6 +151,34 @@ func ExecuteTools(ctx context.Context, toolCalls []ToolCall, streamCtx *Streamin
 				input[k] = v
 			}
 		}
+		// strict schemas send unset optional arguments as null
+		input = DropNullArguments(input)
+		// the model only saw placeholders for personal data, the tool gets the real values
+		redactor, redacting := redactorFrom(ctx)
+		if redacting {
+			input, _ = redactor.RestoreValue(input).(map[string]interface{})
+		}
+
+		// Reject arguments that don't match the tool's schema so the model can retry with valid ones
+		if schema, ok := getToolSchema(tc.Name); ok {
+			if err := ValidateToolInput(schema, input); err != nil {
+				result.Error = err
+				asToolInputError(ctx, tc.Name, err)
+				results = append(results, result)
+				fmt.Printf("[%s] INVALID INPUT for tool %s: %v\n", tc.Provider, tc.Name, err)
+				continue
+			}
+		}
+
+		// Destructive tools wait for the user's confirmation when they asked for it
+		if toolApprovalsEnabled(ctx) && RequiresToolApproval(tc.Name) {
+			if err := awaitToolApproval(ctx, streamCtx, tc.Name, input, ToolApprovalTimeout); err != nil {
+				result.Error = err
+				results = append(results, result)
+				fmt.Printf("[%s] TOOL %s NOT APPROVED: %v\n", tc.Provider, tc.Name, err)
+				continue
+			}
+		}
 
 		fmt.Printf("[%s] executing tool: %s", tc.Provider, tc.Name)
 		if tc.ID != "" {
@@ -137,11 +203,15 @@ func ExecuteTools(ctx context.Context, toolCalls []ToolCall, streamCtx *Streamin
 		// Handle errors (but don't stop the workflow - continue with other tools)
 		if handlerErr != nil {
 			result.Error = handlerErr
+			asToolInputError(ctx, tc.Name, handlerErr)
 			results = append(results, result)
 			fmt.Printf("[%s] ERROR in tool %s: %v (continuing with other tools)\n", tc.Provider, tc.Name, handlerErr)
 			continue
 		}
 
+		if redacting {
+			execResult = redactor.RedactValue(execResult)
+		}
 		result.Result = execResult
 
 		// Check if result contains image content
@@ -181,6 +251,7 @@ func ExecuteTools(ctx context.Context, toolCalls []ToolCall, streamCtx *Streamin
 			}
 		}
 
+		recordToolActivity(ctx, result)
 		results = append(results, result)
 	}
 
@@ -189,6 +260,16 @@ func ExecuteTools(ctx context.Context, toolCalls []ToolCall, streamCtx *Streamin
 
 // FormatAnthropicToolResult formats a ToolExecutionResult for Anthropic's API
 func FormatAnthropicToolResult(result ToolExecutionResult) map[string]interface{} {
+	var inputErr *ToolInputError
+	if errors.As(result.Error, &inputErr) {
+		return map[string]interface{}{
+			"type":        "tool_result",
+			"tool_use_id": result.ToolCallID,
+			"content":     toolInputFeedbackText(inputErr),
+			"is_error":    true,
+		}
+	}
+
 	if result.Error != nil {
 		// Create a helpful error message for the LLM
 		errorMsg := fmt.Sprintf("Tool execution failed: %v. Please check the input parameters and try again. The tool '%s' requires valid parameters.", result.Error, result.ToolName)
@@ -263,7 +344,7 @@ func FormatGeminiToolResult(result ToolExecutionResult) (functionResponse map[st
 	imageBlocks = []map[string]interface{}{}
 
 	if result.Error != nil {
-		resultJSON, _ := json.Marshal(map[string]string{"error": result.Error.Error()})
+		resultJSON, _ := json.Marshal(toolErrorResponse(result.Error))
 		return map[string]interface{}{
 			"type": "function_response",
 			"function": map[string]interface{}{
@@ -332,7 +413,10 @@ func FormatLangChainToolResult(result ToolExecutionResult) (functionResponse map
 
 	var resultText string
 
-	if result.Error != nil {
+	var inputErr *ToolInputError
+	if errors.As(result.Error, &inputErr) {
+		resultText = fmt.Sprintf("Error: %s", toolInputFeedbackText(inputErr))
+	} else if result.Error != nil {
 		resultText = fmt.Sprintf("Error: %v", result.Error)
 	} else if result.HasImage && result.ImageData != nil {
 		// Build text content with shapes info
@@ -389,3 +473,26 @@ func FormatLangChainToolResult(result ToolExecutionResult) (functionResponse map
 		"text": resultText,
 	}, imageBlocks
 }
+
+// statusVariant refines the loader status of a tool call, currently the shape type of addShape
+func statusVariant(tc ToolCall) string {
+	if tc.Name != "addShape" {
+		return ""
+	}
+	shapeType, _ := tc.Input["shapeType"].(string)
+	return shapeType
+}
+
+// isFlowchartRound reports whether a round of tool calls draws both boxes and the arrows between them
+func isFlowchartRound(toolCalls []ToolCall) bool {
+	var boxes, connectors bool
+	for _, tc := range toolCalls {
+		switch statusVariant(tc) {
+		case "arrow", "line":
+			connectors = true
+		case "rect", "circle", "ellipse", "polygon":
+			boxes = true
+		}
+	}
+	return boxes && connectors
+}
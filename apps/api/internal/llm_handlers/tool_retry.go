@@ -0,0 +1,99 @@
+package llmHandlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"sync"
+)
+
+// ToolInputError reports tool arguments the model got wrong, such as a missing field or a malformed UUID.
+// Unlike other tool failures it is fed back as structured feedback so the model can correct the call
+type ToolInputError struct {
+	Tool     string
+	Problems []string
+	// CanRetry is false once the model has already had its retry for this tool in the turn
+	CanRetry bool
+}
+
+func (e *ToolInputError) Error() string {
+	return fmt.Sprintf("invalid tool arguments: %s", strings.Join(e.Problems, "; "))
+}
+
+// InvalidToolInput returns a ToolInputError for a handler's argument check
+func InvalidToolInput(format string, args ...interface{}) error {
+	return &ToolInputError{Problems: []string{fmt.Sprintf(format, args...)}, CanRetry: true}
+}
+
+// toolInputRetriesKey is the context key of the retries used in the current turn
+type toolInputRetriesKey struct{}
+
+// toolInputRetries counts the malformed calls per tool so each tool gets one automatic retry per turn
+type toolInputRetries struct {
+	mu       sync.Mutex
+	attempts map[string]int
+}
+
+// WithToolInputRetries starts tracking malformed tool calls for a turn; the retries come out of the
+// turn's normal iteration budget
+func WithToolInputRetries(ctx context.Context) context.Context {
+	if _, ok := ctx.Value(toolInputRetriesKey{}).(*toolInputRetries); ok {
+		return ctx
+	}
+	return context.WithValue(ctx, toolInputRetriesKey{}, &toolInputRetries{attempts: make(map[string]int)})
+}
+
+// allowRetry records a malformed call to the tool and reports whether the model may retry it
+func allowRetry(ctx context.Context, tool string) bool {
+	retries, ok := ctx.Value(toolInputRetriesKey{}).(*toolInputRetries)
+	if !ok {
+		return true
+	}
+	retries.mu.Lock()
+	defer retries.mu.Unlock()
+	retries.attempts[tool]++
+	return retries.attempts[tool] <= 1
+}
+
+// asToolInputError marks err as a ToolInputError for tool, using up its retry, or returns nil for other errors
+func asToolInputError(ctx context.Context, tool string, err error) *ToolInputError {
+	var inputErr *ToolInputError
+	if !errors.As(err, &inputErr) {
+		return nil
+	}
+	inputErr.Tool = tool
+	inputErr.CanRetry = allowRetry(ctx, tool)
+	return inputErr
+}
+
+// toolInputFeedback is the structured tool result sent back for a ToolInputError
+func toolInputFeedback(err *ToolInputError) map[string]interface{} {
+	instruction := fmt.Sprintf("Fix the arguments listed in problems and call %s again.", err.Tool)
+	if !err.CanRetry {
+		instruction = fmt.Sprintf("Do not call %s again this turn. Tell the user briefly what information is missing or invalid instead of showing this error.", err.Tool)
+	}
+	return map[string]interface{}{
+		"error":       "invalid_tool_input",
+		"tool":        err.Tool,
+		"problems":    err.Problems,
+		"retry":       err.CanRetry,
+		"instruction": instruction,
+	}
+}
+
+// toolInputFeedbackText is toolInputFeedback encoded for providers that take tool results as text
+func toolInputFeedbackText(err *ToolInputError) string {
+	b, _ := json.Marshal(toolInputFeedback(err))
+	return string(b)
+}
+
+// toolErrorResponse is the error payload of a failed tool call for providers that take JSON tool results
+func toolErrorResponse(err error) map[string]interface{} {
+	var inputErr *ToolInputError
+	if errors.As(err, &inputErr) {
+		return toolInputFeedback(inputErr)
+	}
+	return map[string]interface{}{"error": err.Error()}
+}
@@ -0,0 +1,45 @@
+package llmHandlers
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func TestExecuteToolsAllowsOneRetryPerToolForMalformedInput(t *testing.T) {
+	RegisterTool("retryTestTool", func(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+		if _, ok := input["boardId"].(string); !ok {
+			return nil, fmt.Errorf("addShape: %w", InvalidToolInput("boardId is required"))
+		}
+		return "ok", nil
+	})
+	defer UnregisterTool("retryTestTool")
+
+	ctx := WithToolInputRetries(context.Background())
+	call := []ToolCall{{ID: "1", Name: "retryTestTool", Input: map[string]interface{}{"x": 1.0}}}
+
+	first := FormatAnthropicToolResult(ExecuteTools(ctx, call, nil)[0])
+	content, _ := first["content"].(string)
+	if !strings.Contains(content, `"retry":true`) || !strings.Contains(content, "boardId is required") {
+		t.Fatalf("first failure should ask for a retry, got %s", content)
+	}
+
+	second := FormatAnthropicToolResult(ExecuteTools(ctx, call, nil)[0])
+	content, _ = second["content"].(string)
+	if !strings.Contains(content, `"retry":false`) {
+		t.Fatalf("second failure should stop retrying, got %s", content)
+	}
+
+	call[0].Input = map[string]interface{}{"boardId": "b1"}
+	if result := ExecuteTools(ctx, call, nil)[0]; result.Error != nil || result.Result != "ok" {
+		t.Fatalf("valid call failed: %+v", result)
+	}
+}
+
+func TestToolErrorResponseKeepsOtherErrorsPlain(t *testing.T) {
+	resp := toolErrorResponse(fmt.Errorf("failed to save shape"))
+	if resp["error"] != "failed to save shape" || len(resp) != 1 {
+		t.Fatalf("unexpected response: %v", resp)
+	}
+}
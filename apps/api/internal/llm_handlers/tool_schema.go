@@ -0,0 +1,284 @@
+package llmHandlers
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+)
+
+// toolSchemas holds the JSON schema of each registered tool's input, used to reject malformed
+// arguments before they reach the handler
+var (
+	toolSchemasMu sync.RWMutex
+	toolSchemas   = make(map[string]map[string]interface{})
+)
+
+// RegisterToolSchema registers the input schema of a tool; calls with arguments that don't match it
+// are answered with a validation error the model can correct instead of running the handler
+func RegisterToolSchema(name string, schema map[string]interface{}) {
+	toolSchemasMu.Lock()
+	defer toolSchemasMu.Unlock()
+	toolSchemas[name] = schema
+}
+
+// UnregisterToolSchema removes the input schema of a tool that is no longer offered
+func UnregisterToolSchema(name string) {
+	toolSchemasMu.Lock()
+	defer toolSchemasMu.Unlock()
+	delete(toolSchemas, name)
+}
+
+func getToolSchema(name string) (map[string]interface{}, bool) {
+	toolSchemasMu.RLock()
+	defer toolSchemasMu.RUnlock()
+	s, ok := toolSchemas[name]
+	return s, ok
+}
+
+// strictUnsupported are the schema keywords OpenAI's strict mode rejects
+var strictUnsupported = []string{"oneOf", "allOf", "not", "patternProperties", "if", "then", "else"}
+
+// StrictSchema converts a tool schema to OpenAI's strict mode, where the provider guarantees the arguments
+// match it: every object closes its properties and lists all of them as required, and optional properties
+// become nullable instead. It returns false for schemas strict mode can't express, such as free-form objects
+func StrictSchema(schema map[string]interface{}) (map[string]interface{}, bool) {
+	if schemaType(schema) != "object" {
+		return nil, false
+	}
+	return strictNode(schema)
+}
+
+func strictNode(schema map[string]interface{}) (map[string]interface{}, bool) {
+	for _, keyword := range strictUnsupported {
+		if _, ok := schema[keyword]; ok {
+			return nil, false
+		}
+	}
+
+	out := make(map[string]interface{}, len(schema)+1)
+	for k, v := range schema {
+		if k == "default" {
+			continue
+		}
+		out[k] = v
+	}
+
+	switch schemaType(schema) {
+	case "object":
+		properties, ok := schema["properties"].(map[string]interface{})
+		if !ok || len(properties) == 0 {
+			return nil, false
+		}
+		if extra, ok := schema["additionalProperties"].(bool); ok && extra {
+			return nil, false
+		}
+		required := make(map[string]bool)
+		for _, name := range requiredNames(schema) {
+			required[name] = true
+		}
+
+		names := make([]string, 0, len(properties))
+		strictProperties := make(map[string]interface{}, len(properties))
+		for name, raw := range properties {
+			prop, ok := raw.(map[string]interface{})
+			if !ok {
+				return nil, false
+			}
+			strictProp, ok := strictNode(prop)
+			if !ok {
+				return nil, false
+			}
+			if !required[name] {
+				strictProp = nullable(strictProp)
+			}
+			strictProperties[name] = strictProp
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		out["properties"] = strictProperties
+		out["required"] = names
+		out["additionalProperties"] = false
+
+	case "array":
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			strictItems, ok := strictNode(items)
+			if !ok {
+				return nil, false
+			}
+			out["items"] = strictItems
+		}
+	}
+	return out, true
+}
+
+// nullable lets an optional property be sent as null, which strict mode uses for "not set"
+func nullable(schema map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(schema))
+	for k, v := range schema {
+		out[k] = v
+	}
+	if t, ok := schema["type"].(string); ok {
+		out["type"] = []string{t, "null"}
+	}
+	switch enum := schema["enum"].(type) {
+	case []string:
+		values := make([]interface{}, 0, len(enum)+1)
+		for _, v := range enum {
+			values = append(values, v)
+		}
+		out["enum"] = append(values, nil)
+	case []interface{}:
+		out["enum"] = append(append([]interface{}{}, enum...), nil)
+	}
+	return out
+}
+
+// DropNullArguments removes null arguments, which strict mode sends for optional parameters that aren't set
+func DropNullArguments(input map[string]interface{}) map[string]interface{} {
+	for k, v := range input {
+		if v == nil {
+			delete(input, k)
+		}
+	}
+	return input
+}
+
+// ValidateToolInput checks tool arguments against the tool's schema: required properties, types and enums
+func ValidateToolInput(schema map[string]interface{}, input map[string]interface{}) error {
+	var problems []string
+	validateValue(schema, input, "", &problems)
+	if len(problems) == 0 {
+		return nil
+	}
+	return &ToolInputError{Problems: problems, CanRetry: true}
+}
+
+func validateValue(schema map[string]interface{}, value interface{}, path string, problems *[]string) {
+	name := path
+	if name == "" {
+		name = "input"
+	}
+
+	switch schemaType(schema) {
+	case "object":
+		obj, ok := value.(map[string]interface{})
+		if !ok {
+			*problems = append(*problems, fmt.Sprintf("%s must be an object", name))
+			return
+		}
+		for _, req := range requiredNames(schema) {
+			if v, ok := obj[req]; !ok || v == nil {
+				*problems = append(*problems, fmt.Sprintf("%s is required", joinPath(path, req)))
+			}
+		}
+		properties, _ := schema["properties"].(map[string]interface{})
+		for key, v := range obj {
+			prop, ok := properties[key].(map[string]interface{})
+			if !ok || v == nil {
+				continue
+			}
+			validateValue(prop, v, joinPath(path, key), problems)
+		}
+		return
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			*problems = append(*problems, fmt.Sprintf("%s must be an array", name))
+			return
+		}
+		if itemSchema, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range items {
+				validateValue(itemSchema, item, fmt.Sprintf("%s[%d]", name, i), problems)
+			}
+		}
+		return
+	case "string":
+		if _, ok := value.(string); !ok {
+			*problems = append(*problems, fmt.Sprintf("%s must be a string", name))
+			return
+		}
+	case "number":
+		if _, ok := value.(float64); !ok {
+			*problems = append(*problems, fmt.Sprintf("%s must be a number", name))
+			return
+		}
+	case "integer":
+		if n, ok := value.(float64); !ok || n != math.Trunc(n) {
+			*problems = append(*problems, fmt.Sprintf("%s must be an integer", name))
+			return
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			*problems = append(*problems, fmt.Sprintf("%s must be a boolean", name))
+			return
+		}
+	}
+
+	if allowed := enumValues(schema); len(allowed) > 0 {
+		for _, a := range allowed {
+			if a == value {
+				return
+			}
+		}
+		*problems = append(*problems, fmt.Sprintf("%s must be one of %v", name, allowed))
+	}
+}
+
+// schemaType returns the schema's type, ignoring "null" in a nullable type list
+func schemaType(schema map[string]interface{}) string {
+	switch t := schema["type"].(type) {
+	case string:
+		return t
+	case []string:
+		for _, v := range t {
+			if v != "null" {
+				return v
+			}
+		}
+	case []interface{}:
+		for _, v := range t {
+			if s, ok := v.(string); ok && s != "null" {
+				return s
+			}
+		}
+	}
+	return ""
+}
+
+func requiredNames(schema map[string]interface{}) []string {
+	switch req := schema["required"].(type) {
+	case []string:
+		return req
+	case []interface{}:
+		names := make([]string, 0, len(req))
+		for _, r := range req {
+			if s, ok := r.(string); ok {
+				names = append(names, s)
+			}
+		}
+		return names
+	}
+	return nil
+}
+
+func enumValues(schema map[string]interface{}) []interface{} {
+	switch enum := schema["enum"].(type) {
+	case []string:
+		values := make([]interface{}, len(enum))
+		for i, v := range enum {
+			values[i] = v
+		}
+		return values
+	case []interface{}:
+		return enum
+	}
+	return nil
+}
+
+func joinPath(path string, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
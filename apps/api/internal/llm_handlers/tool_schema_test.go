@@ -0,0 +1,97 @@
+package llmHandlers
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func testToolSchema() map[string]interface{} {
+	return map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"boardId": map[string]interface{}{"type": "string"},
+			"shapeType": map[string]interface{}{
+				"type": "string",
+				"enum": []string{"rect", "circle"},
+			},
+			"x": map[string]interface{}{"type": "number"},
+			"points": map[string]interface{}{
+				"type":  "array",
+				"items": map[string]interface{}{"type": "number"},
+			},
+		},
+		"required": []string{"boardId", "shapeType"},
+	}
+}
+
+func TestStrictSchemaRequiresEveryPropertyAndNullsOptionalOnes(t *testing.T) {
+	strict, ok := StrictSchema(testToolSchema())
+	if !ok {
+		t.Fatal("expected the schema to convert")
+	}
+	if strict["additionalProperties"] != false {
+		t.Fatal("expected additionalProperties false")
+	}
+	required := strings.Join(strict["required"].([]string), ",")
+	if required != "boardId,points,shapeType,x" {
+		t.Fatalf("required = %s", required)
+	}
+
+	properties := strict["properties"].(map[string]interface{})
+	x := properties["x"].(map[string]interface{})
+	if types, ok := x["type"].([]string); !ok || len(types) != 2 || types[1] != "null" {
+		t.Fatalf("optional property should be nullable, got %v", x["type"])
+	}
+	if properties["boardId"].(map[string]interface{})["type"] != "string" {
+		t.Fatal("required property should stay non-nullable")
+	}
+
+	// the registered schema must not be modified
+	if _, ok := testToolSchema()["additionalProperties"]; ok {
+		t.Fatal("original schema modified")
+	}
+}
+
+func TestStrictSchemaRejectsFreeFormObjects(t *testing.T) {
+	schema := map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"props": map[string]interface{}{"type": "object"},
+		},
+	}
+	if _, ok := StrictSchema(schema); ok {
+		t.Fatal("expected an object without properties to stay non-strict")
+	}
+}
+
+func TestValidateToolInput(t *testing.T) {
+	var valid map[string]interface{}
+	if err := json.Unmarshal([]byte(`{"boardId":"b1","shapeType":"rect","x":12,"points":[1,2]}`), &valid); err != nil {
+		t.Fatal(err)
+	}
+	if err := ValidateToolInput(testToolSchema(), valid); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	var invalid map[string]interface{}
+	if err := json.Unmarshal([]byte(`{"shapeType":"star","x":"12","points":[1,"a"]}`), &invalid); err != nil {
+		t.Fatal(err)
+	}
+	err := ValidateToolInput(testToolSchema(), invalid)
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+	for _, want := range []string{"boardId is required", "shapeType must be one of", "x must be a number", "points[1] must be a number"} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not mention %q", err, want)
+		}
+	}
+}
+
+func TestDropNullArguments(t *testing.T) {
+	input := DropNullArguments(map[string]interface{}{"boardId": "b1", "x": nil})
+	if _, ok := input["x"]; ok || input["boardId"] != "b1" {
+		t.Fatalf("unexpected input: %v", input)
+	}
+}
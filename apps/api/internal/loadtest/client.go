@@ -0,0 +1,347 @@
+package loadtest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"sync"
+	"time"
+
+	"melina-studio-backend/internal/libraries"
+	"melina-studio-backend/internal/models"
+
+	"github.com/fasthttp/websocket"
+	"github.com/google/uuid"
+)
+
+// client is one simulated board session
+type client struct {
+	id   int
+	opts *Options
+	http *http.Client
+	rec  *Recorder
+
+	token   string
+	boardID string
+
+	writeMu sync.Mutex
+	conn    *websocket.Conn
+
+	mu       sync.Mutex
+	pingSent time.Time
+	turnSent time.Time
+	// firstChunk is set once the current chat turn has streamed its first chunk
+	firstChunk bool
+}
+
+func (c *client) run(ctx context.Context) {
+	token, err := c.opts.Token(c.id)
+	if err != nil {
+		c.rec.Error(MetricConnect)
+		return
+	}
+	c.token = token
+
+	started := time.Now()
+	boardID, err := c.createBoard(ctx)
+	if err != nil {
+		c.rec.Error(MetricCreateBoard)
+		return
+	}
+	c.rec.Latency(MetricCreateBoard, time.Since(started))
+	c.boardID = boardID
+	if !c.opts.KeepBoards {
+		defer c.deleteBoard()
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, c.opts.Duration)
+	defer cancel()
+
+	wsAddr, err := wsURL(c.opts.BaseURL, c.token)
+	if err != nil {
+		c.rec.Error(MetricConnect)
+		return
+	}
+	started = time.Now()
+	conn, _, err := websocket.DefaultDialer.DialContext(runCtx, wsAddr, nil)
+	if err != nil {
+		c.rec.Error(MetricConnect)
+		return
+	}
+	c.rec.Latency(MetricConnect, time.Since(started))
+	c.conn = conn
+	defer conn.Close()
+
+	readDone := make(chan struct{})
+	go func() {
+		defer close(readDone)
+		c.read()
+	}()
+
+	chat := newTicker(c.opts.ChatEvery)
+	save := newTicker(c.opts.SaveEvery)
+	ping := newTicker(c.opts.PingEvery)
+	defer chat.stop()
+	defer save.stop()
+	defer ping.stop()
+
+	for {
+		select {
+		case <-runCtx.Done():
+			c.close()
+			<-readDone
+			c.expireTurn(time.Now())
+			return
+		case <-readDone:
+			if runCtx.Err() == nil {
+				c.rec.Count(CounterDisconnects)
+			}
+			return
+		case now := <-ping.c:
+			c.ping(now)
+		case now := <-chat.c:
+			c.chat(now)
+		case <-save.c:
+			c.save(runCtx)
+		}
+	}
+}
+
+// ticker is a time.Ticker whose channel never fires when its interval is 0
+type ticker struct {
+	t *time.Ticker
+	c <-chan time.Time
+}
+
+func newTicker(every time.Duration) ticker {
+	if every <= 0 {
+		return ticker{}
+	}
+	t := time.NewTicker(every)
+	return ticker{t: t, c: t.C}
+}
+
+func (t ticker) stop() {
+	if t.t != nil {
+		t.t.Stop()
+	}
+}
+
+func (c *client) send(msgType libraries.WebSocketMessageType, data interface{}) error {
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+	return c.conn.WriteJSON(libraries.WebSocketMessage{Type: msgType, Data: data})
+}
+
+// close ends the connection the way a browser tab would, which also stops the reader
+func (c *client) close() {
+	c.writeMu.Lock()
+	_ = c.conn.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""), time.Now().Add(time.Second))
+	c.writeMu.Unlock()
+	_ = c.conn.SetReadDeadline(time.Now().Add(2 * time.Second))
+}
+
+func (c *client) ping(now time.Time) {
+	c.mu.Lock()
+	if !c.pingSent.IsZero() {
+		// the last ping is still unanswered; it shows up as a long round trip when it is
+		c.mu.Unlock()
+		return
+	}
+	c.pingSent = now
+	c.mu.Unlock()
+	if err := c.send(libraries.WebSocketMessageTypePing, nil); err != nil {
+		c.rec.Error(MetricPing)
+	}
+}
+
+// chat starts a turn unless the last one is still streaming
+func (c *client) chat(now time.Time) {
+	c.expireTurn(now)
+	c.mu.Lock()
+	if !c.turnSent.IsZero() {
+		c.mu.Unlock()
+		c.rec.Count(CounterChatSkipped)
+		return
+	}
+	c.turnSent = now
+	c.firstChunk = false
+	c.mu.Unlock()
+
+	err := c.send(libraries.WebSocketMessageTypeMessage, libraries.ChatMessagePayload{
+		BoardId:   c.boardID,
+		Message:   fmt.Sprintf("Load test message from client %d", c.id),
+		ModelName: c.opts.Model,
+	})
+	if err != nil {
+		c.endTurn(false)
+	}
+}
+
+// expireTurn gives up on a chat turn that has run past the turn timeout
+func (c *client) expireTurn(now time.Time) {
+	c.mu.Lock()
+	expired := !c.turnSent.IsZero() && now.Sub(c.turnSent) > c.opts.TurnTimeout
+	c.mu.Unlock()
+	if expired {
+		c.endTurn(false)
+	}
+}
+
+// endTurn records the current chat turn as completed or failed
+func (c *client) endTurn(ok bool) {
+	c.mu.Lock()
+	sent := c.turnSent
+	c.turnSent = time.Time{}
+	c.mu.Unlock()
+	if sent.IsZero() {
+		return
+	}
+	if ok {
+		c.rec.Latency(MetricChatDone, time.Since(sent))
+	} else {
+		c.rec.Error(MetricChatDone)
+	}
+}
+
+// read handles the server's messages until the connection closes
+func (c *client) read() {
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			return
+		}
+		c.rec.Received(len(data))
+
+		var msg struct {
+			Type libraries.WebSocketMessageType `json:"type"`
+		}
+		if err := json.Unmarshal(data, &msg); err != nil {
+			continue
+		}
+		switch msg.Type {
+		case libraries.WebSocketMessageTypePong:
+			c.mu.Lock()
+			sent := c.pingSent
+			c.pingSent = time.Time{}
+			c.mu.Unlock()
+			if !sent.IsZero() {
+				c.rec.Latency(MetricPing, time.Since(sent))
+			}
+		case libraries.WebSocketMessageTypeChatResponse:
+			c.mu.Lock()
+			sent, first := c.turnSent, !c.firstChunk
+			c.firstChunk = true
+			c.mu.Unlock()
+			if !sent.IsZero() && first {
+				c.rec.Latency(MetricChatFirst, time.Since(sent))
+			}
+		case libraries.WebSocketMessageTypeChatCompleted:
+			c.endTurn(true)
+		case libraries.WebSocketMessageTypeError:
+			c.rec.Count(CounterChatErrors)
+			c.endTurn(false)
+		case libraries.WebSocketMessageTypeAuthExpiring:
+			c.refreshToken()
+		}
+	}
+}
+
+// refreshToken hands the connection a new access token before the server closes it
+func (c *client) refreshToken() {
+	token, err := c.opts.Token(c.id)
+	if err != nil {
+		return
+	}
+	c.mu.Lock()
+	c.token = token
+	c.mu.Unlock()
+	if err := c.send(libraries.WebSocketMessageTypeAuthRefresh, libraries.AuthRefreshPayload{Token: token}); err == nil {
+		c.rec.Count(CounterTokenRefresh)
+	}
+}
+
+// request sends an authenticated REST request and returns the response body of a 2xx status
+func (c *client) request(ctx context.Context, method string, path string, contentType string, body io.Reader) ([]byte, error) {
+	req, err := http.NewRequestWithContext(ctx, method, c.opts.BaseURL+"/api/v1"+path, body)
+	if err != nil {
+		return nil, err
+	}
+	c.mu.Lock()
+	req.Header.Set("Authorization", "Bearer "+c.token)
+	c.mu.Unlock()
+	if contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return nil, fmt.Errorf("%s %s: status %d: %s", method, path, resp.StatusCode, respBody)
+	}
+	return respBody, nil
+}
+
+// createBoard creates the sandbox board the client works on; sandboxes stay out of the user's
+// board list and quota, and expire if the run is interrupted before it deletes them
+func (c *client) createBoard(ctx context.Context) (string, error) {
+	body, _ := json.Marshal(map[string]string{"title": fmt.Sprintf("Load test %d", c.id)})
+	respBody, err := c.request(ctx, http.MethodPost, "/boards/sandbox", "application/json", bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	var resp struct {
+		UUID string `json:"uuid"`
+	}
+	if err := json.Unmarshal(respBody, &resp); err != nil {
+		return "", err
+	}
+	return resp.UUID, nil
+}
+
+func (c *client) deleteBoard() {
+	ctx, cancel := context.WithTimeout(context.Background(), 30*time.Second)
+	defer cancel()
+	_, _ = c.request(ctx, http.MethodDelete, "/boards/"+c.boardID+"/delete", "", nil)
+}
+
+// save stores a board of rectangles the way the canvas autosave does
+func (c *client) save(ctx context.Context) {
+	shapes := make([]models.Shape, c.opts.Shapes)
+	for i := range shapes {
+		x, y, w, h := float64(40+(i%10)*120), float64(40+(i/10)*120), 100.0, 80.0
+		shapes[i] = models.Shape{ID: uuid.NewString(), Type: "rect", X: &x, Y: &y, W: &w, H: &h}
+	}
+	boardData, err := json.Marshal(shapes)
+	if err != nil {
+		c.rec.Error(MetricSave)
+		return
+	}
+
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	if err := form.WriteField("boardData", string(boardData)); err != nil {
+		c.rec.Error(MetricSave)
+		return
+	}
+	form.Close()
+
+	started := time.Now()
+	if _, err := c.request(ctx, http.MethodPost, "/boards/"+c.boardID+"/save", form.FormDataContentType(), &body); err != nil {
+		if ctx.Err() == nil {
+			c.rec.Error(MetricSave)
+		}
+		return
+	}
+	c.rec.Latency(MetricSave, time.Since(started))
+}
@@ -0,0 +1,130 @@
+// Package loadtest simulates concurrent board sessions against a running API: every client opens a
+// WebSocket, chats with the agent and saves shapes over REST, and the latencies are reported as percentiles.
+// Point it at a staging instance with LLM_FAKE_MODEL on, so the chat traffic doesn't reach a provider
+package loadtest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Counters reported next to the latencies
+const (
+	CounterDisconnects  = "disconnects"
+	CounterChatSkipped  = "chat_turns_skipped"
+	CounterChatErrors   = "chat_error_messages"
+	CounterTokenRefresh = "token_refreshes"
+)
+
+// Options configure a load test run
+type Options struct {
+	// BaseURL is the API's address, e.g. https://staging.example.com
+	BaseURL string
+	// Clients is how many board sessions run at once
+	Clients int
+	// Duration is how long every client keeps sending traffic
+	Duration time.Duration
+	// RampUp spreads the clients' start over this long
+	RampUp time.Duration
+	// ChatEvery, SaveEvery and PingEvery space each client's chat turns, shape saves and pings; 0 turns them off
+	ChatEvery time.Duration
+	SaveEvery time.Duration
+	PingEvery time.Duration
+	// Model is the model the chat turns ask for; the fake model keeps them off the providers
+	Model string
+	// Shapes is how many shapes each save sends
+	Shapes int
+	// TurnTimeout gives up on a chat turn that hasn't completed
+	TurnTimeout time.Duration
+	// KeepBoards leaves the clients' sandbox boards in place, to inspect them after the run
+	KeepBoards bool
+	// Token returns an access token for client i; it is called again when the server asks for a refresh
+	Token func(i int) (string, error)
+}
+
+func (o *Options) defaults() error {
+	if o.BaseURL == "" {
+		return errors.New("base URL is required")
+	}
+	if o.Token == nil {
+		return errors.New("a token source is required")
+	}
+	if o.Clients <= 0 {
+		o.Clients = 1
+	}
+	if o.Duration <= 0 {
+		o.Duration = time.Minute
+	}
+	if o.Model == "" {
+		o.Model = "fake"
+	}
+	if o.Shapes <= 0 {
+		o.Shapes = 20
+	}
+	if o.TurnTimeout <= 0 {
+		o.TurnTimeout = 2 * time.Minute
+	}
+	o.BaseURL = strings.TrimRight(o.BaseURL, "/")
+	return nil
+}
+
+// wsURL turns the API's address into the WebSocket endpoint's
+func wsURL(baseURL string, token string) (string, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return "", fmt.Errorf("invalid base URL: %w", err)
+	}
+	switch u.Scheme {
+	case "https":
+		u.Scheme = "wss"
+	case "http":
+		u.Scheme = "ws"
+	default:
+		return "", fmt.Errorf("invalid base URL scheme %q", u.Scheme)
+	}
+	u.Path = strings.TrimRight(u.Path, "/") + "/api/v1/ws"
+	u.RawQuery = url.Values{"token": {token}}.Encode()
+	return u.String(), nil
+}
+
+// Run starts the clients, waits for them to finish and reports what they measured
+func Run(ctx context.Context, opts Options) (*Report, error) {
+	if err := opts.defaults(); err != nil {
+		return nil, err
+	}
+	if _, err := wsURL(opts.BaseURL, ""); err != nil {
+		return nil, err
+	}
+
+	recorder := NewRecorder()
+	httpClient := &http.Client{Timeout: 30 * time.Second}
+
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < opts.Clients; i++ {
+		delay := time.Duration(0)
+		if opts.RampUp > 0 && opts.Clients > 1 {
+			delay = opts.RampUp * time.Duration(i) / time.Duration(opts.Clients-1)
+		}
+		wg.Add(1)
+		go func(i int, delay time.Duration) {
+			defer wg.Done()
+			select {
+			case <-ctx.Done():
+				return
+			case <-time.After(delay):
+			}
+			c := &client{id: i, opts: &opts, http: httpClient, rec: recorder}
+			c.run(ctx)
+		}(i, delay)
+	}
+	wg.Wait()
+
+	return recorder.Report(opts.Clients, time.Since(start)), nil
+}
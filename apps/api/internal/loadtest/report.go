@@ -0,0 +1,159 @@
+package loadtest
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Metrics measured by the load test
+const (
+	MetricCreateBoard = "create_board"
+	MetricConnect     = "connect"
+	MetricPing        = "ping"
+	MetricChatFirst   = "chat_first_chunk"
+	MetricChatDone    = "chat_completed"
+	MetricSave        = "shape_save"
+)
+
+// metricOrder is the order metrics are printed in
+var metricOrder = []string{MetricCreateBoard, MetricConnect, MetricPing, MetricChatFirst, MetricChatDone, MetricSave}
+
+// Recorder collects latencies and counters from every simulated client
+type Recorder struct {
+	mu        sync.Mutex
+	latencies map[string][]time.Duration
+	errors    map[string]int
+	counters  map[string]int
+	received  int
+	bytes     int64
+}
+
+func NewRecorder() *Recorder {
+	return &Recorder{
+		latencies: make(map[string][]time.Duration),
+		errors:    make(map[string]int),
+		counters:  make(map[string]int),
+	}
+}
+
+// Latency records how long one operation of the metric took
+func (r *Recorder) Latency(metric string, d time.Duration) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.latencies[metric] = append(r.latencies[metric], d)
+}
+
+// Error records a failed operation of the metric
+func (r *Recorder) Error(metric string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.errors[metric]++
+}
+
+// Count bumps a named counter, e.g. disconnects
+func (r *Recorder) Count(name string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.counters[name]++
+}
+
+// Received records a message the server sent a client
+func (r *Recorder) Received(size int) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.received++
+	r.bytes += int64(size)
+}
+
+// LatencyStats summarizes the samples of one metric
+type LatencyStats struct {
+	Metric string        `json:"metric"`
+	Count  int           `json:"count"`
+	Errors int           `json:"errors"`
+	P50    time.Duration `json:"p50"`
+	P90    time.Duration `json:"p90"`
+	P99    time.Duration `json:"p99"`
+	Max    time.Duration `json:"max"`
+}
+
+// Report is the outcome of a run
+type Report struct {
+	Clients  int            `json:"clients"`
+	Duration time.Duration  `json:"duration"`
+	Metrics  []LatencyStats `json:"metrics"`
+	Counters map[string]int `json:"counters"`
+	// Received is every message the server pushed to the clients; over Duration it is the hub's delivered throughput
+	Received      int     `json:"received"`
+	ReceivedBytes int64   `json:"received_bytes"`
+	MessagesPerS  float64 `json:"messages_per_second"`
+}
+
+// Report summarizes what was recorded over a run of the given length
+func (r *Recorder) Report(clients int, elapsed time.Duration) *Report {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	report := &Report{
+		Clients:       clients,
+		Duration:      elapsed,
+		Counters:      make(map[string]int, len(r.counters)),
+		Received:      r.received,
+		ReceivedBytes: r.bytes,
+	}
+	for name, n := range r.counters {
+		report.Counters[name] = n
+	}
+	if elapsed > 0 {
+		report.MessagesPerS = float64(r.received) / elapsed.Seconds()
+	}
+	for _, metric := range metricOrder {
+		samples := r.latencies[metric]
+		if len(samples) == 0 && r.errors[metric] == 0 {
+			continue
+		}
+		sorted := append([]time.Duration(nil), samples...)
+		sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+		stats := LatencyStats{Metric: metric, Count: len(sorted), Errors: r.errors[metric]}
+		if len(sorted) > 0 {
+			stats.P50 = percentile(sorted, 50)
+			stats.P90 = percentile(sorted, 90)
+			stats.P99 = percentile(sorted, 99)
+			stats.Max = sorted[len(sorted)-1]
+		}
+		report.Metrics = append(report.Metrics, stats)
+	}
+	return report
+}
+
+// percentile returns the nearest-rank percentile of sorted samples
+func percentile(sorted []time.Duration, p int) time.Duration {
+	rank := (p*len(sorted) + 99) / 100
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// Print writes the report as a table
+func (r *Report) Print(w io.Writer) {
+	fmt.Fprintf(w, "%d clients for %s\n\n", r.Clients, r.Duration.Round(time.Second))
+	fmt.Fprintf(w, "%-18s %8s %7s %10s %10s %10s %10s\n", "metric", "count", "errors", "p50", "p90", "p99", "max")
+	for _, m := range r.Metrics {
+		fmt.Fprintf(w, "%-18s %8d %7d %10s %10s %10s %10s\n", m.Metric, m.Count, m.Errors,
+			m.P50.Round(time.Microsecond*100), m.P90.Round(time.Microsecond*100), m.P99.Round(time.Microsecond*100), m.Max.Round(time.Microsecond*100))
+	}
+	fmt.Fprintf(w, "\nhub throughput: %d messages (%.1f MB) delivered, %.1f messages/s\n", r.Received, float64(r.ReceivedBytes)/(1<<20), r.MessagesPerS)
+	if len(r.Counters) > 0 {
+		names := make([]string, 0, len(r.Counters))
+		for name := range r.Counters {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			fmt.Fprintf(w, "%s: %d\n", name, r.Counters[name])
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package loadtest
+
+import (
+	"testing"
+	"time"
+)
+
+func TestPercentile(t *testing.T) {
+	samples := make([]time.Duration, 100)
+	for i := range samples {
+		samples[i] = time.Duration(i+1) * time.Millisecond
+	}
+	cases := map[int]time.Duration{50: 50 * time.Millisecond, 90: 90 * time.Millisecond, 99: 99 * time.Millisecond, 100: 100 * time.Millisecond}
+	for p, want := range cases {
+		if got := percentile(samples, p); got != want {
+			t.Errorf("p%d = %s, want %s", p, got, want)
+		}
+	}
+	if got := percentile([]time.Duration{7 * time.Millisecond}, 50); got != 7*time.Millisecond {
+		t.Errorf("p50 of one sample = %s, want 7ms", got)
+	}
+}
+
+func TestRecorderReport(t *testing.T) {
+	rec := NewRecorder()
+	rec.Latency(MetricPing, 3*time.Millisecond)
+	rec.Latency(MetricPing, time.Millisecond)
+	rec.Latency(MetricPing, 2*time.Millisecond)
+	rec.Error(MetricSave)
+	rec.Received(100)
+	rec.Received(300)
+	rec.Count(CounterDisconnects)
+
+	report := rec.Report(2, 2*time.Second)
+	if len(report.Metrics) != 2 {
+		t.Fatalf("got %d metrics, want ping and shape_save", len(report.Metrics))
+	}
+	ping := report.Metrics[0]
+	if ping.Metric != MetricPing || ping.Count != 3 || ping.P50 != 2*time.Millisecond || ping.Max != 3*time.Millisecond {
+		t.Errorf("unexpected ping stats %+v", ping)
+	}
+	if save := report.Metrics[1]; save.Metric != MetricSave || save.Errors != 1 || save.Count != 0 {
+		t.Errorf("unexpected save stats %+v", save)
+	}
+	if report.Received != 2 || report.ReceivedBytes != 400 || report.MessagesPerS != 1 {
+		t.Errorf("unexpected throughput %d messages, %d bytes, %.1f/s", report.Received, report.ReceivedBytes, report.MessagesPerS)
+	}
+	if report.Counters[CounterDisconnects] != 1 {
+		t.Errorf("disconnects = %d, want 1", report.Counters[CounterDisconnects])
+	}
+}
+
+func TestWSURL(t *testing.T) {
+	got, err := wsURL("https://staging.example.com/", "abc")
+	if err != nil || got != "wss://staging.example.com/api/v1/ws?token=abc" {
+		t.Errorf("wsURL = %q, %v", got, err)
+	}
+	if _, err := wsURL("staging.example.com", "abc"); err == nil {
+		t.Error("expected an error for a URL without a scheme")
+	}
+}
@@ -4,7 +4,7 @@ import (
 	"context"
 	"fmt"
 	"log"
-	"os"
+	"melina-studio-backend/internal/config"
 
 	"melina-studio-backend/internal/constants"
 	"melina-studio-backend/internal/libraries"
@@ -18,11 +18,69 @@ import (
 type Agent struct {
 	llmClient llmHandlers.Client
 	loaderGen *llmHandlers.LoaderGenerator
+	verifier  *DrawingVerifier          // optional vision check of the turn's drawing
+	profile   *llmHandlers.AgentProfile // nil means every tool
+	// confirmDestructive makes destructive tools wait for the user's approval
+	confirmDestructive bool
+	// textOnly models get selections described as text instead of the selection images
+	textOnly bool
+}
+
+// RequireToolApproval makes deleteShape and renameBoard ask the user before they run in this agent's turns
+func (a *Agent) RequireToolApproval() {
+	a.confirmDestructive = true
 }
 
 // NewAgentWithModel creates an agent using the model registry info
 // This is the preferred method as it uses validated model configurations
-func NewAgentWithModel(modelInfo *llmHandlers.ModelInfo, temperature *float32, maxTokens *int, loaderGen *llmHandlers.LoaderGenerator) *Agent {
+// The agent only gets the tools of the given profile
+func NewAgentWithModel(modelInfo *llmHandlers.ModelInfo, temperature *float32, maxTokens *int, loaderGen *llmHandlers.LoaderGenerator, profile *llmHandlers.AgentProfile) *Agent {
+	cfg := providerConfig(modelInfo, temperature, maxTokens)
+	cfg.Tools = profile.FilterTools(cfg.Tools)
+
+	llmClient, err := llmHandlers.New(cfg)
+	if err != nil {
+		log.Fatalf("Failed to initialize LLM client (%s/%s): %v", modelInfo.Provider, modelInfo.ModelID, err)
+	}
+
+	return &Agent{
+		llmClient: llmClient,
+		loaderGen: loaderGen,
+		profile:   profile,
+		textOnly:  modelInfo.TextOnly,
+	}
+}
+
+// NewToolAgent creates an agent with the board tools of the given profile, reporting client
+// errors instead of exiting, for runs outside of the server such as the agent evals
+func NewToolAgent(modelInfo *llmHandlers.ModelInfo, temperature *float32, maxTokens *int, profile *llmHandlers.AgentProfile) (*Agent, error) {
+	cfg := providerConfig(modelInfo, temperature, maxTokens)
+	cfg.Tools = profile.FilterTools(cfg.Tools)
+
+	llmClient, err := llmHandlers.New(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize LLM client (%s/%s): %w", modelInfo.Provider, modelInfo.ModelID, err)
+	}
+
+	return &Agent{llmClient: llmClient, profile: profile, textOnly: modelInfo.TextOnly}, nil
+}
+
+// NewTextAgent creates an agent without board tools, for plain text generation
+// (summaries, exports) that runs outside of a websocket chat turn
+func NewTextAgent(modelInfo *llmHandlers.ModelInfo, temperature *float32, maxTokens *int) (*Agent, error) {
+	cfg := providerConfig(modelInfo, temperature, maxTokens)
+	cfg.Tools = nil
+
+	llmClient, err := llmHandlers.New(cfg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize LLM client (%s/%s): %w", modelInfo.Provider, modelInfo.ModelID, err)
+	}
+
+	return &Agent{llmClient: llmClient, textOnly: modelInfo.TextOnly}, nil
+}
+
+// providerConfig builds the LLM client config for a registry model, including the board tools
+func providerConfig(modelInfo *llmHandlers.ModelInfo, temperature *float32, maxTokens *int) llmHandlers.Config {
 	var cfg llmHandlers.Config
 
 	switch modelInfo.Provider {
@@ -30,7 +88,7 @@ func NewAgentWithModel(modelInfo *llmHandlers.ModelInfo, temperature *float32, m
 		cfg = llmHandlers.Config{
 			Provider:    llmHandlers.ProviderOpenAI,
 			Model:       modelInfo.ModelID,
-			APIKey:      os.Getenv("OPENAI_API_KEY"),
+			APIKey:      config.GetSettings().LLM.OpenAIAPIKey,
 			Tools:       tools.GetOpenAITools(),
 			Temperature: temperature,
 			MaxTokens:   maxTokens,
@@ -40,8 +98,8 @@ func NewAgentWithModel(modelInfo *llmHandlers.ModelInfo, temperature *float32, m
 		cfg = llmHandlers.Config{
 			Provider:    llmHandlers.ProviderLangChainGroq,
 			Model:       modelInfo.ModelID,
-			BaseURL:     os.Getenv("GROQ_BASE_URL"),
-			APIKey:      os.Getenv("GROQ_API_KEY"),
+			BaseURL:     config.GetSettings().LLM.GroqBaseURL,
+			APIKey:      config.GetSettings().LLM.GroqAPIKey,
 			Tools:       tools.GetGroqTools(),
 			Temperature: temperature,
 			MaxTokens:   maxTokens,
@@ -74,19 +132,86 @@ func NewAgentWithModel(modelInfo *llmHandlers.ModelInfo, temperature *float32, m
 			MaxTokens:   maxTokens,
 		}
 
+	case llmHandlers.ProviderDeepSeek, llmHandlers.ProviderMistral:
+		cfg = llmHandlers.Config{
+			Provider:    modelInfo.Provider,
+			Model:       modelInfo.ModelID,
+			Tools:       tools.GetOpenAITools(), // both speak the OpenAI function-calling format
+			Temperature: temperature,
+			MaxTokens:   maxTokens,
+		}
+
+	case llmHandlers.ProviderOllama:
+		cfg = llmHandlers.Config{
+			Provider:    llmHandlers.ProviderOllama,
+			Model:       modelInfo.ModelID,
+			Tools:       tools.GetOpenAITools(), // Ollama is OpenAI-compatible; dropped for models without tool support
+			Temperature: temperature,
+			MaxTokens:   maxTokens,
+		}
+
+	case llmHandlers.ProviderBedrock:
+		cfg = llmHandlers.Config{
+			Provider:    llmHandlers.ProviderBedrock,
+			Model:       modelInfo.ModelID,         // e.g., "anthropic.claude-sonnet-4-5-20250929-v1:0"
+			Tools:       tools.GetAnthropicTools(), // converted to Converse toolSpec by the client
+			Temperature: temperature,
+			MaxTokens:   maxTokens,
+		}
+
+	case llmHandlers.ProviderFake:
+		// the fake model never calls tools, so it gets none
+		cfg = llmHandlers.Config{
+			Provider: llmHandlers.ProviderFake,
+			Model:    modelInfo.ModelID,
+		}
+
+	case llmHandlers.ProviderMock:
+		cfg = llmHandlers.Config{
+			Provider: llmHandlers.ProviderMock,
+			Model:    modelInfo.ModelID,
+			Tools:    tools.GetOpenAITools(), // only used to check the scenario's tool names
+		}
+
 	default:
 		log.Fatalf("Unknown provider: %s", modelInfo.Provider)
 	}
 
-	llmClient, err := llmHandlers.New(cfg)
+	return cfg
+}
+
+// Complete runs a single non-streaming completion with a custom system prompt
+func (a *Agent) Complete(ctx context.Context, systemMessage string, message string) (string, error) {
+	messages := []llmHandlers.Message{
+		{
+			Role:    models.RoleUser,
+			Content: message,
+		},
+	}
+
+	response, err := a.llmClient.Chat(ctx, systemMessage, messages, false)
 	if err != nil {
-		log.Fatalf("Failed to initialize LLM client (%s/%s): %v", modelInfo.Provider, modelInfo.ModelID, err)
+		return "", fmt.Errorf("LLM chat error: %w", err)
 	}
 
-	return &Agent{
-		llmClient: llmClient,
-		loaderGen: loaderGen,
+	return response, nil
+}
+
+// CompleteStream runs a single completion with a custom system prompt, streaming chunks to the client
+func (a *Agent) CompleteStream(ctx context.Context, hub *libraries.Hub, client *libraries.Client, boardId string, systemMessage string, message string) (string, error) {
+	messages := []llmHandlers.Message{
+		{
+			Role:    models.RoleUser,
+			Content: message,
+		},
+	}
+
+	response, err := a.llmClient.ChatStream(ctx, hub, client, boardId, systemMessage, messages, false)
+	if err != nil {
+		return "", fmt.Errorf("LLM chat stream error: %w", err)
 	}
+
+	return response, nil
 }
 
 // ProcessRequest processes a user message with optional board image
@@ -144,7 +269,10 @@ func (a *Agent) ProcessRequestStream(
 	var userContent interface{}
 
 	// Check if we have annotated selections to include
-	if annotatedSelections, ok := selections.([]helpers.AnnotatedSelection); ok && len(annotatedSelections) > 0 {
+	if a.textOnly {
+		// The model cannot view images, so the selection is described in the text
+		userContent = buildTextOnlyContent(message, selections, uploadedImages)
+	} else if annotatedSelections, ok := selections.([]helpers.AnnotatedSelection); ok && len(annotatedSelections) > 0 {
 		// Build multimodal content with annotated images, gotoon data, and uploaded images
 		userContent = helpers.BuildMultimodalContentWithAnnotations(message, annotatedSelections, uploadedImages)
 		log.Printf("Built multimodal content with %d annotated selections and %d uploaded images", len(annotatedSelections), len(uploadedImages))
@@ -196,10 +324,15 @@ func (a *Agent) ProcessRequestStreamWithUsage(
 	uploadedImages []helpers.UploadedImage,
 	enableThinking bool,
 	canvasStateXML string,
-	customRules string) (*llmHandlers.ResponseWithUsage, error) {
+	customRules string,
+	facilitationContext string,
+	referenceContext string) (*llmHandlers.ResponseWithUsage, error) {
 
 	// Build messages for the LLM
 	systemMessage := fmt.Sprintf(prompts.MASTER_PROMPT, boardId, activeTheme)
+	if a.profile != nil && a.profile.Instructions != "" {
+		systemMessage += "\n\n" + a.profile.Instructions
+	}
 
 	// Prepend canvas state to user message if available
 	// This gives the LLM spatial awareness of existing shapes
@@ -214,11 +347,25 @@ func (a *Agent) ProcessRequestStreamWithUsage(
 		log.Printf("Prepended custom rules to message (%d chars)", len(customRules))
 	}
 
+	// Referenced boards are background material for this turn only
+	if referenceContext != "" {
+		effectiveMessage = referenceContext + "\n\n" + effectiveMessage
+		log.Printf("Prepended referenced boards to message (%d chars)", len(referenceContext))
+	}
+
+	// Facilitation instructions go first so the current step drives the whole turn
+	if facilitationContext != "" {
+		effectiveMessage = facilitationContext + "\n\n" + effectiveMessage
+		log.Printf("Prepended facilitation context to message (%d chars)", len(facilitationContext))
+	}
+
 	// Build user message content - may include annotated images if selections provided
 	var userContent interface{}
 
 	// Check if we have annotated selections to include
-	if annotatedSelections, ok := selections.([]helpers.AnnotatedSelection); ok && len(annotatedSelections) > 0 {
+	if a.textOnly {
+		userContent = buildTextOnlyContent(effectiveMessage, selections, uploadedImages)
+	} else if annotatedSelections, ok := selections.([]helpers.AnnotatedSelection); ok && len(annotatedSelections) > 0 {
 		userContent = helpers.BuildMultimodalContentWithAnnotations(effectiveMessage, annotatedSelections, uploadedImages)
 		log.Printf("Built multimodal content with %d annotated selections and %d uploaded images", len(annotatedSelections), len(uploadedImages))
 	} else if images, ok := selections.([]helpers.ShapeImage); ok && len(images) > 0 {
@@ -249,9 +396,16 @@ func (a *Agent) ProcessRequestStreamWithUsage(
 	}
 
 	ctx = context.WithValue(ctx, constants.MaxIterationsKey, constants.DefaultMaxIterations)
+	ctx, activity := llmHandlers.WithToolActivity(ctx)
+	if a.profile != nil {
+		ctx = llmHandlers.WithAgentProfile(ctx, a.profile)
+	}
+	if a.confirmDestructive {
+		ctx = llmHandlers.WithToolApprovals(ctx)
+	}
 
 	// Call the LLM with usage tracking
-	resp, err := a.llmClient.ChatStreamWithUsage(llmHandlers.ChatStreamRequest{
+	req := llmHandlers.ChatStreamRequest{
 		Ctx:            ctx,
 		Hub:            hub,
 		Client:         client,
@@ -260,10 +414,16 @@ func (a *Agent) ProcessRequestStreamWithUsage(
 		Messages:       messages,
 		EnableThinking: enableThinking,
 		LoaderGen:      a.loaderGen,
-	})
+	}
+	resp, err := a.llmClient.ChatStreamWithUsage(req)
 	if err != nil {
 		return nil, fmt.Errorf("LLM chat error: %w", err)
 	}
 
+	// Check what was drawn against the request when the user turned verification on
+	if a.verifier != nil && tools.ChangedDrawing(activity.Results()) {
+		resp = a.verifyDrawing(ctx, req, message, resp, activity)
+	}
+
 	return resp, nil
 }
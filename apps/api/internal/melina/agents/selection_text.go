@@ -0,0 +1,76 @@
+package agents
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"melina-studio-backend/internal/melina/helpers"
+	"melina-studio-backend/internal/melina/tools"
+	"melina-studio-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// buildTextOnlyContent is the user message for a model without image input: the selection images
+// are replaced by a description of the selected shapes, and dropped uploads are mentioned
+func buildTextOnlyContent(message string, selections interface{}, uploadedImages []helpers.UploadedImage) string {
+	var metadata []string
+	var shapes []helpers.ShapeImage
+	if annotatedSelections, ok := selections.([]helpers.AnnotatedSelection); ok {
+		for _, sel := range annotatedSelections {
+			if sel.ShapeMetadata != "" {
+				metadata = append(metadata, sel.ShapeMetadata)
+			}
+			shapes = append(shapes, sel.Shapes...)
+		}
+	} else if images, ok := selections.([]helpers.ShapeImage); ok {
+		shapes = images
+	}
+
+	var parts []string
+	if len(shapes) > 0 {
+		contextText := "The user has selected shapes on the canvas. This model cannot view images, so the selection is described below; shapes are referred to by their badge number."
+		if len(metadata) > 0 {
+			contextText += "\n\nShape data (use shapeIds with updateShape tool):\n" + strings.Join(metadata, "\n\n")
+		}
+		if description := tools.DescribeSelection(selectedShapes(shapes)); description != "" {
+			contextText += "\n\nSelected shapes:\n" + description
+		}
+		parts = append(parts, contextText)
+	}
+	if len(uploadedImages) > 0 {
+		parts = append(parts, fmt.Sprintf("The user also attached %d reference image(s), which this model cannot view. Ask them to describe the images if they matter for the request.", len(uploadedImages)))
+	}
+	if len(parts) == 0 {
+		return message
+	}
+
+	log.Printf("Described %d selected shapes as text for a model without image input (%d uploaded images dropped)", len(shapes), len(uploadedImages))
+	return strings.Join(append(parts, message), "\n\n")
+}
+
+// selectedShapes turns the shape data of the selection images back into board shapes
+func selectedShapes(images []helpers.ShapeImage) []tools.SelectedShape {
+	selected := make([]tools.SelectedShape, 0, len(images))
+	for _, img := range images {
+		if img.ShapeData == nil {
+			continue
+		}
+		shapeType, _ := img.ShapeData["type"].(string)
+		if shapeType == "" || shapeType == "unknown" {
+			continue
+		}
+		data, err := json.Marshal(img.ShapeData)
+		if err != nil {
+			continue
+		}
+		shape := models.BoardData{Type: models.Type(shapeType), Data: data}
+		if id, err := uuid.Parse(img.ShapeId); err == nil {
+			shape.UUID = id
+		}
+		selected = append(selected, tools.SelectedShape{Number: img.Number, Shape: shape})
+	}
+	return selected
+}
@@ -0,0 +1,186 @@
+package agents
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"github.com/google/uuid"
+
+	"melina-studio-backend/internal/config"
+	"melina-studio-backend/internal/libraries"
+	llmHandlers "melina-studio-backend/internal/llm_handlers"
+	"melina-studio-backend/internal/melina/helpers"
+	"melina-studio-backend/internal/melina/prompts"
+	"melina-studio-backend/internal/melina/tools"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+)
+
+// snapshotImageTokens approximates what the board snapshot costs as vision input; text is counted with tiktoken
+const snapshotImageTokens = 1000
+
+// DrawingVerifier checks the board after the agent draws on it, using a cheap vision model
+type DrawingVerifier struct {
+	agent     *Agent
+	modelName string
+	usage     *llmHandlers.TokenUsage
+}
+
+// Verification is the vision model's verdict on a drawing
+type Verification struct {
+	Matches bool     `json:"matches"`
+	Issues  []string `json:"issues"`
+}
+
+// NewDrawingVerifier creates a verifier with the configured verification model
+func NewDrawingVerifier() (*DrawingVerifier, error) {
+	modelName := config.GetSettings().LLM.VerificationModel
+	modelInfo, err := llmHandlers.ValidateModel(modelName)
+	if err != nil {
+		return nil, fmt.Errorf("invalid verification model %s: %w", modelName, err)
+	}
+
+	temperature := float32(0)
+	agent, err := NewTextAgent(modelInfo, &temperature, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &DrawingVerifier{agent: agent, modelName: modelName}, nil
+}
+
+// Usage returns the verification model and the tokens it used in this turn, nil when it didn't run
+func (v *DrawingVerifier) Usage() (string, *llmHandlers.TokenUsage) {
+	return v.modelName, v.usage
+}
+
+// Verify asks the vision model whether the board snapshot matches the user's request
+func (v *DrawingVerifier) Verify(ctx context.Context, request string, snapshotBase64 string) (*Verification, error) {
+	imageData, err := base64.StdEncoding.DecodeString(snapshotBase64)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode board snapshot: %w", err)
+	}
+
+	text := fmt.Sprintf("User request:\n%s\n\nThe board after the assistant's changes:", request)
+	messages := []llmHandlers.Message{
+		{
+			Role:    models.RoleUser,
+			Content: helpers.FormatMessageWithImage(text, imageData),
+		},
+	}
+
+	reply, err := v.agent.llmClient.Chat(ctx, prompts.VERIFICATION_PROMPT, messages, false)
+	if err != nil {
+		return nil, fmt.Errorf("verification call failed: %w", err)
+	}
+	v.addUsage(prompts.VERIFICATION_PROMPT+text, reply)
+
+	return parseVerification(reply)
+}
+
+// addUsage estimates the tokens of a verification call, since Chat doesn't report usage
+func (v *DrawingVerifier) addUsage(input string, output string) {
+	inputTokens, _ := libraries.CountTokens(input, v.modelName)
+	outputTokens, _ := libraries.CountTokens(output, v.modelName)
+	inputTokens += snapshotImageTokens
+
+	if v.usage == nil {
+		v.usage = &llmHandlers.TokenUsage{CountingMethod: "tiktoken"}
+	}
+	v.usage.InputTokens += inputTokens
+	v.usage.OutputTokens += outputTokens
+	v.usage.TotalTokens += inputTokens + outputTokens
+}
+
+// parseVerification reads the JSON verdict, tolerating code fences or text around it
+func parseVerification(reply string) (*Verification, error) {
+	start := strings.Index(reply, "{")
+	end := strings.LastIndex(reply, "}")
+	if start < 0 || end < start {
+		return nil, fmt.Errorf("verification reply has no JSON object: %q", reply)
+	}
+
+	var verification Verification
+	if err := json.Unmarshal([]byte(reply[start:end+1]), &verification); err != nil {
+		return nil, fmt.Errorf("failed to parse verification reply: %w", err)
+	}
+	return &verification, nil
+}
+
+// SetVerifier turns on the vision check of drawings for this agent's turns
+func (a *Agent) SetVerifier(verifier *DrawingVerifier) {
+	a.verifier = verifier
+}
+
+// verifyDrawing checks the board after a turn that drew on it and runs one corrective pass when the
+// drawing doesn't match the request. Verification problems are logged and the original response kept
+func (a *Agent) verifyDrawing(ctx context.Context, req llmHandlers.ChatStreamRequest, request string, resp *llmHandlers.ResponseWithUsage, activity *llmHandlers.ToolActivity) *llmHandlers.ResponseWithUsage {
+	boardID, err := uuid.Parse(req.BoardID)
+	if err != nil {
+		return resp
+	}
+	stored, err := repo.NewBoardDataRepository(config.DB).GetBoardData(boardID)
+	if err != nil {
+		log.Printf("[verification] failed to get board data: %v", err)
+		return resp
+	}
+	snapshot, err := tools.RenderBoardSnapshot(tools.SnapshotShapes(stored, activity.CreatedShapes()))
+	if err != nil {
+		log.Printf("[verification] failed to render board snapshot: %v", err)
+		return resp
+	}
+
+	verification, err := a.verifier.Verify(ctx, request, snapshot)
+	if err != nil {
+		log.Printf("[verification] %v", err)
+		return resp
+	}
+	if verification.Matches || len(verification.Issues) == 0 {
+		log.Printf("[verification] drawing on board %s matches the request", req.BoardID)
+		return resp
+	}
+	log.Printf("[verification] drawing on board %s has %d issues, running a corrective pass", req.BoardID, len(verification.Issues))
+
+	issues := make([]string, len(verification.Issues))
+	for i, issue := range verification.Issues {
+		issues[i] = "- " + issue
+	}
+	req.Messages = append(append([]llmHandlers.Message(nil), req.Messages...),
+		llmHandlers.Message{Role: models.RoleAssistant, Content: resp.Text},
+		llmHandlers.Message{Role: models.RoleUser, Content: fmt.Sprintf(prompts.VERIFICATION_CORRECTION_PROMPT, strings.Join(issues, "\n"))},
+	)
+	if a.loaderGen != nil {
+		a.loaderGen.Reset()
+	}
+
+	corrected, err := a.llmClient.ChatStreamWithUsage(req)
+	if err != nil {
+		log.Printf("[verification] corrective pass failed: %v", err)
+		return resp
+	}
+	return mergeResponses(resp, corrected)
+}
+
+// mergeResponses joins the first pass of a turn with its corrective pass
+func mergeResponses(first *llmHandlers.ResponseWithUsage, second *llmHandlers.ResponseWithUsage) *llmHandlers.ResponseWithUsage {
+	merged := &llmHandlers.ResponseWithUsage{
+		Text:     strings.TrimSpace(strings.TrimSpace(first.Text) + "\n\n" + strings.TrimSpace(second.Text)),
+		Thinking: strings.TrimSpace(first.Thinking + "\n\n" + second.Thinking),
+	}
+	if first.TokenUsage != nil || second.TokenUsage != nil {
+		merged.TokenUsage = &llmHandlers.TokenUsage{}
+		for _, usage := range []*llmHandlers.TokenUsage{first.TokenUsage, second.TokenUsage} {
+			if usage == nil {
+				continue
+			}
+			merged.TokenUsage.InputTokens += usage.InputTokens
+			merged.TokenUsage.OutputTokens += usage.OutputTokens
+			merged.TokenUsage.TotalTokens += usage.TotalTokens
+			merged.TokenUsage.CountingMethod = usage.CountingMethod
+		}
+	}
+	return merged
+}
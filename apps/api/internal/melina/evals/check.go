@@ -0,0 +1,142 @@
+package evals
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"melina-studio-backend/internal/melina/tools"
+	"melina-studio-backend/internal/models"
+)
+
+// Expectation lists the assertions on the board after the agent's turn; unset fields aren't checked
+type Expectation struct {
+	// Shapes is the number of shapes on the board after the turn
+	Shapes *CountRange `json:"shapes,omitempty"`
+	// Created is the number of shapes that weren't on the board before the turn
+	Created *CountRange `json:"created,omitempty"`
+	// Types is the number of shapes of each type after the turn, e.g. {"arrow": {"min": 3}}
+	Types map[string]CountRange `json:"types,omitempty"`
+	// PreserveSeed fails the case when the agent deleted any of the seeded shapes
+	PreserveSeed bool `json:"preserve_seed,omitempty"`
+	// Labels must each appear in the text or name of some shape, ignoring case
+	Labels       []string                 `json:"labels,omitempty"`
+	Connectivity *ConnectivityExpectation `json:"connectivity,omitempty"`
+}
+
+// CountRange bounds a count; a missing bound isn't checked
+type CountRange struct {
+	Min *int `json:"min,omitempty"`
+	Max *int `json:"max,omitempty"`
+}
+
+// ConnectivityExpectation checks the diagram drawn on the whole board, see tools.SummarizeDiagram
+type ConnectivityExpectation struct {
+	MinConnectors int  `json:"min_connectors,omitempty"`
+	MaxDangling   *int `json:"max_dangling,omitempty"`
+	// Connected requires every node to be linked to the others by connectors
+	Connected bool `json:"connected,omitempty"`
+	Acyclic   bool `json:"acyclic,omitempty"`
+}
+
+// check returns the reason the count is out of range, or "" when it isn't
+func (r CountRange) check(what string, n int) string {
+	if r.Min != nil && n < *r.Min {
+		return fmt.Sprintf("expected at least %d %s, got %d", *r.Min, what, n)
+	}
+	if r.Max != nil && n > *r.Max {
+		return fmt.Sprintf("expected at most %d %s, got %d", *r.Max, what, n)
+	}
+	return ""
+}
+
+// Check compares the board before and after the turn with the expectation and returns every failed assertion
+func Check(expect Expectation, before []models.BoardData, after []models.BoardData) []string {
+	failures := []string{}
+	fail := func(reason string) {
+		if reason != "" {
+			failures = append(failures, reason)
+		}
+	}
+
+	existed := make(map[string]bool, len(before))
+	for _, shape := range before {
+		existed[shape.UUID.String()] = true
+	}
+	remaining := make(map[string]bool, len(after))
+	created := 0
+	counts := map[string]int{}
+	for _, shape := range after {
+		remaining[shape.UUID.String()] = true
+		if !existed[shape.UUID.String()] {
+			created++
+		}
+		counts[string(shape.Type)]++
+	}
+
+	if expect.Shapes != nil {
+		fail(expect.Shapes.check("shapes on the board", len(after)))
+	}
+	if expect.Created != nil {
+		fail(expect.Created.check("created shapes", created))
+	}
+	for shapeType, want := range expect.Types {
+		fail(want.check(shapeType+" shapes", counts[shapeType]))
+	}
+
+	if expect.PreserveSeed {
+		deleted := 0
+		for id := range existed {
+			if !remaining[id] {
+				deleted++
+			}
+		}
+		if deleted > 0 {
+			fail(fmt.Sprintf("expected the seeded shapes to be kept, %d were deleted", deleted))
+		}
+	}
+
+	if len(expect.Labels) > 0 {
+		text := strings.ToLower(strings.Join(shapeLabels(after), "\n"))
+		for _, label := range expect.Labels {
+			if !strings.Contains(text, strings.ToLower(label)) {
+				fail(fmt.Sprintf("expected a shape labeled %q", label))
+			}
+		}
+	}
+
+	if c := expect.Connectivity; c != nil {
+		summary := tools.SummarizeDiagram(after)
+		if summary.Connectors < c.MinConnectors {
+			fail(fmt.Sprintf("expected at least %d connectors, got %d", c.MinConnectors, summary.Connectors))
+		}
+		if c.MaxDangling != nil && summary.Dangling > *c.MaxDangling {
+			fail(fmt.Sprintf("expected at most %d dangling connectors, got %d", *c.MaxDangling, summary.Dangling))
+		}
+		if c.Connected && summary.Components > 1 {
+			fail(fmt.Sprintf("expected a connected diagram, got %d separate parts", summary.Components))
+		}
+		if c.Acyclic && summary.Cycles > 0 {
+			fail(fmt.Sprintf("expected no cycles, got %d", summary.Cycles))
+		}
+	}
+
+	return failures
+}
+
+// shapeLabels returns the text and names of the shapes
+func shapeLabels(shapes []models.BoardData) []string {
+	var labels []string
+	for _, shape := range shapes {
+		var data map[string]interface{}
+		if err := json.Unmarshal(shape.Data, &data); err != nil {
+			continue
+		}
+		for _, key := range []string{"text", "name"} {
+			if label, ok := data[key].(string); ok && label != "" {
+				labels = append(labels, label)
+			}
+		}
+	}
+	return labels
+}
@@ -0,0 +1,60 @@
+package evals
+
+import (
+	"encoding/json"
+	"testing"
+
+	"melina-studio-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func boardShape(t *testing.T, shapeType models.Type, data map[string]interface{}) models.BoardData {
+	t.Helper()
+	bytes, err := json.Marshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return models.BoardData{UUID: uuid.New(), Type: shapeType, Data: bytes}
+}
+
+func intPtr(v int) *int { return &v }
+
+func TestCheck(t *testing.T) {
+	start := boardShape(t, models.Rect, map[string]interface{}{"x": 0.0, "y": 0.0, "w": 100.0, "h": 50.0, "text": "Start"})
+	end := boardShape(t, models.Rect, map[string]interface{}{"x": 300.0, "y": 0.0, "w": 100.0, "h": 50.0, "text": "End"})
+	arrow := boardShape(t, models.Arrow, map[string]interface{}{
+		"start": map[string]float64{"x": 100, "y": 25},
+		"end":   map[string]float64{"x": 300, "y": 25},
+	})
+	before := []models.BoardData{start}
+
+	expect := Expectation{
+		Created:      &CountRange{Min: intPtr(2), Max: intPtr(2)},
+		Types:        map[string]CountRange{"arrow": {Min: intPtr(1)}},
+		PreserveSeed: true,
+		Labels:       []string{"start", "END"},
+		Connectivity: &ConnectivityExpectation{MinConnectors: 1, MaxDangling: intPtr(0), Connected: true, Acyclic: true},
+	}
+	if failures := Check(expect, before, []models.BoardData{start, end, arrow}); len(failures) != 0 {
+		t.Fatalf("expected the board to pass, got %v", failures)
+	}
+
+	// the seeded shape and the arrow are gone: too few shapes and arrows, a missing label and no connector
+	failures := Check(expect, before, []models.BoardData{end})
+	if len(failures) != 5 {
+		t.Fatalf("expected 5 failures, got %d: %v", len(failures), failures)
+	}
+}
+
+func TestLoadSuites(t *testing.T) {
+	suites, err := LoadSuites("../../../evals")
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, suite := range suites {
+		if len(suite.Cases) == 0 {
+			t.Errorf("suite %s has no cases", suite.Name)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package evals
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// CaseResult is the outcome of one case with one model
+type CaseResult struct {
+	Suite    string   `json:"suite"`
+	Case     string   `json:"case"`
+	Model    string   `json:"model"`
+	Passed   bool     `json:"passed"`
+	Failures []string `json:"failures,omitempty"`
+	// Error is set when the turn itself failed, e.g. the provider returned an error
+	Error      string   `json:"error,omitempty"`
+	ToolCalls  []string `json:"tool_calls,omitempty"`
+	Shapes     int      `json:"shapes"`
+	Tokens     int      `json:"tokens,omitempty"`
+	DurationMs int64    `json:"duration_ms"`
+}
+
+// key identifies the same case and model across reports
+func (r CaseResult) key() string {
+	return r.Suite + "/" + r.Case + "@" + r.Model
+}
+
+// Report is the result of an eval run, saved so the next run can be compared against it
+type Report struct {
+	StartedAt     time.Time    `json:"started_at"`
+	PromptVersion string       `json:"prompt_version"`
+	ToolsVersion  string       `json:"tools_version"`
+	Results       []CaseResult `json:"results"`
+}
+
+// Failed returns the results that didn't pass
+func (r *Report) Failed() []CaseResult {
+	var failed []CaseResult
+	for _, result := range r.Results {
+		if !result.Passed {
+			failed = append(failed, result)
+		}
+	}
+	return failed
+}
+
+// Regressions returns the cases that passed in the baseline and fail now, for the same model
+// Cases that are new or weren't run in the baseline aren't regressions
+func Regressions(baseline *Report, current *Report) []CaseResult {
+	passed := make(map[string]bool, len(baseline.Results))
+	for _, result := range baseline.Results {
+		passed[result.key()] = result.Passed
+	}
+	var regressions []CaseResult
+	for _, result := range current.Results {
+		if !result.Passed && passed[result.key()] {
+			regressions = append(regressions, result)
+		}
+	}
+	return regressions
+}
+
+// LoadReport reads a report saved by Save
+func LoadReport(path string) (*Report, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var report Report
+	if err := json.Unmarshal(data, &report); err != nil {
+		return nil, fmt.Errorf("failed to parse report %s: %w", path, err)
+	}
+	return &report, nil
+}
+
+// Save writes the report as indented JSON
+func (r *Report) Save(path string) error {
+	data, err := json.MarshalIndent(r, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// Print writes one line per result and the failed assertions under it
+func (r *Report) Print(w io.Writer) {
+	for _, result := range r.Results {
+		status := "PASS"
+		if !result.Passed {
+			status = "FAIL"
+		}
+		fmt.Fprintf(w, "%s  %s/%s  [%s]  %d shapes, %d tool calls, %.1fs\n",
+			status, result.Suite, result.Case, result.Model, result.Shapes, len(result.ToolCalls), float64(result.DurationMs)/1000)
+		if result.Error != "" {
+			fmt.Fprintf(w, "      error: %s\n", result.Error)
+		}
+		for _, failure := range result.Failures {
+			fmt.Fprintf(w, "      %s\n", failure)
+		}
+	}
+	fmt.Fprintf(w, "\n%d/%d passed (prompt %s, tools %s)\n", len(r.Results)-len(r.Failed()), len(r.Results), r.PromptVersion, r.ToolsVersion)
+}
@@ -0,0 +1,25 @@
+package evals
+
+import "testing"
+
+func TestRegressions(t *testing.T) {
+	baseline := &Report{Results: []CaseResult{
+		{Suite: "flowcharts", Case: "login", Model: "a", Passed: true},
+		{Suite: "flowcharts", Case: "login", Model: "b", Passed: false},
+		{Suite: "editing", Case: "delete", Model: "a", Passed: true},
+	}}
+	current := &Report{Results: []CaseResult{
+		{Suite: "flowcharts", Case: "login", Model: "a", Passed: false},
+		{Suite: "flowcharts", Case: "login", Model: "b", Passed: false},
+		{Suite: "editing", Case: "delete", Model: "a", Passed: true},
+		{Suite: "editing", Case: "new case", Model: "a", Passed: false},
+	}}
+
+	regressions := Regressions(baseline, current)
+	if len(regressions) != 1 || regressions[0].key() != "flowcharts/login@a" {
+		t.Fatalf("expected only flowcharts/login@a to regress, got %v", regressions)
+	}
+	if failed := current.Failed(); len(failed) != 3 {
+		t.Fatalf("expected 3 failed results, got %d", len(failed))
+	}
+}
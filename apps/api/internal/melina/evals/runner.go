@@ -0,0 +1,172 @@
+package evals
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"melina-studio-backend/internal/libraries"
+	llmHandlers "melina-studio-backend/internal/llm_handlers"
+	"melina-studio-backend/internal/melina/agents"
+	"melina-studio-backend/internal/melina/prompts"
+	"melina-studio-backend/internal/melina/tools"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+
+	"github.com/google/uuid"
+)
+
+// evalTheme is the board theme of every eval turn, so colors don't vary between runs
+const evalTheme = "light"
+
+// evalBoardTTL only matters when a run dies mid-case; the cleanup service then deletes the board
+const evalBoardTTL = time.Hour
+
+// Runner plays cases on sandbox boards of an eval user; the boards are deleted after each case
+type Runner struct {
+	userID        uuid.UUID
+	boardRepo     repo.BoardRepoInterface
+	boardDataRepo repo.BoardDataRepoInterface
+	timeout       time.Duration
+}
+
+func NewRunner(userID uuid.UUID, boardRepo repo.BoardRepoInterface, boardDataRepo repo.BoardDataRepoInterface, timeout time.Duration) *Runner {
+	return &Runner{
+		userID:        userID,
+		boardRepo:     boardRepo,
+		boardDataRepo: boardDataRepo,
+		timeout:       timeout,
+	}
+}
+
+// Run plays every case of the suites with every model
+func (r *Runner) Run(ctx context.Context, suites []Suite, modelNames []string) *Report {
+	report := &Report{
+		StartedAt:     time.Now(),
+		PromptVersion: prompts.MasterPromptVersion(),
+		ToolsVersion:  ToolsVersion(),
+	}
+	for _, modelName := range modelNames {
+		for _, suite := range suites {
+			for _, c := range suite.Cases {
+				result := r.RunCase(ctx, modelName, suite.Name, c)
+				log.Printf("[evals] %s/%s [%s] passed=%t", suite.Name, c.Name, modelName, result.Passed)
+				report.Results = append(report.Results, result)
+			}
+		}
+	}
+	return report
+}
+
+// RunCase seeds a board, sends the case's prompt to the model at temperature 0 and checks the board after the turn
+func (r *Runner) RunCase(ctx context.Context, modelName string, suiteName string, c Case) CaseResult {
+	result := CaseResult{Suite: suiteName, Case: c.Name, Model: modelName}
+	started := time.Now()
+	defer func() {
+		result.DurationMs = time.Since(started).Milliseconds()
+	}()
+
+	if err := r.runCase(ctx, modelName, c, &result); err != nil {
+		result.Error = err.Error()
+		result.Passed = false
+	}
+	return result
+}
+
+func (r *Runner) runCase(ctx context.Context, modelName string, c Case, result *CaseResult) error {
+	modelInfo, err := llmHandlers.ValidateModel(modelName)
+	if err != nil {
+		return err
+	}
+	temperature := float32(0)
+	agent, err := agents.NewToolAgent(modelInfo, &temperature, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	boardID, err := r.boardRepo.CreateSandboxBoard(&models.Board{Title: "Eval: " + c.Name, UserID: r.userID}, evalBoardTTL)
+	if err != nil {
+		return fmt.Errorf("failed to create eval board: %w", err)
+	}
+	defer r.cleanup(boardID)
+
+	for i := range c.Seed {
+		shape := c.Seed[i]
+		shape.ID = uuid.NewString()
+		if err := r.boardDataRepo.SaveShapeData(boardID, &shape); err != nil {
+			return fmt.Errorf("failed to seed shape %d: %w", i+1, err)
+		}
+	}
+	before, err := r.boardDataRepo.GetBoardData(boardID)
+	if err != nil {
+		return fmt.Errorf("failed to read seeded board: %w", err)
+	}
+
+	// the agent sees the seeded board the way it does in a chat turn
+	var canvasStateXML string
+	if len(before) > 0 {
+		if state := tools.GenerateCanvasState(before, 50.0, 100.0); state != nil {
+			canvasStateXML = tools.FormatCanvasStateXML(state)
+		}
+	}
+
+	turnCtx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	turnCtx, activity := llmHandlers.WithToolActivity(turnCtx)
+
+	// the streamed reply isn't needed, the relay client just drains it
+	relay, stop := libraries.NewRelayClient(r.userID.String(), func(string) {})
+	resp, err := agent.ProcessRequestStreamWithUsage(turnCtx, libraries.NewHub(), relay, c.Prompt, nil, boardID.String(), evalTheme, nil, nil, false, canvasStateXML, "", "", "")
+	stop()
+
+	for _, call := range activity.Results() {
+		result.ToolCalls = append(result.ToolCalls, call.ToolName)
+	}
+	if err != nil {
+		return err
+	}
+	if resp.TokenUsage != nil {
+		result.Tokens = resp.TokenUsage.TotalTokens
+	}
+
+	stored, err := r.boardDataRepo.GetBoardData(boardID)
+	if err != nil {
+		return fmt.Errorf("failed to read board after the turn: %w", err)
+	}
+	// shapes the agent created are only saved by the client, so they come from the turn's activity
+	after := tools.SnapshotShapes(stored, activity.CreatedShapes())
+	result.Shapes = len(after)
+	result.Failures = Check(c.Expect, before, after)
+	result.Passed = len(result.Failures) == 0
+	return nil
+}
+
+// cleanup removes the eval board and its shapes
+func (r *Runner) cleanup(boardID uuid.UUID) {
+	if err := r.boardRepo.PurgeBoard(boardID); err != nil {
+		log.Printf("[evals] failed to delete eval board %s: %v", boardID, err)
+	}
+}
+
+// ToolsVersion identifies the tool schemas the agent runs with, the way prompts.MasterPromptVersion does the prompt
+func ToolsVersion() string {
+	return tools.SchemaVersion()
+}
+
+// ProviderModels returns one model of each provider in the registry, the first by name
+func ProviderModels() []string {
+	first := map[llmHandlers.Provider]string{}
+	for name, info := range llmHandlers.ModelRegistry {
+		if current, ok := first[info.Provider]; !ok || name < current {
+			first[info.Provider] = name
+		}
+	}
+	modelNames := make([]string, 0, len(first))
+	for _, name := range first {
+		modelNames = append(modelNames, name)
+	}
+	sort.Strings(modelNames)
+	return modelNames
+}
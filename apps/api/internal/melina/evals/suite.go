@@ -0,0 +1,63 @@
+// Package evals replays a corpus of prompts against seeded boards and checks the shapes the agent
+// leaves behind, so changes to the master prompt or the tool schemas can be checked for regressions
+package evals
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"melina-studio-backend/internal/models"
+)
+
+// Suite is a file of golden-board cases, e.g. evals/flowcharts.json
+type Suite struct {
+	Name  string `json:"name"`
+	Cases []Case `json:"cases"`
+}
+
+// Case is one prompt sent to the agent on a board seeded with the given shapes
+// Seed shapes get fresh ids on every run, so their "id" can be left out
+type Case struct {
+	Name   string         `json:"name"`
+	Prompt string         `json:"prompt"`
+	Seed   []models.Shape `json:"seed,omitempty"`
+	Expect Expectation    `json:"expect"`
+}
+
+// LoadSuites reads every *.json suite of a directory, in file name order
+func LoadSuites(dir string) ([]Suite, error) {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return nil, err
+	}
+	sort.Strings(paths)
+
+	suites := make([]Suite, 0, len(paths))
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, err
+		}
+		var suite Suite
+		if err := json.Unmarshal(data, &suite); err != nil {
+			return nil, fmt.Errorf("failed to parse suite %s: %w", path, err)
+		}
+		if suite.Name == "" {
+			suite.Name = strings.TrimSuffix(filepath.Base(path), ".json")
+		}
+		for i, c := range suite.Cases {
+			if c.Name == "" || strings.TrimSpace(c.Prompt) == "" {
+				return nil, fmt.Errorf("suite %s: case %d needs a name and a prompt", suite.Name, i+1)
+			}
+		}
+		suites = append(suites, suite)
+	}
+	if len(suites) == 0 {
+		return nil, fmt.Errorf("no suites found in %s", dir)
+	}
+	return suites, nil
+}
@@ -0,0 +1,57 @@
+package prompts
+
+// FACILITATION_PROMPT wraps the instructions for the current facilitation step
+// Placeholders: kind, topic, step, step instructions
+var FACILITATION_PROMPT = `
+<FACILITATION>
+  You are facilitating a %s session on this board.
+  <TOPIC>%s</TOPIC>
+  <CURRENT_STEP>%s</CURRENT_STEP>
+
+  <STEP_INSTRUCTIONS>
+%s
+  </STEP_INSTRUCTIONS>
+
+  Only perform the work for the current step. Do not jump ahead to later steps.
+  When the step is done, briefly tell the user what happens next.
+</FACILITATION>
+`
+
+// FACILITATION_STEP_INSTRUCTIONS holds the per-step instructions, keyed by kind and then step
+var FACILITATION_STEP_INSTRUCTIONS = map[string]map[string]string{
+	"retro": {
+		"start": `    Welcome the participants and restate the retrospective topic in one sentence.
+    Add a text title with the topic at the top of the free canvas area.`,
+		"create_columns": `    Create three labeled frames side by side below the title: "Went well", "To improve" and "Action items".
+    Make every frame the same size (around 350x600) with an 80px gap between them.`,
+		"collect_notes": `    Help the user capture notes. For every note the user mentions, add a small rect with a text label inside the matching column frame.
+    Stack notes vertically inside a frame with a 20px gap. Do not invent notes the user did not give you.`,
+		"cluster": `    Look at the notes inside each column and group notes that talk about the same thing.
+    Move related notes next to each other and add a short text label above each group naming the theme.`,
+		"summarize": `    Read every note and theme on the board and reply with a short summary:
+    what went well, what needs improvement, and a numbered list of action items.
+    Add the action items as text inside the "Action items" frame if they are not already there.`,
+		"completed": `    The retrospective is finished. Answer follow-up questions but do not restructure the board.`,
+	},
+	"brainstorm": {
+		"start": `    Welcome the participants and restate the brainstorm question in one sentence.
+    Add the question as a large text title at the top of the free canvas area.`,
+		"create_columns": `    Create one large labeled frame named "Ideas" below the title, around 1000x700, to hold sticky notes.`,
+		"collect_notes": `    Help the user capture ideas. For every idea the user mentions, add a small rect with a text label inside the "Ideas" frame.
+    Lay ideas out in a grid with a 20px gap. You may suggest up to three extra ideas, but ask before adding them.`,
+		"cluster": `    Group ideas that share a theme. Create a labeled frame per theme and move the matching ideas into it.
+    Keep the frames aligned in a row with an 80px gap.`,
+		"summarize": `    Reply with a short summary of the strongest themes, the most promising ideas, and suggested next steps.`,
+		"completed": `    The brainstorm is finished. Answer follow-up questions but do not restructure the board.`,
+	},
+}
+
+// FACILITATION_KICKOFF_MESSAGES are the user-facing chat messages that trigger each step
+var FACILITATION_KICKOFF_MESSAGES = map[string]string{
+	"start":          "Let's get started.",
+	"create_columns": "Set up the board for the session.",
+	"collect_notes":  "Let's collect notes.",
+	"cluster":        "Group the notes into themes.",
+	"summarize":      "Summarize the session.",
+	"completed":      "Wrap up the session.",
+}
@@ -0,0 +1,14 @@
+package prompts
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+)
+
+// MasterPromptVersion identifies the system prompt the agent runs with, so feedback can be compared
+// across prompt changes. It is derived from the prompt's text and changes whenever the prompt does
+var MasterPromptVersion = sync.OnceValue(func() string {
+	sum := sha256.Sum256([]byte(MASTER_PROMPT))
+	return hex.EncodeToString(sum[:6])
+})
@@ -0,0 +1,5 @@
+package prompts
+
+// REDACTION_PROMPT is added to the system prompt of turns whose personal data was replaced with placeholders
+var REDACTION_PROMPT = `Some emails, phone numbers and card numbers in this conversation and on the board were replaced with placeholders such as [EMAIL_1], [PHONE_1] or [CARD_1] to protect the user's privacy.
+Each placeholder always stands for the same value. Use the placeholders exactly as written, in replies and in tool arguments; they are swapped back for the real values before anything is shown or saved. Never guess the hidden values.`
@@ -0,0 +1,224 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"melina-studio-backend/internal/models"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var extractActionItemsSchema = toolSchema{
+	Name:        "extractActionItems",
+	Description: "Creates action item cards on the board from the action items you found in the board text and chat history. Each card carries a title and optional assignee and due date, and is exposed to external trackers. Read the board (getBoardData) and the conversation first, then pass every concrete action item you found. Items whose title already exists as a card are skipped.",
+	Parameters: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"boardId": map[string]interface{}{
+				"type":        "string",
+				"description": "The UUID of the board",
+			},
+			"items": map[string]interface{}{
+				"type":        "array",
+				"description": "Action items to create as cards",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"title": map[string]interface{}{
+							"type":        "string",
+							"description": "Short imperative description of the task",
+						},
+						"assignee": map[string]interface{}{
+							"type":        "string",
+							"description": "Person responsible, only if mentioned on the board or in chat (optional)",
+						},
+						"dueDate": map[string]interface{}{
+							"type":        "string",
+							"description": "Due date in YYYY-MM-DD format, only if mentioned (optional)",
+						},
+					},
+					"required": []string{"title"},
+				},
+			},
+			"x": map[string]interface{}{
+				"type":        "number",
+				"description": "X coordinate of the first card (optional, defaults to below existing content)",
+			},
+			"y": map[string]interface{}{
+				"type":        "number",
+				"description": "Y coordinate of the first card (optional, defaults to below existing content)",
+			},
+		},
+		"required": []string{"boardId", "items"},
+	},
+}
+
+const (
+	actionItemCardWidth   = 240.0
+	actionItemCardHeight  = 110.0
+	actionItemCardGap     = 20.0
+	actionItemCardColumns = 4
+	actionItemDateLayout  = "2006-01-02"
+)
+
+// ExtractActionItemsHandler creates action_item card shapes for the items found by the LLM
+func (d *ToolDeps) ExtractActionItemsHandler(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+	streamCtx, err := getStreamingContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	boardIdStr, ok := input["boardId"].(string)
+	if !ok || boardIdStr == "" {
+		return nil, fmt.Errorf("boardId is required and must be a non-empty string")
+	}
+	boardId, err := uuid.Parse(boardIdStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid boardId format: %w", err)
+	}
+
+	rawItems, ok := input["items"].([]interface{})
+	if !ok || len(rawItems) == 0 {
+		return nil, fmt.Errorf("items is required and must be a non-empty array")
+	}
+
+	shapes, err := d.BoardData.GetBoardData(boardId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shapes from database: %w", err)
+	}
+
+	// Titles that already have a card, so repeated extraction doesn't duplicate them
+	existing := map[string]bool{}
+	for _, shape := range shapes {
+		if shape.Type != models.ActionItemCard {
+			continue
+		}
+		var data map[string]interface{}
+		if err := json.Unmarshal(shape.Data, &data); err == nil {
+			if title, ok := data["text"].(string); ok {
+				existing[normalizeActionItemTitle(title)] = true
+			}
+		}
+	}
+
+	originX, originY := 0.0, 0.0
+	if state := GenerateCanvasState(shapes, 0, 0); state != nil {
+		originX = state.OverallBounds.MinX
+		originY = state.OverallBounds.MaxY + clusterFrameGap
+	}
+	if x, ok := input["x"].(float64); ok {
+		originX = x
+	}
+	if y, ok := input["y"].(float64); ok {
+		originY = y
+	}
+
+	created := make([]map[string]interface{}, 0, len(rawItems))
+	skipped := []string{}
+	warnings := []string{}
+	status := "open"
+	width, height := actionItemCardWidth, actionItemCardHeight
+	fill, stroke := "#fef9c3", "#ca8a04"
+
+	for _, raw := range rawItems {
+		item, ok := raw.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		title, _ := item["title"].(string)
+		title = strings.TrimSpace(title)
+		if title == "" {
+			continue
+		}
+		key := normalizeActionItemTitle(title)
+		if existing[key] {
+			skipped = append(skipped, title)
+			continue
+		}
+		existing[key] = true
+
+		index := len(created)
+		x := originX + float64(index%actionItemCardColumns)*(actionItemCardWidth+actionItemCardGap)
+		y := originY + float64(index/actionItemCardColumns)*(actionItemCardHeight+actionItemCardGap)
+
+		shape := &models.Shape{
+			ID:     uuid.New().String(),
+			Type:   string(models.ActionItemCard),
+			X:      &x,
+			Y:      &y,
+			W:      &width,
+			H:      &height,
+			Text:   &title,
+			Status: &status,
+			Fill:   &fill,
+			Stroke: &stroke,
+		}
+		if assignee, ok := item["assignee"].(string); ok && strings.TrimSpace(assignee) != "" {
+			a := strings.TrimSpace(assignee)
+			shape.Assignee = &a
+		}
+		if dueDate, ok := item["dueDate"].(string); ok && strings.TrimSpace(dueDate) != "" {
+			d := strings.TrimSpace(dueDate)
+			if _, err := time.Parse(actionItemDateLayout, d); err != nil {
+				warnings = append(warnings, fmt.Sprintf("ignored due date %q for %q (expected YYYY-MM-DD)", d, title))
+			} else {
+				shape.DueDate = &d
+			}
+		}
+
+		if err := d.BoardData.SaveShapeData(boardId, shape); err != nil {
+			return nil, fmt.Errorf("failed to save action item: %w", err)
+		}
+
+		shapeMap := actionItemShapeMap(shape)
+		sendShapeCreated(ctx, streamCtx, boardIdStr, shapeMap)
+		created = append(created, shapeMap)
+	}
+
+	if len(created) > 0 {
+		d.invalidateBoardImageCache(streamCtx, boardId)
+	}
+
+	result := map[string]interface{}{
+		"success": true,
+		"created": created,
+		"message": fmt.Sprintf("Created %d action item cards", len(created)),
+	}
+	if len(skipped) > 0 {
+		result["skipped"] = skipped
+	}
+	if len(warnings) > 0 {
+		result["warnings"] = warnings
+	}
+	return result, nil
+}
+
+// actionItemShapeMap builds the websocket shape payload for an action item card
+func actionItemShapeMap(shape *models.Shape) map[string]interface{} {
+	shapeMap := map[string]interface{}{
+		"id":     shape.ID,
+		"type":   shape.Type,
+		"x":      *shape.X,
+		"y":      *shape.Y,
+		"w":      *shape.W,
+		"h":      *shape.H,
+		"text":   *shape.Text,
+		"status": *shape.Status,
+		"fill":   *shape.Fill,
+		"stroke": *shape.Stroke,
+	}
+	if shape.Assignee != nil {
+		shapeMap["assignee"] = *shape.Assignee
+	}
+	if shape.DueDate != nil {
+		shapeMap["dueDate"] = *shape.DueDate
+	}
+	return shapeMap
+}
+
+func normalizeActionItemTitle(title string) string {
+	return strings.ToLower(strings.Join(strings.Fields(title), " "))
+}
@@ -0,0 +1,93 @@
+package tools
+
+import (
+	"testing"
+
+	"melina-studio-backend/internal/models"
+)
+
+func TestExtractActionItemsLayout(t *testing.T) {
+	tt := newToolTest(t)
+	tt.data.add(t, tt.boardID, models.Rect, map[string]interface{}{"x": 40.0, "y": 0.0, "w": 200.0, "h": 100.0})
+
+	items := []interface{}{
+		map[string]interface{}{"title": "  Write   docs "},
+		map[string]interface{}{"title": "write docs"},
+		map[string]interface{}{"title": ""},
+		"not an item",
+	}
+	for _, title := range []string{"Plan sprint", "Fix login", "Ship v2", "Book room"} {
+		items = append(items, map[string]interface{}{"title": title})
+	}
+	result := tt.result(tt.deps.ExtractActionItemsHandler(tt.ctx, map[string]interface{}{"boardId": tt.boardID.String(), "items": items}))
+
+	created, _ := result["created"].([]map[string]interface{})
+	if len(created) != 5 {
+		t.Fatalf("expected 5 cards without the repeated and empty titles, got %v", created)
+	}
+	if skipped, _ := result["skipped"].([]string); len(skipped) != 1 || skipped[0] != "write docs" {
+		t.Errorf("expected the repeated title to be skipped, got %v", result["skipped"])
+	}
+	if created[0]["text"] != "Write   docs" || created[0]["status"] != "open" {
+		t.Errorf("expected a trimmed open card, got %v", created[0])
+	}
+
+	// cards start below the existing content and wrap after a row of actionItemCardColumns
+	originY := 100.0 + clusterFrameGap
+	if created[0]["x"] != 40.0 || created[0]["y"] != originY {
+		t.Errorf("expected the first card below the content at (40, %v), got (%v, %v)", originY, created[0]["x"], created[0]["y"])
+	}
+	if created[3]["x"] != 40.0+3*(actionItemCardWidth+actionItemCardGap) || created[3]["y"] != originY {
+		t.Errorf("expected the fourth card at the end of the first row, got (%v, %v)", created[3]["x"], created[3]["y"])
+	}
+	if created[4]["x"] != 40.0 || created[4]["y"] != originY+actionItemCardHeight+actionItemCardGap {
+		t.Errorf("expected the fifth card to start a second row, got (%v, %v)", created[4]["x"], created[4]["y"])
+	}
+
+	result = tt.result(tt.deps.ExtractActionItemsHandler(tt.ctx, map[string]interface{}{
+		"boardId": tt.boardID.String(),
+		"items":   []interface{}{map[string]interface{}{"title": "Call vendor"}},
+		"x":       500.0,
+		"y":       -20.0,
+	}))
+	if card := result["created"].([]map[string]interface{})[0]; card["x"] != 500.0 || card["y"] != -20.0 {
+		t.Errorf("expected the given position to be used, got (%v, %v)", card["x"], card["y"])
+	}
+
+	if _, err := tt.deps.ExtractActionItemsHandler(tt.ctx, map[string]interface{}{"boardId": tt.boardID.String(), "items": []interface{}{}}); err == nil {
+		t.Error("expected an empty item list to fail")
+	}
+}
+
+func TestNewActionItem(t *testing.T) {
+	tt := newToolTest(t)
+	tt.result(tt.deps.ExtractActionItemsHandler(tt.ctx, map[string]interface{}{
+		"boardId": tt.boardID.String(),
+		"items": []interface{}{
+			map[string]interface{}{"title": "Write docs", "assignee": " Sam ", "dueDate": "2026-11-02"},
+			map[string]interface{}{"title": "Ship v2"},
+		},
+	}))
+
+	cards, _ := tt.data.GetShapesByType(tt.boardID, models.ActionItemCard)
+	if len(cards) != 2 {
+		t.Fatalf("expected 2 stored cards, got %d", len(cards))
+	}
+	docs, err := models.NewActionItem(cards[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	if docs.ID != cards[0].UUID.String() || docs.BoardID != tt.boardID.String() || docs.Title != "Write docs" || docs.Status != "open" {
+		t.Errorf("unexpected action item %+v", docs)
+	}
+	if docs.Assignee == nil || *docs.Assignee != "Sam" || docs.DueDate == nil || *docs.DueDate != "2026-11-02" {
+		t.Errorf("expected the assignee and due date to be kept, got %+v", docs)
+	}
+	if ship, _ := models.NewActionItem(cards[1]); ship.Assignee != nil || ship.DueDate != nil {
+		t.Errorf("expected no assignee or due date, got %+v", ship)
+	}
+
+	if _, err := models.NewActionItem(models.BoardData{Data: []byte("not json")}); err == nil {
+		t.Error("expected unreadable card data to fail")
+	}
+}
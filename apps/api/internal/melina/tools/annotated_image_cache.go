@@ -10,14 +10,14 @@ import (
 	"path/filepath"
 	"sort"
 
-	"melina-studio-backend/internal/config"
 	"melina-studio-backend/internal/models"
 	"melina-studio-backend/internal/repo"
 
 	"github.com/google/uuid"
 )
 
-const annotatedImageDir = "temp/annotated_images"
+// annotatedImageDir is where annotated board images are cached; a var so tests can use a temp dir
+var annotatedImageDir = "temp/annotated_images"
 
 // ComputeShapesHash computes a hash of the shapes data for cache invalidation
 // The hash includes annotation numbers and shape positions to detect changes
@@ -114,8 +114,7 @@ func DeleteAnnotatedImage(boardId string) error {
 
 // InvalidateAnnotatedImageCache marks the board's annotated image cache as invalid
 // by clearing the hash in the database
-func InvalidateAnnotatedImageCache(userId uuid.UUID, boardId uuid.UUID) error {
-	boardRepo := repo.NewBoardRepository(config.DB)
+func InvalidateAnnotatedImageCache(boardRepo repo.BoardRepoInterface, userId uuid.UUID, boardId uuid.UUID) error {
 	return boardRepo.UpdateBoard(userId, boardId, &models.Board{
 		AnnotatedImageHash: "",
 	})
@@ -123,7 +122,7 @@ func InvalidateAnnotatedImageCache(userId uuid.UUID, boardId uuid.UUID) error {
 
 // GetOrCreateAnnotatedImage checks the cache and returns the annotated image
 // If the cache is invalid or doesn't exist, it generates a new annotated image
-func GetOrCreateAnnotatedImage(userId uuid.UUID, boardId string, shapes []models.BoardData, originalImageBase64 string) (string, error) {
+func GetOrCreateAnnotatedImage(boardRepo repo.BoardRepoInterface, userId uuid.UUID, boardId string, shapes []models.BoardData, originalImageBase64 string) (string, error) {
 	boardIdUUID, err := uuid.Parse(boardId)
 	if err != nil {
 		return "", fmt.Errorf("invalid boardId: %w", err)
@@ -133,7 +132,6 @@ func GetOrCreateAnnotatedImage(userId uuid.UUID, boardId string, shapes []models
 	currentHash := ComputeShapesHash(shapes)
 
 	// Get the board to check stored hash
-	boardRepo := repo.NewBoardRepository(config.DB)
 	board, err := boardRepo.GetBoardById(userId, boardIdUUID)
 	if err != nil {
 		return "", fmt.Errorf("failed to get board: %w", err)
@@ -0,0 +1,96 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"os"
+	"sync"
+
+	"github.com/fogleman/gg"
+	"github.com/golang/freetype/truetype"
+	"golang.org/x/image/font"
+)
+
+// The badge font is parsed once; faces are cheap to create but hold a glyph cache that isn't safe
+// for concurrent use, so every drawing context gets its own
+var (
+	badgeFontOnce sync.Once
+	badgeFont     *truetype.Font
+	badgeFontErr  error
+)
+
+func loadBadgeFont() (*truetype.Font, error) {
+	badgeFontOnce.Do(func() {
+		fontPath := getFontPath()
+		data, err := os.ReadFile(fontPath)
+		if err != nil {
+			badgeFontErr = fmt.Errorf("could not read font %s: %w", fontPath, err)
+			return
+		}
+		badgeFont, badgeFontErr = truetype.Parse(data)
+	})
+	return badgeFont, badgeFontErr
+}
+
+// fontFace returns a face of the badge font at the given size, nil when the font isn't available
+func fontFace(size float64) font.Face {
+	f, err := loadBadgeFont()
+	if err != nil {
+		return nil
+	}
+	return truetype.NewFace(f, &truetype.Options{Size: size})
+}
+
+// setFontSize switches the context to the badge font at the given size, reporting false when it isn't available
+// and the context keeps gg's built-in face
+func setFontSize(dc *gg.Context, size float64) bool {
+	face := fontFace(size)
+	if face == nil {
+		return false
+	}
+	dc.SetFontFace(face)
+	return true
+}
+
+// canvasPool reuses the pixel buffers of annotated images; boards are exported at the same size over and over
+var canvasPool sync.Pool
+
+// newAnnotationCanvas decodes a base64 PNG into a pooled RGBA buffer and returns a context drawing on it
+// Call the returned release once the image is encoded
+func newAnnotationCanvas(imageBase64 string) (*gg.Context, func(), error) {
+	imageData, err := base64.StdEncoding.DecodeString(imageBase64)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode base64 image: %w", err)
+	}
+	img, err := png.Decode(bytes.NewReader(imageData))
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to decode PNG: %w", err)
+	}
+
+	bounds := img.Bounds()
+	rect := image.Rect(0, 0, bounds.Dx(), bounds.Dy())
+	rgba, _ := canvasPool.Get().(*image.RGBA)
+	if rgba == nil || cap(rgba.Pix) < 4*rect.Dx()*rect.Dy() {
+		rgba = image.NewRGBA(rect)
+	} else {
+		rgba = &image.RGBA{Pix: rgba.Pix[:4*rect.Dx()*rect.Dy()], Stride: 4 * rect.Dx(), Rect: rect}
+	}
+	// draw.Src overwrites every pixel, so a reused buffer needs no clearing
+	draw.Draw(rgba, rect, img, bounds.Min, draw.Src)
+
+	release := func() { canvasPool.Put(rgba) }
+	return gg.NewContextForRGBA(rgba), release, nil
+}
+
+// encodeAnnotation encodes the drawn image as a base64 PNG
+func encodeAnnotation(dc *gg.Context) (string, error) {
+	data, err := encodePNG(dc.Image().(*image.RGBA))
+	if err != nil {
+		return "", fmt.Errorf("failed to encode annotated image: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
@@ -0,0 +1,206 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"image"
+	_ "image/jpeg"
+	_ "image/png"
+	"strings"
+	"time"
+	"unicode"
+	"unicode/utf8"
+
+	"melina-studio-backend/internal/libraries"
+	llmHandlers "melina-studio-backend/internal/llm_handlers"
+	"melina-studio-backend/internal/models"
+
+	"github.com/google/uuid"
+	xdraw "golang.org/x/image/draw"
+)
+
+// Board covers are 3:1 banners shown above the board in listings
+const (
+	BoardCoverWidth           = 1200
+	BoardCoverHeight          = 400
+	MaxBoardDescriptionLength = 500
+	maxBoardIconRunes         = 8 // an emoji with modifiers and joiners, not a word
+)
+
+var setBoardCoverSchema = toolSchema{
+	Name:        "setBoardCover",
+	Description: "Sets how the board looks in the user's board list: an emoji icon, a one or two sentence description, and a cover image cropped from the current board. Use it after creating a board's main content so the board is easy to recognize, or when the user asks for an icon, description or cover.",
+	Parameters: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"boardId": map[string]interface{}{
+				"type":        "string",
+				"description": "The UUID of the board",
+			},
+			"icon": map[string]interface{}{
+				"type":        "string",
+				"description": "A single emoji for the board, e.g. \"🗺️\" (optional; an empty string removes the icon)",
+			},
+			"description": map[string]interface{}{
+				"type":        "string",
+				"description": fmt.Sprintf("What the board is about, at most %d characters (optional)", MaxBoardDescriptionLength),
+			},
+			"coverFromBoard": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Crop a cover image from the board as it looks now (optional)",
+			},
+		},
+		"required": []string{"boardId"},
+	},
+}
+
+// ValidateBoardIcon accepts a single emoji, or an empty icon which removes it
+func ValidateBoardIcon(icon string) error {
+	if icon == "" {
+		return nil
+	}
+	if utf8.RuneCountInString(icon) > maxBoardIconRunes {
+		return fmt.Errorf("icon must be a single emoji")
+	}
+	for _, r := range icon {
+		if unicode.IsLetter(r) || unicode.IsSpace(r) {
+			return fmt.Errorf("icon must be a single emoji")
+		}
+	}
+	return nil
+}
+
+// ValidateBoardDescription limits a board description to MaxBoardDescriptionLength characters
+func ValidateBoardDescription(description string) error {
+	if utf8.RuneCountInString(description) > MaxBoardDescriptionLength {
+		return fmt.Errorf("description must be at most %d characters", MaxBoardDescriptionLength)
+	}
+	return nil
+}
+
+// RenderBoardCover crops the middle band of a board image to the cover's aspect ratio and scales it to
+// BoardCoverWidth x BoardCoverHeight, returning the PNG
+func RenderBoardCover(boardImage []byte) ([]byte, error) {
+	src, _, err := image.Decode(bytes.NewReader(boardImage))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode board image: %w", err)
+	}
+	bounds := src.Bounds()
+	if bounds.Empty() {
+		return nil, fmt.Errorf("board image is empty")
+	}
+
+	crop := bounds
+	if bounds.Dx()*BoardCoverHeight > bounds.Dy()*BoardCoverWidth {
+		width := bounds.Dy() * BoardCoverWidth / BoardCoverHeight
+		crop.Min.X += (bounds.Dx() - width) / 2
+		crop.Max.X = crop.Min.X + width
+	} else {
+		height := max(bounds.Dx()*BoardCoverHeight/BoardCoverWidth, 1)
+		crop.Min.Y += (bounds.Dy() - height) / 2
+		crop.Max.Y = crop.Min.Y + height
+	}
+
+	cover := image.NewRGBA(image.Rect(0, 0, BoardCoverWidth, BoardCoverHeight))
+	xdraw.CatmullRom.Scale(cover, cover.Bounds(), src, crop, xdraw.Src, nil)
+	return encodePNG(cover)
+}
+
+// storePNG uploads a PNG to the storage bucket and returns its URL
+func storePNG(ctx context.Context, key string, data []byte) (string, error) {
+	return libraries.GetClients().Upload(ctx, key, bytes.NewReader(data), "image/png")
+}
+
+// SetBoardCoverHandler is the handler for the setBoardCover tool
+func (d *ToolDeps) SetBoardCoverHandler(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+	boardIdStr, ok := input["boardId"].(string)
+	if !ok {
+		return nil, llmHandlers.InvalidToolInput("boardId is required and must be a string")
+	}
+	boardId, err := uuid.Parse(boardIdStr)
+	if err != nil {
+		return nil, llmHandlers.InvalidToolInput("invalid boardId: %v", err)
+	}
+
+	streamCtx, err := getStreamingContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	userId, err := uuid.Parse(streamCtx.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid userId: %w", err)
+	}
+
+	var cover models.BoardCover
+	if icon, ok := input["icon"].(string); ok {
+		icon = strings.TrimSpace(icon)
+		if err := ValidateBoardIcon(icon); err != nil {
+			return nil, llmHandlers.InvalidToolInput("%v", err)
+		}
+		cover.Icon = &icon
+	}
+	if description, ok := input["description"].(string); ok {
+		description = strings.TrimSpace(description)
+		if err := ValidateBoardDescription(description); err != nil {
+			return nil, llmHandlers.InvalidToolInput("%v", err)
+		}
+		cover.Description = &description
+	}
+	if fromBoard, _ := input["coverFromBoard"].(bool); fromBoard {
+		boardImage, err := d.BoardImage(boardIdStr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get board image: %w", err)
+		}
+		encoded, _ := boardImage["image"].(string)
+		data, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("failed to decode board image: %w", err)
+		}
+		rendered, err := RenderBoardCover(data)
+		if err != nil {
+			return nil, err
+		}
+		url, err := d.StoreImage(ctx, BoardCoverKey(boardId), rendered)
+		if err != nil {
+			return nil, fmt.Errorf("failed to store cover image: %w", err)
+		}
+		url = VersionedURL(url)
+		cover.CoverImage = &url
+	}
+	if cover.Icon == nil && cover.Description == nil && cover.CoverImage == nil {
+		return nil, llmHandlers.InvalidToolInput("set at least one of icon, description or coverFromBoard")
+	}
+
+	if err := d.Boards.SetBoardCover(userId, boardId, cover); err != nil {
+		return nil, fmt.Errorf("failed to update board: %w", err)
+	}
+	libraries.SendBoardCoverMessage(streamCtx.Hub, streamCtx.Client, &libraries.BoardCoverPayload{
+		BoardId:     boardIdStr,
+		CoverImage:  cover.CoverImage,
+		Icon:        cover.Icon,
+		Description: cover.Description,
+	})
+
+	result := map[string]interface{}{
+		"success": true,
+		"boardId": boardIdStr,
+		"message": "Board cover updated",
+	}
+	if cover.CoverImage != nil {
+		result["coverImage"] = *cover.CoverImage
+	}
+	return result, nil
+}
+
+// BoardCoverKey is where a board's cover is stored; it sits under the board's prefix with its selection images
+func BoardCoverKey(boardId uuid.UUID) string {
+	return boardId.String() + "/cover.png"
+}
+
+// VersionedURL adds the current time to the URL of an object that is overwritten in place, so clients
+// don't keep showing the cached old one
+func VersionedURL(url string) string {
+	return fmt.Sprintf("%s?v=%d", url, time.Now().Unix())
+}
@@ -0,0 +1,68 @@
+package tools
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+)
+
+func TestRenderBoardCover(t *testing.T) {
+	for _, size := range []image.Point{{1600, 900}, {300, 1200}, {5000, 200}} {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, size.X, size.Y))); err != nil {
+			t.Fatal(err)
+		}
+		rendered, err := RenderBoardCover(buf.Bytes())
+		if err != nil {
+			t.Fatalf("%v: unexpected error: %v", size, err)
+		}
+		cover, err := png.Decode(bytes.NewReader(rendered))
+		if err != nil {
+			t.Fatalf("%v: cover is not a PNG: %v", size, err)
+		}
+		if got := cover.Bounds().Size(); got != (image.Point{BoardCoverWidth, BoardCoverHeight}) {
+			t.Errorf("%v: got a %v cover", size, got)
+		}
+	}
+
+	if _, err := RenderBoardCover([]byte("not an image")); err == nil {
+		t.Error("expected an error for data that isn't an image")
+	}
+}
+
+func TestValidateBoardIcon(t *testing.T) {
+	for _, icon := range []string{"", "🗺️", "👩🏽‍💻", "🚀"} {
+		if err := ValidateBoardIcon(icon); err != nil {
+			t.Errorf("%q: unexpected error: %v", icon, err)
+		}
+	}
+	for _, icon := range []string{"map", "🚀 launch", "🚀🚀🚀🚀🚀🚀🚀🚀🚀"} {
+		if err := ValidateBoardIcon(icon); err == nil {
+			t.Errorf("%q: expected an error", icon)
+		}
+	}
+}
+
+func TestSetBoardCoverHandler(t *testing.T) {
+	tt := newToolTest(t)
+	result := tt.result(tt.deps.SetBoardCoverHandler(tt.ctx, map[string]interface{}{
+		"boardId":        tt.boardID.String(),
+		"icon":           "🗺️",
+		"description":    "  Q3 product roadmap  ",
+		"coverFromBoard": true,
+	}))
+
+	board := tt.boards.boards[tt.boardID]
+	if board.Icon != "🗺️" || board.Description != "Q3 product roadmap" {
+		t.Errorf("got icon %q and description %q", board.Icon, board.Description)
+	}
+	if !strings.HasPrefix(board.CoverImage, "https://storage.example/"+tt.boardID.String()+"/cover.png?v=") || result["coverImage"] != board.CoverImage {
+		t.Errorf("got cover %q, result %v", board.CoverImage, result)
+	}
+
+	if _, err := tt.deps.SetBoardCoverHandler(tt.ctx, map[string]interface{}{"boardId": tt.boardID.String()}); err == nil {
+		t.Error("expected an error when nothing is set")
+	}
+}
@@ -0,0 +1,107 @@
+package tools
+
+import (
+	"fmt"
+	"melina-studio-backend/internal/models"
+	"sort"
+	"strings"
+)
+
+// outlineItem is a shape that carries readable content for the board outline
+type outlineItem struct {
+	id     string
+	label  string
+	bounds BoundingBox
+}
+
+// BuildBoardOutline renders a plain-text outline of a board for LLM prompts
+// Frames become sections containing the text inside them, in reading order (top to bottom, left to right)
+func BuildBoardOutline(shapes []models.BoardData) string {
+	var frames, texts []outlineItem
+	typeCounts := map[string]int{}
+
+	for _, shape := range shapes {
+		typeCounts[string(shape.Type)]++
+
+		bounds, data, err := GetShapeBounds(shape, 0)
+		if err != nil {
+			continue
+		}
+		switch shape.Type {
+		case models.Frame:
+			name, _ := data["name"].(string)
+			if strings.TrimSpace(name) == "" {
+				name = "Untitled frame"
+			}
+			frames = append(frames, outlineItem{id: shape.UUID.String(), label: name, bounds: bounds})
+		case models.Text:
+			text, _ := data["text"].(string)
+			if strings.TrimSpace(text) == "" {
+				continue
+			}
+			texts = append(texts, outlineItem{id: shape.UUID.String(), label: text, bounds: bounds})
+		}
+	}
+
+	sortByReadingOrder(frames)
+	sortByReadingOrder(texts)
+
+	var sb strings.Builder
+	placed := make([]bool, len(texts))
+
+	for _, frame := range frames {
+		sb.WriteString(fmt.Sprintf("## %s\n", frame.label))
+		for i, text := range texts {
+			if placed[i] || !containsBounds(frame.bounds, text.bounds.MinX, text.bounds.MinY) {
+				continue
+			}
+			placed[i] = true
+			sb.WriteString(fmt.Sprintf("- %s\n", singleLine(text.label)))
+		}
+		sb.WriteString("\n")
+	}
+
+	var loose []string
+	for i, text := range texts {
+		if !placed[i] {
+			loose = append(loose, singleLine(text.label))
+		}
+	}
+	if len(loose) > 0 {
+		if len(frames) > 0 {
+			sb.WriteString("## Outside frames\n")
+		}
+		for _, text := range loose {
+			sb.WriteString(fmt.Sprintf("- %s\n", text))
+		}
+		sb.WriteString("\n")
+	}
+
+	if len(typeCounts) > 0 {
+		types := make([]string, 0, len(typeCounts))
+		for t := range typeCounts {
+			types = append(types, t)
+		}
+		sort.Strings(types)
+		parts := make([]string, 0, len(types))
+		for _, t := range types {
+			parts = append(parts, fmt.Sprintf("%d %s", typeCounts[t], t))
+		}
+		sb.WriteString(fmt.Sprintf("Shapes on board: %s\n", strings.Join(parts, ", ")))
+	}
+
+	return strings.TrimSpace(sb.String())
+}
+
+func sortByReadingOrder(items []outlineItem) {
+	sort.SliceStable(items, func(i, j int) bool {
+		if items[i].bounds.MinY != items[j].bounds.MinY {
+			return items[i].bounds.MinY < items[j].bounds.MinY
+		}
+		return items[i].bounds.MinX < items[j].bounds.MinX
+	})
+}
+
+func singleLine(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,266 @@
+package tools
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strings"
+
+	"github.com/fogleman/gg"
+	"github.com/google/uuid"
+
+	llmHandlers "melina-studio-backend/internal/llm_handlers"
+	"melina-studio-backend/internal/models"
+)
+
+// drawingTools are the tools that change what the board looks like
+var drawingTools = map[string]bool{
+	"addShape":           true,
+	"updateShape":        true,
+	"deleteShape":        true,
+	"clusterNotes":       true,
+	"extractActionItems": true,
+	"generateWireframe":  true,
+	"replaceText":        true,
+}
+
+// ChangedDrawing reports whether any of the tool calls of a turn drew on the board
+func ChangedDrawing(results []llmHandlers.ToolExecutionResult) bool {
+	for _, result := range results {
+		if drawingTools[result.ToolName] {
+			return true
+		}
+	}
+	return false
+}
+
+// SnapshotShapes combines the stored shapes with the ones created during the turn that the client
+// hasn't saved yet
+func SnapshotShapes(stored []models.BoardData, created []map[string]interface{}) []models.BoardData {
+	shapes := append([]models.BoardData(nil), stored...)
+	seen := make(map[uuid.UUID]bool, len(stored))
+	for _, shape := range stored {
+		seen[shape.UUID] = true
+	}
+
+	for _, shape := range created {
+		idStr, _ := shape["id"].(string)
+		id, err := uuid.Parse(idStr)
+		if err != nil || seen[id] {
+			continue
+		}
+		shapeType, _ := shape["type"].(string)
+		data := make(map[string]interface{}, len(shape))
+		for k, v := range shape {
+			if k != "id" && k != "type" {
+				data[k] = v
+			}
+		}
+		bytes, err := json.Marshal(data)
+		if err != nil {
+			continue
+		}
+		seen[id] = true
+		shapes = append(shapes, models.BoardData{UUID: id, Type: models.Type(shapeType), Data: bytes})
+	}
+	return shapes
+}
+
+const (
+	// snapshotMaxSide caps the longest side of a rendered snapshot, which is all a vision check needs
+	snapshotMaxSide = 1600.0
+	snapshotPadding = 40.0
+)
+
+// RenderBoardSnapshot draws the shapes server-side as a PNG with numbered badges and returns it base64 encoded.
+// The board image the client uploads lags behind the agent's edits, so checks made right after a turn
+// render the shapes themselves. Shapes are drawn as outlines with their labels; SVG paths and images
+// are drawn as their bounding boxes
+func RenderBoardSnapshot(shapes []models.BoardData) (string, error) {
+	if len(shapes) == 0 {
+		return "", fmt.Errorf("no shapes to render")
+	}
+
+	type renderedShape struct {
+		shape  models.BoardData
+		bounds BoundingBox
+		data   map[string]interface{}
+	}
+	rendered := make([]renderedShape, 0, len(shapes))
+	overall := BoundingBox{MinX: math.MaxFloat64, MinY: math.MaxFloat64, MaxX: -math.MaxFloat64, MaxY: -math.MaxFloat64}
+	for _, shape := range shapes {
+		bounds, data, err := GetShapeBounds(shape, 0)
+		if err != nil {
+			continue
+		}
+		rendered = append(rendered, renderedShape{shape: shape, bounds: bounds, data: data})
+		overall = mergeBounds(overall, bounds)
+	}
+	if len(rendered) == 0 {
+		return "", fmt.Errorf("no shapes to render")
+	}
+
+	width := overall.MaxX - overall.MinX + 2*snapshotPadding
+	height := overall.MaxY - overall.MinY + 2*snapshotPadding
+	scale := math.Min(1, snapshotMaxSide/math.Max(width, height))
+	dc := gg.NewContext(int(math.Ceil(width*scale)), int(math.Ceil(height*scale)))
+	dc.SetColor(color.White)
+	dc.Clear()
+
+	// canvas coordinates map to pixels through this transform
+	toPixel := func(x, y float64) (float64, float64) {
+		return (x - overall.MinX + snapshotPadding) * scale, (y - overall.MinY + snapshotPadding) * scale
+	}
+
+	for _, r := range rendered {
+		dc.Push()
+		dc.Scale(scale, scale)
+		dc.Translate(snapshotPadding-overall.MinX, snapshotPadding-overall.MinY)
+		drawSnapshotShape(dc, string(r.shape.Type), r.data, r.bounds)
+		dc.Pop()
+	}
+
+	badge := DefaultBadgeConfig()
+	setFontSize(dc, badge.FontSize)
+	for i, r := range rendered {
+		number := r.shape.AnnotationNumber
+		if number == 0 {
+			number = i + 1
+		}
+		cx, cy, ok := CalculateShapeCenter(string(r.shape.Type), r.data)
+		if !ok {
+			cx, cy = (r.bounds.MinX+r.bounds.MaxX)/2, (r.bounds.MinY+r.bounds.MaxY)/2
+		}
+		px, py := toPixel(cx, cy)
+		drawBadge(dc, px, py, number, badge)
+	}
+
+	data, err := encodePNG(dc.Image().(*image.RGBA))
+	if err != nil {
+		return "", fmt.Errorf("failed to encode board snapshot: %w", err)
+	}
+	return base64.StdEncoding.EncodeToString(data), nil
+}
+
+// drawSnapshotShape draws one shape in canvas coordinates
+func drawSnapshotShape(dc *gg.Context, shapeType string, data map[string]interface{}, bounds BoundingBox) {
+	stroke, _ := data["stroke"].(string)
+	fill, _ := data["fill"].(string)
+	strokeWidth, _ := data["strokeWidth"].(float64)
+	if strokeWidth <= 0 {
+		strokeWidth = 2
+	}
+	dc.SetLineWidth(strokeWidth)
+
+	w := bounds.MaxX - bounds.MinX
+	h := bounds.MaxY - bounds.MinY
+
+	switch shapeType {
+	case "circle", "ellipse":
+		dc.DrawEllipse(bounds.MinX+w/2, bounds.MinY+h/2, w/2, h/2)
+		fillAndStroke(dc, fill, stroke)
+
+	case "line", "arrow", "pencil", "polygon":
+		points := snapshotPoints(data)
+		if len(points) < 4 {
+			return
+		}
+		offsetX, _ := data["x"].(float64)
+		offsetY, _ := data["y"].(float64)
+		dc.MoveTo(points[0]+offsetX, points[1]+offsetY)
+		for i := 2; i+1 < len(points); i += 2 {
+			dc.LineTo(points[i]+offsetX, points[i+1]+offsetY)
+		}
+		if shapeType == "polygon" {
+			dc.ClosePath()
+			fillAndStroke(dc, fill, stroke)
+			return
+		}
+		setSnapshotColor(dc, stroke, color.Black)
+		dc.Stroke()
+		if shapeType == "arrow" {
+			n := len(points)
+			drawArrowHead(dc, points[n-4]+offsetX, points[n-3]+offsetY, points[n-2]+offsetX, points[n-1]+offsetY, strokeWidth)
+		}
+
+	case "text":
+		fontSize, _ := data["fontSize"].(float64)
+		if fontSize <= 0 {
+			fontSize = 16
+		}
+		text, _ := data["text"].(string)
+		setFontSize(dc, fontSize)
+		setSnapshotColor(dc, fill, color.Black)
+		for i, line := range strings.Split(text, "\n") {
+			dc.DrawString(line, bounds.MinX, bounds.MinY+fontSize*(1+1.4*float64(i)))
+		}
+
+	case "path", "image":
+		dc.SetDash(6, 4)
+		dc.DrawRectangle(bounds.MinX, bounds.MinY, w, h)
+		setSnapshotColor(dc, stroke, color.Gray{Y: 128})
+		dc.Stroke()
+		dc.SetDash()
+
+	default:
+		dc.DrawRectangle(bounds.MinX, bounds.MinY, w, h)
+		fillAndStroke(dc, fill, stroke)
+		if label := snapshotLabel(data); label != "" && setFontSize(dc, 14) {
+			dc.SetColor(color.Black)
+			dc.DrawStringWrapped(label, bounds.MinX+w/2, bounds.MinY+h/2, 0.5, 0.5, math.Max(w-8, 10), 1.3, gg.AlignCenter)
+		}
+	}
+}
+
+// fillAndStroke fills the current path when the shape has a fill and outlines it
+func fillAndStroke(dc *gg.Context, fill string, stroke string) {
+	if strings.HasPrefix(fill, "#") {
+		dc.SetHexColor(fill)
+		dc.FillPreserve()
+	}
+	setSnapshotColor(dc, stroke, color.Black)
+	dc.Stroke()
+}
+
+func setSnapshotColor(dc *gg.Context, hex string, fallback color.Color) {
+	if strings.HasPrefix(hex, "#") {
+		dc.SetHexColor(hex)
+		return
+	}
+	dc.SetColor(fallback)
+}
+
+// drawArrowHead draws the head of an arrow ending at (x2, y2)
+func drawArrowHead(dc *gg.Context, x1, y1, x2, y2, strokeWidth float64) {
+	angle := math.Atan2(y2-y1, x2-x1)
+	size := 8 + strokeWidth*2
+	dc.MoveTo(x2, y2)
+	dc.LineTo(x2-size*math.Cos(angle-math.Pi/6), y2-size*math.Sin(angle-math.Pi/6))
+	dc.LineTo(x2-size*math.Cos(angle+math.Pi/6), y2-size*math.Sin(angle+math.Pi/6))
+	dc.ClosePath()
+	dc.Fill()
+}
+
+func snapshotPoints(data map[string]interface{}) []float64 {
+	raw, _ := data["points"].([]interface{})
+	points := make([]float64, 0, len(raw))
+	for _, v := range raw {
+		if f, ok := v.(float64); ok {
+			points = append(points, f)
+		}
+	}
+	return points
+}
+
+// snapshotLabel is the text shown inside box-like shapes: frames, cards, buttons and entities
+func snapshotLabel(data map[string]interface{}) string {
+	for _, key := range []string{"text", "label", "title", "name"} {
+		if s, ok := data[key].(string); ok && s != "" {
+			return s
+		}
+	}
+	return ""
+}
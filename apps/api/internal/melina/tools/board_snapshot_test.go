@@ -0,0 +1,100 @@
+package tools
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"image/png"
+	"testing"
+
+	"github.com/google/uuid"
+
+	llmHandlers "melina-studio-backend/internal/llm_handlers"
+	"melina-studio-backend/internal/models"
+)
+
+func snapshotTestShape(t *testing.T, shapeType models.Type, data map[string]interface{}) models.BoardData {
+	t.Helper()
+	raw, err := json.Marshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return models.BoardData{UUID: uuid.New(), Type: shapeType, Data: raw}
+}
+
+func TestRenderBoardSnapshotFitsTheShapes(t *testing.T) {
+	shapes := []models.BoardData{
+		snapshotTestShape(t, models.Rect, map[string]interface{}{"x": 100.0, "y": 100.0, "w": 200.0, "h": 100.0, "fill": "#ffeeaa"}),
+		snapshotTestShape(t, models.Arrow, map[string]interface{}{"points": []float64{300, 150, 500, 150}}),
+		snapshotTestShape(t, models.Circle, map[string]interface{}{"x": 560.0, "y": 150.0, "r": 60.0}),
+		snapshotTestShape(t, models.Text, map[string]interface{}{"x": 120.0, "y": 120.0, "text": "Start"}),
+	}
+
+	snapshot, err := RenderBoardSnapshot(shapes)
+	if err != nil {
+		t.Fatalf("render failed: %v", err)
+	}
+	data, err := base64.StdEncoding.DecodeString(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("snapshot is not a PNG: %v", err)
+	}
+
+	// shapes span x 100..620 and y 90..210, plus padding on each side
+	bounds := img.Bounds()
+	if bounds.Dx() != int(520+2*snapshotPadding) || bounds.Dy() != int(120+2*snapshotPadding) {
+		t.Fatalf("unexpected size %dx%d", bounds.Dx(), bounds.Dy())
+	}
+}
+
+func TestRenderBoardSnapshotScalesLargeBoards(t *testing.T) {
+	shapes := []models.BoardData{
+		snapshotTestShape(t, models.Rect, map[string]interface{}{"x": 0.0, "y": 0.0, "w": 8000.0, "h": 1000.0}),
+	}
+	snapshot, err := RenderBoardSnapshot(shapes)
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, _ := base64.StdEncoding.DecodeString(snapshot)
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if img.Bounds().Dx() > int(snapshotMaxSide) {
+		t.Fatalf("width %d exceeds %v", img.Bounds().Dx(), snapshotMaxSide)
+	}
+}
+
+func TestSnapshotShapesAddsUnsavedShapesOnce(t *testing.T) {
+	stored := []models.BoardData{snapshotTestShape(t, models.Rect, map[string]interface{}{"x": 0.0, "y": 0.0})}
+	newID := uuid.New().String()
+	created := []map[string]interface{}{
+		{"id": stored[0].UUID.String(), "type": "rect", "x": 0.0, "y": 0.0},
+		{"id": newID, "type": "circle", "x": 10.0, "y": 10.0, "r": 5.0},
+		{"id": "not-a-uuid", "type": "rect"},
+	}
+
+	shapes := SnapshotShapes(stored, created)
+	if len(shapes) != 2 {
+		t.Fatalf("got %d shapes, want 2", len(shapes))
+	}
+	if shapes[1].UUID.String() != newID || shapes[1].Type != models.Circle {
+		t.Fatalf("unexpected unsaved shape: %+v", shapes[1])
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal(shapes[1].Data, &data); err != nil || data["r"] != 5.0 || data["id"] != nil {
+		t.Fatalf("unexpected data %s", shapes[1].Data)
+	}
+}
+
+func TestChangedDrawing(t *testing.T) {
+	if ChangedDrawing([]llmHandlers.ToolExecutionResult{{ToolName: "getBoardData"}, {ToolName: "validateDiagram"}}) {
+		t.Fatal("read-only tools should not count as drawing")
+	}
+	if !ChangedDrawing([]llmHandlers.ToolExecutionResult{{ToolName: "getBoardData"}, {ToolName: "addShape"}}) {
+		t.Fatal("addShape should count as drawing")
+	}
+}
@@ -0,0 +1,236 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"sort"
+
+	llmHandlers "melina-studio-backend/internal/llm_handlers"
+	"melina-studio-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+var getBoardStatsSchema = toolSchema{
+	Name:        "getBoardStats",
+	Description: "Returns cheap statistics about the board without the image: shape counts by type, the extent of the content, empty areas inside it and next to it where new shapes fit, and a summary of the connector graph (nodes, connectors, dangling ends, separate groups, cycles, the most connected nodes). Use it to answer questions like \"how complex is this diagram\" or to choose where to place new content; call getBoardData when you need to see or edit specific shapes.",
+	Parameters: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"boardId": map[string]interface{}{
+				"type":        "string",
+				"description": "The UUID of the board",
+			},
+		},
+		"required": []string{"boardId"},
+	},
+}
+
+const (
+	// the content is divided into about this many cells per side to look for empty areas
+	statsGridCells = 40
+	statsMinCell   = 50.0
+	// empty areas smaller than this on either side are gaps between shapes, not room for content
+	statsMinEmptySide = 150.0
+	statsMaxEmpty     = 3
+	statsTopNodes     = 3
+	// new content placed next to the existing content keeps this distance
+	statsPlacementGap = 100.0
+)
+
+// StatsRect is an axis-aligned area of the board
+type StatsRect struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// ConnectedNode is a node of the diagram with the number of connectors attached to it
+type ConnectedNode struct {
+	ShapeID    string `json:"shapeId"`
+	Name       string `json:"name"`
+	Connectors int    `json:"connectors"`
+}
+
+// BoardStats summarizes a board for the getBoardStats tool
+type BoardStats struct {
+	TotalShapes int            `json:"totalShapes"`
+	ByType      map[string]int `json:"byType"`
+	Locked      int            `json:"locked"`
+	Regions     int            `json:"regions"` // clusters of nearby shapes
+	Extent      *StatsRect     `json:"extent,omitempty"`
+	// EmptyAreas are the largest areas inside the extent without shapes, largest first
+	EmptyAreas []StatsRect `json:"emptyAreas"`
+	// Placement suggests where new content can go without overlapping, right of and below the content
+	Placement     map[string]StatsRect `json:"placement,omitempty"`
+	Diagram       DiagramSummary       `json:"diagram"`
+	MostConnected []ConnectedNode      `json:"mostConnected,omitempty"`
+}
+
+// ComputeBoardStats builds the statistics of a board's shapes
+func ComputeBoardStats(shapes []models.BoardData) BoardStats {
+	stats := BoardStats{
+		TotalShapes: len(shapes),
+		ByType:      map[string]int{},
+		EmptyAreas:  []StatsRect{},
+	}
+	var bounds []BoundingBox
+	for _, shape := range shapes {
+		stats.ByType[string(shape.Type)]++
+		if shape.Locked {
+			stats.Locked++
+		}
+		if b, _, err := GetShapeBounds(shape, 0); err == nil {
+			bounds = append(bounds, b)
+		}
+	}
+
+	if state := GenerateCanvasState(shapes, 0, 100.0); state != nil {
+		extent := state.OverallBounds
+		stats.Regions = len(state.OccupiedRegions)
+		stats.Extent = &StatsRect{X: extent.MinX, Y: extent.MinY, Width: extent.MaxX - extent.MinX, Height: extent.MaxY - extent.MinY}
+		stats.EmptyAreas = emptyAreas(extent, bounds)
+		stats.Placement = map[string]StatsRect{
+			"right": {X: extent.MaxX + statsPlacementGap, Y: extent.MinY, Width: stats.Extent.Width, Height: stats.Extent.Height},
+			"below": {X: extent.MinX, Y: extent.MaxY + statsPlacementGap, Width: stats.Extent.Width, Height: stats.Extent.Height},
+		}
+	}
+
+	nodes, edges := buildDiagramGraph(shapes)
+	stats.Diagram = SummarizeDiagram(shapes)
+	degree := make([]int, len(nodes))
+	for _, edge := range edges {
+		if edge.from >= 0 {
+			degree[edge.from]++
+		}
+		if edge.to >= 0 && edge.to != edge.from {
+			degree[edge.to]++
+		}
+	}
+	order := make([]int, 0, len(nodes))
+	for i := range nodes {
+		if degree[i] > 0 {
+			order = append(order, i)
+		}
+	}
+	sort.SliceStable(order, func(a, b int) bool { return degree[order[a]] > degree[order[b]] })
+	for _, i := range order[:min(len(order), statsTopNodes)] {
+		stats.MostConnected = append(stats.MostConnected, ConnectedNode{ShapeID: nodes[i].id, Name: nodeName(nodes[i]), Connectors: degree[i]})
+	}
+	return stats
+}
+
+// emptyAreas finds the largest empty rectangles inside the extent on a coarse grid of the shapes' bounds
+func emptyAreas(extent BoundingBox, bounds []BoundingBox) []StatsRect {
+	width, height := extent.MaxX-extent.MinX, extent.MaxY-extent.MinY
+	cell := math.Max(statsMinCell, math.Max(width, height)/statsGridCells)
+	cols, rows := int(math.Ceil(width/cell)), int(math.Ceil(height/cell))
+	if cols == 0 || rows == 0 {
+		return []StatsRect{}
+	}
+
+	occupied := make([][]bool, rows)
+	for r := range occupied {
+		occupied[r] = make([]bool, cols)
+	}
+	for _, b := range bounds {
+		c0, c1 := gridSpan(b.MinX-extent.MinX, b.MaxX-extent.MinX, cell, cols)
+		r0, r1 := gridSpan(b.MinY-extent.MinY, b.MaxY-extent.MinY, cell, rows)
+		for r := r0; r <= r1; r++ {
+			for c := c0; c <= c1; c++ {
+				occupied[r][c] = true
+			}
+		}
+	}
+
+	minCells := int(math.Ceil(statsMinEmptySide / cell))
+	areas := []StatsRect{}
+	for len(areas) < statsMaxEmpty {
+		r0, c0, r1, c1, ok := largestEmptyRect(occupied)
+		if !ok || r1-r0+1 < minCells || c1-c0+1 < minCells {
+			break
+		}
+		for r := r0; r <= r1; r++ {
+			for c := c0; c <= c1; c++ {
+				occupied[r][c] = true
+			}
+		}
+		areas = append(areas, StatsRect{
+			X:      extent.MinX + float64(c0)*cell,
+			Y:      extent.MinY + float64(r0)*cell,
+			Width:  math.Min(float64(c1-c0+1)*cell, extent.MaxX-extent.MinX-float64(c0)*cell),
+			Height: math.Min(float64(r1-r0+1)*cell, extent.MaxY-extent.MinY-float64(r0)*cell),
+		})
+	}
+	return areas
+}
+
+// gridSpan returns the cells from lo to hi touch, clamped to the grid
+func gridSpan(lo, hi, cell float64, n int) (int, int) {
+	first := max(0, min(n-1, int(math.Floor(lo/cell))))
+	last := max(0, min(n-1, int(math.Floor(hi/cell))))
+	return first, last
+}
+
+// largestEmptyRect returns the largest rectangle of free cells, scanning row histograms
+func largestEmptyRect(occupied [][]bool) (r0, c0, r1, c1 int, ok bool) {
+	cols := len(occupied[0])
+	heights := make([]int, cols)
+	best := 0
+	for r, row := range occupied {
+		for c, taken := range row {
+			if taken {
+				heights[c] = 0
+			} else {
+				heights[c]++
+			}
+		}
+		// widest run of columns at least h tall, for each column's height
+		for c := 0; c < cols; c++ {
+			h := heights[c]
+			if h == 0 {
+				continue
+			}
+			left, right := c, c
+			for left > 0 && heights[left-1] >= h {
+				left--
+			}
+			for right < cols-1 && heights[right+1] >= h {
+				right++
+			}
+			if area := h * (right - left + 1); area > best {
+				best = area
+				r0, c0, r1, c1, ok = r-h+1, left, r, right, true
+			}
+		}
+	}
+	return r0, c0, r1, c1, ok
+}
+
+// GetBoardStatsHandler is the handler for the getBoardStats tool
+func (d *ToolDeps) GetBoardStatsHandler(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+	boardIdStr, ok := input["boardId"].(string)
+	if !ok {
+		return nil, llmHandlers.InvalidToolInput("boardId is required and must be a string")
+	}
+	boardId, err := uuid.Parse(boardIdStr)
+	if err != nil {
+		return nil, llmHandlers.InvalidToolInput("invalid boardId: %v", err)
+	}
+
+	shapes, err := d.BoardData.GetBoardData(boardId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shapes from database: %w", err)
+	}
+	stats := ComputeBoardStats(shapes)
+
+	return map[string]interface{}{
+		"success": true,
+		"boardId": boardIdStr,
+		"stats":   stats,
+		"message": fmt.Sprintf("Board has %d shapes in %d regions, %d diagram nodes and %d connectors",
+			stats.TotalShapes, stats.Regions, stats.Diagram.Nodes, stats.Diagram.Connectors),
+	}, nil
+}
@@ -0,0 +1,54 @@
+package tools
+
+import (
+	"testing"
+
+	"melina-studio-backend/internal/models"
+)
+
+func TestComputeBoardStats(t *testing.T) {
+	start := boardShape(t, models.Rect, map[string]interface{}{"x": 0.0, "y": 0.0, "w": 100.0, "h": 100.0})
+	shapes := []models.BoardData{
+		start,
+		boardShape(t, models.Rect, map[string]interface{}{"x": 900.0, "y": 0.0, "w": 100.0, "h": 100.0}),
+		boardShape(t, models.Rect, map[string]interface{}{"x": 0.0, "y": 900.0, "w": 100.0, "h": 100.0}),
+		boardShape(t, models.Arrow, map[string]interface{}{"start": map[string]interface{}{"x": 100.0, "y": 50.0}, "end": map[string]interface{}{"x": 900.0, "y": 50.0}}),
+		boardShape(t, models.Arrow, map[string]interface{}{"start": map[string]interface{}{"x": 50.0, "y": 100.0}, "end": map[string]interface{}{"x": 50.0, "y": 900.0}}),
+	}
+	shapes[2].Locked = true
+
+	stats := ComputeBoardStats(shapes)
+	if stats.TotalShapes != 5 || stats.ByType["rect"] != 3 || stats.ByType["arrow"] != 2 || stats.Locked != 1 {
+		t.Errorf("unexpected counts %+v", stats)
+	}
+	if stats.Extent == nil || stats.Extent.Width != 1000 || stats.Extent.Height != 1000 {
+		t.Errorf("expected a 1000x1000 extent, got %+v", stats.Extent)
+	}
+	if stats.Diagram.Nodes != 3 || stats.Diagram.Connectors != 2 || stats.Diagram.Components != 1 {
+		t.Errorf("unexpected diagram summary %+v", stats.Diagram)
+	}
+	if len(stats.MostConnected) == 0 || stats.MostConnected[0].ShapeID != start.UUID.String() || stats.MostConnected[0].Connectors != 2 {
+		t.Errorf("expected the first rect to be the most connected, got %+v", stats.MostConnected)
+	}
+
+	if len(stats.EmptyAreas) == 0 {
+		t.Fatal("expected an empty area between the rects")
+	}
+	area := stats.EmptyAreas[0]
+	if area.Width < 600 || area.Height < 600 {
+		t.Errorf("expected the large empty middle of the board, got %+v", area)
+	}
+	for _, shape := range shapes {
+		b, _, _ := GetShapeBounds(shape, 0)
+		if b.MinX < area.X+area.Width && b.MaxX > area.X && b.MinY < area.Y+area.Height && b.MaxY > area.Y {
+			t.Errorf("empty area %+v overlaps shape %v", area, b)
+		}
+	}
+}
+
+func TestComputeBoardStatsEmpty(t *testing.T) {
+	stats := ComputeBoardStats(nil)
+	if stats.TotalShapes != 0 || stats.Extent != nil || len(stats.EmptyAreas) != 0 {
+		t.Errorf("unexpected stats for an empty board %+v", stats)
+	}
+}
@@ -0,0 +1,159 @@
+package tools
+
+import (
+	"fmt"
+	"html"
+	"math"
+	"strconv"
+	"strings"
+
+	"melina-studio-backend/internal/models"
+)
+
+// svgPadding is the margin around the shapes of an SVG export, in canvas units
+const svgPadding = 40.0
+
+// RenderBoardSVG draws the shapes as an SVG document in canvas units, for exports that stay editable in design
+// tools. Like RenderBoardSnapshot it covers the shape types the agent draws; images are drawn as their
+// bounding boxes. A non-empty watermark is written in the bottom-right corner
+func RenderBoardSVG(shapes []models.BoardData, watermark string) ([]byte, error) {
+	type renderedShape struct {
+		shape  models.BoardData
+		bounds BoundingBox
+		data   map[string]interface{}
+	}
+	rendered := make([]renderedShape, 0, len(shapes))
+	overall := BoundingBox{MinX: math.MaxFloat64, MinY: math.MaxFloat64, MaxX: -math.MaxFloat64, MaxY: -math.MaxFloat64}
+	for _, shape := range shapes {
+		bounds, data, err := GetShapeBounds(shape, 0)
+		if err != nil {
+			continue
+		}
+		rendered = append(rendered, renderedShape{shape: shape, bounds: bounds, data: data})
+		overall = mergeBounds(overall, bounds)
+	}
+	if len(rendered) == 0 {
+		return nil, fmt.Errorf("no shapes to render")
+	}
+
+	minX, minY := overall.MinX-svgPadding, overall.MinY-svgPadding
+	width := overall.MaxX - overall.MinX + 2*svgPadding
+	height := overall.MaxY - overall.MinY + 2*svgPadding
+
+	var b strings.Builder
+	fmt.Fprintf(&b, `<svg xmlns="http://www.w3.org/2000/svg" width="%s" height="%s" viewBox="%s %s %s %s" font-family="Helvetica, Arial, sans-serif">`+"\n",
+		svgNum(width), svgNum(height), svgNum(minX), svgNum(minY), svgNum(width), svgNum(height))
+	b.WriteString(`<defs><marker id="arrowhead" viewBox="0 0 10 10" refX="9" refY="5" markerWidth="6" markerHeight="6" orient="auto-start-reverse">` +
+		`<path d="M0 0 L10 5 L0 10 Z" fill="context-stroke"/></marker></defs>` + "\n")
+	fmt.Fprintf(&b, `<rect x="%s" y="%s" width="%s" height="%s" fill="#ffffff"/>`+"\n", svgNum(minX), svgNum(minY), svgNum(width), svgNum(height))
+	for _, r := range rendered {
+		writeSVGShape(&b, string(r.shape.Type), r.data, r.bounds)
+	}
+	if watermark != "" {
+		fmt.Fprintf(&b, `<text x="%s" y="%s" font-size="14" fill="#000000" fill-opacity="0.5" text-anchor="end">%s</text>`+"\n",
+			svgNum(minX+width-12), svgNum(minY+height-12), html.EscapeString(watermark))
+	}
+	b.WriteString("</svg>\n")
+	return []byte(b.String()), nil
+}
+
+// writeSVGShape writes one shape in canvas coordinates, the SVG counterpart of drawSnapshotShape
+func writeSVGShape(b *strings.Builder, shapeType string, data map[string]interface{}, bounds BoundingBox) {
+	stroke, _ := data["stroke"].(string)
+	fill, _ := data["fill"].(string)
+	strokeWidth, _ := data["strokeWidth"].(float64)
+	if strokeWidth <= 0 {
+		strokeWidth = 2
+	}
+	paint := fmt.Sprintf(`fill="%s" stroke="%s" stroke-width="%s"`, svgColor(fill, "none"), svgColor(stroke, "#000000"), svgNum(strokeWidth))
+
+	w := bounds.MaxX - bounds.MinX
+	h := bounds.MaxY - bounds.MinY
+
+	switch shapeType {
+	case "circle", "ellipse":
+		fmt.Fprintf(b, `<ellipse cx="%s" cy="%s" rx="%s" ry="%s" %s/>`+"\n",
+			svgNum(bounds.MinX+w/2), svgNum(bounds.MinY+h/2), svgNum(w/2), svgNum(h/2), paint)
+
+	case "line", "arrow", "pencil", "polygon":
+		points := snapshotPoints(data)
+		if len(points) < 4 {
+			return
+		}
+		offsetX, _ := data["x"].(float64)
+		offsetY, _ := data["y"].(float64)
+		coords := make([]string, 0, len(points)/2)
+		for i := 0; i+1 < len(points); i += 2 {
+			coords = append(coords, svgNum(points[i]+offsetX)+","+svgNum(points[i+1]+offsetY))
+		}
+		if shapeType == "polygon" {
+			fmt.Fprintf(b, `<polygon points="%s" %s/>`+"\n", strings.Join(coords, " "), paint)
+			return
+		}
+		marker := ""
+		if shapeType == "arrow" {
+			marker = ` marker-end="url(#arrowhead)"`
+		}
+		fmt.Fprintf(b, `<polyline points="%s" fill="none" stroke="%s" stroke-width="%s" stroke-linecap="round" stroke-linejoin="round"%s/>`+"\n",
+			strings.Join(coords, " "), svgColor(stroke, "#000000"), svgNum(strokeWidth), marker)
+
+	case "text":
+		fontSize, _ := data["fontSize"].(float64)
+		if fontSize <= 0 {
+			fontSize = 16
+		}
+		text, _ := data["text"].(string)
+		writeSVGText(b, text, bounds.MinX, bounds.MinY+fontSize, fontSize, svgColor(fill, "#000000"), "start")
+
+	case "path":
+		d, _ := data["data"].(string)
+		if d == "" {
+			return
+		}
+		fmt.Fprintf(b, `<path d="%s" transform="translate(%s %s)" %s/>`+"\n", html.EscapeString(d), svgNum(bounds.MinX), svgNum(bounds.MinY), paint)
+
+	case "image":
+		fmt.Fprintf(b, `<rect x="%s" y="%s" width="%s" height="%s" fill="none" stroke="%s" stroke-dasharray="6 4"/>`+"\n",
+			svgNum(bounds.MinX), svgNum(bounds.MinY), svgNum(w), svgNum(h), svgColor(stroke, "#808080"))
+
+	case "frame":
+		fmt.Fprintf(b, `<rect x="%s" y="%s" width="%s" height="%s" %s/>`+"\n", svgNum(bounds.MinX), svgNum(bounds.MinY), svgNum(w), svgNum(h), paint)
+		if name, _ := data["name"].(string); name != "" {
+			writeSVGText(b, name, bounds.MinX, bounds.MinY-8, 14, "#000000", "start")
+		}
+
+	default:
+		fmt.Fprintf(b, `<rect x="%s" y="%s" width="%s" height="%s" %s/>`+"\n", svgNum(bounds.MinX), svgNum(bounds.MinY), svgNum(w), svgNum(h), paint)
+		if label := snapshotLabel(data); label != "" {
+			lines := float64(strings.Count(label, "\n") + 1)
+			writeSVGText(b, label, bounds.MinX+w/2, bounds.MinY+h/2-(lines-1)*14*0.65+5, 14, "#000000", "middle")
+		}
+	}
+}
+
+// writeSVGText writes text with one tspan per line, the first line's baseline at y
+func writeSVGText(b *strings.Builder, text string, x, y, fontSize float64, fill string, anchor string) {
+	fmt.Fprintf(b, `<text x="%s" y="%s" font-size="%s" fill="%s" text-anchor="%s">`, svgNum(x), svgNum(y), svgNum(fontSize), fill, anchor)
+	for i, line := range strings.Split(text, "\n") {
+		dy := "0"
+		if i > 0 {
+			dy = svgNum(fontSize * 1.4)
+		}
+		fmt.Fprintf(b, `<tspan x="%s" dy="%s">%s</tspan>`, svgNum(x), dy, html.EscapeString(line))
+	}
+	b.WriteString("</text>\n")
+}
+
+// svgColor keeps hex colors only, so shape data can't inject markup through an attribute
+func svgColor(value string, fallback string) string {
+	if len(value) > 1 && len(value) <= 9 && value[0] == '#' {
+		if _, err := strconv.ParseUint(value[1:], 16, 64); err == nil {
+			return value
+		}
+	}
+	return fallback
+}
+
+func svgNum(f float64) string {
+	return strconv.FormatFloat(math.Round(f*100)/100, 'f', -1, 64)
+}
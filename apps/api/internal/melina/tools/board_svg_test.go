@@ -0,0 +1,47 @@
+package tools
+
+import (
+	"encoding/xml"
+	"strings"
+	"testing"
+
+	"melina-studio-backend/internal/models"
+)
+
+func TestRenderBoardSVG(t *testing.T) {
+	shapes := []models.BoardData{
+		snapshotTestShape(t, models.Rect, map[string]interface{}{"x": 100.0, "y": 100.0, "w": 200.0, "h": 100.0, "fill": "#ffeeaa", "text": "Login <form>"}),
+		snapshotTestShape(t, models.Arrow, map[string]interface{}{"points": []float64{300, 150, 500, 150}, "stroke": `red" onload="alert(1)`}),
+		snapshotTestShape(t, models.Circle, map[string]interface{}{"x": 560.0, "y": 150.0, "r": 60.0}),
+		snapshotTestShape(t, models.Frame, map[string]interface{}{"x": 50.0, "y": 50.0, "w": 700.0, "h": 300.0, "name": "Flow"}),
+	}
+
+	svg, err := RenderBoardSVG(shapes, "Made with Melina Studio")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := xml.Unmarshal(svg, new(interface{})); err != nil {
+		t.Fatalf("expected well-formed XML, got %v:\n%s", err, svg)
+	}
+	doc := string(svg)
+	for _, want := range []string{
+		`viewBox="10 10 780 380"`,
+		`<rect x="100" y="100" width="200" height="100" fill="#ffeeaa"`,
+		`Login &lt;form&gt;`,
+		`marker-end="url(#arrowhead)"`,
+		`<ellipse cx="560" cy="150" rx="60" ry="60"`,
+		`>Flow</tspan>`,
+		`>Made with Melina Studio</text>`,
+	} {
+		if !strings.Contains(doc, want) {
+			t.Errorf("expected %q in\n%s", want, doc)
+		}
+	}
+	if strings.Contains(doc, "onload") {
+		t.Errorf("expected the invalid stroke to be dropped, got\n%s", doc)
+	}
+
+	if _, err := RenderBoardSVG(nil, ""); err == nil {
+		t.Error("expected an empty board to fail")
+	}
+}
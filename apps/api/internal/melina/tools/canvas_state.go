@@ -67,7 +67,7 @@ func GetShapeBounds(shapeData models.BoardData, padding float64) (BoundingBox, m
 	shapeType := string(shapeData.Type)
 
 	switch shapeType {
-	case "rect", "frame":
+	case "rect", "frame", "action_item", "timeline", "milestone", "button", "input", "navbar", "card", "entity":
 		x := getFloat("x", 0)
 		y := getFloat("y", 0)
 		w := getFloat("w", 100)
@@ -0,0 +1,491 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"melina-studio-backend/internal/models"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/google/uuid"
+)
+
+var clusterNotesSchema = toolSchema{
+	Name:        "clusterNotes",
+	Description: "Groups similar sticky notes (text shapes, and the rect behind them if any) by meaning. Embeds every note text, clusters them with k-means, moves each group into its own labeled frame and returns the themes found. Use this for affinity mapping or when the user asks to group, cluster or organize notes by theme.",
+	Parameters: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"boardId": map[string]interface{}{
+				"type":        "string",
+				"description": "The UUID of the board",
+			},
+			"shapeIds": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "IDs of the text shapes to cluster (optional, defaults to every text shape on the board)",
+			},
+			"clusterCount": map[string]interface{}{
+				"type":        "number",
+				"description": "Number of groups to create (optional, picked automatically from the number of notes)",
+			},
+			"x": map[string]interface{}{
+				"type":        "number",
+				"description": "X coordinate of the first frame (optional, defaults to below existing content)",
+			},
+			"y": map[string]interface{}{
+				"type":        "number",
+				"description": "Y coordinate of the first frame (optional, defaults to below existing content)",
+			},
+		},
+		"required": []string{"boardId"},
+	},
+}
+
+const (
+	clusterFramePadding = 20.0
+	clusterFrameHeader  = 40.0
+	clusterFrameGap     = 80.0
+	clusterNoteGap      = 16.0
+	clusterMaxGroups    = 8
+)
+
+// clusterNote is a note being clustered, with the optional sticky rect behind its text
+type clusterNote struct {
+	text      map[string]interface{}
+	container map[string]interface{}
+	bounds    BoundingBox
+	content   string
+}
+
+// ClusterNotesHandler groups notes into labeled frames by semantic similarity
+func (d *ToolDeps) ClusterNotesHandler(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+	streamCtx, err := getStreamingContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	boardIdStr, ok := input["boardId"].(string)
+	if !ok || boardIdStr == "" {
+		return nil, fmt.Errorf("boardId is required and must be a non-empty string")
+	}
+	boardId, err := uuid.Parse(boardIdStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid boardId format: %w", err)
+	}
+
+	shapes, err := d.BoardData.GetBoardData(boardId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shapes from database: %w", err)
+	}
+
+	wanted := map[string]bool{}
+	if ids, ok := input["shapeIds"].([]interface{}); ok {
+		for _, id := range ids {
+			if s, ok := id.(string); ok {
+				wanted[s] = true
+			}
+		}
+	}
+
+	notes := collectClusterNotes(shapes, wanted)
+	if len(notes) < 2 {
+		return nil, fmt.Errorf("at least 2 text notes are required to cluster, found %d", len(notes))
+	}
+
+	k := defaultClusterCount(len(notes))
+	if count, ok := input["clusterCount"].(float64); ok && count >= 1 {
+		k = int(count)
+	}
+	if k > len(notes) {
+		k = len(notes)
+	}
+
+	texts := make([]string, len(notes))
+	for i, n := range notes {
+		texts[i] = n.content
+	}
+
+	// Prefer semantic embeddings, fall back to word counts when the embedding API is unavailable
+	method := "embeddings"
+	vectors, err := d.Embed(ctx, texts)
+	if err != nil {
+		fmt.Printf("Warning: embedding notes failed, falling back to term vectors: %v\n", err)
+		vectors = termFrequencyVectors(texts)
+		method = "term_frequency"
+	}
+
+	assignments := kMeansCluster(vectors, k, 50)
+	groups := make([][]int, k)
+	for i, c := range assignments {
+		groups[c] = append(groups[c], i)
+	}
+
+	// Start below existing content unless the caller picked a spot
+	originX, originY := 0.0, 0.0
+	if state := GenerateCanvasState(shapes, 0, 0); state != nil {
+		originX = state.OverallBounds.MinX
+		originY = state.OverallBounds.MaxY + clusterFrameGap
+	}
+	if x, ok := input["x"].(float64); ok {
+		originX = x
+	}
+	if y, ok := input["y"].(float64); ok {
+		originY = y
+	}
+
+	themes := make([]map[string]interface{}, 0, k)
+	frameX := originX
+	for _, group := range groups {
+		if len(group) == 0 {
+			continue
+		}
+
+		groupTexts := make([]string, len(group))
+		for i, idx := range group {
+			groupTexts[i] = texts[idx]
+		}
+		label := clusterTheme(groupTexts, texts)
+
+		// Size the frame around a single column of notes
+		frameW := 260.0
+		for _, idx := range group {
+			frameW = math.Max(frameW, notes[idx].bounds.MaxX-notes[idx].bounds.MinX+2*clusterFramePadding)
+		}
+		cursorY := originY + clusterFrameHeader
+		noteIds := make([]string, 0, len(group))
+		for _, idx := range group {
+			n := notes[idx]
+			dx := frameX + clusterFramePadding - n.bounds.MinX
+			dy := cursorY - n.bounds.MinY
+			if n.container != nil {
+				offsetShape(n.container, dx, dy)
+				if err := d.persistShapeMove(streamCtx, boardId, n.container); err != nil {
+					return nil, fmt.Errorf("failed to move note: %w", err)
+				}
+			}
+			offsetShape(n.text, dx, dy)
+			if err := d.persistShapeMove(streamCtx, boardId, n.text); err != nil {
+				return nil, fmt.Errorf("failed to move note: %w", err)
+			}
+			noteIds = append(noteIds, n.text["id"].(string))
+			cursorY += n.bounds.MaxY - n.bounds.MinY + clusterNoteGap
+		}
+		frameH := cursorY - originY + clusterFramePadding - clusterNoteGap
+
+		frame := map[string]interface{}{
+			"id":     uuid.New().String(),
+			"type":   "frame",
+			"x":      frameX,
+			"y":      originY,
+			"w":      frameW,
+			"h":      frameH,
+			"name":   label,
+			"stroke": "#94a3b8",
+		}
+		sendShapeCreated(ctx, streamCtx, boardIdStr, frame)
+
+		themes = append(themes, map[string]interface{}{
+			"theme":   label,
+			"frameId": frame["id"],
+			"noteIds": noteIds,
+			"notes":   groupTexts,
+		})
+		frameX += frameW + clusterFrameGap
+	}
+
+	d.invalidateBoardImageCache(streamCtx, boardId)
+
+	return map[string]interface{}{
+		"success": true,
+		"method":  method,
+		"themes":  themes,
+		"message": fmt.Sprintf("Grouped %d notes into %d themes. Theme labels are keyword based; rename the frames with updateShape if a better name fits.", len(notes), len(themes)),
+	}, nil
+}
+
+// collectClusterNotes finds text shapes and pairs each with the smallest rect that contains it
+func collectClusterNotes(shapes []models.BoardData, wanted map[string]bool) []clusterNote {
+	type rectShape struct {
+		data   map[string]interface{}
+		bounds BoundingBox
+		used   bool
+	}
+	var rects []*rectShape
+	var notes []clusterNote
+
+	for _, shape := range shapes {
+		// locked notes and frames stay where the user put them
+		if shape.Locked {
+			continue
+		}
+		bounds, _, err := GetShapeBounds(shape, 0)
+		if err != nil {
+			continue
+		}
+		data, err := shapeDataMap(shape.UUID, string(shape.Type), shape.Data)
+		if err != nil {
+			continue
+		}
+		switch shape.Type {
+		case models.Rect:
+			rects = append(rects, &rectShape{data: data, bounds: bounds})
+		case models.Text:
+			content, _ := data["text"].(string)
+			if strings.TrimSpace(content) == "" {
+				continue
+			}
+			if len(wanted) > 0 && !wanted[shape.UUID.String()] {
+				continue
+			}
+			notes = append(notes, clusterNote{text: data, bounds: bounds, content: content})
+		}
+	}
+
+	for i := range notes {
+		var best *rectShape
+		for _, r := range rects {
+			if r.used || !containsBounds(r.bounds, notes[i].bounds.MinX, notes[i].bounds.MinY) {
+				continue
+			}
+			if best == nil || boundsArea(r.bounds) < boundsArea(best.bounds) {
+				best = r
+			}
+		}
+		if best != nil {
+			best.used = true
+			notes[i].container = best.data
+			notes[i].bounds = mergeBounds(notes[i].bounds, best.bounds)
+		}
+	}
+
+	sort.SliceStable(notes, func(i, j int) bool {
+		if notes[i].bounds.MinY != notes[j].bounds.MinY {
+			return notes[i].bounds.MinY < notes[j].bounds.MinY
+		}
+		return notes[i].bounds.MinX < notes[j].bounds.MinX
+	})
+	return notes
+}
+
+func containsBounds(b BoundingBox, x, y float64) bool {
+	return x >= b.MinX && x <= b.MaxX && y >= b.MinY && y <= b.MaxY
+}
+
+func boundsArea(b BoundingBox) float64 {
+	return (b.MaxX - b.MinX) * (b.MaxY - b.MinY)
+}
+
+// offsetShape moves a shape data map by dx, dy
+func offsetShape(shape map[string]interface{}, dx, dy float64) {
+	if x, ok := shape["x"].(float64); ok {
+		shape["x"] = x + dx
+	}
+	if y, ok := shape["y"].(float64); ok {
+		shape["y"] = y + dy
+	}
+}
+
+// defaultClusterCount picks a group count from the number of notes (rule of thumb sqrt(n/2))
+func defaultClusterCount(n int) int {
+	k := int(math.Round(math.Sqrt(float64(n) / 2)))
+	if k < 1 {
+		k = 1
+	}
+	if k > clusterMaxGroups {
+		k = clusterMaxGroups
+	}
+	return k
+}
+
+// kMeansCluster assigns each vector to one of k clusters using cosine distance
+// Centroids are seeded deterministically (farthest point first) so results are stable across runs
+func kMeansCluster(vectors [][]float64, k int, maxIterations int) []int {
+	n := len(vectors)
+	assignments := make([]int, n)
+	if n == 0 || k <= 1 {
+		return assignments
+	}
+	if k > n {
+		k = n
+	}
+
+	normalized := make([][]float64, n)
+	for i, v := range vectors {
+		normalized[i] = normalizeVector(v)
+	}
+
+	centroids := [][]float64{normalized[0]}
+	for len(centroids) < k {
+		farthest, farthestDist := 0, -1.0
+		for i, v := range normalized {
+			nearest := math.MaxFloat64
+			for _, c := range centroids {
+				nearest = math.Min(nearest, cosineDistance(v, c))
+			}
+			if nearest > farthestDist {
+				farthest, farthestDist = i, nearest
+			}
+		}
+		centroids = append(centroids, normalized[farthest])
+	}
+
+	for iter := 0; iter < maxIterations; iter++ {
+		changed := iter == 0
+		for i, v := range normalized {
+			best, bestDist := 0, math.MaxFloat64
+			for c, centroid := range centroids {
+				if d := cosineDistance(v, centroid); d < bestDist {
+					best, bestDist = c, d
+				}
+			}
+			if assignments[i] != best {
+				assignments[i] = best
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+
+		dim := len(normalized[0])
+		sums := make([][]float64, k)
+		counts := make([]int, k)
+		for c := range sums {
+			sums[c] = make([]float64, dim)
+		}
+		for i, v := range normalized {
+			c := assignments[i]
+			counts[c]++
+			for d := range v {
+				sums[c][d] += v[d]
+			}
+		}
+		for c := range centroids {
+			if counts[c] > 0 {
+				centroids[c] = normalizeVector(sums[c])
+			}
+		}
+	}
+	return assignments
+}
+
+func normalizeVector(v []float64) []float64 {
+	var norm float64
+	for _, x := range v {
+		norm += x * x
+	}
+	norm = math.Sqrt(norm)
+	out := make([]float64, len(v))
+	if norm == 0 {
+		return out
+	}
+	for i, x := range v {
+		out[i] = x / norm
+	}
+	return out
+}
+
+// cosineDistance expects normalized vectors of the same length
+func cosineDistance(a, b []float64) float64 {
+	var dot float64
+	for i := range a {
+		if i < len(b) {
+			dot += a[i] * b[i]
+		}
+	}
+	return 1 - dot
+}
+
+var clusterStopWords = map[string]bool{
+	"a": true, "an": true, "the": true, "and": true, "or": true, "but": true, "to": true, "of": true,
+	"in": true, "on": true, "for": true, "with": true, "is": true, "are": true, "was": true, "were": true,
+	"be": true, "we": true, "our": true, "it": true, "its": true, "this": true, "that": true, "too": true,
+	"not": true, "no": true, "more": true, "less": true, "very": true, "at": true, "by": true, "as": true,
+	"from": true, "i": true, "you": true, "they": true, "so": true, "should": true, "could": true, "would": true,
+}
+
+// tokenizeNote lowercases a note and splits it into words, dropping stop words and short tokens
+func tokenizeNote(text string) []string {
+	words := strings.FieldsFunc(strings.ToLower(text), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	tokens := make([]string, 0, len(words))
+	for _, w := range words {
+		if len(w) < 3 || clusterStopWords[w] {
+			continue
+		}
+		tokens = append(tokens, w)
+	}
+	return tokens
+}
+
+// termFrequencyVectors builds bag-of-words vectors over a shared vocabulary
+func termFrequencyVectors(texts []string) [][]float64 {
+	vocab := map[string]int{}
+	tokenized := make([][]string, len(texts))
+	for i, t := range texts {
+		tokenized[i] = tokenizeNote(t)
+		for _, w := range tokenized[i] {
+			if _, ok := vocab[w]; !ok {
+				vocab[w] = len(vocab)
+			}
+		}
+	}
+	vectors := make([][]float64, len(texts))
+	for i, tokens := range tokenized {
+		v := make([]float64, len(vocab)+1)
+		// The extra dimension keeps notes without vocabulary words from being zero vectors
+		v[len(vocab)] = 0.01
+		for _, w := range tokens {
+			v[vocab[w]]++
+		}
+		vectors[i] = v
+	}
+	return vectors
+}
+
+// clusterTheme names a group after its most distinctive words compared with all notes
+func clusterTheme(groupTexts []string, allTexts []string) string {
+	docFreq := map[string]int{}
+	for _, t := range allTexts {
+		seen := map[string]bool{}
+		for _, w := range tokenizeNote(t) {
+			if !seen[w] {
+				docFreq[w]++
+				seen[w] = true
+			}
+		}
+	}
+
+	scores := map[string]float64{}
+	for _, t := range groupTexts {
+		for _, w := range tokenizeNote(t) {
+			scores[w] += math.Log(1 + float64(len(allTexts))/float64(docFreq[w]))
+		}
+	}
+	if len(scores) == 0 {
+		return "Other"
+	}
+
+	words := make([]string, 0, len(scores))
+	for w := range scores {
+		words = append(words, w)
+	}
+	sort.Slice(words, func(i, j int) bool {
+		if scores[words[i]] != scores[words[j]] {
+			return scores[words[i]] > scores[words[j]]
+		}
+		return words[i] < words[j]
+	})
+	if len(words) > 2 {
+		words = words[:2]
+	}
+	for i, w := range words {
+		r := []rune(w)
+		words[i] = strings.ToUpper(string(r[0])) + string(r[1:])
+	}
+	return strings.Join(words, " / ")
+}
@@ -0,0 +1,54 @@
+package tools
+
+import "testing"
+
+func TestKMeansCluster_SeparatesTopics(t *testing.T) {
+	texts := []string{
+		"deploys are slow",
+		"slow deploys block releases",
+		"great team lunch",
+		"team lunch was fun",
+	}
+
+	assignments := kMeansCluster(termFrequencyVectors(texts), 2, 50)
+
+	if assignments[0] != assignments[1] {
+		t.Errorf("Expected deploy notes in the same cluster, got %v", assignments)
+	}
+	if assignments[2] != assignments[3] {
+		t.Errorf("Expected lunch notes in the same cluster, got %v", assignments)
+	}
+	if assignments[0] == assignments[2] {
+		t.Errorf("Expected deploy and lunch notes in different clusters, got %v", assignments)
+	}
+}
+
+func TestKMeansCluster_SingleCluster(t *testing.T) {
+	assignments := kMeansCluster([][]float64{{1, 0}, {0, 1}, {1, 1}}, 1, 10)
+	for i, c := range assignments {
+		if c != 0 {
+			t.Errorf("Expected note %d in cluster 0, got %d", i, c)
+		}
+	}
+}
+
+func TestClusterTheme(t *testing.T) {
+	all := []string{"slow deploys", "deploys fail often", "team lunch"}
+	theme := clusterTheme(all[:2], all)
+	if theme != "Deploys / Fail" {
+		t.Errorf("Expected theme 'Deploys / Fail', got %q", theme)
+	}
+
+	if got := clusterTheme([]string{"a an the"}, all); got != "Other" {
+		t.Errorf("Expected 'Other' for notes without keywords, got %q", got)
+	}
+}
+
+func TestDefaultClusterCount(t *testing.T) {
+	cases := map[int]int{1: 1, 2: 1, 8: 2, 18: 3, 500: clusterMaxGroups}
+	for n, want := range cases {
+		if got := defaultClusterCount(n); got != want {
+			t.Errorf("defaultClusterCount(%d) = %d, want %d", n, got, want)
+		}
+	}
+}
@@ -0,0 +1,172 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"melina-studio-backend/internal/config"
+	llmHandlers "melina-studio-backend/internal/llm_handlers"
+	"melina-studio-backend/internal/models"
+)
+
+// ErrInvalidCustomTool is returned for a custom tool definition the agent can't be given
+var ErrInvalidCustomTool = errors.New("invalid custom tool")
+
+// customToolName is what providers accept as a function name
+var customToolName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]{0,63}$`)
+
+// customToolMethods are the HTTP methods a custom tool may use
+var customToolMethods = map[string]bool{
+	http.MethodGet:    true,
+	http.MethodPost:   true,
+	http.MethodPut:    true,
+	http.MethodPatch:  true,
+	http.MethodDelete: true,
+}
+
+// CustomToolSpec is a custom tool as the agent and the executor use it
+type CustomToolSpec struct {
+	Name             string
+	Description      string
+	Parameters       map[string]interface{}
+	Method           string
+	URL              string
+	Headers          map[string]string
+	Timeout          time.Duration
+	RequiresApproval bool
+}
+
+// CustomToolSpecFrom decodes a stored custom tool
+func CustomToolSpecFrom(tool models.CustomTool) (CustomToolSpec, error) {
+	spec := CustomToolSpec{
+		Name:             tool.Name,
+		Description:      tool.Description,
+		Method:           tool.Method,
+		URL:              tool.URL,
+		Timeout:          time.Duration(tool.TimeoutSeconds) * time.Second,
+		RequiresApproval: tool.RequiresApproval,
+	}
+	if err := json.Unmarshal(tool.Parameters, &spec.Parameters); err != nil {
+		return spec, fmt.Errorf("%w: parameters must be a JSON schema object", ErrInvalidCustomTool)
+	}
+	if len(tool.Headers) > 0 {
+		if err := json.Unmarshal(tool.Headers, &spec.Headers); err != nil {
+			return spec, fmt.Errorf("%w: headers must map names to strings", ErrInvalidCustomTool)
+		}
+	}
+	return spec, nil
+}
+
+// ValidateCustomTool checks a custom tool against the provider naming rules, the built-in tools and the
+// deployment's limits
+func ValidateCustomTool(cfg config.CustomToolConfig, spec CustomToolSpec) error {
+	if !customToolName.MatchString(spec.Name) {
+		return fmt.Errorf("%w: name must start with a letter and have at most 64 letters, digits and underscores", ErrInvalidCustomTool)
+	}
+	if IsBuiltinTool(spec.Name) {
+		return fmt.Errorf("%w: %s is a built-in tool", ErrInvalidCustomTool, spec.Name)
+	}
+	if spec.Description == "" {
+		return fmt.Errorf("%w: a description is required, it tells the model when to call the tool", ErrInvalidCustomTool)
+	}
+	if spec.Parameters["type"] != "object" {
+		return fmt.Errorf("%w: parameters must be a JSON schema of type object", ErrInvalidCustomTool)
+	}
+	if !customToolMethods[spec.Method] {
+		return fmt.Errorf("%w: method must be GET, POST, PUT, PATCH or DELETE", ErrInvalidCustomTool)
+	}
+
+	properties, _ := spec.Parameters["properties"].(map[string]interface{})
+	for _, match := range urlPlaceholder.FindAllStringSubmatch(spec.URL, -1) {
+		if _, ok := properties[match[1]]; !ok {
+			return fmt.Errorf("%w: the URL placeholder {%s} is not a parameter", ErrInvalidCustomTool, match[1])
+		}
+	}
+	u, err := url.Parse(urlPlaceholder.ReplaceAllString(spec.URL, "x"))
+	if err != nil || u.Host == "" {
+		return fmt.Errorf("%w: url must be an absolute URL", ErrInvalidCustomTool)
+	}
+	if err := NewHTTPToolExecutor(cfg).checkURL(u); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidCustomTool, err)
+	}
+
+	for name, value := range spec.Headers {
+		for _, ref := range headerEnvRef.FindAllStringSubmatch(value, -1) {
+			if !strings.HasPrefix(ref[1], customToolEnvPrefix) {
+				return fmt.Errorf("%w: header %s may only read %s* variables", ErrInvalidCustomTool, name, customToolEnvPrefix)
+			}
+		}
+	}
+	if spec.Timeout < 0 || (cfg.MaxTimeout > 0 && spec.Timeout > cfg.MaxTimeout) {
+		return fmt.Errorf("%w: timeout must be at most %s", ErrInvalidCustomTool, cfg.MaxTimeout)
+	}
+	return nil
+}
+
+// the custom tools the agent is currently offered
+var (
+	customToolsMu sync.RWMutex
+	customTools   []CustomToolSpec
+)
+
+// SetCustomTools replaces the custom tools offered to the agent: the new ones are registered with their
+// schemas and the ones no longer listed are unregistered. Agents created afterwards get the new set
+func SetCustomTools(specs []CustomToolSpec, executor *HTTPToolExecutor) {
+	customToolsMu.Lock()
+	defer customToolsMu.Unlock()
+
+	current := make(map[string]bool, len(specs))
+	for _, spec := range specs {
+		spec := spec
+		current[spec.Name] = true
+		llmHandlers.RegisterTool(spec.Name, func(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+			return executor.Execute(ctx, spec, input)
+		})
+		llmHandlers.RegisterToolSchema(spec.Name, spec.Parameters)
+		llmHandlers.SetToolApprovalRequired(spec.Name, spec.RequiresApproval)
+	}
+	for _, old := range customTools {
+		if !current[old.Name] {
+			llmHandlers.UnregisterTool(old.Name)
+			llmHandlers.UnregisterToolSchema(old.Name)
+			llmHandlers.SetToolApprovalRequired(old.Name, false)
+		}
+	}
+	customTools = specs
+}
+
+// customToolSchemas returns the custom tools offered to the agent, after the built-in ones
+func customToolSchemas() []toolSchema {
+	customToolsMu.RLock()
+	defer customToolsMu.RUnlock()
+	schemas := make([]toolSchema, 0, len(customTools))
+	for _, spec := range customTools {
+		schemas = append(schemas, toolSchema{Name: spec.Name, Description: spec.Description, Parameters: spec.Parameters})
+	}
+	return schemas
+}
+
+// IsBuiltinTool reports whether a tool name is taken by one of the compiled-in tools
+func IsBuiltinTool(name string) bool {
+	for _, tool := range GetAnthropicTools() {
+		if tool["name"] == name {
+			customToolsMu.RLock()
+			defer customToolsMu.RUnlock()
+			for _, spec := range customTools {
+				if spec.Name == name {
+					return false
+				}
+			}
+			return true
+		}
+	}
+	return false
+}
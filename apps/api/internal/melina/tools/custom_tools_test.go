@@ -0,0 +1,207 @@
+package tools
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"melina-studio-backend/internal/config"
+	llmHandlers "melina-studio-backend/internal/llm_handlers"
+)
+
+func testCustomToolConfig(hosts ...string) config.CustomToolConfig {
+	return config.CustomToolConfig{
+		Enabled:          true,
+		AllowedHosts:     hosts,
+		DefaultTimeout:   5 * time.Second,
+		MaxTimeout:       10 * time.Second,
+		MaxResponseBytes: 1024,
+	}
+}
+
+func jiraToolSpec() CustomToolSpec {
+	return CustomToolSpec{
+		Name:        "createJiraTicket",
+		Description: "Create a Jira ticket in a project",
+		Parameters: map[string]interface{}{
+			"type": "object",
+			"properties": map[string]interface{}{
+				"project": map[string]interface{}{"type": "string"},
+				"summary": map[string]interface{}{"type": "string"},
+			},
+		},
+		Method:  http.MethodPost,
+		URL:     "https://acme.atlassian.net/rest/api/3/projects/{project}/issues",
+		Headers: map[string]string{"Authorization": "Bearer {{env:CUSTOM_TOOL_JIRA_TOKEN}}"},
+	}
+}
+
+func TestValidateCustomTool(t *testing.T) {
+	cfg := testCustomToolConfig("*.atlassian.net")
+	if err := ValidateCustomTool(cfg, jiraToolSpec()); err != nil {
+		t.Fatalf("valid tool rejected: %v", err)
+	}
+
+	cases := map[string]func(*CustomToolSpec){
+		"bad name":            func(s *CustomToolSpec) { s.Name = "create-ticket" },
+		"built-in name":       func(s *CustomToolSpec) { s.Name = "renameBoard" },
+		"no description":      func(s *CustomToolSpec) { s.Description = "" },
+		"not an object":       func(s *CustomToolSpec) { s.Parameters = map[string]interface{}{"type": "string"} },
+		"bad method":          func(s *CustomToolSpec) { s.Method = "CONNECT" },
+		"unknown placeholder": func(s *CustomToolSpec) { s.URL = "https://acme.atlassian.net/issues/{key}" },
+		"host not allowed":    func(s *CustomToolSpec) { s.URL = "https://evil.example.com/issues" },
+		"plain http":          func(s *CustomToolSpec) { s.URL = "http://acme.atlassian.net/issues" },
+		"relative url":        func(s *CustomToolSpec) { s.URL = "/issues" },
+		"secret env":          func(s *CustomToolSpec) { s.Headers = map[string]string{"Authorization": "{{env:JWT_SECRET}}"} },
+		"timeout too long":    func(s *CustomToolSpec) { s.Timeout = time.Minute },
+	}
+	for name, mutate := range cases {
+		spec := jiraToolSpec()
+		mutate(&spec)
+		if err := ValidateCustomTool(cfg, spec); !errors.Is(err, ErrInvalidCustomTool) {
+			t.Errorf("%s: got %v, want ErrInvalidCustomTool", name, err)
+		}
+	}
+}
+
+func TestBuildCustomToolRequest(t *testing.T) {
+	t.Setenv("CUSTOM_TOOL_JIRA_TOKEN", "secret")
+	t.Setenv("JWT_SECRET", "do-not-send")
+	executor := NewHTTPToolExecutor(testCustomToolConfig("*.atlassian.net"))
+
+	spec := jiraToolSpec()
+	spec.Headers["X-Leak"] = "{{env:JWT_SECRET}}"
+	req, err := executor.buildRequest(context.Background(), spec, map[string]interface{}{"project": "MEL 1", "summary": "Fix login"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := req.URL.String(); got != "https://acme.atlassian.net/rest/api/3/projects/MEL%201/issues" {
+		t.Errorf("url = %s", got)
+	}
+	body, _ := io.ReadAll(req.Body)
+	if string(body) != `{"summary":"Fix login"}` {
+		t.Errorf("body = %s", body)
+	}
+	if got := req.Header.Get("Authorization"); got != "Bearer secret" {
+		t.Errorf("Authorization = %q", got)
+	}
+	if got := req.Header.Get("X-Leak"); got != "" {
+		t.Errorf("X-Leak = %q, want the non-CUSTOM_TOOL_ variable left out", got)
+	}
+
+	spec.Method = http.MethodGet
+	req, err = executor.buildRequest(context.Background(), spec, map[string]interface{}{"project": "MEL", "summary": "a&b"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := req.URL.Query().Get("summary"); got != "a&b" || req.Body != nil {
+		t.Errorf("GET query summary = %q, body = %v", got, req.Body)
+	}
+
+	if _, err := executor.buildRequest(context.Background(), spec, map[string]interface{}{"summary": "x"}); err == nil {
+		t.Error("a missing URL argument should fail the call")
+	}
+}
+
+func TestBlockedToolIP(t *testing.T) {
+	for _, addr := range []string{"127.0.0.1", "10.1.2.3", "192.168.0.10", "169.254.169.254", "::1", "fd00::1", "0.0.0.0", "0.1.2.3", "100.64.0.1", "100.127.255.254", "::ffff:100.64.0.1"} {
+		if !blockedToolIP(net.ParseIP(addr)) {
+			t.Errorf("%s should be blocked", addr)
+		}
+	}
+	for _, addr := range []string{"8.8.8.8", "100.63.255.255", "100.128.0.1", "2606:4700:4700::1111"} {
+		if blockedToolIP(net.ParseIP(addr)) {
+			t.Errorf("%s should be allowed", addr)
+		}
+	}
+}
+
+func TestHTTPToolExecutorExecute(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/ok":
+			w.Write([]byte(`{"key":"MEL-1"}`))
+		case "/big":
+			w.Write([]byte(strings.Repeat("x", 2048)))
+		default:
+			http.Error(w, "no such issue", http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+	u, _ := url.Parse(server.URL)
+
+	spec := CustomToolSpec{Name: "lookup", Method: http.MethodGet}
+	call := func(cfg config.CustomToolConfig, path string) (interface{}, error) {
+		spec.URL = server.URL + path
+		return NewHTTPToolExecutor(cfg).Execute(context.Background(), spec, nil)
+	}
+
+	cfg := testCustomToolConfig(u.Hostname())
+	cfg.AllowHTTP = true
+	if _, err := call(cfg, "/ok"); !errors.Is(err, ErrToolAddressBlocked) {
+		t.Fatalf("loopback call: got %v, want ErrToolAddressBlocked", err)
+	}
+
+	cfg.AllowPrivateNetworks = true
+	result, err := call(cfg, "/ok")
+	if err != nil {
+		t.Fatal(err)
+	}
+	body := result.(map[string]interface{})["body"].(map[string]interface{})
+	if body["key"] != "MEL-1" {
+		t.Errorf("body = %v", body)
+	}
+	if _, err := call(cfg, "/big"); !errors.Is(err, ErrToolResponseTooBig) {
+		t.Errorf("large response: got %v, want ErrToolResponseTooBig", err)
+	}
+	if _, err := call(cfg, "/missing"); err == nil || !strings.Contains(err.Error(), "404") {
+		t.Errorf("404: got %v", err)
+	}
+
+	cfg.AllowedHosts = []string{"example.com"}
+	if _, err := call(cfg, "/ok"); !errors.Is(err, ErrToolHostNotAllowed) {
+		t.Errorf("unlisted host: got %v, want ErrToolHostNotAllowed", err)
+	}
+}
+
+func TestSetCustomTools(t *testing.T) {
+	executor := NewHTTPToolExecutor(testCustomToolConfig("*.atlassian.net"))
+	spec := jiraToolSpec()
+	spec.RequiresApproval = true
+	SetCustomTools([]CustomToolSpec{spec}, executor)
+	defer SetCustomTools(nil, executor)
+
+	if !hasTool(GetAnthropicTools(), spec.Name) || !hasTool(GetOpenAITools(), spec.Name) {
+		t.Fatal("custom tool not offered to the agent")
+	}
+	if !llmHandlers.RequiresToolApproval(spec.Name) {
+		t.Error("custom tool should require approval")
+	}
+	if IsBuiltinTool(spec.Name) {
+		t.Error("a custom tool is not a built-in one")
+	}
+
+	SetCustomTools(nil, executor)
+	if hasTool(GetAnthropicTools(), spec.Name) || llmHandlers.RequiresToolApproval(spec.Name) {
+		t.Error("removed custom tool is still offered")
+	}
+}
+
+func hasTool(defs []map[string]interface{}, name string) bool {
+	for _, def := range defs {
+		if def["name"] == name {
+			return true
+		}
+		if function, ok := def["function"].(map[string]interface{}); ok && function["name"] == name {
+			return true
+		}
+	}
+	return false
+}
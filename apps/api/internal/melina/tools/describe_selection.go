@@ -0,0 +1,149 @@
+package tools
+
+import (
+	"fmt"
+	"melina-studio-backend/internal/models"
+	"strings"
+)
+
+// selectionLabelMaxLen keeps one long sticky note from crowding out the rest of the description
+const selectionLabelMaxLen = 160
+
+// SelectedShape is a shape of the user's selection with the number of its badge in the selection images
+type SelectedShape struct {
+	Number int
+	Shape  models.BoardData
+}
+
+// DescribeSelection writes what the selection images show as text, for models without image input:
+// each shape's type, position, size, colors and text, which selected shapes sit inside others,
+// and the connectors that join them. Positions are board coordinates
+func DescribeSelection(selected []SelectedShape) string {
+	if len(selected) == 0 {
+		return ""
+	}
+
+	type described struct {
+		SelectedShape
+		bounds BoundingBox
+		ok     bool
+	}
+	items := make([]described, 0, len(selected))
+	shapes := make([]models.BoardData, 0, len(selected))
+	numberByID := map[string]int{}
+	overall := BoundingBox{}
+	haveOverall := false
+
+	for _, s := range selected {
+		item := described{SelectedShape: s}
+		if bounds, _, err := GetShapeBounds(s.Shape, 0); err == nil {
+			item.bounds, item.ok = bounds, true
+			if !haveOverall {
+				overall, haveOverall = bounds, true
+			} else {
+				overall = mergeBounds(overall, bounds)
+			}
+		}
+		items = append(items, item)
+		shapes = append(shapes, s.Shape)
+		numberByID[s.Shape.UUID.String()] = s.Number
+	}
+
+	var sb strings.Builder
+	for _, item := range items {
+		sb.WriteString(describeSelectedShape(item.Number, item.Shape))
+		if !item.ok {
+			sb.WriteString("\n")
+			continue
+		}
+		// the smallest selected box that fully holds the shape is its container
+		container, containerArea := 0, 0.0
+		for _, other := range items {
+			if other.Number == item.Number || !other.ok || !isContainerType(other.Shape.Type) {
+				continue
+			}
+			if !containsBounds(other.bounds, item.bounds.MinX, item.bounds.MinY) || !containsBounds(other.bounds, item.bounds.MaxX, item.bounds.MaxY) {
+				continue
+			}
+			if area := boundsArea(other.bounds); container == 0 || area < containerArea {
+				container, containerArea = other.Number, area
+			}
+		}
+		if container > 0 {
+			sb.WriteString(fmt.Sprintf(", inside #%d", container))
+		}
+		sb.WriteString("\n")
+	}
+
+	nodes, edges := buildDiagramGraph(shapes)
+	var connections []string
+	for _, edge := range edges {
+		if edge.from < 0 || edge.to < 0 {
+			continue
+		}
+		from, to := nodes[edge.from], nodes[edge.to]
+		line := fmt.Sprintf("#%d %s -> #%d %s (connector #%d", numberByID[from.id], nodeName(from), numberByID[to.id], nodeName(to), numberByID[edge.id])
+		if edge.label != "" {
+			line += fmt.Sprintf(", labeled %q", edge.label)
+		}
+		connections = append(connections, line+")")
+	}
+	if len(connections) > 0 {
+		sb.WriteString("Connections:\n")
+		for _, c := range connections {
+			sb.WriteString("- " + c + "\n")
+		}
+	}
+
+	if haveOverall {
+		sb.WriteString(fmt.Sprintf("Selection spans (%.0f, %.0f) to (%.0f, %.0f)\n", overall.MinX, overall.MinY, overall.MaxX, overall.MaxY))
+	}
+	return strings.TrimSpace(sb.String())
+}
+
+// describeSelectedShape is the first part of a shape's line: number, type, geometry, colors and text
+func describeSelectedShape(number int, shape models.BoardData) string {
+	line := fmt.Sprintf("#%d %s", number, shape.Type)
+	bounds, data, err := GetShapeBounds(shape, 0)
+	if err != nil {
+		return line
+	}
+
+	if label := snapshotLabel(data); label != "" {
+		label = singleLine(label)
+		if len(label) > selectionLabelMaxLen {
+			label = label[:selectionLabelMaxLen] + "..."
+		}
+		line += fmt.Sprintf(" %q", label)
+	}
+
+	switch shape.Type {
+	case models.Arrow, models.Line:
+		if start, end, ok := connectorEnds(data); ok {
+			line += fmt.Sprintf(" from (%.0f, %.0f) to (%.0f, %.0f)", start[0], start[1], end[0], end[1])
+			break
+		}
+		fallthrough
+	default:
+		line += fmt.Sprintf(" at (%.0f, %.0f), %.0fx%.0f", bounds.MinX, bounds.MinY, bounds.MaxX-bounds.MinX, bounds.MaxY-bounds.MinY)
+	}
+	if shape.Type == models.Polygon && isDiamond(data) {
+		line += ", diamond"
+	}
+
+	for _, key := range []string{"fill", "stroke"} {
+		if color, ok := data[key].(string); ok && color != "" && color != "transparent" {
+			line += fmt.Sprintf(", %s %s", key, color)
+		}
+	}
+	return line
+}
+
+// isContainerType reports whether a shape is a box other shapes are usually drawn inside
+func isContainerType(t models.Type) bool {
+	switch t {
+	case models.Frame, models.Rect, models.Card, models.Ellipse, models.Circle:
+		return true
+	}
+	return false
+}
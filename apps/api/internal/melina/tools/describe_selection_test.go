@@ -0,0 +1,39 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+
+	"melina-studio-backend/internal/models"
+)
+
+func TestDescribeSelection(t *testing.T) {
+	selected := []SelectedShape{
+		{Number: 1, Shape: snapshotTestShape(t, models.Frame, map[string]interface{}{"x": 0.0, "y": 0.0, "w": 600.0, "h": 300.0, "name": "Checkout"})},
+		{Number: 2, Shape: snapshotTestShape(t, models.Rect, map[string]interface{}{"x": 40.0, "y": 40.0, "w": 160.0, "h": 80.0, "text": "Cart", "fill": "#ffeeaa"})},
+		{Number: 3, Shape: snapshotTestShape(t, models.Rect, map[string]interface{}{"x": 400.0, "y": 40.0, "w": 160.0, "h": 80.0, "text": "Pay", "stroke": "#333333"})},
+		{Number: 4, Shape: snapshotTestShape(t, models.Arrow, map[string]interface{}{"points": []float64{200, 80, 400, 80}})},
+	}
+
+	got := DescribeSelection(selected)
+	for _, want := range []string{
+		`#2 rect "Cart" at (40, 40), 160x80, fill #ffeeaa, inside #1`,
+		`#3 rect "Pay" at (400, 40), 160x80, stroke #333333, inside #1`,
+		"#4 arrow from (200, 80) to (400, 80)",
+		`#2 "Cart" -> #3 "Pay" (connector #4)`,
+		"Selection spans (0, 0) to (600, 300)",
+	} {
+		if !strings.Contains(got, want) {
+			t.Errorf("description is missing %q:\n%s", want, got)
+		}
+	}
+	if strings.Contains(strings.SplitN(got, "\n", 2)[0], "inside") {
+		t.Errorf("the frame should not be inside anything:\n%s", got)
+	}
+}
+
+func TestDescribeSelectionEmpty(t *testing.T) {
+	if got := DescribeSelection(nil); got != "" {
+		t.Fatalf("got %q", got)
+	}
+}
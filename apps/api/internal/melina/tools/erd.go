@@ -0,0 +1,611 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"melina-studio-backend/internal/models"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var generateDDLSchema = toolSchema{
+	Name:        "generateDDL",
+	Description: "Generates SQL DDL (CREATE TABLE statements with primary and foreign keys) from the ERD entity shapes on the board. Use this when the user asks for the SQL, schema or migration for their data model.",
+	Parameters: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"boardId": map[string]interface{}{
+				"type":        "string",
+				"description": "The UUID of the board",
+			},
+			"shapeIds": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Only include these entity shapes (optional, defaults to every entity on the board)",
+			},
+		},
+		"required": []string{"boardId"},
+	},
+}
+
+const (
+	erdEntityWidth  = 260.0
+	erdHeaderHeight = 36.0
+	erdRowHeight    = 24.0
+	erdBodyPadding  = 8.0
+	erdGapX         = 120.0
+	erdGapY         = 80.0
+	erdMaxTables    = 100
+)
+
+// ERDTable is a database table parsed from DDL or read from an entity shape
+type ERDTable struct {
+	Name    string                `json:"name"`
+	Columns []models.EntityColumn `json:"columns"`
+}
+
+var (
+	sqlLineComment     = regexp.MustCompile(`--[^\n]*`)
+	sqlBlockComment    = regexp.MustCompile(`(?s)/\*.*?\*/`)
+	sqlCreateTable     = regexp.MustCompile(`(?is)^create\s+(?:(?:global|local)\s+)?(?:(?:temporary|temp|unlogged)\s+)?table\s+(?:if\s+not\s+exists\s+)?`)
+	sqlAlterForeignKey = regexp.MustCompile(`(?is)^alter\s+table\s+(?:only\s+)?(?:if\s+exists\s+)?(\S+)\s+add\s+(?:constraint\s+\S+\s+)?foreign\s+key\s*\(([^)]*)\)\s*references\s+([^\s(]+)\s*(?:\(([^)]*)\))?`)
+	sqlPlainIdentifier = regexp.MustCompile(`^[a-z_][a-z0-9_]*$`)
+)
+
+// ParseSQLDDL extracts tables from CREATE TABLE statements
+// Column and table level primary keys, unique constraints and foreign keys are kept, as are
+// foreign keys added later with ALTER TABLE; every other statement is ignored
+func ParseSQLDDL(sql string) ([]ERDTable, error) {
+	sql = sqlBlockComment.ReplaceAllString(sql, " ")
+	sql = sqlLineComment.ReplaceAllString(sql, " ")
+
+	var tables []ERDTable
+	index := map[string]int{}
+
+	for _, stmt := range splitTopLevel(sql, ';') {
+		stmt = strings.TrimSpace(stmt)
+		if stmt == "" {
+			continue
+		}
+
+		if loc := sqlCreateTable.FindStringIndex(stmt); loc != nil {
+			rest := stmt[loc[1]:]
+			open := strings.Index(rest, "(")
+			if open < 0 {
+				continue
+			}
+			closeIdx := matchingParen(rest, open)
+			if closeIdx < 0 {
+				return nil, fmt.Errorf("unbalanced parentheses in CREATE TABLE %s", strings.TrimSpace(rest[:open]))
+			}
+			table := ERDTable{Name: sqlIdentifier(rest[:open])}
+			if table.Name == "" {
+				continue
+			}
+			parseTableBody(&table, rest[open+1:closeIdx])
+			index[strings.ToLower(table.Name)] = len(tables)
+			tables = append(tables, table)
+			if len(tables) > erdMaxTables {
+				return nil, fmt.Errorf("too many tables, the limit is %d", erdMaxTables)
+			}
+			continue
+		}
+
+		if m := sqlAlterForeignKey.FindStringSubmatch(stmt); m != nil {
+			i, ok := index[strings.ToLower(sqlIdentifier(m[1]))]
+			if !ok {
+				continue
+			}
+			applyForeignKey(&tables[i], splitIdentifiers(m[2]), sqlIdentifier(m[3]), splitIdentifiers(m[4]))
+		}
+	}
+
+	if len(tables) == 0 {
+		return nil, fmt.Errorf("no CREATE TABLE statements found")
+	}
+	return tables, nil
+}
+
+// parseTableBody reads the column definitions and table constraints of a CREATE TABLE
+func parseTableBody(table *ERDTable, body string) {
+	type foreignKey struct {
+		columns    []string
+		refTable   string
+		refColumns []string
+	}
+	var primaryKey []string
+	var uniques [][]string
+	var foreignKeys []foreignKey
+
+	for _, part := range splitTopLevel(body, ',') {
+		tokens := splitTopLevel(strings.TrimSpace(part), ' ')
+		tokens = nonEmpty(tokens)
+		if len(tokens) == 0 {
+			continue
+		}
+		if strings.EqualFold(tokens[0], "constraint") && len(tokens) > 2 {
+			tokens = tokens[2:]
+		}
+
+		first := strings.ToUpper(tokens[0])
+		switch {
+		case first == "PRIMARY" && len(tokens) > 1 && strings.EqualFold(tokens[1], "key"):
+			primaryKey = splitIdentifiers(parenContent(strings.Join(tokens[2:], " ")))
+			continue
+		case first == "FOREIGN" && len(tokens) > 1 && strings.EqualFold(tokens[1], "key"):
+			rest := strings.Join(tokens[2:], " ")
+			columns := splitIdentifiers(parenContent(rest))
+			refTable, refColumns := parseReferences(rest)
+			foreignKeys = append(foreignKeys, foreignKey{columns: columns, refTable: refTable, refColumns: refColumns})
+			continue
+		case first == "UNIQUE":
+			uniques = append(uniques, splitIdentifiers(parenContent(strings.Join(tokens[1:], " "))))
+			continue
+		case first == "CHECK" || first == "KEY" || first == "INDEX" || first == "EXCLUDE" || first == "FULLTEXT" || first == "SPATIAL":
+			continue
+		}
+
+		column := models.EntityColumn{Name: sqlIdentifier(tokens[0])}
+		var typeParts []string
+		i := 1
+		for ; i < len(tokens); i++ {
+			if isColumnConstraintKeyword(tokens[i]) {
+				break
+			}
+			typeParts = append(typeParts, tokens[i])
+		}
+		column.Type = strings.Join(typeParts, " ")
+		column.Type = strings.ReplaceAll(column.Type, " (", "(")
+
+		for ; i < len(tokens); i++ {
+			switch strings.ToUpper(tokens[i]) {
+			case "PRIMARY":
+				column.PrimaryKey = true
+				column.NotNull = true
+			case "NOT":
+				if i+1 < len(tokens) && strings.EqualFold(tokens[i+1], "null") {
+					column.NotNull = true
+					i++
+				}
+			case "UNIQUE":
+				column.Unique = true
+			case "REFERENCES":
+				refTable, refColumns := parseReferences(strings.Join(tokens[i:], " "))
+				column.References = referenceTarget(refTable, refColumns, 0)
+			}
+		}
+		table.Columns = append(table.Columns, column)
+	}
+
+	for _, name := range primaryKey {
+		if c := findColumn(table, name); c != nil {
+			c.PrimaryKey = true
+			c.NotNull = true
+		}
+	}
+	for _, unique := range uniques {
+		if len(unique) != 1 {
+			continue
+		}
+		if c := findColumn(table, unique[0]); c != nil {
+			c.Unique = true
+		}
+	}
+	for _, fk := range foreignKeys {
+		applyForeignKey(table, fk.columns, fk.refTable, fk.refColumns)
+	}
+}
+
+func applyForeignKey(table *ERDTable, columns []string, refTable string, refColumns []string) {
+	for i, name := range columns {
+		if c := findColumn(table, name); c != nil {
+			c.References = referenceTarget(refTable, refColumns, i)
+		}
+	}
+}
+
+// parseReferences reads "REFERENCES table (columns)" anywhere in the text
+func parseReferences(text string) (string, []string) {
+	upper := strings.ToUpper(text)
+	i := strings.Index(upper, "REFERENCES")
+	if i < 0 {
+		return "", nil
+	}
+	rest := strings.TrimSpace(text[i+len("REFERENCES"):])
+	end := strings.IndexAny(rest, " (")
+	if end < 0 {
+		return sqlIdentifier(rest), nil
+	}
+	refTable := sqlIdentifier(rest[:end])
+	rest = strings.TrimSpace(rest[end:])
+	if strings.HasPrefix(rest, "(") {
+		return refTable, splitIdentifiers(parenContent(rest))
+	}
+	return refTable, nil
+}
+
+func referenceTarget(refTable string, refColumns []string, i int) string {
+	if refTable == "" {
+		return ""
+	}
+	if i < len(refColumns) {
+		return refTable + "." + refColumns[i]
+	}
+	return refTable
+}
+
+func isColumnConstraintKeyword(token string) bool {
+	switch strings.ToUpper(token) {
+	case "NOT", "NULL", "PRIMARY", "REFERENCES", "DEFAULT", "UNIQUE", "CHECK", "CONSTRAINT",
+		"GENERATED", "AUTO_INCREMENT", "AUTOINCREMENT", "COLLATE", "IDENTITY", "ON", "COMMENT":
+		return true
+	}
+	return false
+}
+
+func findColumn(table *ERDTable, name string) *models.EntityColumn {
+	for i := range table.Columns {
+		if strings.EqualFold(table.Columns[i].Name, name) {
+			return &table.Columns[i]
+		}
+	}
+	return nil
+}
+
+// splitTopLevel splits on sep outside of parentheses and quotes; a space separator splits on any whitespace
+func splitTopLevel(text string, sep byte) []string {
+	var parts []string
+	depth := 0
+	var quote byte
+	start := 0
+	for i := 0; i < len(text); i++ {
+		ch := text[i]
+		if quote != 0 {
+			if ch == quote {
+				quote = 0
+			}
+			continue
+		}
+		switch ch {
+		case '\'', '"', '`':
+			quote = ch
+		case '[':
+			quote = ']'
+		case '(':
+			depth++
+		case ')':
+			depth--
+		}
+		isSep := ch == sep || (sep == ' ' && (ch == '\t' || ch == '\n' || ch == '\r'))
+		if depth == 0 && isSep {
+			parts = append(parts, text[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, text[start:])
+}
+
+func matchingParen(text string, open int) int {
+	depth := 0
+	for i := open; i < len(text); i++ {
+		switch text[i] {
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// parenContent returns the text inside the first parenthesized group
+func parenContent(text string) string {
+	open := strings.Index(text, "(")
+	if open < 0 {
+		return ""
+	}
+	closeIdx := matchingParen(text, open)
+	if closeIdx < 0 {
+		return ""
+	}
+	return text[open+1 : closeIdx]
+}
+
+func splitIdentifiers(list string) []string {
+	var names []string
+	for _, part := range strings.Split(list, ",") {
+		fields := strings.Fields(part)
+		if len(fields) == 0 {
+			continue
+		}
+		if name := sqlIdentifier(fields[0]); name != "" {
+			names = append(names, name)
+		}
+	}
+	return names
+}
+
+// sqlIdentifier unquotes an identifier and drops its schema prefix
+func sqlIdentifier(text string) string {
+	text = strings.TrimSpace(text)
+	if dot := strings.LastIndex(text, "."); dot >= 0 {
+		text = text[dot+1:]
+	}
+	return strings.Trim(text, "\"`[] ")
+}
+
+func nonEmpty(parts []string) []string {
+	result := parts[:0]
+	for _, p := range parts {
+		if strings.TrimSpace(p) != "" {
+			result = append(result, strings.TrimSpace(p))
+		}
+	}
+	return result
+}
+
+// LayoutERD places one entity shape per table in a grid and connects foreign keys to the tables they reference
+func LayoutERD(tables []ERDTable, originX, originY float64) ([]*models.Shape, []*models.Shape) {
+	perRow := int(math.Ceil(math.Sqrt(float64(len(tables)))))
+	if perRow < 1 {
+		perRow = 1
+	}
+
+	entities := make([]*models.Shape, 0, len(tables))
+	byName := map[string]*models.Shape{}
+	fill, stroke := "#ffffff", "#475569"
+	width := erdEntityWidth
+
+	y := originY
+	for rowStart := 0; rowStart < len(tables); rowStart += perRow {
+		rowEnd := rowStart + perRow
+		if rowEnd > len(tables) {
+			rowEnd = len(tables)
+		}
+		rowHeight := 0.0
+		for i := rowStart; i < rowEnd; i++ {
+			table := tables[i]
+			x := originX + float64(i-rowStart)*(erdEntityWidth+erdGapX)
+			entityY := y
+			height := erdHeaderHeight + float64(len(table.Columns))*erdRowHeight + erdBodyPadding
+			name := table.Name
+			columns := table.Columns
+			entity := &models.Shape{
+				ID:      uuid.New().String(),
+				Type:    string(models.Entity),
+				X:       &x,
+				Y:       &entityY,
+				W:       &width,
+				H:       &height,
+				Name:    &name,
+				Columns: &columns,
+				Fill:    &fill,
+				Stroke:  &stroke,
+			}
+			entities = append(entities, entity)
+			byName[strings.ToLower(name)] = entity
+			rowHeight = math.Max(rowHeight, height)
+		}
+		y += rowHeight + erdGapY
+	}
+
+	var relations []*models.Shape
+	for i, table := range tables {
+		source := entities[i]
+		for _, column := range table.Columns {
+			if column.References == "" {
+				continue
+			}
+			refTable := strings.SplitN(column.References, ".", 2)[0]
+			target, ok := byName[strings.ToLower(refTable)]
+			if !ok || target == source {
+				continue
+			}
+			start, end := entityConnectorEnds(source, target)
+			bend := 0.0
+			relationStroke := "#64748b"
+			relations = append(relations, &models.Shape{
+				ID:     uuid.New().String(),
+				Type:   string(models.Arrow),
+				Start:  start,
+				End:    end,
+				Bend:   &bend,
+				Stroke: &relationStroke,
+			})
+		}
+	}
+
+	return entities, relations
+}
+
+// entityConnectorEnds picks the facing edges of two entities for a relation arrow
+func entityConnectorEnds(source, target *models.Shape) (map[string]float64, map[string]float64) {
+	sx, sy, sw, sh := *source.X, *source.Y, *source.W, *source.H
+	tx, ty, tw, th := *target.X, *target.Y, *target.W, *target.H
+
+	switch {
+	case tx >= sx+sw:
+		return map[string]float64{"x": sx + sw, "y": sy + sh/2}, map[string]float64{"x": tx, "y": ty + th/2}
+	case tx+tw <= sx:
+		return map[string]float64{"x": sx, "y": sy + sh/2}, map[string]float64{"x": tx + tw, "y": ty + th/2}
+	case ty >= sy+sh:
+		return map[string]float64{"x": sx + sw/2, "y": sy + sh}, map[string]float64{"x": tx + tw/2, "y": ty}
+	default:
+		return map[string]float64{"x": sx + sw/2, "y": sy}, map[string]float64{"x": tx + tw/2, "y": ty + th}
+	}
+}
+
+// ERDTablesFromShapes reads the entity shapes of a board in reading order
+// When wanted is non-empty only those shape IDs are included
+func ERDTablesFromShapes(shapes []models.BoardData, wanted map[string]bool) []ERDTable {
+	type positioned struct {
+		table ERDTable
+		x, y  float64
+	}
+	var entities []positioned
+
+	for _, shape := range shapes {
+		if shape.Type != models.Entity {
+			continue
+		}
+		if len(wanted) > 0 && !wanted[shape.UUID.String()] {
+			continue
+		}
+		var data struct {
+			X       float64               `json:"x"`
+			Y       float64               `json:"y"`
+			Name    string                `json:"name"`
+			Columns []models.EntityColumn `json:"columns"`
+		}
+		if err := json.Unmarshal(shape.Data, &data); err != nil || strings.TrimSpace(data.Name) == "" {
+			continue
+		}
+		entities = append(entities, positioned{table: ERDTable{Name: data.Name, Columns: data.Columns}, x: data.X, y: data.Y})
+	}
+
+	sort.SliceStable(entities, func(i, j int) bool {
+		if entities[i].y != entities[j].y {
+			return entities[i].y < entities[j].y
+		}
+		return entities[i].x < entities[j].x
+	})
+
+	tables := make([]ERDTable, 0, len(entities))
+	for _, e := range entities {
+		tables = append(tables, e.table)
+	}
+	return tables
+}
+
+// GenerateDDL renders CREATE TABLE statements, with referenced tables created before the tables that use them
+func GenerateDDL(tables []ERDTable) string {
+	var sb strings.Builder
+	for i, table := range orderByDependencies(tables) {
+		if i > 0 {
+			sb.WriteString("\n")
+		}
+		var lines []string
+		var primaryKey []string
+		for _, column := range table.Columns {
+			columnType := column.Type
+			if columnType == "" {
+				columnType = "TEXT"
+			}
+			line := fmt.Sprintf("  %s %s", quoteSQLIdentifier(column.Name), columnType)
+			if column.NotNull && !column.PrimaryKey {
+				line += " NOT NULL"
+			}
+			if column.Unique && !column.PrimaryKey {
+				line += " UNIQUE"
+			}
+			lines = append(lines, line)
+			if column.PrimaryKey {
+				primaryKey = append(primaryKey, quoteSQLIdentifier(column.Name))
+			}
+		}
+		if len(primaryKey) > 0 {
+			lines = append(lines, fmt.Sprintf("  PRIMARY KEY (%s)", strings.Join(primaryKey, ", ")))
+		}
+		for _, column := range table.Columns {
+			if column.References == "" {
+				continue
+			}
+			parts := strings.SplitN(column.References, ".", 2)
+			ref := quoteSQLIdentifier(parts[0])
+			if len(parts) == 2 {
+				ref += fmt.Sprintf(" (%s)", quoteSQLIdentifier(parts[1]))
+			}
+			lines = append(lines, fmt.Sprintf("  FOREIGN KEY (%s) REFERENCES %s", quoteSQLIdentifier(column.Name), ref))
+		}
+
+		sb.WriteString(fmt.Sprintf("CREATE TABLE %s (\n", quoteSQLIdentifier(table.Name)))
+		sb.WriteString(strings.Join(lines, ",\n"))
+		sb.WriteString("\n);\n")
+	}
+	return sb.String()
+}
+
+// orderByDependencies sorts tables so referenced tables come first, keeping the input order otherwise
+// Tables in a reference cycle keep their input order
+func orderByDependencies(tables []ERDTable) []ERDTable {
+	index := map[string]int{}
+	for i, table := range tables {
+		index[strings.ToLower(table.Name)] = i
+	}
+
+	ordered := make([]ERDTable, 0, len(tables))
+	state := make([]int, len(tables)) // 0 unvisited, 1 visiting, 2 done
+	var visit func(i int)
+	visit = func(i int) {
+		if state[i] != 0 {
+			return
+		}
+		state[i] = 1
+		for _, column := range tables[i].Columns {
+			if column.References == "" {
+				continue
+			}
+			ref := strings.ToLower(strings.SplitN(column.References, ".", 2)[0])
+			if j, ok := index[ref]; ok && j != i {
+				visit(j)
+			}
+		}
+		state[i] = 2
+		ordered = append(ordered, tables[i])
+	}
+	for i := range tables {
+		visit(i)
+	}
+	return ordered
+}
+
+func quoteSQLIdentifier(name string) string {
+	if sqlPlainIdentifier.MatchString(name) {
+		return name
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// GenerateDDLHandler renders the board's entity shapes as SQL DDL
+func (d *ToolDeps) GenerateDDLHandler(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+	boardIdStr, ok := input["boardId"].(string)
+	if !ok || boardIdStr == "" {
+		return nil, fmt.Errorf("boardId is required and must be a non-empty string")
+	}
+	boardId, err := uuid.Parse(boardIdStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid boardId format: %w", err)
+	}
+
+	shapes, err := d.BoardData.GetShapesByType(boardId, models.Entity)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shapes from database: %w", err)
+	}
+
+	wanted := map[string]bool{}
+	for _, id := range stringSlice(input["shapeIds"]) {
+		wanted[id] = true
+	}
+	tables := ERDTablesFromShapes(shapes, wanted)
+	if len(tables) == 0 {
+		return map[string]interface{}{
+			"success": false,
+			"message": "No entity shapes found. Import SQL or create entities first.",
+		}, nil
+	}
+
+	return map[string]interface{}{
+		"success": true,
+		"tables":  len(tables),
+		"sql":     GenerateDDL(tables),
+		"message": fmt.Sprintf("Generated DDL for %d tables. Show the SQL to the user in a code block.", len(tables)),
+	}, nil
+}
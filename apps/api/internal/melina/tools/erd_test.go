@@ -0,0 +1,117 @@
+package tools
+
+import (
+	"strings"
+	"testing"
+)
+
+const erdTestDDL = `
+-- accounts
+CREATE TABLE IF NOT EXISTS public.users (
+  id SERIAL PRIMARY KEY,
+  email VARCHAR (255) NOT NULL UNIQUE,
+  created_at TIMESTAMP DEFAULT now()
+);
+
+/* orders belong to users */
+CREATE TABLE "orders" (
+  id BIGINT NOT NULL,
+  user_id INT REFERENCES users(id) ON DELETE CASCADE,
+  total NUMERIC(10, 2),
+  CONSTRAINT orders_pk PRIMARY KEY (id)
+);
+
+CREATE TABLE order_items (
+  order_id BIGINT,
+  sku TEXT,
+  PRIMARY KEY (order_id, sku)
+);
+
+ALTER TABLE order_items ADD CONSTRAINT fk_order FOREIGN KEY (order_id) REFERENCES orders (id);
+CREATE INDEX idx_orders_user ON orders (user_id);
+`
+
+func TestParseSQLDDL(t *testing.T) {
+	tables, err := ParseSQLDDL(erdTestDDL)
+	if err != nil {
+		t.Fatalf("ParseSQLDDL returned error: %v", err)
+	}
+	if len(tables) != 3 {
+		t.Fatalf("Expected 3 tables, got %d", len(tables))
+	}
+
+	users := tables[0]
+	if users.Name != "users" || len(users.Columns) != 3 {
+		t.Fatalf("Unexpected users table %+v", users)
+	}
+	if !users.Columns[0].PrimaryKey || users.Columns[0].Type != "SERIAL" {
+		t.Errorf("Expected users.id to be a SERIAL primary key, got %+v", users.Columns[0])
+	}
+	if email := users.Columns[1]; email.Type != "VARCHAR(255)" || !email.NotNull || !email.Unique {
+		t.Errorf("Unexpected users.email %+v", email)
+	}
+
+	orders := tables[1]
+	if orders.Name != "orders" || !orders.Columns[0].PrimaryKey {
+		t.Errorf("Expected orders.id primary key from table constraint, got %+v", orders)
+	}
+	if orders.Columns[1].References != "users.id" {
+		t.Errorf("Expected orders.user_id to reference users.id, got %q", orders.Columns[1].References)
+	}
+	if orders.Columns[2].Type != "NUMERIC(10, 2)" {
+		t.Errorf("Expected NUMERIC(10, 2), got %q", orders.Columns[2].Type)
+	}
+
+	items := tables[2]
+	if !items.Columns[0].PrimaryKey || !items.Columns[1].PrimaryKey {
+		t.Errorf("Expected composite primary key on order_items, got %+v", items.Columns)
+	}
+	if items.Columns[0].References != "orders.id" {
+		t.Errorf("Expected ALTER TABLE foreign key on order_items.order_id, got %q", items.Columns[0].References)
+	}
+}
+
+func TestParseSQLDDL_NoTables(t *testing.T) {
+	if _, err := ParseSQLDDL("SELECT 1;"); err == nil {
+		t.Error("Expected error when there are no CREATE TABLE statements")
+	}
+}
+
+func TestGenerateDDL_RoundTrip(t *testing.T) {
+	tables, err := ParseSQLDDL(erdTestDDL)
+	if err != nil {
+		t.Fatalf("ParseSQLDDL returned error: %v", err)
+	}
+
+	// referenced tables must be created first even when listed last
+	reversed := []ERDTable{tables[2], tables[1], tables[0]}
+	ddl := GenerateDDL(reversed)
+
+	if strings.Index(ddl, "CREATE TABLE users") > strings.Index(ddl, "CREATE TABLE orders") ||
+		strings.Index(ddl, "CREATE TABLE orders") > strings.Index(ddl, "CREATE TABLE order_items") {
+		t.Errorf("Expected tables in dependency order, got:\n%s", ddl)
+	}
+
+	again, err := ParseSQLDDL(ddl)
+	if err != nil {
+		t.Fatalf("Generated DDL does not parse: %v\n%s", err, ddl)
+	}
+	if len(again) != 3 || again[1].Columns[1].References != "users.id" {
+		t.Errorf("Round trip lost data:\n%s", ddl)
+	}
+}
+
+func TestLayoutERD_ConnectsForeignKeys(t *testing.T) {
+	tables, _ := ParseSQLDDL(erdTestDDL)
+	entities, relations := LayoutERD(tables, 0, 0)
+
+	if len(entities) != 3 {
+		t.Fatalf("Expected 3 entities, got %d", len(entities))
+	}
+	if len(relations) != 2 {
+		t.Fatalf("Expected 2 relations, got %d", len(relations))
+	}
+	if *entities[0].Name != "users" || *entities[0].H != erdHeaderHeight+3*erdRowHeight+erdBodyPadding {
+		t.Errorf("Unexpected users entity %+v", entities[0])
+	}
+}
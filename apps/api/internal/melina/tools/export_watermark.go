@@ -0,0 +1,109 @@
+package tools
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	"github.com/fogleman/gg"
+	xdraw "golang.org/x/image/draw"
+)
+
+// Watermark positions
+const (
+	WatermarkTopLeft     = "top-left"
+	WatermarkTopRight    = "top-right"
+	WatermarkBottomLeft  = "bottom-left"
+	WatermarkBottomRight = "bottom-right"
+	WatermarkCenter      = "center"
+)
+
+// Watermark is what ApplyWatermark draws over an exported board
+type Watermark struct {
+	// Text is drawn on a light label when there is no logo
+	Text string
+	// Logo is drawn instead of the text, scaled to at most a quarter of the image width
+	Logo     image.Image
+	Position string
+	// Opacity is between 0 and 1
+	Opacity float64
+}
+
+// ApplyWatermark composites the watermark over a board image and returns the PNG. The mark scales with the
+// image so it stays legible on large exports without covering small ones
+func ApplyWatermark(boardImage []byte, wm Watermark) ([]byte, error) {
+	src, _, err := image.Decode(bytes.NewReader(boardImage))
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode board image: %w", err)
+	}
+	bounds := src.Bounds()
+	if bounds.Empty() {
+		return nil, fmt.Errorf("board image is empty")
+	}
+	canvas := image.NewRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	draw.Draw(canvas, canvas.Bounds(), src, bounds.Min, draw.Src)
+
+	opacity := math.Max(0, math.Min(1, wm.Opacity))
+	shortSide := float64(min(bounds.Dx(), bounds.Dy()))
+	margin := math.Max(8, shortSide*0.02)
+
+	if wm.Logo != nil {
+		drawWatermarkLogo(canvas, wm, opacity, margin)
+	} else if wm.Text != "" {
+		drawWatermarkText(canvas, wm, opacity, margin, shortSide)
+	}
+
+	return encodePNG(canvas)
+}
+
+func drawWatermarkLogo(canvas *image.RGBA, wm Watermark, opacity float64, margin float64) {
+	logoBounds := wm.Logo.Bounds()
+	if logoBounds.Empty() {
+		return
+	}
+	scale := math.Min(1, float64(canvas.Bounds().Dx())/4/float64(logoBounds.Dx()))
+	width := max(int(float64(logoBounds.Dx())*scale), 1)
+	height := max(int(float64(logoBounds.Dy())*scale), 1)
+	scaled := image.NewRGBA(image.Rect(0, 0, width, height))
+	xdraw.CatmullRom.Scale(scaled, scaled.Bounds(), wm.Logo, logoBounds, xdraw.Src, nil)
+
+	x, y := watermarkOrigin(wm.Position, canvas.Bounds().Size(), float64(width), float64(height), margin)
+	target := image.Rect(int(x), int(y), int(x)+width, int(y)+height)
+	mask := image.NewUniform(color.Alpha{A: uint8(opacity * 255)})
+	draw.DrawMask(canvas, target, scaled, image.Point{}, mask, image.Point{}, draw.Over)
+}
+
+func drawWatermarkText(canvas *image.RGBA, wm Watermark, opacity float64, margin float64, shortSide float64) {
+	dc := gg.NewContextForRGBA(canvas)
+	setFontSize(dc, math.Max(12, math.Min(48, shortSide*0.035)))
+	textWidth, textHeight := dc.MeasureString(wm.Text)
+	padding := textHeight * 0.6
+	width, height := textWidth+2*padding, textHeight+2*padding
+
+	x, y := watermarkOrigin(wm.Position, canvas.Bounds().Size(), width, height, margin)
+	dc.SetRGBA(1, 1, 1, 0.8*opacity)
+	dc.DrawRoundedRectangle(x, y, width, height, height/4)
+	dc.Fill()
+	dc.SetRGBA(0.07, 0.07, 0.07, opacity)
+	dc.DrawStringAnchored(wm.Text, x+width/2, y+height/2, 0.5, 0.35)
+}
+
+// watermarkOrigin returns the top-left corner of a width x height mark placed at position, bottom-right by default
+func watermarkOrigin(position string, size image.Point, width, height, margin float64) (float64, float64) {
+	left, top := margin, margin
+	right, bottom := float64(size.X)-width-margin, float64(size.Y)-height-margin
+	switch position {
+	case WatermarkTopLeft:
+		return left, top
+	case WatermarkTopRight:
+		return right, top
+	case WatermarkBottomLeft:
+		return left, bottom
+	case WatermarkCenter:
+		return (float64(size.X) - width) / 2, (float64(size.Y) - height) / 2
+	}
+	return right, bottom
+}
@@ -0,0 +1,86 @@
+package tools
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"image/png"
+	"testing"
+)
+
+func whitePNG(t *testing.T, width, height int) []byte {
+	t.Helper()
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	for i := range img.Pix {
+		img.Pix[i] = 0xff
+	}
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		t.Fatal(err)
+	}
+	return buf.Bytes()
+}
+
+// markedRegion returns the bounding box of the pixels the watermark changed on a white image
+func markedRegion(t *testing.T, data []byte) image.Rectangle {
+	t.Helper()
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("watermarked export is not a PNG: %v", err)
+	}
+	var marked image.Rectangle
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if r, g, b, _ := img.At(x, y).RGBA(); r != 0xffff || g != 0xffff || b != 0xffff {
+				marked = marked.Union(image.Rect(x, y, x+1, y+1))
+			}
+		}
+	}
+	return marked
+}
+
+func TestApplyWatermarkText(t *testing.T) {
+	data, err := ApplyWatermark(whitePNG(t, 800, 600), Watermark{Text: "Made with Melina Studio", Opacity: 0.7})
+	if err != nil {
+		t.Fatal(err)
+	}
+	marked := markedRegion(t, data)
+	if marked.Empty() {
+		t.Fatal("no watermark was drawn")
+	}
+	if marked.Min.X < 400 || marked.Min.Y < 300 {
+		t.Errorf("default watermark should sit in the bottom-right corner, drawn at %v", marked)
+	}
+
+	data, _ = ApplyWatermark(whitePNG(t, 800, 600), Watermark{Text: "Made with Melina Studio", Position: WatermarkTopLeft, Opacity: 0.7})
+	if marked := markedRegion(t, data); marked.Max.X > 400 || marked.Max.Y > 300 {
+		t.Errorf("top-left watermark drawn at %v", marked)
+	}
+}
+
+func TestApplyWatermarkLogo(t *testing.T) {
+	logo := image.NewRGBA(image.Rect(0, 0, 1000, 200))
+	for i := 0; i < len(logo.Pix); i += 4 {
+		logo.Pix[i], logo.Pix[i+3] = 0xff, 0xff
+	}
+
+	data, err := ApplyWatermark(whitePNG(t, 800, 600), Watermark{Logo: logo, Position: WatermarkBottomLeft, Opacity: 0.5})
+	if err != nil {
+		t.Fatal(err)
+	}
+	marked := markedRegion(t, data)
+	if marked.Dx() > 200 {
+		t.Errorf("logo should be scaled to a quarter of the width, covers %v", marked)
+	}
+	img, _ := png.Decode(bytes.NewReader(data))
+	if c := color.RGBAModel.Convert(img.At(marked.Min.X+10, marked.Min.Y+10)).(color.RGBA); c.G < 100 || c.G > 160 {
+		t.Errorf("half-opaque red over white should be pink, got %v", c)
+	}
+}
+
+func TestApplyWatermarkRejectsInvalidImages(t *testing.T) {
+	if _, err := ApplyWatermark([]byte("not an image"), Watermark{Text: "x"}); err == nil {
+		t.Error("expected an error for data that isn't an image")
+	}
+}
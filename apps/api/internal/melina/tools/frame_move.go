@@ -0,0 +1,118 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+
+	"melina-studio-backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+var (
+	ErrFrameNotFound   = errors.New("frame not found")
+	ErrShapeNotMovable = errors.New("shape can't be moved into a frame")
+	ErrShapeNotOnBoard = errors.New("shape not found on board")
+)
+
+// FrameMove is a shape placed in a frame by MoveIntoFrame
+type FrameMove struct {
+	FrameID   uuid.UUID
+	FrameName string
+	// Data is the shape's data at its new position, nil when it already was in the frame
+	Data datatypes.JSON
+}
+
+// findFrame matches a frame by id, else by name
+func findFrame(shapes []models.BoardData, ref string) *models.BoardData {
+	ref = strings.TrimSpace(ref)
+	for i := range shapes {
+		if shapes[i].Type == models.Frame && shapes[i].UUID.String() == ref {
+			return &shapes[i]
+		}
+	}
+	for i := range shapes {
+		if shapes[i].Type != models.Frame {
+			continue
+		}
+		var data map[string]interface{}
+		if json.Unmarshal(shapes[i].Data, &data) != nil {
+			continue
+		}
+		if name, _ := data["name"].(string); strings.EqualFold(strings.TrimSpace(name), ref) {
+			return &shapes[i]
+		}
+	}
+	return nil
+}
+
+// MoveIntoFrame places a shape in a frame, found by id or name, at the frame's left edge below the shapes
+// already in it, the way moveCard stacks cards. An action item card moved into a kanban column takes the
+// column's status. Frames and connectors can't be moved, they would leave what they hold or link behind
+func MoveIntoFrame(shapes []models.BoardData, shapeID uuid.UUID, frameRef string) (*FrameMove, error) {
+	var shape *models.BoardData
+	for i := range shapes {
+		if shapes[i].UUID == shapeID {
+			shape = &shapes[i]
+		}
+	}
+	if shape == nil {
+		return nil, ErrShapeNotOnBoard
+	}
+	switch shape.Type {
+	case models.Frame, models.Arrow, models.Line:
+		return nil, fmt.Errorf("%w: it is a %s", ErrShapeNotMovable, shape.Type)
+	}
+	frame := findFrame(shapes, frameRef)
+	if frame == nil {
+		return nil, fmt.Errorf("%w: %q", ErrFrameNotFound, frameRef)
+	}
+	frameBounds, frameData, err := GetShapeBounds(*frame, 0)
+	if err != nil {
+		return nil, err
+	}
+	name, _ := frameData["name"].(string)
+	move := &FrameMove{FrameID: frame.UUID, FrameName: strings.TrimSpace(name)}
+
+	bounds, data, err := GetShapeBounds(*shape, 0)
+	if err != nil {
+		return nil, err
+	}
+	if containsBounds(frameBounds, (bounds.MinX+bounds.MaxX)/2, (bounds.MinY+bounds.MaxY)/2) {
+		return move, nil
+	}
+
+	// below the shapes already in the frame
+	y := frameBounds.MinY + kanbanColumnHeader
+	for _, other := range shapes {
+		if other.UUID == shape.UUID || other.Type == models.Frame {
+			continue
+		}
+		otherBounds, _, err := GetShapeBounds(other, 0)
+		if err != nil {
+			continue
+		}
+		inFrame := containsBounds(frameBounds, (otherBounds.MinX+otherBounds.MaxX)/2, (otherBounds.MinY+otherBounds.MaxY)/2)
+		if inFrame && otherBounds.MaxY+actionItemCardGap > y {
+			y = otherBounds.MaxY + actionItemCardGap
+		}
+	}
+
+	// the bounds move with x and y, points being relative to them
+	x, _ := data["x"].(float64)
+	top, _ := data["y"].(float64)
+	data["x"] = x + frameBounds.MinX + kanbanColumnPadding - bounds.MinX
+	data["y"] = top + y - bounds.MinY
+	if status, _ := frameData["kanbanStatus"].(string); shape.Type == models.ActionItemCard && strings.TrimSpace(status) != "" {
+		data["status"] = strings.TrimSpace(status)
+	}
+	bytes, err := json.Marshal(data)
+	if err != nil {
+		return nil, err
+	}
+	move.Data = bytes
+	return move, nil
+}
@@ -0,0 +1,94 @@
+package tools
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"melina-studio-backend/internal/models"
+	"sort"
+	"strings"
+)
+
+// frameSpecProps are the shape properties that carry meaning for code generation
+var frameSpecProps = []string{"text", "placeholder", "variant", "items", "name", "fill", "stroke", "fontSize"}
+
+// FrameSpec is the structured description of a frame and the shapes inside it
+type FrameSpec struct {
+	Name       string                   `json:"name"`
+	Width      float64                  `json:"width"`
+	Height     float64                  `json:"height"`
+	Components []map[string]interface{} `json:"components"`
+}
+
+// BuildFrameSpec describes the shapes whose top-left corner lies inside the frame,
+// with positions relative to the frame and in reading order (top to bottom, left to right)
+func BuildFrameSpec(frame models.BoardData, shapes []models.BoardData) (*FrameSpec, error) {
+	if frame.Type != models.Frame {
+		return nil, fmt.Errorf("shape %s is a %s, not a frame", frame.UUID, frame.Type)
+	}
+	frameBounds, frameData, err := GetShapeBounds(frame, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read frame: %w", err)
+	}
+
+	name, _ := frameData["name"].(string)
+	if strings.TrimSpace(name) == "" {
+		name = "Screen"
+	}
+	spec := &FrameSpec{
+		Name:       name,
+		Width:      frameBounds.MaxX - frameBounds.MinX,
+		Height:     frameBounds.MaxY - frameBounds.MinY,
+		Components: []map[string]interface{}{},
+	}
+
+	type child struct {
+		bounds    BoundingBox
+		component map[string]interface{}
+	}
+	var children []child
+
+	for _, shape := range shapes {
+		if shape.UUID == frame.UUID {
+			continue
+		}
+		bounds, data, err := GetShapeBounds(shape, 0)
+		if err != nil || !containsBounds(frameBounds, bounds.MinX, bounds.MinY) {
+			continue
+		}
+
+		component := map[string]interface{}{
+			"type": string(shape.Type),
+			"x":    math.Round(bounds.MinX - frameBounds.MinX),
+			"y":    math.Round(bounds.MinY - frameBounds.MinY),
+			"w":    math.Round(bounds.MaxX - bounds.MinX),
+			"h":    math.Round(bounds.MaxY - bounds.MinY),
+		}
+		for _, key := range frameSpecProps {
+			if v, ok := data[key]; ok && v != "" {
+				component[key] = v
+			}
+		}
+		children = append(children, child{bounds: bounds, component: component})
+	}
+
+	sort.SliceStable(children, func(i, j int) bool {
+		if children[i].bounds.MinY != children[j].bounds.MinY {
+			return children[i].bounds.MinY < children[j].bounds.MinY
+		}
+		return children[i].bounds.MinX < children[j].bounds.MinX
+	})
+	for _, c := range children {
+		spec.Components = append(spec.Components, c.component)
+	}
+	return spec, nil
+}
+
+// JSON renders the spec as indented JSON for LLM prompts
+func (s *FrameSpec) JSON() (string, error) {
+	bytes, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	return string(bytes), nil
+}
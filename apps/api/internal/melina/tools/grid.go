@@ -0,0 +1,116 @@
+package tools
+
+import (
+	"fmt"
+	"math"
+
+	"melina-studio-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// Grid sizes a board can use, in canvas pixels
+const (
+	MinGridSize = 4
+	MaxGridSize = 200
+)
+
+// ValidateGridSize limits a board's grid to sizes the canvas can draw
+func ValidateGridSize(size int) error {
+	if size < MinGridSize || size > MaxGridSize {
+		return fmt.Errorf("grid size must be between %d and %d", MinGridSize, MaxGridSize)
+	}
+	return nil
+}
+
+// BoardSnapGrid returns the grid the board's shapes snap to, or 0 when snapping is off
+func BoardSnapGrid(board models.Board) float64 {
+	if !board.SnapToGrid || board.GridSize <= 0 {
+		return 0
+	}
+	return float64(board.GridSize)
+}
+
+// snapValue rounds a coordinate to the nearest grid line
+func snapValue(v float64, grid float64) float64 {
+	return math.Round(v/grid) * grid
+}
+
+// snapSize rounds a size to the grid, keeping at least one grid step so a shape never collapses
+func snapSize(v float64, grid float64) float64 {
+	return math.Max(grid, snapValue(v, grid))
+}
+
+// SnapShapeMap rounds the position and size of a shape, in the map form the tools send to the
+// client, to the grid. Points of lines and polygons are relative to x and y and move with them
+func SnapShapeMap(shape map[string]interface{}, grid float64) {
+	if grid <= 0 {
+		return
+	}
+	for _, key := range []string{"x", "y"} {
+		if v, ok := shape[key].(float64); ok {
+			shape[key] = snapValue(v, grid)
+		}
+	}
+	for _, key := range []string{"w", "h"} {
+		if v, ok := shape[key].(float64); ok {
+			shape[key] = snapSize(v, grid)
+		}
+	}
+	for _, key := range []string{"start", "end"} {
+		if point, ok := shape[key].(map[string]interface{}); ok {
+			for _, axis := range []string{"x", "y"} {
+				if v, ok := point[axis].(float64); ok {
+					point[axis] = snapValue(v, grid)
+				}
+			}
+		}
+	}
+}
+
+// SnapShape is SnapShapeMap for shapes saved by the client; it reports whether anything moved
+func SnapShape(shape *models.Shape, grid float64) bool {
+	if grid <= 0 {
+		return false
+	}
+	changed := false
+	snap := func(v *float64, round func(float64, float64) float64) {
+		if v == nil {
+			return
+		}
+		if snapped := round(*v, grid); snapped != *v {
+			*v = snapped
+			changed = true
+		}
+	}
+	snap(shape.X, snapValue)
+	snap(shape.Y, snapValue)
+	snap(shape.W, snapSize)
+	snap(shape.H, snapSize)
+	for _, point := range []map[string]float64{shape.Start, shape.End} {
+		for axis, v := range point {
+			if snapped := snapValue(v, grid); snapped != v {
+				point[axis] = snapped
+				changed = true
+			}
+		}
+	}
+	return changed
+}
+
+// boardSnapGrid looks up the grid the board's shapes snap to; a board that can't be loaded doesn't snap
+func (d *ToolDeps) boardSnapGrid(userID string, boardIdStr string) float64 {
+	userId, err := uuid.Parse(userID)
+	if err != nil {
+		return 0
+	}
+	boardId, err := uuid.Parse(boardIdStr)
+	if err != nil {
+		return 0
+	}
+	board, err := d.Boards.GetBoardById(userId, boardId)
+	if err != nil {
+		return 0
+	}
+	return BoardSnapGrid(board)
+}
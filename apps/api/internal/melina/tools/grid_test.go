@@ -0,0 +1,53 @@
+package tools
+
+import (
+	"testing"
+
+	"melina-studio-backend/internal/models"
+)
+
+func TestSnapShapeMap(t *testing.T) {
+	shape := map[string]interface{}{
+		"x": 31.0, "y": 9.0, "w": 47.0, "h": 4.0,
+		"start":  map[string]interface{}{"x": 12.0, "y": 58.0},
+		"points": []float64{0, 0, 13, 7},
+	}
+	SnapShapeMap(shape, 20)
+	if shape["x"] != 40.0 || shape["y"] != 0.0 || shape["w"] != 40.0 || shape["h"] != 20.0 {
+		t.Errorf("unexpected snapped position and size %v", shape)
+	}
+	if start := shape["start"].(map[string]interface{}); start["x"] != 20.0 || start["y"] != 60.0 {
+		t.Errorf("expected the arrow start to snap, got %v", start)
+	}
+	if points := shape["points"].([]float64); points[2] != 13 {
+		t.Errorf("expected relative points to be left alone, got %v", points)
+	}
+}
+
+func TestSnapShape(t *testing.T) {
+	x, y, w := 40.0, 61.0, 100.0
+	shape := &models.Shape{X: &x, Y: &y, W: &w}
+	if !SnapShape(shape, 20) || *shape.Y != 60 {
+		t.Errorf("expected y to snap to 60, got %v", *shape.Y)
+	}
+	if SnapShape(shape, 20) {
+		t.Error("expected an already snapped shape to be left unchanged")
+	}
+	if SnapShape(&models.Shape{X: &y}, 0) {
+		t.Error("expected no snapping without a grid")
+	}
+}
+
+func TestBoardSnapGrid(t *testing.T) {
+	if grid := BoardSnapGrid(models.Board{GridSize: 20}); grid != 0 {
+		t.Errorf("expected no grid when snapping is off, got %v", grid)
+	}
+	if grid := BoardSnapGrid(models.Board{GridSize: 25, SnapToGrid: true}); grid != 25 {
+		t.Errorf("expected the board's grid, got %v", grid)
+	}
+	for size, valid := range map[int]bool{2: false, 4: true, 20: true, 200: true, 500: false} {
+		if err := ValidateGridSize(size); (err == nil) != valid {
+			t.Errorf("grid size %d: got %v", size, err)
+		}
+	}
+}
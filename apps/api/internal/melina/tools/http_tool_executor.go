@@ -0,0 +1,286 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"syscall"
+	"time"
+
+	"melina-studio-backend/internal/config"
+)
+
+// maxToolRedirects is how many redirects a custom tool call follows, each to an allowed host
+const maxToolRedirects = 3
+
+// toolErrorBodyLimit is how much of a failed call's response is shown to the model
+const toolErrorBodyLimit = 500
+
+var (
+	ErrToolHostNotAllowed = errors.New("host is not in CUSTOM_TOOLS_ALLOWED_HOSTS")
+	ErrToolAddressBlocked = errors.New("address is on a private network")
+	ErrToolResponseTooBig = errors.New("response is larger than CUSTOM_TOOLS_MAX_RESPONSE_KB")
+)
+
+// urlPlaceholder matches the {name} argument placeholders of a custom tool's URL
+var urlPlaceholder = regexp.MustCompile(`\{([A-Za-z_][A-Za-z0-9_]*)\}`)
+
+// headerEnvRef matches the {{env:NAME}} references of a custom tool's headers
+var headerEnvRef = regexp.MustCompile(`\{\{env:([A-Z0-9_]+)\}\}`)
+
+// customToolEnvPrefix limits the environment variables headers can read, so a tool can't send JWT_SECRET anywhere
+const customToolEnvPrefix = "CUSTOM_TOOL_"
+
+// HTTPToolExecutor makes the HTTP calls of custom tools inside the deployment's limits: only allowed hosts,
+// https unless http is allowed, no private addresses even through DNS or redirects, a timeout per call
+// and a cap on the response read
+type HTTPToolExecutor struct {
+	cfg    config.CustomToolConfig
+	client *http.Client
+}
+
+func NewHTTPToolExecutor(cfg config.CustomToolConfig) *HTTPToolExecutor {
+	dialer := &net.Dialer{
+		Timeout: 10 * time.Second,
+		// the address is checked after DNS resolution, so a name can't be pointed at an internal service
+		Control: func(network, address string, _ syscall.RawConn) error {
+			if cfg.AllowPrivateNetworks {
+				return nil
+			}
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			if ip := net.ParseIP(host); ip == nil || blockedToolIP(ip) {
+				return fmt.Errorf("%w: %s", ErrToolAddressBlocked, host)
+			}
+			return nil
+		},
+	}
+	transport := &http.Transport{
+		// no proxy from the environment: it would connect on the tools' behalf, past the address check
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ResponseHeaderTimeout: cfg.MaxTimeout,
+		MaxIdleConnsPerHost:   4,
+		IdleConnTimeout:       90 * time.Second,
+	}
+	executor := &HTTPToolExecutor{cfg: cfg}
+	executor.client = &http.Client{
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= maxToolRedirects {
+				return fmt.Errorf("stopped after %d redirects", maxToolRedirects)
+			}
+			return executor.checkURL(req.URL)
+		},
+	}
+	return executor
+}
+
+// blockedToolNets are the ranges that aren't public but that net.IP has no check for: carrier-grade NAT, which
+// some clouds use internally, and "this network"
+var blockedToolNets = []*net.IPNet{
+	mustParseCIDR("100.64.0.0/10"),
+	mustParseCIDR("0.0.0.0/8"),
+}
+
+func mustParseCIDR(cidr string) *net.IPNet {
+	_, block, err := net.ParseCIDR(cidr)
+	if err != nil {
+		panic(err)
+	}
+	return block
+}
+
+// blockedToolIP reports whether an address is loopback, private, link-local (cloud metadata) or otherwise not public
+func blockedToolIP(ip net.IP) bool {
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() || ip.IsUnspecified() {
+		return true
+	}
+	for _, block := range blockedToolNets {
+		if block.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkURL applies the scheme and host allowlist to a URL a tool calls or is redirected to
+func (e *HTTPToolExecutor) checkURL(u *url.URL) error {
+	switch u.Scheme {
+	case "https":
+	case "http":
+		if !e.cfg.AllowHTTP {
+			return errors.New("custom tools must call https URLs")
+		}
+	default:
+		return fmt.Errorf("unsupported URL scheme %q", u.Scheme)
+	}
+	if !e.cfg.AllowsHost(u.Host) {
+		return fmt.Errorf("%w: %s", ErrToolHostNotAllowed, u.Hostname())
+	}
+	return nil
+}
+
+// timeout returns how long a call of the tool may take
+func (e *HTTPToolExecutor) timeout(spec CustomToolSpec) time.Duration {
+	timeout := spec.Timeout
+	if timeout <= 0 {
+		timeout = e.cfg.DefaultTimeout
+	}
+	if e.cfg.MaxTimeout > 0 && timeout > e.cfg.MaxTimeout {
+		timeout = e.cfg.MaxTimeout
+	}
+	return timeout
+}
+
+// Execute calls the tool's endpoint with the model's arguments and returns the status and decoded response
+func (e *HTTPToolExecutor) Execute(ctx context.Context, spec CustomToolSpec, input map[string]interface{}) (interface{}, error) {
+	req, err := e.buildRequest(ctx, spec, input)
+	if err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(ctx, e.timeout(spec))
+	defer cancel()
+	req = req.WithContext(ctx)
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) {
+			return nil, fmt.Errorf("%s timed out after %s", spec.Name, e.timeout(spec))
+		}
+		return nil, fmt.Errorf("%s failed: %w", spec.Name, err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, e.cfg.MaxResponseBytes+1))
+	if err != nil {
+		return nil, fmt.Errorf("%s failed reading the response: %w", spec.Name, err)
+	}
+	if int64(len(body)) > e.cfg.MaxResponseBytes {
+		return nil, fmt.Errorf("%s: %w", spec.Name, ErrToolResponseTooBig)
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		text := string(body)
+		if len(text) > toolErrorBodyLimit {
+			text = text[:toolErrorBodyLimit] + "..."
+		}
+		return nil, fmt.Errorf("%s: the endpoint answered %d: %s", spec.Name, resp.StatusCode, text)
+	}
+
+	result := map[string]interface{}{"status": resp.StatusCode}
+	var decoded interface{}
+	if len(body) > 0 && json.Unmarshal(body, &decoded) == nil {
+		result["body"] = decoded
+	} else {
+		result["body"] = string(body)
+	}
+	return result, nil
+}
+
+// Send makes a request built by the caller, e.g. an export upload, inside the same limits as the tool calls.
+// The caller bounds it with the request's context and closes the response
+func (e *HTTPToolExecutor) Send(req *http.Request) (*http.Response, error) {
+	if err := e.checkURL(req.URL); err != nil {
+		return nil, err
+	}
+	return e.client.Do(req)
+}
+
+// buildRequest fills the URL placeholders with their arguments and sends the rest as query parameters
+// (GET, DELETE) or a JSON body
+func (e *HTTPToolExecutor) buildRequest(ctx context.Context, spec CustomToolSpec, input map[string]interface{}) (*http.Request, error) {
+	args := make(map[string]interface{}, len(input))
+	for key, value := range input {
+		args[key] = value
+	}
+
+	var missing []string
+	rawURL := urlPlaceholder.ReplaceAllStringFunc(spec.URL, func(match string) string {
+		name := match[1 : len(match)-1]
+		value, ok := args[name]
+		if !ok {
+			missing = append(missing, name)
+			return match
+		}
+		delete(args, name)
+		return url.PathEscape(argString(value))
+	})
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("%s needs %s", spec.Name, strings.Join(missing, ", "))
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return nil, fmt.Errorf("%s has an invalid URL: %w", spec.Name, err)
+	}
+	if err := e.checkURL(u); err != nil {
+		return nil, err
+	}
+
+	var body io.Reader
+	switch spec.Method {
+	case http.MethodGet, http.MethodDelete:
+		query := u.Query()
+		for key, value := range args {
+			query.Set(key, argString(value))
+		}
+		u.RawQuery = query.Encode()
+	default:
+		data, err := json.Marshal(args)
+		if err != nil {
+			return nil, err
+		}
+		body = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, spec.Method, u.String(), body)
+	if err != nil {
+		return nil, err
+	}
+	for name, value := range spec.Headers {
+		req.Header.Set(name, expandHeaderEnv(value))
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	req.Header.Set("Accept", "application/json")
+	req.Header.Set("User-Agent", "Melina-Tools/1.0")
+	return req, nil
+}
+
+// argString renders an argument for a URL or query string; objects and arrays are sent as JSON
+func argString(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case nil:
+		return ""
+	case float64, bool, int:
+		return fmt.Sprint(v)
+	}
+	data, _ := json.Marshal(value)
+	return string(data)
+}
+
+// expandHeaderEnv replaces the {{env:CUSTOM_TOOL_*}} references of a header value
+func expandHeaderEnv(value string) string {
+	return headerEnvRef.ReplaceAllStringFunc(value, func(match string) string {
+		name := headerEnvRef.FindStringSubmatch(match)[1]
+		if !strings.HasPrefix(name, customToolEnvPrefix) {
+			return ""
+		}
+		return os.Getenv(name)
+	})
+}
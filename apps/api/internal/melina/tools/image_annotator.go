@@ -1,11 +1,9 @@
 package tools
 
 import (
-	"bytes"
 	"encoding/base64"
 	"fmt"
 	"image/color"
-	"image/png"
 	"math"
 	"os"
 	"path/filepath"
@@ -235,39 +233,21 @@ func getFontPath() string {
 // AnnotateImage takes a base64-encoded PNG image and shapes data,
 // draws numbered badges at each shape's center, and returns the annotated base64 image
 func AnnotateImage(imageBase64 string, shapes []map[string]interface{}) (string, []ShapeCenter, error) {
-	// Decode base64 image
-	imageData, err := base64.StdEncoding.DecodeString(imageBase64)
+	// Decode the image into a drawing context
+	dc, release, err := newAnnotationCanvas(imageBase64)
 	if err != nil {
-		return "", nil, fmt.Errorf("failed to decode base64 image: %w", err)
+		return "", nil, err
 	}
+	defer release()
 
-	// Decode PNG
-	img, err := png.Decode(bytes.NewReader(imageData))
-	if err != nil {
-		return "", nil, fmt.Errorf("failed to decode PNG: %w", err)
-	}
-
-	// Create drawing context
-	bounds := img.Bounds()
-	dc := gg.NewContext(bounds.Dx(), bounds.Dy())
-	dc.DrawImage(img, 0, 0)
-
-	// Load font
-	fontPath := getFontPath()
+	// Use the cached font, gg's default face if it isn't available
 	config := DefaultBadgeConfig()
-
-	// Try to load the font, use default if not available
-	if _, err := os.Stat(fontPath); err == nil {
-		if err := dc.LoadFontFace(fontPath, config.FontSize); err != nil {
-			// Font loading failed, will use default
-			fmt.Printf("Warning: Could not load font from %s: %v\n", fontPath, err)
-		}
-	}
+	setFontSize(dc, config.FontSize)
 
 	// Calculate centers and draw badges
 	centers := make([]ShapeCenter, 0, len(shapes))
-	imgWidth := float64(bounds.Dx())
-	imgHeight := float64(bounds.Dy())
+	imgWidth := float64(dc.Width())
+	imgHeight := float64(dc.Height())
 
 	for i, shape := range shapes {
 		shapeType, ok := shape["type"].(string)
@@ -303,13 +283,10 @@ func AnnotateImage(imageBase64 string, shapes []map[string]interface{}) (string,
 	}
 
 	// Encode result to PNG
-	var buf bytes.Buffer
-	if err := png.Encode(&buf, dc.Image()); err != nil {
-		return "", nil, fmt.Errorf("failed to encode annotated image: %w", err)
+	annotatedBase64, err := encodeAnnotation(dc)
+	if err != nil {
+		return "", nil, err
 	}
-
-	// Return base64 encoded result
-	annotatedBase64 := base64.StdEncoding.EncodeToString(buf.Bytes())
 	return annotatedBase64, centers, nil
 }
 
@@ -354,45 +331,28 @@ func AnnotateImageFromFile(imagePath string, shapes []map[string]interface{}) (s
 // Each shape map must have a "number" field with the annotation number
 // Note: The frontend exports images at 2x pixelRatio, so coordinates must be scaled
 func AnnotateImageWithNumbers(imageBase64 string, shapes []map[string]interface{}) (string, []ShapeCenter, error) {
-	// Decode base64 image
-	imageData, err := base64.StdEncoding.DecodeString(imageBase64)
+	// Decode the image into a drawing context
+	dc, release, err := newAnnotationCanvas(imageBase64)
 	if err != nil {
-		return "", nil, fmt.Errorf("failed to decode base64 image: %w", err)
+		return "", nil, err
 	}
-
-	// Decode PNG
-	img, err := png.Decode(bytes.NewReader(imageData))
-	if err != nil {
-		return "", nil, fmt.Errorf("failed to decode PNG: %w", err)
-	}
-
-	// Create drawing context
-	bounds := img.Bounds()
-	dc := gg.NewContext(bounds.Dx(), bounds.Dy())
-	dc.DrawImage(img, 0, 0)
+	defer release()
 
 	// The frontend exports images at 2x pixelRatio (see helpers.ts)
 	// Shape coordinates are in canvas units, but the image is 2x larger
 	pixelRatio := 2.0
 
-	// Load font - scale font size for higher resolution image
-	fontPath := getFontPath()
+	// Scale the badges for the higher resolution image
 	config := DefaultBadgeConfig()
 	config.Radius *= pixelRatio
 	config.BorderWidth *= pixelRatio
 	config.FontSize *= pixelRatio
-
-	// Try to load the font, use default if not available
-	if _, err := os.Stat(fontPath); err == nil {
-		if err := dc.LoadFontFace(fontPath, config.FontSize); err != nil {
-			fmt.Printf("Warning: Could not load font from %s: %v\n", fontPath, err)
-		}
-	}
+	setFontSize(dc, config.FontSize)
 
 	// Calculate centers and draw badges
 	centers := make([]ShapeCenter, 0, len(shapes))
-	imgWidth := float64(bounds.Dx())
-	imgHeight := float64(bounds.Dy())
+	imgWidth := float64(dc.Width())
+	imgHeight := float64(dc.Height())
 
 	for _, shape := range shapes {
 		shapeType, ok := shape["type"].(string)
@@ -443,12 +403,9 @@ func AnnotateImageWithNumbers(imageBase64 string, shapes []map[string]interface{
 	}
 
 	// Encode result to PNG
-	var buf bytes.Buffer
-	if err := png.Encode(&buf, dc.Image()); err != nil {
-		return "", nil, fmt.Errorf("failed to encode annotated image: %w", err)
+	annotatedBase64, err := encodeAnnotation(dc)
+	if err != nil {
+		return "", nil, err
 	}
-
-	// Return base64 encoded result
-	annotatedBase64 := base64.StdEncoding.EncodeToString(buf.Bytes())
 	return annotatedBase64, centers, nil
 }
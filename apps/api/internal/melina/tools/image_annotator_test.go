@@ -284,3 +284,73 @@ func TestAnnotateImage_InvalidShapeData(t *testing.T) {
 		t.Error("Expected non-empty annotated image")
 	}
 }
+
+// benchmarkShapes lays out n numbered rects on a grid, like a busy board
+func benchmarkShapes(n int) []map[string]interface{} {
+	shapes := make([]map[string]interface{}, n)
+	for i := range shapes {
+		shapes[i] = map[string]interface{}{
+			"type":   "rect",
+			"number": i + 1,
+			"x":      float64(40 + (i%10)*90),
+			"y":      float64(40 + (i/10)*70),
+			"w":      80.0,
+			"h":      60.0,
+		}
+	}
+	return shapes
+}
+
+// BenchmarkAnnotateImageWithNumbers annotates a 100-shape board exported at 2x
+func BenchmarkAnnotateImageWithNumbers(b *testing.B) {
+	img := createTestImage(2000, 1500)
+	shapes := benchmarkShapes(100)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, _, err := AnnotateImageWithNumbers(img, shapes); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func TestEncodePNGRoundTrip(t *testing.T) {
+	for _, tc := range []struct {
+		name          string
+		width, height int
+		alpha         uint8
+	}{
+		{"opaque multi strip", 300, 700, 255},
+		{"translucent", 120, 90, 128},
+		{"single row", 17, 1, 255},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			img := image.NewRGBA(image.Rect(0, 0, tc.width, tc.height))
+			for y := 0; y < tc.height; y++ {
+				for x := 0; x < tc.width; x++ {
+					c := color.NRGBA{R: uint8(x * 7), G: uint8(y * 3), B: uint8(x ^ y), A: tc.alpha}
+					if x%11 == 0 {
+						c.A = 0
+					}
+					img.Set(x, y, c)
+				}
+			}
+
+			data, err := encodePNG(img)
+			if err != nil {
+				t.Fatalf("encodePNG: %v", err)
+			}
+			decoded, err := png.Decode(bytes.NewReader(data))
+			if err != nil {
+				t.Fatalf("decoding the encoded PNG: %v", err)
+			}
+			for y := 0; y < tc.height; y++ {
+				for x := 0; x < tc.width; x++ {
+					want := color.NRGBAModel.Convert(img.At(x, y))
+					if got := color.NRGBAModel.Convert(decoded.At(x, y)); got != want {
+						t.Fatalf("pixel (%d,%d) = %v, want %v", x, y, got, want)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,456 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"strings"
+
+	"melina-studio-backend/internal/libraries"
+	llmHandlers "melina-studio-backend/internal/llm_handlers"
+	"melina-studio-backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+var createColumnSchema = toolSchema{
+	Name:        "createColumn",
+	Description: "Creates a kanban column: a frame in kanban mode whose action item cards take its status. Moving a card into the column, by you or the user, sets the card's status to the column's. New columns go right of the existing ones unless you pass x and y.",
+	Parameters: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"boardId": map[string]interface{}{
+				"type":        "string",
+				"description": "The UUID of the board",
+			},
+			"name": map[string]interface{}{
+				"type":        "string",
+				"description": "Column title, e.g. To do, In progress, Done",
+			},
+			"status": map[string]interface{}{
+				"type":        "string",
+				"description": "Status the column's cards get (optional, defaults to the name in lower case)",
+			},
+			"x": map[string]interface{}{
+				"type":        "number",
+				"description": "X coordinate (optional, defaults to right of the last column)",
+			},
+			"y": map[string]interface{}{
+				"type":        "number",
+				"description": "Y coordinate (optional, defaults to the top of the last column)",
+			},
+			"width": map[string]interface{}{
+				"type":        "number",
+				"description": "Width (optional, defaults to one card plus padding)",
+			},
+			"height": map[string]interface{}{
+				"type":        "number",
+				"description": "Height (optional)",
+			},
+		},
+		"required": []string{"boardId", "name"},
+	},
+}
+
+var moveCardSchema = toolSchema{
+	Name:        "moveCard",
+	Description: "Moves an action item card into a kanban column, below the cards already in it, and sets the card's status to the column's. Use it instead of updateShape to change a card's status on a kanban board.",
+	Parameters: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"boardId": map[string]interface{}{
+				"type":        "string",
+				"description": "The UUID of the board",
+			},
+			"cardId": map[string]interface{}{
+				"type":        "string",
+				"description": "The id of the action item card",
+			},
+			"column": map[string]interface{}{
+				"type":        "string",
+				"description": "The id, name or status of the target column",
+			},
+		},
+		"required": []string{"boardId", "cardId", "column"},
+	},
+}
+
+const (
+	kanbanColumnPadding = 30.0
+	kanbanColumnHeader  = 60.0
+	kanbanColumnGap     = 40.0
+	kanbanColumnHeight  = 640.0
+	// defaultCardStatus is the status of a card that was never in a column, as trackers see it
+	defaultCardStatus = "open"
+)
+
+// kanbanColumn is a frame in kanban mode
+type kanbanColumn struct {
+	id     uuid.UUID
+	name   string
+	status string
+	bounds BoundingBox
+}
+
+// CardStatusChange is a card that took the status of the column it is in
+type CardStatusChange struct {
+	ShapeID        uuid.UUID
+	ColumnID       uuid.UUID
+	Title          string
+	Status         string
+	PreviousStatus string
+	Data           datatypes.JSON // the card's data with its new status
+}
+
+// kanbanColumns returns the board's frames in kanban mode
+func kanbanColumns(shapes []models.BoardData) []kanbanColumn {
+	var columns []kanbanColumn
+	for _, shape := range shapes {
+		if shape.Type != models.Frame {
+			continue
+		}
+		bounds, data, err := GetShapeBounds(shape, 0)
+		if err != nil {
+			continue
+		}
+		status, _ := data["kanbanStatus"].(string)
+		if status = strings.TrimSpace(status); status == "" {
+			continue
+		}
+		name, _ := data["name"].(string)
+		columns = append(columns, kanbanColumn{id: shape.UUID, name: strings.TrimSpace(name), status: status, bounds: bounds})
+	}
+	return columns
+}
+
+// columnAt returns the column a card is in, going by the card's center; the smallest column wins when they overlap
+func columnAt(columns []kanbanColumn, card BoundingBox) *kanbanColumn {
+	x, y := (card.MinX+card.MaxX)/2, (card.MinY+card.MaxY)/2
+	var found *kanbanColumn
+	for i := range columns {
+		if containsBounds(columns[i].bounds, x, y) && (found == nil || boundsArea(columns[i].bounds) < boundsArea(found.bounds)) {
+			found = &columns[i]
+		}
+	}
+	return found
+}
+
+// cardStatus is the status of a card's data, open when it has none
+func cardStatus(data map[string]interface{}) string {
+	if status, ok := data["status"].(string); ok && status != "" {
+		return status
+	}
+	return defaultCardStatus
+}
+
+// SyncCardStatuses gives every unlocked action item card in a kanban column the column's status and returns
+// the cards that changed. Cards outside the columns keep their status
+func SyncCardStatuses(shapes []models.BoardData) []CardStatusChange {
+	columns := kanbanColumns(shapes)
+	if len(columns) == 0 {
+		return nil
+	}
+	var changes []CardStatusChange
+	for _, shape := range shapes {
+		if shape.Type != models.ActionItemCard || shape.Locked {
+			continue
+		}
+		bounds, data, err := GetShapeBounds(shape, 0)
+		if err != nil {
+			continue
+		}
+		column := columnAt(columns, bounds)
+		if column == nil {
+			continue
+		}
+		previous := cardStatus(data)
+		if previous == column.status {
+			continue
+		}
+		data["status"] = column.status
+		bytes, err := json.Marshal(data)
+		if err != nil {
+			continue
+		}
+		title, _ := data["text"].(string)
+		changes = append(changes, CardStatusChange{
+			ShapeID:        shape.UUID,
+			ColumnID:       column.id,
+			Title:          title,
+			Status:         column.status,
+			PreviousStatus: previous,
+			Data:           bytes,
+		})
+	}
+	return changes
+}
+
+// CardMovedActivity describes a card that changed columns for the activity feed
+func CardMovedActivity(userID uuid.UUID, boardID uuid.UUID, actor models.ActivityActor, change CardStatusChange) *models.BoardActivity {
+	summary := fmt.Sprintf("Moved %q to %s", change.Title, change.Status)
+	if actor == models.ActivityActorAgent {
+		summary = fmt.Sprintf("Melina moved %q to %s", change.Title, change.Status)
+	}
+	metadata, _ := json.Marshal(map[string]string{
+		"column_id": change.ColumnID.String(),
+		"from":      change.PreviousStatus,
+		"to":        change.Status,
+	})
+	shapeID := change.ShapeID
+	return &models.BoardActivity{
+		BoardID:   boardID,
+		UserID:    userID,
+		Type:      models.ActivityCardMoved,
+		Actor:     actor,
+		ShapeID:   &shapeID,
+		ShapeType: string(models.ActionItemCard),
+		Summary:   summary,
+		Metadata:  datatypes.JSON(metadata),
+	}
+}
+
+// findKanbanColumn matches a column by id, name or status
+func findKanbanColumn(columns []kanbanColumn, ref string) *kanbanColumn {
+	ref = strings.TrimSpace(ref)
+	for i := range columns {
+		if columns[i].id.String() == ref {
+			return &columns[i]
+		}
+	}
+	for i := range columns {
+		if strings.EqualFold(columns[i].name, ref) || strings.EqualFold(columns[i].status, ref) {
+			return &columns[i]
+		}
+	}
+	return nil
+}
+
+// CreateColumnHandler is the handler for the createColumn tool
+func (d *ToolDeps) CreateColumnHandler(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+	boardIdStr, ok := input["boardId"].(string)
+	if !ok {
+		return nil, llmHandlers.InvalidToolInput("boardId is required and must be a string")
+	}
+	boardId, err := uuid.Parse(boardIdStr)
+	if err != nil {
+		return nil, llmHandlers.InvalidToolInput("invalid boardId: %v", err)
+	}
+	name, _ := input["name"].(string)
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, llmHandlers.InvalidToolInput("name is required")
+	}
+	status, _ := input["status"].(string)
+	status = strings.TrimSpace(status)
+	if status == "" {
+		status = strings.ToLower(name)
+	}
+
+	streamCtx, err := getStreamingContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	shapes, err := d.BoardData.GetBoardData(boardId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shapes from database: %w", err)
+	}
+	columns := kanbanColumns(shapes)
+	for _, column := range columns {
+		if strings.EqualFold(column.status, status) {
+			return nil, llmHandlers.InvalidToolInput("column %q already has the status %q", column.name, status)
+		}
+	}
+
+	// next to the last column, else below the content
+	x, y := placementOrigin, placementOrigin
+	if len(columns) > 0 {
+		last := columns[0].bounds
+		for _, column := range columns[1:] {
+			if column.bounds.MaxX > last.MaxX {
+				last = column.bounds
+			}
+		}
+		x, y = last.MaxX+kanbanColumnGap, last.MinY
+	} else if state := GenerateCanvasState(shapes, 0, 0); state != nil {
+		x, y = state.OverallBounds.MinX, state.OverallBounds.MaxY+clusterFrameGap
+	}
+	if value, ok := input["x"].(float64); ok {
+		x = value
+	}
+	if value, ok := input["y"].(float64); ok {
+		y = value
+	}
+	width, height := actionItemCardWidth+2*kanbanColumnPadding, kanbanColumnHeight
+	if value, ok := input["width"].(float64); ok && value > 0 {
+		width = value
+	}
+	if value, ok := input["height"].(float64); ok && value > 0 {
+		height = value
+	}
+	stroke := "#94a3b8"
+
+	shape := &models.Shape{
+		ID:           uuid.New().String(),
+		Type:         string(models.Frame),
+		X:            &x,
+		Y:            &y,
+		W:            &width,
+		H:            &height,
+		Name:         &name,
+		KanbanStatus: &status,
+		Stroke:       &stroke,
+	}
+	if err := d.BoardData.SaveShapeData(boardId, shape); err != nil {
+		return nil, fmt.Errorf("failed to save column: %w", err)
+	}
+	shapeMap := map[string]interface{}{
+		"id":           shape.ID,
+		"type":         shape.Type,
+		"x":            x,
+		"y":            y,
+		"w":            width,
+		"h":            height,
+		"name":         name,
+		"kanbanStatus": status,
+		"stroke":       stroke,
+	}
+	sendShapeCreated(ctx, streamCtx, boardIdStr, shapeMap)
+	d.invalidateBoardImageCache(streamCtx, boardId)
+
+	return map[string]interface{}{
+		"success":  true,
+		"columnId": shape.ID,
+		"status":   status,
+		"message":  fmt.Sprintf("Created column %q; cards moved into it get the status %q", name, status),
+	}, nil
+}
+
+// MoveCardHandler is the handler for the moveCard tool
+func (d *ToolDeps) MoveCardHandler(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+	boardIdStr, ok := input["boardId"].(string)
+	if !ok {
+		return nil, llmHandlers.InvalidToolInput("boardId is required and must be a string")
+	}
+	boardId, err := uuid.Parse(boardIdStr)
+	if err != nil {
+		return nil, llmHandlers.InvalidToolInput("invalid boardId: %v", err)
+	}
+	cardIdStr, ok := input["cardId"].(string)
+	if !ok {
+		return nil, llmHandlers.InvalidToolInput("cardId is required and must be a string")
+	}
+	columnRef, _ := input["column"].(string)
+	if strings.TrimSpace(columnRef) == "" {
+		return nil, llmHandlers.InvalidToolInput("column is required")
+	}
+
+	streamCtx, err := getStreamingContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	shapes, err := d.BoardData.GetBoardData(boardId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shapes from database: %w", err)
+	}
+	columns := kanbanColumns(shapes)
+	column := findKanbanColumn(columns, columnRef)
+	if column == nil {
+		return nil, llmHandlers.InvalidToolInput("no kanban column %q on the board; create it with createColumn first", columnRef)
+	}
+
+	var card *models.BoardData
+	for i := range shapes {
+		if shapes[i].UUID.String() == cardIdStr {
+			card = &shapes[i]
+		}
+	}
+	if card == nil {
+		return nil, llmHandlers.InvalidToolInput("shape with id %s not found on board", cardIdStr)
+	}
+	if card.Type != models.ActionItemCard {
+		return nil, llmHandlers.InvalidToolInput("shape %s is a %s, only action item cards can be moved between columns", cardIdStr, card.Type)
+	}
+	if card.Locked {
+		return lockedShapeResult(cardIdStr), nil
+	}
+	cardBounds, data, err := GetShapeBounds(*card, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse card data: %w", err)
+	}
+	previous := cardStatus(data)
+	if current := columnAt(columns, cardBounds); current != nil && current.id == column.id && previous == column.status {
+		return map[string]interface{}{
+			"success": true,
+			"cardId":  cardIdStr,
+			"status":  previous,
+			"message": fmt.Sprintf("The card is already in %q", column.name),
+		}, nil
+	}
+
+	// below the cards already in the column
+	y := column.bounds.MinY + kanbanColumnHeader
+	for _, shape := range shapes {
+		if shape.Type != models.ActionItemCard || shape.UUID == card.UUID {
+			continue
+		}
+		bounds, _, err := GetShapeBounds(shape, 0)
+		if err != nil {
+			continue
+		}
+		if in := columnAt(columns, bounds); in != nil && in.id == column.id && bounds.MaxY+actionItemCardGap > y {
+			y = bounds.MaxY + actionItemCardGap
+		}
+	}
+	shape, err := shapeDataMap(card.UUID, string(card.Type), card.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse card data: %w", err)
+	}
+	shape["x"] = column.bounds.MinX + kanbanColumnPadding
+	shape["y"] = y
+	shape["status"] = column.status
+	if err := d.persistShapeMove(streamCtx, boardId, shape); err != nil {
+		return nil, fmt.Errorf("failed to move card: %w", err)
+	}
+	d.invalidateBoardImageCache(streamCtx, boardId)
+
+	title, _ := data["text"].(string)
+	change := CardStatusChange{ShapeID: card.UUID, ColumnID: column.id, Title: title, Status: column.status, PreviousStatus: previous}
+	if previous != column.status {
+		d.recordCardMoved(streamCtx, boardId, change)
+	}
+
+	return map[string]interface{}{
+		"success": true,
+		"cardId":  cardIdStr,
+		"status":  column.status,
+		"message": fmt.Sprintf("Moved %q to %q", title, column.name),
+	}, nil
+}
+
+// recordCardMoved logs a status change the agent made and tells the user's connections about it
+func (d *ToolDeps) recordCardMoved(streamCtx *llmHandlers.StreamingContext, boardId uuid.UUID, change CardStatusChange) {
+	userId, err := uuid.Parse(streamCtx.UserID)
+	if err != nil {
+		return
+	}
+	activity := CardMovedActivity(userId, boardId, models.ActivityActorAgent, change)
+	if err := d.BoardActivity.Create(activity); err != nil {
+		log.Printf("Failed to record board activity: %v", err)
+	} else if streamCtx.Hub != nil {
+		libraries.SendBoardActivityMessage(streamCtx.Hub, streamCtx.UserID, &libraries.BoardActivityPayload{
+			BoardId:  boardId.String(),
+			Activity: activity,
+		})
+	}
+	if streamCtx.Hub != nil {
+		libraries.SendCardStatusMessage(streamCtx.Hub, streamCtx.UserID, &libraries.CardStatusPayload{
+			BoardId:        boardId.String(),
+			ShapeId:        change.ShapeID.String(),
+			ColumnId:       change.ColumnID.String(),
+			Status:         change.Status,
+			PreviousStatus: change.PreviousStatus,
+		})
+	}
+}
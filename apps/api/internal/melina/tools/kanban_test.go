@@ -0,0 +1,111 @@
+package tools
+
+import (
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"melina-studio-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func kanbanShape(t *testing.T, shapeType models.Type, data map[string]interface{}) models.BoardData {
+	t.Helper()
+	bytes, err := json.Marshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return models.BoardData{UUID: uuid.New(), Type: shapeType, Data: bytes}
+}
+
+func TestSyncCardStatuses(t *testing.T) {
+	todo := kanbanShape(t, models.Frame, map[string]interface{}{"name": "To do", "kanbanStatus": "todo", "x": 0.0, "y": 0.0, "w": 300.0, "h": 600.0})
+	done := kanbanShape(t, models.Frame, map[string]interface{}{"name": "Done", "kanbanStatus": "done", "x": 340.0, "y": 0.0, "w": 300.0, "h": 600.0})
+	plain := kanbanShape(t, models.Frame, map[string]interface{}{"name": "Notes", "x": 700.0, "y": 0.0, "w": 300.0, "h": 600.0})
+
+	moved := kanbanShape(t, models.ActionItemCard, map[string]interface{}{"text": "Ship it", "status": "todo", "x": 370.0, "y": 60.0, "w": 240.0, "h": 110.0})
+	settled := kanbanShape(t, models.ActionItemCard, map[string]interface{}{"text": "Plan", "status": "todo", "x": 30.0, "y": 60.0, "w": 240.0, "h": 110.0})
+	fresh := kanbanShape(t, models.ActionItemCard, map[string]interface{}{"text": "Write docs", "x": 30.0, "y": 200.0, "w": 240.0, "h": 110.0})
+	outside := kanbanShape(t, models.ActionItemCard, map[string]interface{}{"text": "Someday", "status": "open", "x": 730.0, "y": 60.0, "w": 240.0, "h": 110.0})
+	locked := kanbanShape(t, models.ActionItemCard, map[string]interface{}{"text": "Frozen", "status": "todo", "x": 370.0, "y": 200.0, "w": 240.0, "h": 110.0})
+	locked.Locked = true
+
+	changes := SyncCardStatuses([]models.BoardData{todo, done, plain, moved, settled, fresh, outside, locked})
+	if len(changes) != 2 {
+		t.Fatalf("expected the moved and the new card to change, got %+v", changes)
+	}
+	byID := map[uuid.UUID]CardStatusChange{}
+	for _, change := range changes {
+		byID[change.ShapeID] = change
+	}
+	if c := byID[moved.UUID]; c.Status != "done" || c.PreviousStatus != "todo" || c.ColumnID != done.UUID {
+		t.Errorf("expected the card moved to Done to be done, got %+v", c)
+	}
+	if c := byID[fresh.UUID]; c.Status != "todo" || c.PreviousStatus != "open" {
+		t.Errorf("expected a card without status to count as open, got %+v", c)
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal(byID[moved.UUID].Data, &data); err != nil || data["status"] != "done" || data["text"] != "Ship it" {
+		t.Errorf("expected the stored data to keep the card and carry its status, got %v", data)
+	}
+
+	if SyncCardStatuses([]models.BoardData{plain, outside}) != nil {
+		t.Error("expected a board without columns to change nothing")
+	}
+}
+
+func TestCardMovedActivity(t *testing.T) {
+	change := CardStatusChange{ShapeID: uuid.New(), ColumnID: uuid.New(), Title: "Ship it", Status: "done", PreviousStatus: "todo"}
+	user := CardMovedActivity(uuid.New(), uuid.New(), models.ActivityActorUser, change)
+	if user.Summary != `Moved "Ship it" to done` || *user.ShapeID != change.ShapeID || user.Type != models.ActivityCardMoved {
+		t.Errorf("unexpected activity %+v", user)
+	}
+	if agent := CardMovedActivity(uuid.New(), uuid.New(), models.ActivityActorAgent, change); agent.Summary != `Melina moved "Ship it" to done` {
+		t.Errorf("unexpected agent summary %q", agent.Summary)
+	}
+}
+
+func TestMoveIntoFrame(t *testing.T) {
+	inbox := kanbanShape(t, models.Frame, map[string]interface{}{"name": "Inbox", "x": 0.0, "y": 0.0, "w": 300.0, "h": 600.0})
+	done := kanbanShape(t, models.Frame, map[string]interface{}{"name": "Done", "kanbanStatus": "done", "x": 400.0, "y": 0.0, "w": 300.0, "h": 600.0})
+	inside := kanbanShape(t, models.Rect, map[string]interface{}{"x": 30.0, "y": 60.0, "w": 200.0, "h": 100.0})
+	note := kanbanShape(t, models.Rect, map[string]interface{}{"x": 900.0, "y": 900.0, "w": 100.0, "h": 50.0})
+	card := kanbanShape(t, models.ActionItemCard, map[string]interface{}{"text": "Ship it", "status": "todo", "x": 900.0, "y": 100.0, "w": 240.0, "h": 110.0})
+	arrow := kanbanShape(t, models.Arrow, map[string]interface{}{"points": []float64{0, 0, 10, 10}})
+	shapes := []models.BoardData{inbox, done, inside, note, card, arrow}
+
+	move, err := MoveIntoFrame(shapes, note.UUID, "inbox")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal(move.Data, &data); err != nil {
+		t.Fatal(err)
+	}
+	if move.FrameID != inbox.UUID || data["x"] != 30.0 || data["y"] != 180.0 || data["w"] != 100.0 {
+		t.Errorf("expected the note below the shape in Inbox, got %v in %s", data, move.FrameName)
+	}
+
+	move, err = MoveIntoFrame(shapes, card.UUID, done.UUID.String())
+	if err != nil {
+		t.Fatal(err)
+	}
+	data = nil
+	if err := json.Unmarshal(move.Data, &data); err != nil || data["status"] != "done" || data["x"] != 430.0 || data["y"] != 60.0 {
+		t.Errorf("expected the card at the top of Done with its status, got %v", data)
+	}
+
+	if move, err := MoveIntoFrame(shapes, inside.UUID, "Inbox"); err != nil || move.Data != nil {
+		t.Errorf("expected a shape already in the frame to stay, got %+v, %v", move, err)
+	}
+	if _, err := MoveIntoFrame(shapes, arrow.UUID, "Inbox"); !errors.Is(err, ErrShapeNotMovable) {
+		t.Errorf("expected arrows not to move, got %v", err)
+	}
+	if _, err := MoveIntoFrame(shapes, note.UUID, "Backlog"); !errors.Is(err, ErrFrameNotFound) {
+		t.Errorf("expected an unknown frame to fail, got %v", err)
+	}
+	if _, err := MoveIntoFrame(shapes, uuid.New(), "Inbox"); !errors.Is(err, ErrShapeNotOnBoard) {
+		t.Errorf("expected an unknown shape to fail, got %v", err)
+	}
+}
@@ -0,0 +1,170 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	llmHandlers "melina-studio-backend/internal/llm_handlers"
+	"melina-studio-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// PlacementGap is the space kept between an automatically placed shape and the shapes around it
+	PlacementGap = 50.0
+	// placementOrigin is where the first shape of an empty board goes
+	placementOrigin = 100.0
+)
+
+// PlacementSpot is the top-left corner of the free area found for a new shape
+type PlacementSpot struct {
+	X float64 `json:"x"`
+	Y float64 `json:"y"`
+}
+
+// FindFreeSpot finds the top-left corner of a free width x height area, as close as possible to the
+// anchor (right of it first, then below, left and above), that keeps PlacementGap from every occupied box. With no anchor the whole content is the
+// anchor, so the spot is next to the existing content. Candidates are taken right of, below, left
+// of and above the anchor and every occupied box; below everything is always free
+func FindFreeSpot(occupied []BoundingBox, width, height float64, anchor *BoundingBox) PlacementSpot {
+	if len(occupied) == 0 {
+		if anchor != nil {
+			return PlacementSpot{X: anchor.MaxX + PlacementGap, Y: anchor.MinY}
+		}
+		return PlacementSpot{X: placementOrigin, Y: placementOrigin}
+	}
+
+	content := occupied[0]
+	for _, b := range occupied[1:] {
+		content = mergeBounds(content, b)
+	}
+	target := content
+	if anchor != nil {
+		target = *anchor
+	}
+
+	around := func(b BoundingBox) []PlacementSpot {
+		return []PlacementSpot{
+			{X: b.MaxX + PlacementGap, Y: b.MinY},
+			{X: b.MinX, Y: b.MaxY + PlacementGap},
+			{X: b.MinX - PlacementGap - width, Y: b.MinY},
+			{X: b.MinX, Y: b.MinY - PlacementGap - height},
+		}
+	}
+	candidates := around(target)
+	for _, b := range occupied {
+		candidates = append(candidates, around(b)...)
+	}
+
+	free := func(spot PlacementSpot) bool {
+		for _, b := range occupied {
+			if spot.X < b.MaxX+PlacementGap && spot.X+width > b.MinX-PlacementGap &&
+				spot.Y < b.MaxY+PlacementGap && spot.Y+height > b.MinY-PlacementGap {
+				return false
+			}
+		}
+		return true
+	}
+
+	best := PlacementSpot{X: content.MinX, Y: content.MaxY + PlacementGap}
+	bestDistance := math.Inf(1)
+	for _, spot := range candidates {
+		if !free(spot) {
+			continue
+		}
+		// edge to edge, so a wide anchor doesn't favor the spots below it; ties keep the candidate order
+		dx := math.Max(0, math.Max(target.MinX-(spot.X+width), spot.X-target.MaxX))
+		dy := math.Max(0, math.Max(target.MinY-(spot.Y+height), spot.Y-target.MaxY))
+		distance := math.Hypot(dx, dy)
+		if distance < bestDistance {
+			best, bestDistance = spot, distance
+		}
+	}
+	return best
+}
+
+// OccupiedBounds returns the bounds of the shapes, skipping shapes whose size can't be computed
+func OccupiedBounds(shapes []models.BoardData) []BoundingBox {
+	bounds := make([]BoundingBox, 0, len(shapes))
+	for _, shape := range shapes {
+		if b, _, err := GetShapeBounds(shape, 0); err == nil {
+			bounds = append(bounds, b)
+		}
+	}
+	return bounds
+}
+
+// AnchorBounds merges the bounds of the shapes with the given ids; nil when none of them is found
+func AnchorBounds(shapes []models.BoardData, ids []string) *BoundingBox {
+	wanted := make(map[string]bool, len(ids))
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	var anchor *BoundingBox
+	for _, shape := range shapes {
+		if !wanted[shape.UUID.String()] {
+			continue
+		}
+		b, _, err := GetShapeBounds(shape, 0)
+		if err != nil {
+			continue
+		}
+		if anchor == nil {
+			anchor = &b
+		} else {
+			merged := mergeBounds(*anchor, b)
+			anchor = &merged
+		}
+	}
+	return anchor
+}
+
+// placeShape moves a new shape, given as the map addShape builds, to a free spot near the anchor
+// It returns the shape's new x and y, which are its position rather than its top-left corner for
+// shapes like circles that are drawn around x and y
+func placeShape(shape map[string]interface{}, shapes []models.BoardData, anchor *BoundingBox) (float64, float64, error) {
+	shapeType, _ := shape["type"].(string)
+	shape["x"], shape["y"] = 0.0, 0.0
+	data, err := json.Marshal(shape)
+	if err != nil {
+		return 0, 0, err
+	}
+	bounds, _, err := GetShapeBounds(models.BoardData{Type: models.Type(shapeType), Data: data}, 0)
+	if err != nil {
+		return 0, 0, err
+	}
+
+	spot := FindFreeSpot(OccupiedBounds(shapes), bounds.MaxX-bounds.MinX, bounds.MaxY-bounds.MinY, anchor)
+	// bounds of a shape at 0,0 are its offset from x and y
+	x, y := spot.X-bounds.MinX, spot.Y-bounds.MinY
+	shape["x"], shape["y"] = x, y
+	return x, y, nil
+}
+
+// autoPlaceShape places a shape addShape built with position "auto" next to the related shapes
+// The board's shapes include the ones created earlier in the turn, which the client hasn't saved yet
+func (d *ToolDeps) autoPlaceShape(ctx context.Context, boardIdStr string, shape map[string]interface{}, nearIds []string) (float64, float64, error) {
+	boardId, err := uuid.Parse(boardIdStr)
+	if err != nil {
+		return 0, 0, llmHandlers.InvalidToolInput("invalid boardId: %v", err)
+	}
+	stored, err := d.BoardData.GetBoardData(boardId)
+	if err != nil {
+		return 0, 0, fmt.Errorf("failed to get shapes from database: %w", err)
+	}
+	shapes := stored
+	if activity, ok := llmHandlers.ToolActivityFrom(ctx); ok {
+		shapes = SnapshotShapes(stored, activity.CreatedShapes())
+	}
+
+	var anchor *BoundingBox
+	if len(nearIds) > 0 {
+		if anchor = AnchorBounds(shapes, nearIds); anchor == nil {
+			return 0, 0, llmHandlers.InvalidToolInput("none of nearShapeIds was found on the board")
+		}
+	}
+	return placeShape(shape, shapes, anchor)
+}
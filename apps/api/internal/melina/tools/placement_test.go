@@ -0,0 +1,46 @@
+package tools
+
+import (
+	"math"
+	"testing"
+)
+
+func overlapsAny(spot PlacementSpot, width, height float64, occupied []BoundingBox) bool {
+	for _, b := range occupied {
+		if spot.X < b.MaxX && spot.X+width > b.MinX && spot.Y < b.MaxY && spot.Y+height > b.MinY {
+			return true
+		}
+	}
+	return false
+}
+
+func TestFindFreeSpot(t *testing.T) {
+	if spot := FindFreeSpot(nil, 100, 100, nil); spot.X != placementOrigin || spot.Y != placementOrigin {
+		t.Errorf("expected the origin on an empty board, got %+v", spot)
+	}
+
+	occupied := []BoundingBox{
+		{MinX: 0, MinY: 0, MaxX: 200, MaxY: 100},
+		{MinX: 240, MinY: 0, MaxX: 440, MaxY: 100},
+		{MinX: 0, MinY: 500, MaxX: 200, MaxY: 600},
+	}
+	anchor := occupied[2]
+	spot := FindFreeSpot(occupied, 150, 80, &anchor)
+	if overlapsAny(spot, 150, 80, occupied) {
+		t.Errorf("spot %+v overlaps the content", spot)
+	}
+	// right of the anchor is free, so the shape goes there rather than next to the other boxes
+	if spot.X != anchor.MaxX+PlacementGap || spot.Y != anchor.MinY {
+		t.Errorf("expected the spot right of the anchor, got %+v", spot)
+	}
+
+	// the gap right of the first box is too narrow, so the shape can't squeeze in between
+	first := occupied[0]
+	spot = FindFreeSpot(occupied, 150, 80, &first)
+	if overlapsAny(spot, 150, 80, occupied) {
+		t.Errorf("spot %+v overlaps the content", spot)
+	}
+	if distance := math.Hypot(spot.X-first.MinX, spot.Y-first.MinY); distance > 300 {
+		t.Errorf("expected a spot near the first box, got %+v", spot)
+	}
+}
@@ -0,0 +1,78 @@
+package tools
+
+import (
+	"sort"
+	"sync"
+
+	llmHandlers "melina-studio-backend/internal/llm_handlers"
+)
+
+// PluginTool is a tool served by a plugin process
+type PluginTool struct {
+	Name             string
+	Description      string
+	Parameters       map[string]interface{}
+	RequiresApproval bool
+	Handler          llmHandlers.ToolHandler
+}
+
+// the plugin tools offered to the agent, by plugin name
+var (
+	pluginToolsMu sync.RWMutex
+	pluginTools   = make(map[string][]PluginTool)
+)
+
+// SetPluginTools registers the tools of a plugin, replacing the ones it registered before
+func SetPluginTools(plugin string, pluginToolList []PluginTool) {
+	pluginToolsMu.Lock()
+	defer pluginToolsMu.Unlock()
+
+	current := make(map[string]bool, len(pluginToolList))
+	for _, tool := range pluginToolList {
+		current[tool.Name] = true
+		llmHandlers.RegisterTool(tool.Name, tool.Handler)
+		llmHandlers.RegisterToolSchema(tool.Name, tool.Parameters)
+		llmHandlers.SetToolApprovalRequired(tool.Name, tool.RequiresApproval)
+	}
+	for _, old := range pluginTools[plugin] {
+		if !current[old.Name] {
+			llmHandlers.UnregisterTool(old.Name)
+			llmHandlers.UnregisterToolSchema(old.Name)
+			llmHandlers.SetToolApprovalRequired(old.Name, false)
+		}
+	}
+	if len(pluginToolList) == 0 {
+		delete(pluginTools, plugin)
+		return
+	}
+	pluginTools[plugin] = pluginToolList
+}
+
+// pluginToolSchemas returns the plugin tools, after the built-in ones and ordered by plugin
+func pluginToolSchemas() []toolSchema {
+	pluginToolsMu.RLock()
+	defer pluginToolsMu.RUnlock()
+	plugins := make([]string, 0, len(pluginTools))
+	for plugin := range pluginTools {
+		plugins = append(plugins, plugin)
+	}
+	sort.Strings(plugins)
+
+	var schemas []toolSchema
+	for _, plugin := range plugins {
+		for _, tool := range pluginTools[plugin] {
+			schemas = append(schemas, toolSchema{Name: tool.Name, Description: tool.Description, Parameters: tool.Parameters})
+		}
+	}
+	return schemas
+}
+
+// ToolExists reports whether the agent already has a tool of the name: built-in, from a plugin or custom
+func ToolExists(name string) bool {
+	for _, tool := range GetAnthropicTools() {
+		if tool["name"] == name {
+			return true
+		}
+	}
+	return false
+}
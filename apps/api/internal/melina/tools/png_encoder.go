@@ -0,0 +1,185 @@
+package tools
+
+import (
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"hash/adler32"
+	"hash/crc32"
+	"image"
+	"runtime"
+	"sync"
+)
+
+// encodePNG encodes an RGBA image as a PNG, deflating horizontal strips of rows in parallel.
+// image/png filters and compresses on one core, which dominates annotating a large board.
+// Rows use the Up filter, which suits boards' large flat areas; each strip is deflated on its own and
+// ends on a sync flush, so the strips concatenate into one zlib stream whose checksum is combined at the end
+func encodePNG(img *image.RGBA) ([]byte, error) {
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+	opaque := img.Opaque()
+	bpp := 4
+	colorType := byte(6) // truecolor with alpha
+	if opaque {
+		bpp = 3
+		colorType = 2 // truecolor
+	}
+	rowLen := 1 + width*bpp
+
+	strips := runtime.GOMAXPROCS(0)
+	if strips > 8 {
+		strips = 8
+	}
+	// small images aren't worth the goroutines
+	if maxStrips := height / 64; strips > maxStrips {
+		strips = maxStrips
+	}
+	if strips < 1 {
+		strips = 1
+	}
+
+	type stripResult struct {
+		compressed bytes.Buffer
+		adler      uint32
+		length     int
+		err        error
+	}
+	results := make([]stripResult, strips)
+	rowsPerStrip := (height + strips - 1) / strips
+
+	var wg sync.WaitGroup
+	for s := 0; s < strips; s++ {
+		start := s * rowsPerStrip
+		end := min(start+rowsPerStrip, height)
+		if start >= end {
+			continue
+		}
+		wg.Add(1)
+		go func(s, start, end int) {
+			defer wg.Done()
+			res := &results[s]
+
+			filtered := make([]byte, (end-start)*rowLen)
+			prev := make([]byte, width*bpp)
+			cur := make([]byte, width*bpp)
+			if start > 0 {
+				pngRow(img, start-1, opaque, prev)
+			}
+			for y := start; y < end; y++ {
+				pngRow(img, y, opaque, cur)
+				row := filtered[(y-start)*rowLen : (y-start+1)*rowLen]
+				row[0] = 2 // Up
+				for i := range cur {
+					row[i+1] = cur[i] - prev[i]
+				}
+				prev, cur = cur, prev
+			}
+
+			res.adler = adler32.Checksum(filtered)
+			res.length = len(filtered)
+			w, err := flate.NewWriter(&res.compressed, flate.BestSpeed)
+			if err != nil {
+				res.err = err
+				return
+			}
+			if _, err := w.Write(filtered); err != nil {
+				res.err = err
+				return
+			}
+			if end == height {
+				res.err = w.Close()
+			} else {
+				res.err = w.Flush()
+			}
+		}(s, start, end)
+	}
+	wg.Wait()
+
+	// zlib header for the fastest compression level, then the strips and the combined checksum
+	var idat bytes.Buffer
+	idat.Write([]byte{0x78, 0x01})
+	checksum := uint32(1)
+	for i := range results {
+		if results[i].err != nil {
+			return nil, results[i].err
+		}
+		if results[i].length == 0 {
+			continue
+		}
+		idat.Write(results[i].compressed.Bytes())
+		checksum = adler32Combine(checksum, results[i].adler, results[i].length)
+	}
+	binary.Write(&idat, binary.BigEndian, checksum)
+
+	var out bytes.Buffer
+	out.WriteString("\x89PNG\r\n\x1a\n")
+	ihdr := make([]byte, 13)
+	binary.BigEndian.PutUint32(ihdr[0:4], uint32(width))
+	binary.BigEndian.PutUint32(ihdr[4:8], uint32(height))
+	ihdr[8] = 8 // bit depth
+	ihdr[9] = colorType
+	writePNGChunk(&out, "IHDR", ihdr)
+	writePNGChunk(&out, "IDAT", idat.Bytes())
+	writePNGChunk(&out, "IEND", nil)
+	return out.Bytes(), nil
+}
+
+// pngRow writes row y as PNG samples: RGB for opaque images, otherwise non-premultiplied RGBA like image/png
+func pngRow(img *image.RGBA, y int, opaque bool, dst []byte) {
+	src := img.Pix[y*img.Stride : y*img.Stride+img.Rect.Dx()*4]
+	if opaque {
+		for i, j := 0, 0; i < len(src); i, j = i+4, j+3 {
+			dst[j], dst[j+1], dst[j+2] = src[i], src[i+1], src[i+2]
+		}
+		return
+	}
+	for i := 0; i < len(src); i += 4 {
+		a := uint32(src[i+3])
+		switch a {
+		case 0xff:
+			copy(dst[i:i+4], src[i:i+4])
+		case 0:
+			dst[i], dst[i+1], dst[i+2], dst[i+3] = 0, 0, 0, 0
+		default:
+			// same rounding as color.NRGBAModel
+			a16 := a * 0x101
+			dst[i] = uint8((uint32(src[i]) * 0x101 * 0xffff / a16) >> 8)
+			dst[i+1] = uint8((uint32(src[i+1]) * 0x101 * 0xffff / a16) >> 8)
+			dst[i+2] = uint8((uint32(src[i+2]) * 0x101 * 0xffff / a16) >> 8)
+			dst[i+3] = uint8(a)
+		}
+	}
+}
+
+func writePNGChunk(out *bytes.Buffer, chunkType string, data []byte) {
+	binary.Write(out, binary.BigEndian, uint32(len(data)))
+	crc := crc32.NewIEEE()
+	crc.Write([]byte(chunkType))
+	crc.Write(data)
+	out.WriteString(chunkType)
+	out.Write(data)
+	binary.Write(out, binary.BigEndian, crc.Sum32())
+}
+
+// adler32Combine returns the Adler-32 of two concatenated inputs from their checksums (zlib's adler32_combine)
+func adler32Combine(adler1, adler2 uint32, len2 int) uint32 {
+	const base = 65521
+	rem := uint32(len2 % base)
+	sum1 := adler1 & 0xffff
+	sum2 := (rem * sum1) % base
+	sum1 += (adler2 & 0xffff) + base - 1
+	sum2 += (adler1 >> 16) + (adler2 >> 16) + base - rem
+	if sum1 >= base {
+		sum1 -= base
+	}
+	if sum1 >= base {
+		sum1 -= base
+	}
+	if sum2 >= base<<1 {
+		sum2 -= base << 1
+	}
+	if sum2 >= base {
+		sum2 -= base
+	}
+	return sum1 | sum2<<16
+}
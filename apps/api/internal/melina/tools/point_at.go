@@ -0,0 +1,119 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+
+	"melina-studio-backend/internal/libraries"
+	llmHandlers "melina-studio-backend/internal/llm_handlers"
+
+	"github.com/google/uuid"
+)
+
+var pointAtSchema = toolSchema{
+	Name:        "pointAt",
+	Description: "Briefly highlights shapes on the user's screen, or points a laser pointer at them, while you talk about them. Nothing is saved and the highlight fades on its own. Use it when explaining or reviewing a diagram (\"this step\", \"these two services\"); use focusOn instead when the shapes may be off screen.",
+	Parameters: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"boardId": map[string]interface{}{
+				"type":        "string",
+				"description": "The UUID of the board",
+			},
+			"shapeIds": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "The shapes to point at, including shapes created earlier in this turn",
+			},
+			"style": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"highlight", "pointer"},
+				"description": "highlight outlines the shapes (default); pointer shows a laser dot at their center",
+			},
+			"label": map[string]interface{}{
+				"type":        "string",
+				"description": "Optional short caption shown next to the highlight",
+			},
+			"durationSeconds": map[string]interface{}{
+				"type":        "number",
+				"description": "How long it stays visible, 1 to 30 seconds (default 5)",
+			},
+		},
+		"required": []string{"boardId", "shapeIds"},
+	},
+}
+
+// PointAtHandler is the handler for the pointAt tool
+func (d *ToolDeps) PointAtHandler(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+	boardIdStr, ok := input["boardId"].(string)
+	if !ok {
+		return nil, llmHandlers.InvalidToolInput("boardId is required and must be a string")
+	}
+	boardId, err := uuid.Parse(boardIdStr)
+	if err != nil {
+		return nil, llmHandlers.InvalidToolInput("invalid boardId: %v", err)
+	}
+	shapeIds := stringSlice(input["shapeIds"])
+	if len(shapeIds) == 0 {
+		return nil, llmHandlers.InvalidToolInput("shapeIds is required and must list at least one shape")
+	}
+	kind := libraries.AnnotationHighlight
+	if style, _ := input["style"].(string); style != "" {
+		kind = libraries.AnnotationKind(style)
+		if kind != libraries.AnnotationHighlight && kind != libraries.AnnotationPointer {
+			return nil, llmHandlers.InvalidToolInput("style must be highlight or pointer")
+		}
+	}
+	seconds := 5.0
+	if v, ok := input["durationSeconds"].(float64); ok {
+		seconds = min(max(v, 1), 30)
+	}
+
+	streamCtx, err := getStreamingContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := d.BoardData.GetBoardData(boardId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shapes from database: %w", err)
+	}
+	shapes := stored
+	if activity, ok := llmHandlers.ToolActivityFrom(ctx); ok {
+		shapes = SnapshotShapes(stored, activity.CreatedShapes())
+	}
+	bounds := AnchorBounds(shapes, shapeIds)
+	if bounds == nil {
+		return nil, llmHandlers.InvalidToolInput("none of shapeIds was found on the board")
+	}
+
+	label, _ := input["label"].(string)
+	annotation := &libraries.AnnotationPayload{
+		BoardId:  boardIdStr,
+		Kind:     kind,
+		ShapeIds: shapeIds,
+		Bounds: &libraries.SelectionBounds{
+			MinX:   bounds.MinX,
+			MinY:   bounds.MinY,
+			Width:  bounds.MaxX - bounds.MinX,
+			Height: bounds.MaxY - bounds.MinY,
+		},
+		Label: label,
+		TTLMs: int(seconds * 1000),
+	}
+	if kind == libraries.AnnotationPointer {
+		x, y := (bounds.MinX+bounds.MaxX)/2, (bounds.MinY+bounds.MaxY)/2
+		annotation.X, annotation.Y = &x, &y
+	}
+	if streamCtx.Hub != nil {
+		if err := libraries.SendAgentAnnotation(streamCtx.Hub, streamCtx.UserID, annotation); err != nil {
+			return nil, fmt.Errorf("failed to send annotation: %w", err)
+		}
+	}
+
+	return map[string]interface{}{
+		"success": true,
+		"boardId": boardIdStr,
+		"message": fmt.Sprintf("Showing a %s on %d shape(s) for %.0f seconds", kind, len(shapeIds), seconds),
+	}, nil
+}
@@ -0,0 +1,285 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"melina-studio-backend/internal/libraries"
+	llmHandlers "melina-studio-backend/internal/llm_handlers"
+	"melina-studio-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+var buildPresentationSchema = toolSchema{
+	Name:        "buildPresentation",
+	Description: "Turns the board's frames into a presentation: an ordered sequence of slides the user can present, one frame per slide. Without frameIds the frames are ordered logically: by the numbers their names start with, else by the arrows between them, else in reading order (rows top to bottom, left to right). Pass frameIds to set the order yourself, e.g. intro first and summary last; frames left out are not part of the presentation. Only frames saved on the board can be used, not ones created in this turn.",
+	Parameters: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"boardId": map[string]interface{}{
+				"type":        "string",
+				"description": "The UUID of the board",
+			},
+			"frameIds": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Optional frame ids in the order to present them",
+			},
+		},
+		"required": []string{"boardId"},
+	},
+}
+
+// slideFrame is a frame considered for the presentation
+type slideFrame struct {
+	shape  models.BoardData
+	name   string
+	bounds BoundingBox
+}
+
+var leadingNumber = regexp.MustCompile(`^\s*(?:step|slide|part)?\s*(\d+)`)
+
+// boardFrames returns the frames of the board with their names and bounds
+func boardFrames(shapes []models.BoardData) []slideFrame {
+	var frames []slideFrame
+	for _, shape := range shapes {
+		if shape.Type != models.Frame {
+			continue
+		}
+		bounds, data, err := GetShapeBounds(shape, 0)
+		if err != nil {
+			continue
+		}
+		name, _ := data["name"].(string)
+		frames = append(frames, slideFrame{shape: shape, name: strings.TrimSpace(name), bounds: bounds})
+	}
+	return frames
+}
+
+// sortFramesByReadingOrder sorts frames in rows, top to bottom, then left to right within a row
+// A frame starts a new row once it begins below the middle of the row's first frame
+func sortFramesByReadingOrder(frames []slideFrame) {
+	sort.SliceStable(frames, func(i, j int) bool { return frames[i].bounds.MinY < frames[j].bounds.MinY })
+	rowStart := 0
+	for i := 1; i <= len(frames); i++ {
+		if i < len(frames) {
+			first := frames[rowStart].bounds
+			if frames[i].bounds.MinY < (first.MinY+first.MaxY)/2 {
+				continue
+			}
+		}
+		row := frames[rowStart:i]
+		sort.SliceStable(row, func(a, b int) bool { return row[a].bounds.MinX < row[b].bounds.MinX })
+		rowStart = i
+	}
+}
+
+// OrderFrames puts the board's frames in a logical presentation order: by the numbers their names
+// start with when every frame has one, else following the arrows between frames, else in reading order
+func OrderFrames(shapes []models.BoardData) []uuid.UUID {
+	frames := boardFrames(shapes)
+	sortFramesByReadingOrder(frames)
+
+	ids := func() []uuid.UUID {
+		ordered := make([]uuid.UUID, len(frames))
+		for i, frame := range frames {
+			ordered[i] = frame.shape.UUID
+		}
+		return ordered
+	}
+
+	numbers := make(map[uuid.UUID]int, len(frames))
+	for _, frame := range frames {
+		match := leadingNumber.FindStringSubmatch(strings.ToLower(frame.name))
+		if match == nil {
+			break
+		}
+		numbers[frame.shape.UUID], _ = strconv.Atoi(match[1])
+	}
+	if len(frames) > 0 && len(numbers) == len(frames) {
+		sort.SliceStable(frames, func(i, j int) bool { return numbers[frames[i].shape.UUID] < numbers[frames[j].shape.UUID] })
+		return ids()
+	}
+
+	// arrows whose ends are in different frames give the flow between them
+	inFrame := func(x, y float64) int {
+		best := -1
+		for i, frame := range frames {
+			if containsBounds(frame.bounds, x, y) && (best < 0 || boundsArea(frame.bounds) < boundsArea(frames[best].bounds)) {
+				best = i
+			}
+		}
+		return best
+	}
+	next := make([][]int, len(frames))
+	incoming := make([]int, len(frames))
+	flows := 0
+	for _, shape := range shapes {
+		if shape.Type != models.Arrow {
+			continue
+		}
+		data, err := shapeDataMap(shape.UUID, string(shape.Type), shape.Data)
+		if err != nil {
+			continue
+		}
+		start, end, ok := connectorEnds(data)
+		if !ok {
+			continue
+		}
+		from, to := inFrame(start[0], start[1]), inFrame(end[0], end[1])
+		if from < 0 || to < 0 || from == to {
+			continue
+		}
+		next[from] = append(next[from], to)
+		incoming[to]++
+		flows++
+	}
+	if flows == 0 {
+		return ids()
+	}
+
+	// follow the arrows, taking the first ready frame in reading order; a cycle is broken at its first frame
+	done := make([]bool, len(frames))
+	order := make([]slideFrame, 0, len(frames))
+	for len(order) < len(frames) {
+		pick := -1
+		for i := range frames {
+			if !done[i] && incoming[i] == 0 {
+				pick = i
+				break
+			}
+		}
+		if pick < 0 {
+			for i := range frames {
+				if !done[i] {
+					pick = i
+					break
+				}
+			}
+		}
+		done[pick] = true
+		order = append(order, frames[pick])
+		for _, to := range next[pick] {
+			incoming[to]--
+		}
+	}
+	frames = order
+	return ids()
+}
+
+// BuildPresentation lists the board's slides in order, with the viewport that shows each one
+func BuildPresentation(boardId uuid.UUID, shapes []models.BoardData) models.Presentation {
+	presentation := models.Presentation{BoardID: boardId, Slides: []models.Slide{}, OtherFrames: []uuid.UUID{}}
+	frames := boardFrames(shapes)
+	sortFramesByReadingOrder(frames)
+	var slides []slideFrame
+	for _, frame := range frames {
+		if frame.shape.SlideOrder == nil {
+			presentation.OtherFrames = append(presentation.OtherFrames, frame.shape.UUID)
+			continue
+		}
+		slides = append(slides, frame)
+	}
+	sort.SliceStable(slides, func(i, j int) bool { return *slides[i].shape.SlideOrder < *slides[j].shape.SlideOrder })
+	for i, frame := range slides {
+		name := frame.name
+		if name == "" {
+			name = fmt.Sprintf("Slide %d", i+1)
+		}
+		presentation.Slides = append(presentation.Slides, models.Slide{
+			Index:    i,
+			FrameID:  frame.shape.UUID,
+			Name:     name,
+			Bounds:   BoundsRect(frame.bounds),
+			Viewport: FitViewport(frame.bounds, FocusCanvasWidth, FocusCanvasHeight),
+		})
+	}
+	return presentation
+}
+
+// BuildPresentationHandler is the handler for the buildPresentation tool
+func (d *ToolDeps) BuildPresentationHandler(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+	boardIdStr, ok := input["boardId"].(string)
+	if !ok {
+		return nil, llmHandlers.InvalidToolInput("boardId is required and must be a string")
+	}
+	boardId, err := uuid.Parse(boardIdStr)
+	if err != nil {
+		return nil, llmHandlers.InvalidToolInput("invalid boardId: %v", err)
+	}
+
+	streamCtx, err := getStreamingContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	shapes, err := d.BoardData.GetBoardData(boardId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shapes from database: %w", err)
+	}
+	frames := make(map[string]bool)
+	for _, frame := range boardFrames(shapes) {
+		frames[frame.shape.UUID.String()] = true
+	}
+	if len(frames) == 0 {
+		return map[string]interface{}{
+			"success": false,
+			"boardId": boardIdStr,
+			"message": "The board has no saved frames. Create frames first; frames created in this turn can be ordered in the next one.",
+		}, nil
+	}
+
+	var order []uuid.UUID
+	if requested := stringSlice(input["frameIds"]); len(requested) > 0 {
+		seen := make(map[string]bool, len(requested))
+		for _, id := range requested {
+			if !frames[id] {
+				return nil, llmHandlers.InvalidToolInput("%s is not a saved frame of this board", id)
+			}
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			order = append(order, uuid.MustParse(id))
+		}
+	} else {
+		order = OrderFrames(shapes)
+	}
+
+	if err := d.BoardData.SetSlideOrder(boardId, order); err != nil {
+		return nil, fmt.Errorf("failed to save the presentation: %w", err)
+	}
+	for i := range shapes {
+		shapes[i].SlideOrder = nil
+		for position, id := range order {
+			if shapes[i].UUID == id {
+				shapes[i].SlideOrder = &position
+			}
+		}
+	}
+	presentation := BuildPresentation(boardId, shapes)
+
+	if streamCtx.Hub != nil {
+		libraries.SendPresentationUpdatedMessage(streamCtx.Hub, streamCtx.UserID, &libraries.PresentationUpdatedPayload{
+			BoardId:  boardIdStr,
+			FrameIds: order,
+		})
+	}
+
+	slides := make([]map[string]interface{}, len(presentation.Slides))
+	for i, slide := range presentation.Slides {
+		slides[i] = map[string]interface{}{"slide": i + 1, "frameId": slide.FrameID.String(), "name": slide.Name}
+	}
+	return map[string]interface{}{
+		"success":     true,
+		"boardId":     boardIdStr,
+		"slides":      slides,
+		"otherFrames": len(presentation.OtherFrames),
+		"message":     fmt.Sprintf("Presentation has %d slides", len(slides)),
+	}, nil
+}
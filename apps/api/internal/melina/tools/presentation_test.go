@@ -0,0 +1,74 @@
+package tools
+
+import (
+	"testing"
+
+	"melina-studio-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func TestOrderFrames(t *testing.T) {
+	frame := func(name string, x, y float64) models.BoardData {
+		return boardShape(t, models.Frame, map[string]interface{}{"name": name, "x": x, "y": y, "w": 400.0, "h": 300.0})
+	}
+	names := func(shapes []models.BoardData, order []uuid.UUID) []string {
+		byID := map[uuid.UUID]string{}
+		for _, frame := range boardFrames(shapes) {
+			byID[frame.shape.UUID] = frame.name
+		}
+		result := make([]string, len(order))
+		for i, id := range order {
+			result[i] = byID[id]
+		}
+		return result
+	}
+	assertOrder := func(label string, got []string, want ...string) {
+		t.Helper()
+		if len(got) != len(want) {
+			t.Fatalf("%s: got %v, want %v", label, got, want)
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Fatalf("%s: got %v, want %v", label, got, want)
+			}
+		}
+	}
+
+	// a row that isn't perfectly aligned is still read left to right
+	reading := []models.BoardData{frame("C", 0, 500), frame("B", 500, 20), frame("A", 0, 0)}
+	assertOrder("reading order", names(reading, OrderFrames(reading)), "A", "B", "C")
+
+	numbered := []models.BoardData{frame("2. Problem", 0, 0), frame("Step 10 wrap up", 500, 0), frame("1 Intro", 1000, 0)}
+	assertOrder("numbered", names(numbered, OrderFrames(numbered)), "1 Intro", "2. Problem", "Step 10 wrap up")
+
+	flow := []models.BoardData{frame("Login", 0, 0), frame("Signup", 500, 0), frame("Home", 1000, 0),
+		boardShape(t, models.Arrow, map[string]interface{}{
+			"start": map[string]interface{}{"x": 700.0, "y": 150.0}, "end": map[string]interface{}{"x": 200.0, "y": 150.0},
+		}),
+		boardShape(t, models.Arrow, map[string]interface{}{
+			"start": map[string]interface{}{"x": 300.0, "y": 150.0}, "end": map[string]interface{}{"x": 1200.0, "y": 150.0},
+		}),
+	}
+	assertOrder("arrows", names(flow, OrderFrames(flow)), "Signup", "Login", "Home")
+}
+
+func TestBuildPresentation(t *testing.T) {
+	first, second := 1, 0
+	intro := boardShape(t, models.Frame, map[string]interface{}{"name": "Intro", "x": 0.0, "y": 0.0, "w": 400.0, "h": 300.0})
+	intro.SlideOrder = &second
+	outro := boardShape(t, models.Frame, map[string]interface{}{"x": 500.0, "y": 0.0, "w": 400.0, "h": 300.0})
+	outro.SlideOrder = &first
+	notes := boardShape(t, models.Frame, map[string]interface{}{"name": "Notes", "x": 0.0, "y": 500.0, "w": 400.0, "h": 300.0})
+
+	presentation := BuildPresentation(uuid.New(), []models.BoardData{outro, notes, intro})
+	if len(presentation.Slides) != 2 || presentation.Slides[0].FrameID != intro.UUID || presentation.Slides[1].Name != "Slide 2" {
+		t.Fatalf("unexpected slides %+v", presentation.Slides)
+	}
+	if presentation.Slides[0].Viewport.X != 200 || presentation.Slides[0].Viewport.Y != 150 {
+		t.Errorf("expected the viewport to center on the frame, got %+v", presentation.Slides[0].Viewport)
+	}
+	if len(presentation.OtherFrames) != 1 || presentation.OtherFrames[0] != notes.UUID {
+		t.Errorf("expected the frame without a slide order to be left out, got %v", presentation.OtherFrames)
+	}
+}
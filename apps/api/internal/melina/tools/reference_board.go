@@ -0,0 +1,135 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"melina-studio-backend/internal/models"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var referenceBoardSchema = toolSchema{
+	Name:        "referenceBoard",
+	Description: "Reads another of the user's boards (read-only) so you can keep the current board consistent with it, e.g. 'match the architecture board'. Find the board by its UUID or by (part of) its title. Returns the board's outline: frames and the text inside them. Never modify the referenced board; create shapes on the current board only.",
+	Parameters: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"referencedBoardId": map[string]interface{}{
+				"type":        "string",
+				"description": "The UUID of the board to read (optional if title is given)",
+			},
+			"title": map[string]interface{}{
+				"type":        "string",
+				"description": "Title or part of the title of the board to read (optional if referencedBoardId is given)",
+			},
+		},
+	},
+}
+
+// ReferenceBoardHandler returns the outline of another board owned by the same user
+func (d *ToolDeps) ReferenceBoardHandler(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+	streamCtx, err := getStreamingContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	userId, err := uuid.Parse(streamCtx.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("user not available for permission check")
+	}
+
+	var board models.Board
+
+	if idStr, ok := input["referencedBoardId"].(string); ok && idStr != "" {
+		boardId, err := uuid.Parse(idStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid referencedBoardId format: %w", err)
+		}
+		board, err = d.Boards.GetBoardById(userId, boardId)
+		if err != nil || board.IsDeleted {
+			return nil, fmt.Errorf("board not found or you don't have access to it")
+		}
+	} else {
+		title, _ := input["title"].(string)
+		title = strings.TrimSpace(title)
+		if title == "" {
+			return nil, fmt.Errorf("referencedBoardId or title is required")
+		}
+		boards, err := d.Boards.GetAllBoards(userId)
+		if err != nil {
+			return nil, fmt.Errorf("failed to list boards: %w", err)
+		}
+		matches := matchBoardsByTitle(boards, title, streamCtx.BoardId)
+		switch len(matches) {
+		case 0:
+			titles := make([]string, 0, len(boards))
+			for _, b := range boards {
+				if b.UUID.String() != streamCtx.BoardId {
+					titles = append(titles, b.Title)
+				}
+			}
+			return map[string]interface{}{
+				"success":         false,
+				"message":         fmt.Sprintf("No board matches %q. Ask the user which board they mean.", title),
+				"availableBoards": titles,
+			}, nil
+		case 1:
+			board = matches[0]
+		default:
+			candidates := make([]map[string]interface{}, 0, len(matches))
+			for _, b := range matches {
+				candidates = append(candidates, map[string]interface{}{"referencedBoardId": b.UUID.String(), "title": b.Title})
+			}
+			return map[string]interface{}{
+				"success":    false,
+				"message":    fmt.Sprintf("Several boards match %q. Pick one by referencedBoardId or ask the user.", title),
+				"candidates": candidates,
+			}, nil
+		}
+	}
+
+	shapes, err := d.BoardData.GetBoardData(board.UUID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shapes from database: %w", err)
+	}
+
+	return map[string]interface{}{
+		"success": true,
+		"title":   board.Title,
+		"shapes":  len(shapes),
+		"outline": BuildBoardOutline(shapes),
+		"message": "This board is read-only reference material. Apply what you need to the current board.",
+	}, nil
+}
+
+// matchBoardsByTitle returns the boards whose title matches exactly (case-insensitive), or else contains the query
+// The current board is never a match
+func matchBoardsByTitle(boards []models.Board, query string, currentBoardId string) []models.Board {
+	query = strings.ToLower(query)
+	var exact, partial []models.Board
+	for _, b := range boards {
+		if b.UUID.String() == currentBoardId {
+			continue
+		}
+		title := strings.ToLower(strings.TrimSpace(b.Title))
+		switch {
+		case title == query:
+			exact = append(exact, b)
+		case strings.Contains(title, query):
+			partial = append(partial, b)
+		}
+	}
+	if len(exact) > 0 {
+		return exact
+	}
+	return partial
+}
+
+// FormatReferencedBoard renders a board outline for the REFERENCED_BOARDS prompt block
+func FormatReferencedBoard(board models.Board, shapes []models.BoardData) string {
+	outline := BuildBoardOutline(shapes)
+	if outline == "" {
+		outline = "(the board has no text content)"
+	}
+	return fmt.Sprintf("  <BOARD title=%q>\n%s\n  </BOARD>", board.Title, outline)
+}
@@ -0,0 +1,25 @@
+package tools
+
+import (
+	"melina-studio-backend/internal/models"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestMatchBoardsByTitle(t *testing.T) {
+	current := models.Board{UUID: uuid.New(), Title: "Architecture v2"}
+	arch := models.Board{UUID: uuid.New(), Title: "Architecture"}
+	archOld := models.Board{UUID: uuid.New(), Title: "Old architecture"}
+	boards := []models.Board{current, arch, archOld, {UUID: uuid.New(), Title: "Roadmap"}}
+
+	matches := matchBoardsByTitle(boards, "architecture", current.UUID.String())
+	if len(matches) != 1 || matches[0].UUID != arch.UUID {
+		t.Errorf("Expected the exact title match only, got %v", matches)
+	}
+
+	matches = matchBoardsByTitle(boards, "ARCH", current.UUID.String())
+	if len(matches) != 2 {
+		t.Errorf("Expected 2 partial matches excluding the current board, got %d", len(matches))
+	}
+}
@@ -0,0 +1,288 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log"
+	"regexp"
+
+	"melina-studio-backend/internal/libraries"
+	llmHandlers "melina-studio-backend/internal/llm_handlers"
+	"melina-studio-backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+var replaceTextSchema = toolSchema{
+	Name:        "replaceText",
+	Description: "Finds and replaces text in every shape on the board in one call: text shapes, sticky notes, action items, frame and table names, input placeholders and navbar or card items. Use it for renames across the board (\"rename 'Login' to 'Sign in' everywhere\") instead of updating shapes one by one. Locked shapes are left unchanged. Returns each change made.",
+	Parameters: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"boardId": map[string]interface{}{
+				"type":        "string",
+				"description": "The UUID of the board",
+			},
+			"find": map[string]interface{}{
+				"type":        "string",
+				"description": "The text to find, or a Go (RE2) regular expression when regex is true",
+			},
+			"replace": map[string]interface{}{
+				"type":        "string",
+				"description": "The replacement text; with regex, $1 and ${name} insert capture groups",
+			},
+			"regex": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Treat find as a regular expression (optional, default false)",
+			},
+			"matchCase": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Only match the same upper and lower case (optional, default false)",
+			},
+			"wholeWord": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Only match whole words, so 'Log' doesn't match 'Login' (optional, default false)",
+			},
+		},
+		"required": []string{"boardId", "find", "replace"},
+	},
+}
+
+// maxTextFindLength keeps patterns to what a person would type
+const maxTextFindLength = 500
+
+// TextReplaceOptions is a find-and-replace over a board's text
+type TextReplaceOptions struct {
+	Find      string `json:"find"`
+	Replace   string `json:"replace"`
+	Regex     bool   `json:"regex"`
+	MatchCase bool   `json:"match_case"`
+	WholeWord bool   `json:"whole_word"`
+}
+
+// TextChange is one text field a replacement changed
+type TextChange struct {
+	ShapeID string `json:"shape_id"`
+	Type    string `json:"type"`
+	Field   string `json:"field"`
+	Before  string `json:"before"`
+	After   string `json:"after"`
+	Count   int    `json:"count"`
+}
+
+// TextReplaceReport lists what a replacement changed
+type TextReplaceReport struct {
+	Replacements  int          `json:"replacements"`
+	Shapes        int          `json:"shapes"`
+	Changes       []TextChange `json:"changes"`
+	SkippedLocked []string     `json:"skipped_locked,omitempty"` // locked shapes that had matches
+}
+
+// replaceableTextFields are the string fields of a shape's data that hold text the user wrote
+var replaceableTextFields = []string{"text", "name", "placeholder"}
+
+// compileTextPattern builds the pattern for the options, quoting literal text
+func compileTextPattern(opts TextReplaceOptions) (*regexp.Regexp, error) {
+	if opts.Find == "" {
+		return nil, fmt.Errorf("find must not be empty")
+	}
+	if len(opts.Find) > maxTextFindLength {
+		return nil, fmt.Errorf("find must be at most %d characters", maxTextFindLength)
+	}
+	pattern := opts.Find
+	if !opts.Regex {
+		pattern = regexp.QuoteMeta(pattern)
+	}
+	if opts.WholeWord {
+		pattern = `\b(?:` + pattern + `)\b`
+	}
+	if !opts.MatchCase {
+		pattern = `(?i)` + pattern
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression: %w", err)
+	}
+	return re, nil
+}
+
+// ReplaceBoardText applies a find-and-replace to the text of the shapes and returns the shapes that
+// changed, with their new data, and the report. Locked shapes are never changed; nothing is stored
+func ReplaceBoardText(shapes []models.BoardData, opts TextReplaceOptions) ([]models.BoardData, *TextReplaceReport, error) {
+	re, err := compileTextPattern(opts)
+	if err != nil {
+		return nil, nil, err
+	}
+	replace := func(s string) (string, int) {
+		count := len(re.FindAllStringIndex(s, -1))
+		if count == 0 {
+			return s, 0
+		}
+		if opts.Regex {
+			return re.ReplaceAllString(s, opts.Replace), count
+		}
+		return re.ReplaceAllLiteralString(s, opts.Replace), count
+	}
+
+	report := &TextReplaceReport{Changes: []TextChange{}}
+	var updated []models.BoardData
+	for _, shape := range shapes {
+		var data map[string]interface{}
+		if err := json.Unmarshal(shape.Data, &data); err != nil {
+			continue
+		}
+
+		var changes []TextChange
+		change := func(field string, before string) string {
+			after, count := replace(before)
+			if count > 0 && after != before {
+				changes = append(changes, TextChange{
+					ShapeID: shape.UUID.String(),
+					Type:    string(shape.Type),
+					Field:   field,
+					Before:  before,
+					After:   after,
+					Count:   count,
+				})
+			}
+			return after
+		}
+		for _, field := range replaceableTextFields {
+			if value, ok := data[field].(string); ok {
+				data[field] = change(field, value)
+			}
+		}
+		if items, ok := data["items"].([]interface{}); ok {
+			for i, item := range items {
+				if value, ok := item.(string); ok {
+					items[i] = change(fmt.Sprintf("items[%d]", i), value)
+				}
+			}
+		}
+		if len(changes) == 0 {
+			continue
+		}
+		if shape.Locked {
+			report.SkippedLocked = append(report.SkippedLocked, shape.UUID.String())
+			continue
+		}
+
+		bytes, err := json.Marshal(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		shape.Data = datatypes.JSON(bytes)
+		updated = append(updated, shape)
+		report.Shapes++
+		for _, c := range changes {
+			report.Replacements += c.Count
+		}
+		report.Changes = append(report.Changes, changes...)
+	}
+	return updated, report, nil
+}
+
+// ShapeDataUpdates maps the shapes' ids to their data, for BoardDataRepo.UpdateShapesData
+func ShapeDataUpdates(shapes []models.BoardData) map[uuid.UUID]datatypes.JSON {
+	updates := make(map[uuid.UUID]datatypes.JSON, len(shapes))
+	for _, shape := range shapes {
+		updates[shape.UUID] = shape.Data
+	}
+	return updates
+}
+
+// ReplaceTextHandler is the handler for the replaceText tool
+func (d *ToolDeps) ReplaceTextHandler(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+	boardIdStr, ok := input["boardId"].(string)
+	if !ok {
+		return nil, llmHandlers.InvalidToolInput("boardId is required and must be a string")
+	}
+	boardId, err := uuid.Parse(boardIdStr)
+	if err != nil {
+		return nil, llmHandlers.InvalidToolInput("invalid boardId: %v", err)
+	}
+
+	var opts TextReplaceOptions
+	if opts.Find, ok = input["find"].(string); !ok {
+		return nil, llmHandlers.InvalidToolInput("find is required and must be a string")
+	}
+	if opts.Replace, ok = input["replace"].(string); !ok {
+		return nil, llmHandlers.InvalidToolInput("replace is required and must be a string")
+	}
+	opts.Regex, _ = input["regex"].(bool)
+	opts.MatchCase, _ = input["matchCase"].(bool)
+	opts.WholeWord, _ = input["wholeWord"].(bool)
+
+	streamCtx, err := getStreamingContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	shapes, err := d.BoardData.GetBoardData(boardId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shapes from database: %w", err)
+	}
+	updated, report, err := ReplaceBoardText(shapes, opts)
+	if err != nil {
+		return nil, llmHandlers.InvalidToolInput("%v", err)
+	}
+
+	if len(updated) > 0 {
+		if err := d.BoardData.UpdateShapesData(boardId, ShapeDataUpdates(updated)); err != nil {
+			return nil, fmt.Errorf("failed to save replaced text: %w", err)
+		}
+		if streamCtx.Hub != nil && streamCtx.Client != nil {
+			for _, shape := range updated {
+				if shapeMap, err := shapeDataMap(shape.UUID, string(shape.Type), shape.Data); err == nil {
+					libraries.SendShapeUpdatedMessage(streamCtx.Hub, streamCtx.Client, boardIdStr, shapeMap)
+				}
+			}
+		}
+		d.invalidateBoardImageCache(streamCtx, boardId)
+		d.recordTextReplaced(streamCtx, boardId, opts, report)
+	}
+
+	message := fmt.Sprintf("Replaced %d matches in %d shapes", report.Replacements, report.Shapes)
+	if len(report.SkippedLocked) > 0 {
+		message += fmt.Sprintf("; %d locked shapes with matches were left unchanged, tell the user to unlock them if they should change too", len(report.SkippedLocked))
+	}
+	return map[string]interface{}{
+		"success":       true,
+		"replacements":  report.Replacements,
+		"shapes":        report.Shapes,
+		"changes":       report.Changes,
+		"skippedLocked": report.SkippedLocked,
+		"message":       message,
+	}, nil
+}
+
+// recordTextReplaced adds the replacement to the board's activity feed
+func (d *ToolDeps) recordTextReplaced(streamCtx *llmHandlers.StreamingContext, boardId uuid.UUID, opts TextReplaceOptions, report *TextReplaceReport) {
+	userId, err := uuid.Parse(streamCtx.UserID)
+	if err != nil {
+		return
+	}
+	activity := &models.BoardActivity{
+		BoardID: boardId,
+		UserID:  userId,
+		Type:    models.ActivityShapeRenamed,
+		Actor:   models.ActivityActorAgent,
+		Count:   report.Shapes,
+		Summary: TextReplacedSummary("Melina replaced", opts, report),
+	}
+	if err := d.BoardActivity.Create(activity); err != nil {
+		log.Printf("Failed to record board activity: %v", err)
+		return
+	}
+	libraries.SendBoardActivityMessage(streamCtx.Hub, streamCtx.UserID, &libraries.BoardActivityPayload{
+		BoardId:  boardId.String(),
+		Activity: activity,
+	})
+}
+
+// TextReplacedSummary describes a replacement for the activity feed
+func TextReplacedSummary(verb string, opts TextReplaceOptions, report *TextReplaceReport) string {
+	return fmt.Sprintf("%s %q with %q in %d shapes", verb, opts.Find, opts.Replace, report.Shapes)
+}
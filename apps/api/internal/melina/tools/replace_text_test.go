@@ -0,0 +1,87 @@
+package tools
+
+import (
+	"encoding/json"
+	"testing"
+
+	"melina-studio-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func boardShape(t *testing.T, shapeType models.Type, data map[string]interface{}) models.BoardData {
+	t.Helper()
+	bytes, err := json.Marshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return models.BoardData{UUID: uuid.New(), Type: shapeType, Data: bytes}
+}
+
+func TestReplaceBoardText(t *testing.T) {
+	shapes := []models.BoardData{
+		boardShape(t, models.Text, map[string]interface{}{"text": "Login, then LOGIN again. Logins: 2"}),
+		boardShape(t, models.Navbar, map[string]interface{}{"items": []string{"Home", "Login"}}),
+		boardShape(t, models.Input, map[string]interface{}{"placeholder": "login email", "text": "unrelated"}),
+		boardShape(t, models.Rect, map[string]interface{}{"x": 10.0, "fill": "#login"}),
+	}
+
+	tests := []struct {
+		name         string
+		opts         TextReplaceOptions
+		replacements int
+		shapes       int
+		first        string
+	}{
+		{"ignores case by default", TextReplaceOptions{Find: "login", Replace: "Sign in"}, 5, 3, "Sign in, then Sign in again. Sign ins: 2"},
+		{"matches case", TextReplaceOptions{Find: "Login", Replace: "Sign in", MatchCase: true}, 3, 2, "Sign in, then LOGIN again. Sign ins: 2"},
+		{"whole words only", TextReplaceOptions{Find: "login", Replace: "Sign in", WholeWord: true}, 4, 3, "Sign in, then Sign in again. Logins: 2"},
+		{"regex with groups", TextReplaceOptions{Find: `(\w+)s: (\d)`, Replace: "$2 $1", Regex: true}, 1, 1, "Login, then LOGIN again. 2 Login"},
+		{"literal text is quoted", TextReplaceOptions{Find: "Logins: 2", Replace: "$1"}, 1, 1, "Login, then LOGIN again. $1"},
+	}
+	for _, tc := range tests {
+		updated, report, err := ReplaceBoardText(shapes, tc.opts)
+		if err != nil {
+			t.Fatalf("%s: unexpected error: %v", tc.name, err)
+		}
+		if report.Replacements != tc.replacements || report.Shapes != tc.shapes || len(updated) != tc.shapes {
+			t.Errorf("%s: expected %d replacements in %d shapes, got %+v", tc.name, tc.replacements, tc.shapes, report)
+		}
+		if len(updated) == 0 || updated[0].UUID != shapes[0].UUID {
+			t.Errorf("%s: expected the text shape to change first", tc.name)
+			continue
+		}
+		var data map[string]interface{}
+		_ = json.Unmarshal(updated[0].Data, &data)
+		if data["text"] != tc.first {
+			t.Errorf("%s: got %q, want %q", tc.name, data["text"], tc.first)
+		}
+	}
+
+	var stored map[string]interface{}
+	_ = json.Unmarshal(shapes[0].Data, &stored)
+	if stored["text"] != "Login, then LOGIN again. Logins: 2" {
+		t.Errorf("expected the input shapes to be left unchanged, got %q", stored["text"])
+	}
+}
+
+func TestReplaceBoardTextSkipsLocked(t *testing.T) {
+	shape := boardShape(t, models.Text, map[string]interface{}{"text": "Login"})
+	shape.Locked = true
+
+	updated, report, err := ReplaceBoardText([]models.BoardData{shape}, TextReplaceOptions{Find: "Login", Replace: "Sign in"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(updated) != 0 || report.Replacements != 0 || len(report.SkippedLocked) != 1 {
+		t.Errorf("expected the locked shape to be skipped, got %+v", report)
+	}
+}
+
+func TestReplaceBoardTextInvalid(t *testing.T) {
+	for _, opts := range []TextReplaceOptions{{Find: ""}, {Find: "[", Regex: true}} {
+		if _, _, err := ReplaceBoardText(nil, opts); err == nil {
+			t.Errorf("%+v: expected an error", opts)
+		}
+	}
+}
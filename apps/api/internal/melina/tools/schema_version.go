@@ -0,0 +1,19 @@
+package tools
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+)
+
+// SchemaVersion identifies the tool schemas the agent runs with, the way prompts.MasterPromptVersion
+// does the prompt; turns record it so a replay can tell when the tools changed since
+var SchemaVersion = sync.OnceValue(func() string {
+	data, err := json.Marshal(GetAnthropicTools())
+	if err != nil {
+		return ""
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:6])
+})
@@ -0,0 +1,515 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	llmHandlers "melina-studio-backend/internal/llm_handlers"
+	"melina-studio-backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+var addTimelineItemSchema = toolSchema{
+	Name:        "addTimelineItem",
+	Description: "Adds a roadmap item to the board's timeline: a bar from startDate to endDate, or a milestone marker on startDate. You don't position items horizontally: their x and width come from their dates on the board's time scale, so items with the same dates line up and the timeline stays consistent when dates change. Each new item goes on its own row below the existing timeline items unless you pass y. The first item of a board fixes where the time scale starts.",
+	Parameters: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"boardId": map[string]interface{}{
+				"type":        "string",
+				"description": "The UUID of the board",
+			},
+			"kind": map[string]interface{}{
+				"type":        "string",
+				"enum":        []string{"bar", "milestone"},
+				"description": "bar for a task or phase with a start and end date, milestone for a single date (default bar)",
+			},
+			"title": map[string]interface{}{
+				"type":        "string",
+				"description": "Label shown on the item",
+			},
+			"startDate": map[string]interface{}{
+				"type":        "string",
+				"description": "Start date of a bar, or the date of a milestone, in YYYY-MM-DD format",
+			},
+			"endDate": map[string]interface{}{
+				"type":        "string",
+				"description": "Last day of a bar in YYYY-MM-DD format, inclusive (required for bars)",
+			},
+			"y": map[string]interface{}{
+				"type":        "number",
+				"description": "Y coordinate of the item (optional, defaults to the row below the existing timeline items)",
+			},
+			"x": map[string]interface{}{
+				"type":        "number",
+				"description": "Where the time scale starts, only used for the board's first timeline item (optional, defaults to the left of the existing content)",
+			},
+			"fill": map[string]interface{}{
+				"type":        "string",
+				"description": "Fill color (optional)",
+			},
+		},
+		"required": []string{"boardId", "title", "startDate"},
+	},
+}
+
+var updateTimelineItemSchema = toolSchema{
+	Name:        "updateTimelineItem",
+	Description: "Changes the title, dates, row or color of a timeline bar or milestone. New dates move and resize the item on the board's time scale; don't use updateShape to move timeline items.",
+	Parameters: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"boardId": map[string]interface{}{
+				"type":        "string",
+				"description": "The UUID of the board",
+			},
+			"shapeId": map[string]interface{}{
+				"type":        "string",
+				"description": "The id of the timeline or milestone shape",
+			},
+			"title": map[string]interface{}{
+				"type":        "string",
+				"description": "New label (optional)",
+			},
+			"startDate": map[string]interface{}{
+				"type":        "string",
+				"description": "New start date, or milestone date, in YYYY-MM-DD format (optional)",
+			},
+			"endDate": map[string]interface{}{
+				"type":        "string",
+				"description": "New last day of a bar in YYYY-MM-DD format (optional)",
+			},
+			"y": map[string]interface{}{
+				"type":        "number",
+				"description": "New Y coordinate (optional)",
+			},
+			"fill": map[string]interface{}{
+				"type":        "string",
+				"description": "New fill color (optional)",
+			},
+		},
+		"required": []string{"boardId", "shapeId"},
+	},
+}
+
+const (
+	// TimelineDateLayout is the format of timeline dates
+	TimelineDateLayout = "2006-01-02"
+	// DefaultTimelineDayWidth is the width of a day on a board that hasn't set its time scale
+	DefaultTimelineDayWidth = 20.0
+	MinTimelineDayWidth     = 1.0
+	MaxTimelineDayWidth     = 400.0
+
+	timelineBarHeight = 40.0
+	timelineRowGap    = 16.0
+	milestoneSize     = 24.0
+)
+
+// TimeScale maps dates to x coordinates: the origin date starts at OriginX and every day is DayWidth wide
+type TimeScale struct {
+	Origin   time.Time
+	OriginX  float64
+	DayWidth float64
+}
+
+// ParseTimelineDate parses a YYYY-MM-DD date
+func ParseTimelineDate(value string) (time.Time, error) {
+	date, err := time.Parse(TimelineDateLayout, strings.TrimSpace(value))
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid date %q, expected YYYY-MM-DD", value)
+	}
+	return date, nil
+}
+
+// ValidateTimelineDayWidth limits the width of a day to what the canvas can show
+func ValidateTimelineDayWidth(width float64) error {
+	if width < MinTimelineDayWidth || width > MaxTimelineDayWidth {
+		return fmt.Errorf("timeline day width must be between %g and %g", MinTimelineDayWidth, MaxTimelineDayWidth)
+	}
+	return nil
+}
+
+// BoardTimeScale returns the board's time scale; false when the board has no timeline origin yet
+func BoardTimeScale(board models.Board) (TimeScale, bool) {
+	if board.TimelineOrigin == "" {
+		return TimeScale{}, false
+	}
+	origin, err := ParseTimelineDate(board.TimelineOrigin)
+	if err != nil {
+		return TimeScale{}, false
+	}
+	dayWidth := board.TimelineDayWidth
+	if dayWidth <= 0 {
+		dayWidth = DefaultTimelineDayWidth
+	}
+	return TimeScale{Origin: origin, OriginX: board.TimelineOriginX, DayWidth: dayWidth}, true
+}
+
+// days counts the days from the origin to the date; dates are parsed in UTC, so every day is 24 hours
+func (s TimeScale) days(date time.Time) float64 {
+	return math.Round(date.Sub(s.Origin).Hours() / 24)
+}
+
+// X is where the date starts on the canvas
+func (s TimeScale) X(date time.Time) float64 {
+	return s.OriginX + s.days(date)*s.DayWidth
+}
+
+// DateAt is the date whose day contains x
+func (s TimeScale) DateAt(x float64) time.Time {
+	return s.Origin.AddDate(0, 0, int(math.Floor((x-s.OriginX)/s.DayWidth)))
+}
+
+// TimelineSpan returns the x and width of a timeline bar or milestone with the given dates
+// A bar covers its start day through its end day; a milestone is a fixed-size marker centered
+// on the start of its day. An end before the start counts as a one-day bar
+func TimelineSpan(shapeType models.Type, startDate string, endDate string, scale TimeScale) (float64, float64, error) {
+	start, err := ParseTimelineDate(startDate)
+	if err != nil {
+		return 0, 0, err
+	}
+	if shapeType == models.Milestone {
+		return scale.X(start) - milestoneSize/2, milestoneSize, nil
+	}
+	end := start
+	if endDate != "" {
+		if end, err = ParseTimelineDate(endDate); err != nil {
+			return 0, 0, err
+		}
+	}
+	days := math.Max(1, scale.days(end)-scale.days(start)+1)
+	return scale.X(start), days * scale.DayWidth, nil
+}
+
+// LayoutTimelineShape sets the x and width of a timeline or milestone shape saved by the client from its
+// dates; it reports whether they changed. Shapes of other types or with invalid dates are left alone
+func LayoutTimelineShape(shape *models.Shape, scale TimeScale) bool {
+	shapeType := models.Type(shape.Type)
+	if (shapeType != models.Timeline && shapeType != models.Milestone) || shape.StartDate == nil {
+		return false
+	}
+	endDate := ""
+	if shape.EndDate != nil {
+		endDate = *shape.EndDate
+	}
+	x, w, err := TimelineSpan(shapeType, *shape.StartDate, endDate, scale)
+	if err != nil {
+		return false
+	}
+	if shape.X != nil && shape.W != nil && *shape.X == x && *shape.W == w {
+		return false
+	}
+	shape.X, shape.W = &x, &w
+	return true
+}
+
+// layoutTimelineMap is LayoutTimelineShape for a stored shape's data map
+func layoutTimelineMap(shapeType models.Type, data map[string]interface{}, scale TimeScale) bool {
+	startDate, _ := data["startDate"].(string)
+	endDate, _ := data["endDate"].(string)
+	x, w, err := TimelineSpan(shapeType, startDate, endDate, scale)
+	if err != nil {
+		return false
+	}
+	if data["x"] == x && data["w"] == w {
+		return false
+	}
+	data["x"], data["w"] = x, w
+	return true
+}
+
+// RelayoutTimeline lays the board's unlocked timeline and milestone shapes out on the time scale again,
+// e.g. after it changed, and returns the new data of the shapes that moved
+func RelayoutTimeline(shapes []models.BoardData, scale TimeScale) map[uuid.UUID]datatypes.JSON {
+	updates := make(map[uuid.UUID]datatypes.JSON)
+	for _, shape := range shapes {
+		if (shape.Type != models.Timeline && shape.Type != models.Milestone) || shape.Locked {
+			continue
+		}
+		var data map[string]interface{}
+		if err := json.Unmarshal(shape.Data, &data); err != nil {
+			continue
+		}
+		if !layoutTimelineMap(shape.Type, data, scale) {
+			continue
+		}
+		if bytes, err := json.Marshal(data); err == nil {
+			updates[shape.UUID] = bytes
+		}
+	}
+	return updates
+}
+
+// nextTimelineRow is the y of a new row below the board's timeline items, or below the content when there are none
+func nextTimelineRow(shapes []models.BoardData) float64 {
+	bottom, found := 0.0, false
+	for _, shape := range shapes {
+		if shape.Type != models.Timeline && shape.Type != models.Milestone {
+			continue
+		}
+		if b, _, err := GetShapeBounds(shape, 0); err == nil && (!found || b.MaxY > bottom) {
+			bottom, found = b.MaxY, true
+		}
+	}
+	if found {
+		return bottom + timelineRowGap
+	}
+	if state := GenerateCanvasState(shapes, 0, 0); state != nil {
+		return state.OverallBounds.MaxY + clusterFrameGap
+	}
+	return placementOrigin
+}
+
+// timelineShapeMap builds the websocket shape payload for a timeline item
+func timelineShapeMap(shape *models.Shape) map[string]interface{} {
+	shapeMap := map[string]interface{}{
+		"id":        shape.ID,
+		"type":      shape.Type,
+		"x":         *shape.X,
+		"y":         *shape.Y,
+		"w":         *shape.W,
+		"h":         *shape.H,
+		"text":      *shape.Text,
+		"startDate": *shape.StartDate,
+		"fill":      *shape.Fill,
+		"stroke":    *shape.Stroke,
+	}
+	if shape.EndDate != nil {
+		shapeMap["endDate"] = *shape.EndDate
+	}
+	return shapeMap
+}
+
+// AddTimelineItemHandler is the handler for the addTimelineItem tool
+func (d *ToolDeps) AddTimelineItemHandler(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+	boardIdStr, ok := input["boardId"].(string)
+	if !ok {
+		return nil, llmHandlers.InvalidToolInput("boardId is required and must be a string")
+	}
+	boardId, err := uuid.Parse(boardIdStr)
+	if err != nil {
+		return nil, llmHandlers.InvalidToolInput("invalid boardId: %v", err)
+	}
+	title, _ := input["title"].(string)
+	title = strings.TrimSpace(title)
+	if title == "" {
+		return nil, llmHandlers.InvalidToolInput("title is required")
+	}
+
+	shapeType := models.Timeline
+	kind, _ := input["kind"].(string)
+	switch kind {
+	case "", "bar":
+	case "milestone":
+		shapeType = models.Milestone
+	default:
+		return nil, llmHandlers.InvalidToolInput("kind must be bar or milestone")
+	}
+
+	startDate, _ := input["startDate"].(string)
+	start, err := ParseTimelineDate(startDate)
+	if err != nil {
+		return nil, llmHandlers.InvalidToolInput("startDate: %v", err)
+	}
+	startDate = start.Format(TimelineDateLayout)
+	var endDate *string
+	if shapeType == models.Timeline {
+		value, _ := input["endDate"].(string)
+		end, err := ParseTimelineDate(value)
+		if err != nil {
+			return nil, llmHandlers.InvalidToolInput("endDate is required for a bar: %v", err)
+		}
+		if end.Before(start) {
+			return nil, llmHandlers.InvalidToolInput("endDate must not be before startDate")
+		}
+		formatted := end.Format(TimelineDateLayout)
+		endDate = &formatted
+	}
+
+	streamCtx, err := getStreamingContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	userId, err := uuid.Parse(streamCtx.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user id: %w", err)
+	}
+	board, err := d.Boards.GetBoardById(userId, boardId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get board: %w", err)
+	}
+	shapes, err := d.BoardData.GetBoardData(boardId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shapes from database: %w", err)
+	}
+
+	// the board's first item fixes the time scale: its start date begins at x
+	scale, ok := BoardTimeScale(board)
+	if !ok {
+		originX := placementOrigin
+		if state := GenerateCanvasState(shapes, 0, 0); state != nil {
+			originX = state.OverallBounds.MinX
+		}
+		if x, ok := input["x"].(float64); ok {
+			originX = x
+		}
+		dayWidth := board.TimelineDayWidth
+		if dayWidth <= 0 {
+			dayWidth = DefaultTimelineDayWidth
+		}
+		if err := d.Boards.SetBoardTimeline(userId, boardId, models.BoardTimeline{Origin: &startDate, OriginX: &originX, DayWidth: &dayWidth}); err != nil {
+			return nil, fmt.Errorf("failed to save the board's time scale: %w", err)
+		}
+		scale = TimeScale{Origin: start, OriginX: originX, DayWidth: dayWidth}
+	}
+
+	y := nextTimelineRow(shapes)
+	if value, ok := input["y"].(float64); ok {
+		y = value
+	}
+	end := ""
+	if endDate != nil {
+		end = *endDate
+	}
+	x, w, err := TimelineSpan(shapeType, startDate, end, scale)
+	if err != nil {
+		return nil, llmHandlers.InvalidToolInput("%v", err)
+	}
+	h := timelineBarHeight
+	fill, stroke := "#dbeafe", "#2563eb"
+	if shapeType == models.Milestone {
+		h = milestoneSize
+		fill, stroke = "#fde68a", "#d97706"
+	}
+	if value, ok := input["fill"].(string); ok && value != "" {
+		fill = value
+	}
+
+	shape := &models.Shape{
+		ID:        uuid.New().String(),
+		Type:      string(shapeType),
+		X:         &x,
+		Y:         &y,
+		W:         &w,
+		H:         &h,
+		Text:      &title,
+		StartDate: &startDate,
+		EndDate:   endDate,
+		Fill:      &fill,
+		Stroke:    &stroke,
+	}
+	if err := d.BoardData.SaveShapeData(boardId, shape); err != nil {
+		return nil, fmt.Errorf("failed to save timeline item: %w", err)
+	}
+
+	shapeMap := timelineShapeMap(shape)
+	sendShapeCreated(ctx, streamCtx, boardIdStr, shapeMap)
+	d.invalidateBoardImageCache(streamCtx, boardId)
+
+	return map[string]interface{}{
+		"success": true,
+		"shapeId": shape.ID,
+		"shape":   shapeMap,
+		"message": fmt.Sprintf("Added %s %q", shapeType, title),
+	}, nil
+}
+
+// UpdateTimelineItemHandler is the handler for the updateTimelineItem tool
+func (d *ToolDeps) UpdateTimelineItemHandler(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+	boardIdStr, ok := input["boardId"].(string)
+	if !ok {
+		return nil, llmHandlers.InvalidToolInput("boardId is required and must be a string")
+	}
+	boardId, err := uuid.Parse(boardIdStr)
+	if err != nil {
+		return nil, llmHandlers.InvalidToolInput("invalid boardId: %v", err)
+	}
+	shapeIdStr, ok := input["shapeId"].(string)
+	if !ok {
+		return nil, llmHandlers.InvalidToolInput("shapeId is required and must be a string")
+	}
+	shapeId, err := uuid.Parse(shapeIdStr)
+	if err != nil {
+		return nil, llmHandlers.InvalidToolInput("invalid shapeId: %v", err)
+	}
+
+	streamCtx, err := getStreamingContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	userId, err := uuid.Parse(streamCtx.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid user id: %w", err)
+	}
+	board, err := d.Boards.GetBoardById(userId, boardId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get board: %w", err)
+	}
+	shapes, err := d.BoardData.GetShapesByUUIDs([]uuid.UUID{shapeId})
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shape from database: %w", err)
+	}
+	if len(shapes) == 0 || shapes[0].BoardId != boardId {
+		return nil, llmHandlers.InvalidToolInput("shape with id %s not found on board", shapeIdStr)
+	}
+	stored := shapes[0]
+	if stored.Type != models.Timeline && stored.Type != models.Milestone {
+		return nil, llmHandlers.InvalidToolInput("shape %s is a %s, not a timeline item", shapeIdStr, stored.Type)
+	}
+	if stored.Locked {
+		return lockedShapeResult(shapeIdStr), nil
+	}
+	shape, err := shapeDataMap(stored.UUID, string(stored.Type), stored.Data)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse shape data: %w", err)
+	}
+
+	if title, ok := input["title"].(string); ok && strings.TrimSpace(title) != "" {
+		shape["text"] = strings.TrimSpace(title)
+	}
+	for _, key := range []string{"startDate", "endDate"} {
+		value, ok := input[key].(string)
+		if !ok || value == "" {
+			continue
+		}
+		date, err := ParseTimelineDate(value)
+		if err != nil {
+			return nil, llmHandlers.InvalidToolInput("%s: %v", key, err)
+		}
+		shape[key] = date.Format(TimelineDateLayout)
+	}
+	if stored.Type == models.Timeline {
+		startDate, _ := shape["startDate"].(string)
+		endDate, _ := shape["endDate"].(string)
+		if endDate != "" && endDate < startDate {
+			return nil, llmHandlers.InvalidToolInput("endDate must not be before startDate")
+		}
+	}
+	if y, ok := input["y"].(float64); ok {
+		shape["y"] = y
+	}
+	if fill, ok := input["fill"].(string); ok && fill != "" {
+		shape["fill"] = fill
+	}
+
+	if scale, ok := BoardTimeScale(board); ok {
+		layoutTimelineMap(stored.Type, shape, scale)
+	}
+	if err := d.persistShapeMove(streamCtx, boardId, shape); err != nil {
+		return nil, fmt.Errorf("failed to save timeline item: %w", err)
+	}
+	d.invalidateBoardImageCache(streamCtx, boardId)
+
+	return map[string]interface{}{
+		"success": true,
+		"shapeId": shapeIdStr,
+		"shape":   shape,
+		"message": fmt.Sprintf("Updated %s", stored.Type),
+	}, nil
+}
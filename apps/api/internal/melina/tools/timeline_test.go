@@ -0,0 +1,108 @@
+package tools
+
+import (
+	"encoding/json"
+	"testing"
+
+	"melina-studio-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func testTimeScale(t *testing.T) TimeScale {
+	t.Helper()
+	scale, ok := BoardTimeScale(models.Board{TimelineOrigin: "2026-01-01", TimelineOriginX: 100})
+	if !ok {
+		t.Fatal("expected a board with an origin to have a time scale")
+	}
+	return scale
+}
+
+func TestBoardTimeScale(t *testing.T) {
+	if _, ok := BoardTimeScale(models.Board{}); ok {
+		t.Error("expected a board without an origin to have no time scale")
+	}
+	scale := testTimeScale(t)
+	if scale.DayWidth != DefaultTimelineDayWidth {
+		t.Errorf("expected the default day width, got %v", scale.DayWidth)
+	}
+
+	date, _ := ParseTimelineDate("2026-02-01")
+	if x := scale.X(date); x != 100+31*DefaultTimelineDayWidth {
+		t.Errorf("expected February to start 31 days after the origin, got %v", x)
+	}
+	if got := scale.DateAt(scale.X(date) + 5).Format(TimelineDateLayout); got != "2026-02-01" {
+		t.Errorf("expected x inside a day to map back to it, got %s", got)
+	}
+	if got := scale.DateAt(99).Format(TimelineDateLayout); got != "2025-12-31" {
+		t.Errorf("expected x left of the origin to be the day before it, got %s", got)
+	}
+}
+
+func TestTimelineSpan(t *testing.T) {
+	scale := testTimeScale(t)
+	cases := []struct {
+		name             string
+		shapeType        models.Type
+		start, end       string
+		wantX, wantWidth float64
+	}{
+		{"bar ends inclusive", models.Timeline, "2026-01-03", "2026-01-05", 140, 60},
+		{"bar without an end is one day", models.Timeline, "2026-01-03", "", 140, 20},
+		{"bar ending before it starts is one day", models.Timeline, "2026-01-03", "2026-01-01", 140, 20},
+		{"milestone centered on its date", models.Milestone, "2026-01-03", "", 140 - milestoneSize/2, milestoneSize},
+	}
+	for _, c := range cases {
+		x, w, err := TimelineSpan(c.shapeType, c.start, c.end, scale)
+		if err != nil {
+			t.Fatalf("%s: %v", c.name, err)
+		}
+		if x != c.wantX || w != c.wantWidth {
+			t.Errorf("%s: got x %v w %v, want x %v w %v", c.name, x, w, c.wantX, c.wantWidth)
+		}
+	}
+	if _, _, err := TimelineSpan(models.Timeline, "03/01/2026", "", scale); err == nil {
+		t.Error("expected a date in another format to fail")
+	}
+}
+
+func TestLayoutTimelineShape(t *testing.T) {
+	scale := testTimeScale(t)
+	x, w := 0.0, 10.0
+	start, end := "2026-01-02", "2026-01-03"
+	shape := &models.Shape{Type: "timeline", X: &x, W: &w, StartDate: &start, EndDate: &end}
+	if !LayoutTimelineShape(shape, scale) || *shape.X != 120 || *shape.W != 40 {
+		t.Errorf("expected the bar to move to its dates, got x %v w %v", *shape.X, *shape.W)
+	}
+	if LayoutTimelineShape(shape, scale) {
+		t.Error("expected a laid out bar to stay put")
+	}
+	rect := &models.Shape{Type: "rect", X: &x, StartDate: &start}
+	if LayoutTimelineShape(rect, scale) {
+		t.Error("expected other shape types to be left alone")
+	}
+}
+
+func TestRelayoutTimeline(t *testing.T) {
+	scale := testTimeScale(t)
+	shape := func(shapeType models.Type, locked bool, data map[string]interface{}) models.BoardData {
+		bytes, _ := json.Marshal(data)
+		return models.BoardData{UUID: uuid.New(), Type: shapeType, Locked: locked, Data: bytes}
+	}
+	moved := shape(models.Timeline, false, map[string]interface{}{"x": 0.0, "w": 20.0, "startDate": "2026-01-01", "endDate": "2026-01-02"})
+	placed := shape(models.Milestone, false, map[string]interface{}{"x": 100 - milestoneSize/2, "w": milestoneSize, "startDate": "2026-01-01"})
+	locked := shape(models.Timeline, true, map[string]interface{}{"x": 0.0, "w": 20.0, "startDate": "2026-01-01"})
+	rect := shape(models.Rect, false, map[string]interface{}{"x": 0.0, "startDate": "2026-01-01"})
+
+	updates := RelayoutTimeline([]models.BoardData{moved, placed, locked, rect}, scale)
+	if len(updates) != 1 {
+		t.Fatalf("expected only the unlocked bar off its dates to move, got %d updates", len(updates))
+	}
+	var data map[string]interface{}
+	if err := json.Unmarshal(updates[moved.UUID], &data); err != nil {
+		t.Fatal(err)
+	}
+	if data["x"] != 100.0 || data["w"] != 40.0 {
+		t.Errorf("expected the bar at the origin spanning two days, got %v", data)
+	}
+}
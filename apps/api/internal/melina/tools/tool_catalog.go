@@ -0,0 +1,63 @@
+package tools
+
+import (
+	llmHandlers "melina-studio-backend/internal/llm_handlers"
+)
+
+// ToolSource says where an agent tool comes from
+type ToolSource string
+
+const (
+	ToolSourceBuiltin ToolSource = "builtin"
+	ToolSourcePlugin  ToolSource = "plugin"
+	ToolSourceCustom  ToolSource = "custom"
+)
+
+// ToolInfo describes an agent tool for the action catalog
+type ToolInfo struct {
+	Name        string
+	Description string
+	Parameters  map[string]interface{}
+	Source      ToolSource
+	// Plugin is the plugin serving the tool, for plugin tools
+	Plugin string
+	// RequiresApproval is set when the tool asks the user before running, for users who turned confirmations on
+	RequiresApproval bool
+}
+
+// ToolCatalog lists every tool the agent is offered right now, in the order it is offered; plugin and custom
+// tools appear as soon as they are loaded
+func ToolCatalog() []ToolInfo {
+	pluginToolsMu.RLock()
+	pluginOf := make(map[string]string)
+	for plugin, list := range pluginTools {
+		for _, tool := range list {
+			pluginOf[tool.Name] = plugin
+		}
+	}
+	pluginToolsMu.RUnlock()
+
+	customToolsMu.RLock()
+	custom := make(map[string]bool, len(customTools))
+	for _, spec := range customTools {
+		custom[spec.Name] = true
+	}
+	customToolsMu.RUnlock()
+
+	exposed := GetAnthropicTools()
+	catalog := make([]ToolInfo, 0, len(exposed))
+	for _, tool := range exposed {
+		info := ToolInfo{Source: ToolSourceBuiltin}
+		info.Name, _ = tool["name"].(string)
+		info.Description, _ = tool["description"].(string)
+		info.Parameters, _ = tool["input_schema"].(map[string]interface{})
+		if plugin, ok := pluginOf[info.Name]; ok {
+			info.Source, info.Plugin = ToolSourcePlugin, plugin
+		} else if custom[info.Name] {
+			info.Source = ToolSourceCustom
+		}
+		info.RequiresApproval = llmHandlers.RequiresToolApproval(info.Name)
+		catalog = append(catalog, info)
+	}
+	return catalog
+}
@@ -0,0 +1,123 @@
+package tools
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"melina-studio-backend/internal/libraries"
+	llmHandlers "melina-studio-backend/internal/llm_handlers"
+	"melina-studio-backend/internal/repo"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+// ToolDeps holds what the tool handlers need from outside of the package; the handlers are its methods
+// so tests can run them against fakes instead of the database
+type ToolDeps struct {
+	Boards        repo.BoardRepoInterface
+	BoardData     repo.BoardDataRepoInterface
+	BoardActivity repo.BoardActivityRepoInterface
+	// Embed embeds note texts for clusterNotes; on error it falls back to term vectors
+	Embed func(ctx context.Context, texts []string) ([][]float64, error)
+	// BoardImage returns the board image the client last uploaded, see GetBoardData
+	BoardImage func(boardId string) (map[string]interface{}, error)
+	// StoreImage uploads an image the tools make, e.g. a board cover, and returns its URL
+	StoreImage func(ctx context.Context, key string, data []byte) (string, error)
+}
+
+// NewToolDeps returns the dependencies the tools use in the server
+func NewToolDeps(db *gorm.DB) *ToolDeps {
+	return &ToolDeps{
+		Boards:        repo.NewBoardRepository(db),
+		BoardData:     repo.NewBoardDataRepository(db),
+		BoardActivity: repo.NewBoardActivityRepository(db),
+		Embed:         llmHandlers.EmbedTexts,
+		BoardImage:    GetBoardData,
+		StoreImage:    storePNG,
+	}
+}
+
+// getStreamingContext extracts the StreamingContext that the LLM handlers attach to tool calls
+func getStreamingContext(ctx context.Context) (*llmHandlers.StreamingContext, error) {
+	streamCtx, ok := llmHandlers.StreamingContextFrom(ctx)
+	if !ok {
+		return nil, fmt.Errorf("streaming context not available")
+	}
+	return streamCtx, nil
+}
+
+// shapeDataMap decodes the JSON data of a stored shape and adds its id and type
+func shapeDataMap(id uuid.UUID, shapeType string, data datatypes.JSON) (map[string]interface{}, error) {
+	var dataMap map[string]interface{}
+	if err := json.Unmarshal(data, &dataMap); err != nil {
+		return nil, err
+	}
+	dataMap["id"] = id.String()
+	dataMap["type"] = shapeType
+	return dataMap, nil
+}
+
+// lockedShapeResult is what updateShape and deleteShape return for a locked shape; it isn't an error,
+// the model should tell the user instead of retrying
+func lockedShapeResult(shapeId string) map[string]interface{} {
+	return map[string]interface{}{
+		"success": false,
+		"locked":  true,
+		"shapeId": shapeId,
+		"message": "shape is locked. Tell the user it is locked and that they need to unlock it before it can be changed or deleted.",
+	}
+}
+
+// persistShapeMove saves an updated shape data map and notifies the client
+// The map must carry "id" and "type"; they are stripped before storing
+func (d *ToolDeps) persistShapeMove(streamCtx *llmHandlers.StreamingContext, boardId uuid.UUID, shape map[string]interface{}) error {
+	shapeIdStr, _ := shape["id"].(string)
+	shapeId, err := uuid.Parse(shapeIdStr)
+	if err != nil {
+		return fmt.Errorf("invalid shape id: %w", err)
+	}
+
+	stored := make(map[string]interface{}, len(shape))
+	for k, v := range shape {
+		if k == "id" || k == "type" {
+			continue
+		}
+		stored[k] = v
+	}
+	bytes, err := json.Marshal(stored)
+	if err != nil {
+		return err
+	}
+
+	if err := d.BoardData.UpdateShapeData(boardId, shapeId, datatypes.JSON(bytes)); err != nil {
+		return err
+	}
+
+	if streamCtx.Hub != nil && streamCtx.Client != nil {
+		libraries.SendShapeUpdatedMessage(streamCtx.Hub, streamCtx.Client, boardId.String(), shape)
+	}
+	return nil
+}
+
+// sendShapeCreated notifies the client of a shape a tool created and records it for the turn,
+// since the client persists new shapes after the agent is done
+func sendShapeCreated(ctx context.Context, streamCtx *llmHandlers.StreamingContext, boardId string, shape map[string]interface{}) {
+	llmHandlers.RecordCreatedShape(ctx, shape)
+	if streamCtx.Hub != nil && streamCtx.Client != nil {
+		libraries.SendShapeCreatedMessage(streamCtx.Hub, streamCtx.Client, boardId, shape)
+	}
+}
+
+// invalidateBoardImageCache drops the annotated image cache after a tool changes the board
+func (d *ToolDeps) invalidateBoardImageCache(streamCtx *llmHandlers.StreamingContext, boardId uuid.UUID) {
+	userIdUUID, err := uuid.Parse(streamCtx.UserID)
+	if err != nil {
+		return
+	}
+	if err := InvalidateAnnotatedImageCache(d.Boards, userIdUUID, boardId); err != nil {
+		// Log but don't fail - cache invalidation is not critical
+		fmt.Printf("Warning: failed to invalidate annotated image cache: %v\n", err)
+	}
+}
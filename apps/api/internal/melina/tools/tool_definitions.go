@@ -4,22 +4,17 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
-	"melina-studio-backend/internal/config"
+	"log"
 	"melina-studio-backend/internal/libraries"
 	llmHandlers "melina-studio-backend/internal/llm_handlers"
 	"melina-studio-backend/internal/models"
-	"melina-studio-backend/internal/repo"
 
 	"github.com/google/uuid"
 )
 
-func init() {
-	RegisterAllTools()
-}
-
 // get anthropic tools returns
 func GetAnthropicTools() []map[string]interface{} {
-	return []map[string]interface{}{
+	tools := []map[string]interface{}{
 		{
 			"name":        "getBoardData",
 			"description": "Retrieves the current board data as an image for a given board id. Returns the base64 encoded image of the board with numbered badges overlaid on each shape (1, 2, 3...) and a list of all shapes with their IDs, numbers, and properties. Each shape in the array has a 'number' field that corresponds to the badge shown on that shape in the image. Use this to see what shapes exist on the board and identify which shape ID corresponds to which visual element before updating them.",
@@ -36,7 +31,7 @@ func GetAnthropicTools() []map[string]interface{} {
 		},
 		{
 			"name":        "addShape",
-			"description": "Adds a shape to the board in react konva format. Supports rect, circle, line, arrow, ellipse, polygon, text, pencil, and path (SVG). For complex shapes like animals, break them down into multiple basic shapes. Use 'path' type with SVG path data for complex vector graphics - IMPORTANT: 'data' parameter with SVG path string (e.g., 'M10 10 L90 90 Z') is REQUIRED for path shapes. The shape will appear on the board immediately.",
+			"description": "Adds a shape to the board in react konva format. Supports rect, circle, line, arrow, ellipse, polygon, text, pencil, path (SVG), and the wireframe UI components button, input, navbar and card. For complex shapes like animals, break them down into multiple basic shapes. Use 'path' type with SVG path data for complex vector graphics - IMPORTANT: 'data' parameter with SVG path string (e.g., 'M10 10 L90 90 Z') is REQUIRED for path shapes. The shape will appear on the board immediately.",
 			"input_schema": map[string]interface{}{
 				"type": "object",
 				"properties": map[string]interface{}{
@@ -46,8 +41,8 @@ func GetAnthropicTools() []map[string]interface{} {
 					},
 					"shapeType": map[string]interface{}{
 						"type":        "string",
-						"enum":        []string{"rect", "circle", "line", "arrow", "ellipse", "polygon", "text", "pencil", "path", "frame"},
-						"description": "Type of shape to create. Use 'path' for SVG path shapes. Use 'frame' for grouping containers with labels.",
+						"enum":        []string{"rect", "circle", "line", "arrow", "ellipse", "polygon", "text", "pencil", "path", "frame", "button", "input", "navbar", "card"},
+						"description": "Type of shape to create. Use 'path' for SVG path shapes. Use 'frame' for grouping containers with labels. Use 'button', 'input', 'navbar' and 'card' for wireframe UI components.",
 					},
 					"x": map[string]interface{}{
 						"type":        "number",
@@ -57,13 +52,23 @@ func GetAnthropicTools() []map[string]interface{} {
 						"type":        "number",
 						"description": "Y coordinate (required for most shapes)",
 					},
+					"position": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"auto"},
+						"description": "Set to 'auto' instead of x and y to let the server place the shape in free space, next to nearShapeIds or else next to the existing content, without overlapping anything (not for arrows)",
+					},
+					"nearShapeIds": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "With position 'auto': the related shapes to place the new shape next to (optional)",
+					},
 					"width": map[string]interface{}{
 						"type":        "number",
-						"description": "Width (for rect, ellipse)",
+						"description": "Width (for rect, ellipse, frame and UI components)",
 					},
 					"height": map[string]interface{}{
 						"type":        "number",
-						"description": "Height (for rect, ellipse)",
+						"description": "Height (for rect, ellipse, frame and UI components)",
 					},
 					"radius": map[string]interface{}{
 						"type":        "number",
@@ -83,7 +88,7 @@ func GetAnthropicTools() []map[string]interface{} {
 					},
 					"text": map[string]interface{}{
 						"type":        "string",
-						"description": "Text content (for text shapes)",
+						"description": "Text content (for text shapes) or label (for button, navbar brand and card title)",
 					},
 					"fontSize": map[string]interface{}{
 						"type":        "number",
@@ -130,6 +135,20 @@ func GetAnthropicTools() []map[string]interface{} {
 						"type":        "number",
 						"description": "Size of arrow head (default: 12)",
 					},
+					"variant": map[string]interface{}{
+						"type":        "string",
+						"enum":        []string{"primary", "secondary", "ghost"},
+						"description": "Visual style for button shapes (default: primary)",
+					},
+					"placeholder": map[string]interface{}{
+						"type":        "string",
+						"description": "Placeholder text for input shapes",
+					},
+					"items": map[string]interface{}{
+						"type":        "array",
+						"items":       map[string]interface{}{"type": "string"},
+						"description": "Link labels for navbar shapes, or body lines for card shapes",
+					},
 				},
 				"required": []string{"boardId", "shapeType"},
 			},
@@ -256,10 +275,11 @@ func GetAnthropicTools() []map[string]interface{} {
 			},
 		},
 	}
+	return append(tools, anthropicToolSchemas()...)
 }
 
 func GetOpenAITools() []map[string]interface{} {
-	return []map[string]interface{}{
+	tools := []map[string]interface{}{
 		{
 			"type": "function",
 			"function": map[string]interface{}{
@@ -281,7 +301,7 @@ func GetOpenAITools() []map[string]interface{} {
 			"type": "function",
 			"function": map[string]interface{}{
 				"name":        "addShape",
-				"description": "Adds a shape to the board in react konva format. Supports rect, circle, line, arrow, ellipse, polygon, text, pencil, and path (SVG). For complex shapes like animals, break them down into multiple basic shapes. Use 'path' type with SVG path data for complex vector graphics - IMPORTANT: 'data' parameter with SVG path string (e.g., 'M10 10 L90 90 Z') is REQUIRED for path shapes. The shape will appear on the board immediately.",
+				"description": "Adds a shape to the board in react konva format. Supports rect, circle, line, arrow, ellipse, polygon, text, pencil, path (SVG), and the wireframe UI components button, input, navbar and card. For complex shapes like animals, break them down into multiple basic shapes. Use 'path' type with SVG path data for complex vector graphics - IMPORTANT: 'data' parameter with SVG path string (e.g., 'M10 10 L90 90 Z') is REQUIRED for path shapes. The shape will appear on the board immediately.",
 				"parameters": map[string]interface{}{
 					"type": "object",
 					"properties": map[string]interface{}{
@@ -291,8 +311,8 @@ func GetOpenAITools() []map[string]interface{} {
 						},
 						"shapeType": map[string]interface{}{
 							"type":        "string",
-							"enum":        []string{"rect", "circle", "line", "arrow", "ellipse", "polygon", "text", "pencil", "path", "frame"},
-							"description": "Type of shape to create. Use 'path' for SVG path shapes. Use 'frame' for grouping containers with labels.",
+							"enum":        []string{"rect", "circle", "line", "arrow", "ellipse", "polygon", "text", "pencil", "path", "frame", "button", "input", "navbar", "card"},
+							"description": "Type of shape to create. Use 'path' for SVG path shapes. Use 'frame' for grouping containers with labels. Use 'button', 'input', 'navbar' and 'card' for wireframe UI components.",
 						},
 						"x": map[string]interface{}{
 							"type":        "number",
@@ -302,13 +322,23 @@ func GetOpenAITools() []map[string]interface{} {
 							"type":        "number",
 							"description": "Y coordinate (required for most shapes)",
 						},
+						"position": map[string]interface{}{
+							"type":        "string",
+							"enum":        []string{"auto"},
+							"description": "Set to 'auto' instead of x and y to let the server place the shape in free space, next to nearShapeIds or else next to the existing content, without overlapping anything (not for arrows)",
+						},
+						"nearShapeIds": map[string]interface{}{
+							"type":        "array",
+							"items":       map[string]interface{}{"type": "string"},
+							"description": "With position 'auto': the related shapes to place the new shape next to (optional)",
+						},
 						"width": map[string]interface{}{
 							"type":        "number",
-							"description": "Width (for rect, ellipse)",
+							"description": "Width (for rect, ellipse, frame and UI components)",
 						},
 						"height": map[string]interface{}{
 							"type":        "number",
-							"description": "Height (for rect, ellipse)",
+							"description": "Height (for rect, ellipse, frame and UI components)",
 						},
 						"radius": map[string]interface{}{
 							"type":        "number",
@@ -328,7 +358,7 @@ func GetOpenAITools() []map[string]interface{} {
 						},
 						"text": map[string]interface{}{
 							"type":        "string",
-							"description": "Text content (for text shapes)",
+							"description": "Text content (for text shapes) or label (for button, navbar brand and card title)",
 						},
 						"fontSize": map[string]interface{}{
 							"type":        "number",
@@ -371,6 +401,20 @@ func GetOpenAITools() []map[string]interface{} {
 							"type":        "number",
 							"description": "Size of arrow head (default: 12)",
 						},
+						"variant": map[string]interface{}{
+							"type":        "string",
+							"enum":        []string{"primary", "secondary", "ghost"},
+							"description": "Visual style for button shapes (default: primary)",
+						},
+						"placeholder": map[string]interface{}{
+							"type":        "string",
+							"description": "Placeholder text for input shapes",
+						},
+						"items": map[string]interface{}{
+							"type":        "array",
+							"items":       map[string]interface{}{"type": "string"},
+							"description": "Link labels for navbar shapes, or body lines for card shapes",
+						},
 					},
 					"required": []string{"boardId", "shapeType"},
 				},
@@ -510,6 +554,7 @@ func GetOpenAITools() []map[string]interface{} {
 			},
 		},
 	}
+	return append(tools, openAIToolSchemas()...)
 }
 
 // GetGeminiTools returns tool definitions in Gemini function calling format
@@ -527,20 +572,16 @@ func GetGroqTools() []map[string]interface{} {
 // Also includes shape data with IDs and numbers so the LLM can identify shapes for updates
 // Each shape has a numbered badge on the image that matches the "number" field in the shapes array
 // Uses caching to avoid re-annotating images when shapes haven't changed
-func GetBoardDataHandler(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+func (d *ToolDeps) GetBoardDataHandler(ctx context.Context, input map[string]interface{}) (interface{}, error) {
 	boardId, ok := input["boardId"].(string)
 	if !ok {
-		return nil, fmt.Errorf("boardId is required")
+		return nil, llmHandlers.InvalidToolInput("boardId is required")
 	}
 
 	// Get StreamingContext from context to extract userId
-	streamCtxValue := ctx.Value("streamingContext")
-	if streamCtxValue == nil {
-		return nil, fmt.Errorf("streaming context not available")
-	}
-	streamCtx, ok := streamCtxValue.(*llmHandlers.StreamingContext)
-	if !ok {
-		return nil, fmt.Errorf("invalid streaming context type")
+	streamCtx, err := getStreamingContext(ctx)
+	if err != nil {
+		return nil, err
 	}
 	userIdUUID, err := uuid.Parse(streamCtx.UserID)
 	if err != nil {
@@ -550,17 +591,16 @@ func GetBoardDataHandler(ctx context.Context, input map[string]interface{}) (int
 	// Get shape data from database first (needed for annotation and caching)
 	boardIdUUID, err := uuid.Parse(boardId)
 	if err != nil {
-		return nil, fmt.Errorf("invalid boardId format: %w", err)
+		return nil, llmHandlers.InvalidToolInput("invalid boardId format: %v", err)
 	}
 
-	boardDataRepo := repo.NewBoardDataRepository(config.DB)
-	shapesData, err := boardDataRepo.GetBoardData(boardIdUUID)
+	shapesData, err := d.BoardData.GetBoardData(boardIdUUID)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get shapes from database: %w", err)
 	}
 
 	// Get the original image
-	boardData, err := GetBoardData(boardId)
+	boardData, err := d.BoardImage(boardId)
 	if err != nil {
 		return nil, fmt.Errorf("failed to get board data: %w", err)
 	}
@@ -571,7 +611,7 @@ func GetBoardDataHandler(ctx context.Context, input map[string]interface{}) (int
 	}
 
 	// Get or create annotated image (uses caching)
-	annotatedImage, err := GetOrCreateAnnotatedImage(userIdUUID, boardId, shapesData, imageBase64)
+	annotatedImage, err := GetOrCreateAnnotatedImage(d.Boards, userIdUUID, boardId, shapesData, imageBase64)
 	if err != nil {
 		// If annotation fails, fall back to original image without numbers
 		fmt.Printf("Warning: Image annotation failed: %v\n", err)
@@ -599,6 +639,9 @@ func GetBoardDataHandler(ctx context.Context, input map[string]interface{}) (int
 		for k, v := range dataMap {
 			shape[k] = v
 		}
+		if shapeData.Locked {
+			shape["locked"] = true
+		}
 
 		shapes = append(shapes, shape)
 	}
@@ -617,26 +660,20 @@ func GetBoardDataHandler(ctx context.Context, input map[string]interface{}) (int
 
 // AddShapeHandler is the handler for the AddShape tool
 // Returns a map with special key "_shapeContent" that will be formatted as shape content blocks
-func AddShapeHandler(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+func (d *ToolDeps) AddShapeHandler(ctx context.Context, input map[string]interface{}) (interface{}, error) {
 	// Validate input is not empty
 	if len(input) == 0 {
-		return nil, fmt.Errorf("tool input is empty - boardId, shapeType, x, and y are required")
+		return nil, llmHandlers.InvalidToolInput("tool input is empty - boardId, shapeType, x, and y are required")
 	}
 
 	// Get StreamingContext from context
-	streamCtxValue := ctx.Value("streamingContext")
-	if streamCtxValue == nil {
-		return nil, fmt.Errorf("streaming context not available - cannot send shape via WebSocket")
-	}
-
-	// Type assert to StreamingContext
-	streamCtx, ok := streamCtxValue.(*llmHandlers.StreamingContext)
-	if !ok {
-		return nil, fmt.Errorf("invalid streaming context type")
+	streamCtx, err := getStreamingContext(ctx)
+	if err != nil {
+		return nil, err
 	}
 
 	// Check if hub and client are available
-	if streamCtx == nil || streamCtx.Hub == nil || streamCtx.Client == nil {
+	if streamCtx.Hub == nil || streamCtx.Client == nil {
 		return nil, fmt.Errorf("WebSocket connection not available - cannot send shape")
 	}
 
@@ -644,12 +681,12 @@ func AddShapeHandler(ctx context.Context, input map[string]interface{}) (interfa
 
 	boardId, ok := input["boardId"].(string)
 	if !ok || boardId == "" {
-		return nil, fmt.Errorf("boardId is required and must be a non-empty string")
+		return nil, llmHandlers.InvalidToolInput("boardId is required and must be a non-empty string")
 	}
 
 	shapeType, ok := input["shapeType"].(string)
 	if !ok || shapeType == "" {
-		return nil, fmt.Errorf("shapeType is required and must be a string")
+		return nil, llmHandlers.InvalidToolInput("shapeType is required and must be a string")
 	}
 
 	// validate shape type
@@ -664,16 +701,30 @@ func AddShapeHandler(ctx context.Context, input map[string]interface{}) (interfa
 		"pencil":  true,
 		"path":    true,
 		"frame":   true,
+		"button":  true,
+		"input":   true,
+		"navbar":  true,
+		"card":    true,
 	}
 	if !validateTypes[shapeType] {
-		return nil, fmt.Errorf("invalid shape type: %s", shapeType)
+		return nil, llmHandlers.InvalidToolInput("invalid shape type: %s", shapeType)
 	}
 
 	// Extract coordinates based on shape type
 	var x, y float64
 	var hasXY bool
+	position, _ := input["position"].(string)
+	autoPlace := position == "auto"
+	if position != "" && !autoPlace {
+		return nil, llmHandlers.InvalidToolInput("position must be \"auto\" when given")
+	}
 
-	if shapeType == "arrow" {
+	if autoPlace && shapeType == "arrow" {
+		return nil, llmHandlers.InvalidToolInput("position \"auto\" is not supported for arrows; give startX, startY, endX and endY")
+	} else if autoPlace {
+		// x and y are found once the shape's size is known
+		hasXY = true
+	} else if shapeType == "arrow" {
 		// Arrows use startX/startY/endX/endY, x/y are optional fallback
 		x, hasXY = input["x"].(float64)
 		if hasXY {
@@ -685,11 +736,11 @@ func AddShapeHandler(ctx context.Context, input map[string]interface{}) (interfa
 		var ok bool
 		x, ok = input["x"].(float64)
 		if !ok {
-			return nil, fmt.Errorf("x coordinate is required and must be a number")
+			return nil, llmHandlers.InvalidToolInput("x coordinate is required and must be a number")
 		}
 		y, ok = input["y"].(float64)
 		if !ok {
-			return nil, fmt.Errorf("y coordinate is required and must be a number")
+			return nil, llmHandlers.InvalidToolInput("y coordinate is required and must be a number")
 		}
 		hasXY = true
 	}
@@ -745,7 +796,7 @@ func AddShapeHandler(ctx context.Context, input map[string]interface{}) (interfa
 		} else if hasXY {
 			startX = x
 		} else {
-			return nil, fmt.Errorf("arrow requires startX or x coordinate")
+			return nil, llmHandlers.InvalidToolInput("arrow requires startX or x coordinate")
 		}
 
 		if sy, ok := input["startY"].(float64); ok {
@@ -753,7 +804,7 @@ func AddShapeHandler(ctx context.Context, input map[string]interface{}) (interfa
 		} else if hasXY {
 			startY = y
 		} else {
-			return nil, fmt.Errorf("arrow requires startY or y coordinate")
+			return nil, llmHandlers.InvalidToolInput("arrow requires startY or y coordinate")
 		}
 		shape["start"] = map[string]interface{}{"x": startX, "y": startY}
 
@@ -792,7 +843,7 @@ func AddShapeHandler(ctx context.Context, input map[string]interface{}) (interfa
 	case "path":
 		data, ok := input["data"].(string)
 		if !ok || data == "" {
-			return nil, fmt.Errorf("'data' property with SVG path string (e.g., 'M10 10 L90 90 Z') is required for path shapes")
+			return nil, llmHandlers.InvalidToolInput("'data' property with SVG path string (e.g., 'M10 10 L90 90 Z') is required for path shapes")
 		}
 		shape["data"] = data
 	case "frame":
@@ -805,6 +856,8 @@ func AddShapeHandler(ctx context.Context, input map[string]interface{}) (interfa
 		if name, ok := input["name"].(string); ok && name != "" {
 			shape["name"] = name
 		}
+	case "button", "input", "navbar", "card":
+		applyUIComponentProps(shape, shapeType, input)
 	}
 
 	// Add styling properties (optional)
@@ -818,13 +871,26 @@ func AddShapeHandler(ctx context.Context, input map[string]interface{}) (interfa
 		shape["strokeWidth"] = strokeWidth
 	}
 
+	if autoPlace {
+		x, y, err = d.autoPlaceShape(ctx, boardId, shape, stringSlice(input["nearShapeIds"]))
+		if err != nil {
+			return nil, err
+		}
+	}
+	grid := d.boardSnapGrid(streamCtx.UserID, boardId)
+	if grid > 0 {
+		SnapShapeMap(shape, grid)
+		x, _ = shape["x"].(float64)
+		y, _ = shape["y"].(float64)
+	}
+
 	// Emit WebSocket event
-	libraries.SendShapeCreatedMessage(streamCtx.Hub, streamCtx.Client, boardId, shape)
+	sendShapeCreated(ctx, streamCtx, boardId, shape)
 
 	// Invalidate the annotated image cache since a new shape was added
 	if boardIdUUID, err := uuid.Parse(boardId); err == nil {
 		if userIdUUID, err := uuid.Parse(streamCtx.UserID); err == nil {
-			if err := InvalidateAnnotatedImageCache(userIdUUID, boardIdUUID); err != nil {
+			if err := InvalidateAnnotatedImageCache(d.Boards, userIdUUID, boardIdUUID); err != nil {
 				// Log but don't fail - cache invalidation is not critical
 				fmt.Printf("Warning: failed to invalidate annotated image cache: %v\n", err)
 			}
@@ -832,40 +898,38 @@ func AddShapeHandler(ctx context.Context, input map[string]interface{}) (interfa
 	}
 
 	// Return success response
-	return map[string]interface{}{
+	result := map[string]interface{}{
 		"success": true,
 		"shapeId": shape["id"],
 		"message": fmt.Sprintf("Successfully created %s shape at (%.2f, %.2f)", shapeType, x, y),
 		"shape":   shape,
-	}, nil
+	}
+	if grid > 0 {
+		result["snappedToGrid"] = grid
+	}
+	return result, nil
 }
 
 // RenameBoardHandler is the handler for the RenameBoard tool
-func RenameBoardHandler(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+func (d *ToolDeps) RenameBoardHandler(ctx context.Context, input map[string]interface{}) (interface{}, error) {
 	boardIdStr, ok := input["boardId"].(string)
 	if !ok {
-		return nil, fmt.Errorf("boardId is required and must be a string")
+		return nil, llmHandlers.InvalidToolInput("boardId is required and must be a string")
 	}
 	boardId, err := uuid.Parse(boardIdStr)
 	if err != nil {
-		return nil, fmt.Errorf("invalid boardId: %w", err)
+		return nil, llmHandlers.InvalidToolInput("invalid boardId: %v", err)
 	}
 
 	newName, ok := input["newName"].(string)
 	if !ok {
-		return nil, fmt.Errorf("newName is required and must be a string")
+		return nil, llmHandlers.InvalidToolInput("newName is required and must be a string")
 	}
 
 	// Get StreamingContext from context
-	streamCtxValue := ctx.Value("streamingContext")
-	if streamCtxValue == nil {
-		return nil, fmt.Errorf("streaming context not available - cannot send board renamed via WebSocket")
-	}
-
-	// Type assert to StreamingContext
-	streamCtx, ok := streamCtxValue.(*llmHandlers.StreamingContext)
-	if !ok {
-		return nil, fmt.Errorf("invalid streaming context type")
+	streamCtx, err := getStreamingContext(ctx)
+	if err != nil {
+		return nil, err
 	}
 
 	// Parse userId from StreamingContext
@@ -874,13 +938,20 @@ func RenameBoardHandler(ctx context.Context, input map[string]interface{}) (inte
 		return nil, fmt.Errorf("invalid userId: %w", err)
 	}
 
-	// Access database via config and create repository
-	boardRepo := repo.NewBoardRepository(config.DB)
+	// archived boards are done projects, their titles stay as the user left them
+	if board, err := d.Boards.GetBoardById(userIdUUID, boardId); err == nil && board.IsArchived {
+		return map[string]interface{}{
+			"success": false,
+			"boardId": boardIdStr,
+			"message": "This board is archived, so it keeps its title. Don't rename it.",
+		}, nil
+	}
+
 	// Update the board
 	updatePayload := &models.Board{
 		Title: newName,
 	}
-	err = boardRepo.UpdateBoard(userIdUUID, boardId, updatePayload)
+	err = d.Boards.UpdateBoard(userIdUUID, boardId, updatePayload)
 	if err != nil {
 		return nil, fmt.Errorf("failed to update board: %w", err)
 	}
@@ -888,6 +959,22 @@ func RenameBoardHandler(ctx context.Context, input map[string]interface{}) (inte
 	// Send WebSocket event
 	libraries.SendBoardRenamedMessage(streamCtx.Hub, streamCtx.Client, boardIdStr, newName)
 
+	activity := &models.BoardActivity{
+		BoardID: boardId,
+		UserID:  userIdUUID,
+		Type:    models.ActivityBoardRenamed,
+		Actor:   models.ActivityActorAgent,
+		Summary: fmt.Sprintf("Melina renamed the board to %q", newName),
+	}
+	if err := d.BoardActivity.Create(activity); err != nil {
+		log.Printf("Failed to record board activity: %v", err)
+	} else {
+		libraries.SendBoardActivityMessage(streamCtx.Hub, streamCtx.UserID, &libraries.BoardActivityPayload{
+			BoardId:  boardIdStr,
+			Activity: activity,
+		})
+	}
+
 	// Return success response
 	return map[string]interface{}{
 		"success": true,
@@ -898,26 +985,20 @@ func RenameBoardHandler(ctx context.Context, input map[string]interface{}) (inte
 }
 
 // UpdateShapeHandler is the handler for the updateShape tool
-func UpdateShapeHandler(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+func (d *ToolDeps) UpdateShapeHandler(ctx context.Context, input map[string]interface{}) (interface{}, error) {
 	// Validate input is not empty
 	if len(input) == 0 {
-		return nil, fmt.Errorf("tool input is empty - boardId and shapeId are required")
+		return nil, llmHandlers.InvalidToolInput("tool input is empty - boardId and shapeId are required")
 	}
 
 	// Get StreamingContext from context
-	streamCtxValue := ctx.Value("streamingContext")
-	if streamCtxValue == nil {
-		return nil, fmt.Errorf("streaming context not available - cannot send shape update via WebSocket")
-	}
-
-	// Type assert to StreamingContext
-	streamCtx, ok := streamCtxValue.(*llmHandlers.StreamingContext)
-	if !ok {
-		return nil, fmt.Errorf("invalid streaming context type")
+	streamCtx, err := getStreamingContext(ctx)
+	if err != nil {
+		return nil, err
 	}
 
 	// Check if hub and client are available
-	if streamCtx == nil || streamCtx.Hub == nil || streamCtx.Client == nil {
+	if streamCtx.Hub == nil || streamCtx.Client == nil {
 		return nil, fmt.Errorf("WebSocket connection not available - cannot send shape update")
 	}
 
@@ -926,30 +1007,27 @@ func UpdateShapeHandler(ctx context.Context, input map[string]interface{}) (inte
 	// Validate and extract boardId
 	boardIdStr, ok := input["boardId"].(string)
 	if !ok || boardIdStr == "" {
-		return nil, fmt.Errorf("boardId is required and must be a non-empty string")
+		return nil, llmHandlers.InvalidToolInput("boardId is required and must be a non-empty string")
 	}
 
 	boardId, err := uuid.Parse(boardIdStr)
 	if err != nil {
-		return nil, fmt.Errorf("invalid boardId format: %w", err)
+		return nil, llmHandlers.InvalidToolInput("invalid boardId format: %v", err)
 	}
 
 	// Validate and extract shapeId
 	shapeIdStr, ok := input["shapeId"].(string)
 	if !ok || shapeIdStr == "" {
-		return nil, fmt.Errorf("shapeId is required and must be a non-empty string")
+		return nil, llmHandlers.InvalidToolInput("shapeId is required and must be a non-empty string")
 	}
 
 	shapeId, err := uuid.Parse(shapeIdStr)
 	if err != nil {
-		return nil, fmt.Errorf("invalid shapeId format: %w", err)
+		return nil, llmHandlers.InvalidToolInput("invalid shapeId format: %v", err)
 	}
 
-	// Create repository instance
-	boardDataRepo := repo.NewBoardDataRepository(config.DB)
-
 	// Retrieve all board data to find the shape
-	boardDataList, err := boardDataRepo.GetBoardData(boardId)
+	boardDataList, err := d.BoardData.GetBoardData(boardId)
 	if err != nil {
 		return nil, fmt.Errorf("failed to retrieve board data: %w", err)
 	}
@@ -964,7 +1042,10 @@ func UpdateShapeHandler(ctx context.Context, input map[string]interface{}) (inte
 	}
 
 	if existingBoardData == nil {
-		return nil, fmt.Errorf("shape with id %s not found on board", shapeIdStr)
+		return nil, llmHandlers.InvalidToolInput("shape with id %s not found on board", shapeIdStr)
+	}
+	if existingBoardData.Locked {
+		return lockedShapeResult(shapeIdStr), nil
 	}
 
 	// Parse existing shape data from JSON
@@ -1101,15 +1182,17 @@ func UpdateShapeHandler(ctx context.Context, input map[string]interface{}) (inte
 		shape.Name = getString("name")
 	}
 
+	SnapShape(shape, d.boardSnapGrid(streamCtx.UserID, boardIdStr))
+
 	// Save updated shape to database
-	err = boardDataRepo.SaveShapeData(boardId, shape)
+	err = d.BoardData.SaveShapeData(boardId, shape)
 	if err != nil {
 		return nil, fmt.Errorf("failed to save updated shape: %w", err)
 	}
 
 	// Invalidate the annotated image cache since shape was updated
 	if userIdUUID, err := uuid.Parse(streamCtx.UserID); err == nil {
-		if err := InvalidateAnnotatedImageCache(userIdUUID, boardId); err != nil {
+		if err := InvalidateAnnotatedImageCache(d.Boards, userIdUUID, boardId); err != nil {
 			// Log but don't fail - cache invalidation is not critical
 			fmt.Printf("Warning: failed to invalidate annotated image cache: %v\n", err)
 		}
@@ -1175,26 +1258,25 @@ func UpdateShapeHandler(ctx context.Context, input map[string]interface{}) (inte
 
 // GetShapeDetailsHandler fetches full details of a shape by its ID
 // Used when the LLM needs to know current properties before modifying (e.g., "make it twice as big")
-func GetShapeDetailsHandler(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+func (d *ToolDeps) GetShapeDetailsHandler(ctx context.Context, input map[string]interface{}) (interface{}, error) {
 	shapeIdStr, ok := input["shapeId"].(string)
 	if !ok || shapeIdStr == "" {
-		return nil, fmt.Errorf("shapeId is required and must be a non-empty string")
+		return nil, llmHandlers.InvalidToolInput("shapeId is required and must be a non-empty string")
 	}
 
 	shapeId, err := uuid.Parse(shapeIdStr)
 	if err != nil {
-		return nil, fmt.Errorf("invalid shapeId format: %w", err)
+		return nil, llmHandlers.InvalidToolInput("invalid shapeId format: %v", err)
 	}
 
 	// Fetch shape from database
-	boardDataRepo := repo.NewBoardDataRepository(config.DB)
-	shapes, err := boardDataRepo.GetShapesByUUIDs([]uuid.UUID{shapeId})
+	shapes, err := d.BoardData.GetShapesByUUIDs([]uuid.UUID{shapeId})
 	if err != nil {
 		return nil, fmt.Errorf("failed to fetch shape: %w", err)
 	}
 
 	if len(shapes) == 0 {
-		return nil, fmt.Errorf("shape with id %s not found", shapeIdStr)
+		return nil, llmHandlers.InvalidToolInput("shape with id %s not found", shapeIdStr)
 	}
 
 	shape := shapes[0]
@@ -1216,64 +1298,69 @@ func GetShapeDetailsHandler(ctx context.Context, input map[string]interface{}) (
 	for k, v := range dataMap {
 		result[k] = v
 	}
+	if shape.Locked {
+		result["locked"] = true
+	}
 
 	return result, nil
 }
 
 // DeleteShapeHandler deletes a shape from the board
-func DeleteShapeHandler(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+func (d *ToolDeps) DeleteShapeHandler(ctx context.Context, input map[string]interface{}) (interface{}, error) {
 	// Validate input
 	if len(input) == 0 {
-		return nil, fmt.Errorf("tool input is empty - boardId and shapeId are required")
+		return nil, llmHandlers.InvalidToolInput("tool input is empty - boardId and shapeId are required")
 	}
 
 	// Get StreamingContext from context
-	streamCtxValue := ctx.Value("streamingContext")
-	if streamCtxValue == nil {
-		return nil, fmt.Errorf("streaming context not available - cannot send shape deletion via WebSocket")
-	}
-
-	streamCtx, ok := streamCtxValue.(*llmHandlers.StreamingContext)
-	if !ok {
-		return nil, fmt.Errorf("invalid streaming context type")
+	streamCtx, err := getStreamingContext(ctx)
+	if err != nil {
+		return nil, err
 	}
 
-	if streamCtx == nil || streamCtx.Hub == nil || streamCtx.Client == nil {
+	if streamCtx.Hub == nil || streamCtx.Client == nil {
 		return nil, fmt.Errorf("WebSocket connection not available - cannot send shape deletion")
 	}
 
 	// Validate boardId
 	boardIdStr, ok := input["boardId"].(string)
 	if !ok || boardIdStr == "" {
-		return nil, fmt.Errorf("boardId is required and must be a non-empty string")
+		return nil, llmHandlers.InvalidToolInput("boardId is required and must be a non-empty string")
 	}
 
 	boardId, err := uuid.Parse(boardIdStr)
 	if err != nil {
-		return nil, fmt.Errorf("invalid boardId format: %w", err)
+		return nil, llmHandlers.InvalidToolInput("invalid boardId format: %v", err)
 	}
 
 	// Validate shapeId
 	shapeIdStr, ok := input["shapeId"].(string)
 	if !ok || shapeIdStr == "" {
-		return nil, fmt.Errorf("shapeId is required and must be a non-empty string")
+		return nil, llmHandlers.InvalidToolInput("shapeId is required and must be a non-empty string")
 	}
 
 	shapeId, err := uuid.Parse(shapeIdStr)
 	if err != nil {
-		return nil, fmt.Errorf("invalid shapeId format: %w", err)
+		return nil, llmHandlers.InvalidToolInput("invalid shapeId format: %v", err)
+	}
+
+	shapes, err := d.BoardData.GetShapesByUUIDs([]uuid.UUID{shapeId})
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch shape: %w", err)
+	}
+	if len(shapes) > 0 && shapes[0].Locked {
+		return lockedShapeResult(shapeIdStr), nil
 	}
 
 	// Delete from database
-	boardDataRepo := repo.NewBoardDataRepository(config.DB)
-	err = boardDataRepo.DeleteShape(boardId, shapeId)
+	err = d.BoardData.DeleteShape(boardId, shapeId)
 	if err != nil {
 		return nil, fmt.Errorf("failed to delete shape: %w", err)
 	}
 
 	// Invalidate annotated image cache
 	if userIdUUID, err := uuid.Parse(streamCtx.UserID); err == nil {
-		if err := InvalidateAnnotatedImageCache(userIdUUID, boardId); err != nil {
+		if err := InvalidateAnnotatedImageCache(d.Boards, userIdUUID, boardId); err != nil {
 			fmt.Printf("Warning: failed to invalidate annotated image cache: %v\n", err)
 		}
 	}
@@ -1288,29 +1375,36 @@ func DeleteShapeHandler(ctx context.Context, input map[string]interface{}) (inte
 	}, nil
 }
 
-// RegisterAllTools registers all tools with the toolHandlers registry
-func RegisterAllTools() {
-	llmHandlers.RegisterTool("getBoardData", func(ctx context.Context, input map[string]interface{}) (interface{}, error) {
-		return GetBoardDataHandler(ctx, input)
-	})
-
-	llmHandlers.RegisterTool("addShape", func(ctx context.Context, input map[string]interface{}) (interface{}, error) {
-		return AddShapeHandler(ctx, input)
-	})
-
-	llmHandlers.RegisterTool("renameBoard", func(ctx context.Context, input map[string]interface{}) (interface{}, error) {
-		return RenameBoardHandler(ctx, input)
-	})
-
-	llmHandlers.RegisterTool("updateShape", func(ctx context.Context, input map[string]interface{}) (interface{}, error) {
-		return UpdateShapeHandler(ctx, input)
-	})
-
-	llmHandlers.RegisterTool("getShapeDetails", func(ctx context.Context, input map[string]interface{}) (interface{}, error) {
-		return GetShapeDetailsHandler(ctx, input)
-	})
-
-	llmHandlers.RegisterTool("deleteShape", func(ctx context.Context, input map[string]interface{}) (interface{}, error) {
-		return DeleteShapeHandler(ctx, input)
-	})
+// RegisterTools registers all tools with the toolHandlers registry, running against the given dependencies
+func RegisterTools(d *ToolDeps) {
+	llmHandlers.RegisterTool("getBoardData", d.GetBoardDataHandler)
+	llmHandlers.RegisterTool("addShape", d.AddShapeHandler)
+	llmHandlers.RegisterTool("renameBoard", d.RenameBoardHandler)
+	llmHandlers.RegisterTool("updateShape", d.UpdateShapeHandler)
+	llmHandlers.RegisterTool("getShapeDetails", d.GetShapeDetailsHandler)
+	llmHandlers.RegisterTool("deleteShape", d.DeleteShapeHandler)
+	llmHandlers.RegisterTool("clusterNotes", d.ClusterNotesHandler)
+	llmHandlers.RegisterTool("extractActionItems", d.ExtractActionItemsHandler)
+	llmHandlers.RegisterTool("generateWireframe", d.GenerateWireframeHandler)
+	llmHandlers.RegisterTool("validateDiagram", d.ValidateDiagramHandler)
+	llmHandlers.RegisterTool("generateDDL", d.GenerateDDLHandler)
+	llmHandlers.RegisterTool("referenceBoard", d.ReferenceBoardHandler)
+	llmHandlers.RegisterTool("setBoardCover", d.SetBoardCoverHandler)
+	llmHandlers.RegisterTool("replaceText", d.ReplaceTextHandler)
+	llmHandlers.RegisterTool("getBoardStats", d.GetBoardStatsHandler)
+	llmHandlers.RegisterTool("focusOn", d.FocusOnHandler)
+	llmHandlers.RegisterTool("pointAt", d.PointAtHandler)
+	llmHandlers.RegisterTool("buildPresentation", d.BuildPresentationHandler)
+	llmHandlers.RegisterTool("addTimelineItem", d.AddTimelineItemHandler)
+	llmHandlers.RegisterTool("updateTimelineItem", d.UpdateTimelineItemHandler)
+	llmHandlers.RegisterTool("createColumn", d.CreateColumnHandler)
+	llmHandlers.RegisterTool("moveCard", d.MoveCardHandler)
+
+	// tool arguments are validated against the same schemas the providers are given
+	for _, tool := range GetAnthropicTools() {
+		name, _ := tool["name"].(string)
+		if schema, ok := tool["input_schema"].(map[string]interface{}); ok {
+			llmHandlers.RegisterToolSchema(name, schema)
+		}
+	}
 }
@@ -0,0 +1,33 @@
+package tools
+
+import (
+	"testing"
+
+	llmHandlers "melina-studio-backend/internal/llm_handlers"
+)
+
+func TestOpenAIToolSchemasConvertToStrict(t *testing.T) {
+	for _, tool := range GetOpenAITools() {
+		fn, _ := tool["function"].(map[string]interface{})
+		name, _ := fn["name"].(string)
+		parameters, _ := fn["parameters"].(map[string]interface{})
+		if _, ok := llmHandlers.StrictSchema(parameters); !ok {
+			t.Errorf("%s cannot use strict mode", name)
+		}
+	}
+}
+
+func TestAgentProfilesOnlyNameExistingTools(t *testing.T) {
+	names := make(map[string]bool)
+	for _, tool := range GetAnthropicTools() {
+		name, _ := tool["name"].(string)
+		names[name] = true
+	}
+	for _, profile := range llmHandlers.AgentProfiles {
+		for _, tool := range profile.Tools {
+			if !names[tool] {
+				t.Errorf("profile %s names unknown tool %s", profile.Name, tool)
+			}
+		}
+	}
+}
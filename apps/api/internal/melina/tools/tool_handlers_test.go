@@ -0,0 +1,823 @@
+package tools
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"image"
+	"image/png"
+	"strings"
+	"testing"
+
+	"melina-studio-backend/internal/libraries"
+	llmHandlers "melina-studio-backend/internal/llm_handlers"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// fakeBoardRepo keeps boards in memory; methods the tools don't call panic through the nil interface
+type fakeBoardRepo struct {
+	repo.BoardRepoInterface
+	boards map[uuid.UUID]*models.Board
+}
+
+func (r *fakeBoardRepo) GetBoardById(userID uuid.UUID, boardId uuid.UUID) (models.Board, error) {
+	board, ok := r.boards[boardId]
+	if !ok || board.UserID != userID {
+		return models.Board{}, errors.New("record not found")
+	}
+	return *board, nil
+}
+
+func (r *fakeBoardRepo) GetAllBoards(userID uuid.UUID) ([]models.Board, error) {
+	var boards []models.Board
+	for _, board := range r.boards {
+		if board.UserID == userID {
+			boards = append(boards, *board)
+		}
+	}
+	return boards, nil
+}
+
+func (r *fakeBoardRepo) UpdateBoard(userID uuid.UUID, boardId uuid.UUID, update *models.Board) error {
+	board, ok := r.boards[boardId]
+	if !ok || board.UserID != userID {
+		return nil
+	}
+	if update.Title != "" {
+		board.Title = update.Title
+	}
+	board.AnnotatedImageHash = update.AnnotatedImageHash
+	return nil
+}
+
+func (r *fakeBoardRepo) SetBoardCover(userID uuid.UUID, boardId uuid.UUID, cover models.BoardCover) error {
+	board, ok := r.boards[boardId]
+	if !ok || board.UserID != userID {
+		return nil
+	}
+	if cover.CoverImage != nil {
+		board.CoverImage = *cover.CoverImage
+	}
+	if cover.Icon != nil {
+		board.Icon = *cover.Icon
+	}
+	if cover.Description != nil {
+		board.Description = *cover.Description
+	}
+	return nil
+}
+
+func (r *fakeBoardRepo) SetBoardTimeline(userID uuid.UUID, boardId uuid.UUID, timeline models.BoardTimeline) error {
+	board, ok := r.boards[boardId]
+	if !ok || board.UserID != userID {
+		return nil
+	}
+	if timeline.Origin != nil {
+		board.TimelineOrigin = *timeline.Origin
+	}
+	if timeline.OriginX != nil {
+		board.TimelineOriginX = *timeline.OriginX
+	}
+	if timeline.DayWidth != nil {
+		board.TimelineDayWidth = *timeline.DayWidth
+	}
+	return nil
+}
+
+// fakeBoardDataRepo keeps the shapes of every board in memory
+type fakeBoardDataRepo struct {
+	repo.BoardDataRepoInterface
+	shapes []models.BoardData
+}
+
+func (r *fakeBoardDataRepo) add(t *testing.T, boardId uuid.UUID, shapeType models.Type, data map[string]interface{}) string {
+	t.Helper()
+	bytes, err := json.Marshal(data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	id := uuid.New()
+	r.shapes = append(r.shapes, models.BoardData{UUID: id, BoardId: boardId, Type: shapeType, Data: bytes})
+	return id.String()
+}
+
+func (r *fakeBoardDataRepo) get(id string) map[string]interface{} {
+	for _, shape := range r.shapes {
+		if shape.UUID.String() == id {
+			var data map[string]interface{}
+			_ = json.Unmarshal(shape.Data, &data)
+			return data
+		}
+	}
+	return nil
+}
+
+func (r *fakeBoardDataRepo) lock(id string) {
+	for i := range r.shapes {
+		if r.shapes[i].UUID.String() == id {
+			r.shapes[i].Locked = true
+		}
+	}
+}
+
+func (r *fakeBoardDataRepo) GetBoardData(boardId uuid.UUID) ([]models.BoardData, error) {
+	var shapes []models.BoardData
+	for _, shape := range r.shapes {
+		if shape.BoardId == boardId {
+			shapes = append(shapes, shape)
+		}
+	}
+	return shapes, nil
+}
+
+func (r *fakeBoardDataRepo) GetShapesByType(boardId uuid.UUID, shapeType models.Type) ([]models.BoardData, error) {
+	var shapes []models.BoardData
+	for _, shape := range r.shapes {
+		if shape.BoardId == boardId && shape.Type == shapeType {
+			shapes = append(shapes, shape)
+		}
+	}
+	return shapes, nil
+}
+
+func (r *fakeBoardDataRepo) GetShapesByUUIDs(ids []uuid.UUID) ([]models.BoardData, error) {
+	var shapes []models.BoardData
+	for _, shape := range r.shapes {
+		for _, id := range ids {
+			if shape.UUID == id {
+				shapes = append(shapes, shape)
+			}
+		}
+	}
+	return shapes, nil
+}
+
+func (r *fakeBoardDataRepo) SaveShapeData(boardId uuid.UUID, shape *models.Shape) error {
+	var data map[string]interface{}
+	bytes, _ := json.Marshal(shape)
+	if err := json.Unmarshal(bytes, &data); err != nil {
+		return err
+	}
+	delete(data, "id")
+	delete(data, "type")
+	bytes, _ = json.Marshal(data)
+
+	id := uuid.MustParse(shape.ID)
+	for i := range r.shapes {
+		if r.shapes[i].UUID == id {
+			r.shapes[i].Data = bytes
+			return nil
+		}
+	}
+	r.shapes = append(r.shapes, models.BoardData{UUID: id, BoardId: boardId, Type: models.Type(shape.Type), Data: bytes})
+	return nil
+}
+
+func (r *fakeBoardDataRepo) UpdateShapeData(boardId uuid.UUID, shapeId uuid.UUID, data datatypes.JSON) error {
+	for i := range r.shapes {
+		if r.shapes[i].UUID == shapeId && r.shapes[i].BoardId == boardId {
+			r.shapes[i].Data = data
+		}
+	}
+	return nil
+}
+
+func (r *fakeBoardDataRepo) UpdateShapesData(boardId uuid.UUID, updates map[uuid.UUID]datatypes.JSON) error {
+	for id, data := range updates {
+		if err := r.UpdateShapeData(boardId, id, data); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (r *fakeBoardDataRepo) DeleteShape(boardId uuid.UUID, shapeId uuid.UUID) error {
+	kept := r.shapes[:0]
+	for _, shape := range r.shapes {
+		if shape.UUID != shapeId || shape.BoardId != boardId {
+			kept = append(kept, shape)
+		}
+	}
+	r.shapes = kept
+	return nil
+}
+
+func (r *fakeBoardDataRepo) SetSlideOrder(boardId uuid.UUID, frameIds []uuid.UUID) error {
+	for i := range r.shapes {
+		if r.shapes[i].BoardId != boardId {
+			continue
+		}
+		r.shapes[i].SlideOrder = nil
+		for position, id := range frameIds {
+			if r.shapes[i].UUID == id {
+				r.shapes[i].SlideOrder = &position
+			}
+		}
+	}
+	return nil
+}
+
+type fakeBoardActivityRepo struct {
+	repo.BoardActivityRepoInterface
+	created []*models.BoardActivity
+}
+
+func (r *fakeBoardActivityRepo) Create(activities ...*models.BoardActivity) error {
+	r.created = append(r.created, activities...)
+	return nil
+}
+
+// toolTest is a board of a user with fake dependencies and a running chat turn
+type toolTest struct {
+	t          *testing.T
+	deps       *ToolDeps
+	boards     *fakeBoardRepo
+	data       *fakeBoardDataRepo
+	activities *fakeBoardActivityRepo
+	userID     uuid.UUID
+	boardID    uuid.UUID
+	ctx        context.Context
+	activity   *llmHandlers.ToolActivity
+}
+
+func newToolTest(t *testing.T) *toolTest {
+	t.Helper()
+	userID, boardID := uuid.New(), uuid.New()
+	tt := &toolTest{
+		t:          t,
+		boards:     &fakeBoardRepo{boards: map[uuid.UUID]*models.Board{boardID: {UUID: boardID, UserID: userID, Title: "Roadmap", AnnotatedImageHash: "cached"}}},
+		data:       &fakeBoardDataRepo{},
+		activities: &fakeBoardActivityRepo{},
+		userID:     userID,
+		boardID:    boardID,
+	}
+	tt.deps = &ToolDeps{
+		Boards:        tt.boards,
+		BoardData:     tt.data,
+		BoardActivity: tt.activities,
+		Embed: func(ctx context.Context, texts []string) ([][]float64, error) {
+			return nil, errors.New("no embeddings in tests")
+		},
+		BoardImage: func(boardId string) (map[string]interface{}, error) {
+			return map[string]interface{}{"boardId": boardId, "image": testPNG(t), "format": "png"}, nil
+		},
+		StoreImage: func(ctx context.Context, key string, data []byte) (string, error) {
+			return "https://storage.example/" + key, nil
+		},
+	}
+
+	hub := libraries.NewHub()
+	go hub.Run()
+	client, stop := libraries.NewRelayClient(userID.String(), func(string) {})
+	t.Cleanup(stop)
+	ctx := llmHandlers.WithStreamingContext(context.Background(), &llmHandlers.StreamingContext{
+		Hub:     hub,
+		Client:  client,
+		BoardId: boardID.String(),
+		UserID:  userID.String(),
+	})
+	tt.ctx, tt.activity = llmHandlers.WithToolActivity(ctx)
+	return tt
+}
+
+func testPNG(t *testing.T) string {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, image.NewRGBA(image.Rect(0, 0, 400, 300))); err != nil {
+		t.Fatal(err)
+	}
+	return base64.StdEncoding.EncodeToString(buf.Bytes())
+}
+
+// result checks a handler's return values and returns its result map
+func (tt *toolTest) result(result interface{}, err error) map[string]interface{} {
+	tt.t.Helper()
+	if err != nil {
+		tt.t.Fatalf("unexpected error: %v", err)
+	}
+	m, ok := result.(map[string]interface{})
+	if !ok {
+		tt.t.Fatalf("expected a map result, got %T", result)
+	}
+	return m
+}
+
+func TestToolsNeedStreamingContext(t *testing.T) {
+	deps := newToolTest(t).deps
+	if _, err := deps.AddShapeHandler(context.Background(), map[string]interface{}{"boardId": uuid.NewString(), "shapeType": "rect", "x": 1.0, "y": 1.0}); err == nil {
+		t.Fatal("expected addShape to fail without a streaming context")
+	}
+}
+
+func TestGetBoardDataHandler(t *testing.T) {
+	annotatedImageDir = t.TempDir()
+	tt := newToolTest(t)
+	tt.data.add(t, tt.boardID, models.Rect, map[string]interface{}{"x": 10.0, "y": 10.0, "w": 50.0, "h": 40.0})
+
+	result := tt.result(tt.deps.GetBoardDataHandler(tt.ctx, map[string]interface{}{"boardId": tt.boardID.String()}))
+	if result["_imageContent"] != true || result["image"] == "" {
+		t.Fatalf("expected image content, got %v", result)
+	}
+	if shapes, _ := result["shapes"].([]map[string]interface{}); len(shapes) != 1 {
+		t.Fatalf("expected 1 shape, got %v", result["shapes"])
+	}
+}
+
+func TestAddShapeHandler(t *testing.T) {
+	tt := newToolTest(t)
+	result := tt.result(tt.deps.AddShapeHandler(tt.ctx, map[string]interface{}{
+		"boardId": tt.boardID.String(), "shapeType": "rect", "x": 10.0, "y": 20.0, "width": 100.0, "height": 50.0, "fill": "#fff",
+	}))
+	if result["success"] != true {
+		t.Fatalf("expected success, got %v", result)
+	}
+	created := tt.activity.CreatedShapes()
+	if len(created) != 1 || created[0]["w"] != 100.0 || created[0]["fill"] != "#fff" {
+		t.Fatalf("expected the rect to be recorded for the turn, got %v", created)
+	}
+	if tt.boards.boards[tt.boardID].AnnotatedImageHash != "" {
+		t.Error("expected the annotated image cache to be invalidated")
+	}
+
+	if _, err := tt.deps.AddShapeHandler(tt.ctx, map[string]interface{}{"boardId": tt.boardID.String(), "shapeType": "hexagon", "x": 1.0, "y": 1.0}); err == nil {
+		t.Error("expected an unknown shape type to fail")
+	}
+}
+
+func TestFocusOnHandler(t *testing.T) {
+	tt := newToolTest(t)
+	stored := tt.data.add(t, tt.boardID, models.Rect, map[string]interface{}{"x": 0.0, "y": 0.0, "w": 200.0, "h": 100.0})
+	created := tt.result(tt.deps.AddShapeHandler(tt.ctx, map[string]interface{}{
+		"boardId": tt.boardID.String(), "shapeType": "rect", "x": 400.0, "y": 0.0, "width": 300.0, "height": 100.0,
+	}))["shapeId"].(string)
+
+	result := tt.result(tt.deps.FocusOnHandler(tt.ctx, map[string]interface{}{
+		"boardId": tt.boardID.String(), "shapeIds": []interface{}{stored, created},
+	}))
+	viewport := result["viewport"].(models.Viewport)
+	if viewport.X != 350 || viewport.Y != 50 || viewport.Zoom != 1.6 {
+		t.Errorf("expected the viewport to frame both rects, including the one created this turn, got %+v", viewport)
+	}
+
+	if _, err := tt.deps.FocusOnHandler(tt.ctx, map[string]interface{}{"boardId": tt.boardID.String(), "shapeIds": []interface{}{uuid.NewString()}}); err == nil {
+		t.Error("expected unknown shapes to fail")
+	}
+}
+
+func TestPointAtHandler(t *testing.T) {
+	tt := newToolTest(t)
+	stored := tt.data.add(t, tt.boardID, models.Rect, map[string]interface{}{"x": 0.0, "y": 0.0, "w": 200.0, "h": 100.0})
+
+	result := tt.result(tt.deps.PointAtHandler(tt.ctx, map[string]interface{}{
+		"boardId": tt.boardID.String(), "shapeIds": []interface{}{stored}, "style": "pointer", "durationSeconds": 90.0,
+	}))
+	if result["success"] != true || !strings.Contains(result["message"].(string), "30 seconds") {
+		t.Errorf("expected the pointer to be shown for at most 30 seconds, got %v", result)
+	}
+
+	if _, err := tt.deps.PointAtHandler(tt.ctx, map[string]interface{}{"boardId": tt.boardID.String(), "shapeIds": []interface{}{stored}, "style": "circle"}); err == nil {
+		t.Error("expected an unknown style to fail")
+	}
+	if _, err := tt.deps.PointAtHandler(tt.ctx, map[string]interface{}{"boardId": tt.boardID.String(), "shapeIds": []interface{}{uuid.NewString()}}); err == nil {
+		t.Error("expected unknown shapes to fail")
+	}
+}
+
+func TestBuildPresentationHandler(t *testing.T) {
+	tt := newToolTest(t)
+	if result := tt.result(tt.deps.BuildPresentationHandler(tt.ctx, map[string]interface{}{"boardId": tt.boardID.String()})); result["success"] != false {
+		t.Errorf("expected a board without frames to fail, got %v", result)
+	}
+
+	second := tt.data.add(t, tt.boardID, models.Frame, map[string]interface{}{"name": "Design", "x": 500.0, "y": 0.0, "w": 400.0, "h": 300.0})
+	first := tt.data.add(t, tt.boardID, models.Frame, map[string]interface{}{"name": "Goals", "x": 0.0, "y": 0.0, "w": 400.0, "h": 300.0})
+	result := tt.result(tt.deps.BuildPresentationHandler(tt.ctx, map[string]interface{}{"boardId": tt.boardID.String()}))
+	slides := result["slides"].([]map[string]interface{})
+	if len(slides) != 2 || slides[0]["frameId"] != first || slides[1]["frameId"] != second {
+		t.Fatalf("expected the frames in reading order, got %v", slides)
+	}
+
+	tt.result(tt.deps.BuildPresentationHandler(tt.ctx, map[string]interface{}{"boardId": tt.boardID.String(), "frameIds": []interface{}{second}}))
+	presentation := BuildPresentation(tt.boardID, tt.data.shapes)
+	if len(presentation.Slides) != 1 || presentation.Slides[0].FrameID.String() != second || len(presentation.OtherFrames) != 1 {
+		t.Errorf("expected only the chosen frame to stay a slide, got %+v", presentation)
+	}
+
+	if _, err := tt.deps.BuildPresentationHandler(tt.ctx, map[string]interface{}{"boardId": tt.boardID.String(), "frameIds": []interface{}{uuid.NewString()}}); err == nil {
+		t.Error("expected an unknown frame to fail")
+	}
+}
+
+func TestTimelineItemHandlers(t *testing.T) {
+	tt := newToolTest(t)
+	bar := tt.result(tt.deps.AddTimelineItemHandler(tt.ctx, map[string]interface{}{
+		"boardId": tt.boardID.String(), "title": "Beta", "startDate": "2026-03-02", "endDate": "2026-03-06", "x": 100.0,
+	}))
+	board := tt.boards.boards[tt.boardID]
+	if board.TimelineOrigin != "2026-03-02" || board.TimelineOriginX != 100 || board.TimelineDayWidth != DefaultTimelineDayWidth {
+		t.Fatalf("expected the first item to set the time scale, got %+v", board)
+	}
+	barShape := bar["shape"].(map[string]interface{})
+	if barShape["x"] != 100.0 || barShape["w"] != 100.0 {
+		t.Errorf("expected a five-day bar at the origin, got %v", barShape)
+	}
+
+	milestone := tt.result(tt.deps.AddTimelineItemHandler(tt.ctx, map[string]interface{}{
+		"boardId": tt.boardID.String(), "kind": "milestone", "title": "Launch", "startDate": "2026-03-12",
+	}))
+	launch := milestone["shape"].(map[string]interface{})
+	if launch["type"] != "milestone" || launch["x"] != 100.0+10*20.0-milestoneSize/2 {
+		t.Errorf("expected the milestone centered on its date, got %v", launch)
+	}
+	if launch["y"].(float64) <= barShape["y"].(float64) {
+		t.Errorf("expected the milestone on the row below the bar, got %v", launch)
+	}
+
+	barID := bar["shapeId"].(string)
+	tt.result(tt.deps.UpdateTimelineItemHandler(tt.ctx, map[string]interface{}{
+		"boardId": tt.boardID.String(), "shapeId": barID, "startDate": "2026-03-04", "endDate": "2026-03-04",
+	}))
+	if data := tt.data.get(barID); data["x"] != 140.0 || data["w"] != 20.0 || data["startDate"] != "2026-03-04" {
+		t.Errorf("expected the rescheduled bar to move and shrink, got %v", data)
+	}
+
+	if _, err := tt.deps.AddTimelineItemHandler(tt.ctx, map[string]interface{}{
+		"boardId": tt.boardID.String(), "title": "Backwards", "startDate": "2026-03-04", "endDate": "2026-03-01",
+	}); err == nil {
+		t.Error("expected an end before the start to fail")
+	}
+	tt.data.lock(barID)
+	if result := tt.result(tt.deps.UpdateTimelineItemHandler(tt.ctx, map[string]interface{}{
+		"boardId": tt.boardID.String(), "shapeId": barID, "title": "Renamed",
+	})); result["locked"] != true {
+		t.Errorf("expected a locked item to be reported, got %v", result)
+	}
+}
+
+func TestKanbanHandlers(t *testing.T) {
+	tt := newToolTest(t)
+	todo := tt.result(tt.deps.CreateColumnHandler(tt.ctx, map[string]interface{}{"boardId": tt.boardID.String(), "name": "To do", "x": 0.0, "y": 0.0}))
+	done := tt.result(tt.deps.CreateColumnHandler(tt.ctx, map[string]interface{}{"boardId": tt.boardID.String(), "name": "Done"}))
+	if todo["status"] != "to do" || done["status"] != "done" {
+		t.Fatalf("expected the statuses to default to the names, got %v and %v", todo, done)
+	}
+	if column := tt.data.get(done["columnId"].(string)); column["x"] != 340.0 || column["y"] != 0.0 || column["kanbanStatus"] != "done" {
+		t.Errorf("expected the second column right of the first, got %v", column)
+	}
+	if _, err := tt.deps.CreateColumnHandler(tt.ctx, map[string]interface{}{"boardId": tt.boardID.String(), "name": "DONE"}); err == nil {
+		t.Error("expected a second column with the same status to fail")
+	}
+
+	first := tt.data.add(t, tt.boardID, models.ActionItemCard, map[string]interface{}{"text": "Plan", "status": "to do", "x": 30.0, "y": 60.0, "w": 240.0, "h": 110.0})
+	second := tt.data.add(t, tt.boardID, models.ActionItemCard, map[string]interface{}{"text": "Ship", "status": "to do", "x": 30.0, "y": 190.0, "w": 240.0, "h": 110.0})
+	tt.result(tt.deps.MoveCardHandler(tt.ctx, map[string]interface{}{"boardId": tt.boardID.String(), "cardId": first, "column": "Done"}))
+	tt.result(tt.deps.MoveCardHandler(tt.ctx, map[string]interface{}{"boardId": tt.boardID.String(), "cardId": second, "column": done["columnId"]}))
+	if card := tt.data.get(first); card["status"] != "done" || card["x"] != 370.0 || card["y"] != 60.0 {
+		t.Errorf("expected the first card at the top of Done, got %v", card)
+	}
+	if card := tt.data.get(second); card["status"] != "done" || card["y"] != 190.0 {
+		t.Errorf("expected the second card below the first, got %v", card)
+	}
+	if len(tt.activities.created) != 2 || tt.activities.created[0].Type != models.ActivityCardMoved {
+		t.Errorf("expected both moves in the activity feed, got %v", tt.activities.created)
+	}
+
+	if _, err := tt.deps.MoveCardHandler(tt.ctx, map[string]interface{}{"boardId": tt.boardID.String(), "cardId": first, "column": "Blocked"}); err == nil {
+		t.Error("expected an unknown column to fail")
+	}
+	tt.data.lock(second)
+	if result := tt.result(tt.deps.MoveCardHandler(tt.ctx, map[string]interface{}{"boardId": tt.boardID.String(), "cardId": second, "column": "to do"})); result["locked"] != true {
+		t.Errorf("expected a locked card to be reported, got %v", result)
+	}
+}
+
+func TestAddShapeHandlerSnapsToGrid(t *testing.T) {
+	tt := newToolTest(t)
+	tt.boards.boards[tt.boardID].GridSize = 20
+	tt.boards.boards[tt.boardID].SnapToGrid = true
+
+	result := tt.result(tt.deps.AddShapeHandler(tt.ctx, map[string]interface{}{
+		"boardId": tt.boardID.String(), "shapeType": "rect", "x": 33.0, "y": 48.0, "width": 95.0, "height": 41.0,
+	}))
+	shape := result["shape"].(map[string]interface{})
+	if shape["x"] != 40.0 || shape["y"] != 40.0 || shape["w"] != 100.0 || shape["h"] != 40.0 {
+		t.Errorf("expected the rect to snap to the 20px grid, got %v", shape)
+	}
+
+	id := tt.data.add(t, tt.boardID, models.Rect, map[string]interface{}{"x": 0.0, "y": 0.0, "w": 40.0, "h": 40.0})
+	tt.result(tt.deps.UpdateShapeHandler(tt.ctx, map[string]interface{}{"boardId": tt.boardID.String(), "shapeId": id, "x": 71.0}))
+	if data := tt.data.get(id); data["x"] != 80.0 {
+		t.Errorf("expected the moved rect to snap, got %v", data)
+	}
+}
+
+func TestAddShapeHandlerAutoPosition(t *testing.T) {
+	tt := newToolTest(t)
+	stored := tt.data.add(t, tt.boardID, models.Rect, map[string]interface{}{"x": 0.0, "y": 0.0, "w": 200.0, "h": 100.0})
+	tt.result(tt.deps.AddShapeHandler(tt.ctx, map[string]interface{}{
+		"boardId": tt.boardID.String(), "shapeType": "rect", "x": 240.0, "y": 0.0, "width": 100.0, "height": 100.0,
+	}))
+
+	result := tt.result(tt.deps.AddShapeHandler(tt.ctx, map[string]interface{}{
+		"boardId": tt.boardID.String(), "shapeType": "circle", "radius": 50.0, "position": "auto", "nearShapeIds": []interface{}{stored},
+	}))
+	shape := result["shape"].(map[string]interface{})
+	x, y := shape["x"].(float64), shape["y"].(float64)
+	placed := BoundingBox{MinX: x - 50, MinY: y - 50, MaxX: x + 50, MaxY: y + 50}
+	for _, b := range []BoundingBox{{MinX: 0, MinY: 0, MaxX: 200, MaxY: 100}, {MinX: 240, MinY: 0, MaxX: 340, MaxY: 100}} {
+		if placed.MinX < b.MaxX && placed.MaxX > b.MinX && placed.MinY < b.MaxY && placed.MaxY > b.MinY {
+			t.Errorf("auto placed circle %+v overlaps %+v, including the shape created earlier in the turn", placed, b)
+		}
+	}
+
+	if _, err := tt.deps.AddShapeHandler(tt.ctx, map[string]interface{}{"boardId": tt.boardID.String(), "shapeType": "arrow", "position": "auto"}); err == nil {
+		t.Error("expected auto placement of an arrow to fail")
+	}
+	if _, err := tt.deps.AddShapeHandler(tt.ctx, map[string]interface{}{"boardId": tt.boardID.String(), "shapeType": "rect", "position": "auto", "nearShapeIds": []interface{}{uuid.NewString()}}); err == nil {
+		t.Error("expected unknown near shapes to fail")
+	}
+}
+
+func TestRenameBoardHandler(t *testing.T) {
+	tt := newToolTest(t)
+	tt.result(tt.deps.RenameBoardHandler(tt.ctx, map[string]interface{}{"boardId": tt.boardID.String(), "newName": "Q3 Roadmap"}))
+
+	if got := tt.boards.boards[tt.boardID].Title; got != "Q3 Roadmap" {
+		t.Errorf("got title %q", got)
+	}
+	if len(tt.activities.created) != 1 || tt.activities.created[0].Type != models.ActivityBoardRenamed {
+		t.Errorf("expected a rename activity, got %v", tt.activities.created)
+	}
+
+	tt.boards.boards[tt.boardID].IsArchived = true
+	result := tt.result(tt.deps.RenameBoardHandler(tt.ctx, map[string]interface{}{"boardId": tt.boardID.String(), "newName": "Something else"}))
+	if result["success"] != false || tt.boards.boards[tt.boardID].Title != "Q3 Roadmap" {
+		t.Errorf("expected an archived board to keep its title, got %v", result)
+	}
+}
+
+func TestUpdateShapeHandler(t *testing.T) {
+	tt := newToolTest(t)
+	id := tt.data.add(t, tt.boardID, models.Rect, map[string]interface{}{"x": 10.0, "y": 10.0, "w": 50.0, "h": 40.0, "fill": "#000"})
+
+	tt.result(tt.deps.UpdateShapeHandler(tt.ctx, map[string]interface{}{"boardId": tt.boardID.String(), "shapeId": id, "x": 200.0, "width": 80.0}))
+	data := tt.data.get(id)
+	if data["x"] != 200.0 || data["w"] != 80.0 || data["y"] != 10.0 || data["fill"] != "#000" {
+		t.Errorf("expected x and w to change and the rest to be kept, got %v", data)
+	}
+
+	if _, err := tt.deps.UpdateShapeHandler(tt.ctx, map[string]interface{}{"boardId": tt.boardID.String(), "shapeId": uuid.NewString(), "x": 1.0}); err == nil {
+		t.Error("expected an unknown shape to fail")
+	}
+
+	tt.data.lock(id)
+	result := tt.result(tt.deps.UpdateShapeHandler(tt.ctx, map[string]interface{}{"boardId": tt.boardID.String(), "shapeId": id, "x": 5.0}))
+	if result["success"] != false || result["locked"] != true {
+		t.Errorf("expected a locked result, got %v", result)
+	}
+	if data := tt.data.get(id); data["x"] != 200.0 {
+		t.Errorf("expected the locked shape to be kept, got %v", data)
+	}
+}
+
+func TestGetShapeDetailsHandler(t *testing.T) {
+	tt := newToolTest(t)
+	id := tt.data.add(t, tt.boardID, models.Circle, map[string]interface{}{"x": 10.0, "y": 10.0, "r": 25.0})
+
+	result := tt.result(tt.deps.GetShapeDetailsHandler(tt.ctx, map[string]interface{}{"shapeId": id}))
+	if result["type"] != "circle" || result["r"] != 25.0 || result["boardId"] != tt.boardID.String() {
+		t.Errorf("unexpected details %v", result)
+	}
+}
+
+func TestDeleteShapeHandler(t *testing.T) {
+	tt := newToolTest(t)
+	id := tt.data.add(t, tt.boardID, models.Rect, map[string]interface{}{"x": 10.0, "y": 10.0, "w": 50.0, "h": 40.0})
+	tt.data.add(t, tt.boardID, models.Rect, map[string]interface{}{"x": 100.0, "y": 10.0, "w": 50.0, "h": 40.0})
+
+	tt.result(tt.deps.DeleteShapeHandler(tt.ctx, map[string]interface{}{"boardId": tt.boardID.String(), "shapeId": id}))
+	if len(tt.data.shapes) != 1 || tt.data.get(id) != nil {
+		t.Errorf("expected only the other shape to be left, got %d shapes", len(tt.data.shapes))
+	}
+
+	locked := tt.data.shapes[0].UUID.String()
+	tt.data.lock(locked)
+	result := tt.result(tt.deps.DeleteShapeHandler(tt.ctx, map[string]interface{}{"boardId": tt.boardID.String(), "shapeId": locked}))
+	if result["locked"] != true || len(tt.data.shapes) != 1 {
+		t.Errorf("expected the locked shape to be kept, got %v", result)
+	}
+}
+
+func TestReplaceTextHandler(t *testing.T) {
+	tt := newToolTest(t)
+	login := tt.data.add(t, tt.boardID, models.Text, map[string]interface{}{"x": 0.0, "y": 0.0, "text": "Login page"})
+	button := tt.data.add(t, tt.boardID, models.Button, map[string]interface{}{"x": 0.0, "y": 100.0, "text": "login"})
+	locked := tt.data.add(t, tt.boardID, models.Frame, map[string]interface{}{"x": 0.0, "y": 200.0, "name": "Login flow"})
+	tt.data.lock(locked)
+
+	result := tt.result(tt.deps.ReplaceTextHandler(tt.ctx, map[string]interface{}{"boardId": tt.boardID.String(), "find": "login", "replace": "Sign in"}))
+	if result["shapes"] != 2 || result["replacements"] != 2 {
+		t.Errorf("expected 2 replacements in 2 shapes, got %v", result)
+	}
+	if tt.data.get(login)["text"] != "Sign in page" || tt.data.get(button)["text"] != "Sign in" {
+		t.Errorf("expected both texts to be replaced, got %v and %v", tt.data.get(login), tt.data.get(button))
+	}
+	if tt.data.get(locked)["name"] != "Login flow" {
+		t.Errorf("expected the locked frame to be kept, got %v", tt.data.get(locked))
+	}
+	if skipped, _ := result["skippedLocked"].([]string); len(skipped) != 1 {
+		t.Errorf("expected the locked frame to be reported, got %v", result["skippedLocked"])
+	}
+	if len(tt.activities.created) != 1 {
+		t.Errorf("expected one activity entry, got %d", len(tt.activities.created))
+	}
+
+	if _, err := tt.deps.ReplaceTextHandler(tt.ctx, map[string]interface{}{"boardId": tt.boardID.String(), "find": "(", "replace": "x", "regex": true}); err == nil {
+		t.Error("expected an invalid regex to fail")
+	}
+}
+
+func TestClusterNotesHandler(t *testing.T) {
+	tt := newToolTest(t)
+	for i, text := range []string{"pricing too high", "pricing page confusing", "login is slow", "login fails on mobile"} {
+		tt.data.add(t, tt.boardID, models.Text, map[string]interface{}{"x": float64(i * 150), "y": 0.0, "text": text, "fontSize": 16.0})
+	}
+
+	result := tt.result(tt.deps.ClusterNotesHandler(tt.ctx, map[string]interface{}{"boardId": tt.boardID.String(), "clusterCount": 2.0}))
+	if result["method"] != "term_frequency" {
+		t.Errorf("expected the term vector fallback when embeddings fail, got %v", result["method"])
+	}
+	if themes, _ := result["themes"].([]map[string]interface{}); len(themes) != 2 {
+		t.Errorf("expected 2 themes, got %v", result["themes"])
+	}
+	if created := tt.activity.CreatedShapes(); len(created) != 2 || created[0]["type"] != "frame" {
+		t.Errorf("expected 2 frames, got %v", created)
+	}
+}
+
+func TestClusterNotesHandlerKeepsLockedNotes(t *testing.T) {
+	tt := newToolTest(t)
+	var ids []string
+	for i, text := range []string{"pricing too high", "pricing page confusing", "login is slow", "login fails on mobile"} {
+		ids = append(ids, tt.data.add(t, tt.boardID, models.Text, map[string]interface{}{"x": float64(i * 150), "y": 0.0, "text": text, "fontSize": 16.0}))
+	}
+	tt.data.lock(ids[3])
+
+	tt.result(tt.deps.ClusterNotesHandler(tt.ctx, map[string]interface{}{"boardId": tt.boardID.String(), "clusterCount": 2.0}))
+	if data := tt.data.get(ids[3]); data["x"] != 450.0 || data["y"] != 0.0 {
+		t.Errorf("expected the locked note to stay where it was, got %v", data)
+	}
+	if data := tt.data.get(ids[0]); data["x"] == 0.0 && data["y"] == 0.0 {
+		t.Errorf("expected the unlocked notes to be grouped, got %v", data)
+	}
+}
+
+func TestExtractActionItemsHandler(t *testing.T) {
+	tt := newToolTest(t)
+	tt.data.add(t, tt.boardID, models.ActionItemCard, map[string]interface{}{"x": 0.0, "y": 0.0, "w": 240.0, "h": 110.0, "text": "Ship v2"})
+
+	result := tt.result(tt.deps.ExtractActionItemsHandler(tt.ctx, map[string]interface{}{
+		"boardId": tt.boardID.String(),
+		"items": []interface{}{
+			map[string]interface{}{"title": "ship v2"},
+			map[string]interface{}{"title": "Write docs", "assignee": "Sam", "dueDate": "next week"},
+		},
+	}))
+	if created, _ := result["created"].([]map[string]interface{}); len(created) != 1 || created[0]["assignee"] != "Sam" {
+		t.Errorf("expected only Write docs to be created, got %v", result["created"])
+	}
+	if skipped, _ := result["skipped"].([]string); len(skipped) != 1 {
+		t.Errorf("expected the existing card to be skipped, got %v", result["skipped"])
+	}
+	if warnings, _ := result["warnings"].([]string); len(warnings) != 1 {
+		t.Errorf("expected a warning for the bad due date, got %v", result["warnings"])
+	}
+	if len(tt.data.shapes) != 2 {
+		t.Errorf("expected the new card to be saved, got %d shapes", len(tt.data.shapes))
+	}
+}
+
+func TestGenerateWireframeHandler(t *testing.T) {
+	tt := newToolTest(t)
+	result := tt.result(tt.deps.GenerateWireframeHandler(tt.ctx, map[string]interface{}{
+		"boardId": tt.boardID.String(),
+		"screens": []interface{}{
+			map[string]interface{}{
+				"name": "Login",
+				"components": []interface{}{
+					map[string]interface{}{"type": "input", "placeholder": "Email"},
+					map[string]interface{}{"type": "button", "text": "Sign in"},
+				},
+			},
+		},
+	}))
+	if screens, _ := result["screens"].([]map[string]interface{}); len(screens) != 1 {
+		t.Fatalf("expected 1 screen, got %v", result["screens"])
+	}
+	if created := tt.activity.CreatedShapes(); len(created) != 3 {
+		t.Errorf("expected a frame and 2 components, got %d shapes", len(created))
+	}
+}
+
+func TestValidateDiagramHandler(t *testing.T) {
+	tt := newToolTest(t)
+	tt.data.add(t, tt.boardID, models.Rect, map[string]interface{}{"x": 0.0, "y": 0.0, "w": 100.0, "h": 50.0, "text": "Start"})
+	tt.data.add(t, tt.boardID, models.Rect, map[string]interface{}{"x": 300.0, "y": 0.0, "w": 100.0, "h": 50.0})
+	tt.data.add(t, tt.boardID, models.Arrow, map[string]interface{}{
+		"start": map[string]float64{"x": 100, "y": 25},
+		"end":   map[string]float64{"x": 300, "y": 25},
+	})
+
+	result := tt.result(tt.deps.ValidateDiagramHandler(tt.ctx, map[string]interface{}{"boardId": tt.boardID.String()}))
+	if result["nodes"] != 2 || result["connectors"] != 1 {
+		t.Fatalf("expected 2 nodes and 1 connector, got %v", result)
+	}
+	if got := findingTypes(result["findings"].([]DiagramFinding)); got["unlabeled_node"] != 1 || len(got) != 1 {
+		t.Errorf("expected only the unlabeled node, got %v", got)
+	}
+}
+
+func TestGetBoardStatsHandler(t *testing.T) {
+	tt := newToolTest(t)
+	tt.data.add(t, tt.boardID, models.Rect, map[string]interface{}{"x": 0.0, "y": 0.0, "w": 100.0, "h": 50.0, "text": "Start"})
+	end := tt.data.add(t, tt.boardID, models.Rect, map[string]interface{}{"x": 300.0, "y": 0.0, "w": 100.0, "h": 50.0, "text": "End"})
+	alone := tt.data.add(t, tt.boardID, models.Rect, map[string]interface{}{"x": 0.0, "y": 600.0, "w": 100.0, "h": 50.0, "text": "Later"})
+	tt.data.lock(alone)
+	tt.data.add(t, tt.boardID, models.Arrow, map[string]interface{}{
+		"start": map[string]float64{"x": 100, "y": 25},
+		"end":   map[string]float64{"x": 300, "y": 25},
+	})
+	// the second connector leaves End and stops in empty space
+	tt.data.add(t, tt.boardID, models.Arrow, map[string]interface{}{
+		"start": map[string]float64{"x": 400, "y": 25},
+		"end":   map[string]float64{"x": 600, "y": 25},
+	})
+
+	result := tt.result(tt.deps.GetBoardStatsHandler(tt.ctx, map[string]interface{}{"boardId": tt.boardID.String()}))
+	stats := result["stats"].(BoardStats)
+	for name, counts := range map[string][2]int{
+		"shapes":     {stats.TotalShapes, 5},
+		"rects":      {stats.ByType[string(models.Rect)], 3},
+		"arrows":     {stats.ByType[string(models.Arrow)], 2},
+		"locked":     {stats.Locked, 1},
+		"nodes":      {stats.Diagram.Nodes, 3},
+		"connectors": {stats.Diagram.Connectors, 2},
+		"dangling":   {stats.Diagram.Dangling, 1},
+		"components": {stats.Diagram.Components, 2},
+	} {
+		if counts[0] != counts[1] {
+			t.Errorf("expected %d %s, got %d", counts[1], name, counts[0])
+		}
+	}
+	if len(stats.MostConnected) == 0 || stats.MostConnected[0].ShapeID != end || stats.MostConnected[0].Connectors != 2 {
+		t.Errorf("expected End to be the most connected node, got %+v", stats.MostConnected)
+	}
+	if stats.Extent == nil || *stats.Extent != (StatsRect{X: 0, Y: 0, Width: 400, Height: 650}) {
+		t.Errorf("expected the extent to cover the rects, got %+v", stats.Extent)
+	}
+
+	if _, err := tt.deps.GetBoardStatsHandler(tt.ctx, map[string]interface{}{"boardId": "nope"}); err == nil {
+		t.Error("expected an invalid board id to fail")
+	}
+}
+
+func TestGenerateDDLHandler(t *testing.T) {
+	tt := newToolTest(t)
+	tt.data.add(t, tt.boardID, models.Entity, map[string]interface{}{
+		"x": 0.0, "y": 0.0, "name": "users",
+		"columns": []models.EntityColumn{{Name: "id", Type: "uuid", PrimaryKey: true}},
+	})
+
+	result := tt.result(tt.deps.GenerateDDLHandler(tt.ctx, map[string]interface{}{"boardId": tt.boardID.String()}))
+	if sql, _ := result["sql"].(string); !strings.Contains(sql, "CREATE TABLE") || !strings.Contains(sql, "users") {
+		t.Errorf("unexpected DDL %q", result["sql"])
+	}
+}
+
+func TestReferenceBoardHandler(t *testing.T) {
+	tt := newToolTest(t)
+	otherID := uuid.New()
+	tt.boards.boards[otherID] = &models.Board{UUID: otherID, UserID: tt.userID, Title: "Research notes"}
+	tt.data.add(t, otherID, models.Text, map[string]interface{}{"x": 0.0, "y": 0.0, "text": "Users want dark mode"})
+
+	result := tt.result(tt.deps.ReferenceBoardHandler(tt.ctx, map[string]interface{}{"title": "research"}))
+	if result["success"] != true || result["title"] != "Research notes" {
+		t.Fatalf("expected the research board, got %v", result)
+	}
+	if outline, _ := result["outline"].(string); !strings.Contains(outline, "dark mode") {
+		t.Errorf("expected the outline to include the note, got %q", outline)
+	}
+
+	// boards of other users are never found
+	strangerID := uuid.New()
+	tt.boards.boards[strangerID] = &models.Board{UUID: strangerID, UserID: uuid.New(), Title: "Secret"}
+	if _, err := tt.deps.ReferenceBoardHandler(tt.ctx, map[string]interface{}{"referencedBoardId": strangerID.String()}); err == nil {
+		t.Error("expected another user's board to be refused")
+	}
+}
@@ -0,0 +1,68 @@
+package tools
+
+// toolSchema describes a tool once so it can be rendered in every provider format
+// Newer tools are declared this way instead of duplicating the schema per provider
+type toolSchema struct {
+	Name        string
+	Description string
+	Parameters  map[string]interface{}
+}
+
+// toolSchemas lists the tools declared with toolSchema, in the order they are exposed to the LLM
+func toolSchemas() []toolSchema {
+	return []toolSchema{
+		clusterNotesSchema,
+		extractActionItemsSchema,
+		generateWireframeSchema,
+		validateDiagramSchema,
+		generateDDLSchema,
+		referenceBoardSchema,
+		setBoardCoverSchema,
+		replaceTextSchema,
+		getBoardStatsSchema,
+		focusOnSchema,
+		pointAtSchema,
+		buildPresentationSchema,
+		addTimelineItemSchema,
+		updateTimelineItemSchema,
+		createColumnSchema,
+		moveCardSchema,
+	}
+}
+
+// exposedToolSchemas returns toolSchemas followed by the plugin tools and the custom tools
+func exposedToolSchemas() []toolSchema {
+	schemas := append(toolSchemas(), pluginToolSchemas()...)
+	return append(schemas, customToolSchemas()...)
+}
+
+// anthropicToolSchemas renders the exposed tool schemas in Anthropic's input_schema format
+func anthropicToolSchemas() []map[string]interface{} {
+	schemas := exposedToolSchemas()
+	result := make([]map[string]interface{}, 0, len(schemas))
+	for _, s := range schemas {
+		result = append(result, map[string]interface{}{
+			"name":         s.Name,
+			"description":  s.Description,
+			"input_schema": s.Parameters,
+		})
+	}
+	return result
+}
+
+// openAIToolSchemas renders the exposed tool schemas in OpenAI's function calling format
+func openAIToolSchemas() []map[string]interface{} {
+	schemas := exposedToolSchemas()
+	result := make([]map[string]interface{}, 0, len(schemas))
+	for _, s := range schemas {
+		result = append(result, map[string]interface{}{
+			"type": "function",
+			"function": map[string]interface{}{
+				"name":        s.Name,
+				"description": s.Description,
+				"parameters":  s.Parameters,
+			},
+		})
+	}
+	return result
+}
@@ -0,0 +1,505 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"melina-studio-backend/internal/models"
+	"sort"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var validateDiagramSchema = toolSchema{
+	Name:        "validateDiagram",
+	Description: "Checks a flowchart or architecture diagram for common issues: nodes without connectors, connectors that don't touch a node, cycles in a flow that should be acyclic, nodes without labels, and decisions (diamonds) with missing or unlabeled branches. Connectors are arrows and lines; an end attaches to the node it touches. Returns findings with the shapeIds involved so you can report them or fix them with addShape/updateShape.",
+	Parameters: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"boardId": map[string]interface{}{
+				"type":        "string",
+				"description": "The UUID of the board",
+			},
+			"shapeIds": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "Only validate these shapes (optional, defaults to the whole board)",
+			},
+			"acyclic": map[string]interface{}{
+				"type":        "boolean",
+				"description": "Report cycles as errors (default: true). Set to false for diagrams where loops are expected, like state machines or retry flows.",
+			},
+		},
+		"required": []string{"boardId"},
+	},
+}
+
+const (
+	// connector ends within this distance of a node's bounds attach to it
+	diagramAttachTolerance = 24.0
+	// text within this distance of a connector's midpoint labels it
+	diagramEdgeLabelDistance = 60.0
+	diagramMaxFindings       = 50
+)
+
+// diagramNode is a shape that connectors can attach to
+type diagramNode struct {
+	id       string
+	kind     string
+	label    string
+	bounds   BoundingBox
+	decision bool
+}
+
+// diagramEdge is a connector between two nodes; from/to are -1 when the end is not attached
+type diagramEdge struct {
+	id       string
+	from, to int
+	label    string
+}
+
+// DiagramFinding is a single issue found by validateDiagram
+type DiagramFinding struct {
+	Type     string   `json:"type"`
+	Severity string   `json:"severity"`
+	Message  string   `json:"message"`
+	ShapeIds []string `json:"shapeIds"`
+}
+
+// ValidateDiagramHandler inspects the board's connectors and node labels and returns findings
+func (d *ToolDeps) ValidateDiagramHandler(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+	boardIdStr, ok := input["boardId"].(string)
+	if !ok || boardIdStr == "" {
+		return nil, fmt.Errorf("boardId is required and must be a non-empty string")
+	}
+	boardId, err := uuid.Parse(boardIdStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid boardId format: %w", err)
+	}
+
+	acyclic := true
+	if v, ok := input["acyclic"].(bool); ok {
+		acyclic = v
+	}
+
+	shapes, err := d.BoardData.GetBoardData(boardId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shapes from database: %w", err)
+	}
+
+	if wanted := stringSlice(input["shapeIds"]); len(wanted) > 0 {
+		keep := map[string]bool{}
+		for _, id := range wanted {
+			keep[id] = true
+		}
+		filtered := shapes[:0]
+		for _, shape := range shapes {
+			if keep[shape.UUID.String()] {
+				filtered = append(filtered, shape)
+			}
+		}
+		shapes = filtered
+	}
+
+	nodes, edges := buildDiagramGraph(shapes)
+	if len(nodes) == 0 {
+		return map[string]interface{}{
+			"success":  true,
+			"findings": []DiagramFinding{},
+			"message":  "No diagram nodes (rects, circles, ellipses, polygons, cards) found to validate",
+		}, nil
+	}
+
+	findings := validateDiagramGraph(nodes, edges, acyclic)
+	truncated := len(findings) > diagramMaxFindings
+	if truncated {
+		findings = findings[:diagramMaxFindings]
+	}
+
+	errorsCount := 0
+	for _, f := range findings {
+		if f.Severity == "error" {
+			errorsCount++
+		}
+	}
+
+	message := fmt.Sprintf("Checked %d nodes and %d connectors: %d errors, %d warnings", len(nodes), len(edges), errorsCount, len(findings)-errorsCount)
+	if len(findings) == 0 {
+		message = fmt.Sprintf("Checked %d nodes and %d connectors: no issues found", len(nodes), len(edges))
+	}
+
+	return map[string]interface{}{
+		"success":    true,
+		"nodes":      len(nodes),
+		"connectors": len(edges),
+		"findings":   findings,
+		"truncated":  truncated,
+		"message":    message,
+	}, nil
+}
+
+// buildDiagramGraph turns shapes into nodes and connectors
+// Text shapes label the node they sit inside, or the connector whose midpoint they are closest to
+func buildDiagramGraph(shapes []models.BoardData) ([]diagramNode, []diagramEdge) {
+	var nodes []diagramNode
+	var edges []diagramEdge
+	type textShape struct {
+		text   string
+		bounds BoundingBox
+	}
+	var texts []textShape
+	var ends [][2]float64
+
+	for _, shape := range shapes {
+		switch shape.Type {
+		case models.Rect, models.Circle, models.Ellipse, models.Polygon, models.Card, models.ActionItemCard, models.Entity:
+			bounds, data, err := GetShapeBounds(shape, 0)
+			if err != nil {
+				continue
+			}
+			label, _ := data["text"].(string)
+			if name, ok := data["name"].(string); ok && label == "" {
+				label = name
+			}
+			nodes = append(nodes, diagramNode{
+				id:       shape.UUID.String(),
+				kind:     string(shape.Type),
+				label:    strings.TrimSpace(label),
+				bounds:   bounds,
+				decision: shape.Type == models.Polygon && isDiamond(data),
+			})
+		case models.Arrow, models.Line:
+			data, err := shapeDataMap(shape.UUID, string(shape.Type), shape.Data)
+			if err != nil {
+				continue
+			}
+			start, end, ok := connectorEnds(data)
+			if !ok {
+				continue
+			}
+			edges = append(edges, diagramEdge{id: shape.UUID.String(), from: -1, to: -1})
+			ends = append(ends, [2]float64{start[0], start[1]}, [2]float64{end[0], end[1]})
+		case models.Text:
+			bounds, data, err := GetShapeBounds(shape, 0)
+			if err != nil {
+				continue
+			}
+			text, _ := data["text"].(string)
+			if strings.TrimSpace(text) != "" {
+				texts = append(texts, textShape{text: singleLine(text), bounds: bounds})
+			}
+		}
+	}
+
+	for i := range edges {
+		start, end := ends[2*i], ends[2*i+1]
+		edges[i].from = attachNode(nodes, start[0], start[1])
+		edges[i].to = attachNode(nodes, end[0], end[1])
+	}
+
+	for _, t := range texts {
+		// Labels inside a node belong to the smallest node that contains them
+		if n := smallestContainingNode(nodes, t.bounds.MinX, t.bounds.MinY); n >= 0 {
+			if nodes[n].label == "" {
+				nodes[n].label = t.text
+			} else {
+				nodes[n].label += " " + t.text
+			}
+			continue
+		}
+		cx, cy := (t.bounds.MinX+t.bounds.MaxX)/2, (t.bounds.MinY+t.bounds.MaxY)/2
+		best, bestDist := -1, diagramEdgeLabelDistance
+		for i := range edges {
+			start, end := ends[2*i], ends[2*i+1]
+			mx, my := (start[0]+end[0])/2, (start[1]+end[1])/2
+			if d := math.Hypot(cx-mx, cy-my); d <= bestDist {
+				best, bestDist = i, d
+			}
+		}
+		if best >= 0 && edges[best].label == "" {
+			edges[best].label = t.text
+		}
+	}
+
+	return nodes, edges
+}
+
+// validateDiagramGraph runs the checks on an already built graph
+func validateDiagramGraph(nodes []diagramNode, edges []diagramEdge, acyclic bool) []DiagramFinding {
+	findings := []DiagramFinding{}
+	degree := make([]int, len(nodes))
+	outgoing := make([][]int, len(nodes))
+
+	for i, edge := range edges {
+		switch {
+		case edge.from < 0 && edge.to < 0:
+			findings = append(findings, DiagramFinding{
+				Type:     "dangling_connector",
+				Severity: "warning",
+				Message:  "Connector is not attached to any node",
+				ShapeIds: []string{edge.id},
+			})
+			continue
+		case edge.from < 0 || edge.to < 0:
+			attached := edge.from
+			if attached < 0 {
+				attached = edge.to
+			}
+			findings = append(findings, DiagramFinding{
+				Type:     "dangling_connector",
+				Severity: "warning",
+				Message:  fmt.Sprintf("Connector from %s has a loose end", nodeName(nodes[attached])),
+				ShapeIds: []string{edge.id, nodes[attached].id},
+			})
+		}
+		if edge.from >= 0 {
+			degree[edge.from]++
+		}
+		if edge.to >= 0 {
+			degree[edge.to]++
+		}
+		if edge.from >= 0 && edge.to >= 0 {
+			outgoing[edge.from] = append(outgoing[edge.from], i)
+		}
+	}
+
+	for i, node := range nodes {
+		if len(edges) > 0 && degree[i] == 0 {
+			findings = append(findings, DiagramFinding{
+				Type:     "orphan_node",
+				Severity: "warning",
+				Message:  fmt.Sprintf("%s is not connected to anything", nodeName(node)),
+				ShapeIds: []string{node.id},
+			})
+		}
+		if node.label == "" {
+			findings = append(findings, DiagramFinding{
+				Type:     "unlabeled_node",
+				Severity: "warning",
+				Message:  fmt.Sprintf("%s has no label", nodeName(node)),
+				ShapeIds: []string{node.id},
+			})
+		}
+		if !node.decision {
+			continue
+		}
+		if len(outgoing[i]) < 2 {
+			findings = append(findings, DiagramFinding{
+				Type:     "decision_missing_branch",
+				Severity: "error",
+				Message:  fmt.Sprintf("Decision %s has %d outgoing branches, expected at least 2", nodeName(node), len(outgoing[i])),
+				ShapeIds: []string{node.id},
+			})
+		}
+		for _, e := range outgoing[i] {
+			if edges[e].label == "" {
+				findings = append(findings, DiagramFinding{
+					Type:     "unlabeled_decision_branch",
+					Severity: "warning",
+					Message:  fmt.Sprintf("A branch of decision %s has no label (e.g. Yes/No)", nodeName(node)),
+					ShapeIds: []string{edges[e].id, node.id},
+				})
+			}
+		}
+	}
+
+	if acyclic {
+		for _, cycle := range findCycles(len(nodes), edges) {
+			names := make([]string, 0, len(cycle))
+			ids := make([]string, 0, len(cycle))
+			for _, n := range cycle {
+				names = append(names, nodeName(nodes[n]))
+				ids = append(ids, nodes[n].id)
+			}
+			findings = append(findings, DiagramFinding{
+				Type:     "cycle",
+				Severity: "error",
+				Message:  fmt.Sprintf("Flow loops back: %s -> %s", strings.Join(names, " -> "), names[0]),
+				ShapeIds: ids,
+			})
+		}
+	}
+
+	return findings
+}
+
+// findCycles returns one node path per distinct cycle found with a depth-first search
+func findCycles(nodeCount int, edges []diagramEdge) [][]int {
+	adjacency := make([][]int, nodeCount)
+	for _, edge := range edges {
+		if edge.from >= 0 && edge.to >= 0 {
+			adjacency[edge.from] = append(adjacency[edge.from], edge.to)
+		}
+	}
+
+	const (
+		unvisited = iota
+		onStack
+		done
+	)
+	state := make([]int, nodeCount)
+	var stack []int
+	var cycles [][]int
+	seen := map[string]bool{}
+
+	var visit func(n int)
+	visit = func(n int) {
+		state[n] = onStack
+		stack = append(stack, n)
+		for _, next := range adjacency[n] {
+			switch state[next] {
+			case unvisited:
+				visit(next)
+			case onStack:
+				start := len(stack) - 1
+				for stack[start] != next {
+					start--
+				}
+				cycle := append([]int(nil), stack[start:]...)
+				key := cycleKey(cycle)
+				if !seen[key] {
+					seen[key] = true
+					cycles = append(cycles, cycle)
+				}
+			}
+		}
+		stack = stack[:len(stack)-1]
+		state[n] = done
+	}
+
+	for n := 0; n < nodeCount; n++ {
+		if state[n] == unvisited {
+			visit(n)
+		}
+	}
+	return cycles
+}
+
+func cycleKey(cycle []int) string {
+	sorted := append([]int(nil), cycle...)
+	sort.Ints(sorted)
+	return fmt.Sprint(sorted)
+}
+
+// connectorEnds returns the absolute start and end points of an arrow or line
+func connectorEnds(data map[string]interface{}) ([2]float64, [2]float64, bool) {
+	start, startOk := data["start"].(map[string]interface{})
+	end, endOk := data["end"].(map[string]interface{})
+	if startOk && endOk {
+		sx, _ := start["x"].(float64)
+		sy, _ := start["y"].(float64)
+		ex, _ := end["x"].(float64)
+		ey, _ := end["y"].(float64)
+		return [2]float64{sx, sy}, [2]float64{ex, ey}, true
+	}
+
+	points, ok := data["points"].([]interface{})
+	if !ok || len(points) < 4 {
+		return [2]float64{}, [2]float64{}, false
+	}
+	coords := make([]float64, 0, len(points))
+	for _, p := range points {
+		f, ok := p.(float64)
+		if !ok {
+			return [2]float64{}, [2]float64{}, false
+		}
+		coords = append(coords, f)
+	}
+	ox, _ := data["x"].(float64)
+	oy, _ := data["y"].(float64)
+	n := len(coords)
+	return [2]float64{coords[0] + ox, coords[1] + oy}, [2]float64{coords[n-2] + ox, coords[n-1] + oy}, true
+}
+
+// attachNode returns the smallest node whose bounds (grown by the tolerance) contain the point, or -1
+func attachNode(nodes []diagramNode, x, y float64) int {
+	best := -1
+	for i, node := range nodes {
+		grown := BoundingBox{
+			MinX: node.bounds.MinX - diagramAttachTolerance,
+			MinY: node.bounds.MinY - diagramAttachTolerance,
+			MaxX: node.bounds.MaxX + diagramAttachTolerance,
+			MaxY: node.bounds.MaxY + diagramAttachTolerance,
+		}
+		if !containsBounds(grown, x, y) {
+			continue
+		}
+		if best < 0 || boundsArea(node.bounds) < boundsArea(nodes[best].bounds) {
+			best = i
+		}
+	}
+	return best
+}
+
+func smallestContainingNode(nodes []diagramNode, x, y float64) int {
+	best := -1
+	for i, node := range nodes {
+		if !containsBounds(node.bounds, x, y) {
+			continue
+		}
+		if best < 0 || boundsArea(node.bounds) < boundsArea(nodes[best].bounds) {
+			best = i
+		}
+	}
+	return best
+}
+
+// isDiamond reports whether a polygon has four points, the usual flowchart decision shape
+func isDiamond(data map[string]interface{}) bool {
+	points, ok := data["points"].([]interface{})
+	return ok && len(points) == 8
+}
+
+func nodeName(node diagramNode) string {
+	if node.label != "" {
+		return fmt.Sprintf("%q", node.label)
+	}
+	return fmt.Sprintf("unlabeled %s", node.kind)
+}
+
+// DiagramSummary is the connectivity of the diagram drawn by a set of shapes
+type DiagramSummary struct {
+	Nodes      int `json:"nodes"`
+	Connectors int `json:"connectors"`
+	// Dangling counts connectors with at least one end that doesn't touch a node
+	Dangling int `json:"dangling"`
+	// Components counts groups of nodes linked by connectors; a single connected diagram has one
+	Components int `json:"components"`
+	Cycles     int `json:"cycles"`
+}
+
+// SummarizeDiagram builds the diagram graph of the shapes, the same way validateDiagram does
+func SummarizeDiagram(shapes []models.BoardData) DiagramSummary {
+	nodes, edges := buildDiagramGraph(shapes)
+	summary := DiagramSummary{
+		Nodes:      len(nodes),
+		Connectors: len(edges),
+		Cycles:     len(findCycles(len(nodes), edges)),
+	}
+
+	parent := make([]int, len(nodes))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(n int) int {
+		if parent[n] != n {
+			parent[n] = find(parent[n])
+		}
+		return parent[n]
+	}
+	for _, edge := range edges {
+		if edge.from < 0 || edge.to < 0 {
+			summary.Dangling++
+			continue
+		}
+		parent[find(edge.from)] = find(edge.to)
+	}
+	for i := range nodes {
+		if find(i) == i {
+			summary.Components++
+		}
+	}
+	return summary
+}
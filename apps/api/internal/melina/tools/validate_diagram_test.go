@@ -0,0 +1,81 @@
+package tools
+
+import "testing"
+
+func findingTypes(findings []DiagramFinding) map[string]int {
+	types := map[string]int{}
+	for _, f := range findings {
+		types[f.Type]++
+	}
+	return types
+}
+
+func TestValidateDiagramGraph_Cycle(t *testing.T) {
+	nodes := []diagramNode{{id: "a", label: "A"}, {id: "b", label: "B"}, {id: "c", label: "C"}}
+	edges := []diagramEdge{
+		{id: "ab", from: 0, to: 1},
+		{id: "bc", from: 1, to: 2},
+		{id: "ca", from: 2, to: 0},
+	}
+
+	if got := findingTypes(validateDiagramGraph(nodes, edges, true)); got["cycle"] != 1 {
+		t.Errorf("Expected one cycle finding, got %v", got)
+	}
+	if got := findingTypes(validateDiagramGraph(nodes, edges, false)); got["cycle"] != 0 {
+		t.Errorf("Expected no cycle finding when loops are allowed, got %v", got)
+	}
+}
+
+func TestValidateDiagramGraph_OrphansAndDangling(t *testing.T) {
+	nodes := []diagramNode{{id: "a", label: "A"}, {id: "b", label: "B"}, {id: "c"}}
+	edges := []diagramEdge{
+		{id: "ab", from: 0, to: 1},
+		{id: "loose", from: 1, to: -1},
+	}
+
+	got := findingTypes(validateDiagramGraph(nodes, edges, true))
+	if got["orphan_node"] != 1 || got["unlabeled_node"] != 1 || got["dangling_connector"] != 1 {
+		t.Errorf("Unexpected findings %v", got)
+	}
+}
+
+func TestValidateDiagramGraph_Decisions(t *testing.T) {
+	nodes := []diagramNode{
+		{id: "d", label: "Valid?", decision: true},
+		{id: "yes", label: "Save"},
+		{id: "no", label: "Retry"},
+		{id: "d2", label: "Done?", decision: true},
+	}
+	edges := []diagramEdge{
+		{id: "d-yes", from: 0, to: 1, label: "Yes"},
+		{id: "d-no", from: 0, to: 2},
+		{id: "yes-d2", from: 1, to: 3},
+	}
+
+	got := findingTypes(validateDiagramGraph(nodes, edges, true))
+	if got["unlabeled_decision_branch"] != 1 {
+		t.Errorf("Expected one unlabeled branch, got %v", got)
+	}
+	if got["decision_missing_branch"] != 1 {
+		t.Errorf("Expected one decision without enough branches, got %v", got)
+	}
+}
+
+func TestConnectorEnds(t *testing.T) {
+	start, end, ok := connectorEnds(map[string]interface{}{
+		"x":      10.0,
+		"y":      20.0,
+		"points": []interface{}{0.0, 0.0, 50.0, 5.0, 100.0, 10.0},
+	})
+	if !ok || start != [2]float64{10, 20} || end != [2]float64{110, 30} {
+		t.Errorf("Unexpected ends %v %v", start, end)
+	}
+
+	start, end, ok = connectorEnds(map[string]interface{}{
+		"start": map[string]interface{}{"x": 1.0, "y": 2.0},
+		"end":   map[string]interface{}{"x": 3.0, "y": 4.0},
+	})
+	if !ok || start != [2]float64{1, 2} || end != [2]float64{3, 4} {
+		t.Errorf("Unexpected ends %v %v", start, end)
+	}
+}
@@ -0,0 +1,123 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"math"
+
+	"melina-studio-backend/internal/libraries"
+	llmHandlers "melina-studio-backend/internal/llm_handlers"
+	"melina-studio-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+var focusOnSchema = toolSchema{
+	Name:        "focusOn",
+	Description: "Moves the user's view of the board to the given shapes, zoomed so they all fit. Use it after drawing or changing something away from where the user is looking, or to point at the shapes you are talking about. Pass a frame's id to show the whole frame.",
+	Parameters: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"boardId": map[string]interface{}{
+				"type":        "string",
+				"description": "The UUID of the board",
+			},
+			"shapeIds": map[string]interface{}{
+				"type":        "array",
+				"items":       map[string]interface{}{"type": "string"},
+				"description": "The shapes to show, including shapes created earlier in this turn",
+			},
+		},
+		"required": []string{"boardId", "shapeIds"},
+	},
+}
+
+const (
+	// FocusCanvasWidth and FocusCanvasHeight approximate the client's canvas when it doesn't send its size
+	FocusCanvasWidth  = 1280.0
+	FocusCanvasHeight = 800.0
+	MinViewportZoom   = 0.1
+	MaxViewportZoom   = 2.0
+	focusPadding      = 80.0
+)
+
+// FitViewport centers a viewport on the bounds, zoomed so they fit a canvas of the given size with some padding
+func FitViewport(b BoundingBox, canvasWidth, canvasHeight float64) models.Viewport {
+	width, height := b.MaxX-b.MinX, b.MaxY-b.MinY
+	zoom := MaxViewportZoom
+	if width > 0 {
+		zoom = math.Min(zoom, (canvasWidth-2*focusPadding)/width)
+	}
+	if height > 0 {
+		zoom = math.Min(zoom, (canvasHeight-2*focusPadding)/height)
+	}
+	return models.Viewport{
+		X:    b.MinX + width/2,
+		Y:    b.MinY + height/2,
+		Zoom: ClampZoom(zoom),
+	}
+}
+
+// ClampZoom keeps a zoom level in the range the client supports
+func ClampZoom(zoom float64) float64 {
+	return math.Max(MinViewportZoom, math.Min(MaxViewportZoom, zoom))
+}
+
+// BoundsRect converts bounds to the x, y, width, height form the API returns
+func BoundsRect(b BoundingBox) models.AnchorBounds {
+	return models.AnchorBounds{X: b.MinX, Y: b.MinY, Width: b.MaxX - b.MinX, Height: b.MaxY - b.MinY}
+}
+
+// FocusOnHandler is the handler for the focusOn tool
+func (d *ToolDeps) FocusOnHandler(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+	boardIdStr, ok := input["boardId"].(string)
+	if !ok {
+		return nil, llmHandlers.InvalidToolInput("boardId is required and must be a string")
+	}
+	boardId, err := uuid.Parse(boardIdStr)
+	if err != nil {
+		return nil, llmHandlers.InvalidToolInput("invalid boardId: %v", err)
+	}
+	shapeIds := stringSlice(input["shapeIds"])
+	if len(shapeIds) == 0 {
+		return nil, llmHandlers.InvalidToolInput("shapeIds is required and must list at least one shape")
+	}
+
+	streamCtx, err := getStreamingContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	stored, err := d.BoardData.GetBoardData(boardId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shapes from database: %w", err)
+	}
+	// shapes drawn earlier in the turn aren't saved yet, and are usually the ones to show
+	shapes := stored
+	if activity, ok := llmHandlers.ToolActivityFrom(ctx); ok {
+		shapes = SnapshotShapes(stored, activity.CreatedShapes())
+	}
+	bounds := AnchorBounds(shapes, shapeIds)
+	if bounds == nil {
+		return nil, llmHandlers.InvalidToolInput("none of shapeIds was found on the board")
+	}
+
+	viewport := FitViewport(*bounds, FocusCanvasWidth, FocusCanvasHeight)
+	if streamCtx.Hub != nil && streamCtx.Client != nil {
+		libraries.SendViewportChangeMessage(streamCtx.Hub, streamCtx.Client, &libraries.ViewportChangePayload{
+			BoardId:  boardIdStr,
+			ShapeIds: shapeIds,
+			X:        viewport.X,
+			Y:        viewport.Y,
+			Zoom:     viewport.Zoom,
+		})
+	}
+
+	return map[string]interface{}{
+		"success":  true,
+		"boardId":  boardIdStr,
+		"viewport": viewport,
+		"bounds":   BoundsRect(*bounds),
+		"message":  fmt.Sprintf("Moved the user's view to %d shape(s)", len(shapeIds)),
+	}, nil
+}
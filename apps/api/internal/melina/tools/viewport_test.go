@@ -0,0 +1,22 @@
+package tools
+
+import "testing"
+
+func TestFitViewport(t *testing.T) {
+	v := FitViewport(BoundingBox{MinX: 100, MinY: 200, MaxX: 300, MaxY: 300}, FocusCanvasWidth, FocusCanvasHeight)
+	if v.X != 200 || v.Y != 250 || v.Zoom != MaxViewportZoom {
+		t.Errorf("small shapes: got %+v", v)
+	}
+
+	v = FitViewport(BoundingBox{MinX: 0, MinY: 0, MaxX: 1120, MaxY: 100}, FocusCanvasWidth, FocusCanvasHeight)
+	if v.Zoom != 1 {
+		t.Errorf("wide shapes should fit the width: got zoom %v", v.Zoom)
+	}
+	if v = FitViewport(BoundingBox{MinX: 0, MinY: 0, MaxX: 960, MaxY: 100}, 640, 400); v.Zoom != 0.5 {
+		t.Errorf("a smaller canvas should zoom out: got zoom %v", v.Zoom)
+	}
+
+	if v = FitViewport(BoundingBox{MinX: 0, MinY: 0, MaxX: 100000, MaxY: 10}, FocusCanvasWidth, FocusCanvasHeight); v.Zoom != MinViewportZoom {
+		t.Errorf("huge content should stop at the minimum zoom: got %v", v.Zoom)
+	}
+}
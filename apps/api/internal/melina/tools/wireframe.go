@@ -0,0 +1,375 @@
+package tools
+
+import (
+	"context"
+	"fmt"
+	"melina-studio-backend/internal/libraries"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var generateWireframeSchema = toolSchema{
+	Name:        "generateWireframe",
+	Description: "Lays out one or more UI screens as wireframes. Each screen becomes a frame and its components (navbar, text, input, button, card) are stacked top to bottom inside it with consistent spacing. Use this instead of addShape when the user asks to wireframe, mock up or sketch a page or screen.",
+	Parameters: map[string]interface{}{
+		"type": "object",
+		"properties": map[string]interface{}{
+			"boardId": map[string]interface{}{
+				"type":        "string",
+				"description": "The UUID of the board",
+			},
+			"screens": map[string]interface{}{
+				"type":        "array",
+				"description": "Screens to lay out side by side, left to right",
+				"items": map[string]interface{}{
+					"type": "object",
+					"properties": map[string]interface{}{
+						"name": map[string]interface{}{
+							"type":        "string",
+							"description": "Screen name shown as the frame label (e.g., 'Sign up')",
+						},
+						"device": map[string]interface{}{
+							"type":        "string",
+							"enum":        []string{"mobile", "desktop"},
+							"description": "Screen size preset (default: mobile)",
+						},
+						"components": map[string]interface{}{
+							"type":        "array",
+							"description": "Components in top to bottom order",
+							"items": map[string]interface{}{
+								"type": "object",
+								"properties": map[string]interface{}{
+									"type": map[string]interface{}{
+										"type": "string",
+										"enum": []string{"navbar", "text", "input", "button", "card"},
+									},
+									"text": map[string]interface{}{
+										"type":        "string",
+										"description": "Heading/paragraph text, button label, navbar brand or card title",
+									},
+									"placeholder": map[string]interface{}{
+										"type":        "string",
+										"description": "Placeholder for inputs",
+									},
+									"variant": map[string]interface{}{
+										"type":        "string",
+										"enum":        []string{"primary", "secondary", "ghost"},
+										"description": "Button style",
+									},
+									"items": map[string]interface{}{
+										"type":        "array",
+										"items":       map[string]interface{}{"type": "string"},
+										"description": "Navbar links or card body lines",
+									},
+									"height": map[string]interface{}{
+										"type":        "number",
+										"description": "Override the default component height (optional)",
+									},
+								},
+								"required": []string{"type"},
+							},
+						},
+					},
+					"required": []string{"name", "components"},
+				},
+			},
+			"x": map[string]interface{}{
+				"type":        "number",
+				"description": "X coordinate of the first screen (optional, defaults to below existing content)",
+			},
+			"y": map[string]interface{}{
+				"type":        "number",
+				"description": "Y coordinate of the first screen (optional, defaults to below existing content)",
+			},
+		},
+		"required": []string{"boardId", "screens"},
+	},
+}
+
+const (
+	wireframeMobileWidth   = 375.0
+	wireframeMobileHeight  = 667.0
+	wireframeDesktopWidth  = 1280.0
+	wireframeDesktopHeight = 800.0
+	wireframePadding       = 24.0
+	wireframeComponentGap  = 16.0
+	wireframeScreenGap     = 80.0
+	wireframeMaxComponents = 40
+)
+
+// uiComponentDefaults are the default height and colors of each wireframe component
+var uiComponentDefaults = map[string]struct {
+	height float64
+	fill   string
+	stroke string
+}{
+	"navbar": {height: 56, fill: "#f1f5f9", stroke: "#94a3b8"},
+	"input":  {height: 44, fill: "#ffffff", stroke: "#94a3b8"},
+	"button": {height: 44, fill: "#334155", stroke: "#334155"},
+	"card":   {height: 140, fill: "#ffffff", stroke: "#cbd5e1"},
+	"text":   {height: 24},
+}
+
+// wireframeComponent is a component of a screen before layout
+type wireframeComponent struct {
+	kind   string
+	props  map[string]interface{}
+	height float64
+}
+
+// wireframeScreen is a screen of the wireframe before layout
+type wireframeScreen struct {
+	name       string
+	width      float64
+	height     float64
+	components []wireframeComponent
+}
+
+// applyUIComponentProps copies the UI component fields from tool input onto a shape, filling in defaults
+func applyUIComponentProps(shape map[string]interface{}, shapeType string, input map[string]interface{}) {
+	defaults := uiComponentDefaults[shapeType]
+	if width, ok := input["width"].(float64); ok {
+		shape["w"] = width
+	} else if shapeType == "navbar" {
+		shape["w"] = wireframeMobileWidth
+	} else {
+		shape["w"] = wireframeMobileWidth - 2*wireframePadding
+	}
+	if height, ok := input["height"].(float64); ok {
+		shape["h"] = height
+	} else {
+		shape["h"] = defaults.height
+	}
+	if text, ok := input["text"].(string); ok && text != "" {
+		shape["text"] = text
+	}
+	if placeholder, ok := input["placeholder"].(string); ok && placeholder != "" {
+		shape["placeholder"] = placeholder
+	}
+	if shapeType == "button" {
+		variant, _ := input["variant"].(string)
+		if variant == "" {
+			variant = "primary"
+		}
+		shape["variant"] = variant
+	}
+	if items := stringSlice(input["items"]); len(items) > 0 {
+		shape["items"] = items
+	}
+	if defaults.fill != "" {
+		shape["fill"] = defaults.fill
+	}
+	if defaults.stroke != "" {
+		shape["stroke"] = defaults.stroke
+	}
+}
+
+// GenerateWireframeHandler lays out screens as frames filled with UI component shapes
+func (d *ToolDeps) GenerateWireframeHandler(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+	streamCtx, err := getStreamingContext(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if streamCtx.Hub == nil || streamCtx.Client == nil {
+		return nil, fmt.Errorf("WebSocket connection not available - cannot send shapes")
+	}
+
+	boardIdStr, ok := input["boardId"].(string)
+	if !ok || boardIdStr == "" {
+		return nil, fmt.Errorf("boardId is required and must be a non-empty string")
+	}
+	boardId, err := uuid.Parse(boardIdStr)
+	if err != nil {
+		return nil, fmt.Errorf("invalid boardId format: %w", err)
+	}
+
+	screens, err := parseWireframeScreens(input["screens"])
+	if err != nil {
+		return nil, err
+	}
+
+	shapes, err := d.BoardData.GetBoardData(boardId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shapes from database: %w", err)
+	}
+
+	originX, originY := 0.0, 0.0
+	if state := GenerateCanvasState(shapes, 0, 0); state != nil {
+		originX = state.OverallBounds.MinX
+		originY = state.OverallBounds.MaxY + wireframeScreenGap
+	}
+	if x, ok := input["x"].(float64); ok {
+		originX = x
+	}
+	if y, ok := input["y"].(float64); ok {
+		originY = y
+	}
+
+	libraries.SendEventType(streamCtx.Hub, streamCtx.Client, libraries.WebSocketMessageTypeShapeStart)
+
+	results := make([]map[string]interface{}, 0, len(screens))
+	screenX := originX
+	for _, screen := range screens {
+		frame, components := layoutWireframeScreen(screen, screenX, originY)
+		sendShapeCreated(ctx, streamCtx, boardIdStr, frame)
+
+		componentIds := make([]interface{}, 0, len(components))
+		for _, component := range components {
+			sendShapeCreated(ctx, streamCtx, boardIdStr, component)
+			componentIds = append(componentIds, component["id"])
+		}
+
+		results = append(results, map[string]interface{}{
+			"screen":       screen.name,
+			"frameId":      frame["id"],
+			"componentIds": componentIds,
+		})
+		screenX += screen.width + wireframeScreenGap
+	}
+
+	d.invalidateBoardImageCache(streamCtx, boardId)
+
+	return map[string]interface{}{
+		"success": true,
+		"screens": results,
+		"message": fmt.Sprintf("Created %d wireframe screens", len(results)),
+	}, nil
+}
+
+// parseWireframeScreens validates the screens input of generateWireframe
+func parseWireframeScreens(raw interface{}) ([]wireframeScreen, error) {
+	rawScreens, ok := raw.([]interface{})
+	if !ok || len(rawScreens) == 0 {
+		return nil, fmt.Errorf("screens is required and must be a non-empty array")
+	}
+
+	screens := make([]wireframeScreen, 0, len(rawScreens))
+	for i, rs := range rawScreens {
+		screenInput, ok := rs.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("screen %d must be an object", i)
+		}
+
+		screen := wireframeScreen{width: wireframeMobileWidth, height: wireframeMobileHeight}
+		screen.name, _ = screenInput["name"].(string)
+		if strings.TrimSpace(screen.name) == "" {
+			screen.name = fmt.Sprintf("Screen %d", i+1)
+		}
+		if device, _ := screenInput["device"].(string); device == "desktop" {
+			screen.width, screen.height = wireframeDesktopWidth, wireframeDesktopHeight
+		}
+
+		rawComponents, _ := screenInput["components"].([]interface{})
+		if len(rawComponents) == 0 {
+			return nil, fmt.Errorf("screen %q has no components", screen.name)
+		}
+		if len(rawComponents) > wireframeMaxComponents {
+			return nil, fmt.Errorf("screen %q has %d components, the limit is %d", screen.name, len(rawComponents), wireframeMaxComponents)
+		}
+
+		for j, rc := range rawComponents {
+			props, ok := rc.(map[string]interface{})
+			if !ok {
+				return nil, fmt.Errorf("component %d of screen %q must be an object", j, screen.name)
+			}
+			kind, _ := props["type"].(string)
+			defaults, ok := uiComponentDefaults[kind]
+			if !ok {
+				return nil, fmt.Errorf("invalid component type %q in screen %q", kind, screen.name)
+			}
+			height := defaults.height
+			if h, ok := props["height"].(float64); ok && h > 0 {
+				height = h
+			}
+			screen.components = append(screen.components, wireframeComponent{kind: kind, props: props, height: height})
+		}
+		screens = append(screens, screen)
+	}
+	return screens, nil
+}
+
+// layoutWireframeScreen positions a screen's components inside its frame
+// Navbars span the full frame width at their place in the flow; everything else is inset by the padding
+// The frame grows taller than its device preset when the components don't fit
+func layoutWireframeScreen(screen wireframeScreen, x, y float64) (map[string]interface{}, []map[string]interface{}) {
+	components := make([]map[string]interface{}, 0, len(screen.components))
+	cursorY := y
+
+	for i, component := range screen.components {
+		shape := map[string]interface{}{
+			"id":   uuid.New().String(),
+			"type": component.kind,
+		}
+
+		if component.kind == "navbar" {
+			shape["x"] = x
+			shape["w"] = screen.width
+		} else {
+			if i == 0 || screen.components[i-1].kind == "navbar" {
+				cursorY += wireframePadding
+			}
+			shape["x"] = x + wireframePadding
+			shape["w"] = screen.width - 2*wireframePadding
+		}
+		shape["y"] = cursorY
+
+		if component.kind == "text" {
+			text, _ := component.props["text"].(string)
+			shape["text"] = text
+			shape["fontSize"] = 16.0
+			if i == 0 || screen.components[i-1].kind == "navbar" {
+				// The first text of a screen reads as its heading
+				shape["fontSize"] = 24.0
+			}
+			shape["fill"] = "#0f172a"
+		} else {
+			props := map[string]interface{}{}
+			for k, v := range component.props {
+				props[k] = v
+			}
+			props["width"] = shape["w"]
+			props["height"] = component.height
+			applyUIComponentProps(shape, component.kind, props)
+		}
+		components = append(components, shape)
+
+		cursorY += component.height
+		if component.kind != "navbar" {
+			cursorY += wireframeComponentGap
+		}
+	}
+
+	height := screen.height
+	if used := cursorY - y + wireframePadding; used > height {
+		height = used
+	}
+
+	frame := map[string]interface{}{
+		"id":     uuid.New().String(),
+		"type":   "frame",
+		"x":      x,
+		"y":      y,
+		"w":      screen.width,
+		"h":      height,
+		"name":   screen.name,
+		"stroke": "#94a3b8",
+		"fill":   "#ffffff",
+	}
+	return frame, components
+}
+
+// stringSlice converts a JSON array of strings, skipping empty and non-string entries
+func stringSlice(raw interface{}) []string {
+	values, ok := raw.([]interface{})
+	if !ok {
+		return nil
+	}
+	result := make([]string, 0, len(values))
+	for _, v := range values {
+		if s, ok := v.(string); ok && strings.TrimSpace(s) != "" {
+			result = append(result, s)
+		}
+	}
+	return result
+}
@@ -0,0 +1,73 @@
+package tools
+
+import "testing"
+
+func TestLayoutWireframeScreen_StacksComponents(t *testing.T) {
+	screen := wireframeScreen{
+		name:   "Sign up",
+		width:  wireframeMobileWidth,
+		height: wireframeMobileHeight,
+		components: []wireframeComponent{
+			{kind: "navbar", props: map[string]interface{}{"text": "Acme"}, height: 56},
+			{kind: "text", props: map[string]interface{}{"text": "Create account"}, height: 24},
+			{kind: "input", props: map[string]interface{}{"placeholder": "Email"}, height: 44},
+			{kind: "button", props: map[string]interface{}{"text": "Sign up"}, height: 44},
+		},
+	}
+
+	frame, components := layoutWireframeScreen(screen, 100, 200)
+
+	if frame["name"] != "Sign up" || frame["h"] != wireframeMobileHeight {
+		t.Errorf("Unexpected frame %v", frame)
+	}
+	if len(components) != 4 {
+		t.Fatalf("Expected 4 components, got %d", len(components))
+	}
+
+	navbar := components[0]
+	if navbar["x"] != 100.0 || navbar["y"] != 200.0 || navbar["w"] != wireframeMobileWidth {
+		t.Errorf("Expected navbar to span the top of the frame, got %v", navbar)
+	}
+
+	heading := components[1]
+	if heading["y"] != 200.0+56+wireframePadding || heading["fontSize"] != 24.0 {
+		t.Errorf("Expected heading below the navbar, got %v", heading)
+	}
+
+	button := components[3]
+	if button["x"] != 100.0+wireframePadding || button["w"] != wireframeMobileWidth-2*wireframePadding {
+		t.Errorf("Expected button inset by the padding, got %v", button)
+	}
+	if button["variant"] != "primary" {
+		t.Errorf("Expected default button variant primary, got %v", button["variant"])
+	}
+	if components[2]["y"].(float64) >= button["y"].(float64) {
+		t.Errorf("Expected input above button")
+	}
+}
+
+func TestLayoutWireframeScreen_GrowsFrame(t *testing.T) {
+	screen := wireframeScreen{name: "Feed", width: wireframeMobileWidth, height: wireframeMobileHeight}
+	for i := 0; i < 10; i++ {
+		screen.components = append(screen.components, wireframeComponent{kind: "card", props: map[string]interface{}{}, height: 140})
+	}
+
+	frame, _ := layoutWireframeScreen(screen, 0, 0)
+
+	want := wireframePadding + 10*140 + 10*wireframeComponentGap + wireframePadding
+	if frame["h"] != want {
+		t.Errorf("Expected frame height %v, got %v", want, frame["h"])
+	}
+}
+
+func TestParseWireframeScreens_RejectsUnknownComponent(t *testing.T) {
+	_, err := parseWireframeScreens([]interface{}{
+		map[string]interface{}{
+			"name":       "Home",
+			"components": []interface{}{map[string]interface{}{"type": "carousel"}},
+		},
+	})
+	if err == nil {
+		t.Error("Expected error for unknown component type")
+	}
+}
@@ -2,19 +2,24 @@ package workflow
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
 	"strings"
 	"time"
 
 	"github.com/google/uuid"
+	"gorm.io/gorm"
 
 	"melina-studio-backend/internal/config"
 	"melina-studio-backend/internal/libraries"
 	llmHandlers "melina-studio-backend/internal/llm_handlers"
 	"melina-studio-backend/internal/melina/agents"
 	"melina-studio-backend/internal/melina/helpers"
+	"melina-studio-backend/internal/melina/prompts"
 	"melina-studio-backend/internal/melina/tools"
+	"melina-studio-backend/internal/models"
 	"melina-studio-backend/internal/repo"
 	"melina-studio-backend/internal/service"
 )
@@ -23,14 +28,16 @@ type Workflow struct {
 	chatRepo       repo.ChatRepoInterface
 	boardDataRepo  repo.BoardDataRepoInterface
 	boardRepo      repo.BoardRepoInterface
+	tenantService  *service.TenantService
 	imageProcessor *service.ImageProcessor
 }
 
-func NewWorkflow(chatRepo repo.ChatRepoInterface, boardDataRepo repo.BoardDataRepoInterface, boardRepo repo.BoardRepoInterface) *Workflow {
+func NewWorkflow(chatRepo repo.ChatRepoInterface, boardDataRepo repo.BoardDataRepoInterface, boardRepo repo.BoardRepoInterface, tenantService *service.TenantService) *Workflow {
 	return &Workflow{
 		chatRepo:       chatRepo,
 		boardDataRepo:  boardDataRepo,
 		boardRepo:      boardRepo,
+		tenantService:  tenantService,
 		imageProcessor: service.NewImageProcessor(boardDataRepo),
 	}
 }
@@ -56,6 +63,13 @@ func (w *Workflow) ProcessChatMessage(hub *libraries.Hub, client *libraries.Clie
 		return
 	}
 
+	// load the user once for the preferences that shape this turn
+	user, err := repo.NewAuthRepository(config.DB).GetUserByID(userIdUUID)
+	if err != nil {
+		log.Printf("Failed to load preferences of user %s: %v", userIdUUID, err)
+	}
+	prefs := preferencesOf(user, err)
+
 	// Generate canvas state for spatial awareness
 	// This helps the LLM know where existing shapes are located
 	var canvasStateXML string
@@ -84,8 +98,6 @@ func (w *Workflow) ProcessChatMessage(hub *libraries.Hub, client *libraries.Clie
 		log.Printf("User %s blocked: %d/%d tokens used (%.2f%%)", userIdUUID, consumed, limit, percentage)
 
 		// Calculate reset date
-		authRepo := repo.NewAuthRepository(config.DB)
-		user, _ := authRepo.GetUserByID(userIdUUID)
 		var resetDate string
 		if user.LastTokenResetDate != nil {
 			nextReset := user.LastTokenResetDate.AddDate(0, 1, 0)
@@ -104,6 +116,19 @@ func (w *Workflow) ProcessChatMessage(hub *libraries.Hub, client *libraries.Clie
 		return
 	}
 
+	// Refuse the turn when a hard-capped board or tenant budget is spent
+	budgetService := service.NewBudgetService(repo.NewBudgetRepository(config.DB), repo.NewAuthRepository(config.DB))
+	if status, err := budgetService.CheckTurn(userIdUUID, boardIdUUID, time.Now()); err != nil {
+		if errors.Is(err, service.ErrBudgetExceeded) {
+			log.Printf("Board %s blocked by %s budget: $%.2f/$%.2f spent", boardIdUUID, status.Budget.Scope, status.SpentUSD, status.Budget.MonthlyLimitUSD)
+			libraries.SendBudgetExceeded(hub, client, service.BudgetPayload(status, 100))
+			return
+		}
+		log.Printf("Error checking budget: %v", err)
+		libraries.SendErrorMessage(hub, client, "Failed to check budget")
+		return
+	}
+
 	// Create loader generator for dynamic loader messages (before chat_starting so we can send thinking message)
 	loaderGen, err := llmHandlers.NewLoaderGenerator()
 	if err != nil {
@@ -138,8 +163,39 @@ func (w *Workflow) ProcessChatMessage(hub *libraries.Hub, client *libraries.Clie
 		return
 	}
 
+	// in multi-tenant mode the user's tenant may restrict which models are available
+	if err := w.tenantService.CheckUserModel(userIdUUID, cfg.ModelName); err != nil {
+		libraries.SendErrorMessage(hub, client, fmt.Sprintf("Model not available: %s", cfg.ModelName))
+		return
+	}
+
+	// switch to a fallback model while the requested model's provider is failing
+	modelName, modelInfo, err := llmHandlers.ResolveHealthyModel(cfg.ModelName, func(name string) bool {
+		return w.tenantService.CheckUserModel(userIdUUID, name) == nil
+	})
+	if err != nil {
+		libraries.SendErrorMessage(hub, client, fmt.Sprintf("Invalid model: %s", cfg.ModelName))
+		return
+	}
+
+	// the board's agent profile decides which tools the agent gets
+	profile := w.agentProfile(userIdUUID, boardIdUUID)
+
 	// Create agent with validated model info and loader generator
-	agent := agents.NewAgentWithModel(modelInfo, cfg.Temperature, cfg.MaxTokens, loaderGen)
+	agent := agents.NewAgentWithModel(modelInfo, cfg.Temperature, cfg.MaxTokens, loaderGen, profile)
+
+	// the vision check of drawings costs an extra call, so it only runs for users who turned it on
+	var verifier *agents.DrawingVerifier
+	if prefs.VerifyDrawings {
+		if verifier, err = agents.NewDrawingVerifier(); err != nil {
+			log.Printf("Warning: drawing verification disabled: %v", err)
+		} else {
+			agent.SetVerifier(verifier)
+		}
+	}
+	if prefs.ConfirmDestructiveTools {
+		agent.RequireToolApproval()
+	}
 
 	// Process selection images using the image processor service
 	annotatedSelections := w.imageProcessor.ProcessSelectionImages(cfg.Message.Metadata)
@@ -148,7 +204,7 @@ func (w *Workflow) ProcessChatMessage(hub *libraries.Hub, client *libraries.Clie
 	var uploadedImages []helpers.UploadedImage
 	if cfg.Message.Metadata != nil && len(cfg.Message.Metadata.UploadedImageUrls) > 0 {
 		log.Printf("Found %d uploaded image URLs in metadata: %v", len(cfg.Message.Metadata.UploadedImageUrls), cfg.Message.Metadata.UploadedImageUrls)
-		uploadedImages = w.imageProcessor.ProcessUploadedImages(cfg.Message.Metadata.UploadedImageUrls)
+		uploadedImages = w.imageProcessor.ProcessUploadedImages(cfg.Message.Metadata.UploadedImageUrls, cfg.Message.Metadata.Attachments)
 		log.Printf("Processed %d uploaded images successfully", len(uploadedImages))
 	} else {
 		log.Printf("No uploaded images in metadata (metadata nil: %v)", cfg.Message.Metadata == nil)
@@ -161,19 +217,101 @@ func (w *Workflow) ProcessChatMessage(hub *libraries.Hub, client *libraries.Clie
 		log.Printf("Failed to get formatted custom rules: %v", err)
 	}
 
+	// inject the current step instructions if a facilitation session is running on this board
+	facilitationService := service.NewFacilitationService(repo.NewFacilitationRepository(config.DB))
+	facilitationContext, err := facilitationService.GetFormattedStepInstructions(userIdUUID, boardIdUUID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		log.Printf("Failed to get facilitation context: %v", err)
+	}
+
+	// pull the outlines and thumbnails of the boards the user attached to this turn
+	var referenceContext string
+	if len(cfg.ReferencedBoardIds) > 0 {
+		referenceService := service.NewBoardReferenceService(w.boardRepo, w.boardDataRepo)
+		var thumbnails []string
+		referenceContext, thumbnails = referenceService.BuildReferenceContext(userIdUUID, boardIdUUID, cfg.ReferencedBoardIds)
+		uploadedImages = append(uploadedImages, w.imageProcessor.ProcessUploadedImages(thumbnails, nil)...)
+	}
+
+	// check the turn against what the model supports before calling it, adjusting instead of failing in the provider
+	images := len(uploadedImages)
+	for _, sel := range annotatedSelections {
+		images += len(sel.Shapes)
+	}
+	promptParts := []string{prompts.MASTER_PROMPT, profile.Instructions, cfg.Message.Message, canvasStateXML, customRulesString, facilitationContext, referenceContext}
+	plan := llmHandlers.PlanTurn(modelInfo, llmHandlers.TurnRequest{
+		Images:       images,
+		Thinking:     cfg.EnableThinking,
+		Tools:        profile.Tools == nil || len(profile.Tools) > 0,
+		PromptTokens: llmHandlers.EstimateTokens(strings.Join(promptParts, "\n\n")),
+		History:      chatHistory,
+	})
+	for _, warning := range plan.Warnings {
+		log.Printf("[workflow] %s on %s: %s", warning.Code, modelName, warning.Message)
+		libraries.SendCapabilityWarning(hub, client, &libraries.CapabilityWarningPayload{
+			BoardId: cfg.BoardId,
+			Code:    warning.Code,
+			Message: warning.Message,
+			Model:   modelName,
+		})
+	}
+	if plan.Blocked {
+		libraries.SendErrorMessage(hub, client, "Message is too long for the selected model")
+		return
+	}
+	chatHistory = chatHistory[plan.HistoryStart:]
+
+	// keep emails, phone and card numbers out of the request when the user asked to; the model sees
+	// placeholders, the tools get the real values back and the reply is restored before it is saved.
+	// The turn metadata keeps the redacted prompt, as the model saw it
+	prompt := turnPrompt{
+		Message:             cfg.Message.Message,
+		CanvasState:         canvasStateXML,
+		CustomRules:         customRulesString,
+		FacilitationContext: facilitationContext,
+		ReferenceContext:    referenceContext,
+		Instructions:        profile.Instructions,
+	}
+	var redactor *llmHandlers.Redactor
+	if prefs.RedactPII {
+		redactor = llmHandlers.NewRedactor()
+		prompt = prompt.redact(redactor)
+		chatHistory = redactor.RedactMessages(chatHistory)
+		// the agent adds the profile's instructions when it builds the system prompt
+		profile.Instructions = prompt.Instructions
+	}
+	llmCustomRules := prompt.CustomRules
+	if redactor != nil && redactor.Redacted() {
+		llmCustomRules = strings.TrimSpace(llmCustomRules + "\n\n" + prompts.REDACTION_PROMPT)
+	}
+
+	// a fixed seed per turn makes it replayable on providers that take one
+	turnCtx := service.WithLLMPrivacy(context.Background(), userIdUUID, llmHandlers.DataClassBoard, llmHandlers.DataClassChat)
+	if redactor != nil {
+		turnCtx = llmHandlers.WithRedactor(turnCtx, redactor)
+	}
+	var seed *int64
+	if llmHandlers.SeedSupported(modelInfo.Provider) {
+		turnSeed := llmHandlers.NewTurnSeed()
+		seed = &turnSeed
+		turnCtx = llmHandlers.WithSeed(turnCtx, turnSeed)
+	}
+
 	// process the chat message - pass client and boardId for streaming
 	responseWithUsage, err := agent.ProcessRequestStreamWithUsage(
-		context.Background(),
+		turnCtx,
 		hub, client,
-		cfg.Message.Message,
+		prompt.Message,
 		chatHistory,
 		cfg.BoardId,
 		cfg.ActiveTheme,
 		annotatedSelections,
 		uploadedImages,
-		cfg.EnableThinking,
-		canvasStateXML,
-		customRulesString,
+		plan.Thinking,
+		prompt.CanvasState,
+		llmCustomRules,
+		prompt.FacilitationContext,
+		prompt.ReferenceContext,
 	)
 	if err != nil {
 		// Log the error for debugging
@@ -195,6 +333,10 @@ func (w *Workflow) ProcessChatMessage(hub *libraries.Hub, client *libraries.Clie
 	aiResponse := responseWithUsage.Text
 	tokenUsage := responseWithUsage.TokenUsage
 	thinking := responseWithUsage.Thinking
+	if redactor != nil {
+		aiResponse = redactor.Restore(aiResponse)
+		thinking = redactor.Restore(thinking)
+	}
 
 	// Safety net: if aiResponse is empty, provide a default message to prevent database issues
 	if strings.TrimSpace(aiResponse) == "" {
@@ -202,9 +344,9 @@ func (w *Workflow) ProcessChatMessage(hub *libraries.Hub, client *libraries.Clie
 		aiResponse = "I processed your request but was unable to generate a text response. Please check the board for any changes that were made."
 	}
 
-	// Convert thinking to pointer (nil if empty)
+	// Convert thinking to pointer (nil if empty or the user opted out of storing it)
 	var thoughtPtr *string
-	if strings.TrimSpace(thinking) != "" {
+	if strings.TrimSpace(thinking) != "" && prefs.StoreThinking {
 		thoughtPtr = &thinking
 	}
 
@@ -215,30 +357,176 @@ func (w *Workflow) ProcessChatMessage(hub *libraries.Hub, client *libraries.Clie
 		return
 	}
 
+	snapshot, err := json.Marshal(shapes)
+	if err != nil {
+		log.Printf("Failed to snapshot board %s for turn metadata: %v", boardIdUUID, err)
+	}
+	turnMetadata := &models.TurnMetadata{
+		ChatID:              ai_message_id,
+		HumanMessageID:      human_message_id,
+		BoardID:             boardIdUUID,
+		UserID:              userIdUUID,
+		ModelName:           modelName,
+		Provider:            string(modelInfo.Provider),
+		PromptVersion:       prompts.MasterPromptVersion(),
+		ToolsVersion:        tools.SchemaVersion(),
+		Temperature:         cfg.Temperature,
+		MaxTokens:           cfg.MaxTokens,
+		Seed:                seed,
+		Thinking:            plan.Thinking,
+		AgentProfile:        profile.Name,
+		Theme:               cfg.ActiveTheme,
+		CustomRules:         prompt.CustomRules,
+		FacilitationContext: prompt.FacilitationContext,
+		ReferenceContext:    prompt.ReferenceContext,
+		BoardSnapshot:       snapshot,
+		Selections:          images,
+	}
+	if err := repo.NewTurnMetadataRepository(config.DB).Create(turnMetadata); err != nil {
+		log.Printf("Failed to store turn metadata: %v", err)
+	}
+
+	// the uploaded images become attachments of the user's message, kept until the message or attachment is deleted
+	if cfg.Message.Metadata != nil && len(cfg.Message.Metadata.UploadedImageUrls) > 0 {
+		if _, err := repo.NewTempUploadRepository(config.DB).AttachToMessage(boardIdUUID, userIdUUID, cfg.Message.Metadata.UploadedImageUrls, human_message_id); err != nil {
+			log.Printf("Failed to attach uploaded images to message %s: %v", human_message_id, err)
+		}
+	}
+
+	activityService := service.NewActivityService(repo.NewBoardActivityRepository(config.DB), hub)
+	activityService.RecordAgentRun(userIdUUID, boardIdUUID, cfg.Message.Message, modelName, human_message_id, ai_message_id)
+
 	// Store token consumption and handle warnings asynchronously to avoid latency
 	if tokenUsage != nil {
 		// Run all token tracking operations in a goroutine to not block the response
-		go runTokenTrackingOperations(hub, client, userIdUUID, boardIdUUID, human_message_id, string(modelInfo.Provider), cfg.ModelName, tokenUsage)
+		go runTokenTrackingOperations(hub, client, userIdUUID, boardIdUUID, human_message_id, ai_message_id, string(modelInfo.Provider), modelName, tokenUsage)
+	}
+	if verifier != nil {
+		if verificationModel, usage := verifier.Usage(); usage != nil {
+			go recordVerificationUsage(userIdUUID, boardIdUUID, human_message_id, verificationModel, usage)
+		}
 	}
 
-	// send an event that the chat is completed
-	libraries.SendChatMessageResponse(hub, client, libraries.WebSocketMessageTypeChatCompleted, &libraries.ChatMessageResponsePayload{
+	// send an event that the chat is completed, carrying the canonical reply so the client can
+	// replace the text it assembled from chunks and recover from any it missed
+	completed := &libraries.ChatMessageResponsePayload{
 		BoardId:        cfg.BoardId,
 		Message:        aiResponse,
 		HumanMessageId: human_message_id.String(),
 		AiMessageId:    ai_message_id.String(),
-	})
+		Final:          true,
+		Thinking:       thinking,
+		ModelName:      modelName,
+		PromptVersion:  prompts.MasterPromptVersion(),
+		TokenUsage:     llmHandlers.CompletedUsage(modelName, tokenUsage),
+	}
+	libraries.SendChatMessageResponse(hub, client, libraries.WebSocketMessageTypeChatCompleted, completed)
+
+}
+
+// agentProfile returns the agent profile picked for the board's chat, falling back to the default profile
+func (w *Workflow) agentProfile(userID uuid.UUID, boardID uuid.UUID) *llmHandlers.AgentProfile {
+	name := ""
+	if board, err := w.boardRepo.GetBoardById(userID, boardID); err != nil {
+		log.Printf("Failed to load agent profile of board %s: %v", boardID, err)
+	} else {
+		name = board.AgentProfile
+	}
+	profile, err := llmHandlers.GetAgentProfile(name)
+	if err != nil {
+		log.Printf("Warning: %v, using the default profile", err)
+		profile, _ = llmHandlers.GetAgentProfile(llmHandlers.DefaultAgentProfile)
+	}
+	return profile
+}
+
+// turnPreferences are the user's settings that change how a turn runs
+type turnPreferences struct {
+	// StoreThinking keeps the reasoning transcript with the reply
+	StoreThinking bool
+	// VerifyDrawings runs the vision check of the agent's drawings
+	VerifyDrawings bool
+	// ConfirmDestructiveTools asks the user to approve deletes and renames before the agent runs them
+	ConfirmDestructiveTools bool
+	// RedactPII keeps emails, phone and card numbers from the model
+	RedactPII bool
+}
 
+// preferencesOf returns the turn preferences of a user loaded with err
+// When the user can't be loaded the optional steps are skipped and the turn is still redacted,
+// which only costs the model some detail
+func preferencesOf(user models.User, err error) turnPreferences {
+	if err != nil {
+		return turnPreferences{RedactPII: true}
+	}
+	return turnPreferences{
+		StoreThinking:           user.StoreThinking,
+		VerifyDrawings:          user.VerifyDrawings,
+		ConfirmDestructiveTools: user.ConfirmDestructiveTools,
+		RedactPII:               user.RedactPII,
+	}
+}
+
+// turnPrompt is the text a turn adds to the prompt, which PII redaction covers as a whole
+type turnPrompt struct {
+	Message             string
+	CanvasState         string
+	CustomRules         string
+	FacilitationContext string
+	ReferenceContext    string
+	Instructions        string
+}
+
+// redact replaces the emails, phone and card numbers of every part with the redactor's placeholders
+func (p turnPrompt) redact(redactor *llmHandlers.Redactor) turnPrompt {
+	return turnPrompt{
+		Message:             redactor.Redact(p.Message),
+		CanvasState:         redactor.Redact(p.CanvasState),
+		CustomRules:         redactor.Redact(p.CustomRules),
+		FacilitationContext: redactor.Redact(p.FacilitationContext),
+		ReferenceContext:    redactor.Redact(p.ReferenceContext),
+		Instructions:        redactor.Redact(p.Instructions),
+	}
+}
+
+// recordVerificationUsage counts the tokens of the drawing check against the user, priced at the verification model
+func recordVerificationUsage(userID uuid.UUID, boardID uuid.UUID, messageID uuid.UUID, model string, usage *llmHandlers.TokenUsage) {
+	provider := ""
+	if info, err := llmHandlers.ValidateModel(model); err == nil {
+		provider = string(info.Provider)
+	}
+	tokenRepo := repo.NewTokenConsumptionRepository(config.DB)
+	if err := tokenRepo.CreateFromUsage(userID, &boardID, &messageID, provider, model, usage); err != nil {
+		log.Printf("Failed to create verification token consumption record: %v", err)
+	}
+	if err := service.IncrementUserTokens(config.DB, userID, usage.TotalTokens); err != nil {
+		log.Printf("Failed to increment user tokens for verification: %v", err)
+	}
 }
 
 // runTokenTrackingOperations runs the token tracking operations asynchronously to avoid latency
-func runTokenTrackingOperations(hub *libraries.Hub, client *libraries.Client, userID uuid.UUID, boardID uuid.UUID, messageID uuid.UUID, provider string, model string, usage *llmHandlers.TokenUsage) {
+func runTokenTrackingOperations(hub *libraries.Hub, client *libraries.Client, userID uuid.UUID, boardID uuid.UUID, messageID uuid.UUID, aiMessageID uuid.UUID, provider string, model string, usage *llmHandlers.TokenUsage) {
 	// 1. Store token consumption record
 	tokenRepo := repo.NewTokenConsumptionRepository(config.DB)
 	if err := tokenRepo.CreateFromUsage(userID, &boardID, &messageID, provider, model, usage); err != nil {
 		log.Printf("Failed to create token consumption record: %v", err)
 	}
 
+	// Store the cost of the turn on the reply
+	if err := repo.NewChatRepository(config.DB).SetMessageCost(aiMessageID, llmHandlers.UsageCost(model, usage)); err != nil {
+		log.Printf("Failed to store message cost: %v", err)
+	}
+
+	// Alert on board and tenant budgets crossing 50/80/100% of their monthly limit
+	budgetService := service.NewBudgetService(repo.NewBudgetRepository(config.DB), repo.NewAuthRepository(config.DB))
+	alerts, err := budgetService.RecordSpend(userID, boardID, time.Now())
+	if err != nil {
+		log.Printf("Failed to check budgets: %v", err)
+	}
+	for _, alert := range alerts {
+		libraries.SendBudgetAlert(hub, client, service.BudgetPayload(alert.Status, alert.Threshold))
+	}
+
 	// 2. Increment user's token consumption
 	if err := service.IncrementUserTokens(config.DB, userID, usage.TotalTokens); err != nil {
 		log.Printf("Failed to increment user tokens: %v", err)
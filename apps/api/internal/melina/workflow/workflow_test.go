@@ -0,0 +1,58 @@
+package workflow
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	llmHandlers "melina-studio-backend/internal/llm_handlers"
+	"melina-studio-backend/internal/models"
+)
+
+func TestTurnPromptRedactsFacilitationNotes(t *testing.T) {
+	prompt := turnPrompt{
+		Message:             "Summarize the retro",
+		FacilitationContext: "Step 2: ask jane.doe@example.com to share, or call her on +1 415 555 0134",
+		CustomRules:         "Send follow-ups to team@example.com",
+		Instructions:        "You are running as the Reviewer agent.",
+	}
+	redactor := llmHandlers.NewRedactor()
+	redacted := prompt.redact(redactor)
+
+	for name, text := range map[string]string{"facilitation": redacted.FacilitationContext, "custom rules": redacted.CustomRules} {
+		if strings.Contains(text, "@example.com") || strings.Contains(text, "555 0134") {
+			t.Errorf("expected the %s to be redacted, got %q", name, text)
+		}
+	}
+	if !strings.Contains(redacted.FacilitationContext, "[EMAIL_") || !strings.Contains(redacted.FacilitationContext, "[PHONE_") {
+		t.Errorf("expected placeholders in the facilitation notes, got %q", redacted.FacilitationContext)
+	}
+	if redacted.Message != prompt.Message || redacted.Instructions != prompt.Instructions {
+		t.Errorf("expected text without PII to stay as it is, got %+v", redacted)
+	}
+	if got := redactor.Restore(redacted.FacilitationContext); got != prompt.FacilitationContext {
+		t.Errorf("expected the notes to be restorable, got %q", got)
+	}
+	if !redactor.Redacted() {
+		t.Error("expected the redactor to report the redaction")
+	}
+}
+
+func TestPreferencesOf(t *testing.T) {
+	user := models.User{StoreThinking: true, RedactPII: false, VerifyDrawings: true}
+	got := preferencesOf(user, nil)
+	want := turnPreferences{StoreThinking: true, VerifyDrawings: true}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	if got := preferencesOf(models.User{}, nil); got.StoreThinking {
+		t.Error("thinking should not be stored for users who didn't opt in")
+	}
+
+	// a user that can't be loaded keeps nothing and is still redacted
+	got = preferencesOf(models.User{StoreThinking: true, ConfirmDestructiveTools: true}, errors.New("connection refused"))
+	if got != (turnPreferences{RedactPII: true}) {
+		t.Errorf("expected only redaction when the user can't be loaded, got %+v", got)
+	}
+}
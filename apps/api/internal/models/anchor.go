@@ -0,0 +1,38 @@
+package models
+
+import "github.com/google/uuid"
+
+type AnchorTarget string
+
+const (
+	AnchorTargetShape AnchorTarget = "shape"
+	AnchorTargetChat  AnchorTarget = "chat"
+)
+
+// Viewport is the camera position the frontend should open the board at
+type Viewport struct {
+	X    float64 `json:"x"`
+	Y    float64 `json:"y"`
+	Zoom float64 `json:"zoom"`
+}
+
+type AnchorBounds struct {
+	X      float64 `json:"x"`
+	Y      float64 `json:"y"`
+	Width  float64 `json:"width"`
+	Height float64 `json:"height"`
+}
+
+// BoardAnchor is a deep link to a shape or chat message on a board
+// Anchor is the stable token the frontend passes back as ?anchor= when loading the board
+type BoardAnchor struct {
+	Anchor   string        `json:"anchor"`
+	BoardID  uuid.UUID     `json:"board_id"`
+	Target   AnchorTarget  `json:"target"`
+	ShapeID  *uuid.UUID    `json:"shape_id,omitempty"`
+	ChatID   *uuid.UUID    `json:"chat_id,omitempty"`
+	Found    bool          `json:"found"`
+	Bounds   *AnchorBounds `json:"bounds,omitempty"`
+	Viewport *Viewport     `json:"viewport,omitempty"`
+	URL      string        `json:"url,omitempty"`
+}
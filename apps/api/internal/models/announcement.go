@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type AnnouncementKind string
+
+const (
+	AnnouncementFeature     AnnouncementKind = "feature"
+	AnnouncementImprovement AnnouncementKind = "improvement"
+	AnnouncementFix         AnnouncementKind = "fix"
+	AnnouncementNotice      AnnouncementKind = "notice"
+)
+
+// Announcement is a changelog entry or product notice shown in the app
+// It is a draft until PublishedAt is set; publishing pushes it to every open connection
+type Announcement struct {
+	UUID        uuid.UUID        `gorm:"type:uuid;primaryKey" json:"uuid"`
+	Title       string           `gorm:"type:varchar(200);not null" json:"title"`
+	Body        string           `gorm:"type:text;not null" json:"body"` // markdown
+	Kind        AnnouncementKind `gorm:"type:varchar(20);not null;default:'feature'" json:"kind"`
+	Link        string           `gorm:"type:varchar(500)" json:"link,omitempty"`
+	PublishedAt *time.Time       `gorm:"index" json:"published_at,omitempty"`
+	CreatedAt   time.Time        `json:"created_at"`
+	UpdatedAt   time.Time        `json:"updated_at"`
+}
+
+// AnnouncementRead records that a user has read an announcement
+type AnnouncementRead struct {
+	AnnouncementID uuid.UUID `gorm:"type:uuid;primaryKey" json:"announcement_id"`
+	UserID         uuid.UUID `gorm:"type:uuid;primaryKey;index" json:"user_id"`
+	ReadAt         time.Time `json:"read_at"`
+}
+
+// UserAnnouncement is a published announcement with whether the user has read it
+type UserAnnouncement struct {
+	Announcement
+	Read bool `json:"read"`
+}
@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// APIKey lets an integration such as Zapier or Make act as its user on the integration routes. Only the key's
+// hash is stored; the key itself is shown once, when it is created
+type APIKey struct {
+	UUID   uuid.UUID `gorm:"type:uuid;primaryKey" json:"uuid"`
+	UserID uuid.UUID `gorm:"type:uuid;not null;index" json:"user_id"`
+	Name   string    `gorm:"type:varchar(100);not null" json:"name"`
+	// Prefix is the start of the key, to tell the keys apart
+	Prefix     string     `gorm:"type:varchar(16);not null" json:"prefix"`
+	KeyHash    string     `gorm:"type:varchar(64);not null;uniqueIndex" json:"-"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
@@ -0,0 +1,66 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+type AutomationTrigger string
+
+const (
+	AutomationTriggerShapeCreated AutomationTrigger = "shape_created"
+	AutomationTriggerShapeUpdated AutomationTrigger = "shape_updated"
+)
+
+type AutomationAction string
+
+const (
+	// AutomationActionAgentPrompt runs the board agent with the rule's prompt
+	AutomationActionAgentPrompt AutomationAction = "agent_prompt"
+	// AutomationActionWebhook posts the event to the rule's URL
+	AutomationActionWebhook AutomationAction = "webhook"
+	// AutomationActionMoveToFrame moves the shape into the rule's frame
+	AutomationActionMoveToFrame AutomationAction = "move_to_frame"
+)
+
+type AutomationRunStatus string
+
+const (
+	AutomationRunSucceeded AutomationRunStatus = "succeeded"
+	AutomationRunFailed    AutomationRunStatus = "failed"
+)
+
+// AutomationRule is a board owner's "when a shape matching the filters is created or updated, do this" rule.
+// Rules only fire on the user's own edits, so what an automation or the agent changes can't set off another run
+type AutomationRule struct {
+	UUID    uuid.UUID         `gorm:"type:uuid;primaryKey" json:"uuid"`
+	BoardID uuid.UUID         `gorm:"type:uuid;not null;index" json:"board_id"`
+	UserID  uuid.UUID         `gorm:"type:uuid;not null" json:"user_id"`
+	Name    string            `gorm:"type:varchar(100);not null" json:"name"`
+	Enabled bool              `gorm:"not null;default:true" json:"enabled"`
+	Trigger AutomationTrigger `gorm:"type:varchar(20);not null" json:"trigger"`
+	// Filters narrow the shapes the rule fires for: {"shape_types": [...], "tag": "...", "text_contains": "..."}
+	Filters datatypes.JSON   `gorm:"type:jsonb" json:"filters,omitempty"`
+	Action  AutomationAction `gorm:"type:varchar(20);not null" json:"action"`
+	// ActionConfig is {"prompt": ...}, {"url": ...} or {"frame": ...} depending on the action
+	ActionConfig datatypes.JSON `gorm:"type:jsonb;not null" json:"action_config"`
+	LastRunAt    *time.Time     `json:"last_run_at,omitempty"`
+	CreatedAt    time.Time      `json:"created_at"`
+	UpdatedAt    time.Time      `json:"updated_at"`
+}
+
+// AutomationRun is an entry of a rule's execution log
+type AutomationRun struct {
+	UUID       uuid.UUID           `gorm:"type:uuid;primaryKey" json:"uuid"`
+	RuleID     uuid.UUID           `gorm:"type:uuid;not null;index:idx_automation_run_rule_created,priority:1" json:"rule_id"`
+	BoardID    uuid.UUID           `gorm:"type:uuid;not null" json:"board_id"`
+	ShapeID    *uuid.UUID          `gorm:"type:uuid" json:"shape_id,omitempty"`
+	Trigger    AutomationTrigger   `gorm:"type:varchar(20);not null" json:"trigger"`
+	Status     AutomationRunStatus `gorm:"type:varchar(20);not null" json:"status"`
+	Output     string              `gorm:"type:text" json:"output,omitempty"`
+	Error      string              `gorm:"type:text" json:"error,omitempty"`
+	DurationMs int64               `gorm:"not null;default:0" json:"duration_ms"`
+	CreatedAt  time.Time           `gorm:"index:idx_automation_run_rule_created,priority:2" json:"created_at"`
+}
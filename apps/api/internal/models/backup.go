@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// TenantBackupVersion is bumped whenever the backup format changes incompatibly
+const TenantBackupVersion = 1
+
+// TenantBackup is the full dump of a user's boards, shapes and chats
+type TenantBackup struct {
+	Version   int           `json:"version"`
+	ID        string        `json:"id"`
+	UserID    uuid.UUID     `json:"user_id"`
+	CreatedAt time.Time     `json:"created_at"`
+	Boards    []Board       `json:"boards"`
+	BoardData []BoardData   `json:"board_data"`
+	Chats     []Chat        `json:"chats"`
+	Assets    []BackupAsset `json:"assets"`
+}
+
+// BackupAsset maps a storage object referenced by the tenant's data to its copy inside the backup
+type BackupAsset struct {
+	ObjectKey string `json:"object_key"`
+	BackupKey string `json:"backup_key"`
+}
+
+// BackupInfo summarizes a backup
+type BackupInfo struct {
+	ID        string    `json:"id"`
+	UserID    uuid.UUID `json:"user_id"`
+	CreatedAt time.Time `json:"created_at"`
+	Boards    int       `json:"boards"`
+	Shapes    int       `json:"shapes"`
+	Chats     int       `json:"chats"`
+	Assets    int       `json:"assets"`
+	Location  string    `json:"location"`
+}
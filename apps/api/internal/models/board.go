@@ -8,13 +8,48 @@ import (
 
 // Board represents the database model
 type Board struct {
-	UUID               uuid.UUID `gorm:"column:uuid;primarykey" json:"uuid"`
-	Title              string    `gorm:"not null" json:"title"`
-	UserID             uuid.UUID `gorm:"not null" json:"user_id"`
-	Starred            bool      `gorm:"default:false" json:"starred"`
-	IsDeleted          bool      `gorm:"default:false" json:"is_deleted"`
-	Thumbnail          string    `json:"thumbnail"`
-	AnnotatedImageHash string    `gorm:"default:''" json:"annotated_image_hash"`
-	CreatedAt          time.Time `json:"created_at"`
-	UpdatedAt          time.Time `json:"updated_at"`
+	UUID               uuid.UUID  `gorm:"column:uuid;primarykey" json:"uuid"`
+	Title              string     `gorm:"not null" json:"title"`
+	UserID             uuid.UUID  `gorm:"not null" json:"user_id"`
+	TenantID           *uuid.UUID `gorm:"type:uuid;index" json:"tenant_id,omitempty"`
+	Starred            bool       `gorm:"default:false" json:"starred"`
+	IsDeleted          bool       `gorm:"default:false" json:"is_deleted"`
+	Thumbnail          string     `json:"thumbnail"`
+	CoverImage         string     `gorm:"default:''" json:"cover_image"` // Uploaded cover, or one cropped from the board preview
+	Icon               string     `gorm:"default:''" json:"icon"`        // Emoji shown with the title
+	Description        string     `gorm:"default:''" json:"description"`
+	AnnotatedImageHash string     `gorm:"default:''" json:"annotated_image_hash"`
+	AgentProfile       string     `gorm:"not null;default:'default'" json:"agent_profile"` // Agent profile of the board's chat, which decides the tools it gets
+	GridSize           int        `gorm:"not null;default:20" json:"grid_size"`            // Spacing of the board's grid in canvas pixels
+	SnapToGrid         bool       `gorm:"default:false" json:"snap_to_grid"`               // When set, shape coordinates the agent or a save sends are rounded to the grid
+	TimelineOrigin     string     `gorm:"default:''" json:"timeline_origin"`               // Date (YYYY-MM-DD) at TimelineOriginX on the board's time scale, empty until the first timeline item
+	TimelineOriginX    float64    `gorm:"not null;default:0" json:"timeline_origin_x"`     // Canvas x where the origin date begins
+	TimelineDayWidth   float64    `gorm:"not null;default:20" json:"timeline_day_width"`   // Canvas pixels per day of timeline and milestone shapes
+	IsArchived         bool       `gorm:"default:false;index" json:"is_archived"`          // Archived boards are kept as they are but left out of the default board list
+	ArchivedAt         *time.Time `json:"archived_at,omitempty"`
+	IsSandbox          bool       `gorm:"default:false;index" json:"is_sandbox"` // Sandbox boards are for experiments: hidden from board lists and search, deleted once they expire
+	ExpiresAt          *time.Time `json:"expires_at,omitempty"`                  // When the cleanup service deletes a sandbox board
+	CreatedAt          time.Time  `json:"created_at"`
+	UpdatedAt          time.Time  `json:"updated_at"`
+}
+
+// BoardGrid is a change to a board's grid settings; nil fields are left as they are
+type BoardGrid struct {
+	GridSize   *int
+	SnapToGrid *bool
+}
+
+// BoardTimeline is a change to a board's time scale; nil fields are left as they are
+type BoardTimeline struct {
+	Origin   *string
+	OriginX  *float64
+	DayWidth *float64
+}
+
+// BoardCover is a change to a board's cover, icon and description; nil fields are left as they are
+// and an empty string clears the field
+type BoardCover struct {
+	CoverImage  *string
+	Icon        *string
+	Description *string
 }
@@ -0,0 +1,45 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+type ActivityType string
+
+const (
+	ActivityShapeAdded      ActivityType = "shape_added"
+	ActivityShapeRenamed    ActivityType = "shape_renamed"
+	ActivityShapeDeleted    ActivityType = "shape_deleted"
+	ActivityBoardRenamed    ActivityType = "board_renamed"
+	ActivityBoardCleared    ActivityType = "board_cleared"
+	ActivityBoardArchived   ActivityType = "board_archived"
+	ActivityBoardUnarchived ActivityType = "board_unarchived"
+	ActivityAgentRun        ActivityType = "agent_run"
+	ActivityCardMoved       ActivityType = "card_moved"
+)
+
+type ActivityActor string
+
+const (
+	ActivityActorUser  ActivityActor = "user"
+	ActivityActorAgent ActivityActor = "agent"
+)
+
+// BoardActivity is an entry of a board's operation log, shown as the board's activity feed
+// Count is greater than 1 when several changes of the same type were saved at once
+type BoardActivity struct {
+	UUID      uuid.UUID      `gorm:"type:uuid;primaryKey" json:"uuid"`
+	BoardID   uuid.UUID      `gorm:"type:uuid;not null;index:idx_board_activity_board_created,priority:1" json:"board_id"`
+	UserID    uuid.UUID      `gorm:"type:uuid;not null" json:"user_id"`
+	Type      ActivityType   `gorm:"type:varchar(30);not null" json:"type"`
+	Actor     ActivityActor  `gorm:"type:varchar(10);not null" json:"actor"`
+	ShapeID   *uuid.UUID     `gorm:"type:uuid" json:"shape_id,omitempty"`
+	ShapeType string         `gorm:"type:varchar(30)" json:"shape_type,omitempty"`
+	Count     int            `gorm:"default:1" json:"count"`
+	Summary   string         `gorm:"type:text" json:"summary"`
+	Metadata  datatypes.JSON `gorm:"type:jsonb" json:"metadata,omitempty"`
+	CreatedAt time.Time      `gorm:"index:idx_board_activity_board_created,priority:2" json:"created_at"`
+}
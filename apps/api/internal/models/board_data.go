@@ -1,6 +1,7 @@
 package models
 
 import (
+	"encoding/json"
 	"time"
 
 	"github.com/google/uuid"
@@ -21,6 +22,21 @@ const (
 	Polygon Type = "polygon"
 	Path    Type = "path"
 	Frame   Type = "frame"
+
+	ActionItemCard Type = "action_item"
+
+	// Roadmap shapes whose x and width follow their dates on the board's time scale
+	Timeline  Type = "timeline"
+	Milestone Type = "milestone"
+
+	// Wireframe UI components
+	Button Type = "button"
+	Input  Type = "input"
+	Navbar Type = "navbar"
+	Card   Type = "card"
+
+	// ERD entity (database table) shape
+	Entity Type = "entity"
 )
 
 type BoardData struct {
@@ -30,6 +46,8 @@ type BoardData struct {
 	Data             datatypes.JSON `json:"data"`
 	ImageUrl         *string        `json:"image_url,omitempty"`
 	AnnotationNumber int            `gorm:"not null;default:0" json:"annotation_number"`
+	Locked           bool           `gorm:"not null;default:false" json:"locked"` // Locked shapes can't be changed or deleted, by the user or the agent, until unlocked
+	SlideOrder       *int           `json:"slide_order,omitempty"`                // Position of a frame in the board's presentation, nil when it isn't a slide
 	CreatedAt        time.Time      `json:"created_at"`
 	UpdatedAt        time.Time      `json:"updated_at"`
 }
@@ -51,9 +69,75 @@ type Shape struct {
 	FontFamily  *string    `json:"fontFamily,omitempty"`
 	Data        *string    `json:"data,omitempty"` // SVG path data string for path shapes
 	Name        *string    `json:"name,omitempty"` // Label text for frame shapes
+	// KanbanStatus puts a frame in kanban mode: it is a column and the action item cards in it get this status
+	KanbanStatus *string `json:"kanbanStatus,omitempty"`
 	// Arrow-specific fields (new format)
 	Start         map[string]float64 `json:"start,omitempty"`
 	End           map[string]float64 `json:"end,omitempty"`
 	Bend          *float64           `json:"bend,omitempty"`
 	ArrowHeadSize *float64           `json:"arrowHeadSize,omitempty"`
+	// Action item card fields
+	Assignee *string `json:"assignee,omitempty"`
+	DueDate  *string `json:"dueDate,omitempty"` // YYYY-MM-DD
+	Status   *string `json:"status,omitempty"`  // open or done
+	// Timeline fields; a milestone only has a start date
+	StartDate *string `json:"startDate,omitempty"` // YYYY-MM-DD
+	EndDate   *string `json:"endDate,omitempty"`   // YYYY-MM-DD, inclusive
+	// Wireframe UI component fields
+	Variant     *string   `json:"variant,omitempty"`     // button style: primary, secondary or ghost
+	Placeholder *string   `json:"placeholder,omitempty"` // input placeholder text
+	Items       *[]string `json:"items,omitempty"`       // navbar links or card body lines
+	// ERD entity fields (the table name is stored in Name)
+	Columns *[]EntityColumn `json:"columns,omitempty"`
+}
+
+// EntityColumn is an attribute of an ERD entity shape
+type EntityColumn struct {
+	Name       string `json:"name"`
+	Type       string `json:"type"`
+	PrimaryKey bool   `json:"pk,omitempty"`
+	NotNull    bool   `json:"notNull,omitempty"`
+	Unique     bool   `json:"unique,omitempty"`
+	References string `json:"references,omitempty"` // "table.column" for foreign keys
+}
+
+// ActionItem is the tracker-friendly view of an action_item card shape
+type ActionItem struct {
+	ID        string    `json:"id"`
+	BoardID   string    `json:"board_id"`
+	Title     string    `json:"title"`
+	Assignee  *string   `json:"assignee,omitempty"`
+	DueDate   *string   `json:"due_date,omitempty"`
+	Status    string    `json:"status"`
+	X         float64   `json:"x"`
+	Y         float64   `json:"y"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
+
+// NewActionItem converts a stored action_item shape to its tracker view
+func NewActionItem(shape BoardData) (ActionItem, error) {
+	var data map[string]interface{}
+	if err := json.Unmarshal(shape.Data, &data); err != nil {
+		return ActionItem{}, err
+	}
+
+	item := ActionItem{
+		ID:        shape.UUID.String(),
+		BoardID:   shape.BoardId.String(),
+		Status:    "open",
+		UpdatedAt: shape.UpdatedAt,
+	}
+	item.Title, _ = data["text"].(string)
+	item.X, _ = data["x"].(float64)
+	item.Y, _ = data["y"].(float64)
+	if status, ok := data["status"].(string); ok && status != "" {
+		item.Status = status
+	}
+	if assignee, ok := data["assignee"].(string); ok && assignee != "" {
+		item.Assignee = &assignee
+	}
+	if dueDate, ok := data["dueDate"].(string); ok && dueDate != "" {
+		item.DueDate = &dueDate
+	}
+	return item, nil
 }
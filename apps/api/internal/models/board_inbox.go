@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// BoardInbox is the email address of a board: the emails sent or forwarded to it become sticky notes on the board
+type BoardInbox struct {
+	UUID    uuid.UUID `gorm:"type:uuid;primaryKey" json:"uuid"`
+	BoardID uuid.UUID `gorm:"type:uuid;not null;uniqueIndex" json:"board_id"`
+	UserID  uuid.UUID `gorm:"type:uuid;not null" json:"user_id"`
+	// Token is the address's local part; rotating it retires the old address
+	Token   string `gorm:"type:varchar(32);not null;uniqueIndex" json:"-"`
+	Address string `gorm:"-" json:"address"`
+	// Summarize has the board's agent add a summary next to every email's note
+	Summarize bool `gorm:"not null;default:false" json:"summarize"`
+	// AllowedSenders are the addresses, or "@example.com" domains, emails are accepted from; empty accepts anyone
+	AllowedSenders datatypes.JSON `gorm:"type:jsonb" json:"allowed_senders"`
+	LastReceivedAt *time.Time     `json:"last_received_at,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SummaryStatus string
+
+const (
+	SummaryStatusPending   SummaryStatus = "pending"
+	SummaryStatusRunning   SummaryStatus = "running"
+	SummaryStatusCompleted SummaryStatus = "completed"
+	SummaryStatusFailed    SummaryStatus = "failed"
+)
+
+// BoardSummary stores a generated markdown summary of a board (decisions, open questions, action items)
+type BoardSummary struct {
+	UUID      uuid.UUID     `gorm:"type:uuid;primaryKey" json:"uuid"`
+	BoardID   uuid.UUID     `gorm:"type:uuid;not null;index" json:"board_id"`
+	UserID    uuid.UUID     `gorm:"type:uuid;not null;index" json:"user_id"`
+	Status    SummaryStatus `gorm:"type:varchar(20);not null;default:'pending'" json:"status"`
+	ModelName string        `gorm:"type:varchar(100)" json:"model_name"`
+	Markdown  string        `gorm:"type:text" json:"markdown,omitempty"`
+	Error     string        `gorm:"type:text" json:"error,omitempty"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// BoardVersionTrigger is what took a board snapshot
+type BoardVersionTrigger string
+
+const (
+	// BoardVersionManual is a snapshot the owner asked for
+	BoardVersionManual BoardVersionTrigger = "manual"
+	// BoardVersionAuto is a periodic snapshot of a board whose shapes changed
+	BoardVersionAuto BoardVersionTrigger = "auto"
+	// BoardVersionBeforeRestore is the canvas as it was before a restore, so the restore can be undone
+	BoardVersionBeforeRestore BoardVersionTrigger = "before_restore"
+)
+
+// BoardVersion is a snapshot of every shape of a board
+type BoardVersion struct {
+	UUID    uuid.UUID           `gorm:"type:uuid;primaryKey" json:"uuid"`
+	BoardID uuid.UUID           `gorm:"type:uuid;not null;index:idx_board_version_board_created,priority:1" json:"board_id"`
+	UserID  uuid.UUID           `gorm:"type:uuid;not null" json:"user_id"`
+	Label   string              `gorm:"type:varchar(100)" json:"label,omitempty"`
+	Trigger BoardVersionTrigger `gorm:"type:varchar(20);not null" json:"trigger"`
+	// Shapes are the board's BoardData rows as they were; they are left out of listings
+	Shapes     datatypes.JSON `gorm:"type:jsonb;not null" json:"-"`
+	ShapeCount int            `gorm:"not null;default:0" json:"shape_count"`
+	// Checksum identifies the shapes, so periodic snapshots of an unchanged board are skipped
+	Checksum  string    `gorm:"type:varchar(64);not null" json:"-"`
+	CreatedAt time.Time `gorm:"index:idx_board_version_board_created,priority:2" json:"created_at"`
+}
@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// BoardViewport is where a user last left the camera on a board, restored when they open it again
+type BoardViewport struct {
+	UserID    uuid.UUID `gorm:"type:uuid;primaryKey" json:"-"`
+	BoardID   uuid.UUID `gorm:"type:uuid;primaryKey" json:"board_id"`
+	X         float64   `gorm:"not null" json:"x"`
+	Y         float64   `gorm:"not null" json:"y"`
+	Zoom      float64   `gorm:"not null;default:1" json:"zoom"`
+	UpdatedAt time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
@@ -0,0 +1,42 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type BudgetScope string
+
+const (
+	BudgetScopeBoard  BudgetScope = "board"
+	BudgetScopeTenant BudgetScope = "tenant"
+)
+
+// Budget caps the monthly LLM spend of a board or a tenant; the period is the calendar month (UTC)
+type Budget struct {
+	UUID            uuid.UUID   `gorm:"type:uuid;primaryKey" json:"uuid"`
+	Scope           BudgetScope `gorm:"type:varchar(16);not null;uniqueIndex:idx_budget_scope" json:"scope"`
+	ScopeID         uuid.UUID   `gorm:"type:uuid;not null;uniqueIndex:idx_budget_scope" json:"scope_id"`
+	MonthlyLimitUSD float64     `gorm:"column:monthly_limit_usd;not null" json:"monthly_limit_usd"`
+	// HardStop refuses new agent turns once the limit is reached, until the period resets
+	HardStop bool `gorm:"not null;default:false" json:"hard_stop"`
+	// AlertEmail receives the threshold emails; board budgets fall back to the board owner
+	AlertEmail string `gorm:"type:varchar(255)" json:"alert_email,omitempty"`
+	// AlertedThreshold is the highest threshold already alerted in AlertedPeriod, so each alert goes out once
+	AlertedThreshold int        `gorm:"not null;default:0" json:"alerted_threshold"`
+	AlertedPeriod    *time.Time `json:"alerted_period,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        time.Time  `json:"updated_at"`
+}
+
+// BudgetStatus is a budget with its spend in the current period
+type BudgetStatus struct {
+	Budget      *Budget   `json:"budget"`
+	SpentUSD    float64   `json:"spent_usd"`
+	Percentage  float64   `json:"percentage"`
+	PeriodStart time.Time `json:"period_start"`
+	PeriodEnd   time.Time `json:"period_end"`
+	// Blocked is set when the budget is spent and hard-stopped
+	Blocked bool `json:"blocked"`
+}
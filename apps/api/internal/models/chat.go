@@ -18,7 +18,8 @@ type Chat struct {
 	BoardUUID uuid.UUID `gorm:"not null" json:"board_uuid"`
 	Content   string    `gorm:"not null" json:"content"`
 	Role      Role      `gorm:"not null" json:"role"`
-	Thought   *string   `gorm:"type:text" json:"thought,omitempty"` // Only for assistant messages (thinking/reasoning content)
+	Thought   *string   `gorm:"type:text" json:"thought,omitempty"`        // Only for assistant messages (thinking/reasoning content)
+	CostUSD   *float64  `gorm:"column:cost_usd" json:"cost_usd,omitempty"` // Only for assistant messages, the cost of the whole turn
 	CreatedAt time.Time `json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
 }
@@ -0,0 +1,44 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// CleanupSettingsID is the key of the only cleanup settings row
+const CleanupSettingsID = 1
+
+// CleanupSettings are the cleanup job's settings changed through the admin API; they replace the
+// environment's once stored, so restarts keep them
+type CleanupSettings struct {
+	ID              int  `gorm:"primaryKey" json:"-"`
+	Enabled         bool `gorm:"not null" json:"enabled"`
+	IntervalMinutes int  `gorm:"not null" json:"interval_minutes"` // time between scheduled runs
+	// UploadMaxAgeMinutes is how long temporary uploads are kept before a run deletes them
+	UploadMaxAgeMinutes int       `gorm:"not null" json:"upload_max_age_minutes"`
+	UpdatedAt           time.Time `json:"updated_at"`
+}
+
+type CleanupTrigger string
+
+const (
+	CleanupTriggerScheduled CleanupTrigger = "scheduled"
+	CleanupTriggerManual    CleanupTrigger = "manual"
+)
+
+// CleanupRun is the outcome of one pass of the cleanup job
+type CleanupRun struct {
+	UUID             uuid.UUID      `gorm:"type:uuid;primaryKey" json:"uuid"`
+	Trigger          CleanupTrigger `gorm:"type:varchar(20);not null" json:"trigger"`
+	StartedAt        time.Time      `gorm:"not null;index" json:"started_at"`
+	DurationMs       int64          `json:"duration_ms"`
+	UploadsDeleted   int            `json:"uploads_deleted"`
+	UploadsFailed    int            `json:"uploads_failed"`
+	SandboxesDeleted int            `json:"sandboxes_deleted"`
+	SandboxesFailed  int            `json:"sandboxes_failed"`
+	PoliciesApplied  int            `json:"retention_policies_applied"`
+	PoliciesFailed   int            `json:"retention_policies_failed"`
+	// Errors are the failures that stopped a task altogether, e.g. a database error listing its work
+	Errors string `gorm:"type:text" json:"errors,omitempty"`
+}
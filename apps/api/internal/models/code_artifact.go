@@ -0,0 +1,28 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type CodeFramework string
+
+const (
+	CodeFrameworkHTML  CodeFramework = "html"
+	CodeFrameworkReact CodeFramework = "react"
+)
+
+// CodeArtifact stores component code generated from a wireframe frame
+type CodeArtifact struct {
+	UUID      uuid.UUID     `gorm:"type:uuid;primaryKey" json:"uuid"`
+	BoardID   uuid.UUID     `gorm:"type:uuid;not null;index" json:"board_id"`
+	UserID    uuid.UUID     `gorm:"type:uuid;not null;index" json:"user_id"`
+	FrameID   uuid.UUID     `gorm:"type:uuid;not null" json:"frame_id"`
+	FrameName string        `gorm:"type:varchar(255)" json:"frame_name"`
+	Framework CodeFramework `gorm:"type:varchar(20);not null" json:"framework"`
+	ModelName string        `gorm:"type:varchar(100)" json:"model_name"`
+	Code      string        `gorm:"type:text" json:"code,omitempty"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt time.Time     `json:"updated_at"`
+}
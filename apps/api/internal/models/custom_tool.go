@@ -0,0 +1,33 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// CustomTool is an agent tool declared as data: the model calls it with arguments matching Parameters and
+// the arguments are sent to an HTTP endpoint, so deployments add integrations without a release
+type CustomTool struct {
+	UUID uuid.UUID `gorm:"type:uuid;primaryKey" json:"uuid"`
+	// Name is what the model calls, e.g. createJiraTicket; it can't shadow a built-in tool
+	Name        string `gorm:"type:varchar(64);not null;uniqueIndex" json:"name"`
+	Description string `gorm:"type:text;not null" json:"description"`
+	// Parameters is the JSON schema of the arguments, an object schema
+	Parameters datatypes.JSON `gorm:"type:jsonb;not null" json:"parameters"`
+	// Method is GET, POST, PUT, PATCH or DELETE; GET and DELETE send the arguments as query parameters, the others as a JSON body
+	Method string `gorm:"type:varchar(10);not null;default:'POST'" json:"method"`
+	// URL is the endpoint; {name} placeholders in it are filled with the arguments of that name
+	URL string `gorm:"type:text;not null" json:"url"`
+	// Headers are sent with every call; {{env:CUSTOM_TOOL_*}} in a value is replaced with that environment variable,
+	// so secrets stay out of the database
+	Headers datatypes.JSON `gorm:"type:jsonb" json:"headers,omitempty"`
+	// TimeoutSeconds bounds each call; 0 uses CUSTOM_TOOLS_TIMEOUT_SECONDS
+	TimeoutSeconds int `gorm:"not null;default:0" json:"timeout_seconds"`
+	// RequiresApproval asks users who confirm destructive tools before the call is made
+	RequiresApproval bool      `gorm:"not null;default:false" json:"requires_approval"`
+	Enabled          bool      `gorm:"not null;default:true" json:"enabled"`
+	CreatedAt        time.Time `json:"created_at"`
+	UpdatedAt        time.Time `json:"updated_at"`
+}
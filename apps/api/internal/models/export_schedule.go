@@ -0,0 +1,80 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+type ExportFormat string
+
+const (
+	ExportFormatPNG  ExportFormat = "png"
+	ExportFormatSVG  ExportFormat = "svg"
+	ExportFormatJSON ExportFormat = "json"
+)
+
+type ExportFrequency string
+
+const (
+	ExportFrequencyNightly ExportFrequency = "nightly"
+	ExportFrequencyWeekly  ExportFrequency = "weekly"
+)
+
+type ExportDestination string
+
+const (
+	// ExportDestinationGCS writes to a Google Cloud Storage bucket with the user's service account key
+	ExportDestinationGCS ExportDestination = "gcs"
+	// ExportDestinationS3 writes to an S3 or S3-compatible bucket with the user's access key
+	ExportDestinationS3 ExportDestination = "s3"
+	// ExportDestinationWebhook posts the export to the user's URL, signed when a secret is set
+	ExportDestinationWebhook ExportDestination = "webhook"
+)
+
+type ExportRunStatus string
+
+const (
+	ExportRunSucceeded ExportRunStatus = "succeeded"
+	ExportRunFailed    ExportRunStatus = "failed"
+)
+
+// ExportSchedule pushes a board's export to an external destination every night or week
+type ExportSchedule struct {
+	UUID      uuid.UUID       `gorm:"type:uuid;primaryKey" json:"uuid"`
+	BoardID   uuid.UUID       `gorm:"type:uuid;not null;index" json:"board_id"`
+	UserID    uuid.UUID       `gorm:"type:uuid;not null" json:"user_id"`
+	Format    ExportFormat    `gorm:"type:varchar(10);not null" json:"format"`
+	Frequency ExportFrequency `gorm:"type:varchar(10);not null" json:"frequency"`
+	// Hour is the UTC hour the export runs at; Weekday (0 is Sunday) the day of the weekly ones
+	Hour        int               `gorm:"not null" json:"hour"`
+	Weekday     int               `gorm:"not null;default:0" json:"weekday"`
+	Destination ExportDestination `gorm:"type:varchar(10);not null" json:"destination"`
+	// Target is where the export goes, without secrets: {"bucket", "prefix", "region", "endpoint"} or {"url"}
+	Target datatypes.JSON `gorm:"type:jsonb;not null" json:"target"`
+	// Credentials are the destination's service account key, access key or webhook secret, encrypted with
+	// CREDENTIALS_ENCRYPTION_KEY; they are never sent back to the client
+	Credentials    string     `gorm:"type:text" json:"-"`
+	HasCredentials bool       `gorm:"-" json:"has_credentials"`
+	Enabled        bool       `gorm:"not null;default:true" json:"enabled"`
+	NextRunAt      time.Time  `gorm:"not null;index" json:"next_run_at"`
+	LastRunAt      *time.Time `json:"last_run_at,omitempty"`
+	LastError      string     `gorm:"type:text" json:"last_error,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+	UpdatedAt      time.Time  `json:"updated_at"`
+}
+
+// ExportRun is an entry of a schedule's delivery log
+type ExportRun struct {
+	UUID       uuid.UUID       `gorm:"type:uuid;primaryKey" json:"uuid"`
+	ScheduleID uuid.UUID       `gorm:"type:uuid;not null;index:idx_export_run_schedule_created,priority:1" json:"schedule_id"`
+	BoardID    uuid.UUID       `gorm:"type:uuid;not null" json:"board_id"`
+	Status     ExportRunStatus `gorm:"type:varchar(20);not null" json:"status"`
+	// Location is the object written or the URL posted to
+	Location   string    `gorm:"type:text" json:"location,omitempty"`
+	Bytes      int64     `gorm:"not null;default:0" json:"bytes"`
+	Error      string    `gorm:"type:text" json:"error,omitempty"`
+	DurationMs int64     `gorm:"not null;default:0" json:"duration_ms"`
+	CreatedAt  time.Time `gorm:"index:idx_export_run_schedule_created,priority:2" json:"created_at"`
+}
@@ -0,0 +1,59 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type FacilitationKind string
+
+const (
+	FacilitationRetro      FacilitationKind = "retro"
+	FacilitationBrainstorm FacilitationKind = "brainstorm"
+)
+
+type FacilitationStep string
+
+const (
+	FacilitationStepStart     FacilitationStep = "start"
+	FacilitationStepColumns   FacilitationStep = "create_columns"
+	FacilitationStepCollect   FacilitationStep = "collect_notes"
+	FacilitationStepCluster   FacilitationStep = "cluster"
+	FacilitationStepSummarize FacilitationStep = "summarize"
+	FacilitationStepCompleted FacilitationStep = "completed"
+)
+
+// FacilitationSteps is the ordered list of steps every facilitation session walks through
+var FacilitationSteps = []FacilitationStep{
+	FacilitationStepStart,
+	FacilitationStepColumns,
+	FacilitationStepCollect,
+	FacilitationStepCluster,
+	FacilitationStepSummarize,
+	FacilitationStepCompleted,
+}
+
+// FacilitationSession tracks a server-driven retro/brainstorm flow on a board
+type FacilitationSession struct {
+	UUID      uuid.UUID        `gorm:"type:uuid;primaryKey" json:"uuid"`
+	BoardID   uuid.UUID        `gorm:"type:uuid;not null;index" json:"board_id"`
+	UserID    uuid.UUID        `gorm:"type:uuid;not null;index" json:"user_id"`
+	Kind      FacilitationKind `gorm:"type:varchar(20);not null" json:"kind"`
+	Topic     string           `gorm:"type:text" json:"topic"`
+	Step      FacilitationStep `gorm:"type:varchar(30);not null" json:"step"`
+	IsActive  bool             `gorm:"default:true;index" json:"is_active"`
+	CreatedAt time.Time        `json:"created_at"`
+	UpdatedAt time.Time        `json:"updated_at"`
+}
+
+// NextFacilitationStep returns the step that follows the given one
+// The completed step is terminal and returns itself
+func NextFacilitationStep(step FacilitationStep) FacilitationStep {
+	for i, s := range FacilitationSteps {
+		if s == step && i+1 < len(FacilitationSteps) {
+			return FacilitationSteps[i+1]
+		}
+	}
+	return FacilitationStepCompleted
+}
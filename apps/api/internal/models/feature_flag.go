@@ -0,0 +1,36 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// FeatureFlag gates a feature that is rolled out gradually or toggled without a deploy
+type FeatureFlag struct {
+	UUID        uuid.UUID `gorm:"type:uuid;primaryKey" json:"uuid"`
+	Key         string    `gorm:"type:varchar(100);not null;uniqueIndex" json:"key"`
+	Description string    `gorm:"type:text" json:"description,omitempty"`
+	// Enabled is the kill switch; a disabled flag is off for everyone
+	Enabled bool `gorm:"not null;default:false" json:"enabled"`
+	// RolloutPercentage is the share of users (0-100) the flag is on for, bucketed by user ID
+	RolloutPercentage int `gorm:"not null;default:0" json:"rollout_percentage"`
+	// UserIDs always get the flag while it is enabled, regardless of the rollout
+	UserIDs   datatypes.JSON `gorm:"type:jsonb" json:"user_ids,omitempty"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+}
+
+// TargetedUserIDs returns the users the flag is always on for
+func (f *FeatureFlag) TargetedUserIDs() []string {
+	if len(f.UserIDs) == 0 {
+		return nil
+	}
+	var ids []string
+	if err := json.Unmarshal(f.UserIDs, &ids); err != nil {
+		return nil
+	}
+	return ids
+}
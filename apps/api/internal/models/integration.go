@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// The integration items are flat, as Zapier and Make expect. Cursor is the item's creation time in
+// microseconds; passed back as since, it returns the items created after this one
+
+// IntegrationBoard is a board as the integration triggers and actions return it
+type IntegrationBoard struct {
+	ID        uuid.UUID `json:"id"`
+	Title     string    `json:"title"`
+	CreatedAt time.Time `json:"created_at"`
+	Cursor    int64     `gorm:"-" json:"cursor"`
+}
+
+// IntegrationShape is a shape as the integration triggers and actions return it
+type IntegrationShape struct {
+	ID         uuid.UUID `json:"id"`
+	BoardID    uuid.UUID `json:"board_id"`
+	BoardTitle string    `json:"board_title"`
+	Type       string    `json:"type"`
+	Text       string    `json:"text"`
+	CreatedAt  time.Time `json:"created_at"`
+	Cursor     int64     `gorm:"-" json:"cursor"`
+}
+
+// IntegrationChat is an agent reply with the message it answered, as the integration triggers return it
+type IntegrationChat struct {
+	ID         uuid.UUID `json:"id"`
+	BoardID    uuid.UUID `json:"board_id"`
+	BoardTitle string    `json:"board_title"`
+	Prompt     string    `json:"prompt"`
+	Reply      string    `json:"reply"`
+	CreatedAt  time.Time `json:"created_at"`
+	Cursor     int64     `gorm:"-" json:"cursor"`
+}
@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// LLMDataAudit counts the requests that sent a class of data to a model provider, per day and tenant
+// It is the privacy audit: which providers received what, and whether the requests carried opt-outs
+type LLMDataAudit struct {
+	Day         time.Time `gorm:"type:date;primaryKey" json:"day"`
+	TenantID    uuid.UUID `gorm:"type:uuid;primaryKey" json:"tenant_id"` // uuid.Nil for the default tenant
+	Provider    string    `gorm:"type:varchar(50);primaryKey" json:"provider"`
+	DataClass   string    `gorm:"type:varchar(20);primaryKey" json:"data_class"`
+	PrivacyMode bool      `gorm:"primaryKey" json:"privacy_mode"`
+	// Controls are the opt-outs the requests carried, comma-separated; empty when there were none
+	Controls  string    `gorm:"type:varchar(255)" json:"controls"`
+	Requests  int64     `gorm:"not null;default:0" json:"requests"`
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// MessageFeedback is a user's thumbs up or down on an AI message; rating the message again replaces it
+type MessageFeedback struct {
+	UUID    uuid.UUID `gorm:"type:uuid;primaryKey" json:"uuid"`
+	UserID  uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_feedback_message_user,priority:2" json:"user_id"`
+	BoardID uuid.UUID `gorm:"type:uuid;not null;index" json:"board_id"`
+	ChatID  uuid.UUID `gorm:"type:uuid;not null;uniqueIndex:idx_feedback_message_user,priority:1" json:"message_id"`
+	// Rating is 1 for thumbs up and -1 for thumbs down
+	Rating        int       `gorm:"type:smallint;not null" json:"rating"`
+	ModelName     string    `gorm:"type:varchar(100);index" json:"model_name,omitempty"`
+	PromptVersion string    `gorm:"type:varchar(32);index" json:"prompt_version,omitempty"`
+	Comment       string    `gorm:"type:text" json:"comment,omitempty"`
+	CreatedAt     time.Time `gorm:"index" json:"created_at"`
+	UpdatedAt     time.Time `json:"updated_at"`
+}
+
+func (MessageFeedback) TableName() string {
+	return "message_feedback"
+}
+
+// FeedbackAggregate is the feedback of one model or prompt version, for prompt-quality dashboards
+type FeedbackAggregate struct {
+	Key          string  `json:"key"`
+	Up           int64   `json:"up"`
+	Down         int64   `json:"down"`
+	Total        int64   `json:"total"`
+	Comments     int64   `json:"comments"`
+	ApprovalRate float64 `json:"approval_rate"`
+}
@@ -0,0 +1,65 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+type SampleBoardStatus string
+
+const (
+	SampleBoardPending SampleBoardStatus = "pending"
+	SampleBoardReady   SampleBoardStatus = "ready"
+	SampleBoardFailed  SampleBoardStatus = "failed"
+)
+
+type OnboardingStep string
+
+const (
+	OnboardingOpenSampleBoard OnboardingStep = "open_sample_board"
+	OnboardingSendMessage     OnboardingStep = "send_first_message"
+	OnboardingDrawShape       OnboardingStep = "draw_shape"
+	OnboardingCreateBoard     OnboardingStep = "create_board"
+)
+
+// OnboardingSteps are the steps of the guided tour, in the order they are shown
+var OnboardingSteps = []OnboardingStep{
+	OnboardingOpenSampleBoard,
+	OnboardingSendMessage,
+	OnboardingDrawShape,
+	OnboardingCreateBoard,
+}
+
+// OnboardingProgress is a new user's way through the guided tour, created at signup
+type OnboardingProgress struct {
+	UserID        uuid.UUID         `gorm:"type:uuid;primaryKey" json:"user_id"`
+	SampleBoardID *uuid.UUID        `gorm:"type:uuid" json:"sample_board_id,omitempty"`
+	Status        SampleBoardStatus `gorm:"type:varchar(16);not null;default:'pending'" json:"sample_board_status"`
+	// CompletedSteps maps each completed step to when it was completed
+	CompletedSteps datatypes.JSON `gorm:"type:jsonb;not null;default:'{}'" json:"-"`
+	DismissedAt    *time.Time     `json:"dismissed_at,omitempty"`
+	CreatedAt      time.Time      `json:"created_at"`
+	UpdatedAt      time.Time      `json:"updated_at"`
+}
+
+func (OnboardingProgress) TableName() string {
+	return "onboarding_progress"
+}
+
+// OnboardingStepStatus is a step of the tour as shown to the user
+type OnboardingStepStatus struct {
+	Step        OnboardingStep `json:"step"`
+	Done        bool           `json:"done"`
+	CompletedAt *time.Time     `json:"completed_at,omitempty"`
+}
+
+// OnboardingView is the tour's progress returned by the onboarding endpoints
+type OnboardingView struct {
+	SampleBoardID     *uuid.UUID             `json:"sample_board_id,omitempty"`
+	SampleBoardStatus SampleBoardStatus      `json:"sample_board_status"`
+	Steps             []OnboardingStepStatus `json:"steps"`
+	Completed         bool                   `json:"completed"`
+	Dismissed         bool                   `json:"dismissed"`
+}
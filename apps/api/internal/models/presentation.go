@@ -0,0 +1,20 @@
+package models
+
+import "github.com/google/uuid"
+
+// Slide is a frame of a board's presentation with the viewport that shows it
+type Slide struct {
+	Index    int          `json:"index"` // 0-based position in the presentation
+	FrameID  uuid.UUID    `json:"frame_id"`
+	Name     string       `json:"name"`
+	Bounds   AnchorBounds `json:"bounds"`
+	Viewport Viewport     `json:"viewport"`
+}
+
+// Presentation is the ordered frame sequence of a board
+// OtherFrames are the board's frames that aren't part of it
+type Presentation struct {
+	BoardID     uuid.UUID   `json:"board_id"`
+	Slides      []Slide     `json:"slides"`
+	OtherFrames []uuid.UUID `json:"other_frames"`
+}
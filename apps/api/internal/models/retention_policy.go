@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// RetentionPolicy holds the data retention settings of a workspace, which is owned by a single user
+// A nil limit keeps that data forever
+type RetentionPolicy struct {
+	UUID              uuid.UUID `gorm:"type:uuid;primaryKey" json:"uuid"`
+	UserID            uuid.UUID `gorm:"type:uuid;not null;uniqueIndex" json:"user_id"`
+	ChatRetentionDays *int      `json:"chat_retention_days"`
+	// ArchivedChatRetentionDays limits the chats of archived boards in place of ChatRetentionDays, so done
+	// projects can be kept longer than active ones
+	ArchivedChatRetentionDays *int      `json:"archived_chat_retention_days"`
+	SnapshotsToKeep           *int      `json:"snapshots_to_keep"` // newest board summaries and code artifacts kept per board
+	CreatedAt                 time.Time `json:"created_at"`
+	UpdatedAt                 time.Time `json:"updated_at"`
+}
+
+// RetentionReport describes what a retention run purged, or would purge when DryRun is set
+type RetentionReport struct {
+	UserID              uuid.UUID  `json:"user_id"`
+	DryRun              bool       `json:"dry_run"`
+	ChatsBefore         *time.Time `json:"chats_before,omitempty"`
+	Chats               int64      `json:"chats"`
+	ArchivedChatsBefore *time.Time `json:"archived_chats_before,omitempty"`
+	ArchivedChats       int64      `json:"archived_chats"`
+	BoardSummaries      int        `json:"board_summaries"`
+	CodeArtifacts       int        `json:"code_artifacts"`
+	SnapshotsKept       *int       `json:"snapshots_kept_per_board,omitempty"`
+}
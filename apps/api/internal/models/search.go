@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+type SearchHitType string
+
+const (
+	SearchHitBoard SearchHitType = "board"
+	SearchHitShape SearchHitType = "shape"
+	SearchHitChat  SearchHitType = "chat"
+)
+
+// SearchHit is a single global search result, with the IDs needed to deep-link to it
+type SearchHit struct {
+	Type       SearchHitType `json:"type"`
+	BoardID    uuid.UUID     `json:"board_id"`
+	BoardTitle string        `json:"board_title"`
+	ShapeID    *uuid.UUID    `json:"shape_id,omitempty"`
+	ShapeType  *string       `json:"shape_type,omitempty"`
+	ChatID     *uuid.UUID    `json:"chat_id,omitempty"`
+	Role       *string       `json:"role,omitempty"`
+	Text       string        `json:"-"`
+	Snippet    string        `json:"snippet"`
+	Rank       float64       `json:"rank"`
+	UpdatedAt  time.Time     `json:"updated_at"`
+}
@@ -0,0 +1,34 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// StorageKind is what a stored object is used for
+type StorageKind string
+
+const (
+	StorageKindUpload  StorageKind = "upload"  // images uploaded to the chat
+	StorageKindPreview StorageKind = "preview" // board thumbnails, covers and selection images
+	StorageKindAvatar  StorageKind = "avatar"
+)
+
+// StorageUsage is one object a user keeps in storage, counted against their plan's quota.
+// Objects overwritten at the same key replace their size instead of adding to it
+type StorageUsage struct {
+	UserID    uuid.UUID   `gorm:"type:uuid;primaryKey" json:"-"`
+	ObjectKey string      `gorm:"type:varchar(500);primaryKey" json:"object_key"`
+	Kind      StorageKind `gorm:"type:varchar(20);not null" json:"kind"`
+	Bytes     int64       `gorm:"not null" json:"bytes"`
+	UpdatedAt time.Time   `gorm:"autoUpdateTime" json:"updated_at"`
+}
+
+// StorageReport is a user's storage usage against their plan's quota
+type StorageReport struct {
+	Plan       Subscription          `json:"plan"`
+	UsedBytes  int64                 `json:"used_bytes"`
+	LimitBytes int64                 `json:"limit_bytes"` // 0 when unlimited
+	ByKind     map[StorageKind]int64 `json:"by_kind"`
+}
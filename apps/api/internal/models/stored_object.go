@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// StoredObject is a GCS object stored once per content hash and bucket, shared by every upload of the same bytes
+type StoredObject struct {
+	ContentHash string    `gorm:"type:varchar(64);primaryKey" json:"content_hash"` // hex SHA-256 of the content
+	Bucket      string    `gorm:"type:varchar(255);primaryKey" json:"bucket"`
+	ObjectKey   string    `gorm:"type:varchar(500);not null" json:"object_key"`
+	URL         string    `gorm:"type:varchar(500);not null" json:"url"`
+	RefCount    int       `gorm:"not null;default:0" json:"ref_count"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+	UpdatedAt   time.Time `gorm:"autoUpdateTime" json:"updated_at"`
+}
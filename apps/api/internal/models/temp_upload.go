@@ -7,10 +7,19 @@ import (
 )
 
 // TempUpload tracks temporary image uploads for cleanup
+// Committing an upload to a chat message or shape makes it a permanent board asset that cleanup leaves alone
 type TempUpload struct {
 	UUID      uuid.UUID `gorm:"type:uuid;default:gen_random_uuid();primaryKey" json:"uuid"`
 	BoardID   uuid.UUID `gorm:"type:uuid;not null;index" json:"board_id"`
+	UserID    uuid.UUID `gorm:"type:uuid" json:"user_id"`                     // uploader, whose storage quota the upload counts against
 	ObjectKey string    `gorm:"type:varchar(500);not null" json:"object_key"` // GCS object key
 	URL       string    `gorm:"type:varchar(500);not null" json:"url"`        // Full public URL
-	CreatedAt time.Time `gorm:"autoCreateTime" json:"created_at"`
+	// ContentHash and Bucket point at the shared StoredObject; empty for uploads made before content dedupe
+	ContentHash string    `gorm:"type:varchar(64)" json:"content_hash,omitempty"`
+	Bucket      string    `gorm:"type:varchar(255)" json:"bucket,omitempty"`
+	CreatedAt   time.Time `gorm:"autoCreateTime" json:"created_at"`
+	// CommittedAt is set once the upload is bound to MessageID or ShapeID
+	CommittedAt *time.Time `gorm:"index" json:"committed_at,omitempty"`
+	MessageID   *uuid.UUID `gorm:"type:uuid" json:"message_id,omitempty"`
+	ShapeID     *uuid.UUID `gorm:"type:uuid" json:"shape_id,omitempty"`
 }
@@ -0,0 +1,55 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// Tenant is an isolated customer of a multi-tenant deployment
+// Users and boards without a tenant belong to the default tenant
+type Tenant struct {
+	UUID uuid.UUID `gorm:"type:uuid;primaryKey" json:"uuid"`
+	// Slug is matched against the request subdomain or tenant header
+	Slug string `gorm:"type:varchar(63);not null;uniqueIndex" json:"slug"`
+	Name string `gorm:"type:varchar(255);not null" json:"name"`
+	// AllowedModels restricts the LLMs the tenant may use; empty allows every model
+	AllowedModels datatypes.JSON `gorm:"type:jsonb" json:"allowed_models,omitempty"`
+	// StorageBucket overrides GCP_STORAGE_BUCKET for the tenant's uploads
+	StorageBucket string `gorm:"type:varchar(255)" json:"storage_bucket,omitempty"`
+	// PrivacyMode sends the model providers' data-retention and training opt-outs with every request
+	PrivacyMode bool `gorm:"not null;default:false" json:"privacy_mode"`
+	// DataRegion pins the tenant's LLM traffic to a region (eu, us); empty lets it go anywhere
+	DataRegion string    `gorm:"type:varchar(8)" json:"data_region,omitempty"`
+	IsActive   bool      `gorm:"not null;default:true" json:"is_active"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// AllowedModelNames returns the models the tenant is restricted to, nil when every model is allowed
+func (t *Tenant) AllowedModelNames() []string {
+	if len(t.AllowedModels) == 0 {
+		return nil
+	}
+	var names []string
+	if err := json.Unmarshal(t.AllowedModels, &names); err != nil {
+		return nil
+	}
+	return names
+}
+
+// AllowsModel reports whether the tenant may use the model
+func (t *Tenant) AllowsModel(modelName string) bool {
+	names := t.AllowedModelNames()
+	if len(names) == 0 {
+		return true
+	}
+	for _, name := range names {
+		if name == modelName {
+			return true
+		}
+	}
+	return false
+}
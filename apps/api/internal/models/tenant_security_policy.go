@@ -0,0 +1,35 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// TenantSecurityPolicy is an organization's sign-in policy: the networks its users may connect from and
+// how long their sessions last
+type TenantSecurityPolicy struct {
+	TenantID uuid.UUID `gorm:"type:uuid;primaryKey" json:"tenant_id"`
+	// AllowedIPRanges lists CIDR ranges such as 203.0.113.0/24; empty allows every network
+	AllowedIPRanges datatypes.JSON `gorm:"type:jsonb" json:"allowed_ip_ranges"`
+	// MaxSessionMinutes signs users out this long after they signed in, however active they are; 0 keeps the 7-day sessions
+	MaxSessionMinutes int `gorm:"not null;default:0" json:"max_session_minutes"`
+	// ReauthIntervalMinutes makes clients re-authenticate with their refresh token at least this often; 0 keeps the 15 minutes of an access token
+	ReauthIntervalMinutes int       `gorm:"not null;default:0" json:"reauth_interval_minutes"`
+	CreatedAt             time.Time `json:"created_at"`
+	UpdatedAt             time.Time `json:"updated_at"`
+}
+
+// IPRanges returns the allowed CIDR ranges, nil when every network is allowed
+func (p *TenantSecurityPolicy) IPRanges() []string {
+	if len(p.AllowedIPRanges) == 0 {
+		return nil
+	}
+	var ranges []string
+	if err := json.Unmarshal(p.AllowedIPRanges, &ranges); err != nil {
+		return nil
+	}
+	return ranges
+}
@@ -36,6 +36,8 @@ type TokenConsumption struct {
 	InputTokens    int    `gorm:"column:input_tokens;not null" json:"input_tokens"`
 	OutputTokens   int    `gorm:"column:output_tokens;not null" json:"output_tokens"`
 	CountingMethod string `gorm:"not null" json:"counting_method"`
+	// CostUSD is priced from the model price sheet when the request is recorded
+	CostUSD float64 `gorm:"column:cost_usd;not null;default:0" json:"cost_usd"`
 
 	CreatedAt time.Time `gorm:"index:idx_user_created" json:"created_at"`
 	UpdatedAt time.Time `json:"updated_at"`
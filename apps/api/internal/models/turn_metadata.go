@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+// TurnMetadata records how an AI message was generated, so the turn can be replayed when debugging
+type TurnMetadata struct {
+	ChatID         uuid.UUID `gorm:"type:uuid;primaryKey" json:"message_id"` // the AI message
+	HumanMessageID uuid.UUID `gorm:"type:uuid;not null" json:"human_message_id"`
+	BoardID        uuid.UUID `gorm:"type:uuid;not null;index" json:"board_id"`
+	UserID         uuid.UUID `gorm:"type:uuid;not null" json:"user_id"`
+	ModelName      string    `gorm:"type:varchar(100)" json:"model_name"`
+	Provider       string    `gorm:"type:varchar(30)" json:"provider"`
+	PromptVersion  string    `gorm:"type:varchar(32)" json:"prompt_version"`
+	ToolsVersion   string    `gorm:"type:varchar(32)" json:"tools_version"`
+	Temperature    *float32  `json:"temperature,omitempty"`
+	MaxTokens      *int      `json:"max_tokens,omitempty"`
+	// Seed is nil when the provider takes no seed
+	Seed         *int64 `json:"seed,omitempty"`
+	Thinking     bool   `gorm:"not null;default:false" json:"thinking"`
+	AgentProfile string `gorm:"type:varchar(50)" json:"agent_profile"`
+	Theme        string `gorm:"type:varchar(20)" json:"theme"`
+	// the text added to the message, and the board's shapes when the turn started
+	CustomRules         string         `gorm:"type:text" json:"-"`
+	FacilitationContext string         `gorm:"type:text" json:"-"`
+	ReferenceContext    string         `gorm:"type:text" json:"-"`
+	BoardSnapshot       datatypes.JSON `gorm:"type:jsonb" json:"-"`
+	// Selections counts the selection and uploaded images, which are not kept and can't be replayed
+	Selections int       `gorm:"not null;default:0" json:"selections"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+func (TurnMetadata) TableName() string {
+	return "turn_metadata"
+}
@@ -24,18 +24,33 @@ const (
 )
 
 type User struct {
-	UUID                  uuid.UUID    `gorm:"column:uuid;type:uuid;primaryKey" json:"uuid"`
-	Email                 string       `gorm:"not null;unique" json:"email"`
-	Password              *string      `gorm:"type:varchar(255)" json:"password,omitempty"` // Optional - nil for OAuth users
-	FirstName             string       `gorm:"not null" json:"first_name"`
-	LastName              string       `gorm:"not null" json:"last_name"`
-	Avatar                string       `gorm:"type:varchar(255)" json:"avatar,omitempty"`
-	LoginMethod           LoginMethod  `gorm:"not null;default:'email'" json:"login_method"`
-	Subscription          Subscription `gorm:"not null;default:'free'" json:"subscription"`
-	SubscriptionStartDate *time.Time   `gorm:"column:subscription_start_date" json:"subscription_start_date,omitempty"`
-	TokensConsumed        int          `gorm:"column:tokens_consumed;not null;default:0" json:"tokens_consumed"`
-	LastTokenResetDate    *time.Time   `gorm:"column:last_token_reset_date" json:"last_token_reset_date,omitempty"`
-	Country               *string      `gorm:"type:varchar(2)" json:"country,omitempty"` // ISO country code (IN, US, etc.)
-	CreatedAt             time.Time    `json:"created_at"`
-	UpdatedAt             time.Time    `json:"updated_at"`
+	UUID                    uuid.UUID    `gorm:"column:uuid;type:uuid;primaryKey" json:"uuid"`
+	Email                   string       `gorm:"not null;unique" json:"email"`
+	Password                *string      `gorm:"type:varchar(255)" json:"password,omitempty"` // Optional - nil for OAuth users
+	FirstName               string       `gorm:"not null" json:"first_name"`
+	LastName                string       `gorm:"not null" json:"last_name"`
+	Avatar                  string       `gorm:"type:varchar(255)" json:"avatar,omitempty"`
+	LoginMethod             LoginMethod  `gorm:"not null;default:'email'" json:"login_method"`
+	Subscription            Subscription `gorm:"not null;default:'free'" json:"subscription"`
+	SubscriptionStartDate   *time.Time   `gorm:"column:subscription_start_date" json:"subscription_start_date,omitempty"`
+	TokensConsumed          int          `gorm:"column:tokens_consumed;not null;default:0" json:"tokens_consumed"`
+	LastTokenResetDate      *time.Time   `gorm:"column:last_token_reset_date" json:"last_token_reset_date,omitempty"`
+	Country                 *string      `gorm:"type:varchar(2)" json:"country,omitempty"`                // ISO country code (IN, US, etc.)
+	TenantID                *uuid.UUID   `gorm:"type:uuid;index" json:"tenant_id,omitempty"`              // nil for the default tenant
+	StoreThinking           bool         `gorm:"not null;default:true" json:"store_thinking"`             // Privacy: keep the model's reasoning with each reply
+	VerifyDrawings          bool         `gorm:"not null;default:false" json:"verify_drawings"`           // Check drawn results with an extra vision call, which costs tokens
+	ConfirmDestructiveTools bool         `gorm:"not null;default:false" json:"confirm_destructive_tools"` // Ask before the agent deletes shapes or renames boards
+	RedactPII               bool         `gorm:"not null;default:false" json:"redact_pii"`                // Privacy: replace emails, phone and card numbers before they reach the model
+	Timezone                string       `gorm:"type:varchar(64)" json:"timezone,omitempty"`              // IANA name, e.g. Europe/Berlin
+	Locale                  string       `gorm:"type:varchar(35)" json:"locale,omitempty"`                // BCP 47 tag, e.g. en-US
+	CreatedAt               time.Time    `json:"created_at"`
+	UpdatedAt               time.Time    `json:"updated_at"`
+}
+
+// TenantKey returns the user's tenant ID as carried in auth tokens, empty for the default tenant
+func (u User) TenantKey() string {
+	if u.TenantID == nil {
+		return ""
+	}
+	return u.TenantID.String()
 }
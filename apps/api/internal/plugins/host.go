@@ -0,0 +1,311 @@
+// Package plugins runs the third-party plugins built with pkg/pluginsdk: it starts each plugin's program,
+// gives the agent its tools and serves its routes, within the scopes the operator granted
+package plugins
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"melina-studio-backend/internal/config"
+	llmHandlers "melina-studio-backend/internal/llm_handlers"
+	"melina-studio-backend/internal/melina/tools"
+	"melina-studio-backend/internal/repo"
+	"melina-studio-backend/pkg/pluginsdk"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrPluginNotFound        = errors.New("plugin not found")
+	ErrPluginUnavailable     = errors.New("plugin is not running")
+	ErrScopeNotGranted       = errors.New("scope is not in PLUGINS_GRANTED_SCOPES")
+	ErrUnsupportedAPIVersion = errors.New("plugin API version is not supported")
+)
+
+// supportedAPIVersions are the plugin protocol versions this API speaks
+var supportedAPIVersions = map[int]bool{pluginsdk.APIVersion: true}
+
+// PluginInfo is a loaded plugin as the admin route reports it
+type PluginInfo struct {
+	Name        string                `json:"name"`
+	Version     string                `json:"version"`
+	APIVersion  int                   `json:"api_version"`
+	Description string                `json:"description,omitempty"`
+	Scopes      []string              `json:"scopes"`
+	State       string                `json:"state"`
+	Restarts    int                   `json:"restarts"`
+	Tools       []string              `json:"tools"`
+	Routes      []pluginsdk.RouteSpec `json:"routes"`
+}
+
+// Route is a route a plugin serves
+type Route struct {
+	Plugin string
+	pluginsdk.RouteSpec
+}
+
+// Host starts the plugins and keeps them running while the API is up
+type Host struct {
+	cfg       config.PluginConfig
+	boardData repo.BoardDataRepoInterface
+
+	mu        sync.RWMutex
+	plugins   map[string]*process
+	socketDir string
+}
+
+var host *Host
+
+// GetHost returns the process-wide plugin host
+func GetHost() *Host {
+	return host
+}
+
+// InitHost creates the process-wide plugin host
+func InitHost(cfg config.PluginConfig, boardData repo.BoardDataRepoInterface) *Host {
+	host = NewHost(cfg, boardData)
+	return host
+}
+
+func NewHost(cfg config.PluginConfig, boardData repo.BoardDataRepoInterface) *Host {
+	return &Host{
+		cfg:       cfg,
+		boardData: boardData,
+		plugins:   make(map[string]*process),
+	}
+}
+
+// Load starts every plugin in the plugin directory and registers its tools. A plugin that can't be loaded
+// is logged and skipped, so one broken plugin doesn't keep the API from starting
+func (h *Host) Load() error {
+	if !h.cfg.Enabled {
+		log.Println("Plugins are disabled")
+		return nil
+	}
+	entries, err := os.ReadDir(h.cfg.Dir)
+	if errors.Is(err, os.ErrNotExist) {
+		log.Printf("Plugin directory %s does not exist, no plugins loaded", h.cfg.Dir)
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	socketDir, err := os.MkdirTemp("", "melina-plugins-")
+	if err != nil {
+		return err
+	}
+	h.socketDir = socketDir
+
+	for _, entry := range entries {
+		if !entry.IsDir() {
+			continue
+		}
+		dir := filepath.Join(h.cfg.Dir, entry.Name())
+		manifest, err := pluginsdk.LoadManifest(dir)
+		if errors.Is(err, os.ErrNotExist) {
+			continue
+		}
+		if err == nil {
+			err = h.load(dir, manifest)
+		}
+		if err != nil {
+			log.Printf("Skipping plugin in %s: %v", dir, err)
+			continue
+		}
+		log.Printf("Loaded plugin %s %s", manifest.Name, manifest.Version)
+	}
+	return nil
+}
+
+// load starts one plugin and registers its tools
+func (h *Host) load(dir string, manifest *pluginsdk.Manifest) error {
+	if err := h.checkManifest(manifest); err != nil {
+		return err
+	}
+	h.mu.RLock()
+	_, loaded := h.plugins[manifest.Name]
+	h.mu.RUnlock()
+	if loaded {
+		return fmt.Errorf("another plugin is already named %s", manifest.Name)
+	}
+
+	p, err := newProcess(h.cfg, dir, manifest, filepath.Join(h.socketDir, manifest.Name+".sock"))
+	if err != nil {
+		return err
+	}
+	if err := p.start(); err != nil {
+		p.stop()
+		return err
+	}
+
+	description := p.description
+	pluginTools := make([]tools.PluginTool, 0, len(description.Tools))
+	for _, spec := range description.Tools {
+		if tools.ToolExists(spec.Name) {
+			p.stop()
+			return fmt.Errorf("the agent already has a tool named %s", spec.Name)
+		}
+		pluginTools = append(pluginTools, tools.PluginTool{
+			Name:             spec.Name,
+			Description:      spec.Description,
+			Parameters:       spec.Parameters,
+			RequiresApproval: spec.RequiresApproval,
+			Handler:          h.toolHandler(p, spec.Name),
+		})
+	}
+	tools.SetPluginTools(manifest.Name, pluginTools)
+
+	h.mu.Lock()
+	h.plugins[manifest.Name] = p
+	h.mu.Unlock()
+	return nil
+}
+
+// checkManifest refuses plugins of an unsupported API version or asking for scopes that weren't granted
+func (h *Host) checkManifest(manifest *pluginsdk.Manifest) error {
+	if !supportedAPIVersions[manifest.APIVersion] {
+		return fmt.Errorf("%w: %s needs version %d", ErrUnsupportedAPIVersion, manifest.Name, manifest.APIVersion)
+	}
+	for _, scope := range manifest.Scopes {
+		if !h.cfg.Grants(scope) {
+			return fmt.Errorf("%w: %s asks for %s", ErrScopeNotGranted, manifest.Name, scope)
+		}
+	}
+	return nil
+}
+
+// toolHandler returns the handler the agent calls for a plugin tool. The chat's board and, with their
+// scopes, the user and the board's shapes are sent along
+func (h *Host) toolHandler(p *process, tool string) llmHandlers.ToolHandler {
+	return func(ctx context.Context, input map[string]interface{}) (interface{}, error) {
+		call := pluginsdk.ToolCall{Tool: tool, Input: input}
+		if streamCtx, ok := llmHandlers.StreamingContextFrom(ctx); ok {
+			call.BoardID = streamCtx.BoardId
+			if p.manifest.HasScope(pluginsdk.ScopeUserRead) {
+				call.UserID = streamCtx.UserID
+			}
+		}
+		if call.BoardID != "" && p.manifest.HasScope(pluginsdk.ScopeBoardRead) {
+			shapes, err := h.boardShapes(call.BoardID)
+			if err != nil {
+				return nil, fmt.Errorf("failed to read the board for %s: %w", tool, err)
+			}
+			call.Shapes = shapes
+		}
+
+		var result interface{}
+		err := p.call(ctx, func(ctx context.Context, client *pluginsdk.Client) error {
+			var err error
+			result, err = client.CallTool(ctx, call)
+			return err
+		})
+		return result, err
+	}
+}
+
+// boardShapes returns the board's shapes as the tools see them, with their id and type
+func (h *Host) boardShapes(boardID string) ([]map[string]interface{}, error) {
+	id, err := uuid.Parse(boardID)
+	if err != nil {
+		return nil, err
+	}
+	stored, err := h.boardData.GetBoardData(id)
+	if err != nil {
+		return nil, err
+	}
+	shapes := make([]map[string]interface{}, 0, len(stored))
+	for _, shape := range stored {
+		data := map[string]interface{}{}
+		if err := json.Unmarshal(shape.Data, &data); err != nil {
+			return nil, err
+		}
+		data["id"] = shape.UUID.String()
+		data["type"] = string(shape.Type)
+		shapes = append(shapes, data)
+	}
+	return shapes, nil
+}
+
+// HandleRoute passes a request to a plugin route; the user's ID is only sent with the user:read scope
+func (h *Host) HandleRoute(ctx context.Context, plugin string, req pluginsdk.RouteRequest, userID string) (*pluginsdk.RouteResponse, error) {
+	h.mu.RLock()
+	p, ok := h.plugins[plugin]
+	h.mu.RUnlock()
+	if !ok {
+		return nil, ErrPluginNotFound
+	}
+	if p.manifest.HasScope(pluginsdk.ScopeUserRead) {
+		req.UserID = userID
+	}
+
+	var resp *pluginsdk.RouteResponse
+	err := p.call(ctx, func(ctx context.Context, client *pluginsdk.Client) error {
+		var err error
+		resp, err = client.HandleRoute(ctx, req)
+		return err
+	})
+	return resp, err
+}
+
+// Routes returns the routes of the loaded plugins, ordered by plugin
+func (h *Host) Routes() []Route {
+	var routes []Route
+	for _, info := range h.List() {
+		for _, spec := range info.Routes {
+			routes = append(routes, Route{Plugin: info.Name, RouteSpec: spec})
+		}
+	}
+	return routes
+}
+
+// List returns the loaded plugins, ordered by name
+func (h *Host) List() []PluginInfo {
+	if h == nil {
+		return nil
+	}
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+	infos := make([]PluginInfo, 0, len(h.plugins))
+	for _, p := range h.plugins {
+		p.mu.RLock()
+		info := PluginInfo{
+			Name:        p.manifest.Name,
+			Version:     p.manifest.Version,
+			APIVersion:  p.manifest.APIVersion,
+			Description: p.manifest.Description,
+			Scopes:      p.manifest.Scopes,
+			State:       p.state,
+			Restarts:    p.restarts,
+			Tools:       []string{},
+			Routes:      p.description.Routes,
+		}
+		for _, tool := range p.description.Tools {
+			info.Tools = append(info.Tools, tool.Name)
+		}
+		p.mu.RUnlock()
+		infos = append(infos, info)
+	}
+	sort.Slice(infos, func(i, j int) bool { return infos[i].Name < infos[j].Name })
+	return infos
+}
+
+// Stop stops every plugin and removes their tools
+func (h *Host) Stop() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	for name, p := range h.plugins {
+		tools.SetPluginTools(name, nil)
+		p.stop()
+	}
+	h.plugins = make(map[string]*process)
+	if h.socketDir != "" {
+		os.RemoveAll(h.socketDir)
+	}
+}
@@ -0,0 +1,168 @@
+package plugins
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"melina-studio-backend/internal/config"
+	llmHandlers "melina-studio-backend/internal/llm_handlers"
+	"melina-studio-backend/internal/melina/tools"
+	"melina-studio-backend/pkg/pluginsdk"
+)
+
+// TestMain runs the test binary as the plugin when the host starts it
+func TestMain(m *testing.M) {
+	if os.Getenv(pluginsdk.EnvAddr) != "" {
+		if err := pluginsdk.Serve(testPlugin{}); err != nil {
+			os.Exit(1)
+		}
+		os.Exit(0)
+	}
+	os.Exit(m.Run())
+}
+
+type testPlugin struct{}
+
+func (testPlugin) Describe() pluginsdk.Description {
+	return pluginsdk.Description{
+		Name:    "test-plugin",
+		Version: "1.0.0",
+		Tools: []pluginsdk.ToolSpec{
+			{Name: "pluginEcho", Description: "Echo the input", Parameters: map[string]interface{}{"type": "object"}},
+			{Name: "pluginCrash", Description: "Exit the plugin", Parameters: map[string]interface{}{"type": "object"}},
+		},
+		Routes: []pluginsdk.RouteSpec{{Method: "POST", Path: "/echo/:id"}},
+	}
+}
+
+func (testPlugin) CallTool(ctx context.Context, call pluginsdk.ToolCall) (interface{}, error) {
+	if call.Tool == "pluginCrash" {
+		os.Exit(3)
+	}
+	return map[string]interface{}{"input": call.Input, "user": call.UserID, "secret": os.Getenv("PLUGIN_TEST_PLUGIN_SECRET"), "leak": os.Getenv("JWT_SECRET")}, nil
+}
+
+func (testPlugin) HandleRoute(ctx context.Context, req pluginsdk.RouteRequest) (*pluginsdk.RouteResponse, error) {
+	return pluginsdk.JSONResponse(201, map[string]string{"id": req.Params["id"], "user": req.UserID})
+}
+
+func testConfig(dir string, scopes ...string) config.PluginConfig {
+	return config.PluginConfig{Enabled: true, Dir: dir, GrantedScopes: scopes, StartTimeout: 10 * time.Second, CallTimeout: 5 * time.Second}
+}
+
+// writeTestPlugin installs the test binary as a plugin with the given scopes
+func writeTestPlugin(t *testing.T, scopes string) string {
+	t.Helper()
+	root := t.TempDir()
+	dir := filepath.Join(root, "test-plugin")
+	if err := os.Mkdir(dir, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Symlink(exe, filepath.Join(dir, "plugin")); err != nil {
+		t.Fatal(err)
+	}
+	manifest := "name: test-plugin\nversion: 1.0.0\napi_version: 1\ncommand: plugin\nscopes: " + scopes + "\n"
+	if err := os.WriteFile(filepath.Join(dir, pluginsdk.ManifestFile), []byte(manifest), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return root
+}
+
+func TestCheckManifest(t *testing.T) {
+	h := NewHost(testConfig("", pluginsdk.ScopeTools), nil)
+	manifest := &pluginsdk.Manifest{Name: "p", Version: "1", APIVersion: pluginsdk.APIVersion, Command: "p", Scopes: []string{pluginsdk.ScopeTools}}
+	if err := h.checkManifest(manifest); err != nil {
+		t.Fatalf("granted manifest refused: %v", err)
+	}
+	manifest.Scopes = append(manifest.Scopes, pluginsdk.ScopeRoutes)
+	if err := h.checkManifest(manifest); !errors.Is(err, ErrScopeNotGranted) {
+		t.Errorf("ungranted scope: got %v", err)
+	}
+	manifest.Scopes = nil
+	manifest.APIVersion = pluginsdk.APIVersion + 1
+	if err := h.checkManifest(manifest); !errors.Is(err, ErrUnsupportedAPIVersion) {
+		t.Errorf("unsupported version: got %v", err)
+	}
+}
+
+func TestHostRunsPlugin(t *testing.T) {
+	t.Setenv("PLUGIN_TEST_PLUGIN_SECRET", "s3cret")
+	t.Setenv("JWT_SECRET", "do-not-pass")
+	root := writeTestPlugin(t, "[tools, routes, user:read]")
+
+	h := NewHost(testConfig(root, pluginsdk.ScopeTools, pluginsdk.ScopeRoutes, pluginsdk.ScopeUserRead), nil)
+	if err := h.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer h.Stop()
+	if infos := h.List(); len(infos) != 1 || infos[0].State != StateRunning {
+		t.Fatalf("plugins = %+v", infos)
+	}
+	if !tools.ToolExists("pluginEcho") {
+		t.Fatal("plugin tool not offered to the agent")
+	}
+
+	ctx := llmHandlers.WithStreamingContext(context.Background(), &llmHandlers.StreamingContext{UserID: "u1"})
+	results := llmHandlers.ExecuteTools(ctx, []llmHandlers.ToolCall{{Name: "pluginEcho", Input: map[string]interface{}{"text": "hi"}}}, nil)
+	if results[0].Error != nil {
+		t.Fatal(results[0].Error)
+	}
+	got := results[0].Result.(map[string]interface{})
+	if got["user"] != "u1" || got["secret"] != "s3cret" || got["leak"] != "" {
+		t.Errorf("tool result = %v", got)
+	}
+
+	routes := h.Routes()
+	if len(routes) != 1 || routes[0].Plugin != "test-plugin" || routes[0].Path != "/echo/:id" {
+		t.Fatalf("routes = %+v", routes)
+	}
+	resp, err := h.HandleRoute(context.Background(), "test-plugin", pluginsdk.RouteRequest{Method: "POST", Route: "/echo/:id", Params: map[string]string{"id": "7"}}, "u1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != 201 || string(resp.Body) != `{"id":"7","user":"u1"}` {
+		t.Errorf("route response = %d %s", resp.Status, resp.Body)
+	}
+
+	// a crash fails the call and the plugin is restarted
+	results = llmHandlers.ExecuteTools(context.Background(), []llmHandlers.ToolCall{{Name: "pluginCrash", Input: map[string]interface{}{"now": true}}}, nil)
+	if results[0].Error == nil {
+		t.Error("the crashing call should fail")
+	}
+	deadline := time.Now().Add(15 * time.Second)
+	for {
+		info := h.List()[0]
+		if info.State == StateRunning && info.Restarts > 0 {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("plugin not restarted: %+v", info)
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+
+	h.Stop()
+	if tools.ToolExists("pluginEcho") {
+		t.Error("stopped plugin's tools are still offered")
+	}
+}
+
+func TestHostSkipsUngrantedPlugin(t *testing.T) {
+	root := writeTestPlugin(t, "[tools, routes]")
+	h := NewHost(testConfig(root, pluginsdk.ScopeTools), nil)
+	if err := h.Load(); err != nil {
+		t.Fatal(err)
+	}
+	defer h.Stop()
+	if infos := h.List(); len(infos) != 0 {
+		t.Errorf("plugin with an ungranted scope was loaded: %+v", infos)
+	}
+}
@@ -0,0 +1,280 @@
+package plugins
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"melina-studio-backend/internal/config"
+	"melina-studio-backend/pkg/pluginsdk"
+
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+// Plugin states, as the admin route reports them
+const (
+	StateRunning    = "running"
+	StateRestarting = "restarting"
+	StateStopped    = "stopped"
+)
+
+const (
+	// stopGrace is how long a plugin has to exit after it is asked to
+	stopGrace = 5 * time.Second
+	// maxRestartBackoff caps the wait between restarts of a plugin that keeps failing
+	maxRestartBackoff = time.Minute
+)
+
+// passedEnv are the variables of the API's environment every plugin gets, besides its own PLUGIN_<NAME>_*
+var passedEnv = []string{"PATH", "HOME", "TMPDIR", "TZ", "LANG"}
+
+// process is a plugin's running program. It is restarted when it exits, and its tools and routes fail
+// with ErrPluginUnavailable until it answers again
+type process struct {
+	cfg      config.PluginConfig
+	manifest *pluginsdk.Manifest
+	dir      string
+	addr     string
+	client   *pluginsdk.Client
+
+	mu          sync.RWMutex
+	state       string
+	description *pluginsdk.Description
+	cmd         *exec.Cmd
+	exited      chan struct{}
+	restarts    int
+	stopping    bool
+	stopChan    chan struct{}
+}
+
+func newProcess(cfg config.PluginConfig, dir string, manifest *pluginsdk.Manifest, addr string) (*process, error) {
+	client, err := pluginsdk.Dial(addr)
+	if err != nil {
+		return nil, err
+	}
+	return &process{
+		cfg:      cfg,
+		manifest: manifest,
+		dir:      dir,
+		addr:     addr,
+		client:   client,
+		state:    StateStopped,
+		stopChan: make(chan struct{}),
+	}, nil
+}
+
+// start launches the plugin, waits until it answers and checks it is the plugin its manifest describes
+func (p *process) start() error {
+	cmd, exited, err := p.launch()
+	if err != nil {
+		return err
+	}
+	description, err := p.waitReady(exited)
+	if err == nil {
+		err = description.Validate(p.manifest)
+	}
+
+	p.mu.Lock()
+	if err == nil && p.stopping {
+		err = errors.New("stopped while starting")
+	}
+	if err != nil {
+		p.mu.Unlock()
+		terminate(cmd, exited)
+		return err
+	}
+	p.cmd = cmd
+	p.exited = exited
+	p.description = description
+	p.state = StateRunning
+	p.mu.Unlock()
+
+	go p.supervise(exited)
+	return nil
+}
+
+// launch starts the plugin's command with only the environment it is meant to see
+func (p *process) launch() (*exec.Cmd, chan struct{}, error) {
+	_ = os.Remove(p.addr)
+
+	cmd := exec.Command(filepath.Join(p.dir, p.manifest.Command), p.manifest.Args...)
+	cmd.Dir = p.dir
+	cmd.Env = p.env()
+	cmd.Stdout = &lineLogger{prefix: "[plugin " + p.manifest.Name + "] "}
+	cmd.Stderr = cmd.Stdout
+	if err := cmd.Start(); err != nil {
+		return nil, nil, err
+	}
+
+	exited := make(chan struct{})
+	go func() {
+		if err := cmd.Wait(); err != nil {
+			log.Printf("Plugin %s exited: %v", p.manifest.Name, err)
+		}
+		close(exited)
+	}()
+	return cmd, exited, nil
+}
+
+// env is the plugin's environment: a few basics, its PLUGIN_<NAME>_* variables and where to listen
+func (p *process) env() []string {
+	var env []string
+	for _, name := range passedEnv {
+		if value, ok := os.LookupEnv(name); ok {
+			env = append(env, name+"="+value)
+		}
+	}
+	prefix := p.manifest.EnvPrefix()
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, prefix) {
+			env = append(env, kv)
+		}
+	}
+	return append(env,
+		pluginsdk.EnvAddr+"="+p.addr,
+		pluginsdk.EnvAPIVersion+"="+strconv.Itoa(pluginsdk.APIVersion),
+	)
+}
+
+// waitReady asks the plugin to describe itself until it answers, exits or runs out of StartTimeout
+func (p *process) waitReady(exited <-chan struct{}) (*pluginsdk.Description, error) {
+	deadline := time.Now().Add(p.cfg.StartTimeout)
+	for {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		description, err := p.client.Describe(ctx)
+		cancel()
+		if err == nil {
+			return description, nil
+		}
+		if time.Now().After(deadline) {
+			return nil, fmt.Errorf("did not answer within %s: %v", p.cfg.StartTimeout, status.Convert(err).Message())
+		}
+		select {
+		case <-exited:
+			return nil, errors.New("exited while starting")
+		case <-time.After(100 * time.Millisecond):
+		}
+	}
+}
+
+// supervise restarts the plugin when it exits, backing off while it keeps failing
+func (p *process) supervise(exited <-chan struct{}) {
+	<-exited
+	p.mu.Lock()
+	if p.stopping {
+		p.mu.Unlock()
+		return
+	}
+	p.state = StateRestarting
+	p.mu.Unlock()
+
+	backoff := time.Second
+	for {
+		select {
+		case <-p.stopChan:
+			return
+		case <-time.After(backoff):
+		}
+		p.mu.Lock()
+		p.restarts++
+		p.mu.Unlock()
+		err := p.start()
+		if err == nil {
+			log.Printf("Plugin %s restarted", p.manifest.Name)
+			return
+		}
+		log.Printf("Failed to restart plugin %s: %v", p.manifest.Name, err)
+		backoff = min(2*backoff, maxRestartBackoff)
+	}
+}
+
+// stop asks the plugin to exit and kills it if it doesn't in time
+func (p *process) stop() {
+	p.mu.Lock()
+	if p.stopping {
+		p.mu.Unlock()
+		return
+	}
+	p.stopping = true
+	p.state = StateStopped
+	close(p.stopChan)
+	cmd, exited := p.cmd, p.exited
+	p.mu.Unlock()
+
+	if cmd != nil {
+		terminate(cmd, exited)
+	}
+	p.client.Close()
+	_ = os.Remove(p.addr)
+}
+
+// terminate interrupts the command and kills it after stopGrace
+func terminate(cmd *exec.Cmd, exited <-chan struct{}) {
+	if err := cmd.Process.Signal(os.Interrupt); err != nil {
+		cmd.Process.Kill()
+	}
+	select {
+	case <-exited:
+	case <-time.After(stopGrace):
+		cmd.Process.Kill()
+		<-exited
+	}
+}
+
+// call runs a request against the running plugin within CallTimeout
+func (p *process) call(ctx context.Context, do func(ctx context.Context, client *pluginsdk.Client) error) error {
+	p.mu.RLock()
+	state := p.state
+	p.mu.RUnlock()
+	if state != StateRunning {
+		return fmt.Errorf("%w: %s is %s", ErrPluginUnavailable, p.manifest.Name, state)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.cfg.CallTimeout)
+	defer cancel()
+	err := do(ctx, p.client)
+	if err == nil {
+		return nil
+	}
+	switch s := status.Convert(err); s.Code() {
+	case codes.DeadlineExceeded:
+		return fmt.Errorf("plugin %s timed out after %s", p.manifest.Name, p.cfg.CallTimeout)
+	case codes.Unavailable:
+		return fmt.Errorf("%w: %s", ErrPluginUnavailable, p.manifest.Name)
+	default:
+		return fmt.Errorf("plugin %s: %s", p.manifest.Name, s.Message())
+	}
+}
+
+// lineLogger writes a plugin's output to the API's log, a line at a time
+type lineLogger struct {
+	prefix string
+	mu     sync.Mutex
+	buf    bytes.Buffer
+}
+
+func (l *lineLogger) Write(data []byte) (int, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.buf.Write(data)
+	for {
+		line, err := l.buf.ReadString('\n')
+		if err != nil {
+			// keep the partial line for the next write
+			l.buf.Reset()
+			l.buf.WriteString(line)
+			return len(data), nil
+		}
+		log.Print(l.prefix + strings.TrimRight(line, "\r\n"))
+	}
+}
@@ -0,0 +1,115 @@
+package repo
+
+import (
+	"melina-studio-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AnnouncementRepo struct {
+	db *gorm.DB
+}
+
+type AnnouncementRepoInterface interface {
+	Create(announcement *models.Announcement) error
+	Update(announcement *models.Announcement) error
+	Get(id uuid.UUID) (*models.Announcement, error)
+	List() ([]models.Announcement, error)
+	Delete(id uuid.UUID) error
+	ListPublished(userID uuid.UUID, limit int) ([]models.UserAnnouncement, error)
+	CountUnread(userID uuid.UUID) (int64, error)
+	MarkRead(userID uuid.UUID, ids []uuid.UUID) error
+	MarkAllRead(userID uuid.UUID) error
+}
+
+func NewAnnouncementRepository(db *gorm.DB) AnnouncementRepoInterface {
+	return &AnnouncementRepo{db: db}
+}
+
+func (r *AnnouncementRepo) Create(announcement *models.Announcement) error {
+	announcement.UUID = uuid.New()
+	announcement.CreatedAt = time.Now()
+	announcement.UpdatedAt = time.Now()
+	return r.db.Create(announcement).Error
+}
+
+// Update saves every field of an existing announcement
+func (r *AnnouncementRepo) Update(announcement *models.Announcement) error {
+	announcement.UpdatedAt = time.Now()
+	return r.db.Save(announcement).Error
+}
+
+// Get returns an announcement, or gorm.ErrRecordNotFound
+func (r *AnnouncementRepo) Get(id uuid.UUID) (*models.Announcement, error) {
+	var announcement models.Announcement
+	if err := r.db.Where("uuid = ?", id).First(&announcement).Error; err != nil {
+		return nil, err
+	}
+	return &announcement, nil
+}
+
+// List returns every announcement, drafts included, newest first
+func (r *AnnouncementRepo) List() ([]models.Announcement, error) {
+	var announcements []models.Announcement
+	err := r.db.Order("created_at DESC").Find(&announcements).Error
+	return announcements, err
+}
+
+// Delete removes an announcement and its read receipts, returning gorm.ErrRecordNotFound when there is none
+func (r *AnnouncementRepo) Delete(id uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("announcement_id = ?", id).Delete(&models.AnnouncementRead{}).Error; err != nil {
+			return err
+		}
+		result := tx.Where("uuid = ?", id).Delete(&models.Announcement{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return nil
+	})
+}
+
+// ListPublished returns the latest published announcements with the user's read state, newest first
+func (r *AnnouncementRepo) ListPublished(userID uuid.UUID, limit int) ([]models.UserAnnouncement, error) {
+	var announcements []models.UserAnnouncement
+	err := r.db.Table("announcements").
+		Select("announcements.*, announcement_reads.user_id IS NOT NULL AS read").
+		Joins("LEFT JOIN announcement_reads ON announcement_reads.announcement_id = announcements.uuid AND announcement_reads.user_id = ?", userID).
+		Where("announcements.published_at IS NOT NULL").
+		Order("announcements.published_at DESC").
+		Limit(limit).
+		Scan(&announcements).Error
+	return announcements, err
+}
+
+// CountUnread counts the published announcements the user hasn't read
+func (r *AnnouncementRepo) CountUnread(userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Announcement{}).
+		Where("published_at IS NOT NULL").
+		Where("NOT EXISTS (SELECT 1 FROM announcement_reads WHERE announcement_reads.announcement_id = announcements.uuid AND announcement_reads.user_id = ?)", userID).
+		Count(&count).Error
+	return count, err
+}
+
+// MarkRead records the announcements as read; unknown, unpublished and already read ids are skipped
+func (r *AnnouncementRepo) MarkRead(userID uuid.UUID, ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Exec(`INSERT INTO announcement_reads (announcement_id, user_id, read_at)
+		SELECT uuid, ?, NOW() FROM announcements WHERE uuid IN ? AND published_at IS NOT NULL
+		ON CONFLICT DO NOTHING`, userID, ids).Error
+}
+
+// MarkAllRead records every published announcement as read
+func (r *AnnouncementRepo) MarkAllRead(userID uuid.UUID) error {
+	return r.db.Exec(`INSERT INTO announcement_reads (announcement_id, user_id, read_at)
+		SELECT uuid, ?, NOW() FROM announcements WHERE published_at IS NOT NULL
+		ON CONFLICT DO NOTHING`, userID).Error
+}
@@ -0,0 +1,72 @@
+package repo
+
+import (
+	"melina-studio-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type APIKeyRepo struct {
+	db *gorm.DB
+}
+
+type APIKeyRepoInterface interface {
+	CreateKey(key *models.APIKey) error
+	ListKeys(userID uuid.UUID) ([]models.APIKey, error)
+	CountKeys(userID uuid.UUID) (int64, error)
+	GetByHash(keyHash string) (*models.APIKey, error)
+	DeleteKey(userID uuid.UUID, keyID uuid.UUID) error
+	MarkUsed(keyID uuid.UUID, at time.Time) error
+}
+
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepoInterface {
+	return &APIKeyRepo{db: db}
+}
+
+// CreateKey stores a new API key
+func (r *APIKeyRepo) CreateKey(key *models.APIKey) error {
+	key.UUID = uuid.New()
+	key.CreatedAt = time.Now()
+	return r.db.Create(key).Error
+}
+
+// ListKeys returns the user's API keys, newest first
+func (r *APIKeyRepo) ListKeys(userID uuid.UUID) ([]models.APIKey, error) {
+	var keys []models.APIKey
+	err := r.db.Where("user_id = ?", userID).Order("created_at DESC").Find(&keys).Error
+	return keys, err
+}
+
+func (r *APIKeyRepo) CountKeys(userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.APIKey{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+// GetByHash returns the API key with the hash, gorm.ErrRecordNotFound when there is none
+func (r *APIKeyRepo) GetByHash(keyHash string) (*models.APIKey, error) {
+	var key models.APIKey
+	if err := r.db.Where("key_hash = ?", keyHash).First(&key).Error; err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+// DeleteKey revokes an API key, returning gorm.ErrRecordNotFound when the user has no such key
+func (r *APIKeyRepo) DeleteKey(userID uuid.UUID, keyID uuid.UUID) error {
+	result := r.db.Where("uuid = ? AND user_id = ?", keyID, userID).Delete(&models.APIKey{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+// MarkUsed records when the key was last used
+func (r *APIKeyRepo) MarkUsed(keyID uuid.UUID, at time.Time) error {
+	return r.db.Model(&models.APIKey{}).Where("uuid = ?", keyID).Update("last_used_at", at).Error
+}
@@ -20,6 +20,10 @@ type AuthRepoInterface interface {
 	UpdateUserByID(id uuid.UUID, payload *models.User) error
 	DeleteUser(id uuid.UUID) error
 	UpdateUserSubscription(userID uuid.UUID, subscription models.Subscription, startDate time.Time) error
+	SetStoreThinking(userID uuid.UUID, enabled bool) error
+	SetVerifyDrawings(userID uuid.UUID, enabled bool) error
+	SetConfirmDestructiveTools(userID uuid.UUID, enabled bool) error
+	SetRedactPII(userID uuid.UUID, enabled bool) error
 }
 
 func NewAuthRepository(db *gorm.DB) AuthRepoInterface {
@@ -75,3 +79,34 @@ func (r *AuthRepo) UpdateUserSubscription(userID uuid.UUID, subscription models.
 	}
 	return r.db.Model(&models.User{}).Where("uuid = ?", userID).Updates(updates).Error
 }
+
+// SetVerifyDrawings turns the vision check of the agent's drawings on or off
+func (r *AuthRepo) SetVerifyDrawings(userID uuid.UUID, enabled bool) error {
+	return r.db.Model(&models.User{}).Where("uuid = ?", userID).Update("verify_drawings", enabled).Error
+}
+
+// SetConfirmDestructiveTools turns the confirmation prompt before destructive tool calls on or off
+func (r *AuthRepo) SetConfirmDestructiveTools(userID uuid.UUID, enabled bool) error {
+	return r.db.Model(&models.User{}).Where("uuid = ?", userID).Update("confirm_destructive_tools", enabled).Error
+}
+
+// SetRedactPII turns the redaction of personal data in LLM requests on or off
+func (r *AuthRepo) SetRedactPII(userID uuid.UUID, enabled bool) error {
+	return r.db.Model(&models.User{}).Where("uuid = ?", userID).Update("redact_pii", enabled).Error
+}
+
+// SetStoreThinking turns reasoning persistence on or off; turning it off also erases the reasoning already stored
+func (r *AuthRepo) SetStoreThinking(userID uuid.UUID, enabled bool) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.User{}).Where("uuid = ?", userID).Update("store_thinking", enabled).Error; err != nil {
+			return err
+		}
+		if enabled {
+			return nil
+		}
+		return tx.Model(&models.Chat{}).
+			Where("board_uuid IN (?)", tx.Model(&models.Board{}).Select("uuid").Where("user_id = ?", userID)).
+			Where("thought IS NOT NULL").
+			Update("thought", nil).Error
+	})
+}
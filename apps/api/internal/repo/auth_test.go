@@ -0,0 +1,98 @@
+package repo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// recordingConn is a connection pool that records the statements it is sent instead of running them
+type recordingConn struct {
+	statements []string
+	args       [][]interface{}
+	committed  bool
+}
+
+func (c *recordingConn) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *recordingConn) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	c.statements = append(c.statements, query)
+	c.args = append(c.args, args)
+	return driverResult(1), nil
+}
+
+func (c *recordingConn) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	return nil, errors.New("not supported")
+}
+
+func (c *recordingConn) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	return nil
+}
+
+func (c *recordingConn) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	return &recordingTx{c}, nil
+}
+
+// recordingTx sends a transaction's statements to the connection that began it
+type recordingTx struct {
+	*recordingConn
+}
+
+func (tx *recordingTx) Commit() error {
+	tx.committed = true
+	return nil
+}
+
+func (tx *recordingTx) Rollback() error {
+	return nil
+}
+
+type driverResult int64
+
+func (r driverResult) LastInsertId() (int64, error) { return 0, nil }
+func (r driverResult) RowsAffected() (int64, error) { return int64(r), nil }
+
+func newRecordingDB(t *testing.T) (*gorm.DB, *recordingConn) {
+	t.Helper()
+	conn := &recordingConn{}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: conn}), &gorm.Config{DisableAutomaticPing: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	return db, conn
+}
+
+func TestSetStoreThinking(t *testing.T) {
+	db, conn := newRecordingDB(t)
+	if err := NewAuthRepository(db).SetStoreThinking(uuid.New(), true); err != nil {
+		t.Fatal(err)
+	}
+	if len(conn.statements) != 1 || !strings.Contains(conn.statements[0], `"store_thinking"`) {
+		t.Fatalf("turning thinking on should only update the preference, got %q", conn.statements)
+	}
+
+	db, conn = newRecordingDB(t)
+	if err := NewAuthRepository(db).SetStoreThinking(uuid.New(), false); err != nil {
+		t.Fatal(err)
+	}
+	if len(conn.statements) != 2 || !conn.committed {
+		t.Fatalf("expected the preference and the stored thinking to change in one transaction, got %q", conn.statements)
+	}
+	erase := conn.statements[1]
+	if conn.args[1][0] != nil {
+		t.Errorf("expected the stored thinking to be cleared, got %v", conn.args[1][0])
+	}
+	for _, part := range []string{`UPDATE "chats" SET "thought"=$1`, "board_uuid IN (SELECT", "user_id =", "thought IS NOT NULL"} {
+		if !strings.Contains(erase, part) {
+			t.Errorf("expected %q in %q", part, erase)
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package repo
+
+import (
+	"melina-studio-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type AutomationRepo struct {
+	db *gorm.DB
+}
+
+type AutomationRepoInterface interface {
+	CreateRule(rule *models.AutomationRule) error
+	UpdateRule(rule *models.AutomationRule) error
+	GetRule(boardID uuid.UUID, ruleID uuid.UUID) (*models.AutomationRule, error)
+	ListRules(boardID uuid.UUID) ([]models.AutomationRule, error)
+	ListEnabledRules(boardID uuid.UUID) ([]models.AutomationRule, error)
+	CountRules(boardID uuid.UUID) (int64, error)
+	DeleteRule(boardID uuid.UUID, ruleID uuid.UUID) error
+	CreateRun(run *models.AutomationRun) error
+	ListRuns(ruleID uuid.UUID, limit int) ([]models.AutomationRun, error)
+}
+
+func NewAutomationRepository(db *gorm.DB) AutomationRepoInterface {
+	return &AutomationRepo{db: db}
+}
+
+// CreateRule stores a new automation rule
+func (r *AutomationRepo) CreateRule(rule *models.AutomationRule) error {
+	rule.UUID = uuid.New()
+	rule.CreatedAt = time.Now()
+	rule.UpdatedAt = time.Now()
+	return r.db.Create(rule).Error
+}
+
+// UpdateRule saves every field of an existing rule
+func (r *AutomationRepo) UpdateRule(rule *models.AutomationRule) error {
+	rule.UpdatedAt = time.Now()
+	return r.db.Save(rule).Error
+}
+
+// GetRule returns a rule of the board, gorm.ErrRecordNotFound when the board has none with that id
+func (r *AutomationRepo) GetRule(boardID uuid.UUID, ruleID uuid.UUID) (*models.AutomationRule, error) {
+	var rule models.AutomationRule
+	if err := r.db.Where("uuid = ? AND board_id = ?", ruleID, boardID).First(&rule).Error; err != nil {
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// ListRules returns the board's rules, oldest first
+func (r *AutomationRepo) ListRules(boardID uuid.UUID) ([]models.AutomationRule, error) {
+	var rules []models.AutomationRule
+	err := r.db.Where("board_id = ?", boardID).Order("created_at").Find(&rules).Error
+	return rules, err
+}
+
+// ListEnabledRules returns the board's rules that fire, oldest first
+func (r *AutomationRepo) ListEnabledRules(boardID uuid.UUID) ([]models.AutomationRule, error) {
+	var rules []models.AutomationRule
+	err := r.db.Where("board_id = ? AND enabled = ?", boardID, true).Order("created_at").Find(&rules).Error
+	return rules, err
+}
+
+func (r *AutomationRepo) CountRules(boardID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.AutomationRule{}).Where("board_id = ?", boardID).Count(&count).Error
+	return count, err
+}
+
+// DeleteRule removes a rule with its runs, returning gorm.ErrRecordNotFound when the board has no such rule
+func (r *AutomationRepo) DeleteRule(boardID uuid.UUID, ruleID uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("uuid = ? AND board_id = ?", ruleID, boardID).Delete(&models.AutomationRule{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		if err := tx.Where("rule_id = ?", ruleID).Delete(&models.AutomationRun{}).Error; err != nil {
+			return err
+		}
+		return nil
+	})
+}
+
+// CreateRun logs a run of a rule and stamps the rule's last run
+func (r *AutomationRepo) CreateRun(run *models.AutomationRun) error {
+	run.UUID = uuid.New()
+	run.CreatedAt = time.Now()
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(run).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.AutomationRule{}).Where("uuid = ?", run.RuleID).Update("last_run_at", run.CreatedAt).Error
+	})
+}
+
+// ListRuns returns the latest runs of a rule, newest first
+func (r *AutomationRepo) ListRuns(ruleID uuid.UUID, limit int) ([]models.AutomationRun, error) {
+	var runs []models.AutomationRun
+	err := r.db.Where("rule_id = ?", ruleID).Order("created_at DESC").Limit(limit).Find(&runs).Error
+	return runs, err
+}
@@ -0,0 +1,79 @@
+package repo
+
+import (
+	"melina-studio-backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+const backupRestoreBatchSize = 200
+
+type BackupRepo struct {
+	db *gorm.DB
+}
+
+type BackupRepoInterface interface {
+	LoadTenant(userID uuid.UUID) (*models.TenantBackup, error)
+	RestoreTenant(backup *models.TenantBackup) error
+	ListUserIDsWithBoards() ([]uuid.UUID, error)
+}
+
+func NewBackupRepository(db *gorm.DB) BackupRepoInterface {
+	return &BackupRepo{db: db}
+}
+
+// LoadTenant reads every board of the user, deleted ones included, with their shapes and chats
+func (r *BackupRepo) LoadTenant(userID uuid.UUID) (*models.TenantBackup, error) {
+	backup := &models.TenantBackup{UserID: userID}
+	if err := r.db.Where("user_id = ?", userID).Order("created_at").Find(&backup.Boards).Error; err != nil {
+		return nil, err
+	}
+	if len(backup.Boards) == 0 {
+		return backup, nil
+	}
+
+	boardIDs := make([]uuid.UUID, len(backup.Boards))
+	for i, board := range backup.Boards {
+		boardIDs[i] = board.UUID
+	}
+	if err := r.db.Where("board_id IN ?", boardIDs).Order("created_at").Find(&backup.BoardData).Error; err != nil {
+		return nil, err
+	}
+	if err := r.db.Where("board_uuid IN ?", boardIDs).Order("created_at").Find(&backup.Chats).Error; err != nil {
+		return nil, err
+	}
+	return backup, nil
+}
+
+// RestoreTenant upserts the backup's rows in a single transaction
+// Rows that exist are overwritten with the backed up values; rows created after the backup are kept
+func (r *BackupRepo) RestoreTenant(backup *models.TenantBackup) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		upsert := tx.Clauses(clause.OnConflict{UpdateAll: true})
+		if len(backup.Boards) > 0 {
+			if err := upsert.CreateInBatches(backup.Boards, backupRestoreBatchSize).Error; err != nil {
+				return err
+			}
+		}
+		if len(backup.BoardData) > 0 {
+			if err := upsert.CreateInBatches(backup.BoardData, backupRestoreBatchSize).Error; err != nil {
+				return err
+			}
+		}
+		if len(backup.Chats) > 0 {
+			if err := upsert.CreateInBatches(backup.Chats, backupRestoreBatchSize).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// ListUserIDsWithBoards returns every user that owns at least one board
+func (r *BackupRepo) ListUserIDsWithBoards() ([]uuid.UUID, error) {
+	var userIDs []uuid.UUID
+	err := r.db.Model(&models.Board{}).Distinct("user_id").Pluck("user_id", &userIDs).Error
+	return userIDs, err
+}
@@ -17,11 +17,20 @@ type BoardRepo struct {
 
 type BoardRepoInterface interface {
 	CreateBoard(board *models.Board) (uuid.UUID, error)
+	CreateSandboxBoard(board *models.Board, ttl time.Duration) (uuid.UUID, error)
 	GetAllBoards(userID uuid.UUID) ([]models.Board, error)
+	ListBoards(userID uuid.UUID, archived bool, columns []string) ([]models.Board, error)
+	GetBoardsVersion(userID uuid.UUID) (int64, time.Time, error)
 	GetBoardById(userID uuid.UUID, boardId uuid.UUID) (models.Board, error)
 	UpdateBoard(userID uuid.UUID, boardId uuid.UUID, board *models.Board) error
 	DeleteBoardByID(userID uuid.UUID, boardId uuid.UUID) error
+	SetArchived(userID uuid.UUID, boardId uuid.UUID, archived bool) error
+	SetBoardCover(userID uuid.UUID, boardId uuid.UUID, cover models.BoardCover) error
+	SetBoardGrid(userID uuid.UUID, boardId uuid.UUID, grid models.BoardGrid) error
+	SetBoardTimeline(userID uuid.UUID, boardId uuid.UUID, timeline models.BoardTimeline) error
 	ValidateBoardOwnership(userID uuid.UUID, boardId uuid.UUID) error
+	GetExpiredSandboxBoards(now time.Time) ([]models.Board, error)
+	PurgeBoard(boardId uuid.UUID) error
 }
 
 func NewBoardRepository(db *gorm.DB) BoardRepoInterface {
@@ -38,6 +47,14 @@ func (r *BoardRepo) CreateBoard(board *models.Board) (uuid.UUID, error) {
 	return uuid, err
 }
 
+// CreateSandboxBoard creates a sandbox board that expires after ttl
+func (r *BoardRepo) CreateSandboxBoard(board *models.Board, ttl time.Duration) (uuid.UUID, error) {
+	expiresAt := time.Now().Add(ttl)
+	board.IsSandbox = true
+	board.ExpiresAt = &expiresAt
+	return r.CreateBoard(board)
+}
+
 // GetBoardById returns a board by its ID
 func (r *BoardRepo) GetBoardById(userID uuid.UUID, boardId uuid.UUID) (models.Board, error) {
 	var board models.Board
@@ -58,13 +75,93 @@ func (r *BoardRepo) DeleteBoardByID(userID uuid.UUID, boardId uuid.UUID) error {
 	}).Error
 }
 
-// GetAllBoards returns all boards in the database
+// SetArchived archives or unarchives a board; archiving is not deleting, an archived board keeps everything
+func (r *BoardRepo) SetArchived(userID uuid.UUID, boardId uuid.UUID, archived bool) error {
+	now := time.Now()
+	var archivedAt *time.Time
+	if archived {
+		archivedAt = &now
+	}
+	return r.db.Model(&models.Board{}).Where("uuid = ? AND user_id = ? AND is_deleted = ?", boardId, userID, false).Updates(map[string]any{
+		"is_archived": archived,
+		"archived_at": archivedAt,
+		"updated_at":  now,
+	}).Error
+}
+
+// SetBoardCover updates the board's cover, icon and description; unlike UpdateBoard it can clear them
+func (r *BoardRepo) SetBoardCover(userID uuid.UUID, boardId uuid.UUID, cover models.BoardCover) error {
+	updates := map[string]any{"updated_at": time.Now()}
+	if cover.CoverImage != nil {
+		updates["cover_image"] = *cover.CoverImage
+	}
+	if cover.Icon != nil {
+		updates["icon"] = *cover.Icon
+	}
+	if cover.Description != nil {
+		updates["description"] = *cover.Description
+	}
+	return r.db.Model(&models.Board{}).Where("uuid = ? AND user_id = ? AND is_deleted = ?", boardId, userID, false).Updates(updates).Error
+}
+
+// SetBoardGrid updates a board's grid settings; snapping can be turned off, which a struct update would skip
+func (r *BoardRepo) SetBoardGrid(userID uuid.UUID, boardId uuid.UUID, grid models.BoardGrid) error {
+	updates := map[string]any{"updated_at": time.Now()}
+	if grid.GridSize != nil {
+		updates["grid_size"] = *grid.GridSize
+	}
+	if grid.SnapToGrid != nil {
+		updates["snap_to_grid"] = *grid.SnapToGrid
+	}
+	return r.db.Model(&models.Board{}).Where("uuid = ? AND user_id = ? AND is_deleted = ?", boardId, userID, false).Updates(updates).Error
+}
+
+// SetBoardTimeline updates a board's time scale; the origin can be cleared or moved to x 0, which a struct update would skip
+func (r *BoardRepo) SetBoardTimeline(userID uuid.UUID, boardId uuid.UUID, timeline models.BoardTimeline) error {
+	updates := map[string]any{"updated_at": time.Now()}
+	if timeline.Origin != nil {
+		updates["timeline_origin"] = *timeline.Origin
+	}
+	if timeline.OriginX != nil {
+		updates["timeline_origin_x"] = *timeline.OriginX
+	}
+	if timeline.DayWidth != nil {
+		updates["timeline_day_width"] = *timeline.DayWidth
+	}
+	return r.db.Model(&models.Board{}).Where("uuid = ? AND user_id = ? AND is_deleted = ?", boardId, userID, false).Updates(updates).Error
+}
+
+// GetAllBoards returns all of the user's boards except sandboxes, archived ones included
 func (r *BoardRepo) GetAllBoards(userID uuid.UUID) ([]models.Board, error) {
 	var boards []models.Board
-	err := r.db.Where("user_id = ? AND is_deleted = ?", userID, false).Find(&boards).Error
+	err := r.db.Where("user_id = ? AND is_deleted = ? AND is_sandbox = ?", userID, false, false).Find(&boards).Error
 	return boards, err
 }
 
+// ListBoards returns the user's archived or active boards, sandboxes excepted, with only the given columns loaded,
+// or every column when none are given
+func (r *BoardRepo) ListBoards(userID uuid.UUID, archived bool, columns []string) ([]models.Board, error) {
+	var boards []models.Board
+	query := r.db.Where("user_id = ? AND is_deleted = ? AND is_sandbox = ? AND is_archived = ?", userID, false, false, archived)
+	if len(columns) > 0 {
+		query = query.Select(columns)
+	}
+	err := query.Find(&boards).Error
+	return boards, err
+}
+
+// GetBoardsVersion returns the number of the user's boards and when the last one changed; deleting a board
+// changes the count, so it identifies a version of the board list
+func (r *BoardRepo) GetBoardsVersion(userID uuid.UUID) (int64, time.Time, error) {
+	var version dataVersion
+	err := r.db.Model(&models.Board{}).
+		Select("COUNT(*) AS count, MAX(updated_at) AS last_updated").
+		Where("user_id = ? AND is_deleted = ? AND is_sandbox = ?", userID, false, false).
+		Scan(&version).Error
+	count, lastUpdated := version.values()
+	return count, lastUpdated, err
+}
+
 // ValidateBoardOwnership checks if user owns the specified board
 func (r *BoardRepo) ValidateBoardOwnership(userID uuid.UUID, boardId uuid.UUID) error {
 	var count int64
@@ -79,3 +176,35 @@ func (r *BoardRepo) ValidateBoardOwnership(userID uuid.UUID, boardId uuid.UUID)
 	}
 	return nil
 }
+
+// GetExpiredSandboxBoards returns the sandbox boards that expired before now, deleted ones included
+func (r *BoardRepo) GetExpiredSandboxBoards(now time.Time) ([]models.Board, error) {
+	var boards []models.Board
+	err := r.db.Where("is_sandbox = ? AND expires_at < ?", true, now).Find(&boards).Error
+	return boards, err
+}
+
+// PurgeBoard removes a board from the database for good, with its shapes, messages and everything recorded about them
+func (r *BoardRepo) PurgeBoard(boardId uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		boardScoped := []any{
+			&models.BoardData{},
+			&models.TurnMetadata{},
+			&models.MessageFeedback{},
+			&models.BoardActivity{},
+			&models.BoardSummary{},
+			&models.CodeArtifact{},
+			&models.FacilitationSession{},
+			&models.BoardVersion{},
+		}
+		for _, model := range boardScoped {
+			if err := tx.Where("board_id = ?", boardId).Delete(model).Error; err != nil {
+				return err
+			}
+		}
+		if err := tx.Where("board_uuid = ?", boardId).Delete(&models.Chat{}).Error; err != nil {
+			return err
+		}
+		return tx.Where("uuid = ?", boardId).Delete(&models.Board{}).Error
+	})
+}
@@ -0,0 +1,82 @@
+package repo
+
+import (
+	"melina-studio-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type BoardActivityRepo struct {
+	db *gorm.DB
+}
+
+type BoardActivityRepoInterface interface {
+	Create(activities ...*models.BoardActivity) error
+	ListByBoard(boardID uuid.UUID, page int, pageSize int) ([]models.BoardActivity, int64, error)
+	ListSince(boardID uuid.UUID, since time.Time, types []models.ActivityType, actor models.ActivityActor, limit int) ([]models.BoardActivity, error)
+}
+
+func NewBoardActivityRepository(db *gorm.DB) BoardActivityRepoInterface {
+	return &BoardActivityRepo{db: db}
+}
+
+// Create stores one or more activity entries
+func (r *BoardActivityRepo) Create(activities ...*models.BoardActivity) error {
+	if len(activities) == 0 {
+		return nil
+	}
+	now := time.Now()
+	for _, activity := range activities {
+		activity.UUID = uuid.New()
+		activity.CreatedAt = now
+		if activity.Count == 0 {
+			activity.Count = 1
+		}
+	}
+	return r.db.Create(activities).Error
+}
+
+// ListByBoard returns a page of a board's activity, newest first
+func (r *BoardActivityRepo) ListByBoard(boardID uuid.UUID, page int, pageSize int) ([]models.BoardActivity, int64, error) {
+	var activities []models.BoardActivity
+	var total int64
+
+	if page < 1 {
+		page = 1
+	}
+	const DefaultPageSize = 20
+	const MaxPageSize = 100
+	if pageSize <= 0 {
+		pageSize = DefaultPageSize
+	}
+	if pageSize > MaxPageSize {
+		pageSize = MaxPageSize
+	}
+
+	base := r.db.Model(&models.BoardActivity{}).Where("board_id = ?", boardID)
+	if err := base.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	err := base.Order("created_at DESC").
+		Limit(pageSize).
+		Offset((page - 1) * pageSize).
+		Find(&activities).Error
+	return activities, total, err
+}
+
+// ListSince returns the board's activity of the given types logged after since, newest first; an empty actor
+// means anyone's
+func (r *BoardActivityRepo) ListSince(boardID uuid.UUID, since time.Time, types []models.ActivityType, actor models.ActivityActor, limit int) ([]models.BoardActivity, error) {
+	var activities []models.BoardActivity
+	query := r.db.Where("board_id = ? AND created_at > ? AND type IN ?", boardID, since, types)
+	if actor != "" {
+		query = query.Where("actor = ?", actor)
+	}
+	err := query.Order("created_at DESC").
+		Limit(limit).
+		Find(&activities).Error
+	return activities, err
+}
@@ -30,6 +30,34 @@ type BoardDataRepoInterface interface {
 	GetNextAnnotationNumber(boardId uuid.UUID) (int, error)
 	GetShapeByUUID(shapeUUID uuid.UUID) (*models.BoardData, error)
 	GetShapesByUUIDs(shapeUUIDs []uuid.UUID) ([]models.BoardData, error)
+	UpdateShapeData(boardId uuid.UUID, shapeId uuid.UUID, data datatypes.JSON) error
+	UpdateShapesData(boardId uuid.UUID, updates map[uuid.UUID]datatypes.JSON) error
+	ApplyShapeOperations(boardId uuid.UUID, ops []ShapeOperation) error
+	SetShapeLocked(boardId uuid.UUID, shapeId uuid.UUID, locked bool) error
+	SetSlideOrder(boardId uuid.UUID, frameIds []uuid.UUID) error
+	GetShapesByType(boardId uuid.UUID, shapeType models.Type) ([]models.BoardData, error)
+	CountShapesByBoard(boardIds []uuid.UUID) (map[uuid.UUID]int64, error)
+	GetBoardDataVersion(boardId uuid.UUID) (int64, time.Time, error)
+	GetUserBoardDataVersion(userID uuid.UUID) (int64, time.Time, error)
+}
+
+// ErrShapeNotFound is returned when a batched update names a shape that isn't on the board
+var ErrShapeNotFound = errors.New("shape not found")
+
+// ShapeOp is the kind of a batched shape change
+type ShapeOp string
+
+const (
+	ShapeOpCreate ShapeOp = "create"
+	ShapeOpUpdate ShapeOp = "update"
+	ShapeOpDelete ShapeOp = "delete"
+)
+
+// ShapeOperation is one change of a batched shape save: Shape is set for create and update, ID for every op
+type ShapeOperation struct {
+	Op    ShapeOp
+	ID    uuid.UUID
+	Shape *models.Shape
 }
 
 // NewBoardDataRepository returns a new instance of BoardDataRepo
@@ -147,6 +175,67 @@ func (r *BoardDataRepo) SaveShapeData(boardId uuid.UUID, shapeData *models.Shape
 		addString("fontFamily", shapeData.FontFamily)
 		addString("fill", shapeData.Fill)
 
+	case "action_item":
+		addFloat("x", shapeData.X)
+		addFloat("y", shapeData.Y)
+		addFloat("w", shapeData.W)
+		addFloat("h", shapeData.H)
+		addString("text", shapeData.Text)
+		addString("assignee", shapeData.Assignee)
+		addString("dueDate", shapeData.DueDate)
+		addString("status", shapeData.Status)
+		addString("stroke", shapeData.Stroke)
+		addString("fill", shapeData.Fill)
+
+	case "frame":
+		addFloat("x", shapeData.X)
+		addFloat("y", shapeData.Y)
+		addFloat("w", shapeData.W)
+		addFloat("h", shapeData.H)
+		addString("name", shapeData.Name)
+		addString("kanbanStatus", shapeData.KanbanStatus)
+		addString("stroke", shapeData.Stroke)
+		addString("fill", shapeData.Fill)
+		addFloat("strokeWidth", shapeData.StrokeWidth)
+
+	case "timeline", "milestone":
+		addFloat("x", shapeData.X)
+		addFloat("y", shapeData.Y)
+		addFloat("w", shapeData.W)
+		addFloat("h", shapeData.H)
+		addString("text", shapeData.Text)
+		addString("startDate", shapeData.StartDate)
+		addString("endDate", shapeData.EndDate)
+		addString("stroke", shapeData.Stroke)
+		addString("fill", shapeData.Fill)
+
+	case "button", "input", "navbar", "card":
+		addFloat("x", shapeData.X)
+		addFloat("y", shapeData.Y)
+		addFloat("w", shapeData.W)
+		addFloat("h", shapeData.H)
+		addString("text", shapeData.Text)
+		addString("variant", shapeData.Variant)
+		addString("placeholder", shapeData.Placeholder)
+		if shapeData.Items != nil {
+			dataMap["items"] = *shapeData.Items
+		}
+		addFloat("fontSize", shapeData.FontSize)
+		addString("stroke", shapeData.Stroke)
+		addString("fill", shapeData.Fill)
+
+	case "entity":
+		addFloat("x", shapeData.X)
+		addFloat("y", shapeData.Y)
+		addFloat("w", shapeData.W)
+		addFloat("h", shapeData.H)
+		addString("name", shapeData.Name)
+		if shapeData.Columns != nil {
+			dataMap["columns"] = *shapeData.Columns
+		}
+		addString("stroke", shapeData.Stroke)
+		addString("fill", shapeData.Fill)
+
 	case "path":
 		addFloat("x", shapeData.X)
 		addFloat("y", shapeData.Y)
@@ -250,6 +339,67 @@ func (r *BoardDataRepo) GetBoardData(boardId uuid.UUID) ([]models.BoardData, err
 	return boardData, err
 }
 
+// CountShapesByBoard returns the number of shapes on each of the boards; boards without shapes are left out
+func (r *BoardDataRepo) CountShapesByBoard(boardIds []uuid.UUID) (map[uuid.UUID]int64, error) {
+	counts := make(map[uuid.UUID]int64, len(boardIds))
+	if len(boardIds) == 0 {
+		return counts, nil
+	}
+
+	var rows []struct {
+		BoardId uuid.UUID
+		Count   int64
+	}
+	err := r.db.Model(&models.BoardData{}).
+		Select("board_id, COUNT(*) AS count").
+		Where("board_id IN ?", boardIds).
+		Group("board_id").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	for _, row := range rows {
+		counts[row.BoardId] = row.Count
+	}
+	return counts, nil
+}
+
+// dataVersion is the shape count and last change of a set of shapes; it changes whenever a shape is
+// added, updated or deleted, so it can stand in for the shapes in an ETag without loading them
+type dataVersion struct {
+	Count       int64
+	LastUpdated *time.Time
+}
+
+func (v dataVersion) values() (int64, time.Time) {
+	if v.LastUpdated == nil {
+		return v.Count, time.Time{}
+	}
+	return v.Count, *v.LastUpdated
+}
+
+// GetBoardDataVersion returns the number of shapes on a board and when the last one changed
+func (r *BoardDataRepo) GetBoardDataVersion(boardId uuid.UUID) (int64, time.Time, error) {
+	var version dataVersion
+	err := r.db.Model(&models.BoardData{}).
+		Select("COUNT(*) AS count, MAX(updated_at) AS last_updated").
+		Where("board_id = ?", boardId).
+		Scan(&version).Error
+	count, lastUpdated := version.values()
+	return count, lastUpdated, err
+}
+
+// GetUserBoardDataVersion is GetBoardDataVersion over all of the user's boards that are listed, sandboxes excepted
+func (r *BoardDataRepo) GetUserBoardDataVersion(userID uuid.UUID) (int64, time.Time, error) {
+	var version dataVersion
+	err := r.db.Model(&models.BoardData{}).
+		Select("COUNT(*) AS count, MAX(updated_at) AS last_updated").
+		Where("board_id IN (?)", r.db.Model(&models.Board{}).Select("uuid").Where("user_id = ? AND is_deleted = ? AND is_sandbox = ?", userID, false, false)).
+		Scan(&version).Error
+	count, lastUpdated := version.values()
+	return count, lastUpdated, err
+}
+
 func (r *BoardDataRepo) ClearBoardData(boardId uuid.UUID) error {
 	return r.db.Where("board_id = ?", boardId).Delete(&models.BoardData{}).Error
 }
@@ -307,3 +457,141 @@ func (r *BoardDataRepo) GetShapesByUUIDs(shapeUUIDs []uuid.UUID) ([]models.Board
 	err := r.db.Where("uuid IN ?", shapeUUIDs).Find(&shapes).Error
 	return shapes, err
 }
+
+// UpdateShapeData replaces the raw JSON data of a shape, keeping its type and annotation number
+func (r *BoardDataRepo) UpdateShapeData(boardId uuid.UUID, shapeId uuid.UUID, data datatypes.JSON) error {
+	result := r.db.Model(&models.BoardData{}).
+		Where("board_id = ? AND uuid = ?", boardId, shapeId).
+		Updates(map[string]any{
+			"data":       data,
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("shape not found")
+	}
+	return nil
+}
+
+// UpdateShapesData replaces the data of several shapes in one transaction; if one of them is missing
+// none is changed
+func (r *BoardDataRepo) UpdateShapesData(boardId uuid.UUID, updates map[uuid.UUID]datatypes.JSON) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		for shapeId, data := range updates {
+			result := tx.Model(&models.BoardData{}).
+				Where("board_id = ? AND uuid = ?", boardId, shapeId).
+				Updates(map[string]any{
+					"data":       data,
+					"updated_at": now,
+				})
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return fmt.Errorf("shape %s not found", shapeId)
+			}
+		}
+		return nil
+	})
+}
+
+// CheckShapeOperation checks an operation against the stored shape it touches, nil when there is none. Updating
+// a missing shape fails with ErrShapeNotFound, and so does writing to a shape of another board, as shape ids are
+// global and a shape can't be taken over. Deletes only ever touch the board's own shapes
+func CheckShapeOperation(boardId uuid.UUID, op ShapeOperation, stored *models.BoardData) error {
+	switch op.Op {
+	case ShapeOpCreate, ShapeOpUpdate:
+		if (stored == nil && op.Op == ShapeOpUpdate) || (stored != nil && stored.BoardId != boardId) {
+			return fmt.Errorf("%w: %s", ErrShapeNotFound, op.ID)
+		}
+		return nil
+	case ShapeOpDelete:
+		return nil
+	}
+	return fmt.Errorf("unknown shape operation %q", op.Op)
+}
+
+// ApplyShapeOperations applies a batch of shape changes in one transaction; if one fails none is applied.
+// Creating a shape that is already on the board updates it and deleting a missing one does nothing, so a
+// client can resend a batch it didn't get an answer for. Updating a missing shape fails with ErrShapeNotFound
+func (r *BoardDataRepo) ApplyShapeOperations(boardId uuid.UUID, ops []ShapeOperation) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		txRepo := &BoardDataRepo{db: tx}
+		for _, op := range ops {
+			switch op.Op {
+			case ShapeOpCreate, ShapeOpUpdate:
+				var existing models.BoardData
+				var stored *models.BoardData
+				err := tx.Where("uuid = ?", op.ID).First(&existing).Error
+				if err == nil {
+					stored = &existing
+				} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+					return err
+				}
+				if err := CheckShapeOperation(boardId, op, stored); err != nil {
+					return err
+				}
+				if err := txRepo.SaveShapeData(boardId, op.Shape); err != nil {
+					return err
+				}
+			case ShapeOpDelete:
+				if err := tx.Where("board_id = ? AND uuid = ?", boardId, op.ID).Delete(&models.BoardData{}).Error; err != nil {
+					return err
+				}
+			default:
+				return fmt.Errorf("unknown shape operation %q", op.Op)
+			}
+		}
+		return nil
+	})
+}
+
+// SetShapeLocked locks or unlocks a shape
+func (r *BoardDataRepo) SetShapeLocked(boardId uuid.UUID, shapeId uuid.UUID, locked bool) error {
+	result := r.db.Model(&models.BoardData{}).
+		Where("board_id = ? AND uuid = ?", boardId, shapeId).
+		Updates(map[string]any{
+			"locked":     locked,
+			"updated_at": time.Now(),
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("shape not found")
+	}
+	return nil
+}
+
+// SetSlideOrder makes the frames the board's presentation, in the given order; other frames stop being slides
+func (r *BoardDataRepo) SetSlideOrder(boardId uuid.UUID, frameIds []uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.BoardData{}).
+			Where("board_id = ? AND slide_order IS NOT NULL", boardId).
+			Update("slide_order", nil).Error; err != nil {
+			return err
+		}
+		for i, frameId := range frameIds {
+			result := tx.Model(&models.BoardData{}).
+				Where("board_id = ? AND uuid = ? AND type = ?", boardId, frameId, models.Frame).
+				Update("slide_order", i)
+			if result.Error != nil {
+				return result.Error
+			}
+			if result.RowsAffected == 0 {
+				return fmt.Errorf("frame %s not found", frameId)
+			}
+		}
+		return nil
+	})
+}
+
+// GetShapesByType returns all shapes of one type on a board, oldest first
+func (r *BoardDataRepo) GetShapesByType(boardId uuid.UUID, shapeType models.Type) ([]models.BoardData, error) {
+	var shapes []models.BoardData
+	err := r.db.Where("board_id = ? AND type = ?", boardId, shapeType).Order("created_at ASC").Find(&shapes).Error
+	return shapes, err
+}
@@ -0,0 +1,74 @@
+package repo
+
+import (
+	"melina-studio-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type BoardInboxRepo struct {
+	db *gorm.DB
+}
+
+type BoardInboxRepoInterface interface {
+	CreateInbox(inbox *models.BoardInbox) error
+	UpdateInbox(inbox *models.BoardInbox) error
+	GetByBoard(boardID uuid.UUID) (*models.BoardInbox, error)
+	GetByToken(token string) (*models.BoardInbox, error)
+	DeleteInbox(boardID uuid.UUID) error
+	MarkReceived(inboxID uuid.UUID, at time.Time) error
+}
+
+func NewBoardInboxRepository(db *gorm.DB) BoardInboxRepoInterface {
+	return &BoardInboxRepo{db: db}
+}
+
+// CreateInbox stores a board's new address
+func (r *BoardInboxRepo) CreateInbox(inbox *models.BoardInbox) error {
+	inbox.UUID = uuid.New()
+	inbox.CreatedAt = time.Now()
+	inbox.UpdatedAt = time.Now()
+	return r.db.Create(inbox).Error
+}
+
+// UpdateInbox saves every field of an existing address
+func (r *BoardInboxRepo) UpdateInbox(inbox *models.BoardInbox) error {
+	inbox.UpdatedAt = time.Now()
+	return r.db.Save(inbox).Error
+}
+
+// GetByBoard returns the board's address, gorm.ErrRecordNotFound when it has none
+func (r *BoardInboxRepo) GetByBoard(boardID uuid.UUID) (*models.BoardInbox, error) {
+	var inbox models.BoardInbox
+	if err := r.db.Where("board_id = ?", boardID).First(&inbox).Error; err != nil {
+		return nil, err
+	}
+	return &inbox, nil
+}
+
+// GetByToken returns the address with the local part, gorm.ErrRecordNotFound when there is none
+func (r *BoardInboxRepo) GetByToken(token string) (*models.BoardInbox, error) {
+	var inbox models.BoardInbox
+	if err := r.db.Where("token = ?", token).First(&inbox).Error; err != nil {
+		return nil, err
+	}
+	return &inbox, nil
+}
+
+// DeleteInbox removes the board's address, returning gorm.ErrRecordNotFound when it has none
+func (r *BoardInboxRepo) DeleteInbox(boardID uuid.UUID) error {
+	result := r.db.Where("board_id = ?", boardID).Delete(&models.BoardInbox{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
+func (r *BoardInboxRepo) MarkReceived(inboxID uuid.UUID, at time.Time) error {
+	return r.db.Model(&models.BoardInbox{}).Where("uuid = ?", inboxID).Update("last_received_at", at).Error
+}
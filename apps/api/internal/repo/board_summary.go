@@ -0,0 +1,62 @@
+package repo
+
+import (
+	"melina-studio-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type BoardSummaryRepo struct {
+	db *gorm.DB
+}
+
+type BoardSummaryRepoInterface interface {
+	Create(summary *models.BoardSummary) error
+	GetByID(userID uuid.UUID, boardID uuid.UUID, summaryID uuid.UUID) (*models.BoardSummary, error)
+	ListByBoard(userID uuid.UUID, boardID uuid.UUID) ([]models.BoardSummary, error)
+	UpdateStatus(summaryID uuid.UUID, status models.SummaryStatus, markdown string, errMsg string) error
+}
+
+func NewBoardSummaryRepository(db *gorm.DB) BoardSummaryRepoInterface {
+	return &BoardSummaryRepo{db: db}
+}
+
+// Create stores a new summary record
+func (r *BoardSummaryRepo) Create(summary *models.BoardSummary) error {
+	summary.UUID = uuid.New()
+	summary.CreatedAt = time.Now()
+	summary.UpdatedAt = time.Now()
+	return r.db.Create(summary).Error
+}
+
+// GetByID returns a summary owned by the user
+func (r *BoardSummaryRepo) GetByID(userID uuid.UUID, boardID uuid.UUID, summaryID uuid.UUID) (*models.BoardSummary, error) {
+	var summary models.BoardSummary
+	err := r.db.Where("uuid = ? AND board_id = ? AND user_id = ?", summaryID, boardID, userID).First(&summary).Error
+	if err != nil {
+		return nil, err
+	}
+	return &summary, nil
+}
+
+// ListByBoard returns all summaries of a board, newest first, without their content
+func (r *BoardSummaryRepo) ListByBoard(userID uuid.UUID, boardID uuid.UUID) ([]models.BoardSummary, error) {
+	var summaries []models.BoardSummary
+	err := r.db.Select("uuid", "board_id", "user_id", "status", "model_name", "error", "created_at", "updated_at").
+		Where("board_id = ? AND user_id = ?", boardID, userID).
+		Order("created_at DESC").
+		Find(&summaries).Error
+	return summaries, err
+}
+
+// UpdateStatus updates the status and, when provided, the content or error of a summary
+func (r *BoardSummaryRepo) UpdateStatus(summaryID uuid.UUID, status models.SummaryStatus, markdown string, errMsg string) error {
+	return r.db.Model(&models.BoardSummary{}).Where("uuid = ?", summaryID).Updates(map[string]any{
+		"status":     status,
+		"markdown":   markdown,
+		"error":      errMsg,
+		"updated_at": time.Now(),
+	}).Error
+}
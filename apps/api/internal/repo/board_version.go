@@ -0,0 +1,91 @@
+package repo
+
+import (
+	"melina-studio-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// boardVersionRestoreBatchSize is how many shapes a restore inserts per statement
+const boardVersionRestoreBatchSize = 500
+
+type BoardVersionRepo struct {
+	db *gorm.DB
+}
+
+type BoardVersionRepoInterface interface {
+	CreateVersion(version *models.BoardVersion) error
+	ListVersions(boardID uuid.UUID, limit int) ([]models.BoardVersion, error)
+	GetVersion(boardID uuid.UUID, versionID uuid.UUID) (*models.BoardVersion, error)
+	GetLatestChecksum(boardID uuid.UUID) (string, error)
+	PruneVersions(boardID uuid.UUID, keep int) error
+	ListChangedBoards(since time.Time, limit int) ([]models.Board, error)
+	RestoreShapes(boardID uuid.UUID, shapes []models.BoardData) error
+}
+
+func NewBoardVersionRepository(db *gorm.DB) BoardVersionRepoInterface {
+	return &BoardVersionRepo{db: db}
+}
+
+// CreateVersion stores a new snapshot
+func (r *BoardVersionRepo) CreateVersion(version *models.BoardVersion) error {
+	version.UUID = uuid.New()
+	version.CreatedAt = time.Now()
+	return r.db.Create(version).Error
+}
+
+// ListVersions returns the board's snapshots without their shapes, newest first
+func (r *BoardVersionRepo) ListVersions(boardID uuid.UUID, limit int) ([]models.BoardVersion, error) {
+	var versions []models.BoardVersion
+	err := r.db.Omit("shapes").Where("board_id = ?", boardID).Order("created_at DESC").Limit(limit).Find(&versions).Error
+	return versions, err
+}
+
+// GetVersion returns a snapshot of the board with its shapes, gorm.ErrRecordNotFound when the board has none with that id
+func (r *BoardVersionRepo) GetVersion(boardID uuid.UUID, versionID uuid.UUID) (*models.BoardVersion, error) {
+	var version models.BoardVersion
+	if err := r.db.Where("uuid = ? AND board_id = ?", versionID, boardID).First(&version).Error; err != nil {
+		return nil, err
+	}
+	return &version, nil
+}
+
+// GetLatestChecksum returns the checksum of the board's newest snapshot, empty when it has none
+func (r *BoardVersionRepo) GetLatestChecksum(boardID uuid.UUID) (string, error) {
+	var checksums []string
+	err := r.db.Model(&models.BoardVersion{}).Where("board_id = ?", boardID).Order("created_at DESC").Limit(1).Pluck("checksum", &checksums).Error
+	if err != nil || len(checksums) == 0 {
+		return "", err
+	}
+	return checksums[0], nil
+}
+
+// PruneVersions deletes the board's snapshots beyond the newest keep
+func (r *BoardVersionRepo) PruneVersions(boardID uuid.UUID, keep int) error {
+	newest := r.db.Model(&models.BoardVersion{}).Select("uuid").Where("board_id = ?", boardID).Order("created_at DESC").Limit(keep)
+	return r.db.Where("board_id = ? AND uuid NOT IN (?)", boardID, newest).Delete(&models.BoardVersion{}).Error
+}
+
+// ListChangedBoards returns the boards, deleted ones excepted, with a shape added or updated after since
+func (r *BoardVersionRepo) ListChangedBoards(since time.Time, limit int) ([]models.Board, error) {
+	var boards []models.Board
+	err := r.db.Where("is_deleted = ? AND uuid IN (?)", false,
+		r.db.Model(&models.BoardData{}).Select("board_id").Where("updated_at > ?", since)).
+		Limit(limit).Find(&boards).Error
+	return boards, err
+}
+
+// RestoreShapes replaces every shape of the board with the given ones in a single transaction
+func (r *BoardVersionRepo) RestoreShapes(boardID uuid.UUID, shapes []models.BoardData) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("board_id = ?", boardID).Delete(&models.BoardData{}).Error; err != nil {
+			return err
+		}
+		if len(shapes) == 0 {
+			return nil
+		}
+		return tx.CreateInBatches(shapes, boardVersionRestoreBatchSize).Error
+	})
+}
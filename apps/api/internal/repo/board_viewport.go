@@ -0,0 +1,45 @@
+package repo
+
+import (
+	"errors"
+	"melina-studio-backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// BoardViewportRepo represents the repository for the board viewport model
+type BoardViewportRepo struct {
+	db *gorm.DB
+}
+
+type BoardViewportRepoInterface interface {
+	GetViewport(userID uuid.UUID, boardID uuid.UUID) (*models.BoardViewport, error)
+	SaveViewport(viewport *models.BoardViewport) error
+}
+
+func NewBoardViewportRepository(db *gorm.DB) BoardViewportRepoInterface {
+	return &BoardViewportRepo{db: db}
+}
+
+// GetViewport returns the user's last viewport on the board, nil if they never moved the camera there
+func (r *BoardViewportRepo) GetViewport(userID uuid.UUID, boardID uuid.UUID) (*models.BoardViewport, error) {
+	var viewport models.BoardViewport
+	err := r.db.Where("user_id = ? AND board_id = ?", userID, boardID).First(&viewport).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &viewport, nil
+}
+
+// SaveViewport stores the user's viewport on the board, replacing the previous one
+func (r *BoardViewportRepo) SaveViewport(viewport *models.BoardViewport) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "board_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"x", "y", "zoom", "updated_at"}),
+	}).Create(viewport).Error
+}
@@ -0,0 +1,92 @@
+package repo
+
+import (
+	"errors"
+	"fmt"
+	"melina-studio-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type BudgetRepo struct {
+	db *gorm.DB
+}
+
+type BudgetRepoInterface interface {
+	Get(scope models.BudgetScope, scopeID uuid.UUID) (*models.Budget, error)
+	Save(budget *models.Budget) error
+	Delete(scope models.BudgetScope, scopeID uuid.UUID) error
+	GetSpend(scope models.BudgetScope, scopeID uuid.UUID, since time.Time) (float64, error)
+	MarkAlerted(budgetID uuid.UUID, threshold int, period time.Time) (bool, error)
+}
+
+func NewBudgetRepository(db *gorm.DB) BudgetRepoInterface {
+	return &BudgetRepo{db: db}
+}
+
+// Get returns the budget of a board or tenant, or gorm.ErrRecordNotFound if none is set
+func (r *BudgetRepo) Get(scope models.BudgetScope, scopeID uuid.UUID) (*models.Budget, error) {
+	var budget models.Budget
+	if err := r.db.Where("scope = ? AND scope_id = ?", scope, scopeID).First(&budget).Error; err != nil {
+		return nil, err
+	}
+	return &budget, nil
+}
+
+// Save creates or replaces the budget of a board or tenant, keeping the alerts already sent this period
+func (r *BudgetRepo) Save(budget *models.Budget) error {
+	existing, err := r.Get(budget.Scope, budget.ScopeID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	now := time.Now()
+	budget.UpdatedAt = now
+	if existing == nil {
+		budget.UUID = uuid.New()
+		budget.CreatedAt = now
+		return r.db.Create(budget).Error
+	}
+	budget.UUID = existing.UUID
+	budget.CreatedAt = existing.CreatedAt
+	budget.AlertedThreshold = existing.AlertedThreshold
+	budget.AlertedPeriod = existing.AlertedPeriod
+	return r.db.Save(budget).Error
+}
+
+func (r *BudgetRepo) Delete(scope models.BudgetScope, scopeID uuid.UUID) error {
+	return r.db.Where("scope = ? AND scope_id = ?", scope, scopeID).Delete(&models.Budget{}).Error
+}
+
+// GetSpend returns the USD spent on a board, or by a tenant's users, since the given time
+func (r *BudgetRepo) GetSpend(scope models.BudgetScope, scopeID uuid.UUID, since time.Time) (float64, error) {
+	query := r.db.Model(&models.TokenConsumption{}).
+		Select("COALESCE(SUM(cost_usd), 0)").
+		Where("created_at >= ?", since)
+	switch scope {
+	case models.BudgetScopeBoard:
+		query = query.Where("board_uuid = ?", scopeID)
+	case models.BudgetScopeTenant:
+		query = query.Where("user_uuid IN (?)", r.db.Model(&models.User{}).Select("uuid").Where("tenant_id = ?", scopeID))
+	default:
+		return 0, fmt.Errorf("unknown budget scope: %s", scope)
+	}
+
+	var spent float64
+	err := query.Scan(&spent).Error
+	return spent, err
+}
+
+// MarkAlerted records that a threshold was alerted in a period; false means an equal or higher one already was,
+// which keeps concurrent turns from sending the same alert twice
+func (r *BudgetRepo) MarkAlerted(budgetID uuid.UUID, threshold int, period time.Time) (bool, error) {
+	result := r.db.Model(&models.Budget{}).
+		Where("uuid = ?", budgetID).
+		Where("alerted_period IS NULL OR alerted_period < ? OR alerted_threshold < ?", period, threshold).
+		Updates(map[string]interface{}{
+			"alerted_threshold": threshold,
+			"alerted_period":    period,
+		})
+	return result.RowsAffected > 0, result.Error
+}
@@ -18,7 +18,11 @@ type ChatRepoInterface interface {
 	GetChatsByBoardId(boardId uuid.UUID, page int, pageSize int, fields ...string) ([]models.Chat, int64, error)
 	CreateHumanAndAiMessages(boardUUID uuid.UUID, humanMessage string, aiMessage string, thought *string) (uuid.UUID, uuid.UUID, error)
 	GetChatHistory(boardId uuid.UUID, size int) ([]llmHandlers.Message, error)
+	GetChatHistoryBefore(boardId uuid.UUID, before time.Time, size int) ([]llmHandlers.Message, error)
 	GetLatestChats(boardId uuid.UUID, limit int, fields ...string) ([]models.Chat, error)
+	GetChatByID(boardId uuid.UUID, chatId uuid.UUID) (*models.Chat, error)
+	GetUserChatByID(userID uuid.UUID, chatId uuid.UUID) (*models.Chat, error)
+	SetMessageCost(chatId uuid.UUID, costUSD float64) error
 }
 
 func NewChatRepository(db *gorm.DB) ChatRepoInterface {
@@ -157,3 +161,52 @@ func (r *ChatRepo) GetChatHistory(boardId uuid.UUID, size int) ([]llmHandlers.Me
 
 	return chatHistoryMessages, nil
 }
+
+// GetChatHistoryBefore returns the history a turn started at the given time was sent, the way GetChatHistory
+// picked it then
+func (r *ChatRepo) GetChatHistoryBefore(boardId uuid.UUID, before time.Time, size int) ([]llmHandlers.Message, error) {
+	var chats []models.Chat
+	err := r.db.Model(&models.Chat{}).
+		Select("role", "content").
+		Where("board_uuid = ? AND created_at < ?", boardId, before).
+		Order("created_at ASC").
+		Limit(size).
+		Find(&chats).Error
+	if err != nil {
+		return nil, err
+	}
+
+	history := make([]llmHandlers.Message, 0, len(chats))
+	for _, chat := range chats {
+		history = append(history, llmHandlers.Message{Role: chat.Role, Content: chat.Content})
+	}
+	return history, nil
+}
+
+// GetChatByID returns a single chat message of a board
+func (r *ChatRepo) GetChatByID(boardId uuid.UUID, chatId uuid.UUID) (*models.Chat, error) {
+	var chat models.Chat
+	err := r.db.Where("uuid = ? AND board_uuid = ?", chatId, boardId).First(&chat).Error
+	if err != nil {
+		return nil, err
+	}
+	return &chat, nil
+}
+
+// GetUserChatByID returns a message from any of the user's boards that aren't deleted
+func (r *ChatRepo) GetUserChatByID(userID uuid.UUID, chatId uuid.UUID) (*models.Chat, error) {
+	var chat models.Chat
+	err := r.db.
+		Joins("JOIN boards ON boards.uuid = chats.board_uuid").
+		Where("chats.uuid = ? AND boards.user_id = ? AND boards.is_deleted = ?", chatId, userID, false).
+		First(&chat).Error
+	if err != nil {
+		return nil, err
+	}
+	return &chat, nil
+}
+
+// SetMessageCost stores the cost of the turn on its assistant message
+func (r *ChatRepo) SetMessageCost(chatId uuid.UUID, costUSD float64) error {
+	return r.db.Model(&models.Chat{}).Where("uuid = ?", chatId).Update("cost_usd", costUSD).Error
+}
@@ -0,0 +1,71 @@
+package repo
+
+import (
+	"errors"
+	"melina-studio-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// CleanupRepo stores the cleanup job's settings and the stats of its runs
+type CleanupRepo struct {
+	db *gorm.DB
+}
+
+type CleanupRepoInterface interface {
+	GetSettings() (*models.CleanupSettings, error)
+	SaveSettings(settings *models.CleanupSettings) error
+	CreateRun(run *models.CleanupRun) error
+	ListRuns(limit int) ([]models.CleanupRun, error)
+	DeleteRunsBefore(before time.Time) error
+}
+
+func NewCleanupRepository(db *gorm.DB) CleanupRepoInterface {
+	return &CleanupRepo{db: db}
+}
+
+// GetSettings returns the stored settings, nil while the job still runs with the environment's
+func (r *CleanupRepo) GetSettings() (*models.CleanupSettings, error) {
+	var settings models.CleanupSettings
+	err := r.db.Where("id = ?", models.CleanupSettingsID).First(&settings).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &settings, nil
+}
+
+// SaveSettings stores the settings, replacing the previous ones
+func (r *CleanupRepo) SaveSettings(settings *models.CleanupSettings) error {
+	settings.ID = models.CleanupSettingsID
+	settings.UpdatedAt = time.Now()
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"enabled", "interval_minutes", "upload_max_age_minutes", "updated_at"}),
+	}).Create(settings).Error
+}
+
+// CreateRun stores the stats of a cleanup run
+func (r *CleanupRepo) CreateRun(run *models.CleanupRun) error {
+	if run.UUID == uuid.Nil {
+		run.UUID = uuid.New()
+	}
+	return r.db.Create(run).Error
+}
+
+// ListRuns returns the latest runs, newest first
+func (r *CleanupRepo) ListRuns(limit int) ([]models.CleanupRun, error) {
+	var runs []models.CleanupRun
+	err := r.db.Order("started_at DESC").Limit(limit).Find(&runs).Error
+	return runs, err
+}
+
+// DeleteRunsBefore drops the stats of runs started before the given time
+func (r *CleanupRepo) DeleteRunsBefore(before time.Time) error {
+	return r.db.Where("started_at < ?", before).Delete(&models.CleanupRun{}).Error
+}
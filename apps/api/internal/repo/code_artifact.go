@@ -0,0 +1,51 @@
+package repo
+
+import (
+	"melina-studio-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CodeArtifactRepo struct {
+	db *gorm.DB
+}
+
+type CodeArtifactRepoInterface interface {
+	Create(artifact *models.CodeArtifact) error
+	GetByID(userID uuid.UUID, boardID uuid.UUID, artifactID uuid.UUID) (*models.CodeArtifact, error)
+	ListByBoard(userID uuid.UUID, boardID uuid.UUID) ([]models.CodeArtifact, error)
+}
+
+func NewCodeArtifactRepository(db *gorm.DB) CodeArtifactRepoInterface {
+	return &CodeArtifactRepo{db: db}
+}
+
+// Create stores a new code artifact
+func (r *CodeArtifactRepo) Create(artifact *models.CodeArtifact) error {
+	artifact.UUID = uuid.New()
+	artifact.CreatedAt = time.Now()
+	artifact.UpdatedAt = time.Now()
+	return r.db.Create(artifact).Error
+}
+
+// GetByID returns a code artifact owned by the user
+func (r *CodeArtifactRepo) GetByID(userID uuid.UUID, boardID uuid.UUID, artifactID uuid.UUID) (*models.CodeArtifact, error) {
+	var artifact models.CodeArtifact
+	err := r.db.Where("uuid = ? AND board_id = ? AND user_id = ?", artifactID, boardID, userID).First(&artifact).Error
+	if err != nil {
+		return nil, err
+	}
+	return &artifact, nil
+}
+
+// ListByBoard returns all code artifacts of a board, newest first, without their code
+func (r *CodeArtifactRepo) ListByBoard(userID uuid.UUID, boardID uuid.UUID) ([]models.CodeArtifact, error) {
+	var artifacts []models.CodeArtifact
+	err := r.db.Select("uuid", "board_id", "user_id", "frame_id", "frame_name", "framework", "model_name", "created_at", "updated_at").
+		Where("board_id = ? AND user_id = ?", boardID, userID).
+		Order("created_at DESC").
+		Find(&artifacts).Error
+	return artifacts, err
+}
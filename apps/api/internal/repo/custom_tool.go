@@ -0,0 +1,74 @@
+package repo
+
+import (
+	"melina-studio-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type CustomToolRepo struct {
+	db *gorm.DB
+}
+
+type CustomToolRepoInterface interface {
+	Create(tool *models.CustomTool) error
+	Update(tool *models.CustomTool) error
+	GetByName(name string) (*models.CustomTool, error)
+	List() ([]models.CustomTool, error)
+	ListEnabled() ([]models.CustomTool, error)
+	Delete(name string) error
+}
+
+func NewCustomToolRepository(db *gorm.DB) CustomToolRepoInterface {
+	return &CustomToolRepo{db: db}
+}
+
+// Create stores a new custom tool
+func (r *CustomToolRepo) Create(tool *models.CustomTool) error {
+	tool.UUID = uuid.New()
+	tool.CreatedAt = time.Now()
+	tool.UpdatedAt = time.Now()
+	return r.db.Create(tool).Error
+}
+
+// Update saves every field of an existing custom tool
+func (r *CustomToolRepo) Update(tool *models.CustomTool) error {
+	tool.UpdatedAt = time.Now()
+	return r.db.Save(tool).Error
+}
+
+func (r *CustomToolRepo) GetByName(name string) (*models.CustomTool, error) {
+	var tool models.CustomTool
+	if err := r.db.Where("name = ?", name).First(&tool).Error; err != nil {
+		return nil, err
+	}
+	return &tool, nil
+}
+
+// List returns every custom tool ordered by name
+func (r *CustomToolRepo) List() ([]models.CustomTool, error) {
+	var tools []models.CustomTool
+	err := r.db.Order("name").Find(&tools).Error
+	return tools, err
+}
+
+// ListEnabled returns the custom tools offered to the agent, ordered by name
+func (r *CustomToolRepo) ListEnabled() ([]models.CustomTool, error) {
+	var tools []models.CustomTool
+	err := r.db.Where("enabled = ?", true).Order("name").Find(&tools).Error
+	return tools, err
+}
+
+// Delete removes a custom tool, returning gorm.ErrRecordNotFound when there is none
+func (r *CustomToolRepo) Delete(name string) error {
+	result := r.db.Where("name = ?", name).Delete(&models.CustomTool{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
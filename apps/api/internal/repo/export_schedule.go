@@ -0,0 +1,125 @@
+package repo
+
+import (
+	"melina-studio-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type ExportScheduleRepo struct {
+	db *gorm.DB
+}
+
+type ExportScheduleRepoInterface interface {
+	CreateSchedule(schedule *models.ExportSchedule) error
+	UpdateSchedule(schedule *models.ExportSchedule) error
+	GetSchedule(boardID uuid.UUID, scheduleID uuid.UUID) (*models.ExportSchedule, error)
+	GetScheduleByID(scheduleID uuid.UUID) (*models.ExportSchedule, error)
+	ListSchedules(boardID uuid.UUID) ([]models.ExportSchedule, error)
+	CountSchedules(boardID uuid.UUID) (int64, error)
+	DeleteSchedule(boardID uuid.UUID, scheduleID uuid.UUID) error
+	ListDue(now time.Time, limit int) ([]models.ExportSchedule, error)
+	Claim(scheduleID uuid.UUID, dueAt time.Time, nextRunAt time.Time) (bool, error)
+	CreateRun(run *models.ExportRun) error
+	ListRuns(scheduleID uuid.UUID, limit int) ([]models.ExportRun, error)
+}
+
+func NewExportScheduleRepository(db *gorm.DB) ExportScheduleRepoInterface {
+	return &ExportScheduleRepo{db: db}
+}
+
+// CreateSchedule stores a new export schedule
+func (r *ExportScheduleRepo) CreateSchedule(schedule *models.ExportSchedule) error {
+	schedule.UUID = uuid.New()
+	schedule.CreatedAt = time.Now()
+	schedule.UpdatedAt = time.Now()
+	return r.db.Create(schedule).Error
+}
+
+// UpdateSchedule saves every field of an existing schedule
+func (r *ExportScheduleRepo) UpdateSchedule(schedule *models.ExportSchedule) error {
+	schedule.UpdatedAt = time.Now()
+	return r.db.Save(schedule).Error
+}
+
+// GetSchedule returns a schedule of the board, gorm.ErrRecordNotFound when the board has none with that id
+func (r *ExportScheduleRepo) GetSchedule(boardID uuid.UUID, scheduleID uuid.UUID) (*models.ExportSchedule, error) {
+	var schedule models.ExportSchedule
+	if err := r.db.Where("uuid = ? AND board_id = ?", scheduleID, boardID).First(&schedule).Error; err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// GetScheduleByID returns a schedule whatever its board, for the job that runs it
+func (r *ExportScheduleRepo) GetScheduleByID(scheduleID uuid.UUID) (*models.ExportSchedule, error) {
+	var schedule models.ExportSchedule
+	if err := r.db.Where("uuid = ?", scheduleID).First(&schedule).Error; err != nil {
+		return nil, err
+	}
+	return &schedule, nil
+}
+
+// ListSchedules returns the board's schedules, oldest first
+func (r *ExportScheduleRepo) ListSchedules(boardID uuid.UUID) ([]models.ExportSchedule, error) {
+	var schedules []models.ExportSchedule
+	err := r.db.Where("board_id = ?", boardID).Order("created_at").Find(&schedules).Error
+	return schedules, err
+}
+
+func (r *ExportScheduleRepo) CountSchedules(boardID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.ExportSchedule{}).Where("board_id = ?", boardID).Count(&count).Error
+	return count, err
+}
+
+// DeleteSchedule removes a schedule with its runs, returning gorm.ErrRecordNotFound when the board has no such schedule
+func (r *ExportScheduleRepo) DeleteSchedule(boardID uuid.UUID, scheduleID uuid.UUID) error {
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		result := tx.Where("uuid = ? AND board_id = ?", scheduleID, boardID).Delete(&models.ExportSchedule{})
+		if result.Error != nil {
+			return result.Error
+		}
+		if result.RowsAffected == 0 {
+			return gorm.ErrRecordNotFound
+		}
+		return tx.Where("schedule_id = ?", scheduleID).Delete(&models.ExportRun{}).Error
+	})
+}
+
+// ListDue returns the enabled schedules whose next run has come, most overdue first
+func (r *ExportScheduleRepo) ListDue(now time.Time, limit int) ([]models.ExportSchedule, error) {
+	var schedules []models.ExportSchedule
+	err := r.db.Where("enabled = ? AND next_run_at <= ?", true, now).Order("next_run_at").Limit(limit).Find(&schedules).Error
+	return schedules, err
+}
+
+// Claim moves a due schedule to its next run, reporting false when another instance already did
+func (r *ExportScheduleRepo) Claim(scheduleID uuid.UUID, dueAt time.Time, nextRunAt time.Time) (bool, error) {
+	result := r.db.Model(&models.ExportSchedule{}).
+		Where("uuid = ? AND next_run_at = ?", scheduleID, dueAt).
+		Update("next_run_at", nextRunAt)
+	return result.RowsAffected == 1, result.Error
+}
+
+// CreateRun logs a run of a schedule and stamps the schedule's last run and error
+func (r *ExportScheduleRepo) CreateRun(run *models.ExportRun) error {
+	run.UUID = uuid.New()
+	run.CreatedAt = time.Now()
+	return r.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(run).Error; err != nil {
+			return err
+		}
+		return tx.Model(&models.ExportSchedule{}).Where("uuid = ?", run.ScheduleID).
+			Updates(map[string]interface{}{"last_run_at": run.CreatedAt, "last_error": run.Error}).Error
+	})
+}
+
+// ListRuns returns the latest runs of a schedule, newest first
+func (r *ExportScheduleRepo) ListRuns(scheduleID uuid.UUID, limit int) ([]models.ExportRun, error) {
+	var runs []models.ExportRun
+	err := r.db.Where("schedule_id = ?", scheduleID).Order("created_at DESC").Limit(limit).Find(&runs).Error
+	return runs, err
+}
@@ -0,0 +1,73 @@
+package repo
+
+import (
+	"melina-studio-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type FacilitationRepo struct {
+	db *gorm.DB
+}
+
+type FacilitationRepoInterface interface {
+	CreateSession(session *models.FacilitationSession) (uuid.UUID, error)
+	GetActiveSession(userID uuid.UUID, boardID uuid.UUID) (*models.FacilitationSession, error)
+	UpdateStep(sessionID uuid.UUID, step models.FacilitationStep) error
+	EndSession(sessionID uuid.UUID) error
+	EndActiveSessions(userID uuid.UUID, boardID uuid.UUID) error
+}
+
+func NewFacilitationRepository(db *gorm.DB) FacilitationRepoInterface {
+	return &FacilitationRepo{db: db}
+}
+
+// CreateSession creates a new facilitation session
+func (r *FacilitationRepo) CreateSession(session *models.FacilitationSession) (uuid.UUID, error) {
+	session.UUID = uuid.New()
+	session.IsActive = true
+	session.CreatedAt = time.Now()
+	session.UpdatedAt = time.Now()
+	err := r.db.Create(session).Error
+	return session.UUID, err
+}
+
+// GetActiveSession returns the active facilitation session for a board
+func (r *FacilitationRepo) GetActiveSession(userID uuid.UUID, boardID uuid.UUID) (*models.FacilitationSession, error) {
+	var session models.FacilitationSession
+	err := r.db.Where("board_id = ? AND user_id = ? AND is_active = ?", boardID, userID, true).
+		Order("created_at DESC").
+		First(&session).Error
+	if err != nil {
+		return nil, err
+	}
+	return &session, nil
+}
+
+// UpdateStep moves a session to the given step
+func (r *FacilitationRepo) UpdateStep(sessionID uuid.UUID, step models.FacilitationStep) error {
+	return r.db.Model(&models.FacilitationSession{}).Where("uuid = ?", sessionID).Updates(map[string]any{
+		"step":       step,
+		"updated_at": time.Now(),
+	}).Error
+}
+
+// EndSession marks a session as no longer active
+func (r *FacilitationRepo) EndSession(sessionID uuid.UUID) error {
+	return r.db.Model(&models.FacilitationSession{}).Where("uuid = ?", sessionID).Updates(map[string]any{
+		"is_active":  false,
+		"updated_at": time.Now(),
+	}).Error
+}
+
+// EndActiveSessions ends every active session on a board so only one flow runs at a time
+func (r *FacilitationRepo) EndActiveSessions(userID uuid.UUID, boardID uuid.UUID) error {
+	return r.db.Model(&models.FacilitationSession{}).
+		Where("board_id = ? AND user_id = ? AND is_active = ?", boardID, userID, true).
+		Updates(map[string]any{
+			"is_active":  false,
+			"updated_at": time.Now(),
+		}).Error
+}
@@ -0,0 +1,66 @@
+package repo
+
+import (
+	"melina-studio-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type FeatureFlagRepo struct {
+	db *gorm.DB
+}
+
+type FeatureFlagRepoInterface interface {
+	Create(flag *models.FeatureFlag) error
+	Update(flag *models.FeatureFlag) error
+	GetByKey(key string) (*models.FeatureFlag, error)
+	List() ([]models.FeatureFlag, error)
+	Delete(key string) error
+}
+
+func NewFeatureFlagRepository(db *gorm.DB) FeatureFlagRepoInterface {
+	return &FeatureFlagRepo{db: db}
+}
+
+// Create stores a new feature flag
+func (r *FeatureFlagRepo) Create(flag *models.FeatureFlag) error {
+	flag.UUID = uuid.New()
+	flag.CreatedAt = time.Now()
+	flag.UpdatedAt = time.Now()
+	return r.db.Create(flag).Error
+}
+
+// Update saves every field of an existing feature flag
+func (r *FeatureFlagRepo) Update(flag *models.FeatureFlag) error {
+	flag.UpdatedAt = time.Now()
+	return r.db.Save(flag).Error
+}
+
+func (r *FeatureFlagRepo) GetByKey(key string) (*models.FeatureFlag, error) {
+	var flag models.FeatureFlag
+	if err := r.db.Where("key = ?", key).First(&flag).Error; err != nil {
+		return nil, err
+	}
+	return &flag, nil
+}
+
+// List returns every feature flag ordered by key
+func (r *FeatureFlagRepo) List() ([]models.FeatureFlag, error) {
+	var flags []models.FeatureFlag
+	err := r.db.Order("key").Find(&flags).Error
+	return flags, err
+}
+
+// Delete removes a feature flag, returning gorm.ErrRecordNotFound when there is none
+func (r *FeatureFlagRepo) Delete(key string) error {
+	result := r.db.Where("key = ?", key).Delete(&models.FeatureFlag{})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package repo
+
+import (
+	"melina-studio-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type IntegrationRepo struct {
+	db *gorm.DB
+}
+
+type IntegrationRepoInterface interface {
+	ListNewBoards(userID uuid.UUID, since time.Time, limit int) ([]models.IntegrationBoard, error)
+	ListNewShapes(userID uuid.UUID, boardID *uuid.UUID, since time.Time, limit int) ([]models.IntegrationShape, error)
+	ListCompletedChats(userID uuid.UUID, boardID *uuid.UUID, since time.Time, limit int) ([]models.IntegrationChat, error)
+}
+
+func NewIntegrationRepository(db *gorm.DB) IntegrationRepoInterface {
+	return &IntegrationRepo{db: db}
+}
+
+// the integration triggers list the items created after since, newest first, on the user's listed boards;
+// a nil board filter is passed twice so "? IS NULL" can match every board
+const (
+	integrationBoardsSQL = `
+SELECT b.uuid AS id, b.title, b.created_at
+FROM boards b
+WHERE b.user_id = ? AND b.is_deleted = false AND b.is_sandbox = false AND b.created_at > ?
+ORDER BY b.created_at DESC
+LIMIT ?`
+
+	integrationShapesSQL = `
+SELECT bd.uuid AS id, bd.board_id, b.title AS board_title, bd.type,
+       left(coalesce(bd.data->>'text', bd.data->>'name', ''), 2000) AS text, bd.created_at
+FROM board_data bd
+JOIN boards b ON b.uuid = bd.board_id
+WHERE b.user_id = ? AND b.is_deleted = false AND b.is_sandbox = false
+  AND (CAST(? AS uuid) IS NULL OR bd.board_id = ?) AND bd.created_at > ?
+ORDER BY bd.created_at DESC
+LIMIT ?`
+
+	integrationChatsSQL = `
+SELECT c.uuid AS id, c.board_uuid AS board_id, b.title AS board_title, c.content AS reply, c.created_at,
+       coalesce((SELECT u.content FROM chats u
+                 WHERE u.board_uuid = c.board_uuid AND u.role = 'user' AND u.created_at <= c.created_at
+                 ORDER BY u.created_at DESC LIMIT 1), '') AS prompt
+FROM chats c
+JOIN boards b ON b.uuid = c.board_uuid
+WHERE b.user_id = ? AND b.is_deleted = false AND b.is_sandbox = false AND c.role = 'assistant'
+  AND (CAST(? AS uuid) IS NULL OR c.board_uuid = ?) AND c.created_at > ?
+ORDER BY c.created_at DESC
+LIMIT ?`
+)
+
+// ListNewBoards returns the user's boards created after since, newest first
+func (r *IntegrationRepo) ListNewBoards(userID uuid.UUID, since time.Time, limit int) ([]models.IntegrationBoard, error) {
+	var boards []models.IntegrationBoard
+	err := r.db.Raw(integrationBoardsSQL, userID, since, limit).Scan(&boards).Error
+	return boards, err
+}
+
+// ListNewShapes returns the shapes created after since on the user's boards, or on one of them, newest first
+func (r *IntegrationRepo) ListNewShapes(userID uuid.UUID, boardID *uuid.UUID, since time.Time, limit int) ([]models.IntegrationShape, error) {
+	var shapes []models.IntegrationShape
+	err := r.db.Raw(integrationShapesSQL, userID, boardID, boardID, since, limit).Scan(&shapes).Error
+	return shapes, err
+}
+
+// ListCompletedChats returns the agent replies saved after since on the user's boards, or on one of them, newest first
+func (r *IntegrationRepo) ListCompletedChats(userID uuid.UUID, boardID *uuid.UUID, since time.Time, limit int) ([]models.IntegrationChat, error) {
+	var chats []models.IntegrationChat
+	err := r.db.Raw(integrationChatsSQL, userID, boardID, boardID, since, limit).Scan(&chats).Error
+	return chats, err
+}
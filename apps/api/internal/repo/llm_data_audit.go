@@ -0,0 +1,49 @@
+package repo
+
+import (
+	"melina-studio-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type LLMDataAuditRepo struct {
+	db *gorm.DB
+}
+
+type LLMDataAuditRepoInterface interface {
+	Increment(rows []models.LLMDataAudit) error
+	List(since time.Time, tenantID *uuid.UUID) ([]models.LLMDataAudit, error)
+}
+
+func NewLLMDataAuditRepository(db *gorm.DB) LLMDataAuditRepoInterface {
+	return &LLMDataAuditRepo{db: db}
+}
+
+// Increment adds the rows' request counts to the day's counters, creating the ones that don't exist yet
+func (r *LLMDataAuditRepo) Increment(rows []models.LLMDataAudit) error {
+	if len(rows) == 0 {
+		return nil
+	}
+	return r.db.Clauses(clause.OnConflict{
+		Columns: []clause.Column{{Name: "day"}, {Name: "tenant_id"}, {Name: "provider"}, {Name: "data_class"}, {Name: "privacy_mode"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{
+			"requests":   gorm.Expr("llm_data_audits.requests + excluded.requests"),
+			"controls":   gorm.Expr("excluded.controls"),
+			"updated_at": gorm.Expr("NOW()"),
+		}),
+	}).Create(&rows).Error
+}
+
+// List returns the counters since the day, of one tenant or all when tenantID is nil
+func (r *LLMDataAuditRepo) List(since time.Time, tenantID *uuid.UUID) ([]models.LLMDataAudit, error) {
+	var rows []models.LLMDataAudit
+	query := r.db.Where("day >= ?", since.Format("2006-01-02"))
+	if tenantID != nil {
+		query = query.Where("tenant_id = ?", *tenantID)
+	}
+	err := query.Order("day ASC, provider ASC, data_class ASC").Find(&rows).Error
+	return rows, err
+}
@@ -0,0 +1,73 @@
+package repo
+
+import (
+	"melina-studio-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// FeedbackGroup is a column feedback can be aggregated by
+type FeedbackGroup string
+
+const (
+	FeedbackByModel         FeedbackGroup = "model_name"
+	FeedbackByPromptVersion FeedbackGroup = "prompt_version"
+)
+
+type MessageFeedbackRepo struct {
+	db *gorm.DB
+}
+
+type MessageFeedbackRepoInterface interface {
+	Upsert(feedback *models.MessageFeedback) error
+	List(rating int, since time.Time, limit int) ([]models.MessageFeedback, error)
+	Aggregate(group FeedbackGroup, since time.Time) ([]models.FeedbackAggregate, error)
+}
+
+func NewMessageFeedbackRepository(db *gorm.DB) MessageFeedbackRepoInterface {
+	return &MessageFeedbackRepo{db: db}
+}
+
+// Upsert stores the user's feedback on a message, replacing what they gave it before
+func (r *MessageFeedbackRepo) Upsert(feedback *models.MessageFeedback) error {
+	feedback.UUID = uuid.New()
+	feedback.CreatedAt = time.Now()
+	feedback.UpdatedAt = time.Now()
+	return r.db.Clauses(
+		clause.OnConflict{
+			Columns:   []clause.Column{{Name: "chat_id"}, {Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"rating", "model_name", "prompt_version", "comment", "updated_at"}),
+		},
+		clause.Returning{},
+	).Create(feedback).Error
+}
+
+// List returns the latest feedback since the given time, newest first; a rating of 0 returns both kinds
+func (r *MessageFeedbackRepo) List(rating int, since time.Time, limit int) ([]models.MessageFeedback, error) {
+	query := r.db.Where("created_at >= ?", since)
+	if rating != 0 {
+		query = query.Where("rating = ?", rating)
+	}
+	var feedback []models.MessageFeedback
+	err := query.Order("created_at DESC").Limit(limit).Find(&feedback).Error
+	return feedback, err
+}
+
+// Aggregate counts the feedback since the given time per model or prompt version, most rated first
+func (r *MessageFeedbackRepo) Aggregate(group FeedbackGroup, since time.Time) ([]models.FeedbackAggregate, error) {
+	var rows []models.FeedbackAggregate
+	err := r.db.Model(&models.MessageFeedback{}).
+		Select(`COALESCE(NULLIF(`+string(group)+`, ''), 'unknown') AS key,
+			COUNT(*) FILTER (WHERE rating > 0) AS up,
+			COUNT(*) FILTER (WHERE rating < 0) AS down,
+			COUNT(*) AS total,
+			COUNT(*) FILTER (WHERE comment <> '') AS comments`).
+		Where("created_at >= ?", since).
+		Group("key").
+		Order("total DESC").
+		Scan(&rows).Error
+	return rows, err
+}
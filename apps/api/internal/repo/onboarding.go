@@ -0,0 +1,67 @@
+package repo
+
+import (
+	"melina-studio-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OnboardingRepo represents the repository for the onboarding progress of new users
+type OnboardingRepo struct {
+	db *gorm.DB
+}
+
+type OnboardingRepoInterface interface {
+	Create(progress *models.OnboardingProgress) (bool, error)
+	Get(userID uuid.UUID) (*models.OnboardingProgress, error)
+	SetSampleBoard(userID uuid.UUID, boardID *uuid.UUID, status models.SampleBoardStatus) error
+	CompleteStep(userID uuid.UUID, step models.OnboardingStep, at time.Time) error
+	Dismiss(userID uuid.UUID, at time.Time) error
+}
+
+func NewOnboardingRepository(db *gorm.DB) OnboardingRepoInterface {
+	return &OnboardingRepo{db: db}
+}
+
+// Create records the progress of a new user; it reports false when the user already has one
+func (r *OnboardingRepo) Create(progress *models.OnboardingProgress) (bool, error) {
+	result := r.db.Clauses(clause.OnConflict{DoNothing: true}).Create(progress)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
+// Get returns the user's progress, or gorm.ErrRecordNotFound for users who signed up before onboarding existed
+func (r *OnboardingRepo) Get(userID uuid.UUID) (*models.OnboardingProgress, error) {
+	var progress models.OnboardingProgress
+	if err := r.db.Where("user_id = ?", userID).First(&progress).Error; err != nil {
+		return nil, err
+	}
+	return &progress, nil
+}
+
+func (r *OnboardingRepo) SetSampleBoard(userID uuid.UUID, boardID *uuid.UUID, status models.SampleBoardStatus) error {
+	return r.db.Model(&models.OnboardingProgress{}).
+		Where("user_id = ?", userID).
+		Updates(map[string]interface{}{"sample_board_id": boardID, "status": status, "updated_at": time.Now()}).Error
+}
+
+// CompleteStep marks a step done; completing it again keeps the first completion time
+func (r *OnboardingRepo) CompleteStep(userID uuid.UUID, step models.OnboardingStep, at time.Time) error {
+	return r.db.Model(&models.OnboardingProgress{}).
+		Where("user_id = ?", userID).
+		Updates(map[string]interface{}{
+			"completed_steps": gorm.Expr("jsonb_build_object(?::text, ?::text) || completed_steps", string(step), at.UTC().Format(time.RFC3339)),
+			"updated_at":      time.Now(),
+		}).Error
+}
+
+func (r *OnboardingRepo) Dismiss(userID uuid.UUID, at time.Time) error {
+	return r.db.Model(&models.OnboardingProgress{}).
+		Where("user_id = ? AND dismissed_at IS NULL", userID).
+		Updates(map[string]interface{}{"dismissed_at": at, "updated_at": time.Now()}).Error
+}
@@ -0,0 +1,124 @@
+package repo
+
+import (
+	"errors"
+	"fmt"
+	"melina-studio-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type RetentionRepo struct {
+	db *gorm.DB
+}
+
+type RetentionRepoInterface interface {
+	GetPolicy(userID uuid.UUID) (*models.RetentionPolicy, error)
+	SavePolicy(policy *models.RetentionPolicy) error
+	ListPolicies() ([]models.RetentionPolicy, error)
+	CountChatsBefore(userID uuid.UUID, archived bool, before time.Time) (int64, error)
+	DeleteChatsBefore(userID uuid.UUID, archived bool, before time.Time) (int64, error)
+	ExcessSummaryIDs(userID uuid.UUID, keep int) ([]uuid.UUID, error)
+	ExcessArtifactIDs(userID uuid.UUID, keep int) ([]uuid.UUID, error)
+	DeleteSummaries(ids []uuid.UUID) error
+	DeleteArtifacts(ids []uuid.UUID) error
+}
+
+func NewRetentionRepository(db *gorm.DB) RetentionRepoInterface {
+	return &RetentionRepo{db: db}
+}
+
+// GetPolicy returns the user's retention policy, or gorm.ErrRecordNotFound if none is set
+func (r *RetentionRepo) GetPolicy(userID uuid.UUID) (*models.RetentionPolicy, error) {
+	var policy models.RetentionPolicy
+	if err := r.db.Where("user_id = ?", userID).First(&policy).Error; err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// SavePolicy creates or replaces the user's retention policy
+func (r *RetentionRepo) SavePolicy(policy *models.RetentionPolicy) error {
+	existing, err := r.GetPolicy(policy.UserID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+	now := time.Now()
+	policy.UpdatedAt = now
+	if existing == nil {
+		policy.UUID = uuid.New()
+		policy.CreatedAt = now
+		return r.db.Create(policy).Error
+	}
+	policy.UUID = existing.UUID
+	policy.CreatedAt = existing.CreatedAt
+	return r.db.Save(policy).Error
+}
+
+// ListPolicies returns every policy that limits something
+func (r *RetentionRepo) ListPolicies() ([]models.RetentionPolicy, error) {
+	var policies []models.RetentionPolicy
+	err := r.db.Where("chat_retention_days IS NOT NULL OR archived_chat_retention_days IS NOT NULL OR snapshots_to_keep IS NOT NULL").Find(&policies).Error
+	return policies, err
+}
+
+// userBoards selects the uuids of the user's archived or other boards
+func (r *RetentionRepo) userBoards(userID uuid.UUID, archived bool) *gorm.DB {
+	return r.db.Model(&models.Board{}).Select("uuid").Where("user_id = ? AND is_archived = ?", userID, archived)
+}
+
+// CountChatsBefore counts the chat messages on the user's archived or other boards created before the cutoff
+func (r *RetentionRepo) CountChatsBefore(userID uuid.UUID, archived bool, before time.Time) (int64, error) {
+	var count int64
+	err := r.db.Model(&models.Chat{}).
+		Where("board_uuid IN (?)", r.userBoards(userID, archived)).
+		Where("created_at < ?", before).
+		Count(&count).Error
+	return count, err
+}
+
+// DeleteChatsBefore deletes the chat messages on the user's archived or other boards created before the cutoff
+func (r *RetentionRepo) DeleteChatsBefore(userID uuid.UUID, archived bool, before time.Time) (int64, error) {
+	result := r.db.Where("board_uuid IN (?)", r.userBoards(userID, archived)).
+		Where("created_at < ?", before).
+		Delete(&models.Chat{})
+	return result.RowsAffected, result.Error
+}
+
+// excessIDs returns the rows of a per-board table beyond the newest keep rows of each board
+func (r *RetentionRepo) excessIDs(table string, userID uuid.UUID, keep int) ([]uuid.UUID, error) {
+	var ids []uuid.UUID
+	err := r.db.Raw(fmt.Sprintf(`
+SELECT uuid FROM (
+  SELECT uuid, row_number() OVER (PARTITION BY board_id ORDER BY created_at DESC) AS position
+  FROM %s WHERE user_id = ?
+) ranked
+WHERE position > ?`, table), userID, keep).Scan(&ids).Error
+	return ids, err
+}
+
+// ExcessSummaryIDs returns the user's board summaries beyond the newest keep of each board
+func (r *RetentionRepo) ExcessSummaryIDs(userID uuid.UUID, keep int) ([]uuid.UUID, error) {
+	return r.excessIDs("board_summaries", userID, keep)
+}
+
+// ExcessArtifactIDs returns the user's code artifacts beyond the newest keep of each board
+func (r *RetentionRepo) ExcessArtifactIDs(userID uuid.UUID, keep int) ([]uuid.UUID, error) {
+	return r.excessIDs("code_artifacts", userID, keep)
+}
+
+func (r *RetentionRepo) DeleteSummaries(ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Where("uuid IN ?", ids).Delete(&models.BoardSummary{}).Error
+}
+
+func (r *RetentionRepo) DeleteArtifacts(ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return nil
+	}
+	return r.db.Where("uuid IN ?", ids).Delete(&models.CodeArtifact{}).Error
+}
@@ -0,0 +1,141 @@
+package repo
+
+import (
+	"melina-studio-backend/internal/models"
+	"sort"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type SearchRepo struct {
+	db *gorm.DB
+}
+
+type SearchRepoInterface interface {
+	Search(userID uuid.UUID, query string, types []models.SearchHitType, limit int) ([]models.SearchHit, error)
+	Recent(userID uuid.UUID, types []models.SearchHitType, limit int) ([]models.SearchHit, error)
+}
+
+func NewSearchRepository(db *gorm.DB) SearchRepoInterface {
+	return &SearchRepo{db: db}
+}
+
+// the to_tsvector expressions match the GIN indexes created in config.createSearchIndexes
+const (
+	searchBoardsSQL = `
+SELECT 'board' AS type, b.uuid AS board_id, b.title AS board_title, b.title AS text,
+       ts_rank(to_tsvector('english', b.title), q) AS rank, b.updated_at
+FROM boards b, websearch_to_tsquery('english', ?) q
+WHERE b.user_id = ? AND b.is_deleted = false AND b.is_sandbox = false
+  AND to_tsvector('english', b.title) @@ q
+ORDER BY rank DESC
+LIMIT ?`
+
+	searchShapesSQL = `
+SELECT 'shape' AS type, bd.board_id, b.title AS board_title, bd.uuid AS shape_id, bd.type AS shape_type,
+       left(coalesce(bd.data->>'text', '') || ' ' || coalesce(bd.data->>'name', ''), 2000) AS text,
+       ts_rank(to_tsvector('english', coalesce(bd.data->>'text', '') || ' ' || coalesce(bd.data->>'name', '')), q) AS rank,
+       bd.updated_at
+FROM board_data bd
+JOIN boards b ON b.uuid = bd.board_id, websearch_to_tsquery('english', ?) q
+WHERE b.user_id = ? AND b.is_deleted = false AND b.is_sandbox = false
+  AND to_tsvector('english', coalesce(bd.data->>'text', '') || ' ' || coalesce(bd.data->>'name', '')) @@ q
+ORDER BY rank DESC
+LIMIT ?`
+
+	searchChatsSQL = `
+SELECT 'chat' AS type, c.board_uuid AS board_id, b.title AS board_title, c.uuid AS chat_id, c.role,
+       left(c.content, 2000) AS text,
+       ts_rank(to_tsvector('english', c.content), q) AS rank, c.updated_at
+FROM chats c
+JOIN boards b ON b.uuid = c.board_uuid, websearch_to_tsquery('english', ?) q
+WHERE b.user_id = ? AND b.is_deleted = false AND b.is_sandbox = false
+  AND to_tsvector('english', c.content) @@ q
+ORDER BY rank DESC
+LIMIT ?`
+)
+
+// recent* return the latest items with text, the candidate pool for semantic search
+const (
+	recentBoardsSQL = `
+SELECT 'board' AS type, b.uuid AS board_id, b.title AS board_title, b.title AS text, b.updated_at
+FROM boards b
+WHERE b.user_id = ? AND b.is_deleted = false AND b.is_sandbox = false AND b.title <> ''
+ORDER BY b.updated_at DESC
+LIMIT ?`
+
+	recentShapesSQL = `
+SELECT 'shape' AS type, bd.board_id, b.title AS board_title, bd.uuid AS shape_id, bd.type AS shape_type,
+       left(trim(coalesce(bd.data->>'text', '') || ' ' || coalesce(bd.data->>'name', '')), 2000) AS text,
+       bd.updated_at
+FROM board_data bd
+JOIN boards b ON b.uuid = bd.board_id
+WHERE b.user_id = ? AND b.is_deleted = false AND b.is_sandbox = false
+  AND trim(coalesce(bd.data->>'text', '') || coalesce(bd.data->>'name', '')) <> ''
+ORDER BY bd.updated_at DESC
+LIMIT ?`
+
+	recentChatsSQL = `
+SELECT 'chat' AS type, c.board_uuid AS board_id, b.title AS board_title, c.uuid AS chat_id, c.role,
+       left(c.content, 2000) AS text, c.updated_at
+FROM chats c
+JOIN boards b ON b.uuid = c.board_uuid
+WHERE b.user_id = ? AND b.is_deleted = false AND b.is_sandbox = false AND c.content <> ''
+ORDER BY c.updated_at DESC
+LIMIT ?`
+)
+
+// Search runs a full-text search over the user's board titles, shape text and chat messages
+// Each type is searched separately and the hits are merged by rank
+func (r *SearchRepo) Search(userID uuid.UUID, query string, types []models.SearchHitType, limit int) ([]models.SearchHit, error) {
+	statements := map[models.SearchHitType]string{
+		models.SearchHitBoard: searchBoardsSQL,
+		models.SearchHitShape: searchShapesSQL,
+		models.SearchHitChat:  searchChatsSQL,
+	}
+
+	hits := []models.SearchHit{}
+	for _, t := range types {
+		stmt, ok := statements[t]
+		if !ok {
+			continue
+		}
+		var typeHits []models.SearchHit
+		if err := r.db.Raw(stmt, query, userID, limit).Scan(&typeHits).Error; err != nil {
+			return nil, err
+		}
+		hits = append(hits, typeHits...)
+	}
+
+	sort.SliceStable(hits, func(i, j int) bool {
+		return hits[i].Rank > hits[j].Rank
+	})
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	return hits, nil
+}
+
+// Recent returns up to limit of the user's most recently updated items of each type
+func (r *SearchRepo) Recent(userID uuid.UUID, types []models.SearchHitType, limit int) ([]models.SearchHit, error) {
+	statements := map[models.SearchHitType]string{
+		models.SearchHitBoard: recentBoardsSQL,
+		models.SearchHitShape: recentShapesSQL,
+		models.SearchHitChat:  recentChatsSQL,
+	}
+
+	hits := []models.SearchHit{}
+	for _, t := range types {
+		stmt, ok := statements[t]
+		if !ok {
+			continue
+		}
+		var typeHits []models.SearchHit
+		if err := r.db.Raw(stmt, userID, limit).Scan(&typeHits).Error; err != nil {
+			return nil, err
+		}
+		hits = append(hits, typeHits...)
+	}
+	return hits, nil
+}
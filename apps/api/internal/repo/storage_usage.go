@@ -0,0 +1,68 @@
+package repo
+
+import (
+	"melina-studio-backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StorageUsageRepo represents the repository for the storage usage model
+type StorageUsageRepo struct {
+	db *gorm.DB
+}
+
+type StorageUsageRepoInterface interface {
+	Upsert(usage *models.StorageUsage) error
+	Delete(userID uuid.UUID, objectKey string) error
+	GetObjectBytes(userID uuid.UUID, objectKey string) (int64, error)
+	GetTotalsByKind(userID uuid.UUID) (map[models.StorageKind]int64, error)
+}
+
+func NewStorageUsageRepository(db *gorm.DB) StorageUsageRepoInterface {
+	return &StorageUsageRepo{db: db}
+}
+
+// Upsert records an object, replacing the size of an earlier object at the same key
+func (r *StorageUsageRepo) Upsert(usage *models.StorageUsage) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "object_key"}},
+		DoUpdates: clause.AssignmentColumns([]string{"kind", "bytes", "updated_at"}),
+	}).Create(usage).Error
+}
+
+func (r *StorageUsageRepo) Delete(userID uuid.UUID, objectKey string) error {
+	return r.db.Where("user_id = ? AND object_key = ?", userID, objectKey).Delete(&models.StorageUsage{}).Error
+}
+
+// GetObjectBytes returns the recorded size of one object, 0 if it isn't recorded
+func (r *StorageUsageRepo) GetObjectBytes(userID uuid.UUID, objectKey string) (int64, error) {
+	var bytes int64
+	err := r.db.Model(&models.StorageUsage{}).
+		Select("COALESCE(SUM(bytes), 0)").
+		Where("user_id = ? AND object_key = ?", userID, objectKey).
+		Scan(&bytes).Error
+	return bytes, err
+}
+
+// GetTotalsByKind returns the bytes a user stores per kind of object
+func (r *StorageUsageRepo) GetTotalsByKind(userID uuid.UUID) (map[models.StorageKind]int64, error) {
+	var rows []struct {
+		Kind  models.StorageKind
+		Total int64
+	}
+	err := r.db.Model(&models.StorageUsage{}).
+		Select("kind, SUM(bytes) AS total").
+		Where("user_id = ?", userID).
+		Group("kind").
+		Scan(&rows).Error
+	if err != nil {
+		return nil, err
+	}
+	totals := make(map[models.StorageKind]int64, len(rows))
+	for _, row := range rows {
+		totals[row.Kind] = row.Total
+	}
+	return totals, nil
+}
@@ -0,0 +1,63 @@
+package repo
+
+import (
+	"melina-studio-backend/internal/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// StoredObjectRepo represents the repository for the content-addressed stored objects
+type StoredObjectRepo struct {
+	db *gorm.DB
+}
+
+type StoredObjectRepoInterface interface {
+	Get(bucket string, contentHash string) (*models.StoredObject, error)
+	AddRef(object *models.StoredObject) error
+	Release(bucket string, contentHash string) (int, error)
+	Delete(bucket string, contentHash string) error
+}
+
+func NewStoredObjectRepository(db *gorm.DB) StoredObjectRepoInterface {
+	return &StoredObjectRepo{db: db}
+}
+
+// Get returns the object stored for the content, or gorm.ErrRecordNotFound
+func (r *StoredObjectRepo) Get(bucket string, contentHash string) (*models.StoredObject, error) {
+	var object models.StoredObject
+	if err := r.db.Where("bucket = ? AND content_hash = ?", bucket, contentHash).First(&object).Error; err != nil {
+		return nil, err
+	}
+	return &object, nil
+}
+
+// AddRef records the object with one reference, or adds a reference when it is already recorded
+func (r *StoredObjectRepo) AddRef(object *models.StoredObject) error {
+	object.RefCount = 1
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "content_hash"}, {Name: "bucket"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"ref_count": gorm.Expr("stored_objects.ref_count + 1"), "updated_at": gorm.Expr("NOW()")}),
+	}).Create(object).Error
+}
+
+// Release drops one reference and returns how many are left, or gorm.ErrRecordNotFound
+func (r *StoredObjectRepo) Release(bucket string, contentHash string) (int, error) {
+	var object models.StoredObject
+	result := r.db.Model(&object).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "ref_count"}}}).
+		Where("bucket = ? AND content_hash = ?", bucket, contentHash).
+		Updates(map[string]interface{}{"ref_count": gorm.Expr("GREATEST(ref_count - 1, 0)"), "updated_at": gorm.Expr("NOW()")})
+	if result.Error != nil {
+		return 0, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return 0, gorm.ErrRecordNotFound
+	}
+	return object.RefCount, nil
+}
+
+// Delete removes the record once nothing references the object anymore
+func (r *StoredObjectRepo) Delete(bucket string, contentHash string) error {
+	return r.db.Where("bucket = ? AND content_hash = ? AND ref_count = 0", bucket, contentHash).Delete(&models.StoredObject{}).Error
+}
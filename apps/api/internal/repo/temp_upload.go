@@ -1,6 +1,7 @@
 package repo
 
 import (
+	"errors"
 	"melina-studio-backend/internal/models"
 	"time"
 
@@ -15,7 +16,12 @@ type TempUploadRepo struct {
 
 type TempUploadRepoInterface interface {
 	Create(upload *models.TempUpload) error
+	GetByID(id uuid.UUID) (*models.TempUpload, error)
+	Commit(id uuid.UUID, messageID *uuid.UUID, shapeID *uuid.UUID) (bool, error)
+	AttachToMessage(boardID uuid.UUID, userID uuid.UUID, urls []string, messageID uuid.UUID) (int64, error)
+	GetByMessage(messageID uuid.UUID) ([]models.TempUpload, error)
 	GetExpired(maxAge time.Duration) ([]models.TempUpload, error)
+	GetOrphanedAttachments() ([]models.TempUpload, error)
 	DeleteByIDs(ids []uuid.UUID) error
 }
 
@@ -31,11 +37,66 @@ func (r *TempUploadRepo) Create(upload *models.TempUpload) error {
 	return r.db.Create(upload).Error
 }
 
-// GetExpired returns records older than maxAge
+// GetByID returns the upload, or nil if there is none
+func (r *TempUploadRepo) GetByID(id uuid.UUID) (*models.TempUpload, error) {
+	var upload models.TempUpload
+	err := r.db.Where("uuid = ?", id).First(&upload).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &upload, nil
+}
+
+// Commit binds an uncommitted upload to a message or shape; false means it was already committed
+func (r *TempUploadRepo) Commit(id uuid.UUID, messageID *uuid.UUID, shapeID *uuid.UUID) (bool, error) {
+	result := r.db.Model(&models.TempUpload{}).
+		Where("uuid = ? AND committed_at IS NULL", id).
+		Updates(map[string]interface{}{
+			"committed_at": time.Now(),
+			"message_id":   messageID,
+			"shape_id":     shapeID,
+		})
+	return result.RowsAffected > 0, result.Error
+}
+
+// AttachToMessage commits the user's uncommitted uploads on the board with one of the urls to the message
+func (r *TempUploadRepo) AttachToMessage(boardID uuid.UUID, userID uuid.UUID, urls []string, messageID uuid.UUID) (int64, error) {
+	if len(urls) == 0 {
+		return 0, nil
+	}
+	result := r.db.Model(&models.TempUpload{}).
+		Where("board_id = ? AND user_id = ? AND url IN ? AND committed_at IS NULL", boardID, userID, urls).
+		Updates(map[string]interface{}{
+			"committed_at": time.Now(),
+			"message_id":   messageID,
+		})
+	return result.RowsAffected, result.Error
+}
+
+// GetByMessage returns the uploads attached to a message, oldest first
+func (r *TempUploadRepo) GetByMessage(messageID uuid.UUID) ([]models.TempUpload, error) {
+	var uploads []models.TempUpload
+	err := r.db.Where("message_id = ?", messageID).Order("created_at ASC").Find(&uploads).Error
+	return uploads, err
+}
+
+// GetOrphanedAttachments returns the uploads attached to messages that no longer exist
+func (r *TempUploadRepo) GetOrphanedAttachments() ([]models.TempUpload, error) {
+	var uploads []models.TempUpload
+	err := r.db.
+		Where("message_id IS NOT NULL AND NOT EXISTS (SELECT 1 FROM chats WHERE chats.uuid = temp_uploads.message_id)").
+		Find(&uploads).Error
+	return uploads, err
+}
+
+// GetExpired returns uncommitted records older than maxAge
 func (r *TempUploadRepo) GetExpired(maxAge time.Duration) ([]models.TempUpload, error) {
 	var uploads []models.TempUpload
 	cutoff := time.Now().Add(-maxAge)
-	err := r.db.Where("created_at < ?", cutoff).Find(&uploads).Error
+	err := r.db.Where("created_at < ? AND committed_at IS NULL", cutoff).Find(&uploads).Error
 	return uploads, err
 }
 
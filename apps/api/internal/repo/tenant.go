@@ -0,0 +1,62 @@
+package repo
+
+import (
+	"melina-studio-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type TenantRepo struct {
+	db *gorm.DB
+}
+
+type TenantRepoInterface interface {
+	Create(tenant *models.Tenant) error
+	Update(tenant *models.Tenant) error
+	GetByID(id uuid.UUID) (*models.Tenant, error)
+	GetBySlug(slug string) (*models.Tenant, error)
+	List() ([]models.Tenant, error)
+}
+
+func NewTenantRepository(db *gorm.DB) TenantRepoInterface {
+	return &TenantRepo{db: db}
+}
+
+// Create stores a new tenant
+func (r *TenantRepo) Create(tenant *models.Tenant) error {
+	tenant.UUID = uuid.New()
+	tenant.CreatedAt = time.Now()
+	tenant.UpdatedAt = time.Now()
+	return r.db.Create(tenant).Error
+}
+
+// Update saves every field of an existing tenant
+func (r *TenantRepo) Update(tenant *models.Tenant) error {
+	tenant.UpdatedAt = time.Now()
+	return r.db.Save(tenant).Error
+}
+
+func (r *TenantRepo) GetByID(id uuid.UUID) (*models.Tenant, error) {
+	var tenant models.Tenant
+	if err := r.db.Where("uuid = ?", id).First(&tenant).Error; err != nil {
+		return nil, err
+	}
+	return &tenant, nil
+}
+
+func (r *TenantRepo) GetBySlug(slug string) (*models.Tenant, error) {
+	var tenant models.Tenant
+	if err := r.db.Where("slug = ?", slug).First(&tenant).Error; err != nil {
+		return nil, err
+	}
+	return &tenant, nil
+}
+
+// List returns every tenant ordered by slug
+func (r *TenantRepo) List() ([]models.Tenant, error) {
+	var tenants []models.Tenant
+	err := r.db.Order("slug").Find(&tenants).Error
+	return tenants, err
+}
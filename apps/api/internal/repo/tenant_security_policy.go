@@ -0,0 +1,44 @@
+package repo
+
+import (
+	"melina-studio-backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TenantSecurityPolicyRepo struct {
+	db *gorm.DB
+}
+
+type TenantSecurityPolicyRepoInterface interface {
+	Get(tenantID uuid.UUID) (*models.TenantSecurityPolicy, error)
+	Save(policy *models.TenantSecurityPolicy) error
+	Delete(tenantID uuid.UUID) error
+}
+
+func NewTenantSecurityPolicyRepository(db *gorm.DB) TenantSecurityPolicyRepoInterface {
+	return &TenantSecurityPolicyRepo{db: db}
+}
+
+// Get returns the policy of a tenant, or gorm.ErrRecordNotFound if none is set
+func (r *TenantSecurityPolicyRepo) Get(tenantID uuid.UUID) (*models.TenantSecurityPolicy, error) {
+	var policy models.TenantSecurityPolicy
+	if err := r.db.Where("tenant_id = ?", tenantID).First(&policy).Error; err != nil {
+		return nil, err
+	}
+	return &policy, nil
+}
+
+// Save creates or replaces the policy of a tenant
+func (r *TenantSecurityPolicyRepo) Save(policy *models.TenantSecurityPolicy) error {
+	return r.db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "tenant_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"allowed_ip_ranges", "max_session_minutes", "reauth_interval_minutes", "updated_at"}),
+	}).Create(policy).Error
+}
+
+func (r *TenantSecurityPolicyRepo) Delete(tenantID uuid.UUID) error {
+	return r.db.Where("tenant_id = ?", tenantID).Delete(&models.TenantSecurityPolicy{}).Error
+}
@@ -33,6 +33,15 @@ type DailyTokenUsage struct {
 	RequestCount int64  `json:"request_count"`
 }
 
+// BoardCost is the spend on a board since a point in time
+type BoardCost struct {
+	BoardID      uuid.UUID `json:"board_id"`
+	Since        time.Time `json:"since"`
+	CostUSD      float64   `json:"cost_usd"`
+	TotalTokens  int64     `json:"total_tokens"`
+	RequestCount int64     `json:"request_count"`
+}
+
 // TokenUsageByModel represents token usage grouped by model
 type TokenUsageByModel struct {
 	Model        string `json:"model"`
@@ -51,6 +60,7 @@ type TokenConsumptionRepoInterface interface {
 	GetDailyUsage(userID uuid.UUID, days int) ([]DailyTokenUsage, error)
 	GetUsageByModel(userID uuid.UUID, days int) ([]TokenUsageByModel, error)
 	GetAnalyticsSummary(userID uuid.UUID, days int) (totalTokens int64, totalRequests int64, err error)
+	GetBoardCost(userID uuid.UUID, boardID uuid.UUID, since time.Time) (*BoardCost, error)
 }
 
 func NewTokenConsumptionRepository(db *gorm.DB) TokenConsumptionRepoInterface {
@@ -76,6 +86,9 @@ func (r *TokenConsumptionRepo) Create(tc *models.TokenConsumption) error {
 
 // CreateFromUsage creates a new token consumption record from usage data
 func (r *TokenConsumptionRepo) CreateFromUsage(userID uuid.UUID, boardID *uuid.UUID, chatID *uuid.UUID, provider string, model string, tokenUsage *llmHandlers.TokenUsage) error {
+	// price the model that actually ran, before it is mapped to the provider's reporting name
+	cost := llmHandlers.UsageCost(model, tokenUsage)
+
 	switch provider {
 	case "openai":
 		model = string(LLMModelOpenAI)
@@ -102,6 +115,7 @@ func (r *TokenConsumptionRepo) CreateFromUsage(userID uuid.UUID, boardID *uuid.U
 		InputTokens:    tokenUsage.InputTokens,
 		OutputTokens:   tokenUsage.OutputTokens,
 		CountingMethod: tokenUsage.CountingMethod,
+		CostUSD:        cost,
 		CreatedAt:      time.Now(),
 		UpdatedAt:      time.Now(),
 	}
@@ -195,3 +209,18 @@ func (r *TokenConsumptionRepo) GetAnalyticsSummary(userID uuid.UUID, days int) (
 
 	return result.TotalTokens, result.TotalRequests, err
 }
+
+// GetBoardCost returns the user's spend on a board since the given time
+func (r *TokenConsumptionRepo) GetBoardCost(userID uuid.UUID, boardID uuid.UUID, since time.Time) (*BoardCost, error) {
+	var result BoardCost
+	err := r.db.Model(&models.TokenConsumption{}).
+		Select("COALESCE(SUM(cost_usd), 0) as cost_usd, COALESCE(SUM(total_tokens), 0) as total_tokens, COUNT(*) as request_count").
+		Where("user_uuid = ? AND board_uuid = ? AND created_at >= ?", userID, boardID, since).
+		Scan(&result).Error
+	if err != nil {
+		return nil, err
+	}
+	result.BoardID = boardID
+	result.Since = since
+	return &result, nil
+}
@@ -0,0 +1,36 @@
+package repo
+
+import (
+	"melina-studio-backend/internal/models"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type TurnMetadataRepo struct {
+	db *gorm.DB
+}
+
+type TurnMetadataRepoInterface interface {
+	Create(metadata *models.TurnMetadata) error
+	GetByChatID(chatID uuid.UUID) (*models.TurnMetadata, error)
+}
+
+func NewTurnMetadataRepository(db *gorm.DB) TurnMetadataRepoInterface {
+	return &TurnMetadataRepo{db: db}
+}
+
+func (r *TurnMetadataRepo) Create(metadata *models.TurnMetadata) error {
+	metadata.CreatedAt = time.Now()
+	return r.db.Create(metadata).Error
+}
+
+// GetByChatID returns the metadata of the turn that produced an AI message
+func (r *TurnMetadataRepo) GetByChatID(chatID uuid.UUID) (*models.TurnMetadata, error) {
+	var metadata models.TurnMetadata
+	if err := r.db.Where("chat_id = ?", chatID).First(&metadata).Error; err != nil {
+		return nil, err
+	}
+	return &metadata, nil
+}
@@ -0,0 +1,361 @@
+package service
+
+import (
+	"slices"
+	"sort"
+	"strings"
+	"unicode"
+
+	"melina-studio-backend/internal/melina/tools"
+	"melina-studio-backend/internal/models"
+)
+
+// ActionKind is the kind of thing a catalog action runs
+type ActionKind string
+
+const (
+	ActionKindTool     ActionKind = "tool"
+	ActionKindEndpoint ActionKind = "endpoint"
+	ActionKindTemplate ActionKind = "template"
+)
+
+// Permissions an action can require
+const (
+	ActionPermissionPublic        = "public"
+	ActionPermissionAuthenticated = "authenticated"
+	ActionPermissionAdmin         = "admin"
+	ActionPermissionAPIKey        = "api_key"
+	ActionPermissionBoardOwner    = "board_owner"
+)
+
+// apiPathPrefix keeps the catalog to the API, leaving out the health checks and static routes
+const apiPathPrefix = "/api/"
+
+// CatalogAction is one thing the backend can do: an agent tool, an HTTP endpoint or a template that starts a
+// preset flow through an endpoint
+type CatalogAction struct {
+	ID          string     `json:"id"`
+	Kind        ActionKind `json:"kind"`
+	Name        string     `json:"name"`
+	Description string     `json:"description,omitempty"`
+	Category    string     `json:"category"`
+	Method      string     `json:"method,omitempty"`
+	Path        string     `json:"path,omitempty"`
+	// Parameters is a JSON schema of the tool input or the endpoint's path and body parameters
+	Parameters  map[string]interface{} `json:"parameters,omitempty"`
+	Permissions []string               `json:"permissions"`
+	// Source is where a tool comes from: builtin, plugin or custom
+	Source           string `json:"source,omitempty"`
+	RequiresApproval bool   `json:"requires_approval,omitempty"`
+}
+
+// RouteInfo is a registered route as the router reports it, with the function names of its handlers.
+// Middleware mounted on a prefix shows up as a route whose handlers are all middleware
+type RouteInfo struct {
+	Method   string
+	Path     string
+	Handlers []string
+}
+
+// ActionFilter narrows the catalog; empty fields match everything
+type ActionFilter struct {
+	// Query matches the id, name, description, category and path, case-insensitively
+	Query    string
+	Kind     ActionKind
+	Category string
+}
+
+// middlewarePermissions maps the auth middlewares to the permission they enforce. The tenant and feature
+// flag middlewares don't gate access, so they are left out
+var middlewarePermissions = map[string]string{
+	"internal/auth.AuthMiddleware":   ActionPermissionAuthenticated,
+	"internal/auth.AdminMiddleware":  ActionPermissionAdmin,
+	"internal/auth.APIKeyMiddleware": ActionPermissionAPIKey,
+}
+
+// ActionCatalogService lists what the backend can do, for the command palette and for clients discovering the
+// tools loaded since they last looked. The catalog is rebuilt on every call, so plugin and custom tools show
+// up as soon as they are loaded
+type ActionCatalogService struct{}
+
+func NewActionCatalogService() *ActionCatalogService {
+	return &ActionCatalogService{}
+}
+
+// List returns the tools, endpoints and templates matching the filter, sorted by kind and id
+func (s *ActionCatalogService) List(routes []RouteInfo, filter ActionFilter) []CatalogAction {
+	var actions []CatalogAction
+	actions = append(actions, toolActions(tools.ToolCatalog())...)
+	actions = append(actions, EndpointActions(routes)...)
+	actions = append(actions, templateActions()...)
+	return FilterActions(actions, filter)
+}
+
+// FilterActions keeps the actions matching the filter and sorts them by kind and id
+func FilterActions(actions []CatalogAction, filter ActionFilter) []CatalogAction {
+	query := strings.ToLower(strings.TrimSpace(filter.Query))
+	matched := make([]CatalogAction, 0, len(actions))
+	for _, action := range actions {
+		if filter.Kind != "" && action.Kind != filter.Kind {
+			continue
+		}
+		if filter.Category != "" && !strings.EqualFold(action.Category, filter.Category) {
+			continue
+		}
+		if query != "" {
+			text := strings.ToLower(strings.Join([]string{action.ID, action.Name, action.Description, action.Category, action.Path}, " "))
+			if !strings.Contains(text, query) {
+				continue
+			}
+		}
+		matched = append(matched, action)
+	}
+	sort.Slice(matched, func(i, j int) bool {
+		if matched[i].Kind != matched[j].Kind {
+			return matched[i].Kind < matched[j].Kind
+		}
+		return matched[i].ID < matched[j].ID
+	})
+	return matched
+}
+
+// toolActions lists the agent tools; they run on a board the user owns, from its chat
+func toolActions(catalog []tools.ToolInfo) []CatalogAction {
+	actions := make([]CatalogAction, 0, len(catalog))
+	for _, tool := range catalog {
+		actions = append(actions, CatalogAction{
+			ID:               "tool:" + tool.Name,
+			Kind:             ActionKindTool,
+			Name:             tool.Name,
+			Description:      tool.Description,
+			Category:         "tools",
+			Parameters:       tool.Parameters,
+			Permissions:      []string{ActionPermissionAuthenticated, ActionPermissionBoardOwner},
+			Source:           string(tool.Source),
+			RequiresApproval: tool.RequiresApproval,
+		})
+	}
+	return actions
+}
+
+// EndpointActions lists the API endpoints among the routes, in router order. The permissions come from the
+// auth middleware mounted on a prefix of the path before the endpoint was registered, which is the middleware
+// a request to it goes through
+func EndpointActions(routes []RouteInfo) []CatalogAction {
+	var actions []CatalogAction
+	seen := make(map[string]bool)
+	var mounted []RouteInfo
+	method := ""
+	for _, route := range routes {
+		// Each method has its own stack, starting over with the middleware mounted on every method
+		if route.Method != method {
+			method = route.Method
+			mounted = nil
+		}
+		if isMiddlewareRoute(route) {
+			mounted = append(mounted, route)
+			continue
+		}
+		if route.Method == "HEAD" || !strings.HasPrefix(route.Path, apiPathPrefix) || len(route.Handlers) == 0 {
+			continue
+		}
+		id := "endpoint:" + route.Method + " " + route.Path
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+
+		name := humanizeHandlerName(route.Handlers[len(route.Handlers)-1])
+		actions = append(actions, CatalogAction{
+			ID:          id,
+			Kind:        ActionKindEndpoint,
+			Name:        name,
+			Description: name + " (" + route.Method + " " + route.Path + ")",
+			Category:    endpointCategory(route.Path),
+			Method:      route.Method,
+			Path:        route.Path,
+			Parameters:  pathParameters(route.Path),
+			Permissions: endpointPermissions(route.Path, mounted),
+		})
+	}
+	return actions
+}
+
+// isMiddlewareRoute reports whether every handler of the route is a middleware rather than an endpoint
+func isMiddlewareRoute(route RouteInfo) bool {
+	if len(route.Handlers) == 0 {
+		return false
+	}
+	for _, handler := range route.Handlers {
+		if !strings.Contains(handler, "Middleware") {
+			return false
+		}
+	}
+	return true
+}
+
+// endpointPermissions returns the permissions the mounted middleware enforces on the path, plus board
+// ownership for board routes
+func endpointPermissions(path string, mounted []RouteInfo) []string {
+	var permissions []string
+	for _, route := range mounted {
+		prefix := strings.TrimSuffix(route.Path, "/")
+		if path != prefix && !strings.HasPrefix(path, prefix+"/") {
+			continue
+		}
+		for _, handler := range route.Handlers {
+			for name, permission := range middlewarePermissions {
+				if strings.Contains(handler, name+".") && !slices.Contains(permissions, permission) {
+					permissions = append(permissions, permission)
+				}
+			}
+		}
+	}
+	if len(permissions) == 0 {
+		permissions = append(permissions, ActionPermissionPublic)
+	}
+	if strings.Contains(path, "/:boardId") && !slices.Contains(permissions, ActionPermissionAdmin) {
+		permissions = append(permissions, ActionPermissionBoardOwner)
+	}
+	return permissions
+}
+
+// endpointCategory groups an endpoint by the first fixed segment after the API version, looking past the
+// board a board route is scoped to
+func endpointCategory(path string) string {
+	segments := strings.Split(strings.Trim(strings.TrimPrefix(path, apiPathPrefix), "/"), "/")
+	if len(segments) > 0 && strings.HasPrefix(segments[0], "v") {
+		segments = segments[1:]
+	}
+	if len(segments) > 2 && segments[0] == "boards" && strings.HasPrefix(segments[1], ":") {
+		segments = segments[2:]
+	}
+	for _, segment := range segments {
+		if segment != "" && !strings.HasPrefix(segment, ":") {
+			return segment
+		}
+	}
+	return "api"
+}
+
+// pathParameters describes the path parameters of the endpoint as a JSON schema, nil when it has none
+func pathParameters(path string) map[string]interface{} {
+	properties := map[string]interface{}{}
+	var required []string
+	for _, segment := range strings.Split(path, "/") {
+		if !strings.HasPrefix(segment, ":") {
+			continue
+		}
+		name := strings.TrimSuffix(strings.TrimPrefix(segment, ":"), "?")
+		properties[name] = map[string]interface{}{
+			"type":        "string",
+			"description": "Path parameter",
+		}
+		if !strings.HasSuffix(segment, "?") {
+			required = append(required, name)
+		}
+	}
+	if len(properties) == 0 {
+		return nil
+	}
+	schema := map[string]interface{}{"type": "object", "properties": properties}
+	if len(required) > 0 {
+		schema["required"] = required
+	}
+	return schema
+}
+
+// humanizeHandlerName turns a handler's function name into a label, for instance
+// "melina-studio-backend/internal/handlers.(*AutomationHandler).ListAutomations-fm" into "List automations"
+func humanizeHandlerName(funcName string) string {
+	parts := strings.Split(strings.TrimSuffix(funcName, "-fm"), ".")
+	name := ""
+	// Closures are named after the function returning them, followed by func1, func2...
+	for i := len(parts) - 1; i >= 0; i-- {
+		if part := parts[i]; part != "" && !strings.HasPrefix(part, "func") {
+			name = part
+			break
+		}
+	}
+
+	var words []string
+	word := []rune{}
+	runes := []rune(name)
+	for i, r := range runes {
+		// A new word starts at an upper case letter following a lower case one, or ending an acronym
+		startsWord := unicode.IsUpper(r) && i > 0 &&
+			(unicode.IsLower(runes[i-1]) || (i+1 < len(runes) && unicode.IsLower(runes[i+1])))
+		if startsWord && len(word) > 0 {
+			words = append(words, string(word))
+			word = word[:0]
+		}
+		word = append(word, r)
+	}
+	if len(word) > 0 {
+		words = append(words, string(word))
+	}
+	for i, w := range words {
+		// Acronyms keep their case, the other words after the first are lower cased
+		if i > 0 && strings.ToUpper(w) != w {
+			words[i] = strings.ToLower(w)
+		}
+	}
+	return strings.Join(words, " ")
+}
+
+// templateActions lists the preset flows a board can start: the facilitation sessions and the code exports of a frame
+func templateActions() []CatalogAction {
+	var actions []CatalogAction
+	steps := make([]string, 0, len(models.FacilitationSteps))
+	for _, step := range models.FacilitationSteps {
+		steps = append(steps, string(step))
+	}
+	facilitation := map[models.FacilitationKind]string{
+		models.FacilitationRetro:      "Run a retrospective: columns, notes, themes and action items",
+		models.FacilitationBrainstorm: "Run a brainstorm: collect ideas, cluster them and summarize",
+	}
+	for kind, description := range facilitation {
+		actions = append(actions, CatalogAction{
+			ID:          "template:facilitation:" + string(kind),
+			Kind:        ActionKindTemplate,
+			Name:        "Start a " + string(kind),
+			Description: description + " (steps: " + strings.Join(steps, ", ") + ")",
+			Category:    "facilitation",
+			Method:      "POST",
+			Path:        "/api/v1/boards/:boardId/facilitation",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"boardId": map[string]interface{}{"type": "string", "description": "Path parameter"},
+					"kind":    map[string]interface{}{"type": "string", "enum": []string{string(kind)}},
+					"topic":   map[string]interface{}{"type": "string", "description": "What the session is about"},
+				},
+				"required": []string{"boardId", "kind", "topic"},
+			},
+			Permissions: []string{ActionPermissionAuthenticated, ActionPermissionBoardOwner},
+		})
+	}
+	for _, framework := range []models.CodeFramework{models.CodeFrameworkHTML, models.CodeFrameworkReact} {
+		actions = append(actions, CatalogAction{
+			ID:          "template:export-code:" + string(framework),
+			Kind:        ActionKindTemplate,
+			Name:        "Export a frame as " + string(framework),
+			Description: "Generate " + string(framework) + " code from the shapes inside a frame",
+			Category:    "code_export",
+			Method:      "POST",
+			Path:        "/api/v1/boards/:boardId/frames/:frameId/export-code",
+			Parameters: map[string]interface{}{
+				"type": "object",
+				"properties": map[string]interface{}{
+					"boardId":    map[string]interface{}{"type": "string", "description": "Path parameter"},
+					"frameId":    map[string]interface{}{"type": "string", "description": "Path parameter"},
+					"framework":  map[string]interface{}{"type": "string", "enum": []string{string(framework)}},
+					"model_name": map[string]interface{}{"type": "string", "description": "Model to generate with, the default one when empty"},
+				},
+				"required": []string{"boardId", "frameId", "framework"},
+			},
+			Permissions: []string{ActionPermissionAuthenticated, ActionPermissionBoardOwner},
+		})
+	}
+	return actions
+}
@@ -0,0 +1,83 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestHumanizeHandlerName(t *testing.T) {
+	cases := map[string]string{
+		"melina-studio-backend/internal/handlers.(*AutomationHandler).ListAutomations-fm": "List automations",
+		"melina-studio-backend/internal/handlers.(*APIKeyHandler).CreateAPIKey-fm":        "Create API key",
+		"melina-studio-backend/internal/libraries.WebSocketHandler.func1":                 "Web socket handler",
+	}
+	for funcName, want := range cases {
+		if got := humanizeHandlerName(funcName); got != want {
+			t.Errorf("%s: got %q, want %q", funcName, got, want)
+		}
+	}
+}
+
+func TestEndpointActions(t *testing.T) {
+	const (
+		tenant = "melina-studio-backend/internal/auth.TenantMiddleware.func1"
+		authMw = "melina-studio-backend/internal/auth.AuthMiddleware.func1"
+		flags  = "melina-studio-backend/internal/auth.FeatureFlagMiddleware.func1"
+		admin  = "melina-studio-backend/internal/auth.AdminMiddleware.func1"
+	)
+	routes := []RouteInfo{
+		{Method: "GET", Path: "/api/v1", Handlers: []string{tenant}},
+		{Method: "GET", Path: "/api/v1/auth/me", Handlers: []string{"handlers.(*AuthHandler).Me-fm"}},
+		{Method: "GET", Path: "/api/v1/admin", Handlers: []string{admin}},
+		{Method: "GET", Path: "/api/v1/admin/boards/:boardId", Handlers: []string{"handlers.(*AdminHandler).GetBoard-fm"}},
+		{Method: "GET", Path: "/api/v1", Handlers: []string{authMw, flags}},
+		{Method: "GET", Path: "/api/v1/boards/:boardId/inbox", Handlers: []string{"handlers.(*InboundEmailHandler).GetInbox-fm"}},
+		{Method: "GET", Path: "/health", Handlers: []string{"api.Health"}},
+		// The next method's stack starts over with the middleware mounted on every method
+		{Method: "POST", Path: "/api/v1", Handlers: []string{tenant}},
+		{Method: "POST", Path: "/api/v1/auth/login", Handlers: []string{"api.AuthRateLimiter.func1", "handlers.(*AuthHandler).Login-fm"}},
+	}
+
+	actions := EndpointActions(routes)
+	if len(actions) != 4 {
+		t.Fatalf("expected the 4 API endpoints, got %+v", actions)
+	}
+	want := map[string][]string{
+		"endpoint:GET /api/v1/auth/me":               {ActionPermissionPublic},
+		"endpoint:GET /api/v1/admin/boards/:boardId": {ActionPermissionAdmin},
+		"endpoint:GET /api/v1/boards/:boardId/inbox": {ActionPermissionAuthenticated, ActionPermissionBoardOwner},
+		"endpoint:POST /api/v1/auth/login":           {ActionPermissionPublic},
+	}
+	for _, action := range actions {
+		if !reflect.DeepEqual(action.Permissions, want[action.ID]) {
+			t.Errorf("%s: got permissions %v, want %v", action.ID, action.Permissions, want[action.ID])
+		}
+	}
+	if inbox := actions[2]; inbox.Name != "Get inbox" || inbox.Category != "inbox" || inbox.Parameters == nil {
+		t.Errorf("unexpected inbox endpoint %+v", inbox)
+	}
+	if login := actions[3]; login.Name != "Login" || login.Category != "auth" {
+		t.Errorf("expected the last handler to name the endpoint, got %+v", login)
+	}
+}
+
+func TestFilterActions(t *testing.T) {
+	actions := []CatalogAction{
+		{ID: "tool:renderShape", Kind: ActionKindTool, Name: "renderShape", Category: "tools"},
+		{ID: "endpoint:GET /api/v1/boards/:boardId/inbox", Kind: ActionKindEndpoint, Name: "Get inbox", Category: "inbox"},
+		{ID: "template:facilitation:retro", Kind: ActionKindTemplate, Name: "Start a retro", Category: "facilitation"},
+	}
+
+	if got := FilterActions(actions, ActionFilter{}); len(got) != 3 || got[0].Kind != ActionKindEndpoint {
+		t.Errorf("expected every action sorted by kind, got %+v", got)
+	}
+	if got := FilterActions(actions, ActionFilter{Query: "INBOX"}); len(got) != 1 || got[0].Name != "Get inbox" {
+		t.Errorf("expected the query to match case-insensitively, got %+v", got)
+	}
+	if got := FilterActions(actions, ActionFilter{Kind: ActionKindTemplate, Category: "Facilitation"}); len(got) != 1 {
+		t.Errorf("expected the kind and category to narrow to the template, got %+v", got)
+	}
+	if got := FilterActions(actions, ActionFilter{Query: "nothing"}); got == nil || len(got) != 0 {
+		t.Errorf("expected an empty list, got %+v", got)
+	}
+}
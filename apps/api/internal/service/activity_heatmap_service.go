@@ -0,0 +1,183 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sort"
+	"time"
+
+	"melina-studio-backend/internal/melina/tools"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidHeatmap = errors.New("invalid heatmap request")
+
+const (
+	// heatmapDefaultDays and heatmapMaxDays bound how far back the activity is read
+	heatmapDefaultDays = 30
+	heatmapMaxDays     = 365
+	// heatmapDefaultCell, heatmapMinCell and heatmapMaxCell bound the side of a cell, in canvas units
+	heatmapDefaultCell = 200
+	heatmapMinCell     = 50
+	heatmapMaxCell     = 2000
+	// heatmapMaxActivities caps the entries one heatmap reads; the newest are kept
+	heatmapMaxActivities = 5000
+)
+
+// heatmapActivityTypes are the activities that happen somewhere on the canvas
+var heatmapActivityTypes = []models.ActivityType{
+	models.ActivityShapeAdded,
+	models.ActivityShapeRenamed,
+	models.ActivityShapeDeleted,
+	models.ActivityCardMoved,
+}
+
+// HeatmapOptions selects the activity a heatmap covers
+type HeatmapOptions struct {
+	// Days is how far back to look, 0 meaning the default
+	Days int
+	// CellSize is the side of a cell, 0 meaning the default
+	CellSize float64
+	// Actor keeps the changes of the user or of the agent only, empty meaning both
+	Actor models.ActivityActor
+}
+
+// HeatmapCell is a square of the canvas and the changes made in it; Intensity is Count relative to the busiest cell
+type HeatmapCell struct {
+	X         float64 `json:"x"`
+	Y         float64 `json:"y"`
+	Width     float64 `json:"width"`
+	Height    float64 `json:"height"`
+	Count     int     `json:"count"`
+	Intensity float64 `json:"intensity"`
+}
+
+// ActivityHeatmap is where the changes to a board concentrate, as cells to draw over the canvas, busiest first
+type ActivityHeatmap struct {
+	BoardID  uuid.UUID     `json:"board_id"`
+	Since    time.Time     `json:"since"`
+	CellSize float64       `json:"cell_size"`
+	Cells    []HeatmapCell `json:"cells"`
+	MaxCount int           `json:"max_count"`
+	// Located counts the changes placed on the canvas; Unlocated those to shapes that are gone and whose
+	// position wasn't logged
+	Located   int `json:"located"`
+	Unlocated int `json:"unlocated"`
+	// Truncated is set when older activity was left out past the cap
+	Truncated bool `json:"truncated"`
+}
+
+// ActivityHeatmapService builds heatmaps of where a board is edited from its activity log
+type ActivityHeatmapService struct {
+	activityRepo  repo.BoardActivityRepoInterface
+	boardDataRepo repo.BoardDataRepoInterface
+}
+
+func NewActivityHeatmapService(activityRepo repo.BoardActivityRepoInterface, boardDataRepo repo.BoardDataRepoInterface) *ActivityHeatmapService {
+	return &ActivityHeatmapService{
+		activityRepo:  activityRepo,
+		boardDataRepo: boardDataRepo,
+	}
+}
+
+// Heatmap counts the board's shape changes per cell of the canvas
+func (s *ActivityHeatmapService) Heatmap(boardID uuid.UUID, opts HeatmapOptions) (*ActivityHeatmap, error) {
+	days := opts.Days
+	if days == 0 {
+		days = heatmapDefaultDays
+	}
+	if days < 0 || days > heatmapMaxDays {
+		return nil, fmt.Errorf("%w: days must be 1 to %d", ErrInvalidHeatmap, heatmapMaxDays)
+	}
+	cellSize := opts.CellSize
+	if cellSize == 0 {
+		cellSize = heatmapDefaultCell
+	}
+	// NaN passes any range check, and neither it nor infinity makes a cell
+	if math.IsNaN(cellSize) || math.IsInf(cellSize, 0) || cellSize < heatmapMinCell || cellSize > heatmapMaxCell {
+		return nil, fmt.Errorf("%w: cell must be %d to %d", ErrInvalidHeatmap, heatmapMinCell, heatmapMaxCell)
+	}
+	if opts.Actor != "" && opts.Actor != models.ActivityActorUser && opts.Actor != models.ActivityActorAgent {
+		return nil, fmt.Errorf("%w: actor must be 'user' or 'agent'", ErrInvalidHeatmap)
+	}
+
+	since := time.Now().AddDate(0, 0, -days)
+	// the actor is filtered in the query, so the cap keeps as many of their changes as it can
+	activities, err := s.activityRepo.ListSince(boardID, since, heatmapActivityTypes, opts.Actor, heatmapMaxActivities)
+	if err != nil {
+		return nil, err
+	}
+	shapes, err := s.boardDataRepo.GetBoardData(boardID)
+	if err != nil {
+		return nil, err
+	}
+
+	heatmap := BuildActivityHeatmap(activities, shapes, cellSize)
+	heatmap.BoardID = boardID
+	heatmap.Since = since
+	heatmap.Truncated = len(activities) >= heatmapMaxActivities
+	return heatmap, nil
+}
+
+// BuildActivityHeatmap places each activity where it was logged or, failing that, at the current center of the
+// shapes it touched, and counts them per cell
+func BuildActivityHeatmap(activities []models.BoardActivity, shapes []models.BoardData, cellSize float64) *ActivityHeatmap {
+	centers := make(map[uuid.UUID][2]float64, len(shapes))
+	for _, shape := range shapes {
+		if b, _, err := tools.GetShapeBounds(shape, 0); err == nil {
+			centers[shape.UUID] = [2]float64{(b.MinX + b.MaxX) / 2, (b.MinY + b.MaxY) / 2}
+		}
+	}
+
+	heatmap := &ActivityHeatmap{CellSize: cellSize, Cells: []HeatmapCell{}}
+	counts := make(map[[2]int]int)
+	for _, activity := range activities {
+		points, shapeIDs := activityLocations(activity)
+		if len(points) == 0 {
+			for _, id := range shapeIDs {
+				if center, ok := centers[id]; ok {
+					points = append(points, center)
+				}
+			}
+		}
+		if len(points) == 0 {
+			heatmap.Unlocated += max(activity.Count, 1)
+			continue
+		}
+		for _, point := range points {
+			counts[[2]int{int(math.Floor(point[0] / cellSize)), int(math.Floor(point[1] / cellSize))}]++
+		}
+		heatmap.Located += len(points)
+		// a grouped entry may cover more changes than it could place
+		heatmap.Unlocated += max(activity.Count-len(points), 0)
+	}
+
+	for cell, count := range counts {
+		heatmap.Cells = append(heatmap.Cells, HeatmapCell{
+			X:      float64(cell[0]) * cellSize,
+			Y:      float64(cell[1]) * cellSize,
+			Width:  cellSize,
+			Height: cellSize,
+			Count:  count,
+		})
+		heatmap.MaxCount = max(heatmap.MaxCount, count)
+	}
+	for i := range heatmap.Cells {
+		heatmap.Cells[i].Intensity = float64(heatmap.Cells[i].Count) / float64(heatmap.MaxCount)
+	}
+	sort.Slice(heatmap.Cells, func(i, j int) bool {
+		a, b := heatmap.Cells[i], heatmap.Cells[j]
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		if a.Y != b.Y {
+			return a.Y < b.Y
+		}
+		return a.X < b.X
+	})
+	return heatmap
+}
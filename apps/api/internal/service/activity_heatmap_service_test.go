@@ -0,0 +1,129 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"math"
+	"testing"
+	"time"
+
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+
+	"github.com/google/uuid"
+)
+
+func TestBuildActivityHeatmap(t *testing.T) {
+	userID, boardID := uuid.New(), uuid.New()
+	note, card, gone := uuid.New(), uuid.New(), uuid.New()
+	shapes := []models.BoardData{
+		storedShape(note, models.Rect, `{"x":20,"y":20,"w":100,"h":100}`),
+		storedShape(card, models.Rect, `{"x":420,"y":20,"w":100,"h":100}`),
+	}
+	existing := append(shapes, storedShape(gone, models.Rect, `{"x":30,"y":30,"w":20,"h":20}`))
+
+	// renaming the note and deleting the shape next to it, then moving the card
+	activities := []models.BoardActivity{}
+	for _, activity := range DiffShapeActivities(userID, boardID, existing, []models.Shape{
+		{ID: note.String(), Type: "rect", Text: strPtr("Idea")},
+		{ID: card.String(), Type: "rect"},
+	}) {
+		activities = append(activities, *activity)
+	}
+	activities = append(activities, models.BoardActivity{Type: models.ActivityCardMoved, ShapeID: &card, Count: 1})
+	// an add whose shape was deleted since, with nothing logged about where it was
+	activities = append(activities, models.BoardActivity{Type: models.ActivityShapeAdded, ShapeID: ptrUUID(uuid.New()), Count: 1})
+
+	heatmap := BuildActivityHeatmap(activities, shapes, 200)
+	if heatmap.Located != 3 || heatmap.Unlocated != 1 {
+		t.Fatalf("expected 3 located and 1 unlocated changes, got %d and %d", heatmap.Located, heatmap.Unlocated)
+	}
+	if len(heatmap.Cells) != 2 || heatmap.MaxCount != 2 {
+		t.Fatalf("expected 2 cells, the busiest with 2 changes, got %+v", heatmap.Cells)
+	}
+	busiest, other := heatmap.Cells[0], heatmap.Cells[1]
+	if busiest.X != 0 || busiest.Y != 0 || busiest.Count != 2 || busiest.Intensity != 1 {
+		t.Errorf("expected the rename and the deletion in the first cell, got %+v", busiest)
+	}
+	if other.X != 400 || other.Count != 1 || other.Intensity != 0.5 || other.Width != 200 {
+		t.Errorf("expected the card move in the third cell, got %+v", other)
+	}
+}
+
+func TestGroupedActivitiesKeepTheirShapes(t *testing.T) {
+	var existing []models.BoardData
+	for i := 0; i < 4; i++ {
+		existing = append(existing, storedShape(uuid.New(), models.Rect, `{"x":1000,"y":1000,"w":10,"h":10}`))
+	}
+	activities := DiffShapeActivities(uuid.New(), uuid.New(), existing, nil)
+	if len(activities) != 1 || activities[0].Count != 4 {
+		t.Fatalf("expected one grouped deletion, got %d", len(activities))
+	}
+
+	heatmap := BuildActivityHeatmap([]models.BoardActivity{*activities[0]}, nil, 500)
+	if heatmap.Located != 4 || len(heatmap.Cells) != 1 || heatmap.Cells[0].X != 1000 {
+		t.Errorf("expected the 4 deletions to be placed where the shapes were, got %+v", heatmap)
+	}
+}
+
+func ptrUUID(id uuid.UUID) *uuid.UUID { return &id }
+
+type fakeHeatmapActivityRepo struct {
+	repo.BoardActivityRepoInterface
+	actor      models.ActivityActor
+	activities []models.BoardActivity
+}
+
+func (f *fakeHeatmapActivityRepo) ListSince(boardID uuid.UUID, since time.Time, types []models.ActivityType, actor models.ActivityActor, limit int) ([]models.BoardActivity, error) {
+	f.actor = actor
+	return f.activities[:min(limit, len(f.activities))], nil
+}
+
+type fakeHeatmapBoardDataRepo struct {
+	repo.BoardDataRepoInterface
+}
+
+func (f *fakeHeatmapBoardDataRepo) GetBoardData(boardId uuid.UUID) ([]models.BoardData, error) {
+	return nil, nil
+}
+
+func TestActivityHeatmapOptions(t *testing.T) {
+	activities := &fakeHeatmapActivityRepo{}
+	s := NewActivityHeatmapService(activities, &fakeHeatmapBoardDataRepo{})
+	boardID := uuid.New()
+
+	for name, opts := range map[string]HeatmapOptions{
+		"NaN cell":      {CellSize: math.NaN()},
+		"infinite cell": {CellSize: math.Inf(1)},
+		"small cell":    {CellSize: heatmapMinCell - 1},
+		"too many days": {Days: heatmapMaxDays + 1},
+		"unknown actor": {Actor: "robot"},
+	} {
+		if _, err := s.Heatmap(boardID, opts); !errors.Is(err, ErrInvalidHeatmap) {
+			t.Errorf("%s: got %v, want ErrInvalidHeatmap", name, err)
+		}
+	}
+
+	// the actor is left to the query, so a full page of their own changes is what truncates
+	x, y := 10.0, 10.0
+	metadata, _ := json.Marshal(activityMetadata{X: &x, Y: &y})
+	activities.activities = make([]models.BoardActivity, heatmapMaxActivities)
+	for i := range activities.activities {
+		activities.activities[i] = models.BoardActivity{Type: models.ActivityShapeDeleted, Actor: models.ActivityActorUser, Count: 1, Metadata: metadata}
+	}
+	heatmap, err := s.Heatmap(boardID, HeatmapOptions{Actor: models.ActivityActorUser})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if activities.actor != models.ActivityActorUser {
+		t.Errorf("expected the actor to be passed to the query, got %q", activities.actor)
+	}
+	if !heatmap.Truncated || heatmap.Located != heatmapMaxActivities {
+		t.Errorf("got truncated %v located %d, want a truncated heatmap of %d changes", heatmap.Truncated, heatmap.Located, heatmapMaxActivities)
+	}
+
+	activities.activities = activities.activities[:10]
+	if heatmap, _ := s.Heatmap(boardID, HeatmapOptions{}); heatmap.Truncated || activities.actor != "" {
+		t.Errorf("expected an untruncated heatmap of anyone's changes, got %v %q", heatmap.Truncated, activities.actor)
+	}
+}
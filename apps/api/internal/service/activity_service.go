@@ -0,0 +1,237 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"melina-studio-backend/internal/libraries"
+	"melina-studio-backend/internal/melina/tools"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+	"strings"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+const (
+	// maxIndividualActivities is how many changes of one type a save may log individually before they are grouped
+	maxIndividualActivities = 3
+	activityLabelRunes      = 60
+)
+
+type ActivityService struct {
+	activityRepo repo.BoardActivityRepoInterface
+	hub          *libraries.Hub
+}
+
+func NewActivityService(activityRepo repo.BoardActivityRepoInterface, hub *libraries.Hub) *ActivityService {
+	return &ActivityService{
+		activityRepo: activityRepo,
+		hub:          hub,
+	}
+}
+
+// Record stores activity entries and pushes them to the board owner's open connections
+// Failures are logged only, the activity feed must never fail the change it describes
+func (s *ActivityService) Record(activities ...*models.BoardActivity) {
+	if len(activities) == 0 {
+		return
+	}
+	if err := s.activityRepo.Create(activities...); err != nil {
+		log.Printf("Failed to record board activity: %v", err)
+		return
+	}
+	if s.hub == nil {
+		return
+	}
+	for _, activity := range activities {
+		libraries.SendBoardActivityMessage(s.hub, activity.UserID.String(), &libraries.BoardActivityPayload{
+			BoardId:  activity.BoardID.String(),
+			Activity: activity,
+		})
+	}
+}
+
+// RecordAgentRun logs a completed chat turn of the agent
+func (s *ActivityService) RecordAgentRun(userID uuid.UUID, boardID uuid.UUID, prompt string, modelName string, humanMessageID uuid.UUID, aiMessageID uuid.UUID) {
+	metadata, _ := json.Marshal(map[string]string{
+		"model":            modelName,
+		"human_message_id": humanMessageID.String(),
+		"ai_message_id":    aiMessageID.String(),
+	})
+	s.Record(&models.BoardActivity{
+		BoardID:  boardID,
+		UserID:   userID,
+		Type:     models.ActivityAgentRun,
+		Actor:    models.ActivityActorAgent,
+		Summary:  fmt.Sprintf("Melina responded to %q", activityLabel(prompt)),
+		Metadata: datatypes.JSON(metadata),
+	})
+}
+
+// DiffShapeActivities compares the stored shapes of a board with a full save and describes what changed
+// Moves and style edits are not logged; only added, deleted and relabelled shapes are
+func DiffShapeActivities(userID uuid.UUID, boardID uuid.UUID, existing []models.BoardData, incoming []models.Shape) []*models.BoardActivity {
+	type storedShape struct {
+		shapeType string
+		label     string
+	}
+	stored := make(map[string]storedShape, len(existing))
+	for _, shape := range existing {
+		stored[shape.UUID.String()] = storedShape{shapeType: string(shape.Type), label: storedShapeLabel(shape)}
+	}
+
+	newActivity := func(activityType models.ActivityType, shapeID string, shapeType string, summary string) *models.BoardActivity {
+		activity := &models.BoardActivity{
+			BoardID:   boardID,
+			UserID:    userID,
+			Type:      activityType,
+			Actor:     models.ActivityActorUser,
+			ShapeType: shapeType,
+			Summary:   summary,
+		}
+		if id, err := uuid.Parse(shapeID); err == nil {
+			activity.ShapeID = &id
+		}
+		return activity
+	}
+
+	var added, renamed, deleted []*models.BoardActivity
+	seen := make(map[string]bool, len(incoming))
+	for _, shape := range incoming {
+		seen[shape.ID] = true
+		label := incomingShapeLabel(shape)
+		previous, ok := stored[shape.ID]
+		switch {
+		case !ok:
+			added = append(added, newActivity(models.ActivityShapeAdded, shape.ID, shape.Type, describeShape("Added", shape.Type, label)))
+		case label != previous.label && label != "":
+			summary := fmt.Sprintf("Renamed %q to %q", activityLabel(previous.label), activityLabel(label))
+			if previous.label == "" {
+				summary = fmt.Sprintf("Labelled %s %q", shape.Type, activityLabel(label))
+			}
+			renamed = append(renamed, newActivity(models.ActivityShapeRenamed, shape.ID, shape.Type, summary))
+		}
+	}
+	for _, shape := range existing {
+		id := shape.UUID.String()
+		if !seen[id] {
+			activity := newActivity(models.ActivityShapeDeleted, id, string(shape.Type), describeShape("Deleted", string(shape.Type), stored[id].label))
+			// the shape is gone, so the heatmap can only place the deletion where it was
+			if b, _, err := tools.GetShapeBounds(shape, 0); err == nil {
+				x, y := (b.MinX+b.MaxX)/2, (b.MinY+b.MaxY)/2
+				metadata, _ := json.Marshal(activityMetadata{X: &x, Y: &y})
+				activity.Metadata = datatypes.JSON(metadata)
+			}
+			deleted = append(deleted, activity)
+		}
+	}
+
+	var activities []*models.BoardActivity
+	activities = append(activities, groupActivities(added, "Added %d shapes")...)
+	activities = append(activities, groupActivities(renamed, "Renamed %d shapes")...)
+	activities = append(activities, groupActivities(deleted, "Deleted %d shapes")...)
+	return activities
+}
+
+// activityMetadata is the part of a shape activity's metadata that says where it happened: the shape's center
+// for a deletion, and for a grouped entry the shapes it covers and the centers known of them
+type activityMetadata struct {
+	X        *float64     `json:"x,omitempty"`
+	Y        *float64     `json:"y,omitempty"`
+	ShapeIDs []string     `json:"shape_ids,omitempty"`
+	Points   [][2]float64 `json:"points,omitempty"`
+}
+
+// groupActivities collapses a batch of same-type activities into one entry when there are too many to list,
+// keeping the shapes they cover in its metadata
+func groupActivities(activities []*models.BoardActivity, summaryFormat string) []*models.BoardActivity {
+	if len(activities) <= maxIndividualActivities {
+		return activities
+	}
+	first := activities[0]
+	var grouped activityMetadata
+	for _, activity := range activities {
+		if activity.ShapeID != nil {
+			grouped.ShapeIDs = append(grouped.ShapeIDs, activity.ShapeID.String())
+		}
+		points, _ := activityLocations(*activity)
+		grouped.Points = append(grouped.Points, points...)
+	}
+	metadata, _ := json.Marshal(grouped)
+	return []*models.BoardActivity{{
+		BoardID:  first.BoardID,
+		UserID:   first.UserID,
+		Type:     first.Type,
+		Actor:    first.Actor,
+		Count:    len(activities),
+		Summary:  fmt.Sprintf(summaryFormat, len(activities)),
+		Metadata: datatypes.JSON(metadata),
+	}}
+}
+
+// activityLocations reads where a shape activity happened from its metadata: the points recorded when it was
+// logged, and the shapes it touched, to be placed where they are now
+func activityLocations(activity models.BoardActivity) ([][2]float64, []uuid.UUID) {
+	var metadata activityMetadata
+	if len(activity.Metadata) > 0 {
+		// other activities keep other fields there, which are ignored
+		_ = json.Unmarshal(activity.Metadata, &metadata)
+	}
+	points := metadata.Points
+	if metadata.X != nil && metadata.Y != nil {
+		points = append(points, [2]float64{*metadata.X, *metadata.Y})
+	}
+	var shapeIDs []uuid.UUID
+	if activity.ShapeID != nil {
+		shapeIDs = append(shapeIDs, *activity.ShapeID)
+	}
+	for _, id := range metadata.ShapeIDs {
+		if shapeID, err := uuid.Parse(id); err == nil {
+			shapeIDs = append(shapeIDs, shapeID)
+		}
+	}
+	return points, shapeIDs
+}
+
+func describeShape(verb string, shapeType string, label string) string {
+	if label == "" {
+		return fmt.Sprintf("%s %s", verb, shapeType)
+	}
+	return fmt.Sprintf("%s %s %q", verb, shapeType, activityLabel(label))
+}
+
+func storedShapeLabel(shape models.BoardData) string {
+	var data struct {
+		Text string `json:"text"`
+		Name string `json:"name"`
+	}
+	if err := json.Unmarshal(shape.Data, &data); err != nil {
+		return ""
+	}
+	if strings.TrimSpace(data.Text) != "" {
+		return strings.TrimSpace(data.Text)
+	}
+	return strings.TrimSpace(data.Name)
+}
+
+func incomingShapeLabel(shape models.Shape) string {
+	if shape.Text != nil && strings.TrimSpace(*shape.Text) != "" {
+		return strings.TrimSpace(*shape.Text)
+	}
+	if shape.Name != nil {
+		return strings.TrimSpace(*shape.Name)
+	}
+	return ""
+}
+
+// activityLabel shortens text to a single line for activity summaries
+func activityLabel(text string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+	if len(runes) > activityLabelRunes {
+		return string(runes[:activityLabelRunes]) + "…"
+	}
+	return text
+}
@@ -0,0 +1,70 @@
+package service
+
+import (
+	"fmt"
+	"melina-studio-backend/internal/models"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+func storedShape(id uuid.UUID, shapeType models.Type, data string) models.BoardData {
+	return models.BoardData{UUID: id, Type: shapeType, Data: datatypes.JSON(data)}
+}
+
+func strPtr(s string) *string { return &s }
+
+func TestDiffShapeActivities(t *testing.T) {
+	userID, boardID := uuid.New(), uuid.New()
+	kept, relabelled, removed, added := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+
+	existing := []models.BoardData{
+		storedShape(kept, "rect", `{"x":1}`),
+		storedShape(relabelled, "text", `{"text":"Login"}`),
+		storedShape(removed, "frame", `{"name":"Checkout"}`),
+	}
+	incoming := []models.Shape{
+		{ID: kept.String(), Type: "rect"},
+		{ID: relabelled.String(), Type: "text", Text: strPtr("Sign in")},
+		{ID: added.String(), Type: "circle"},
+	}
+
+	activities := DiffShapeActivities(userID, boardID, existing, incoming)
+	if len(activities) != 3 {
+		t.Fatalf("expected 3 activities, got %d", len(activities))
+	}
+	want := []struct {
+		activityType models.ActivityType
+		shapeID      uuid.UUID
+		summary      string
+	}{
+		{models.ActivityShapeAdded, added, "Added circle"},
+		{models.ActivityShapeRenamed, relabelled, `Renamed "Login" to "Sign in"`},
+		{models.ActivityShapeDeleted, removed, `Deleted frame "Checkout"`},
+	}
+	for i, w := range want {
+		a := activities[i]
+		if a.Type != w.activityType || a.ShapeID == nil || *a.ShapeID != w.shapeID || a.Summary != w.summary {
+			t.Errorf("activity %d: got %s %v %q", i, a.Type, a.ShapeID, a.Summary)
+		}
+		if a.BoardID != boardID || a.UserID != userID || a.Actor != models.ActivityActorUser {
+			t.Errorf("activity %d: wrong board, user or actor", i)
+		}
+	}
+}
+
+func TestDiffShapeActivitiesGroupsLargeSaves(t *testing.T) {
+	var incoming []models.Shape
+	for i := 0; i < 5; i++ {
+		incoming = append(incoming, models.Shape{ID: uuid.NewString(), Type: "rect", Text: strPtr(fmt.Sprintf("Note %d", i))})
+	}
+
+	activities := DiffShapeActivities(uuid.New(), uuid.New(), nil, incoming)
+	if len(activities) != 1 {
+		t.Fatalf("expected a single grouped activity, got %d", len(activities))
+	}
+	if activities[0].Count != 5 || activities[0].Summary != "Added 5 shapes" || activities[0].ShapeID != nil {
+		t.Errorf("unexpected grouped activity %+v", activities[0])
+	}
+}
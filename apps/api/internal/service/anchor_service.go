@@ -0,0 +1,157 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"melina-studio-backend/internal/config"
+	"melina-studio-backend/internal/melina/tools"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidAnchor  = errors.New("invalid anchor")
+	ErrAnchorNotFound = errors.New("anchor target not found")
+)
+
+type AnchorService struct {
+	boardDataRepo repo.BoardDataRepoInterface
+	chatRepo      repo.ChatRepoInterface
+}
+
+func NewAnchorService(boardDataRepo repo.BoardDataRepoInterface, chatRepo repo.ChatRepoInterface) *AnchorService {
+	return &AnchorService{
+		boardDataRepo: boardDataRepo,
+		chatRepo:      chatRepo,
+	}
+}
+
+// CreateAnchor builds a deep link to a shape or chat message of a board
+// Shape anchors carry a viewport hint that frames the shape, used as fallback if the shape is later removed
+func (s *AnchorService) CreateAnchor(boardID uuid.UUID, target models.AnchorTarget, targetID uuid.UUID) (*models.BoardAnchor, error) {
+	anchor := &models.BoardAnchor{BoardID: boardID, Target: target, Found: true}
+
+	switch target {
+	case models.AnchorTargetShape:
+		bounds, err := s.shapeBounds(boardID, targetID)
+		if err != nil {
+			return nil, err
+		}
+		viewport := viewportForBounds(*bounds)
+		anchor.ShapeID = &targetID
+		anchor.Bounds = bounds
+		anchor.Viewport = &viewport
+	case models.AnchorTargetChat:
+		if _, err := s.chatRepo.GetChatByID(boardID, targetID); err != nil {
+			return nil, ErrAnchorNotFound
+		}
+		anchor.ChatID = &targetID
+	default:
+		return nil, ErrInvalidAnchor
+	}
+
+	anchor.Anchor = formatAnchor(target, targetID, anchor.Viewport)
+	anchor.URL = anchorURL(boardID, anchor.Anchor)
+	return anchor, nil
+}
+
+// ResolveAnchor returns the current position of an anchor's target, for use on board load
+// A missing target is not an error: Found is false and the viewport hint from the token is returned
+func (s *AnchorService) ResolveAnchor(boardID uuid.UUID, token string) (*models.BoardAnchor, error) {
+	target, targetID, hint, err := parseAnchor(token)
+	if err != nil {
+		return nil, err
+	}
+	anchor := &models.BoardAnchor{Anchor: token, BoardID: boardID, Target: target, Viewport: hint}
+
+	switch target {
+	case models.AnchorTargetShape:
+		anchor.ShapeID = &targetID
+		if bounds, err := s.shapeBounds(boardID, targetID); err == nil {
+			viewport := viewportForBounds(*bounds)
+			anchor.Found = true
+			anchor.Bounds = bounds
+			anchor.Viewport = &viewport
+		}
+	case models.AnchorTargetChat:
+		anchor.ChatID = &targetID
+		_, err := s.chatRepo.GetChatByID(boardID, targetID)
+		anchor.Found = err == nil
+	}
+	return anchor, nil
+}
+
+func (s *AnchorService) shapeBounds(boardID uuid.UUID, shapeID uuid.UUID) (*models.AnchorBounds, error) {
+	shape, err := s.boardDataRepo.GetShapeByUUID(shapeID)
+	if err != nil || shape.BoardId != boardID {
+		return nil, ErrAnchorNotFound
+	}
+	box, _, err := tools.GetShapeBounds(*shape, 0)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get shape bounds: %w", err)
+	}
+	bounds := tools.BoundsRect(box)
+	return &bounds, nil
+}
+
+// formatAnchor encodes an anchor token: "<target>_<uuid>" plus "@x,y,zoom" when a viewport hint is known
+func formatAnchor(target models.AnchorTarget, targetID uuid.UUID, viewport *models.Viewport) string {
+	token := fmt.Sprintf("%s_%s", target, targetID)
+	if viewport != nil {
+		token += fmt.Sprintf("@%s,%s,%s",
+			strconv.FormatFloat(math.Round(viewport.X), 'f', -1, 64),
+			strconv.FormatFloat(math.Round(viewport.Y), 'f', -1, 64),
+			strconv.FormatFloat(math.Round(viewport.Zoom*100)/100, 'f', -1, 64))
+	}
+	return token
+}
+
+// parseAnchor decodes a token produced by formatAnchor
+func parseAnchor(token string) (models.AnchorTarget, uuid.UUID, *models.Viewport, error) {
+	ref, hint, hasHint := strings.Cut(strings.TrimSpace(token), "@")
+	kind, idStr, ok := strings.Cut(ref, "_")
+	if !ok {
+		return "", uuid.Nil, nil, ErrInvalidAnchor
+	}
+	target := models.AnchorTarget(kind)
+	if target != models.AnchorTargetShape && target != models.AnchorTargetChat {
+		return "", uuid.Nil, nil, ErrInvalidAnchor
+	}
+	targetID, err := uuid.Parse(idStr)
+	if err != nil {
+		return "", uuid.Nil, nil, ErrInvalidAnchor
+	}
+	if !hasHint {
+		return target, targetID, nil, nil
+	}
+
+	parts := strings.Split(hint, ",")
+	if len(parts) != 3 {
+		return "", uuid.Nil, nil, ErrInvalidAnchor
+	}
+	values := make([]float64, 3)
+	for i, part := range parts {
+		v, err := strconv.ParseFloat(part, 64)
+		if err != nil || math.IsNaN(v) || math.IsInf(v, 0) {
+			return "", uuid.Nil, nil, ErrInvalidAnchor
+		}
+		values[i] = v
+	}
+	return target, targetID, &models.Viewport{X: values[0], Y: values[1], Zoom: tools.ClampZoom(values[2])}, nil
+}
+
+// viewportForBounds centers the viewport on the bounds, fitted to the default canvas size
+func viewportForBounds(b models.AnchorBounds) models.Viewport {
+	box := tools.BoundingBox{MinX: b.X, MinY: b.Y, MaxX: b.X + b.Width, MaxY: b.Y + b.Height}
+	return tools.FitViewport(box, tools.FocusCanvasWidth, tools.FocusCanvasHeight)
+}
+
+func anchorURL(boardID uuid.UUID, token string) string {
+	return fmt.Sprintf("%s/playground/%s?anchor=%s", config.GetSettings().Server.FrontendURL, boardID, url.QueryEscape(token))
+}
@@ -0,0 +1,55 @@
+package service
+
+import (
+	"melina-studio-backend/internal/melina/tools"
+	"melina-studio-backend/internal/models"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestAnchorRoundTrip(t *testing.T) {
+	id := uuid.New()
+	viewport := &models.Viewport{X: 120.4, Y: -35.6, Zoom: 1.256}
+	token := formatAnchor(models.AnchorTargetShape, id, viewport)
+	if want := "shape_" + id.String() + "@120,-36,1.26"; token != want {
+		t.Fatalf("got %q, want %q", token, want)
+	}
+
+	target, gotID, hint, err := parseAnchor(token)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if target != models.AnchorTargetShape || gotID != id {
+		t.Errorf("got %s %s", target, gotID)
+	}
+	if hint == nil || hint.X != 120 || hint.Y != -36 || hint.Zoom != 1.26 {
+		t.Errorf("unexpected viewport hint %+v", hint)
+	}
+
+	target, gotID, hint, err = parseAnchor("chat_" + id.String())
+	if err != nil || target != models.AnchorTargetChat || gotID != id || hint != nil {
+		t.Errorf("chat anchor: got %s %s %v %v", target, gotID, hint, err)
+	}
+}
+
+func TestParseAnchorRejectsMalformed(t *testing.T) {
+	id := uuid.NewString()
+	for _, token := range []string{"", "shape", "frame_" + id, "shape_not-a-uuid", "shape_" + id + "@1,2", "shape_" + id + "@1,2,NaN"} {
+		if _, _, _, err := parseAnchor(token); err != ErrInvalidAnchor {
+			t.Errorf("%q: expected ErrInvalidAnchor, got %v", token, err)
+		}
+	}
+}
+
+func TestViewportForBounds(t *testing.T) {
+	v := viewportForBounds(models.AnchorBounds{X: 100, Y: 200, Width: 200, Height: 100})
+	if v.X != 200 || v.Y != 250 || v.Zoom != tools.MaxViewportZoom {
+		t.Errorf("small shape: got %+v", v)
+	}
+
+	v = viewportForBounds(models.AnchorBounds{X: 0, Y: 0, Width: 2240, Height: 100})
+	if v.Zoom != 0.5 {
+		t.Errorf("wide shape should fit the width: got zoom %v", v.Zoom)
+	}
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"melina-studio-backend/internal/libraries"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	maxAnnouncementTitleLength = 200
+	// defaultAnnouncementLimit is how many announcements the in-app changelog shows
+	defaultAnnouncementLimit = 20
+	maxAnnouncementLimit     = 100
+)
+
+var (
+	ErrInvalidAnnouncement  = errors.New("invalid announcement")
+	ErrAnnouncementNotFound = errors.New("announcement not found")
+)
+
+type AnnouncementService struct {
+	announcementRepo repo.AnnouncementRepoInterface
+	hub              *libraries.Hub
+}
+
+func NewAnnouncementService(announcementRepo repo.AnnouncementRepoInterface, hub *libraries.Hub) *AnnouncementService {
+	return &AnnouncementService{
+		announcementRepo: announcementRepo,
+		hub:              hub,
+	}
+}
+
+// AnnouncementUpdate holds the announcement fields to set; nil fields are left untouched
+// Publish publishes a draft; a published announcement can't go back to being a draft
+type AnnouncementUpdate struct {
+	Title   *string                  `json:"title"`
+	Body    *string                  `json:"body"`
+	Kind    *models.AnnouncementKind `json:"kind"`
+	Link    *string                  `json:"link"`
+	Publish bool                     `json:"publish"`
+}
+
+// UserAnnouncements is the in-app changelog of a user
+type UserAnnouncements struct {
+	Announcements []models.UserAnnouncement `json:"announcements"`
+	Unread        int64                     `json:"unread"`
+}
+
+// List returns every announcement, drafts included, for the admin API
+func (s *AnnouncementService) List() ([]models.Announcement, error) {
+	return s.announcementRepo.List()
+}
+
+// Create creates a draft, or publishes it right away when the update asks to
+func (s *AnnouncementService) Create(update AnnouncementUpdate) (*models.Announcement, error) {
+	announcement := &models.Announcement{Kind: models.AnnouncementFeature}
+	if err := applyAnnouncementUpdate(announcement, update); err != nil {
+		return nil, err
+	}
+	if announcement.Title == "" || announcement.Body == "" {
+		return nil, fmt.Errorf("%w: title and body are required", ErrInvalidAnnouncement)
+	}
+	if err := s.announcementRepo.Create(announcement); err != nil {
+		return nil, err
+	}
+	s.deliver(announcement, update.Publish)
+	return announcement, nil
+}
+
+// Update edits an announcement; edits of a published announcement aren't pushed again
+func (s *AnnouncementService) Update(id uuid.UUID, update AnnouncementUpdate) (*models.Announcement, error) {
+	announcement, err := s.announcementRepo.Get(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrAnnouncementNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	wasPublished := announcement.PublishedAt != nil
+
+	if err := applyAnnouncementUpdate(announcement, update); err != nil {
+		return nil, err
+	}
+	if err := s.announcementRepo.Update(announcement); err != nil {
+		return nil, err
+	}
+	s.deliver(announcement, update.Publish && !wasPublished)
+	return announcement, nil
+}
+
+func (s *AnnouncementService) Delete(id uuid.UUID) error {
+	err := s.announcementRepo.Delete(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrAnnouncementNotFound
+	}
+	return err
+}
+
+// ListForUser returns the latest published announcements with the user's read state and unread count
+func (s *AnnouncementService) ListForUser(userID uuid.UUID, limit int) (*UserAnnouncements, error) {
+	if limit <= 0 {
+		limit = defaultAnnouncementLimit
+	}
+	if limit > maxAnnouncementLimit {
+		limit = maxAnnouncementLimit
+	}
+	announcements, err := s.announcementRepo.ListPublished(userID, limit)
+	if err != nil {
+		return nil, err
+	}
+	unread, err := s.announcementRepo.CountUnread(userID)
+	if err != nil {
+		return nil, err
+	}
+	if announcements == nil {
+		announcements = []models.UserAnnouncement{}
+	}
+	return &UserAnnouncements{Announcements: announcements, Unread: unread}, nil
+}
+
+// MarkRead marks the given announcements as read, or all of them when ids is empty
+func (s *AnnouncementService) MarkRead(userID uuid.UUID, ids []uuid.UUID) error {
+	if len(ids) == 0 {
+		return s.announcementRepo.MarkAllRead(userID)
+	}
+	return s.announcementRepo.MarkRead(userID, ids)
+}
+
+// deliver pushes an announcement that was just published to every open connection
+func (s *AnnouncementService) deliver(announcement *models.Announcement, published bool) {
+	if !published || s.hub == nil {
+		return
+	}
+	libraries.SendAnnouncementMessage(s.hub, &models.UserAnnouncement{Announcement: *announcement})
+}
+
+func applyAnnouncementUpdate(announcement *models.Announcement, update AnnouncementUpdate) error {
+	if update.Title != nil {
+		title := strings.TrimSpace(*update.Title)
+		if title == "" || len([]rune(title)) > maxAnnouncementTitleLength {
+			return fmt.Errorf("%w: title must be 1 to %d characters", ErrInvalidAnnouncement, maxAnnouncementTitleLength)
+		}
+		announcement.Title = title
+	}
+	if update.Body != nil {
+		body := strings.TrimSpace(*update.Body)
+		if body == "" {
+			return fmt.Errorf("%w: body can't be empty", ErrInvalidAnnouncement)
+		}
+		announcement.Body = body
+	}
+	if update.Kind != nil {
+		switch *update.Kind {
+		case models.AnnouncementFeature, models.AnnouncementImprovement, models.AnnouncementFix, models.AnnouncementNotice:
+			announcement.Kind = *update.Kind
+		default:
+			return fmt.Errorf("%w: kind must be feature, improvement, fix or notice", ErrInvalidAnnouncement)
+		}
+	}
+	if update.Link != nil {
+		link := strings.TrimSpace(*update.Link)
+		if link != "" {
+			if u, err := url.Parse(link); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+				return fmt.Errorf("%w: link must be an http(s) URL", ErrInvalidAnnouncement)
+			}
+		}
+		announcement.Link = link
+	}
+	if update.Publish && announcement.PublishedAt == nil {
+		now := time.Now()
+		announcement.PublishedAt = &now
+	}
+	return nil
+}
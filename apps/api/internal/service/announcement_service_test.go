@@ -0,0 +1,54 @@
+package service
+
+import (
+	"errors"
+	"testing"
+
+	"melina-studio-backend/internal/models"
+)
+
+func TestApplyAnnouncementUpdate(t *testing.T) {
+	title, body, link := "  Dark mode  ", "Boards now follow your system theme.", "https://melina.studio/changelog"
+	kind := models.AnnouncementImprovement
+	announcement := &models.Announcement{Kind: models.AnnouncementFeature}
+
+	if err := applyAnnouncementUpdate(announcement, AnnouncementUpdate{Title: &title, Body: &body, Kind: &kind, Link: &link}); err != nil {
+		t.Fatal(err)
+	}
+	if announcement.Title != "Dark mode" || announcement.Kind != kind || announcement.Link != link {
+		t.Fatalf("unexpected announcement %+v", announcement)
+	}
+	if announcement.PublishedAt != nil {
+		t.Fatal("an announcement is a draft until it is published")
+	}
+
+	if err := applyAnnouncementUpdate(announcement, AnnouncementUpdate{Publish: true}); err != nil {
+		t.Fatal(err)
+	}
+	publishedAt := announcement.PublishedAt
+	if publishedAt == nil {
+		t.Fatal("expected the announcement to be published")
+	}
+	if err := applyAnnouncementUpdate(announcement, AnnouncementUpdate{Publish: true}); err != nil {
+		t.Fatal(err)
+	}
+	if announcement.PublishedAt != publishedAt {
+		t.Fatal("publishing again must keep the first publication time")
+	}
+}
+
+func TestApplyAnnouncementUpdateRejectsInvalidFields(t *testing.T) {
+	blank, badLink := " ", "javascript:alert(1)"
+	badKind := models.AnnouncementKind("rumour")
+
+	for name, update := range map[string]AnnouncementUpdate{
+		"blank title": {Title: &blank},
+		"blank body":  {Body: &blank},
+		"bad kind":    {Kind: &badKind},
+		"bad link":    {Link: &badLink},
+	} {
+		if err := applyAnnouncementUpdate(&models.Announcement{}, update); !errors.Is(err, ErrInvalidAnnouncement) {
+			t.Errorf("%s: got %v, want ErrInvalidAnnouncement", name, err)
+		}
+	}
+}
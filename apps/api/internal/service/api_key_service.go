@@ -0,0 +1,132 @@
+package service
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base32"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"melina-studio-backend/internal/auth"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidAPIKeyInput = errors.New("invalid API key")
+	ErrAPIKeyNotFound     = errors.New("API key not found")
+	ErrTooManyAPIKeys     = errors.New("you have the most API keys allowed")
+)
+
+const (
+	// maxAPIKeysPerUser caps the keys of one user
+	maxAPIKeysPerUser = 10
+	// apiKeyNameLimit caps a key's name
+	apiKeyNameLimit = 100
+	// apiKeyUsageInterval is how stale a key's last use may get before it is written again
+	apiKeyUsageInterval = time.Minute
+)
+
+// APIKeyService manages the API keys integrations authenticate with and resolves them for the middleware
+type APIKeyService struct {
+	keyRepo  repo.APIKeyRepoInterface
+	authRepo repo.AuthRepoInterface
+}
+
+func NewAPIKeyService(keyRepo repo.APIKeyRepoInterface, authRepo repo.AuthRepoInterface) *APIKeyService {
+	return &APIKeyService{
+		keyRepo:  keyRepo,
+		authRepo: authRepo,
+	}
+}
+
+// List returns the user's keys, without the keys themselves
+func (s *APIKeyService) List(userID uuid.UUID) ([]models.APIKey, error) {
+	keys, err := s.keyRepo.ListKeys(userID)
+	if err != nil {
+		return nil, err
+	}
+	if keys == nil {
+		keys = []models.APIKey{}
+	}
+	return keys, nil
+}
+
+// Create issues a key for the user and returns it with its record; the key can't be read again
+func (s *APIKeyService) Create(userID uuid.UUID, name string) (string, *models.APIKey, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || utf8.RuneCountInString(name) > apiKeyNameLimit {
+		return "", nil, fmt.Errorf("%w: the name must be 1 to %d characters", ErrInvalidAPIKeyInput, apiKeyNameLimit)
+	}
+	count, err := s.keyRepo.CountKeys(userID)
+	if err != nil {
+		return "", nil, err
+	}
+	if count >= maxAPIKeysPerUser {
+		return "", nil, ErrTooManyAPIKeys
+	}
+
+	buf := make([]byte, 20)
+	if _, err := rand.Read(buf); err != nil {
+		return "", nil, err
+	}
+	key := auth.APIKeyPrefix + strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(buf))
+	record := &models.APIKey{
+		UserID:  userID,
+		Name:    name,
+		Prefix:  key[:len(auth.APIKeyPrefix)+6],
+		KeyHash: hashAPIKey(key),
+	}
+	if err := s.keyRepo.CreateKey(record); err != nil {
+		return "", nil, err
+	}
+	return key, record, nil
+}
+
+// Delete revokes one of the user's keys
+func (s *APIKeyService) Delete(userID uuid.UUID, keyID uuid.UUID) error {
+	err := s.keyRepo.DeleteKey(userID, keyID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrAPIKeyNotFound
+	}
+	return err
+}
+
+// Lookup returns the user a key acts as, auth.ErrInvalidAPIKey when the key or its user doesn't exist
+func (s *APIKeyService) Lookup(key string) (*auth.APIKeyOwner, error) {
+	record, err := s.keyRepo.GetByHash(hashAPIKey(key))
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, auth.ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.authRepo.GetUserByID(record.UserID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, auth.ErrInvalidAPIKey
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if now := time.Now(); record.LastUsedAt == nil || now.Sub(*record.LastUsedAt) > apiKeyUsageInterval {
+		if err := s.keyRepo.MarkUsed(record.UUID, now); err != nil {
+			log.Printf("[api-keys] failed to record the use of key %s: %v", record.UUID, err)
+		}
+	}
+	return &auth.APIKeyOwner{UserID: user.UUID.String(), TenantID: user.TenantKey()}, nil
+}
+
+// hashAPIKey is what is stored of a key; keys are random enough that a plain hash can't be reversed
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
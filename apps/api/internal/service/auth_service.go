@@ -17,13 +17,29 @@ func NewAuthService(refreshTokenRepo repo.RefreshTokenRepoInterface) *AuthServic
 }
 
 // CreateAndStoreRefreshToken generates a JWT refresh token and stores metadata in DB
-func (s *AuthService) CreateAndStoreRefreshToken(userID uuid.UUID, userAgent, ipAddress string) (string, error) {
+func (s *AuthService) CreateAndStoreRefreshToken(userID uuid.UUID, tenantID string, userAgent, ipAddress string) (string, error) {
 	// Generate JWT refresh token with unique ID
-	refreshToken, tokenID, err := auth.GenerateRefreshToken(userID.String())
+	refreshToken, tokenID, err := auth.GenerateRefreshToken(userID.String(), tenantID)
 	if err != nil {
 		return "", err
 	}
+	return refreshToken, s.storeRefreshToken(userID, tokenID, userAgent, ipAddress)
+}
+
+// RotateRefreshToken generates and stores the refresh token that replaces a used one, in the same session
+func (s *AuthService) RotateRefreshToken(claims *auth.JWTClaims, userAgent, ipAddress string) (string, error) {
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return "", err
+	}
+	refreshToken, tokenID, err := auth.RenewRefreshToken(claims)
+	if err != nil {
+		return "", err
+	}
+	return refreshToken, s.storeRefreshToken(userID, tokenID, userAgent, ipAddress)
+}
 
+func (s *AuthService) storeRefreshToken(userID uuid.UUID, tokenID string, userAgent, ipAddress string) error {
 	// Store token metadata in DB for revocation tracking
 	refreshTokenModel := &models.RefreshToken{
 		UserID:    userID,
@@ -33,11 +49,7 @@ func (s *AuthService) CreateAndStoreRefreshToken(userID uuid.UUID, userAgent, ip
 		IPAddress: ipAddress,
 	}
 
-	if err := s.refreshTokenRepo.Create(refreshTokenModel); err != nil {
-		return "", err
-	}
-
-	return refreshToken, nil
+	return s.refreshTokenRepo.Create(refreshTokenModel)
 }
 
 // ValidateAndGetToken validates a refresh token JWT and checks if it's revoked
@@ -0,0 +1,694 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+
+	"melina-studio-backend/internal/config"
+	"melina-studio-backend/internal/libraries"
+	llmHandlers "melina-studio-backend/internal/llm_handlers"
+	"melina-studio-backend/internal/melina/agents"
+	"melina-studio-backend/internal/melina/tools"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidAutomation  = errors.New("invalid automation rule")
+	ErrAutomationNotFound = errors.New("automation rule not found")
+	ErrTooManyAutomations = errors.New("the board has the most automation rules allowed")
+)
+
+const (
+	// automationRunHistorySize is how many runs of a rule the log returns
+	automationRunHistorySize = 50
+	// automationOutputLimit caps the output kept with a run
+	automationOutputLimit     = 2000
+	maxAutomationPromptLength = 4000
+)
+
+// AutomationFilters narrow the shapes a rule fires for; an empty filter matches every shape
+type AutomationFilters struct {
+	// ShapeTypes are the shape types the rule fires for, e.g. ["rect", "action_item"]
+	ShapeTypes []string `json:"shape_types,omitempty"`
+	// Tag is a hashtag the shape's text or name must have, with or without the #, matched case-insensitively
+	Tag string `json:"tag,omitempty"`
+	// TextContains must appear in the shape's text or name, case-insensitively
+	TextContains string `json:"text_contains,omitempty"`
+}
+
+// AutomationActionConfig holds the setting of the rule's action
+type AutomationActionConfig struct {
+	// Prompt is what the agent is asked on agent_prompt; {{shape.id}}, {{shape.type}} and {{shape.text}} are filled in
+	Prompt string `json:"prompt,omitempty"`
+	// URL receives the event as a JSON POST on webhook
+	URL string `json:"url,omitempty"`
+	// Frame is the id or name of the frame the shape is moved into on move_to_frame
+	Frame string `json:"frame,omitempty"`
+}
+
+// AutomationRuleInput is a rule as the board owner defines it; a PUT replaces every field
+type AutomationRuleInput struct {
+	Name    string                   `json:"name"`
+	Trigger models.AutomationTrigger `json:"trigger"`
+	Filters AutomationFilters        `json:"filters"`
+	Action  models.AutomationAction  `json:"action"`
+	Config  AutomationActionConfig   `json:"action_config"`
+	// Enabled defaults to true
+	Enabled *bool `json:"enabled"`
+}
+
+// ShapeEvent is a shape a save created or changed
+type ShapeEvent struct {
+	Trigger models.AutomationTrigger
+	Shape   models.BoardData
+}
+
+// AutomationService manages the boards' automation rules and runs them when their owner saves shapes.
+// Runs happen on the job queue and write straight to the database, so the shapes an automation changes
+// never set off another rule
+type AutomationService struct {
+	cfg             config.AutomationConfig
+	db              *gorm.DB
+	automationRepo  repo.AutomationRepoInterface
+	boardRepo       repo.BoardRepoInterface
+	boardDataRepo   repo.BoardDataRepoInterface
+	customRulesRepo repo.CustomRulesRepoInterface
+	tokenRepo       repo.TokenConsumptionRepoInterface
+	jobs            *JobQueue
+	hub             *libraries.Hub
+	webhooks        *tools.HTTPToolExecutor
+
+	mu        sync.Mutex
+	lastFired map[string]time.Time
+}
+
+func NewAutomationService(
+	cfg config.AutomationConfig,
+	db *gorm.DB,
+	automationRepo repo.AutomationRepoInterface,
+	boardRepo repo.BoardRepoInterface,
+	boardDataRepo repo.BoardDataRepoInterface,
+	customRulesRepo repo.CustomRulesRepoInterface,
+	tokenRepo repo.TokenConsumptionRepoInterface,
+	jobs *JobQueue,
+	hub *libraries.Hub,
+) *AutomationService {
+	return &AutomationService{
+		cfg:             cfg,
+		db:              db,
+		automationRepo:  automationRepo,
+		boardRepo:       boardRepo,
+		boardDataRepo:   boardDataRepo,
+		customRulesRepo: customRulesRepo,
+		tokenRepo:       tokenRepo,
+		jobs:            jobs,
+		hub:             hub,
+		webhooks:        tools.NewHTTPToolExecutor(cfg.Webhooks),
+		lastFired:       make(map[string]time.Time),
+	}
+}
+
+// List returns the board's rules
+func (s *AutomationService) List(boardID uuid.UUID) ([]models.AutomationRule, error) {
+	rules, err := s.automationRepo.ListRules(boardID)
+	if err != nil {
+		return nil, err
+	}
+	if rules == nil {
+		rules = []models.AutomationRule{}
+	}
+	return rules, nil
+}
+
+// Create adds a rule to the board, up to MaxRulesPerBoard
+func (s *AutomationService) Create(userID uuid.UUID, boardID uuid.UUID, input AutomationRuleInput) (*models.AutomationRule, error) {
+	count, err := s.automationRepo.CountRules(boardID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= int64(s.cfg.MaxRulesPerBoard) {
+		return nil, fmt.Errorf("%w (%d)", ErrTooManyAutomations, s.cfg.MaxRulesPerBoard)
+	}
+	rule := &models.AutomationRule{BoardID: boardID, UserID: userID}
+	if err := s.apply(rule, input); err != nil {
+		return nil, err
+	}
+	if err := s.automationRepo.CreateRule(rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// Update replaces a rule of the board
+func (s *AutomationService) Update(boardID uuid.UUID, ruleID uuid.UUID, input AutomationRuleInput) (*models.AutomationRule, error) {
+	rule, err := s.getRule(boardID, ruleID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.apply(rule, input); err != nil {
+		return nil, err
+	}
+	if err := s.automationRepo.UpdateRule(rule); err != nil {
+		return nil, err
+	}
+	return rule, nil
+}
+
+// Delete removes a rule of the board with its execution log
+func (s *AutomationService) Delete(boardID uuid.UUID, ruleID uuid.UUID) error {
+	err := s.automationRepo.DeleteRule(boardID, ruleID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrAutomationNotFound
+	}
+	return err
+}
+
+// Runs returns the latest runs of a rule of the board, newest first
+func (s *AutomationService) Runs(boardID uuid.UUID, ruleID uuid.UUID) ([]models.AutomationRun, error) {
+	if _, err := s.getRule(boardID, ruleID); err != nil {
+		return nil, err
+	}
+	runs, err := s.automationRepo.ListRuns(ruleID, automationRunHistorySize)
+	if err != nil {
+		return nil, err
+	}
+	if runs == nil {
+		runs = []models.AutomationRun{}
+	}
+	return runs, nil
+}
+
+func (s *AutomationService) getRule(boardID uuid.UUID, ruleID uuid.UUID) (*models.AutomationRule, error) {
+	rule, err := s.automationRepo.GetRule(boardID, ruleID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrAutomationNotFound
+	}
+	return rule, err
+}
+
+// apply validates the input and copies it onto the rule
+func (s *AutomationService) apply(rule *models.AutomationRule, input AutomationRuleInput) error {
+	input, err := normalizeAutomationInput(input, s.cfg)
+	if err != nil {
+		return err
+	}
+	filters, err := json.Marshal(input.Filters)
+	if err != nil {
+		return err
+	}
+	actionConfig, err := json.Marshal(input.Config)
+	if err != nil {
+		return err
+	}
+	rule.Name = input.Name
+	rule.Trigger = input.Trigger
+	rule.Filters = datatypes.JSON(filters)
+	rule.Action = input.Action
+	rule.ActionConfig = datatypes.JSON(actionConfig)
+	rule.Enabled = input.Enabled == nil || *input.Enabled
+	return nil
+}
+
+// normalizeAutomationInput checks a rule and trims its fields; only the setting of the rule's action is kept
+func normalizeAutomationInput(input AutomationRuleInput, cfg config.AutomationConfig) (AutomationRuleInput, error) {
+	input.Name = strings.TrimSpace(input.Name)
+	if input.Name == "" || len(input.Name) > 100 {
+		return input, fmt.Errorf("%w: name must have 1 to 100 characters", ErrInvalidAutomation)
+	}
+	switch input.Trigger {
+	case models.AutomationTriggerShapeCreated, models.AutomationTriggerShapeUpdated:
+	default:
+		return input, fmt.Errorf("%w: trigger must be %s or %s", ErrInvalidAutomation, models.AutomationTriggerShapeCreated, models.AutomationTriggerShapeUpdated)
+	}
+
+	input.Filters.Tag = strings.TrimPrefix(strings.TrimSpace(input.Filters.Tag), "#")
+	if strings.IndexFunc(input.Filters.Tag, unicode.IsSpace) >= 0 {
+		return input, fmt.Errorf("%w: tag can't have spaces", ErrInvalidAutomation)
+	}
+	input.Filters.TextContains = strings.TrimSpace(input.Filters.TextContains)
+	var shapeTypes []string
+	for _, shapeType := range input.Filters.ShapeTypes {
+		if shapeType = strings.TrimSpace(shapeType); shapeType != "" {
+			shapeTypes = append(shapeTypes, shapeType)
+		}
+	}
+	input.Filters.ShapeTypes = shapeTypes
+
+	action := AutomationActionConfig{}
+	switch input.Action {
+	case models.AutomationActionAgentPrompt:
+		action.Prompt = strings.TrimSpace(input.Config.Prompt)
+		if action.Prompt == "" || len(action.Prompt) > maxAutomationPromptLength {
+			return input, fmt.Errorf("%w: prompt must have 1 to %d characters", ErrInvalidAutomation, maxAutomationPromptLength)
+		}
+	case models.AutomationActionWebhook:
+		action.URL = strings.TrimSpace(input.Config.URL)
+		if err := checkWebhookURL(action.URL, cfg.Webhooks, "AUTOMATION_WEBHOOK_ALLOWED_HOSTS"); err != nil {
+			return input, fmt.Errorf("%w: %v", ErrInvalidAutomation, err)
+		}
+	case models.AutomationActionMoveToFrame:
+		action.Frame = strings.TrimSpace(input.Config.Frame)
+		if action.Frame == "" {
+			return input, fmt.Errorf("%w: frame is required", ErrInvalidAutomation)
+		}
+	default:
+		return input, fmt.Errorf("%w: action must be %s, %s or %s", ErrInvalidAutomation,
+			models.AutomationActionAgentPrompt, models.AutomationActionWebhook, models.AutomationActionMoveToFrame)
+	}
+	input.Config = action
+	return input, nil
+}
+
+// checkWebhookURL refuses URLs the executor would refuse to call, so the owner learns it when saving the rule
+// or schedule; hostsEnv names the setting listing the allowed hosts
+func checkWebhookURL(rawURL string, cfg config.CustomToolConfig, hostsEnv string) error {
+	u, err := url.Parse(rawURL)
+	if err != nil || u.Host == "" {
+		return errors.New("url must be an absolute URL")
+	}
+	if u.Scheme != "https" && !(u.Scheme == "http" && cfg.AllowHTTP) {
+		return errors.New("url must be an https URL")
+	}
+	if strings.ContainsAny(rawURL, "{}") {
+		return errors.New("url can't have placeholders")
+	}
+	if !cfg.AllowsHost(u.Host) {
+		return fmt.Errorf("%s is not in %s", u.Hostname(), hostsEnv)
+	}
+	return nil
+}
+
+// ShapeEvents compares the stored shapes before and after a save: a shape that wasn't stored was created,
+// one whose data changed was updated
+func ShapeEvents(before []models.BoardData, after []models.BoardData) []ShapeEvent {
+	previous := make(map[uuid.UUID]models.BoardData, len(before))
+	for _, shape := range before {
+		previous[shape.UUID] = shape
+	}
+	var events []ShapeEvent
+	for _, shape := range after {
+		old, ok := previous[shape.UUID]
+		switch {
+		case !ok:
+			events = append(events, ShapeEvent{Trigger: models.AutomationTriggerShapeCreated, Shape: shape})
+		case old.Type != shape.Type || !sameShapeData(old.Data, shape.Data):
+			events = append(events, ShapeEvent{Trigger: models.AutomationTriggerShapeUpdated, Shape: shape})
+		}
+	}
+	return events
+}
+
+// sameShapeData compares shape data by value, whatever the key order and spacing
+func sameShapeData(a datatypes.JSON, b datatypes.JSON) bool {
+	if bytes.Equal(a, b) {
+		return true
+	}
+	var left, right interface{}
+	if json.Unmarshal(a, &left) != nil || json.Unmarshal(b, &right) != nil {
+		return false
+	}
+	normalizedLeft, _ := json.Marshal(left)
+	normalizedRight, _ := json.Marshal(right)
+	return bytes.Equal(normalizedLeft, normalizedRight)
+}
+
+// automationShapeText is the text a rule's tag and text filters look at: the shape's text and its name
+func automationShapeText(shape models.BoardData) string {
+	var data map[string]interface{}
+	if json.Unmarshal(shape.Data, &data) != nil {
+		return ""
+	}
+	var parts []string
+	for _, key := range []string{"text", "name"} {
+		if value, ok := data[key].(string); ok && value != "" {
+			parts = append(parts, value)
+		}
+	}
+	return strings.Join(parts, "\n")
+}
+
+// hasHashtag reports whether the text has #tag as a word of its own
+func hasHashtag(text string, tag string) bool {
+	words := strings.FieldsFunc(text, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '#' && r != '_' && r != '-'
+	})
+	for _, word := range words {
+		if strings.EqualFold(word, "#"+tag) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesAutomation reports whether a rule fires for the event
+func MatchesAutomation(trigger models.AutomationTrigger, filters AutomationFilters, event ShapeEvent) bool {
+	if event.Trigger != trigger {
+		return false
+	}
+	if len(filters.ShapeTypes) > 0 {
+		matched := false
+		for _, shapeType := range filters.ShapeTypes {
+			if strings.EqualFold(shapeType, string(event.Shape.Type)) {
+				matched = true
+			}
+		}
+		if !matched {
+			return false
+		}
+	}
+	if filters.Tag == "" && filters.TextContains == "" {
+		return true
+	}
+	text := automationShapeText(event.Shape)
+	if filters.Tag != "" && !hasHashtag(text, filters.Tag) {
+		return false
+	}
+	if filters.TextContains != "" && !strings.Contains(strings.ToLower(text), strings.ToLower(filters.TextContains)) {
+		return false
+	}
+	return true
+}
+
+// expandAutomationPrompt fills the shape placeholders of an agent_prompt rule
+func expandAutomationPrompt(prompt string, shape models.BoardData) string {
+	return strings.NewReplacer(
+		"{{shape.id}}", shape.UUID.String(),
+		"{{shape.type}}", string(shape.Type),
+		"{{shape.text}}", automationShapeText(shape),
+	).Replace(prompt)
+}
+
+// ShapesSaved runs the board's rules for the shapes the owner just saved. before holds the stored versions
+// of the saved shapes and saved what the save sent; only shapes that were created or changed fire rules.
+// Saves by anyone but the board's owner fire nothing, and rules always run as the user who made them.
+// Failures are logged, they never fail the save
+func (s *AutomationService) ShapesSaved(userID uuid.UUID, boardID uuid.UUID, before []models.BoardData, saved []models.Shape) {
+	if s == nil || !s.cfg.Enabled || len(saved) == 0 {
+		return
+	}
+	if err := s.boardRepo.ValidateBoardOwnership(userID, boardID); err != nil {
+		log.Printf("[automation] user %s saved board %s without owning it, no rules run", userID, boardID)
+		return
+	}
+	rules, err := s.automationRepo.ListEnabledRules(boardID)
+	if err != nil {
+		log.Printf("[automation] failed to load rules of board %s: %v", boardID, err)
+		return
+	}
+	if len(rules) == 0 {
+		return
+	}
+
+	ids := make([]uuid.UUID, 0, len(saved))
+	for _, shape := range saved {
+		if id, err := uuid.Parse(shape.ID); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	stored, err := s.boardDataRepo.GetShapesByUUIDs(ids)
+	if err != nil {
+		log.Printf("[automation] failed to load saved shapes of board %s: %v", boardID, err)
+		return
+	}
+	after := stored[:0]
+	for _, shape := range stored {
+		if shape.BoardId == boardID {
+			after = append(after, shape)
+		}
+	}
+	events := ShapeEvents(before, after)
+
+	runs := 0
+	for _, rule := range rules {
+		var filters AutomationFilters
+		if len(rule.Filters) > 0 {
+			if err := json.Unmarshal(rule.Filters, &filters); err != nil {
+				log.Printf("[automation] rule %s has invalid filters: %v", rule.UUID, err)
+				continue
+			}
+		}
+		for _, event := range events {
+			if !MatchesAutomation(rule.Trigger, filters, event) || !s.fire(rule.UUID, event.Shape.UUID) {
+				continue
+			}
+			if runs >= s.cfg.MaxRunsPerSave {
+				log.Printf("[automation] board %s reached %d runs in one save, skipping the rest", boardID, s.cfg.MaxRunsPerSave)
+				return
+			}
+			runs++
+			s.enqueue(rule, event)
+		}
+	}
+}
+
+// fire reports whether the rule may run for the shape, i.e. it didn't within Cooldown, and marks it as run
+func (s *AutomationService) fire(ruleID uuid.UUID, shapeID uuid.UUID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	key := ruleID.String() + "/" + shapeID.String()
+	if last, ok := s.lastFired[key]; ok && now.Sub(last) < s.cfg.Cooldown {
+		return false
+	}
+	if len(s.lastFired) > 1000 {
+		for k, last := range s.lastFired {
+			if now.Sub(last) >= s.cfg.Cooldown {
+				delete(s.lastFired, k)
+			}
+		}
+	}
+	s.lastFired[key] = now
+	return true
+}
+
+// enqueue runs the rule for the event on the job queue, logging a failed run when the queue is full
+func (s *AutomationService) enqueue(rule models.AutomationRule, event ShapeEvent) {
+	err := s.jobs.Enqueue("automation:"+rule.UUID.String(), func(ctx context.Context) error {
+		s.run(ctx, rule, event)
+		return nil
+	})
+	if err != nil {
+		s.record(rule, event, time.Now(), automationResult{}, err)
+	}
+}
+
+// run executes the rule's action as the rule's owner and logs the run
+func (s *AutomationService) run(ctx context.Context, rule models.AutomationRule, event ShapeEvent) {
+	started := time.Now()
+	var actionConfig AutomationActionConfig
+	if err := json.Unmarshal(rule.ActionConfig, &actionConfig); err != nil {
+		s.record(rule, event, started, automationResult{}, fmt.Errorf("invalid action config: %w", err))
+		return
+	}
+
+	var result automationResult
+	var err error
+	switch rule.Action {
+	case models.AutomationActionWebhook:
+		result, err = s.callWebhook(ctx, rule, event, actionConfig.URL)
+	case models.AutomationActionMoveToFrame:
+		result, err = s.moveToFrame(rule.BoardID, event.Shape.UUID, actionConfig.Frame)
+	case models.AutomationActionAgentPrompt:
+		result, err = s.runAgent(ctx, rule.UserID, rule.BoardID, expandAutomationPrompt(actionConfig.Prompt, event.Shape))
+	default:
+		err = fmt.Errorf("unknown action %s", rule.Action)
+	}
+	s.record(rule, event, started, result, err)
+}
+
+// automationResult is what an action did
+type automationResult struct {
+	output string
+	// changed are the shapes the action created or moved
+	changed []string
+	// reloadBoard is set when the agent changed the board in ways changed doesn't list
+	reloadBoard bool
+}
+
+// record stores the run in the rule's log and tells the owner's connections
+func (s *AutomationService) record(rule models.AutomationRule, event ShapeEvent, started time.Time, result automationResult, runErr error) {
+	shapeID := event.Shape.UUID
+	run := &models.AutomationRun{
+		RuleID:     rule.UUID,
+		BoardID:    rule.BoardID,
+		ShapeID:    &shapeID,
+		Trigger:    event.Trigger,
+		Status:     models.AutomationRunSucceeded,
+		Output:     truncateAutomationOutput(result.output),
+		DurationMs: time.Since(started).Milliseconds(),
+	}
+	if runErr != nil {
+		run.Status = models.AutomationRunFailed
+		run.Error = truncateAutomationOutput(runErr.Error())
+		log.Printf("[automation] rule %s failed on shape %s: %v", rule.UUID, shapeID, runErr)
+	}
+	if err := s.automationRepo.CreateRun(run); err != nil {
+		log.Printf("[automation] failed to log run of rule %s: %v", rule.UUID, err)
+	}
+	if s.hub != nil {
+		libraries.SendAutomationRunMessage(s.hub, rule.UserID.String(), &libraries.AutomationRunPayload{
+			BoardId:       rule.BoardID.String(),
+			RuleId:        rule.UUID.String(),
+			RunId:         run.UUID.String(),
+			ShapeId:       shapeID.String(),
+			Status:        string(run.Status),
+			Error:         run.Error,
+			ChangedShapes: result.changed,
+			ReloadBoard:   result.reloadBoard,
+		})
+	}
+}
+
+func truncateAutomationOutput(text string) string {
+	if len(text) > automationOutputLimit {
+		return text[:automationOutputLimit] + "..."
+	}
+	return text
+}
+
+// callWebhook posts the event to the rule's URL within the AUTOMATION_WEBHOOK_* limits
+func (s *AutomationService) callWebhook(ctx context.Context, rule models.AutomationRule, event ShapeEvent, webhookURL string) (automationResult, error) {
+	shape := map[string]interface{}{}
+	if err := json.Unmarshal(event.Shape.Data, &shape); err != nil {
+		return automationResult{}, err
+	}
+	shape["id"] = event.Shape.UUID.String()
+	shape["type"] = string(event.Shape.Type)
+	payload := map[string]interface{}{
+		"event":    string(event.Trigger),
+		"rule":     map[string]interface{}{"id": rule.UUID.String(), "name": rule.Name},
+		"board_id": rule.BoardID.String(),
+		"shape":    shape,
+	}
+	spec := tools.CustomToolSpec{Name: "webhook", Method: http.MethodPost, URL: webhookURL}
+	response, err := s.webhooks.Execute(ctx, spec, payload)
+	if err != nil {
+		return automationResult{}, err
+	}
+	data, _ := json.Marshal(response)
+	return automationResult{output: string(data)}, nil
+}
+
+// moveToFrame moves the shape into the rule's frame, unless it is locked or already there
+func (s *AutomationService) moveToFrame(boardID uuid.UUID, shapeID uuid.UUID, frame string) (automationResult, error) {
+	shapes, err := s.boardDataRepo.GetBoardData(boardID)
+	if err != nil {
+		return automationResult{}, err
+	}
+	for _, shape := range shapes {
+		if shape.UUID == shapeID && shape.Locked {
+			return automationResult{}, errors.New("the shape is locked")
+		}
+	}
+	move, err := tools.MoveIntoFrame(shapes, shapeID, frame)
+	if err != nil {
+		return automationResult{}, err
+	}
+	if move.Data == nil {
+		return automationResult{output: fmt.Sprintf("Already in %q", move.FrameName)}, nil
+	}
+	if err := s.boardDataRepo.UpdateShapeData(boardID, shapeID, move.Data); err != nil {
+		return automationResult{}, err
+	}
+	return automationResult{output: fmt.Sprintf("Moved into %q", move.FrameName), changed: []string{shapeID.String()}}, nil
+}
+
+// runAgent asks the board's agent the prompt with the default model, as if the owner had sent it without
+// chat history, and saves the shapes it creates. The tokens count against the owner's limit
+func (s *AutomationService) runAgent(ctx context.Context, userID uuid.UUID, boardID uuid.UUID, prompt string) (automationResult, error) {
+	allowed, _, _, _, err := CheckTokenLimitBeforeRequest(s.db, userID)
+	if err != nil {
+		return automationResult{}, fmt.Errorf("failed to check the token limit: %w", err)
+	}
+	if !allowed {
+		return automationResult{}, errors.New("the board owner has used their token limit")
+	}
+
+	board, err := s.boardRepo.GetBoardById(userID, boardID)
+	if err != nil {
+		return automationResult{}, fmt.Errorf("failed to load the board: %w", err)
+	}
+	modelName := llmHandlers.DefaultModel()
+	modelInfo, err := llmHandlers.ValidateModel(modelName)
+	if err != nil {
+		return automationResult{}, err
+	}
+	profile, err := llmHandlers.GetAgentProfile(board.AgentProfile)
+	if err != nil {
+		profile, _ = llmHandlers.GetAgentProfile(llmHandlers.DefaultAgentProfile)
+	}
+	agent, err := agents.NewToolAgent(modelInfo, nil, nil, profile)
+	if err != nil {
+		return automationResult{}, err
+	}
+
+	shapes, err := s.boardDataRepo.GetBoardData(boardID)
+	if err != nil {
+		return automationResult{}, fmt.Errorf("failed to read the board: %w", err)
+	}
+	var canvasStateXML string
+	if len(shapes) > 0 {
+		if state := tools.GenerateCanvasState(shapes, 50.0, 100.0); state != nil {
+			canvasStateXML = tools.FormatCanvasStateXML(state)
+		}
+	}
+	customRules, err := s.customRulesRepo.GetFormattedCustomRules(userID)
+	if err != nil {
+		log.Printf("[automation] failed to get custom rules of user %s: %v", userID, err)
+	}
+
+	runCtx, cancel := context.WithTimeout(ctx, s.cfg.AgentTimeout)
+	defer cancel()
+	runCtx, activity := llmHandlers.WithToolActivity(runCtx)
+	runCtx = WithLLMPrivacy(runCtx, userID, llmHandlers.DataClassBoard)
+
+	// nobody watches the run's stream, the relay client just drains it
+	relay, stop := libraries.NewRelayClient(userID.String(), func(string) {})
+	resp, err := agent.ProcessRequestStreamWithUsage(runCtx, libraries.NewHub(), relay, prompt, nil, boardID.String(), "",
+		nil, nil, false, canvasStateXML, customRules, "", "")
+	stop()
+	if err != nil {
+		return automationResult{}, fmt.Errorf("agent run failed: %w", err)
+	}
+	if resp.TokenUsage != nil {
+		if err := s.tokenRepo.CreateFromUsage(userID, &boardID, nil, string(modelInfo.Provider), modelName, resp.TokenUsage); err != nil {
+			log.Printf("[automation] failed to record token consumption: %v", err)
+		}
+		if err := IncrementUserTokens(s.db, userID, resp.TokenUsage.TotalTokens); err != nil {
+			log.Printf("[automation] failed to increment user tokens: %v", err)
+		}
+	}
+
+	// the tools leave saving new shapes to the client, which isn't there
+	result := automationResult{output: resp.Text, reloadBoard: tools.ChangedDrawing(activity.Results())}
+	stored, err := s.boardDataRepo.GetBoardData(boardID)
+	if err != nil {
+		return result, fmt.Errorf("failed to read the board: %w", err)
+	}
+	for _, shape := range tools.SnapshotShapes(stored, activity.CreatedShapes())[len(stored):] {
+		shape.BoardId = boardID
+		if shape.AnnotationNumber, err = s.boardDataRepo.GetNextAnnotationNumber(boardID); err != nil {
+			return result, err
+		}
+		if err := s.boardDataRepo.CreateBoardData(&shape); err != nil {
+			return result, fmt.Errorf("failed to save a created shape: %w", err)
+		}
+		result.changed = append(result.changed, shape.UUID.String())
+	}
+	return result, nil
+}
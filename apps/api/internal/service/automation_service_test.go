@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"melina-studio-backend/internal/config"
+	"melina-studio-backend/internal/libraries"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+func TestShapeEvents(t *testing.T) {
+	kept, moved, reordered, added := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	before := []models.BoardData{
+		storedShape(kept, "rect", `{"x":1,"y":2}`),
+		storedShape(moved, "rect", `{"x":1,"y":2}`),
+		storedShape(reordered, "text", `{"text":"Hi","x":1}`),
+	}
+	after := []models.BoardData{
+		storedShape(kept, "rect", `{"x":1,"y":2}`),
+		storedShape(moved, "rect", `{"x":5,"y":2}`),
+		storedShape(reordered, "text", `{"x": 1, "text": "Hi"}`),
+		storedShape(added, "circle", `{"r":3}`),
+	}
+
+	events := ShapeEvents(before, after)
+	if len(events) != 2 {
+		t.Fatalf("expected the moved and the added shape, got %+v", events)
+	}
+	if events[0].Shape.UUID != moved || events[0].Trigger != models.AutomationTriggerShapeUpdated {
+		t.Errorf("expected the moved shape to be updated, got %+v", events[0])
+	}
+	if events[1].Shape.UUID != added || events[1].Trigger != models.AutomationTriggerShapeCreated {
+		t.Errorf("expected the added shape to be created, got %+v", events[1])
+	}
+}
+
+func TestMatchesAutomation(t *testing.T) {
+	note := ShapeEvent{Trigger: models.AutomationTriggerShapeCreated, Shape: storedShape(uuid.New(), "text", `{"text":"Fix login #Bug, asap"}`)}
+	frame := ShapeEvent{Trigger: models.AutomationTriggerShapeCreated, Shape: storedShape(uuid.New(), "frame", `{"name":"#bugs"}`)}
+	created := models.AutomationTriggerShapeCreated
+
+	cases := []struct {
+		name    string
+		trigger models.AutomationTrigger
+		filters AutomationFilters
+		event   ShapeEvent
+		want    bool
+	}{
+		{"no filters", created, AutomationFilters{}, note, true},
+		{"other trigger", models.AutomationTriggerShapeUpdated, AutomationFilters{}, note, false},
+		{"tag in text", created, AutomationFilters{Tag: "bug"}, note, true},
+		{"longer tag", created, AutomationFilters{Tag: "bug"}, frame, false},
+		{"tag in name", created, AutomationFilters{Tag: "BUGS"}, frame, true},
+		{"shape type", created, AutomationFilters{ShapeTypes: []string{"rect", "text"}, Tag: "bug"}, note, true},
+		{"other shape type", created, AutomationFilters{ShapeTypes: []string{"rect"}}, note, false},
+		{"text contains", created, AutomationFilters{TextContains: "LOGIN"}, note, true},
+		{"text missing", created, AutomationFilters{Tag: "bug", TextContains: "signup"}, note, false},
+	}
+	for _, c := range cases {
+		if got := MatchesAutomation(c.trigger, c.filters, c.event); got != c.want {
+			t.Errorf("%s: got %v, want %v", c.name, got, c.want)
+		}
+	}
+}
+
+func TestNormalizeAutomationInput(t *testing.T) {
+	cfg := config.AutomationConfig{Webhooks: config.CustomToolConfig{AllowedHosts: []string{"hooks.example.com"}}}
+	valid := AutomationRuleInput{
+		Name:    " Triage bugs ",
+		Trigger: models.AutomationTriggerShapeCreated,
+		Filters: AutomationFilters{Tag: " #bug "},
+		Action:  models.AutomationActionMoveToFrame,
+		Config:  AutomationActionConfig{Frame: "Bugs", Prompt: "left over"},
+	}
+	input, err := normalizeAutomationInput(valid, cfg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if input.Name != "Triage bugs" || input.Filters.Tag != "bug" || input.Config != (AutomationActionConfig{Frame: "Bugs"}) {
+		t.Errorf("expected a trimmed rule keeping only the frame, got %+v", input)
+	}
+
+	webhook := valid
+	webhook.Action = models.AutomationActionWebhook
+	webhook.Config = AutomationActionConfig{URL: "https://hooks.example.com/melina"}
+	if _, err := normalizeAutomationInput(webhook, cfg); err != nil {
+		t.Errorf("expected an allowed webhook to pass, got %v", err)
+	}
+
+	invalid := map[string]func(*AutomationRuleInput){
+		"no name":        func(in *AutomationRuleInput) { in.Name = " " },
+		"bad trigger":    func(in *AutomationRuleInput) { in.Trigger = "shape_deleted" },
+		"tag with space": func(in *AutomationRuleInput) { in.Filters.Tag = "two words" },
+		"bad action":     func(in *AutomationRuleInput) { in.Action = "email" },
+		"no frame":       func(in *AutomationRuleInput) { in.Config.Frame = "" },
+		"no prompt": func(in *AutomationRuleInput) {
+			in.Action, in.Config.Prompt = models.AutomationActionAgentPrompt, " "
+		},
+		"host not allowed": func(in *AutomationRuleInput) {
+			in.Action, in.Config.URL = models.AutomationActionWebhook, "https://evil.example.org/hook"
+		},
+		"plain http": func(in *AutomationRuleInput) {
+			in.Action, in.Config.URL = models.AutomationActionWebhook, "http://hooks.example.com/melina"
+		},
+	}
+	for name, change := range invalid {
+		input := valid
+		change(&input)
+		if _, err := normalizeAutomationInput(input, cfg); !errors.Is(err, ErrInvalidAutomation) {
+			t.Errorf("%s: expected ErrInvalidAutomation, got %v", name, err)
+		}
+	}
+}
+
+func TestExpandAutomationPrompt(t *testing.T) {
+	id := uuid.New()
+	shape := storedShape(id, "text", `{"text":"Fix login #bug"}`)
+	got := expandAutomationPrompt("Triage {{shape.type}} {{shape.id}}: {{shape.text}}", shape)
+	if want := "Triage text " + id.String() + ": Fix login #bug"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+type fakeAutomationRepo struct {
+	repo.AutomationRepoInterface
+	rules []models.AutomationRule
+	runs  []*models.AutomationRun
+}
+
+func (r *fakeAutomationRepo) ListEnabledRules(boardID uuid.UUID) ([]models.AutomationRule, error) {
+	return r.rules, nil
+}
+
+func (r *fakeAutomationRepo) CreateRun(run *models.AutomationRun) error {
+	r.runs = append(r.runs, run)
+	return nil
+}
+
+type fakeAutomationBoardRepo struct {
+	repo.BoardRepoInterface
+	owners map[uuid.UUID]uuid.UUID
+}
+
+func (r *fakeAutomationBoardRepo) ValidateBoardOwnership(userID uuid.UUID, boardId uuid.UUID) error {
+	if r.owners[boardId] != userID {
+		return errors.New("board not found")
+	}
+	return nil
+}
+
+type fakeAutomationBoardDataRepo struct {
+	repo.BoardDataRepoInterface
+	shapes []models.BoardData
+}
+
+func (r *fakeAutomationBoardDataRepo) GetShapesByUUIDs(ids []uuid.UUID) ([]models.BoardData, error) {
+	return r.shapes, nil
+}
+
+func TestShapesSavedRunsRulesAsTheirOwner(t *testing.T) {
+	owner, stranger, boardID, shapeID := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	shape := storedShape(shapeID, "text", `{"text":"Fix login #bug"}`)
+	shape.BoardId = boardID
+	automations := &fakeAutomationRepo{rules: []models.AutomationRule{{
+		UUID:    uuid.New(),
+		BoardID: boardID,
+		UserID:  owner,
+		Enabled: true,
+		Trigger: models.AutomationTriggerShapeCreated,
+		Action:  models.AutomationActionMoveToFrame,
+		// a broken config fails the run right away, which is all the test needs to see who it ran as
+		ActionConfig: datatypes.JSON(`not json`),
+	}}}
+	jobs := NewJobQueue(config.JobQueueConfig{Workers: 1, Capacity: 1})
+	hub := &libraries.Hub{Direct: make(chan libraries.DirectMessage, 1)}
+	s := NewAutomationService(
+		config.AutomationConfig{Enabled: true, MaxRunsPerSave: 5, Cooldown: time.Minute},
+		nil,
+		automations,
+		&fakeAutomationBoardRepo{owners: map[uuid.UUID]uuid.UUID{boardID: owner}},
+		&fakeAutomationBoardDataRepo{shapes: []models.BoardData{shape}},
+		nil, nil, jobs, hub,
+	)
+	saved := []models.Shape{{ID: shapeID.String(), Type: "text"}}
+
+	// someone else saving to the board must not fire the owner's rules
+	s.ShapesSaved(stranger, boardID, nil, saved)
+	if len(jobs.jobs) != 0 {
+		t.Fatal("expected a save by another user to fire no rules")
+	}
+
+	s.ShapesSaved(owner, boardID, nil, saved)
+	if len(jobs.jobs) != 1 {
+		t.Fatalf("expected the owner's save to queue one run, got %d", len(jobs.jobs))
+	}
+	job := <-jobs.jobs
+	if err := job.run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	if len(automations.runs) != 1 || automations.runs[0].Status != models.AutomationRunFailed || *automations.runs[0].ShapeID != shapeID {
+		t.Fatalf("expected one failed run to be logged, got %+v", automations.runs)
+	}
+	if notice := <-hub.Direct; notice.UserID != owner.String() {
+		t.Errorf("expected the rule's owner to be told about the run, got %s", notice.UserID)
+	}
+}
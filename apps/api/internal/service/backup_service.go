@@ -0,0 +1,343 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"melina-studio-backend/internal/config"
+	"melina-studio-backend/internal/libraries"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+	"net/url"
+	"path"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrBackupStorageUnavailable = errors.New("backup storage is not configured")
+	ErrInvalidBackupID          = errors.New("invalid backup id")
+	ErrInvalidBackup            = errors.New("invalid backup")
+)
+
+// backupIDLayout makes backup IDs sortable timestamps
+const backupIDLayout = "20060102T150405Z"
+
+// BackupService dumps a user's boards, shapes, chats and stored assets to a bucket and restores them
+type BackupService struct {
+	config     config.BackupConfig
+	backupRepo repo.BackupRepoInterface
+	gcsClient  *libraries.Clients
+	stopChan   chan struct{}
+	doneChan   chan struct{}
+}
+
+// NewBackupService creates a new backup service
+func NewBackupService(cfg config.BackupConfig, backupRepo repo.BackupRepoInterface, gcsClient *libraries.Clients) *BackupService {
+	return &BackupService{
+		config:     cfg,
+		backupRepo: backupRepo,
+		gcsClient:  gcsClient,
+		stopChan:   make(chan struct{}),
+		doneChan:   make(chan struct{}),
+	}
+}
+
+// Start launches the scheduled backup goroutine
+func (s *BackupService) Start() {
+	if !s.config.Enabled {
+		log.Println("Scheduled backups are disabled")
+		return
+	}
+
+	go s.runBackupLoop()
+	log.Printf("Backup service started (interval: %v, keep: %d)", s.config.Interval, s.config.Keep)
+}
+
+// Stop gracefully shuts down the backup service
+func (s *BackupService) Stop() {
+	if !s.config.Enabled {
+		return
+	}
+
+	log.Println("Stopping backup service...")
+	close(s.stopChan)
+	<-s.doneChan
+	log.Println("Backup service stopped")
+}
+
+func (s *BackupService) runBackupLoop() {
+	defer close(s.doneChan)
+
+	ticker := time.NewTicker(s.config.Interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.backupAll()
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+// backupAll backs up every user that owns boards and prunes their old backups
+func (s *BackupService) backupAll() {
+	ctx := context.Background()
+
+	userIDs, err := s.backupRepo.ListUserIDsWithBoards()
+	if err != nil {
+		log.Printf("Backup: failed to list users: %v", err)
+		return
+	}
+
+	for _, userID := range userIDs {
+		info, err := s.Backup(ctx, userID)
+		if err != nil {
+			log.Printf("Backup: failed for user %s: %v", userID, err)
+			continue
+		}
+		log.Printf("Backup: stored %s (%d boards, %d shapes, %d chats, %d assets)", info.Location, info.Boards, info.Shapes, info.Chats, info.Assets)
+
+		if err := s.prune(ctx, userID); err != nil {
+			log.Printf("Backup: failed to prune old backups of user %s: %v", userID, err)
+		}
+	}
+}
+
+// Backup dumps the user's data and copies the assets it references into the backup bucket
+func (s *BackupService) Backup(ctx context.Context, userID uuid.UUID) (*models.BackupInfo, error) {
+	if err := s.checkStorage(); err != nil {
+		return nil, err
+	}
+
+	backup, err := s.backupRepo.LoadTenant(userID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load tenant data: %w", err)
+	}
+	createdAt := time.Now().UTC()
+	backup.Version = models.TenantBackupVersion
+	backup.ID = createdAt.Format(backupIDLayout)
+	backup.CreatedAt = createdAt
+
+	root := s.backupRoot(userID, backup.ID)
+	gcp := config.GetSettings().GCP
+	sourceBucket := gcp.StorageBucket
+	for _, objectKey := range collectAssetKeys(backup, gcp.GCSBaseURL, sourceBucket) {
+		backupKey := path.Join(root, "assets", objectKey)
+		if err := s.gcsClient.CopyObject(ctx, sourceBucket, objectKey, s.config.Bucket, backupKey); err != nil {
+			// a missing asset must not lose the rest of the backup
+			log.Printf("Backup: skipping asset %s: %v", objectKey, err)
+			continue
+		}
+		backup.Assets = append(backup.Assets, models.BackupAsset{ObjectKey: objectKey, BackupKey: backupKey})
+	}
+
+	data, err := json.Marshal(backup)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode backup: %w", err)
+	}
+	if err := s.gcsClient.WriteObject(ctx, s.config.Bucket, path.Join(root, "backup.json"), bytes.NewReader(data), "application/json"); err != nil {
+		return nil, err
+	}
+
+	return s.info(backup), nil
+}
+
+// List returns the IDs of the user's backups, newest first
+func (s *BackupService) List(ctx context.Context, userID uuid.UUID) ([]string, error) {
+	if err := s.checkStorage(); err != nil {
+		return nil, err
+	}
+	prefixes, err := s.gcsClient.ListPrefixes(ctx, s.config.Bucket, s.backupRoot(userID, "")+"/")
+	if err != nil {
+		return nil, err
+	}
+
+	ids := make([]string, 0, len(prefixes))
+	for _, prefix := range prefixes {
+		id := path.Base(strings.TrimSuffix(prefix, "/"))
+		if _, err := time.Parse(backupIDLayout, id); err == nil {
+			ids = append(ids, id)
+		}
+	}
+	sort.Sort(sort.Reverse(sort.StringSlice(ids)))
+	return ids, nil
+}
+
+// Restore writes a backup of the user back: rows are upserted and assets are copied back to their original keys
+func (s *BackupService) Restore(ctx context.Context, userID uuid.UUID, backupID string) (*models.BackupInfo, error) {
+	if err := s.checkStorage(); err != nil {
+		return nil, err
+	}
+	if _, err := time.Parse(backupIDLayout, backupID); err != nil {
+		return nil, ErrInvalidBackupID
+	}
+
+	data, err := s.gcsClient.ReadObject(ctx, s.config.Bucket, path.Join(s.backupRoot(userID, backupID), "backup.json"))
+	if err != nil {
+		return nil, err
+	}
+	var backup models.TenantBackup
+	if err := json.Unmarshal(data, &backup); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidBackup, err)
+	}
+	if err := validateTenantBackup(&backup, userID); err != nil {
+		return nil, err
+	}
+
+	if err := s.backupRepo.RestoreTenant(&backup); err != nil {
+		return nil, fmt.Errorf("failed to restore tenant data: %w", err)
+	}
+
+	for _, asset := range backup.Assets {
+		content, err := s.gcsClient.ReadObject(ctx, s.config.Bucket, asset.BackupKey)
+		if err != nil {
+			log.Printf("Restore: skipping asset %s: %v", asset.ObjectKey, err)
+			continue
+		}
+		if _, err := s.gcsClient.Upload(ctx, asset.ObjectKey, bytes.NewReader(content), ""); err != nil {
+			log.Printf("Restore: failed to restore asset %s: %v", asset.ObjectKey, err)
+		}
+	}
+
+	return s.info(&backup), nil
+}
+
+// prune removes the user's backups beyond the configured number to keep
+func (s *BackupService) prune(ctx context.Context, userID uuid.UUID) error {
+	ids, err := s.List(ctx, userID)
+	if err != nil {
+		return err
+	}
+	for i := s.config.Keep; i < len(ids); i++ {
+		if err := s.gcsClient.RemovePrefix(ctx, s.config.Bucket, s.backupRoot(userID, ids[i])+"/"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *BackupService) checkStorage() error {
+	if s.gcsClient == nil || s.config.Bucket == "" {
+		return ErrBackupStorageUnavailable
+	}
+	return nil
+}
+
+func (s *BackupService) backupRoot(userID uuid.UUID, backupID string) string {
+	return path.Join(s.config.Prefix, userID.String(), backupID)
+}
+
+func (s *BackupService) info(backup *models.TenantBackup) *models.BackupInfo {
+	return &models.BackupInfo{
+		ID:        backup.ID,
+		UserID:    backup.UserID,
+		CreatedAt: backup.CreatedAt,
+		Boards:    len(backup.Boards),
+		Shapes:    len(backup.BoardData),
+		Chats:     len(backup.Chats),
+		Assets:    len(backup.Assets),
+		Location:  fmt.Sprintf("gs://%s/%s", s.config.Bucket, s.backupRoot(backup.UserID, backup.ID)),
+	}
+}
+
+// validateTenantBackup makes sure a backup only touches the given user's boards before it is restored
+func validateTenantBackup(backup *models.TenantBackup, userID uuid.UUID) error {
+	if backup.Version != models.TenantBackupVersion {
+		return fmt.Errorf("%w: unsupported version %d", ErrInvalidBackup, backup.Version)
+	}
+	if backup.UserID != userID {
+		return fmt.Errorf("%w: backup belongs to another user", ErrInvalidBackup)
+	}
+
+	boards := make(map[uuid.UUID]bool, len(backup.Boards))
+	for _, board := range backup.Boards {
+		if board.UserID != userID {
+			return fmt.Errorf("%w: board %s belongs to another user", ErrInvalidBackup, board.UUID)
+		}
+		boards[board.UUID] = true
+	}
+	for _, shape := range backup.BoardData {
+		if !boards[shape.BoardId] {
+			return fmt.Errorf("%w: shape %s references an unknown board", ErrInvalidBackup, shape.UUID)
+		}
+	}
+	for _, chat := range backup.Chats {
+		if !boards[chat.BoardUUID] {
+			return fmt.Errorf("%w: chat %s references an unknown board", ErrInvalidBackup, chat.UUID)
+		}
+	}
+	return nil
+}
+
+// collectAssetKeys returns the storage keys of the bucket objects referenced by a tenant's boards and shapes
+func collectAssetKeys(backup *models.TenantBackup, baseURL string, bucket string) []string {
+	seen := map[string]bool{}
+	var keys []string
+	add := func(raw string) {
+		if key, ok := assetObjectKey(raw, baseURL, bucket); ok && !seen[key] {
+			seen[key] = true
+			keys = append(keys, key)
+		}
+	}
+
+	for _, board := range backup.Boards {
+		add(board.Thumbnail)
+	}
+	for _, shape := range backup.BoardData {
+		if shape.ImageUrl != nil {
+			add(*shape.ImageUrl)
+		}
+		var data interface{}
+		if err := json.Unmarshal(shape.Data, &data); err == nil {
+			walkStrings(data, add)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// assetObjectKey extracts the object key from a public URL of the bucket ({base}/{bucket}/{key})
+func assetObjectKey(raw string, baseURL string, bucket string) (string, bool) {
+	if baseURL == "" || bucket == "" {
+		return "", false
+	}
+	prefix := strings.TrimSuffix(baseURL, "/") + "/" + bucket + "/"
+	if !strings.HasPrefix(raw, prefix) {
+		return "", false
+	}
+	key := strings.TrimPrefix(raw, prefix)
+	if i := strings.IndexAny(key, "?#"); i >= 0 {
+		key = key[:i]
+	}
+	if unescaped, err := url.PathUnescape(key); err == nil {
+		key = unescaped
+	}
+	if key == "" || strings.Contains(key, "..") {
+		return "", false
+	}
+	return key, true
+}
+
+func walkStrings(value interface{}, visit func(string)) {
+	switch v := value.(type) {
+	case string:
+		visit(v)
+	case []interface{}:
+		for _, item := range v {
+			walkStrings(item, visit)
+		}
+	case map[string]interface{}:
+		for _, item := range v {
+			walkStrings(item, visit)
+		}
+	}
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"errors"
+	"melina-studio-backend/internal/models"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+func TestAssetObjectKey(t *testing.T) {
+	base, bucket := "https://storage.googleapis.com", "melina"
+	tests := []struct {
+		raw  string
+		key  string
+		want bool
+	}{
+		{"https://storage.googleapis.com/melina/board/shape.png", "board/shape.png", true},
+		{"https://storage.googleapis.com/melina/a%20b.png?v=2", "a b.png", true},
+		{"https://storage.googleapis.com/other/x.png", "", false},
+		{"https://storage.googleapis.com/melina/../x.png", "", false},
+		{"https://example.com/x.png", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		key, ok := assetObjectKey(tt.raw, base, bucket)
+		if ok != tt.want || key != tt.key {
+			t.Errorf("%q: got (%q, %v), want (%q, %v)", tt.raw, key, ok, tt.key, tt.want)
+		}
+	}
+}
+
+func TestCollectAssetKeys(t *testing.T) {
+	base, bucket := "https://cdn.test", "melina"
+	imageURL := "https://cdn.test/melina/board/selection.png"
+	backup := &models.TenantBackup{
+		Boards: []models.Board{{Thumbnail: "https://cdn.test/melina/board.png"}, {Thumbnail: ""}},
+		BoardData: []models.BoardData{
+			{ImageUrl: &imageURL, Data: datatypes.JSON(`{"src":"https://cdn.test/melina/upload/1.png","nested":{"list":["https://cdn.test/melina/board.png"]}}`)},
+		},
+	}
+
+	got := collectAssetKeys(backup, base, bucket)
+	want := []string{"board.png", "board/selection.png", "upload/1.png"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestValidateTenantBackup(t *testing.T) {
+	userID, boardID := uuid.New(), uuid.New()
+	valid := func() *models.TenantBackup {
+		return &models.TenantBackup{
+			Version:   models.TenantBackupVersion,
+			UserID:    userID,
+			Boards:    []models.Board{{UUID: boardID, UserID: userID}},
+			BoardData: []models.BoardData{{UUID: uuid.New(), BoardId: boardID}},
+			Chats:     []models.Chat{{UUID: uuid.New(), BoardUUID: boardID}},
+		}
+	}
+
+	if err := validateTenantBackup(valid(), userID); err != nil {
+		t.Fatalf("expected valid backup, got %v", err)
+	}
+
+	otherUser := valid()
+	otherUser.Boards[0].UserID = uuid.New()
+	strayShape := valid()
+	strayShape.BoardData[0].BoardId = uuid.New()
+	strayChat := valid()
+	strayChat.Chats[0].BoardUUID = uuid.New()
+	oldVersion := valid()
+	oldVersion.Version = 0
+
+	for name, backup := range map[string]*models.TenantBackup{
+		"board of another user":  otherUser,
+		"shape of unknown board": strayShape,
+		"chat of unknown board":  strayChat,
+		"unsupported version":    oldVersion,
+	} {
+		if err := validateTenantBackup(backup, userID); !errors.Is(err, ErrInvalidBackup) {
+			t.Errorf("%s: expected ErrInvalidBackup, got %v", name, err)
+		}
+	}
+	if err := validateTenantBackup(valid(), uuid.New()); !errors.Is(err, ErrInvalidBackup) {
+		t.Errorf("restoring into another user should fail, got %v", err)
+	}
+}
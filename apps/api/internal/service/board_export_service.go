@@ -0,0 +1,105 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"image"
+	"log"
+	"melina-studio-backend/internal/config"
+	"melina-studio-backend/internal/melina/tools"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+	"os"
+	"path/filepath"
+
+	"github.com/google/uuid"
+)
+
+// ErrBoardPreviewMissing is returned when the board has no saved preview to export
+var ErrBoardPreviewMissing = errors.New("board has no saved preview")
+
+// BoardExportService exports board images, watermarked for the plans whose exports carry it
+type BoardExportService struct {
+	config     config.ExportConfig
+	authRepo   repo.AuthRepoInterface
+	watermark  tools.Watermark
+	previewDir string
+}
+
+func NewBoardExportService(cfg config.ExportConfig, authRepo repo.AuthRepoInterface) *BoardExportService {
+	watermark := tools.Watermark{
+		Text:     cfg.WatermarkText,
+		Position: cfg.WatermarkPosition,
+		Opacity:  cfg.WatermarkOpacity,
+	}
+	if cfg.WatermarkImage != "" {
+		logo, err := loadWatermarkLogo(cfg.WatermarkImage)
+		if err != nil {
+			log.Printf("Export watermark: %v, using the text instead", err)
+		}
+		watermark.Logo = logo
+	}
+	return &BoardExportService{
+		config:     cfg,
+		authRepo:   authRepo,
+		watermark:  watermark,
+		previewDir: "temp/images",
+	}
+}
+
+// ExportPNG returns the board's preview as a PNG, reporting whether the user's plan had it watermarked
+func (s *BoardExportService) ExportPNG(userID uuid.UUID, boardID uuid.UUID) ([]byte, bool, error) {
+	preview, err := os.ReadFile(filepath.Join(s.previewDir, boardID.String()+".png"))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, false, ErrBoardPreviewMissing
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	user, err := s.authRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, false, err
+	}
+	if !s.config.Watermarked(user.Subscription) {
+		return preview, false, nil
+	}
+
+	watermarked, err := tools.ApplyWatermark(preview, s.watermark)
+	if err != nil {
+		return nil, false, err
+	}
+	return watermarked, true, nil
+}
+
+// ExportSVG renders the shapes as an SVG, with the watermark text for the plans whose exports carry it;
+// the logo isn't embedded, the text is used instead
+func (s *BoardExportService) ExportSVG(userID uuid.UUID, shapes []models.BoardData) ([]byte, bool, error) {
+	user, err := s.authRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, false, err
+	}
+	watermark := ""
+	if s.config.Watermarked(user.Subscription) {
+		watermark = s.watermark.Text
+	}
+	svg, err := tools.RenderBoardSVG(shapes, watermark)
+	if err != nil {
+		return nil, false, err
+	}
+	return svg, watermark != "", nil
+}
+
+// loadWatermarkLogo reads the watermark logo once at startup rather than on every export
+func loadWatermarkLogo(path string) (image.Image, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open watermark image: %w", err)
+	}
+	defer file.Close()
+	logo, _, err := image.Decode(file)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decode watermark image %s: %w", path, err)
+	}
+	return logo, nil
+}
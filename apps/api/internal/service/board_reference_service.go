@@ -0,0 +1,71 @@
+package service
+
+import (
+	"fmt"
+	"log"
+	"melina-studio-backend/internal/melina/tools"
+	"melina-studio-backend/internal/repo"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+// maxReferencedBoards caps how many boards can be attached to a single chat turn
+const maxReferencedBoards = 3
+
+type BoardReferenceService struct {
+	boardRepo     repo.BoardRepoInterface
+	boardDataRepo repo.BoardDataRepoInterface
+}
+
+func NewBoardReferenceService(boardRepo repo.BoardRepoInterface, boardDataRepo repo.BoardDataRepoInterface) *BoardReferenceService {
+	return &BoardReferenceService{
+		boardRepo:     boardRepo,
+		boardDataRepo: boardDataRepo,
+	}
+}
+
+// BuildReferenceContext renders the outlines of the referenced boards the user owns and returns their thumbnail URLs
+// Boards the user doesn't own, deleted boards and the current board are skipped
+func (s *BoardReferenceService) BuildReferenceContext(userID uuid.UUID, currentBoardID uuid.UUID, boardIDs []string) (string, []string) {
+	var blocks []string
+	var thumbnails []string
+	seen := map[uuid.UUID]bool{currentBoardID: true}
+
+	for _, idStr := range boardIDs {
+		if len(blocks) == maxReferencedBoards {
+			log.Printf("Ignoring referenced boards beyond the first %d", maxReferencedBoards)
+			break
+		}
+		boardID, err := uuid.Parse(idStr)
+		if err != nil || seen[boardID] {
+			continue
+		}
+		seen[boardID] = true
+
+		board, err := s.boardRepo.GetBoardById(userID, boardID)
+		if err != nil || board.IsDeleted {
+			log.Printf("Skipping referenced board %s: not accessible to user %s", boardID, userID)
+			continue
+		}
+		shapes, err := s.boardDataRepo.GetBoardData(boardID)
+		if err != nil {
+			log.Printf("Skipping referenced board %s: %v", boardID, err)
+			continue
+		}
+
+		blocks = append(blocks, tools.FormatReferencedBoard(board, shapes))
+		if board.Thumbnail != "" {
+			thumbnails = append(thumbnails, board.Thumbnail)
+		}
+	}
+
+	if len(blocks) == 0 {
+		return "", nil
+	}
+	intro := "The user attached these boards as read-only reference. Keep the current board consistent with them where it makes sense; never modify them."
+	if len(thumbnails) > 0 {
+		intro += " Their thumbnails are attached as images."
+	}
+	return fmt.Sprintf("<REFERENCED_BOARDS>\n  %s\n%s\n</REFERENCED_BOARDS>", intro, strings.Join(blocks, "\n")), thumbnails
+}
@@ -0,0 +1,253 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	llmHandlers "melina-studio-backend/internal/llm_handlers"
+	"melina-studio-backend/internal/melina/agents"
+	"melina-studio-backend/internal/melina/prompts"
+	"melina-studio-backend/internal/melina/tools"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidSummary = errors.New("invalid board summary request")
+
+const (
+	// boards above these sizes are summarized on the job queue instead of inside the request
+	summaryInlineMaxShapes = 150
+	summaryInlineMaxChats  = 40
+	summaryChatHistorySize = 100
+	// of the fetched history, only the messages most relevant to the board go into the prompt
+	summaryRelevantChats = 40
+	summaryTimeout       = 3 * time.Minute
+)
+
+type BoardSummaryService struct {
+	summaryRepo   repo.BoardSummaryRepoInterface
+	boardDataRepo repo.BoardDataRepoInterface
+	chatRepo      repo.ChatRepoInterface
+	jobQueue      *JobQueue
+	embed         func(ctx context.Context, texts []string) ([][]float64, error)
+}
+
+func NewBoardSummaryService(
+	summaryRepo repo.BoardSummaryRepoInterface,
+	boardDataRepo repo.BoardDataRepoInterface,
+	chatRepo repo.ChatRepoInterface,
+	jobQueue *JobQueue,
+) *BoardSummaryService {
+	return &BoardSummaryService{
+		summaryRepo:   summaryRepo,
+		boardDataRepo: boardDataRepo,
+		chatRepo:      chatRepo,
+		jobQueue:      jobQueue,
+		embed:         llmHandlers.EmbedTexts,
+	}
+}
+
+// CreateSummary creates a summary record and generates it
+// Small boards are summarized inline; long boards are queued and the returned summary is still pending.
+// An unknown model is an ErrInvalidSummary; a failed generation returns the failed summary with the error
+func (s *BoardSummaryService) CreateSummary(ctx context.Context, userID uuid.UUID, boardID uuid.UUID, modelName string) (*models.BoardSummary, bool, error) {
+	if modelName == "" {
+		modelName = llmHandlers.DefaultModel()
+	}
+	modelName, modelInfo, err := llmHandlers.ResolveHealthyModel(modelName, nil)
+	if err != nil {
+		return nil, false, fmt.Errorf("%w: %v", ErrInvalidSummary, err)
+	}
+
+	shapes, err := s.boardDataRepo.GetBoardData(boardID)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get board data: %w", err)
+	}
+	chats, total, err := s.chatRepo.GetChatsByBoardId(boardID, 1, summaryChatHistorySize)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to get chat history: %w", err)
+	}
+
+	summary := &models.BoardSummary{
+		BoardID:   boardID,
+		UserID:    userID,
+		Status:    models.SummaryStatusPending,
+		ModelName: modelName,
+	}
+	if err := s.summaryRepo.Create(summary); err != nil {
+		return nil, false, err
+	}
+
+	// the summary sends the board and its chat to the provider, under the user's privacy mode
+	ctx = WithLLMPrivacy(ctx, userID, llmHandlers.DataClassBoard, llmHandlers.DataClassChat)
+	outline := tools.BuildBoardOutline(shapes)
+	chats = selectRelevantChats(ctx, s.embed, outline, chats, summaryRelevantChats)
+	input := buildSummaryInput(outline, chats)
+	long := len(shapes) > summaryInlineMaxShapes || total > summaryInlineMaxChats
+
+	if long && s.jobQueue != nil {
+		summaryID := summary.UUID
+		err := s.jobQueue.Enqueue("summary:"+summaryID.String(), func(ctx context.Context) error {
+			ctx = WithLLMPrivacy(ctx, userID, llmHandlers.DataClassBoard, llmHandlers.DataClassChat)
+			_, err := s.generate(ctx, summaryID, modelName, modelInfo, input, true)
+			return err
+		})
+		if err == nil {
+			return summary, true, nil
+		}
+		log.Printf("Failed to queue summary %s, generating inline: %v", summaryID, err)
+	}
+
+	markdown, err := s.generate(ctx, summary.UUID, modelName, modelInfo, input, false)
+	if err != nil {
+		summary.Status = models.SummaryStatusFailed
+		summary.Error = err.Error()
+		return summary, false, err
+	}
+	summary.Status = models.SummaryStatusCompleted
+	summary.Markdown = markdown
+	return summary, false, nil
+}
+
+// generate runs the LLM and stores the result (or the failure) on the summary record
+// Queued summaries go through the provider's batch API when it has one, which is cheaper but slower
+func (s *BoardSummaryService) generate(ctx context.Context, summaryID uuid.UUID, modelName string, modelInfo *llmHandlers.ModelInfo, input string, queued bool) (string, error) {
+	if err := s.summaryRepo.UpdateStatus(summaryID, models.SummaryStatusRunning, "", ""); err != nil {
+		return "", err
+	}
+
+	var markdown string
+	var err error
+	if queued && llmHandlers.BatchAvailable(modelName) {
+		markdown, err = generateSummaryBatch(ctx, modelName, input)
+		if err != nil && ctx.Err() == nil {
+			log.Printf("Batch summary %s failed, generating interactively: %v", summaryID, err)
+		}
+	}
+	if markdown == "" {
+		if err = ctx.Err(); err == nil {
+			summaryCtx, cancel := context.WithTimeout(ctx, summaryTimeout)
+			markdown, err = generateSummaryMarkdown(summaryCtx, modelInfo, input)
+			cancel()
+		}
+	}
+	if err != nil {
+		if updateErr := s.summaryRepo.UpdateStatus(summaryID, models.SummaryStatusFailed, "", err.Error()); updateErr != nil {
+			log.Printf("Failed to mark summary %s as failed: %v", summaryID, updateErr)
+		}
+		return "", err
+	}
+
+	if err := s.summaryRepo.UpdateStatus(summaryID, models.SummaryStatusCompleted, markdown, ""); err != nil {
+		return "", err
+	}
+	return markdown, nil
+}
+
+func generateSummaryMarkdown(ctx context.Context, modelInfo *llmHandlers.ModelInfo, input string) (string, error) {
+	// temperature 0 keeps summaries of an unchanged board stable and lets the response cache reuse them
+	temperature := float32(0)
+	agent, err := agents.NewTextAgent(modelInfo, &temperature, nil)
+	if err != nil {
+		return "", err
+	}
+
+	markdown, err := agent.Complete(ctx, prompts.SUMMARY_PROMPT, input)
+	if err != nil {
+		return "", err
+	}
+	return cleanSummaryMarkdown(markdown)
+}
+
+// generateSummaryBatch summarizes through the batch API, giving up after the batch wait limit
+func generateSummaryBatch(ctx context.Context, modelName string, input string) (string, error) {
+	ctx, cancel := context.WithTimeout(ctx, llmHandlers.BatchMaxWait())
+	defer cancel()
+
+	temperature := float32(0)
+	markdown, _, err := llmHandlers.CompleteBatch(ctx, modelName, prompts.SUMMARY_PROMPT, input, &temperature)
+	if err != nil {
+		return "", err
+	}
+	return cleanSummaryMarkdown(markdown)
+}
+
+func cleanSummaryMarkdown(markdown string) (string, error) {
+	markdown = strings.TrimSpace(markdown)
+	// Some models wrap the whole document in a code fence
+	markdown = strings.TrimPrefix(markdown, "```markdown")
+	markdown = strings.TrimPrefix(markdown, "```")
+	markdown = strings.TrimSuffix(markdown, "```")
+	markdown = strings.TrimSpace(markdown)
+	if markdown == "" {
+		return "", fmt.Errorf("model returned an empty summary")
+	}
+	return markdown, nil
+}
+
+// buildSummaryInput combines the board outline and chat transcript into the user message
+func buildSummaryInput(outline string, chats []models.Chat) string {
+	var sb strings.Builder
+	sb.WriteString("<BOARD_OUTLINE>\n")
+	if outline == "" {
+		sb.WriteString("(the board has no text content)\n")
+	} else {
+		sb.WriteString(outline)
+		sb.WriteString("\n")
+	}
+	sb.WriteString("</BOARD_OUTLINE>\n\n")
+
+	sb.WriteString("<CHAT_HISTORY>\n")
+	for _, chat := range chats {
+		sb.WriteString(fmt.Sprintf("%s: %s\n", chat.Role, strings.TrimSpace(chat.Content)))
+	}
+	sb.WriteString("</CHAT_HISTORY>\n")
+	return sb.String()
+}
+
+// selectRelevantChats keeps the keep messages closest in meaning to the board outline, in their original order
+// Long histories drift into small talk and retries; ranking keeps the discussion about what is on the board.
+// Without an outline or embeddings it keeps the first keep messages (the newest, as fetched)
+func selectRelevantChats(ctx context.Context, embed func(ctx context.Context, texts []string) ([][]float64, error), outline string, chats []models.Chat, keep int) []models.Chat {
+	if len(chats) <= keep {
+		return chats
+	}
+	if strings.TrimSpace(outline) == "" || embed == nil {
+		return chats[:keep]
+	}
+
+	texts := make([]string, 0, len(chats)+1)
+	texts = append(texts, outline)
+	for _, chat := range chats {
+		texts = append(texts, chat.Content)
+	}
+	vectors, err := embed(ctx, texts)
+	if err != nil || len(vectors) != len(texts) {
+		log.Printf("Summary relevance ranking unavailable, using the newest messages: %v", err)
+		return chats[:keep]
+	}
+
+	order := make([]int, len(chats))
+	scores := make([]float64, len(chats))
+	for i := range chats {
+		order[i] = i
+		scores[i] = llmHandlers.CosineSimilarity(vectors[0], vectors[i+1])
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		return scores[order[a]] > scores[order[b]]
+	})
+	kept := order[:keep]
+	sort.Ints(kept)
+
+	selected := make([]models.Chat, 0, keep)
+	for _, i := range kept {
+		selected = append(selected, chats[i])
+	}
+	return selected
+}
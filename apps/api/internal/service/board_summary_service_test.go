@@ -0,0 +1,160 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"encoding/xml"
+	"errors"
+	"io"
+	llmHandlers "melina-studio-backend/internal/llm_handlers"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestSelectRelevantChats(t *testing.T) {
+	chats := []models.Chat{
+		{Content: "checkout flow"},
+		{Content: "thanks!"},
+		{Content: "checkout retries"},
+		{Content: "what's the weather"},
+	}
+	// embed scores texts mentioning checkout as close to the outline
+	embed := func(ctx context.Context, texts []string) ([][]float64, error) {
+		vectors := make([][]float64, len(texts))
+		for i, text := range texts {
+			if strings.Contains(text, "checkout") {
+				vectors[i] = []float64{1, 0}
+			} else {
+				vectors[i] = []float64{0, 1}
+			}
+		}
+		return vectors, nil
+	}
+
+	got := selectRelevantChats(context.Background(), embed, "- checkout", chats, 2)
+	if len(got) != 2 || got[0].Content != "checkout flow" || got[1].Content != "checkout retries" {
+		t.Errorf("got %v, want the checkout messages in their original order", got)
+	}
+
+	failing := func(ctx context.Context, texts []string) ([][]float64, error) {
+		return nil, errors.New("no provider")
+	}
+	got = selectRelevantChats(context.Background(), failing, "- checkout", chats, 2)
+	if len(got) != 2 || got[1].Content != "thanks!" {
+		t.Errorf("fallback should keep the first messages, got %v", got)
+	}
+
+	if got := selectRelevantChats(context.Background(), embed, "", chats, 10); len(got) != 4 {
+		t.Errorf("short histories are kept whole, got %d", len(got))
+	}
+}
+
+type fakeSummaryRepo struct {
+	repo.BoardSummaryRepoInterface
+	createErr error
+	created   int
+}
+
+func (f *fakeSummaryRepo) Create(summary *models.BoardSummary) error {
+	f.created++
+	return f.createErr
+}
+
+type fakeSummaryBoardDataRepo struct {
+	repo.BoardDataRepoInterface
+	err error
+}
+
+func (f *fakeSummaryBoardDataRepo) GetBoardData(boardId uuid.UUID) ([]models.BoardData, error) {
+	return nil, f.err
+}
+
+type fakeSummaryChatRepo struct {
+	repo.ChatRepoInterface
+}
+
+func (f *fakeSummaryChatRepo) GetChatsByBoardId(boardId uuid.UUID, page int, pageSize int, fields ...string) ([]models.Chat, int64, error) {
+	return nil, 0, nil
+}
+
+func TestCreateSummaryErrors(t *testing.T) {
+	dbErr := errors.New("connection refused")
+	cases := map[string]struct {
+		model       string
+		boardErr    error
+		createErr   error
+		wantInvalid bool
+	}{
+		"unknown model":       {model: "no-such-model", wantInvalid: true},
+		"board data not read": {model: llmHandlers.DefaultModelName, boardErr: dbErr},
+		"record not created":  {model: llmHandlers.DefaultModelName, createErr: dbErr},
+	}
+	for name, tc := range cases {
+		summaries := &fakeSummaryRepo{createErr: tc.createErr}
+		s := NewBoardSummaryService(summaries, &fakeSummaryBoardDataRepo{err: tc.boardErr}, &fakeSummaryChatRepo{}, nil)
+		summary, queued, err := s.CreateSummary(context.Background(), uuid.New(), uuid.New(), tc.model)
+		if err == nil || summary != nil || queued {
+			t.Errorf("%s: got %v %v %v, want an error and no summary", name, summary, queued, err)
+			continue
+		}
+		if errors.Is(err, ErrInvalidSummary) != tc.wantInvalid {
+			t.Errorf("%s: ErrInvalidSummary is %v, want %v (%v)", name, !tc.wantInvalid, tc.wantInvalid, err)
+		}
+		if tc.wantInvalid && summaries.created != 0 {
+			t.Errorf("%s: expected no summary record for an invalid request", name)
+		}
+	}
+}
+
+func TestSummaryDocx(t *testing.T) {
+	markdown := "# Q3 Planning\n\n## Decisions\n- Ship **v2** by June & review\n- [ ] Book the venue\n- [x] Pick a date\n\n---\nPlain <text>"
+	doc, err := SummaryDocx(markdown)
+	if err != nil {
+		t.Fatal(err)
+	}
+	zr, err := zip.NewReader(bytes.NewReader(doc), int64(len(doc)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	parts := make(map[string]string)
+	for _, f := range zr.File {
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, _ := io.ReadAll(rc)
+		rc.Close()
+		parts[f.Name] = string(content)
+	}
+	for _, name := range []string{"[Content_Types].xml", "_rels/.rels", "word/_rels/document.xml.rels", "word/styles.xml", "word/document.xml"} {
+		if _, ok := parts[name]; !ok {
+			t.Fatalf("missing part %s", name)
+		}
+		if err := xml.Unmarshal([]byte(parts[name]), new(struct{})); err != nil {
+			t.Errorf("%s isn't well-formed: %v", name, err)
+		}
+	}
+
+	document := parts["word/document.xml"]
+	for _, want := range []string{
+		`<w:pStyle w:val="Heading1"/></w:pPr><w:r><w:t xml:space="preserve">Q3 Planning</w:t>`,
+		`<w:pStyle w:val="Heading2"/>`,
+		`<w:pStyle w:val="ListBullet"/>`,
+		`<w:rPr><w:b/></w:rPr><w:t xml:space="preserve">v2</w:t>`,
+		`by June &amp; review`,
+		`☐`, `☑`,
+		`Plain &lt;text&gt;`,
+	} {
+		if !strings.Contains(document, want) {
+			t.Errorf("document lacks %s", want)
+		}
+	}
+	if strings.Contains(document, "**") || strings.Contains(document, "[ ]") || strings.Contains(document, "---") {
+		t.Errorf("expected markdown syntax to be dropped: %s", document)
+	}
+}
@@ -0,0 +1,271 @@
+package service
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"melina-studio-backend/internal/config"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidBoardVersion  = errors.New("invalid board version")
+	ErrBoardVersionNotFound = errors.New("board version not found")
+)
+
+const (
+	// boardVersionLabelLimit caps a snapshot's label
+	boardVersionLabelLimit = 100
+	// boardVersionsPerPass caps the boards one periodic pass snapshots; the rest wait for the next one
+	boardVersionsPerPass = 500
+)
+
+// BoardVersionService snapshots the shapes of boards, manually or periodically, and rolls boards back to them.
+// Each instance snapshots the boards changed since its last pass, skipping those whose newest snapshot is
+// already up to date
+type BoardVersionService struct {
+	cfg           config.BoardVersionConfig
+	versionRepo   repo.BoardVersionRepoInterface
+	boardDataRepo repo.BoardDataRepoInterface
+	stopChan      chan struct{}
+	doneChan      chan struct{}
+}
+
+func NewBoardVersionService(cfg config.BoardVersionConfig, versionRepo repo.BoardVersionRepoInterface, boardDataRepo repo.BoardDataRepoInterface) *BoardVersionService {
+	return &BoardVersionService{
+		cfg:           cfg,
+		versionRepo:   versionRepo,
+		boardDataRepo: boardDataRepo,
+		stopChan:      make(chan struct{}),
+		doneChan:      make(chan struct{}),
+	}
+}
+
+var boardVersionService *BoardVersionService
+
+// GetBoardVersionService returns the process-wide board version service created by InitBoardVersionService
+func GetBoardVersionService() *BoardVersionService {
+	return boardVersionService
+}
+
+// InitBoardVersionService creates the process-wide board version service
+func InitBoardVersionService(cfg config.BoardVersionConfig, versionRepo repo.BoardVersionRepoInterface, boardDataRepo repo.BoardDataRepoInterface) *BoardVersionService {
+	boardVersionService = NewBoardVersionService(cfg, versionRepo, boardDataRepo)
+	return boardVersionService
+}
+
+// Start launches the goroutine that takes the periodic snapshots
+func (s *BoardVersionService) Start() {
+	if !s.cfg.Enabled {
+		log.Println("Periodic board snapshots are disabled")
+		return
+	}
+
+	go s.runSnapshotLoop()
+	log.Printf("Board version service started (interval: %v)", s.cfg.Interval)
+}
+
+// Stop gracefully shuts down the board version service, letting a running pass finish
+func (s *BoardVersionService) Stop() {
+	if !s.cfg.Enabled {
+		return
+	}
+
+	log.Println("Stopping board version service...")
+	close(s.stopChan)
+	<-s.doneChan
+	log.Println("Board version service stopped")
+}
+
+func (s *BoardVersionService) runSnapshotLoop() {
+	defer close(s.doneChan)
+
+	ticker := time.NewTicker(s.cfg.Interval)
+	defer ticker.Stop()
+
+	// The first pass picks up the changes made while the instance was down, up to one interval back
+	since := time.Now().Add(-s.cfg.Interval)
+	for {
+		select {
+		case <-ticker.C:
+			now := time.Now()
+			s.snapshotChanged(since)
+			since = now
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+// snapshotChanged takes a snapshot of every board with a shape added or updated after since
+func (s *BoardVersionService) snapshotChanged(since time.Time) {
+	boards, err := s.versionRepo.ListChangedBoards(since, boardVersionsPerPass)
+	if err != nil {
+		log.Printf("[versions] failed to list changed boards: %v", err)
+		return
+	}
+	taken := 0
+	for _, board := range boards {
+		version, err := s.snapshot(board.UserID, board.UUID, models.BoardVersionAuto, "", true)
+		if err != nil {
+			log.Printf("[versions] failed to snapshot board %s: %v", board.UUID, err)
+			continue
+		}
+		if version != nil {
+			taken++
+		}
+	}
+	if taken > 0 {
+		log.Printf("[versions] took %d periodic snapshots", taken)
+	}
+}
+
+// List returns the board's snapshots, newest first, without their shapes
+func (s *BoardVersionService) List(boardID uuid.UUID) ([]models.BoardVersion, error) {
+	versions, err := s.versionRepo.ListVersions(boardID, s.cfg.MaxPerBoard)
+	if err != nil {
+		return nil, err
+	}
+	if versions == nil {
+		versions = []models.BoardVersion{}
+	}
+	return versions, nil
+}
+
+// Create takes a snapshot of the board now
+func (s *BoardVersionService) Create(userID uuid.UUID, boardID uuid.UUID, label string) (*models.BoardVersion, error) {
+	label = strings.TrimSpace(label)
+	if utf8.RuneCountInString(label) > boardVersionLabelLimit {
+		return nil, fmt.Errorf("%w: the label is longer than %d characters", ErrInvalidBoardVersion, boardVersionLabelLimit)
+	}
+	return s.snapshot(userID, boardID, models.BoardVersionManual, label, false)
+}
+
+// Restore rolls the board's shapes back to a snapshot. The canvas is snapshotted first, so the restore can
+// itself be undone; that snapshot is returned with the one restored
+func (s *BoardVersionService) Restore(userID uuid.UUID, boardID uuid.UUID, versionID uuid.UUID) (*models.BoardVersion, *models.BoardVersion, error) {
+	version, err := s.versionRepo.GetVersion(boardID, versionID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, ErrBoardVersionNotFound
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	var shapes []models.BoardData
+	if err := json.Unmarshal(version.Shapes, &shapes); err != nil {
+		return nil, nil, fmt.Errorf("failed to read version %s: %w", versionID, err)
+	}
+
+	label := "Before restoring " + version.CreatedAt.UTC().Format("2006-01-02 15:04 UTC")
+	backup, err := s.snapshot(userID, boardID, models.BoardVersionBeforeRestore, label, false)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to snapshot the board before restoring: %w", err)
+	}
+
+	// The restored shapes count as changed now, so ETags and the next periodic pass see the restore
+	now := time.Now()
+	for i := range shapes {
+		shapes[i].BoardId = boardID
+		shapes[i].UpdatedAt = now
+	}
+	if err := s.versionRepo.RestoreShapes(boardID, shapes); err != nil {
+		return nil, nil, err
+	}
+	version.Shapes = nil
+	return version, backup, nil
+}
+
+// snapshot stores the board's current shapes as a version and prunes the oldest ones. With skipUnchanged,
+// nothing is stored when the newest snapshot has the same shapes, and the returned version is nil
+func (s *BoardVersionService) snapshot(userID uuid.UUID, boardID uuid.UUID, trigger models.BoardVersionTrigger, label string, skipUnchanged bool) (*models.BoardVersion, error) {
+	shapes, err := s.boardDataRepo.GetBoardData(boardID)
+	if err != nil {
+		return nil, err
+	}
+	checksum, err := boardShapesChecksum(shapes)
+	if err != nil {
+		return nil, err
+	}
+	if skipUnchanged {
+		latest, err := s.versionRepo.GetLatestChecksum(boardID)
+		if err != nil {
+			return nil, err
+		}
+		if latest == checksum {
+			return nil, nil
+		}
+	}
+	if shapes == nil {
+		shapes = []models.BoardData{}
+	}
+	data, err := json.Marshal(shapes)
+	if err != nil {
+		return nil, err
+	}
+
+	version := &models.BoardVersion{
+		BoardID:    boardID,
+		UserID:     userID,
+		Label:      label,
+		Trigger:    trigger,
+		Shapes:     datatypes.JSON(data),
+		ShapeCount: len(shapes),
+		Checksum:   checksum,
+	}
+	if err := s.versionRepo.CreateVersion(version); err != nil {
+		return nil, err
+	}
+	if err := s.versionRepo.PruneVersions(boardID, s.cfg.MaxPerBoard); err != nil {
+		log.Printf("[versions] failed to prune the snapshots of board %s: %v", boardID, err)
+	}
+	version.Shapes = nil
+	return version, nil
+}
+
+// boardShapesChecksum identifies the content of a board's shapes whatever their order; timestamps are left
+// out, so saving a board without changing it keeps its checksum
+func boardShapesChecksum(shapes []models.BoardData) (string, error) {
+	type shapeContent struct {
+		UUID             uuid.UUID       `json:"uuid"`
+		Type             models.Type     `json:"type"`
+		Data             json.RawMessage `json:"data"`
+		ImageUrl         *string         `json:"image_url"`
+		AnnotationNumber int             `json:"annotation_number"`
+		Locked           bool            `json:"locked"`
+		SlideOrder       *int            `json:"slide_order"`
+	}
+	contents := make([]shapeContent, 0, len(shapes))
+	for _, shape := range shapes {
+		contents = append(contents, shapeContent{
+			UUID:             shape.UUID,
+			Type:             shape.Type,
+			Data:             json.RawMessage(shape.Data),
+			ImageUrl:         shape.ImageUrl,
+			AnnotationNumber: shape.AnnotationNumber,
+			Locked:           shape.Locked,
+			SlideOrder:       shape.SlideOrder,
+		})
+	}
+	sort.Slice(contents, func(i, j int) bool {
+		return contents[i].UUID.String() < contents[j].UUID.String()
+	})
+	data, err := json.Marshal(contents)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,43 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	"melina-studio-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func TestBoardShapesChecksum(t *testing.T) {
+	first, second := uuid.New(), uuid.New()
+	shapes := []models.BoardData{
+		storedShape(first, "rect", `{"x":1,"y":2}`),
+		storedShape(second, "text", `{"text":"Hi"}`),
+	}
+	base, err := boardShapesChecksum(shapes)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	reordered := []models.BoardData{shapes[1], shapes[0]}
+	reordered[0].UpdatedAt = time.Now()
+	if got, _ := boardShapesChecksum(reordered); got != base {
+		t.Error("expected the order and timestamps of the shapes to be ignored")
+	}
+
+	moved := []models.BoardData{storedShape(first, "rect", `{"x":5,"y":2}`), shapes[1]}
+	if got, _ := boardShapesChecksum(moved); got == base {
+		t.Error("expected a moved shape to change the checksum")
+	}
+
+	locked := []models.BoardData{shapes[0], shapes[1]}
+	locked[1].Locked = true
+	if got, _ := boardShapesChecksum(locked); got == base {
+		t.Error("expected a lock to change the checksum")
+	}
+
+	if got, _ := boardShapesChecksum(shapes[:1]); got == base {
+		t.Error("expected a deleted shape to change the checksum")
+	}
+}
@@ -0,0 +1,247 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"melina-studio-backend/internal/libraries"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+	"net/mail"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidBudget  = errors.New("invalid budget")
+	ErrBudgetNotFound = errors.New("budget not found")
+	ErrBudgetExceeded = errors.New("budget exceeded")
+)
+
+// budgetThresholds are the percentages of a budget that send an alert, in ascending order
+var budgetThresholds = []int{50, 80, 100}
+
+const maxBudgetUSD = 1_000_000
+
+// BudgetAlert is a threshold a budget crossed with the turn that was just recorded
+type BudgetAlert struct {
+	Status    *models.BudgetStatus
+	Threshold int
+}
+
+type BudgetService struct {
+	budgetRepo repo.BudgetRepoInterface
+	authRepo   repo.AuthRepoInterface
+}
+
+func NewBudgetService(budgetRepo repo.BudgetRepoInterface, authRepo repo.AuthRepoInterface) *BudgetService {
+	return &BudgetService{budgetRepo: budgetRepo, authRepo: authRepo}
+}
+
+// GetBudget returns a budget with its spend in the current period
+func (s *BudgetService) GetBudget(scope models.BudgetScope, scopeID uuid.UUID, now time.Time) (*models.BudgetStatus, error) {
+	budget, err := s.budgetRepo.Get(scope, scopeID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrBudgetNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	return s.status(budget, now)
+}
+
+// SetBudget validates and stores the monthly budget of a board or tenant
+func (s *BudgetService) SetBudget(scope models.BudgetScope, scopeID uuid.UUID, monthlyLimitUSD float64, hardStop bool, alertEmail string) (*models.Budget, error) {
+	if monthlyLimitUSD <= 0 || monthlyLimitUSD > maxBudgetUSD {
+		return nil, fmt.Errorf("%w: monthly_limit_usd must be greater than 0 and at most %d", ErrInvalidBudget, maxBudgetUSD)
+	}
+	alertEmail = strings.TrimSpace(alertEmail)
+	if alertEmail != "" {
+		if _, err := mail.ParseAddress(alertEmail); err != nil {
+			return nil, fmt.Errorf("%w: alert_email is not a valid email address", ErrInvalidBudget)
+		}
+	}
+
+	budget := &models.Budget{
+		Scope:           scope,
+		ScopeID:         scopeID,
+		MonthlyLimitUSD: monthlyLimitUSD,
+		HardStop:        hardStop,
+		AlertEmail:      alertEmail,
+	}
+	if err := s.budgetRepo.Save(budget); err != nil {
+		return nil, err
+	}
+	return budget, nil
+}
+
+func (s *BudgetService) DeleteBudget(scope models.BudgetScope, scopeID uuid.UUID) error {
+	return s.budgetRepo.Delete(scope, scopeID)
+}
+
+// CheckTurn refuses an agent turn on the board with ErrBudgetExceeded, and the spent budget's status,
+// when the board's or the user's tenant's budget is spent and hard-stopped
+func (s *BudgetService) CheckTurn(userID uuid.UUID, boardID uuid.UUID, now time.Time) (*models.BudgetStatus, error) {
+	budgets, _, err := s.budgetsFor(userID, boardID)
+	if err != nil {
+		return nil, err
+	}
+	for _, budget := range budgets {
+		if !budget.HardStop {
+			continue
+		}
+		status, err := s.status(budget, now)
+		if err != nil {
+			return nil, err
+		}
+		if status.Blocked {
+			return status, ErrBudgetExceeded
+		}
+	}
+	return nil, nil
+}
+
+// RecordSpend checks the budgets of the board after a turn and returns the thresholds crossed for the first time
+// this period; each alert is also emailed to the budget's alert address, or the board owner for board budgets
+func (s *BudgetService) RecordSpend(userID uuid.UUID, boardID uuid.UUID, now time.Time) ([]BudgetAlert, error) {
+	budgets, user, err := s.budgetsFor(userID, boardID)
+	if err != nil {
+		return nil, err
+	}
+
+	var alerts []BudgetAlert
+	for _, budget := range budgets {
+		status, err := s.status(budget, now)
+		if err != nil {
+			return alerts, err
+		}
+		threshold := crossedThreshold(status.SpentUSD, budget.MonthlyLimitUSD)
+		if threshold == 0 || alreadyAlerted(budget, threshold, status.PeriodStart) {
+			continue
+		}
+		marked, err := s.budgetRepo.MarkAlerted(budget.UUID, threshold, status.PeriodStart)
+		if err != nil {
+			return alerts, err
+		}
+		if !marked {
+			continue
+		}
+
+		alert := BudgetAlert{Status: status, Threshold: threshold}
+		alerts = append(alerts, alert)
+
+		recipient := budget.AlertEmail
+		if recipient == "" && budget.Scope == models.BudgetScopeBoard && user != nil {
+			recipient = user.Email
+		}
+		if recipient != "" && libraries.EmailEnabled() {
+			subject, body := budgetAlertEmail(alert)
+			if err := libraries.SendEmail([]string{recipient}, subject, body); err != nil {
+				log.Printf("Failed to email budget alert for %s %s: %v", budget.Scope, budget.ScopeID, err)
+			}
+		}
+	}
+	return alerts, nil
+}
+
+// budgetsFor returns the budgets that apply to a turn on the board: the board's own and the user's tenant's
+func (s *BudgetService) budgetsFor(userID uuid.UUID, boardID uuid.UUID) ([]*models.Budget, *models.User, error) {
+	var budgets []*models.Budget
+	budget, err := s.budgetRepo.Get(models.BudgetScopeBoard, boardID)
+	if err == nil {
+		budgets = append(budgets, budget)
+	} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil, err
+	}
+
+	user, err := s.authRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+	if user.TenantID != nil {
+		budget, err := s.budgetRepo.Get(models.BudgetScopeTenant, *user.TenantID)
+		if err == nil {
+			budgets = append(budgets, budget)
+		} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, err
+		}
+	}
+	return budgets, &user, nil
+}
+
+func (s *BudgetService) status(budget *models.Budget, now time.Time) (*models.BudgetStatus, error) {
+	start, end := budgetPeriod(now)
+	spent, err := s.budgetRepo.GetSpend(budget.Scope, budget.ScopeID, start)
+	if err != nil {
+		return nil, err
+	}
+	return &models.BudgetStatus{
+		Budget:      budget,
+		SpentUSD:    spent,
+		Percentage:  spent / budget.MonthlyLimitUSD * 100,
+		PeriodStart: start,
+		PeriodEnd:   end,
+		Blocked:     budget.HardStop && spent >= budget.MonthlyLimitUSD,
+	}, nil
+}
+
+// budgetPeriod returns the calendar month (UTC) containing now; budgets reset when it ends
+func budgetPeriod(now time.Time) (time.Time, time.Time) {
+	now = now.UTC()
+	start := time.Date(now.Year(), now.Month(), 1, 0, 0, 0, 0, time.UTC)
+	return start, start.AddDate(0, 1, 0)
+}
+
+// crossedThreshold returns the highest alert threshold the spend has reached, 0 for none
+func crossedThreshold(spent float64, limit float64) int {
+	if limit <= 0 {
+		return 0
+	}
+	crossed := 0
+	for _, threshold := range budgetThresholds {
+		if spent*100 >= limit*float64(threshold) {
+			crossed = threshold
+		}
+	}
+	return crossed
+}
+
+// alreadyAlerted reports whether the threshold, or a higher one, was alerted in the period
+func alreadyAlerted(budget *models.Budget, threshold int, periodStart time.Time) bool {
+	if budget.AlertedPeriod == nil || budget.AlertedPeriod.Before(periodStart) {
+		return false
+	}
+	return budget.AlertedThreshold >= threshold
+}
+
+// BudgetPayload converts a budget status into its WebSocket payload
+func BudgetPayload(status *models.BudgetStatus, threshold int) *libraries.BudgetPayload {
+	return &libraries.BudgetPayload{
+		Scope:     string(status.Budget.Scope),
+		ScopeID:   status.Budget.ScopeID.String(),
+		Threshold: threshold,
+		SpentUSD:  status.SpentUSD,
+		LimitUSD:  status.Budget.MonthlyLimitUSD,
+		HardStop:  status.Budget.HardStop,
+		ResetDate: status.PeriodEnd.Format(time.RFC3339),
+	}
+}
+
+func budgetAlertEmail(alert BudgetAlert) (string, string) {
+	budget := alert.Status.Budget
+	subject := fmt.Sprintf("Melina Studio: %s budget at %d%%", budget.Scope, alert.Threshold)
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("The %s budget %s has used $%.2f of its $%.2f monthly limit (%d%%).\n\n",
+		budget.Scope, budget.ScopeID, alert.Status.SpentUSD, budget.MonthlyLimitUSD, alert.Threshold))
+	if alert.Threshold >= 100 && budget.HardStop {
+		sb.WriteString("New agent turns are refused until the budget resets")
+	} else {
+		sb.WriteString("The budget resets")
+	}
+	sb.WriteString(fmt.Sprintf(" on %s.\n", alert.Status.PeriodEnd.Format("January 2, 2006")))
+	return subject, sb.String()
+}
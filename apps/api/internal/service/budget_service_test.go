@@ -0,0 +1,70 @@
+package service
+
+import (
+	"melina-studio-backend/internal/models"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCrossedThreshold(t *testing.T) {
+	tests := []struct {
+		spent, limit float64
+		want         int
+	}{
+		{0, 10, 0},
+		{4.99, 10, 0},
+		{5, 10, 50},
+		{7.99, 10, 50},
+		{8, 10, 80},
+		{10, 10, 100},
+		{25, 10, 100},
+		{5, 0, 0},
+	}
+	for _, tc := range tests {
+		if got := crossedThreshold(tc.spent, tc.limit); got != tc.want {
+			t.Errorf("crossedThreshold(%v, %v) = %d, want %d", tc.spent, tc.limit, got, tc.want)
+		}
+	}
+}
+
+func TestBudgetPeriod(t *testing.T) {
+	start, end := budgetPeriod(time.Date(2026, 12, 31, 23, 30, 0, 0, time.FixedZone("IST", 5*3600+1800)))
+	if !start.Equal(time.Date(2026, 12, 1, 0, 0, 0, 0, time.UTC)) || !end.Equal(time.Date(2027, 1, 1, 0, 0, 0, 0, time.UTC)) {
+		t.Errorf("got %v - %v", start, end)
+	}
+}
+
+func TestAlreadyAlerted(t *testing.T) {
+	march := time.Date(2026, 3, 1, 0, 0, 0, 0, time.UTC)
+	april := time.Date(2026, 4, 1, 0, 0, 0, 0, time.UTC)
+
+	if alreadyAlerted(&models.Budget{}, 50, march) {
+		t.Error("a budget that never alerted should alert")
+	}
+	alerted := &models.Budget{AlertedThreshold: 80, AlertedPeriod: &march}
+	if !alreadyAlerted(alerted, 50, march) || !alreadyAlerted(alerted, 80, march) {
+		t.Error("lower and equal thresholds were already alerted this period")
+	}
+	if alreadyAlerted(alerted, 100, march) {
+		t.Error("a higher threshold should alert")
+	}
+	if alreadyAlerted(alerted, 50, april) {
+		t.Error("alerts should start over in a new period")
+	}
+}
+
+func TestBudgetAlertEmail(t *testing.T) {
+	status := &models.BudgetStatus{
+		Budget:    &models.Budget{Scope: models.BudgetScopeBoard, MonthlyLimitUSD: 20, HardStop: true},
+		SpentUSD:  20.5,
+		PeriodEnd: time.Date(2026, 5, 1, 0, 0, 0, 0, time.UTC),
+	}
+	subject, body := budgetAlertEmail(BudgetAlert{Status: status, Threshold: 100})
+	if !strings.Contains(subject, "100%") {
+		t.Errorf("subject = %q", subject)
+	}
+	if !strings.Contains(body, "$20.50 of its $20.00") || !strings.Contains(body, "refused until the budget resets on May 1, 2026") {
+		t.Errorf("body = %q", body)
+	}
+}
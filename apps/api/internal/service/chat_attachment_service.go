@@ -0,0 +1,102 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"melina-studio-backend/internal/libraries"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrAttachmentMessageNotFound = errors.New("message not found")
+	ErrAttachmentNotFound        = errors.New("attachment not found")
+)
+
+// ChatAttachmentService lists and deletes the images attached to chat messages
+// An attachment is an upload committed to the user message it was sent with
+type ChatAttachmentService struct {
+	chatRepo       repo.ChatRepoInterface
+	tempUploadRepo repo.TempUploadRepoInterface
+	gcsClient      *libraries.Clients
+	contentStore   *ContentStore
+	storageQuota   *StorageQuotaService
+}
+
+func NewChatAttachmentService(chatRepo repo.ChatRepoInterface, tempUploadRepo repo.TempUploadRepoInterface, gcsClient *libraries.Clients, contentStore *ContentStore, storageQuota *StorageQuotaService) *ChatAttachmentService {
+	return &ChatAttachmentService{
+		chatRepo:       chatRepo,
+		tempUploadRepo: tempUploadRepo,
+		gcsClient:      gcsClient,
+		contentStore:   contentStore,
+		storageQuota:   storageQuota,
+	}
+}
+
+// List returns the images attached to one of the user's messages
+func (s *ChatAttachmentService) List(userID uuid.UUID, messageID uuid.UUID) ([]models.TempUpload, error) {
+	if err := s.checkMessage(userID, messageID); err != nil {
+		return nil, err
+	}
+	return s.tempUploadRepo.GetByMessage(messageID)
+}
+
+// Delete removes an image attached to one of the user's messages, deleting the stored object
+// once no other upload references it
+func (s *ChatAttachmentService) Delete(ctx context.Context, userID uuid.UUID, messageID uuid.UUID, attachmentID uuid.UUID) error {
+	if err := s.checkMessage(userID, messageID); err != nil {
+		return err
+	}
+	upload, err := s.tempUploadRepo.GetByID(attachmentID)
+	if err != nil {
+		return err
+	}
+	if upload == nil || upload.MessageID == nil || *upload.MessageID != messageID {
+		return ErrAttachmentNotFound
+	}
+
+	released := releaseUploads(ctx, s.gcsClient, s.contentStore, s.storageQuota, []models.TempUpload{*upload})
+	if len(released) == 0 {
+		return errors.New("failed to delete the attachment's image")
+	}
+	return s.tempUploadRepo.DeleteByIDs(released)
+}
+
+// checkMessage makes sure the message is on one of the user's boards
+func (s *ChatAttachmentService) checkMessage(userID uuid.UUID, messageID uuid.UUID) error {
+	_, err := s.chatRepo.GetUserChatByID(userID, messageID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrAttachmentMessageNotFound
+	}
+	return err
+}
+
+// releaseUploads deletes the uploads' images from storage and releases their quota usage, returning the
+// ids of the uploads it released; deduplicated uploads only drop their reference, and the object goes with the last one
+func releaseUploads(ctx context.Context, gcsClient *libraries.Clients, contentStore *ContentStore, storageQuota *StorageQuotaService, uploads []models.TempUpload) []uuid.UUID {
+	var released []uuid.UUID
+	for _, upload := range uploads {
+		if upload.ContentHash != "" {
+			if err := contentStore.Release(ctx, upload.Bucket, upload.ContentHash); err != nil {
+				log.Printf("Failed to release upload %s: %v", upload.ObjectKey, err)
+				continue
+			}
+		} else if err := gcsClient.Remove(ctx, upload.ObjectKey); err != nil {
+			log.Printf("Failed to delete upload %s from GCS: %v", upload.ObjectKey, err)
+			continue
+		}
+		released = append(released, upload.UUID)
+
+		// the released upload no longer counts against its uploader's quota
+		if upload.UserID != uuid.Nil {
+			if err := storageQuota.Release(upload.UserID, upload.ObjectKey); err != nil {
+				log.Printf("Failed to release storage usage of %s: %v", upload.ObjectKey, err)
+			}
+		}
+	}
+	return released
+}
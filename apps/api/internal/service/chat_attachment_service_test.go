@@ -0,0 +1,65 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type fakeAttachmentChatRepo struct {
+	repo.ChatRepoInterface
+	owner    uuid.UUID
+	messages map[uuid.UUID]bool
+}
+
+func (f *fakeAttachmentChatRepo) GetUserChatByID(userID uuid.UUID, chatId uuid.UUID) (*models.Chat, error) {
+	if userID != f.owner || !f.messages[chatId] {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &models.Chat{UUID: chatId}, nil
+}
+
+func (f *fakeTempUploadRepo) GetByMessage(messageID uuid.UUID) ([]models.TempUpload, error) {
+	var uploads []models.TempUpload
+	for _, upload := range f.uploads {
+		if upload.MessageID != nil && *upload.MessageID == messageID {
+			uploads = append(uploads, *upload)
+		}
+	}
+	return uploads, nil
+}
+
+func TestChatAttachments(t *testing.T) {
+	userID, messageID, otherMessage := uuid.New(), uuid.New(), uuid.New()
+	attachmentID := uuid.New()
+	chats := &fakeAttachmentChatRepo{owner: userID, messages: map[uuid.UUID]bool{messageID: true, otherMessage: true}}
+	uploads := &fakeTempUploadRepo{uploads: map[uuid.UUID]*models.TempUpload{
+		attachmentID: {UUID: attachmentID, UserID: userID, MessageID: &messageID},
+		uuid.New():   {UUID: uuid.New(), UserID: userID},
+	}}
+	s := NewChatAttachmentService(chats, uploads, nil, nil, nil)
+
+	attachments, err := s.List(userID, messageID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(attachments) != 1 || attachments[0].UUID != attachmentID {
+		t.Fatalf("got attachments %+v, want only %s", attachments, attachmentID)
+	}
+
+	if _, err := s.List(uuid.New(), messageID); !errors.Is(err, ErrAttachmentMessageNotFound) {
+		t.Fatalf("another user's message: got %v, want ErrAttachmentMessageNotFound", err)
+	}
+	if err := s.Delete(context.Background(), uuid.New(), messageID, attachmentID); !errors.Is(err, ErrAttachmentMessageNotFound) {
+		t.Fatalf("deleting from another user's message: got %v, want ErrAttachmentMessageNotFound", err)
+	}
+	if err := s.Delete(context.Background(), userID, otherMessage, attachmentID); !errors.Is(err, ErrAttachmentNotFound) {
+		t.Fatalf("deleting through another message: got %v, want ErrAttachmentNotFound", err)
+	}
+}
@@ -2,53 +2,135 @@ package service
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"log"
 	"melina-studio-backend/internal/config"
 	"melina-studio-backend/internal/libraries"
+	"melina-studio-backend/internal/models"
 	"melina-studio-backend/internal/repo"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
+)
+
+var (
+	ErrInvalidCleanupSettings = errors.New("invalid cleanup settings")
+	ErrCleanupRunning         = errors.New("a cleanup run is already in progress")
+)
 
-	"github.com/google/uuid"
+const (
+	maxCleanupIntervalMinutes = 7 * 24 * 60
+	maxUploadMaxAgeMinutes    = 30 * 24 * 60
+	// cleanupRunHistory is how long the stats of a run are kept
+	cleanupRunHistory = 30 * 24 * time.Hour
 )
 
-// CleanupService handles background cleanup of temporary uploads
+// CleanupService handles background cleanup of temporary uploads and expired sandbox boards, and enforces retention policies
+// Its schedule and upload retention start from the environment and can be changed at runtime through the admin API
 type CleanupService struct {
-	config         config.CleanupConfig
-	tempUploadRepo repo.TempUploadRepoInterface
-	gcsClient      *libraries.Clients
-	stopChan       chan struct{}
-	doneChan       chan struct{}
+	config           config.CleanupConfig
+	cleanupRepo      repo.CleanupRepoInterface
+	tempUploadRepo   repo.TempUploadRepoInterface
+	boardRepo        repo.BoardRepoInterface
+	gcsClient        *libraries.Clients
+	contentStore     *ContentStore
+	storageQuota     *StorageQuotaService
+	retentionService *RetentionService
+	mu               sync.Mutex // guards config, stored and nextRunAt
+	stored           bool       // the settings came from the database rather than the environment
+	nextRunAt        time.Time
+	runMu            sync.Mutex // held for the whole of a run, so scheduled and manual runs never overlap
+	running          atomic.Bool
+	started          bool
+	reconfigure      chan struct{}
+	stopChan         chan struct{}
+	doneChan         chan struct{}
+}
+
+// CleanupSettingsUpdate holds the settings to change; nil fields are left untouched
+type CleanupSettingsUpdate struct {
+	Enabled             *bool `json:"enabled"`
+	IntervalMinutes     *int  `json:"interval_minutes"`
+	UploadMaxAgeMinutes *int  `json:"upload_max_age_minutes"`
+}
+
+// CleanupStatus is the cleanup job as the admin API shows it
+type CleanupStatus struct {
+	Settings  models.CleanupSettings `json:"settings"`
+	Stored    bool                   `json:"stored"` // false while the environment's settings are used
+	Running   bool                   `json:"running"`
+	NextRunAt *time.Time             `json:"next_run_at,omitempty"`
+	LastRun   *models.CleanupRun     `json:"last_run,omitempty"`
 }
 
 // NewCleanupService creates a new cleanup service
 func NewCleanupService(
 	cfg config.CleanupConfig,
+	cleanupRepo repo.CleanupRepoInterface,
 	tempUploadRepo repo.TempUploadRepoInterface,
+	boardRepo repo.BoardRepoInterface,
 	gcsClient *libraries.Clients,
+	contentStore *ContentStore,
+	storageQuota *StorageQuotaService,
+	retentionService *RetentionService,
 ) *CleanupService {
 	return &CleanupService{
-		config:         cfg,
-		tempUploadRepo: tempUploadRepo,
-		gcsClient:      gcsClient,
-		stopChan:       make(chan struct{}),
-		doneChan:       make(chan struct{}),
+		config:           cfg,
+		cleanupRepo:      cleanupRepo,
+		tempUploadRepo:   tempUploadRepo,
+		boardRepo:        boardRepo,
+		gcsClient:        gcsClient,
+		contentStore:     contentStore,
+		storageQuota:     storageQuota,
+		retentionService: retentionService,
+		reconfigure:      make(chan struct{}, 1),
+		stopChan:         make(chan struct{}),
+		doneChan:         make(chan struct{}),
 	}
 }
 
-// Start launches the background cleanup goroutine
+var cleanupService *CleanupService
+
+// GetCleanupService returns the process-wide cleanup service created by InitCleanupService
+func GetCleanupService() *CleanupService {
+	return cleanupService
+}
+
+// InitCleanupService creates the process-wide cleanup service
+func InitCleanupService(
+	cfg config.CleanupConfig,
+	cleanupRepo repo.CleanupRepoInterface,
+	tempUploadRepo repo.TempUploadRepoInterface,
+	boardRepo repo.BoardRepoInterface,
+	gcsClient *libraries.Clients,
+	contentStore *ContentStore,
+	storageQuota *StorageQuotaService,
+	retentionService *RetentionService,
+) *CleanupService {
+	cleanupService = NewCleanupService(cfg, cleanupRepo, tempUploadRepo, boardRepo, gcsClient, contentStore, storageQuota, retentionService)
+	return cleanupService
+}
+
+// Start loads the stored settings and launches the background cleanup goroutine
+// The loop runs even while cleanup is disabled, so it can be enabled at runtime
 func (s *CleanupService) Start() {
-	if !s.config.Enabled {
+	s.loadStoredSettings()
+	s.started = true
+	go s.runCleanupLoop()
+
+	cfg := s.currentConfig()
+	if !cfg.Enabled {
 		log.Println("Cleanup service is disabled")
 		return
 	}
-
-	go s.runCleanupLoop()
-	log.Printf("Cleanup service started (interval: %v, max age: %v)", s.config.Interval, s.config.MaxAge)
+	log.Printf("Cleanup service started (interval: %v, max age: %v)", cfg.Interval, cfg.MaxAge)
 }
 
 // Stop gracefully shuts down the cleanup service
 func (s *CleanupService) Stop() {
-	if !s.config.Enabled {
+	if !s.started {
 		return
 	}
 
@@ -58,63 +140,271 @@ func (s *CleanupService) Stop() {
 	log.Println("Cleanup service stopped")
 }
 
-// runCleanupLoop runs the ticker-based cleanup loop
+// runCleanupLoop runs a pass every interval while cleanup is enabled
 func (s *CleanupService) runCleanupLoop() {
 	defer close(s.doneChan)
 
-	ticker := time.NewTicker(s.config.Interval)
-	defer ticker.Stop()
-
 	// Run cleanup immediately on start
-	s.cleanupExpiredUploads()
+	if s.currentConfig().Enabled {
+		s.run(models.CleanupTriggerScheduled)
+	}
+
+	timer := time.NewTimer(s.scheduleNextRun())
+	defer timer.Stop()
 
 	for {
 		select {
-		case <-ticker.C:
-			s.cleanupExpiredUploads()
+		case <-timer.C:
+			// settings changed through another instance reach this one at its next tick
+			s.loadStoredSettings()
+			if s.currentConfig().Enabled {
+				s.run(models.CleanupTriggerScheduled)
+			}
+		case <-s.reconfigure:
 		case <-s.stopChan:
 			return
 		}
+		timer.Reset(s.scheduleNextRun())
+	}
+}
+
+// scheduleNextRun records when the next run is due and returns the time until then
+func (s *CleanupService) scheduleNextRun() time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.nextRunAt = time.Now().Add(s.config.Interval)
+	return s.config.Interval
+}
+
+func (s *CleanupService) currentConfig() config.CleanupConfig {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.config
+}
+
+// loadStoredSettings replaces the environment's settings with the stored ones, if any
+func (s *CleanupService) loadStoredSettings() {
+	if s.cleanupRepo == nil {
+		return
+	}
+	settings, err := s.cleanupRepo.GetSettings()
+	if err != nil {
+		log.Printf("Cleanup: failed to load settings: %v", err)
+		return
+	}
+	if settings == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.applySettings(*settings)
+	s.stored = true
+}
+
+// applySettings copies settings into the running config; call with mu held
+func (s *CleanupService) applySettings(settings models.CleanupSettings) {
+	s.config.Enabled = settings.Enabled
+	s.config.Interval = time.Duration(settings.IntervalMinutes) * time.Minute
+	s.config.MaxAge = time.Duration(settings.UploadMaxAgeMinutes) * time.Minute
+}
+
+// settingsFromConfig describes a config as settings
+func settingsFromConfig(cfg config.CleanupConfig) models.CleanupSettings {
+	return models.CleanupSettings{
+		ID:                  models.CleanupSettingsID,
+		Enabled:             cfg.Enabled,
+		IntervalMinutes:     int(cfg.Interval / time.Minute),
+		UploadMaxAgeMinutes: int(cfg.MaxAge / time.Minute),
 	}
 }
 
-// cleanupExpiredUploads queries DB for expired uploads and deletes them from GCS and DB
-func (s *CleanupService) cleanupExpiredUploads() {
+func validateCleanupSettings(settings models.CleanupSettings) error {
+	if settings.IntervalMinutes < 1 || settings.IntervalMinutes > maxCleanupIntervalMinutes {
+		return fmt.Errorf("%w: interval_minutes must be between 1 and %d", ErrInvalidCleanupSettings, maxCleanupIntervalMinutes)
+	}
+	if settings.UploadMaxAgeMinutes < 1 || settings.UploadMaxAgeMinutes > maxUploadMaxAgeMinutes {
+		return fmt.Errorf("%w: upload_max_age_minutes must be between 1 and %d", ErrInvalidCleanupSettings, maxUploadMaxAgeMinutes)
+	}
+	return nil
+}
+
+// Status returns the current settings, when the next run is due and the stats of the last run
+func (s *CleanupService) Status() (*CleanupStatus, error) {
+	s.mu.Lock()
+	status := &CleanupStatus{
+		Settings: settingsFromConfig(s.config),
+		Stored:   s.stored,
+		Running:  s.running.Load(),
+	}
+	if s.config.Enabled && !s.nextRunAt.IsZero() {
+		next := s.nextRunAt
+		status.NextRunAt = &next
+	}
+	s.mu.Unlock()
+
+	runs, err := s.cleanupRepo.ListRuns(1)
+	if err != nil {
+		return nil, err
+	}
+	if len(runs) > 0 {
+		status.LastRun = &runs[0]
+	}
+	return status, nil
+}
+
+// UpdateSettings validates and stores new settings and applies them right away; the next run is
+// rescheduled from now with the new interval
+func (s *CleanupService) UpdateSettings(update CleanupSettingsUpdate) (*models.CleanupSettings, error) {
+	s.mu.Lock()
+	settings := settingsFromConfig(s.config)
+	s.mu.Unlock()
+
+	if update.Enabled != nil {
+		settings.Enabled = *update.Enabled
+	}
+	if update.IntervalMinutes != nil {
+		settings.IntervalMinutes = *update.IntervalMinutes
+	}
+	if update.UploadMaxAgeMinutes != nil {
+		settings.UploadMaxAgeMinutes = *update.UploadMaxAgeMinutes
+	}
+	if err := validateCleanupSettings(settings); err != nil {
+		return nil, err
+	}
+	if err := s.cleanupRepo.SaveSettings(&settings); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.applySettings(settings)
+	s.stored = true
+	s.mu.Unlock()
+
+	select {
+	case s.reconfigure <- struct{}{}:
+	default:
+	}
+	return &settings, nil
+}
+
+// ListRuns returns the stats of the latest runs, newest first
+func (s *CleanupService) ListRuns(limit int) ([]models.CleanupRun, error) {
+	return s.cleanupRepo.ListRuns(limit)
+}
+
+// RunNow runs a cleanup pass right away, even while the schedule is disabled, and returns its stats
+func (s *CleanupService) RunNow() (*models.CleanupRun, error) {
+	return s.run(models.CleanupTriggerManual)
+}
+
+// run runs one pass of every cleanup task and stores its stats
+func (s *CleanupService) run(trigger models.CleanupTrigger) (*models.CleanupRun, error) {
+	if !s.runMu.TryLock() {
+		return nil, ErrCleanupRunning
+	}
+	defer s.runMu.Unlock()
+	s.running.Store(true)
+	defer s.running.Store(false)
+
+	cfg := s.currentConfig()
+	run := &models.CleanupRun{Trigger: trigger, StartedAt: time.Now()}
+	var failures []string
+	if err := s.cleanupExpiredUploads(cfg.MaxAge, run); err != nil {
+		failures = append(failures, "uploads: "+err.Error())
+	}
+	if err := s.cleanupExpiredSandboxes(run); err != nil {
+		failures = append(failures, "sandboxes: "+err.Error())
+	}
+	if s.retentionService != nil {
+		applied, failed, err := s.retentionService.ApplyAll()
+		run.PoliciesApplied, run.PoliciesFailed = applied, failed
+		if err != nil {
+			failures = append(failures, "retention: "+err.Error())
+		}
+	}
+	run.DurationMs = time.Since(run.StartedAt).Milliseconds()
+	run.Errors = strings.Join(failures, "; ")
+
+	if s.cleanupRepo != nil {
+		if err := s.cleanupRepo.CreateRun(run); err != nil {
+			log.Printf("Cleanup: failed to store run stats: %v", err)
+		}
+		if err := s.cleanupRepo.DeleteRunsBefore(time.Now().Add(-cleanupRunHistory)); err != nil {
+			log.Printf("Cleanup: failed to prune run stats: %v", err)
+		}
+	}
+	return run, nil
+}
+
+// cleanupExpiredUploads queries DB for uploads older than maxAge, and for attachments of deleted messages,
+// and deletes them from GCS and DB, counting them in run
+func (s *CleanupService) cleanupExpiredUploads(maxAge time.Duration, run *models.CleanupRun) error {
 	ctx := context.Background()
 
 	// Get expired uploads from DB
-	expiredUploads, err := s.tempUploadRepo.GetExpired(s.config.MaxAge)
+	expiredUploads, err := s.tempUploadRepo.GetExpired(maxAge)
 	if err != nil {
 		log.Printf("Cleanup: failed to get expired uploads: %v", err)
-		return
+		return err
 	}
 
+	// attachments go with their message, e.g. when retention deletes it or a board is purged
+	orphaned, err := s.tempUploadRepo.GetOrphanedAttachments()
+	if err != nil {
+		log.Printf("Cleanup: failed to get attachments of deleted messages: %v", err)
+		return err
+	}
+	expiredUploads = append(expiredUploads, orphaned...)
+
 	if len(expiredUploads) == 0 {
 		log.Println("Cleanup: no expired uploads found")
-		return
+		return nil
 	}
 
-	log.Printf("Cleanup: found %d expired uploads to clean up", len(expiredUploads))
+	log.Printf("Cleanup: found %d expired uploads to clean up (%d attachments of deleted messages)", len(expiredUploads), len(orphaned))
 
-	// Track successfully deleted IDs
-	var deletedIDs []uuid.UUID
-
-	// Delete each file from GCS
-	for _, upload := range expiredUploads {
-		if err := s.gcsClient.Remove(ctx, upload.ObjectKey); err != nil {
-			log.Printf("Cleanup: failed to delete %s from GCS: %v", upload.ObjectKey, err)
-			continue
-		}
-		deletedIDs = append(deletedIDs, upload.UUID)
-		log.Printf("Cleanup: deleted %s from GCS", upload.ObjectKey)
-	}
+	deletedIDs := releaseUploads(ctx, s.gcsClient, s.contentStore, s.storageQuota, expiredUploads)
+	run.UploadsFailed = len(expiredUploads) - len(deletedIDs)
 
 	// Delete successfully removed records from DB
 	if len(deletedIDs) > 0 {
 		if err := s.tempUploadRepo.DeleteByIDs(deletedIDs); err != nil {
 			log.Printf("Cleanup: failed to delete DB records: %v", err)
-			return
+			return err
 		}
 		log.Printf("Cleanup: deleted %d records from database", len(deletedIDs))
 	}
+	run.UploadsDeleted = len(deletedIDs)
+	return nil
+}
+
+// cleanupExpiredSandboxes deletes the sandbox boards past their expiry, with their selection images, counting them in run
+func (s *CleanupService) cleanupExpiredSandboxes(run *models.CleanupRun) error {
+	ctx := context.Background()
+
+	boards, err := s.boardRepo.GetExpiredSandboxBoards(time.Now())
+	if err != nil {
+		log.Printf("Cleanup: failed to get expired sandbox boards: %v", err)
+		return err
+	}
+	if len(boards) == 0 {
+		return nil
+	}
+
+	deleted := 0
+	for _, board := range boards {
+		// sandboxes get no thumbnail, selection images are stored under the board's id
+		if err := s.gcsClient.RemovePrefix(ctx, libraries.StorageBucket(ctx), board.UUID.String()+"/"); err != nil {
+			log.Printf("Cleanup: failed to delete images of sandbox board %s: %v", board.UUID, err)
+			continue
+		}
+		if err := s.boardRepo.PurgeBoard(board.UUID); err != nil {
+			log.Printf("Cleanup: failed to delete sandbox board %s: %v", board.UUID, err)
+			continue
+		}
+		deleted++
+	}
+	log.Printf("Cleanup: deleted %d of %d expired sandbox boards", deleted, len(boards))
+	run.SandboxesDeleted, run.SandboxesFailed = deleted, len(boards)-deleted
+	return nil
 }
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"errors"
+	"melina-studio-backend/internal/config"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+	"testing"
+	"time"
+)
+
+// fakeCleanupRepo keeps the cleanup settings and runs in memory
+type fakeCleanupRepo struct {
+	repo.CleanupRepoInterface
+	settings *models.CleanupSettings
+	runs     []models.CleanupRun
+}
+
+func (r *fakeCleanupRepo) GetSettings() (*models.CleanupSettings, error) {
+	return r.settings, nil
+}
+
+func (r *fakeCleanupRepo) SaveSettings(settings *models.CleanupSettings) error {
+	stored := *settings
+	r.settings = &stored
+	return nil
+}
+
+func (r *fakeCleanupRepo) ListRuns(limit int) ([]models.CleanupRun, error) {
+	runs := r.runs
+	if len(runs) > limit {
+		runs = runs[:limit]
+	}
+	return runs, nil
+}
+
+func newTestCleanupService(cleanupRepo *fakeCleanupRepo) *CleanupService {
+	cfg := config.CleanupConfig{Enabled: true, Interval: time.Hour, MaxAge: 30 * time.Minute, SandboxTTL: 24 * time.Hour}
+	return NewCleanupService(cfg, cleanupRepo, nil, nil, nil, nil, nil, nil)
+}
+
+func TestCleanupUpdateSettings(t *testing.T) {
+	cleanupRepo := &fakeCleanupRepo{}
+	s := newTestCleanupService(cleanupRepo)
+
+	settings, err := s.UpdateSettings(CleanupSettingsUpdate{IntervalMinutes: intPtr(15)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !settings.Enabled || settings.IntervalMinutes != 15 || settings.UploadMaxAgeMinutes != 30 {
+		t.Errorf("expected only the interval to change, got %+v", settings)
+	}
+	if cleanupRepo.settings == nil || cleanupRepo.settings.IntervalMinutes != 15 {
+		t.Errorf("expected the settings to be stored, got %+v", cleanupRepo.settings)
+	}
+	if cfg := s.currentConfig(); cfg.Interval != 15*time.Minute || cfg.MaxAge != 30*time.Minute {
+		t.Errorf("expected the new interval to apply right away, got %+v", cfg)
+	}
+	select {
+	case <-s.reconfigure:
+	default:
+		t.Error("expected the loop to be told to reschedule")
+	}
+
+	for _, update := range []CleanupSettingsUpdate{
+		{IntervalMinutes: intPtr(0)},
+		{IntervalMinutes: intPtr(maxCleanupIntervalMinutes + 1)},
+		{UploadMaxAgeMinutes: intPtr(-5)},
+	} {
+		if _, err := s.UpdateSettings(update); !errors.Is(err, ErrInvalidCleanupSettings) {
+			t.Errorf("expected ErrInvalidCleanupSettings for %+v, got %v", update, err)
+		}
+	}
+	if cleanupRepo.settings.IntervalMinutes != 15 {
+		t.Errorf("expected invalid settings not to be stored, got %+v", cleanupRepo.settings)
+	}
+}
+
+func TestCleanupLoadStoredSettings(t *testing.T) {
+	cleanupRepo := &fakeCleanupRepo{}
+	s := newTestCleanupService(cleanupRepo)
+	s.loadStoredSettings()
+	if status, _ := s.Status(); status.Stored || status.Settings.IntervalMinutes != 60 {
+		t.Errorf("expected the environment's settings without stored ones, got %+v", status)
+	}
+
+	cleanupRepo.settings = &models.CleanupSettings{Enabled: false, IntervalMinutes: 5, UploadMaxAgeMinutes: 120}
+	s.loadStoredSettings()
+	cfg := s.currentConfig()
+	if cfg.Enabled || cfg.Interval != 5*time.Minute || cfg.MaxAge != 2*time.Hour || cfg.SandboxTTL != 24*time.Hour {
+		t.Errorf("expected the stored settings to replace the environment's, got %+v", cfg)
+	}
+}
+
+func TestCleanupStatus(t *testing.T) {
+	cleanupRepo := &fakeCleanupRepo{runs: []models.CleanupRun{{Trigger: models.CleanupTriggerManual, UploadsDeleted: 3}}}
+	s := newTestCleanupService(cleanupRepo)
+	s.scheduleNextRun()
+
+	status, err := s.Status()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status.LastRun == nil || status.LastRun.UploadsDeleted != 3 || status.NextRunAt == nil || status.Running {
+		t.Errorf("unexpected status %+v", status)
+	}
+}
+
+func TestCleanupRunsDoNotOverlap(t *testing.T) {
+	s := newTestCleanupService(&fakeCleanupRepo{})
+	s.runMu.Lock()
+	defer s.runMu.Unlock()
+	if _, err := s.RunNow(); !errors.Is(err, ErrCleanupRunning) {
+		t.Errorf("expected ErrCleanupRunning while a run is in progress, got %v", err)
+	}
+}
@@ -0,0 +1,164 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"melina-studio-backend/internal/libraries"
+	llmHandlers "melina-studio-backend/internal/llm_handlers"
+	"melina-studio-backend/internal/melina/agents"
+	"melina-studio-backend/internal/melina/prompts"
+	"melina-studio-backend/internal/melina/tools"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrInvalidCodeFramework = errors.New("invalid code framework")
+	ErrFrameNotFound        = errors.New("frame not found")
+	ErrEmptyFrame           = errors.New("frame has no shapes")
+)
+
+const codeExportTimeout = 3 * time.Minute
+
+type CodeExportService struct {
+	artifactRepo  repo.CodeArtifactRepoInterface
+	boardDataRepo repo.BoardDataRepoInterface
+	hub           *libraries.Hub
+}
+
+func NewCodeExportService(artifactRepo repo.CodeArtifactRepoInterface, boardDataRepo repo.BoardDataRepoInterface, hub *libraries.Hub) *CodeExportService {
+	return &CodeExportService{
+		artifactRepo:  artifactRepo,
+		boardDataRepo: boardDataRepo,
+		hub:           hub,
+	}
+}
+
+// ExportFrame generates component code for a frame and stores it as an artifact
+// Chunks are streamed to the user's open websocket connections while the code is generated
+func (s *CodeExportService) ExportFrame(ctx context.Context, userID uuid.UUID, boardID uuid.UUID, frameID uuid.UUID, framework models.CodeFramework, modelName string) (*models.CodeArtifact, error) {
+	if framework == "" {
+		framework = models.CodeFrameworkReact
+	}
+	instructions, ok := prompts.CODE_EXPORT_FRAMEWORK_INSTRUCTIONS[string(framework)]
+	if !ok {
+		return nil, ErrInvalidCodeFramework
+	}
+	if modelName == "" {
+		modelName = llmHandlers.DefaultModel()
+	}
+	modelName, modelInfo, err := llmHandlers.ResolveHealthyModel(modelName, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	shapes, err := s.boardDataRepo.GetBoardData(boardID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get board data: %w", err)
+	}
+	var frame *models.BoardData
+	for i := range shapes {
+		if shapes[i].UUID == frameID && shapes[i].Type == models.Frame {
+			frame = &shapes[i]
+			break
+		}
+	}
+	if frame == nil {
+		return nil, ErrFrameNotFound
+	}
+
+	spec, err := tools.BuildFrameSpec(*frame, shapes)
+	if err != nil {
+		return nil, err
+	}
+	if len(spec.Components) == 0 {
+		return nil, ErrEmptyFrame
+	}
+	input, err := spec.JSON()
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode frame: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, codeExportTimeout)
+	defer cancel()
+	ctx = WithLLMPrivacy(ctx, userID, llmHandlers.DataClassBoard)
+
+	code, err := s.generate(ctx, userID, boardID, frameID, modelInfo, fmt.Sprintf(prompts.CODE_EXPORT_PROMPT, instructions), input)
+	if err != nil {
+		return nil, err
+	}
+
+	artifact := &models.CodeArtifact{
+		BoardID:   boardID,
+		UserID:    userID,
+		FrameID:   frameID,
+		FrameName: spec.Name,
+		Framework: framework,
+		ModelName: modelName,
+		Code:      code,
+	}
+	if err := s.artifactRepo.Create(artifact); err != nil {
+		return nil, err
+	}
+
+	if s.hub != nil {
+		libraries.SendCodeExportMessage(s.hub, userID.String(), libraries.WebSocketMessageTypeCodeExportDone, &libraries.CodeExportPayload{
+			BoardId:    boardID.String(),
+			FrameId:    frameID.String(),
+			ArtifactId: artifact.UUID.String(),
+		})
+	}
+	return artifact, nil
+}
+
+// generate runs the LLM, relaying streamed chunks to the user when a hub is available
+func (s *CodeExportService) generate(ctx context.Context, userID uuid.UUID, boardID uuid.UUID, frameID uuid.UUID, modelInfo *llmHandlers.ModelInfo, systemMessage string, input string) (string, error) {
+	agent, err := agents.NewTextAgent(modelInfo, nil, nil)
+	if err != nil {
+		return "", err
+	}
+
+	var code string
+	if s.hub == nil {
+		code, err = agent.Complete(ctx, systemMessage, input)
+	} else {
+		relay, stop := libraries.NewRelayClient(userID.String(), func(chunk string) {
+			libraries.SendCodeExportMessage(s.hub, userID.String(), libraries.WebSocketMessageTypeCodeExportChunk, &libraries.CodeExportPayload{
+				BoardId: boardID.String(),
+				FrameId: frameID.String(),
+				Chunk:   chunk,
+			})
+		})
+		code, err = agent.CompleteStream(ctx, s.hub, relay, boardID.String(), systemMessage, input)
+		stop()
+	}
+	if err != nil {
+		return "", err
+	}
+
+	code = stripCodeFence(code)
+	if code == "" {
+		return "", fmt.Errorf("model returned no code")
+	}
+	return code, nil
+}
+
+// stripCodeFence removes a markdown code fence wrapping the whole reply, including its language tag
+func stripCodeFence(text string) string {
+	text = strings.TrimSpace(text)
+	if !strings.HasPrefix(text, "```") {
+		return text
+	}
+	if newline := strings.Index(text, "\n"); newline >= 0 {
+		text = text[newline+1:]
+	} else {
+		text = strings.TrimPrefix(text, "```")
+	}
+	text = strings.TrimSuffix(strings.TrimSpace(text), "```")
+	return strings.TrimSpace(text)
+}
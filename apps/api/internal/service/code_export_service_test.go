@@ -0,0 +1,16 @@
+package service
+
+import "testing"
+
+func TestStripCodeFence(t *testing.T) {
+	cases := map[string]string{
+		"```tsx\nexport default function A() {}\n```": "export default function A() {}",
+		"```\n<html></html>\n```":                     "<html></html>",
+		"  <div></div>  ":                             "<div></div>",
+	}
+	for in, want := range cases {
+		if got := stripCodeFence(in); got != want {
+			t.Errorf("stripCodeFence(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"melina-studio-backend/internal/libraries"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+	"mime"
+	"path"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+// ContentStore uploads images once per content hash and counts the references to them, so the same
+// reference image used across chats and boards takes one object in the bucket
+type ContentStore struct {
+	storedObjectRepo repo.StoredObjectRepoInterface
+	gcsClient        *libraries.Clients
+}
+
+// NewContentStore creates a new content store
+func NewContentStore(storedObjectRepo repo.StoredObjectRepoInterface, gcsClient *libraries.Clients) *ContentStore {
+	return &ContentStore{storedObjectRepo: storedObjectRepo, gcsClient: gcsClient}
+}
+
+// Store uploads the content unless the bucket already holds the same bytes, and adds a reference to it
+func (s *ContentStore) Store(ctx context.Context, data []byte, contentType string, filename string) (*models.StoredObject, error) {
+	contentHash := ContentHash(data)
+	bucket := libraries.StorageBucket(ctx)
+
+	object, err := s.storedObjectRepo.Get(bucket, contentHash)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, fmt.Errorf("failed to look up stored object: %w", err)
+	}
+	if object == nil {
+		object = &models.StoredObject{
+			ContentHash: contentHash,
+			Bucket:      bucket,
+			ObjectKey:   ContentObjectKey(contentHash, contentType, filename),
+		}
+		// an upload racing this one writes the same bytes to the same key, so both may upload
+		url, err := s.gcsClient.Upload(ctx, object.ObjectKey, bytes.NewReader(data), contentType)
+		if err != nil {
+			return nil, err
+		}
+		object.URL = url
+	} else {
+		log.Printf("Content store: reusing %s for identical content", object.ObjectKey)
+	}
+
+	if err := s.storedObjectRepo.AddRef(object); err != nil {
+		return nil, fmt.Errorf("failed to reference stored object: %w", err)
+	}
+	return object, nil
+}
+
+// Release drops a reference and deletes the object from the bucket once nothing references it
+func (s *ContentStore) Release(ctx context.Context, bucket string, contentHash string) error {
+	object, err := s.storedObjectRepo.Get(bucket, contentHash)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		// already gone, there is nothing left to release
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to look up stored object: %w", err)
+	}
+	remaining, err := s.storedObjectRepo.Release(bucket, contentHash)
+	if err != nil {
+		return fmt.Errorf("failed to release stored object: %w", err)
+	}
+	if remaining > 0 {
+		return nil
+	}
+
+	if err := s.gcsClient.Remove(libraries.WithStorageBucket(ctx, bucket), object.ObjectKey); err != nil {
+		return err
+	}
+	return s.storedObjectRepo.Delete(bucket, contentHash)
+}
+
+// ContentHash is the hex SHA-256 that identifies stored content
+func ContentHash(data []byte) string {
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:])
+}
+
+// ContentObjectKey names objects by their hash: content/{hash}{ext}, the extension taken from the
+// uploaded file name or else the content type
+func ContentObjectKey(contentHash string, contentType string, filename string) string {
+	ext := strings.ToLower(path.Ext(filename))
+	if ext == "" {
+		if exts, err := mime.ExtensionsByType(contentType); err == nil && len(exts) > 0 {
+			ext = exts[0]
+		}
+	}
+	return "content/" + contentHash + ext
+}
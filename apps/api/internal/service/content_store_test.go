@@ -0,0 +1,34 @@
+package service
+
+import "testing"
+
+func TestContentHash(t *testing.T) {
+	// SHA-256 of "abc"
+	want := "ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"
+	if got := ContentHash([]byte("abc")); got != want {
+		t.Errorf("ContentHash = %s, want %s", got, want)
+	}
+	if ContentHash([]byte("abc")) == ContentHash([]byte("abd")) {
+		t.Error("different content must hash differently")
+	}
+}
+
+func TestContentObjectKey(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		filename    string
+		want        string
+	}{
+		{"extension from the file name", "image/png", "Sketch.PNG", "content/abc.png"},
+		{"extension from the content type", "image/png", "sketch", "content/abc.png"},
+		{"no extension", "", "sketch", "content/abc"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ContentObjectKey("abc", tt.contentType, tt.filename); got != tt.want {
+				t.Errorf("ContentObjectKey = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
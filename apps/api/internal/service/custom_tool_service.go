@@ -0,0 +1,215 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"melina-studio-backend/internal/config"
+	"melina-studio-backend/internal/melina/tools"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+	"strings"
+	"sync"
+	"time"
+
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidCustomTool  = tools.ErrInvalidCustomTool
+	ErrCustomToolNotFound = errors.New("custom tool not found")
+)
+
+// CustomToolInput is a custom tool as admins define it; a PUT replaces every field
+type CustomToolInput struct {
+	Description      string            `json:"description"`
+	Parameters       json.RawMessage   `json:"parameters"`
+	Method           string            `json:"method"`
+	URL              string            `json:"url"`
+	Headers          map[string]string `json:"headers"`
+	TimeoutSeconds   int               `json:"timeout_seconds"`
+	RequiresApproval bool              `json:"requires_approval"`
+	// Enabled defaults to true
+	Enabled *bool `json:"enabled"`
+}
+
+// CustomToolService manages the declarative HTTP tools and keeps the agent's set of them current on every
+// instance: changes apply at once on the instance that made them and within ReloadInterval on the others
+type CustomToolService struct {
+	cfg      config.CustomToolConfig
+	toolRepo repo.CustomToolRepoInterface
+	executor *tools.HTTPToolExecutor
+
+	mu       sync.Mutex
+	started  bool
+	stopChan chan struct{}
+	doneChan chan struct{}
+}
+
+var customToolService *CustomToolService
+
+// GetCustomToolService returns the process-wide custom tool service
+func GetCustomToolService() *CustomToolService {
+	return customToolService
+}
+
+// InitCustomToolService creates the process-wide custom tool service
+func InitCustomToolService(cfg config.CustomToolConfig, toolRepo repo.CustomToolRepoInterface) *CustomToolService {
+	customToolService = NewCustomToolService(cfg, toolRepo)
+	return customToolService
+}
+
+func NewCustomToolService(cfg config.CustomToolConfig, toolRepo repo.CustomToolRepoInterface) *CustomToolService {
+	return &CustomToolService{
+		cfg:      cfg,
+		toolRepo: toolRepo,
+		executor: tools.NewHTTPToolExecutor(cfg),
+		stopChan: make(chan struct{}),
+		doneChan: make(chan struct{}),
+	}
+}
+
+// Start loads the custom tools and reloads them every ReloadInterval; without CUSTOM_TOOLS_ENABLED it does nothing
+func (s *CustomToolService) Start() {
+	if !s.cfg.Enabled {
+		log.Println("Custom tools are disabled")
+		return
+	}
+	if err := s.Reload(); err != nil {
+		log.Printf("Failed to load custom tools: %v", err)
+	}
+	if s.cfg.ReloadInterval <= 0 {
+		return
+	}
+	s.started = true
+	go s.runReloadLoop()
+}
+
+// Stop ends the reload loop
+func (s *CustomToolService) Stop() {
+	if !s.started {
+		return
+	}
+	close(s.stopChan)
+	<-s.doneChan
+}
+
+func (s *CustomToolService) runReloadLoop() {
+	defer close(s.doneChan)
+	ticker := time.NewTicker(s.cfg.ReloadInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-s.stopChan:
+			return
+		case <-ticker.C:
+			if err := s.Reload(); err != nil {
+				log.Printf("Failed to reload custom tools: %v", err)
+			}
+		}
+	}
+}
+
+// Reload gives the agent the enabled custom tools as they are stored. A tool that no longer passes validation,
+// e.g. after its host was removed from CUSTOM_TOOLS_ALLOWED_HOSTS, is left out and logged
+func (s *CustomToolService) Reload() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.cfg.Enabled {
+		tools.SetCustomTools(nil, s.executor)
+		return nil
+	}
+
+	stored, err := s.toolRepo.ListEnabled()
+	if err != nil {
+		return err
+	}
+	specs := make([]tools.CustomToolSpec, 0, len(stored))
+	for _, tool := range stored {
+		spec, err := tools.CustomToolSpecFrom(tool)
+		if err == nil {
+			err = tools.ValidateCustomTool(s.cfg, spec)
+		}
+		if err != nil {
+			log.Printf("Skipping custom tool %s: %v", tool.Name, err)
+			continue
+		}
+		specs = append(specs, spec)
+	}
+	tools.SetCustomTools(specs, s.executor)
+	return nil
+}
+
+// List returns every custom tool, enabled or not
+func (s *CustomToolService) List() ([]models.CustomTool, error) {
+	return s.toolRepo.List()
+}
+
+// Set creates or replaces the custom tool of the name and reloads the agent's tools
+func (s *CustomToolService) Set(name string, input CustomToolInput) (*models.CustomTool, error) {
+	name = strings.TrimSpace(name)
+	method := strings.ToUpper(strings.TrimSpace(input.Method))
+	if method == "" {
+		method = "POST"
+	}
+	enabled := true
+	if input.Enabled != nil {
+		enabled = *input.Enabled
+	}
+
+	tool := models.CustomTool{
+		Name:             name,
+		Description:      strings.TrimSpace(input.Description),
+		Parameters:       datatypes.JSON(input.Parameters),
+		Method:           method,
+		URL:              strings.TrimSpace(input.URL),
+		TimeoutSeconds:   input.TimeoutSeconds,
+		RequiresApproval: input.RequiresApproval,
+		Enabled:          enabled,
+	}
+	if len(input.Headers) > 0 {
+		headers, err := json.Marshal(input.Headers)
+		if err != nil {
+			return nil, err
+		}
+		tool.Headers = datatypes.JSON(headers)
+	}
+
+	spec, err := tools.CustomToolSpecFrom(tool)
+	if err != nil {
+		return nil, err
+	}
+	if err := tools.ValidateCustomTool(s.cfg, spec); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.toolRepo.GetByName(name)
+	switch {
+	case err == nil:
+		tool.UUID = existing.UUID
+		tool.CreatedAt = existing.CreatedAt
+		err = s.toolRepo.Update(&tool)
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		err = s.toolRepo.Create(&tool)
+	}
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Reload(); err != nil {
+		return nil, fmt.Errorf("saved %s but failed to reload the tools: %w", name, err)
+	}
+	return &tool, nil
+}
+
+// Delete removes the custom tool and takes it away from the agent
+func (s *CustomToolService) Delete(name string) error {
+	if err := s.toolRepo.Delete(name); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrCustomToolNotFound
+		}
+		return err
+	}
+	return s.Reload()
+}
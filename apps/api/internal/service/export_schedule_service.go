@@ -0,0 +1,677 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"net/url"
+	"path"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"melina-studio-backend/internal/config"
+	"melina-studio-backend/internal/libraries"
+	"melina-studio-backend/internal/melina/tools"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidExportSchedule  = errors.New("invalid export schedule")
+	ErrExportScheduleNotFound = errors.New("export schedule not found")
+	ErrTooManyExportSchedules = errors.New("the board has the most export schedules allowed")
+)
+
+const (
+	// exportRunHistorySize is how many runs of a schedule the log returns
+	exportRunHistorySize = 50
+	// exportDuePerPoll caps the schedules one poll starts; the rest are picked up by the next one
+	exportDuePerPoll = 100
+	// exportErrorLimit caps the error kept with a run
+	exportErrorLimit = 2000
+)
+
+// exportBucketName matches the bucket names GCS and S3 both accept
+var exportBucketName = regexp.MustCompile(`^[a-z0-9][a-z0-9._-]{1,220}[a-z0-9]$`)
+
+// exportRegion matches AWS region names such as eu-west-1
+var exportRegion = regexp.MustCompile(`^[a-z0-9-]{1,30}$`)
+
+// ExportTarget is where a schedule's exports go, without secrets
+type ExportTarget struct {
+	// Bucket and Prefix are the object location on gcs and s3
+	Bucket string `json:"bucket,omitempty"`
+	Prefix string `json:"prefix,omitempty"`
+	// Region is the bucket's region on s3, us-east-1 by default
+	Region string `json:"region,omitempty"`
+	// Endpoint is an S3-compatible service's URL (R2, MinIO, ...); empty means AWS
+	Endpoint string `json:"endpoint,omitempty"`
+	// URL receives the export as a POST on webhook
+	URL string `json:"url,omitempty"`
+}
+
+// ExportCredentials are the secrets of a destination; they are stored encrypted and never returned
+type ExportCredentials struct {
+	// ServiceAccountKey is the JSON key of a service account that can write to the gcs bucket
+	ServiceAccountKey json.RawMessage `json:"service_account_key,omitempty"`
+	// AccessKeyID and SecretAccessKey are an access key that can put objects in the s3 bucket
+	AccessKeyID     string `json:"access_key_id,omitempty"`
+	SecretAccessKey string `json:"secret_access_key,omitempty"`
+	// WebhookSecret, when set, signs the webhook's requests (X-Melina-Signature)
+	WebhookSecret string `json:"webhook_secret,omitempty"`
+}
+
+// ExportScheduleInput is a schedule as the board owner defines it; a PUT replaces every field
+type ExportScheduleInput struct {
+	Format    models.ExportFormat    `json:"format"`
+	Frequency models.ExportFrequency `json:"frequency"`
+	// Hour is the UTC hour to run at, EXPORT_SCHEDULES_DEFAULT_HOUR_UTC by default
+	Hour *int `json:"hour"`
+	// Weekday is the day weekly exports run on, 0 being Sunday; Monday by default
+	Weekday     *int                     `json:"weekday"`
+	Destination models.ExportDestination `json:"destination"`
+	Target      ExportTarget             `json:"target"`
+	// Credentials replace the stored ones; an update without them keeps the stored ones if the destination stays the same
+	Credentials *ExportCredentials `json:"credentials"`
+	// Enabled defaults to true
+	Enabled *bool `json:"enabled"`
+}
+
+// exportFile is a rendered export
+type exportFile struct {
+	data        []byte
+	contentType string
+	extension   string
+}
+
+// ExportScheduleService manages the boards' export schedules and pushes the due exports to their destinations.
+// Each instance polls for due schedules and claims them before queueing them, so one export runs once
+// however many instances there are
+type ExportScheduleService struct {
+	cfg           config.ExportScheduleConfig
+	scheduleRepo  repo.ExportScheduleRepoInterface
+	boardRepo     repo.BoardRepoInterface
+	boardDataRepo repo.BoardDataRepoInterface
+	exports       *BoardExportService
+	jobs          *JobQueue
+	uploads       *tools.HTTPToolExecutor
+	stopChan      chan struct{}
+	doneChan      chan struct{}
+}
+
+func NewExportScheduleService(
+	cfg config.ExportScheduleConfig,
+	scheduleRepo repo.ExportScheduleRepoInterface,
+	boardRepo repo.BoardRepoInterface,
+	boardDataRepo repo.BoardDataRepoInterface,
+	exports *BoardExportService,
+	jobs *JobQueue,
+) *ExportScheduleService {
+	return &ExportScheduleService{
+		cfg:           cfg,
+		scheduleRepo:  scheduleRepo,
+		boardRepo:     boardRepo,
+		boardDataRepo: boardDataRepo,
+		exports:       exports,
+		jobs:          jobs,
+		uploads:       tools.NewHTTPToolExecutor(cfg.Destinations),
+		stopChan:      make(chan struct{}),
+		doneChan:      make(chan struct{}),
+	}
+}
+
+var exportScheduleService *ExportScheduleService
+
+// GetExportScheduleService returns the process-wide export schedule service created by InitExportScheduleService
+func GetExportScheduleService() *ExportScheduleService {
+	return exportScheduleService
+}
+
+// InitExportScheduleService creates the process-wide export schedule service
+func InitExportScheduleService(
+	cfg config.ExportScheduleConfig,
+	scheduleRepo repo.ExportScheduleRepoInterface,
+	boardRepo repo.BoardRepoInterface,
+	boardDataRepo repo.BoardDataRepoInterface,
+	exports *BoardExportService,
+	jobs *JobQueue,
+) *ExportScheduleService {
+	exportScheduleService = NewExportScheduleService(cfg, scheduleRepo, boardRepo, boardDataRepo, exports, jobs)
+	return exportScheduleService
+}
+
+// Start launches the goroutine that queues the due exports
+func (s *ExportScheduleService) Start() {
+	if !s.cfg.Enabled {
+		log.Println("Scheduled exports are disabled")
+		return
+	}
+
+	go s.runScheduleLoop()
+	log.Printf("Export schedule service started (poll interval: %v)", s.cfg.PollInterval)
+}
+
+// Stop gracefully shuts down the export schedule service; queued exports are stopped with the job queue
+func (s *ExportScheduleService) Stop() {
+	if !s.cfg.Enabled {
+		return
+	}
+
+	log.Println("Stopping export schedule service...")
+	close(s.stopChan)
+	<-s.doneChan
+	log.Println("Export schedule service stopped")
+}
+
+func (s *ExportScheduleService) runScheduleLoop() {
+	defer close(s.doneChan)
+
+	ticker := time.NewTicker(s.cfg.PollInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			s.queueDue(time.Now())
+		case <-s.stopChan:
+			return
+		}
+	}
+}
+
+// queueDue claims the due schedules, moving each to its next run, and queues their exports
+func (s *ExportScheduleService) queueDue(now time.Time) {
+	due, err := s.scheduleRepo.ListDue(now, exportDuePerPoll)
+	if err != nil {
+		log.Printf("[export] failed to list due schedules: %v", err)
+		return
+	}
+	for _, schedule := range due {
+		next := NextExportRun(schedule.Frequency, schedule.Hour, schedule.Weekday, now)
+		claimed, err := s.scheduleRepo.Claim(schedule.UUID, schedule.NextRunAt, next)
+		if err != nil {
+			log.Printf("[export] failed to claim schedule %s: %v", schedule.UUID, err)
+			continue
+		}
+		if claimed {
+			s.enqueue(schedule)
+		}
+	}
+}
+
+// List returns the board's schedules
+func (s *ExportScheduleService) List(boardID uuid.UUID) ([]models.ExportSchedule, error) {
+	schedules, err := s.scheduleRepo.ListSchedules(boardID)
+	if err != nil {
+		return nil, err
+	}
+	if schedules == nil {
+		schedules = []models.ExportSchedule{}
+	}
+	for i := range schedules {
+		schedules[i].HasCredentials = schedules[i].Credentials != ""
+	}
+	return schedules, nil
+}
+
+// Create adds a schedule to the board, up to MaxPerBoard
+func (s *ExportScheduleService) Create(userID uuid.UUID, boardID uuid.UUID, input ExportScheduleInput) (*models.ExportSchedule, error) {
+	count, err := s.scheduleRepo.CountSchedules(boardID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= int64(s.cfg.MaxPerBoard) {
+		return nil, fmt.Errorf("%w (%d)", ErrTooManyExportSchedules, s.cfg.MaxPerBoard)
+	}
+	schedule := &models.ExportSchedule{BoardID: boardID, UserID: userID}
+	if err := s.apply(schedule, input); err != nil {
+		return nil, err
+	}
+	if err := s.scheduleRepo.CreateSchedule(schedule); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// Update replaces a schedule of the board
+func (s *ExportScheduleService) Update(boardID uuid.UUID, scheduleID uuid.UUID, input ExportScheduleInput) (*models.ExportSchedule, error) {
+	schedule, err := s.getSchedule(boardID, scheduleID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.apply(schedule, input); err != nil {
+		return nil, err
+	}
+	if err := s.scheduleRepo.UpdateSchedule(schedule); err != nil {
+		return nil, err
+	}
+	return schedule, nil
+}
+
+// Delete removes a schedule of the board with its delivery log
+func (s *ExportScheduleService) Delete(boardID uuid.UUID, scheduleID uuid.UUID) error {
+	err := s.scheduleRepo.DeleteSchedule(boardID, scheduleID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrExportScheduleNotFound
+	}
+	return err
+}
+
+// RunNow queues an export of the schedule outside its schedule, e.g. to check the destination works
+func (s *ExportScheduleService) RunNow(boardID uuid.UUID, scheduleID uuid.UUID) error {
+	schedule, err := s.getSchedule(boardID, scheduleID)
+	if err != nil {
+		return err
+	}
+	return s.jobs.Enqueue("export:"+schedule.UUID.String(), func(ctx context.Context) error {
+		s.run(ctx, *schedule)
+		return nil
+	})
+}
+
+// Runs returns the latest runs of a schedule of the board, newest first
+func (s *ExportScheduleService) Runs(boardID uuid.UUID, scheduleID uuid.UUID) ([]models.ExportRun, error) {
+	if _, err := s.getSchedule(boardID, scheduleID); err != nil {
+		return nil, err
+	}
+	runs, err := s.scheduleRepo.ListRuns(scheduleID, exportRunHistorySize)
+	if err != nil {
+		return nil, err
+	}
+	if runs == nil {
+		runs = []models.ExportRun{}
+	}
+	return runs, nil
+}
+
+func (s *ExportScheduleService) getSchedule(boardID uuid.UUID, scheduleID uuid.UUID) (*models.ExportSchedule, error) {
+	schedule, err := s.scheduleRepo.GetSchedule(boardID, scheduleID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrExportScheduleNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	schedule.HasCredentials = schedule.Credentials != ""
+	return schedule, nil
+}
+
+// apply validates the input and copies it onto the schedule, encrypting new credentials
+func (s *ExportScheduleService) apply(schedule *models.ExportSchedule, input ExportScheduleInput) error {
+	keepCredentials := input.Credentials == nil && schedule.Credentials != "" && schedule.Destination == input.Destination
+	input, err := normalizeExportScheduleInput(input, s.cfg, keepCredentials)
+	if err != nil {
+		return err
+	}
+	target, err := json.Marshal(input.Target)
+	if err != nil {
+		return err
+	}
+	if !keepCredentials {
+		schedule.Credentials = ""
+		if input.Credentials != nil {
+			sealed, err := sealExportCredentials(*input.Credentials)
+			if err != nil {
+				return err
+			}
+			schedule.Credentials = sealed
+		}
+	}
+
+	timing := schedule.Frequency != input.Frequency || schedule.Hour != *input.Hour || schedule.Weekday != *input.Weekday
+	schedule.Format = input.Format
+	schedule.Frequency = input.Frequency
+	schedule.Hour = *input.Hour
+	schedule.Weekday = *input.Weekday
+	schedule.Destination = input.Destination
+	schedule.Target = datatypes.JSON(target)
+	schedule.Enabled = input.Enabled == nil || *input.Enabled
+	schedule.HasCredentials = schedule.Credentials != ""
+	if timing || schedule.NextRunAt.IsZero() {
+		schedule.NextRunAt = NextExportRun(schedule.Frequency, schedule.Hour, schedule.Weekday, time.Now())
+	}
+	return nil
+}
+
+// sealExportCredentials encrypts the credentials with CREDENTIALS_ENCRYPTION_KEY
+func sealExportCredentials(creds ExportCredentials) (string, error) {
+	key, err := config.GetSettings().Auth.CredentialsKeyBytes()
+	if err != nil {
+		return "", fmt.Errorf("%w: credentials can't be stored: %v", ErrInvalidExportSchedule, err)
+	}
+	data, err := json.Marshal(creds)
+	if err != nil {
+		return "", err
+	}
+	return libraries.SealCredentials(key, data)
+}
+
+// openExportCredentials decrypts the credentials of a schedule; a schedule without any has empty ones
+func openExportCredentials(sealed string) (ExportCredentials, error) {
+	var creds ExportCredentials
+	if sealed == "" {
+		return creds, nil
+	}
+	key, err := config.GetSettings().Auth.CredentialsKeyBytes()
+	if err != nil {
+		return creds, err
+	}
+	data, err := libraries.OpenCredentials(key, sealed)
+	if err != nil {
+		return creds, err
+	}
+	err = json.Unmarshal(data, &creds)
+	return creds, err
+}
+
+// normalizeExportScheduleInput checks a schedule and trims its fields; only the target fields and credentials
+// of the schedule's destination are kept. keepCredentials is set when the stored credentials stay in use
+func normalizeExportScheduleInput(input ExportScheduleInput, cfg config.ExportScheduleConfig, keepCredentials bool) (ExportScheduleInput, error) {
+	switch input.Format {
+	case models.ExportFormatPNG, models.ExportFormatSVG, models.ExportFormatJSON:
+	default:
+		return input, fmt.Errorf("%w: format must be %s, %s or %s", ErrInvalidExportSchedule, models.ExportFormatPNG, models.ExportFormatSVG, models.ExportFormatJSON)
+	}
+
+	hour, weekday := cfg.DefaultHour, 0
+	if input.Hour != nil {
+		hour = *input.Hour
+	}
+	if hour < 0 || hour > 23 {
+		return input, fmt.Errorf("%w: hour must be between 0 and 23", ErrInvalidExportSchedule)
+	}
+	switch input.Frequency {
+	case models.ExportFrequencyNightly:
+	case models.ExportFrequencyWeekly:
+		weekday = int(time.Monday)
+		if input.Weekday != nil {
+			weekday = *input.Weekday
+		}
+		if weekday < 0 || weekday > 6 {
+			return input, fmt.Errorf("%w: weekday must be between 0 (Sunday) and 6", ErrInvalidExportSchedule)
+		}
+	default:
+		return input, fmt.Errorf("%w: frequency must be %s or %s", ErrInvalidExportSchedule, models.ExportFrequencyNightly, models.ExportFrequencyWeekly)
+	}
+	input.Hour, input.Weekday = &hour, &weekday
+
+	target := ExportTarget{}
+	var creds *ExportCredentials
+	given := input.Credentials
+	if given == nil {
+		given = &ExportCredentials{}
+	}
+	switch input.Destination {
+	case models.ExportDestinationGCS, models.ExportDestinationS3:
+		target.Bucket = strings.TrimSpace(input.Target.Bucket)
+		if !exportBucketName.MatchString(target.Bucket) {
+			return input, fmt.Errorf("%w: bucket must be a valid bucket name", ErrInvalidExportSchedule)
+		}
+		target.Prefix = strings.Trim(strings.TrimSpace(input.Target.Prefix), "/")
+		if len(target.Prefix) > 200 {
+			return input, fmt.Errorf("%w: prefix can't be longer than 200 characters", ErrInvalidExportSchedule)
+		}
+		if input.Destination == models.ExportDestinationGCS {
+			if !keepCredentials {
+				if err := checkServiceAccountKey(given.ServiceAccountKey); err != nil {
+					return input, fmt.Errorf("%w: %v", ErrInvalidExportSchedule, err)
+				}
+				creds = &ExportCredentials{ServiceAccountKey: given.ServiceAccountKey}
+			}
+			break
+		}
+
+		target.Region = strings.TrimSpace(input.Target.Region)
+		if target.Region == "" {
+			target.Region = "us-east-1"
+		}
+		if !exportRegion.MatchString(target.Region) {
+			return input, fmt.Errorf("%w: region must be a valid region name", ErrInvalidExportSchedule)
+		}
+		target.Endpoint = strings.TrimSuffix(strings.TrimSpace(input.Target.Endpoint), "/")
+		objectURL := libraries.S3ObjectURL(target.Endpoint, target.Bucket, target.Region, "export")
+		if err := checkWebhookURL(objectURL, cfg.Destinations, "EXPORT_DESTINATION_ALLOWED_HOSTS"); err != nil {
+			return input, fmt.Errorf("%w: endpoint: %v", ErrInvalidExportSchedule, err)
+		}
+		if !keepCredentials {
+			accessKey, secret := strings.TrimSpace(given.AccessKeyID), strings.TrimSpace(given.SecretAccessKey)
+			if accessKey == "" || secret == "" {
+				return input, fmt.Errorf("%w: credentials need access_key_id and secret_access_key", ErrInvalidExportSchedule)
+			}
+			creds = &ExportCredentials{AccessKeyID: accessKey, SecretAccessKey: secret}
+		}
+
+	case models.ExportDestinationWebhook:
+		target.URL = strings.TrimSpace(input.Target.URL)
+		if err := checkWebhookURL(target.URL, cfg.Destinations, "EXPORT_DESTINATION_ALLOWED_HOSTS"); err != nil {
+			return input, fmt.Errorf("%w: %v", ErrInvalidExportSchedule, err)
+		}
+		if !keepCredentials {
+			if secret := strings.TrimSpace(given.WebhookSecret); secret != "" {
+				creds = &ExportCredentials{WebhookSecret: secret}
+			}
+		}
+
+	default:
+		return input, fmt.Errorf("%w: destination must be %s, %s or %s", ErrInvalidExportSchedule,
+			models.ExportDestinationGCS, models.ExportDestinationS3, models.ExportDestinationWebhook)
+	}
+	input.Target = target
+	input.Credentials = creds
+	return input, nil
+}
+
+// checkServiceAccountKey refuses anything but a service account key, see libraries.WriteObjectAs
+func checkServiceAccountKey(raw json.RawMessage) error {
+	var key struct {
+		Type        string `json:"type"`
+		ClientEmail string `json:"client_email"`
+		PrivateKey  string `json:"private_key"`
+	}
+	if len(raw) == 0 {
+		return errors.New("credentials need a service_account_key")
+	}
+	if err := json.Unmarshal(raw, &key); err != nil || key.Type != "service_account" || key.ClientEmail == "" || key.PrivateKey == "" {
+		return errors.New("service_account_key must be a service account JSON key")
+	}
+	return nil
+}
+
+// NextExportRun returns the first time after after that a schedule runs: every day at hour UTC, or every week
+// on weekday at hour UTC
+func NextExportRun(frequency models.ExportFrequency, hour int, weekday int, after time.Time) time.Time {
+	after = after.UTC()
+	next := time.Date(after.Year(), after.Month(), after.Day(), hour, 0, 0, 0, time.UTC)
+	step := 1
+	if frequency == models.ExportFrequencyWeekly {
+		step = 7
+		next = next.AddDate(0, 0, (weekday-int(next.Weekday())+7)%7)
+	}
+	for !next.After(after) {
+		next = next.AddDate(0, 0, step)
+	}
+	return next
+}
+
+// enqueue runs the export on the job queue, logging a failed run when the queue is full
+func (s *ExportScheduleService) enqueue(schedule models.ExportSchedule) {
+	err := s.jobs.Enqueue("export:"+schedule.UUID.String(), func(ctx context.Context) error {
+		s.run(ctx, schedule)
+		return nil
+	})
+	if err != nil {
+		s.record(schedule, time.Now(), "", 0, err)
+	}
+}
+
+// run renders the board and pushes it to the destination, then logs the run
+func (s *ExportScheduleService) run(ctx context.Context, schedule models.ExportSchedule) {
+	started := time.Now()
+	ctx, cancel := context.WithTimeout(ctx, s.cfg.UploadTimeout)
+	defer cancel()
+
+	location, size, err := s.deliver(ctx, schedule, started)
+	s.record(schedule, started, location, size, err)
+}
+
+func (s *ExportScheduleService) deliver(ctx context.Context, schedule models.ExportSchedule, now time.Time) (string, int64, error) {
+	var target ExportTarget
+	if err := json.Unmarshal(schedule.Target, &target); err != nil {
+		return "", 0, fmt.Errorf("invalid target: %w", err)
+	}
+	creds, err := openExportCredentials(schedule.Credentials)
+	if err != nil {
+		return "", 0, err
+	}
+	file, err := s.render(schedule)
+	if err != nil {
+		return "", 0, err
+	}
+	size := int64(len(file.data))
+
+	key := path.Join(target.Prefix, schedule.BoardID.String(), now.UTC().Format("2006-01-02T150405Z")+"."+file.extension)
+	switch schedule.Destination {
+	case models.ExportDestinationGCS:
+		err := libraries.WriteObjectAs(ctx, creds.ServiceAccountKey, target.Bucket, key, bytes.NewReader(file.data), file.contentType)
+		return "gs://" + target.Bucket + "/" + key, size, err
+	case models.ExportDestinationS3:
+		return s.putS3Object(ctx, target, creds, key, file)
+	case models.ExportDestinationWebhook:
+		return target.URL, size, s.postWebhook(ctx, schedule, target.URL, creds.WebhookSecret, file, now)
+	}
+	return "", 0, fmt.Errorf("unknown destination %s", schedule.Destination)
+}
+
+// render exports the board in the schedule's format, watermarked like the owner's manual exports
+func (s *ExportScheduleService) render(schedule models.ExportSchedule) (exportFile, error) {
+	board, err := s.boardRepo.GetBoardById(schedule.UserID, schedule.BoardID)
+	if err != nil {
+		return exportFile{}, fmt.Errorf("board not found: %w", err)
+	}
+
+	switch schedule.Format {
+	case models.ExportFormatPNG:
+		png, _, err := s.exports.ExportPNG(schedule.UserID, schedule.BoardID)
+		if errors.Is(err, ErrBoardPreviewMissing) {
+			return exportFile{}, errors.New("the board has no saved preview yet, open it once to create one")
+		}
+		return exportFile{data: png, contentType: "image/png", extension: "png"}, err
+	}
+
+	shapes, err := s.boardDataRepo.GetBoardData(schedule.BoardID)
+	if err != nil {
+		return exportFile{}, err
+	}
+	if schedule.Format == models.ExportFormatSVG {
+		svg, _, err := s.exports.ExportSVG(schedule.UserID, shapes)
+		return exportFile{data: svg, contentType: "image/svg+xml", extension: "svg"}, err
+	}
+	if shapes == nil {
+		shapes = []models.BoardData{}
+	}
+	data, err := json.Marshal(map[string]interface{}{
+		"board":       board,
+		"shapes":      shapes,
+		"exported_at": time.Now().UTC(),
+	})
+	return exportFile{data: data, contentType: "application/json", extension: "json"}, err
+}
+
+// putS3Object uploads the export with a signed PUT, within the EXPORT_DESTINATION_* limits
+func (s *ExportScheduleService) putS3Object(ctx context.Context, target ExportTarget, creds ExportCredentials, key string, file exportFile) (string, int64, error) {
+	objectURL := libraries.S3ObjectURL(target.Endpoint, target.Bucket, target.Region, key)
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, objectURL, bytes.NewReader(file.data))
+	if err != nil {
+		return "", 0, err
+	}
+	req.Header.Set("Content-Type", file.contentType)
+	libraries.SignAWSRequest(req, file.data, libraries.AWSCredentials{
+		AccessKeyID:     creds.AccessKeyID,
+		SecretAccessKey: creds.SecretAccessKey,
+	}, target.Region, "s3", time.Now())
+
+	if err := s.send(req); err != nil {
+		return "", 0, err
+	}
+	return "s3://" + target.Bucket + "/" + key, int64(len(file.data)), nil
+}
+
+// postWebhook posts the export to the URL; with a secret, X-Melina-Signature is the hex HMAC-SHA256 of
+// "<timestamp>.<body>", so the receiver can check the request and reject replays
+func (s *ExportScheduleService) postWebhook(ctx context.Context, schedule models.ExportSchedule, webhookURL string, secret string, file exportFile, now time.Time) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, webhookURL, bytes.NewReader(file.data))
+	if err != nil {
+		return err
+	}
+	timestamp := strconv.FormatInt(now.Unix(), 10)
+	req.Header.Set("Content-Type", file.contentType)
+	req.Header.Set("X-Melina-Board-Id", schedule.BoardID.String())
+	req.Header.Set("X-Melina-Export-Format", string(schedule.Format))
+	req.Header.Set("X-Melina-Timestamp", timestamp)
+	if secret != "" {
+		req.Header.Set("X-Melina-Signature", "sha256="+ExportWebhookSignature(secret, timestamp, file.data))
+	}
+	return s.send(req)
+}
+
+// ExportWebhookSignature signs a webhook export the way receivers check it
+func ExportWebhookSignature(secret string, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp + "."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// send makes an upload request, failing on a non-2xx answer
+func (s *ExportScheduleService) send(req *http.Request) error {
+	req.Header.Set("User-Agent", "Melina-Exports/1.0")
+	resp, err := s.uploads.Send(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, 500))
+		return fmt.Errorf("%s answered %d: %s", (&url.URL{Scheme: req.URL.Scheme, Host: req.URL.Host}).String(), resp.StatusCode, strings.TrimSpace(string(body)))
+	}
+	_, _ = io.Copy(io.Discard, io.LimitReader(resp.Body, 64*1024))
+	return nil
+}
+
+// record stores the run in the schedule's log
+func (s *ExportScheduleService) record(schedule models.ExportSchedule, started time.Time, location string, size int64, runErr error) {
+	run := &models.ExportRun{
+		ScheduleID: schedule.UUID,
+		BoardID:    schedule.BoardID,
+		Status:     models.ExportRunSucceeded,
+		Location:   location,
+		Bytes:      size,
+		DurationMs: time.Since(started).Milliseconds(),
+	}
+	if runErr != nil {
+		run.Status = models.ExportRunFailed
+		run.Bytes = 0
+		run.Error = runErr.Error()
+		if len(run.Error) > exportErrorLimit {
+			run.Error = run.Error[:exportErrorLimit] + "..."
+		}
+		log.Printf("[export] schedule %s failed: %v", schedule.UUID, runErr)
+	}
+	if err := s.scheduleRepo.CreateRun(run); err != nil {
+		log.Printf("[export] failed to log run of schedule %s: %v", schedule.UUID, err)
+	}
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"melina-studio-backend/internal/melina/prompts"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidFacilitationKind = errors.New("invalid facilitation kind")
+
+type FacilitationService struct {
+	facilitationRepo repo.FacilitationRepoInterface
+}
+
+func NewFacilitationService(facilitationRepo repo.FacilitationRepoInterface) *FacilitationService {
+	return &FacilitationService{facilitationRepo: facilitationRepo}
+}
+
+// StartSession ends any running session on the board and starts a new one at the first step
+func (s *FacilitationService) StartSession(userID uuid.UUID, boardID uuid.UUID, kind models.FacilitationKind, topic string) (*models.FacilitationSession, error) {
+	if kind != models.FacilitationRetro && kind != models.FacilitationBrainstorm {
+		return nil, ErrInvalidFacilitationKind
+	}
+
+	if err := s.facilitationRepo.EndActiveSessions(userID, boardID); err != nil {
+		return nil, err
+	}
+
+	session := &models.FacilitationSession{
+		BoardID: boardID,
+		UserID:  userID,
+		Kind:    kind,
+		Topic:   topic,
+		Step:    models.FacilitationStepStart,
+	}
+	if _, err := s.facilitationRepo.CreateSession(session); err != nil {
+		return nil, err
+	}
+	return session, nil
+}
+
+// GetActiveSession returns the running session for a board
+func (s *FacilitationService) GetActiveSession(userID uuid.UUID, boardID uuid.UUID) (*models.FacilitationSession, error) {
+	return s.facilitationRepo.GetActiveSession(userID, boardID)
+}
+
+// AdvanceSession moves the running session to its next step
+// Reaching the completed step also ends the session
+func (s *FacilitationService) AdvanceSession(userID uuid.UUID, boardID uuid.UUID) (*models.FacilitationSession, error) {
+	session, err := s.facilitationRepo.GetActiveSession(userID, boardID)
+	if err != nil {
+		return nil, err
+	}
+
+	session.Step = models.NextFacilitationStep(session.Step)
+	if err := s.facilitationRepo.UpdateStep(session.UUID, session.Step); err != nil {
+		return nil, err
+	}
+
+	if session.Step == models.FacilitationStepCompleted {
+		if err := s.facilitationRepo.EndSession(session.UUID); err != nil {
+			return nil, err
+		}
+		session.IsActive = false
+	}
+	return session, nil
+}
+
+// EndSession stops the running session on a board
+func (s *FacilitationService) EndSession(userID uuid.UUID, boardID uuid.UUID) error {
+	return s.facilitationRepo.EndActiveSessions(userID, boardID)
+}
+
+// GetFormattedStepInstructions returns the prompt block for the active session on a board
+// Returns an empty string when no session is running
+func (s *FacilitationService) GetFormattedStepInstructions(userID uuid.UUID, boardID uuid.UUID) (string, error) {
+	session, err := s.facilitationRepo.GetActiveSession(userID, boardID)
+	if err != nil {
+		return "", err
+	}
+	return FormatFacilitationPrompt(session), nil
+}
+
+// FormatFacilitationPrompt renders the step instructions for a session
+func FormatFacilitationPrompt(session *models.FacilitationSession) string {
+	steps, ok := prompts.FACILITATION_STEP_INSTRUCTIONS[string(session.Kind)]
+	if !ok {
+		return ""
+	}
+	instructions, ok := steps[string(session.Step)]
+	if !ok {
+		return ""
+	}
+	return fmt.Sprintf(prompts.FACILITATION_PROMPT, session.Kind, session.Topic, session.Step, instructions)
+}
+
+// GetFacilitationKickoffMessage returns the chat message the client sends to run a step
+func GetFacilitationKickoffMessage(step models.FacilitationStep) string {
+	return prompts.FACILITATION_KICKOFF_MESSAGES[string(step)]
+}
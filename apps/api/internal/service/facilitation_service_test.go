@@ -0,0 +1,133 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"melina-studio-backend/internal/melina/prompts"
+	"melina-studio-backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// fakeFacilitationRepo keeps sessions in memory, at most one of them active per board as the database does
+type fakeFacilitationRepo struct {
+	sessions []*models.FacilitationSession
+}
+
+func (f *fakeFacilitationRepo) CreateSession(session *models.FacilitationSession) (uuid.UUID, error) {
+	session.UUID = uuid.New()
+	session.IsActive = true
+	f.sessions = append(f.sessions, session)
+	return session.UUID, nil
+}
+
+func (f *fakeFacilitationRepo) GetActiveSession(userID uuid.UUID, boardID uuid.UUID) (*models.FacilitationSession, error) {
+	for _, session := range f.sessions {
+		if session.IsActive && session.UserID == userID && session.BoardID == boardID {
+			copied := *session
+			return &copied, nil
+		}
+	}
+	return nil, gorm.ErrRecordNotFound
+}
+
+func (f *fakeFacilitationRepo) UpdateStep(sessionID uuid.UUID, step models.FacilitationStep) error {
+	for _, session := range f.sessions {
+		if session.UUID == sessionID {
+			session.Step = step
+		}
+	}
+	return nil
+}
+
+func (f *fakeFacilitationRepo) EndSession(sessionID uuid.UUID) error {
+	for _, session := range f.sessions {
+		if session.UUID == sessionID {
+			session.IsActive = false
+		}
+	}
+	return nil
+}
+
+func (f *fakeFacilitationRepo) EndActiveSessions(userID uuid.UUID, boardID uuid.UUID) error {
+	for _, session := range f.sessions {
+		if session.UserID == userID && session.BoardID == boardID {
+			session.IsActive = false
+		}
+	}
+	return nil
+}
+
+func TestFacilitationSessionWalksEveryStep(t *testing.T) {
+	facilitations := &fakeFacilitationRepo{}
+	s := NewFacilitationService(facilitations)
+	userID, boardID := uuid.New(), uuid.New()
+
+	if _, err := s.StartSession(userID, boardID, "standup", "Sprint 12"); !errors.Is(err, ErrInvalidFacilitationKind) {
+		t.Fatalf("expected ErrInvalidFacilitationKind, got %v", err)
+	}
+	first, err := s.StartSession(userID, boardID, models.FacilitationRetro, "Sprint 12")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// starting again replaces the running session rather than running two
+	session, err := s.StartSession(userID, boardID, models.FacilitationBrainstorm, "Launch ideas")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if active, _ := s.GetActiveSession(userID, boardID); active == nil || active.UUID != session.UUID || active.UUID == first.UUID {
+		t.Fatalf("expected the new session to be the only active one, got %v", active)
+	}
+	if session.Step != models.FacilitationStepStart {
+		t.Fatalf("expected the session to start at %q, got %q", models.FacilitationStepStart, session.Step)
+	}
+
+	for _, want := range models.FacilitationSteps[1:] {
+		session, err = s.AdvanceSession(userID, boardID)
+		if err != nil {
+			t.Fatalf("advancing to %s: %v", want, err)
+		}
+		if session.Step != want {
+			t.Fatalf("got step %q, want %q", session.Step, want)
+		}
+	}
+	if session.IsActive {
+		t.Error("expected the completed session to be ended")
+	}
+	if _, err := s.AdvanceSession(userID, boardID); !errors.Is(err, gorm.ErrRecordNotFound) {
+		t.Errorf("expected no session to advance once completed, got %v", err)
+	}
+	if prompt, err := s.GetFormattedStepInstructions(userID, boardID); err == nil || prompt != "" {
+		t.Errorf("expected no instructions without a running session, got %q %v", prompt, err)
+	}
+}
+
+func TestFacilitationKickoffAndInstructions(t *testing.T) {
+	if got := models.NextFacilitationStep(models.FacilitationStepCompleted); got != models.FacilitationStepCompleted {
+		t.Errorf("expected completed to be terminal, got %q", got)
+	}
+	if got := models.NextFacilitationStep("unknown"); got != models.FacilitationStepCompleted {
+		t.Errorf("expected an unknown step to complete, got %q", got)
+	}
+
+	for _, kind := range []models.FacilitationKind{models.FacilitationRetro, models.FacilitationBrainstorm} {
+		for _, step := range models.FacilitationSteps {
+			if GetFacilitationKickoffMessage(step) == "" {
+				t.Errorf("step %s has no kickoff message", step)
+			}
+			prompt := FormatFacilitationPrompt(&models.FacilitationSession{Kind: kind, Topic: "Sprint 12", Step: step})
+			if prompt == "" || !strings.Contains(prompt, "Sprint 12") || !strings.Contains(prompt, prompts.FACILITATION_STEP_INSTRUCTIONS[string(kind)][string(step)]) {
+				t.Errorf("%s %s: got prompt %q", kind, step, prompt)
+			}
+		}
+	}
+	if prompt := FormatFacilitationPrompt(&models.FacilitationSession{Kind: "standup", Step: models.FacilitationStepStart}); prompt != "" {
+		t.Errorf("expected no prompt for an unknown kind, got %q", prompt)
+	}
+	if GetFacilitationKickoffMessage("unknown") != "" {
+		t.Error("expected no kickoff message for an unknown step")
+	}
+}
@@ -0,0 +1,210 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidFeatureFlag  = errors.New("invalid feature flag")
+	ErrFeatureFlagNotFound = errors.New("feature flag not found")
+)
+
+// featureFlagCacheTTL bounds how long a flag change takes to reach every instance
+const featureFlagCacheTTL = 30 * time.Second
+
+var featureFlagKeyPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_.-]{0,99}$`)
+
+type FeatureFlagService struct {
+	flagRepo  repo.FeatureFlagRepoInterface
+	mu        sync.Mutex
+	flags     []models.FeatureFlag
+	expiresAt time.Time
+}
+
+func NewFeatureFlagService(flagRepo repo.FeatureFlagRepoInterface) *FeatureFlagService {
+	return &FeatureFlagService{
+		flagRepo: flagRepo,
+	}
+}
+
+// FeatureFlagUpdate holds the flag fields to change; nil fields are left untouched
+type FeatureFlagUpdate struct {
+	Description       *string   `json:"description"`
+	Enabled           *bool     `json:"enabled"`
+	RolloutPercentage *int      `json:"rollout_percentage"`
+	UserIDs           *[]string `json:"user_ids"`
+}
+
+// Evaluate returns every flag's state for the user, as served to the frontend
+func (s *FeatureFlagService) Evaluate(userID string) (map[string]bool, error) {
+	flags, err := s.cachedFlags()
+	if err != nil {
+		return nil, err
+	}
+	result := make(map[string]bool, len(flags))
+	for i := range flags {
+		result[flags[i].Key] = flagEnabledFor(&flags[i], userID)
+	}
+	return result, nil
+}
+
+// IsEnabled reports whether the flag is on for the user; unknown flags and lookup failures count as off
+func (s *FeatureFlagService) IsEnabled(key string, userID string) bool {
+	flags, err := s.cachedFlags()
+	if err != nil {
+		return false
+	}
+	for i := range flags {
+		if flags[i].Key == key {
+			return flagEnabledFor(&flags[i], userID)
+		}
+	}
+	return false
+}
+
+// List returns every flag straight from the database
+func (s *FeatureFlagService) List() ([]models.FeatureFlag, error) {
+	return s.flagRepo.List()
+}
+
+// Upsert creates the flag if it doesn't exist yet and applies the update
+func (s *FeatureFlagService) Upsert(key string, update FeatureFlagUpdate) (*models.FeatureFlag, error) {
+	key = strings.ToLower(strings.TrimSpace(key))
+	if !featureFlagKeyPattern.MatchString(key) {
+		return nil, fmt.Errorf("%w: key must be lowercase letters, digits, '.', '_' or '-'", ErrInvalidFeatureFlag)
+	}
+
+	flag, err := s.flagRepo.GetByKey(key)
+	isNew := errors.Is(err, gorm.ErrRecordNotFound)
+	if err != nil && !isNew {
+		return nil, err
+	}
+	if isNew {
+		flag = &models.FeatureFlag{Key: key}
+	}
+
+	if update.Description != nil {
+		flag.Description = strings.TrimSpace(*update.Description)
+	}
+	if update.Enabled != nil {
+		flag.Enabled = *update.Enabled
+	}
+	if update.RolloutPercentage != nil {
+		if *update.RolloutPercentage < 0 || *update.RolloutPercentage > 100 {
+			return nil, fmt.Errorf("%w: rollout_percentage must be between 0 and 100", ErrInvalidFeatureFlag)
+		}
+		flag.RolloutPercentage = *update.RolloutPercentage
+	}
+	if update.UserIDs != nil {
+		if err := setFlagUserIDs(flag, *update.UserIDs); err != nil {
+			return nil, err
+		}
+	}
+
+	if isNew {
+		err = s.flagRepo.Create(flag)
+	} else {
+		err = s.flagRepo.Update(flag)
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.invalidate()
+	return flag, nil
+}
+
+// Delete removes the flag, turning it off for everyone
+func (s *FeatureFlagService) Delete(key string) error {
+	if err := s.flagRepo.Delete(key); err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrFeatureFlagNotFound
+		}
+		return err
+	}
+	s.invalidate()
+	return nil
+}
+
+// cachedFlags returns all flags, reloading them once the cache has expired
+func (s *FeatureFlagService) cachedFlags() ([]models.FeatureFlag, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.flags != nil && time.Now().Before(s.expiresAt) {
+		return s.flags, nil
+	}
+
+	flags, err := s.flagRepo.List()
+	if err != nil {
+		return nil, err
+	}
+	if flags == nil {
+		flags = []models.FeatureFlag{}
+	}
+	s.flags = flags
+	s.expiresAt = time.Now().Add(featureFlagCacheTTL)
+	return s.flags, nil
+}
+
+func (s *FeatureFlagService) invalidate() {
+	s.mu.Lock()
+	s.flags = nil
+	s.mu.Unlock()
+}
+
+// flagEnabledFor evaluates a flag for a user: targeted users first, then the percentage rollout
+func flagEnabledFor(flag *models.FeatureFlag, userID string) bool {
+	if !flag.Enabled {
+		return false
+	}
+	for _, id := range flag.TargetedUserIDs() {
+		if id == userID {
+			return true
+		}
+	}
+	if flag.RolloutPercentage >= 100 {
+		return true
+	}
+	if flag.RolloutPercentage <= 0 || userID == "" {
+		return false
+	}
+	return rolloutBucket(flag.Key, userID) < uint32(flag.RolloutPercentage)
+}
+
+// rolloutBucket places a user in one of 100 buckets per flag, so a user keeps their bucket as the
+// rollout grows and different flags don't always land on the same users
+func rolloutBucket(key string, userID string) uint32 {
+	h := fnv.New32a()
+	h.Write([]byte(key + ":" + userID))
+	return h.Sum32() % 100
+}
+
+func setFlagUserIDs(flag *models.FeatureFlag, userIDs []string) error {
+	if len(userIDs) == 0 {
+		flag.UserIDs = nil
+		return nil
+	}
+	for _, id := range userIDs {
+		if _, err := uuid.Parse(id); err != nil {
+			return fmt.Errorf("%w: invalid user id %q", ErrInvalidFeatureFlag, id)
+		}
+	}
+	encoded, err := json.Marshal(userIDs)
+	if err != nil {
+		return err
+	}
+	flag.UserIDs = datatypes.JSON(encoded)
+	return nil
+}
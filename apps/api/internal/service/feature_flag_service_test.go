@@ -0,0 +1,71 @@
+package service
+
+import (
+	"errors"
+	"melina-studio-backend/internal/models"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestFlagEnabledFor(t *testing.T) {
+	userID := uuid.NewString()
+
+	disabled := &models.FeatureFlag{Key: "plan-mode", Enabled: false, RolloutPercentage: 100}
+	if flagEnabledFor(disabled, userID) {
+		t.Error("disabled flag should be off")
+	}
+
+	full := &models.FeatureFlag{Key: "plan-mode", Enabled: true, RolloutPercentage: 100}
+	if !flagEnabledFor(full, userID) {
+		t.Error("100% rollout should be on")
+	}
+
+	targeted := &models.FeatureFlag{Key: "plan-mode", Enabled: true}
+	if err := setFlagUserIDs(targeted, []string{userID}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !flagEnabledFor(targeted, userID) {
+		t.Error("targeted user should get the flag at 0% rollout")
+	}
+	if flagEnabledFor(targeted, uuid.NewString()) {
+		t.Error("other users should not get a 0% flag")
+	}
+}
+
+func TestRolloutIsStableAndMonotonic(t *testing.T) {
+	flag := &models.FeatureFlag{Key: "crdt-sync", Enabled: true}
+	var users []string
+	for i := 0; i < 1000; i++ {
+		users = append(users, uuid.NewString())
+	}
+
+	enabledAt := func(percentage int) map[string]bool {
+		flag.RolloutPercentage = percentage
+		on := map[string]bool{}
+		for _, u := range users {
+			if flagEnabledFor(flag, u) {
+				on[u] = true
+			}
+		}
+		return on
+	}
+
+	quarter := enabledAt(25)
+	half := enabledAt(50)
+	for u := range quarter {
+		if !half[u] {
+			t.Fatalf("user %s lost the flag when the rollout grew", u)
+		}
+	}
+	if len(half) < 400 || len(half) > 600 {
+		t.Errorf("expected roughly half of the users at 50%%, got %d/1000", len(half))
+	}
+}
+
+func TestSetFlagUserIDsRejectsInvalidIDs(t *testing.T) {
+	flag := &models.FeatureFlag{}
+	if err := setFlagUserIDs(flag, []string{"not-a-uuid"}); !errors.Is(err, ErrInvalidFeatureFlag) {
+		t.Errorf("expected ErrInvalidFeatureFlag, got %v", err)
+	}
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"melina-studio-backend/internal/melina/prompts"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	maxFeedbackCommentLength = 2000
+	// defaultFeedbackWindow is how far back the dashboards look when no start is given
+	defaultFeedbackWindow = 30 * 24 * time.Hour
+	defaultFeedbackLimit  = 50
+	maxFeedbackLimit      = 500
+)
+
+var (
+	ErrInvalidFeedback         = errors.New("invalid feedback")
+	ErrFeedbackMessageNotFound = errors.New("message not found")
+)
+
+type FeedbackService struct {
+	feedbackRepo repo.MessageFeedbackRepoInterface
+	chatRepo     repo.ChatRepoInterface
+}
+
+func NewFeedbackService(feedbackRepo repo.MessageFeedbackRepoInterface, chatRepo repo.ChatRepoInterface) *FeedbackService {
+	return &FeedbackService{
+		feedbackRepo: feedbackRepo,
+		chatRepo:     chatRepo,
+	}
+}
+
+// FeedbackInput is a thumbs up or down on an AI message
+// The model and prompt version come from the turn's chat_completed event; the prompt version
+// defaults to the one running now, which is the turn's unless a deploy changed the prompt since
+type FeedbackInput struct {
+	MessageID     uuid.UUID `json:"message_id"`
+	Rating        string    `json:"rating"` // "up" or "down"
+	ModelName     string    `json:"model_name"`
+	PromptVersion string    `json:"prompt_version"`
+	Comment       string    `json:"comment"`
+}
+
+// Submit stores the user's feedback on one of their AI messages, replacing earlier feedback on it
+func (s *FeedbackService) Submit(userID uuid.UUID, input FeedbackInput) (*models.MessageFeedback, error) {
+	feedback, err := newMessageFeedback(userID, input)
+	if err != nil {
+		return nil, err
+	}
+
+	chat, err := s.chatRepo.GetUserChatByID(userID, input.MessageID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrFeedbackMessageNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if chat.Role != models.RoleAssistant {
+		return nil, fmt.Errorf("%w: only AI messages can be rated", ErrInvalidFeedback)
+	}
+	feedback.BoardID = chat.BoardUUID
+
+	if err := s.feedbackRepo.Upsert(feedback); err != nil {
+		return nil, err
+	}
+	return feedback, nil
+}
+
+// List returns the latest feedback for review; rating is "up", "down" or empty for both
+func (s *FeedbackService) List(rating string, since time.Time, limit int) ([]models.MessageFeedback, error) {
+	value := 0
+	if rating != "" {
+		var err error
+		if value, err = parseFeedbackRating(rating); err != nil {
+			return nil, err
+		}
+	}
+	if limit <= 0 {
+		limit = defaultFeedbackLimit
+	}
+	if limit > maxFeedbackLimit {
+		limit = maxFeedbackLimit
+	}
+	return s.feedbackRepo.List(value, feedbackSince(since), limit)
+}
+
+// Aggregate compares the feedback per model or per prompt version
+func (s *FeedbackService) Aggregate(groupBy string, since time.Time) ([]models.FeedbackAggregate, error) {
+	group := repo.FeedbackGroup(groupBy)
+	if groupBy == "" {
+		group = repo.FeedbackByPromptVersion
+	}
+	if group != repo.FeedbackByModel && group != repo.FeedbackByPromptVersion {
+		return nil, fmt.Errorf("%w: group_by must be model_name or prompt_version", ErrInvalidFeedback)
+	}
+
+	rows, err := s.feedbackRepo.Aggregate(group, feedbackSince(since))
+	if err != nil {
+		return nil, err
+	}
+	for i := range rows {
+		if rows[i].Total > 0 {
+			rows[i].ApprovalRate = float64(rows[i].Up) / float64(rows[i].Total)
+		}
+	}
+	if rows == nil {
+		rows = []models.FeedbackAggregate{}
+	}
+	return rows, nil
+}
+
+func newMessageFeedback(userID uuid.UUID, input FeedbackInput) (*models.MessageFeedback, error) {
+	if input.MessageID == uuid.Nil {
+		return nil, fmt.Errorf("%w: message_id is required", ErrInvalidFeedback)
+	}
+	rating, err := parseFeedbackRating(input.Rating)
+	if err != nil {
+		return nil, err
+	}
+	comment := strings.TrimSpace(input.Comment)
+	if len([]rune(comment)) > maxFeedbackCommentLength {
+		return nil, fmt.Errorf("%w: comment is longer than %d characters", ErrInvalidFeedback, maxFeedbackCommentLength)
+	}
+	modelName := strings.TrimSpace(input.ModelName)
+	if len(modelName) > 100 {
+		return nil, fmt.Errorf("%w: model_name is too long", ErrInvalidFeedback)
+	}
+	promptVersion := strings.TrimSpace(input.PromptVersion)
+	if promptVersion == "" {
+		promptVersion = prompts.MasterPromptVersion()
+	}
+	if len(promptVersion) > 32 {
+		return nil, fmt.Errorf("%w: prompt_version is too long", ErrInvalidFeedback)
+	}
+
+	return &models.MessageFeedback{
+		UserID:        userID,
+		ChatID:        input.MessageID,
+		Rating:        rating,
+		ModelName:     modelName,
+		PromptVersion: promptVersion,
+		Comment:       comment,
+	}, nil
+}
+
+func parseFeedbackRating(rating string) (int, error) {
+	switch strings.ToLower(strings.TrimSpace(rating)) {
+	case "up":
+		return 1, nil
+	case "down":
+		return -1, nil
+	}
+	return 0, fmt.Errorf("%w: rating must be up or down", ErrInvalidFeedback)
+}
+
+func feedbackSince(since time.Time) time.Time {
+	if since.IsZero() {
+		return time.Now().Add(-defaultFeedbackWindow)
+	}
+	return since
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"melina-studio-backend/internal/melina/prompts"
+
+	"github.com/google/uuid"
+)
+
+func TestNewMessageFeedback(t *testing.T) {
+	userID, messageID := uuid.New(), uuid.New()
+
+	feedback, err := newMessageFeedback(userID, FeedbackInput{MessageID: messageID, Rating: "Down", Comment: "  drew the wrong shape  "})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if feedback.Rating != -1 || feedback.ChatID != messageID || feedback.Comment != "drew the wrong shape" {
+		t.Fatalf("unexpected feedback %+v", feedback)
+	}
+	if feedback.PromptVersion != prompts.MasterPromptVersion() {
+		t.Fatalf("got prompt version %q, want the running one %q", feedback.PromptVersion, prompts.MasterPromptVersion())
+	}
+}
+
+func TestNewMessageFeedbackRejectsInvalidInput(t *testing.T) {
+	messageID := uuid.New()
+	for name, input := range map[string]FeedbackInput{
+		"no message":   {Rating: "up"},
+		"bad rating":   {MessageID: messageID, Rating: "meh"},
+		"long comment": {MessageID: messageID, Rating: "up", Comment: strings.Repeat("a", maxFeedbackCommentLength+1)},
+	} {
+		if _, err := newMessageFeedback(uuid.New(), input); !errors.Is(err, ErrInvalidFeedback) {
+			t.Errorf("%s: got %v, want ErrInvalidFeedback", name, err)
+		}
+	}
+}
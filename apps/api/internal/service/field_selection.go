@@ -0,0 +1,96 @@
+package service
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// BoardFields are the board fields clients may pick with ?fields=; their json names are also their columns
+var BoardFields = []string{
+	"uuid", "title", "user_id", "tenant_id", "starred", "is_deleted", "thumbnail", "cover_image", "icon", "description",
+	"annotated_image_hash", "agent_profile", "grid_size", "snap_to_grid", "timeline_origin",
+	"timeline_origin_x", "timeline_day_width", "is_archived", "archived_at", "created_at", "updated_at",
+}
+
+// BoardListIncludes are the extras GET /boards adds with ?include=
+var BoardListIncludes = []string{"shape_count"}
+
+// BoardIncludes are the parts of GET /boards/:boardId picked with ?include=; both are sent by default
+var BoardIncludes = []string{"shapes", "info"}
+
+// ParseFieldList parses a comma separated ?fields= or ?include= value, rejecting names that aren't allowed
+// It returns nil for an empty value, meaning the default response
+func ParseFieldList(raw string, allowed []string) ([]string, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	allowedSet := make(map[string]bool, len(allowed))
+	for _, name := range allowed {
+		allowedSet[name] = true
+	}
+
+	var fields []string
+	seen := make(map[string]bool)
+	var unknown []string
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		if !allowedSet[name] {
+			unknown = append(unknown, name)
+			continue
+		}
+		fields = append(fields, name)
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("unknown fields: %s", strings.Join(unknown, ", "))
+	}
+	return fields, nil
+}
+
+// SelectFields keeps only the given json fields of a struct or of every element of a slice
+// A nil field list returns v unchanged
+func SelectFields(v interface{}, fields []string) (interface{}, error) {
+	if fields == nil {
+		return v, nil
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+
+	var decoded interface{}
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		return nil, err
+	}
+
+	switch value := decoded.(type) {
+	case map[string]interface{}:
+		return pickKeys(value, fields), nil
+	case []interface{}:
+		picked := make([]map[string]interface{}, 0, len(value))
+		for _, item := range value {
+			if obj, ok := item.(map[string]interface{}); ok {
+				picked = append(picked, pickKeys(obj, fields))
+			}
+		}
+		return picked, nil
+	default:
+		return nil, fmt.Errorf("fields can only be selected on objects, got %T", decoded)
+	}
+}
+
+func pickKeys(obj map[string]interface{}, fields []string) map[string]interface{} {
+	picked := make(map[string]interface{}, len(fields))
+	for _, field := range fields {
+		if value, ok := obj[field]; ok {
+			picked[field] = value
+		}
+	}
+	return picked
+}
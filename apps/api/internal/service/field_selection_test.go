@@ -0,0 +1,56 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"melina-studio-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func TestParseFieldList(t *testing.T) {
+	fields, err := ParseFieldList(" uuid, title,,uuid ,thumbnail", BoardFields)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if want := []string{"uuid", "title", "thumbnail"}; !reflect.DeepEqual(fields, want) {
+		t.Fatalf("got %v, want %v", fields, want)
+	}
+
+	if fields, err := ParseFieldList("", BoardFields); err != nil || fields != nil {
+		t.Fatalf("an empty list means the default response, got %v, %v", fields, err)
+	}
+	if _, err := ParseFieldList("title,password,secret", BoardFields); err == nil || err.Error() != "unknown fields: password, secret" {
+		t.Fatalf("expected the unknown fields to be reported, got %v", err)
+	}
+}
+
+func TestSelectFields(t *testing.T) {
+	boards := []models.Board{
+		{UUID: uuid.New(), Title: "Roadmap", Thumbnail: "https://example.com/a.png", UpdatedAt: time.Now()},
+		{UUID: uuid.New(), Title: "Retro"},
+	}
+
+	selected, err := SelectFields(boards, []string{"uuid", "title"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	list, ok := selected.([]map[string]interface{})
+	if !ok || len(list) != 2 {
+		t.Fatalf("expected two boards, got %#v", selected)
+	}
+	if len(list[0]) != 2 || list[0]["title"] != "Roadmap" || list[0]["uuid"] != boards[0].UUID.String() {
+		t.Fatalf("expected only uuid and title, got %v", list[0])
+	}
+
+	single, err := SelectFields(boards[1], []string{"title"})
+	if err != nil || !reflect.DeepEqual(single, map[string]interface{}{"title": "Retro"}) {
+		t.Fatalf("got %v, %v", single, err)
+	}
+
+	if same, _ := SelectFields(boards, nil); !reflect.DeepEqual(same, boards) {
+		t.Fatal("no field list should return the value unchanged")
+	}
+}
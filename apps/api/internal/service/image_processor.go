@@ -8,6 +8,7 @@ import (
 	"log"
 	"net/http"
 	"strings"
+	"sync"
 
 	"github.com/alpkeskin/gotoon"
 	"github.com/google/uuid"
@@ -48,7 +49,7 @@ func (p *ImageProcessor) ProcessSelectionImages(metadata *libraries.ChatMessageM
 	shapeDataMap := p.fetchShapeDataFromDB(metadata.ShapeImageUrls)
 
 	// Step 3: Fetch images and annotate each selection group
-	annotatedSelections := p.annotateSelectionGroups(urlToGroup, shapeDataMap)
+	annotatedSelections := p.annotateSelectionGroups(urlToGroup, shapeDataMap, metadata.Attachments)
 
 	log.Printf("Created %d annotated selections", len(annotatedSelections))
 	return annotatedSelections
@@ -112,40 +113,85 @@ func (p *ImageProcessor) fetchShapeDataFromDB(shapeUrls []libraries.ShapeImageUr
 }
 
 // annotateSelectionGroups processes each selection group and creates annotated selections
-func (p *ImageProcessor) annotateSelectionGroups(urlToGroup map[string]*selectionGroup, shapeDataMap map[string]map[string]any) []helpers.AnnotatedSelection {
-	var annotatedSelections []helpers.AnnotatedSelection
+// Images are fetched and annotated concurrently; shapes are numbered in between so the numbers stay
+// consecutive across the groups whose image loaded
+func (p *ImageProcessor) annotateSelectionGroups(urlToGroup map[string]*selectionGroup, shapeDataMap map[string]map[string]any, attachments []libraries.FrameAttachment) []helpers.AnnotatedSelection {
+	groups := make([]*selectionGroup, 0, len(urlToGroup))
+	for _, group := range urlToGroup {
+		groups = append(groups, group)
+	}
+
+	// Fetch the images
+	images := make([]string, len(groups))
+	var wg sync.WaitGroup
+	for i, group := range groups {
+		wg.Add(1)
+		go func(i int, group *selectionGroup) {
+			defer wg.Done()
+			imageBase64, _, err := p.loadImageAsBase64(group.url, attachments)
+			if err != nil {
+				log.Printf("Failed to fetch image: %v", err)
+				return
+			}
+			images[i] = imageBase64
+		}(i, group)
+	}
+	wg.Wait()
+
+	annotatedSelections := make([]helpers.AnnotatedSelection, len(groups))
+	loaded := make([]bool, len(groups))
 	globalShapeNumber := 1
 
-	for _, group := range urlToGroup {
-		// Fetch the image
-		imageBase64, err := p.fetchImageAsBase64(group.url)
-		if err != nil {
-			log.Printf("Failed to fetch image: %v", err)
+	for i, group := range groups {
+		if images[i] == "" {
 			continue
 		}
+		loaded[i] = true
 
 		// Build shapes arrays
 		shapesForAnnotation, shapeImages, shapesForToon := p.buildShapeArrays(group, shapeDataMap, &globalShapeNumber)
 
-		// Annotate the image
-		annotatedImage, _, err := tools.AnnotateImageWithNumbers(imageBase64, shapesForAnnotation)
-		if err != nil {
-			log.Printf("Warning: Failed to annotate image: %v, using original", err)
-			annotatedImage = imageBase64
+		annotatedSelections[i] = helpers.AnnotatedSelection{
+			MimeType:      "image/png",
+			Shapes:        shapeImages,
+			ShapeMetadata: p.encodeShapesAsToon(shapesForToon),
 		}
 
-		// Format with TOON using gotoon library
-		shapeMetadata := p.encodeShapesAsToon(shapesForToon)
+		// Annotate the image
+		wg.Add(1)
+		go func(i int, shapesForAnnotation []map[string]any) {
+			defer wg.Done()
+			annotatedImage, _, err := tools.AnnotateImageWithNumbers(images[i], shapesForAnnotation)
+			if err != nil {
+				log.Printf("Warning: Failed to annotate image: %v, using original", err)
+				annotatedImage = images[i]
+			}
+			annotatedSelections[i].AnnotatedImage = annotatedImage
+		}(i, shapesForAnnotation)
+	}
+	wg.Wait()
 
-		annotatedSelections = append(annotatedSelections, helpers.AnnotatedSelection{
-			AnnotatedImage: annotatedImage,
-			MimeType:       "image/png",
-			Shapes:         shapeImages,
-			ShapeMetadata:  shapeMetadata,
-		})
+	var result []helpers.AnnotatedSelection
+	for i, selection := range annotatedSelections {
+		if loaded[i] {
+			result = append(result, selection)
+		}
 	}
+	return result
+}
 
-	return annotatedSelections
+// loadImageAsBase64 returns an image sent inline with the message, or fetches it from its URL, as base64
+// The media type is only known for inline images
+func (p *ImageProcessor) loadImageAsBase64(url string, attachments []libraries.FrameAttachment) (string, string, error) {
+	attachment, isAttachment, err := libraries.ResolveAttachment(url, attachments)
+	if err != nil {
+		return "", "", err
+	}
+	if isAttachment {
+		return base64.StdEncoding.EncodeToString(attachment.Data), attachment.MediaType, nil
+	}
+	imageBase64, err := p.fetchImageAsBase64(url)
+	return imageBase64, "", err
 }
 
 // fetchImageAsBase64 fetches an image from a URL and returns it as base64
@@ -237,7 +283,7 @@ func (p *ImageProcessor) encodeShapesAsToon(shapesForToon []map[string]any) stri
 }
 
 // ProcessUploadedImages fetches uploaded images and returns as base64 (no annotation)
-func (p *ImageProcessor) ProcessUploadedImages(urls []string) []helpers.UploadedImage {
+func (p *ImageProcessor) ProcessUploadedImages(urls []string, attachments []libraries.FrameAttachment) []helpers.UploadedImage {
 	if len(urls) == 0 {
 		return nil
 	}
@@ -246,21 +292,23 @@ func (p *ImageProcessor) ProcessUploadedImages(urls []string) []helpers.Uploaded
 
 	var images []helpers.UploadedImage
 	for _, url := range urls {
-		base64Data, err := p.fetchImageAsBase64(url)
+		base64Data, mimeType, err := p.loadImageAsBase64(url, attachments)
 		if err != nil {
 			log.Printf("Failed to fetch uploaded image from %s: %v", url, err)
 			continue
 		}
 
-		// Detect mime type from URL or default to jpeg
-		mimeType := "image/jpeg"
-		lowerUrl := strings.ToLower(url)
-		if strings.HasSuffix(lowerUrl, ".png") {
-			mimeType = "image/png"
-		} else if strings.HasSuffix(lowerUrl, ".gif") {
-			mimeType = "image/gif"
-		} else if strings.HasSuffix(lowerUrl, ".webp") {
-			mimeType = "image/webp"
+		// Inline images come with their mime type; otherwise detect it from the URL or default to jpeg
+		if mimeType == "" {
+			mimeType = "image/jpeg"
+			lowerUrl := strings.ToLower(url)
+			if strings.HasSuffix(lowerUrl, ".png") {
+				mimeType = "image/png"
+			} else if strings.HasSuffix(lowerUrl, ".gif") {
+				mimeType = "image/gif"
+			} else if strings.HasSuffix(lowerUrl, ".webp") {
+				mimeType = "image/webp"
+			}
 		}
 
 		images = append(images, helpers.UploadedImage{
@@ -0,0 +1,689 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"html"
+	"io"
+	"log"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/http"
+	"net/mail"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"melina-studio-backend/internal/config"
+	"melina-studio-backend/internal/libraries"
+	"melina-studio-backend/internal/melina/tools"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInboundEmailDisabled = errors.New("inbound email is not enabled on this server")
+	ErrInboxNotFound        = errors.New("the board has no email address")
+	ErrInvalidInbox         = errors.New("invalid board email settings")
+	ErrNotAnEmail           = errors.New("the notification is not a received email")
+)
+
+const (
+	// emailBodyLimit is how much of a message part is read; attachments are skipped
+	emailBodyLimit = 1 << 20
+	// emailSummaryPromptLimit caps the body handed to the agent for a summary
+	emailSummaryPromptLimit = 4000
+)
+
+// snsSubscribeHost matches the SNS endpoints a subscription confirmation may point at
+var snsSubscribeHost = regexp.MustCompile(`^sns\.[a-z0-9-]+\.amazonaws\.com(\.cn)?$`)
+
+// htmlDropped matches the HTML elements whose content isn't text
+var htmlDropped = regexp.MustCompile(`(?is)<(style|script|head)[^>]*>.*?</(style|script|head)>`)
+
+// htmlBreak matches the HTML tags that end a line
+var htmlBreak = regexp.MustCompile(`(?i)<br\s*/?>|</(p|div|li|tr|h[1-6])>`)
+
+// htmlTag matches any other HTML tag
+var htmlTag = regexp.MustCompile(`<[^>]*>`)
+
+// InboundEmail is an email as the provider received it
+type InboundEmail struct {
+	From     string
+	FromName string
+	// Recipients are the lower-cased addresses of To, Cc and the envelope, which has the Bcc ones
+	Recipients []string
+	Subject    string
+	Text       string
+}
+
+// SNSMessage is what Amazon SNS posts to an HTTPS subscription
+type SNSMessage struct {
+	Type             string `json:"Type"`
+	MessageId        string `json:"MessageId"`
+	Token            string `json:"Token"`
+	TopicArn         string `json:"TopicArn"`
+	Subject          string `json:"Subject"`
+	Message          string `json:"Message"`
+	SubscribeURL     string `json:"SubscribeURL"`
+	Timestamp        string `json:"Timestamp"`
+	SignatureVersion string `json:"SignatureVersion"`
+	Signature        string `json:"Signature"`
+	SigningCertURL   string `json:"SigningCertURL"`
+}
+
+// InboxInput is a board address's settings; a PUT replaces every field
+type InboxInput struct {
+	// Summarize defaults to false
+	Summarize      *bool    `json:"summarize"`
+	AllowedSenders []string `json:"allowed_senders"`
+}
+
+// inboxWindow counts the emails an address accepted in the current hour
+type inboxWindow struct {
+	start time.Time
+	count int
+}
+
+// InboundEmailService gives boards email addresses and turns the emails sent to them into sticky notes, which the
+// board's agent can summarize. The notes are written straight to the database, so they don't set off automations
+type InboundEmailService struct {
+	cfg           config.InboundEmailConfig
+	inboxRepo     repo.BoardInboxRepoInterface
+	boardRepo     repo.BoardRepoInterface
+	boardDataRepo repo.BoardDataRepoInterface
+	automations   *AutomationService
+	jobs          *JobQueue
+	hub           *libraries.Hub
+	sns           *SNSVerifier
+
+	mu      sync.Mutex
+	windows map[uuid.UUID]*inboxWindow
+}
+
+func NewInboundEmailService(
+	cfg config.InboundEmailConfig,
+	inboxRepo repo.BoardInboxRepoInterface,
+	boardRepo repo.BoardRepoInterface,
+	boardDataRepo repo.BoardDataRepoInterface,
+	automations *AutomationService,
+	jobs *JobQueue,
+	hub *libraries.Hub,
+) *InboundEmailService {
+	return &InboundEmailService{
+		cfg:           cfg,
+		inboxRepo:     inboxRepo,
+		boardRepo:     boardRepo,
+		boardDataRepo: boardDataRepo,
+		automations:   automations,
+		jobs:          jobs,
+		hub:           hub,
+		sns:           NewSNSVerifier(),
+		windows:       make(map[uuid.UUID]*inboxWindow),
+	}
+}
+
+// WebhookAllowed reports whether a webhook call carries the configured secret; with no secret every call is refused
+func (s *InboundEmailService) WebhookAllowed(token string) bool {
+	if !s.cfg.Enabled || s.cfg.WebhookSecret == "" {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(token), []byte(s.cfg.WebhookSecret)) == 1
+}
+
+// VerifySNSMessage checks that an SNS message was signed by Amazon SNS and comes from one of the configured topics
+func (s *InboundEmailService) VerifySNSMessage(ctx context.Context, msg SNSMessage) error {
+	if len(s.cfg.SNSTopicARNs) > 0 && !slices.Contains(s.cfg.SNSTopicARNs, msg.TopicArn) {
+		return fmt.Errorf("%w: topic %q isn't allowed", ErrInvalidSNSSignature, msg.TopicArn)
+	}
+	return s.sns.Verify(ctx, msg)
+}
+
+// Get returns the board's address
+func (s *InboundEmailService) Get(boardID uuid.UUID) (*models.BoardInbox, error) {
+	inbox, err := s.inboxRepo.GetByBoard(boardID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrInboxNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	inbox.Address = s.address(inbox.Token)
+	return inbox, nil
+}
+
+// Save gives the board an address, or changes the settings of the one it has
+func (s *InboundEmailService) Save(userID uuid.UUID, boardID uuid.UUID, input InboxInput) (*models.BoardInbox, error) {
+	if !s.cfg.Enabled || s.cfg.Domain == "" {
+		return nil, ErrInboundEmailDisabled
+	}
+	senders, err := normalizeAllowedSenders(input.AllowedSenders)
+	if err != nil {
+		return nil, err
+	}
+	allowed, err := json.Marshal(senders)
+	if err != nil {
+		return nil, err
+	}
+
+	inbox, err := s.inboxRepo.GetByBoard(boardID)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if inbox == nil {
+		token, err := newInboxToken()
+		if err != nil {
+			return nil, err
+		}
+		inbox = &models.BoardInbox{BoardID: boardID, UserID: userID, Token: token}
+	}
+	inbox.Summarize = input.Summarize != nil && *input.Summarize
+	inbox.AllowedSenders = datatypes.JSON(allowed)
+	if inbox.UUID == uuid.Nil {
+		err = s.inboxRepo.CreateInbox(inbox)
+	} else {
+		err = s.inboxRepo.UpdateInbox(inbox)
+	}
+	if err != nil {
+		return nil, err
+	}
+	inbox.Address = s.address(inbox.Token)
+	return inbox, nil
+}
+
+// Rotate gives the board a new address; emails to the old one are dropped
+func (s *InboundEmailService) Rotate(boardID uuid.UUID) (*models.BoardInbox, error) {
+	inbox, err := s.Get(boardID)
+	if err != nil {
+		return nil, err
+	}
+	if inbox.Token, err = newInboxToken(); err != nil {
+		return nil, err
+	}
+	if err := s.inboxRepo.UpdateInbox(inbox); err != nil {
+		return nil, err
+	}
+	inbox.Address = s.address(inbox.Token)
+	return inbox, nil
+}
+
+// Delete removes the board's address
+func (s *InboundEmailService) Delete(boardID uuid.UUID) error {
+	err := s.inboxRepo.DeleteInbox(boardID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return ErrInboxNotFound
+	}
+	return err
+}
+
+func (s *InboundEmailService) address(token string) string {
+	return token + "@" + s.cfg.Domain
+}
+
+// newInboxToken returns a random, unguessable local part
+func newInboxToken() (string, error) {
+	buf := make([]byte, 10)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return strings.ToLower(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(buf)), nil
+}
+
+// normalizeAllowedSenders lower-cases the sender addresses and "@domain" entries, refusing anything else
+func normalizeAllowedSenders(senders []string) ([]string, error) {
+	normalized := []string{}
+	for _, sender := range senders {
+		sender = strings.ToLower(strings.TrimSpace(sender))
+		if sender == "" {
+			continue
+		}
+		valid := strings.HasPrefix(sender, "@") && len(sender) > 1 && !strings.ContainsAny(sender[1:], "@ ")
+		if !valid {
+			addr, err := mail.ParseAddress(sender)
+			valid = err == nil && addr.Address == sender
+		}
+		if !valid {
+			return nil, fmt.Errorf("%w: %q is neither an address nor an @domain", ErrInvalidInbox, sender)
+		}
+		normalized = append(normalized, sender)
+	}
+	return normalized, nil
+}
+
+// senderAllowed reports whether the address's allowlist accepts the sender; an empty list accepts anyone
+func senderAllowed(allowed []string, from string) bool {
+	if len(allowed) == 0 {
+		return true
+	}
+	from = strings.ToLower(from)
+	for _, sender := range allowed {
+		if sender == from || (strings.HasPrefix(sender, "@") && strings.HasSuffix(from, sender)) {
+			return true
+		}
+	}
+	return false
+}
+
+// inboxToken returns the token of a board address on the domain, ignoring a "+tag" suffix
+func inboxToken(address string, domain string) (string, bool) {
+	at := strings.LastIndex(address, "@")
+	if at <= 0 || domain == "" || !strings.EqualFold(address[at+1:], domain) {
+		return "", false
+	}
+	local := strings.ToLower(address[:at])
+	if plus := strings.Index(local, "+"); plus >= 0 {
+		local = local[:plus]
+	}
+	if local == "" || len(local) > 32 {
+		return "", false
+	}
+	return local, true
+}
+
+// Receive adds the email to every board it was sent to and returns how many got it. Unknown addresses and
+// refused senders are skipped rather than failed, so the provider doesn't retry
+func (s *InboundEmailService) Receive(email InboundEmail) int {
+	delivered := 0
+	seen := map[string]bool{}
+	for _, recipient := range email.Recipients {
+		token, ok := inboxToken(recipient, s.cfg.Domain)
+		if !ok || seen[token] {
+			continue
+		}
+		seen[token] = true
+		inbox, err := s.inboxRepo.GetByToken(token)
+		if err != nil {
+			if !errors.Is(err, gorm.ErrRecordNotFound) {
+				log.Printf("[inbound-email] failed to look up %s: %v", recipient, err)
+			}
+			continue
+		}
+		if err := s.deliver(*inbox, email); err != nil {
+			log.Printf("[inbound-email] dropped an email from %s to board %s: %v", email.From, inbox.BoardID, err)
+			continue
+		}
+		delivered++
+	}
+	return delivered
+}
+
+// deliver adds the email to the address's board as a sticky note and queues its summary
+func (s *InboundEmailService) deliver(inbox models.BoardInbox, email InboundEmail) error {
+	var allowed []string
+	if len(inbox.AllowedSenders) > 0 {
+		if err := json.Unmarshal(inbox.AllowedSenders, &allowed); err != nil {
+			return err
+		}
+	}
+	if !senderAllowed(allowed, email.From) {
+		return errors.New("the sender is not allowed")
+	}
+	if !s.accept(inbox.UUID) {
+		return fmt.Errorf("the address took %d emails this hour", s.cfg.MaxPerHour)
+	}
+	if _, err := s.boardRepo.GetBoardById(inbox.UserID, inbox.BoardID); err != nil {
+		return fmt.Errorf("board not found: %w", err)
+	}
+
+	shapes, err := s.boardDataRepo.GetBoardData(inbox.BoardID)
+	if err != nil {
+		return err
+	}
+	text := EmailNoteText(email, s.cfg.MaxNoteChars)
+	note := stickyNoteShapes(text, tools.OccupiedBounds(shapes))
+	changed := make([]string, 0, len(note))
+	for _, shape := range note {
+		if err := s.boardDataRepo.SaveShapeData(inbox.BoardID, shape); err != nil {
+			return fmt.Errorf("failed to save the note: %w", err)
+		}
+		changed = append(changed, shape.ID)
+	}
+	if err := s.inboxRepo.MarkReceived(inbox.UUID, time.Now()); err != nil {
+		log.Printf("[inbound-email] failed to stamp address of board %s: %v", inbox.BoardID, err)
+	}
+	s.notify(inbox, email, &libraries.InboundEmailPayload{ChangedShapes: changed})
+
+	if inbox.Summarize && s.automations != nil {
+		noteID := note[len(note)-1].ID
+		err := s.jobs.Enqueue("inbound-email:"+inbox.BoardID.String(), func(ctx context.Context) error {
+			s.summarize(ctx, inbox, email, noteID)
+			return nil
+		})
+		if err != nil {
+			s.notify(inbox, email, &libraries.InboundEmailPayload{Summary: true, Error: err.Error()})
+		}
+	}
+	return nil
+}
+
+// accept reports whether the address may take another email this hour, and counts it
+func (s *InboundEmailService) accept(inboxID uuid.UUID) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := time.Now()
+	window, ok := s.windows[inboxID]
+	if !ok || now.Sub(window.start) >= time.Hour {
+		if len(s.windows) > 1000 {
+			for id, w := range s.windows {
+				if now.Sub(w.start) >= time.Hour {
+					delete(s.windows, id)
+				}
+			}
+		}
+		window = &inboxWindow{start: now}
+		s.windows[inboxID] = window
+	}
+	if window.count >= s.cfg.MaxPerHour {
+		return false
+	}
+	window.count++
+	return true
+}
+
+// summarize has the board's agent add a summary of the email next to its note, the way agent_prompt automations
+// run: default model, AUTOMATIONS_AGENT_TIMEOUT_SECONDS, tokens counted against the owner
+func (s *InboundEmailService) summarize(ctx context.Context, inbox models.BoardInbox, email InboundEmail, noteID string) {
+	body := email.Text
+	if len(body) > emailSummaryPromptLimit {
+		body = body[:emailSummaryPromptLimit] + "..."
+	}
+	prompt := fmt.Sprintf("An email was just added to the board as a sticky note (text shape %s). "+
+		"Add one short note right next to it summarizing the email in a few bullet points, ending with the action items it asks for, if any. "+
+		"Don't change the email's note.\n\nFrom: %s\nSubject: %s\n\n%s", noteID, email.From, email.Subject, body)
+
+	result, err := s.automations.runAgent(ctx, inbox.UserID, inbox.BoardID, prompt)
+	payload := &libraries.InboundEmailPayload{Summary: true, ChangedShapes: result.changed, ReloadBoard: result.reloadBoard}
+	if err != nil {
+		log.Printf("[inbound-email] summary failed on board %s: %v", inbox.BoardID, err)
+		payload.Error = err.Error()
+	}
+	s.notify(inbox, email, payload)
+}
+
+func (s *InboundEmailService) notify(inbox models.BoardInbox, email InboundEmail, payload *libraries.InboundEmailPayload) {
+	if s.hub == nil {
+		return
+	}
+	payload.BoardId = inbox.BoardID.String()
+	payload.From = email.From
+	payload.Subject = email.Subject
+	libraries.SendInboundEmailMessage(s.hub, inbox.UserID.String(), payload)
+}
+
+// EmailNoteText is what an email's sticky note says: the subject, the sender and the body, cut to maxChars
+func EmailNoteText(email InboundEmail, maxChars int) string {
+	subject := strings.TrimSpace(email.Subject)
+	if subject == "" {
+		subject = "(no subject)"
+	}
+	from := email.From
+	if email.FromName != "" {
+		from = email.FromName
+	}
+	text := "✉ " + subject + "\nFrom: " + from
+	if body := cleanEmailText(email.Text); body != "" {
+		if utf8.RuneCountInString(body) > maxChars {
+			body = strings.TrimSpace(string([]rune(body)[:maxChars])) + "…"
+		}
+		text += "\n\n" + body
+	}
+	return text
+}
+
+// cleanEmailText drops the quoted replies and the signature of an email body and squeezes blank lines
+func cleanEmailText(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	if cut := strings.Index(text, "\n-- \n"); cut >= 0 {
+		text = text[:cut]
+	}
+	var kept []string
+	blank := false
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimRight(line, " \t")
+		if strings.HasPrefix(line, ">") {
+			continue
+		}
+		if line == "" {
+			if blank || len(kept) == 0 {
+				continue
+			}
+			blank = true
+		} else {
+			blank = false
+		}
+		kept = append(kept, line)
+	}
+	return strings.TrimSpace(strings.Join(kept, "\n"))
+}
+
+// htmlToText keeps the text of an HTML body, one line per paragraph
+func htmlToText(body string) string {
+	body = htmlDropped.ReplaceAllString(body, "")
+	body = htmlBreak.ReplaceAllString(body, "\n")
+	body = htmlTag.ReplaceAllString(body, "")
+	lines := strings.Split(html.UnescapeString(body), "\n")
+	for i, line := range lines {
+		lines[i] = strings.Join(strings.Fields(line), " ")
+	}
+	return strings.Join(lines, "\n")
+}
+
+// ParseRawEmail reads a MIME message: the sender, the To and Cc recipients, the subject and the text body,
+// taken from the text/plain part or else from the HTML one
+func ParseRawEmail(raw []byte) (InboundEmail, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(raw))
+	if err != nil {
+		return InboundEmail{}, fmt.Errorf("invalid email: %w", err)
+	}
+	var email InboundEmail
+	decoder := new(mime.WordDecoder)
+	if email.Subject, err = decoder.DecodeHeader(msg.Header.Get("Subject")); err != nil {
+		email.Subject = msg.Header.Get("Subject")
+	}
+	if from, err := mail.ParseAddress(msg.Header.Get("From")); err == nil {
+		email.From, email.FromName = strings.ToLower(from.Address), from.Name
+	}
+	for _, header := range []string{"To", "Cc"} {
+		addresses, _ := msg.Header.AddressList(header)
+		for _, address := range addresses {
+			email.Recipients = append(email.Recipients, strings.ToLower(address.Address))
+		}
+	}
+	plain, htmlBody := readEmailPart(msg.Header, msg.Body)
+	email.Text = plain
+	if strings.TrimSpace(plain) == "" {
+		email.Text = htmlToText(htmlBody)
+	}
+	return email, nil
+}
+
+// readEmailPart returns the text/plain and text/html bodies of a part, looking into multipart ones
+func readEmailPart(header interface{ Get(string) string }, body io.Reader) (string, string) {
+	if strings.HasPrefix(strings.ToLower(header.Get("Content-Disposition")), "attachment") {
+		return "", ""
+	}
+	mediaType, params, err := mime.ParseMediaType(header.Get("Content-Type"))
+	if err != nil {
+		mediaType = "text/plain"
+	}
+	switch strings.ToLower(header.Get("Content-Transfer-Encoding")) {
+	case "base64":
+		body = base64.NewDecoder(base64.StdEncoding, &newlineSkipper{body})
+	case "quoted-printable":
+		body = quotedprintable.NewReader(body)
+	}
+
+	if strings.HasPrefix(mediaType, "multipart/") {
+		var plain, htmlBody string
+		reader := multipart.NewReader(body, params["boundary"])
+		for {
+			part, err := reader.NextPart()
+			if err != nil {
+				break
+			}
+			p, h := readEmailPart(part.Header, part)
+			if plain == "" {
+				plain = p
+			}
+			if htmlBody == "" {
+				htmlBody = h
+			}
+		}
+		return plain, htmlBody
+	}
+
+	data, err := io.ReadAll(io.LimitReader(body, emailBodyLimit))
+	if err != nil {
+		return "", ""
+	}
+	switch mediaType {
+	case "text/plain":
+		return string(data), ""
+	case "text/html":
+		return "", string(data)
+	}
+	return "", ""
+}
+
+// newlineSkipper drops the line breaks of a base64 body, which the decoder doesn't accept
+type newlineSkipper struct {
+	r io.Reader
+}
+
+func (n *newlineSkipper) Read(p []byte) (int, error) {
+	count, err := n.r.Read(p)
+	kept := 0
+	for _, b := range p[:count] {
+		if b != '\r' && b != '\n' {
+			p[kept] = b
+			kept++
+		}
+	}
+	return kept, err
+}
+
+// SendGridEmail reads the fields SendGrid Inbound Parse posts, either parsed or, with "Send Raw", the whole
+// message in "email". The envelope adds the Bcc recipients
+func SendGridEmail(fields map[string]string) (InboundEmail, error) {
+	var email InboundEmail
+	if raw := fields["email"]; raw != "" {
+		parsed, err := ParseRawEmail([]byte(raw))
+		if err != nil {
+			return InboundEmail{}, err
+		}
+		email = parsed
+	} else {
+		if from, err := mail.ParseAddress(fields["from"]); err == nil {
+			email.From, email.FromName = strings.ToLower(from.Address), from.Name
+		}
+		for _, field := range []string{"to", "cc"} {
+			addresses, _ := mail.ParseAddressList(fields[field])
+			for _, address := range addresses {
+				email.Recipients = append(email.Recipients, strings.ToLower(address.Address))
+			}
+		}
+		email.Subject = fields["subject"]
+		email.Text = fields["text"]
+		if strings.TrimSpace(email.Text) == "" {
+			email.Text = htmlToText(fields["html"])
+		}
+	}
+
+	var envelope struct {
+		To   []string `json:"to"`
+		From string   `json:"from"`
+	}
+	if fields["envelope"] != "" && json.Unmarshal([]byte(fields["envelope"]), &envelope) == nil {
+		for _, to := range envelope.To {
+			email.Recipients = append(email.Recipients, strings.ToLower(strings.TrimSpace(to)))
+		}
+		if email.From == "" {
+			email.From = strings.ToLower(envelope.From)
+		}
+	}
+	if len(email.Recipients) == 0 {
+		return InboundEmail{}, errors.New("the email has no recipients")
+	}
+	return email, nil
+}
+
+// ParseSESNotification reads the notification an SES receipt rule's SNS action publishes; the action must
+// include the message content, with UTF-8 or Base64 encoding
+func ParseSESNotification(message string) (InboundEmail, error) {
+	var notification struct {
+		NotificationType string `json:"notificationType"`
+		Mail             struct {
+			Destination []string `json:"destination"`
+		} `json:"mail"`
+		Receipt struct {
+			Action struct {
+				Encoding string `json:"encoding"`
+			} `json:"action"`
+		} `json:"receipt"`
+		Content string `json:"content"`
+	}
+	if err := json.Unmarshal([]byte(message), &notification); err != nil {
+		return InboundEmail{}, fmt.Errorf("invalid SES notification: %w", err)
+	}
+	if notification.NotificationType != "Received" {
+		return InboundEmail{}, ErrNotAnEmail
+	}
+	if notification.Content == "" {
+		return InboundEmail{}, errors.New("the SES notification has no content, the SNS action must include it")
+	}
+	raw := []byte(notification.Content)
+	if strings.EqualFold(notification.Receipt.Action.Encoding, "BASE64") {
+		decoded, err := base64.StdEncoding.DecodeString(notification.Content)
+		if err != nil {
+			return InboundEmail{}, fmt.Errorf("invalid SES content: %w", err)
+		}
+		raw = decoded
+	}
+	email, err := ParseRawEmail(raw)
+	if err != nil {
+		return InboundEmail{}, err
+	}
+	for _, destination := range notification.Mail.Destination {
+		email.Recipients = append(email.Recipients, strings.ToLower(destination))
+	}
+	return email, nil
+}
+
+// ConfirmSNSSubscription visits the subscription's confirmation URL, which must be on an SNS endpoint
+func ConfirmSNSSubscription(ctx context.Context, subscribeURL string) error {
+	u, err := url.Parse(subscribeURL)
+	if err != nil || u.Scheme != "https" || !snsSubscribeHost.MatchString(u.Hostname()) {
+		return fmt.Errorf("refusing to confirm a subscription at %q", subscribeURL)
+	}
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, u.String(), nil)
+	if err != nil {
+		return err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("SNS answered %d", resp.StatusCode)
+	}
+	return nil
+}
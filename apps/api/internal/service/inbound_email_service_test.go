@@ -0,0 +1,160 @@
+package service
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+const multipartEmail = "From: \"Ada Lovelace\" <Ada@Example.com>\r\n" +
+	"To: abc123@in.melina.studio, bob@example.com\r\n" +
+	"Subject: =?UTF-8?Q?Caf=C3=A9_plans?=\r\n" +
+	"MIME-Version: 1.0\r\n" +
+	"Content-Type: multipart/alternative; boundary=\"b1\"\r\n" +
+	"\r\n" +
+	"--b1\r\n" +
+	"Content-Type: text/plain; charset=utf-8\r\n" +
+	"Content-Transfer-Encoding: quoted-printable\r\n" +
+	"\r\n" +
+	"Let's meet at the caf=C3=A9.\r\n" +
+	"--b1\r\n" +
+	"Content-Type: text/html; charset=utf-8\r\n" +
+	"\r\n" +
+	"<p>Let's meet</p>\r\n" +
+	"--b1--\r\n"
+
+func TestParseRawEmail(t *testing.T) {
+	email, err := ParseRawEmail([]byte(multipartEmail))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if email.From != "ada@example.com" || email.FromName != "Ada Lovelace" || email.Subject != "Café plans" {
+		t.Errorf("unexpected headers %+v", email)
+	}
+	if want := []string{"abc123@in.melina.studio", "bob@example.com"}; !reflect.DeepEqual(email.Recipients, want) {
+		t.Errorf("got recipients %v, want %v", email.Recipients, want)
+	}
+	if strings.TrimSpace(email.Text) != "Let's meet at the café." {
+		t.Errorf("expected the decoded plain part, got %q", email.Text)
+	}
+
+	htmlOnly := "From: ada@example.com\r\nTo: abc123@in.melina.studio\r\nSubject: Hi\r\n" +
+		"Content-Type: text/html\r\nContent-Transfer-Encoding: base64\r\n\r\n" +
+		base64.StdEncoding.EncodeToString([]byte("<style>p{}</style><p>One &amp; two</p><p>Three</p>")) + "\r\n"
+	email, err = ParseRawEmail([]byte(htmlOnly))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.TrimSpace(email.Text) != "One & two\nThree" {
+		t.Errorf("expected the text of the HTML part, got %q", email.Text)
+	}
+}
+
+func TestSendGridEmail(t *testing.T) {
+	email, err := SendGridEmail(map[string]string{
+		"from":     "Ada <ada@example.com>",
+		"to":       "team@example.com",
+		"subject":  "Notes",
+		"text":     "Body",
+		"envelope": `{"to":["ABC123@in.melina.studio"],"from":"ada@example.com"}`,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"team@example.com", "abc123@in.melina.studio"}; !reflect.DeepEqual(email.Recipients, want) {
+		t.Errorf("expected the envelope to add the Bcc recipient, got %v", email.Recipients)
+	}
+	if email.From != "ada@example.com" || email.Text != "Body" {
+		t.Errorf("unexpected email %+v", email)
+	}
+
+	if _, err := SendGridEmail(map[string]string{"subject": "Nobody"}); err == nil {
+		t.Error("expected an email without recipients to fail")
+	}
+}
+
+func TestParseSESNotification(t *testing.T) {
+	notification, _ := json.Marshal(map[string]any{
+		"notificationType": "Received",
+		"mail":             map[string]any{"destination": []string{"Hidden@in.melina.studio"}},
+		"receipt":          map[string]any{"action": map[string]any{"type": "SNS", "encoding": "BASE64"}},
+		"content":          base64.StdEncoding.EncodeToString([]byte(multipartEmail)),
+	})
+	email, err := ParseSESNotification(string(notification))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if last := email.Recipients[len(email.Recipients)-1]; last != "hidden@in.melina.studio" {
+		t.Errorf("expected the destination as a recipient, got %v", email.Recipients)
+	}
+
+	bounce, _ := json.Marshal(map[string]any{"notificationType": "Bounce"})
+	if _, err := ParseSESNotification(string(bounce)); !errors.Is(err, ErrNotAnEmail) {
+		t.Errorf("expected ErrNotAnEmail, got %v", err)
+	}
+}
+
+func TestInboxToken(t *testing.T) {
+	cases := map[string]string{
+		"abc123@in.melina.studio":       "abc123",
+		"ABC123+ideas@IN.melina.studio": "abc123",
+		"abc123@melina.studio":          "",
+		"@in.melina.studio":             "",
+	}
+	for address, want := range cases {
+		got, ok := inboxToken(address, "in.melina.studio")
+		if got != want || ok != (want != "") {
+			t.Errorf("%s: got %q %v, want %q", address, got, ok, want)
+		}
+	}
+}
+
+func TestAllowedSenders(t *testing.T) {
+	senders, err := normalizeAllowedSenders([]string{" Ada@Example.com ", "@Team.io", ""})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"ada@example.com", "@team.io"}; !reflect.DeepEqual(senders, want) {
+		t.Errorf("got %v, want %v", senders, want)
+	}
+	if _, err := normalizeAllowedSenders([]string{"not an address"}); !errors.Is(err, ErrInvalidInbox) {
+		t.Errorf("expected ErrInvalidInbox, got %v", err)
+	}
+
+	if !senderAllowed(nil, "anyone@example.com") {
+		t.Error("expected an empty list to allow anyone")
+	}
+	if !senderAllowed(senders, "bob@team.io") || !senderAllowed(senders, "ada@example.com") {
+		t.Error("expected the listed address and domain to be allowed")
+	}
+	if senderAllowed(senders, "bob@example.com") {
+		t.Error("expected an unlisted sender to be refused")
+	}
+}
+
+func TestEmailNoteText(t *testing.T) {
+	email := InboundEmail{
+		From:     "ada@example.com",
+		FromName: "Ada",
+		Subject:  "Launch",
+		Text:     "Ship it Friday.\r\n\r\n\r\nThanks\r\n> earlier reply\r\n-- \r\nAda, CEO\r\n",
+	}
+	want := "✉ Launch\nFrom: Ada\n\nShip it Friday.\n\nThanks"
+	if got := EmailNoteText(email, 1000); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+	if got := EmailNoteText(email, 4); !strings.HasSuffix(got, "\n\nShip…") {
+		t.Errorf("expected the body to be cut, got %q", got)
+	}
+}
+
+func TestWrapNoteText(t *testing.T) {
+	got := wrapNoteText("one two three\n\nabcdefghij", 8)
+	want := []string{"one two", "three", "", "abcdefgh", "ij"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,216 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"melina-studio-backend/internal/libraries"
+	"melina-studio-backend/internal/melina/tools"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidIntegrationInput = errors.New("invalid integration request")
+	ErrIntegrationBoardMissing = errors.New("board not found")
+)
+
+const (
+	// integrationDefaultLimit and integrationMaxLimit bound the items a trigger returns
+	integrationDefaultLimit = 50
+	integrationMaxLimit     = 100
+	// integrationTitleLimit caps the title of a board created by an integration
+	integrationTitleLimit = 200
+	// integrationNoteLimit caps the text of a note added by an integration
+	integrationNoteLimit = 5000
+)
+
+// IntegrationUser is the account an API key acts as, the connection label integrations show
+type IntegrationUser struct {
+	ID    uuid.UUID `json:"id"`
+	Email string    `json:"email"`
+	Name  string    `json:"name"`
+}
+
+// IntegrationService serves the polling triggers and the actions of the no-code integrations (Zapier, Make).
+// Triggers return flat items, newest first, each with an id to deduplicate on and a cursor to poll from
+type IntegrationService struct {
+	integrationRepo repo.IntegrationRepoInterface
+	authRepo        repo.AuthRepoInterface
+	boardRepo       repo.BoardRepoInterface
+	boardDataRepo   repo.BoardDataRepoInterface
+	hub             *libraries.Hub
+}
+
+func NewIntegrationService(
+	integrationRepo repo.IntegrationRepoInterface,
+	authRepo repo.AuthRepoInterface,
+	boardRepo repo.BoardRepoInterface,
+	boardDataRepo repo.BoardDataRepoInterface,
+	hub *libraries.Hub,
+) *IntegrationService {
+	return &IntegrationService{
+		integrationRepo: integrationRepo,
+		authRepo:        authRepo,
+		boardRepo:       boardRepo,
+		boardDataRepo:   boardDataRepo,
+		hub:             hub,
+	}
+}
+
+// ParseIntegrationCursor reads a since parameter: an item's cursor, in microseconds, or an RFC 3339 time.
+// Empty means from the beginning
+func ParseIntegrationCursor(since string) (time.Time, error) {
+	since = strings.TrimSpace(since)
+	if since == "" {
+		return time.Time{}, nil
+	}
+	if micros, err := strconv.ParseInt(since, 10, 64); err == nil && micros >= 0 {
+		return time.UnixMicro(micros), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, since); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("%w: since must be a cursor or an RFC 3339 time", ErrInvalidIntegrationInput)
+}
+
+// IntegrationLimit clamps a requested item count, 0 meaning the default
+func IntegrationLimit(limit int) int {
+	if limit <= 0 {
+		return integrationDefaultLimit
+	}
+	return min(limit, integrationMaxLimit)
+}
+
+// Me returns the account the key acts as
+func (s *IntegrationService) Me(userID uuid.UUID) (*IntegrationUser, error) {
+	user, err := s.authRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	return &IntegrationUser{
+		ID:    user.UUID,
+		Email: user.Email,
+		Name:  strings.TrimSpace(user.FirstName + " " + user.LastName),
+	}, nil
+}
+
+// NewBoards returns the boards created after since
+func (s *IntegrationService) NewBoards(userID uuid.UUID, since time.Time, limit int) ([]models.IntegrationBoard, error) {
+	boards, err := s.integrationRepo.ListNewBoards(userID, since, IntegrationLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	if boards == nil {
+		boards = []models.IntegrationBoard{}
+	}
+	for i := range boards {
+		boards[i].Cursor = boards[i].CreatedAt.UnixMicro()
+	}
+	return boards, nil
+}
+
+// NewShapes returns the shapes created after since, on every board or on boardID
+func (s *IntegrationService) NewShapes(userID uuid.UUID, boardID *uuid.UUID, since time.Time, limit int) ([]models.IntegrationShape, error) {
+	shapes, err := s.integrationRepo.ListNewShapes(userID, boardID, since, IntegrationLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	if shapes == nil {
+		shapes = []models.IntegrationShape{}
+	}
+	for i := range shapes {
+		shapes[i].Cursor = shapes[i].CreatedAt.UnixMicro()
+	}
+	return shapes, nil
+}
+
+// CompletedChats returns the agent replies saved after since, on every board or on boardID
+func (s *IntegrationService) CompletedChats(userID uuid.UUID, boardID *uuid.UUID, since time.Time, limit int) ([]models.IntegrationChat, error) {
+	chats, err := s.integrationRepo.ListCompletedChats(userID, boardID, since, IntegrationLimit(limit))
+	if err != nil {
+		return nil, err
+	}
+	if chats == nil {
+		chats = []models.IntegrationChat{}
+	}
+	for i := range chats {
+		chats[i].Cursor = chats[i].CreatedAt.UnixMicro()
+	}
+	return chats, nil
+}
+
+// CreateBoard creates an empty board
+func (s *IntegrationService) CreateBoard(userID uuid.UUID, tenantID *uuid.UUID, title string) (*models.IntegrationBoard, error) {
+	title = strings.TrimSpace(title)
+	if title == "" || utf8.RuneCountInString(title) > integrationTitleLimit {
+		return nil, fmt.Errorf("%w: the title must be 1 to %d characters", ErrInvalidIntegrationInput, integrationTitleLimit)
+	}
+	boardID, err := s.boardRepo.CreateBoard(&models.Board{Title: title, UserID: userID, TenantID: tenantID})
+	if err != nil {
+		return nil, err
+	}
+	// the stored created_at is what the new board trigger pages by, so the cursor is read back rather than taken now
+	board, err := s.boardRepo.GetBoardById(userID, boardID)
+	if err != nil {
+		return nil, err
+	}
+	return &models.IntegrationBoard{ID: boardID, Title: board.Title, CreatedAt: board.CreatedAt, Cursor: board.CreatedAt.UnixMicro()}, nil
+}
+
+// AddNote adds a sticky note with the text next to the board's content and returns its text shape
+func (s *IntegrationService) AddNote(userID uuid.UUID, boardID uuid.UUID, text string) (*models.IntegrationShape, error) {
+	text = strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n"))
+	if text == "" || utf8.RuneCountInString(text) > integrationNoteLimit {
+		return nil, fmt.Errorf("%w: the text must be 1 to %d characters", ErrInvalidIntegrationInput, integrationNoteLimit)
+	}
+	board, err := s.boardRepo.GetBoardById(userID, boardID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrIntegrationBoardMissing
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	shapes, err := s.boardDataRepo.GetBoardData(boardID)
+	if err != nil {
+		return nil, err
+	}
+	note := stickyNoteShapes(text, tools.OccupiedBounds(shapes))
+	changed := make([]string, 0, len(note))
+	for _, shape := range note {
+		if err := s.boardDataRepo.SaveShapeData(boardID, shape); err != nil {
+			return nil, fmt.Errorf("failed to save the note: %w", err)
+		}
+		changed = append(changed, shape.ID)
+	}
+	if s.hub != nil {
+		libraries.SendIntegrationActionMessage(s.hub, userID.String(), &libraries.IntegrationActionPayload{
+			BoardId:       boardID.String(),
+			Action:        "add_note",
+			ChangedShapes: changed,
+		})
+	}
+
+	textShape := note[len(note)-1]
+	stored, err := s.boardDataRepo.GetShapeByUUID(uuid.MustParse(textShape.ID))
+	if err != nil {
+		return nil, err
+	}
+	return &models.IntegrationShape{
+		ID:         stored.UUID,
+		BoardID:    boardID,
+		BoardTitle: board.Title,
+		Type:       textShape.Type,
+		Text:       *textShape.Text,
+		CreatedAt:  stored.CreatedAt,
+		Cursor:     stored.CreatedAt.UnixMicro(),
+	}, nil
+}
@@ -0,0 +1,35 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestParseIntegrationCursor(t *testing.T) {
+	created := time.Date(2026, 3, 1, 12, 30, 0, 123456000, time.UTC)
+
+	if since, err := ParseIntegrationCursor(""); err != nil || !since.IsZero() {
+		t.Errorf("expected no cursor to start from the beginning, got %v %v", since, err)
+	}
+	since, err := ParseIntegrationCursor("1772368200123456")
+	if err != nil || !since.Equal(created) {
+		t.Errorf("expected the cursor to be the creation time, got %v %v", since, err)
+	}
+	since, err = ParseIntegrationCursor("2026-03-01T12:30:00.123456Z")
+	if err != nil || !since.Equal(created) {
+		t.Errorf("expected an RFC 3339 time to be accepted, got %v %v", since, err)
+	}
+	if _, err := ParseIntegrationCursor("yesterday"); !errors.Is(err, ErrInvalidIntegrationInput) {
+		t.Errorf("expected ErrInvalidIntegrationInput, got %v", err)
+	}
+}
+
+func TestIntegrationLimit(t *testing.T) {
+	cases := map[int]int{0: integrationDefaultLimit, -3: integrationDefaultLimit, 10: 10, 1000: integrationMaxLimit}
+	for limit, want := range cases {
+		if got := IntegrationLimit(limit); got != want {
+			t.Errorf("%d: got %d, want %d", limit, got, want)
+		}
+	}
+}
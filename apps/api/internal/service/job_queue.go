@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	"melina-studio-backend/internal/config"
+	"sync"
+)
+
+var ErrJobQueueFull = errors.New("job queue is full")
+
+// Job is a unit of background work run by the job queue
+type Job func(ctx context.Context) error
+
+type queuedJob struct {
+	name string
+	run  Job
+}
+
+// JobQueue runs long tasks (summaries, exports, ...) on a fixed pool of workers
+type JobQueue struct {
+	config config.JobQueueConfig
+	jobs   chan queuedJob
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+}
+
+var jobQueue *JobQueue
+
+// GetJobQueue returns the process-wide job queue created by InitJobQueue
+func GetJobQueue() *JobQueue {
+	return jobQueue
+}
+
+// InitJobQueue creates the process-wide job queue
+func InitJobQueue(cfg config.JobQueueConfig) *JobQueue {
+	jobQueue = NewJobQueue(cfg)
+	return jobQueue
+}
+
+// NewJobQueue creates a new job queue
+func NewJobQueue(cfg config.JobQueueConfig) *JobQueue {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &JobQueue{
+		config: cfg,
+		jobs:   make(chan queuedJob, cfg.Capacity),
+		ctx:    ctx,
+		cancel: cancel,
+	}
+}
+
+// Start launches the worker goroutines
+func (q *JobQueue) Start() {
+	for i := 0; i < q.config.Workers; i++ {
+		q.wg.Add(1)
+		go q.runWorker()
+	}
+	log.Printf("Job queue started (workers: %d, capacity: %d)", q.config.Workers, q.config.Capacity)
+}
+
+// Stop cancels running jobs and waits for the workers to exit
+func (q *JobQueue) Stop() {
+	log.Println("Stopping job queue...")
+	q.cancel()
+	q.wg.Wait()
+	log.Println("Job queue stopped")
+}
+
+// Enqueue adds a job to the queue without blocking
+func (q *JobQueue) Enqueue(name string, job Job) error {
+	select {
+	case q.jobs <- queuedJob{name: name, run: job}:
+		return nil
+	default:
+		return ErrJobQueueFull
+	}
+}
+
+// runWorker executes jobs until the queue is stopped
+func (q *JobQueue) runWorker() {
+	defer q.wg.Done()
+	for {
+		select {
+		case <-q.ctx.Done():
+			return
+		case job := <-q.jobs:
+			q.runJob(job)
+		}
+	}
+}
+
+// runJob runs a single job with panic recovery so one bad job can't kill a worker
+func (q *JobQueue) runJob(job queuedJob) {
+	defer func() {
+		if r := recover(); r != nil {
+			log.Printf("Job %s panicked: %v", job.name, r)
+		}
+	}()
+
+	if err := job.run(q.ctx); err != nil {
+		log.Printf("Job %s failed: %v", job.name, err)
+		return
+	}
+	log.Printf("Job %s completed", job.name)
+}
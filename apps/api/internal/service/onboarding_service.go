@@ -0,0 +1,243 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+const sampleBoardTitle = "Welcome to Melina"
+
+// sampleBoardWelcome is the first message of the sample board's chat, as if Melina had written it
+const sampleBoardWelcome = "Hi, I'm Melina! This board is yours to play with. " +
+	"Ask me to draw something, like \"add a login form wireframe next to the checklist\", " +
+	"or select a few shapes and ask me to explain, restyle or connect them. " +
+	"When you're ready, create a board of your own from the sidebar."
+
+var ErrUnknownOnboardingStep = errors.New("unknown onboarding step")
+
+type OnboardingService struct {
+	onboardingRepo repo.OnboardingRepoInterface
+	boardRepo      repo.BoardRepoInterface
+	boardDataRepo  repo.BoardDataRepoInterface
+	chatRepo       repo.ChatRepoInterface
+	jobQueue       *JobQueue
+}
+
+func NewOnboardingService(
+	onboardingRepo repo.OnboardingRepoInterface,
+	boardRepo repo.BoardRepoInterface,
+	boardDataRepo repo.BoardDataRepoInterface,
+	chatRepo repo.ChatRepoInterface,
+	jobQueue *JobQueue,
+) *OnboardingService {
+	return &OnboardingService{
+		onboardingRepo: onboardingRepo,
+		boardRepo:      boardRepo,
+		boardDataRepo:  boardDataRepo,
+		chatRepo:       chatRepo,
+		jobQueue:       jobQueue,
+	}
+}
+
+// Start sets up onboarding for a user who just signed up; the sample board is provisioned in the
+// background so signup doesn't wait for it. Failures are logged only, they must never fail the signup
+func (s *OnboardingService) Start(userID uuid.UUID, tenantID *uuid.UUID) {
+	created, err := s.onboardingRepo.Create(&models.OnboardingProgress{UserID: userID, Status: models.SampleBoardPending})
+	if err != nil {
+		log.Printf("Failed to start onboarding for user %s: %v", userID, err)
+		return
+	}
+	if !created {
+		return
+	}
+
+	provision := func(ctx context.Context) error {
+		return s.provisionSampleBoard(ctx, userID, tenantID)
+	}
+	if s.jobQueue != nil {
+		err := s.jobQueue.Enqueue("onboarding:"+userID.String(), provision)
+		if err == nil {
+			return
+		}
+		log.Printf("Failed to queue sample board for user %s, provisioning in the background: %v", userID, err)
+	}
+	go func() {
+		if err := provision(context.Background()); err != nil {
+			log.Printf("Failed to provision sample board for user %s: %v", userID, err)
+		}
+	}()
+}
+
+// StartNow sets up onboarding and provisions the sample board before returning, for the seed command
+func (s *OnboardingService) StartNow(ctx context.Context, userID uuid.UUID, tenantID *uuid.UUID) (uuid.UUID, error) {
+	if _, err := s.onboardingRepo.Create(&models.OnboardingProgress{UserID: userID, Status: models.SampleBoardPending}); err != nil {
+		return uuid.Nil, fmt.Errorf("failed to start onboarding: %w", err)
+	}
+	boardID, err := s.createSampleBoard(ctx, userID, tenantID)
+	if err != nil {
+		if markErr := s.onboardingRepo.SetSampleBoard(userID, nil, models.SampleBoardFailed); markErr != nil {
+			log.Printf("Failed to mark sample board of user %s as failed: %v", userID, markErr)
+		}
+		return uuid.Nil, err
+	}
+	return boardID, s.onboardingRepo.SetSampleBoard(userID, &boardID, models.SampleBoardReady)
+}
+
+// provisionSampleBoard creates the sample board from its template with Melina's welcome message
+func (s *OnboardingService) provisionSampleBoard(ctx context.Context, userID uuid.UUID, tenantID *uuid.UUID) error {
+	boardID, err := s.createSampleBoard(ctx, userID, tenantID)
+	if err != nil {
+		if markErr := s.onboardingRepo.SetSampleBoard(userID, nil, models.SampleBoardFailed); markErr != nil {
+			log.Printf("Failed to mark sample board of user %s as failed: %v", userID, markErr)
+		}
+		return err
+	}
+	return s.onboardingRepo.SetSampleBoard(userID, &boardID, models.SampleBoardReady)
+}
+
+func (s *OnboardingService) createSampleBoard(ctx context.Context, userID uuid.UUID, tenantID *uuid.UUID) (uuid.UUID, error) {
+	boardID, err := s.boardRepo.CreateBoard(&models.Board{
+		Title:    sampleBoardTitle,
+		UserID:   userID,
+		TenantID: tenantID,
+	})
+	if err != nil {
+		return uuid.Nil, fmt.Errorf("failed to create sample board: %w", err)
+	}
+
+	for _, shape := range sampleBoardShapes() {
+		if err := ctx.Err(); err != nil {
+			return uuid.Nil, err
+		}
+		if err := s.boardDataRepo.SaveShapeData(boardID, shape); err != nil {
+			return uuid.Nil, fmt.Errorf("failed to add sample shape: %w", err)
+		}
+	}
+
+	if err := s.chatRepo.CreateChat(&models.Chat{
+		UUID:      uuid.New(),
+		BoardUUID: boardID,
+		Content:   sampleBoardWelcome,
+		Role:      models.RoleAssistant,
+	}); err != nil {
+		return uuid.Nil, fmt.Errorf("failed to add welcome message: %w", err)
+	}
+	return boardID, nil
+}
+
+// GetProgress returns the user's progress; users who signed up before onboarding existed have none
+func (s *OnboardingService) GetProgress(userID uuid.UUID) (*models.OnboardingView, error) {
+	progress, err := s.onboardingRepo.Get(userID)
+	if err != nil {
+		return nil, err
+	}
+	return onboardingView(progress)
+}
+
+// CompleteStep marks a step of the tour done and returns the updated progress
+func (s *OnboardingService) CompleteStep(userID uuid.UUID, step models.OnboardingStep) (*models.OnboardingView, error) {
+	if !isOnboardingStep(step) {
+		return nil, fmt.Errorf("%w: %s", ErrUnknownOnboardingStep, step)
+	}
+	if _, err := s.onboardingRepo.Get(userID); err != nil {
+		return nil, err
+	}
+	if err := s.onboardingRepo.CompleteStep(userID, step, time.Now()); err != nil {
+		return nil, err
+	}
+	return s.GetProgress(userID)
+}
+
+// Dismiss hides the tour for good, whatever steps are left
+func (s *OnboardingService) Dismiss(userID uuid.UUID) (*models.OnboardingView, error) {
+	if _, err := s.onboardingRepo.Get(userID); err != nil {
+		return nil, err
+	}
+	if err := s.onboardingRepo.Dismiss(userID, time.Now()); err != nil {
+		return nil, err
+	}
+	return s.GetProgress(userID)
+}
+
+func isOnboardingStep(step models.OnboardingStep) bool {
+	for _, known := range models.OnboardingSteps {
+		if step == known {
+			return true
+		}
+	}
+	return false
+}
+
+// onboardingView lists every step of the tour with its completion
+func onboardingView(progress *models.OnboardingProgress) (*models.OnboardingView, error) {
+	completed := map[models.OnboardingStep]time.Time{}
+	if len(progress.CompletedSteps) > 0 {
+		if err := json.Unmarshal(progress.CompletedSteps, &completed); err != nil {
+			return nil, fmt.Errorf("failed to read completed onboarding steps: %w", err)
+		}
+	}
+
+	view := &models.OnboardingView{
+		SampleBoardID:     progress.SampleBoardID,
+		SampleBoardStatus: progress.Status,
+		Completed:         true,
+		Dismissed:         progress.DismissedAt != nil,
+	}
+	for _, step := range models.OnboardingSteps {
+		status := models.OnboardingStepStatus{Step: step}
+		if at, ok := completed[step]; ok {
+			status.Done = true
+			status.CompletedAt = &at
+		} else {
+			view.Completed = false
+		}
+		view.Steps = append(view.Steps, status)
+	}
+	return view, nil
+}
+
+// sampleBoardShapes is the template of the sample board: a short guide with a checklist of the tour's steps
+func sampleBoardShapes() []*models.Shape {
+	num := func(v float64) *float64 { return &v }
+	str := func(v string) *string { return &v }
+	text := func(x, y, size float64, content string) *models.Shape {
+		return &models.Shape{
+			ID: uuid.NewString(), Type: string(models.Text),
+			X: num(x), Y: num(y), Text: str(content), FontSize: num(size), Fill: str("#1f2937"),
+		}
+	}
+	task := func(y float64, title string) *models.Shape {
+		return &models.Shape{
+			ID: uuid.NewString(), Type: string(models.ActionItemCard),
+			X: num(100), Y: num(y), W: num(320), H: num(64), Text: str(title), Status: str("open"),
+		}
+	}
+
+	return []*models.Shape{
+		text(100, 40, 32, "Welcome to Melina 👋"),
+		text(100, 90, 18, "Melina draws on this board for you. Try the steps below, in any order."),
+		task(150, "Say hi to Melina in the chat"),
+		task(230, "Ask Melina to draw a flowchart"),
+		task(310, "Draw a shape yourself with the toolbar"),
+		task(390, "Create your own board"),
+		{
+			ID: uuid.NewString(), Type: string(models.Rect),
+			X: num(520), Y: num(150), W: num(300), H: num(180),
+			Stroke: str("#6366f1"), Fill: str("#eef2ff"), StrokeWidth: num(2),
+		},
+		text(540, 170, 18, "Select me and ask Melina\nto turn me into a login form"),
+		{
+			ID: uuid.NewString(), Type: string(models.Arrow),
+			Start: map[string]float64{"x": 430, "y": 182}, End: map[string]float64{"x": 510, "y": 220},
+			Stroke: str("#6366f1"), StrokeWidth: num(2),
+		},
+	}
+}
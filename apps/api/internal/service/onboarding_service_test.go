@@ -0,0 +1,54 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"melina-studio-backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+func TestOnboardingViewListsEveryStep(t *testing.T) {
+	at := time.Date(2026, 1, 2, 3, 4, 5, 0, time.UTC)
+	progress := &models.OnboardingProgress{
+		Status:         models.SampleBoardReady,
+		CompletedSteps: datatypes.JSON(`{"open_sample_board":"2026-01-02T03:04:05Z"}`),
+	}
+
+	view, err := onboardingView(progress)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(view.Steps) != len(models.OnboardingSteps) {
+		t.Fatalf("got %d steps, want %d", len(view.Steps), len(models.OnboardingSteps))
+	}
+	if first := view.Steps[0]; !first.Done || first.CompletedAt == nil || !first.CompletedAt.Equal(at) {
+		t.Fatalf("the opened sample board should be done at %v, got %+v", at, first)
+	}
+	if view.Steps[1].Done || view.Completed {
+		t.Fatal("steps that weren't completed must not be done")
+	}
+}
+
+func TestOnboardingCompleteStepRejectsUnknownSteps(t *testing.T) {
+	s := &OnboardingService{}
+	if _, err := s.CompleteStep(uuid.New(), "fly_to_the_moon"); !errors.Is(err, ErrUnknownOnboardingStep) {
+		t.Fatalf("got %v, want ErrUnknownOnboardingStep", err)
+	}
+}
+
+func TestSampleBoardShapesHaveUniqueIDs(t *testing.T) {
+	seen := map[string]bool{}
+	for _, shape := range sampleBoardShapes() {
+		if _, err := uuid.Parse(shape.ID); err != nil {
+			t.Fatalf("shape id %q isn't a uuid", shape.ID)
+		}
+		if seen[shape.ID] {
+			t.Fatalf("duplicate shape id %s", shape.ID)
+		}
+		seen[shape.ID] = true
+	}
+}
@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"log"
+	llmHandlers "melina-studio-backend/internal/llm_handlers"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// defaultPrivacyReportWindow is how far back the report looks when no start is given
+const defaultPrivacyReportWindow = 30 * 24 * time.Hour
+
+// PrivacyService decides whether a user's LLM requests run in privacy mode and keeps the audit of
+// which providers received what classes of data
+type PrivacyService struct {
+	auditRepo   repo.LLMDataAuditRepoInterface
+	tenantRepo  repo.TenantRepoInterface
+	authRepo    repo.AuthRepoInterface
+	defaultMode bool // privacy mode of users outside a tenant
+}
+
+// PrivacyReport is the audit of the requests sent to model providers since a day
+type PrivacyReport struct {
+	Since     time.Time               `json:"since"`
+	TenantID  *uuid.UUID              `json:"tenant_id,omitempty"`
+	Providers []ProviderPrivacyReport `json:"providers"`
+}
+
+// ProviderPrivacyReport is what one provider received
+type ProviderPrivacyReport struct {
+	Provider string `json:"provider"`
+	// Controls are the opt-outs the provider gets in privacy mode; empty when it has none per request
+	Controls    []string                 `json:"controls"`
+	DataClasses []DataClassPrivacyReport `json:"data_classes"`
+}
+
+// DataClassPrivacyReport counts the requests that sent a class of data to the provider
+type DataClassPrivacyReport struct {
+	DataClass string `json:"data_class"`
+	Requests  int64  `json:"requests"`
+	// PrivacyModeRequests were made in privacy mode; OptedOutRequests also carried an opt-out
+	PrivacyModeRequests int64 `json:"privacy_mode_requests"`
+	OptedOutRequests    int64 `json:"opted_out_requests"`
+}
+
+func NewPrivacyService(auditRepo repo.LLMDataAuditRepoInterface, tenantRepo repo.TenantRepoInterface, authRepo repo.AuthRepoInterface, defaultMode bool) *PrivacyService {
+	return &PrivacyService{
+		auditRepo:   auditRepo,
+		tenantRepo:  tenantRepo,
+		authRepo:    authRepo,
+		defaultMode: defaultMode,
+	}
+}
+
+var privacyService *PrivacyService
+
+// GetPrivacyService returns the process-wide privacy service created by InitPrivacyService
+func GetPrivacyService() *PrivacyService {
+	return privacyService
+}
+
+// InitPrivacyService creates the process-wide privacy service and makes it record the audit of every LLM request
+func InitPrivacyService(auditRepo repo.LLMDataAuditRepoInterface, tenantRepo repo.TenantRepoInterface, authRepo repo.AuthRepoInterface, defaultMode bool) *PrivacyService {
+	privacyService = NewPrivacyService(auditRepo, tenantRepo, authRepo, defaultMode)
+	llmHandlers.SetPrivacyAuditRecorder(privacyService)
+	return privacyService
+}
+
+// WithLLMPrivacy marks the LLM requests made with the returned context as the user's, carrying their
+// organization's privacy mode; classes are the data the caller puts in the prompt, e.g. the board
+func WithLLMPrivacy(ctx context.Context, userID uuid.UUID, classes ...llmHandlers.DataClass) context.Context {
+	if privacyService == nil {
+		return ctx
+	}
+	return llmHandlers.WithPrivacy(ctx, privacyService.RequestPrivacy(userID, classes...))
+}
+
+// RequestPrivacy looks up the privacy mode and data region of the user's organization
+// When the lookup fails the request runs in privacy mode, since the opt-outs never break a request
+func (s *PrivacyService) RequestPrivacy(userID uuid.UUID, classes ...llmHandlers.DataClass) llmHandlers.RequestPrivacy {
+	privacy := llmHandlers.RequestPrivacy{PrivacyMode: s.defaultMode, DataClasses: classes}
+	user, err := s.authRepo.GetUserByID(userID)
+	if err != nil {
+		log.Printf("[privacy] failed to look up user %s, using privacy mode: %v", userID, err)
+		privacy.PrivacyMode = true
+		return privacy
+	}
+	if user.TenantID == nil {
+		return privacy
+	}
+	tenant, err := s.tenantRepo.GetByID(*user.TenantID)
+	if err != nil {
+		log.Printf("[privacy] failed to look up tenant %s, using privacy mode: %v", *user.TenantID, err)
+		privacy.PrivacyMode = true
+		return privacy
+	}
+	privacy.TenantID = tenant.UUID
+	privacy.PrivacyMode = tenant.PrivacyMode
+	privacy.DataRegion = llmHandlers.DataRegion(tenant.DataRegion)
+	return privacy
+}
+
+// RecordLLMRequest counts the request in the audit, in the background so it never delays the request
+func (s *PrivacyService) RecordLLMRequest(entry llmHandlers.PrivacyAuditEntry) {
+	rows := privacyAuditRows(entry, time.Now())
+	if len(rows) == 0 {
+		return
+	}
+	go func() {
+		if err := s.auditRepo.Increment(rows); err != nil {
+			log.Printf("[privacy] failed to record the audit of a %s request: %v", entry.Provider, err)
+		}
+	}()
+}
+
+// privacyAuditRows turns a request into one counter increment per data class it sent
+func privacyAuditRows(entry llmHandlers.PrivacyAuditEntry, now time.Time) []models.LLMDataAudit {
+	now = now.UTC()
+	day := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+	rows := make([]models.LLMDataAudit, 0, len(entry.DataClasses))
+	for _, class := range entry.DataClasses {
+		rows = append(rows, models.LLMDataAudit{
+			Day:         day,
+			TenantID:    entry.TenantID,
+			Provider:    string(entry.Provider),
+			DataClass:   string(class),
+			PrivacyMode: entry.PrivacyMode,
+			Controls:    strings.Join(entry.Controls, ","),
+			Requests:    1,
+		})
+	}
+	return rows
+}
+
+// Report sums the audit since the day, of one tenant or all; a zero since means the last 30 days
+func (s *PrivacyService) Report(since time.Time, tenantID *uuid.UUID) (*PrivacyReport, error) {
+	if since.IsZero() {
+		since = time.Now().Add(-defaultPrivacyReportWindow)
+	}
+	rows, err := s.auditRepo.List(since, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	report := buildPrivacyReport(rows)
+	report.Since = since
+	report.TenantID = tenantID
+	return report, nil
+}
+
+// buildPrivacyReport groups the audit counters by provider and data class
+func buildPrivacyReport(rows []models.LLMDataAudit) *PrivacyReport {
+	byProvider := make(map[string]map[string]*DataClassPrivacyReport)
+	for _, row := range rows {
+		classes, ok := byProvider[row.Provider]
+		if !ok {
+			classes = make(map[string]*DataClassPrivacyReport)
+			byProvider[row.Provider] = classes
+		}
+		class, ok := classes[row.DataClass]
+		if !ok {
+			class = &DataClassPrivacyReport{DataClass: row.DataClass}
+			classes[row.DataClass] = class
+		}
+		class.Requests += row.Requests
+		if row.PrivacyMode {
+			class.PrivacyModeRequests += row.Requests
+			if row.Controls != "" {
+				class.OptedOutRequests += row.Requests
+			}
+		}
+	}
+
+	report := &PrivacyReport{Providers: []ProviderPrivacyReport{}}
+	for provider, classes := range byProvider {
+		entry := ProviderPrivacyReport{
+			Provider:    provider,
+			Controls:    llmHandlers.PrivacyControls(llmHandlers.Provider(provider)),
+			DataClasses: make([]DataClassPrivacyReport, 0, len(classes)),
+		}
+		if entry.Controls == nil {
+			entry.Controls = []string{}
+		}
+		for _, class := range classes {
+			entry.DataClasses = append(entry.DataClasses, *class)
+		}
+		sort.Slice(entry.DataClasses, func(i, j int) bool { return entry.DataClasses[i].DataClass < entry.DataClasses[j].DataClass })
+		report.Providers = append(report.Providers, entry)
+	}
+	sort.Slice(report.Providers, func(i, j int) bool { return report.Providers[i].Provider < report.Providers[j].Provider })
+	return report
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"testing"
+	"time"
+
+	llmHandlers "melina-studio-backend/internal/llm_handlers"
+
+	"github.com/google/uuid"
+)
+
+func TestPrivacyAuditRowsAndReport(t *testing.T) {
+	tenantID := uuid.New()
+	now := time.Date(2025, 3, 4, 23, 30, 0, 0, time.FixedZone("UTC-2", -2*60*60))
+
+	optedOut := privacyAuditRows(llmHandlers.PrivacyAuditEntry{
+		TenantID:    tenantID,
+		Provider:    llmHandlers.ProviderOpenAI,
+		DataClasses: []llmHandlers.DataClass{llmHandlers.DataClassBoard, llmHandlers.DataClassChat},
+		PrivacyMode: true,
+		Controls:    llmHandlers.PrivacyControls(llmHandlers.ProviderOpenAI),
+	}, now)
+	if len(optedOut) != 2 {
+		t.Fatalf("got %d rows, want one per data class", len(optedOut))
+	}
+	if day := optedOut[0].Day; !day.Equal(time.Date(2025, 3, 5, 0, 0, 0, 0, time.UTC)) {
+		t.Fatalf("got day %v, want the UTC day of the request", day)
+	}
+
+	rows := append(optedOut, privacyAuditRows(llmHandlers.PrivacyAuditEntry{
+		Provider:    llmHandlers.ProviderOpenAI,
+		DataClasses: []llmHandlers.DataClass{llmHandlers.DataClassChat},
+	}, now)...)
+	rows = append(rows, privacyAuditRows(llmHandlers.PrivacyAuditEntry{
+		Provider:    llmHandlers.ProviderGemini,
+		DataClasses: []llmHandlers.DataClass{llmHandlers.DataClassImages},
+		PrivacyMode: true,
+	}, now)...)
+
+	report := buildPrivacyReport(rows)
+	if len(report.Providers) != 2 || report.Providers[0].Provider != "gemini" || report.Providers[1].Provider != "openai" {
+		t.Fatalf("unexpected providers %+v", report.Providers)
+	}
+	gemini := report.Providers[0]
+	if len(gemini.Controls) != 0 || gemini.DataClasses[0].PrivacyModeRequests != 1 || gemini.DataClasses[0].OptedOutRequests != 0 {
+		t.Fatalf("gemini has no opt-out, got %+v", gemini)
+	}
+	openai := report.Providers[1]
+	chat := openai.DataClasses[1]
+	if chat.DataClass != "chat" || chat.Requests != 2 || chat.PrivacyModeRequests != 1 || chat.OptedOutRequests != 1 {
+		t.Fatalf("unexpected openai chat counts %+v", chat)
+	}
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+	_ "time/tzdata" // timezones are validated against the embedded database, the container has none
+
+	"golang.org/x/text/language"
+)
+
+// maxProfileNameLength is the longest first or last name accepted
+const maxProfileNameLength = 100
+
+var ErrInvalidProfile = errors.New("invalid profile")
+
+// ProfileUpdate is the part of a user's profile they can edit themselves; nil fields are left unchanged
+type ProfileUpdate struct {
+	FirstName *string `json:"first_name"`
+	LastName  *string `json:"last_name"`
+	Timezone  *string `json:"timezone"`
+	Locale    *string `json:"locale"`
+}
+
+// Normalize trims and validates the update in place, canonicalizing the timezone and locale
+func (p *ProfileUpdate) Normalize() error {
+	for field, value := range map[string]*string{"first_name": p.FirstName, "last_name": p.LastName} {
+		if value == nil {
+			continue
+		}
+		*value = strings.TrimSpace(*value)
+		if *value == "" {
+			return fmt.Errorf("%w: %s can't be empty", ErrInvalidProfile, field)
+		}
+		if len([]rune(*value)) > maxProfileNameLength {
+			return fmt.Errorf("%w: %s is longer than %d characters", ErrInvalidProfile, field, maxProfileNameLength)
+		}
+	}
+	if p.Timezone != nil {
+		tz, err := NormalizeTimezone(*p.Timezone)
+		if err != nil {
+			return err
+		}
+		*p.Timezone = tz
+	}
+	if p.Locale != nil {
+		locale, err := NormalizeLocale(*p.Locale)
+		if err != nil {
+			return err
+		}
+		*p.Locale = locale
+	}
+	return nil
+}
+
+// NormalizeTimezone checks an IANA timezone name such as "Europe/Berlin"
+func NormalizeTimezone(tz string) (string, error) {
+	tz = strings.TrimSpace(tz)
+	// "Local" would be the server's zone, not the user's
+	if tz == "" || tz == "Local" {
+		return "", fmt.Errorf("%w: timezone must be an IANA name such as Europe/Berlin", ErrInvalidProfile)
+	}
+	loc, err := time.LoadLocation(tz)
+	if err != nil {
+		return "", fmt.Errorf("%w: unknown timezone %q", ErrInvalidProfile, tz)
+	}
+	return loc.String(), nil
+}
+
+// NormalizeLocale checks a BCP 47 language tag and returns its canonical form, e.g. "en-us" becomes "en-US"
+func NormalizeLocale(locale string) (string, error) {
+	tag, err := language.Parse(strings.TrimSpace(locale))
+	if err != nil || tag == language.Und {
+		return "", fmt.Errorf("%w: locale must be a language tag such as en-US", ErrInvalidProfile)
+	}
+	return tag.String(), nil
+}
@@ -0,0 +1,39 @@
+package service
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestProfileUpdateNormalize(t *testing.T) {
+	first, tz, locale := "  Ada ", "Europe/Berlin", "en-us"
+	update := &ProfileUpdate{FirstName: &first, Timezone: &tz, Locale: &locale}
+	if err := update.Normalize(); err != nil {
+		t.Fatal(err)
+	}
+	if *update.FirstName != "Ada" || *update.Timezone != "Europe/Berlin" || *update.Locale != "en-US" {
+		t.Fatalf("got %q %q %q", *update.FirstName, *update.Timezone, *update.Locale)
+	}
+	if update.LastName != nil {
+		t.Fatal("fields that weren't sent must stay unset")
+	}
+}
+
+func TestProfileUpdateNormalizeRejectsInvalidFields(t *testing.T) {
+	blank, long := "   ", strings.Repeat("a", maxProfileNameLength+1)
+	tzLocal, tzUnknown := "Local", "Mars/Olympus"
+	localeBad := "not a locale"
+
+	for name, update := range map[string]*ProfileUpdate{
+		"blank name":       {FirstName: &blank},
+		"long name":        {LastName: &long},
+		"server timezone":  {Timezone: &tzLocal},
+		"unknown timezone": {Timezone: &tzUnknown},
+		"bad locale":       {Locale: &localeBad},
+	} {
+		if err := update.Normalize(); !errors.Is(err, ErrInvalidProfile) {
+			t.Errorf("%s: got %v, want ErrInvalidProfile", name, err)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var ErrInvalidRetentionPolicy = errors.New("invalid retention policy")
+
+const (
+	maxChatRetentionDays = 3650
+	maxSnapshotsToKeep   = 1000
+)
+
+type RetentionService struct {
+	retentionRepo repo.RetentionRepoInterface
+}
+
+func NewRetentionService(retentionRepo repo.RetentionRepoInterface) *RetentionService {
+	return &RetentionService{retentionRepo: retentionRepo}
+}
+
+// GetPolicy returns the user's retention policy; without one nothing is purged
+func (s *RetentionService) GetPolicy(userID uuid.UUID) (*models.RetentionPolicy, error) {
+	policy, err := s.retentionRepo.GetPolicy(userID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return &models.RetentionPolicy{UserID: userID}, nil
+	}
+	return policy, err
+}
+
+// UpdatePolicy validates and stores the user's retention policy
+func (s *RetentionService) UpdatePolicy(userID uuid.UUID, chatRetentionDays *int, archivedChatRetentionDays *int, snapshotsToKeep *int) (*models.RetentionPolicy, error) {
+	if err := validateRetentionPolicy(chatRetentionDays, snapshotsToKeep); err != nil {
+		return nil, err
+	}
+	if archivedChatRetentionDays != nil && (*archivedChatRetentionDays < 1 || *archivedChatRetentionDays > maxChatRetentionDays) {
+		return nil, fmt.Errorf("%w: archived_chat_retention_days must be between 1 and %d", ErrInvalidRetentionPolicy, maxChatRetentionDays)
+	}
+	policy := &models.RetentionPolicy{
+		UserID:                    userID,
+		ChatRetentionDays:         chatRetentionDays,
+		ArchivedChatRetentionDays: archivedChatRetentionDays,
+		SnapshotsToKeep:           snapshotsToKeep,
+	}
+	if err := s.retentionRepo.SavePolicy(policy); err != nil {
+		return nil, err
+	}
+	return policy, nil
+}
+
+func validateRetentionPolicy(chatRetentionDays *int, snapshotsToKeep *int) error {
+	if chatRetentionDays != nil && (*chatRetentionDays < 1 || *chatRetentionDays > maxChatRetentionDays) {
+		return fmt.Errorf("%w: chat_retention_days must be between 1 and %d", ErrInvalidRetentionPolicy, maxChatRetentionDays)
+	}
+	if snapshotsToKeep != nil && (*snapshotsToKeep < 1 || *snapshotsToKeep > maxSnapshotsToKeep) {
+		return fmt.Errorf("%w: snapshots_to_keep must be between 1 and %d", ErrInvalidRetentionPolicy, maxSnapshotsToKeep)
+	}
+	return nil
+}
+
+// Apply enforces a retention policy; with dryRun set it only reports what would be purged
+func (s *RetentionService) Apply(policy *models.RetentionPolicy, now time.Time, dryRun bool) (*models.RetentionReport, error) {
+	report := &models.RetentionReport{UserID: policy.UserID, DryRun: dryRun, SnapshotsKept: policy.SnapshotsToKeep}
+
+	// archived boards have their own chat limit, the other one doesn't reach them
+	if cutoff := chatRetentionCutoff(policy, now); cutoff != nil {
+		report.ChatsBefore = cutoff
+		chats, err := s.purgeChats(policy.UserID, false, *cutoff, dryRun)
+		if err != nil {
+			return nil, fmt.Errorf("failed to purge chats: %w", err)
+		}
+		report.Chats = chats
+	}
+	if cutoff := archivedChatRetentionCutoff(policy, now); cutoff != nil {
+		report.ArchivedChatsBefore = cutoff
+		chats, err := s.purgeChats(policy.UserID, true, *cutoff, dryRun)
+		if err != nil {
+			return nil, fmt.Errorf("failed to purge chats of archived boards: %w", err)
+		}
+		report.ArchivedChats = chats
+	}
+
+	if policy.SnapshotsToKeep != nil {
+		summaryIDs, err := s.retentionRepo.ExcessSummaryIDs(policy.UserID, *policy.SnapshotsToKeep)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find board summaries to purge: %w", err)
+		}
+		artifactIDs, err := s.retentionRepo.ExcessArtifactIDs(policy.UserID, *policy.SnapshotsToKeep)
+		if err != nil {
+			return nil, fmt.Errorf("failed to find code artifacts to purge: %w", err)
+		}
+		if !dryRun {
+			if err := s.retentionRepo.DeleteSummaries(summaryIDs); err != nil {
+				return nil, fmt.Errorf("failed to purge board summaries: %w", err)
+			}
+			if err := s.retentionRepo.DeleteArtifacts(artifactIDs); err != nil {
+				return nil, fmt.Errorf("failed to purge code artifacts: %w", err)
+			}
+		}
+		report.BoardSummaries = len(summaryIDs)
+		report.CodeArtifacts = len(artifactIDs)
+	}
+
+	return report, nil
+}
+
+// ApplyAll enforces every stored retention policy, used by the cleanup service; it returns how many
+// policies were applied and how many failed
+func (s *RetentionService) ApplyAll() (int, int, error) {
+	policies, err := s.retentionRepo.ListPolicies()
+	if err != nil {
+		log.Printf("Retention: failed to list policies: %v", err)
+		return 0, 0, err
+	}
+	now := time.Now()
+	applied, failed := 0, 0
+	for i := range policies {
+		report, err := s.Apply(&policies[i], now, false)
+		if err != nil {
+			log.Printf("Retention: failed for user %s: %v", policies[i].UserID, err)
+			failed++
+			continue
+		}
+		applied++
+		if report.Chats > 0 || report.ArchivedChats > 0 || report.BoardSummaries > 0 || report.CodeArtifacts > 0 {
+			log.Printf("Retention: purged %d chats, %d chats of archived boards, %d board summaries and %d code artifacts for user %s",
+				report.Chats, report.ArchivedChats, report.BoardSummaries, report.CodeArtifacts, policies[i].UserID)
+		}
+	}
+	return applied, failed, nil
+}
+
+// purgeChats deletes, or with dryRun counts, the chats before the cutoff on the user's archived or other boards
+func (s *RetentionService) purgeChats(userID uuid.UUID, archived bool, cutoff time.Time, dryRun bool) (int64, error) {
+	if dryRun {
+		return s.retentionRepo.CountChatsBefore(userID, archived, cutoff)
+	}
+	return s.retentionRepo.DeleteChatsBefore(userID, archived, cutoff)
+}
+
+// chatRetentionCutoff returns the creation time before which chats are purged, or nil to keep them all
+func chatRetentionCutoff(policy *models.RetentionPolicy, now time.Time) *time.Time {
+	return daysCutoff(policy.ChatRetentionDays, now)
+}
+
+// archivedChatRetentionCutoff is chatRetentionCutoff for the chats of archived boards
+func archivedChatRetentionCutoff(policy *models.RetentionPolicy, now time.Time) *time.Time {
+	return daysCutoff(policy.ArchivedChatRetentionDays, now)
+}
+
+func daysCutoff(days *int, now time.Time) *time.Time {
+	if days == nil {
+		return nil
+	}
+	cutoff := now.AddDate(0, 0, -*days)
+	return &cutoff
+}
@@ -0,0 +1,181 @@
+package service
+
+import (
+	"errors"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+	"testing"
+	"time"
+
+	"github.com/google/uuid"
+)
+
+func intPtr(v int) *int { return &v }
+
+func TestValidateRetentionPolicy(t *testing.T) {
+	valid := []struct{ days, keep *int }{
+		{nil, nil},
+		{intPtr(30), nil},
+		{nil, intPtr(5)},
+		{intPtr(maxChatRetentionDays), intPtr(1)},
+	}
+	for _, tc := range valid {
+		if err := validateRetentionPolicy(tc.days, tc.keep); err != nil {
+			t.Errorf("expected valid policy, got %v", err)
+		}
+	}
+
+	invalid := []struct{ days, keep *int }{
+		{intPtr(0), nil},
+		{intPtr(maxChatRetentionDays + 1), nil},
+		{nil, intPtr(0)},
+		{nil, intPtr(-3)},
+	}
+	for _, tc := range invalid {
+		if err := validateRetentionPolicy(tc.days, tc.keep); !errors.Is(err, ErrInvalidRetentionPolicy) {
+			t.Errorf("expected ErrInvalidRetentionPolicy, got %v", err)
+		}
+	}
+}
+
+func TestChatRetentionCutoff(t *testing.T) {
+	now := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+	if cutoff := chatRetentionCutoff(&models.RetentionPolicy{}, now); cutoff != nil {
+		t.Errorf("no limit should keep every chat, got %v", cutoff)
+	}
+	cutoff := chatRetentionCutoff(&models.RetentionPolicy{ChatRetentionDays: intPtr(30)}, now)
+	if cutoff == nil || !cutoff.Equal(time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("got %v", cutoff)
+	}
+	if cutoff := archivedChatRetentionCutoff(&models.RetentionPolicy{ChatRetentionDays: intPtr(30)}, now); cutoff != nil {
+		t.Errorf("the chat limit should not reach archived boards, got %v", cutoff)
+	}
+	cutoff = archivedChatRetentionCutoff(&models.RetentionPolicy{ArchivedChatRetentionDays: intPtr(365)}, now)
+	if cutoff == nil || !cutoff.Equal(time.Date(2025, 3, 31, 12, 0, 0, 0, time.UTC)) {
+		t.Errorf("got %v", cutoff)
+	}
+}
+
+// fakeRetentionRepo has chats created at given times on active and archived boards
+type fakeRetentionRepo struct {
+	repo.RetentionRepoInterface
+	chats    map[bool][]time.Time
+	excess   []uuid.UUID
+	saved    *models.RetentionPolicy
+	deleted  int
+	archived []bool
+}
+
+func (r *fakeRetentionRepo) SavePolicy(policy *models.RetentionPolicy) error {
+	r.saved = policy
+	return nil
+}
+
+func (r *fakeRetentionRepo) CountChatsBefore(userID uuid.UUID, archived bool, before time.Time) (int64, error) {
+	var count int64
+	for _, created := range r.chats[archived] {
+		if created.Before(before) {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeRetentionRepo) DeleteChatsBefore(userID uuid.UUID, archived bool, before time.Time) (int64, error) {
+	count, _ := r.CountChatsBefore(userID, archived, before)
+	r.archived = append(r.archived, archived)
+	var kept []time.Time
+	for _, created := range r.chats[archived] {
+		if !created.Before(before) {
+			kept = append(kept, created)
+		}
+	}
+	r.chats[archived] = kept
+	return count, nil
+}
+
+func (r *fakeRetentionRepo) ExcessSummaryIDs(userID uuid.UUID, keep int) ([]uuid.UUID, error) {
+	return r.excess, nil
+}
+
+func (r *fakeRetentionRepo) ExcessArtifactIDs(userID uuid.UUID, keep int) ([]uuid.UUID, error) {
+	return nil, nil
+}
+
+func (r *fakeRetentionRepo) DeleteSummaries(ids []uuid.UUID) error {
+	r.deleted += len(ids)
+	return nil
+}
+
+func (r *fakeRetentionRepo) DeleteArtifacts(ids []uuid.UUID) error {
+	r.deleted += len(ids)
+	return nil
+}
+
+func TestApplyRetentionToArchivedBoards(t *testing.T) {
+	now := time.Date(2026, 3, 31, 12, 0, 0, 0, time.UTC)
+	daysAgo := func(days int) time.Time { return now.AddDate(0, 0, -days) }
+	retention := &fakeRetentionRepo{
+		chats: map[bool][]time.Time{
+			false: {daysAgo(100), daysAgo(40), daysAgo(5)},
+			true:  {daysAgo(400), daysAgo(100), daysAgo(40)},
+		},
+		excess: []uuid.UUID{uuid.New()},
+	}
+	s := NewRetentionService(retention)
+	policy := &models.RetentionPolicy{UserID: uuid.New(), ChatRetentionDays: intPtr(30), ArchivedChatRetentionDays: intPtr(365), SnapshotsToKeep: intPtr(3)}
+
+	report, err := s.Apply(policy, now, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Chats != 2 || report.ArchivedChats != 1 || report.BoardSummaries != 1 {
+		t.Errorf("expected 2 chats, 1 archived chat and 1 summary to be reported, got %+v", report)
+	}
+	if len(retention.archived) != 0 || retention.deleted != 0 {
+		t.Fatal("a dry run must not delete anything")
+	}
+
+	report, err = s.Apply(policy, now, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Chats != 2 || report.ArchivedChats != 1 || !report.ArchivedChatsBefore.Equal(daysAgo(365)) {
+		t.Errorf("unexpected report %+v", report)
+	}
+	if len(retention.chats[false]) != 1 || len(retention.chats[true]) != 2 || retention.deleted != 1 {
+		t.Errorf("expected each board kind to keep its recent chats, got %v", retention.chats)
+	}
+
+	// without an archived limit the chats of archived boards are kept, however old
+	report, err = s.Apply(&models.RetentionPolicy{UserID: policy.UserID, ChatRetentionDays: intPtr(1)}, now, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.ArchivedChats != 0 || report.ArchivedChatsBefore != nil || len(retention.chats[true]) != 2 {
+		t.Errorf("expected the archived chats to be kept, got %+v", report)
+	}
+}
+
+func TestUpdateRetentionPolicy(t *testing.T) {
+	retention := &fakeRetentionRepo{}
+	s := NewRetentionService(retention)
+	userID := uuid.New()
+
+	for _, days := range []int{0, maxChatRetentionDays + 1} {
+		if _, err := s.UpdatePolicy(userID, nil, intPtr(days), nil); !errors.Is(err, ErrInvalidRetentionPolicy) {
+			t.Errorf("archived_chat_retention_days %d: expected ErrInvalidRetentionPolicy, got %v", days, err)
+		}
+	}
+	if retention.saved != nil {
+		t.Fatal("an invalid policy must not be saved")
+	}
+
+	policy, err := s.UpdatePolicy(userID, intPtr(30), intPtr(365), nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if retention.saved != policy || *policy.ArchivedChatRetentionDays != 365 || *policy.ChatRetentionDays != 30 || policy.UserID != userID {
+		t.Errorf("unexpected saved policy %+v", retention.saved)
+	}
+}
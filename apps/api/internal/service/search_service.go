@@ -0,0 +1,231 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"log"
+	llmHandlers "melina-studio-backend/internal/llm_handlers"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+	"sort"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrSearchQueryTooShort = errors.New("search query must be at least 2 characters")
+	ErrInvalidSearchType   = errors.New("invalid search type")
+)
+
+const (
+	defaultSearchLimit = 20
+	maxSearchLimit     = 50
+	searchSnippetRunes = 160
+	// semantic search re-ranks keyword matches plus this many recent items of each type
+	semanticCandidateLimit = 100
+)
+
+var allSearchHitTypes = []models.SearchHitType{models.SearchHitBoard, models.SearchHitShape, models.SearchHitChat}
+
+type SearchService struct {
+	searchRepo repo.SearchRepoInterface
+	embed      func(ctx context.Context, texts []string) ([][]float64, error)
+}
+
+func NewSearchService(searchRepo repo.SearchRepoInterface) *SearchService {
+	return &SearchService{searchRepo: searchRepo, embed: llmHandlers.EmbedTexts}
+}
+
+// Search returns the user's best matching boards, shapes and chat messages
+// types is a comma separated filter (board,shape,chat); empty means all of them
+func (s *SearchService) Search(userID uuid.UUID, query string, types string, limit int) ([]models.SearchHit, error) {
+	query, hitTypes, limit, err := normalizeSearchParams(query, types, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	hits, err := s.searchRepo.Search(userID, query, hitTypes, limit)
+	if err != nil {
+		return nil, err
+	}
+	for i := range hits {
+		hits[i].Snippet = searchSnippet(hits[i].Text, query)
+	}
+	return hits, nil
+}
+
+// SemanticSearch ranks items by meaning instead of shared words, e.g. "pricing" finds "plans and tiers"
+// Candidates are the keyword matches plus the most recent items of each type; rank is the cosine similarity.
+// When the embedding provider is unavailable the keyword results are returned instead
+func (s *SearchService) SemanticSearch(ctx context.Context, userID uuid.UUID, query string, types string, limit int) ([]models.SearchHit, error) {
+	query, hitTypes, limit, err := normalizeSearchParams(query, types, limit)
+	if err != nil {
+		return nil, err
+	}
+
+	keywordHits, err := s.searchRepo.Search(userID, query, hitTypes, semanticCandidateLimit)
+	if err != nil {
+		return nil, err
+	}
+	recent, err := s.searchRepo.Recent(userID, hitTypes, semanticCandidateLimit)
+	if err != nil {
+		return nil, err
+	}
+	candidates := mergeSearchCandidates(keywordHits, recent)
+
+	hits := keywordHits
+	if len(candidates) > 0 {
+		texts := make([]string, 0, len(candidates)+1)
+		texts = append(texts, query)
+		for _, c := range candidates {
+			texts = append(texts, c.Text)
+		}
+		vectors, err := s.embed(ctx, texts)
+		if err != nil {
+			log.Printf("Semantic search unavailable, using keyword results: %v", err)
+		} else {
+			hits = rankBySimilarity(vectors[0], candidates, vectors[1:])
+		}
+	}
+
+	if len(hits) > limit {
+		hits = hits[:limit]
+	}
+	for i := range hits {
+		hits[i].Snippet = searchSnippet(hits[i].Text, query)
+	}
+	return hits, nil
+}
+
+func normalizeSearchParams(query string, types string, limit int) (string, []models.SearchHitType, int, error) {
+	query = strings.TrimSpace(query)
+	if utf8.RuneCountInString(query) < 2 {
+		return "", nil, 0, ErrSearchQueryTooShort
+	}
+	hitTypes, err := parseSearchTypes(types)
+	if err != nil {
+		return "", nil, 0, err
+	}
+	if limit <= 0 {
+		limit = defaultSearchLimit
+	}
+	if limit > maxSearchLimit {
+		limit = maxSearchLimit
+	}
+	return query, hitTypes, limit, nil
+}
+
+// mergeSearchCandidates joins hit lists, dropping items that appear in both
+func mergeSearchCandidates(lists ...[]models.SearchHit) []models.SearchHit {
+	seen := map[string]bool{}
+	var merged []models.SearchHit
+	for _, list := range lists {
+		for _, hit := range list {
+			key := string(hit.Type) + ":" + hit.BoardID.String()
+			if hit.ShapeID != nil {
+				key += ":" + hit.ShapeID.String()
+			}
+			if hit.ChatID != nil {
+				key += ":" + hit.ChatID.String()
+			}
+			if seen[key] || strings.TrimSpace(hit.Text) == "" {
+				continue
+			}
+			seen[key] = true
+			merged = append(merged, hit)
+		}
+	}
+	return merged
+}
+
+// rankBySimilarity scores each candidate against the query vector and sorts best first
+func rankBySimilarity(queryVector []float64, candidates []models.SearchHit, vectors [][]float64) []models.SearchHit {
+	ranked := make([]models.SearchHit, len(candidates))
+	copy(ranked, candidates)
+	for i := range ranked {
+		if i < len(vectors) {
+			ranked[i].Rank = llmHandlers.CosineSimilarity(queryVector, vectors[i])
+		} else {
+			ranked[i].Rank = 0
+		}
+	}
+	sort.SliceStable(ranked, func(i, j int) bool {
+		return ranked[i].Rank > ranked[j].Rank
+	})
+	return ranked
+}
+
+// parseSearchTypes parses the type filter, accepting plural forms ("boards") as well
+func parseSearchTypes(raw string) ([]models.SearchHitType, error) {
+	if strings.TrimSpace(raw) == "" {
+		return allSearchHitTypes, nil
+	}
+	seen := map[models.SearchHitType]bool{}
+	var result []models.SearchHitType
+	for _, part := range strings.Split(raw, ",") {
+		t := models.SearchHitType(strings.TrimSuffix(strings.ToLower(strings.TrimSpace(part)), "s"))
+		switch t {
+		case models.SearchHitBoard, models.SearchHitShape, models.SearchHitChat:
+		default:
+			return nil, ErrInvalidSearchType
+		}
+		if !seen[t] {
+			seen[t] = true
+			result = append(result, t)
+		}
+	}
+	return result, nil
+}
+
+// searchSnippet cuts a plain-text excerpt of text centered on the first query term it contains
+func searchSnippet(text string, query string) string {
+	text = strings.Join(strings.Fields(text), " ")
+	runes := []rune(text)
+	if len(runes) <= searchSnippetRunes {
+		return text
+	}
+
+	lower := []rune(strings.ToLower(text))
+	match := -1
+	for _, term := range strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if idx := indexRunes(lower, []rune(term)); idx >= 0 && (match < 0 || idx < match) {
+			match = idx
+		}
+	}
+
+	start := 0
+	if match > searchSnippetRunes/3 {
+		start = match - searchSnippetRunes/3
+	}
+	end := start + searchSnippetRunes
+	if end > len(runes) {
+		end = len(runes)
+		start = end - searchSnippetRunes
+	}
+
+	snippet := strings.TrimSpace(string(runes[start:end]))
+	if start > 0 {
+		snippet = "…" + snippet
+	}
+	if end < len(runes) {
+		snippet += "…"
+	}
+	return snippet
+}
+
+func indexRunes(haystack []rune, needle []rune) int {
+	if len(needle) == 0 {
+		return -1
+	}
+	for i := 0; i+len(needle) <= len(haystack); i++ {
+		if string(haystack[i:i+len(needle)]) == string(needle) {
+			return i
+		}
+	}
+	return -1
+}
@@ -0,0 +1,80 @@
+package service
+
+import (
+	"melina-studio-backend/internal/models"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestParseSearchTypes(t *testing.T) {
+	types, err := parseSearchTypes("")
+	if err != nil || len(types) != 3 {
+		t.Fatalf("empty filter: got %v, %v", types, err)
+	}
+
+	types, err = parseSearchTypes("Boards, chat,board")
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(types) != 2 || types[0] != models.SearchHitBoard || types[1] != models.SearchHitChat {
+		t.Errorf("got %v", types)
+	}
+
+	if _, err := parseSearchTypes("frames"); err != ErrInvalidSearchType {
+		t.Errorf("expected ErrInvalidSearchType, got %v", err)
+	}
+}
+
+func TestSearchSnippet(t *testing.T) {
+	if got := searchSnippet("  short   text ", "text"); got != "short text" {
+		t.Errorf("short text: got %q", got)
+	}
+
+	text := strings.Repeat("filler ", 60) + "the Checkout flow needs retries " + strings.Repeat("tail ", 60)
+	got := searchSnippet(text, "checkout")
+	if !strings.Contains(got, "Checkout flow") {
+		t.Errorf("snippet should contain the match: %q", got)
+	}
+	if !strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "…") {
+		t.Errorf("snippet should be elided on both sides: %q", got)
+	}
+
+	got = searchSnippet(strings.Repeat("word ", 100), "missing")
+	if strings.HasPrefix(got, "…") || !strings.HasSuffix(got, "…") {
+		t.Errorf("no match should start at the beginning: %q", got)
+	}
+}
+
+func TestMergeSearchCandidates(t *testing.T) {
+	board := uuid.New()
+	shape := uuid.New()
+	keyword := []models.SearchHit{{Type: models.SearchHitShape, BoardID: board, ShapeID: &shape, Text: "pricing tiers"}}
+	recent := []models.SearchHit{
+		{Type: models.SearchHitShape, BoardID: board, ShapeID: &shape, Text: "pricing tiers"},
+		{Type: models.SearchHitBoard, BoardID: board, Text: "Roadmap"},
+		{Type: models.SearchHitBoard, BoardID: uuid.New(), Text: "  "},
+	}
+
+	merged := mergeSearchCandidates(keyword, recent)
+	if len(merged) != 2 || merged[1].Text != "Roadmap" {
+		t.Errorf("got %v, want the shape once plus the board", merged)
+	}
+}
+
+func TestRankBySimilarity(t *testing.T) {
+	candidates := []models.SearchHit{{Text: "far"}, {Text: "close"}, {Text: "middle"}}
+	vectors := [][]float64{{0, 1}, {1, 0.1}, {1, 1}}
+
+	ranked := rankBySimilarity([]float64{1, 0}, candidates, vectors)
+	if ranked[0].Text != "close" || ranked[1].Text != "middle" || ranked[2].Text != "far" {
+		t.Errorf("got order %s, %s, %s", ranked[0].Text, ranked[1].Text, ranked[2].Text)
+	}
+	if ranked[0].Rank <= ranked[1].Rank {
+		t.Errorf("rank should be the similarity, got %v", ranked)
+	}
+	if candidates[0].Rank != 0 {
+		t.Error("candidates should not be modified")
+	}
+}
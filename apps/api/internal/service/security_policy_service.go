@@ -0,0 +1,193 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"melina-studio-backend/internal/auth"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+	"net"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidSecurityPolicy  = errors.New("invalid security policy")
+	ErrSecurityPolicyNotFound = errors.New("security policy not found")
+)
+
+const (
+	maxAllowedIPRanges = 100
+	// a session can't outlast its refresh token, nor re-authentication be spaced wider than an access token lives
+	maxSessionMinutes     = int(auth.RefreshTokenExpiry / time.Minute)
+	maxReauthIntervalMins = int(auth.AccessTokenExpiry / time.Minute)
+)
+
+// securityPolicyCacheTTL bounds how long a policy change takes to reach every instance, since the policy is read on every request
+const securityPolicyCacheTTL = time.Minute
+
+type cachedSecurityPolicy struct {
+	policy    *auth.SecurityPolicy
+	expiresAt time.Time
+}
+
+// SecurityPolicyService stores the organizations' sign-in policies and serves them to the authenticators
+type SecurityPolicyService struct {
+	policyRepo repo.TenantSecurityPolicyRepoInterface
+	mu         sync.Mutex
+	cache      map[string]cachedSecurityPolicy
+}
+
+// SecurityPolicyInput is a tenant's policy as an admin sets it
+type SecurityPolicyInput struct {
+	AllowedIPRanges       []string `json:"allowed_ip_ranges"`
+	MaxSessionMinutes     int      `json:"max_session_minutes"`
+	ReauthIntervalMinutes int      `json:"reauth_interval_minutes"`
+}
+
+func NewSecurityPolicyService(policyRepo repo.TenantSecurityPolicyRepoInterface) *SecurityPolicyService {
+	return &SecurityPolicyService{
+		policyRepo: policyRepo,
+		cache:      make(map[string]cachedSecurityPolicy),
+	}
+}
+
+// Get returns the policy of a tenant
+func (s *SecurityPolicyService) Get(tenantID uuid.UUID) (*models.TenantSecurityPolicy, error) {
+	policy, err := s.policyRepo.Get(tenantID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrSecurityPolicyNotFound
+	}
+	return policy, err
+}
+
+// Set validates and stores the policy of a tenant; it applies to requests already signed in
+func (s *SecurityPolicyService) Set(tenantID uuid.UUID, input SecurityPolicyInput) (*models.TenantSecurityPolicy, error) {
+	ranges, err := normalizeIPRanges(input.AllowedIPRanges)
+	if err != nil {
+		return nil, err
+	}
+	if input.MaxSessionMinutes < 0 || input.MaxSessionMinutes > maxSessionMinutes {
+		return nil, fmt.Errorf("%w: max_session_minutes must be between 0 and %d", ErrInvalidSecurityPolicy, maxSessionMinutes)
+	}
+	if input.ReauthIntervalMinutes < 0 || input.ReauthIntervalMinutes > maxReauthIntervalMins {
+		return nil, fmt.Errorf("%w: reauth_interval_minutes must be between 0 and %d", ErrInvalidSecurityPolicy, maxReauthIntervalMins)
+	}
+
+	policy := &models.TenantSecurityPolicy{
+		TenantID:              tenantID,
+		MaxSessionMinutes:     input.MaxSessionMinutes,
+		ReauthIntervalMinutes: input.ReauthIntervalMinutes,
+	}
+	if len(ranges) > 0 {
+		encoded, err := json.Marshal(ranges)
+		if err != nil {
+			return nil, err
+		}
+		policy.AllowedIPRanges = encoded
+	}
+	if err := s.policyRepo.Save(policy); err != nil {
+		return nil, err
+	}
+	s.forget(tenantID)
+	return policy, nil
+}
+
+// Delete removes the policy of a tenant
+func (s *SecurityPolicyService) Delete(tenantID uuid.UUID) error {
+	if err := s.policyRepo.Delete(tenantID); err != nil {
+		return err
+	}
+	s.forget(tenantID)
+	return nil
+}
+
+// Lookup returns the policy the authenticators enforce for a tenant, nil when it has none; cached briefly
+func (s *SecurityPolicyService) Lookup(tenantID string) (*auth.SecurityPolicy, error) {
+	s.mu.Lock()
+	entry, ok := s.cache[tenantID]
+	s.mu.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.policy, nil
+	}
+
+	id, err := uuid.Parse(tenantID)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tenant ID %q", tenantID)
+	}
+	stored, err := s.policyRepo.Get(id)
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	var policy *auth.SecurityPolicy
+	if stored != nil {
+		if policy, err = enforcedSecurityPolicy(stored); err != nil {
+			return nil, err
+		}
+	}
+
+	s.mu.Lock()
+	s.cache[tenantID] = cachedSecurityPolicy{policy: policy, expiresAt: time.Now().Add(securityPolicyCacheTTL)}
+	s.mu.Unlock()
+	return policy, nil
+}
+
+func (s *SecurityPolicyService) forget(tenantID uuid.UUID) {
+	s.mu.Lock()
+	delete(s.cache, tenantID.String())
+	s.mu.Unlock()
+}
+
+// enforcedSecurityPolicy converts a stored policy to the form the authenticators check
+func enforcedSecurityPolicy(stored *models.TenantSecurityPolicy) (*auth.SecurityPolicy, error) {
+	policy := &auth.SecurityPolicy{
+		MaxSession:     time.Duration(stored.MaxSessionMinutes) * time.Minute,
+		ReauthInterval: time.Duration(stored.ReauthIntervalMinutes) * time.Minute,
+	}
+	for _, cidr := range stored.IPRanges() {
+		_, ipRange, err := net.ParseCIDR(cidr)
+		if err != nil {
+			return nil, fmt.Errorf("stored IP range %q of tenant %s: %w", cidr, stored.TenantID, err)
+		}
+		policy.AllowedIPRanges = append(policy.AllowedIPRanges, ipRange)
+	}
+	return policy, nil
+}
+
+// normalizeIPRanges validates the ranges and writes them in CIDR form; a single address becomes a /32 or /128
+func normalizeIPRanges(ranges []string) ([]string, error) {
+	if len(ranges) > maxAllowedIPRanges {
+		return nil, fmt.Errorf("%w: at most %d IP ranges", ErrInvalidSecurityPolicy, maxAllowedIPRanges)
+	}
+	normalized := make([]string, 0, len(ranges))
+	seen := make(map[string]bool)
+	for _, value := range ranges {
+		value = strings.TrimSpace(value)
+		if !strings.Contains(value, "/") {
+			ip := net.ParseIP(value)
+			if ip == nil {
+				return nil, fmt.Errorf("%w: %q is not an IP address or CIDR range", ErrInvalidSecurityPolicy, value)
+			}
+			if ip.To4() != nil {
+				value += "/32"
+			} else {
+				value += "/128"
+			}
+		}
+		_, ipRange, err := net.ParseCIDR(value)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %q is not an IP address or CIDR range", ErrInvalidSecurityPolicy, value)
+		}
+		cidr := ipRange.String()
+		if !seen[cidr] {
+			seen[cidr] = true
+			normalized = append(normalized, cidr)
+		}
+	}
+	return normalized, nil
+}
@@ -0,0 +1,102 @@
+package service
+
+import (
+	"errors"
+	"melina-studio-backend/internal/models"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type memorySecurityPolicyRepo struct {
+	policies map[uuid.UUID]*models.TenantSecurityPolicy
+	gets     int
+}
+
+func (r *memorySecurityPolicyRepo) Get(tenantID uuid.UUID) (*models.TenantSecurityPolicy, error) {
+	r.gets++
+	policy, ok := r.policies[tenantID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return policy, nil
+}
+
+func (r *memorySecurityPolicyRepo) Save(policy *models.TenantSecurityPolicy) error {
+	r.policies[policy.TenantID] = policy
+	return nil
+}
+
+func (r *memorySecurityPolicyRepo) Delete(tenantID uuid.UUID) error {
+	delete(r.policies, tenantID)
+	return nil
+}
+
+func TestNormalizeIPRanges(t *testing.T) {
+	got, err := normalizeIPRanges([]string{" 203.0.113.7 ", "198.51.100.12/24", "2001:db8::1", "198.51.100.0/24"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{"203.0.113.7/32", "198.51.100.0/24", "2001:db8::1/128"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+
+	for _, bad := range []string{"office", "10.0.0.0/33", ""} {
+		if _, err := normalizeIPRanges([]string{bad}); !errors.Is(err, ErrInvalidSecurityPolicy) {
+			t.Errorf("%q: got %v, want ErrInvalidSecurityPolicy", bad, err)
+		}
+	}
+}
+
+func TestSecurityPolicyLimits(t *testing.T) {
+	s := NewSecurityPolicyService(&memorySecurityPolicyRepo{policies: map[uuid.UUID]*models.TenantSecurityPolicy{}})
+	tenantID := uuid.New()
+
+	for _, input := range []SecurityPolicyInput{
+		{MaxSessionMinutes: -1},
+		{MaxSessionMinutes: maxSessionMinutes + 1},
+		{ReauthIntervalMinutes: maxReauthIntervalMins + 1},
+	} {
+		if _, err := s.Set(tenantID, input); !errors.Is(err, ErrInvalidSecurityPolicy) {
+			t.Errorf("%+v: got %v, want ErrInvalidSecurityPolicy", input, err)
+		}
+	}
+	if _, err := s.Set(tenantID, SecurityPolicyInput{MaxSessionMinutes: 8 * 60, ReauthIntervalMinutes: 5}); err != nil {
+		t.Errorf("valid policy refused: %v", err)
+	}
+}
+
+func TestSecurityPolicyLookupCachesUntilChanged(t *testing.T) {
+	policyRepo := &memorySecurityPolicyRepo{policies: map[uuid.UUID]*models.TenantSecurityPolicy{}}
+	s := NewSecurityPolicyService(policyRepo)
+	tenantID := uuid.New()
+
+	if policy, err := s.Lookup(tenantID.String()); policy != nil || err != nil {
+		t.Fatalf("tenant without a policy: got %v, %v", policy, err)
+	}
+	s.Lookup(tenantID.String())
+	if policyRepo.gets != 1 {
+		t.Errorf("a missing policy should be cached too, read %d times", policyRepo.gets)
+	}
+
+	if _, err := s.Set(tenantID, SecurityPolicyInput{AllowedIPRanges: []string{"203.0.113.0/24"}, MaxSessionMinutes: 60}); err != nil {
+		t.Fatal(err)
+	}
+	policy, err := s.Lookup(tenantID.String())
+	if err != nil || policy == nil {
+		t.Fatalf("policy not seen after it was set: %v, %v", policy, err)
+	}
+	if !policy.AllowsIP("203.0.113.9") || policy.AllowsIP("198.51.100.9") {
+		t.Error("enforced allowlist does not match the stored ranges")
+	}
+
+	if err := s.Delete(tenantID); err != nil {
+		t.Fatal(err)
+	}
+	if policy, _ := s.Lookup(tenantID.String()); policy != nil {
+		t.Error("policy still enforced after it was deleted")
+	}
+}
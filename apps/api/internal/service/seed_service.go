@@ -0,0 +1,298 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"melina-studio-backend/internal/auth"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// SeedPassword is the password of every demo user
+const SeedPassword = "melina-demo"
+
+// SeedUser is a demo user and the boards seeded for them
+type SeedUser struct {
+	Email        string              `json:"email"`
+	Password     string              `json:"password"`
+	UserID       uuid.UUID           `json:"user_id"`
+	Subscription models.Subscription `json:"subscription"`
+	// Existing is set when the user was already there, in which case nothing was seeded for them
+	Existing bool        `json:"existing"`
+	Boards   []SeedBoard `json:"boards,omitempty"`
+}
+
+// SeedBoard is a seeded board
+type SeedBoard struct {
+	BoardID  uuid.UUID `json:"board_id"`
+	Title    string    `json:"title"`
+	Shapes   int       `json:"shapes"`
+	Messages int       `json:"messages"`
+}
+
+// demoUser describes a demo user to seed
+type demoUser struct {
+	email        string
+	firstName    string
+	lastName     string
+	subscription models.Subscription
+}
+
+var demoUsers = []demoUser{
+	{email: "demo@melina.local", firstName: "Demo", lastName: "Free", subscription: models.SubscriptionFree},
+	{email: "pro@melina.local", firstName: "Demo", lastName: "Pro", subscription: models.SubscriptionPro},
+	{email: "premium@melina.local", firstName: "Demo", lastName: "Premium", subscription: models.SubscriptionPremium},
+}
+
+// demoBoard is a board every demo user gets, with the chat that drew it
+type demoBoard struct {
+	title  string
+	shapes func() []*models.Shape
+	// chat holds the user's messages and Melina's replies, in turn
+	chat [][2]string
+}
+
+var demoBoards = []demoBoard{
+	{
+		title:  "Signup flow",
+		shapes: signupFlowShapes,
+		chat: [][2]string{
+			{"Draw the signup flow: form, email check, verification and the dashboard", "I drew the signup flow with a decision for existing emails and arrows between the steps."},
+			{"Make the verification step stand out", "The verification step is now filled in amber so it stands out from the others."},
+		},
+	},
+	{
+		title:  "Landing page wireframe",
+		shapes: landingPageShapes,
+		chat: [][2]string{
+			{"Sketch a landing page with a navbar, a hero and three feature cards", "Here is the landing page: a navbar, the hero with an email field and call to action, and three feature cards."},
+		},
+	},
+	{
+		title:  "Sprint board",
+		shapes: sprintBoardShapes,
+		chat: [][2]string{
+			{"Set up a kanban board for this sprint", "I added To do, In progress and Done columns with the sprint's first tasks."},
+			{"Move the login fix to done", "Done, \"Fix login redirect\" is in the Done column now."},
+		},
+	},
+}
+
+// SeedService creates demo users and boards, so a fresh local environment has something to open and
+// the e2e tests have fixtures. Only for development databases
+type SeedService struct {
+	authRepo      repo.AuthRepoInterface
+	boardRepo     repo.BoardRepoInterface
+	boardDataRepo repo.BoardDataRepoInterface
+	chatRepo      repo.ChatRepoInterface
+	onboarding    *OnboardingService
+}
+
+func NewSeedService(
+	authRepo repo.AuthRepoInterface,
+	boardRepo repo.BoardRepoInterface,
+	boardDataRepo repo.BoardDataRepoInterface,
+	chatRepo repo.ChatRepoInterface,
+	onboarding *OnboardingService,
+) *SeedService {
+	return &SeedService{
+		authRepo:      authRepo,
+		boardRepo:     boardRepo,
+		boardDataRepo: boardDataRepo,
+		chatRepo:      chatRepo,
+		onboarding:    onboarding,
+	}
+}
+
+// Seed creates the demo users with their boards and chat history, and the onboarding sample board from
+// its template. Users that already exist are left as they are, so running it twice is harmless
+func (s *SeedService) Seed(ctx context.Context) ([]SeedUser, error) {
+	password, err := auth.HashPassword(SeedPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	seeded := make([]SeedUser, 0, len(demoUsers))
+	for _, demo := range demoUsers {
+		user := SeedUser{Email: demo.email, Password: SeedPassword, Subscription: demo.subscription}
+
+		existing, err := s.authRepo.GetUserByEmail(demo.email)
+		switch {
+		case err == nil:
+			user.UserID = existing.UUID
+			user.Subscription = existing.Subscription
+			user.Existing = true
+			seeded = append(seeded, user)
+			continue
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return seeded, fmt.Errorf("failed to look up %s: %w", demo.email, err)
+		}
+
+		userID, err := s.authRepo.CreateUser(&models.User{
+			Email:        demo.email,
+			Password:     &password,
+			FirstName:    demo.firstName,
+			LastName:     demo.lastName,
+			LoginMethod:  models.LoginMethodEmail,
+			Subscription: demo.subscription,
+		})
+		if err != nil {
+			return seeded, fmt.Errorf("failed to create %s: %w", demo.email, err)
+		}
+		user.UserID = userID
+
+		for _, board := range demoBoards {
+			if err := ctx.Err(); err != nil {
+				return seeded, err
+			}
+			created, err := s.seedBoard(userID, board)
+			if err != nil {
+				return seeded, err
+			}
+			user.Boards = append(user.Boards, created)
+		}
+
+		if s.onboarding != nil {
+			boardID, err := s.onboarding.StartNow(ctx, userID, nil)
+			if err != nil {
+				return seeded, err
+			}
+			user.Boards = append(user.Boards, SeedBoard{BoardID: boardID, Title: sampleBoardTitle, Shapes: len(sampleBoardShapes()), Messages: 1})
+		}
+		seeded = append(seeded, user)
+	}
+	return seeded, nil
+}
+
+func (s *SeedService) seedBoard(userID uuid.UUID, board demoBoard) (SeedBoard, error) {
+	boardID, err := s.boardRepo.CreateBoard(&models.Board{Title: board.title, UserID: userID})
+	if err != nil {
+		return SeedBoard{}, fmt.Errorf("failed to create board %q: %w", board.title, err)
+	}
+	shapes := board.shapes()
+	for _, shape := range shapes {
+		if err := s.boardDataRepo.SaveShapeData(boardID, shape); err != nil {
+			return SeedBoard{}, fmt.Errorf("failed to add a shape to %q: %w", board.title, err)
+		}
+	}
+	for _, turn := range board.chat {
+		if _, _, err := s.chatRepo.CreateHumanAndAiMessages(boardID, turn[0], turn[1], nil); err != nil {
+			return SeedBoard{}, fmt.Errorf("failed to add chat history to %q: %w", board.title, err)
+		}
+	}
+	return SeedBoard{BoardID: boardID, Title: board.title, Shapes: len(shapes), Messages: 2 * len(board.chat)}, nil
+}
+
+func seedNum(v float64) *float64 { return &v }
+func seedStr(v string) *string   { return &v }
+
+func seedText(x, y, size float64, content string) *models.Shape {
+	return &models.Shape{
+		ID: uuid.NewString(), Type: string(models.Text),
+		X: seedNum(x), Y: seedNum(y), Text: seedStr(content), FontSize: seedNum(size), Fill: seedStr("#1f2937"),
+	}
+}
+
+func seedArrow(x1, y1, x2, y2 float64) *models.Shape {
+	return &models.Shape{
+		ID: uuid.NewString(), Type: string(models.Arrow),
+		Start: map[string]float64{"x": x1, "y": y1}, End: map[string]float64{"x": x2, "y": y2},
+		Stroke: seedStr("#475569"), StrokeWidth: seedNum(2),
+	}
+}
+
+// signupFlowShapes is a flowchart of boxes, a decision and the arrows between them
+func signupFlowShapes() []*models.Shape {
+	step := func(x, y float64, label string, fill string) []*models.Shape {
+		return []*models.Shape{
+			{
+				ID: uuid.NewString(), Type: string(models.Rect),
+				X: seedNum(x), Y: seedNum(y), W: seedNum(180), H: seedNum(70),
+				Stroke: seedStr("#475569"), Fill: seedStr(fill), StrokeWidth: seedNum(2),
+			},
+			seedText(x+20, y+25, 16, label),
+		}
+	}
+	shapes := []*models.Shape{seedText(100, 30, 28, "Signup flow")}
+	shapes = append(shapes, step(100, 100, "Signup form", "#e0f2fe")...)
+	shapes = append(shapes, &models.Shape{
+		ID: uuid.NewString(), Type: string(models.Polygon),
+		Points: &[]float64{450, 100, 540, 135, 450, 170, 360, 135},
+		Stroke: seedStr("#475569"), Fill: seedStr("#f1f5f9"), StrokeWidth: seedNum(2),
+	}, seedText(405, 127, 14, "Email taken?"))
+	shapes = append(shapes, step(620, 100, "Verify email", "#fde68a")...)
+	shapes = append(shapes, step(620, 260, "Dashboard", "#dcfce7")...)
+	shapes = append(shapes, step(360, 260, "Sign in instead", "#fee2e2")...)
+	shapes = append(shapes,
+		seedArrow(280, 135, 360, 135),
+		seedArrow(540, 135, 620, 135),
+		seedArrow(710, 170, 710, 260),
+		seedArrow(450, 170, 450, 260),
+	)
+	return shapes
+}
+
+// landingPageShapes is a wireframe built from the wireframe components
+func landingPageShapes() []*models.Shape {
+	card := func(x float64, title string, lines ...string) *models.Shape {
+		return &models.Shape{
+			ID: uuid.NewString(), Type: string(models.Card),
+			X: seedNum(x), Y: seedNum(420), W: seedNum(260), H: seedNum(160),
+			Text: seedStr(title), Items: &lines,
+		}
+	}
+	return []*models.Shape{
+		{
+			ID: uuid.NewString(), Type: string(models.Navbar),
+			X: seedNum(100), Y: seedNum(40), W: seedNum(840), H: seedNum(60),
+			Text: seedStr("Melina"), Items: &[]string{"Features", "Pricing", "Docs", "Sign in"},
+		},
+		seedText(100, 160, 40, "Draw with words"),
+		seedText(100, 220, 18, "Describe a diagram and watch it appear on your board."),
+		{
+			ID: uuid.NewString(), Type: string(models.Input),
+			X: seedNum(100), Y: seedNum(280), W: seedNum(320), H: seedNum(44),
+			Placeholder: seedStr("you@company.com"),
+		},
+		{
+			ID: uuid.NewString(), Type: string(models.Button),
+			X: seedNum(440), Y: seedNum(280), W: seedNum(160), H: seedNum(44),
+			Text: seedStr("Get started"), Variant: seedStr("primary"),
+		},
+		card(100, "Flowcharts", "Boxes, decisions and arrows", "Laid out for you"),
+		card(390, "Wireframes", "Buttons, inputs and cards", "Ready to iterate"),
+		card(680, "Kanban", "Columns and task cards", "Kept in sync"),
+	}
+}
+
+// sprintBoardShapes is a kanban board: columns with action item cards that have the column's status
+func sprintBoardShapes() []*models.Shape {
+	column := func(x float64, name string, status string) *models.Shape {
+		return &models.Shape{
+			ID: uuid.NewString(), Type: string(models.Frame),
+			X: seedNum(x), Y: seedNum(100), W: seedNum(300), H: seedNum(440),
+			Name: seedStr(name), KanbanStatus: seedStr(status),
+		}
+	}
+	task := func(x, y float64, title string, status string) *models.Shape {
+		return &models.Shape{
+			ID: uuid.NewString(), Type: string(models.ActionItemCard),
+			X: seedNum(x + 20), Y: seedNum(y), W: seedNum(260), H: seedNum(64),
+			Text: seedStr(title), Status: seedStr(status),
+		}
+	}
+	return []*models.Shape{
+		seedText(100, 40, 28, "Sprint 12"),
+		column(100, "To do", "to do"),
+		column(420, "In progress", "in progress"),
+		column(740, "Done", "done"),
+		task(100, 160, "Write onboarding emails", "to do"),
+		task(100, 240, "Add export to PDF", "to do"),
+		task(420, 160, "Board sharing permissions", "in progress"),
+		task(740, 160, "Fix login redirect", "done"),
+	}
+}
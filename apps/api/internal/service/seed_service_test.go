@@ -0,0 +1,40 @@
+package service
+
+import (
+	"melina-studio-backend/internal/models"
+	"testing"
+)
+
+func TestDemoBoardsHaveDistinctShapes(t *testing.T) {
+	for _, board := range demoBoards {
+		shapes := board.shapes()
+		if len(shapes) == 0 {
+			t.Errorf("%s has no shapes", board.title)
+		}
+		seen := map[string]bool{}
+		for _, shape := range shapes {
+			if seen[shape.ID] {
+				t.Errorf("%s has shape %s twice", board.title, shape.ID)
+			}
+			seen[shape.ID] = true
+		}
+		if len(board.chat) == 0 {
+			t.Errorf("%s has no chat history", board.title)
+		}
+	}
+}
+
+func TestSprintBoardCardsMatchTheirColumns(t *testing.T) {
+	shapes := sprintBoardShapes()
+	statuses := map[string]bool{}
+	for _, shape := range shapes {
+		if shape.Type == string(models.Frame) && shape.KanbanStatus != nil {
+			statuses[*shape.KanbanStatus] = true
+		}
+	}
+	for _, shape := range shapes {
+		if shape.Type == string(models.ActionItemCard) && !statuses[*shape.Status] {
+			t.Errorf("card %q has status %q, which no column has", *shape.Text, *shape.Status)
+		}
+	}
+}
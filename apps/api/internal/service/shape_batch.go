@@ -0,0 +1,99 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+
+	"github.com/google/uuid"
+)
+
+var ErrInvalidShapeBatch = errors.New("invalid shape batch")
+
+// MaxShapeBatchOperations caps the operations of one batched save; a larger change should use the full save
+const MaxShapeBatchOperations = 500
+
+// ShapeOperationInput is one operation of a batched save: create and update carry the shape, delete the id
+type ShapeOperationInput struct {
+	Op    string        `json:"op"`
+	ID    string        `json:"id"`
+	Shape *models.Shape `json:"shape"`
+}
+
+// ShapeBatch is what a batched save will do once the locked shapes are left out
+type ShapeBatch struct {
+	Applied []repo.ShapeOperation
+	// LockedIDs are the shapes left alone because they are locked
+	LockedIDs []string
+	// Unlocked are the stored shapes the applied operations touch, as they were before the save
+	Unlocked []models.BoardData
+}
+
+// ParseShapeOperations validates a batch. Each shape may appear once, so the order of the operations doesn't matter
+func ParseShapeOperations(inputs []ShapeOperationInput) ([]repo.ShapeOperation, error) {
+	if len(inputs) == 0 {
+		return nil, fmt.Errorf("%w: no operations provided", ErrInvalidShapeBatch)
+	}
+	if len(inputs) > MaxShapeBatchOperations {
+		return nil, fmt.Errorf("%w: a batch can have at most %d operations", ErrInvalidShapeBatch, MaxShapeBatchOperations)
+	}
+
+	ops := make([]repo.ShapeOperation, 0, len(inputs))
+	seen := make(map[uuid.UUID]bool, len(inputs))
+	for i, input := range inputs {
+		op := repo.ShapeOperation{Op: repo.ShapeOp(input.Op), Shape: input.Shape}
+		id := input.ID
+		switch op.Op {
+		case repo.ShapeOpCreate, repo.ShapeOpUpdate:
+			if input.Shape == nil {
+				return nil, fmt.Errorf("%w: operation %d has no shape", ErrInvalidShapeBatch, i)
+			}
+			id = input.Shape.ID
+		case repo.ShapeOpDelete:
+		default:
+			return nil, fmt.Errorf("%w: operation %d must be create, update or delete", ErrInvalidShapeBatch, i)
+		}
+		parsed, err := uuid.Parse(id)
+		if err != nil {
+			return nil, fmt.Errorf("%w: operation %d has an invalid shape ID", ErrInvalidShapeBatch, i)
+		}
+		op.ID = parsed
+		if seen[op.ID] {
+			return nil, fmt.Errorf("%w: shape %s appears in more than one operation", ErrInvalidShapeBatch, op.ID)
+		}
+		seen[op.ID] = true
+		ops = append(ops, op)
+	}
+	return ops, nil
+}
+
+// PlanShapeBatch checks the operations against the stored shapes they touch, leaving out the locked ones.
+// It fails with repo.ErrShapeNotFound as the transaction would, before anything is written
+func PlanShapeBatch(boardID uuid.UUID, ops []repo.ShapeOperation, stored []models.BoardData) (*ShapeBatch, error) {
+	byID := make(map[uuid.UUID]*models.BoardData, len(stored))
+	for i := range stored {
+		byID[stored[i].UUID] = &stored[i]
+	}
+
+	batch := &ShapeBatch{}
+	for _, op := range ops {
+		shape := byID[op.ID]
+		if err := repo.CheckShapeOperation(boardID, op, shape); err != nil {
+			return nil, err
+		}
+		// a delete of another board's shape is a no-op, its lock isn't this board's business
+		if shape == nil || shape.BoardId != boardID {
+			batch.Applied = append(batch.Applied, op)
+			continue
+		}
+		if shape.Locked {
+			batch.LockedIDs = append(batch.LockedIDs, shape.UUID.String())
+			continue
+		}
+		batch.Unlocked = append(batch.Unlocked, *shape)
+		batch.Applied = append(batch.Applied, op)
+	}
+	return batch, nil
+}
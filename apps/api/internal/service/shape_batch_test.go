@@ -0,0 +1,109 @@
+package service
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+
+	"github.com/google/uuid"
+)
+
+func TestParseShapeOperations(t *testing.T) {
+	a, b := uuid.New().String(), uuid.New().String()
+	tooMany := make([]ShapeOperationInput, MaxShapeBatchOperations+1)
+	for i := range tooMany {
+		tooMany[i] = ShapeOperationInput{Op: "delete", ID: uuid.New().String()}
+	}
+
+	cases := []struct {
+		name    string
+		inputs  []ShapeOperationInput
+		wantErr string
+	}{
+		{"valid", []ShapeOperationInput{{Op: "create", Shape: &models.Shape{ID: a}}, {Op: "delete", ID: b}}, ""},
+		{"empty", nil, "no operations"},
+		{"too many", tooMany, "at most"},
+		{"unknown op", []ShapeOperationInput{{Op: "move", ID: a}}, "operation 0 must be create, update or delete"},
+		{"create without shape", []ShapeOperationInput{{Op: "create", ID: a}}, "operation 0 has no shape"},
+		{"invalid id", []ShapeOperationInput{{Op: "delete", ID: "nope"}}, "operation 0 has an invalid shape ID"},
+		{"update takes the shape's id", []ShapeOperationInput{{Op: "update", ID: a, Shape: &models.Shape{ID: "nope"}}}, "invalid shape ID"},
+		{"duplicate ids", []ShapeOperationInput{{Op: "update", Shape: &models.Shape{ID: a}}, {Op: "delete", ID: a}}, "appears in more than one operation"},
+	}
+	for _, tc := range cases {
+		ops, err := ParseShapeOperations(tc.inputs)
+		if tc.wantErr == "" {
+			if err != nil || len(ops) != len(tc.inputs) {
+				t.Errorf("%s: got %v, %v", tc.name, ops, err)
+			}
+			continue
+		}
+		if !errors.Is(err, ErrInvalidShapeBatch) || !strings.Contains(err.Error(), tc.wantErr) {
+			t.Errorf("%s: got %v, want ErrInvalidShapeBatch with %q", tc.name, err, tc.wantErr)
+		}
+	}
+}
+
+func TestPlanShapeBatch(t *testing.T) {
+	boardID, otherBoardID := uuid.New(), uuid.New()
+	unlocked, locked, foreign, missing := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	stored := []models.BoardData{
+		{UUID: unlocked, BoardId: boardID},
+		{UUID: locked, BoardId: boardID, Locked: true},
+		{UUID: foreign, BoardId: otherBoardID},
+	}
+	op := func(kind repo.ShapeOp, id uuid.UUID) repo.ShapeOperation {
+		return repo.ShapeOperation{Op: kind, ID: id, Shape: &models.Shape{ID: id.String()}}
+	}
+
+	cases := []struct {
+		name        string
+		ops         []repo.ShapeOperation
+		wantApplied []uuid.UUID
+		wantLocked  []string
+		wantErr     error
+	}{
+		{"update unlocked", []repo.ShapeOperation{op(repo.ShapeOpUpdate, unlocked)}, []uuid.UUID{unlocked}, nil, nil},
+		{"create new", []repo.ShapeOperation{op(repo.ShapeOpCreate, missing)}, []uuid.UUID{missing}, nil, nil},
+		{"create existing is an update", []repo.ShapeOperation{op(repo.ShapeOpCreate, unlocked)}, []uuid.UUID{unlocked}, nil, nil},
+		{"locked shapes are skipped", []repo.ShapeOperation{op(repo.ShapeOpUpdate, locked), op(repo.ShapeOpDelete, unlocked)}, []uuid.UUID{unlocked}, []string{locked.String()}, nil},
+		{"locked shapes can't be deleted", []repo.ShapeOperation{op(repo.ShapeOpDelete, locked)}, nil, []string{locked.String()}, nil},
+		{"update of a missing shape", []repo.ShapeOperation{op(repo.ShapeOpUpdate, missing)}, nil, nil, repo.ErrShapeNotFound},
+		{"delete of a missing shape", []repo.ShapeOperation{op(repo.ShapeOpDelete, missing)}, []uuid.UUID{missing}, nil, nil},
+		{"update of another board's shape", []repo.ShapeOperation{op(repo.ShapeOpUpdate, foreign)}, nil, nil, repo.ErrShapeNotFound},
+		{"create over another board's shape", []repo.ShapeOperation{op(repo.ShapeOpCreate, foreign)}, nil, nil, repo.ErrShapeNotFound},
+		{"delete of another board's shape", []repo.ShapeOperation{op(repo.ShapeOpDelete, foreign)}, []uuid.UUID{foreign}, nil, nil},
+	}
+	for _, tc := range cases {
+		batch, err := PlanShapeBatch(boardID, tc.ops, stored)
+		if tc.wantErr != nil {
+			if !errors.Is(err, tc.wantErr) {
+				t.Errorf("%s: got %v, want %v", tc.name, err, tc.wantErr)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%s: %v", tc.name, err)
+			continue
+		}
+		var applied []uuid.UUID
+		for _, op := range batch.Applied {
+			applied = append(applied, op.ID)
+		}
+		if !reflect.DeepEqual(applied, tc.wantApplied) || !reflect.DeepEqual(batch.LockedIDs, tc.wantLocked) {
+			t.Errorf("%s: applied %v locked %v, want %v %v", tc.name, applied, batch.LockedIDs, tc.wantApplied, tc.wantLocked)
+		}
+		for _, shape := range batch.Unlocked {
+			if shape.Locked || shape.BoardId != boardID {
+				t.Errorf("%s: unexpected snapshot of %s", tc.name, shape.UUID)
+			}
+		}
+	}
+
+	if err := repo.CheckShapeOperation(boardID, repo.ShapeOperation{Op: "move", ID: unlocked}, nil); err == nil {
+		t.Error("expected an unknown operation to be refused")
+	}
+}
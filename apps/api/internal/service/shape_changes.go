@@ -0,0 +1,56 @@
+package service
+
+import (
+	"encoding/json"
+
+	"melina-studio-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// ShapeChanges is what a save did to a board, as the shape messages the board's viewers receive
+type ShapeChanges struct {
+	Created []map[string]interface{}
+	Updated []map[string]interface{}
+	Deleted []string
+}
+
+// Empty reports whether the save changed nothing
+func (c ShapeChanges) Empty() bool {
+	return len(c.Created) == 0 && len(c.Updated) == 0 && len(c.Deleted) == 0
+}
+
+// DiffShapeChanges compares the stored shapes a save could touch, before and after it: shapes that weren't
+// stored were created, those whose data changed were updated, and those no longer stored were deleted
+func DiffShapeChanges(before []models.BoardData, after []models.BoardData) ShapeChanges {
+	var changes ShapeChanges
+	for _, event := range ShapeEvents(before, after) {
+		shape := shapeMessage(event.Shape)
+		if event.Trigger == models.AutomationTriggerShapeCreated {
+			changes.Created = append(changes.Created, shape)
+		} else {
+			changes.Updated = append(changes.Updated, shape)
+		}
+	}
+	remaining := make(map[uuid.UUID]bool, len(after))
+	for _, shape := range after {
+		remaining[shape.UUID] = true
+	}
+	for _, shape := range before {
+		if !remaining[shape.UUID] {
+			changes.Deleted = append(changes.Deleted, shape.UUID.String())
+		}
+	}
+	return changes
+}
+
+// shapeMessage flattens a stored shape into the form the agent's shape messages use: its data with its id and type
+func shapeMessage(shape models.BoardData) map[string]interface{} {
+	message := make(map[string]interface{})
+	if err := json.Unmarshal(shape.Data, &message); err != nil || message == nil {
+		message = make(map[string]interface{})
+	}
+	message["id"] = shape.UUID.String()
+	message["type"] = string(shape.Type)
+	return message
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"testing"
+
+	"melina-studio-backend/internal/models"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+)
+
+func TestDiffShapeChanges(t *testing.T) {
+	kept, moved, removed, added := uuid.New(), uuid.New(), uuid.New(), uuid.New()
+	before := []models.BoardData{
+		{UUID: kept, Type: models.Rect, Data: datatypes.JSON(`{"x": 1, "y": 2}`)},
+		{UUID: moved, Type: models.Rect, Data: datatypes.JSON(`{"x": 1, "y": 2}`)},
+		{UUID: removed, Type: models.Text, Data: datatypes.JSON(`{"text": "bye"}`)},
+	}
+	after := []models.BoardData{
+		{UUID: kept, Type: models.Rect, Data: datatypes.JSON(`{"y":2,"x":1}`)},
+		{UUID: moved, Type: models.Rect, Data: datatypes.JSON(`{"x": 5, "y": 2}`)},
+		{UUID: added, Type: models.Text, Data: datatypes.JSON(`{"text": "hi"}`)},
+	}
+
+	changes := DiffShapeChanges(before, after)
+	if len(changes.Created) != 1 || changes.Created[0]["id"] != added.String() || changes.Created[0]["text"] != "hi" {
+		t.Errorf("expected the new shape to be created, got %+v", changes.Created)
+	}
+	if len(changes.Updated) != 1 || changes.Updated[0]["id"] != moved.String() || changes.Updated[0]["type"] != string(models.Rect) {
+		t.Errorf("expected only the moved shape to be updated, got %+v", changes.Updated)
+	}
+	if len(changes.Deleted) != 1 || changes.Deleted[0] != removed.String() {
+		t.Errorf("expected the removed shape to be deleted, got %+v", changes.Deleted)
+	}
+	if !DiffShapeChanges(before, before).Empty() {
+		t.Error("expected an unchanged board to have no changes")
+	}
+}
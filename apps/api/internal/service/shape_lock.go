@@ -0,0 +1,42 @@
+package service
+
+import (
+	"melina-studio-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// LockedShapes splits a board's stored shapes into the locked ones, which saves and clears keep as they are,
+// and the others
+type LockedShapes struct {
+	IDs      []uuid.UUID
+	Unlocked []models.BoardData
+	locked   map[uuid.UUID]bool
+}
+
+func SplitLockedShapes(stored []models.BoardData) *LockedShapes {
+	l := &LockedShapes{locked: make(map[uuid.UUID]bool)}
+	for _, shape := range stored {
+		if shape.Locked {
+			l.locked[shape.UUID] = true
+			l.IDs = append(l.IDs, shape.UUID)
+		} else {
+			l.Unlocked = append(l.Unlocked, shape)
+		}
+	}
+	return l
+}
+
+// Has reports whether the shape is locked
+func (l *LockedShapes) Has(id uuid.UUID) bool {
+	return l.locked[id]
+}
+
+// Strings returns the locked shape IDs as the client sends them
+func (l *LockedShapes) Strings() []string {
+	ids := make([]string, len(l.IDs))
+	for i, id := range l.IDs {
+		ids[i] = id.String()
+	}
+	return ids
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"reflect"
+	"testing"
+
+	"melina-studio-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+func TestSplitLockedShapes(t *testing.T) {
+	first, second, free := uuid.New(), uuid.New(), uuid.New()
+	locked := SplitLockedShapes([]models.BoardData{
+		{UUID: first, Locked: true},
+		{UUID: free},
+		{UUID: second, Locked: true},
+	})
+
+	if !reflect.DeepEqual(locked.IDs, []uuid.UUID{first, second}) {
+		t.Errorf("expected the locked shapes in stored order, got %v", locked.IDs)
+	}
+	if len(locked.Unlocked) != 1 || locked.Unlocked[0].UUID != free {
+		t.Errorf("expected only the free shape to be unlocked, got %v", locked.Unlocked)
+	}
+	if !locked.Has(first) || !locked.Has(second) || locked.Has(free) || locked.Has(uuid.New()) {
+		t.Error("expected Has to report exactly the locked shapes")
+	}
+	if got := locked.Strings(); !reflect.DeepEqual(got, []string{first.String(), second.String()}) {
+		t.Errorf("unexpected IDs %v", got)
+	}
+
+	none := SplitLockedShapes(nil)
+	if len(none.IDs) != 0 || none.Has(first) || len(none.Strings()) != 0 {
+		t.Errorf("expected an empty board to lock nothing, got %+v", none)
+	}
+}
@@ -0,0 +1,182 @@
+package service
+
+import (
+	"context"
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+)
+
+var ErrInvalidSNSSignature = errors.New("invalid SNS message signature")
+
+const (
+	// snsCertLimit is how much of a signing certificate is read
+	snsCertLimit = 64 << 10
+	// snsCertCacheSize caps the signing certificates kept; SNS uses a handful per region
+	snsCertCacheSize = 32
+)
+
+// SNSVerifier checks that messages posted to an SNS subscription were signed by Amazon SNS. The signing
+// certificates are fetched from SNS endpoints only, and kept
+type SNSVerifier struct {
+	// fetchCert gets the PEM certificate at a signing certificate URL already checked to be on SNS
+	fetchCert func(ctx context.Context, certURL string) ([]byte, error)
+
+	mu    sync.Mutex
+	certs map[string]*x509.Certificate
+}
+
+func NewSNSVerifier() *SNSVerifier {
+	return &SNSVerifier{
+		fetchCert: fetchSNSCert,
+		certs:     make(map[string]*x509.Certificate),
+	}
+}
+
+// Verify checks the message's signature against the certificate it names
+func (v *SNSVerifier) Verify(ctx context.Context, msg SNSMessage) error {
+	var hash crypto.Hash
+	switch msg.SignatureVersion {
+	case "1":
+		hash = crypto.SHA1
+	case "2":
+		hash = crypto.SHA256
+	default:
+		return fmt.Errorf("%w: unsupported signature version %q", ErrInvalidSNSSignature, msg.SignatureVersion)
+	}
+	signature, err := base64.StdEncoding.DecodeString(msg.Signature)
+	if err != nil || len(signature) == 0 {
+		return fmt.Errorf("%w: the signature isn't base64", ErrInvalidSNSSignature)
+	}
+	signed, err := snsStringToSign(msg)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSNSSignature, err)
+	}
+	cert, err := v.cert(ctx, msg.SigningCertURL)
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSNSSignature, err)
+	}
+	key, ok := cert.PublicKey.(*rsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("%w: the signing certificate has no RSA key", ErrInvalidSNSSignature)
+	}
+
+	var digest []byte
+	if hash == crypto.SHA1 {
+		sum := sha1.Sum([]byte(signed))
+		digest = sum[:]
+	} else {
+		sum := sha256.Sum256([]byte(signed))
+		digest = sum[:]
+	}
+	if err := rsa.VerifyPKCS1v15(key, hash, digest, signature); err != nil {
+		return fmt.Errorf("%w: the signature doesn't match", ErrInvalidSNSSignature)
+	}
+	return nil
+}
+
+// cert returns the certificate at a signing certificate URL, which must be an https URL of an SNS endpoint
+func (v *SNSVerifier) cert(ctx context.Context, certURL string) (*x509.Certificate, error) {
+	u, err := url.Parse(certURL)
+	if err != nil || u.Scheme != "https" || u.User != nil || u.Port() != "" ||
+		!snsSubscribeHost.MatchString(u.Hostname()) || !strings.HasSuffix(u.Path, ".pem") {
+		return nil, fmt.Errorf("refusing the signing certificate at %q", certURL)
+	}
+
+	v.mu.Lock()
+	cert, ok := v.certs[certURL]
+	v.mu.Unlock()
+	if ok && time.Now().Before(cert.NotAfter) {
+		return cert, nil
+	}
+
+	body, err := v.fetchCert(ctx, u.String())
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(body)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, errors.New("the signing certificate isn't a PEM certificate")
+	}
+	cert, err = x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return nil, err
+	}
+	if now := time.Now(); now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+		return nil, errors.New("the signing certificate has expired")
+	}
+
+	v.mu.Lock()
+	if len(v.certs) >= snsCertCacheSize {
+		v.certs = make(map[string]*x509.Certificate)
+	}
+	v.certs[certURL] = cert
+	v.mu.Unlock()
+	return cert, nil
+}
+
+func fetchSNSCert(ctx context.Context, certURL string) ([]byte, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, certURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("SNS answered %d for the signing certificate", resp.StatusCode)
+	}
+	return io.ReadAll(io.LimitReader(resp.Body, snsCertLimit))
+}
+
+// snsStringToSign builds the text SNS signs: the message's fields, in a fixed order that depends on its type,
+// each as its name and value on their own lines
+func snsStringToSign(msg SNSMessage) (string, error) {
+	var fields [][2]string
+	switch msg.Type {
+	case "Notification":
+		fields = [][2]string{{"Message", msg.Message}, {"MessageId", msg.MessageId}}
+		// the subject is only signed when the notification has one
+		if msg.Subject != "" {
+			fields = append(fields, [2]string{"Subject", msg.Subject})
+		}
+		fields = append(fields, [][2]string{{"Timestamp", msg.Timestamp}, {"TopicArn", msg.TopicArn}, {"Type", msg.Type}}...)
+	case "SubscriptionConfirmation", "UnsubscribeConfirmation":
+		fields = [][2]string{
+			{"Message", msg.Message},
+			{"MessageId", msg.MessageId},
+			{"SubscribeURL", msg.SubscribeURL},
+			{"Timestamp", msg.Timestamp},
+			{"Token", msg.Token},
+			{"TopicArn", msg.TopicArn},
+			{"Type", msg.Type},
+		}
+	default:
+		return "", fmt.Errorf("unknown message type %q", msg.Type)
+	}
+
+	var b strings.Builder
+	for _, field := range fields {
+		b.WriteString(field[0])
+		b.WriteString("\n")
+		b.WriteString(field[1])
+		b.WriteString("\n")
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,165 @@
+package service
+
+import (
+	"context"
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"melina-studio-backend/internal/config"
+)
+
+const testSNSCertURL = "https://sns.us-east-1.amazonaws.com/SimpleNotificationService-abc.pem"
+
+// newTestSNSVerifier returns a verifier whose certificate fetches are answered with a fresh self-signed
+// certificate, the key that signs for it, and a count of the fetches
+func newTestSNSVerifier(t *testing.T) (*SNSVerifier, *rsa.PrivateKey, *int) {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "sns.amazonaws.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	if err != nil {
+		t.Fatal(err)
+	}
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+
+	fetches := 0
+	v := NewSNSVerifier()
+	v.fetchCert = func(ctx context.Context, certURL string) ([]byte, error) {
+		fetches++
+		return certPEM, nil
+	}
+	return v, key, &fetches
+}
+
+// signSNS signs the message as SNS does with the given signature version
+func signSNS(t *testing.T, key *rsa.PrivateKey, msg *SNSMessage, version string) {
+	t.Helper()
+	signed, err := snsStringToSign(*msg)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sha256Sum, sha1Sum := sha256.Sum256([]byte(signed)), sha1.Sum([]byte(signed))
+	hash, digest := crypto.SHA256, sha256Sum[:]
+	if version == "1" {
+		hash, digest = crypto.SHA1, sha1Sum[:]
+	}
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, hash, digest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	msg.SignatureVersion = version
+	msg.Signature = base64.StdEncoding.EncodeToString(signature)
+	msg.SigningCertURL = testSNSCertURL
+}
+
+func testSNSNotification() SNSMessage {
+	return SNSMessage{
+		Type:      "Notification",
+		MessageId: "22b80b92-fdea-4c2c-8f9d-bdfb0c7bf324",
+		TopicArn:  "arn:aws:sns:us-east-1:123456789012:inbound",
+		Subject:   "Amazon SES Email Receipt Notification",
+		Message:   `{"notificationType":"Received"}`,
+		Timestamp: "2026-10-17T09:00:00.000Z",
+	}
+}
+
+func TestSNSVerifierAcceptsSignedMessages(t *testing.T) {
+	v, key, fetches := newTestSNSVerifier(t)
+
+	for _, version := range []string{"1", "2"} {
+		msg := testSNSNotification()
+		signSNS(t, key, &msg, version)
+		if err := v.Verify(context.Background(), msg); err != nil {
+			t.Errorf("version %s: %v", version, err)
+		}
+	}
+
+	confirmation := SNSMessage{
+		Type:         "SubscriptionConfirmation",
+		MessageId:    "165545c9-2a5c-472c-8df2-7ff2be2b3b1b",
+		Token:        "2336412f37",
+		TopicArn:     "arn:aws:sns:us-east-1:123456789012:inbound",
+		Message:      "You have chosen to subscribe to the topic",
+		SubscribeURL: "https://sns.us-east-1.amazonaws.com/?Action=ConfirmSubscription&Token=2336412f37",
+		Timestamp:    "2026-10-17T09:00:00.000Z",
+	}
+	signSNS(t, key, &confirmation, "2")
+	if err := v.Verify(context.Background(), confirmation); err != nil {
+		t.Errorf("subscription confirmation: %v", err)
+	}
+
+	if *fetches != 1 {
+		t.Errorf("expected the certificate to be fetched once, got %d", *fetches)
+	}
+}
+
+func TestSNSVerifierRefusesForgedMessages(t *testing.T) {
+	v, key, _ := newTestSNSVerifier(t)
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := map[string]func(msg *SNSMessage){
+		"tampered message": func(msg *SNSMessage) { msg.Message = `{"notificationType":"Bounce"}` },
+		"tampered topic":   func(msg *SNSMessage) { msg.TopicArn = "arn:aws:sns:us-east-1:999999999999:other" },
+		"dropped subject":  func(msg *SNSMessage) { msg.Subject = "" },
+		"other key":        func(msg *SNSMessage) { signSNS(t, otherKey, msg, "2") },
+		"unsigned":         func(msg *SNSMessage) { msg.Signature = "" },
+		"unknown version":  func(msg *SNSMessage) { msg.SignatureVersion = "3" },
+		"unknown type":     func(msg *SNSMessage) { msg.Type = "Other" },
+		"http cert":        func(msg *SNSMessage) { msg.SigningCertURL = "http://sns.us-east-1.amazonaws.com/cert.pem" },
+		"foreign cert":     func(msg *SNSMessage) { msg.SigningCertURL = "https://attacker.example.com/cert.pem" },
+		"lookalike cert": func(msg *SNSMessage) {
+			msg.SigningCertURL = "https://sns.us-east-1.amazonaws.com.attacker.example.com/cert.pem"
+		},
+		"cert with port": func(msg *SNSMessage) { msg.SigningCertURL = "https://sns.us-east-1.amazonaws.com:8443/cert.pem" },
+		"not a pem path": func(msg *SNSMessage) { msg.SigningCertURL = "https://sns.us-east-1.amazonaws.com/?Action=Unsubscribe" },
+	}
+	for name, tamper := range cases {
+		msg := testSNSNotification()
+		signSNS(t, key, &msg, "2")
+		tamper(&msg)
+		if err := v.Verify(context.Background(), msg); !errors.Is(err, ErrInvalidSNSSignature) {
+			t.Errorf("%s: expected ErrInvalidSNSSignature, got %v", name, err)
+		}
+	}
+}
+
+func TestVerifySNSMessageChecksTheTopic(t *testing.T) {
+	v, key, _ := newTestSNSVerifier(t)
+	msg := testSNSNotification()
+	signSNS(t, key, &msg, "2")
+
+	s := &InboundEmailService{sns: v}
+	if err := s.VerifySNSMessage(context.Background(), msg); err != nil {
+		t.Errorf("expected any topic to be taken when none are configured, got %v", err)
+	}
+	s.cfg = config.InboundEmailConfig{SNSTopicARNs: []string{msg.TopicArn}}
+	if err := s.VerifySNSMessage(context.Background(), msg); err != nil {
+		t.Errorf("expected the configured topic to be taken, got %v", err)
+	}
+	s.cfg = config.InboundEmailConfig{SNSTopicARNs: []string{"arn:aws:sns:us-east-1:123456789012:other"}}
+	if err := s.VerifySNSMessage(context.Background(), msg); !errors.Is(err, ErrInvalidSNSSignature) {
+		t.Errorf("expected another topic to be refused, got %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"strings"
+	"unicode/utf8"
+
+	"melina-studio-backend/internal/melina/tools"
+	"melina-studio-backend/internal/models"
+
+	"github.com/google/uuid"
+)
+
+// sticky note geometry: a yellow rect with the text wrapped to its width
+const (
+	stickyNoteWidth     = 280.0
+	stickyNoteMinHeight = 120.0
+	stickyNotePadding   = 16.0
+	stickyNoteFontSize  = 14.0
+	stickyNoteLineChars = 36
+)
+
+// stickyNoteShapes builds a yellow sticky note, a rect with the text on it, in a free spot next to the board's content
+func stickyNoteShapes(text string, occupied []tools.BoundingBox) []*models.Shape {
+	lines := wrapNoteText(text, stickyNoteLineChars)
+	wrapped := strings.Join(lines, "\n")
+	width := stickyNoteWidth
+	height := max(stickyNoteMinHeight, float64(len(lines))*stickyNoteFontSize*1.4+2*stickyNotePadding)
+	spot := tools.FindFreeSpot(occupied, width, height, nil)
+
+	x, y := spot.X, spot.Y
+	textX, textY := x+stickyNotePadding, y+stickyNotePadding
+	fill, stroke, ink := "#fef9c3", "#ca8a04", "#422006"
+	fontSize := stickyNoteFontSize
+	return []*models.Shape{
+		{ID: uuid.New().String(), Type: string(models.Rect), X: &x, Y: &y, W: &width, H: &height, Fill: &fill, Stroke: &stroke},
+		{ID: uuid.New().String(), Type: string(models.Text), X: &textX, Y: &textY, Text: &wrapped, FontSize: &fontSize, Fill: &ink},
+	}
+}
+
+// wrapNoteText breaks the lines of the text at word boundaries to fit width characters; text shapes don't wrap
+func wrapNoteText(text string, width int) []string {
+	var lines []string
+	for _, paragraph := range strings.Split(text, "\n") {
+		line := ""
+		for _, word := range strings.Fields(paragraph) {
+			for utf8.RuneCountInString(word) > width {
+				if line != "" {
+					lines = append(lines, line)
+					line = ""
+				}
+				runes := []rune(word)
+				lines = append(lines, string(runes[:width]))
+				word = string(runes[width:])
+			}
+			switch {
+			case line == "":
+				line = word
+			case utf8.RuneCountInString(line)+1+utf8.RuneCountInString(word) <= width:
+				line += " " + word
+			default:
+				lines = append(lines, line)
+				line = word
+			}
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
@@ -0,0 +1,98 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"melina-studio-backend/internal/config"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+
+	"github.com/google/uuid"
+)
+
+var ErrStorageQuotaExceeded = errors.New("storage quota exceeded")
+
+// StorageQuotaService tracks the bytes each user stores and enforces their plan's quota on uploads
+type StorageQuotaService struct {
+	config           config.StorageQuotaConfig
+	storageUsageRepo repo.StorageUsageRepoInterface
+	authRepo         repo.AuthRepoInterface
+}
+
+func NewStorageQuotaService(cfg config.StorageQuotaConfig, storageUsageRepo repo.StorageUsageRepoInterface, authRepo repo.AuthRepoInterface) *StorageQuotaService {
+	return &StorageQuotaService{config: cfg, storageUsageRepo: storageUsageRepo, authRepo: authRepo}
+}
+
+// Report returns what the user stores, per kind, and their quota
+func (s *StorageQuotaService) Report(userID uuid.UUID) (*models.StorageReport, error) {
+	user, err := s.authRepo.GetUserByID(userID)
+	if err != nil {
+		return nil, err
+	}
+	byKind, err := s.storageUsageRepo.GetTotalsByKind(userID)
+	if err != nil {
+		return nil, err
+	}
+	report := &models.StorageReport{
+		Plan:       user.Subscription,
+		LimitBytes: s.config.Limit(user.Subscription),
+		ByKind:     byKind,
+	}
+	for _, bytes := range byKind {
+		report.UsedBytes += bytes
+	}
+	return report, nil
+}
+
+// Check refuses an upload of size bytes to objectKey with ErrStorageQuotaExceeded when it would take the user
+// over their quota. The report is returned either way so callers can tell the user where they stand
+func (s *StorageQuotaService) Check(userID uuid.UUID, objectKey string, size int64) (*models.StorageReport, error) {
+	report, err := s.Report(userID)
+	if err != nil {
+		return nil, err
+	}
+	replaced, err := s.storageUsageRepo.GetObjectBytes(userID, objectKey)
+	if err != nil {
+		return nil, err
+	}
+	if exceedsQuota(report.UsedBytes, replaced, size, report.LimitBytes) {
+		return report, fmt.Errorf("%w: %d of %d bytes used, upload is %d bytes", ErrStorageQuotaExceeded, report.UsedBytes, report.LimitBytes, size)
+	}
+	return report, nil
+}
+
+// Record counts an uploaded object against the user's quota. Failures are logged; the upload already happened
+func (s *StorageQuotaService) Record(userID uuid.UUID, kind models.StorageKind, objectKey string, size int64) {
+	usage := &models.StorageUsage{UserID: userID, ObjectKey: objectKey, Kind: kind, Bytes: size}
+	if err := s.storageUsageRepo.Upsert(usage); err != nil {
+		log.Printf("Storage quota: failed to record %s for user %s: %v", objectKey, userID, err)
+	}
+}
+
+// Release stops counting an object the user no longer stores
+func (s *StorageQuotaService) Release(userID uuid.UUID, objectKey string) error {
+	return s.storageUsageRepo.Delete(userID, objectKey)
+}
+
+// exceedsQuota reports whether storing size bytes, in place of replaced bytes at the same key, goes over limit
+func exceedsQuota(used, replaced, size, limit int64) bool {
+	if limit <= 0 {
+		return false
+	}
+	return used-replaced+size > limit
+}
+
+// FormatBytes renders a size for people, e.g. "4.2 MB"
+func FormatBytes(bytes int64) string {
+	const unit = 1024
+	if bytes < unit {
+		return fmt.Sprintf("%d B", bytes)
+	}
+	div, exp := int64(unit), 0
+	for n := bytes / unit; n >= unit; n /= unit {
+		div *= unit
+		exp++
+	}
+	return fmt.Sprintf("%.1f %cB", float64(bytes)/float64(div), "KMGTPE"[exp])
+}
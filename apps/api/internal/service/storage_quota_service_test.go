@@ -0,0 +1,25 @@
+package service
+
+import "testing"
+
+func TestExceedsQuota(t *testing.T) {
+	const mb = 1 << 20
+	tests := []struct {
+		name                        string
+		used, replaced, size, limit int64
+		want                        bool
+	}{
+		{"fits", 50 * mb, 0, 10 * mb, 100 * mb, false},
+		{"exactly at the limit", 90 * mb, 0, 10 * mb, 100 * mb, false},
+		{"over the limit", 95 * mb, 0, 10 * mb, 100 * mb, true},
+		{"overwrite only counts the difference", 98 * mb, 5 * mb, 6 * mb, 100 * mb, false},
+		{"unlimited", 500 * mb, 0, 10 * mb, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := exceedsQuota(tt.used, tt.replaced, tt.size, tt.limit); got != tt.want {
+				t.Errorf("exceedsQuota(%d, %d, %d, %d) = %v, want %v", tt.used, tt.replaced, tt.size, tt.limit, got, tt.want)
+			}
+		})
+	}
+}
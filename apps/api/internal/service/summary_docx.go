@@ -0,0 +1,141 @@
+package service
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// docxBold matches the **bold** spans of a summary line
+var docxBold = regexp.MustCompile(`\*\*([^*]+)\*\*`)
+
+// docxHeading matches a heading line and its level
+var docxHeading = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+
+// docxListItem matches a bullet, the only list the summary prompt asks for, with an optional task box
+var docxListItem = regexp.MustCompile(`^\s*[-*+]\s+(\[[ xX]\]\s+)?`)
+
+const docxContentTypes = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">
+<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>
+<Default Extension="xml" ContentType="application/xml"/>
+<Override PartName="/word/document.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.document.main+xml"/>
+<Override PartName="/word/styles.xml" ContentType="application/vnd.openxmlformats-officedocument.wordprocessingml.styles+xml"/>
+</Types>`
+
+const docxRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="word/document.xml"/>
+</Relationships>`
+
+const docxDocumentRels = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">
+<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/styles" Target="styles.xml"/>
+</Relationships>`
+
+const docxStyles = `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:styles xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main">
+<w:style w:type="paragraph" w:default="1" w:styleId="Normal"><w:name w:val="Normal"/><w:pPr><w:spacing w:after="120"/></w:pPr><w:rPr><w:sz w:val="22"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading1"><w:name w:val="heading 1"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:pPr><w:keepNext/><w:spacing w:before="240"/><w:outlineLvl w:val="0"/></w:pPr><w:rPr><w:b/><w:sz w:val="36"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading2"><w:name w:val="heading 2"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:pPr><w:keepNext/><w:spacing w:before="240"/><w:outlineLvl w:val="1"/></w:pPr><w:rPr><w:b/><w:sz w:val="28"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="Heading3"><w:name w:val="heading 3"/><w:basedOn w:val="Normal"/><w:next w:val="Normal"/><w:pPr><w:keepNext/><w:outlineLvl w:val="2"/></w:pPr><w:rPr><w:b/><w:sz w:val="24"/></w:rPr></w:style>
+<w:style w:type="paragraph" w:styleId="ListBullet"><w:name w:val="List Bullet"/><w:basedOn w:val="Normal"/><w:pPr><w:spacing w:after="60"/><w:ind w:left="360" w:hanging="360"/></w:pPr></w:style>
+</w:styles>`
+
+// SummaryDocx renders a summary's markdown as a Word document: headings keep their level, bullets and task
+// boxes become list paragraphs and **bold** stays bold. Other markdown is kept as text
+func SummaryDocx(markdown string) ([]byte, error) {
+	var body strings.Builder
+	for _, line := range strings.Split(strings.ReplaceAll(markdown, "\r\n", "\n"), "\n") {
+		trimmed := strings.TrimSpace(line)
+		switch {
+		case trimmed == "", strings.Trim(trimmed, "-*_") == "":
+			// blank lines and rules only separate blocks, which paragraph spacing already does
+			continue
+		case docxHeading.MatchString(trimmed):
+			match := docxHeading.FindStringSubmatch(trimmed)
+			writeDocxParagraph(&body, fmt.Sprintf("Heading%d", min(len(match[1]), 3)), "", match[2])
+		case docxListItem.MatchString(line):
+			match := docxListItem.FindStringSubmatch(line)
+			prefix := "•\t"
+			if box := strings.TrimSpace(match[1]); box != "" {
+				prefix = "☐\t"
+				if box != "[ ]" {
+					prefix = "☑\t"
+				}
+			}
+			writeDocxParagraph(&body, "ListBullet", prefix, line[len(match[0]):])
+		default:
+			writeDocxParagraph(&body, "", "", trimmed)
+		}
+	}
+
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	parts := []struct{ name, content string }{
+		{"[Content_Types].xml", docxContentTypes},
+		{"_rels/.rels", docxRels},
+		{"word/_rels/document.xml.rels", docxDocumentRels},
+		{"word/styles.xml", docxStyles},
+		{"word/document.xml", `<?xml version="1.0" encoding="UTF-8" standalone="yes"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>` + body.String() + `</w:body></w:document>`},
+	}
+	for _, part := range parts {
+		w, err := zw.Create(part.name)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := w.Write([]byte(part.content)); err != nil {
+			return nil, err
+		}
+	}
+	if err := zw.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// writeDocxParagraph writes a paragraph of the style, empty meaning Normal, splitting the text into runs at its
+// **bold** spans
+func writeDocxParagraph(body *strings.Builder, style string, prefix string, text string) {
+	body.WriteString("<w:p>")
+	if style != "" {
+		body.WriteString(`<w:pPr><w:pStyle w:val="` + style + `"/></w:pPr>`)
+	}
+	if prefix != "" {
+		writeDocxRun(body, prefix, false)
+	}
+	last := 0
+	for _, span := range docxBold.FindAllStringSubmatchIndex(text, -1) {
+		writeDocxRun(body, text[last:span[0]], false)
+		writeDocxRun(body, text[span[2]:span[3]], true)
+		last = span[1]
+	}
+	writeDocxRun(body, text[last:], false)
+	body.WriteString("</w:p>")
+}
+
+func writeDocxRun(body *strings.Builder, text string, bold bool) {
+	if text == "" {
+		return
+	}
+	body.WriteString("<w:r>")
+	if bold {
+		body.WriteString("<w:rPr><w:b/></w:rPr>")
+	}
+	for i, segment := range strings.Split(text, "\t") {
+		if i > 0 {
+			body.WriteString("<w:tab/>")
+		}
+		if segment == "" {
+			continue
+		}
+		body.WriteString(`<w:t xml:space="preserve">`)
+		_ = xml.EscapeText(body, []byte(segment))
+		body.WriteString("</w:t>")
+	}
+	body.WriteString("</w:r>")
+}
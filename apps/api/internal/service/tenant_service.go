@@ -0,0 +1,202 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	llmHandlers "melina-studio-backend/internal/llm_handlers"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/datatypes"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidTenant    = errors.New("invalid tenant")
+	ErrTenantNotFound   = errors.New("tenant not found")
+	ErrModelNotAllowed  = errors.New("model is not allowed for this tenant")
+	ErrModelNotInRegion = errors.New("model is not available in this tenant's data region")
+)
+
+// tenantCacheTTL bounds how long a tenant change takes to reach every resolved request
+const tenantCacheTTL = time.Minute
+
+var tenantSlugPattern = regexp.MustCompile(`^[a-z0-9]([a-z0-9-]{0,61}[a-z0-9])?$`)
+
+type cachedTenant struct {
+	tenant    *models.Tenant
+	expiresAt time.Time
+}
+
+type TenantService struct {
+	tenantRepo repo.TenantRepoInterface
+	authRepo   repo.AuthRepoInterface
+	mu         sync.Mutex
+	cache      map[string]cachedTenant
+}
+
+func NewTenantService(tenantRepo repo.TenantRepoInterface, authRepo repo.AuthRepoInterface) *TenantService {
+	return &TenantService{
+		tenantRepo: tenantRepo,
+		authRepo:   authRepo,
+		cache:      make(map[string]cachedTenant),
+	}
+}
+
+// TenantUpdate holds the tenant fields to change; nil fields are left untouched
+type TenantUpdate struct {
+	Name          *string   `json:"name"`
+	AllowedModels *[]string `json:"allowed_models"`
+	StorageBucket *string   `json:"storage_bucket"`
+	PrivacyMode   *bool     `json:"privacy_mode"`
+	DataRegion    *string   `json:"data_region"`
+	IsActive      *bool     `json:"is_active"`
+}
+
+// Resolve returns the tenant for a slug, cached briefly since it runs on every request
+func (s *TenantService) Resolve(slug string) (*models.Tenant, error) {
+	s.mu.Lock()
+	entry, ok := s.cache[slug]
+	s.mu.Unlock()
+	if ok && time.Now().Before(entry.expiresAt) {
+		return entry.tenant, nil
+	}
+
+	tenant, err := s.tenantRepo.GetBySlug(slug)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTenantNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	s.cache[slug] = cachedTenant{tenant: tenant, expiresAt: time.Now().Add(tenantCacheTTL)}
+	s.mu.Unlock()
+	return tenant, nil
+}
+
+// Create validates and stores a new tenant
+func (s *TenantService) Create(slug string, name string, allowedModels []string, storageBucket string) (*models.Tenant, error) {
+	slug = strings.ToLower(strings.TrimSpace(slug))
+	if !tenantSlugPattern.MatchString(slug) {
+		return nil, fmt.Errorf("%w: slug must be a valid subdomain label", ErrInvalidTenant)
+	}
+	if strings.TrimSpace(name) == "" {
+		name = slug
+	}
+	tenant := &models.Tenant{Slug: slug, Name: strings.TrimSpace(name), StorageBucket: strings.TrimSpace(storageBucket), IsActive: true}
+	if err := setAllowedModels(tenant, allowedModels); err != nil {
+		return nil, err
+	}
+	if err := s.tenantRepo.Create(tenant); err != nil {
+		return nil, err
+	}
+	return tenant, nil
+}
+
+// Update applies changes to a tenant and drops it from the resolve cache
+func (s *TenantService) Update(id uuid.UUID, update TenantUpdate) (*models.Tenant, error) {
+	tenant, err := s.tenantRepo.GetByID(id)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTenantNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if update.Name != nil && strings.TrimSpace(*update.Name) != "" {
+		tenant.Name = strings.TrimSpace(*update.Name)
+	}
+	if update.AllowedModels != nil {
+		if err := setAllowedModels(tenant, *update.AllowedModels); err != nil {
+			return nil, err
+		}
+	}
+	if update.StorageBucket != nil {
+		tenant.StorageBucket = strings.TrimSpace(*update.StorageBucket)
+	}
+	if update.PrivacyMode != nil {
+		tenant.PrivacyMode = *update.PrivacyMode
+	}
+	if update.DataRegion != nil {
+		region, err := llmHandlers.ParseDataRegion(*update.DataRegion)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidTenant, err)
+		}
+		tenant.DataRegion = string(region)
+	}
+	if update.IsActive != nil {
+		tenant.IsActive = *update.IsActive
+	}
+	if err := s.tenantRepo.Update(tenant); err != nil {
+		return nil, err
+	}
+
+	s.mu.Lock()
+	delete(s.cache, tenant.Slug)
+	s.mu.Unlock()
+	return tenant, nil
+}
+
+func (s *TenantService) List() ([]models.Tenant, error) {
+	return s.tenantRepo.List()
+}
+
+// CheckUserModel verifies the user's tenant may use the model; users of the default tenant may use any
+func (s *TenantService) CheckUserModel(userID uuid.UUID, modelName string) error {
+	user, err := s.authRepo.GetUserByID(userID)
+	if err != nil {
+		return err
+	}
+	if user.TenantID == nil {
+		return nil
+	}
+	tenant, err := s.tenantRepo.GetByID(*user.TenantID)
+	if err != nil {
+		return err
+	}
+	return CheckTenantModel(tenant, modelName)
+}
+
+// CheckTenantModel verifies the tenant may use the model and that the model can keep requests in the
+// tenant's data region; an empty model name means the default model
+func CheckTenantModel(tenant *models.Tenant, modelName string) error {
+	if tenant == nil {
+		return nil
+	}
+	if modelName == "" {
+		modelName = llmHandlers.DefaultModel()
+	}
+	if !tenant.AllowsModel(modelName) {
+		return fmt.Errorf("%w: %s", ErrModelNotAllowed, modelName)
+	}
+	if !llmHandlers.ModelServesRegion(modelName, llmHandlers.DataRegion(tenant.DataRegion)) {
+		return fmt.Errorf("%w: %s", ErrModelNotInRegion, modelName)
+	}
+	return nil
+}
+
+func setAllowedModels(tenant *models.Tenant, allowedModels []string) error {
+	if len(allowedModels) == 0 {
+		tenant.AllowedModels = nil
+		return nil
+	}
+	for _, name := range allowedModels {
+		if _, err := llmHandlers.ValidateModel(name); err != nil {
+			return fmt.Errorf("%w: unknown model %q", ErrInvalidTenant, name)
+		}
+	}
+	encoded, err := json.Marshal(allowedModels)
+	if err != nil {
+		return err
+	}
+	tenant.AllowedModels = datatypes.JSON(encoded)
+	return nil
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"errors"
+	llmHandlers "melina-studio-backend/internal/llm_handlers"
+	"melina-studio-backend/internal/models"
+	"testing"
+)
+
+func TestCheckTenantModel(t *testing.T) {
+	if err := CheckTenantModel(nil, "claude-4-opus"); err != nil {
+		t.Errorf("default tenant should allow every model, got %v", err)
+	}
+
+	unrestricted := &models.Tenant{}
+	if err := CheckTenantModel(unrestricted, "claude-4-opus"); err != nil {
+		t.Errorf("tenant without allowed models should allow every model, got %v", err)
+	}
+
+	restricted := &models.Tenant{}
+	if err := setAllowedModels(restricted, []string{"claude-4.5-sonnet", llmHandlers.DefaultModelName}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if err := CheckTenantModel(restricted, "claude-4.5-sonnet"); err != nil {
+		t.Errorf("expected allowed model, got %v", err)
+	}
+	if err := CheckTenantModel(restricted, ""); err != nil {
+		t.Errorf("empty model name should resolve to the default model, got %v", err)
+	}
+	if err := CheckTenantModel(restricted, "claude-4-opus"); !errors.Is(err, ErrModelNotAllowed) {
+		t.Errorf("expected ErrModelNotAllowed, got %v", err)
+	}
+}
+
+func TestSetAllowedModelsRejectsUnknownModel(t *testing.T) {
+	tenant := &models.Tenant{}
+	if err := setAllowedModels(tenant, []string{"not-a-model"}); !errors.Is(err, ErrInvalidTenant) {
+		t.Errorf("expected ErrInvalidTenant, got %v", err)
+	}
+	if err := setAllowedModels(tenant, nil); err != nil || tenant.AllowedModels != nil {
+		t.Errorf("expected empty allow-list to clear the restriction, got %v", err)
+	}
+}
+
+func TestCheckTenantModelDataRegion(t *testing.T) {
+	tenant := &models.Tenant{DataRegion: string(llmHandlers.DataRegionEU)}
+	if err := CheckTenantModel(tenant, "gpt-4.1"); !errors.Is(err, ErrModelNotInRegion) {
+		t.Errorf("expected ErrModelNotInRegion, got %v", err)
+	}
+	if err := CheckTenantModel(tenant, "claude-4.5-sonnet"); err != nil {
+		t.Errorf("claude on vertex has an EU location, got %v", err)
+	}
+}
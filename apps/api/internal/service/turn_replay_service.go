@@ -0,0 +1,212 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+
+	"melina-studio-backend/internal/libraries"
+	llmHandlers "melina-studio-backend/internal/llm_handlers"
+	"melina-studio-backend/internal/melina/agents"
+	"melina-studio-backend/internal/melina/prompts"
+	"melina-studio-backend/internal/melina/tools"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	// replayHistorySize matches the history the chat workflow sends with a turn
+	replayHistorySize = 20
+	replayTimeout     = 5 * time.Minute
+)
+
+var ErrTurnNotFound = errors.New("no turn metadata for this message")
+
+type TurnReplayService struct {
+	turnRepo      repo.TurnMetadataRepoInterface
+	chatRepo      repo.ChatRepoInterface
+	boardRepo     repo.BoardRepoInterface
+	boardDataRepo repo.BoardDataRepoInterface
+	sandboxTTL    time.Duration
+}
+
+func NewTurnReplayService(
+	turnRepo repo.TurnMetadataRepoInterface,
+	chatRepo repo.ChatRepoInterface,
+	boardRepo repo.BoardRepoInterface,
+	boardDataRepo repo.BoardDataRepoInterface,
+	sandboxTTL time.Duration,
+) *TurnReplayService {
+	return &TurnReplayService{
+		turnRepo:      turnRepo,
+		chatRepo:      chatRepo,
+		boardRepo:     boardRepo,
+		boardDataRepo: boardDataRepo,
+		sandboxTTL:    sandboxTTL,
+	}
+}
+
+// TurnReplay compares a replayed turn with the reply the user got
+type TurnReplay struct {
+	Metadata       *models.TurnMetadata `json:"metadata"`
+	SandboxBoardID string               `json:"sandbox_board_id,omitempty"` // only when the board was kept
+	Original       string               `json:"original"`
+	Reply          string               `json:"reply"`
+	Identical      bool                 `json:"identical"`
+	ToolCalls      []string             `json:"tool_calls"`
+	Shapes         int                  `json:"shapes"`
+	Tokens         int                  `json:"tokens"`
+	// Drift lists what differs from the original turn and can explain a different reply
+	Drift []string `json:"drift,omitempty"`
+}
+
+// Replay runs an AI message's turn again on a sandbox copy of the board as it was when the turn started,
+// with the recorded model, prompt inputs, temperature and seed. The sandbox is owned by the message's user
+// and deleted afterwards unless keepBoard is set, when it lives until it expires; replays are not charged to the user
+func (s *TurnReplayService) Replay(ctx context.Context, messageID uuid.UUID, keepBoard bool) (*TurnReplay, error) {
+	metadata, err := s.turnRepo.GetByChatID(messageID)
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, ErrTurnNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	human, err := s.chatRepo.GetChatByID(metadata.BoardID, metadata.HumanMessageID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the turn's message: %w", err)
+	}
+	original, err := s.chatRepo.GetChatByID(metadata.BoardID, metadata.ChatID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the turn's reply: %w", err)
+	}
+	history, err := s.chatRepo.GetChatHistoryBefore(metadata.BoardID, human.CreatedAt, replayHistorySize)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the turn's history: %w", err)
+	}
+
+	modelInfo, err := llmHandlers.ValidateModel(metadata.ModelName)
+	if err != nil {
+		return nil, err
+	}
+	profile, err := llmHandlers.GetAgentProfile(metadata.AgentProfile)
+	if err != nil {
+		profile, _ = llmHandlers.GetAgentProfile(llmHandlers.DefaultAgentProfile)
+	}
+	agent, err := agents.NewToolAgent(modelInfo, metadata.Temperature, metadata.MaxTokens, profile)
+	if err != nil {
+		return nil, err
+	}
+
+	sandboxID, err := s.boardRepo.CreateSandboxBoard(&models.Board{Title: "Replay of " + messageID.String(), UserID: metadata.UserID}, s.sandboxTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create sandbox board: %w", err)
+	}
+	if !keepBoard {
+		defer s.cleanup(sandboxID)
+	}
+	seeded, err := s.seedSandbox(sandboxID, metadata.BoardSnapshot)
+	if err != nil {
+		return nil, err
+	}
+
+	var canvasStateXML string
+	if len(seeded) > 0 {
+		if state := tools.GenerateCanvasState(seeded, 50.0, 100.0); state != nil {
+			canvasStateXML = tools.FormatCanvasStateXML(state)
+		}
+	}
+
+	turnCtx, cancel := context.WithTimeout(ctx, replayTimeout)
+	defer cancel()
+	turnCtx, activity := llmHandlers.WithToolActivity(turnCtx)
+	turnCtx = WithLLMPrivacy(turnCtx, metadata.UserID, llmHandlers.DataClassBoard, llmHandlers.DataClassChat)
+	if metadata.Seed != nil {
+		turnCtx = llmHandlers.WithSeed(turnCtx, *metadata.Seed)
+	}
+
+	// nobody watches the replay stream, the relay client just drains it
+	relay, stop := libraries.NewRelayClient(metadata.UserID.String(), func(string) {})
+	resp, err := agent.ProcessRequestStreamWithUsage(turnCtx, libraries.NewHub(), relay, human.Content, history, sandboxID.String(), metadata.Theme,
+		nil, nil, metadata.Thinking, canvasStateXML, metadata.CustomRules, metadata.FacilitationContext, metadata.ReferenceContext)
+	stop()
+	if err != nil {
+		return nil, fmt.Errorf("replay failed: %w", err)
+	}
+
+	stored, err := s.boardDataRepo.GetBoardData(sandboxID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read sandbox board: %w", err)
+	}
+	replay := &TurnReplay{
+		Metadata:  metadata,
+		Original:  original.Content,
+		Reply:     resp.Text,
+		Identical: resp.Text == original.Content,
+		Shapes:    len(tools.SnapshotShapes(stored, activity.CreatedShapes())),
+		Drift:     turnDrift(metadata, modelInfo.Provider),
+	}
+	for _, call := range activity.Results() {
+		replay.ToolCalls = append(replay.ToolCalls, call.ToolName)
+	}
+	if resp.TokenUsage != nil {
+		replay.Tokens = resp.TokenUsage.TotalTokens
+	}
+	if keepBoard {
+		replay.SandboxBoardID = sandboxID.String()
+	}
+	return replay, nil
+}
+
+// seedSandbox copies the snapshot's shapes to the sandbox board under new ids, since shape ids are global
+func (s *TurnReplayService) seedSandbox(sandboxID uuid.UUID, snapshot []byte) ([]models.BoardData, error) {
+	var shapes []models.BoardData
+	if len(snapshot) > 0 {
+		if err := json.Unmarshal(snapshot, &shapes); err != nil {
+			return nil, fmt.Errorf("failed to read board snapshot: %w", err)
+		}
+	}
+	for i := range shapes {
+		shapes[i].UUID = uuid.New()
+		shapes[i].BoardId = sandboxID
+		if err := s.boardDataRepo.CreateBoardData(&shapes[i]); err != nil {
+			return nil, fmt.Errorf("failed to seed sandbox shape %d: %w", i+1, err)
+		}
+	}
+	return shapes, nil
+}
+
+// cleanup removes the sandbox board with its shapes
+func (s *TurnReplayService) cleanup(boardID uuid.UUID) {
+	if err := s.boardRepo.PurgeBoard(boardID); err != nil {
+		log.Printf("[replay] failed to delete sandbox board %s: %v", boardID, err)
+	}
+}
+
+// turnDrift lists what a replay can't reproduce of the recorded turn
+func turnDrift(metadata *models.TurnMetadata, provider llmHandlers.Provider) []string {
+	var drift []string
+	if current := prompts.MasterPromptVersion(); metadata.PromptVersion != current {
+		drift = append(drift, fmt.Sprintf("system prompt changed since the turn (%s, now %s)", metadata.PromptVersion, current))
+	}
+	if current := tools.SchemaVersion(); metadata.ToolsVersion != current {
+		drift = append(drift, fmt.Sprintf("tool schemas changed since the turn (%s, now %s)", metadata.ToolsVersion, current))
+	}
+	if metadata.Seed == nil || !llmHandlers.SeedSupported(provider) {
+		drift = append(drift, "the provider takes no seed, so sampling is not repeatable")
+	}
+	if metadata.Temperature == nil {
+		drift = append(drift, "the turn ran at the provider's default temperature")
+	} else if *metadata.Temperature != 0 {
+		drift = append(drift, fmt.Sprintf("the turn ran at temperature %.2f", *metadata.Temperature))
+	}
+	if metadata.Selections > 0 {
+		drift = append(drift, fmt.Sprintf("the turn's %d selected or uploaded images are not kept and were left out", metadata.Selections))
+	}
+	return drift
+}
@@ -0,0 +1,37 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	llmHandlers "melina-studio-backend/internal/llm_handlers"
+	"melina-studio-backend/internal/melina/prompts"
+	"melina-studio-backend/internal/melina/tools"
+	"melina-studio-backend/internal/models"
+)
+
+func TestTurnDriftOfAReproducibleTurn(t *testing.T) {
+	seed, temperature := int64(42), float32(0)
+	metadata := &models.TurnMetadata{
+		PromptVersion: prompts.MasterPromptVersion(),
+		ToolsVersion:  tools.SchemaVersion(),
+		Seed:          &seed,
+		Temperature:   &temperature,
+	}
+	if drift := turnDrift(metadata, llmHandlers.ProviderGemini); len(drift) != 0 {
+		t.Fatalf("drift = %v", drift)
+	}
+}
+
+func TestTurnDriftListsWhatCantBeReplayed(t *testing.T) {
+	metadata := &models.TurnMetadata{PromptVersion: "old", ToolsVersion: tools.SchemaVersion(), Selections: 2}
+	drift := strings.Join(turnDrift(metadata, llmHandlers.ProviderVertexAnthropic), "\n")
+	for _, want := range []string{"system prompt changed", "takes no seed", "default temperature", "2 selected or uploaded images"} {
+		if !strings.Contains(drift, want) {
+			t.Errorf("drift is missing %q:\n%s", want, drift)
+		}
+	}
+	if strings.Contains(drift, "tool schemas") {
+		t.Errorf("unexpected tool drift:\n%s", drift)
+	}
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+var (
+	ErrInvalidUploadCommit = errors.New("invalid upload commit")
+	ErrUploadNotFound      = errors.New("upload not found")
+	ErrUploadCommitted     = errors.New("upload already committed")
+)
+
+// UploadCommitService promotes temp uploads to permanent board assets
+type UploadCommitService struct {
+	tempUploadRepo repo.TempUploadRepoInterface
+	chatRepo       repo.ChatRepoInterface
+	boardDataRepo  repo.BoardDataRepoInterface
+}
+
+func NewUploadCommitService(tempUploadRepo repo.TempUploadRepoInterface, chatRepo repo.ChatRepoInterface, boardDataRepo repo.BoardDataRepoInterface) *UploadCommitService {
+	return &UploadCommitService{
+		tempUploadRepo: tempUploadRepo,
+		chatRepo:       chatRepo,
+		boardDataRepo:  boardDataRepo,
+	}
+}
+
+// UploadCommitInput names the chat message or the shape the upload belongs to; exactly one is set
+type UploadCommitInput struct {
+	MessageID *uuid.UUID `json:"message_id"`
+	ShapeID   *uuid.UUID `json:"shape_id"`
+}
+
+// validateUploadCommit checks that the input names exactly one target
+func validateUploadCommit(input UploadCommitInput) error {
+	if (input.MessageID == nil) == (input.ShapeID == nil) {
+		return fmt.Errorf("%w: exactly one of message_id and shape_id is required", ErrInvalidUploadCommit)
+	}
+	if input.MessageID != nil && *input.MessageID == uuid.Nil || input.ShapeID != nil && *input.ShapeID == uuid.Nil {
+		return fmt.Errorf("%w: the target id must not be empty", ErrInvalidUploadCommit)
+	}
+	return nil
+}
+
+// sameCommitTarget reports whether a committed upload is already bound to the input's target
+func sameCommitTarget(upload *models.TempUpload, input UploadCommitInput) bool {
+	if input.MessageID != nil {
+		return upload.MessageID != nil && *upload.MessageID == *input.MessageID
+	}
+	return upload.ShapeID != nil && *upload.ShapeID == *input.ShapeID
+}
+
+// Commit binds the user's upload to a message or shape on the upload's board, so cleanup keeps it
+// Committing again to the same target is a no-op; committing to another target is ErrUploadCommitted
+func (s *UploadCommitService) Commit(userID uuid.UUID, uploadID uuid.UUID, input UploadCommitInput) (*models.TempUpload, error) {
+	if err := validateUploadCommit(input); err != nil {
+		return nil, err
+	}
+
+	upload, err := s.tempUploadRepo.GetByID(uploadID)
+	if err != nil {
+		return nil, err
+	}
+	// someone else's upload is reported as missing, like any other resource the user doesn't own
+	if upload == nil || upload.UserID != userID {
+		return nil, ErrUploadNotFound
+	}
+	if upload.CommittedAt != nil {
+		if sameCommitTarget(upload, input) {
+			return upload, nil
+		}
+		return nil, ErrUploadCommitted
+	}
+
+	if err := s.checkCommitTarget(upload.BoardID, input); err != nil {
+		return nil, err
+	}
+
+	committed, err := s.tempUploadRepo.Commit(uploadID, input.MessageID, input.ShapeID)
+	if err != nil {
+		return nil, err
+	}
+	if !committed {
+		// a concurrent commit got there first, or cleanup removed the upload meanwhile
+		return s.recheckCommit(uploadID, input)
+	}
+	return s.tempUploadRepo.GetByID(uploadID)
+}
+
+// checkCommitTarget makes sure the message or shape is on the upload's board
+func (s *UploadCommitService) checkCommitTarget(boardID uuid.UUID, input UploadCommitInput) error {
+	if input.MessageID != nil {
+		_, err := s.chatRepo.GetChatByID(boardID, *input.MessageID)
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%w: message is not on the upload's board", ErrInvalidUploadCommit)
+		}
+		return err
+	}
+	shape, err := s.boardDataRepo.GetShapeByUUID(*input.ShapeID)
+	if errors.Is(err, gorm.ErrRecordNotFound) || err == nil && shape.BoardId != boardID {
+		return fmt.Errorf("%w: shape is not on the upload's board", ErrInvalidUploadCommit)
+	}
+	return err
+}
+
+func (s *UploadCommitService) recheckCommit(uploadID uuid.UUID, input UploadCommitInput) (*models.TempUpload, error) {
+	upload, err := s.tempUploadRepo.GetByID(uploadID)
+	if err != nil {
+		return nil, err
+	}
+	if upload == nil {
+		return nil, ErrUploadNotFound
+	}
+	if !sameCommitTarget(upload, input) {
+		return nil, ErrUploadCommitted
+	}
+	return upload, nil
+}
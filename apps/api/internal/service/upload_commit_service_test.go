@@ -0,0 +1,102 @@
+package service
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"melina-studio-backend/internal/models"
+	"melina-studio-backend/internal/repo"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+type fakeTempUploadRepo struct {
+	repo.TempUploadRepoInterface
+	uploads map[uuid.UUID]*models.TempUpload
+}
+
+func (f *fakeTempUploadRepo) GetByID(id uuid.UUID) (*models.TempUpload, error) {
+	upload, ok := f.uploads[id]
+	if !ok {
+		return nil, nil
+	}
+	copied := *upload
+	return &copied, nil
+}
+
+func (f *fakeTempUploadRepo) Commit(id uuid.UUID, messageID *uuid.UUID, shapeID *uuid.UUID) (bool, error) {
+	upload, ok := f.uploads[id]
+	if !ok || upload.CommittedAt != nil {
+		return false, nil
+	}
+	now := time.Now()
+	upload.CommittedAt, upload.MessageID, upload.ShapeID = &now, messageID, shapeID
+	return true, nil
+}
+
+type fakeCommitShapeRepo struct {
+	repo.BoardDataRepoInterface
+	shapes map[uuid.UUID]*models.BoardData
+}
+
+func (f *fakeCommitShapeRepo) GetShapeByUUID(shapeUUID uuid.UUID) (*models.BoardData, error) {
+	shape, ok := f.shapes[shapeUUID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return shape, nil
+}
+
+func TestValidateUploadCommit(t *testing.T) {
+	id := uuid.New()
+	for name, input := range map[string]UploadCommitInput{
+		"no target":   {},
+		"two targets": {MessageID: &id, ShapeID: &id},
+		"nil shape":   {ShapeID: &uuid.Nil},
+	} {
+		if err := validateUploadCommit(input); !errors.Is(err, ErrInvalidUploadCommit) {
+			t.Errorf("%s: got %v, want ErrInvalidUploadCommit", name, err)
+		}
+	}
+	if err := validateUploadCommit(UploadCommitInput{MessageID: &id}); err != nil {
+		t.Errorf("message target: got %v", err)
+	}
+}
+
+func TestUploadCommit(t *testing.T) {
+	userID, boardID := uuid.New(), uuid.New()
+	uploadID, shapeID, otherBoardShape := uuid.New(), uuid.New(), uuid.New()
+	uploads := &fakeTempUploadRepo{uploads: map[uuid.UUID]*models.TempUpload{
+		uploadID: {UUID: uploadID, BoardID: boardID, UserID: userID},
+	}}
+	shapes := &fakeCommitShapeRepo{shapes: map[uuid.UUID]*models.BoardData{
+		shapeID:         {UUID: shapeID, BoardId: boardID},
+		otherBoardShape: {UUID: otherBoardShape, BoardId: uuid.New()},
+	}}
+	s := NewUploadCommitService(uploads, nil, shapes)
+
+	if _, err := s.Commit(uuid.New(), uploadID, UploadCommitInput{ShapeID: &shapeID}); !errors.Is(err, ErrUploadNotFound) {
+		t.Fatalf("another user's upload: got %v, want ErrUploadNotFound", err)
+	}
+	if _, err := s.Commit(userID, uploadID, UploadCommitInput{ShapeID: &otherBoardShape}); !errors.Is(err, ErrInvalidUploadCommit) {
+		t.Fatalf("shape on another board: got %v, want ErrInvalidUploadCommit", err)
+	}
+
+	upload, err := s.Commit(userID, uploadID, UploadCommitInput{ShapeID: &shapeID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if upload.CommittedAt == nil || upload.ShapeID == nil || *upload.ShapeID != shapeID {
+		t.Fatalf("upload not committed to the shape: %+v", upload)
+	}
+
+	if _, err := s.Commit(userID, uploadID, UploadCommitInput{ShapeID: &shapeID}); err != nil {
+		t.Fatalf("committing to the same shape again: got %v", err)
+	}
+	messageID := uuid.New()
+	if _, err := s.Commit(userID, uploadID, UploadCommitInput{MessageID: &messageID}); !errors.Is(err, ErrUploadCommitted) {
+		t.Fatalf("committing to another target: got %v, want ErrUploadCommitted", err)
+	}
+}
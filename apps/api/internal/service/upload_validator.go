@@ -0,0 +1,98 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"melina-studio-backend/internal/config"
+	"melina-studio-backend/internal/libraries"
+	"melina-studio-backend/internal/repo"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrUploadTypeNotAllowed = errors.New("upload type not allowed")
+	ErrUploadTooLarge       = errors.New("upload too large")
+	ErrUploadScanFailed     = errors.New("upload could not be scanned")
+)
+
+// UploadValidator checks uploads before they are stored, and so before they become publicly reachable:
+// the type is sniffed from the content, the size is capped per plan and the bytes go through the
+// configured malware scanner
+type UploadValidator struct {
+	config     config.UploadConfig
+	scanner    libraries.UploadScanner
+	scannerErr error
+	authRepo   repo.AuthRepoInterface
+}
+
+func NewUploadValidator(cfg config.UploadConfig, authRepo repo.AuthRepoInterface) *UploadValidator {
+	scanner, err := libraries.NewUploadScanner(cfg)
+	if err != nil {
+		// uploads are refused (unless failing open) rather than silently left unscanned
+		log.Printf("Upload scanning is misconfigured: %v", err)
+	}
+	return &UploadValidator{config: cfg, scanner: scanner, scannerErr: err, authRepo: authRepo}
+}
+
+// Validate returns the sniffed content type of an upload by the user, which is what it should be stored as,
+// or ErrUploadTypeNotAllowed, ErrUploadTooLarge, libraries.ErrUploadInfected or ErrUploadScanFailed
+func (v *UploadValidator) Validate(ctx context.Context, userID uuid.UUID, data []byte) (string, error) {
+	contentType := SniffContentType(data)
+	if !slices.Contains(v.config.AllowedTypes, contentType) {
+		return "", fmt.Errorf("%w: %s files aren't accepted", ErrUploadTypeNotAllowed, contentType)
+	}
+
+	if limit := v.maxBytes(userID); limit > 0 && int64(len(data)) > limit {
+		return "", fmt.Errorf("%w: the file is %s and your plan allows up to %s", ErrUploadTooLarge, FormatBytes(int64(len(data))), FormatBytes(limit))
+	}
+
+	if err := v.scan(ctx, data); err != nil {
+		return "", err
+	}
+	return contentType, nil
+}
+
+// maxBytes returns the largest upload the user's plan allows, 0 when uncapped
+func (v *UploadValidator) maxBytes(userID uuid.UUID) int64 {
+	user, err := v.authRepo.GetUserByID(userID)
+	if err != nil {
+		log.Printf("Upload validation: failed to get user %s, using the free plan's cap: %v", userID, err)
+		return v.config.MaxUploadBytes("")
+	}
+	return v.config.MaxUploadBytes(user.Subscription)
+}
+
+func (v *UploadValidator) scan(ctx context.Context, data []byte) error {
+	err := v.scannerErr
+	if err == nil && v.scanner != nil {
+		err = v.scanner.Scan(ctx, data)
+	}
+	if err == nil || errors.Is(err, libraries.ErrUploadInfected) {
+		return err
+	}
+	if v.config.ScanFailOpen {
+		log.Printf("Upload scan failed, accepting the upload (UPLOAD_SCAN_FAIL_OPEN): %v", err)
+		return nil
+	}
+	return fmt.Errorf("%w: %v", ErrUploadScanFailed, err)
+}
+
+// SniffContentType identifies content by its leading bytes, ignoring the file name and declared type.
+// SVG, which sniffs as XML or text, is told apart by its root element
+func SniffContentType(data []byte) string {
+	contentType, _, _ := strings.Cut(http.DetectContentType(data), ";")
+	if contentType == "text/xml" || contentType == "text/plain" {
+		head := bytes.ToLower(data[:min(len(data), 1024)])
+		if bytes.Contains(head, []byte("<svg")) {
+			return "image/svg+xml"
+		}
+	}
+	return contentType
+}
@@ -0,0 +1,186 @@
+// Package pluginsdk is what third-party plugins build against to give the board agent extra tools and the
+// API extra routes. A plugin is a separate program the API starts at boot: it ships a plugin.yaml manifest
+// next to its executable and calls Serve from main. The API talks to it over gRPC on a unix socket, so a
+// plugin that crashes or hangs only fails its own tool calls and routes.
+package pluginsdk
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// APIVersion is the version of the plugin protocol this SDK speaks. The API refuses plugins built against a
+// version it doesn't support, so a breaking protocol change bumps it
+const APIVersion = 1
+
+// ManifestFile is the name of the manifest in a plugin's directory
+const ManifestFile = "plugin.yaml"
+
+// Scopes a plugin asks for in its manifest; the API only starts plugins whose scopes the operator granted
+const (
+	// ScopeTools lets the plugin give the agent tools
+	ScopeTools = "tools"
+	// ScopeRoutes lets the plugin serve REST routes under /api/v1/plugins/<name>, for signed-in users
+	ScopeRoutes = "routes"
+	// ScopeBoardRead sends the shapes of the chat's board with every tool call
+	ScopeBoardRead = "board:read"
+	// ScopeUserRead sends the calling user's ID with tool calls and route requests
+	ScopeUserRead = "user:read"
+)
+
+var knownScopes = map[string]bool{ScopeTools: true, ScopeRoutes: true, ScopeBoardRead: true, ScopeUserRead: true}
+
+// ErrInvalidManifest is returned for a manifest or description the API can't load
+var ErrInvalidManifest = errors.New("invalid plugin manifest")
+
+// pluginName keeps names usable in URLs, socket paths and environment variable names
+var pluginName = regexp.MustCompile(`^[a-z][a-z0-9-]{0,31}$`)
+
+// toolName is what the LLM providers accept as a function name
+var toolName = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_]{0,63}$`)
+
+// routePath is a path under the plugin's prefix, with optional :param segments
+var routePath = regexp.MustCompile(`^(/[A-Za-z0-9_.~:-]+)+/?$|^/$`)
+
+var routeMethods = map[string]bool{"GET": true, "POST": true, "PUT": true, "PATCH": true, "DELETE": true}
+
+// Manifest is a plugin's plugin.yaml: who it is, what it runs and what it may do
+type Manifest struct {
+	Name        string `yaml:"name" json:"name"`
+	Version     string `yaml:"version" json:"version"`
+	APIVersion  int    `yaml:"api_version" json:"api_version"`
+	Description string `yaml:"description" json:"description,omitempty"`
+	// Command is the executable, relative to the plugin's directory
+	Command string   `yaml:"command" json:"command"`
+	Args    []string `yaml:"args" json:"args,omitempty"`
+	Scopes  []string `yaml:"scopes" json:"scopes"`
+}
+
+// LoadManifest reads and validates the manifest in a plugin's directory
+func LoadManifest(dir string) (*Manifest, error) {
+	data, err := os.ReadFile(filepath.Join(dir, ManifestFile))
+	if err != nil {
+		return nil, err
+	}
+	manifest := &Manifest{}
+	if err := yaml.Unmarshal(data, manifest); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidManifest, err)
+	}
+	if err := manifest.Validate(); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// Validate checks the manifest's fields; whether its API version and scopes are accepted is up to the API
+func (m *Manifest) Validate() error {
+	if !pluginName.MatchString(m.Name) {
+		return fmt.Errorf("%w: name must be lowercase letters, digits and dashes, starting with a letter", ErrInvalidManifest)
+	}
+	if m.Version == "" {
+		return fmt.Errorf("%w: %s has no version", ErrInvalidManifest, m.Name)
+	}
+	if m.APIVersion <= 0 {
+		return fmt.Errorf("%w: %s has no api_version", ErrInvalidManifest, m.Name)
+	}
+	if m.Command == "" || filepath.IsAbs(m.Command) || strings.HasPrefix(filepath.Clean(m.Command), "..") {
+		return fmt.Errorf("%w: %s needs a command inside its directory", ErrInvalidManifest, m.Name)
+	}
+	for _, scope := range m.Scopes {
+		if !knownScopes[scope] {
+			return fmt.Errorf("%w: %s asks for unknown scope %q", ErrInvalidManifest, m.Name, scope)
+		}
+	}
+	return nil
+}
+
+// HasScope reports whether the manifest asks for the scope
+func (m *Manifest) HasScope(scope string) bool {
+	for _, s := range m.Scopes {
+		if s == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// EnvPrefix is the prefix of the API's environment variables passed on to the plugin, e.g. PLUGIN_JIRA_SYNC_
+// for jira-sync. The plugin sees no other variables of the API, so its secrets go there
+func (m *Manifest) EnvPrefix() string {
+	return "PLUGIN_" + strings.ToUpper(strings.ReplaceAll(m.Name, "-", "_")) + "_"
+}
+
+// Description is what a running plugin reports about itself: its identity and the tools and routes it serves
+type Description struct {
+	Name       string      `json:"name"`
+	Version    string      `json:"version"`
+	APIVersion int         `json:"api_version"`
+	Tools      []ToolSpec  `json:"tools,omitempty"`
+	Routes     []RouteSpec `json:"routes,omitempty"`
+}
+
+// ToolSpec is a tool the plugin gives the agent
+type ToolSpec struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+	// Parameters is the JSON schema of the tool's input, of type object
+	Parameters map[string]interface{} `json:"parameters"`
+	// RequiresApproval asks the user before each call, like deleteShape
+	RequiresApproval bool `json:"requires_approval,omitempty"`
+}
+
+// RouteSpec is a REST route the plugin serves, under /api/v1/plugins/<name>
+type RouteSpec struct {
+	Method string `json:"method"`
+	// Path may have :param segments, e.g. /issues/:key
+	Path        string `json:"path"`
+	Description string `json:"description,omitempty"`
+}
+
+// Validate checks that a running plugin is the one its manifest describes and only serves what its scopes allow
+func (d *Description) Validate(manifest *Manifest) error {
+	if d.Name != manifest.Name || d.Version != manifest.Version {
+		return fmt.Errorf("%w: the plugin reports %s %s, its manifest %s %s", ErrInvalidManifest, d.Name, d.Version, manifest.Name, manifest.Version)
+	}
+	if d.APIVersion != manifest.APIVersion {
+		return fmt.Errorf("%w: %s was built against API version %d, its manifest says %d", ErrInvalidManifest, d.Name, d.APIVersion, manifest.APIVersion)
+	}
+	if len(d.Tools) > 0 && !manifest.HasScope(ScopeTools) {
+		return fmt.Errorf("%w: %s serves tools without the %s scope", ErrInvalidManifest, d.Name, ScopeTools)
+	}
+	if len(d.Routes) > 0 && !manifest.HasScope(ScopeRoutes) {
+		return fmt.Errorf("%w: %s serves routes without the %s scope", ErrInvalidManifest, d.Name, ScopeRoutes)
+	}
+
+	seen := make(map[string]bool, len(d.Tools))
+	for _, tool := range d.Tools {
+		if !toolName.MatchString(tool.Name) {
+			return fmt.Errorf("%w: tool name %q must start with a letter and have at most 64 letters, digits and underscores", ErrInvalidManifest, tool.Name)
+		}
+		if seen[tool.Name] {
+			return fmt.Errorf("%w: tool %s is listed twice", ErrInvalidManifest, tool.Name)
+		}
+		seen[tool.Name] = true
+		if tool.Description == "" {
+			return fmt.Errorf("%w: tool %s has no description", ErrInvalidManifest, tool.Name)
+		}
+		if tool.Parameters["type"] != "object" {
+			return fmt.Errorf("%w: the parameters of %s must be a JSON schema of type object", ErrInvalidManifest, tool.Name)
+		}
+	}
+	for _, route := range d.Routes {
+		if !routeMethods[route.Method] {
+			return fmt.Errorf("%w: route method %q must be GET, POST, PUT, PATCH or DELETE", ErrInvalidManifest, route.Method)
+		}
+		if !routePath.MatchString(route.Path) {
+			return fmt.Errorf("%w: route path %q is not a valid path", ErrInvalidManifest, route.Path)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package pluginsdk
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"google.golang.org/grpc"
+)
+
+// Environment variables the API starts a plugin with
+const (
+	// EnvAddr is the unix socket the plugin listens on
+	EnvAddr = "MELINA_PLUGIN_ADDR"
+	// EnvAPIVersion is the protocol version of the API, for plugins that support several
+	EnvAPIVersion = "MELINA_PLUGIN_API_VERSION"
+)
+
+// MaxMessageBytes caps every request and reply between the API and a plugin
+const MaxMessageBytes = 4 << 20
+
+// Plugin is implemented by plugins and passed to Serve
+type Plugin interface {
+	// Describe reports the plugin's name and version, which must match its manifest, and what it serves.
+	// The API version is filled in by the SDK
+	Describe() Description
+	// CallTool runs one of the plugin's tools. The result is sent to the model as JSON; an error is shown
+	// to the model as the tool's failure
+	CallTool(ctx context.Context, call ToolCall) (interface{}, error)
+	// HandleRoute serves one of the plugin's routes
+	HandleRoute(ctx context.Context, req RouteRequest) (*RouteResponse, error)
+}
+
+// ToolCall is a call the agent makes to one of the plugin's tools
+type ToolCall struct {
+	Tool  string                 `json:"tool"`
+	Input map[string]interface{} `json:"input"`
+	// BoardID is the board of the chat, empty outside one
+	BoardID string `json:"board_id,omitempty"`
+	// UserID is set with the user:read scope
+	UserID string `json:"user_id,omitempty"`
+	// Shapes are the board's shapes, set with the board:read scope
+	Shapes []map[string]interface{} `json:"shapes,omitempty"`
+}
+
+// RouteRequest is a request to one of the plugin's routes
+type RouteRequest struct {
+	Method string `json:"method"`
+	// Route is the RouteSpec path the request matched and Params its :param values
+	Route  string            `json:"route"`
+	Params map[string]string `json:"params,omitempty"`
+	Query  map[string]string `json:"query,omitempty"`
+	// ContentType and Body are the request's; other headers, cookies and the user's token are not passed on
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+	// UserID is set with the user:read scope
+	UserID string `json:"user_id,omitempty"`
+}
+
+// RouteResponse is the reply to a RouteRequest
+type RouteResponse struct {
+	Status      int    `json:"status"`
+	ContentType string `json:"content_type,omitempty"`
+	Body        []byte `json:"body,omitempty"`
+}
+
+// JSONResponse returns a route reply with the value encoded as JSON
+func JSONResponse(status int, value interface{}) (*RouteResponse, error) {
+	body, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	return &RouteResponse{Status: status, ContentType: "application/json", Body: body}, nil
+}
+
+// Serve runs the plugin until the API stops it. Call it from main; it returns when the plugin was not
+// started by the API or can't listen
+func Serve(p Plugin) error {
+	addr := os.Getenv(EnvAddr)
+	if addr == "" {
+		return errors.New("pluginsdk: " + EnvAddr + " is not set, plugins are started by the Melina API")
+	}
+	_ = os.Remove(addr)
+	lis, err := net.Listen("unix", addr)
+	if err != nil {
+		return err
+	}
+
+	srv := newServer(p)
+	stop := make(chan os.Signal, 1)
+	signal.Notify(stop, syscall.SIGINT, syscall.SIGTERM)
+	go func() {
+		<-stop
+		srv.GracefulStop()
+	}()
+	return srv.Serve(lis)
+}
+
+// newServer returns a gRPC server serving the plugin
+func newServer(p Plugin) *grpc.Server {
+	srv := grpc.NewServer(grpc.MaxRecvMsgSize(MaxMessageBytes), grpc.MaxSendMsgSize(MaxMessageBytes))
+	srv.RegisterService(&serviceDesc, &pluginServer{plugin: p})
+	return srv
+}
@@ -0,0 +1,154 @@
+package pluginsdk
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func testManifest() *Manifest {
+	return &Manifest{Name: "jira-sync", Version: "1.0.0", APIVersion: APIVersion, Command: "bin/jira", Scopes: []string{ScopeTools, ScopeRoutes}}
+}
+
+func testDescription() Description {
+	return Description{
+		Name:       "jira-sync",
+		Version:    "1.0.0",
+		APIVersion: APIVersion,
+		Tools: []ToolSpec{{
+			Name:        "createJiraTicket",
+			Description: "Create a Jira ticket",
+			Parameters:  map[string]interface{}{"type": "object"},
+		}},
+		Routes: []RouteSpec{{Method: "GET", Path: "/issues/:key"}},
+	}
+}
+
+func TestManifestValidate(t *testing.T) {
+	if err := testManifest().Validate(); err != nil {
+		t.Fatalf("valid manifest rejected: %v", err)
+	}
+	cases := map[string]func(*Manifest){
+		"bad name":        func(m *Manifest) { m.Name = "Jira Sync" },
+		"no version":      func(m *Manifest) { m.Version = "" },
+		"no api version":  func(m *Manifest) { m.APIVersion = 0 },
+		"absolute path":   func(m *Manifest) { m.Command = "/usr/bin/jira" },
+		"outside the dir": func(m *Manifest) { m.Command = "../jira" },
+		"unknown scope":   func(m *Manifest) { m.Scopes = []string{"board:delete"} },
+	}
+	for name, mutate := range cases {
+		m := testManifest()
+		mutate(m)
+		if err := m.Validate(); !errors.Is(err, ErrInvalidManifest) {
+			t.Errorf("%s: got %v, want ErrInvalidManifest", name, err)
+		}
+	}
+
+	if got := testManifest().EnvPrefix(); got != "PLUGIN_JIRA_SYNC_" {
+		t.Errorf("EnvPrefix = %s", got)
+	}
+}
+
+func TestLoadManifest(t *testing.T) {
+	dir := t.TempDir()
+	data := "name: jira-sync\nversion: 1.0.0\napi_version: 1\ncommand: jira\nscopes: [tools]\n"
+	if err := os.WriteFile(filepath.Join(dir, ManifestFile), []byte(data), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	m, err := LoadManifest(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if m.Name != "jira-sync" || !m.HasScope(ScopeTools) || m.HasScope(ScopeRoutes) {
+		t.Errorf("manifest = %+v", m)
+	}
+}
+
+func TestDescriptionValidate(t *testing.T) {
+	d := testDescription()
+	if err := d.Validate(testManifest()); err != nil {
+		t.Fatalf("valid description rejected: %v", err)
+	}
+	cases := map[string]func(*Description, *Manifest){
+		"other version":     func(d *Description, m *Manifest) { d.Version = "2.0.0" },
+		"other api version": func(d *Description, m *Manifest) { d.APIVersion = APIVersion + 1 },
+		"tools unscoped":    func(d *Description, m *Manifest) { m.Scopes = []string{ScopeRoutes} },
+		"routes unscoped":   func(d *Description, m *Manifest) { m.Scopes = []string{ScopeTools} },
+		"bad tool name":     func(d *Description, m *Manifest) { d.Tools[0].Name = "create-ticket" },
+		"duplicate tool":    func(d *Description, m *Manifest) { d.Tools = append(d.Tools, d.Tools[0]) },
+		"not an object":     func(d *Description, m *Manifest) { d.Tools[0].Parameters = map[string]interface{}{"type": "string"} },
+		"bad method":        func(d *Description, m *Manifest) { d.Routes[0].Method = "OPTIONS" },
+		"bad path":          func(d *Description, m *Manifest) { d.Routes[0].Path = "issues" },
+	}
+	for name, mutate := range cases {
+		d, m := testDescription(), testManifest()
+		mutate(&d, m)
+		if err := d.Validate(m); !errors.Is(err, ErrInvalidManifest) {
+			t.Errorf("%s: got %v, want ErrInvalidManifest", name, err)
+		}
+	}
+}
+
+type echoPlugin struct{}
+
+func (echoPlugin) Describe() Description { return testDescription() }
+
+func (echoPlugin) CallTool(ctx context.Context, call ToolCall) (interface{}, error) {
+	if call.Tool != "createJiraTicket" {
+		return nil, errors.New("unknown tool")
+	}
+	return map[string]interface{}{"key": "MEL-1", "summary": call.Input["summary"], "board": call.BoardID}, nil
+}
+
+func (echoPlugin) HandleRoute(ctx context.Context, req RouteRequest) (*RouteResponse, error) {
+	return JSONResponse(200, map[string]string{"key": req.Params["key"], "body": string(req.Body)})
+}
+
+func TestClientServerRoundTrip(t *testing.T) {
+	addr := filepath.Join(t.TempDir(), "p.sock")
+	lis, err := net.Listen("unix", addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := newServer(echoPlugin{})
+	go srv.Serve(lis)
+	defer srv.Stop()
+
+	client, err := Dial(addr)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer client.Close()
+	ctx := context.Background()
+
+	description, err := client.Describe(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if description.APIVersion != APIVersion || len(description.Tools) != 1 || description.Routes[0].Path != "/issues/:key" {
+		t.Errorf("description = %+v", description)
+	}
+
+	result, err := client.CallTool(ctx, ToolCall{Tool: "createJiraTicket", Input: map[string]interface{}{"summary": "Fix login"}, BoardID: "b1"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	got := result.(map[string]interface{})
+	if got["key"] != "MEL-1" || got["summary"] != "Fix login" || got["board"] != "b1" {
+		t.Errorf("result = %v", got)
+	}
+	if _, err := client.CallTool(ctx, ToolCall{Tool: "other"}); err == nil {
+		t.Error("a plugin error should fail the call")
+	}
+
+	resp, err := client.HandleRoute(ctx, RouteRequest{Method: "GET", Route: "/issues/:key", Params: map[string]string{"key": "MEL-1"}, Body: []byte("hi")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if resp.Status != 200 || resp.ContentType != "application/json" || string(resp.Body) != `{"body":"hi","key":"MEL-1"}` {
+		t.Errorf("response = %d %s %s", resp.Status, resp.ContentType, resp.Body)
+	}
+}
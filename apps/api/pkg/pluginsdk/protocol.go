@@ -0,0 +1,188 @@
+package pluginsdk
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/protobuf/types/known/structpb"
+)
+
+// The protocol is a gRPC service whose messages are google.protobuf.Struct values carrying the JSON of the
+// types above, so plugins in other languages only need the well-known types, not generated code:
+//
+//	service melina.plugin.v1.Plugin {
+//	  rpc Describe(google.protobuf.Struct) returns (google.protobuf.Struct);    // {} -> Description
+//	  rpc CallTool(google.protobuf.Struct) returns (google.protobuf.Struct);    // ToolCall -> {"result": ...}
+//	  rpc HandleRoute(google.protobuf.Struct) returns (google.protobuf.Struct); // RouteRequest -> RouteResponse
+//	}
+const serviceName = "melina.plugin.v1.Plugin"
+
+// pluginServer adapts a Plugin to the gRPC service
+type pluginServer struct {
+	plugin Plugin
+}
+
+// rpcService is the handler type of serviceDesc
+type rpcService interface {
+	describe(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error)
+	callTool(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error)
+	handleRoute(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error)
+}
+
+func (s *pluginServer) describe(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error) {
+	description := s.plugin.Describe()
+	description.APIVersion = APIVersion
+	return toStruct(description)
+}
+
+func (s *pluginServer) callTool(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error) {
+	var call ToolCall
+	if err := fromStruct(in, &call); err != nil {
+		return nil, err
+	}
+	result, err := s.plugin.CallTool(ctx, call)
+	if err != nil {
+		return nil, err
+	}
+	return toStruct(map[string]interface{}{"result": result})
+}
+
+func (s *pluginServer) handleRoute(ctx context.Context, in *structpb.Struct) (*structpb.Struct, error) {
+	var req RouteRequest
+	if err := fromStruct(in, &req); err != nil {
+		return nil, err
+	}
+	resp, err := s.plugin.HandleRoute(ctx, req)
+	if err != nil {
+		return nil, err
+	}
+	if resp == nil {
+		resp = &RouteResponse{Status: 204}
+	}
+	return toStruct(resp)
+}
+
+// unaryHandler routes a method of the service to its rpcService function
+func unaryHandler(method string, call func(rpcService, context.Context, *structpb.Struct) (*structpb.Struct, error)) grpc.MethodDesc {
+	return grpc.MethodDesc{
+		MethodName: method,
+		Handler: func(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+			in := new(structpb.Struct)
+			if err := dec(in); err != nil {
+				return nil, err
+			}
+			if interceptor == nil {
+				return call(srv.(rpcService), ctx, in)
+			}
+			info := &grpc.UnaryServerInfo{Server: srv, FullMethod: "/" + serviceName + "/" + method}
+			return interceptor(ctx, in, info, func(ctx context.Context, req interface{}) (interface{}, error) {
+				return call(srv.(rpcService), ctx, req.(*structpb.Struct))
+			})
+		},
+	}
+}
+
+var serviceDesc = grpc.ServiceDesc{
+	ServiceName: serviceName,
+	HandlerType: (*rpcService)(nil),
+	Methods: []grpc.MethodDesc{
+		unaryHandler("Describe", rpcService.describe),
+		unaryHandler("CallTool", rpcService.callTool),
+		unaryHandler("HandleRoute", rpcService.handleRoute),
+	},
+	Metadata: "melina/plugin/v1",
+}
+
+// Client calls a running plugin; the API uses it, and plugin authors can use it to test their plugin
+type Client struct {
+	conn *grpc.ClientConn
+}
+
+// Dial connects to the plugin listening on the unix socket. The connection is made on the first call
+func Dial(addr string) (*Client, error) {
+	conn, err := grpc.NewClient("unix://"+addr,
+		grpc.WithTransportCredentials(insecure.NewCredentials()),
+		grpc.WithDefaultCallOptions(grpc.MaxCallRecvMsgSize(MaxMessageBytes), grpc.MaxCallSendMsgSize(MaxMessageBytes)),
+	)
+	if err != nil {
+		return nil, err
+	}
+	return &Client{conn: conn}, nil
+}
+
+// Close closes the connection
+func (c *Client) Close() error {
+	return c.conn.Close()
+}
+
+func (c *Client) invoke(ctx context.Context, method string, in interface{}) (*structpb.Struct, error) {
+	req, err := toStruct(in)
+	if err != nil {
+		return nil, err
+	}
+	out := new(structpb.Struct)
+	if err := c.conn.Invoke(ctx, "/"+serviceName+"/"+method, req, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// Describe asks the plugin what it serves
+func (c *Client) Describe(ctx context.Context) (*Description, error) {
+	out, err := c.invoke(ctx, "Describe", map[string]interface{}{})
+	if err != nil {
+		return nil, err
+	}
+	description := &Description{}
+	if err := fromStruct(out, description); err != nil {
+		return nil, err
+	}
+	return description, nil
+}
+
+// CallTool runs one of the plugin's tools and returns its result
+func (c *Client) CallTool(ctx context.Context, call ToolCall) (interface{}, error) {
+	out, err := c.invoke(ctx, "CallTool", call)
+	if err != nil {
+		return nil, err
+	}
+	return out.AsMap()["result"], nil
+}
+
+// HandleRoute passes a request to one of the plugin's routes
+func (c *Client) HandleRoute(ctx context.Context, req RouteRequest) (*RouteResponse, error) {
+	out, err := c.invoke(ctx, "HandleRoute", req)
+	if err != nil {
+		return nil, err
+	}
+	resp := &RouteResponse{}
+	if err := fromStruct(out, resp); err != nil {
+		return nil, err
+	}
+	return resp, nil
+}
+
+// toStruct encodes a value as a Struct through its JSON
+func toStruct(value interface{}) (*structpb.Struct, error) {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return nil, err
+	}
+	var fields map[string]interface{}
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, fmt.Errorf("pluginsdk: %T does not encode as a JSON object", value)
+	}
+	return structpb.NewStruct(fields)
+}
+
+// fromStruct decodes a Struct into a value through its JSON
+func fromStruct(s *structpb.Struct, value interface{}) error {
+	data, err := json.Marshal(s.AsMap())
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, value)
+}
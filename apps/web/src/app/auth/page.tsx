@@ -14,6 +14,8 @@ import Logo from "@/components/custom/General/Logo";
 const errorMessages: Record<string, string> = {
   missing_code: "OAuth authorization code is missing",
   oauth_exchange_failed: "Failed to authenticate with provider",
+  oauth_failed: "Failed to start signing in with provider",
+  invalid_oauth_state: "Your sign-in session expired or was not started here, please try again",
   failed_to_get_user_info: "Failed to retrieve user information",
   failed_to_decode_user_info: "Failed to process user information",
   failed_to_check_user: "Failed to verify user account",
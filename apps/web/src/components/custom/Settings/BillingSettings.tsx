@@ -4,9 +4,10 @@ import { SettingsSection, SettingsRow } from "./SettingsSection";
 import { Button } from "@/components/ui/button";
 import { Check } from "lucide-react";
 import { useAuth, Subscription } from "@/providers/AuthProvider";
-import { useState } from "react";
+import { useEffect, useState } from "react";
 import { toast } from "sonner";
 import { createOrder, verifyPayment } from "@/service/orders";
+import { getStorageUsage, StorageUsage } from "@/service/auth";
 
 type Plan = {
   id: Subscription;
@@ -85,6 +86,19 @@ function formatTokens(tokens: number): string {
   return tokens.toLocaleString();
 }
 
+function formatBytes(bytes: number): string {
+  if (bytes >= 1024 * 1024 * 1024) {
+    return `${(bytes / (1024 * 1024 * 1024)).toFixed(1)} GB`;
+  }
+  if (bytes >= 1024 * 1024) {
+    return `${(bytes / (1024 * 1024)).toFixed(1)} MB`;
+  }
+  if (bytes >= 1024) {
+    return `${(bytes / 1024).toFixed(0)} KB`;
+  }
+  return `${bytes} B`;
+}
+
 // Declare Razorpay types
 declare global {
   interface Window {
@@ -96,6 +110,16 @@ export function BillingSettings() {
   const { user, refreshUser } = useAuth();
   const [isProcessing, setIsProcessing] = useState(false);
   const [loadingPlan, setLoadingPlan] = useState<string | null>(null);
+  const [storage, setStorage] = useState<StorageUsage | null>(null);
+
+  useEffect(() => {
+    getStorageUsage()
+      .then(setStorage)
+      .catch(() => setStorage(null));
+  }, [user?.subscription]);
+
+  const storagePercentage =
+    storage && storage.limit_bytes > 0 ? (storage.used_bytes / storage.limit_bytes) * 100 : 0;
 
   const currentPlanId = user?.subscription || "free";
   const tokensConsumed = user?.tokens_consumed || 0;
@@ -227,6 +251,32 @@ export function BillingSettings() {
         </div>
       </SettingsRow>
 
+      {storage && (
+        <SettingsRow label="Storage" description="Uploaded images, board previews and your avatar.">
+          <div className="w-full max-w-md">
+            <div className="flex justify-between text-sm mb-2">
+              <span className="text-muted-foreground">{formatBytes(storage.used_bytes)} used</span>
+              <span className="text-muted-foreground">
+                {storage.limit_bytes > 0 ? `${formatBytes(storage.limit_bytes)} total` : "Unlimited"}
+              </span>
+            </div>
+            {storage.limit_bytes > 0 && (
+              <div className="h-2 bg-muted rounded-full overflow-hidden">
+                <div
+                  className={`h-full rounded-full transition-all ${storagePercentage >= 100
+                    ? "bg-red-500"
+                    : storagePercentage >= 80
+                      ? "bg-yellow-500"
+                      : "bg-green-500"
+                    }`}
+                  style={{ width: `${Math.min(100, storagePercentage)}%` }}
+                />
+              </div>
+            )}
+          </div>
+        </SettingsRow>
+      )}
+
       <SettingsRow label="Available Plans" description="Choose a plan that works for you.">
         <div className="grid gap-4 w-full max-w-2xl">
           {PLANS.map((plan) => {
@@ -25,6 +25,7 @@ import {
   Camera,
   Check,
   X,
+  Globe,
 } from "lucide-react";
 import { useAuth } from "@/providers/AuthProvider";
 
@@ -58,12 +59,16 @@ export function GeneralSettings() {
     first_name?: string;
     last_name?: string;
     avatar?: File;
+    timezone?: string;
+    locale?: string;
   }) => {
     const formData = new FormData();
     // Use !== undefined to allow empty strings if needed
     if (data.first_name !== undefined) formData.append("first_name", data.first_name);
     if (data.last_name !== undefined) formData.append("last_name", data.last_name);
     if (data.avatar) formData.append("avatar", data.avatar);
+    if (data.timezone) formData.append("timezone", data.timezone);
+    if (data.locale) formData.append("locale", data.locale);
 
     console.log("Updating profile with:", {
       first_name: data.first_name,
@@ -93,6 +98,21 @@ export function GeneralSettings() {
     }
   };
 
+  // Timezone and locale are taken from the browser rather than picked from a long list
+  const deviceTimezone = mounted ? Intl.DateTimeFormat().resolvedOptions().timeZone : "";
+  const deviceLocale = mounted ? navigator.language : "";
+
+  const handleUseDeviceRegion = async () => {
+    setIsSaving(true);
+    try {
+      await updateUserProfile({ timezone: deviceTimezone, locale: deviceLocale });
+    } catch (error) {
+      console.error("Failed to save timezone and locale:", error);
+    } finally {
+      setIsSaving(false);
+    }
+  };
+
   const handleCancelEdit = () => {
     setEditedFirstName(user?.first_name || "");
     setEditedLastName(user?.last_name || "");
@@ -289,6 +309,29 @@ export function GeneralSettings() {
         </div>
       </SettingsRow>
 
+      {/* Region Section */}
+      <SettingsRow label="Region" description="Timezone and language used for dates and times.">
+        <div className="flex flex-col gap-3 text-sm">
+          <div className="flex items-center gap-2 text-muted-foreground">
+            <Globe className="h-4 w-4" />
+            <span>
+              {user?.timezone || "No timezone set"} · {user?.locale || "No language set"}
+            </span>
+          </div>
+          {(user?.timezone !== deviceTimezone || user?.locale !== deviceLocale) && (
+            <Button
+              variant="outline"
+              size="sm"
+              className="w-fit cursor-pointer"
+              onClick={handleUseDeviceRegion}
+              disabled={isSaving || !deviceTimezone}
+            >
+              Use this device&apos;s settings ({deviceTimezone}, {deviceLocale})
+            </Button>
+          )}
+        </div>
+      </SettingsRow>
+
       {/* Theme Section */}
       <SettingsRow label="Theme" description="Select your preferred color theme.">
         <Select value={theme} onValueChange={handleThemeChange}>
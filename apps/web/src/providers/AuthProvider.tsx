@@ -21,6 +21,8 @@ export interface User {
   first_name: string;
   last_name: string;
   avatar?: string;
+  timezone?: string; // IANA name, e.g. Europe/Berlin
+  locale?: string; // BCP 47 tag, e.g. en-US
   subscription: Subscription;
   tokens_consumed: number;
   token_limit: number;
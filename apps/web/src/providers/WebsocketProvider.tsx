@@ -1,16 +1,22 @@
 "use client";
 
 import React, { createContext, useEffect, useRef, useState, useCallback } from "react";
-import { getAccessToken } from "@/service/auth";
+import { getAccessToken, onAccessTokenRefresh, refreshToken } from "@/service/auth";
 import api from "@/lib/axios";
+import { BaseURL } from "@/lib/constants";
 
 type Callback = (msg: any) => void;
 
+// "sse" is the Server-Sent Events fallback for networks that block WebSockets
+type Transport = "ws" | "sse";
+
 type WebSocketContextType = {
   socket: WebSocket | null;
   sendMessage: (data: unknown) => void;
+  sendMessageWithImages: (data: object, images: Blob[]) => Promise<void>;
   isConnected: boolean;
   isReconnecting: boolean;
+  transport: Transport;
   subscribe: (type: string, cb: Callback) => () => void;
 };
 
@@ -18,6 +24,9 @@ type WebSocketContextType = {
 const INITIAL_RETRY_DELAY = 1000; // 1 second
 const MAX_RETRY_DELAY = 15000; // 30 seconds max
 const MAX_RETRY_ATTEMPTS = 10;
+// Fall back to SSE when this many WebSocket attempts fail without ever opening
+const SSE_FALLBACK_AFTER_ATTEMPTS = 2;
+const SSE_STREAM_URL = `${BaseURL}/api/v1/chat/stream`;
 
 export const WebSocketContext = createContext<WebSocketContextType | null>(null);
 
@@ -28,9 +37,65 @@ export function WebSocketProvider({ children }: { children: React.ReactNode }) {
   const retryCountRef = useRef(0);
   const retryTimeoutRef = useRef<NodeJS.Timeout | null>(null);
   const intentionalCloseRef = useRef(false);
+  const everOpenedRef = useRef(false);
+  const eventSourceRef = useRef<EventSource | null>(null);
+  const sseClientIdRef = useRef<string | null>(null);
 
   const [isConnected, setIsConnected] = useState(false);
   const [isReconnecting, setIsReconnecting] = useState(false);
+  const [transport, setTransport] = useState<Transport>("ws");
+
+  const dispatch = useCallback((raw: string) => {
+    try {
+      const data = JSON.parse(raw);
+      if (data.type === "pong") {
+        console.log("🟢 Pong received");
+      }
+      const listeners = listenersRef.current.get(data.type);
+      if (listeners) {
+        listeners.forEach((cb) => cb(data));
+      }
+      console.log("📩 Message:", raw);
+    } catch (error) {
+      console.error("Failed to parse message:", error);
+    }
+  }, []);
+
+  // Same events as the WebSocket over Server-Sent Events; messages are POSTed with the stream's client id
+  const connectSSE = useCallback(() => {
+    if (intentionalCloseRef.current || eventSourceRef.current) return;
+
+    console.log("🔁 WebSocket unavailable, falling back to Server-Sent Events");
+    setTransport("sse");
+    setIsReconnecting(true);
+
+    // EventSource reconnects by itself; every new stream announces a new client id
+    const es = new EventSource(SSE_STREAM_URL, { withCredentials: true });
+    eventSourceRef.current = es;
+
+    es.onmessage = (event) => {
+      try {
+        const data = JSON.parse(event.data);
+        if (data.type === "stream_connected") {
+          sseClientIdRef.current = data.data?.client_id ?? null;
+          console.log("✅ Connected to SSE stream");
+          setIsConnected(true);
+          setIsReconnecting(false);
+          return;
+        }
+      } catch {
+        // handled by dispatch
+      }
+      dispatch(event.data);
+    };
+
+    es.onerror = (err) => {
+      console.error("SSE error", err);
+      sseClientIdRef.current = null;
+      setIsConnected(false);
+      setIsReconnecting(es.readyState !== EventSource.CLOSED);
+    };
+  }, [dispatch]);
 
   const connect = useCallback(async () => {
     // Don't reconnect if intentionally closed
@@ -55,24 +120,23 @@ export function WebSocketProvider({ children }: { children: React.ReactNode }) {
 
     ws.onmessage = (event) => {
       try {
-        const data = JSON.parse(event.data);
-        if (data.type === "pong") {
-          console.log("🟢 Pong received");
-        }
-        const listeners = listenersRef.current.get(data.type);
-        if (listeners) {
-          listeners.forEach((cb) => cb(data));
+        // The server closes the socket once the token expires, refresh it in time
+        if (JSON.parse(event.data).type === "auth_expiring") {
+          refreshToken().catch((error) => {
+            console.error("Failed to refresh token for WebSocket:", error);
+          });
         }
-        console.log("📩 Message:", event.data);
-      } catch (error) {
-        console.error("Failed to parse WebSocket message:", error);
+      } catch {
+        // handled by dispatch
       }
+      dispatch(event.data);
     };
 
     ws.onopen = () => {
       console.log("✅ Connected to WebSocket");
       setIsConnected(true);
       setIsReconnecting(false);
+      everOpenedRef.current = true;
       retryCountRef.current = 0; // Reset retry count on successful connection
       // Send ping only after connection is established
       ws.send(JSON.stringify({ type: "ping" }));
@@ -83,6 +147,16 @@ export function WebSocketProvider({ children }: { children: React.ReactNode }) {
       setIsConnected(false);
       socketRef.current = null;
 
+      // A WebSocket that never opened is likely blocked by the network, switch to SSE
+      if (
+        !intentionalCloseRef.current &&
+        !everOpenedRef.current &&
+        retryCountRef.current + 1 >= SSE_FALLBACK_AFTER_ATTEMPTS
+      ) {
+        connectSSE();
+        return;
+      }
+
       // Attempt reconnection if not intentionally closed
       if (!intentionalCloseRef.current && retryCountRef.current < MAX_RETRY_ATTEMPTS) {
         const delay = Math.min(
@@ -109,7 +183,7 @@ export function WebSocketProvider({ children }: { children: React.ReactNode }) {
     ws.onerror = (err) => {
       console.error("WebSocket error", err);
     };
-  }, []);
+  }, [connectSSE, dispatch]);
 
   useEffect(() => {
     // Prevent Strict Mode double-mount cleanup issues
@@ -129,6 +203,7 @@ export function WebSocketProvider({ children }: { children: React.ReactNode }) {
         console.log("Closing WebSocket");
         socketRef.current.close();
       }
+      eventSourceRef.current?.close();
     };
 
     window.addEventListener("beforeunload", handleBeforeUnload);
@@ -141,10 +216,56 @@ export function WebSocketProvider({ children }: { children: React.ReactNode }) {
     };
   }, [connect]);
 
+  // Re-authenticate the open socket with every refreshed token instead of reconnecting
+  useEffect(() => {
+    return onAccessTokenRefresh((token) => {
+      if (socketRef.current?.readyState === WebSocket.OPEN) {
+        socketRef.current.send(JSON.stringify({ type: "auth_refresh", data: { token } }));
+      }
+    });
+  }, []);
+
   const sendMessage = (data: unknown) => {
     if (socketRef.current && socketRef.current.readyState === WebSocket.OPEN) {
       socketRef.current.send(JSON.stringify(data));
+      return;
+    }
+    if (eventSourceRef.current && sseClientIdRef.current) {
+      api
+        .post("/api/v1/chat/stream", { ...(data as object), client_id: sseClientIdRef.current })
+        .catch((error) => console.error("Failed to send message over SSE fallback:", error));
+    }
+  };
+
+  // Sends images inline in a binary frame instead of base64 JSON; the message refers to image N as "attachment:N".
+  // Frame: [4-byte big-endian header length][JSON header][image bytes...]
+  const sendMessageWithImages = async (data: object, images: Blob[]) => {
+    if (!socketRef.current || socketRef.current.readyState !== WebSocket.OPEN) {
+      console.error("Binary frames need an open WebSocket");
+      return;
+    }
+    const buffers = await Promise.all(images.map((image) => image.arrayBuffer()));
+    const header = new TextEncoder().encode(
+      JSON.stringify({
+        ...data,
+        attachments: images.map((image, i) => ({
+          media_type: image.type || "image/png",
+          size: buffers[i].byteLength,
+        })),
+      })
+    );
+
+    const frame = new Uint8Array(
+      4 + header.byteLength + buffers.reduce((total, buffer) => total + buffer.byteLength, 0)
+    );
+    new DataView(frame.buffer).setUint32(0, header.byteLength);
+    frame.set(header, 4);
+    let offset = 4 + header.byteLength;
+    for (const buffer of buffers) {
+      frame.set(new Uint8Array(buffer), offset);
+      offset += buffer.byteLength;
     }
+    socketRef.current.send(frame);
   };
 
   const subscribe = (type: string, cb: Callback) => {
@@ -165,8 +286,10 @@ export function WebSocketProvider({ children }: { children: React.ReactNode }) {
       value={{
         socket: socketRef.current,
         sendMessage,
+        sendMessageWithImages,
         isConnected,
         isReconnecting,
+        transport,
         subscribe,
       }}
     >
@@ -0,0 +1,35 @@
+import api from "@/lib/axios";
+
+export type Announcement = {
+  uuid: string;
+  title: string;
+  body: string; // markdown
+  kind: "feature" | "improvement" | "fix" | "notice";
+  link?: string;
+  published_at: string;
+  read: boolean;
+};
+
+// New announcements also arrive live over the WebSocket as "announcement" messages
+export const getAnnouncements = async (
+  limit?: number
+): Promise<{ announcements: Announcement[]; unread: number }> => {
+  try {
+    const response = await api.get("/api/v1/announcements", { params: { limit } });
+    return response.data;
+  } catch (error: any) {
+    console.log(error, "Error fetching announcements");
+    throw new Error(error?.response?.data?.error || "Error fetching announcements");
+  }
+};
+
+// Marks the given announcements read, or every announcement when ids is omitted
+export const markAnnouncementsRead = async (ids?: string[]) => {
+  try {
+    const response = await api.post("/api/v1/announcements/read", ids ? { ids } : {});
+    return response.data;
+  } catch (error: any) {
+    console.log(error, "Error marking announcements read");
+    throw new Error(error?.response?.data?.error || "Error marking announcements read");
+  }
+};
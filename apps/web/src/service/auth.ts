@@ -4,16 +4,28 @@ import { RegisterPayload } from "@/lib/types";
 
 // Token management for WebSocket auth - will be set by AuthProvider
 let accessTokenRef: { current: string | null } | null = null;
+const accessTokenListeners = new Set<(token: string) => void>();
+
+const updateAccessToken = (token: string) => {
+  if (accessTokenRef) {
+    accessTokenRef.current = token;
+  }
+  accessTokenListeners.forEach((listener) => listener(token));
+};
 
 export const setAccessTokenRef = (ref: { current: string | null }) => {
   accessTokenRef = ref;
 
   // Register callback to update token when axios refreshes it
-  setTokenUpdateCallback((token: string) => {
-    if (accessTokenRef) {
-      accessTokenRef.current = token;
-    }
-  });
+  setTokenUpdateCallback(updateAccessToken);
+};
+
+// Called with every refreshed access token, e.g. to re-authenticate an open WebSocket
+export const onAccessTokenRefresh = (listener: (token: string) => void) => {
+  accessTokenListeners.add(listener);
+  return () => {
+    accessTokenListeners.delete(listener);
+  };
 };
 
 export const getAccessToken = () => {
@@ -67,7 +79,7 @@ export const getMe = async () => {
 
 export const updateUser = async (formData: FormData) => {
   try {
-    const response = await api.patch("/api/v1/auth/me/update", formData);
+    const response = await api.patch("/api/v1/auth/me", formData);
     return response.data;
   } catch (error: any) {
     console.log(error, "Error updating user");
@@ -75,9 +87,29 @@ export const updateUser = async (formData: FormData) => {
   }
 };
 
+export type StorageUsage = {
+  plan: string;
+  used_bytes: number;
+  limit_bytes: number; // 0 when unlimited
+  by_kind: Record<string, number>;
+};
+
+export const getStorageUsage = async (): Promise<StorageUsage> => {
+  try {
+    const response = await api.get("/api/v1/auth/me/storage");
+    return response.data;
+  } catch (error: any) {
+    console.log(error, "Error fetching storage usage");
+    throw new Error(error?.response?.data?.error || "Error fetching storage usage");
+  }
+};
+
 export const refreshToken = async () => {
   try {
     const response = await api.post("/api/v1/auth/refresh");
+    if (response.data?.access_token) {
+      updateAccessToken(response.data.access_token);
+    }
     return response.data;
   } catch (error: any) {
     console.log(error, "Error refreshing token");
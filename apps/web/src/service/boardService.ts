@@ -15,9 +15,14 @@ export const createBoard = async (title: string = "Untitled") => {
   }
 };
 
+// The dashboard list only needs these fields, the shapes are fetched with the board itself
+const BOARD_LIST_FIELDS = "uuid,title,thumbnail,starred,created_at,updated_at";
+
 export const getBoards = async () => {
   try {
-    const response = await axios.get(`${BaseURL}/api/v1/boards`);
+    const response = await axios.get(`${BaseURL}/api/v1/boards`, {
+      params: { fields: BOARD_LIST_FIELDS },
+    });
     return response.data;
   } catch (error: any) {
     console.log(error, "Error getting boards");
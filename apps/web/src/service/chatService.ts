@@ -44,3 +44,20 @@ export const uploadChatImage = async (boardId: string, file: File): Promise<{ ur
     throw new Error(error?.error || "Error uploading chat image");
   }
 };
+
+// Rates an AI message; model_name and prompt_version come from the turn's chat_completed event
+export const submitMessageFeedback = async (feedback: {
+  message_id: string;
+  rating: "up" | "down";
+  model_name?: string;
+  prompt_version?: string;
+  comment?: string;
+}) => {
+  try {
+    const response = await axios.post(`${BaseURL}/api/v1/feedback`, feedback);
+    return response.data;
+  } catch (error: any) {
+    console.log(error, "Error sending feedback");
+    throw new Error(error?.response?.data?.error || "Error sending feedback");
+  }
+};
@@ -0,0 +1,47 @@
+import api from "@/lib/axios";
+
+export type OnboardingStep =
+  | "open_sample_board"
+  | "send_first_message"
+  | "draw_shape"
+  | "create_board";
+
+export type OnboardingProgress = {
+  sample_board_id?: string;
+  sample_board_status: "pending" | "ready" | "failed";
+  steps: { step: OnboardingStep; done: boolean; completed_at?: string }[];
+  completed: boolean;
+  dismissed: boolean;
+};
+
+// Returns null for users who signed up before onboarding existed
+export const getOnboarding = async (): Promise<OnboardingProgress | null> => {
+  try {
+    const response = await api.get("/api/v1/onboarding");
+    return response.data;
+  } catch (error: any) {
+    if (error?.response?.status === 404) return null;
+    console.log(error, "Error fetching onboarding");
+    throw new Error(error?.response?.data?.error || "Error fetching onboarding");
+  }
+};
+
+export const completeOnboardingStep = async (step: OnboardingStep): Promise<OnboardingProgress> => {
+  try {
+    const response = await api.post(`/api/v1/onboarding/steps/${step}`);
+    return response.data;
+  } catch (error: any) {
+    console.log(error, "Error completing onboarding step");
+    throw new Error(error?.response?.data?.error || "Error completing onboarding step");
+  }
+};
+
+export const dismissOnboarding = async (): Promise<OnboardingProgress> => {
+  try {
+    const response = await api.post("/api/v1/onboarding/dismiss");
+    return response.data;
+  } catch (error: any) {
+    console.log(error, "Error dismissing onboarding");
+    throw new Error(error?.response?.data?.error || "Error dismissing onboarding");
+  }
+};